@@ -31,6 +31,8 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/secrets"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -91,6 +93,7 @@ func run() error {
 
 	// Read the configuration
 	config.Reset()
+	secrets.InitConfig()
 	err := config.ReadConfig(cfgFile)
 
 	// Setup logging after reading config (even if failed), to output header correctly
@@ -102,10 +105,21 @@ func run() error {
 	log.L(ctx).Infof("© Copyright 2021 Kaleido, Inc.")
 
 	// Deferred error return from reading config
+	if err == nil {
+		err = config.SetupLang()
+	}
+	if err == nil {
+		err = secrets.ResolveConfig(ctx)
+	}
+	if err == nil {
+		err = tracing.Init(ctx)
+	}
 	if err != nil {
 		cancelCtx()
 		return i18n.WrapError(ctx, err, i18n.MsgConfigFailed)
 	}
+	// The root context is cancelled on shutdown, so flushing the final spans needs its own
+	defer tracing.Shutdown(context.Background())
 
 	// Setup signal handling to cancel the context, which shuts down the API Server
 	errChan := make(chan error)
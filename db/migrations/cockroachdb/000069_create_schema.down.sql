@@ -0,0 +1,36 @@
+DROP TABLE IF EXISTS pseudonym;
+DROP TABLE IF EXISTS tokennft;
+DROP TABLE IF EXISTS tokenapproval;
+DROP TABLE IF EXISTS batchquarantine;
+DROP TABLE IF EXISTS contractlisteners;
+DROP TABLE IF EXISTS ffi;
+DROP TABLE IF EXISTS signingactivity;
+DROP TABLE IF EXISTS receipts;
+DROP TABLE IF EXISTS snapshots;
+DROP TABLE IF EXISTS tokenbridges;
+DROP TABLE IF EXISTS messageholds;
+DROP TABLE IF EXISTS policyapprovals;
+DROP TABLE IF EXISTS blockchainevents;
+DROP TABLE IF EXISTS tokenbalance;
+DROP TABLE IF EXISTS tokentransfer;
+DROP TABLE IF EXISTS tokenpool;
+DROP TABLE IF EXISTS blobs;
+DROP TABLE IF EXISTS nextpins;
+DROP TABLE IF EXISTS nonces;
+DROP TABLE IF EXISTS members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS config;
+DROP TABLE IF EXISTS nodes;
+DROP TABLE IF EXISTS orgs;
+DROP TABLE IF EXISTS pins;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS namespaces;
+DROP TABLE IF EXISTS operations;
+DROP TABLE IF EXISTS offsets;
+DROP TABLE IF EXISTS datatypes;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS batches;
+DROP TABLE IF EXISTS messages_data;
+DROP TABLE IF EXISTS data;
+DROP TABLE IF EXISTS messages;
@@ -0,0 +1,595 @@
+-- CockroachDB support was added after the earlier schema migrations, so this single migration
+-- creates the complete schema at version 69. Later migrations are added alongside postgres.
+
+CREATE TABLE messages (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  cid             CHAR(36),
+  mtype           VARCHAR(64)     NOT NULL,
+  author          VARCHAR(1024)   NOT NULL,
+  created         BIGINT          NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  topics          VARCHAR(1024)   NOT NULL,
+  tag             VARCHAR(64)     NOT NULL,
+  group_hash      CHAR(64),
+  datahash        CHAR(64)        NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  pins            VARCHAR(1024)   NOT NULL,
+  confirmed       BIGINT,
+  tx_type         VARCHAR(64)     NOT NULL,
+  batch_id        UUID,
+  "key"           VARCHAR(1024)   NOT NULL,
+  state           VARCHAR(64)     NOT NULL,
+  immediate       BOOLEAN,
+  custom_headers  VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX messages_id ON messages(id);
+CREATE INDEX messages_sortorder ON messages(confirmed,created);
+CREATE INDEX messages_topics_tag ON messages(namespace,topics,tag);
+
+CREATE TABLE data (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  validator       VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  datatype_name   VARCHAR(64)     NOT NULL,
+  datatype_version VARCHAR(64)     NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  created         BIGINT          NOT NULL,
+  value           BYTEA           NOT NULL,
+  blob_hash       CHAR(64),
+  blob_public     VARCHAR(1024),
+  blob_size       BIGINT          DEFAULT 0
+);
+
+CREATE UNIQUE INDEX data_id ON data(id);
+CREATE INDEX data_blobs ON data(blob_hash);
+CREATE INDEX data_created ON data(namespace,created);
+CREATE INDEX data_hash ON data(namespace,hash);
+
+CREATE TABLE messages_data (
+  seq             SERIAL          PRIMARY KEY,
+  message_id      UUID            NOT NULL,
+  data_id         UUID            NOT NULL,
+  data_hash       CHAR(64)        NOT NULL,
+  data_idx        INT             NOT NULL
+);
+
+CREATE UNIQUE INDEX messages_data_idx ON messages_data(message_id,data_id);
+
+CREATE TABLE batches (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  btype           VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  author          VARCHAR(1024)   NOT NULL,
+  group_hash      CHAR(64),
+  hash            CHAR(64),
+  created         BIGINT          NOT NULL,
+  payload         BYTEA           NOT NULL,
+  payload_ref     VARCHAR(256),
+  confirmed       BIGINT,
+  tx_type         VARCHAR(64)     NOT NULL,
+  tx_id           UUID,
+  "key"           VARCHAR(1024)   NOT NULL,
+  node_id         UUID,
+  manifest        BYTEA
+);
+
+CREATE UNIQUE INDEX batches_id ON batches(id);
+CREATE INDEX batches_created ON batches(namespace,created);
+CREATE INDEX batches_fortx ON batches(namespace,tx_id);
+
+CREATE TABLE transactions (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  ttype           VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  ref             UUID,
+  signer          VARCHAR(1024)   NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  created         BIGINT          NOT NULL,
+  protocol_id     VARCHAR(256),
+  status          VARCHAR(64)     NOT NULL,
+  info            BYTEA,
+  fee_gas_used    VARCHAR(65),
+  fee_amount      VARCHAR(65)
+);
+
+CREATE UNIQUE INDEX transactions_id ON transactions(id);
+CREATE INDEX transactions_created ON transactions(created);
+CREATE INDEX transactions_protocol_id ON transactions(protocol_id);
+CREATE INDEX transactions_ref ON transactions(ref);
+
+CREATE TABLE datatypes (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID            NOT NULL,
+  validator       VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  version         VARCHAR(64)     NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  created         BIGINT          NOT NULL,
+  value           BYTEA
+);
+
+CREATE UNIQUE INDEX datatypes_id ON datatypes(id);
+CREATE UNIQUE INDEX datatypes_unique ON datatypes(namespace,name,version);
+CREATE INDEX datatypes_created ON datatypes(created);
+
+CREATE TABLE offsets (
+  seq             SERIAL          PRIMARY KEY,
+  otype           VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  current         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX offsets_unique ON offsets(otype,name);
+
+CREATE TABLE operations (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  tx_id           UUID            NOT NULL,
+  optype          VARCHAR(64)     NOT NULL,
+  opstatus        VARCHAR(64)     NOT NULL,
+  plugin          VARCHAR(64)     NOT NULL,
+  backend_id      VARCHAR(256)    NOT NULL,
+  created         BIGINT          NOT NULL,
+  updated         BIGINT,
+  error           VARCHAR         NOT NULL,
+  output          BYTEA,
+  input           BYTEA,
+  op_schema       VARCHAR(64)
+);
+
+CREATE UNIQUE INDEX operations_id ON operations(id);
+CREATE INDEX operations_backend ON operations(backend_id);
+CREATE INDEX operations_created ON operations(created);
+CREATE INDEX operations_tx ON operations(tx_id);
+
+CREATE TABLE namespaces (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID,
+  name            VARCHAR(64)     NOT NULL,
+  ntype           VARCHAR(64)     NOT NULL,
+  description     VARCHAR(4096),
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX namespaces_id ON namespaces(id);
+CREATE UNIQUE INDEX namespaces_name ON namespaces(name);
+
+CREATE TABLE subscriptions (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  transport       VARCHAR(64)     NOT NULL,
+  filter_events   VARCHAR(256)    NOT NULL,
+  filter_topics   VARCHAR(256)    NOT NULL,
+  filter_tag      VARCHAR(256)    NOT NULL,
+  filter_group    VARCHAR(256)    NOT NULL,
+  options         BYTEA           NOT NULL,
+  created         BIGINT          NOT NULL,
+  updated         BIGINT,
+  owner           VARCHAR(1024),
+  filter_custom   VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX subscriptions_id ON subscriptions(id);
+CREATE UNIQUE INDEX subscriptions_name ON subscriptions(namespace,name);
+
+CREATE TABLE events (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  etype           VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  ref             UUID,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX events_created ON events(created);
+CREATE UNIQUE INDEX events_id ON events(id);
+
+CREATE TABLE pins (
+  seq             SERIAL          PRIMARY KEY,
+  masked          BOOLEAN         NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  batch_id        UUID            NOT NULL,
+  idx             BIGINT          NOT NULL,
+  dispatched      BOOLEAN         NOT NULL,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX pins_pin ON pins(hash,batch_id,idx);
+CREATE INDEX pins_dispatched ON pins(dispatched);
+
+CREATE TABLE orgs (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID            NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  parent          VARCHAR(1024),
+  identity        VARCHAR(1024)   NOT NULL,
+  description     VARCHAR(4096)   NOT NULL,
+  profile         BYTEA,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX orgs_id ON orgs(id);
+CREATE UNIQUE INDEX orgs_identity ON orgs(identity);
+CREATE UNIQUE INDEX orgs_name ON orgs(name);
+
+CREATE TABLE nodes (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID            NOT NULL,
+  owner           VARCHAR(1024)   NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  description     VARCHAR(4096)   NOT NULL,
+  dx_peer         VARCHAR(256),
+  dx_endpoint     BYTEA,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX nodes_id ON nodes(id);
+CREATE UNIQUE INDEX nodes_owner ON nodes(owner,name);
+CREATE UNIQUE INDEX nodes_peer ON nodes(dx_peer);
+
+CREATE TABLE config (
+  seq             SERIAL          PRIMARY KEY,
+  config_key      VARCHAR(512)    NOT NULL,
+  config_value    BYTEA           NOT NULL
+);
+
+CREATE UNIQUE INDEX config_config_key ON config(config_key);
+CREATE UNIQUE INDEX config_sequence ON config(seq);
+
+CREATE TABLE groups (
+  seq             SERIAL          PRIMARY KEY,
+  message_id      UUID,
+  name            VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  ledger          UUID,
+  hash            CHAR(64)        NOT NULL,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX groups_hash ON groups(hash);
+
+CREATE TABLE members (
+  seq             SERIAL          PRIMARY KEY,
+  group_hash      CHAR(64)        NOT NULL,
+  idx             INT             NOT NULL,
+  identity        VARCHAR(1024)   NOT NULL,
+  node_id         UUID            NOT NULL
+);
+
+CREATE INDEX members_group ON members(group_hash);
+
+CREATE TABLE nonces (
+  seq             SERIAL          PRIMARY KEY,
+  context         CHAR(64)        NOT NULL,
+  nonce           BIGINT          NOT NULL,
+  group_hash      CHAR(64)        NOT NULL,
+  topic           VARCHAR(64)     NOT NULL
+);
+
+CREATE INDEX nonces_context ON nonces(context);
+CREATE INDEX nonces_group ON nonces(group_hash);
+
+CREATE TABLE nextpins (
+  seq             SERIAL          PRIMARY KEY,
+  context         CHAR(64)        NOT NULL,
+  identity        VARCHAR(1024)   NOT NULL,
+  hash            CHAR(64)        NOT NULL,
+  nonce           BIGINT          NOT NULL
+);
+
+CREATE INDEX nextpins_hash ON nextpins(hash);
+
+CREATE TABLE blobs (
+  seq             SERIAL          PRIMARY KEY,
+  hash            CHAR(64)        NOT NULL,
+  payload_ref     VARCHAR(1024)   NOT NULL,
+  created         BIGINT          NOT NULL,
+  peer            VARCHAR(256)    NOT NULL,
+  refs            BIGINT
+);
+
+CREATE INDEX blobs_hash ON blobs(hash);
+
+CREATE TABLE tokenpool (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  protocol_id     VARCHAR(1024)   NOT NULL,
+  type            VARCHAR(64)     NOT NULL,
+  tx_type         VARCHAR(64)     NOT NULL,
+  tx_id           UUID,
+  connector       VARCHAR(64)     NOT NULL,
+  symbol          VARCHAR(64),
+  message_id      UUID,
+  created         BIGINT          NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  standard        VARCHAR(64),
+  state           VARCHAR(64)     NOT NULL
+);
+
+CREATE UNIQUE INDEX tokenpool_id ON tokenpool(id);
+CREATE UNIQUE INDEX tokenpool_name ON tokenpool(namespace,name);
+CREATE UNIQUE INDEX tokenpool_protocolid ON tokenpool(connector,protocol_id);
+CREATE INDEX tokenpool_fortx ON tokenpool(namespace,tx_id);
+
+CREATE TABLE tokentransfer (
+  seq             SERIAL          PRIMARY KEY,
+  local_id        UUID            NOT NULL,
+  type            VARCHAR(64)     NOT NULL,
+  token_index     VARCHAR(1024),
+  "key"           VARCHAR(1024)   NOT NULL,
+  from_key        VARCHAR(1024),
+  to_key          VARCHAR(1024),
+  amount          VARCHAR(65),
+  protocol_id     VARCHAR(1024)   NOT NULL,
+  message_hash    CHAR(64),
+  tx_type         VARCHAR(64),
+  tx_id           UUID,
+  created         BIGINT          NOT NULL,
+  connector       VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64),
+  pool_id         UUID            NOT NULL,
+  message_id      UUID,
+  uri             VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX tokentransfer_id ON tokentransfer(local_id);
+CREATE UNIQUE INDEX tokentransfer_protocolid ON tokentransfer(connector,protocol_id);
+CREATE INDEX tokentransfer_pool ON tokentransfer(pool_id,token_index);
+
+CREATE TABLE tokenbalance (
+  seq             SERIAL          PRIMARY KEY,
+  token_index     VARCHAR(1024),
+  "key"           VARCHAR(1024)   NOT NULL,
+  balance         VARCHAR(65),
+  connector       VARCHAR(64)     NOT NULL,
+  updated         BIGINT          NOT NULL,
+  namespace       VARCHAR(64),
+  pool_id         UUID            NOT NULL,
+  uri             VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX tokenbalance_pool ON tokenbalance(namespace,"key",pool_id,token_index);
+
+CREATE TABLE blockchainevents (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  source          VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(1024),
+  protocol_id     VARCHAR(1024)   NOT NULL,
+  address         VARCHAR(1024),
+  block_number    BIGINT,
+  protocol_tx_id  VARCHAR(1024),
+  listener        VARCHAR(1024),
+  output          BYTEA,
+  info            BYTEA,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX blockchainevents_id ON blockchainevents(id);
+CREATE UNIQUE INDEX blockchainevents_protocolid ON blockchainevents(source,protocol_id);
+CREATE INDEX blockchainevents_name ON blockchainevents(namespace,name);
+CREATE INDEX blockchainevents_protocoltxid ON blockchainevents(protocol_tx_id);
+
+CREATE TABLE policyapprovals (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  type            VARCHAR(64)     NOT NULL,
+  signing_key     VARCHAR(1024),
+  reference       UUID,
+  input           BYTEA,
+  hash            CHAR(64)        NOT NULL,
+  reason          VARCHAR(1024),
+  status          VARCHAR(64)     NOT NULL,
+  created         BIGINT          NOT NULL,
+  decided         BIGINT,
+  decided_by      VARCHAR(1024),
+  comment         VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX policyapprovals_id ON policyapprovals(id);
+CREATE INDEX policyapprovals_hash ON policyapprovals(namespace,hash);
+CREATE INDEX policyapprovals_status ON policyapprovals(status);
+
+CREATE TABLE messageholds (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  group_hash      CHAR(64),
+  tag             VARCHAR(64),
+  message         BYTEA,
+  reason          VARCHAR(1024),
+  status          VARCHAR(64)     NOT NULL,
+  created         BIGINT          NOT NULL,
+  decided         BIGINT,
+  decided_by      VARCHAR(1024),
+  comment         VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX messageholds_id ON messageholds(id);
+CREATE INDEX messageholds_status ON messageholds(namespace,status);
+
+CREATE TABLE tokenbridges (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  from_key        VARCHAR(1024),
+  to_key          VARCHAR(1024),
+  escrow          VARCHAR(1024)   NOT NULL,
+  amount          VARCHAR(65),
+  source_connector VARCHAR(64)     NOT NULL,
+  source_pool     UUID            NOT NULL,
+  source_index    VARCHAR(1024),
+  target_connector VARCHAR(64)     NOT NULL,
+  target_pool     UUID            NOT NULL,
+  target_index    VARCHAR(1024),
+  status          VARCHAR(64)     NOT NULL,
+  error           TEXT,
+  tx_type         VARCHAR(64),
+  tx_id           UUID,
+  created         BIGINT          NOT NULL,
+  updated         BIGINT
+);
+
+CREATE UNIQUE INDEX tokenbridges_id ON tokenbridges(id);
+CREATE INDEX tokenbridges_status ON tokenbridges(namespace,status);
+
+CREATE TABLE snapshots (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  stype           VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  current         BIGINT          NOT NULL,
+  state           TEXT,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX snapshots_name ON snapshots(stype,name);
+
+CREATE TABLE receipts (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  message_id      UUID            NOT NULL,
+  author          VARCHAR(1024)   NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX receipts_id ON receipts(id);
+CREATE UNIQUE INDEX receipts_message_author ON receipts(message_id,author);
+
+CREATE TABLE signingactivity (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  optype          VARCHAR(64)     NOT NULL,
+  plugin          VARCHAR(64)     NOT NULL,
+  op_id           UUID            NOT NULL,
+  tx_id           UUID,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX signingactivity_id ON signingactivity(id);
+CREATE INDEX signingactivity_key ON signingactivity(namespace,"key",created);
+
+CREATE TABLE ffi (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64)     NOT NULL,
+  version         VARCHAR(64)     NOT NULL,
+  description     TEXT,
+  methods         TEXT,
+  events          TEXT
+);
+
+CREATE UNIQUE INDEX ffi_id ON ffi(id);
+CREATE UNIQUE INDEX ffi_name ON ffi(namespace,name,version);
+
+CREATE TABLE contractlisteners (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  interface_id    UUID,
+  namespace       VARCHAR(64)     NOT NULL,
+  name            VARCHAR(64),
+  protocol_id     VARCHAR(1024)   NOT NULL,
+  location        TEXT,
+  event           TEXT            NOT NULL,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX contractlisteners_id ON contractlisteners(id);
+CREATE UNIQUE INDEX contractlisteners_protocolid ON contractlisteners(protocol_id);
+CREATE INDEX contractlisteners_namespace ON contractlisteners(namespace);
+
+CREATE TABLE batchquarantine (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  author          VARCHAR(1024),
+  "key"           VARCHAR(1024),
+  peer            VARCHAR(256),
+  payload_ref     VARCHAR(1024),
+  hash            CHAR(64),
+  reason          VARCHAR(1024),
+  size            BIGINT,
+  messages        BIGINT,
+  batch           BYTEA,
+  status          VARCHAR(64)     NOT NULL,
+  created         BIGINT          NOT NULL,
+  decided         BIGINT,
+  decided_by      VARCHAR(1024),
+  comment         VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX batchquarantine_id ON batchquarantine(id);
+CREATE INDEX batchquarantine_status ON batchquarantine(namespace,status);
+
+CREATE TABLE tokenapproval (
+  seq             SERIAL          PRIMARY KEY,
+  local_id        UUID            NOT NULL,
+  pool_id         UUID            NOT NULL,
+  connector       VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  operator_key    VARCHAR(1024)   NOT NULL,
+  approved        BOOLEAN         NOT NULL,
+  protocol_id     VARCHAR(1024)   NOT NULL,
+  tx_type         VARCHAR(64),
+  tx_id           UUID,
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX tokenapproval_id ON tokenapproval(local_id);
+CREATE UNIQUE INDEX tokenapproval_protocolid ON tokenapproval(connector,protocol_id);
+CREATE INDEX tokenapproval_pool ON tokenapproval(pool_id,"key",operator_key);
+
+CREATE TABLE tokennft (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  pool_id         UUID            NOT NULL,
+  token_index     VARCHAR(1024)   NOT NULL,
+  uri             VARCHAR(1024),
+  connector       VARCHAR(64)     NOT NULL,
+  namespace       VARCHAR(64)     NOT NULL,
+  owner_key       VARCHAR(1024),
+  created         BIGINT          NOT NULL,
+  updated         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX tokennft_id ON tokennft(id);
+CREATE UNIQUE INDEX tokennft_pool ON tokennft(pool_id,token_index);
+CREATE INDEX tokennft_owner ON tokennft(namespace,owner_key);
+
+CREATE TABLE pseudonym (
+  seq             SERIAL          PRIMARY KEY,
+  id              UUID            NOT NULL,
+  message_id      UUID,
+  namespace       VARCHAR(64)     NOT NULL,
+  "key"           VARCHAR(1024)   NOT NULL,
+  owner           VARCHAR(1024),
+  created         BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX pseudonym_id ON pseudonym(id);
+CREATE UNIQUE INDEX pseudonym_key ON pseudonym("key");
@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN filter_blockchainevent TEXT;
@@ -0,0 +1,13 @@
+CREATE TABLE group_keys (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  message_id       UUID,
+  namespace        VARCHAR(64)     NOT NULL,
+  group_hash       CHAR(64)        NOT NULL,
+  epoch            BIGINT          NOT NULL,
+  symmetric_key    CHAR(64)        NOT NULL,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX group_keys_id ON group_keys(id);
+CREATE UNIQUE INDEX group_keys_epoch ON group_keys(group_hash, epoch);
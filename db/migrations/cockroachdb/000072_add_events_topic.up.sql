@@ -0,0 +1 @@
+ALTER TABLE events ADD COLUMN topic VARCHAR(64) DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE batchquarantine DROP COLUMN awaiting_identity;
@@ -0,0 +1 @@
+ALTER TABLE batchquarantine ADD COLUMN awaiting_identity VARCHAR(1024) DEFAULT '';
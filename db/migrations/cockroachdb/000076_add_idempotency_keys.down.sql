@@ -0,0 +1,4 @@
+DROP INDEX messages_idempotencykey;
+ALTER TABLE messages DROP COLUMN idempotency_key;
+DROP INDEX transactions_idempotencykey;
+ALTER TABLE transactions DROP COLUMN idempotency_key;
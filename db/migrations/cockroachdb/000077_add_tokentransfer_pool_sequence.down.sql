@@ -0,0 +1,2 @@
+DROP INDEX tokentransfer_poolsequence;
+ALTER TABLE tokentransfer DROP COLUMN pool_sequence;
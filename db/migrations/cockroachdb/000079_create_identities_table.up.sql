@@ -0,0 +1,18 @@
+CREATE TABLE identities (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  name             VARCHAR(64)     NOT NULL,
+  parent           VARCHAR(1024)   NOT NULL,
+  "key"            VARCHAR(1024)   NOT NULL,
+  description      VARCHAR(4096),
+  profile          BYTEA,
+  claim            UUID,
+  verification     UUID,
+  created          BIGINT          NOT NULL,
+  verified         BIGINT
+);
+
+CREATE UNIQUE INDEX identities_id ON identities(id);
+CREATE UNIQUE INDEX identities_key ON identities("key");
+CREATE UNIQUE INDEX identities_name ON identities(namespace,name);
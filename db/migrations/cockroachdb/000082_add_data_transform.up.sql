@@ -0,0 +1 @@
+ALTER TABLE data ADD COLUMN transform VARCHAR(1024) DEFAULT '';
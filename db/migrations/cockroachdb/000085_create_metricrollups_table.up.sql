@@ -0,0 +1,10 @@
+CREATE TABLE metricrollups (
+  seq              SERIAL          PRIMARY KEY,
+  namespace        VARCHAR(64)     NOT NULL,
+  metric           VARCHAR(64)     NOT NULL,
+  rtype            VARCHAR(64)     NOT NULL,
+  bucket           BIGINT          NOT NULL,
+  count            BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX metricrollups_bucket ON metricrollups(namespace, metric, rtype, bucket);
@@ -0,0 +1,4 @@
+DROP INDEX identities_key;
+DROP INDEX identities_name;
+CREATE UNIQUE INDEX identities_key ON identities("key");
+CREATE UNIQUE INDEX identities_name ON identities(namespace,name);
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE subscriptions ADD COLUMN owner VARCHAR(1024);
+UPDATE subscriptions SET owner = '';
+COMMIT;
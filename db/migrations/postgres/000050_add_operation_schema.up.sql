@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE operations ADD COLUMN op_schema VARCHAR(64);
+UPDATE operations SET op_schema = '';
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+CREATE TABLE blockchainevents (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  source           VARCHAR(64)     NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  name             VARCHAR(1024),
+  protocol_id      VARCHAR(1024)   NOT NULL,
+  address          VARCHAR(1024),
+  block_number     BIGINT,
+  protocol_tx_id   VARCHAR(1024),
+  listener         VARCHAR(1024),
+  output           BYTEA,
+  info             BYTEA,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX blockchainevents_id ON blockchainevents(id);
+CREATE UNIQUE INDEX blockchainevents_protocolid ON blockchainevents(source,protocol_id);
+CREATE INDEX blockchainevents_name ON blockchainevents(namespace,name);
+CREATE INDEX blockchainevents_protocoltxid ON blockchainevents(protocol_tx_id);
+
+COMMIT;
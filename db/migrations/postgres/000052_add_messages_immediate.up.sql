@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE messages ADD COLUMN immediate BOOLEAN;
+UPDATE messages SET immediate = false;
+COMMIT;
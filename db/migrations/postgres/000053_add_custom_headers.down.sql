@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE messages DROP COLUMN custom_headers;
+ALTER TABLE subscriptions DROP COLUMN filter_custom;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE messages ADD COLUMN custom_headers VARCHAR(1024);
+UPDATE messages SET custom_headers = '';
+ALTER TABLE subscriptions ADD COLUMN filter_custom VARCHAR(1024);
+UPDATE subscriptions SET filter_custom = '';
+COMMIT;
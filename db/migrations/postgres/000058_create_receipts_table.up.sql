@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE receipts (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  message_id       UUID            NOT NULL,
+  author           VARCHAR(1024)   NOT NULL,
+  key              VARCHAR(1024)   NOT NULL,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX receipts_id ON receipts(id);
+CREATE UNIQUE INDEX receipts_message_author ON receipts(message_id,author);
+COMMIT;
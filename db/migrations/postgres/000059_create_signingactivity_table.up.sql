@@ -0,0 +1,16 @@
+BEGIN;
+CREATE TABLE signingactivity (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  key              VARCHAR(1024)   NOT NULL,
+  optype           VARCHAR(64)     NOT NULL,
+  plugin           VARCHAR(64)     NOT NULL,
+  op_id            UUID            NOT NULL,
+  tx_id            UUID,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX signingactivity_id ON signingactivity(id);
+CREATE INDEX signingactivity_key ON signingactivity(namespace,key,created);
+COMMIT;
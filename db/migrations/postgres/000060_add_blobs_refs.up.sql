@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE blobs ADD COLUMN refs BIGINT;
+UPDATE blobs SET refs = 1;
+COMMIT;
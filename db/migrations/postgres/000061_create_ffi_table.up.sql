@@ -0,0 +1,16 @@
+BEGIN;
+CREATE TABLE ffi (
+  seq          SERIAL          PRIMARY KEY,
+  id           UUID            NOT NULL,
+  message_id   UUID,
+  namespace    VARCHAR(64)     NOT NULL,
+  name         VARCHAR(64)     NOT NULL,
+  version      VARCHAR(64)     NOT NULL,
+  description  TEXT,
+  methods      TEXT,
+  events       TEXT
+);
+
+CREATE UNIQUE INDEX ffi_id ON ffi(id);
+CREATE UNIQUE INDEX ffi_name ON ffi(namespace,name,version);
+COMMIT;
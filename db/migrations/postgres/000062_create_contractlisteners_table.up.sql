@@ -0,0 +1,17 @@
+BEGIN;
+CREATE TABLE contractlisteners (
+  seq          SERIAL          PRIMARY KEY,
+  id           UUID            NOT NULL,
+  interface_id UUID,
+  namespace    VARCHAR(64)     NOT NULL,
+  name         VARCHAR(64),
+  protocol_id  VARCHAR(1024)   NOT NULL,
+  location     TEXT,
+  event        TEXT            NOT NULL,
+  created      BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX contractlisteners_id ON contractlisteners(id);
+CREATE UNIQUE INDEX contractlisteners_protocolid ON contractlisteners(protocol_id);
+CREATE INDEX contractlisteners_namespace ON contractlisteners(namespace);
+COMMIT;
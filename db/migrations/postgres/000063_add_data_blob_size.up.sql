@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE data ADD COLUMN blob_size BIGINT DEFAULT 0;
+COMMIT;
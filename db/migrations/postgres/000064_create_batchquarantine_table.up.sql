@@ -0,0 +1,25 @@
+BEGIN;
+CREATE TABLE batchquarantine (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  author           VARCHAR(1024),
+  key              VARCHAR(1024),
+  peer             VARCHAR(256),
+  payload_ref      VARCHAR(1024),
+  hash             CHAR(64),
+  reason           VARCHAR(1024),
+  size             BIGINT,
+  messages         BIGINT,
+  batch            BYTEA,
+  status           VARCHAR(64)     NOT NULL,
+  created          BIGINT          NOT NULL,
+  decided          BIGINT,
+  decided_by       VARCHAR(1024),
+  comment          VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX batchquarantine_id ON batchquarantine(id);
+CREATE INDEX batchquarantine_status ON batchquarantine(namespace,status);
+
+COMMIT;
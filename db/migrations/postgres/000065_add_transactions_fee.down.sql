@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE transactions DROP COLUMN fee_gas_used;
+ALTER TABLE transactions DROP COLUMN fee_amount;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE batches ADD COLUMN manifest BYTEA;
+COMMIT;
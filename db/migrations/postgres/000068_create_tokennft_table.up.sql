@@ -0,0 +1,19 @@
+BEGIN;
+CREATE TABLE tokennft (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  pool_id          UUID            NOT NULL,
+  token_index      VARCHAR(1024)   NOT NULL,
+  uri              VARCHAR(1024),
+  connector        VARCHAR(64)     NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  owner_key        VARCHAR(1024),
+  created          BIGINT          NOT NULL,
+  updated          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX tokennft_id ON tokennft(id);
+CREATE UNIQUE INDEX tokennft_pool ON tokennft(pool_id,token_index);
+CREATE INDEX tokennft_owner ON tokennft(namespace,owner_key);
+
+COMMIT;
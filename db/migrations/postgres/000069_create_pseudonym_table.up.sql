@@ -0,0 +1,15 @@
+BEGIN;
+CREATE TABLE pseudonym (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  message_id       UUID,
+  namespace        VARCHAR(64)     NOT NULL,
+  key              VARCHAR(1024)   NOT NULL,
+  owner            VARCHAR(1024),
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX pseudonym_id ON pseudonym(id);
+CREATE UNIQUE INDEX pseudonym_key ON pseudonym(key);
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE subscriptions DROP COLUMN filter_blockchainevent;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE subscriptions ADD COLUMN filter_blockchainevent TEXT;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE events ADD COLUMN topic VARCHAR(64) DEFAULT '';
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE batchquarantine DROP COLUMN awaiting_identity;
+COMMIT;
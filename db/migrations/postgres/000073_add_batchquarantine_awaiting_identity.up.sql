@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE batchquarantine ADD COLUMN awaiting_identity VARCHAR(1024) DEFAULT '';
+COMMIT;
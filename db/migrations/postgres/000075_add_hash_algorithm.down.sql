@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE batches DROP COLUMN hash_algorithm;
+ALTER TABLE data DROP COLUMN hash_algorithm;
+COMMIT;
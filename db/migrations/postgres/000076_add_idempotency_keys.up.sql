@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE messages ADD COLUMN idempotency_key VARCHAR(256);
+CREATE UNIQUE INDEX messages_idempotencykey ON messages(namespace,idempotency_key);
+ALTER TABLE transactions ADD COLUMN idempotency_key VARCHAR(256);
+CREATE UNIQUE INDEX transactions_idempotencykey ON transactions(namespace,idempotency_key);
+COMMIT;
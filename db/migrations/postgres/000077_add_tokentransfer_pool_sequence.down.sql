@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX tokentransfer_poolsequence;
+ALTER TABLE tokentransfer DROP COLUMN pool_sequence;
+COMMIT;
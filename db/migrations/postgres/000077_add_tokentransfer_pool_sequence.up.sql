@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE tokentransfer ADD COLUMN pool_sequence VARCHAR(64);
+CREATE INDEX tokentransfer_poolsequence ON tokentransfer(pool_id,pool_sequence);
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+CREATE TABLE legalholds (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  hold_type        VARCHAR(64)     NOT NULL,
+  ref              UUID,
+  topic            VARCHAR(64),
+  reason           VARCHAR(1024),
+  active           BOOLEAN         NOT NULL,
+  placed           BIGINT          NOT NULL,
+  placed_by        VARCHAR(1024),
+  removed          BIGINT,
+  removed_by       VARCHAR(1024),
+  comment          VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX legalholds_id ON legalholds(id);
+CREATE INDEX legalholds_active ON legalholds(namespace,active);
+
+COMMIT;
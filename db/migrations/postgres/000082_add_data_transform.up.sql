@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE data ADD COLUMN transform VARCHAR(1024);
+UPDATE data SET transform='';
+COMMIT;
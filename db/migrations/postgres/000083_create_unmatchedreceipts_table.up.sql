@@ -0,0 +1,17 @@
+BEGIN;
+CREATE TABLE unmatchedreceipts (
+  seq              SERIAL          PRIMARY KEY,
+  id               UUID            NOT NULL,
+  plugin           VARCHAR(64)     NOT NULL,
+  request_id       VARCHAR(1024)   NOT NULL,
+  status           VARCHAR(64)     NOT NULL,
+  error            VARCHAR,
+  output           BYTEA,
+  created          BIGINT          NOT NULL,
+  operation_id     UUID,
+  reconciled       BIGINT
+);
+
+CREATE UNIQUE INDEX unmatchedreceipts_id ON unmatchedreceipts(id);
+CREATE INDEX unmatchedreceipts_request ON unmatchedreceipts(plugin,request_id);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE messages DROP COLUMN delegation_id;
+DROP TABLE IF EXISTS delegations;
+COMMIT;
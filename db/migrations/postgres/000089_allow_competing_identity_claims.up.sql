@@ -0,0 +1,6 @@
+BEGIN;
+DROP INDEX identities_key;
+DROP INDEX identities_name;
+CREATE INDEX identities_key ON identities(key);
+CREATE INDEX identities_name ON identities(namespace,name);
+COMMIT;
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN owner VARCHAR(1024);
+UPDATE subscriptions SET owner = '';
@@ -0,0 +1,2 @@
+ALTER TABLE operations ADD COLUMN op_schema VARCHAR(64);
+UPDATE operations SET op_schema = '';
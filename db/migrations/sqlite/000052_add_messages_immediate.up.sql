@@ -0,0 +1,2 @@
+ALTER TABLE messages ADD COLUMN immediate BOOLEAN;
+UPDATE messages SET immediate = false;
@@ -0,0 +1,2 @@
+ALTER TABLE messages DROP COLUMN custom_headers;
+ALTER TABLE subscriptions DROP COLUMN filter_custom;
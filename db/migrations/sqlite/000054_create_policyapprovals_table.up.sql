@@ -0,0 +1,20 @@
+CREATE TABLE policyapprovals (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  type             VARCHAR(64)     NOT NULL,
+  signing_key      VARCHAR(1024),
+  reference        UUID,
+  input            BYTEA,
+  hash             CHAR(64)        NOT NULL,
+  reason           VARCHAR(1024),
+  status           VARCHAR(64)     NOT NULL,
+  created          BIGINT          NOT NULL,
+  decided          BIGINT,
+  decided_by       VARCHAR(1024),
+  comment          VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX policyapprovals_id ON policyapprovals(id);
+CREATE INDEX policyapprovals_hash ON policyapprovals(namespace,hash);
+CREATE INDEX policyapprovals_status ON policyapprovals(status);
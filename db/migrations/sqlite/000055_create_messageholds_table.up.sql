@@ -0,0 +1,17 @@
+CREATE TABLE messageholds (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  group_hash       CHAR(64),
+  tag              VARCHAR(64),
+  message          BYTEA,
+  reason           VARCHAR(1024),
+  status           VARCHAR(64)     NOT NULL,
+  created          BIGINT          NOT NULL,
+  decided          BIGINT,
+  decided_by       VARCHAR(1024),
+  comment          VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX messageholds_id ON messageholds(id);
+CREATE INDEX messageholds_status ON messageholds(namespace,status);
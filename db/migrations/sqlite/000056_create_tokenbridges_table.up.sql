@@ -0,0 +1,25 @@
+CREATE TABLE tokenbridges (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  key              VARCHAR(1024)   NOT NULL,
+  from_key         VARCHAR(1024),
+  to_key           VARCHAR(1024),
+  escrow           VARCHAR(1024)   NOT NULL,
+  amount           VARCHAR(65),
+  source_connector VARCHAR(64)     NOT NULL,
+  source_pool      UUID            NOT NULL,
+  source_index     VARCHAR(1024),
+  target_connector VARCHAR(64)     NOT NULL,
+  target_pool      UUID            NOT NULL,
+  target_index     VARCHAR(1024),
+  status           VARCHAR(64)     NOT NULL,
+  error            TEXT,
+  tx_type          VARCHAR(64),
+  tx_id            UUID,
+  created          BIGINT          NOT NULL,
+  updated          BIGINT
+);
+
+CREATE UNIQUE INDEX tokenbridges_id ON tokenbridges(id);
+CREATE INDEX tokenbridges_status ON tokenbridges(namespace,status);
@@ -0,0 +1,11 @@
+CREATE TABLE snapshots (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  stype            VARCHAR(64)     NOT NULL,
+  name             VARCHAR(64)     NOT NULL,
+  current          BIGINT          NOT NULL,
+  state            TEXT,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX snapshots_name ON snapshots(stype,name);
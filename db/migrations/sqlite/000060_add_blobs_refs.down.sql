@@ -0,0 +1 @@
+ALTER TABLE blobs DROP COLUMN refs;
@@ -0,0 +1,2 @@
+ALTER TABLE blobs ADD COLUMN refs BIGINT;
+UPDATE blobs SET refs = 1;
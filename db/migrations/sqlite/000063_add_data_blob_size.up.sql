@@ -0,0 +1 @@
+ALTER TABLE data ADD COLUMN blob_size BIGINT DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN fee_gas_used;
+ALTER TABLE transactions DROP COLUMN fee_amount;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions ADD COLUMN fee_gas_used VARCHAR(65);
+ALTER TABLE transactions ADD COLUMN fee_amount VARCHAR(65);
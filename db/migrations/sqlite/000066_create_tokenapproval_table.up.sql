@@ -0,0 +1,18 @@
+CREATE TABLE tokenapproval (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  local_id         UUID            NOT NULL,
+  pool_id          UUID            NOT NULL,
+  connector        VARCHAR(64)     NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  key              VARCHAR(1024)   NOT NULL,
+  operator_key     VARCHAR(1024)   NOT NULL,
+  approved         BOOLEAN         NOT NULL,
+  protocol_id      VARCHAR(1024)   NOT NULL,
+  tx_type          VARCHAR(64),
+  tx_id            UUID,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX tokenapproval_id ON tokenapproval(local_id);
+CREATE INDEX tokenapproval_pool ON tokenapproval(pool_id,key,operator_key);
+CREATE UNIQUE INDEX tokenapproval_protocolid ON tokenapproval(connector,protocol_id);
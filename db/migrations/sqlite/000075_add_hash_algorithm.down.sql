@@ -0,0 +1,2 @@
+ALTER TABLE batches DROP COLUMN hash_algorithm;
+ALTER TABLE data DROP COLUMN hash_algorithm;
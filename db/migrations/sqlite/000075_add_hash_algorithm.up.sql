@@ -0,0 +1,4 @@
+ALTER TABLE batches ADD COLUMN hash_algorithm VARCHAR(64);
+UPDATE batches SET hash_algorithm='sha256';
+ALTER TABLE data ADD COLUMN hash_algorithm VARCHAR(64);
+UPDATE data SET hash_algorithm='sha256';
@@ -0,0 +1,12 @@
+CREATE TABLE messagetransitions (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  message_id       UUID            NOT NULL,
+  state            VARCHAR(64)     NOT NULL,
+  cause            VARCHAR(1024),
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX messagetransitions_id ON messagetransitions(id);
+CREATE INDEX messagetransitions_message ON messagetransitions(message_id);
@@ -0,0 +1 @@
+ALTER TABLE data DROP COLUMN transform;
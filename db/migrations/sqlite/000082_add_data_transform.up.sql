@@ -0,0 +1,2 @@
+ALTER TABLE data ADD COLUMN transform VARCHAR(1024);
+UPDATE data SET transform='';
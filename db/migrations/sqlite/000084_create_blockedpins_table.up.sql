@@ -0,0 +1,16 @@
+CREATE TABLE blockedpins (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  payload_ref      VARCHAR(1024)   NOT NULL,
+  hash             CHAR(64),
+  signer           VARCHAR(1024),
+  timestamp        BIGINT,
+  attempts         BIGINT          NOT NULL,
+  last_error       VARCHAR,
+  next_attempt     BIGINT          NOT NULL,
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX blockedpins_id ON blockedpins(id);
+CREATE INDEX blockedpins_next_attempt ON blockedpins(next_attempt);
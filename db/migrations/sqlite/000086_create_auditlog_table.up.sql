@@ -0,0 +1,16 @@
+CREATE TABLE auditlog (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  identity         VARCHAR(1024)   NOT NULL,
+  remote           VARCHAR(256)    NOT NULL,
+  method           VARCHAR(16)     NOT NULL,
+  path             VARCHAR(1024)   NOT NULL,
+  route            VARCHAR(64)     NOT NULL,
+  status           INT             NOT NULL,
+  payload_hash     CHAR(64),
+  created          BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX auditlog_id ON auditlog(id);
+CREATE INDEX auditlog_created ON auditlog(created);
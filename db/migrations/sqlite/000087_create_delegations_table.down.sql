@@ -0,0 +1,2 @@
+ALTER TABLE messages DROP COLUMN delegation_id;
+DROP TABLE IF EXISTS delegations;
@@ -0,0 +1,15 @@
+CREATE TABLE delegations (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  message_id       UUID,
+  namespace        VARCHAR(64)     NOT NULL,
+  delegator        VARCHAR(1024)   NOT NULL,
+  delegate         VARCHAR(1024)   NOT NULL,
+  created          BIGINT          NOT NULL,
+  revoked          BIGINT
+);
+
+CREATE UNIQUE INDEX delegations_id ON delegations(id);
+CREATE INDEX delegations_delegator ON delegations(namespace, delegator);
+
+ALTER TABLE messages ADD COLUMN delegation_id UUID;
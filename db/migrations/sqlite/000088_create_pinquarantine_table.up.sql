@@ -0,0 +1,15 @@
+CREATE TABLE pinquarantine (
+  seq              INTEGER         PRIMARY KEY AUTOINCREMENT,
+  id               UUID            NOT NULL,
+  namespace        VARCHAR(64)     NOT NULL,
+  batch_id         UUID,
+  signer           VARCHAR(1024),
+  status           VARCHAR(64)     NOT NULL,
+  created          BIGINT          NOT NULL,
+  decided          BIGINT,
+  decided_by       VARCHAR(1024),
+  comment          VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX pinquarantine_id ON pinquarantine(id);
+CREATE INDEX pinquarantine_status ON pinquarantine(namespace,status);
@@ -20,9 +20,41 @@ import "github.com/hyperledger/firefly/internal/oapispec"
 
 var adminRoutes = []*oapispec.Route{
 	getConfig,
+	putConfig,
 	getConfigRecord,
 	getConfigRecords,
 	postResetConfig,
 	putConfigRecord,
 	deleteConfigRecord,
+	getLogLevels,
+	putLogLevel,
+	getLogTail,
+	getWebSockets,
+	deleteWebSocket,
+	getPolicyApprovals,
+	getPolicyApprovalByID,
+	postPolicyApprovalDecide,
+	getMessageHolds,
+	getMessageHoldByID,
+	postMessageHoldDecide,
+	getBatchQuarantines,
+	getBatchQuarantineByID,
+	postBatchQuarantineDecide,
+	getNamespaceFeatures,
+	postPromote,
+	getDefinitionsExport,
+	postDefinitionsImport,
+	postReplayBlockchain,
+	postConsistencyCheck,
+	getConsistencyReport,
+	getUnmatchedReceipts,
+	getUnmatchedReceiptByID,
+	postUnmatchedReceiptReconcile,
+	getBlockedPins,
+	postBlockedPinRetry,
+	getPinQuarantines,
+	getPinQuarantineByID,
+	postPinQuarantineDecide,
+	getAuditRecords,
+	getAuditRecordByID,
 }
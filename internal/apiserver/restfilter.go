@@ -18,13 +18,8 @@ package apiserver
 
 import (
 	"net/http"
-	"net/url"
 	"reflect"
-	"sort"
-	"strconv"
-	"strings"
 
-	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/database"
 )
@@ -54,96 +49,13 @@ func filterResult(items interface{}, res *database.FilterResult, err error) (int
 	}, nil
 }
 
-func (as *apiServer) getValues(values url.Values, key string) (results []string) {
-	for queryName, queryValues := range values {
-		// We choose to be case insensitive for our filters, so protocolID and protocolid can be used interchangeably
-		if strings.EqualFold(queryName, key) {
-			results = append(results, queryValues...)
-		}
-	}
-	return results
-}
-
 func (as *apiServer) buildFilter(req *http.Request, ff database.QueryFactory) (database.AndFilter, error) {
 	ctx := req.Context()
 	log.L(ctx).Debugf("Query: %s", req.URL.RawQuery)
-	fb := ff.NewFilterLimit(ctx, as.defaultFilterLimit)
-	possibleFields := fb.Fields()
-	sort.Strings(possibleFields)
-	filter := fb.And()
 	_ = req.ParseForm()
-	for _, field := range possibleFields {
-		values := as.getValues(req.Form, field)
-		if len(values) == 1 {
-			filter.Condition(as.getCondition(fb, field, values[0]))
-		} else if len(values) > 0 {
-			sort.Strings(values)
-			fs := make([]database.Filter, len(values))
-			for i, value := range values {
-				fs[i] = as.getCondition(fb, field, value)
-			}
-			filter.Condition(fb.Or(fs...))
-		}
-	}
-	skipVals := as.getValues(req.Form, "skip")
-	if len(skipVals) > 0 {
-		s, _ := strconv.ParseUint(skipVals[0], 10, 64)
-		if as.maxFilterSkip != 0 && s > as.maxFilterSkip {
-			return nil, i18n.NewError(req.Context(), i18n.MsgMaxFilterSkip, as.maxFilterSkip)
-		}
-		filter.Skip(s)
-	}
-	limitVals := as.getValues(req.Form, "limit")
-	if len(limitVals) > 0 {
-		l, _ := strconv.ParseUint(limitVals[0], 10, 64)
-		if as.maxFilterLimit != 0 && l > as.maxFilterLimit {
-			return nil, i18n.NewError(req.Context(), i18n.MsgMaxFilterLimit, as.maxFilterLimit)
-		}
-		filter.Limit(l)
-	}
-	sortVals := as.getValues(req.Form, "sort")
-	for _, sv := range sortVals {
-		subSortVals := strings.Split(sv, ",")
-		for _, ssv := range subSortVals {
-			ssv = strings.TrimSpace(ssv)
-			if ssv != "" {
-				filter.Sort(ssv)
-			}
-		}
-	}
-	descendingVals := as.getValues(req.Form, "descending")
-	ascendingVals := as.getValues(req.Form, "ascending")
-	if len(descendingVals) > 0 && (descendingVals[0] == "" || strings.EqualFold(descendingVals[0], "true")) {
-		filter.Descending()
-	} else if len(ascendingVals) > 0 && (ascendingVals[0] == "" || strings.EqualFold(ascendingVals[0], "true")) {
-		filter.Ascending()
-	}
-	countVals := as.getValues(req.Form, "count")
-	filter.Count(len(countVals) > 0 && (countVals[0] == "" || strings.EqualFold(countVals[0], "true")))
-	return filter, nil
-}
-
-func (as *apiServer) getCondition(fb database.FilterBuilder, field, value string) database.Filter {
-	switch {
-	case strings.HasPrefix(value, ">="):
-		return fb.Gte(field, value[2:])
-	case strings.HasPrefix(value, "<="):
-		return fb.Lte(field, value[2:])
-	case strings.HasPrefix(value, ">"):
-		return fb.Gt(field, value[1:])
-	case strings.HasPrefix(value, "<"):
-		return fb.Lt(field, value[1:])
-	case strings.HasPrefix(value, "@"):
-		return fb.Contains(field, value[1:])
-	case strings.HasPrefix(value, "^"):
-		return fb.IContains(field, value[1:])
-	case strings.HasPrefix(value, "!@"):
-		return fb.NotContains(field, value[2:])
-	case strings.HasPrefix(value, "!^"):
-		return fb.NotIContains(field, value[2:])
-	case strings.HasPrefix(value, "!"):
-		return fb.Neq(field, value[1:])
-	default:
-		return fb.Eq(field, value)
-	}
+	return database.CompileQueryParams(ctx, ff, req.Form, &database.QueryParamLimits{
+		DefaultLimit: as.defaultFilterLimit,
+		MaxLimit:     as.maxFilterLimit,
+		MaxSkip:      as.maxFilterSkip,
+	})
 }
@@ -0,0 +1,48 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var getBlockedPins = &oapispec.Route{
+	Name:       "getBlockedPins",
+	Path:       "pins",
+	Method:     http.MethodGet,
+	PathParams: nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "state", Example: "blocked", Description: i18n.MsgTBD},
+	},
+	FilterFactory:   database.BlockedPinQueryFactory,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return []*fftypes.BlockedPin{} },
+	JSONOutputCodes: []int{http.StatusOK},
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		// Only pins blocked on retrieval of their batch payload are currently exposed
+		if state := r.QP["state"]; state != "blocked" {
+			return nil, i18n.NewError(r.Ctx, i18n.MsgUnknownFieldValue, "state", state)
+		}
+		return filterResult(r.Or.Events().GetBlockedPins(r.Ctx, r.Filter))
+	},
+}
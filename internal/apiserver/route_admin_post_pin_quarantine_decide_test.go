@@ -0,0 +1,53 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostPinQuarantineDecide(t *testing.T) {
+	o, r := newTestAdminServer()
+	mem := &eventmocks.EventManager{}
+	o.On("Events").Return(mem)
+	input := fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin2",
+	}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	u := fftypes.NewUUID()
+	req := httptest.NewRequest("POST", "/admin/api/v1/pins/quarantine/"+u.String()+"/decide", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mem.On("DecidePinQuarantine", mock.Anything, u.String(), mock.MatchedBy(func(d *fftypes.PolicyApprovalDecision) bool {
+		return d.Status == fftypes.PolicyApprovalStatusApproved && d.DecidedBy == "admin2"
+	})).Return(&fftypes.PinQuarantine{ID: u, Status: fftypes.PolicyApprovalStatusApproved}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mem.AssertExpectations(t)
+}
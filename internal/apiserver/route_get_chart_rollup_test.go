@@ -0,0 +1,93 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/mocks/rollupmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetChartRollupBadStartTime(t *testing.T) {
+	_, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/charts/rollups/messages?startTime=abc&buckets=30", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
+
+func TestGetChartRollupBadInterval(t *testing.T) {
+	_, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/charts/rollups/messages?startTime=123&interval=abc&buckets=30", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
+
+func TestGetChartRollupBadBuckets(t *testing.T) {
+	_, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/charts/rollups/messages?startTime=123&buckets=abc", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
+
+func TestGetChartRollupSuccess(t *testing.T) {
+	o, r := newTestAPIServer()
+	mrm := &rollupmocks.Manager{}
+	o.On("Rollup").Return(mrm)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/charts/rollups/messages?startTime=1234567890&interval=1h&buckets=24&types=broadcast,private", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	startTime, _ := fftypes.ParseString("1234567890")
+
+	mrm.On("GetChartRollup", mock.Anything, "mynamespace", "messages", startTime, time.Hour, int64(24), []string{"broadcast", "private"}).
+		Return([]*fftypes.ChartHistogramTyped{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestGetChartRollupDefaults(t *testing.T) {
+	o, r := newTestAPIServer()
+	mrm := &rollupmocks.Manager{}
+	o.On("Rollup").Return(mrm)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/charts/rollups/events?startTime=1234567890&buckets=24", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mrm.On("GetChartRollup", mock.Anything, "mynamespace", "events", mock.Anything, time.Duration(0), int64(24), []string(nil)).
+		Return([]*fftypes.ChartHistogramTyped{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
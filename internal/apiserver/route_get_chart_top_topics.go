@@ -0,0 +1,75 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var chartRankingQueryParams = []*oapispec.QueryParam{
+	{Name: "startTime", Description: i18n.MsgHistogramStartTimeParam, IsBool: false},
+	{Name: "endTime", Description: i18n.MsgHistogramEndTimeParam, IsBool: false},
+	{Name: "limit", Description: i18n.MsgChartRankingLimitParam, IsBool: false},
+}
+
+// parseChartRankingParams parses the optional time range and limit shared by the chart ranking routes
+func parseChartRankingParams(r *oapispec.APIRequest) (startTime, endTime *fftypes.FFTime, limit int, err error) {
+	if r.QP["startTime"] != "" {
+		if startTime, err = fftypes.ParseString(r.QP["startTime"]); err != nil {
+			return nil, nil, 0, i18n.NewError(r.Ctx, i18n.MsgInvalidTimestampParam, "startTime")
+		}
+	}
+	if r.QP["endTime"] != "" {
+		if endTime, err = fftypes.ParseString(r.QP["endTime"]); err != nil {
+			return nil, nil, 0, i18n.NewError(r.Ctx, i18n.MsgInvalidTimestampParam, "endTime")
+		}
+	}
+	if r.QP["limit"] != "" {
+		if limit, err = strconv.Atoi(r.QP["limit"]); err != nil {
+			return nil, nil, 0, i18n.NewError(r.Ctx, i18n.MsgInvalidChartNumberParam, "limit")
+		}
+	}
+	return startTime, endTime, limit, nil
+}
+
+var getChartTopTopics = &oapispec.Route{
+	Name:   "getChartTopTopics",
+	Path:   "namespaces/{ns}/charts/topics",
+	Method: http.MethodGet,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+	},
+	QueryParams:     chartRankingQueryParams,
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return []*fftypes.ChartRanking{} },
+	JSONOutputCodes: []int{http.StatusOK},
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		startTime, endTime, limit, err := parseChartRankingParams(r)
+		if err != nil {
+			return nil, err
+		}
+		return r.Or.GetChartTopTopics(r.Ctx, r.PP["ns"], startTime, endTime, limit)
+	},
+}
@@ -17,7 +17,9 @@
 package apiserver
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -38,8 +40,20 @@ var getDataBlob = &oapispec.Route{
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []byte{} },
-	JSONOutputCodes: []int{http.StatusOK},
+	JSONOutputCodes: []int{http.StatusOK, http.StatusPartialContent},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
-		return r.Or.Data().DownloadBLOB(r.Ctx, r.PP["ns"], r.PP["dataid"])
+		download, err := r.Or.Data().DownloadBLOBRange(r.Ctx, r.PP["ns"], r.PP["dataid"], r.Req.Header.Get("Range"))
+		if err != nil {
+			return nil, err
+		}
+		r.ResponseHeaders.Set("Accept-Ranges", "bytes")
+		if download.Partial {
+			r.ResponseHeaders.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", download.Offset, download.Offset+download.Length-1, download.Size))
+			r.SuccessStatus = http.StatusPartialContent
+		}
+		if download.Size > 0 {
+			r.ResponseHeaders.Set("Content-Length", strconv.FormatInt(download.Length, 10))
+		}
+		return download.Reader, nil
 	},
 }
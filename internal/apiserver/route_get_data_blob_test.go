@@ -18,11 +18,13 @@ package apiserver
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -35,12 +37,58 @@ func TestGetDataBlob(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	res := httptest.NewRecorder()
 
-	mdm.On("DownloadBLOB", mock.Anything, "mynamespace", "abcd1234").
-		Return(ioutil.NopCloser(bytes.NewReader([]byte("hello"))), nil)
+	mdm.On("DownloadBLOBRange", mock.Anything, "mynamespace", "abcd1234", "").
+		Return(&fftypes.BlobDownload{
+			Reader: ioutil.NopCloser(bytes.NewReader([]byte("hello"))),
+			Size:   5,
+			Length: 5,
+		}, nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Equal(t, "bytes", res.Result().Header.Get("Accept-Ranges"))
+	assert.Equal(t, "5", res.Result().Header.Get("Content-Length"))
 	b, err := ioutil.ReadAll(res.Body)
 	assert.NoError(t, err)
 	assert.Equal(t, "hello", string(b))
 }
+
+func TestGetDataBlobRange(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=6-10")
+	res := httptest.NewRecorder()
+
+	mdm.On("DownloadBLOBRange", mock.Anything, "mynamespace", "abcd1234", "bytes=6-10").
+		Return(&fftypes.BlobDownload{
+			Reader:  ioutil.NopCloser(bytes.NewReader([]byte("world"))),
+			Size:    11,
+			Offset:  6,
+			Length:  5,
+			Partial: true,
+		}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 206, res.Result().StatusCode)
+	assert.Equal(t, "bytes 6-10/11", res.Result().Header.Get("Content-Range"))
+	b, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(b))
+}
+
+func TestGetDataBlobRangeFail(t *testing.T) {
+	o, r := newTestAPIServer()
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=20-")
+	res := httptest.NewRecorder()
+
+	mdm.On("DownloadBLOBRange", mock.Anything, "mynamespace", "abcd1234", "bytes=20-").
+		Return(nil, fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+}
@@ -36,6 +36,7 @@ var getMsgs = &oapispec.Route{
 	},
 	QueryParams: []*oapispec.QueryParam{
 		{Name: "fetchdata", IsBool: true, Description: i18n.MsgFetchDataDesc},
+		{Name: "asOf", Description: i18n.MsgAsOfDesc},
 	},
 	FilterFactory:   database.MessageQueryFactory,
 	Description:     i18n.MsgTBD,
@@ -43,7 +44,18 @@ var getMsgs = &oapispec.Route{
 	JSONOutputValue: func() interface{} { return []*fftypes.Message{} },
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
-		if strings.EqualFold(r.QP["fetchdata"], "true") {
+		fetchData := strings.EqualFold(r.QP["fetchdata"], "true")
+		if asOfParam, ok := r.QP["asOf"]; ok {
+			asOf, err := r.Or.ResolveAsOf(r.Ctx, r.PP["ns"], asOfParam)
+			if err != nil {
+				return nil, err
+			}
+			if fetchData {
+				return filterResult(r.Or.GetMessagesWithDataAsOf(r.Ctx, r.PP["ns"], asOf, r.Filter))
+			}
+			return filterResult(r.Or.GetMessagesAsOf(r.Ctx, r.PP["ns"], asOf, r.Filter))
+		}
+		if fetchData {
 			return filterResult(r.Or.GetMessagesWithData(r.Ctx, r.PP["ns"], r.Filter))
 		}
 		return filterResult(r.Or.GetMessages(r.Ctx, r.PP["ns"], r.Filter))
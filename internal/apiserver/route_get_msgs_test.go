@@ -17,10 +17,12 @@
 package apiserver
 
 import (
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -83,3 +85,45 @@ func TestGetMessagesWithCountAndData(t *testing.T) {
 	assert.Equal(t, int64(0), resWithCount.Count)
 	assert.Equal(t, int64(10), resWithCount.Total)
 }
+
+func TestGetMessagesAsOf(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages?asOf=12345", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	asOf := fftypes.Now()
+	o.On("ResolveAsOf", mock.Anything, "mynamespace", "12345").Return(asOf, nil)
+	o.On("GetMessagesAsOf", mock.Anything, "mynamespace", asOf, mock.Anything).
+		Return([]*fftypes.Message{}, nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestGetMessagesAsOfWithData(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages?asOf=2021-11-01T00:00:00Z&fetchdata", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	asOf := fftypes.Now()
+	o.On("ResolveAsOf", mock.Anything, "mynamespace", "2021-11-01T00:00:00Z").Return(asOf, nil)
+	o.On("GetMessagesWithDataAsOf", mock.Anything, "mynamespace", asOf, mock.Anything).
+		Return([]*fftypes.MessageInOut{}, nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestGetMessagesAsOfBad(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/messages?asOf=bad", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("ResolveAsOf", mock.Anything, "mynamespace", "bad").Return(nil, i18n.NewError(context.Background(), i18n.MsgInvalidAsOf, "bad"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 400, res.Result().StatusCode)
+}
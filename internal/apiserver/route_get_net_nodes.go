@@ -26,17 +26,27 @@ import (
 )
 
 var getNetworkNodes = &oapispec.Route{
-	Name:            "getNetworkNodes",
-	Path:            "network/nodes",
-	Method:          http.MethodGet,
-	PathParams:      nil,
-	QueryParams:     nil,
+	Name:       "getNetworkNodes",
+	Path:       "network/nodes",
+	Method:     http.MethodGet,
+	PathParams: nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "asOf", Description: i18n.MsgAsOfDesc},
+	},
 	FilterFactory:   database.NodeQueryFactory,
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Node{} },
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		if asOfParam, ok := r.QP["asOf"]; ok {
+			// Network identities are defined in the system namespace, so event sequences are resolved there
+			asOf, err := r.Or.ResolveAsOf(r.Ctx, fftypes.SystemNamespace, asOfParam)
+			if err != nil {
+				return nil, err
+			}
+			return filterResult(r.Or.NetworkMap().GetNodesAsOf(r.Ctx, asOf, r.Filter))
+		}
 		return filterResult(r.Or.NetworkMap().GetNodes(r.Ctx, r.Filter))
 	},
 }
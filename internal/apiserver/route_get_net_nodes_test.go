@@ -17,6 +17,7 @@
 package apiserver
 
 import (
+	"fmt"
 	"net/http/httptest"
 	"testing"
 
@@ -40,3 +41,31 @@ func TestGetNodess(t *testing.T) {
 
 	assert.Equal(t, 200, res.Result().StatusCode)
 }
+
+func TestGetNodesAsOf(t *testing.T) {
+	o, r := newTestAPIServer()
+	mnm := &networkmapmocks.Manager{}
+	o.On("NetworkMap").Return(mnm)
+	req := httptest.NewRequest("GET", "/api/v1/network/nodes?asOf=12345", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	asOf := fftypes.Now()
+	o.On("ResolveAsOf", mock.Anything, fftypes.SystemNamespace, "12345").Return(asOf, nil)
+	mnm.On("GetNodesAsOf", mock.Anything, asOf, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestGetNodesAsOfBad(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/network/nodes?asOf=bad", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	o.On("ResolveAsOf", mock.Anything, fftypes.SystemNamespace, "bad").Return(nil, fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+}
@@ -0,0 +1,59 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var getSigningKeyReport = &oapispec.Route{
+	Name:   "getSigningKeyReport",
+	Path:   "namespaces/{ns}/signingactivity/{key}/report",
+	Method: http.MethodGet,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+		{Name: "key", Description: i18n.MsgTBD},
+	},
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "startTime", Description: i18n.MsgHistogramStartTimeParam, IsBool: false},
+		{Name: "endTime", Description: i18n.MsgHistogramEndTimeParam, IsBool: false},
+	},
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return &fftypes.SigningKeyReport{} },
+	JSONOutputCodes: []int{http.StatusOK},
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		var startTime, endTime *fftypes.FFTime
+		if r.QP["startTime"] != "" {
+			if startTime, err = fftypes.ParseString(r.QP["startTime"]); err != nil {
+				return nil, i18n.NewError(r.Ctx, i18n.MsgInvalidTimestampParam, "startTime")
+			}
+		}
+		if r.QP["endTime"] != "" {
+			if endTime, err = fftypes.ParseString(r.QP["endTime"]); err != nil {
+				return nil, i18n.NewError(r.Ctx, i18n.MsgInvalidTimestampParam, "endTime")
+			}
+		}
+		return r.Or.GetSigningKeyReport(r.Ctx, r.PP["ns"], r.PP["key"], startTime, endTime)
+	},
+}
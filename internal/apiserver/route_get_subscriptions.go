@@ -18,7 +18,9 @@ package apiserver
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/oapispec"
@@ -33,13 +35,19 @@ var getSubscriptions = &oapispec.Route{
 	PathParams: []*oapispec.PathParam{
 		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
 	},
-	QueryParams:     nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "mine", IsBool: true, Description: i18n.MsgMineDesc},
+	},
 	FilterFactory:   database.SubscriptionQueryFactory,
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Subscription{} },
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
-		return filterResult(r.Or.GetSubscriptions(r.Ctx, r.PP["ns"], r.Filter))
+		filter := r.Filter
+		if strings.EqualFold(r.QP["mine"], "true") {
+			filter.Condition(filter.Builder().Eq("owner", auth.GetIdentity(r.Ctx)))
+		}
+		return filterResult(r.Or.GetSubscriptions(r.Ctx, r.PP["ns"], filter))
 	},
 }
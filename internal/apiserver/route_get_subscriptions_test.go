@@ -17,9 +17,16 @@
 package apiserver
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -37,3 +44,25 @@ func TestGetSubscriptions(t *testing.T) {
 
 	assert.Equal(t, 200, res.Result().StatusCode)
 }
+
+func TestGetSubscriptionsMine(t *testing.T) {
+	o, r := newTestAPIServer()
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/subscriptions?mine", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "app1"}},
+		},
+	}
+	res := httptest.NewRecorder()
+
+	o.On("GetSubscriptions", mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.GetIdentity(ctx) == "CN=app1"
+	}), "mynamespace", mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return strings.Contains(fi.String(), "( owner == 'CN=app1' )")
+	})).Return([]*fftypes.Subscription{}, nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
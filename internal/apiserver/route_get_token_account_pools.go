@@ -43,4 +43,5 @@ var getTokenAccountPools = &oapispec.Route{
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		return filterResult(r.Or.Assets().GetTokenAccountPools(r.Ctx, r.PP["ns"], r.PP["key"], r.Filter))
 	},
+	Feature: fftypes.FeatureTokens,
 }
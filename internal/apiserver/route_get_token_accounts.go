@@ -42,4 +42,5 @@ var getTokenAccounts = &oapispec.Route{
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		return filterResult(r.Or.Assets().GetTokenAccounts(r.Ctx, r.PP["ns"], r.Filter))
 	},
+	Feature: fftypes.FeatureTokens,
 }
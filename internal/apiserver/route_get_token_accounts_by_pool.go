@@ -45,4 +45,5 @@ var getTokenAccountsByPool = &oapispec.Route{
 		return filterResult(r.Or.Assets().GetTokenBalancesByPool(r.Ctx, r.PP["ns"], r.PP["type"], r.PP["name"], r.Filter))
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
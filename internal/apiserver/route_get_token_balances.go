@@ -33,13 +33,23 @@ var getTokenBalances = &oapispec.Route{
 	PathParams: []*oapispec.PathParam{
 		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
 	},
-	QueryParams:     nil,
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "asOf", Description: i18n.MsgAsOfDesc},
+	},
 	FilterFactory:   database.TokenBalanceQueryFactory,
 	Description:     i18n.MsgTBD,
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.TokenBalance{} },
 	JSONOutputCodes: []int{http.StatusOK},
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		if asOfParam, ok := r.QP["asOf"]; ok {
+			asOf, err := r.Or.ResolveAsOf(r.Ctx, r.PP["ns"], asOfParam)
+			if err != nil {
+				return nil, err
+			}
+			return filterResult(r.Or.Assets().GetTokenBalancesAsOf(r.Ctx, r.PP["ns"], asOf, r.Filter))
+		}
 		return filterResult(r.Or.Assets().GetTokenBalances(r.Ctx, r.PP["ns"], r.Filter))
 	},
+	Feature: fftypes.FeatureTokens,
 }
@@ -41,4 +41,5 @@ var getTokenConnectors = &oapispec.Route{
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		return r.Or.Assets().GetTokenConnectors(r.Ctx, r.PP["ns"])
 	},
+	Feature: fftypes.FeatureTokens,
 }
@@ -45,4 +45,5 @@ var getTokenPoolByName = &oapispec.Route{
 		return output, err
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
@@ -43,4 +43,5 @@ var getTokenPoolByNameOrID = &oapispec.Route{
 		output, err = r.Or.Assets().GetTokenPoolByNameOrID(r.Ctx, r.PP["ns"], r.PP["nameOrID"])
 		return output, err
 	},
+	Feature: fftypes.FeatureTokens,
 }
@@ -42,4 +42,5 @@ var getTokenPools = &oapispec.Route{
 	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		return filterResult(r.Or.Assets().GetTokenPools(r.Ctx, r.PP["ns"], r.Filter))
 	},
+	Feature: fftypes.FeatureTokens,
 }
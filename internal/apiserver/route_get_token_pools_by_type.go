@@ -44,4 +44,5 @@ var getTokenPoolsByType = &oapispec.Route{
 		return filterResult(r.Or.Assets().GetTokenPoolsByType(r.Ctx, r.PP["ns"], r.PP["type"], r.Filter))
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
@@ -52,4 +52,5 @@ var getTokenTransfers = &oapispec.Route{
 		}
 		return filterResult(r.Or.Assets().GetTokenTransfers(r.Ctx, r.PP["ns"], filter))
 	},
+	Feature: fftypes.FeatureTokens,
 }
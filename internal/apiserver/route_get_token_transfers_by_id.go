@@ -43,4 +43,5 @@ var getTokenTransferByID = &oapispec.Route{
 		output, err = r.Or.Assets().GetTokenTransferByID(r.Ctx, r.PP["ns"], r.PP["transferID"])
 		return output, err
 	},
+	Feature: fftypes.FeatureTokens,
 }
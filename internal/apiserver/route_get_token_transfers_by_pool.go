@@ -45,4 +45,5 @@ var getTokenTransfersByPool = &oapispec.Route{
 		return filterResult(r.Or.Assets().GetTokenTransfersByPool(r.Ctx, r.PP["ns"], r.PP["type"], r.PP["name"], r.Filter))
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
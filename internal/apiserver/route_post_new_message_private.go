@@ -128,4 +128,5 @@ var postNewMessagePrivate = &oapispec.Route{
 		output, err = r.Or.PrivateMessaging().SendMessage(r.Ctx, r.PP["ns"], r.Input.(*fftypes.MessageInOut), waitConfirm)
 		return output, err
 	},
+	Feature: fftypes.FeaturePrivateMessaging,
 }
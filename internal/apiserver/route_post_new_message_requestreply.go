@@ -44,4 +44,5 @@ var postNewMessageRequestReply = &oapispec.Route{
 		output, err = r.Or.RequestReply(r.Ctx, r.PP["ns"], r.Input.(*fftypes.MessageInOut))
 		return output, err
 	},
+	Feature: fftypes.FeatureSyncRequests,
 }
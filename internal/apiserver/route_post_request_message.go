@@ -45,4 +45,5 @@ var postRequestMessage = &oapispec.Route{
 		return output, err
 	},
 	Deprecated: true, // moving to more intutitive route/return structure
+	Feature:    fftypes.FeatureSyncRequests,
 }
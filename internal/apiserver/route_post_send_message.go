@@ -46,4 +46,5 @@ var postSendMessage = &oapispec.Route{
 		return output, err
 	},
 	Deprecated: true, // moving to more intutitive route/return structure
+	Feature:    fftypes.FeaturePrivateMessaging,
 }
@@ -47,4 +47,5 @@ var postTokenBurn = &oapispec.Route{
 		r.SuccessStatus = syncRetcode(waitConfirm)
 		return r.Or.Assets().BurnTokens(r.Ctx, r.PP["ns"], r.Input.(*fftypes.TokenTransferInput), waitConfirm)
 	},
+	Feature: fftypes.FeatureTokens,
 }
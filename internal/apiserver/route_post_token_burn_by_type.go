@@ -50,4 +50,5 @@ var postTokenBurnByType = &oapispec.Route{
 		return r.Or.Assets().BurnTokensByType(r.Ctx, r.PP["ns"], r.PP["type"], r.PP["name"], r.Input.(*fftypes.TokenTransferInput), waitConfirm)
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
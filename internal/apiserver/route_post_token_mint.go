@@ -47,4 +47,5 @@ var postTokenMint = &oapispec.Route{
 		r.SuccessStatus = syncRetcode(waitConfirm)
 		return r.Or.Assets().MintTokens(r.Ctx, r.PP["ns"], r.Input.(*fftypes.TokenTransferInput), waitConfirm)
 	},
+	Feature: fftypes.FeatureTokens,
 }
@@ -47,4 +47,5 @@ var postTokenPool = &oapispec.Route{
 		r.SuccessStatus = syncRetcode(waitConfirm)
 		return r.Or.Assets().CreateTokenPool(r.Ctx, r.PP["ns"], r.Input.(*fftypes.TokenPool), waitConfirm)
 	},
+	Feature: fftypes.FeatureTokens,
 }
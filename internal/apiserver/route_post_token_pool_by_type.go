@@ -49,4 +49,5 @@ var postTokenPoolByType = &oapispec.Route{
 		return r.Or.Assets().CreateTokenPoolByType(r.Ctx, r.PP["ns"], r.PP["type"], r.Input.(*fftypes.TokenPool), waitConfirm)
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
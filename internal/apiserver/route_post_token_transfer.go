@@ -47,4 +47,5 @@ var postTokenTransfer = &oapispec.Route{
 		r.SuccessStatus = syncRetcode(waitConfirm)
 		return r.Or.Assets().TransferTokens(r.Ctx, r.PP["ns"], r.Input.(*fftypes.TokenTransferInput), waitConfirm)
 	},
+	Feature: fftypes.FeatureTokens,
 }
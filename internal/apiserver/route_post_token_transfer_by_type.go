@@ -50,4 +50,5 @@ var postTokenTransferByType = &oapispec.Route{
 		return r.Or.Assets().TransferTokensByType(r.Ctx, r.PP["ns"], r.PP["type"], r.PP["name"], r.Input.(*fftypes.TokenTransferInput), waitConfirm)
 	},
 	Deprecated: true,
+	Feature:    fftypes.FeatureTokens,
 }
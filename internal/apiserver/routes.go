@@ -22,6 +22,8 @@ const emptyObjectSchema = `{"type": "object"}`
 
 var routes = []*oapispec.Route{
 	postNewDatatype,
+	postNewDelegation,
+	postNewIdentity,
 	postNewNamespace,
 	postNewMessageBroadcast,
 	postNewMessagePrivate,
@@ -29,40 +31,64 @@ var routes = []*oapispec.Route{
 	postNodesSelf,
 	postNewOrganization,
 	postNewOrganizationSelf,
+	postNewPseudonym,
+	postGroupKeyRotate,
+	postDelegationRevoke,
 
 	postBroadcastDatatype,
 	postBroadcastMessage,
 	postBroadcastNamespace,
 	postData,
+	postDataImport,
+	postLegalHold,
+	postLegalHoldRemove,
 	postNewSubscription,
+	postOpRetry,
 	postRegisterOrg,
 	postRegisterNode,
 	postRegisterNodeOrg,
 	postRequestMessage,
 	postSendMessage,
+	postVerifyMessageProof,
 
 	putSubscription,
 
 	deleteSubscription,
 
 	getBatchByID,
+	getBatchManifest,
 	getBatches,
+	getBlockchainEventByID,
+	getBlockchainEvents,
 	getData,
 	getDataBlob,
 	getDataByID,
 	getDatatypeByID,
 	getDatatypeByName,
 	getDatatypes,
+	getDelegationByID,
+	getDelegations,
 	getDataMsgs,
 	getEventByID,
 	getEvents,
+	getIdentities,
+	getIdentityByID,
+	getIdentityDIDDocument,
+	getIdentityUsage,
+	getLegalHoldByID,
+	getLegalHolds,
 	getMsgByID,
 	getMsgData,
 	getMsgEvents,
+	getMsgHistory,
 	getMsgOps,
+	getMsgProof,
+	getMsgReceipts,
+	getMsgTopics,
 	getMsgTxn,
 	getMsgs,
 	getNetworkOrg,
+	getNetworkOrgByName,
 	getNetworkOrgs,
 	getNetworkNode,
 	getNetworkNodes,
@@ -70,14 +96,25 @@ var routes = []*oapispec.Route{
 	getNamespaces,
 	getOpByID,
 	getOps,
+	getPseudonymAuthor,
+	getPseudonymByID,
+	getPseudonyms,
+	getSigningActivity,
+	getSigningKeyReport,
 	getStatus,
 	getSubscriptionByID,
 	getSubscriptions,
+	getTxnCostReport, // must be before getTxnByID
 	getTxnByID,
 	getTxnOps,
 	getTxns,
 
+	getChartActivity,
 	getChartHistogram,
+	getChartHistogramTypes,
+	getChartRollup,
+	getChartTopAuthors,
+	getChartTopTopics,
 
 	postTokenPool,
 	postTokenPoolByType,
@@ -86,6 +123,7 @@ var routes = []*oapispec.Route{
 	getTokenPoolByNameOrID,
 	getTokenPoolByName,
 	getTokenBalances,
+	getTokenNFTs,
 	getTokenAccounts,
 	getTokenAccountsByPool,
 	getTokenAccountPools,
@@ -98,5 +136,20 @@ var routes = []*oapispec.Route{
 	postTokenBurnByType,
 	postTokenTransfer,
 	postTokenTransferByType,
+	getTokenApprovals,
+	postTokenApproval,
+	getTokenBridges,
+	getTokenBridgeByID,
+	postTokenBridge,
 	getTokenConnectors,
+
+	postContractInterface,
+	getContractInterfaces,
+	getContractInterface,
+	getContractInterfaceByNameAndVersion,
+	postContractInvoke,
+	postContractQuery,
+	postContractListener,
+	getContractListeners,
+	getContractListenerByID,
 }
@@ -19,6 +19,7 @@ package apiserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -35,21 +36,30 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/gorilla/mux"
 
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/events/eifactory"
+	"github.com/hyperledger/firefly/internal/events/sse"
 	"github.com/hyperledger/firefly/internal/events/websockets"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/oapispec"
 	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/internal/quota"
+	"github.com/hyperledger/firefly/internal/syncasync"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/prometheus/client_golang/prometheus"
 	muxprom "gitlab.com/msvechla/mux-prometheus/pkg/middleware"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var ffcodeExtractor = regexp.MustCompile(`^(FF\d+):`)
 
+// errorTypePrefix is combined with the FF12345 error code to build the problem "type" URI
+const errorTypePrefix = "urn:hyperledger:firefly:error:"
+
 var (
 	adminConfigPrefix   = config.NewPluginConfig("admin")
 	apiConfigPrefix     = config.NewPluginConfig("http")
@@ -69,6 +79,7 @@ type apiServer struct {
 	apiTimeout         time.Duration
 	apiMaxTimeout      time.Duration
 	metricsEnabled     bool
+	auditEnabled       bool
 }
 
 func InitConfig() {
@@ -86,9 +97,15 @@ func NewAPIServer() Server {
 		apiTimeout:         config.GetDuration(config.APIRequestTimeout),
 		apiMaxTimeout:      config.GetDuration(config.APIRequestMaxTimeout),
 		metricsEnabled:     config.GetBool(config.MetricsEnabled),
+		auditEnabled:       config.GetBool(config.AuditEnabled),
 	}
 }
 
+// NewAPIHandler returns the HTTP handler for the API, without starting a listener - for use in-process
+func NewAPIHandler(ctx context.Context, o orchestrator.Orchestrator) http.Handler {
+	return NewAPIServer().(*apiServer).createMuxRouter(ctx, o)
+}
+
 // Serve is the main entry point for the API Server
 func (as *apiServer) Serve(ctx context.Context, o orchestrator.Orchestrator) (err error) {
 	httpErrChan := make(chan error)
@@ -201,7 +218,7 @@ func (as *apiServer) getParams(req *http.Request, route *oapispec.Route) (queryP
 
 func (as *apiServer) routeHandler(o orchestrator.Orchestrator, route *oapispec.Route) http.HandlerFunc {
 	// Check the mandatory parts are ok at startup time
-	return as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+	return as.auditRoute(o, route, as.apiWrapper(traceRoute(route, func(res http.ResponseWriter, req *http.Request) (int, error) {
 
 		var jsonInput interface{}
 		if route.JSONInputValue != nil {
@@ -221,7 +238,7 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, route *oapispec.R
 				defer multipart.close()
 			case strings.HasPrefix(strings.ToLower(contentType), "application/json"):
 				if jsonInput != nil {
-					err = json.NewDecoder(req.Body).Decode(&jsonInput)
+					err = as.decodeJSONInput(req, &jsonInput)
 				}
 			default:
 				return 415, i18n.NewError(req.Context(), i18n.MsgInvalidContentType)
@@ -231,23 +248,39 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, route *oapispec.R
 		var filter database.AndFilter
 		var status = 400 // if fail parsing input
 		var output interface{}
+		or := o
 		if err == nil {
 			queryParams, pathParams = as.getParams(req, route)
+			if ns := pathParams["ns"]; ns != "" {
+				// Correlate all logging for the request with the namespace
+				req = req.WithContext(log.WithLogField(req.Context(), "ns", ns))
+				// Namespaces with isolated plugins are served by their own orchestrator
+				or = o.ForNamespace(ns)
+			}
 			if route.FilterFactory != nil {
 				filter, err = as.buildFilter(req, route.FilterFactory)
 			}
 		}
 
+		if err == nil && route.Feature != "" {
+			err = o.CheckFeature(req.Context(), pathParams["ns"], route.Feature)
+		}
+
 		if err == nil {
 			r := &oapispec.APIRequest{
-				Ctx:           req.Context(),
-				Or:            o,
-				Req:           req,
-				PP:            pathParams,
-				QP:            queryParams,
-				Filter:        filter,
-				Input:         jsonInput,
-				SuccessStatus: http.StatusOK,
+				Ctx:             auth.WithIdentity(req.Context(), auth.RequestIdentity(req)),
+				Or:              or,
+				Req:             req,
+				PP:              pathParams,
+				QP:              queryParams,
+				Filter:          filter,
+				Input:           jsonInput,
+				SuccessStatus:   http.StatusOK,
+				ResponseHeaders: res.Header(),
+			}
+			if route.FilterFactory != nil {
+				// A page of a list can be slightly stale, so the database can avoid contending with writes to serve it
+				r.Ctx = database.WithHistoricalReads(r.Ctx)
 			}
 			if len(route.JSONOutputCodes) > 0 {
 				r.SuccessStatus = route.JSONOutputCodes[0]
@@ -274,7 +307,32 @@ func (as *apiServer) routeHandler(o orchestrator.Orchestrator, route *oapispec.R
 			status, err = as.handleOutput(req.Context(), res, status, output)
 		}
 		return status, err
-	})
+	})))
+}
+
+// traceRoute runs the handler for a route inside a span, continuing any trace propagated by the caller
+func traceRoute(route *oapispec.Route, handler func(res http.ResponseWriter, req *http.Request) (int, error)) func(res http.ResponseWriter, req *http.Request) (int, error) {
+	return func(res http.ResponseWriter, req *http.Request) (int, error) {
+		ctx, span := tracing.StartSpan(tracing.ExtractHeaders(req.Context(), req.Header), route.Name,
+			attribute.String("http.method", req.Method),
+			attribute.String("http.route", route.Path),
+		)
+		status, err := handler(res, req.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		tracing.EndSpan(span, err)
+		return status, err
+	}
+}
+
+func (as *apiServer) decodeJSONInput(req *http.Request, jsonInput *interface{}) error {
+	err := json.NewDecoder(req.Body).Decode(jsonInput)
+	if err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return i18n.WrapFieldError(req.Context(), typeErr.Field, err, i18n.MsgJSONDecodeFailed)
+		}
+		return i18n.WrapError(req.Context(), err, i18n.MsgJSONDecodeFailed)
+	}
+	return nil
 }
 
 func (as *apiServer) handleOutput(ctx context.Context, res http.ResponseWriter, status int, output interface{}) (int, error) {
@@ -298,7 +356,7 @@ func (as *apiServer) handleOutput(ctx context.Context, res http.ResponseWriter,
 		defer reader.Close()
 		res.Header().Add("Content-Type", "application/octet-stream")
 		res.WriteHeader(status)
-		_, marshalErr = io.Copy(res, reader)
+		_, marshalErr = io.Copy(&flushWriter{w: res}, reader)
 	default:
 		res.Header().Add("Content-Type", "application/json")
 		res.WriteHeader(status)
@@ -312,6 +370,20 @@ func (as *apiServer) handleOutput(ctx context.Context, res http.ResponseWriter,
 	return status, nil
 }
 
+// flushWriter flushes each chunk of a streamed response to the client as it is written,
+// so that long-lived streams (such as a log tail) are delivered in near real time
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
 func (as *apiServer) getTimeout(req *http.Request) time.Duration {
 	// Configure a server-side timeout on each request, to try and avoid cases where the API requester
 	// times out, and we continue to churn indefinitely processing the request.
@@ -335,13 +407,41 @@ func (as *apiServer) getTimeout(req *http.Request) time.Duration {
 	return reqTimeout
 }
 
+// getSyncTimeout returns the timeout requested with the "timeout" query parameter, for calls that
+// block until a confirmation is received (such as confirm=true). Returns zero if none was requested.
+func (as *apiServer) getSyncTimeout(ctx context.Context, req *http.Request) (time.Duration, error) {
+	syncTimeoutParam := req.URL.Query().Get("timeout")
+	if syncTimeoutParam == "" {
+		return 0, nil
+	}
+	syncTimeout, err := fftypes.ParseDurationString(syncTimeoutParam, time.Second /* default is seconds */)
+	if err != nil {
+		return 0, i18n.WrapError(ctx, err, i18n.MsgInvalidSyncTimeout, syncTimeoutParam)
+	}
+	if time.Duration(syncTimeout) > as.apiMaxTimeout {
+		return as.apiMaxTimeout, nil
+	}
+	return time.Duration(syncTimeout), nil
+}
+
 func (as *apiServer) apiWrapper(handler func(res http.ResponseWriter, req *http.Request) (status int, err error)) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 
-		reqTimeout := as.getTimeout(req)
-		ctx, cancel := context.WithTimeout(req.Context(), reqTimeout)
 		httpReqID := fftypes.ShortID()
-		ctx = log.WithLogField(ctx, "httpreq", httpReqID)
+		ctx := log.WithLogField(log.WithComponent(req.Context(), "apiserver"), "httpreq", httpReqID)
+		if acceptLang := req.Header.Get("Accept-Language"); acceptLang != "" {
+			ctx = i18n.WithLang(ctx, i18n.NegotiateLang(acceptLang))
+		}
+		reqTimeout := as.getTimeout(req)
+		syncTimeout, syncTimeoutErr := as.getSyncTimeout(ctx, req)
+		if syncTimeout > reqTimeout {
+			// The request must be allowed to live as long as the wait for confirmation
+			reqTimeout = syncTimeout
+		}
+		ctx, cancel := context.WithTimeout(ctx, reqTimeout)
+		if syncTimeout > 0 {
+			ctx = syncasync.WithTimeout(ctx, syncTimeout)
+		}
 		req = req.WithContext(ctx)
 		defer cancel()
 
@@ -349,17 +449,21 @@ func (as *apiServer) apiWrapper(handler func(res http.ResponseWriter, req *http.
 		l := log.L(ctx)
 		l.Infof("--> %s %s", req.Method, req.URL.Path)
 		startTime := time.Now()
-		status, err := handler(res, req)
+		var status int
+		var err error
+		if syncTimeoutErr != nil {
+			// An invalid timeout is rejected before the handler is invoked, rather than waiting without one
+			err = syncTimeoutErr
+		} else {
+			status, err = handler(res, req)
+		}
 		durationMS := float64(time.Since(startTime)) / float64(time.Millisecond)
 		if err != nil {
 
 			// Routers don't need to tweak the status code when sending errors.
 			// .. either the FF12345 error they raise is mapped to a status hint
-			ffcodeExtract := ffcodeExtractor.FindStringSubmatch(err.Error())
-			if len(ffcodeExtract) >= 2 {
-				if statusHint, ok := i18n.GetStatusHint(ffcodeExtract[1]); ok {
-					status = statusHint
-				}
+			if statusHint, ok := i18n.GetStatusHint(errorCode(err)); ok {
+				status = statusHint
 			}
 
 			// If the context is done, we wrap in 408
@@ -378,19 +482,55 @@ func (as *apiServer) apiWrapper(handler func(res http.ResponseWriter, req *http.
 				status = 500
 			}
 			l.Infof("<-- %s %s [%d] (%.2fms): %s", req.Method, req.URL.Path, status, durationMS, err)
-			res.Header().Add("Content-Type", "application/json")
+			var quotaErr *quota.ExceededError
+			if errors.As(err, &quotaErr) {
+				quotaErr.SetHeaders(res.Header())
+			}
+			res.Header().Set("Content-Type", fftypes.ProblemJSONContentType)
 			res.WriteHeader(status)
-			_ = json.NewEncoder(res).Encode(&fftypes.RESTError{
-				Error: err.Error(),
-			})
+			_ = json.NewEncoder(res).Encode(restErrorFor(req, status, err, httpReqID))
 		} else {
 			l.Infof("<-- %s %s [%d] (%.2fms)", req.Method, req.URL.Path, status, durationMS)
 		}
 	}
 }
 
+// errorCode returns the FF12345 code for an error, falling back to extracting it from
+// the error string for errors that have been passed through non-FireFly wrappers
+func errorCode(err error) string {
+	if code, ok := i18n.ErrorCode(err); ok {
+		return code
+	}
+	ffcodeExtract := ffcodeExtractor.FindStringSubmatch(err.Error())
+	if len(ffcodeExtract) >= 2 {
+		return ffcodeExtract[1]
+	}
+	return ""
+}
+
+// restErrorFor builds the RFC 7807 problem details returned for an error. The correlation ID
+// is the "httpreq" field logged against every line written while processing the request.
+func restErrorFor(req *http.Request, status int, err error, correlationID string) *fftypes.RESTError {
+	msg := err.Error()
+	restErr := &fftypes.RESTError{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        msg,
+		Instance:      req.URL.Path,
+		Error:         msg,
+		Field:         i18n.ErrorField(err),
+		CorrelationID: correlationID,
+	}
+	if code := errorCode(err); code != "" {
+		restErr.Type = errorTypePrefix + code
+		restErr.Code = code
+		restErr.Detail = strings.TrimPrefix(msg, code+": ")
+	}
+	return restErr
+}
+
 func (as *apiServer) notFoundHandler(res http.ResponseWriter, req *http.Request) (status int, err error) {
-	res.Header().Add("Content-Type", "application/json")
 	return 404, i18n.NewError(req.Context(), i18n.Msg404NotFound)
 }
 
@@ -435,6 +575,29 @@ func (as *apiServer) swaggerHandler(routes []*oapispec.Route, url string) func(r
 	}
 }
 
+func (as *apiServer) asyncAPIHandler(url string) func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+	return func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+		// Include the subscription options of the transports available to applications
+		transportOptions := make(map[string]string)
+		for _, name := range []string{"websockets", "webhooks"} {
+			plugin, _ := eifactory.GetPlugin(req.Context(), name)
+			transportOptions[name] = plugin.GetOptionsSchema(req.Context())
+		}
+		doc := oapispec.AsyncAPIGen(req.Context(), url, transportOptions)
+		vars := mux.Vars(req)
+		if vars["ext"] == ".json" {
+			res.Header().Add("Content-Type", "application/json")
+			b, _ := json.Marshal(&doc)
+			_, _ = res.Write(b)
+		} else {
+			res.Header().Add("Content-Type", "application/x-yaml")
+			b, _ := yaml.Marshal(&doc)
+			_, _ = res.Write(b)
+		}
+		return 200, nil
+	}
+}
+
 func (as *apiServer) configurePrometheusInstrumentation(namespace, subsystem string, r *mux.Router) {
 	if as.metricsEnabled {
 		instrumentation := muxprom.NewCustomInstrumentation(
@@ -449,9 +612,28 @@ func (as *apiServer) configurePrometheusInstrumentation(namespace, subsystem str
 	}
 }
 
+// standbyReadOnly rejects requests that could modify state while the node is a warm standby, as the
+// standby database is kept in sync from blockchain and data exchange inputs and must not diverge.
+// The admin API is not affected, as that is where the standby is promoted.
+func (as *apiServer) standbyReadOnly(o orchestrator.Orchestrator) mux.MiddlewareFunc {
+	rejectHandler := as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+		return http.StatusServiceUnavailable, i18n.NewError(req.Context(), i18n.MsgNodeStandby)
+	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			if req.Method != http.MethodGet && o.IsStandby() {
+				rejectHandler(res, req)
+				return
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
 func (as *apiServer) createMuxRouter(ctx context.Context, o orchestrator.Orchestrator) *mux.Router {
 	r := mux.NewRouter()
 	as.configurePrometheusInstrumentation("apiserver", "rest", r)
+	r.Use(as.standbyReadOnly(o))
 
 	for _, route := range routes {
 		if route.JSONHandler != nil {
@@ -459,13 +641,23 @@ func (as *apiServer) createMuxRouter(ctx context.Context, o orchestrator.Orchest
 				Methods(route.Method)
 		}
 	}
-	ws, _ := eifactory.GetPlugin(ctx, "websockets")
 	publicURL := as.getPublicURL(apiConfigPrefix, "")
 	r.HandleFunc(`/api/swagger{ext:\.yaml|\.json|}`, as.apiWrapper(as.swaggerHandler(routes, publicURL)))
+	r.HandleFunc(`/api/asyncapi{ext:\.yaml|\.json|}`, as.apiWrapper(as.asyncAPIHandler(publicURL)))
 	r.HandleFunc(`/api`, as.apiWrapper(as.swaggerUIHandler(publicURL)))
 	r.HandleFunc(`/favicon{any:.*}.png`, favIcons)
 
-	r.HandleFunc(`/ws`, ws.(*websockets.WebSockets).ServeHTTP)
+	r.HandleFunc(`/ws`, as.websocketsHandler(o))
+	r.HandleFunc(`/api/v1/namespaces/{ns}/ws`, as.websocketsHandler(o))
+	r.HandleFunc(`/api/v1/namespaces/{ns}/subscriptions/{id}/sse`, func(res http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		se, ok := o.ForNamespace(vars["ns"]).Events().GetTransport("sse").(*sse.SSE)
+		if !ok {
+			as.apiWrapper(as.notFoundHandler)(res, req)
+			return
+		}
+		se.ServeSubscription(res, req, vars["ns"], vars["id"])
+	}).Methods(http.MethodGet)
 
 	uiPath := config.GetString(config.UIPath)
 	if uiPath != "" && config.GetBool(config.UIEnabled) {
@@ -476,6 +668,23 @@ func (as *apiServer) createMuxRouter(ctx context.Context, o orchestrator.Orchest
 	return r
 }
 
+// websocketsHandler serves websocket connections from the transport of the events manager for the namespace in
+// the path, so applications using a namespace with isolated plugins connect to /api/v1/namespaces/{ns}/ws
+func (as *apiServer) websocketsHandler(o orchestrator.Orchestrator) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		or := o
+		if ns := mux.Vars(req)["ns"]; ns != "" {
+			or = o.ForNamespace(ns)
+		}
+		ws, ok := or.Events().GetTransport("websockets").(*websockets.WebSockets)
+		if !ok {
+			as.apiWrapper(as.notFoundHandler)(res, req)
+			return
+		}
+		ws.ServeHTTP(res, req)
+	}
+}
+
 func (as *apiServer) createAdminMuxRouter(o orchestrator.Orchestrator) *mux.Router {
 	r := mux.NewRouter()
 	as.configurePrometheusInstrumentation("apiserver", "admin", r)
@@ -0,0 +1,98 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// auditStatusWriter records the status code written to the response, so it can be audited
+type auditStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *auditStatusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *auditStatusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// auditPayloadHasher hashes the request payload as it is read by the handler, so the
+// payload does not need to be buffered in order to audit it
+type auditPayloadHasher struct {
+	hash hash.Hash
+	size int64
+}
+
+func (ph *auditPayloadHasher) Write(p []byte) (int, error) {
+	ph.size += int64(len(p))
+	return ph.hash.Write(p)
+}
+
+// auditRoute records every state-changing call to a route in the audit log, once the handler has completed.
+// Read-only (GET) calls are not audited.
+func (as *apiServer) auditRoute(o orchestrator.Orchestrator, route *oapispec.Route, handler http.HandlerFunc) http.HandlerFunc {
+	if !as.auditEnabled {
+		return handler
+	}
+	return func(res http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			handler(res, req)
+			return
+		}
+
+		record := &fftypes.AuditRecord{
+			ID:        fftypes.NewUUID(),
+			Namespace: mux.Vars(req)["ns"],
+			Identity:  auth.RequestIdentity(req),
+			Remote:    req.RemoteAddr,
+			Method:    req.Method,
+			Path:      req.URL.Path,
+			Route:     route.Name,
+			Created:   fftypes.Now(),
+		}
+		hasher := &auditPayloadHasher{hash: fftypes.NewDefaultHash()}
+		req.Body = ioutil.NopCloser(io.TeeReader(req.Body, hasher))
+		sw := &auditStatusWriter{ResponseWriter: res, status: http.StatusOK}
+
+		handler(sw, req)
+
+		record.Status = sw.status
+		if hasher.size > 0 {
+			record.PayloadHash = fftypes.HashResult(hasher.hash)
+		}
+		if err := o.RecordAudit(req.Context(), record); err != nil {
+			log.L(req.Context()).Errorf("Failed to record audit entry for %s %s: %s", req.Method, req.URL.Path, err)
+		}
+	}
+}
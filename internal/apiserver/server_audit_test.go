@@ -0,0 +1,103 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditPostWithPayload(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createAdminMuxRouter(mor)
+	body := []byte(`{"repair":true}`)
+	req := httptest.NewRequest("POST", "/admin/api/v1/consistency/check", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	mor.On("CheckConsistency", mock.Anything, &fftypes.ConsistencyCheckInput{Repair: true}).
+		Return(&fftypes.ConsistencyReport{Repair: true}, nil)
+	var expectedHash fftypes.Bytes32 = sha256.Sum256(body)
+	mor.On("RecordAudit", mock.Anything, mock.MatchedBy(func(record *fftypes.AuditRecord) bool {
+		return record.ID != nil &&
+			record.Namespace == "" &&
+			record.Method == "POST" &&
+			record.Path == "/admin/api/v1/consistency/check" &&
+			record.Route == "postConsistencyCheck" &&
+			record.Status == 200 &&
+			record.PayloadHash.Equals(&expectedHash) &&
+			record.Created != nil
+	})).Return(nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mor.AssertExpectations(t)
+}
+
+func TestAuditDeleteNoPayloadRecordFail(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createMuxRouter(context.Background(), mor)
+	req := httptest.NewRequest("DELETE", "/api/v1/namespaces/ns1/subscriptions/sub1", nil)
+	res := httptest.NewRecorder()
+
+	mor.On("DeleteSubscription", mock.Anything, "ns1", "sub1").Return(nil)
+	mor.On("RecordAudit", mock.Anything, mock.MatchedBy(func(record *fftypes.AuditRecord) bool {
+		return record.Namespace == "ns1" &&
+			record.Route == "deleteSubscription" &&
+			record.Status == 204 &&
+			record.PayloadHash == nil
+	})).Return(fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 204, res.Result().StatusCode)
+	mor.AssertExpectations(t)
+}
+
+func TestAuditGetNotRecorded(t *testing.T) {
+	mor, as := newTestServer()
+	as.auditEnabled = true
+	r := as.createAdminMuxRouter(mor)
+	req := httptest.NewRequest("GET", "/admin/api/v1/consistency/report", nil)
+	res := httptest.NewRecorder()
+
+	mor.On("GetConsistencyReport", mock.Anything).Return(&fftypes.ConsistencyReport{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mor.AssertNotCalled(t, "RecordAudit", mock.Anything, mock.Anything)
+}
+
+func TestAuditStatusWriterFlush(t *testing.T) {
+	res := httptest.NewRecorder()
+	sw := &auditStatusWriter{ResponseWriter: res}
+	sw.Flush()
+	assert.True(t, res.Flushed)
+
+	sw = &auditStatusWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}}
+	sw.Flush() // no-op when the writer cannot flush
+}
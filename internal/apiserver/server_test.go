@@ -24,17 +24,29 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/events/sse"
+	"github.com/hyperledger/firefly/internal/events/websockets"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/oapispec"
+	"github.com/hyperledger/firefly/internal/quota"
+	"github.com/hyperledger/firefly/internal/tracing"
+	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/mocks/eventsmocks"
 	"github.com/hyperledger/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const configDir = "../../test/data/config"
@@ -42,6 +54,9 @@ const configDir = "../../test/data/config"
 func newTestServer() (*orchestratormocks.Orchestrator, *apiServer) {
 	InitConfig()
 	mor := &orchestratormocks.Orchestrator{}
+	mor.On("CheckFeature", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mor.On("IsStandby").Return(false).Maybe()
+	mor.On("ForNamespace", mock.Anything).Return(mor).Maybe()
 	as := &apiServer{
 		apiTimeout: 5 * time.Second,
 	}
@@ -147,6 +162,32 @@ func TestJSONHTTPServePOST201(t *testing.T) {
 	assert.Equal(t, "value2", resJSON["output1"])
 }
 
+func TestJSONHTTPContinuesTrace(t *testing.T) {
+	mo, as := newTestServer()
+	err := tracing.Init(context.Background())
+	assert.NoError(t, err)
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "GET",
+		JSONInputValue:  nil,
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{200},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", trace.SpanContextFromContext(r.Ctx).TraceID().String())
+			return map[string]interface{}{}, nil
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("http://%s/test", s.Listener.Addr()), nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+}
+
 func TestJSONHTTPResponseEncodeFail(t *testing.T) {
 	mo, as := newTestServer()
 	handler := as.routeHandler(mo, &oapispec.Route{
@@ -197,6 +238,56 @@ func TestJSONHTTPNilResponseNon204(t *testing.T) {
 	assert.Regexp(t, "FF10143", resJSON["error"])
 }
 
+func TestStandbyReadOnly(t *testing.T) {
+	_, as := newTestServer()
+	mo := &orchestratormocks.Orchestrator{}
+	mo.On("IsStandby").Return(true)
+	r := as.createMuxRouter(context.Background(), mo)
+	mo.On("GetStatus", mock.Anything).Return(&fftypes.NodeStatus{}, nil)
+
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/messages/broadcast", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 503, res.Result().StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Regexp(t, "FF10422", resJSON["error"])
+
+	req = httptest.NewRequest("GET", "/api/v1/status", nil)
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestJSONHTTPFeatureDisabled(t *testing.T) {
+	_, as := newTestServer()
+	mo := &orchestratormocks.Orchestrator{}
+	mo.On("CheckFeature", mock.Anything, "", fftypes.FeatureTokens).Return(i18n.NewError(context.Background(), i18n.MsgFeatureDisabled, "tokens", "ns1"))
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "GET",
+		JSONInputValue:  nil,
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{200},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			assert.Fail(t, "should not be called")
+			return nil, nil
+		},
+		Feature: fftypes.FeatureTokens,
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/test", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Regexp(t, "FF10412", resJSON["error"])
+}
+
 func TestJSONHTTPDefault500Error(t *testing.T) {
 	mo, as := newTestServer()
 	handler := as.routeHandler(mo, &oapispec.Route{
@@ -220,6 +311,8 @@ func TestJSONHTTPDefault500Error(t *testing.T) {
 	var resJSON map[string]interface{}
 	json.NewDecoder(res.Body).Decode(&resJSON)
 	assert.Regexp(t, "pop", resJSON["error"])
+	assert.Equal(t, "about:blank", resJSON["type"])
+	assert.Nil(t, resJSON["code"])
 }
 
 func TestStatusCodeHintMapping(t *testing.T) {
@@ -247,6 +340,41 @@ func TestStatusCodeHintMapping(t *testing.T) {
 	assert.Regexp(t, "FF10107", resJSON["error"])
 }
 
+func TestQuotaExceededHeaders(t *testing.T) {
+	mo, as := newTestServer()
+	reset := time.Now().Add(30 * time.Second)
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "POST",
+		JSONInputValue:  nil,
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{200},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			return nil, &quota.ExceededError{
+				Err:       i18n.NewError(r.Ctx, i18n.MsgQuotaExceeded, "messagesPerMinute", 10, "ns1"),
+				Limit:     10,
+				Remaining: 0,
+				Reset:     reset,
+			}
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	b, _ := json.Marshal(map[string]interface{}{"input1": "value1"})
+	res, err := http.Post(fmt.Sprintf("http://%s/test", s.Listener.Addr()), "application/json", bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, 429, res.StatusCode)
+	assert.Equal(t, "10", res.Header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", res.Header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, strconv.FormatInt(reset.Unix(), 10), res.Header.Get("X-RateLimit-Reset"))
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Regexp(t, "FF10337", resJSON["error"])
+}
+
 func TestStatusInvalidContentType(t *testing.T) {
 	mo, as := newTestServer()
 	handler := as.routeHandler(mo, &oapispec.Route{
@@ -271,6 +399,36 @@ func TestStatusInvalidContentType(t *testing.T) {
 	assert.Regexp(t, "FF10130", resJSON["error"])
 }
 
+func TestNewAPIHandler(t *testing.T) {
+	mo, _ := newTestServer()
+	mo.On("GetNamespaces", mock.Anything, mock.Anything).Return([]*fftypes.Namespace{}, nil, nil)
+	handler := NewAPIHandler(context.Background(), mo)
+
+	req := httptest.NewRequest("GET", "/api/v1/namespaces", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestListRouteHistoricalReads(t *testing.T) {
+	mo, r := newTestAPIServer()
+	mo.On("GetNamespaces", mock.MatchedBy(func(ctx context.Context) bool {
+		return database.HistoricalReadClause(ctx, func() string { return "AS OF SYSTEM TIME" }) != ""
+	}), mock.Anything).Return([]*fftypes.Namespace{}, nil, nil)
+	mo.On("GetNamespace", mock.MatchedBy(func(ctx context.Context) bool {
+		return database.HistoricalReadClause(ctx, func() string { return "AS OF SYSTEM TIME" }) == ""
+	}), "ns1").Return(&fftypes.Namespace{}, nil)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/api/v1/namespaces", nil))
+	assert.Equal(t, 200, res.Result().StatusCode)
+
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest("GET", "/api/v1/namespaces/ns1", nil))
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mo.AssertExpectations(t)
+}
+
 func TestNotFound(t *testing.T) {
 	_, as := newTestServer()
 	handler := as.apiWrapper(as.notFoundHandler)
@@ -280,9 +438,96 @@ func TestNotFound(t *testing.T) {
 	res, err := http.Get(fmt.Sprintf("http://%s/test", s.Listener.Addr()))
 	assert.NoError(t, err)
 	assert.Equal(t, 404, res.StatusCode)
+	assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
 	var resJSON map[string]interface{}
 	json.NewDecoder(res.Body).Decode(&resJSON)
 	assert.Regexp(t, "FF10109", resJSON["error"])
+	assert.Equal(t, "FF10109", resJSON["code"])
+	assert.Equal(t, "urn:hyperledger:firefly:error:FF10109", resJSON["type"])
+	assert.Equal(t, "Not Found", resJSON["title"])
+	assert.Equal(t, float64(404), resJSON["status"])
+	assert.Equal(t, "Not found", resJSON["detail"])
+	assert.Equal(t, "/test", resJSON["instance"])
+	assert.NotEmpty(t, resJSON["correlationId"])
+}
+
+func TestNotFoundAcceptLanguage(t *testing.T) {
+	err := i18n.LoadCatalog("fr", map[string]string{string(i18n.Msg404NotFound): "Introuvable"})
+	assert.NoError(t, err)
+	_, as := newTestServer()
+	handler := as.apiWrapper(as.notFoundHandler)
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/test", s.Listener.Addr()), nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.8")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, "FF10109: Introuvable", resJSON["error"])
+}
+
+func TestJSONHTTPFieldError(t *testing.T) {
+	mo, as := newTestServer()
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "POST",
+		JSONInputValue:  func() interface{} { return make(map[string]interface{}) },
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{201},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			return nil, i18n.NewFieldError(r.Ctx, "header.tag", i18n.MsgInvalidName, "header.tag")
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Post(fmt.Sprintf("http://%s/test", s.Listener.Addr()), "application/json", bytes.NewReader([]byte(`{}`)))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, "FF10131", resJSON["code"])
+	assert.Equal(t, "header.tag", resJSON["field"])
+}
+
+func TestJSONHTTPDecodeTypeError(t *testing.T) {
+	mo, as := newTestServer()
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "POST",
+		JSONInputValue:  func() interface{} { return &fftypes.Namespace{} },
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{201},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			return nil, nil
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Post(fmt.Sprintf("http://%s/test", s.Listener.Addr()), "application/json", bytes.NewReader([]byte(`{"name":12345}`)))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, "FF10103", resJSON["code"])
+	assert.Equal(t, "name", resJSON["field"])
+
+	res, err = http.Post(fmt.Sprintf("http://%s/test", s.Listener.Addr()), "application/json", bytes.NewReader([]byte(`!json`)))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Equal(t, "FF10103", resJSON["code"])
+}
+
+func TestErrorCodeFromString(t *testing.T) {
+	assert.Equal(t, "FF10109", errorCode(fmt.Errorf("FF10109: Not found")))
+	assert.Equal(t, "", errorCode(fmt.Errorf("pop")))
 }
 
 func TestTimeout(t *testing.T) {
@@ -390,6 +635,114 @@ func TestSwaggerAdminJSON(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAsyncAPIYAML(t *testing.T) {
+	_, r := newTestAPIServer()
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/asyncapi.yaml", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	var doc oapispec.AsyncAPI
+	err = yaml.Unmarshal(b, &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2.0", doc.AsyncAPI)
+	assert.NotNil(t, doc.Components.Schemas["webhooksOptions"])
+}
+
+func TestAsyncAPIJSON(t *testing.T) {
+	_, r := newTestAPIServer()
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/asyncapi.json", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	var doc oapispec.AsyncAPI
+	err = json.Unmarshal(b, &doc)
+	assert.NoError(t, err)
+	assert.Contains(t, doc.Channels, "/ws")
+}
+
+func TestRouteIsolatedNamespace(t *testing.T) {
+	_, as := newTestServer()
+	mor := &orchestratormocks.Orchestrator{}
+	morNS := &orchestratormocks.Orchestrator{}
+	mor.On("IsStandby").Return(false).Maybe()
+	mor.On("ForNamespace", "ns2").Return(morNS)
+	morNS.On("GetNamespace", mock.Anything, "ns2").Return(&fftypes.Namespace{Name: "ns2"}, nil)
+	r := as.createMuxRouter(context.Background(), mor)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/v1/namespaces/ns2", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	mor.AssertExpectations(t)
+	morNS.AssertExpectations(t)
+}
+
+func TestSSESubscriptionNotInitialized(t *testing.T) {
+	mor, r := newTestAPIServer()
+	mem := &eventmocks.EventManager{}
+	mor.On("Events").Return(mem)
+	mem.On("GetTransport", "sse").Return(&sse.SSE{})
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/v1/namespaces/ns1/subscriptions/%s/sse", s.Listener.Addr(), fftypes.NewUUID()))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+}
+
+func TestSSESubscriptionTransportNotEnabled(t *testing.T) {
+	mor, r := newTestAPIServer()
+	mem := &eventmocks.EventManager{}
+	mor.On("Events").Return(mem)
+	mem.On("GetTransport", "sse").Return(nil)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/v1/namespaces/ns1/subscriptions/%s/sse", s.Listener.Addr(), fftypes.NewUUID()))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+}
+
+func TestWebSocketsNotUpgraded(t *testing.T) {
+	mor, r := newTestAPIServer()
+	mem := &eventmocks.EventManager{}
+	mor.On("Events").Return(mem)
+	ws := &websockets.WebSockets{}
+	prefix := config.NewPluginConfig("ut.websockets")
+	ws.InitPrefix(prefix)
+	err := ws.Init(context.Background(), prefix, &eventsmocks.Callbacks{})
+	assert.NoError(t, err)
+	mem.On("GetTransport", "websockets").Return(ws)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/ws", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	mor.AssertNotCalled(t, "ForNamespace", mock.Anything)
+}
+
+func TestWebSocketsNamespaceNotEnabled(t *testing.T) {
+	mor, r := newTestAPIServer()
+	mem := &eventmocks.EventManager{}
+	mor.On("Events").Return(mem)
+	mem.On("GetTransport", "websockets").Return(nil)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/v1/namespaces/ns1/ws", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+	mor.AssertCalled(t, "ForNamespace", "ns1")
+}
+
 func TestWaitForServerStop(t *testing.T) {
 
 	chl1 := make(chan error, 1)
@@ -419,3 +772,75 @@ func TestGetTimeoutMax(t *testing.T) {
 	timeout := as.getTimeout(req)
 	assert.Equal(t, 1*time.Second, timeout)
 }
+
+func TestSyncTimeoutQueryParam(t *testing.T) {
+	mo, as := newTestServer()
+	as.apiMaxTimeout = 1 * time.Minute
+	handler := as.routeHandler(mo, &oapispec.Route{
+		Name:            "testRoute",
+		Path:            "/test",
+		Method:          "POST",
+		JSONInputValue:  nil,
+		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
+		JSONOutputCodes: []int{204},
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+			// The request deadline is extended beyond the Request-Timeout header to cover the sync timeout
+			deadline, ok := r.Ctx.Deadline()
+			assert.True(t, ok)
+			assert.Greater(t, time.Until(deadline), 10*time.Second)
+			return nil, nil
+		},
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s/test?confirm=true&timeout=30s", s.Listener.Addr()), bytes.NewReader([]byte(``)))
+	assert.NoError(t, err)
+	req.Header.Set("Request-Timeout", "1s")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 204, res.StatusCode)
+}
+
+func TestGetSyncTimeout(t *testing.T) {
+	_, as := newTestServer()
+	as.apiMaxTimeout = 1 * time.Minute
+
+	ctx := context.Background()
+	req, _ := http.NewRequest("GET", "http://test.example.com?timeout=1h", nil)
+	syncTimeout, err := as.getSyncTimeout(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1*time.Minute, syncTimeout)
+
+	req, _ = http.NewRequest("GET", "http://test.example.com?timeout=10", nil)
+	syncTimeout, err = as.getSyncTimeout(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, syncTimeout)
+
+	req, _ = http.NewRequest("GET", "http://test.example.com?timeout=!bad", nil)
+	_, err = as.getSyncTimeout(ctx, req)
+	assert.Regexp(t, "FF10458.*!bad", err)
+
+	req, _ = http.NewRequest("GET", "http://test.example.com", nil)
+	syncTimeout, err = as.getSyncTimeout(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), syncTimeout)
+}
+
+func TestSyncTimeoutInvalid(t *testing.T) {
+	_, as := newTestServer()
+	called := false
+	handler := as.apiWrapper(func(res http.ResponseWriter, req *http.Request) (status int, err error) {
+		called = true
+		return 204, nil
+	})
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/test?confirm=true&timeout=!bad", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+	var resJSON map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&resJSON)
+	assert.Regexp(t, "FF10458", resJSON["error"])
+	assert.False(t, called)
+}
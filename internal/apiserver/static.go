@@ -65,10 +65,9 @@ func (h staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// if we got an error (that wasn't that the file doesn't exist) stating the
 		// file, return a 500 internal server error and stop
 		log.L(r.Context()).Errorf("Failed to serve file: %s", err)
+		w.Header().Set("Content-Type", fftypes.ProblemJSONContentType)
 		w.WriteHeader(500)
-		_ = json.NewEncoder(w).Encode(&fftypes.RESTError{
-			Error: i18n.ExpandWithCode(r.Context(), i18n.MsgAPIServerStaticFail),
-		})
+		_ = json.NewEncoder(w).Encode(restErrorFor(r, 500, i18n.NewError(r.Context(), i18n.MsgAPIServerStaticFail), ""))
 		return
 	}
 
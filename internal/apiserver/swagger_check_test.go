@@ -60,3 +60,26 @@ func TestDiffSwaggerYAML(t *testing.T) {
 
 	assert.Equal(t, actualSwaggerHash.Sum(nil), expectedSwaggerHash.Sum(nil), "The swagger generated by the code did not match the swagger.yml file in git. Did you forget to run `make swagger`?")
 }
+
+func TestDiffAsyncAPIYAML(t *testing.T) {
+	as := &apiServer{}
+	handler := as.apiWrapper(as.asyncAPIHandler("http://localhost:12345"))
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/asyncapi.yaml", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+
+	actualAsyncAPIHash := sha1.New()
+	actualAsyncAPIHash.Write(b)
+
+	existingAsyncAPIBytes, err := os.ReadFile(filepath.Join("..", "..", "docs", "swagger", "asyncapi.yaml"))
+	assert.NoError(t, err)
+
+	expectedAsyncAPIHash := sha1.New()
+	expectedAsyncAPIHash.Write(existingAsyncAPIBytes)
+
+	assert.Equal(t, actualAsyncAPIHash.Sum(nil), expectedAsyncAPIHash.Sum(nil), "The AsyncAPI document generated by the code did not match the asyncapi.yaml file in git. Did you forget to run `make swagger`?")
+}
@@ -49,3 +49,17 @@ func TestDownloadSwaggerYAML(t *testing.T) {
 	err = os.WriteFile(filepath.Join("..", "..", "docs", "swagger", "swagger.yaml"), b, 0644)
 	assert.NoError(t, err)
 }
+
+func TestDownloadAsyncAPIYAML(t *testing.T) {
+	as := &apiServer{}
+	handler := as.apiWrapper(as.asyncAPIHandler("http://localhost:12345"))
+	s := httptest.NewServer(http.HandlerFunc(handler))
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/api/asyncapi.yaml", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	err = os.WriteFile(filepath.Join("..", "..", "docs", "swagger", "asyncapi.yaml"), b, 0644)
+	assert.NoError(t, err)
+}
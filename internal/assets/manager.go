@@ -18,6 +18,7 @@ package assets
 
 import (
 	"context"
+	"database/sql/driver"
 
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/config"
@@ -42,9 +43,12 @@ type Manager interface {
 	GetTokenPoolByNameOrID(ctx context.Context, ns string, poolNameOrID string) (*fftypes.TokenPool, error)
 
 	GetTokenBalances(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error)
+	GetTokenBalancesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error)
 	GetTokenAccounts(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenAccount, *database.FilterResult, error)
 	GetTokenAccountPools(ctx context.Context, ns, key string, filter database.AndFilter) ([]*fftypes.TokenAccountPool, *database.FilterResult, error)
 
+	GetTokenNFTs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenNFT, *database.FilterResult, error)
+
 	GetTokenTransfers(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenTransfer, *database.FilterResult, error)
 	GetTokenTransferByID(ctx context.Context, ns, id string) (*fftypes.TokenTransfer, error)
 
@@ -53,8 +57,19 @@ type Manager interface {
 	BurnTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error)
 	TransferTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error)
 
+	TokenApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput, waitConfirm bool) (*fftypes.TokenApproval, error)
+	GetTokenApprovals(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenApproval, *database.FilterResult, error)
+
+	BridgeTokens(ctx context.Context, ns string, bridge *fftypes.TokenBridgeInput) (*fftypes.TokenBridge, error)
+	GetTokenBridges(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBridge, *database.FilterResult, error)
+	GetTokenBridgeByID(ctx context.Context, ns, id string) (*fftypes.TokenBridge, error)
+	TokenBridgeOpUpdate(ctx context.Context, op *fftypes.Operation, txState fftypes.OpStatus, errorMessage string) error
+
 	GetTokenConnectors(ctx context.Context, ns string) ([]*fftypes.TokenConnector, error)
 
+	// RetryOperation resubmits the transfer of a failed token_transfer operation to the connector
+	RetryOperation(ctx context.Context, op *fftypes.Operation) error
+
 	// Deprecated
 	CreateTokenPoolByType(ctx context.Context, ns, connector string, pool *fftypes.TokenPool, waitConfirm bool) (*fftypes.TokenPool, error)
 	GetTokenPoolsByType(ctx context.Context, ns, connector string, filter database.AndFilter) ([]*fftypes.TokenPool, *database.FilterResult, error)
@@ -79,10 +94,11 @@ type assetManager struct {
 	tokens    map[string]tokens.Plugin
 	retry     retry.Retry
 	txhelper  txcommon.Helper
+	preflight txcommon.PreflightChecker
 }
 
-func NewAssetManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, sa syncasync.Bridge, bm broadcast.Manager, pm privatemessaging.Manager, ti map[string]tokens.Plugin) (Manager, error) {
-	if di == nil || im == nil || sa == nil || bm == nil || pm == nil || ti == nil {
+func NewAssetManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, sa syncasync.Bridge, bm broadcast.Manager, pm privatemessaging.Manager, ti map[string]tokens.Plugin, pf txcommon.PreflightChecker) (Manager, error) {
+	if di == nil || im == nil || sa == nil || bm == nil || pm == nil || ti == nil || pf == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	am := &assetManager{
@@ -99,7 +115,8 @@ func NewAssetManager(ctx context.Context, di database.Plugin, im identity.Manage
 			MaximumDelay: config.GetDuration(config.AssetManagerRetryMaxDelay),
 			Factor:       config.GetFloat64(config.AssetManagerRetryFactor),
 		},
-		txhelper: txcommon.NewTransactionHelper(di),
+		txhelper:  txcommon.NewTransactionHelper(di),
+		preflight: pf,
 	}
 	return am, nil
 }
@@ -121,6 +138,67 @@ func (am *assetManager) GetTokenBalances(ctx context.Context, ns string, filter
 	return am.database.GetTokenBalances(ctx, am.scopeNS(ns, filter))
 }
 
+// tokenBalanceHistoricFields are the fields of a balance that change over time, so cannot be used to
+// filter or sort a query for the balances at a point in history
+var tokenBalanceHistoricFields = map[string]bool{"balance": true, "updated": true}
+
+// GetTokenBalancesAsOf returns the balances matching the filter as they were at a point in history,
+// by unwinding the transfers recorded since then from the current balances. The filter selects the
+// same rows as for the current balances (with a zero balance for an account that received the token
+// later), so it cannot use the balance or updated fields, which are only known for the current state.
+func (am *assetManager) GetTokenBalancesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
+	fi, err := filter.Finalize()
+	if err != nil {
+		return nil, nil, err
+	}
+	if field := fi.FieldIn(tokenBalanceHistoricFields); field != "" {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgAsOfHistoricField, field)
+	}
+	balances, fr, err := am.GetTokenBalances(ctx, ns, filter)
+	if err != nil || len(balances) == 0 {
+		return balances, fr, err
+	}
+
+	// Only the transfers that affect the balances on this page are unwound
+	byIdentifier := make(map[string]*fftypes.TokenBalance, len(balances))
+	pools := []driver.Value{}
+	keys := []driver.Value{}
+	seenPools := map[fftypes.UUID]bool{}
+	seenKeys := map[string]bool{}
+	for _, balance := range balances {
+		byIdentifier[balance.Identifier()] = balance
+		if !seenPools[*balance.Pool] {
+			seenPools[*balance.Pool] = true
+			pools = append(pools, balance.Pool)
+		}
+		if !seenKeys[balance.Key] {
+			seenKeys[balance.Key] = true
+			keys = append(keys, balance.Key)
+		}
+	}
+	fb := database.TokenTransferQueryFactory.NewFilter(ctx)
+	transfers, _, err := am.database.GetTokenTransfers(ctx, fb.And(
+		fb.Eq("namespace", ns),
+		fb.In("pool", pools),
+		fb.Or(fb.In("from", keys), fb.In("to", keys)),
+		fb.Gt("created", asOf),
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, transfer := range transfers {
+		if balance, ok := byIdentifier[fftypes.TokenBalanceIdentifier(transfer.Pool, transfer.TokenIndex, transfer.To)]; ok && transfer.To != "" {
+			balance.Balance.Int().Sub(balance.Balance.Int(), transfer.Amount.Int())
+			balance.Updated = nil
+		}
+		if balance, ok := byIdentifier[fftypes.TokenBalanceIdentifier(transfer.Pool, transfer.TokenIndex, transfer.From)]; ok && transfer.From != "" {
+			balance.Balance.Int().Add(balance.Balance.Int(), transfer.Amount.Int())
+			balance.Updated = nil
+		}
+	}
+	return balances, fr, nil
+}
+
 func (am *assetManager) GetTokenBalancesByPool(ctx context.Context, ns, connector, poolName string, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
 	pool, err := am.GetTokenPool(ctx, ns, connector, poolName)
 	if err != nil {
@@ -129,6 +207,10 @@ func (am *assetManager) GetTokenBalancesByPool(ctx context.Context, ns, connecto
 	return am.database.GetTokenBalances(ctx, filter.Condition(filter.Builder().Eq("pool", pool.ID)))
 }
 
+func (am *assetManager) GetTokenNFTs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenNFT, *database.FilterResult, error) {
+	return am.database.GetTokenNFTs(ctx, am.scopeNS(ns, filter))
+}
+
 func (am *assetManager) GetTokenAccounts(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenAccount, *database.FilterResult, error) {
 	return am.database.GetTokenAccounts(ctx, am.scopeNS(ns, filter))
 }
@@ -169,7 +251,7 @@ func (am *assetManager) getTokenConnectorName(ctx context.Context, ns string) (s
 		return "", err
 	}
 	if len(tokenConnectors) != 1 {
-		return "", i18n.NewError(ctx, i18n.MsgFieldNotSpecified, "connector")
+		return "", i18n.NewFieldError(ctx, "connector", i18n.MsgFieldNotSpecified, "connector")
 	}
 	return tokenConnectors[0].Name, nil
 }
@@ -182,7 +264,7 @@ func (am *assetManager) getTokenPoolName(ctx context.Context, ns string) (string
 		return "", err
 	}
 	if *fr.TotalCount != 1 {
-		return "", i18n.NewError(ctx, i18n.MsgFieldNotSpecified, "pool")
+		return "", i18n.NewFieldError(ctx, "pool", i18n.MsgFieldNotSpecified, "pool")
 	}
 	return tokenPools[0].Name, nil
 }
@@ -17,6 +17,7 @@ package assets
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
@@ -27,6 +28,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/tokens"
@@ -43,9 +45,12 @@ func newTestAssets(t *testing.T) (*assetManager, func()) {
 	mbm := &broadcastmocks.Manager{}
 	mpm := &privatemessagingmocks.Manager{}
 	mti := &tokenmocks.Plugin{}
+	mpf := &txcommonmocks.PreflightChecker{}
 	mti.On("Name").Return("ut_tokens").Maybe()
+	mpf.On("CheckBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mpf.On("CheckPolicy", mock.Anything, mock.Anything).Return(nil).Maybe()
 	ctx, cancel := context.WithCancel(context.Background())
-	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, map[string]tokens.Plugin{"magic-tokens": mti})
+	a, err := NewAssetManager(ctx, mdi, mim, mdm, msa, mbm, mpm, map[string]tokens.Plugin{"magic-tokens": mti}, mpf)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
@@ -55,7 +60,7 @@ func newTestAssets(t *testing.T) (*assetManager, func()) {
 }
 
 func TestInitFail(t *testing.T) {
-	_, err := NewAssetManager(context.Background(), nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewAssetManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
@@ -79,6 +84,119 @@ func TestGetTokenBalances(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetTokenNFTs(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenNFTQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenNFTs", context.Background(), f).Return([]*fftypes.TokenNFT{}, nil, nil)
+	_, _, err := am.GetTokenNFTs(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetTokenBalancesAsOf(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	pool := fftypes.NewUUID()
+	asOf := fftypes.Now()
+	balances := []*fftypes.TokenBalance{
+		{Pool: pool, TokenIndex: "1", Key: "0x1", Balance: *fftypes.NewBigInt(100), Updated: fftypes.Now()},
+		{Pool: pool, TokenIndex: "1", Key: "0x2", Balance: *fftypes.NewBigInt(50), Updated: fftypes.Now()},
+		{Pool: pool, TokenIndex: "2", Key: "0x1", Balance: *fftypes.NewBigInt(1), Updated: fftypes.Now()},
+	}
+	transfers := []*fftypes.TokenTransfer{
+		{Type: fftypes.TokenTransferTypeTransfer, Pool: pool, TokenIndex: "1", From: "0x1", To: "0x2", Amount: *fftypes.NewBigInt(10)},
+		{Type: fftypes.TokenTransferTypeMint, Pool: pool, TokenIndex: "1", To: "0x1", Amount: *fftypes.NewBigInt(5)},
+		{Type: fftypes.TokenTransferTypeBurn, Pool: pool, TokenIndex: "1", From: "0x2", Amount: *fftypes.NewBigInt(3)},
+		{Type: fftypes.TokenTransferTypeTransfer, Pool: pool, TokenIndex: "1", From: "0x1", To: "0x3", Amount: *fftypes.NewBigInt(7)},
+	}
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenBalances", context.Background(), f).Return(balances, nil, nil)
+	mdi.On("GetTokenTransfers", context.Background(), mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return strings.HasPrefix(fi.String(), "( namespace == 'ns1' ) && ( pool IN ['"+pool.String()+"'] ) && "+
+			"( ( from IN ['0x1','0x2'] ) || ( to IN ['0x1','0x2'] ) ) && ( created > ")
+	})).Return(transfers, nil, nil)
+	res, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", asOf, f)
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, int64(112), res[0].Balance.Int().Int64())
+	assert.Nil(t, res[0].Updated)
+	assert.Equal(t, int64(43), res[1].Balance.Int().Int64())
+	assert.Nil(t, res[1].Updated)
+	assert.Equal(t, int64(1), res[2].Balance.Int().Int64())
+	assert.NotNil(t, res[2].Updated)
+}
+
+func TestGetTokenBalancesAsOfFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenBalances", context.Background(), f).Return(nil, nil, fmt.Errorf("pop"))
+	_, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), f)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetTokenBalancesAsOfTransfersFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenBalances", context.Background(), f).Return([]*fftypes.TokenBalance{
+		{Pool: fftypes.NewUUID(), Key: "0x1", Balance: *fftypes.NewBigInt(1)},
+	}, nil, nil)
+	mdi.On("GetTokenTransfers", context.Background(), mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), f)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetTokenBalancesAsOfEmpty(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("key", "0x1"))
+	mdi.On("GetTokenBalances", context.Background(), f).Return([]*fftypes.TokenBalance{}, nil, nil)
+	res, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), f)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+	mdi.AssertNotCalled(t, "GetTokenTransfers", mock.Anything, mock.Anything)
+}
+
+func TestGetTokenBalancesAsOfHistoricFilter(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	_, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), fb.And(fb.Gt("balance", 10)))
+	assert.Regexp(t, "FF10457.*balance", err)
+
+	f := fb.And(fb.Eq("key", "0x1"))
+	f.Sort("updated")
+	_, _, err = am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), f)
+	assert.Regexp(t, "FF10457.*updated", err)
+}
+
+func TestGetTokenBalancesAsOfBadFilter(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	fb := database.TokenBalanceQueryFactory.NewFilter(context.Background())
+	_, _, err := am.GetTokenBalancesAsOf(context.Background(), "ns1", fftypes.Now(), fb.And(fb.Eq("wrong", "0x1")))
+	assert.Regexp(t, "FF10148", err)
+}
+
 func TestGetTokenBalancesByPool(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -0,0 +1,164 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (am *assetManager) GetTokenApprovals(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenApproval, *database.FilterResult, error) {
+	return am.database.GetTokenApprovals(ctx, am.scopeNS(ns, filter))
+}
+
+func (am *assetManager) validateApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput) error {
+	if approval.Connector == "" {
+		connector, err := am.getTokenConnectorName(ctx, ns)
+		if err != nil {
+			return err
+		}
+		approval.Connector = connector
+	}
+	if approval.Pool == "" {
+		pool, err := am.getTokenPoolName(ctx, ns)
+		if err != nil {
+			return err
+		}
+		approval.Pool = pool
+	}
+	if approval.Key == "" {
+		org, err := am.identity.GetLocalOrganization(ctx)
+		if err != nil {
+			return err
+		}
+		approval.Key = org.Identity
+	}
+	if approval.Operator == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "operator")
+	}
+	return nil
+}
+
+// TokenApproval grants (or revokes) the right of an operator to transfer tokens from the pool on behalf of the signing key
+func (am *assetManager) TokenApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput, waitConfirm bool) (out *fftypes.TokenApproval, err error) {
+	if err := am.validateApproval(ctx, ns, approval); err != nil {
+		return nil, err
+	}
+	approval.LocalID = fftypes.NewUUID()
+	approval.Namespace = ns
+
+	if waitConfirm {
+		return am.syncasync.WaitForTokenApproval(ctx, ns, approval.LocalID, func(ctx context.Context) error {
+			return am.sendApproval(ctx, ns, approval)
+		})
+	}
+	return &approval.TokenApproval, am.sendApproval(ctx, ns, approval)
+}
+
+func (am *assetManager) sendApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput) error {
+	plugin, err := am.selectTokenPlugin(ctx, approval.Connector)
+	if err != nil {
+		return err
+	}
+
+	if err := am.preflight.CheckBalance(ctx, ns, approval.Key, approval.LocalID); err != nil {
+		return err
+	}
+	if err := am.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenApproval,
+		Namespace:  ns,
+		SigningKey: approval.Key,
+		Reference:  approval.LocalID,
+		Input: fftypes.JSONObject{
+			"connector": approval.Connector,
+			"pool":      approval.Pool,
+			"operator":  approval.Operator,
+			"approved":  approval.Approved,
+		},
+	}); err != nil {
+		return err
+	}
+
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Namespace: ns,
+			Type:      fftypes.TransactionTypeTokenApproval,
+			Signer:    approval.Key,
+			Reference: approval.LocalID,
+		},
+		Created: fftypes.Now(),
+		Status:  fftypes.OpStatusPending,
+	}
+	tx.Hash = tx.Subject.Hash()
+	approval.TX.ID = tx.ID
+	approval.TX.Type = tx.Subject.Type
+
+	op := fftypes.NewTXOperation(
+		plugin,
+		ns,
+		tx.ID,
+		"",
+		fftypes.OpTypeTokenApproval,
+		fftypes.OpStatusPending)
+	txcommon.AddTokenApprovalInputs(op, &approval.TokenApproval)
+
+	var pool *fftypes.TokenPool
+	err = am.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		pool, err = am.GetTokenPoolByNameOrID(ctx, ns, approval.Pool)
+		if err != nil {
+			return err
+		}
+		if pool.State != fftypes.TokenPoolStateConfirmed {
+			return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
+		}
+		approval.TokenApproval.Pool = pool.ID
+
+		if err = am.database.UpsertTransaction(ctx, tx, false /* should be new, or idempotent replay */); err != nil {
+			return err
+		}
+		if err = am.database.InsertOperation(ctx, op); err != nil {
+			return err
+		}
+		return am.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, approval.Key))
+	})
+	if err != nil {
+		return err
+	}
+
+	// if transaction fails, mark tx and op as failed in DB
+	if err = plugin.TokensApproval(ctx, op.ID, pool.ProtocolID, &approval.TokenApproval); err != nil {
+		_ = am.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+			l := log.L(ctx)
+			update := database.OperationQueryFactory.NewUpdate(ctx).
+				Set("status", fftypes.OpStatusFailed)
+			if err = am.database.UpdateTransaction(ctx, tx.ID, update); err != nil {
+				l.Errorf("TX update failed: %s update=[ %s ]", err, update)
+			}
+			if err = am.database.UpdateOperation(ctx, op.ID, update); err != nil {
+				l.Errorf("Operation update failed: %s update=[ %s ]", err, update)
+			}
+			return nil
+		})
+	}
+	return err
+}
@@ -0,0 +1,371 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/syncasync"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestApprovalInput() *fftypes.TokenApprovalInput {
+	return &fftypes.TokenApprovalInput{
+		TokenApproval: fftypes.TokenApproval{
+			Operator: "0x2",
+			Approved: true,
+		},
+		Pool: "pool1",
+	}
+}
+
+func TestGetTokenApprovals(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenApprovalQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenApprovals", context.Background(), f).Return([]*fftypes.TokenApproval{}, nil, nil)
+	_, _, err := am.GetTokenApprovals(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestTokenApprovalSuccess(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	pool := &fftypes.TokenPool{
+		ID:         fftypes.NewUUID(),
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("TokensApproval", context.Background(), mock.Anything, "F1", &approval.TokenApproval).Return(nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeTokenApproval
+	}), false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeTokenApproval && op.Input.GetString("id") == approval.LocalID.String()
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+
+	out, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", out.Key)
+	assert.Equal(t, "magic-tokens", out.Connector)
+	assert.Equal(t, "ns1", out.Namespace)
+	assert.Equal(t, pool.ID, out.Pool)
+	assert.Equal(t, fftypes.TransactionTypeTokenApproval, out.TX.Type)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenApprovalNoConnectors(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	am.tokens = make(map[string]tokens.Plugin)
+
+	_, err := am.TokenApproval(context.Background(), "ns1", newTestApprovalInput(), false)
+	assert.Regexp(t, "FF10292", err)
+}
+
+func TestTokenApprovalUnknownPoolSuccess(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Pool = ""
+	approval.Key = "0x12345"
+	tokenPools := []*fftypes.TokenPool{
+		{
+			Name:       "pool1",
+			ProtocolID: "F1",
+			State:      fftypes.TokenPoolStateConfirmed,
+		},
+	}
+	totalCount := int64(1)
+	filterResult := &database.FilterResult{
+		TotalCount: &totalCount,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mdi.On("GetTokenPools", context.Background(), mock.Anything).Return(tokenPools, filterResult, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(tokenPools[0], nil)
+	mti.On("TokensApproval", context.Background(), mock.Anything, "F1", &approval.TokenApproval).Return(nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "pool1", approval.Pool)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenApprovalGetPoolsError(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Pool = ""
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPools", context.Background(), mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenApprovalIdentityFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", newTestApprovalInput(), false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenApprovalNoOperator(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+	approval.Operator = ""
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.Regexp(t, "FF10140.*operator", err)
+}
+
+func TestTokenApprovalBadConnector(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Connector = "bad"
+	approval.Key = "0x12345"
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.Regexp(t, "FF10272", err)
+}
+
+func TestTokenApprovalInsufficientGasFunds(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestTokenApprovalPolicyHold(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", context.Background(), mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenApproval &&
+			req.Namespace == "ns1" &&
+			req.SigningKey == "0x12345" &&
+			req.Reference == approval.LocalID &&
+			req.Input["operator"] == "0x2" &&
+			req.Input["approved"] == true
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestTokenApprovalBadPool(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenApprovalUnconfirmedPool(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStatePending,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.Regexp(t, "FF10293", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenApprovalTransactionFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenApprovalOperationFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenApprovalFailAndDbFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	approval.Key = "0x12345"
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("TokensApproval", context.Background(), mock.Anything, "F1", &approval.TokenApproval).Return(fmt.Errorf("pop"))
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	mdi.On("UpdateTransaction", context.Background(), mock.Anything, mock.Anything).Return(fmt.Errorf("Update fail"))
+	mdi.On("UpdateOperation", context.Background(), mock.Anything, mock.Anything).Return(fmt.Errorf("Update fail"))
+
+	_, err := am.TokenApproval(context.Background(), "ns1", approval, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenApprovalConfirm(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	approval := newTestApprovalInput()
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	msa := am.syncasync.(*syncasyncmocks.Bridge)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mti.On("TokensApproval", context.Background(), mock.Anything, "F1", &approval.TokenApproval).Return(nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	confirmed := &fftypes.TokenApproval{ProtocolID: "123"}
+	msa.On("WaitForTokenApproval", context.Background(), "ns1", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			send := args[3].(syncasync.RequestSender)
+			send(context.Background())
+		}).
+		Return(confirmed, nil)
+
+	out, err := am.TokenApproval(context.Background(), "ns1", approval, true)
+	assert.NoError(t, err)
+	assert.Equal(t, confirmed, out)
+
+	mdi.AssertExpectations(t)
+	msa.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
@@ -0,0 +1,304 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (am *assetManager) GetTokenBridges(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBridge, *database.FilterResult, error) {
+	return am.database.GetTokenBridges(ctx, am.scopeNS(ns, filter))
+}
+
+func (am *assetManager) GetTokenBridgeByID(ctx context.Context, ns, id string) (*fftypes.TokenBridge, error) {
+	bridgeID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return am.database.GetTokenBridgeByID(ctx, bridgeID)
+}
+
+func (am *assetManager) resolveBridgePool(ctx context.Context, ns, poolNameOrID string, leg *fftypes.TokenBridgeLeg) error {
+	pool, err := am.GetTokenPoolByNameOrID(ctx, ns, poolNameOrID)
+	if err != nil {
+		return err
+	}
+	if pool.State != fftypes.TokenPoolStateConfirmed {
+		return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
+	}
+	if _, err := am.selectTokenPlugin(ctx, pool.Connector); err != nil {
+		return err
+	}
+	leg.Connector = pool.Connector
+	leg.Pool = pool.ID
+	return nil
+}
+
+// BridgeTokens locks tokens into escrow on the source pool. The mint on the target pool, or the compensating
+// return of the escrowed tokens, is driven by the operation updates from the token connectors.
+func (am *assetManager) BridgeTokens(ctx context.Context, ns string, input *fftypes.TokenBridgeInput) (*fftypes.TokenBridge, error) {
+	bridge := &input.TokenBridge
+	switch {
+	case input.SourcePool == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "sourcePool")
+	case input.TargetPool == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "targetPool")
+	case bridge.Escrow == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "escrow")
+	}
+	if err := am.resolveBridgePool(ctx, ns, input.SourcePool, &bridge.Source); err != nil {
+		return nil, err
+	}
+	if err := am.resolveBridgePool(ctx, ns, input.TargetPool, &bridge.Target); err != nil {
+		return nil, err
+	}
+	if *bridge.Source.Pool == *bridge.Target.Pool {
+		return nil, i18n.NewError(ctx, i18n.MsgTokenBridgeSamePool)
+	}
+	if bridge.Key == "" {
+		org, err := am.identity.GetLocalOrganization(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bridge.Key = org.Identity
+	}
+	if bridge.From == "" {
+		bridge.From = bridge.Key
+	}
+	if bridge.To == "" {
+		bridge.To = bridge.Key
+	}
+	bridge.ID = fftypes.NewUUID()
+	bridge.Namespace = ns
+	bridge.Status = fftypes.TokenBridgeStatusPending
+	bridge.Error = ""
+	bridge.Created = fftypes.Now()
+	bridge.Updated = nil
+
+	if err := am.preflight.CheckBalance(ctx, ns, bridge.Key, bridge.ID); err != nil {
+		return nil, err
+	}
+	if err := am.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenTransfer,
+		Namespace:  ns,
+		SigningKey: bridge.Key,
+		Reference:  bridge.ID,
+		Input: fftypes.JSONObject{
+			"type":       "bridge",
+			"sourcePool": bridge.Source.Pool.String(),
+			"targetPool": bridge.Target.Pool.String(),
+			"from":       bridge.From,
+			"to":         bridge.To,
+			"escrow":     bridge.Escrow,
+			"amount":     bridge.Amount.Int().String(),
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Namespace: ns,
+			Type:      fftypes.TransactionTypeTokenBridge,
+			Signer:    bridge.Key,
+			Reference: bridge.ID,
+		},
+		Created: fftypes.Now(),
+		Status:  fftypes.OpStatusPending,
+	}
+	tx.Hash = tx.Subject.Hash()
+	bridge.TX.ID = tx.ID
+	bridge.TX.Type = tx.Subject.Type
+
+	err := am.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		if err = am.database.UpsertTransaction(ctx, tx, false /* should be new */); err != nil {
+			return err
+		}
+		return am.database.InsertTokenBridge(ctx, bridge)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = am.submitBridgeOperation(ctx, bridge, fftypes.OpTypeTokenBridgeLock); err != nil {
+		update := database.TransactionQueryFactory.NewUpdate(ctx).Set("status", fftypes.OpStatusFailed)
+		if txErr := am.database.UpdateTransaction(ctx, tx.ID, update); txErr != nil {
+			log.L(ctx).Errorf("TX update failed: %s update=[ %s ]", txErr, update)
+		}
+		if bridgeErr := am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusFailed, err.Error()); bridgeErr != nil {
+			log.L(ctx).Errorf("Token bridge update failed: %s", bridgeErr)
+		}
+		return nil, err
+	}
+	return bridge, nil
+}
+
+// submitBridgeOperation records and submits one of the linked operations of a token bridge
+func (am *assetManager) submitBridgeOperation(ctx context.Context, bridge *fftypes.TokenBridge, opType fftypes.OpType) error {
+	leg := &bridge.Source
+	transfer := &fftypes.TokenTransfer{Type: fftypes.TokenTransferTypeTransfer, From: bridge.From, To: bridge.Escrow}
+	switch opType {
+	case fftypes.OpTypeTokenBridgeMint:
+		leg = &bridge.Target
+		transfer = &fftypes.TokenTransfer{Type: fftypes.TokenTransferTypeMint, To: bridge.To}
+	case fftypes.OpTypeTokenBridgeUnlock:
+		transfer = &fftypes.TokenTransfer{Type: fftypes.TokenTransferTypeTransfer, From: bridge.Escrow, To: bridge.From}
+	}
+	transfer.LocalID = fftypes.NewUUID()
+	transfer.Pool = leg.Pool
+	transfer.TokenIndex = leg.TokenIndex
+	transfer.Connector = leg.Connector
+	transfer.Namespace = bridge.Namespace
+	transfer.Key = bridge.Key
+	transfer.Amount = bridge.Amount
+	transfer.TX = bridge.TX
+
+	plugin, err := am.selectTokenPlugin(ctx, leg.Connector)
+	if err != nil {
+		return err
+	}
+	pool, err := am.database.GetTokenPoolByID(ctx, leg.Pool)
+	if err != nil {
+		return err
+	}
+	if pool == nil {
+		return i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	op := fftypes.NewTXOperation(
+		plugin,
+		bridge.Namespace,
+		bridge.TX.ID,
+		"",
+		opType,
+		fftypes.OpStatusPending)
+	txcommon.AddTokenBridgeInputs(op, bridge)
+	if err = am.database.InsertOperation(ctx, op); err != nil {
+		return err
+	}
+	if err = am.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, bridge.Key)); err != nil {
+		return err
+	}
+
+	if transfer.Type == fftypes.TokenTransferTypeMint {
+		err = plugin.MintTokens(ctx, op.ID, pool.ProtocolID, transfer)
+	} else {
+		err = plugin.TransferTokens(ctx, op.ID, pool.ProtocolID, transfer)
+	}
+	if err != nil {
+		update := database.OperationQueryFactory.NewUpdate(ctx).
+			Set("status", fftypes.OpStatusFailed).
+			Set("error", err.Error())
+		if opErr := am.database.UpdateOperation(ctx, op.ID, update); opErr != nil {
+			log.L(ctx).Errorf("Operation update failed: %s update=[ %s ]", opErr, update)
+		}
+	}
+	return err
+}
+
+func (am *assetManager) updateBridgeStatus(ctx context.Context, bridge *fftypes.TokenBridge, status fftypes.TokenBridgeStatus, errorMessage string) error {
+	bridge.Status = status
+	bridge.Error = errorMessage
+	bridge.Updated = fftypes.Now()
+	update := database.TokenBridgeQueryFactory.NewUpdate(ctx).
+		Set("status", bridge.Status).
+		Set("error", bridge.Error).
+		Set("updated", bridge.Updated)
+	return am.database.UpdateTokenBridge(ctx, bridge.ID, update)
+}
+
+func (am *assetManager) finishBridge(ctx context.Context, bridge *fftypes.TokenBridge, status fftypes.TokenBridgeStatus, errorMessage string) error {
+	return am.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err := am.updateBridgeStatus(ctx, bridge, status, errorMessage); err != nil {
+			return err
+		}
+		eventType := fftypes.EventTypeTokenBridgeFailed
+		if status == fftypes.TokenBridgeStatusCompleted {
+			eventType = fftypes.EventTypeTokenBridgeCompleted
+		}
+		log.L(ctx).Infof("Token bridge '%s' finished with status=%s", bridge.ID, status)
+		event := fftypes.NewEvent(eventType, bridge.Namespace, bridge.ID)
+		return am.database.InsertEvent(ctx, event)
+	})
+}
+
+func (am *assetManager) compensateBridge(ctx context.Context, bridge *fftypes.TokenBridge, errorMessage string) error {
+	if err := am.updateBridgeStatus(ctx, bridge, fftypes.TokenBridgeStatusCompensating, errorMessage); err != nil {
+		return err
+	}
+	if err := am.submitBridgeOperation(ctx, bridge, fftypes.OpTypeTokenBridgeUnlock); err != nil {
+		return am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusFailed, err.Error())
+	}
+	return nil
+}
+
+// TokenBridgeOpUpdate advances a token bridge, when one of its linked operations succeeds or fails
+func (am *assetManager) TokenBridgeOpUpdate(ctx context.Context, op *fftypes.Operation, txState fftypes.OpStatus, errorMessage string) error {
+	if txState != fftypes.OpStatusSucceeded && txState != fftypes.OpStatusFailed {
+		return nil
+	}
+	bridgeID, err := txcommon.RetrieveTokenBridgeInputs(ctx, op)
+	if err != nil {
+		log.L(ctx).Warnf("Failed to read operation inputs for token bridge operation '%s': %s", op.ID, err)
+		return nil
+	}
+	bridge, err := am.database.GetTokenBridgeByID(ctx, bridgeID)
+	if err != nil {
+		return err
+	}
+	if bridge == nil {
+		log.L(ctx).Warnf("Token bridge '%s' not found for operation '%s'", bridgeID, op.ID)
+		return nil
+	}
+
+	// Each operation can only advance the bridge from the state in which it was submitted,
+	// so that a replayed update never results in a duplicate mint or return of tokens
+	succeeded := txState == fftypes.OpStatusSucceeded
+	switch {
+	case op.Type == fftypes.OpTypeTokenBridgeLock && bridge.Status == fftypes.TokenBridgeStatusPending:
+		if !succeeded {
+			return am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusFailed, errorMessage)
+		}
+		if err := am.updateBridgeStatus(ctx, bridge, fftypes.TokenBridgeStatusLocked, ""); err != nil {
+			return err
+		}
+		if err := am.submitBridgeOperation(ctx, bridge, fftypes.OpTypeTokenBridgeMint); err != nil {
+			return am.compensateBridge(ctx, bridge, err.Error())
+		}
+		return nil
+	case op.Type == fftypes.OpTypeTokenBridgeMint && bridge.Status == fftypes.TokenBridgeStatusLocked:
+		if !succeeded {
+			return am.compensateBridge(ctx, bridge, errorMessage)
+		}
+		return am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusCompleted, "")
+	case op.Type == fftypes.OpTypeTokenBridgeUnlock && bridge.Status == fftypes.TokenBridgeStatusCompensating:
+		if !succeeded {
+			return am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusFailed, errorMessage)
+		}
+		return am.finishBridge(ctx, bridge, fftypes.TokenBridgeStatusCompensated, bridge.Error)
+	default:
+		log.L(ctx).Debugf("Ignoring %s update for operation '%s' of token bridge '%s' with status=%s", txState, op.ID, bridge.ID, bridge.Status)
+		return nil
+	}
+}
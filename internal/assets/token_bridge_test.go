@@ -0,0 +1,658 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestBridgeAssets(t *testing.T) (*assetManager, func()) {
+	am, cancel := newTestAssets(t)
+	mti := &tokenmocks.Plugin{}
+	mti.On("Name").Return("ut_tokens_other").Maybe()
+	am.tokens["other-tokens"] = mti
+	return am, cancel
+}
+
+func newTestBridgePools() (*fftypes.TokenPool, *fftypes.TokenPool) {
+	return &fftypes.TokenPool{
+		ID:         fftypes.NewUUID(),
+		Connector:  "magic-tokens",
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}, &fftypes.TokenPool{
+		ID:         fftypes.NewUUID(),
+		Connector:  "other-tokens",
+		ProtocolID: "F2",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+}
+
+func newTestBridge(status fftypes.TokenBridgeStatus) *fftypes.TokenBridge {
+	return &fftypes.TokenBridge{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+		From:      "0x12345",
+		To:        "0x23456",
+		Escrow:    "0x99999",
+		Amount:    *fftypes.NewBigInt(5),
+		Source: fftypes.TokenBridgeLeg{
+			Connector: "magic-tokens",
+			Pool:      fftypes.NewUUID(),
+		},
+		Target: fftypes.TokenBridgeLeg{
+			Connector: "other-tokens",
+			Pool:      fftypes.NewUUID(),
+		},
+		Status: status,
+		TX: fftypes.TransactionRef{
+			Type: fftypes.TransactionTypeTokenBridge,
+			ID:   fftypes.NewUUID(),
+		},
+	}
+}
+
+func newTestBridgeOp(opType fftypes.OpType, bridge *fftypes.TokenBridge) *fftypes.Operation {
+	return &fftypes.Operation{
+		ID:    fftypes.NewUUID(),
+		Type:  opType,
+		Input: fftypes.JSONObject{"id": bridge.ID.String()},
+	}
+}
+
+func TestGetTokenBridges(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	fb := database.TokenBridgeQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	mdi.On("GetTokenBridges", context.Background(), f).Return([]*fftypes.TokenBridge{}, nil, nil)
+	_, _, err := am.GetTokenBridges(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetTokenBridgeByID(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	u := fftypes.NewUUID()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), u).Return(&fftypes.TokenBridge{}, nil)
+	_, err := am.GetTokenBridgeByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+}
+
+func TestGetTokenBridgeByIDBadID(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	_, err := am.GetTokenBridgeByID(context.Background(), "ns1", "badUUID")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestBridgeTokensSuccess(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	input := &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{
+			To:     "0x23456",
+			Escrow: "0x99999",
+			Amount: *fftypes.NewBigInt(5),
+		},
+		SourcePool: "pool1",
+		TargetPool: target.ID.String(),
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPoolByID", context.Background(), target.ID).Return(target, nil)
+	mdi.On("GetTokenPoolByID", context.Background(), source.ID).Return(source, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeTokenBridge
+	}), false).Return(nil)
+	mdi.On("InsertTokenBridge", context.Background(), mock.MatchedBy(func(bridge *fftypes.TokenBridge) bool {
+		return bridge.Status == fftypes.TokenBridgeStatusPending && bridge.From == "0x12345"
+	})).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeTokenBridgeLock
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", mock.MatchedBy(func(transfer *fftypes.TokenTransfer) bool {
+		return transfer.From == "0x12345" && transfer.To == "0x99999" && transfer.TX.Type == fftypes.TransactionTypeTokenBridge
+	})).Return(nil)
+
+	bridge, err := am.BridgeTokens(context.Background(), "ns1", input)
+	assert.NoError(t, err)
+	assert.Equal(t, "magic-tokens", bridge.Source.Connector)
+	assert.Equal(t, "other-tokens", bridge.Target.Connector)
+	assert.Equal(t, "0x12345", bridge.Key)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestBridgeTokensMissingFields(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{})
+	assert.Regexp(t, "FF10140.*sourcePool", err)
+	_, err = am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{SourcePool: "pool1"})
+	assert.Regexp(t, "FF10140.*targetPool", err)
+	_, err = am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{SourcePool: "pool1", TargetPool: "pool2"})
+	assert.Regexp(t, "FF10140.*escrow", err)
+}
+
+func TestBridgeTokensSourcePoolFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBridgeTokensSourcePoolUnconfirmed(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, _ := newTestBridgePools()
+	source.State = fftypes.TokenPoolStatePending
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.Regexp(t, "FF10293", err)
+}
+
+func TestBridgeTokensSourcePoolUnknownConnector(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, _ := newTestBridgePools()
+	source.Connector = "bad"
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.Regexp(t, "FF10272", err)
+}
+
+func TestBridgeTokensTargetPoolFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, _ := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBridgeTokensSamePool(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, _ := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPoolByID", context.Background(), source.ID).Return(source, nil)
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  source.ID.String(),
+	})
+	assert.Regexp(t, "FF10352", err)
+}
+
+func TestBridgeTokensLocalOrgFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(target, nil)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(nil, fmt.Errorf("pop"))
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBridgeTokensBalanceFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(target, nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(fmt.Errorf("pop"))
+	am.preflight = mpf
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Key: "0x12345", Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestBridgeTokensPolicyFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(target, nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", context.Background(), mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenTransfer && req.Input.GetString("type") == "bridge"
+	})).Return(fmt.Errorf("pop"))
+	am.preflight = mpf
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Key: "0x12345", Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestBridgeTokensTransactionFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(target, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Key: "0x12345", Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBridgeTokensLockFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	source, target := newTestBridgePools()
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(source, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool2").Return(target, nil)
+	mdi.On("GetTokenPoolByID", context.Background(), source.ID).Return(source, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.Anything, false).Return(nil)
+	mdi.On("InsertTokenBridge", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("UpdateOperation", context.Background(), mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("UpdateTransaction", context.Background(), mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("UpdateTokenBridge", context.Background(), mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.BridgeTokens(context.Background(), "ns1", &fftypes.TokenBridgeInput{
+		TokenBridge: fftypes.TokenBridge{Key: "0x12345", Escrow: "0x99999"},
+		SourcePool:  "pool1",
+		TargetPool:  "pool2",
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdatePending(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusPending, "")
+	assert.NoError(t, err)
+}
+
+func TestTokenBridgeOpUpdateBadInput(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	err := am.TokenBridgeOpUpdate(context.Background(), &fftypes.Operation{Type: fftypes.OpTypeTokenBridgeLock}, fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+}
+
+func TestTokenBridgeOpUpdateGetFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(nil, fmt.Errorf("pop"))
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenBridgeOpUpdateNotFound(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(nil, nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+}
+
+func TestTokenBridgeOpUpdateLockedMint(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["other-tokens"].(*tokenmocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Target.Pool).Return(&fftypes.TokenPool{ProtocolID: "F2"}, nil)
+	mdi.On("InsertOperation", context.Background(), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeTokenBridgeMint && *op.Transaction == *bridge.TX.ID
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	mti.On("MintTokens", context.Background(), mock.Anything, "F2", mock.MatchedBy(func(transfer *fftypes.TokenTransfer) bool {
+		return transfer.Type == fftypes.TokenTransferTypeMint && transfer.To == "0x23456"
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusLocked, bridge.Status)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateLockedUpdateFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenBridgeOpUpdateLockFailed(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", context.Background(), mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeTokenBridgeFailed && *event.Reference == *bridge.ID
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusFailed, "lock failed")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusFailed, bridge.Status)
+	assert.Equal(t, "lock failed", bridge.Error)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateMintSubmitFailCompensate(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Target.Pool).Return(nil, nil)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Source.Pool).Return(&fftypes.TokenPool{ProtocolID: "F1"}, nil)
+	mdi.On("InsertOperation", context.Background(), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeTokenBridgeUnlock
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
+	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", mock.MatchedBy(func(transfer *fftypes.TokenTransfer) bool {
+		return transfer.From == "0x99999" && transfer.To == "0x12345"
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusCompensating, bridge.Status)
+	assert.Regexp(t, "FF10109", bridge.Error)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateMintSucceeded(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusLocked)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", context.Background(), mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeTokenBridgeCompleted
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeMint, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusCompleted, bridge.Status)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateMintFailedCompensateFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusLocked)
+	bridge.Source.Connector = "removed"
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", context.Background(), mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeTokenBridgeFailed
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeMint, bridge), fftypes.OpStatusFailed, "mint failed")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusFailed, bridge.Status)
+	assert.Regexp(t, "FF10272", bridge.Error)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateMintFailedCompensateUpdateFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusLocked)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeMint, bridge), fftypes.OpStatusFailed, "mint failed")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTokenBridgeOpUpdateUnlockSucceeded(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusCompensating)
+	bridge.Error = "mint failed"
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", context.Background(), mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeTokenBridgeFailed
+	})).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeUnlock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusCompensated, bridge.Status)
+	assert.Equal(t, "mint failed", bridge.Error)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateUnlockFailed(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusCompensating)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", context.Background(), mock.Anything).Return(nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeUnlock, bridge), fftypes.OpStatusFailed, "unlock failed")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusFailed, bridge.Status)
+	assert.Equal(t, "unlock failed", bridge.Error)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTokenBridgeOpUpdateReplayIgnored(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusLocked)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenBridgeByID", context.Background(), bridge.ID).Return(bridge, nil)
+
+	err := am.TokenBridgeOpUpdate(context.Background(), newTestBridgeOp(fftypes.OpTypeTokenBridgeLock, bridge), fftypes.OpStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TokenBridgeStatusLocked, bridge.Status)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestSubmitBridgeOperationGetPoolFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Source.Pool).Return(nil, fmt.Errorf("pop"))
+
+	err := am.submitBridgeOperation(context.Background(), bridge, fftypes.OpTypeTokenBridgeLock)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSubmitBridgeOperationInsertOpFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Source.Pool).Return(&fftypes.TokenPool{ProtocolID: "F1"}, nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.submitBridgeOperation(context.Background(), bridge, fftypes.OpTypeTokenBridgeLock)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSubmitBridgeOperationInsertSigningActivityFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusPending)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), bridge.Source.Pool).Return(&fftypes.TokenPool{ProtocolID: "F1"}, nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.submitBridgeOperation(context.Background(), bridge, fftypes.OpTypeTokenBridgeLock)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFinishBridgeUpdateFail(t *testing.T) {
+	am, cancel := newTestBridgeAssets(t)
+	defer cancel()
+
+	bridge := newTestBridge(fftypes.TokenBridgeStatusLocked)
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("UpdateTokenBridge", context.Background(), bridge.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.finishBridge(context.Background(), bridge, fftypes.TokenBridgeStatusCompleted, "")
+	assert.EqualError(t, err, "pop")
+}
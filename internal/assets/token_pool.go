@@ -84,6 +84,28 @@ func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftyp
 		})
 	}
 
+	if err := txcommon.CheckTransactionIdempotencyKey(ctx, am.database, pool.Namespace, pool.IdempotencyKey); err != nil {
+		return nil, err
+	}
+	if err := am.preflight.CheckBalance(ctx, pool.Namespace, pool.Key, pool.ID); err != nil {
+		return nil, err
+	}
+	if err := am.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenPool,
+		Namespace:  pool.Namespace,
+		SigningKey: pool.Key,
+		Reference:  pool.ID,
+		Input: fftypes.JSONObject{
+			"type":      pool.Type,
+			"connector": pool.Connector,
+			"name":      pool.Name,
+			"symbol":    pool.Symbol,
+			"config":    pool.Config,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
 	tx := &fftypes.Transaction{
 		ID: fftypes.NewUUID(),
 		Subject: fftypes.TransactionSubject{
@@ -92,8 +114,9 @@ func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftyp
 			Signer:    pool.Key,
 			Reference: pool.ID,
 		},
-		Created: fftypes.Now(),
-		Status:  fftypes.OpStatusPending,
+		Created:        fftypes.Now(),
+		Status:         fftypes.OpStatusPending,
+		IdempotencyKey: pool.IdempotencyKey,
 	}
 	tx.Hash = tx.Subject.Hash()
 	pool.TX.ID = tx.ID
@@ -113,6 +136,9 @@ func (am *assetManager) createTokenPoolInternal(ctx context.Context, pool *fftyp
 		if err == nil {
 			err = am.database.InsertOperation(ctx, op)
 		}
+		if err == nil {
+			err = am.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, pool.Key))
+		}
 		return err
 	})
 	if err != nil {
@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/tokens"
@@ -95,12 +96,86 @@ func TestCreateTokenPoolFail(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mti.On("CreateTokenPool", context.Background(), mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
 	assert.Regexp(t, "pop", err)
 }
 
+func TestCreateTokenPoolInsufficientGasFunds(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	pool := &fftypes.TokenPool{
+		Name: "testpool",
+	}
+
+	mdm := am.data.(*datamocks.Manager)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("VerifyNamespaceExists", context.Background(), "ns1").Return(nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestCreateTokenPoolDuplicateIdempotencyKey(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	pool := &fftypes.TokenPool{
+		Name:           "testpool",
+		IdempotencyKey: "retry1",
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mdm := am.data.(*datamocks.Manager)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("VerifyNamespaceExists", context.Background(), "ns1").Return(nil)
+	mdi.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{
+		{ID: fftypes.NewUUID(), Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeTokenPool, Reference: fftypes.NewUUID()}},
+	}, nil, nil)
+
+	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
+	assert.Regexp(t, "FF10408.*retry1", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCreateTokenPoolPolicyHold(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	pool := &fftypes.TokenPool{
+		Name:      "testpool",
+		Connector: "magic-tokens",
+	}
+
+	mdm := am.data.(*datamocks.Manager)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("VerifyNamespaceExists", context.Background(), "ns1").Return(nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", context.Background(), mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenPool &&
+			req.Namespace == "ns1" &&
+			req.SigningKey == "0x12345" &&
+			req.Reference == pool.ID &&
+			req.Input["name"] == "testpool"
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
 func TestCreateTokenPoolTransactionFail(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -142,7 +217,7 @@ func TestCreateTokenPoolOperationFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
-func TestCreateTokenPoolSuccess(t *testing.T) {
+func TestCreateTokenPoolSigningActivityFail(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
 
@@ -150,6 +225,30 @@ func TestCreateTokenPoolSuccess(t *testing.T) {
 		Name: "testpool",
 	}
 
+	mdi := am.database.(*databasemocks.Plugin)
+	mdm := am.data.(*datamocks.Manager)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdm.On("VerifyNamespaceExists", context.Background(), "ns1").Return(nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
+	}), false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
+	assert.Regexp(t, "pop", err)
+}
+
+func TestCreateTokenPoolSuccess(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	pool := &fftypes.TokenPool{
+		Name:           "testpool",
+		IdempotencyKey: "retry1",
+	}
+
 	mdi := am.database.(*databasemocks.Plugin)
 	mdm := am.data.(*datamocks.Manager)
 	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
@@ -157,13 +256,16 @@ func TestCreateTokenPoolSuccess(t *testing.T) {
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
 	mdm.On("VerifyNamespaceExists", context.Background(), "ns1").Return(nil)
 	mti.On("CreateTokenPool", context.Background(), mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{}, nil, nil)
 	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
-		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
+		return tx.Subject.Type == fftypes.TransactionTypeTokenPool && tx.IdempotencyKey == "retry1"
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
 	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
 }
 
 func TestCreateTokenPoolUnknownConnectorSuccess(t *testing.T) {
@@ -185,6 +287,7 @@ func TestCreateTokenPoolUnknownConnectorSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.CreateTokenPool(context.Background(), "ns1", pool, false)
 	assert.NoError(t, err)
@@ -245,6 +348,7 @@ func TestCreateTokenPoolConfirm(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil).Times(1)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenPool", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
@@ -323,6 +427,7 @@ func TestCreateTokenPoolByTypeFail(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mti.On("CreateTokenPool", context.Background(), mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	_, err := am.CreateTokenPoolByType(context.Background(), "ns1", "magic-tokens", pool, false)
@@ -389,6 +494,7 @@ func TestCreateTokenPoolByTypeSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.CreateTokenPoolByType(context.Background(), "ns1", "magic-tokens", pool, false)
 	assert.NoError(t, err)
@@ -414,6 +520,7 @@ func TestCreateTokenPoolByTypeConfirm(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenPool
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil).Times(1)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenPool", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
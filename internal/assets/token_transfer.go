@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
 )
 
 func (am *assetManager) GetTokenTransfers(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenTransfer, *database.FilterResult, error) {
@@ -213,6 +214,11 @@ func (s *transferSender) resolveAndSend(ctx context.Context, method sendMethod)
 }
 
 func (s *transferSender) resolve(ctx context.Context) error {
+	// Reject a retried submission
+	if err := txcommon.CheckTransactionIdempotencyKey(ctx, s.mgr.database, s.namespace, s.transfer.IdempotencyKey); err != nil {
+		return err
+	}
+
 	// Resolve the attached message
 	if s.transfer.Message != nil {
 		sender, err := s.buildTransferMessage(ctx, s.namespace, s.transfer.Message)
@@ -246,6 +252,10 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		return nil
 	}
 
+	if err := s.mgr.preflightTransfer(ctx, s.namespace, &s.transfer.TokenTransfer); err != nil {
+		return err
+	}
+
 	tx := &fftypes.Transaction{
 		ID: fftypes.NewUUID(),
 		Subject: fftypes.TransactionSubject{
@@ -254,8 +264,9 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 			Signer:    s.transfer.Key,
 			Reference: s.transfer.LocalID,
 		},
-		Created: fftypes.Now(),
-		Status:  fftypes.OpStatusPending,
+		Created:        fftypes.Now(),
+		Status:         fftypes.OpStatusPending,
+		IdempotencyKey: s.transfer.IdempotencyKey,
 	}
 	tx.Hash = tx.Subject.Hash()
 	s.transfer.TX.ID = tx.ID
@@ -268,7 +279,6 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		"",
 		fftypes.OpTypeTokenTransfer,
 		fftypes.OpStatusPending)
-	txcommon.AddTokenTransferInputs(op, &s.transfer.TokenTransfer)
 
 	var pool *fftypes.TokenPool
 	err = s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
@@ -279,6 +289,8 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		if pool.State != fftypes.TokenPoolStateConfirmed {
 			return i18n.NewError(ctx, i18n.MsgTokenPoolNotConfirmed)
 		}
+		s.transfer.TokenTransfer.Pool = pool.ID
+		txcommon.AddTokenTransferInputs(op, &s.transfer.TokenTransfer)
 
 		err = s.mgr.database.UpsertTransaction(ctx, tx, false /* should be new, or idempotent replay */)
 		if err != nil {
@@ -287,9 +299,15 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		if err = s.mgr.database.InsertOperation(ctx, op); err != nil {
 			return err
 		}
+		if err = s.mgr.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, s.transfer.Key)); err != nil {
+			return err
+		}
 		if s.transfer.Message != nil {
 			s.transfer.Message.State = fftypes.MessageStateStaged
 			err = s.mgr.database.UpsertMessage(ctx, &s.transfer.Message.Message, database.UpsertOptimizationNew)
+			if err == nil {
+				err = s.mgr.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(&s.transfer.Message.Message, "awaiting token transfer"))
+			}
 		}
 		return err
 	})
@@ -297,16 +315,7 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 		return err
 	}
 
-	switch s.transfer.Type {
-	case fftypes.TokenTransferTypeMint:
-		err = plugin.MintTokens(ctx, op.ID, pool.ProtocolID, &s.transfer.TokenTransfer)
-	case fftypes.TokenTransferTypeTransfer:
-		err = plugin.TransferTokens(ctx, op.ID, pool.ProtocolID, &s.transfer.TokenTransfer)
-	case fftypes.TokenTransferTypeBurn:
-		err = plugin.BurnTokens(ctx, op.ID, pool.ProtocolID, &s.transfer.TokenTransfer)
-	default:
-		panic(fmt.Sprintf("unknown transfer type: %v", s.transfer.Type))
-	}
+	err = submitTransfer(ctx, plugin, op.ID, pool.ProtocolID, &s.transfer.TokenTransfer)
 
 	// if transaction fails,  mark tx and op as failed in DB
 	if err != nil {
@@ -330,6 +339,66 @@ func (s *transferSender) sendInternal(ctx context.Context, method sendMethod) er
 	return err
 }
 
+func submitTransfer(ctx context.Context, plugin tokens.Plugin, opID *fftypes.UUID, poolProtocolID string, transfer *fftypes.TokenTransfer) error {
+	switch transfer.Type {
+	case fftypes.TokenTransferTypeMint:
+		return plugin.MintTokens(ctx, opID, poolProtocolID, transfer)
+	case fftypes.TokenTransferTypeTransfer:
+		return plugin.TransferTokens(ctx, opID, poolProtocolID, transfer)
+	case fftypes.TokenTransferTypeBurn:
+		return plugin.BurnTokens(ctx, opID, poolProtocolID, transfer)
+	default:
+		panic(fmt.Sprintf("unknown transfer type: %v", transfer.Type))
+	}
+}
+
+// preflightTransfer runs the balance and policy checks that must pass before each submission of a transfer
+func (am *assetManager) preflightTransfer(ctx context.Context, ns string, transfer *fftypes.TokenTransfer) error {
+	if err := am.preflight.CheckBalance(ctx, ns, transfer.Key, transfer.LocalID); err != nil {
+		return err
+	}
+	return am.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenTransfer,
+		Namespace:  ns,
+		SigningKey: transfer.Key,
+		Reference:  transfer.LocalID,
+		Input: fftypes.JSONObject{
+			"type":       transfer.Type,
+			"connector":  transfer.Connector,
+			"pool":       transfer.Pool,
+			"tokenIndex": transfer.TokenIndex,
+			"from":       transfer.From,
+			"to":         transfer.To,
+			"amount":     transfer.Amount.Int().String(),
+		},
+	})
+}
+
+func (am *assetManager) RetryOperation(ctx context.Context, op *fftypes.Operation) error {
+	transfer, err := txcommon.RetrieveTokenTransferRetryInputs(ctx, op)
+	if err != nil {
+		return err
+	}
+	plugin, err := am.selectTokenPlugin(ctx, transfer.Connector)
+	if err != nil {
+		return err
+	}
+	pool, err := am.database.GetTokenPoolByID(ctx, transfer.Pool)
+	if err != nil {
+		return err
+	}
+	if pool == nil {
+		return i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if err = am.preflightTransfer(ctx, op.Namespace, transfer); err != nil {
+		return err
+	}
+	if err = am.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, transfer.Key)); err != nil {
+		return err
+	}
+	return submitTransfer(ctx, plugin, op.ID, pool.ProtocolID, transfer)
+}
+
 func (s *transferSender) buildTransferMessage(ctx context.Context, ns string, in *fftypes.MessageInOut) (sysmessaging.MessageSender, error) {
 	allowedTypes := []fftypes.FFEnum{
 		fftypes.MessageTypeTransferBroadcast,
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/syncasync"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
@@ -28,6 +29,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/tokens"
@@ -114,9 +116,11 @@ func TestMintTokensSuccess(t *testing.T) {
 		TokenTransfer: fftypes.TokenTransfer{
 			Amount: *fftypes.NewBigInt(5),
 		},
-		Pool: "pool1",
+		Pool:           "pool1",
+		IdempotencyKey: "retry1",
 	}
 	pool := &fftypes.TokenPool{
+		ID:         fftypes.NewUUID(),
 		ProtocolID: "F1",
 		State:      fftypes.TokenPoolStateConfirmed,
 	}
@@ -125,15 +129,96 @@ func TestMintTokensSuccess(t *testing.T) {
 	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
 	mim := am.identity.(*identitymanagermocks.Manager)
 	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{}, nil, nil)
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(nil)
 	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
-		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
+		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer && tx.IdempotencyKey == "retry1"
 	}), false).Return(nil)
-	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Input["type"] == fftypes.TokenTransferTypeMint && op.Input["pool"] == pool.ID
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
 	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestMintTokensDuplicateIdempotencyKey(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewBigInt(5),
+		},
+		Pool:           "pool1",
+		IdempotencyKey: "retry1",
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{
+		{ID: fftypes.NewUUID(), Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeTokenTransfer, Reference: fftypes.NewUUID()}},
+	}, nil, nil)
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.Regexp(t, "FF10408.*retry1", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestMintTokensInsufficientGasFunds(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewBigInt(5),
+		},
+		Pool: "pool1",
+	}
+
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestMintTokensPolicyHold(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewBigInt(5),
+		},
+		Pool: "pool1",
+	}
+
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", context.Background(), mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenTransfer &&
+			req.Namespace == "ns1" &&
+			req.SigningKey == "0x12345" &&
+			req.Reference == mint.LocalID &&
+			req.Input["amount"] == "5" &&
+			req.Input["type"] == fftypes.TokenTransferTypeMint
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
 }
 
 func TestMintTokenUnknownConnectorSuccess(t *testing.T) {
@@ -161,6 +246,7 @@ func TestMintTokenUnknownConnectorSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
 	assert.NoError(t, err)
@@ -282,6 +368,7 @@ func TestMintTokenUnknownPoolSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
 	assert.NoError(t, err)
@@ -454,6 +541,7 @@ func TestMintTokensFail(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mdi.On("UpdateTransaction", context.Background(), mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateOperation", context.Background(), mock.Anything, mock.Anything).Return(nil)
 
@@ -483,6 +571,7 @@ func TestMintTokensFailAndDbFail(t *testing.T) {
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("MintTokens", context.Background(), mock.Anything, "F1", &mint.TokenTransfer).Return(fmt.Errorf("pop"))
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer && tx.Status != fftypes.OpStatusFailed
 	}), false).Return(nil)
@@ -521,6 +610,35 @@ func TestMintTokensOperationFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestMintTokensSigningActivityFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mint := &fftypes.TokenTransferInput{
+		TokenTransfer: fftypes.TokenTransfer{
+			Amount: *fftypes.NewBigInt(5),
+		},
+		Pool: "pool1",
+	}
+	pool := &fftypes.TokenPool{
+		ProtocolID: "F1",
+		State:      fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mim := am.identity.(*identitymanagermocks.Manager)
+	mim.On("GetLocalOrganization", context.Background()).Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
+	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
+	}), false).Return(nil)
+	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := am.MintTokens(context.Background(), "ns1", mint, false)
+	assert.EqualError(t, err, "pop")
+}
+
 func TestMintTokensConfirm(t *testing.T) {
 	am, cancel := newTestAssets(t)
 	defer cancel()
@@ -548,6 +666,7 @@ func TestMintTokensConfirm(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
@@ -588,6 +707,7 @@ func TestMintTokensByTypeSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.MintTokensByType(context.Background(), "ns1", "magic-tokens", "pool1", mint, false)
 	assert.NoError(t, err)
@@ -618,6 +738,7 @@ func TestBurnTokensSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.BurnTokens(context.Background(), "ns1", burn, false)
 	assert.NoError(t, err)
@@ -672,6 +793,7 @@ func TestBurnTokensConfirm(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
@@ -712,6 +834,7 @@ func TestBurnTokensByTypeSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.BurnTokensByType(context.Background(), "ns1", "magic-tokens", "pool1", burn, false)
 	assert.NoError(t, err)
@@ -748,6 +871,7 @@ func TestTransferTokensSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
 	assert.NoError(t, err)
@@ -847,6 +971,7 @@ func TestTransferTokensInvalidType(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", am.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", am.ctx, mock.Anything).Return(nil)
 
 	sender := &transferSender{
 		mgr:       am,
@@ -934,11 +1059,13 @@ func TestTransferTokensWithBroadcastMessage(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mbm.On("NewBroadcast", "ns1", transfer.Message).Return(mms)
 	mms.On("Prepare", context.Background()).Return(nil)
 	mdi.On("UpsertMessage", context.Background(), mock.MatchedBy(func(msg *fftypes.Message) bool {
 		return msg.State == fftypes.MessageStateStaged
 	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
 	assert.NoError(t, err)
@@ -1032,11 +1159,13 @@ func TestTransferTokensWithPrivateMessage(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mpm.On("NewMessage", "ns1", transfer.Message).Return(mms)
 	mms.On("Prepare", context.Background()).Return(nil)
 	mdi.On("UpsertMessage", context.Background(), mock.MatchedBy(func(msg *fftypes.Message) bool {
 		return msg.State == fftypes.MessageStateStaged
 	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.TransferTokens(context.Background(), "ns1", transfer, false)
 	assert.NoError(t, err)
@@ -1113,6 +1242,7 @@ func TestTransferTokensConfirm(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForTokenTransfer", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
@@ -1171,6 +1301,7 @@ func TestTransferTokensWithBroadcastConfirm(t *testing.T) {
 	mdi.On("GetTokenPool", context.Background(), "ns1", "pool1").Return(pool, nil)
 	mti.On("TransferTokens", context.Background(), mock.Anything, "F1", &transfer.TokenTransfer).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 	mdi.On("UpsertTransaction", context.Background(), mock.MatchedBy(func(tx *fftypes.Transaction) bool {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
@@ -1179,6 +1310,7 @@ func TestTransferTokensWithBroadcastConfirm(t *testing.T) {
 	mdi.On("UpsertMessage", context.Background(), mock.MatchedBy(func(msg *fftypes.Message) bool {
 		return msg.State == fftypes.MessageStateStaged
 	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", context.Background(), mock.Anything).Return(nil)
 	msa.On("WaitForMessage", context.Background(), "ns1", mock.Anything, mock.Anything).
 		Run(func(args mock.Arguments) {
 			send := args[3].(syncasync.RequestSender)
@@ -1231,6 +1363,7 @@ func TestTransferTokensByTypeSuccess(t *testing.T) {
 		return tx.Subject.Type == fftypes.TransactionTypeTokenTransfer
 	}), false).Return(nil)
 	mdi.On("InsertOperation", context.Background(), mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(nil)
 
 	_, err := am.TransferTokensByType(context.Background(), "ns1", "magic-tokens", "pool1", transfer, false)
 	assert.NoError(t, err)
@@ -1284,3 +1417,135 @@ func TestTransferPrepare(t *testing.T) {
 	err := sender.Prepare(context.Background())
 	assert.NoError(t, err)
 }
+
+func newRetryTransferOp(transferType fftypes.TokenTransferType, connector string) (*fftypes.Operation, *fftypes.TokenTransfer) {
+	transfer := &fftypes.TokenTransfer{
+		Type:    transferType,
+		LocalID: fftypes.NewUUID(),
+		Pool:    fftypes.NewUUID(),
+		Key:     "0x12345",
+		From:    "0x12345",
+		To:      "0x67890",
+		Amount:  *fftypes.NewBigInt(5),
+	}
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        fftypes.OpTypeTokenTransfer,
+		Plugin:      connector,
+	}
+	txcommon.AddTokenTransferInputs(op, transfer)
+	return op, transfer
+}
+
+func TestRetryOperationTransfers(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	mdi := am.database.(*databasemocks.Plugin)
+	mti := am.tokens["magic-tokens"].(*tokenmocks.Plugin)
+	pool := &fftypes.TokenPool{ProtocolID: "F1"}
+
+	for transferType, method := range map[fftypes.TokenTransferType]string{
+		fftypes.TokenTransferTypeMint:     "MintTokens",
+		fftypes.TokenTransferTypeBurn:     "BurnTokens",
+		fftypes.TokenTransferTypeTransfer: "TransferTokens",
+	} {
+		op, transfer := newRetryTransferOp(transferType, "magic-tokens")
+		mdi.On("GetTokenPoolByID", context.Background(), transfer.Pool).Return(pool, nil)
+		mdi.On("InsertSigningActivity", context.Background(), mock.MatchedBy(func(sa *fftypes.SigningActivity) bool {
+			return sa.Operation.Equals(op.ID) && sa.Key == "0x12345"
+		})).Return(nil)
+		mti.On(method, context.Background(), op.ID, "F1", mock.MatchedBy(func(retry *fftypes.TokenTransfer) bool {
+			return retry.LocalID.Equals(transfer.LocalID) &&
+				retry.TX.ID.Equals(op.Transaction) &&
+				retry.To == "0x67890" &&
+				retry.Amount.Int().Int64() == 5
+		})).Return(nil)
+
+		err := am.RetryOperation(context.Background(), op)
+		assert.NoError(t, err)
+	}
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestRetryOperationPolicyReject(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op, transfer := newRetryTransferOp(fftypes.TokenTransferTypeTransfer, "magic-tokens")
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), transfer.Pool).Return(&fftypes.TokenPool{ProtocolID: "F1"}, nil)
+	mpf := &txcommonmocks.PreflightChecker{}
+	am.preflight = mpf
+	mpf.On("CheckBalance", context.Background(), "ns1", "0x12345", transfer.LocalID).Return(nil)
+	mpf.On("CheckPolicy", context.Background(), mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenTransfer &&
+			req.Namespace == "ns1" &&
+			req.Reference.Equals(transfer.LocalID) &&
+			req.Input["to"] == "0x67890" &&
+			req.Input["amount"] == "5"
+	})).Return(fmt.Errorf("pop"))
+
+	err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "InsertSigningActivity", mock.Anything, mock.Anything)
+}
+
+func TestRetryOperationBadInputs(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	err := am.RetryOperation(context.Background(), &fftypes.Operation{Type: fftypes.OpTypeTokenTransfer})
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestRetryOperationBadConnector(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op, _ := newRetryTransferOp(fftypes.TokenTransferTypeMint, "bad")
+	err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10272", err)
+}
+
+func TestRetryOperationGetPoolFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op, transfer := newRetryTransferOp(fftypes.TokenTransferTypeMint, "magic-tokens")
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), transfer.Pool).Return(nil, fmt.Errorf("pop"))
+
+	err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationPoolNotFound(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op, transfer := newRetryTransferOp(fftypes.TokenTransferTypeMint, "magic-tokens")
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), transfer.Pool).Return(nil, nil)
+
+	err := am.RetryOperation(context.Background(), op)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationSigningActivityFail(t *testing.T) {
+	am, cancel := newTestAssets(t)
+	defer cancel()
+
+	op, transfer := newRetryTransferOp(fftypes.TokenTransferTypeMint, "magic-tokens")
+	mdi := am.database.(*databasemocks.Plugin)
+	mdi.On("GetTokenPoolByID", context.Background(), transfer.Pool).Return(&fftypes.TokenPool{}, nil)
+	mdi.On("InsertSigningActivity", context.Background(), mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := am.RetryOperation(context.Background(), op)
+	assert.EqualError(t, err, "pop")
+}
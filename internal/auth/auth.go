@@ -0,0 +1,80 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+type (
+	ctxIdentityKey struct{}
+)
+
+// WithIdentity records the identity of the API caller in the context
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, ctxIdentityKey{}, identity)
+}
+
+// GetIdentity returns the identity of the API caller recorded in the context, or an empty string if none
+func GetIdentity(ctx context.Context) string {
+	identity, _ := ctx.Value(ctxIdentityKey{}).(string)
+	return identity
+}
+
+// RequestIdentity returns the identity of the caller of an HTTP request, which is the subject
+// of the client certificate when the server requires mutual TLS
+func RequestIdentity(req *http.Request) string {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return req.TLS.PeerCertificates[0].Subject.String()
+	}
+	return ""
+}
+
+// IsAdmin returns true if the identity is configured as a subscription admin
+func IsAdmin(identity string) bool {
+	if identity == "" {
+		return false
+	}
+	for _, admin := range config.GetStringSlice(config.SubscriptionAdmins) {
+		if admin == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessSubscription returns true if the identity is allowed to attach to, modify or delete a
+// durable subscription. Subscriptions without an owner are accessible to all identities.
+func CanAccessSubscription(identity string, sub *fftypes.Subscription) bool {
+	return sub.Owner == "" || sub.Owner == identity || IsAdmin(identity)
+}
+
+func CanResolvePseudonym(identity string) bool {
+	if identity == "" {
+		return false
+	}
+	for _, resolver := range config.GetStringSlice(config.BroadcastPseudonymResolvers) {
+		if resolver == identity {
+			return true
+		}
+	}
+	return IsAdmin(identity)
+}
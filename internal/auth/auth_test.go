@@ -0,0 +1,74 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", GetIdentity(ctx))
+	ctx = WithIdentity(ctx, "CN=app1")
+	assert.Equal(t, "CN=app1", GetIdentity(ctx))
+}
+
+func TestRequestIdentity(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", RequestIdentity(req))
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "app1"}},
+		},
+	}
+	assert.Equal(t, "CN=app1", RequestIdentity(req))
+}
+
+func TestCanAccessSubscription(t *testing.T) {
+	config.Reset()
+	config.Set(config.SubscriptionAdmins, []string{"CN=admin"})
+
+	unowned := &fftypes.Subscription{}
+	owned := &fftypes.Subscription{Owner: "CN=app1"}
+
+	assert.True(t, CanAccessSubscription("", unowned))
+	assert.True(t, CanAccessSubscription("CN=app2", unowned))
+	assert.True(t, CanAccessSubscription("CN=app1", owned))
+	assert.True(t, CanAccessSubscription("CN=admin", owned))
+	assert.False(t, CanAccessSubscription("CN=app2", owned))
+	assert.False(t, CanAccessSubscription("", owned))
+}
+
+func TestCanResolvePseudonym(t *testing.T) {
+	config.Reset()
+	config.Set(config.SubscriptionAdmins, []string{"CN=admin"})
+	config.Set(config.BroadcastPseudonymResolvers, []string{"CN=auditor"})
+
+	assert.True(t, CanResolvePseudonym("CN=auditor"))
+	assert.True(t, CanResolvePseudonym("CN=admin"))
+	assert.False(t, CanResolvePseudonym("CN=app1"))
+	assert.False(t, CanResolvePseudonym(""))
+}
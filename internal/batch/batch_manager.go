@@ -41,14 +41,19 @@ func NewBatchManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, di data
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	readPageSize := config.GetUint(config.BatchManagerReadPageSize)
+	fastpathNamespaces := make(map[string]bool)
+	for _, ns := range config.GetStringSlice(config.BatchFastpathNamespaces) {
+		fastpathNamespaces[ns] = true
+	}
 	bm := &batchManager{
-		ctx:                        log.WithLogField(ctx, "role", "batchmgr"),
+		ctx:                        log.WithComponent(log.WithLogField(ctx, "role", "batchmgr"), "batch"),
 		ni:                         ni,
 		database:                   di,
 		data:                       dm,
 		readPageSize:               uint64(readPageSize),
 		messagePollTimeout:         config.GetDuration(config.BatchManagerReadPollTimeout),
 		startupOffsetRetryAttempts: config.GetInt(config.OrchestratorStartupAttempts),
+		fastpathNamespaces:         fastpathNamespaces,
 		dispatchers:                make(map[fftypes.MessageType]*dispatcher),
 		shoulderTap:                make(chan bool, 1),
 		newMessages:                make(chan int64, readPageSize),
@@ -86,6 +91,7 @@ type batchManager struct {
 	readPageSize               uint64
 	messagePollTimeout         time.Duration
 	startupOffsetRetryAttempts int
+	fastpathNamespaces         map[string]bool
 }
 
 type DispatchHandler func(context.Context, *fftypes.Batch, []*fftypes.Bytes32) error
@@ -154,13 +160,24 @@ func (bm *batchManager) removeProcessor(dispatcher *dispatcher, key string) {
 	dispatcher.mux.Unlock()
 }
 
-func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftypes.Bytes32, namespace string, identity *fftypes.Identity) (*batchProcessor, error) {
+func (bm *batchManager) isFastpath(msg *fftypes.Message) bool {
+	return msg.Immediate && bm.fastpathNamespaces[msg.Header.Namespace]
+}
+
+func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftypes.Bytes32, namespace string, identity *fftypes.Identity, fastpath bool) (*batchProcessor, error) {
 	dispatcher, ok := bm.dispatchers[batchType]
 	if !ok {
 		return nil, i18n.NewError(bm.ctx, i18n.MsgUnregisteredBatchType, batchType)
 	}
 	dispatcher.mux.Lock()
 	key := fmt.Sprintf("%s:%s:%s[group=%v]", namespace, identity.Author, identity.Key, group)
+	options := dispatcher.batchOptions
+	if fastpath {
+		// A fastpath processor seals every message into its own batch as soon as it
+		// arrives, so never waits for the batch timeout
+		key += "[fastpath]"
+		options.BatchMaxSize = 1
+	}
 	processor, ok := dispatcher.processors[key]
 	if !ok {
 		processor = newBatchProcessor(
@@ -168,7 +185,7 @@ func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftyp
 			bm.ni,
 			bm.database,
 			&batchProcessorConf{
-				Options:   dispatcher.batchOptions,
+				Options:   options,
 				namespace: namespace,
 				identity:  *identity,
 				group:     group,
@@ -349,7 +366,7 @@ func (bm *batchManager) updateOffset(infiniteRetry bool, newOffset int64) (err e
 
 func (bm *batchManager) dispatchMessage(dispatched chan *batchDispatch, msg *fftypes.Message, data ...*fftypes.Data) error {
 	l := log.L(bm.ctx)
-	processor, err := bm.getProcessor(msg.Header.Type, msg.Header.Group, msg.Header.Namespace, &msg.Header.Identity)
+	processor, err := bm.getProcessor(msg.Header.Type, msg.Header.Group, msg.Header.Namespace, &msg.Header.Identity, bm.isFastpath(msg))
 	if err != nil {
 		return err
 	}
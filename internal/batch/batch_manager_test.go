@@ -105,6 +105,7 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil).Once()
 	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag.RunFn = func(a mock.Arguments) {
@@ -212,6 +213,7 @@ func TestE2EDispatchPrivate(t *testing.T) {
 	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil).Once()
 	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag.RunFn = func(a mock.Arguments) {
@@ -248,6 +250,105 @@ func TestE2EDispatchPrivate(t *testing.T) {
 	bm.WaitStop()
 
 }
+func TestE2EDispatchBroadcastFastpath(t *testing.T) {
+	log.SetLevel("debug")
+	config.Reset()
+	config.Set(config.BatchFastpathNamespaces, []string{"ns1"})
+	defer config.Reset()
+
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	mni.On("GetNodeUUID", mock.Anything).Return(fftypes.NewUUID())
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeBatch, msgBatchOffsetName).Return(&fftypes.Offset{
+		RowID: 12345,
+	}, nil)
+	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	waitForDispatch := make(chan *fftypes.Batch, 1)
+	handler := func(ctx context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		waitForDispatch <- b
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bmi, _ := NewBatchManager(ctx, mni, mdi, mdm)
+	bm := bmi.(*batchManager)
+
+	// The batch would never fill, or time out, within the test
+	bm.RegisterDispatcher([]fftypes.MessageType{fftypes.MessageTypeBroadcast}, handler, Options{
+		BatchMaxSize:   100,
+		BatchTimeout:   120 * time.Second,
+		DisposeTimeout: 120 * time.Second,
+	})
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Type:      fftypes.MessageTypeBroadcast,
+			ID:        fftypes.NewUUID(),
+			Topics:    []string{"topic1"},
+			Namespace: "ns1",
+			Identity:  fftypes.Identity{Author: "did:firefly:org/abcd", Key: "0x12345"},
+		},
+		Immediate: true,
+	}
+	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil).Once()
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		ctx := a.Get(0).(context.Context)
+		fn := a.Get(1).(func(context.Context) error)
+		fn(ctx)
+	}
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := bm.Start()
+	assert.NoError(t, err)
+
+	b := <-waitForDispatch
+	assert.Len(t, b.Payload.Messages, 1)
+	assert.Equal(t, *msg.Header.ID, *b.Payload.Messages[0].Header.ID)
+	assert.NotNil(t, b.Hash)
+	assert.Equal(t, fftypes.HashAlgorithmSHA256, b.HashAlgorithm)
+
+	// Wait until everything closes
+	cancel()
+	bm.WaitStop()
+
+}
+
+func TestGetProcessorFastpath(t *testing.T) {
+	config.Reset()
+	config.Set(config.BatchFastpathNamespaces, []string{"ns1"})
+	defer config.Reset()
+
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	mni := &sysmessagingmocks.LocalNodeInfo{}
+	bmi, _ := NewBatchManager(context.Background(), mni, mdi, mdm)
+	bm := bmi.(*batchManager)
+	defer bm.Close()
+	bm.RegisterDispatcher([]fftypes.MessageType{fftypes.MessageTypeBroadcast}, nil, Options{
+		BatchMaxSize:   100,
+		BatchTimeout:   120 * time.Second,
+		DisposeTimeout: 120 * time.Second,
+	})
+
+	assert.True(t, bm.isFastpath(&fftypes.Message{Header: fftypes.MessageHeader{Namespace: "ns1"}, Immediate: true}))
+	assert.False(t, bm.isFastpath(&fftypes.Message{Header: fftypes.MessageHeader{Namespace: "ns1"}}))
+	assert.False(t, bm.isFastpath(&fftypes.Message{Header: fftypes.MessageHeader{Namespace: "ns2"}, Immediate: true}))
+
+	identity := &fftypes.Identity{Author: "did:firefly:org/abcd", Key: "0x12345"}
+	standard, err := bm.getProcessor(fftypes.MessageTypeBroadcast, nil, "ns1", identity, false)
+	assert.NoError(t, err)
+	fastpath, err := bm.getProcessor(fftypes.MessageTypeBroadcast, nil, "ns1", identity, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, standard, fastpath)
+	assert.Equal(t, uint(100), standard.conf.BatchMaxSize)
+	assert.Equal(t, uint(1), fastpath.conf.BatchMaxSize)
+}
+
 func TestInitFailNoPersistence(t *testing.T) {
 	_, err := NewBatchManager(context.Background(), nil, nil, nil)
 	assert.Error(t, err)
@@ -18,17 +18,21 @@ package batch
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type batchWork struct {
@@ -53,18 +57,19 @@ type batchProcessorConf struct {
 }
 
 type batchProcessor struct {
-	ctx         context.Context
-	ni          sysmessaging.LocalNodeInfo
-	database    database.Plugin
-	name        string
-	cancelCtx   func()
-	closed      bool
-	newWork     chan *batchWork
-	persistWork chan *batchWork
-	sealBatch   chan bool
-	batchSealed chan bool
-	retry       *retry.Retry
-	conf        *batchProcessorConf
+	ctx            context.Context
+	ni             sysmessaging.LocalNodeInfo
+	database       database.Plugin
+	name           string
+	cancelCtx      func()
+	closed         bool
+	newWork        chan *batchWork
+	persistWork    chan *batchWork
+	sealBatch      chan bool
+	batchSealed    chan bool
+	retry          *retry.Retry
+	conf           *batchProcessorConf
+	metricsEnabled bool
 }
 
 func newBatchProcessor(ctx context.Context, ni sysmessaging.LocalNodeInfo, di database.Plugin, conf *batchProcessorConf, retry *retry.Retry) *batchProcessor {
@@ -82,6 +87,11 @@ func newBatchProcessor(ctx context.Context, ni sysmessaging.LocalNodeInfo, di da
 		batchSealed: make(chan bool),
 		retry:       retry,
 		conf:        conf,
+
+		metricsEnabled: config.GetBool(config.MetricsEnabled),
+	}
+	if bp.metricsEnabled {
+		metrics.Registry()
 	}
 	go bp.assemblyLoop()
 	go bp.persistenceLoop()
@@ -157,6 +167,11 @@ func (bp *batchProcessor) createOrAddToBatch(batch *fftypes.Batch, newWork []*ba
 	if batch == nil {
 		batchID := fftypes.NewUUID()
 		l.Debugf("New batch %s", batchID)
+		// The node is public in the network map, so it would link a pseudonym to its organization
+		var node *fftypes.UUID
+		if !strings.HasPrefix(bp.conf.identity.Author, fftypes.FireflyPseudonymDIDPrefix) {
+			node = bp.ni.GetNodeUUID(bp.ctx)
+		}
 		batch = &fftypes.Batch{
 			ID:        batchID,
 			Namespace: bp.conf.namespace,
@@ -164,13 +179,14 @@ func (bp *batchProcessor) createOrAddToBatch(batch *fftypes.Batch, newWork []*ba
 			Group:     bp.conf.group,
 			Payload:   fftypes.BatchPayload{},
 			Created:   fftypes.Now(),
-			Node:      bp.ni.GetNodeUUID(bp.ctx),
+			Node:      node,
 		}
 	}
 	for _, w := range newWork {
 		if w.msg != nil {
 			w.msg.BatchID = batch.ID
-			w.msg.State = "" // state should always be set by receivers when loading the batch
+			w.msg.State = ""          // state should always be set by receivers when loading the batch
+			w.msg.IdempotencyKey = "" // idempotency keys are local to the submitting node
 			batch.Payload.Messages = append(batch.Payload.Messages, w.msg)
 		}
 		batch.Payload.Data = append(batch.Payload.Data, w.data...)
@@ -180,7 +196,7 @@ func (bp *batchProcessor) createOrAddToBatch(batch *fftypes.Batch, newWork []*ba
 
 func (bp *batchProcessor) maskContext(ctx context.Context, msg *fftypes.Message, topic string) (contextOrPin *fftypes.Bytes32, err error) {
 
-	hashBuilder := sha256.New()
+	hashBuilder := fftypes.NewDefaultHash()
 	hashBuilder.Write([]byte(topic))
 
 	// For broadcast we do not need to mask the context, which is just the hash
@@ -239,9 +255,41 @@ func (bp *batchProcessor) maskContexts(ctx context.Context, batch *fftypes.Batch
 }
 
 func (bp *batchProcessor) dispatchBatch(batch *fftypes.Batch, pins []*fftypes.Bytes32) {
+	// The trace context travels with the batch, so the receiving nodes can continue the trace
+	ctx, span := tracing.StartSpan(bp.ctx, "batch.dispatch",
+		attribute.String("ff.namespace", batch.Namespace),
+		attribute.String("ff.batch.id", batch.ID.String()),
+	)
+	batch.Trace = tracing.Carrier(ctx)
+
 	// Call the dispatcher to do the heavy lifting - will only exit if we're closed
-	_ = bp.retry.Do(bp.ctx, "batch dispatch", func(attempt int) (retry bool, err error) {
-		err = bp.conf.dispatch(bp.ctx, batch, pins)
+	err := bp.retry.Do(ctx, "batch dispatch", func(attempt int) (retry bool, err error) {
+		err = bp.conf.dispatch(ctx, batch, pins)
+		if err != nil {
+			return !bp.closed, err
+		}
+		return false, nil
+	})
+	tracing.EndSpan(span, err)
+	if err == nil {
+		bp.recordBatchSent(batch)
+	}
+}
+
+// recordBatchSent adds an entry to the history of each message in a dispatched batch, to show it has been sent
+func (bp *batchProcessor) recordBatchSent(batch *fftypes.Batch) {
+	cause := fmt.Sprintf("dispatched in batch %s", batch.ID)
+	_ = bp.retry.Do(bp.ctx, "batch sent", func(attempt int) (retry bool, err error) {
+		err = bp.database.RunAsGroup(bp.ctx, func(ctx context.Context) error {
+			for _, msg := range batch.Payload.Messages {
+				transition := fftypes.NewMessageTransition(msg, cause)
+				transition.State = fftypes.MessageStateSent
+				if err := bp.database.InsertMessageTransition(ctx, transition); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 		if err != nil {
 			return !bp.closed, err
 		}
@@ -272,8 +320,12 @@ func (bp *batchProcessor) persistBatch(batch *fftypes.Batch, newWork []*batchWor
 					Type: fftypes.TransactionTypeBatchPin,
 					ID:   fftypes.NewUUID(),
 				}
+				batch.HashAlgorithm = fftypes.DefaultHashAlgorithm
 				contexts, err = bp.maskContexts(ctx, batch)
-				batch.Hash = batch.Payload.Hash()
+				if err == nil {
+					batch.Hash, err = batch.CalcHash(ctx)
+				}
+				batch.Manifest = batch.Payload.Manifest()
 				log.L(ctx).Debugf("Batch %s sealed. Hash=%s", batch.ID, batch.Hash)
 			}
 			if err == nil {
@@ -290,6 +342,19 @@ func (bp *batchProcessor) persistBatch(batch *fftypes.Batch, newWork []*batchWor
 	return contexts, err
 }
 
+// recordBatchSealed counts the batch, and the messages assembled into it, as it is sealed.
+// Private batches are always assembled for a group, so the type is derived from that.
+func (bp *batchProcessor) recordBatchSealed(batch *fftypes.Batch) {
+	if bp.metricsEnabled {
+		batchType := fftypes.MessageTypeBroadcast
+		if bp.conf.group != nil {
+			batchType = fftypes.MessageTypePrivate
+		}
+		metrics.BatchSealedCounter.WithLabelValues(bp.conf.namespace, string(batchType)).Inc()
+		metrics.BatchMessagesCounter.WithLabelValues(bp.conf.namespace, string(batchType)).Add(float64(len(batch.Payload.Messages)))
+	}
+}
+
 func (bp *batchProcessor) persistenceLoop() {
 	defer close(bp.batchSealed)
 	l := log.L(bp.ctx)
@@ -354,6 +419,7 @@ func (bp *batchProcessor) persistenceLoop() {
 			// they start blocking waiting for us to complete database of
 			// the current batch.
 			bp.batchSealed <- true
+			bp.recordBatchSealed(currentBatch)
 
 			// Synchronously dispatch the batch. Must be last thing we do in the loop, as we
 			// will break out of the retry in the case that we close
@@ -21,11 +21,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -74,6 +77,7 @@ func TestUnfilledBatch(t *testing.T) {
 	mockRunAsGroupPassthrough(mdi)
 	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
 
 	// Generate the work the work
@@ -102,14 +106,38 @@ func TestUnfilledBatch(t *testing.T) {
 	// Wait for the confirmations, and the dispatch
 	wg.Wait()
 
-	// Check we got all the messages in a single batch
+	// Check we got all the messages in a single batch, with the manifest generated as it was sealed
 	assert.Equal(t, len(dispatched[0].Payload.Messages), 5)
+	assert.Equal(t, len(dispatched[0].Manifest.Messages), 5)
+	assert.Equal(t, *work[0].msg.Header.ID, *dispatched[0].Manifest.Messages[0].ID)
 
 	bp.close()
 	bp.waitClosed()
 
 }
 
+func TestCreateBatchNode(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	batch := bp.createOrAddToBatch(nil, []*batchWork{})
+	assert.NotNil(t, batch.Node)
+	bp.cancelCtx()
+}
+
+func TestCreateBatchPseudonymNoNode(t *testing.T) {
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	bp.conf.identity = fftypes.Identity{Author: fftypes.FireflyPseudonymDIDPrefix + fftypes.NewUUID().String(), Key: "0x23456"}
+	batch := bp.createOrAddToBatch(nil, []*batchWork{
+		{msg: &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}},
+	})
+	assert.Nil(t, batch.Node)
+	assert.Len(t, batch.Payload.Messages, 1)
+	bp.cancelCtx()
+}
+
 func TestFilledBatchSlowPersistence(t *testing.T) {
 	log.SetLevel("debug")
 
@@ -130,6 +158,7 @@ func TestFilledBatchSlowPersistence(t *testing.T) {
 	mockRunAsGroupPassthrough(mdi)
 	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 
 	// Generate the work the work
 	work := make([]*batchWork, 10)
@@ -247,3 +276,56 @@ func TestCalcPinsFail(t *testing.T) {
 	})
 	assert.Regexp(t, "pop", err)
 }
+
+func TestRecordBatchSentRetry(t *testing.T) {
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	defer bp.close()
+	mockRunAsGroupPassthrough(mdi)
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, State: fftypes.MessageStateReady},
+			},
+		},
+	}
+	isSent := func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateSent &&
+			transition.Cause == fmt.Sprintf("dispatched in batch %s", batch.ID)
+	}
+	mdi.On("InsertMessageTransition", mock.Anything, mock.MatchedBy(isSent)).Return(fmt.Errorf("pop")).Once()
+	mdi.On("InsertMessageTransition", mock.Anything, mock.MatchedBy(isSent)).Return(nil).Once()
+
+	bp.recordBatchSent(batch)
+
+	assert.Equal(t, fftypes.MessageStateReady, batch.Payload.Messages[0].State)
+	mdi.AssertExpectations(t)
+}
+
+func TestRecordBatchSealedMetrics(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, true)
+	defer config.Reset()
+	defer metrics.Clear()
+
+	_, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		return nil
+	})
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{}, {}},
+		},
+	}
+	bp.recordBatchSealed(batch)
+	bp.conf.group = fftypes.NewRandB32()
+	bp.recordBatchSealed(batch)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.BatchSealedCounter.WithLabelValues("ns1", "broadcast")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.BatchSealedCounter.WithLabelValues("ns1", "private")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.BatchMessagesCounter.WithLabelValues("ns1", "private")))
+
+	bp.close()
+	bp.waitClosed()
+}
@@ -0,0 +1,124 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchpin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// pinAggregate is a set of batch pins, signed by the same key, that will be submitted in a single blockchain transaction.
+// The submission is tracked by its own ID, which is recorded as the backend ID of the operation for each batch pin.
+type pinAggregate struct {
+	trackingID *fftypes.UUID
+	signingKey string
+	pins       []*blockchain.BatchPin
+	timer      *time.Timer
+}
+
+// pinAggregator coalesces the batch pins of all namespaces, to reduce the number of blockchain transactions
+// submitted by nodes that host many low-volume namespaces. The namespace of each batch is preserved on-chain.
+type pinAggregator struct {
+	ctx        context.Context
+	database   database.Plugin
+	blockchain blockchain.Plugin
+	timeout    time.Duration
+	maxBatches int
+	mux        sync.Mutex
+	pending    map[string]*pinAggregate
+}
+
+func newPinAggregator(ctx context.Context, di database.Plugin, bi blockchain.Plugin, timeout time.Duration, maxBatches int) *pinAggregator {
+	return &pinAggregator{
+		ctx:        log.WithLogField(ctx, "role", "batchpin-aggregator"),
+		database:   di,
+		blockchain: bi,
+		timeout:    timeout,
+		maxBatches: maxBatches,
+		pending:    make(map[string]*pinAggregate),
+	}
+}
+
+// add queues a batch pin for submission, and returns the ID the submission will be tracked by
+func (pa *pinAggregator) add(signingKey string, pin *blockchain.BatchPin) *fftypes.UUID {
+	pa.mux.Lock()
+	defer pa.mux.Unlock()
+
+	agg := pa.pending[signingKey]
+	if agg == nil {
+		agg = &pinAggregate{
+			trackingID: fftypes.NewUUID(),
+			signingKey: signingKey,
+		}
+		pa.pending[signingKey] = agg
+		agg.timer = time.AfterFunc(pa.timeout, func() { pa.flush(agg) })
+	}
+	agg.pins = append(agg.pins, pin)
+	if len(agg.pins) >= pa.maxBatches {
+		agg.timer.Stop()
+		delete(pa.pending, signingKey)
+		go pa.submit(agg)
+	}
+	return agg.trackingID
+}
+
+func (pa *pinAggregator) flush(agg *pinAggregate) {
+	pa.mux.Lock()
+	if pa.pending[agg.signingKey] != agg {
+		// Already submitted when it filled up
+		pa.mux.Unlock()
+		return
+	}
+	delete(pa.pending, agg.signingKey)
+	pa.mux.Unlock()
+	pa.submit(agg)
+}
+
+func (pa *pinAggregator) submit(agg *pinAggregate) {
+	l := log.L(pa.ctx)
+	l.Infof("Submitting %d batch pins for key '%s' in a single transaction. Tracking ID=%s", len(agg.pins), agg.signingKey, agg.trackingID)
+	err := pa.blockchain.SubmitBatchPins(pa.ctx, agg.trackingID, nil /* TODO: ledger selection */, agg.signingKey, agg.pins)
+	if err == nil {
+		return
+	}
+
+	// Fail the operation of every batch in the transaction, so each can be retried individually
+	l.Errorf("Batch pin submission %s failed: %s", agg.trackingID, err)
+	fb := database.OperationQueryFactory.NewFilter(pa.ctx)
+	ops, _, err2 := pa.database.GetOperations(pa.ctx, fb.And(
+		fb.Eq("backendid", agg.trackingID.String()),
+		fb.Eq("type", fftypes.OpTypeBlockchainBatchPin),
+	))
+	if err2 != nil {
+		l.Errorf("Failed to query operations for batch pin submission %s: %s", agg.trackingID, err2)
+		return
+	}
+	update := database.OperationQueryFactory.NewUpdate(pa.ctx).
+		Set("status", fftypes.OpStatusFailed).
+		Set("error", err.Error())
+	for _, op := range ops {
+		if err2 := pa.database.UpdateOperation(pa.ctx, op.ID, update); err2 != nil {
+			l.Errorf("Failed to update operation %s: %s", op.ID, err2)
+		}
+	}
+}
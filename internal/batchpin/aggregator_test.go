@@ -0,0 +1,127 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchpin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPinAggregator(timeout time.Duration, maxBatches int) *pinAggregator {
+	return newPinAggregator(context.Background(), &databasemocks.Plugin{}, &blockchainmocks.Plugin{}, timeout, maxBatches)
+}
+
+func TestPinAggregatorFlushOnTimeout(t *testing.T) {
+	pa := newTestPinAggregator(1*time.Millisecond, 10)
+	mbi := pa.blockchain.(*blockchainmocks.Plugin)
+
+	pin := &blockchain.BatchPin{Namespace: "ns1"}
+	submitted := make(chan struct{})
+	mbi.On("SubmitBatchPins", mock.Anything, mock.Anything, (*fftypes.UUID)(nil), "0x12345", []*blockchain.BatchPin{pin}).
+		Run(func(args mock.Arguments) { close(submitted) }).
+		Return(nil)
+
+	trackingID := pa.add("0x12345", pin)
+	assert.NotNil(t, trackingID)
+
+	<-submitted
+	mbi.AssertExpectations(t)
+}
+
+func TestPinAggregatorSeparateKeys(t *testing.T) {
+	pa := newTestPinAggregator(1*time.Hour, 10)
+
+	trackingID1 := pa.add("0x12345", &blockchain.BatchPin{Namespace: "ns1"})
+	trackingID2 := pa.add("0x12345", &blockchain.BatchPin{Namespace: "ns2"})
+	trackingID3 := pa.add("0x67890", &blockchain.BatchPin{Namespace: "ns1"})
+	assert.Equal(t, *trackingID1, *trackingID2)
+	assert.NotEqual(t, *trackingID1, *trackingID3)
+	assert.Len(t, pa.pending, 2)
+	assert.Len(t, pa.pending["0x12345"].pins, 2)
+}
+
+func TestPinAggregatorFlushAfterFull(t *testing.T) {
+	pa := newTestPinAggregator(1*time.Hour, 1)
+	mbi := pa.blockchain.(*blockchainmocks.Plugin)
+
+	submitted := make(chan struct{})
+	mbi.On("SubmitBatchPins", mock.Anything, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).
+		Run(func(args mock.Arguments) { close(submitted) }).
+		Return(nil).
+		Once()
+
+	pa.add("0x12345", &blockchain.BatchPin{Namespace: "ns1"})
+	<-submitted
+	assert.Empty(t, pa.pending)
+
+	// A timer that fires after the aggregate was submitted when full does nothing
+	pa.flush(&pinAggregate{signingKey: "0x12345"})
+	mbi.AssertExpectations(t)
+}
+
+func TestPinAggregatorSubmitFail(t *testing.T) {
+	pa := newTestPinAggregator(1*time.Hour, 10)
+	mbi := pa.blockchain.(*blockchainmocks.Plugin)
+	mdi := pa.database.(*databasemocks.Plugin)
+
+	agg := &pinAggregate{
+		trackingID: fftypes.NewUUID(),
+		signingKey: "0x12345",
+		pins:       []*blockchain.BatchPin{{Namespace: "ns1"}, {Namespace: "ns2"}},
+	}
+	ops := []*fftypes.Operation{
+		{ID: fftypes.NewUUID()},
+		{ID: fftypes.NewUUID()},
+	}
+	mbi.On("SubmitBatchPins", pa.ctx, agg.trackingID, (*fftypes.UUID)(nil), "0x12345", agg.pins).Return(fmt.Errorf("pop"))
+	mdi.On("GetOperations", pa.ctx, mock.Anything).Return(ops, nil, nil)
+	mdi.On("UpdateOperation", pa.ctx, ops[0].ID, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("UpdateOperation", pa.ctx, ops[1].ID, mock.Anything).Return(nil)
+
+	pa.submit(agg)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestPinAggregatorSubmitFailGetOperationsFail(t *testing.T) {
+	pa := newTestPinAggregator(1*time.Hour, 10)
+	mbi := pa.blockchain.(*blockchainmocks.Plugin)
+	mdi := pa.database.(*databasemocks.Plugin)
+
+	agg := &pinAggregate{
+		trackingID: fftypes.NewUUID(),
+		signingKey: "0x12345",
+		pins:       []*blockchain.BatchPin{{Namespace: "ns1"}},
+	}
+	mbi.On("SubmitBatchPins", pa.ctx, agg.trackingID, (*fftypes.UUID)(nil), "0x12345", agg.pins).Return(fmt.Errorf("pop"))
+	mdi.On("GetOperations", pa.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	pa.submit(agg)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
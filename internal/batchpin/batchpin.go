@@ -19,32 +19,76 @@ package batchpin
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 type Submitter interface {
+	// PreflightCheck verifies the batch can be pinned by its signing key, and is permitted by the policy plugin. It must not
+	// be called inside a database transaction group, so that any alert or approval it records is not rolled back with the failed submission.
+	PreflightCheck(ctx context.Context, batch *fftypes.Batch) error
 	SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error
+
+	// RetryOperation resubmits the batch pin of a failed blockchain_batch_pin operation
+	RetryOperation(ctx context.Context, op *fftypes.Operation) error
 }
 
 type batchPinSubmitter struct {
 	database       database.Plugin
 	identity       identity.Manager
 	blockchain     blockchain.Plugin
+	preflight      txcommon.PreflightChecker
+	aggregator     *pinAggregator
 	metricsEnabled bool
 }
 
-func NewBatchPinSubmitter(di database.Plugin, im identity.Manager, bi blockchain.Plugin) Submitter {
-	return &batchPinSubmitter{
+func NewBatchPinSubmitter(ctx context.Context, di database.Plugin, im identity.Manager, bi blockchain.Plugin, pf txcommon.PreflightChecker) Submitter {
+	bp := &batchPinSubmitter{
 		database:       di,
 		identity:       im,
 		blockchain:     bi,
+		preflight:      pf,
 		metricsEnabled: config.GetBool(config.MetricsEnabled),
 	}
+	if config.GetBool(config.BatchPinAggregationEnabled) {
+		if bi.Capabilities().BatchPinAggregation {
+			bp.aggregator = newPinAggregator(ctx, di, bi,
+				config.GetDuration(config.BatchPinAggregationTimeout),
+				config.GetInt(config.BatchPinAggregationMaxBatches))
+		} else {
+			log.L(ctx).Warnf("Batch pin aggregation is not supported by blockchain plugin '%s' - batches will be pinned individually", bi.Name())
+		}
+	}
+	return bp
+}
+
+func (bp *batchPinSubmitter) PreflightCheck(ctx context.Context, batch *fftypes.Batch) error {
+	if err := bp.preflight.CheckBalance(ctx, batch.Namespace, batch.Key, batch.ID); err != nil {
+		return err
+	}
+	return bp.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeBatchPin,
+		Namespace:  batch.Namespace,
+		SigningKey: batch.Key,
+		Reference:  batch.ID,
+		Input: fftypes.JSONObject{
+			"batch":    batch.ID,
+			"hash":     batch.Hash,
+			"type":     batch.Type,
+			"author":   batch.Author,
+			"group":    batch.Group,
+			"messages": len(batch.Payload.Messages),
+			"data":     len(batch.Payload.Data),
+		},
+	})
 }
 
 func (bp *batchPinSubmitter) SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
@@ -67,27 +111,65 @@ func (bp *batchPinSubmitter) SubmitPinnedBatch(ctx context.Context, batch *fftyp
 
 	// The pending blockchain transaction
 	op := fftypes.NewTXOperation(
-		bp.blockchain,
+		bimux.ForNamespace(bp.blockchain, batch.Namespace),
 		batch.Namespace,
 		batch.Payload.TX.ID,
 		"",
 		fftypes.OpTypeBlockchainBatchPin,
 		fftypes.OpStatusPending)
+	txcommon.AddBatchPinInputs(op, batch.ID, contexts)
 	err = bp.database.InsertOperation(ctx, op)
 	if err != nil {
 		return err
 	}
+	err = bp.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, batch.Key))
+	if err != nil {
+		return err
+	}
+
+	return bp.submitBatchPin(ctx, op, batch, contexts)
+}
 
+func (bp *batchPinSubmitter) RetryOperation(ctx context.Context, op *fftypes.Operation) error {
+	batchID, contexts, err := txcommon.RetrieveBatchPinInputs(ctx, op)
+	if err != nil {
+		return err
+	}
+	batch, err := bp.database.GetBatchByID(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch == nil {
+		return i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if err = bp.PreflightCheck(ctx, batch); err != nil {
+		return err
+	}
+	if err = bp.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, batch.Key)); err != nil {
+		return err
+	}
+	return bp.submitBatchPin(ctx, op, batch, contexts)
+}
+
+func (bp *batchPinSubmitter) submitBatchPin(ctx context.Context, op *fftypes.Operation, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
 	if bp.metricsEnabled {
 		metrics.BatchPinCounter.Inc()
+		metrics.BlockchainTransactionSubmittedCounter.WithLabelValues(batch.Namespace, string(op.Type)).Inc()
 	}
-	// Write the batch pin to the blockchain
-	return bp.blockchain.SubmitBatchPin(ctx, op.ID, nil /* TODO: ledger selection */, batch.Key, &blockchain.BatchPin{
+	pin := &blockchain.BatchPin{
 		Namespace:      batch.Namespace,
 		TransactionID:  batch.Payload.TX.ID,
 		BatchID:        batch.ID,
 		BatchHash:      batch.Hash,
 		BatchPaylodRef: batch.PayloadRef,
 		Contexts:       contexts,
-	})
+	}
+	if bp.aggregator != nil {
+		// The pin is submitted with the pins of other batches signed by the same key, and the operation
+		// is tracked by the ID of that submission
+		trackingID := bp.aggregator.add(batch.Key, pin)
+		return bp.database.UpdateOperation(ctx, op.ID, database.OperationQueryFactory.NewUpdate(ctx).Set("backendid", trackingID.String()))
+	}
+	// Write the batch pin to the blockchain
+	return bp.blockchain.SubmitBatchPin(ctx, op.ID, nil /* TODO: ledger selection */, batch.Key, pin)
 }
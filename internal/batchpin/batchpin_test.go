@@ -19,14 +19,20 @@ package batchpin
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -37,8 +43,60 @@ func newTestBatchPinSubmitter(t *testing.T) *batchPinSubmitter {
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
 	mbi := &blockchainmocks.Plugin{}
+	mpf := &txcommonmocks.PreflightChecker{}
 	mbi.On("Name").Return("ut").Maybe()
-	return NewBatchPinSubmitter(mdi, mim, mbi).(*batchPinSubmitter)
+	return NewBatchPinSubmitter(context.Background(), mdi, mim, mbi, mpf).(*batchPinSubmitter)
+}
+
+func TestPreflightCheck(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Key: "0x12345",
+		},
+	}
+	mpf := bp.preflight.(*txcommonmocks.PreflightChecker)
+	mpf.On("CheckBalance", ctx, "ns1", "0x12345", batch.ID).Return(fmt.Errorf("pop"))
+
+	err := bp.PreflightCheck(ctx, batch)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
+}
+
+func TestPreflightCheckPolicy(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/org1",
+			Key:    "0x12345",
+		},
+		Hash: fftypes.NewRandB32(),
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{}},
+		},
+	}
+	mpf := bp.preflight.(*txcommonmocks.PreflightChecker)
+	mpf.On("CheckBalance", ctx, "ns1", "0x12345", batch.ID).Return(nil)
+	mpf.On("CheckPolicy", ctx, mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeBatchPin &&
+			req.Namespace == "ns1" &&
+			req.SigningKey == "0x12345" &&
+			req.Reference == batch.ID &&
+			req.Input["hash"] == batch.Hash &&
+			req.Input["messages"] == 1
+	})).Return(fmt.Errorf("pop"))
+
+	err := bp.PreflightCheck(ctx, batch)
+	assert.EqualError(t, err, "pop")
+	mpf.AssertExpectations(t)
 }
 
 func TestSubmitPinnedBatchOk(t *testing.T) {
@@ -67,8 +125,10 @@ func TestSubmitPinnedBatchOk(t *testing.T) {
 		assert.Equal(t, fftypes.OpTypeBlockchainBatchPin, op.Type)
 		assert.Equal(t, "ut", op.Plugin)
 		assert.Equal(t, *batch.Payload.TX.ID, *op.Transaction)
+		assert.Equal(t, batch.ID.String(), op.Input.GetString("batch"))
 		return true
 	})).Return(nil)
+	mdi.On("InsertSigningActivity", ctx, mock.Anything).Return(nil)
 	mbi.On("SubmitBatchPin", ctx, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).Return(nil)
 
 	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
@@ -85,7 +145,8 @@ func TestSubmitPinnedBatchWithMetricsOk(t *testing.T) {
 	mdi := bp.database.(*databasemocks.Plugin)
 
 	batch := &fftypes.Batch{
-		ID: fftypes.NewUUID(),
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
 		Identity: fftypes.Identity{
 			Author: "id1",
 			Key:    "0x12345",
@@ -105,10 +166,12 @@ func TestSubmitPinnedBatchWithMetricsOk(t *testing.T) {
 		assert.Equal(t, *batch.Payload.TX.ID, *op.Transaction)
 		return true
 	})).Return(nil)
+	mdi.On("InsertSigningActivity", ctx, mock.Anything).Return(nil)
 	mbi.On("SubmitBatchPin", ctx, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.Anything).Return(nil)
 
 	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
 	assert.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.BlockchainTransactionSubmittedCounter.WithLabelValues("ns1", string(fftypes.OpTypeBlockchainBatchPin))))
 }
 
 func TestSubmitPinnedBatchOpFail(t *testing.T) {
@@ -140,6 +203,36 @@ func TestSubmitPinnedBatchOpFail(t *testing.T) {
 
 }
 
+func TestSubmitPinnedBatchSigningActivityFail(t *testing.T) {
+
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mdi := bp.database.(*databasemocks.Plugin)
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "id1",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	contexts := []*fftypes.Bytes32{}
+
+	mdi.On("UpsertTransaction", ctx, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bp.SubmitPinnedBatch(ctx, batch, contexts)
+	assert.Regexp(t, "pop", err)
+
+}
+
 func TestSubmitPinnedBatchTxInsertFail(t *testing.T) {
 
 	bp := newTestBatchPinSubmitter(t)
@@ -167,3 +260,188 @@ func TestSubmitPinnedBatchTxInsertFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 
 }
+
+func TestRetryOperationOk(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mbi := bp.blockchain.(*blockchainmocks.Plugin)
+	mdi := bp.database.(*databasemocks.Plugin)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "id1",
+			Key:    "0x12345",
+		},
+		Hash: fftypes.NewRandB32(),
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	contexts := []*fftypes.Bytes32{fftypes.NewRandB32()}
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeBlockchainBatchPin,
+	}
+	txcommon.AddBatchPinInputs(op, batch.ID, contexts)
+
+	mpf := bp.preflight.(*txcommonmocks.PreflightChecker)
+	mpf.On("CheckBalance", ctx, "ns1", "0x12345", batch.ID).Return(nil)
+	mpf.On("CheckPolicy", ctx, mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeBatchPin && req.Reference.Equals(batch.ID)
+	})).Return(nil)
+	mdi.On("GetBatchByID", ctx, batch.ID).Return(batch, nil)
+	mdi.On("InsertSigningActivity", ctx, mock.MatchedBy(func(sa *fftypes.SigningActivity) bool {
+		return sa.Operation.Equals(op.ID) && sa.Key == "0x12345"
+	})).Return(nil)
+	mbi.On("SubmitBatchPin", ctx, op.ID, (*fftypes.UUID)(nil), "0x12345", mock.MatchedBy(func(pin *blockchain.BatchPin) bool {
+		return pin.BatchID.Equals(batch.ID) &&
+			pin.TransactionID.Equals(batch.Payload.TX.ID) &&
+			pin.BatchHash.Equals(batch.Hash) &&
+			pin.Contexts[0].Equals(contexts[0])
+	})).Return(nil)
+
+	err := bp.RetryOperation(ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+	mpf.AssertExpectations(t)
+}
+
+func TestRetryOperationPreflightFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mdi := bp.database.(*databasemocks.Plugin)
+	mpf := bp.preflight.(*txcommonmocks.PreflightChecker)
+	op := &fftypes.Operation{}
+	txcommon.AddBatchPinInputs(op, fftypes.NewUUID(), []*fftypes.Bytes32{})
+	mdi.On("GetBatchByID", ctx, mock.Anything).Return(&fftypes.Batch{}, nil)
+	mpf.On("CheckBalance", ctx, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bp.RetryOperation(ctx, op)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertNotCalled(t, "InsertSigningActivity", mock.Anything, mock.Anything)
+}
+
+func TestRetryOperationBadInputs(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+
+	err := bp.RetryOperation(context.Background(), &fftypes.Operation{})
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestRetryOperationGetBatchFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mdi := bp.database.(*databasemocks.Plugin)
+	op := &fftypes.Operation{}
+	txcommon.AddBatchPinInputs(op, fftypes.NewUUID(), []*fftypes.Bytes32{})
+	mdi.On("GetBatchByID", ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := bp.RetryOperation(ctx, op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchNotFound(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mdi := bp.database.(*databasemocks.Plugin)
+	op := &fftypes.Operation{}
+	txcommon.AddBatchPinInputs(op, fftypes.NewUUID(), []*fftypes.Bytes32{})
+	mdi.On("GetBatchByID", ctx, mock.Anything).Return(nil, nil)
+
+	err := bp.RetryOperation(ctx, op)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationSigningActivityFail(t *testing.T) {
+	bp := newTestBatchPinSubmitter(t)
+	ctx := context.Background()
+
+	mdi := bp.database.(*databasemocks.Plugin)
+	op := &fftypes.Operation{}
+	txcommon.AddBatchPinInputs(op, fftypes.NewUUID(), []*fftypes.Bytes32{})
+	mpf := bp.preflight.(*txcommonmocks.PreflightChecker)
+	mpf.On("CheckBalance", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", ctx, mock.Anything).Return(nil)
+	mdi.On("GetBatchByID", ctx, mock.Anything).Return(&fftypes.Batch{}, nil)
+	mdi.On("InsertSigningActivity", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bp.RetryOperation(ctx, op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestNewBatchPinSubmitterAggregationNotSupported(t *testing.T) {
+	config.Reset()
+	config.Set(config.BatchPinAggregationEnabled, true)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ut")
+	mbi.On("Capabilities").Return(&blockchain.Capabilities{})
+	bp := NewBatchPinSubmitter(context.Background(), &databasemocks.Plugin{}, &identitymanagermocks.Manager{}, mbi, &txcommonmocks.PreflightChecker{}).(*batchPinSubmitter)
+	assert.Nil(t, bp.aggregator)
+	mbi.AssertExpectations(t)
+}
+
+func TestSubmitPinnedBatchAggregated(t *testing.T) {
+	config.Reset()
+	config.Set(config.BatchPinAggregationEnabled, true)
+	config.Set(config.BatchPinAggregationMaxBatches, 2)
+	config.Set(config.BatchPinAggregationTimeout, "1h")
+	mdi := &databasemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ut")
+	mbi.On("Capabilities").Return(&blockchain.Capabilities{BatchPinAggregation: true})
+	bp := NewBatchPinSubmitter(context.Background(), mdi, &identitymanagermocks.Manager{}, mbi, &txcommonmocks.PreflightChecker{}).(*batchPinSubmitter)
+	ctx := context.Background()
+
+	newBatch := func(ns string) *fftypes.Batch {
+		return &fftypes.Batch{
+			ID:        fftypes.NewUUID(),
+			Namespace: ns,
+			Identity: fftypes.Identity{
+				Author: "id1",
+				Key:    "0x12345",
+			},
+			Payload: fftypes.BatchPayload{
+				TX: fftypes.TransactionRef{
+					ID: fftypes.NewUUID(),
+				},
+			},
+		}
+	}
+
+	var trackingIDs []string
+	mdi.On("UpsertTransaction", ctx, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", ctx, mock.Anything, mock.MatchedBy(func(update database.Update) bool {
+		info, _ := update.Finalize()
+		trackingIDs = append(trackingIDs, info.String())
+		return strings.HasPrefix(info.String(), "backendid=")
+	})).Return(nil).Twice()
+	submitted := make(chan struct{})
+	mbi.On("SubmitBatchPins", mock.Anything, mock.Anything, (*fftypes.UUID)(nil), "0x12345", mock.MatchedBy(func(pins []*blockchain.BatchPin) bool {
+		return len(pins) == 2 && pins[0].Namespace == "ns1" && pins[1].Namespace == "ns2"
+	})).Run(func(args mock.Arguments) {
+		assert.Contains(t, trackingIDs[0], args[1].(*fftypes.UUID).String())
+		close(submitted)
+	}).Return(nil)
+
+	err := bp.SubmitPinnedBatch(ctx, newBatch("ns1"), []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	err = bp.SubmitPinnedBatch(ctx, newBatch("ns2"), []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+
+	<-submitted
+	assert.Equal(t, trackingIDs[0], trackingIDs[1])
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
@@ -26,29 +26,39 @@ import (
 	"github.com/hyperledger/firefly/pkg/blockchain"
 )
 
-var plugins = []blockchain.Plugin{
-	&ethereum.Ethereum{},
-	&fabric.Fabric{},
+// Each instance of a plugin holds its own connection state, so a new instance is constructed
+// for every blockchain connector that is configured
+var pluginFactories = []func() blockchain.Plugin{
+	func() blockchain.Plugin { return &ethereum.Ethereum{} },
+	func() blockchain.Plugin { return &fabric.Fabric{} },
 }
 
-var pluginsByName = make(map[string]blockchain.Plugin)
+var pluginsByName = make(map[string]func() blockchain.Plugin)
 
 func init() {
-	for _, p := range plugins {
-		pluginsByName[p.Name()] = p
+	for _, factory := range pluginFactories {
+		pluginsByName[factory().Name()] = factory
 	}
 }
 
 func InitPrefix(prefix config.Prefix) {
-	for _, plugin := range plugins {
+	for _, factory := range pluginFactories {
+		plugin := factory()
 		plugin.InitPrefix(prefix.SubPrefix(plugin.Name()))
 	}
 }
 
+// InitPrefixArray initializes the keys of each named blockchain connector. The keys specific to the type of plugin
+// are initialized when each connector is loaded, as the defaults of an array entry can only be applied once it exists.
+func InitPrefixArray(prefix config.PrefixArray) {
+	prefix.AddKnownKey(blockchain.BlockchainConfigName)
+	prefix.AddKnownKey(blockchain.BlockchainConfigType)
+}
+
 func GetPlugin(ctx context.Context, pluginType string) (blockchain.Plugin, error) {
-	plugin, ok := pluginsByName[pluginType]
+	factory, ok := pluginsByName[pluginType]
 	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownBlockchainPlugin, pluginType)
 	}
-	return plugin, nil
+	return factory(), nil
 }
@@ -0,0 +1,163 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bimux
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Multiplexer presents multiple blockchain connectors as a single plugin, so a FireFly core can bridge
+// multiple chains. Each namespace is bound to a named connector, and calls that carry a namespace are
+// routed to that connector. Namespaces that are not bound use the default connector.
+type Multiplexer struct {
+	defaultConnector string
+	connectors       map[string]blockchain.Plugin
+	namespaces       map[string]string
+}
+
+// NewMultiplexer creates a multiplexer over initialized connectors, with a map of namespace names to connector names
+func NewMultiplexer(defaultConnector string, connectors map[string]blockchain.Plugin, namespaces map[string]string) *Multiplexer {
+	return &Multiplexer{
+		defaultConnector: defaultConnector,
+		connectors:       connectors,
+		namespaces:       namespaces,
+	}
+}
+
+// ForNamespace returns the connector a namespace is bound to, when the supplied plugin is a multiplexer,
+// or the supplied plugin itself otherwise
+func ForNamespace(bi blockchain.Plugin, ns string) blockchain.Plugin {
+	if m, ok := bi.(*Multiplexer); ok {
+		return m.ForNamespace(ns)
+	}
+	return bi
+}
+
+// ConnectorName returns the name of the connector a namespace is bound to
+func (m *Multiplexer) ConnectorName(ns string) string {
+	if name, ok := m.namespaces[ns]; ok {
+		return name
+	}
+	return m.defaultConnector
+}
+
+// Connector returns the named connector, or nil if there is no connector with the name
+func (m *Multiplexer) Connector(name string) blockchain.Plugin {
+	return m.connectors[name]
+}
+
+func (m *Multiplexer) ForNamespace(ns string) blockchain.Plugin {
+	return m.connectors[m.ConnectorName(ns)]
+}
+
+func (m *Multiplexer) sortedConnectors() []blockchain.Plugin {
+	names := make([]string, 0, len(m.connectors))
+	for name := range m.connectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	plugins := make([]blockchain.Plugin, len(names))
+	for i, name := range names {
+		plugins[i] = m.connectors[name]
+	}
+	return plugins
+}
+
+func (m *Multiplexer) Name() string {
+	return "multiplexer"
+}
+
+// InitPrefix is a no-op, as the config of each connector is initialized when it is loaded
+func (m *Multiplexer) InitPrefix(prefix config.Prefix) {}
+
+// Init is a no-op, as the connectors are initialized before being multiplexed
+func (m *Multiplexer) Init(ctx context.Context, prefix config.Prefix, callbacks blockchain.Callbacks) error {
+	return nil
+}
+
+func (m *Multiplexer) Start() error {
+	for _, plugin := range m.sortedConnectors() {
+		if err := plugin.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Capabilities are those supported by every connector. Batch pins are never aggregated, as the batches
+// aggregated into a single transaction might be in namespaces bound to different connectors.
+func (m *Multiplexer) Capabilities() *blockchain.Capabilities {
+	capabilities := &blockchain.Capabilities{
+		GlobalSequencer: true,
+	}
+	for _, plugin := range m.sortedConnectors() {
+		capabilities.GlobalSequencer = capabilities.GlobalSequencer && plugin.Capabilities().GlobalSequencer
+	}
+	return capabilities
+}
+
+func (m *Multiplexer) ResolveSigningKey(ctx context.Context, ns, signingKey string) (string, error) {
+	return m.ForNamespace(ns).ResolveSigningKey(ctx, ns, signingKey)
+}
+
+func (m *Multiplexer) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
+	return m.ForNamespace(batch.Namespace).SubmitBatchPin(ctx, operationID, ledgerID, signingKey, batch)
+}
+
+func (m *Multiplexer) SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*blockchain.BatchPin) error {
+	return m.ForNamespace(batches[0].Namespace).SubmitBatchPins(ctx, operationID, ledgerID, signingKey, batches)
+}
+
+func (m *Multiplexer) GetNativeBalance(ctx context.Context, ns, signingKey string) (*fftypes.BigInt, error) {
+	return m.ForNamespace(ns).GetNativeBalance(ctx, ns, signingKey)
+}
+
+func (m *Multiplexer) InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error {
+	return m.ForNamespace(ns).InvokeContract(ctx, ns, operationID, signingKey, location, method, input)
+}
+
+func (m *Multiplexer) QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error) {
+	return m.ForNamespace(ns).QueryContract(ctx, ns, location, method, input)
+}
+
+func (m *Multiplexer) NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error) {
+	return m.ForNamespace(ns).NormalizeContractLocation(ctx, ns, location)
+}
+
+func (m *Multiplexer) AddContractListener(ctx context.Context, listener *fftypes.ContractListener) error {
+	return m.ForNamespace(listener.Namespace).AddContractListener(ctx, listener)
+}
+
+func (m *Multiplexer) GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee {
+	return m.ForNamespace(ns).GetTransactionFee(ns, receipt)
+}
+
+// ResetEventStream rewinds every connector, as a replay is not scoped to a namespace.
+// A single connector can be rewound by calling ResetEventStream on Connector(name) directly.
+func (m *Multiplexer) ResetEventStream(ctx context.Context, fromBlock string) error {
+	for _, plugin := range m.sortedConnectors() {
+		if err := plugin.ResetEventStream(ctx, fromBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
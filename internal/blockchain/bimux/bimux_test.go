@@ -0,0 +1,159 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bimux
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestMultiplexer() (*Multiplexer, *blockchainmocks.Plugin, *blockchainmocks.Plugin) {
+	mbi1 := &blockchainmocks.Plugin{}
+	mbi2 := &blockchainmocks.Plugin{}
+	m := NewMultiplexer("chain1", map[string]blockchain.Plugin{
+		"chain1": mbi1,
+		"chain2": mbi2,
+	}, map[string]string{
+		"ns2": "chain2",
+	})
+	return m, mbi1, mbi2
+}
+
+func TestForNamespace(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	assert.Equal(t, mbi1, ForNamespace(m, "ns1"))
+	assert.Equal(t, mbi2, ForNamespace(m, "ns2"))
+	assert.Equal(t, mbi1, ForNamespace(mbi1, "ns2"))
+	assert.Equal(t, "chain2", m.ConnectorName("ns2"))
+	assert.Equal(t, mbi2, m.Connector("chain2"))
+	assert.Nil(t, m.Connector("chain3"))
+	assert.Equal(t, "multiplexer", m.Name())
+}
+
+func TestInitNoop(t *testing.T) {
+	m, _, _ := newTestMultiplexer()
+	m.InitPrefix(config.NewPluginConfig("blockchain"))
+	assert.NoError(t, m.Init(context.Background(), config.NewPluginConfig("blockchain"), nil))
+}
+
+func TestStart(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	mbi1.On("Start").Return(nil)
+	mbi2.On("Start").Return(nil)
+	assert.NoError(t, m.Start())
+	mbi1.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestStartFail(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	mbi1.On("Start").Return(fmt.Errorf("pop"))
+	assert.EqualError(t, m.Start(), "pop")
+	mbi1.AssertExpectations(t)
+	mbi2.AssertNotCalled(t, "Start")
+}
+
+func TestCapabilities(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	mbi1.On("Capabilities").Return(&blockchain.Capabilities{GlobalSequencer: true, BatchPinAggregation: true})
+	mbi2.On("Capabilities").Return(&blockchain.Capabilities{GlobalSequencer: false, BatchPinAggregation: true})
+	assert.Equal(t, &blockchain.Capabilities{}, m.Capabilities())
+}
+
+func testRoutedCalls(t *testing.T, m *Multiplexer, target *blockchainmocks.Plugin, ns string) {
+	ctx := context.Background()
+	opID := fftypes.NewUUID()
+	pin := &blockchain.BatchPin{Namespace: ns}
+	listener := &fftypes.ContractListener{Namespace: ns}
+	location := fftypes.JSONObject{"address": "0x12345"}
+	method := &fftypes.FFIMethod{Name: "sum"}
+	input := fftypes.JSONObject{"a": 1}
+	receipt := fftypes.JSONObject{"gasUsed": "1"}
+
+	target.On("SubmitBatchPin", ctx, opID, (*fftypes.UUID)(nil), "0x12345", pin).Return(nil)
+	target.On("SubmitBatchPins", ctx, opID, (*fftypes.UUID)(nil), "0x12345", []*blockchain.BatchPin{pin}).Return(nil)
+	target.On("AddContractListener", ctx, listener).Return(nil)
+	target.On("ResolveSigningKey", ctx, ns, "0xABCDE").Return("0xabcde", nil)
+	target.On("GetNativeBalance", ctx, ns, "0xabcde").Return(fftypes.NewBigInt(1), nil)
+	target.On("InvokeContract", ctx, ns, opID, "0xabcde", location, method, input).Return(nil)
+	target.On("QueryContract", ctx, ns, location, method, input).Return("3", nil)
+	target.On("NormalizeContractLocation", ctx, ns, location).Return(location, nil)
+	target.On("GetTransactionFee", ns, receipt).Return(&fftypes.TransactionFee{})
+
+	assert.NoError(t, m.SubmitBatchPin(ctx, opID, nil, "0x12345", pin))
+	assert.NoError(t, m.SubmitBatchPins(ctx, opID, nil, "0x12345", []*blockchain.BatchPin{pin}))
+	assert.NoError(t, m.AddContractListener(ctx, listener))
+	key, err := m.ResolveSigningKey(ctx, ns, "0xABCDE")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xabcde", key)
+	balance, err := m.GetNativeBalance(ctx, ns, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), balance.Int().Int64())
+	assert.NoError(t, m.InvokeContract(ctx, ns, opID, key, location, method, input))
+	result, err := m.QueryContract(ctx, ns, location, method, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", result)
+	normalized, err := m.NormalizeContractLocation(ctx, ns, location)
+	assert.NoError(t, err)
+	assert.Equal(t, location, normalized)
+	assert.NotNil(t, m.GetTransactionFee(ns, receipt))
+}
+
+func TestRoutedByNamespace(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	testRoutedCalls(t, m, mbi2, "ns2")
+	mbi1.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestDefaultConnector(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	testRoutedCalls(t, m, mbi1, "ns1")
+	mbi1.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestResetEventStream(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	mbi1.On("ResetEventStream", mock.Anything, "100").Return(nil)
+	mbi2.On("ResetEventStream", mock.Anything, "100").Return(nil)
+	assert.NoError(t, m.ResetEventStream(context.Background(), "100"))
+	mbi1.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestResetEventStreamFail(t *testing.T) {
+	m, mbi1, mbi2 := newTestMultiplexer()
+	mbi1.On("ResetEventStream", mock.Anything, "100").Return(fmt.Errorf("pop"))
+	assert.EqualError(t, m.ResetEventStream(context.Background(), "100"), "pop")
+	mbi2.AssertNotCalled(t, "ResetEventStream", mock.Anything, mock.Anything)
+}
+
+func TestSubmitBatchPinFail(t *testing.T) {
+	m, mbi1, _ := newTestMultiplexer()
+	mbi1.On("SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := m.SubmitBatchPin(context.Background(), fftypes.NewUUID(), nil, "0x12345", &blockchain.BatchPin{Namespace: "ns1"})
+	assert.EqualError(t, err, "pop")
+}
@@ -19,6 +19,7 @@ package ethereum
 import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
+	"github.com/hyperledger/firefly/internal/restclient"
 )
 
 const (
@@ -45,6 +46,9 @@ const (
 	EthconnectPrefixShort = "prefixShort"
 	// EthconnectPrefixLong is used in HTTP headers in requests to ethconnect
 	EthconnectPrefixLong = "prefixLong"
+
+	// EthereumRPCConfigKey is a sub-key in the config for an optional JSON/RPC endpoint of an ethereum node, used for queries such as native balances
+	EthereumRPCConfigKey = "rpc"
 )
 
 func (e *Ethereum) InitPrefix(prefix config.Prefix) {
@@ -56,4 +60,6 @@ func (e *Ethereum) InitPrefix(prefix config.Prefix) {
 	ethconnectConf.AddKnownKey(EthconnectConfigBatchTimeout, defaultBatchTimeout)
 	ethconnectConf.AddKnownKey(EthconnectPrefixShort, defaultPrefixShort)
 	ethconnectConf.AddKnownKey(EthconnectPrefixLong, defaultPrefixLong)
+
+	restclient.InitPrefix(prefix.SubPrefix(EthereumRPCConfigKey))
 }
@@ -20,7 +20,10 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math/big"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
@@ -29,9 +32,11 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/wsclient"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -47,6 +52,8 @@ type Ethereum struct {
 	capabilities *blockchain.Capabilities
 	callbacks    blockchain.Callbacks
 	client       *resty.Client
+	rpcClient    *resty.Client
+	streams      *streamManager
 	initInfo     struct {
 		stream *eventStream
 		subs   []*subscription
@@ -71,6 +78,61 @@ type ethBatchPinInput struct {
 	Contexts   []string `json:"contexts"`
 }
 
+type ethBatchPinsInput struct {
+	Namespaces  []string   `json:"namespaces"`
+	UUIDs       []string   `json:"uuids"`
+	BatchHashes []string   `json:"batchHashes"`
+	PayloadRefs []string   `json:"payloadRefs"`
+	Contexts    [][]string `json:"contexts"`
+}
+
+type ethABIParam struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Indexed bool   `json:"indexed,omitempty"`
+}
+
+type ethABIMethod struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Inputs  []*ethABIParam `json:"inputs"`
+	Outputs []*ethABIParam `json:"outputs"`
+}
+
+type ethABIEvent struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Inputs []*ethABIParam `json:"inputs"`
+}
+
+type ethContractRequestHeaders struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+type ethContractRequest struct {
+	Headers ethContractRequestHeaders `json:"headers"`
+	From    string                    `json:"from,omitempty"`
+	To      string                    `json:"to"`
+	Method  *ethABIMethod             `json:"method"`
+	Params  []interface{}             `json:"params"`
+}
+
+type ethRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type ethRPCResponse struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int64  `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
 type ethWSCommandPayload struct {
 	Type  string `json:"type"`
 	Topic string `json:"topic,omitempty"`
@@ -90,7 +152,7 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 
 	ethconnectConf := prefix.SubPrefix(EthconnectConfigKey)
 
-	e.ctx = log.WithLogField(ctx, "proto", "ethereum")
+	e.ctx = log.WithComponent(log.WithLogField(ctx, "proto", "ethereum"), "blockchain")
 	e.callbacks = callbacks
 
 	if ethconnectConf.GetString(restclient.HTTPConfigURL) == "" {
@@ -108,34 +170,47 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 	e.prefixShort = ethconnectConf.GetString(EthconnectPrefixShort)
 	e.prefixLong = ethconnectConf.GetString(EthconnectPrefixLong)
 
-	e.client = restclient.New(e.ctx, ethconnectConf)
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(e.ctx, ethconnectConf)
+	if err == nil {
+		e.client, err = restclient.New(e.ctx, ethconnectConf)
+	}
+	rpcConf := prefix.SubPrefix(EthereumRPCConfigKey)
+	if err == nil && rpcConf.GetString(restclient.HTTPConfigURL) != "" {
+		e.rpcClient, err = restclient.New(e.ctx, rpcConf)
+	}
+	if err != nil {
+		return err
+	}
 	e.capabilities = &blockchain.Capabilities{
-		GlobalSequencer: true,
+		GlobalSequencer:     true,
+		BatchPinAggregation: true,
 	}
 
-	wsConfig := wsconfig.GenerateConfigFromPrefix(ethconnectConf)
-
 	if wsConfig.WSKeyPath == "" {
 		wsConfig.WSKeyPath = "/ws"
 	}
 
-	e.wsconn, err = wsclient.New(ctx, wsConfig, e.afterConnect)
+	e.wsconn, err = wsclient.New(ctx, wsConfig, nil)
+	if err == nil {
+		err = e.subscribe(ctx)
+	}
 	if err != nil {
 		return err
 	}
+	e.wsconn.AddReconnectListener(e.reconnected)
 
-	streams := streamManager{
+	e.streams = &streamManager{
 		ctx:          e.ctx,
 		client:       e.client,
 		instancePath: e.instancePath,
 	}
 	batchSize := ethconnectConf.GetUint(EthconnectConfigBatchSize)
 	batchTimeout := uint(ethconnectConf.GetDuration(EthconnectConfigBatchTimeout).Milliseconds())
-	if e.initInfo.stream, err = streams.ensureEventStream(e.topic, batchSize, batchTimeout); err != nil {
+	if e.initInfo.stream, err = e.streams.ensureEventStream(e.topic, batchSize, batchTimeout); err != nil {
 		return err
 	}
 	log.L(e.ctx).Infof("Event stream: %s", e.initInfo.stream.ID)
-	if e.initInfo.subs, err = streams.ensureSubscriptions(e.initInfo.stream.ID, requiredSubscriptions); err != nil {
+	if e.initInfo.subs, err = e.streams.ensureSubscriptions(e.initInfo.stream.ID, requiredSubscriptions); err != nil {
 		return err
 	}
 
@@ -153,22 +228,26 @@ func (e *Ethereum) Capabilities() *blockchain.Capabilities {
 	return e.capabilities
 }
 
-func (e *Ethereum) afterConnect(ctx context.Context, w wsclient.WSClient) error {
-	// Send a subscribe to our topic after each connect/reconnect
+func (e *Ethereum) subscribe(ctx context.Context) error {
+	// Subscribe to our topic, and to replies - the WS client replays these after each reconnect
 	b, _ := json.Marshal(&ethWSCommandPayload{
 		Type:  "listen",
 		Topic: e.topic,
 	})
-	err := w.Send(ctx, b)
+	err := e.wsconn.Subscribe(ctx, b)
 	if err == nil {
 		b, _ = json.Marshal(&ethWSCommandPayload{
 			Type: "listenreplies",
 		})
-		err = w.Send(ctx, b)
+		err = e.wsconn.Subscribe(ctx, b)
 	}
 	return err
 }
 
+func (e *Ethereum) reconnected(ctx context.Context, event *wsclient.WSReconnectEvent) {
+	log.L(ctx).Warnf("Reconnected to ethconnect at %s (reconnects=%d): resubscribed to topic '%s'", event.URL, event.Reconnects, e.topic)
+}
+
 func ethHexFormatB32(b *fftypes.Bytes32) string {
 	if b == nil {
 		return "0x0000000000000000000000000000000000000000000000000000000000000000"
@@ -240,6 +319,12 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 		BatchPaylodRef: sPayloadRef,
 		Contexts:       contexts,
 	}
+	if sTimestamp := msgJSON.GetString("timestamp"); sTimestamp != "" {
+		if batch.Timestamp, err = fftypes.ParseString(sTimestamp); err != nil {
+			log.L(ctx).Warnf("BatchPin event has an invalid block timestamp '%s'", sTimestamp)
+			batch.Timestamp = nil
+		}
+	}
 
 	// If there's an error dispatching the event, we must return the error and shutdown
 	delete(msgJSON, "data")
@@ -271,6 +356,34 @@ func (e *Ethereum) handleReceipt(ctx context.Context, reply fftypes.JSONObject)
 	return e.callbacks.BlockchainOpUpdate(operationID, updateType, message, reply)
 }
 
+func (e *Ethereum) buildBlockchainEvent(msgJSON fftypes.JSONObject, signature string) *fftypes.BlockchainEvent {
+	blockNumber, _ := strconv.ParseInt(msgJSON.GetString("blockNumber"), 0, 64)
+	txIndex, _ := strconv.ParseInt(msgJSON.GetString("transactionIndex"), 0, 64)
+	logIndex, _ := strconv.ParseInt(msgJSON.GetString("logIndex"), 0, 64)
+	dataJSON := msgJSON.GetObject("data")
+	info := fftypes.JSONObject{}
+	for k, v := range msgJSON {
+		if k != "data" {
+			info[k] = v
+		}
+	}
+
+	event := &fftypes.BlockchainEvent{
+		Name:         strings.SplitN(signature, "(", 2)[0],
+		ProtocolID:   fmt.Sprintf("%.12d/%.6d/%.6d", blockNumber, txIndex, logIndex),
+		Address:      msgJSON.GetString("address"),
+		BlockNumber:  blockNumber,
+		ProtocolTxID: msgJSON.GetString("transactionHash"),
+		Listener:     msgJSON.GetString("subID"),
+		Output:       dataJSON,
+		Info:         info,
+	}
+	if signature == broadcastBatchEventSignature {
+		event.Namespace = dataJSON.GetString("namespace")
+	}
+	return event
+}
+
 func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{}) error {
 	l := log.L(ctx)
 
@@ -288,6 +401,11 @@ func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{
 		l1.Infof("Received '%s' message", signature)
 		l1.Tracef("Message: %+v", msgJSON)
 
+		// Every event is recorded in the blockchain event index, before any specific processing
+		if err := e.callbacks.BlockchainEvent(e.buildBlockchainEvent(msgJSON, signature)); err != nil {
+			return err
+		}
+
 		switch signature {
 		case broadcastBatchEventSignature:
 			if err := e.handleBatchPinEvent(ctx1, msgJSON); err != nil {
@@ -345,7 +463,7 @@ func (e *Ethereum) eventLoop() {
 	}
 }
 
-func (e *Ethereum) ResolveSigningKey(ctx context.Context, signingKeyInput string) (signingKey string, err error) {
+func (e *Ethereum) ResolveSigningKey(ctx context.Context, ns, signingKeyInput string) (signingKey string, err error) {
 	return e.validateEthAddress(ctx, signingKeyInput)
 }
 
@@ -358,7 +476,12 @@ func (e *Ethereum) validateEthAddress(ctx context.Context, identity string) (str
 }
 
 func (e *Ethereum) invokeContractMethod(ctx context.Context, method, signingKey string, requestID string, input interface{}, output interface{}) (*resty.Response, error) {
-	return e.client.R().
+	// The request ID is the operation ID, which the connector returns on the receipt
+	ctx, span := tracing.StartSpan(ctx, "ethereum.invoke",
+		attribute.String("ethconnect.method", method),
+		attribute.String("ethconnect.requestId", requestID),
+	)
+	res, err := e.client.R().
 		SetContext(ctx).
 		SetQueryParam(e.prefixShort+"-from", signingKey).
 		SetQueryParam(e.prefixShort+"-sync", "false").
@@ -366,23 +489,33 @@ func (e *Ethereum) invokeContractMethod(ctx context.Context, method, signingKey
 		SetBody(input).
 		SetResult(output).
 		Post(e.instancePath + "/" + method)
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode()))
+	}
+	tracing.EndSpan(span, err)
+	return res, err
 }
 
-func (e *Ethereum) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
-	tx := &asyncTXSubmission{}
-	ethHashes := make([]string, len(batch.Contexts))
+func ethBatchPinValues(batch *blockchain.BatchPin) (uuids, batchHash string, contexts []string) {
+	contexts = make([]string, len(batch.Contexts))
 	for i, v := range batch.Contexts {
-		ethHashes[i] = ethHexFormatB32(v)
+		contexts[i] = ethHexFormatB32(v)
 	}
-	var uuids fftypes.Bytes32
-	copy(uuids[0:16], (*batch.TransactionID)[:])
-	copy(uuids[16:32], (*batch.BatchID)[:])
+	var uuidBytes fftypes.Bytes32
+	copy(uuidBytes[0:16], (*batch.TransactionID)[:])
+	copy(uuidBytes[16:32], (*batch.BatchID)[:])
+	return ethHexFormatB32(&uuidBytes), ethHexFormatB32(batch.BatchHash), contexts
+}
+
+func (e *Ethereum) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
+	tx := &asyncTXSubmission{}
+	uuids, batchHash, contexts := ethBatchPinValues(batch)
 	input := &ethBatchPinInput{
 		Namespace:  batch.Namespace,
-		UUIDs:      ethHexFormatB32(&uuids),
-		BatchHash:  ethHexFormatB32(batch.BatchHash),
+		UUIDs:      uuids,
+		BatchHash:  batchHash,
 		PayloadRef: batch.BatchPaylodRef,
-		Contexts:   ethHashes,
+		Contexts:   contexts,
 	}
 	res, err := e.invokeContractMethod(ctx, "pinBatch", signingKey, operationID.String(), input, tx)
 	if err != nil || !res.IsSuccess() {
@@ -390,3 +523,203 @@ func (e *Ethereum) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID
 	}
 	return nil
 }
+
+func (e *Ethereum) SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*blockchain.BatchPin) error {
+	tx := &asyncTXSubmission{}
+	input := &ethBatchPinsInput{
+		Namespaces:  make([]string, len(batches)),
+		UUIDs:       make([]string, len(batches)),
+		BatchHashes: make([]string, len(batches)),
+		PayloadRefs: make([]string, len(batches)),
+		Contexts:    make([][]string, len(batches)),
+	}
+	for i, batch := range batches {
+		input.Namespaces[i] = batch.Namespace
+		input.UUIDs[i], input.BatchHashes[i], input.Contexts[i] = ethBatchPinValues(batch)
+		input.PayloadRefs[i] = batch.BatchPaylodRef
+	}
+	res, err := e.invokeContractMethod(ctx, "pinBatches", signingKey, operationID.String(), input, tx)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return nil
+}
+
+func (e *Ethereum) GetNativeBalance(ctx context.Context, ns, signingKey string) (*fftypes.BigInt, error) {
+	if e.rpcClient == nil {
+		return nil, nil
+	}
+	var rpcRes ethRPCResponse
+	res, err := e.rpcClient.R().
+		SetContext(ctx).
+		SetBody(&ethRPCRequest{
+			JSONRPC: "2.0",
+			ID:      fftypes.NewUUID().String(),
+			Method:  "eth_getBalance",
+			Params:  []interface{}{signingKey, "latest"},
+		}).
+		SetResult(&rpcRes).
+		Post("/")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	if rpcRes.Error != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgEthereumRPCErr, rpcRes.Error.Message)
+	}
+	balance, ok := new(big.Int).SetString(strings.TrimPrefix(rpcRes.Result, "0x"), 16)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgEthereumRPCErr, rpcRes.Result)
+	}
+	return (*fftypes.BigInt)(balance), nil
+}
+
+func (e *Ethereum) ResetEventStream(ctx context.Context, fromBlock string) error {
+	for _, sub := range e.initInfo.subs {
+		log.L(ctx).Infof("Resetting subscription %s to block %s", sub.ID, fromBlock)
+		if err := e.streams.resetSubscription(ctx, sub.ID, fromBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Ethereum) GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee {
+	gasUsed, ok := new(big.Int).SetString(receipt.GetString("gasUsed"), 0)
+	if !ok {
+		return nil
+	}
+	fee := &fftypes.TransactionFee{GasUsed: (*fftypes.BigInt)(gasUsed)}
+	// Post-London receipts report the effective price paid, older nodes only the price offered
+	gasPriceStr := receipt.GetString("effectiveGasPrice")
+	if gasPriceStr == "" {
+		gasPriceStr = receipt.GetString("gasPrice")
+	}
+	if gasPrice, ok := new(big.Int).SetString(gasPriceStr, 0); ok {
+		fee.Amount = (*fftypes.BigInt)(new(big.Int).Mul(gasUsed, gasPrice))
+	}
+	return fee
+}
+
+func (e *Ethereum) NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error) {
+	address, err := e.validateEthAddress(ctx, location.GetString("address"))
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgContractLocationInvalid, err)
+	}
+	return fftypes.JSONObject{
+		"address": address,
+	}, nil
+}
+
+func ethABIType(ctx context.Context, param *fftypes.FFIParam) (string, error) {
+	if abiType := param.Details.GetString("type"); abiType != "" {
+		return abiType, nil
+	}
+	switch param.Type {
+	case fftypes.FFIParamTypeString:
+		return "string", nil
+	case fftypes.FFIParamTypeInteger:
+		return "uint256", nil
+	case fftypes.FFIParamTypeBoolean:
+		return "bool", nil
+	default:
+		return "", i18n.NewError(ctx, i18n.MsgContractParamTypeUnmapped, param.Name, param.Type)
+	}
+}
+
+func ethABIParams(ctx context.Context, params fftypes.FFIParams) ([]*ethABIParam, error) {
+	abiParams := make([]*ethABIParam, len(params))
+	for i, param := range params {
+		abiType, err := ethABIType(ctx, param)
+		if err != nil {
+			return nil, err
+		}
+		abiParams[i] = &ethABIParam{
+			Name:    param.Name,
+			Type:    abiType,
+			Indexed: param.Details.GetBool("indexed"),
+		}
+	}
+	return abiParams, nil
+}
+
+// buildContractRequest builds a request for the ethconnect messaging API, with an ABI entry generated from
+// the method definition, and the positional parameters taken by name from the input
+func (e *Ethereum) buildContractRequest(ctx context.Context, ns, msgType, requestID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (*ethContractRequest, error) {
+	location, err := e.NormalizeContractLocation(ctx, ns, location)
+	if err != nil {
+		return nil, err
+	}
+	abiMethod := &ethABIMethod{
+		Name: method.Name,
+		Type: "function",
+	}
+	if abiMethod.Inputs, err = ethABIParams(ctx, method.Params); err != nil {
+		return nil, err
+	}
+	if abiMethod.Outputs, err = ethABIParams(ctx, method.Returns); err != nil {
+		return nil, err
+	}
+	params := make([]interface{}, len(method.Params))
+	for i, param := range method.Params {
+		params[i] = input[param.Name]
+	}
+	return &ethContractRequest{
+		Headers: ethContractRequestHeaders{
+			Type: msgType,
+			ID:   requestID,
+		},
+		From:   signingKey,
+		To:     location.GetString("address"),
+		Method: abiMethod,
+		Params: params,
+	}, nil
+}
+
+func (e *Ethereum) InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error {
+	body, err := e.buildContractRequest(ctx, ns, "SendTransaction", operationID.String(), signingKey, location, method, input)
+	if err != nil {
+		return err
+	}
+	res, err := e.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		SetResult(&asyncTXSubmission{}).
+		Post("/")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return nil
+}
+
+func (e *Ethereum) QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error) {
+	body, err := e.buildContractRequest(ctx, ns, "Query", "", "", location, method, input)
+	if err != nil {
+		return nil, err
+	}
+	var output interface{}
+	res, err := e.client.R().
+		SetContext(ctx).
+		SetBody(body).
+		SetResult(&output).
+		Post("/")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return output, nil
+}
+
+func (e *Ethereum) AddContractListener(ctx context.Context, listener *fftypes.ContractListener) (err error) {
+	abiEvent := &ethABIEvent{
+		Name: listener.Event.Name,
+		Type: "event",
+	}
+	if abiEvent.Inputs, err = ethABIParams(ctx, listener.Event.Params); err != nil {
+		return err
+	}
+	sub, err := e.streams.createContractSubscription(ctx, listener.ID.String(), e.initInfo.stream.ID, listener.Location.GetString("address"), abiEvent)
+	if err != nil {
+		return err
+	}
+	listener.ProtocolID = sub.ID
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
@@ -40,6 +41,7 @@ import (
 
 var utConfPrefix = config.NewPluginConfig("eth_unit_tests")
 var utEthconnectConf = utConfPrefix.SubPrefix(EthconnectConfigKey)
+var utRPCConf = utConfPrefix.SubPrefix(EthereumRPCConfigKey)
 
 func resetConf() {
 	config.Reset()
@@ -89,6 +91,35 @@ func TestInitMissingInstance(t *testing.T) {
 	assert.Regexp(t, "FF10138.*instance", err)
 }
 
+func TestInitBadTLS(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "https://localhost:12345")
+	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
+	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utEthconnectConf.Set(tlsconfig.TLSConfigEnabled, true)
+	utEthconnectConf.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestInitBadRPCTLS(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
+	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utRPCConf.Set(restclient.HTTPConfigURL, "https://localhost:8545")
+	utRPCConf.Set(tlsconfig.TLSConfigEnabled, true)
+	utRPCConf.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
 func TestInitMissingTopic(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
@@ -136,15 +167,18 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	utEthconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
 	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
 	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utRPCConf.Set(restclient.HTTPConfigURL, "http://localhost:8545")
 
 	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
 	assert.NoError(t, err)
 
 	assert.Equal(t, "ethereum", e.Name())
+	assert.NotNil(t, e.rpcClient)
 	assert.Equal(t, 4, httpmock.GetTotalCallCount())
 	assert.Equal(t, "es12345", e.initInfo.stream.ID)
 	assert.Equal(t, "sub12345", e.initInfo.subs[0].ID)
 	assert.True(t, e.Capabilities().GlobalSequencer)
+	assert.True(t, e.Capabilities().BatchPinAggregation)
 
 	err = e.Start()
 	assert.NoError(t, err)
@@ -192,6 +226,23 @@ func TestWSConnectFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestWSSubscribeFail(t *testing.T) {
+
+	wsm := &wsmocks.WSClient{}
+	e := &Ethereum{
+		ctx:    context.Background(),
+		wsconn: wsm,
+		topic:  "topic1",
+	}
+	wsm.On("Subscribe", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := e.subscribe(e.ctx)
+	assert.EqualError(t, err, "pop")
+	wsm.AssertNumberOfCalls(t, "Subscribe", 1)
+
+	e.reconnected(e.ctx, &wsclient.WSReconnectEvent{URL: "ws://localhost", Reconnects: 1})
+}
+
 func TestInitAllExistingStreams(t *testing.T) {
 
 	e, cancel := newTestEthereum()
@@ -442,14 +493,93 @@ func TestSubmitBatchPinFail(t *testing.T) {
 
 }
 
+func TestSubmitBatchPinsOK(t *testing.T) {
+
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	addr := ethHexFormatB32(fftypes.NewRandB32())
+	batches := []*blockchain.BatchPin{
+		{
+			Namespace:      "ns1",
+			TransactionID:  fftypes.MustParseUUID("9ffc50ff-6bfe-4502-adc7-93aea54cc059"),
+			BatchID:        fftypes.MustParseUUID("c5df767c-fe44-4e03-8eb5-1c5523097db5"),
+			BatchHash:      fftypes.NewRandB32(),
+			BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+			Contexts: []*fftypes.Bytes32{
+				fftypes.NewRandB32(),
+			},
+		},
+		{
+			Namespace:     "ns2",
+			TransactionID: fftypes.NewUUID(),
+			BatchID:       fftypes.NewUUID(),
+			BatchHash:     fftypes.NewRandB32(),
+			Contexts: []*fftypes.Bytes32{
+				fftypes.NewRandB32(),
+				fftypes.NewRandB32(),
+			},
+		},
+	}
+	opID := fftypes.NewUUID()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/instances/0x12345/pinBatches`,
+		func(req *http.Request) (*http.Response, error) {
+			var body ethBatchPinsInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, addr, req.FormValue(defaultPrefixShort+"-from"))
+			assert.Equal(t, opID.String(), req.FormValue(defaultPrefixShort+"-id"))
+			assert.Equal(t, []string{"ns1", "ns2"}, body.Namespaces)
+			assert.Equal(t, "0x9ffc50ff6bfe4502adc793aea54cc059c5df767cfe444e038eb51c5523097db5", body.UUIDs[0])
+			assert.Equal(t, ethHexFormatB32(batches[1].BatchHash), body.BatchHashes[1])
+			assert.Equal(t, []string{"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", ""}, body.PayloadRefs)
+			assert.Len(t, body.Contexts[0], 1)
+			assert.Equal(t, ethHexFormatB32(batches[1].Contexts[1]), body.Contexts[1][1])
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{})(req)
+		})
+
+	err := e.SubmitBatchPins(context.Background(), opID, nil, addr, batches)
+
+	assert.NoError(t, err)
+
+}
+
+func TestSubmitBatchPinsFail(t *testing.T) {
+
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	addr := ethHexFormatB32(fftypes.NewRandB32())
+	batches := []*blockchain.BatchPin{
+		{
+			TransactionID: fftypes.NewUUID(),
+			BatchID:       fftypes.NewUUID(),
+			BatchHash:     fftypes.NewRandB32(),
+		},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/instances/0x12345/pinBatches`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.SubmitBatchPins(context.Background(), fftypes.NewUUID(), nil, addr, batches)
+
+	assert.Regexp(t, "FF10111", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
 func TestVerifyEthAddress(t *testing.T) {
 	e, cancel := newTestEthereum()
 	defer cancel()
 
-	_, err := e.ResolveSigningKey(context.Background(), "0x12345")
+	_, err := e.ResolveSigningKey(context.Background(), "ns1", "0x12345")
 	assert.Regexp(t, "FF10141", err)
 
-	key, err := e.ResolveSigningKey(context.Background(), "0x2a7c9D5248681CE6c393117E641aD037F5C079F6")
+	key, err := e.ResolveSigningKey(context.Background(), "ns1", "0x2a7c9D5248681CE6c393117E641aD037F5C079F6")
 	assert.NoError(t, err)
 	assert.Equal(t, "0x2a7c9d5248681ce6c393117e641ad037f5c079f6", key)
 
@@ -463,6 +593,7 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
     "blockNumber": "38011",
     "transactionIndex": "0x0",
     "transactionHash": "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628",
+    "timestamp": "1620576488",
     "data": {
       "author": "0X91D2B4381A4CD5C7C0F27565A7D4B829844C8635",
 			"namespace": "ns1",
@@ -484,6 +615,7 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
     "blockNumber": "38011",
     "transactionIndex": "0x1",
     "transactionHash": "0x0c50dff0893e795293189d9cc5ba0d63c4020d8758ace4a69d02c9d6d43cb695",
+    "timestamp": "!1620576488",
     "data": {
       "author": "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635",
 			"namespace": "ns1",
@@ -522,6 +654,7 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{
 		callbacks: em,
 	}
@@ -534,17 +667,19 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	b := em.Calls[1].Arguments[0].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", b.BatchPaylodRef)
-	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[1])
-	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[1].Arguments[1])
+	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[1].Arguments[2])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
+	assert.Equal(t, int64(1620576488), b.Timestamp.Time().Unix())
+	assert.Nil(t, em.Calls[3].Arguments[0].(*blockchain.BatchPin).Timestamp)
 
 	info1 := fftypes.JSONObject{
 		"address":          "0x1C197604587F046FD40684A8f21f4609FB811A7b",
@@ -552,20 +687,22 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 		"logIndex":         "50",
 		"signature":        "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
 		"subID":            "sb-b5b97a4e-a317-4053-6400-1474650efcb5",
+		"timestamp":        "1620576488",
 		"transactionHash":  "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628",
 		"transactionIndex": "0x0",
 	}
-	assert.Equal(t, info1, em.Calls[0].Arguments[3])
+	assert.Equal(t, info1, em.Calls[1].Arguments[3])
 	info2 := fftypes.JSONObject{
 		"address":          "0x1C197604587F046FD40684A8f21f4609FB811A7b",
 		"blockNumber":      "38011",
 		"logIndex":         "51",
 		"signature":        "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
 		"subID":            "sb-b5b97a4e-a317-4053-6400-1474650efcb5",
+		"timestamp":        "!1620576488",
 		"transactionHash":  "0x0c50dff0893e795293189d9cc5ba0d63c4020d8758ace4a69d02c9d6d43cb695",
 		"transactionIndex": "0x1",
 	}
-	assert.Equal(t, info2, em.Calls[1].Arguments[3])
+	assert.Equal(t, info2, em.Calls[3].Arguments[3])
 
 	em.AssertExpectations(t)
 
@@ -598,6 +735,7 @@ func TestHandleMessageEmptyPayloadRef(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{
 		callbacks: em,
 	}
@@ -610,14 +748,14 @@ func TestHandleMessageEmptyPayloadRef(t *testing.T) {
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	b := em.Calls[1].Arguments[0].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Empty(t, b.BatchPaylodRef)
-	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[1])
-	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[1].Arguments[1])
+	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[1].Arguments[2])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
@@ -649,6 +787,7 @@ func TestHandleMessageBatchPinExit(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{
 		callbacks: em,
 	}
@@ -665,17 +804,19 @@ func TestHandleMessageBatchPinExit(t *testing.T) {
 
 func TestHandleMessageBatchPinEmpty(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{callbacks: em}
 	var events []interface{}
 	err := json.Unmarshal([]byte(`[{"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])"}]`), &events)
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadTransactionID(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{callbacks: em}
 	data := []byte(`[{
 		"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
@@ -700,11 +841,12 @@ func TestHandleMessageBatchPinBadTransactionID(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadIDentity(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{callbacks: em}
 	data := []byte(`[{
 		"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
@@ -729,11 +871,12 @@ func TestHandleMessageBatchPinBadIDentity(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadBatchHash(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{callbacks: em}
 	data := []byte(`[{
 		"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
@@ -758,11 +901,12 @@ func TestHandleMessageBatchPinBadBatchHash(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadPin(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Ethereum{callbacks: em}
 	data := []byte(`[{
 		"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])",
@@ -787,7 +931,7 @@ func TestHandleMessageBatchPinBadPin(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchBadJSON(t *testing.T) {
@@ -960,3 +1104,420 @@ func TestHandleReceiptBadRequestID(t *testing.T) {
 func TestFormatNil(t *testing.T) {
 	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", ethHexFormatB32(nil))
 }
+
+func TestHandleMessageBatchBlockchainEventIndexed(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
+	e := &Ethereum{callbacks: em}
+	var events []interface{}
+	err := json.Unmarshal([]byte(`[{
+		"address": "0x1C197604587F046FD40684A8f21f4609FB811A7b",
+		"blockNumber": "38011",
+		"transactionIndex": "0x1",
+		"transactionHash": "0x0c50dff0893e795293189d9cc5ba0d63c4020d8758ace4a69d02c9d6d43cb695",
+		"data": {"field1": "value1"},
+		"subID": "sb-b5b97a4e-a317-4053-6400-1474650efcb5",
+		"signature": "Changed(address,uint256)",
+		"logIndex": "51"
+	}]`), &events)
+	assert.NoError(t, err)
+	err = e.handleMessageBatch(context.Background(), events)
+	assert.NoError(t, err)
+
+	be := em.Calls[0].Arguments[0].(*fftypes.BlockchainEvent)
+	assert.Equal(t, "Changed", be.Name)
+	assert.Equal(t, "000000038011/000001/000051", be.ProtocolID)
+	assert.Equal(t, "0x1C197604587F046FD40684A8f21f4609FB811A7b", be.Address)
+	assert.Equal(t, int64(38011), be.BlockNumber)
+	assert.Equal(t, "0x0c50dff0893e795293189d9cc5ba0d63c4020d8758ace4a69d02c9d6d43cb695", be.ProtocolTxID)
+	assert.Equal(t, "sb-b5b97a4e-a317-4053-6400-1474650efcb5", be.Listener)
+	assert.Equal(t, "value1", be.Output.GetString("field1"))
+	assert.Empty(t, be.Namespace)
+	assert.Nil(t, be.Info["data"])
+	assert.Equal(t, "51", be.Info.GetString("logIndex"))
+
+	em.AssertExpectations(t)
+}
+
+func TestHandleMessageBatchBlockchainEventFail(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(fmt.Errorf("pop"))
+	e := &Ethereum{callbacks: em}
+	var events []interface{}
+	err := json.Unmarshal([]byte(`[{"signature": "BatchPin(address,uint256,string,bytes32,bytes32,string,bytes32[])"}]`), &events)
+	assert.NoError(t, err)
+	err = e.handleMessageBatch(context.Background(), events)
+	assert.Regexp(t, "pop", err)
+	em.AssertExpectations(t)
+}
+
+func TestGetNativeBalanceNoRPC(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	balance, err := e.GetNativeBalance(context.Background(), "ns1", "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, balance)
+}
+
+func TestGetNativeBalanceOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	e.rpcClient = resty.New().SetBaseURL("http://localhost:8545")
+	httpmock.ActivateNonDefault(e.rpcClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:8545/",
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "eth_getBalance", body["method"])
+			assert.Equal(t, []interface{}{"0x12345", "latest"}, body["params"])
+			return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"result":  "0x3e8",
+			})(req)
+		})
+
+	balance, err := e.GetNativeBalance(context.Background(), "ns1", "0x12345")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), balance.Int().Int64())
+}
+
+func TestGetNativeBalanceHTTPFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	e.rpcClient = resty.New().SetBaseURL("http://localhost:8545")
+	httpmock.ActivateNonDefault(e.rpcClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:8545/",
+		httpmock.NewStringResponder(500, "pop"))
+
+	_, err := e.GetNativeBalance(context.Background(), "ns1", "0x12345")
+	assert.Regexp(t, "FF10111", err)
+}
+
+func TestGetNativeBalanceRPCError(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	e.rpcClient = resty.New().SetBaseURL("http://localhost:8545")
+	httpmock.ActivateNonDefault(e.rpcClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:8545/",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    -32602,
+				"message": "invalid address",
+			},
+		}))
+
+	_, err := e.GetNativeBalance(context.Background(), "ns1", "0x12345")
+	assert.Regexp(t, "FF10342.*invalid address", err)
+}
+
+func TestGetNativeBalanceBadResult(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	e.rpcClient = resty.New().SetBaseURL("http://localhost:8545")
+	httpmock.ActivateNonDefault(e.rpcClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:8545/",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"result": "not hex",
+		}))
+
+	_, err := e.GetNativeBalance(context.Background(), "ns1", "0x12345")
+	assert.Regexp(t, "FF10342.*not hex", err)
+}
+
+func TestGetTransactionFeeEffectiveGasPrice(t *testing.T) {
+	e := &Ethereum{}
+	fee := e.GetTransactionFee("ns1", fftypes.JSONObject{
+		"gasUsed":           "21000",
+		"effectiveGasPrice": "0x3b9aca00",
+		"gasPrice":          "1",
+	})
+	assert.Equal(t, "21000", fee.GasUsed.Int().String())
+	assert.Equal(t, "21000000000000", fee.Amount.Int().String())
+}
+
+func TestGetTransactionFeeGasPrice(t *testing.T) {
+	e := &Ethereum{}
+	fee := e.GetTransactionFee("ns1", fftypes.JSONObject{
+		"gasUsed":  "0x5208",
+		"gasPrice": "2",
+	})
+	assert.Equal(t, "21000", fee.GasUsed.Int().String())
+	assert.Equal(t, "42000", fee.Amount.Int().String())
+}
+
+func TestGetTransactionFeeNoGasPrice(t *testing.T) {
+	e := &Ethereum{}
+	fee := e.GetTransactionFee("ns1", fftypes.JSONObject{
+		"gasUsed": "21000",
+	})
+	assert.Equal(t, "21000", fee.GasUsed.Int().String())
+	assert.Nil(t, fee.Amount)
+}
+
+func TestGetTransactionFeeNoGasUsed(t *testing.T) {
+	e := &Ethereum{}
+	fee := e.GetTransactionFee("ns1", fftypes.JSONObject{
+		"transactionHash": "0x12345",
+	})
+	assert.Nil(t, fee)
+}
+
+func testFFIMethod() *fftypes.FFIMethod {
+	return &fftypes.FFIMethod{
+		Name: "set",
+		Params: fftypes.FFIParams{
+			{Name: "x", Type: fftypes.FFIParamTypeInteger, Details: fftypes.JSONObject{"type": "uint8"}},
+			{Name: "label", Type: fftypes.FFIParamTypeString},
+			{Name: "enabled", Type: fftypes.FFIParamTypeBoolean},
+		},
+		Returns: fftypes.FFIParams{
+			{Name: "value", Type: fftypes.FFIParamTypeInteger},
+		},
+	}
+}
+
+func TestNormalizeContractLocation(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	_, err := e.NormalizeContractLocation(context.Background(), "ns1", fftypes.JSONObject{"address": "bad"})
+	assert.Regexp(t, "FF10366.*FF10141", err)
+
+	location, err := e.NormalizeContractLocation(context.Background(), "ns1", fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2a7c9d5248681ce6c393117e641ad037f5c079f6", location.GetString("address"))
+}
+
+func TestInvokeContractOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	opID := fftypes.NewUUID()
+	signingKey := ethHexFormatB32(fftypes.NewRandB32())
+	location := fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	input := fftypes.JSONObject{"x": float64(42), "label": "answer", "enabled": true}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body ethContractRequest
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "SendTransaction", body.Headers.Type)
+			assert.Equal(t, opID.String(), body.Headers.ID)
+			assert.Equal(t, signingKey, body.From)
+			assert.Equal(t, "0x2a7c9d5248681ce6c393117e641ad037f5c079f6", body.To)
+			assert.Equal(t, "set", body.Method.Name)
+			assert.Equal(t, "uint8", body.Method.Inputs[0].Type)
+			assert.Equal(t, "string", body.Method.Inputs[1].Type)
+			assert.Equal(t, "bool", body.Method.Inputs[2].Type)
+			assert.Equal(t, "uint256", body.Method.Outputs[0].Type)
+			assert.Equal(t, []interface{}{float64(42), "answer", true}, body.Params)
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{})(req)
+		})
+
+	err := e.InvokeContract(context.Background(), "ns1", opID, signingKey, location, testFFIMethod(), input)
+	assert.NoError(t, err)
+}
+
+func TestInvokeContractBadLocation(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	err := e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "0x12345", fftypes.JSONObject{}, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10366", err)
+}
+
+func TestInvokeContractUnmappedParam(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	location := fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	method := &fftypes.FFIMethod{
+		Name:   "set",
+		Params: fftypes.FFIParams{{Name: "s", Type: fftypes.FFIParamTypeObject}},
+	}
+	err := e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "0x12345", location, method, fftypes.JSONObject{})
+	assert.Regexp(t, "FF10370.*s.*object", err)
+
+	method = &fftypes.FFIMethod{
+		Name:    "get",
+		Returns: fftypes.FFIParams{{Name: "r", Type: fftypes.FFIParamTypeArray}},
+	}
+	err = e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "0x12345", location, method, fftypes.JSONObject{})
+	assert.Regexp(t, "FF10370.*r.*array", err)
+}
+
+func TestInvokeContractFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	location := fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	err := e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "0x12345", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10111.*pop", err)
+}
+
+func TestQueryContractOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		func(req *http.Request) (*http.Response, error) {
+			var body ethContractRequest
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "Query", body.Headers.Type)
+			assert.Empty(t, body.Headers.ID)
+			assert.Empty(t, body.From)
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{"output": "42"})(req)
+		})
+
+	location := fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	res, err := e.QueryContract(context.Background(), "ns1", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, "42", res.(map[string]interface{})["output"])
+}
+
+func TestQueryContractBadLocation(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	_, err := e.QueryContract(context.Background(), "ns1", fftypes.JSONObject{}, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10366", err)
+}
+
+func TestQueryContractFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	location := fftypes.JSONObject{"address": "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	_, err := e.QueryContract(context.Background(), "ns1", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10111.*pop", err)
+}
+
+func testContractListener() *fftypes.ContractListener {
+	return &fftypes.ContractListener{
+		ID:       fftypes.NewUUID(),
+		Location: fftypes.JSONObject{"address": "0x2a7c9d5248681ce6c393117e641ad037f5c079f6"},
+		Event: &fftypes.FFIEvent{
+			Name: "Changed",
+			Params: fftypes.FFIParams{
+				{Name: "from", Type: fftypes.FFIParamTypeString, Details: fftypes.JSONObject{"type": "address", "indexed": true}},
+				{Name: "value", Type: fftypes.FFIParamTypeInteger},
+			},
+		},
+	}
+}
+
+func TestAddContractListenerOK(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, instancePath: e.instancePath}
+	e.initInfo.stream = &eventStream{ID: "es12345"}
+
+	listener := testContractListener()
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, listener.ID.String(), body.Name)
+			assert.Equal(t, "es12345", body.Stream)
+			assert.Equal(t, "0x2a7c9d5248681ce6c393117e641ad037f5c079f6", body.Address)
+			assert.Equal(t, "event", body.Event.Type)
+			assert.Equal(t, "Changed", body.Event.Name)
+			assert.Equal(t, "address", body.Event.Inputs[0].Type)
+			assert.True(t, body.Event.Inputs[0].Indexed)
+			assert.Equal(t, "uint256", body.Event.Inputs[1].Type)
+			assert.False(t, body.Event.Inputs[1].Indexed)
+			body.ID = "sb-12345"
+			return httpmock.NewJsonResponderOrPanic(200, body)(req)
+		})
+
+	err := e.AddContractListener(context.Background(), listener)
+	assert.NoError(t, err)
+	assert.Equal(t, "sb-12345", listener.ProtocolID)
+}
+
+func TestAddContractListenerUnmappedParam(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+
+	listener := testContractListener()
+	listener.Event.Params = fftypes.FFIParams{{Name: "s", Type: fftypes.FFIParamTypeObject}}
+	err := e.AddContractListener(context.Background(), listener)
+	assert.Regexp(t, "FF10370.*s.*object", err)
+}
+
+func TestAddContractListenerFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, instancePath: e.instancePath}
+	e.initInfo.stream = &eventStream{ID: "es12345"}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.AddContractListener(context.Background(), testContractListener())
+	assert.Regexp(t, "FF10111.*pop", err)
+}
+
+func TestResetEventStream(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, instancePath: e.instancePath}
+	e.initInfo.subs = []*subscription{{ID: "sb1"}, {ID: "sb2"}}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb1/reset`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "12345", body["initialBlock"])
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{})(req)
+		})
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb2/reset`,
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{}))
+
+	err := e.ResetEventStream(context.Background(), "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestResetEventStreamFail(t *testing.T) {
+	e, cancel := newTestEthereum()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, instancePath: e.instancePath}
+	e.initInfo.subs = []*subscription{{ID: "sb1"}}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb1/reset`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.ResetEventStream(context.Background(), "0")
+	assert.Regexp(t, "FF10111.*pop", err)
+}
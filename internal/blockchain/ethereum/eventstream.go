@@ -45,10 +45,12 @@ type eventStream struct {
 }
 
 type subscription struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Stream    string `json:"stream"`
-	FromBlock string `json:"fromBlock"`
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Stream    string       `json:"stream"`
+	FromBlock string       `json:"fromBlock"`
+	Address   string       `json:"address,omitempty"`
+	Event     *ethABIEvent `json:"event,omitempty"`
 }
 
 func (s *streamManager) getEventStreams() (streams []*eventStream, err error) {
@@ -123,6 +125,27 @@ func (s *streamManager) createSubscription(name, stream, event string) (*subscri
 	return &sub, nil
 }
 
+// createContractSubscription subscribes to an event from any contract, supplying the ABI of the event
+// and the address of the contract, rather than using the instance path of the FireFly contract
+func (s *streamManager) createContractSubscription(ctx context.Context, name, stream, address string, event *ethABIEvent) (*subscription, error) {
+	sub := subscription{
+		Name:      name,
+		Stream:    stream,
+		FromBlock: "0",
+		Address:   address,
+		Event:     event,
+	}
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetBody(&sub).
+		SetResult(&sub).
+		Post("/subscriptions")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return &sub, nil
+}
+
 func (s *streamManager) ensureSubscriptions(stream string, subscriptions []string) (subs []*subscription, err error) {
 	// Include a hash of the instance path in the subscription, so if we ever point at a different
 	// contract configuration, we re-subscribe from block 0.
@@ -158,3 +181,15 @@ func (s *streamManager) ensureSubscriptions(stream string, subscriptions []strin
 	}
 	return subs, nil
 }
+
+// resetSubscription rewinds the checkpoint of a subscription, so events are redelivered from the supplied block
+func (s *streamManager) resetSubscription(ctx context.Context, id, fromBlock string) error {
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetBody(map[string]string{"initialBlock": fromBlock}).
+		Post(fmt.Sprintf("/subscriptions/%s/reset", id))
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthconnectRESTErr)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ package fabric
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -130,6 +131,29 @@ func (s *streamManager) createSubscription(name, stream, event string) (*subscri
 	return &sub, nil
 }
 
+// createContractSubscription subscribes to an event from any chaincode, on any channel
+func (s *streamManager) createContractSubscription(ctx context.Context, name, stream, channel, chaincode, event string) (*subscription, error) {
+	sub := subscription{
+		Name:    name,
+		Channel: channel,
+		Signer:  s.signer,
+		Stream:  stream,
+		Filter: eventFilter{
+			ChaincodeID: chaincode,
+			EventFilter: event,
+		},
+	}
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetBody(&sub).
+		SetResult(&sub).
+		Post("/subscriptions")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return &sub, nil
+}
+
 func (s *streamManager) ensureSubscriptions(stream string, subscriptions []string) (subs []*subscription, err error) {
 	existingSubs, err := s.getSubscriptions()
 	if err != nil {
@@ -156,3 +180,15 @@ func (s *streamManager) ensureSubscriptions(stream string, subscriptions []strin
 	}
 	return subs, nil
 }
+
+// resetSubscription rewinds the checkpoint of a subscription, so events are redelivered from the supplied block
+func (s *streamManager) resetSubscription(ctx context.Context, id, fromBlock string) error {
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetBody(map[string]string{"initialBlock": fromBlock}).
+		Post(fmt.Sprintf("/subscriptions/%s/reset", id))
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return nil
+}
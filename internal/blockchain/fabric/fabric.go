@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
@@ -31,9 +32,11 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/wsclient"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -51,6 +54,7 @@ type Fabric struct {
 	capabilities   *blockchain.Capabilities
 	callbacks      blockchain.Callbacks
 	client         *resty.Client
+	streams        *streamManager
 	initInfo       struct {
 		stream *eventStream
 		subs   []*subscription
@@ -140,7 +144,7 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 
 	fabconnectConf := prefix.SubPrefix(FabconnectConfigKey)
 
-	f.ctx = log.WithLogField(ctx, "proto", "fabric")
+	f.ctx = log.WithComponent(log.WithLogField(ctx, "proto", "fabric"), "blockchain")
 	f.callbacks = callbacks
 	f.idCache = make(map[string]*fabIdentity)
 
@@ -162,23 +166,31 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 	f.prefixShort = fabconnectConf.GetString(FabconnectPrefixShort)
 	f.prefixLong = fabconnectConf.GetString(FabconnectPrefixLong)
 
-	f.client = restclient.New(f.ctx, fabconnectConf)
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(f.ctx, fabconnectConf)
+	if err == nil {
+		f.client, err = restclient.New(f.ctx, fabconnectConf)
+	}
+	if err != nil {
+		return err
+	}
 	f.capabilities = &blockchain.Capabilities{
 		GlobalSequencer: true,
 	}
 
-	wsConfig := wsconfig.GenerateConfigFromPrefix(fabconnectConf)
-
 	if wsConfig.WSKeyPath == "" {
 		wsConfig.WSKeyPath = "/ws"
 	}
 
-	f.wsconn, err = wsclient.New(ctx, wsConfig, f.afterConnect)
+	f.wsconn, err = wsclient.New(ctx, wsConfig, nil)
+	if err == nil {
+		err = f.subscribe(ctx)
+	}
 	if err != nil {
 		return err
 	}
+	f.wsconn.AddReconnectListener(f.reconnected)
 
-	streams := streamManager{
+	f.streams = &streamManager{
 		ctx:            f.ctx,
 		client:         f.client,
 		defaultChannel: f.defaultChannel,
@@ -187,11 +199,11 @@ func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks block
 	}
 	batchSize := fabconnectConf.GetUint(FabconnectConfigBatchSize)
 	batchTimeout := uint(fabconnectConf.GetDuration(FabconnectConfigBatchTimeout).Milliseconds())
-	if f.initInfo.stream, err = streams.ensureEventStream(f.topic, batchSize, batchTimeout); err != nil {
+	if f.initInfo.stream, err = f.streams.ensureEventStream(f.topic, batchSize, batchTimeout); err != nil {
 		return err
 	}
 	log.L(f.ctx).Infof("Event stream: %s", f.initInfo.stream.ID)
-	if f.initInfo.subs, err = streams.ensureSubscriptions(f.initInfo.stream.ID, requiredSubscriptions); err != nil {
+	if f.initInfo.subs, err = f.streams.ensureSubscriptions(f.initInfo.stream.ID, requiredSubscriptions); err != nil {
 		return err
 	}
 
@@ -208,22 +220,26 @@ func (f *Fabric) Start() error {
 func (f *Fabric) Capabilities() *blockchain.Capabilities {
 	return f.capabilities
 }
-func (f *Fabric) afterConnect(ctx context.Context, w wsclient.WSClient) error {
-	// Send a subscribe to our topic after each connect/reconnect
+func (f *Fabric) subscribe(ctx context.Context) error {
+	// Subscribe to our topic, and to replies - the WS client replays these after each reconnect
 	b, _ := json.Marshal(&fabWSCommandPayload{
 		Type:  "listen",
 		Topic: f.topic,
 	})
-	err := w.Send(ctx, b)
+	err := f.wsconn.Subscribe(ctx, b)
 	if err == nil {
 		b, _ = json.Marshal(&fabWSCommandPayload{
 			Type: "listenreplies",
 		})
-		err = w.Send(ctx, b)
+		err = f.wsconn.Subscribe(ctx, b)
 	}
 	return err
 }
 
+func (f *Fabric) reconnected(ctx context.Context, event *wsclient.WSReconnectEvent) {
+	log.L(ctx).Warnf("Reconnected to fabconnect at %s (reconnects=%d): resubscribed to topic '%s'", event.URL, event.Reconnects, f.topic)
+}
+
 func (f *Fabric) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
 	sTransactionHash := msgJSON.GetString("transactionId")
 	payloadString := msgJSON.GetString("payload")
@@ -311,6 +327,38 @@ func (f *Fabric) handleReceipt(ctx context.Context, reply fftypes.JSONObject) er
 	return f.callbacks.BlockchainOpUpdate(operationID, updateType, message, reply)
 }
 
+func (f *Fabric) buildBlockchainEvent(msgJSON fftypes.JSONObject, eventName string) *fftypes.BlockchainEvent {
+	blockNumber, _ := strconv.ParseInt(msgJSON.GetString("blockNumber"), 10, 64)
+	sTransactionHash := msgJSON.GetString("transactionId")
+	info := fftypes.JSONObject{}
+	for k, v := range msgJSON {
+		if k != "payload" {
+			info[k] = v
+		}
+	}
+
+	// The payload of chaincode events is base64 encoded, and is JSON for all FireFly events
+	var payload fftypes.JSONObject
+	if bytes, err := base64.StdEncoding.DecodeString(msgJSON.GetString("payload")); err == nil {
+		payload, _ = fftypes.Byteable(bytes).JSONObjectOk()
+	}
+
+	event := &fftypes.BlockchainEvent{
+		Name:         eventName,
+		ProtocolID:   fmt.Sprintf("%.12d/%s", blockNumber, sTransactionHash),
+		Address:      msgJSON.GetString("chaincodeId"),
+		BlockNumber:  blockNumber,
+		ProtocolTxID: sTransactionHash,
+		Listener:     msgJSON.GetString("subId"),
+		Output:       payload,
+		Info:         info,
+	}
+	if eventName == broadcastBatchEventName {
+		event.Namespace = payload.GetString("namespace")
+	}
+	return event
+}
+
 func (f *Fabric) handleMessageBatch(ctx context.Context, messages []interface{}) error {
 	l := log.L(ctx)
 
@@ -328,6 +376,11 @@ func (f *Fabric) handleMessageBatch(ctx context.Context, messages []interface{})
 		l1.Infof("Received '%s' message", eventName)
 		l1.Tracef("Message: %+v", msgJSON)
 
+		// Every event is recorded in the blockchain event index, before any specific processing
+		if err := f.callbacks.BlockchainEvent(f.buildBlockchainEvent(msgJSON, eventName)); err != nil {
+			return err
+		}
+
 		switch eventName {
 		case broadcastBatchEventName:
 			if err := f.handleBatchPinEvent(ctx1, msgJSON); err != nil {
@@ -385,7 +438,7 @@ func (f *Fabric) eventLoop() {
 	}
 }
 
-func (f *Fabric) ResolveSigningKey(ctx context.Context, signingKeyInput string) (string, error) {
+func (f *Fabric) ResolveSigningKey(ctx context.Context, ns, signingKeyInput string) (string, error) {
 	// we expand the short user name into the fully qualified onchain identity:
 	// mspid::x509::{ecert DN}::{CA DN}	return signingKeyInput, nil
 	if !fullIdentityPattern.MatchString(signingKeyInput) {
@@ -416,7 +469,12 @@ func (f *Fabric) ResolveSigningKey(ctx context.Context, signingKeyInput string)
 }
 
 func (f *Fabric) invokeContractMethod(ctx context.Context, channel, chaincode, signingKey string, requestID string, input interface{}, output interface{}) (*resty.Response, error) {
-	return f.client.R().
+	// The request ID is the operation ID, which the connector returns on the receipt
+	ctx, span := tracing.StartSpan(ctx, "fabric.invoke",
+		attribute.String("fabconnect.chaincode", chaincode),
+		attribute.String("fabconnect.requestId", requestID),
+	)
+	res, err := f.client.R().
 		SetContext(ctx).
 		SetQueryParam(f.prefixShort+"-signer", getUserName(signingKey)).
 		SetQueryParam(f.prefixShort+"-channel", channel).
@@ -426,6 +484,11 @@ func (f *Fabric) invokeContractMethod(ctx context.Context, channel, chaincode, s
 		SetBody(input).
 		SetResult(output).
 		Post("/transactions")
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode()))
+	}
+	tracing.EndSpan(span, err)
+	return res, err
 }
 
 func getUserName(fullIDString string) string {
@@ -470,3 +533,109 @@ func (f *Fabric) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID,
 	}
 	return nil
 }
+
+func (f *Fabric) SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*blockchain.BatchPin) error {
+	// The chaincode can only set a single event on each transaction
+	return i18n.NewError(ctx, i18n.MsgBatchPinsNotSupported, f.Name())
+}
+
+func (f *Fabric) GetNativeBalance(ctx context.Context, ns, signingKey string) (*fftypes.BigInt, error) {
+	// Fabric has no native gas token
+	return nil, nil
+}
+
+func (f *Fabric) ResetEventStream(ctx context.Context, fromBlock string) error {
+	for _, sub := range f.initInfo.subs {
+		log.L(ctx).Infof("Resetting subscription %s to block %s", sub.ID, fromBlock)
+		if err := f.streams.resetSubscription(ctx, sub.ID, fromBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fabric) GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee {
+	// Fabric does not charge gas for transactions
+	return nil
+}
+
+func (f *Fabric) NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error) {
+	chaincode := location.GetString("chaincode")
+	if chaincode == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgContractLocationInvalid, "'chaincode' not set")
+	}
+	channel := location.GetString("channel")
+	if channel == "" {
+		channel = f.defaultChannel
+	}
+	return fftypes.JSONObject{
+		"channel":   channel,
+		"chaincode": chaincode,
+	}, nil
+}
+
+// buildContractInput maps the input to the positional string arguments of a chaincode function,
+// with any value that is not already a string passed as JSON
+func buildContractInput(method *fftypes.FFIMethod, input fftypes.JSONObject) *fabTxInput {
+	args := make([]string, len(method.Params))
+	for i, param := range method.Params {
+		switch v := input[param.Name].(type) {
+		case string:
+			args[i] = v
+		default:
+			b, _ := json.Marshal(v)
+			args[i] = string(b)
+		}
+	}
+	return &fabTxInput{
+		Headers: newTxInputHeaders(),
+		Func:    method.Name,
+		Args:    args,
+	}
+}
+
+func (f *Fabric) InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error {
+	location, err := f.NormalizeContractLocation(ctx, ns, location)
+	if err != nil {
+		return err
+	}
+	tx := &asyncTXSubmission{}
+	res, err := f.invokeContractMethod(ctx, location.GetString("channel"), location.GetString("chaincode"), signingKey, operationID.String(), buildContractInput(method, input), tx)
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return nil
+}
+
+func (f *Fabric) QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error) {
+	location, err := f.NormalizeContractLocation(ctx, ns, location)
+	if err != nil {
+		return nil, err
+	}
+	var output interface{}
+	res, err := f.client.R().
+		SetContext(ctx).
+		SetQueryParam(f.prefixShort+"-signer", getUserName(f.signer)).
+		SetQueryParam(f.prefixShort+"-channel", location.GetString("channel")).
+		SetQueryParam(f.prefixShort+"-chaincode", location.GetString("chaincode")).
+		SetBody(buildContractInput(method, input)).
+		SetResult(&output).
+		Post("/query")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return output, nil
+}
+
+func (f *Fabric) AddContractListener(ctx context.Context, listener *fftypes.ContractListener) error {
+	location, err := f.NormalizeContractLocation(ctx, listener.Namespace, listener.Location)
+	if err != nil {
+		return err
+	}
+	sub, err := f.streams.createContractSubscription(ctx, listener.ID.String(), f.initInfo.stream.ID, location.GetString("channel"), location.GetString("chaincode"), listener.Event.Name)
+	if err != nil {
+		return err
+	}
+	listener.ProtocolID = sub.ID
+	return nil
+}
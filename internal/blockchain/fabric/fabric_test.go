@@ -27,6 +27,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
@@ -92,6 +93,21 @@ func TestInitMissingChaincode(t *testing.T) {
 	assert.Regexp(t, "FF10138.*chaincode", err)
 }
 
+func TestInitBadTLS(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	resetConf()
+	utFabconnectConf.Set(restclient.HTTPConfigURL, "https://localhost:12345")
+	utFabconnectConf.Set(FabconnectConfigChaincode, "Firefly")
+	utFabconnectConf.Set(FabconnectConfigSigner, "signer001")
+	utFabconnectConf.Set(FabconnectConfigTopic, "topic1")
+	utFabconnectConf.Set(tlsconfig.TLSConfigEnabled, true)
+	utFabconnectConf.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	err := e.Init(e.ctx, utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
 func TestInitMissingTopic(t *testing.T) {
 	e, cancel := newTestFabric()
 	defer cancel()
@@ -150,6 +166,7 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 	assert.Equal(t, "es12345", e.initInfo.stream.ID)
 	assert.Equal(t, "sub12345", e.initInfo.subs[0].ID)
 	assert.True(t, e.Capabilities().GlobalSequencer)
+	assert.False(t, e.Capabilities().BatchPinAggregation)
 
 	err = e.Start()
 	assert.NoError(t, err)
@@ -198,6 +215,23 @@ func TestWSConnectFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestWSSubscribeFail(t *testing.T) {
+
+	wsm := &wsmocks.WSClient{}
+	e := &Fabric{
+		ctx:    context.Background(),
+		wsconn: wsm,
+		topic:  "topic1",
+	}
+	wsm.On("Subscribe", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := e.subscribe(e.ctx)
+	assert.EqualError(t, err, "pop")
+	wsm.AssertNumberOfCalls(t, "Subscribe", 1)
+
+	e.reconnected(e.ctx, &wsclient.WSReconnectEvent{URL: "ws://localhost", Reconnects: 1})
+}
+
 func TestInitAllExistingStreams(t *testing.T) {
 
 	e, cancel := newTestFabric()
@@ -424,6 +458,13 @@ func TestSubmitBatchEmptyPayloadRef(t *testing.T) {
 
 }
 
+func TestSubmitBatchPinsNotSupported(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	err := e.SubmitBatchPins(context.Background(), fftypes.NewUUID(), nil, "signer001", []*blockchain.BatchPin{})
+	assert.Regexp(t, "FF10413", err)
+}
+
 func TestSubmitBatchPinFail(t *testing.T) {
 
 	e, cancel := newTestFabric()
@@ -458,7 +499,7 @@ func TestResolveFullIDSigner(t *testing.T) {
 	defer cancel()
 
 	id := "org1MSP::x509::CN=admin,OU=client::CN=fabric-ca-server"
-	signKey, err := e.ResolveSigningKey(context.Background(), id)
+	signKey, err := e.ResolveSigningKey(context.Background(), "ns1", id)
 	assert.NoError(t, err)
 	assert.Equal(t, "org1MSP::x509::CN=admin,OU=client::CN=fabric-ca-server", signKey)
 
@@ -478,7 +519,7 @@ func TestResolveSigner(t *testing.T) {
 
 	responder, _ := httpmock.NewJsonResponder(200, res)
 	httpmock.RegisterResponder("GET", `http://localhost:12345/identities/signer001`, responder)
-	resolved, err := e.ResolveSigningKey(context.Background(), "signer001")
+	resolved, err := e.ResolveSigningKey(context.Background(), "ns1", "signer001")
 	assert.NoError(t, err)
 	assert.Equal(t, "org1MSP::x509::CN=admin,OU=client::CN=fabric-ca-server", resolved)
 }
@@ -493,7 +534,7 @@ func TestResolveSignerFailedFabricCARequest(t *testing.T) {
 
 	responder, _ := httpmock.NewJsonResponder(503, res)
 	httpmock.RegisterResponder("GET", `http://localhost:12345/identities/signer001`, responder)
-	_, err := e.ResolveSigningKey(context.Background(), "signer001")
+	_, err := e.ResolveSigningKey(context.Background(), "ns1", "signer001")
 	assert.EqualError(t, err, "FF10284: Error from fabconnect: %!!(MISSING)s()")
 }
 
@@ -511,7 +552,7 @@ func TestResolveSignerBadECertReturned(t *testing.T) {
 
 	responder, _ := httpmock.NewJsonResponder(200, res)
 	httpmock.RegisterResponder("GET", `http://localhost:12345/identities/signer001`, responder)
-	_, err := e.ResolveSigningKey(context.Background(), "signer001")
+	_, err := e.ResolveSigningKey(context.Background(), "ns1", "signer001")
 	assert.Contains(t, err.Error(), "FF10286: Failed to decode certificate:")
 }
 
@@ -529,7 +570,7 @@ func TestResolveSignerBadCACertReturned(t *testing.T) {
 
 	responder, _ := httpmock.NewJsonResponder(200, res)
 	httpmock.RegisterResponder("GET", `http://localhost:12345/identities/signer001`, responder)
-	_, err := e.ResolveSigningKey(context.Background(), "signer001")
+	_, err := e.ResolveSigningKey(context.Background(), "ns1", "signer001")
 	assert.Contains(t, err.Error(), "FF10286: Failed to decode certificate:")
 }
 
@@ -565,6 +606,7 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{
 		callbacks: em,
 	}
@@ -577,14 +619,14 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	b := em.Calls[1].Arguments[0].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", b.BatchPaylodRef)
-	assert.Equal(t, "u0vgwu9s00-x509::CN=user2,OU=client::CN=fabric-ca-server", em.Calls[0].Arguments[1])
-	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", em.Calls[0].Arguments[2])
+	assert.Equal(t, "u0vgwu9s00-x509::CN=user2,OU=client::CN=fabric-ca-server", em.Calls[1].Arguments[1])
+	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", em.Calls[1].Arguments[2])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
@@ -607,6 +649,7 @@ func TestHandleMessageEmptyPayloadRef(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{
 		callbacks: em,
 	}
@@ -619,14 +662,14 @@ func TestHandleMessageEmptyPayloadRef(t *testing.T) {
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	b := em.Calls[1].Arguments[0].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Empty(t, b.BatchPaylodRef)
-	assert.Equal(t, "u0vgwu9s00-x509::CN=user2,OU=client::CN=fabric-ca-server", em.Calls[0].Arguments[1])
-	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", em.Calls[0].Arguments[2])
+	assert.Equal(t, "u0vgwu9s00-x509::CN=user2,OU=client::CN=fabric-ca-server", em.Calls[1].Arguments[1])
+	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", em.Calls[1].Arguments[2])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
@@ -649,6 +692,7 @@ func TestHandleMessageBatchPinExit(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{
 		callbacks: em,
 	}
@@ -676,13 +720,14 @@ func TestHandleMessageBatchPinEmpty(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageUnknownEventName(t *testing.T) {
@@ -698,17 +743,19 @@ func TestHandleMessageUnknownEventName(t *testing.T) {
 ]`)
 
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadBatchHash(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	data := []byte(`[{
 		"chaincodeId": "firefly",
@@ -723,11 +770,12 @@ func TestHandleMessageBatchPinBadBatchHash(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadPin(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	data := []byte(`[{
 		"chaincodeId": "firefly",
@@ -742,11 +790,12 @@ func TestHandleMessageBatchPinBadPin(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadPayloadEncoding(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	data := []byte(`[{
 		"chaincodeId": "firefly",
@@ -761,11 +810,12 @@ func TestHandleMessageBatchPinBadPayloadEncoding(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchPinBadPayloadUUIDs(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
 	e := &Fabric{callbacks: em}
 	data := []byte(`[{
 		"chaincodeId": "firefly",
@@ -780,7 +830,7 @@ func TestHandleMessageBatchPinBadPayloadUUIDs(t *testing.T) {
 	assert.NoError(t, err)
 	err = e.handleMessageBatch(context.Background(), events)
 	assert.NoError(t, err)
-	assert.Equal(t, 0, len(em.Calls))
+	assert.Equal(t, 1, len(em.Calls))
 }
 
 func TestHandleMessageBatchBadJSON(t *testing.T) {
@@ -990,3 +1040,281 @@ func TestHandleReceiptFailedTx(t *testing.T) {
 func TestFormatNil(t *testing.T) {
 	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", hexFormatB32(nil))
 }
+
+func TestHandleMessageBatchBlockchainEventIndexed(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(nil)
+	e := &Fabric{callbacks: em}
+	var events []interface{}
+	err := json.Unmarshal([]byte(`[{
+		"chaincodeId": "firefly",
+		"blockNumber": 91,
+		"transactionId": "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
+		"eventName": "Changed",
+		"payload": "eyJmaWVsZDEiOiJ2YWx1ZTEifQ==",
+		"subId": "sb-0910f6a8-7bd6-4ced-453e-2db68149ce8e"
+	}]`), &events)
+	assert.NoError(t, err)
+	err = e.handleMessageBatch(context.Background(), events)
+	assert.NoError(t, err)
+
+	be := em.Calls[0].Arguments[0].(*fftypes.BlockchainEvent)
+	assert.Equal(t, "Changed", be.Name)
+	assert.Equal(t, "000000000091/ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", be.ProtocolID)
+	assert.Equal(t, "firefly", be.Address)
+	assert.Equal(t, int64(91), be.BlockNumber)
+	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2", be.ProtocolTxID)
+	assert.Equal(t, "sb-0910f6a8-7bd6-4ced-453e-2db68149ce8e", be.Listener)
+	assert.Equal(t, "value1", be.Output.GetString("field1"))
+	assert.Empty(t, be.Namespace)
+	assert.Nil(t, be.Info["payload"])
+
+	em.AssertExpectations(t)
+}
+
+func TestHandleMessageBatchBlockchainEventFail(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	em.On("BlockchainEvent", mock.Anything).Return(fmt.Errorf("pop"))
+	e := &Fabric{callbacks: em}
+	var events []interface{}
+	err := json.Unmarshal([]byte(`[{"eventName": "BatchPin"}]`), &events)
+	assert.NoError(t, err)
+	err = e.handleMessageBatch(context.Background(), events)
+	assert.Regexp(t, "pop", err)
+	em.AssertExpectations(t)
+}
+
+func TestGetNativeBalance(t *testing.T) {
+	e := &Fabric{}
+	balance, err := e.GetNativeBalance(context.Background(), "ns1", "signer001")
+	assert.NoError(t, err)
+	assert.Nil(t, balance)
+}
+
+func TestGetTransactionFee(t *testing.T) {
+	e := &Fabric{}
+	assert.Nil(t, e.GetTransactionFee("ns1", fftypes.JSONObject{"transactionId": "tx1"}))
+}
+
+func testFFIMethod() *fftypes.FFIMethod {
+	return &fftypes.FFIMethod{
+		Name: "CreateAsset",
+		Params: fftypes.FFIParams{
+			{Name: "id", Type: fftypes.FFIParamTypeString},
+			{Name: "value", Type: fftypes.FFIParamTypeInteger},
+			{Name: "owner", Type: fftypes.FFIParamTypeObject},
+		},
+	}
+}
+
+func TestNormalizeContractLocation(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+
+	_, err := e.NormalizeContractLocation(context.Background(), "ns1", fftypes.JSONObject{"channel": "ch1"})
+	assert.Regexp(t, "FF10366.*chaincode", err)
+
+	location, err := e.NormalizeContractLocation(context.Background(), "ns1", fftypes.JSONObject{"chaincode": "assets"})
+	assert.NoError(t, err)
+	assert.Equal(t, "firefly", location.GetString("channel"))
+	assert.Equal(t, "assets", location.GetString("chaincode"))
+
+	location, err = e.NormalizeContractLocation(context.Background(), "ns1", fftypes.JSONObject{"channel": "ch1", "chaincode": "assets"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ch1", location.GetString("channel"))
+}
+
+func TestInvokeContractOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	opID := fftypes.NewUUID()
+	input := fftypes.JSONObject{"id": "asset1", "value": float64(10), "owner": map[string]interface{}{"name": "org1"}}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body fabTxInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "signer001", req.FormValue(defaultPrefixShort+"-signer"))
+			assert.Equal(t, "ch1", req.FormValue(defaultPrefixShort+"-channel"))
+			assert.Equal(t, "assets", req.FormValue(defaultPrefixShort+"-chaincode"))
+			assert.Equal(t, opID.String(), req.FormValue(defaultPrefixShort+"-id"))
+			assert.Equal(t, "CreateAsset", body.Func)
+			assert.Equal(t, []string{"asset1", "10", `{"name":"org1"}`}, body.Args)
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{})(req)
+		})
+
+	location := fftypes.JSONObject{"channel": "ch1", "chaincode": "assets"}
+	err := e.InvokeContract(context.Background(), "ns1", opID, "signer001", location, testFFIMethod(), input)
+	assert.NoError(t, err)
+}
+
+func TestInvokeContractBadLocation(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+
+	err := e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "signer001", fftypes.JSONObject{}, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10366", err)
+}
+
+func TestInvokeContractFail(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	location := fftypes.JSONObject{"chaincode": "assets"}
+	err := e.InvokeContract(context.Background(), "ns1", fftypes.NewUUID(), "signer001", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10284.*pop", err)
+}
+
+func TestQueryContractOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	e.signer = "signer001"
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/query`,
+		func(req *http.Request) (*http.Response, error) {
+			var body fabTxInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "signer001", req.FormValue(defaultPrefixShort+"-signer"))
+			assert.Equal(t, "firefly", req.FormValue(defaultPrefixShort+"-channel"))
+			assert.Equal(t, "assets", req.FormValue(defaultPrefixShort+"-chaincode"))
+			assert.Equal(t, "CreateAsset", body.Func)
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{"result": "ok"})(req)
+		})
+
+	location := fftypes.JSONObject{"chaincode": "assets"}
+	res, err := e.QueryContract(context.Background(), "ns1", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.(map[string]interface{})["result"])
+}
+
+func TestQueryContractBadLocation(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+
+	_, err := e.QueryContract(context.Background(), "ns1", fftypes.JSONObject{}, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10366", err)
+}
+
+func TestQueryContractFail(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/query`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	location := fftypes.JSONObject{"chaincode": "assets"}
+	_, err := e.QueryContract(context.Background(), "ns1", location, testFFIMethod(), fftypes.JSONObject{})
+	assert.Regexp(t, "FF10284.*pop", err)
+}
+
+func TestAddContractListenerOK(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, signer: "signer001"}
+	e.initInfo.stream = &eventStream{ID: "es12345"}
+
+	listener := &fftypes.ContractListener{
+		ID:       fftypes.NewUUID(),
+		Location: fftypes.JSONObject{"chaincode": "assets"},
+		Event:    &fftypes.FFIEvent{Name: "AssetCreated"},
+	}
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, listener.ID.String(), body.Name)
+			assert.Equal(t, "es12345", body.Stream)
+			assert.Equal(t, "firefly", body.Channel)
+			assert.Equal(t, "signer001", body.Signer)
+			assert.Equal(t, "assets", body.Filter.ChaincodeID)
+			assert.Equal(t, "AssetCreated", body.Filter.EventFilter)
+			body.ID = "sb-12345"
+			return httpmock.NewJsonResponderOrPanic(200, body)(req)
+		})
+
+	err := e.AddContractListener(context.Background(), listener)
+	assert.NoError(t, err)
+	assert.Equal(t, "sb-12345", listener.ProtocolID)
+}
+
+func TestAddContractListenerBadLocation(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+
+	err := e.AddContractListener(context.Background(), &fftypes.ContractListener{
+		ID:    fftypes.NewUUID(),
+		Event: &fftypes.FFIEvent{Name: "AssetCreated"},
+	})
+	assert.Regexp(t, "FF10366", err)
+}
+
+func TestAddContractListenerFail(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client, signer: "signer001"}
+	e.initInfo.stream = &eventStream{ID: "es12345"}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.AddContractListener(context.Background(), &fftypes.ContractListener{
+		ID:       fftypes.NewUUID(),
+		Location: fftypes.JSONObject{"chaincode": "assets"},
+		Event:    &fftypes.FFIEvent{Name: "AssetCreated"},
+	})
+	assert.Regexp(t, "FF10284.*pop", err)
+}
+
+func TestResetEventStream(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client}
+	e.initInfo.subs = []*subscription{{ID: "sb1"}, {ID: "sb2"}}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb1/reset`,
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "12345", body["initialBlock"])
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{})(req)
+		})
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb2/reset`,
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{}))
+
+	err := e.ResetEventStream(context.Background(), "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestResetEventStreamFail(t *testing.T) {
+	e, cancel := newTestFabric()
+	defer cancel()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+	e.streams = &streamManager{ctx: e.ctx, client: e.client}
+	e.initInfo.subs = []*subscription{{ID: "sb1"}}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/subscriptions/sb1/reset`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.ResetEventStream(context.Background(), "0")
+	assert.Regexp(t, "FF10284.*pop", err)
+}
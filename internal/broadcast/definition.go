@@ -46,6 +46,15 @@ func (bm *broadcastManager) BroadcastRootOrgDefinition(ctx context.Context, def
 	return bm.broadcastDefinitionCommon(ctx, fftypes.SystemNamespace, def, signingIdentity, tag, waitConfirm)
 }
 
+// BroadcastIdentityClaim broadcasts the claim of a custom identity, signed by its own key. The identity cannot be
+// resolved as the author until its parent has verified the claim, so the author is always the DID of the identity.
+func (bm *broadcastManager) BroadcastIdentityClaim(ctx context.Context, ns string, def *fftypes.CustomIdentity, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error) {
+
+	signingIdentity.Author = def.GetDID()
+
+	return bm.broadcastDefinitionCommon(ctx, ns, def, signingIdentity, tag, waitConfirm)
+}
+
 func (bm *broadcastManager) broadcastDefinitionCommon(ctx context.Context, ns string, def fftypes.Definition, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error) {
 
 	// Serialize it into a data object, as a piece of data we can write to a message
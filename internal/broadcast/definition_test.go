@@ -115,3 +115,27 @@ func TestBroadcastRootOrgDefinitionPassedThroughAnyIdentity(t *testing.T) {
 
 	mim.AssertExpectations(t)
 }
+
+func TestBroadcastIdentityClaimSignedByIdentity(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	// Should call through to upsert data, stop test there
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	identity := &fftypes.CustomIdentity{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+	}
+	signingIdentity := &fftypes.Identity{
+		Author: "anything - overridden",
+		Key:    "0x12345",
+	}
+	_, err := bm.BroadcastIdentityClaim(bm.ctx, "ns1", identity, signingIdentity, fftypes.SystemTagDefineIdentityClaim, false)
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, identity.GetDID(), signingIdentity.Author)
+
+	mdi.AssertExpectations(t)
+}
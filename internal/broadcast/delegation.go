@@ -0,0 +1,87 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// BroadcastDelegation authorizes the delegate org to send messages in the namespace on behalf of the delegator org
+// (the local org by default). The delegation is signed by the delegator, and can only be used once confirmed.
+func (bm *broadcastManager) BroadcastDelegation(ctx context.Context, ns string, delegation *fftypes.Delegation, waitConfirm bool) (*fftypes.Delegation, error) {
+	if delegation.Delegate == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "delegate")
+	}
+	delegate := &fftypes.Identity{Author: delegation.Delegate}
+	if err := bm.identity.ResolveInputIdentity(ctx, delegate); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(delegate.Author, fftypes.FireflyOrgDIDPrefix) {
+		return nil, i18n.NewError(ctx, i18n.MsgOrgNotFound, delegation.Delegate)
+	}
+	signingIdentity := &fftypes.Identity{Author: delegation.Delegator}
+	if err := bm.identity.ResolveInputIdentity(ctx, signingIdentity); err != nil {
+		return nil, err
+	}
+	if delegate.Author == signingIdentity.Author {
+		return nil, i18n.NewError(ctx, i18n.MsgDelegationToSelf, signingIdentity.Author)
+	}
+
+	delegation.ID = fftypes.NewUUID()
+	delegation.Namespace = ns
+	delegation.Delegator = signingIdentity.Author
+	delegation.Delegate = delegate.Author
+	delegation.Created = fftypes.Now()
+	delegation.Revoked = nil
+
+	msg, err := bm.broadcastDefinitionCommon(ctx, ns, delegation, signingIdentity, fftypes.SystemTagDefineDelegation, waitConfirm)
+	if msg != nil {
+		delegation.Message = msg.Header.ID
+	}
+	return delegation, err
+}
+
+// RevokeDelegation broadcasts the revocation of a delegation, signed by the delegator that granted it.
+// Messages sent under the delegation are rejected once the revocation is confirmed.
+func (bm *broadcastManager) RevokeDelegation(ctx context.Context, ns, id string, waitConfirm bool) (*fftypes.Delegation, error) {
+	delegationID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delegation, err := bm.database.GetDelegationByID(ctx, delegationID)
+	if err != nil {
+		return nil, err
+	}
+	if delegation == nil || delegation.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgDelegationNotFound, id, ns)
+	}
+	if delegation.Revoked != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgDelegationRevoked, id)
+	}
+
+	delegation.Revoked = fftypes.Now()
+	signingIdentity := &fftypes.Identity{Author: delegation.Delegator}
+	msg, err := bm.BroadcastDefinition(ctx, ns, delegation, signingIdentity, fftypes.SystemTagDefineDelegation, waitConfirm)
+	if msg != nil {
+		delegation.Message = msg.Header.ID
+	}
+	return delegation, err
+}
@@ -0,0 +1,203 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockResolveOrg(mim *identitymanagermocks.Manager, input, did string) *mock.Call {
+	return mim.On("ResolveInputIdentity", mock.Anything, mock.MatchedBy(func(identity *fftypes.Identity) bool {
+		return identity.Author == input
+	})).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.Identity).Author = did
+		args[1].(*fftypes.Identity).Key = "0x12345"
+	})
+}
+
+func TestBroadcastDelegationOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mockResolveOrg(mim, "org2", "did:firefly:org/org2").Return(nil)
+	mockResolveOrg(mim, "", "did:firefly:org/org1").Return(nil)
+	mdi.On("UpsertData", mock.Anything, mock.MatchedBy(func(data *fftypes.Data) bool {
+		var d fftypes.Delegation
+		err := json.Unmarshal(data.Value, &d)
+		return err == nil && d.Delegator == "did:firefly:org/org1" && d.Delegate == "did:firefly:org/org2" && d.Namespace == "ns1"
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Author == "did:firefly:org/org1" && msg.Header.Tag == string(fftypes.SystemTagDefineDelegation)
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
+
+	delegation, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{
+		Delegate: "org2",
+		Revoked:  fftypes.Now(),
+	}, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, delegation.ID)
+	assert.NotNil(t, delegation.Message)
+	assert.Nil(t, delegation.Revoked)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastDelegationMissingDelegate(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	_, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{}, false)
+	assert.Regexp(t, "FF10140.*delegate", err)
+}
+
+func TestBroadcastDelegationResolveDelegateFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mockResolveOrg(mim, "org2", "").Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{Delegate: "org2"}, false)
+	assert.EqualError(t, err, "pop")
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastDelegationDelegateNotOrg(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mockResolveOrg(mim, "did:firefly:identity/id1", "did:firefly:identity/id1").Return(nil)
+
+	_, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{Delegate: "did:firefly:identity/id1"}, false)
+	assert.Regexp(t, "FF10223", err)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastDelegationResolveDelegatorFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mockResolveOrg(mim, "org2", "did:firefly:org/org2").Return(nil)
+	mockResolveOrg(mim, "org1", "").Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{Delegator: "org1", Delegate: "org2"}, false)
+	assert.EqualError(t, err, "pop")
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastDelegationToSelf(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mockResolveOrg(mim, "org1", "did:firefly:org/org1").Return(nil)
+
+	_, err := bm.BroadcastDelegation(context.Background(), "ns1", &fftypes.Delegation{Delegator: "org1", Delegate: "org1"}, false)
+	assert.Regexp(t, "FF10453", err)
+	mim.AssertExpectations(t)
+}
+
+func TestRevokeDelegationOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	existing := &fftypes.Delegation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Delegator: "did:firefly:org/org1",
+		Delegate:  "did:firefly:org/org2",
+	}
+	mdi.On("GetDelegationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mockResolveOrg(mim, "did:firefly:org/org1", "did:firefly:org/org1").Return(nil)
+	mdi.On("UpsertData", mock.Anything, mock.MatchedBy(func(data *fftypes.Data) bool {
+		var d fftypes.Delegation
+		err := json.Unmarshal(data.Value, &d)
+		return err == nil && d.ID.Equals(existing.ID) && d.Revoked != nil
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
+
+	delegation, err := bm.RevokeDelegation(context.Background(), "ns1", existing.ID.String(), false)
+	assert.NoError(t, err)
+	assert.NotNil(t, delegation.Revoked)
+	assert.NotNil(t, delegation.Message)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestRevokeDelegationBadID(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	_, err := bm.RevokeDelegation(context.Background(), "ns1", "bad", false)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRevokeDelegationLookupFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetDelegationByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := bm.RevokeDelegation(context.Background(), "ns1", fftypes.NewUUID().String(), false)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestRevokeDelegationWrongNamespace(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetDelegationByID", mock.Anything, mock.Anything).Return(&fftypes.Delegation{Namespace: "ns2"}, nil)
+
+	_, err := bm.RevokeDelegation(context.Background(), "ns1", fftypes.NewUUID().String(), false)
+	assert.Regexp(t, "FF10448", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestRevokeDelegationAlreadyRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetDelegationByID", mock.Anything, mock.Anything).Return(&fftypes.Delegation{Namespace: "ns1", Revoked: fftypes.Now()}, nil)
+
+	_, err := bm.RevokeDelegation(context.Background(), "ns1", fftypes.NewUUID().String(), false)
+	assert.Regexp(t, "FF10449", err)
+	mdi.AssertExpectations(t)
+}
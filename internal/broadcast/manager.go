@@ -28,6 +28,7 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/quota"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
 	"github.com/hyperledger/firefly/pkg/blockchain"
@@ -45,7 +46,11 @@ type Manager interface {
 	BroadcastDefinitionAsNode(ctx context.Context, ns string, def fftypes.Definition, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error)
 	BroadcastDefinition(ctx context.Context, ns string, def fftypes.Definition, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error)
 	BroadcastRootOrgDefinition(ctx context.Context, def *fftypes.Organization, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error)
+	BroadcastIdentityClaim(ctx context.Context, ns string, def *fftypes.CustomIdentity, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (msg *fftypes.Message, err error)
 	BroadcastTokenPool(ctx context.Context, ns string, pool *fftypes.TokenPoolAnnouncement, waitConfirm bool) (msg *fftypes.Message, err error)
+	BroadcastPseudonym(ctx context.Context, ns string, pseudonym *fftypes.Pseudonym, waitConfirm bool) (*fftypes.Pseudonym, error)
+	BroadcastDelegation(ctx context.Context, ns string, delegation *fftypes.Delegation, waitConfirm bool) (*fftypes.Delegation, error)
+	RevokeDelegation(ctx context.Context, ns, id string, waitConfirm bool) (*fftypes.Delegation, error)
 	Start() error
 	WaitStop()
 }
@@ -61,12 +66,18 @@ type broadcastManager struct {
 	batch         batch.Manager
 	syncasync     syncasync.Bridge
 	batchpin      batchpin.Submitter
+	quota         quota.Manager
+	compression   fftypes.BatchCompression
 }
 
-func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, bi blockchain.Plugin, dx dataexchange.Plugin, pi publicstorage.Plugin, ba batch.Manager, sa syncasync.Bridge, bp batchpin.Submitter) (Manager, error) {
-	if di == nil || im == nil || dm == nil || bi == nil || dx == nil || pi == nil || ba == nil {
+func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Manager, dm data.Manager, bi blockchain.Plugin, dx dataexchange.Plugin, pi publicstorage.Plugin, ba batch.Manager, sa syncasync.Bridge, bp batchpin.Submitter, qm quota.Manager) (Manager, error) {
+	if di == nil || im == nil || dm == nil || bi == nil || dx == nil || pi == nil || ba == nil || qm == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
+	compression := fftypes.BatchCompression(config.GetString(config.BroadcastBatchCompression)).Lower()
+	if compression != fftypes.BatchCompressionNone && compression != fftypes.BatchCompressionGzip {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownBatchCompression, compression)
+	}
 	bm := &broadcastManager{
 		ctx:           ctx,
 		database:      di,
@@ -78,6 +89,8 @@ func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Ma
 		batch:         ba,
 		syncasync:     sa,
 		batchpin:      bp,
+		quota:         qm,
+		compression:   compression,
 	}
 	bo := batch.Options{
 		BatchMaxSize:   config.GetUint(config.BroadcastBatchSize),
@@ -94,8 +107,18 @@ func NewBroadcastManager(ctx context.Context, di database.Plugin, im identity.Ma
 
 func (bm *broadcastManager) dispatchBatch(ctx context.Context, batch *fftypes.Batch, pins []*fftypes.Bytes32) error {
 
-	// Serialize the full payload, which has already been sealed for us by the BatchManager
-	payload, err := json.Marshal(batch)
+	// Fail fast if the pin cannot be submitted, before uploading the payload
+	if err := bm.batchpin.PreflightCheck(ctx, batch); err != nil {
+		return err
+	}
+
+	// Serialize the full payload, which has already been sealed for us by the BatchManager.
+	// Compression is applied to a copy, as the uncompressed payload is still needed to submit the pin.
+	upload := *batch
+	if err := upload.CompressPayload(ctx, bm.compression); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(&upload)
 	if err != nil {
 		return i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
 	}
@@ -107,9 +130,31 @@ func (bm *broadcastManager) dispatchBatch(ctx context.Context, batch *fftypes.Ba
 		return err
 	}
 
-	return bm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+	// When pinning services are configured, the pin is tracked as an operation in the same DB transaction,
+	// and only requested once that has committed - so the plugin's update always finds the operation
+	var pinOp *fftypes.Operation
+	err = bm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if bm.publicstorage.Capabilities().Pinning {
+			pinOp = fftypes.NewTXOperation(
+				bm.publicstorage,
+				batch.Namespace,
+				batch.Payload.TX.ID,
+				batch.PayloadRef,
+				fftypes.OpTypePublicStoragePin,
+				fftypes.OpStatusPending)
+			if err := bm.database.InsertOperation(ctx, pinOp); err != nil {
+				return err
+			}
+		}
 		return bm.submitTXAndUpdateDB(ctx, batch, pins)
 	})
+	if err != nil {
+		return err
+	}
+	if pinOp != nil {
+		bm.publicstorage.PinData(ctx, pinOp.ID, batch.PayloadRef)
+	}
+	return nil
 }
 
 func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
@@ -138,26 +183,33 @@ func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *ffty
 }
 
 func (bm *broadcastManager) publishBlobs(ctx context.Context, dataToPublish []*fftypes.DataAndBlob) error {
+	// Each unique blob is only published once, even if it is attached to multiple pieces of data
+	published := make(map[fftypes.Bytes32]string)
 	for _, d := range dataToPublish {
-		// Stream from the local data exchange ...
-		reader, err := bm.exchange.DownloadBLOB(ctx, d.Blob.PayloadRef)
-		if err != nil {
-			return i18n.WrapError(ctx, err, i18n.MsgDownloadBlobFailed, d.Blob.PayloadRef)
-		}
-		defer reader.Close()
-
-		// ... to the public storage
-		publicRef, err := bm.publicstorage.PublishData(ctx, reader)
-		if err != nil {
-			return err
+		publicRef, ok := published[*d.Blob.Hash]
+		if ok {
+			log.L(ctx).Infof("Blob with hash '%s' for data '%s' already published to public storage: '%s'", d.Data.Blob.Hash, d.Data.ID, publicRef)
+		} else {
+			// Stream from the local data exchange ...
+			reader, err := bm.exchange.DownloadBLOB(ctx, d.Blob.PayloadRef)
+			if err != nil {
+				return i18n.WrapError(ctx, err, i18n.MsgDownloadBlobFailed, d.Blob.PayloadRef)
+			}
+			defer reader.Close()
+
+			// ... to the public storage
+			publicRef, err = bm.publicstorage.PublishData(ctx, reader)
+			if err != nil {
+				return err
+			}
+			published[*d.Blob.Hash] = publicRef
+			log.L(ctx).Infof("Published blob with hash '%s' for data '%s' to public storage: '%s'", d.Data.Blob.Hash, d.Data.ID, publicRef)
 		}
-		log.L(ctx).Infof("Published blob with hash '%s' for data '%s' to public storage: '%s'", d.Data.Blob, d.Data.ID, publicRef)
 
 		// Update the data in the database, with the public reference.
 		// We do this independently for each piece of data
 		update := database.DataQueryFactory.NewUpdate(ctx).Set("blob.public", publicRef)
-		err = bm.database.UpdateData(ctx, d.Data.ID, update)
-		if err != nil {
+		if err := bm.database.UpdateData(ctx, d.Data.ID, update); err != nil {
 			return err
 		}
 	}
@@ -19,6 +19,7 @@ package broadcast
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -33,9 +34,11 @@ import (
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/mocks/quotamocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -51,8 +54,12 @@ func newTestBroadcast(t *testing.T) (*broadcastManager, func()) {
 	mdx := &dataexchangemocks.Plugin{}
 	msa := &syncasyncmocks.Bridge{}
 	mbp := &batchpinmocks.Submitter{}
+	mqm := &quotamocks.Manager{}
+	mqm.On("CheckMessage", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	mbp.On("PreflightCheck", mock.Anything, mock.Anything).Return(nil).Maybe()
 	mbi.On("Name").Return("ut_blockchain").Maybe()
 	mpi.On("Name").Return("ut_publicstorage").Maybe()
+	mpi.On("Capabilities").Return(&publicstorage.Capabilities{}).Maybe()
 	mba.On("RegisterDispatcher", []fftypes.MessageType{
 		fftypes.MessageTypeBroadcast,
 		fftypes.MessageTypeDefinition,
@@ -67,22 +74,30 @@ func newTestBroadcast(t *testing.T) (*broadcastManager, func()) {
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	b, err := NewBroadcastManager(ctx, mdi, mim, mdm, mbi, mdx, mpi, mba, msa, mbp)
+	b, err := NewBroadcastManager(ctx, mdi, mim, mdm, mbi, mdx, mpi, mba, msa, mbp, mqm)
 	assert.NoError(t, err)
 	return b.(*broadcastManager), cancel
 }
 
 func TestInitFail(t *testing.T) {
-	_, err := NewBroadcastManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewBroadcastManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitFailBadCompression(t *testing.T) {
+	config.Reset()
+	config.Set(config.BroadcastBatchCompression, "zstd")
+	_, err := NewBroadcastManager(context.Background(), &databasemocks.Plugin{}, &identitymanagermocks.Manager{}, &datamocks.Manager{}, &blockchainmocks.Plugin{}, &dataexchangemocks.Plugin{}, &publicstoragemocks.Plugin{}, &batchmocks.Manager{}, &syncasyncmocks.Bridge{}, &batchpinmocks.Submitter{}, &quotamocks.Manager{})
+	assert.Regexp(t, "FF10378.*zstd", err)
+}
+
 func TestBroadcastMessageGood(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
 
 	msg := &fftypes.MessageInOut{}
 	bm.database.(*databasemocks.Plugin).On("UpsertMessage", mock.Anything, &msg.Message, database.UpsertOptimizationNew).Return(nil)
+	bm.database.(*databasemocks.Plugin).On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 
 	broadcast := broadcastSender{
 		mgr: bm,
@@ -132,6 +147,19 @@ func TestDispatchBatchInvalidData(t *testing.T) {
 	assert.Regexp(t, "FF10137", err)
 }
 
+func TestDispatchBatchPreflightFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mbp := &batchpinmocks.Submitter{}
+	bm.batchpin = mbp
+	mbp.On("PreflightCheck", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), &fftypes.Batch{}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+	mbp.AssertExpectations(t)
+}
+
 func TestDispatchBatchUploadFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -161,6 +189,109 @@ func TestDispatchBatchSubmitBatchPinSucceed(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDispatchBatchPinData(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{ID: fftypes.NewUUID()},
+		},
+	}
+
+	mps := &publicstoragemocks.Plugin{}
+	bm.publicstorage = mps
+	mdi := bm.database.(*databasemocks.Plugin)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("Name").Return("ut_publicstorage")
+	mps.On("Capabilities").Return(&publicstorage.Capabilities{Pinning: true})
+	mps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypePublicStoragePin
+	})).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypePublicStorageBatchBroadcast
+	})).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mps.On("PinData", mock.Anything, mock.Anything, "id1").Return()
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+
+	pinOp := mdi.Calls[1].Arguments[1].(*fftypes.Operation)
+	assert.Equal(t, fftypes.OpStatusPending, pinOp.Status)
+	assert.Equal(t, "id1", pinOp.BackendID)
+	assert.Equal(t, *batch.Payload.TX.ID, *pinOp.Transaction)
+	mps.AssertCalled(t, "PinData", mock.Anything, pinOp.ID, "id1")
+	mps.AssertExpectations(t)
+}
+
+func TestDispatchBatchPinOpFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mps := &publicstoragemocks.Plugin{}
+	bm.publicstorage = mps
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps.On("Name").Return("ut_publicstorage")
+	mps.On("Capabilities").Return(&publicstorage.Capabilities{Pinning: true})
+	mps.On("PublishData", mock.Anything, mock.Anything).Return("id1", nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), &fftypes.Batch{}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+	mps.AssertNotCalled(t, "PinData", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDispatchBatchCompressed(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	bm.compression = fftypes.BatchCompressionGzip
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{ID: fftypes.NewUUID()},
+		},
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mps := bm.publicstorage.(*publicstoragemocks.Plugin)
+	mbp := bm.batchpin.(*batchpinmocks.Submitter)
+	mps.On("PublishData", mock.Anything, mock.MatchedBy(func(r io.Reader) bool {
+		var uploaded *fftypes.Batch
+		b, _ := ioutil.ReadAll(r)
+		err := json.Unmarshal(b, &uploaded)
+		if err != nil || uploaded.Compression != fftypes.BatchCompressionGzip || uploaded.Payload.TX.ID != nil {
+			return false
+		}
+		_, err = uploaded.DecompressPayload(context.Background(), 0)
+		return err == nil && *uploaded.Payload.TX.ID == *batch.Payload.TX.ID
+	})).Return("id1", nil)
+	mdi.On("UpdateBatch", mock.Anything, batch.ID, mock.Anything).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mbp.On("SubmitPinnedBatch", mock.Anything, batch, mock.Anything).Return(nil)
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.FFEnum(""), batch.Compression)
+	assert.NotNil(t, batch.Payload.TX.ID)
+	mps.AssertExpectations(t)
+	mbp.AssertExpectations(t)
+}
+
+func TestDispatchBatchCompressFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	bm.compression = "lzw"
+
+	err := bm.dispatchBatch(context.Background(), &fftypes.Batch{}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.Regexp(t, "FF10378", err)
+}
+
 func TestDispatchBatchSubmitBroadcastFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -299,6 +430,38 @@ func TestPublishBlobsUpdateDataFail(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestPublishBlobsDuplicateOnce(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mps := bm.publicstorage.(*publicstoragemocks.Plugin)
+
+	blobHash := fftypes.NewRandB32()
+	dataID1 := fftypes.NewUUID()
+	dataID2 := fftypes.NewUUID()
+
+	ctx := context.Background()
+	mdx.On("DownloadBLOB", ctx, "blob/1").Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil).Once()
+	mps.On("PublishData", ctx, mock.Anything).Return("payload-ref", nil).Once()
+	mdi.On("UpdateData", ctx, dataID1, mock.Anything).Return(nil)
+	mdi.On("UpdateData", ctx, dataID2, mock.Anything).Return(nil)
+
+	blob := &fftypes.Blob{
+		Hash:       blobHash,
+		PayloadRef: "blob/1",
+	}
+	err := bm.publishBlobs(ctx, []*fftypes.DataAndBlob{
+		{Data: &fftypes.Data{ID: dataID1, Blob: &fftypes.BlobRef{Hash: blobHash}}, Blob: blob},
+		{Data: &fftypes.Data{ID: dataID2, Blob: &fftypes.BlobRef{Hash: blobHash}}, Blob: blob},
+	})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	mps.AssertExpectations(t)
+}
+
 func TestPublishBlobsPublishFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
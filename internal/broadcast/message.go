@@ -19,9 +19,12 @@ package broadcast
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/quota"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -47,10 +50,11 @@ func (bm *broadcastManager) BroadcastMessage(ctx context.Context, ns string, in
 }
 
 type broadcastSender struct {
-	mgr       *broadcastManager
-	namespace string
-	msg       *fftypes.MessageInOut
-	resolved  bool
+	mgr         *broadcastManager
+	namespace   string
+	msg         *fftypes.MessageInOut
+	resolved    bool
+	reservation *quota.Reservation
 }
 
 // sendMethod is the specific operation requested of the broadcastSender.
@@ -90,12 +94,19 @@ func (s *broadcastSender) setDefaults() {
 	}
 }
 
-func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod) error {
+func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod) (err error) {
 	sent := false
+	defer func() {
+		// The usage reserved against quotas is only kept if the message was stored
+		if err != nil {
+			s.reservation.Release()
+		}
+		s.reservation = nil
+	}()
 
 	// We optimize the DB storage of all the parts of the message using transaction semantics (assuming those are supported by the DB plugin)
 	var dataToPublish []*fftypes.DataAndBlob
-	err := s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+	err = s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
 		if !s.resolved {
 			if dataToPublish, err = s.resolve(ctx); err != nil {
 				return err
@@ -127,8 +138,23 @@ func (s *broadcastSender) resolveAndSend(ctx context.Context, method sendMethod)
 }
 
 func (s *broadcastSender) resolve(ctx context.Context) ([]*fftypes.DataAndBlob, error) {
+	// Reject a retried submission, before any of its data is stored
+	if err := txcommon.CheckMessageIdempotencyKey(ctx, s.mgr.database, s.namespace, s.msg.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
 	// Resolve the sending identity
-	if !s.isRootOrgBroadcast(ctx) {
+	if s.msg.Header.Delegation != nil {
+		// Sending on behalf of another org, under a delegation that org granted to the local org
+		if err := s.mgr.identity.ResolveDelegatedIdentity(ctx, s.namespace, &s.msg.Header.Identity, s.msg.Header.Delegation); err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+		}
+	} else if strings.HasPrefix(s.msg.Header.Author, fftypes.FireflyPseudonymDIDPrefix) {
+		// Broadcasting under a pseudonym masks the org that sent the message
+		if err := s.mgr.identity.ResolvePseudonymIdentity(ctx, s.namespace, &s.msg.Header.Identity); err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+		}
+	} else if !s.isRootOrgBroadcast(ctx) {
 		if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
 			return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
 		}
@@ -158,7 +184,16 @@ func (s *broadcastSender) sendInternal(ctx context.Context, method sendMethod) (
 	}
 
 	// Store the message - this asynchronously triggers the next step in process
-	return s.mgr.database.UpsertMessage(ctx, &s.msg.Message, database.UpsertOptimizationNew)
+	return s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		// Enforce any quotas configured for the namespace and topics
+		if s.reservation, err = s.mgr.quota.CheckMessage(ctx, s.msg); err != nil {
+			return err
+		}
+		if err := s.mgr.database.UpsertMessage(ctx, &s.msg.Message, database.UpsertOptimizationNew); err != nil {
+			return err
+		}
+		return s.mgr.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(&s.msg.Message, "broadcast submitted"))
+	})
 }
 
 func (s *broadcastSender) isRootOrgBroadcast(ctx context.Context) bool {
@@ -26,12 +26,15 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/quota"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/mocks/quotamocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -56,6 +59,7 @@ func TestBroadcastMessageOk(t *testing.T) {
 		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
 	}, []*fftypes.DataAndBlob{}, nil)
 	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
 	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
 
 	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
@@ -80,6 +84,83 @@ func TestBroadcastMessageOk(t *testing.T) {
 	mdm.AssertExpectations(t)
 }
 
+func TestBroadcastMessageQuotaExceeded(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+	mqm := &quotamocks.Manager{}
+	bm.quota = mqm
+
+	ctx := context.Background()
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+	mqm.On("CheckMessage", ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertNotCalled(t, "UpsertMessage", mock.Anything, mock.Anything, mock.Anything)
+	mdm.AssertExpectations(t)
+	mqm.AssertExpectations(t)
+}
+
+func TestBroadcastMessageQuotaReleasedOnStoreFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	config.Set(config.QuotaNamespaces, fftypes.JSONObjectArray{
+		{"name": "ns1", "messagesPerMinute": 1},
+	})
+	qm, err := quota.NewQuotaManager(context.Background(), mdi)
+	assert.NoError(t, err)
+	bm.quota = qm
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ctx, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop")).Once()
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
+
+	newMsg := func() *fftypes.MessageInOut {
+		return &fftypes.MessageInOut{
+			InlineData: fftypes.InlineData{
+				{Value: fftypes.Byteable(`{"hello": "world"}`)},
+			},
+		}
+	}
+
+	// The usage reserved for the message that failed to store must not count against the quota
+	_, err = bm.BroadcastMessage(ctx, "ns1", newMsg(), false)
+	assert.EqualError(t, err, "pop")
+	_, err = bm.BroadcastMessage(ctx, "ns1", newMsg(), false)
+	assert.NoError(t, err)
+	_, err = bm.BroadcastMessage(ctx, "ns1", newMsg(), false)
+	assert.Regexp(t, "FF10337", err)
+
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
 func TestBroadcastRootOrg(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -113,6 +194,7 @@ func TestBroadcastRootOrg(t *testing.T) {
 		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
 	}, []*fftypes.DataAndBlob{}, nil)
 	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
 	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
 
 	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
@@ -215,6 +297,7 @@ func TestBroadcastMessageWaitConfirmOk(t *testing.T) {
 		}).
 		Return(replyMsg, nil)
 	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
 
 	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
 		Message: fftypes.Message{
@@ -280,6 +363,7 @@ func TestBroadcastMessageWithBlobsOk(t *testing.T) {
 	})).Return("payload-ref", nil)
 	mdi.On("UpdateData", ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
 	mim.On("ResolveInputIdentity", ctx, mock.Anything).Return(nil)
 
 	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
@@ -333,6 +417,34 @@ func TestBroadcastMessageBadInput(t *testing.T) {
 	mdm.AssertExpectations(t)
 }
 
+func TestBroadcastMessageDuplicateIdempotencyKey(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdi.On("GetMessages", ctx, mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}},
+	}, nil, nil)
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			IdempotencyKey: "retry1",
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10407.*retry1", err)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestBroadcastMessageBadIdentity(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -351,6 +463,137 @@ func TestBroadcastMessageBadIdentity(t *testing.T) {
 	mim.AssertExpectations(t)
 }
 
+func TestBroadcastMessagePseudonymOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
+	mim.On("ResolvePseudonymIdentity", ctx, "ns1", mock.Anything).Return(nil)
+
+	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "did:firefly:pseudonym/" + fftypes.NewUUID().String(),
+				},
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", msg.Header.Namespace)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastMessagePseudonymBadIdentity(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	ctx := context.Background()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolvePseudonymIdentity", ctx, "ns1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "did:firefly:pseudonym/" + fftypes.NewUUID().String(),
+				},
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10206", err)
+
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastMessageDelegatedOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdm := bm.data.(*datamocks.Manager)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	ctx := context.Background()
+	delegationID := fftypes.NewUUID()
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		var fn = a[1].(func(context.Context) error)
+		rag.ReturnArguments = mock.Arguments{fn(a[0].(context.Context))}
+	}
+	mdm.On("ResolveInlineDataBroadcast", ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, []*fftypes.DataAndBlob{}, nil)
+	mdi.On("UpsertMessage", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", ctx, mock.Anything).Return(nil)
+	mim.On("ResolveDelegatedIdentity", ctx, "ns1", mock.Anything, delegationID).Return(nil)
+
+	msg, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "org1",
+				},
+				Delegation: delegationID,
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", msg.Header.Namespace)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastMessageDelegatedBadIdentity(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	ctx := context.Background()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveDelegatedIdentity", ctx, "ns1", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastMessage(ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Identity: fftypes.Identity{
+					Author: "org1",
+				},
+				Delegation: fftypes.NewUUID(),
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"hello": "world"}`)},
+		},
+	}, false)
+	assert.Regexp(t, "FF10206", err)
+
+	mim.AssertExpectations(t)
+}
+
 func TestPublishBlobsSendMessageFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
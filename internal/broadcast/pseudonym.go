@@ -0,0 +1,71 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (bm *broadcastManager) BroadcastPseudonym(ctx context.Context, ns string, pseudonym *fftypes.Pseudonym, waitConfirm bool) (*fftypes.Pseudonym, error) {
+	if pseudonym.Key == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "key")
+	}
+	key, err := bm.identity.ResolveSigningKey(ctx, ns, pseudonym.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key that is already registered to an org (or another pseudonym) would reveal who is behind it
+	existing, err := bm.identity.ResolveSigningKeyIdentity(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, i18n.NewError(ctx, i18n.MsgPseudonymKeyInUse, key, existing)
+	}
+	owner, err := bm.identity.ResolveLocalOrgDID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pseudonym.ID = fftypes.NewUUID()
+	pseudonym.Namespace = ns
+	pseudonym.Key = key
+	pseudonym.Owner = owner
+	pseudonym.Created = fftypes.Now()
+
+	// The owner is only recorded locally, before the registration is broadcast
+	if err := bm.database.UpsertPseudonym(ctx, pseudonym); err != nil {
+		return nil, err
+	}
+
+	// The registration is signed by the pseudonymous key itself, so it is not linked to the org
+	def := *pseudonym
+	def.Owner = ""
+	signingIdentity := &fftypes.Identity{
+		Author: pseudonym.GetDID(),
+		Key:    key,
+	}
+	msg, err := bm.broadcastDefinitionCommon(ctx, ns, &def, signingIdentity, fftypes.SystemTagDefinePseudonym, waitConfirm)
+	if msg != nil {
+		pseudonym.Message = msg.Header.ID
+	}
+	return pseudonym, err
+}
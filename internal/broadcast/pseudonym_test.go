@@ -0,0 +1,143 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBroadcastPseudonymOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("", nil)
+	mim.On("ResolveLocalOrgDID", mock.Anything).Return("did:firefly:org/org1", nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.MatchedBy(func(p *fftypes.Pseudonym) bool {
+		return p.Owner == "did:firefly:org/org1" && p.Key == "0x12345" && p.Namespace == "ns1"
+	})).Return(nil)
+	mdi.On("UpsertData", mock.Anything, mock.MatchedBy(func(data *fftypes.Data) bool {
+		var p fftypes.Pseudonym
+		err := json.Unmarshal(data.Value, &p)
+		return err == nil && p.Owner == "" && p.Key == "0x12345"
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", mock.Anything, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Key == "0x12345" && strings.HasPrefix(msg.Header.Author, fftypes.FireflyPseudonymDIDPrefix) &&
+			msg.Header.Tag == string(fftypes.SystemTagDefinePseudonym)
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
+
+	pseudonym, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{
+		Key: "key1",
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "did:firefly:org/org1", pseudonym.Owner)
+	assert.NotNil(t, pseudonym.Message)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastPseudonymMissingKey(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{}, false)
+	assert.Regexp(t, "FF10140.*key", err)
+}
+
+func TestBroadcastPseudonymResolveKeyFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("", fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{Key: "key1"}, false)
+	assert.EqualError(t, err, "pop")
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastPseudonymResolveIdentityFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("", fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{Key: "key1"}, false)
+	assert.EqualError(t, err, "pop")
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastPseudonymKeyInUse(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("did:firefly:org/org1", nil)
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{Key: "key1"}, false)
+	assert.Regexp(t, "FF10382", err)
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastPseudonymLocalOrgFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("", nil)
+	mim.On("ResolveLocalOrgDID", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{Key: "key1"}, false)
+	assert.EqualError(t, err, "pop")
+	mim.AssertExpectations(t)
+}
+
+func TestBroadcastPseudonymUpsertFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mim := bm.identity.(*identitymanagermocks.Manager)
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "key1").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("", nil)
+	mim.On("ResolveLocalOrgDID", mock.Anything).Return("did:firefly:org/org1", nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := bm.BroadcastPseudonym(context.Background(), "ns1", &fftypes.Pseudonym{Key: "key1"}, false)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
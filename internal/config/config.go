@@ -54,10 +54,20 @@ var (
 	APIRequestMaxTimeout = rootKey("api.requestMaxTimeout")
 	// APIShutdownTimeout is the amount of time to wait for any in-flight requests to finish before killing the HTTP server
 	APIShutdownTimeout = rootKey("api.shutdownTimeout")
+	// AuditEnabled records every API call that can change state in the audit log
+	AuditEnabled = rootKey("audit.enabled")
+	// BatchFastpathNamespaces is the list of low latency namespaces, where messages marked immediate are pinned in their own batch without waiting for the batch timeout
+	BatchFastpathNamespaces = rootKey("batch.fastpath.namespaces")
 	// BatchManagerReadPageSize is the size of each page of messages read from the database into memory when assembling batches
 	BatchManagerReadPageSize = rootKey("batch.manager.readPageSize")
 	// BatchManagerReadPollTimeout is how long without any notifications of new messages to wait, before doing a page query
 	BatchManagerReadPollTimeout = rootKey("batch.manager.pollTimeout")
+	// BatchPinAggregationEnabled coalesces the pins of batches signed by the same key, across all namespaces, into a single blockchain transaction
+	BatchPinAggregationEnabled = rootKey("batch.pinAggregation.enabled")
+	// BatchPinAggregationMaxBatches is the maximum number of batches pinned in a single blockchain transaction
+	BatchPinAggregationMaxBatches = rootKey("batch.pinAggregation.maxBatches")
+	// BatchPinAggregationTimeout is the maximum time to wait for other batches to pin in the same blockchain transaction
+	BatchPinAggregationTimeout = rootKey("batch.pinAggregation.timeout")
 	// BatchRetryFactor is the retry backoff factor for database operations performed by the batch manager
 	BatchRetryFactor = rootKey("batch.retry.factor")
 	// BatchRetryInitDelay is the retry initial delay for database operations
@@ -66,18 +76,32 @@ var (
 	BatchRetryMaxDelay = rootKey("batch.retry.maxDelay")
 	// BlockchainType is the name of the blockchain interface plugin being used by this firefly node
 	BlockchainType = rootKey("blockchain.type")
+	// BlockchainsList is the root key containing a list of named blockchain connectors, used in place of the single blockchain plugin
+	BlockchainsList = rootKey("blockchains")
 	// BroadcastBatchAgentTimeout how long to keep around a batching agent for a sending identity before disposal
 	BroadcastBatchAgentTimeout = rootKey("broadcast.batch.agentTimeout")
+	// BroadcastBatchCompression is the compression applied to batch payloads before upload to shared storage (none/gzip)
+	BroadcastBatchCompression = rootKey("broadcast.batch.compression")
+	// BroadcastPseudonymResolvers identities allowed to resolve the true author of pseudonyms registered by this node
+	BroadcastPseudonymResolvers = rootKey("broadcast.pseudonym.resolvers")
 	// BroadcastBatchSize is the maximum size of a batch for broadcast messages
 	BroadcastBatchSize = rootKey("broadcast.batch.size")
 	// BroadcastBatchTimeout is the timeout to wait for a batch to fill, before sending
 	BroadcastBatchTimeout = rootKey("broadcast.batch.timeout")
+	// BroadcastQuorumEnabled if true, broadcast messages are acknowledged with receipts to their author, and only confirmed on the authoring node once a quorum of receipts is received
+	BroadcastQuorumEnabled = rootKey("broadcast.quorum.enabled")
+	// BroadcastQuorumSize is the number of member orgs (other than the author) that must send a receipt before a broadcast is confirmed. Zero means all registered root orgs
+	BroadcastQuorumSize = rootKey("broadcast.quorum.size")
 	// PrivateMessagingBatchAgentTimeout how long to keep around a batching agent for a sending identity before disposal
 	PrivateMessagingBatchAgentTimeout = rootKey("privatemessaging.batch.agentTimeout")
 	// PrivateMessagingBatchSize is the maximum size of a batch for broadcast messages
 	PrivateMessagingBatchSize = rootKey("privatemessaging.batch.size")
 	// PrivateMessagingBatchTimeout is the timeout to wait for a batch to fill, before sending
 	PrivateMessagingBatchTimeout = rootKey("privatemessaging.batch.timeout")
+	// PrivateMessagingHoldGroups is a list of group hashes, for which outbound private messages are held until an operator approves their release
+	PrivateMessagingHoldGroups = rootKey("privatemessaging.hold.groups")
+	// PrivateMessagingHoldTags is a list of message tags, for which outbound private messages are held until an operator approves their release
+	PrivateMessagingHoldTags = rootKey("privatemessaging.hold.tags")
 	// PrivateMessagingOpCorrelationRetries how many times to correlate an event for an operation (such as tx submission) back to an operation.
 	// Needed because the operation update might come back before we are finished persisting the ID of the request
 	PrivateMessagingOpCorrelationRetries = rootKey("privatemessaging.opCorrelationRetries")
@@ -101,6 +125,12 @@ var (
 	CorsEnabled = rootKey("cors.enabled")
 	// CorsMaxAge is the maximum age a browser should rely on CORS checks
 	CorsMaxAge = rootKey("cors.maxAge")
+	// ConsistencyCheckOnStartup runs a consistency check across messages, data, batches, pins and events before the node starts processing
+	ConsistencyCheckOnStartup = rootKey("consistency.checkOnStartup")
+	// ConsistencyPageSize is the number of records read from each collection at a time during a consistency check
+	ConsistencyPageSize = rootKey("consistency.pageSize")
+	// ConsistencyRepair is whether the consistency check on startup repairs the inconsistencies that are safe to repair
+	ConsistencyRepair = rootKey("consistency.repair")
 	// DataexchangeType is the name of the data exchange plugin being used by this firefly node
 	DataexchangeType = rootKey("dataexchange.type")
 	// DatabaseType the type of the database interface plugin to use
@@ -122,8 +152,22 @@ var (
 	// EventAggregatorOpCorrelationRetries how many times to correlate an event for an operation (such as tx submission) back to an operation.
 	// Needed because the operation update might come back before we are finished persisting the ID of the request
 	EventAggregatorOpCorrelationRetries = rootKey("event.aggregator.opCorrelationRetries")
+	// EventAggregatorPinPolicyAction what to do with messages whose batch pin was signed by a key that is not permitted by the pin policy - none, reject or quarantine (until an operator decides the pin quarantine)
+	EventAggregatorPinPolicyAction = rootKey("event.aggregator.pinPolicy.action")
+	// EventAggregatorPinPolicyRules restricts the registered identities that can send on a namespace, or on specific topics within a namespace
+	EventAggregatorPinPolicyRules = rootKey("event.aggregator.pinPolicy.rules")
+	// EventAggregatorPayloadRetryFactor the backoff factor to use for retry of the retrieval of broadcast batch payloads from shared storage
+	EventAggregatorPayloadRetryFactor = rootKey("event.aggregator.payloadRetry.factor")
+	// EventAggregatorPayloadRetryInitDelay the initial delay before retrying the retrieval of a broadcast batch payload
+	EventAggregatorPayloadRetryInitDelay = rootKey("event.aggregator.payloadRetry.initDelay")
+	// EventAggregatorPayloadRetryMaxDelay the maximum delay between retries of the retrieval of a broadcast batch payload
+	EventAggregatorPayloadRetryMaxDelay = rootKey("event.aggregator.payloadRetry.maxDelay")
 	// EventAggregatorPollTimeout the time to wait without a notification of new events, before trying a select on the table
 	EventAggregatorPollTimeout = rootKey("event.aggregator.pollTimeout")
+	// EventAggregatorSnapshotInterval how often to record a snapshot of the aggregator state, so it can be restored quickly on restart (0 disables)
+	EventAggregatorSnapshotInterval = rootKey("event.aggregator.snapshotInterval")
+	// EventAggregatorSnapshotMaxPending the maximum number of parked pins, and of blocked pins, the aggregator tracks to re-check on restart. Pins beyond the limit are logged, and are still processed when their batch or the message blocking them is confirmed
+	EventAggregatorSnapshotMaxPending = rootKey("event.aggregator.snapshotMaxPending")
 	// EventAggregatorRetryFactor the backoff factor to use for retry of database operations
 	EventAggregatorRetryFactor = rootKey("event.aggregator.retry.factor")
 	// EventAggregatorRetryInitDelay the initial delay to use for retry of data base operations
@@ -144,6 +188,30 @@ var (
 	EventDispatcherRetryMaxDelay = rootKey("event.dispatcher.retry.maxDelay")
 	// EventDBEventsBufferSize the size of the buffer of change events
 	EventDBEventsBufferSize = rootKey("event.dbevents.bufferSize")
+	// EventDedupWindow how long to remember the hash of each blockchain event, to skip duplicates redelivered by a connector (0 disables)
+	EventDedupWindow = rootKey("event.dedup.window")
+	// EventDedupMaxEntries the maximum number of recent event hashes to remember
+	EventDedupMaxEntries = rootKey("event.dedup.maxEntries")
+	// EventDedupPersistInterval how often to persist the recent event hashes, so they survive a restart
+	EventDedupPersistInterval = rootKey("event.dedup.persistInterval")
+	// EventReceiveMaxBatchMessages the maximum number of messages in an inbound batch, before it is quarantined (0 disables)
+	EventReceiveMaxBatchMessages = rootKey("event.receive.maxBatchMessages")
+	// EventReceiveMaxBatchSize the maximum payload size of an inbound batch from data exchange or shared storage, before it is quarantined (0 disables)
+	EventReceiveMaxBatchSize = rootKey("event.receive.maxBatchSize")
+	// EventReceiveMaxBlobSize the maximum size of any blob referred to by an inbound batch, before it is quarantined (0 disables)
+	EventReceiveMaxBlobSize = rootKey("event.receive.maxBlobSize")
+	// EventReceiveUnknownAuthorAction what to do with inbound batches from an identity that is not in the network map - reject, quarantine (until the identity arrives) or accept
+	EventReceiveUnknownAuthorAction = rootKey("event.receive.unknownAuthor.action")
+	// EventReceiveUnknownAuthorNamespaces overrides the unknown author action for individual namespaces, as a list of namespace/action pairs
+	EventReceiveUnknownAuthorNamespaces = rootKey("event.receive.unknownAuthor.namespaces")
+	// EventReceiveTimestampsAction what to do with inbound batches declaring timestamps that deviate from the reference time by more than the max skew - none, annotate or reject
+	EventReceiveTimestampsAction = rootKey("event.receive.timestamps.action")
+	// EventReceiveTimestampsMaxSkew the maximum deviation of declared timestamps from the reference time, also used to report clock skew in the node status
+	EventReceiveTimestampsMaxSkew = rootKey("event.receive.timestamps.maxSkew")
+	// FeaturesDisabled is the list of features disabled in every namespace, unless re-enabled for an individual namespace
+	FeaturesDisabled = rootKey("features.disabled")
+	// FeaturesNamespaces overrides the enabled features for individual namespaces, as a list of namespace names with enabled/disabled feature lists
+	FeaturesNamespaces = rootKey("features.namespaces")
 	// GroupCacheSize cache size for private group addresses
 	GroupCacheSize = rootKey("group.cache.size")
 	// GroupCacheTTL cache time-to-live for private group addresses
@@ -160,8 +228,14 @@ var (
 	IdentityManagerCacheLimit = rootKey("identity.manager.cache.limit")
 	// Lang is the language to use for translation
 	Lang = rootKey("lang")
+	// I18nCatalogDir is a directory containing additional <lang>.json message catalogs to load on startup
+	I18nCatalogDir = rootKey("i18n.catalogDir")
+	// LogComponents is a map of component names to log levels, overriding the base log level
+	LogComponents = rootKey("log.components")
 	// LogForceColor forces color to be enabled, even if we do not detect a TTY
 	LogForceColor = rootKey("log.forceColor")
+	// LogJSONEnabled enables JSON formatted log output, for ingestion into centralized logging platforms
+	LogJSONEnabled = rootKey("log.json.enabled")
 	// LogLevel is the logging level
 	LogLevel = rootKey("log.level")
 	// LogNoColor forces color to be disabled, even if we detect a TTY
@@ -184,6 +258,8 @@ var (
 	MetricsEnabled = rootKey("metrics.enabled")
 	// MetricsPath determines what path to serve the Prometheus metrics from
 	MetricsPath = rootKey("metrics.path")
+	// MetricsRollupInterval is the size of the time buckets that message, transfer and event counts are rolled up into for charts. Zero disables the rollups
+	MetricsRollupInterval = rootKey("metrics.rollup.interval")
 	// NamespacesDefault is the default namespace - must be in the predefines list
 	NamespacesDefault = rootKey("namespaces.default")
 	// NamespacesPredefined is a list of namespaces to ensure exists, without requiring a broadcast from the network
@@ -192,6 +268,16 @@ var (
 	NodeName = rootKey("node.name")
 	// NodeDescription is a description for the node
 	NodeDescription = rootKey("node.description")
+	// NodeDXEndpointCheckInterval is how often the local data exchange endpoint is compared with the one registered for the node in the network map. Zero disables the check
+	NodeDXEndpointCheckInterval = rootKey("node.dxEndpointCheck.interval")
+	// NodeDXEndpointCheckAutoUpdate broadcasts an updated node identity when the data exchange endpoint has changed. When false, a warning is logged instead
+	NodeDXEndpointCheckAutoUpdate = rootKey("node.dxEndpointCheck.autoUpdate")
+	// NodeStandby starts the node as a warm standby, processing blockchain and data exchange inputs but with a read-only API, and without dispatching batches or events, until promoted via the admin API
+	NodeStandby = rootKey("node.standby")
+	// OperationRedactInput fields to redact from operation inputs, as "path" or "optype:path" with dot-separated paths into the JSON
+	OperationRedactInput = rootKey("operation.redact.input")
+	// OperationRedactOutput fields to redact from operation outputs, as "path" or "optype:path" with dot-separated paths into the JSON
+	OperationRedactOutput = rootKey("operation.redact.output")
 	// OrgName is the short name o the org
 	OrgName = rootKey("org.name")
 	// OrgIdentityDeprecated deprecated synonym to org.key
@@ -202,8 +288,16 @@ var (
 	OrgDescription = rootKey("org.description")
 	// OrchestratorStartupAttempts is how many time to attempt to connect to core infrastructure on startup
 	OrchestratorStartupAttempts = rootKey("orchestrator.startupAttempts")
+	// PolicyType specifies which policy plugin governs the transactions this node submits to the blockchain
+	PolicyType = rootKey("policy.type")
 	// PublicStorageType specifies which public storage interface plugin to use
 	PublicStorageType = rootKey("publicstorage.type")
+	// QuotaNamespaces is a list of quotas on the messages sent by this node in a namespace, and optionally on individual topics in that namespace
+	QuotaNamespaces = rootKey("quota.namespaces")
+	// QuotaWarningThreshold is the fraction of a quota that can be used before a quota warning event is emitted
+	QuotaWarningThreshold = rootKey("quota.warningThreshold")
+	// SubscriptionAdmins identities allowed to manage all durable subscriptions, regardless of which identity owns them
+	SubscriptionAdmins = rootKey("subscription.admins")
 	// SubscriptionDefaultsReadAhead default read ahead to enable for subscriptions that do not explicitly configure readahead
 	SubscriptionDefaultsReadAhead = rootKey("subscription.defaults.batchSize")
 	// SubscriptionMax maximum number of pre-defined subscriptions that can exist (note for high fan-out consider connecting a dedicated pub/sub broker to the dispatcher)
@@ -220,10 +314,36 @@ var (
 	AssetManagerRetryMaxDelay = rootKey("asset.manager.retry.maxDelay")
 	// AssetManagerRetryFactor the backoff factor to use for retry of database operations
 	AssetManagerRetryFactor = rootKey("asset.manager.retry.factor")
+	// TracingEnabled determines whether OpenTelemetry spans will be exported for API requests, and the calls made to connectors
+	TracingEnabled = rootKey("tracing.enabled")
+	// TracingServiceName is the service name that spans are reported under, which should be unique for each node
+	TracingServiceName = rootKey("tracing.serviceName")
+	// TracingEndpoint is the host:port of the OTLP/HTTP collector to export spans to
+	TracingEndpoint = rootKey("tracing.endpoint")
+	// TracingInsecure exports spans over plain HTTP, rather than HTTPS
+	TracingInsecure = rootKey("tracing.insecure")
+	// TracingSampleRatio is the fraction of new traces to sample, between 0 and 1. Traces propagated from a caller follow the caller's decision
+	TracingSampleRatio = rootKey("tracing.sampleRatio")
+	// TransactionPreflightEnabled enables a check of the native balance of the signing key, before submitting blockchain transactions
+	TransactionPreflightEnabled = rootKey("transaction.preflight.enabled")
+	// TransactionPreflightMinBalance is the minimum native balance (in the smallest denomination) the signing key must hold to submit a transaction
+	TransactionPreflightMinBalance = rootKey("transaction.preflight.minBalance")
+	// UUIDVersion is the version of UUID generated for new objects - v4 (random) or v7 (time-ordered, for append-friendly database indexes)
+	UUIDVersion = rootKey("uuid.version")
 	// UIEnabled set to false to disable the UI (default is true, so UI will be enabled if ui.path is valid)
 	UIEnabled = rootKey("ui.enabled")
 	// UIPath the path on which to serve the UI
 	UIPath = rootKey("ui.path")
+	// DataImportRequestTimeout is the maximum time allowed to download the content of a data import
+	DataImportRequestTimeout = rootKey("data.import.requestTimeout")
+	// DataImportAllowedHosts restricts the hosts that data can be imported from, including after redirects (empty allows any host)
+	DataImportAllowedHosts = rootKey("data.import.allowedHosts")
+	// DataImportAllowPrivateAddresses allows data to be imported from loopback, link-local and private network addresses, which are refused by default
+	DataImportAllowPrivateAddresses = rootKey("data.import.allowPrivateAddresses")
+	// DataBlobMaxSize is the maximum size of a blob uploaded to data exchange, either through the API or copied from public storage (0 disables)
+	DataBlobMaxSize = rootKey("data.blob.maxSize")
+	// DataTransformRules are transformations applied to the value of outbound data before it is validated, hashed and stored, such as stripping fields for a datatype
+	DataTransformRules = rootKey("data.transform.rules")
 	// ValidatorCacheSize
 	ValidatorCacheSize = rootKey("validator.cache.size")
 	// ValidatorCacheTTL
@@ -250,6 +370,7 @@ type Prefix interface {
 	GetBool(key string) bool
 	GetInt(key string) int
 	GetInt64(key string) int64
+	GetFloat64(key string) float64
 	GetByteSize(key string) int64
 	GetUint(key string) uint
 	GetDuration(key string) time.Duration
@@ -273,7 +394,11 @@ type PrefixArray interface {
 type RootKey string
 
 func Reset() {
+	viperMutex.Lock()
 	viper.Reset()
+	keysMutex.Lock()
+	replacedValues = map[string]interface{}{}
+	keysMutex.Unlock()
 
 	// Set defaults
 	viper.SetDefault(string(APIDefaultFilterLimit), 25)
@@ -283,8 +408,13 @@ func Reset() {
 	viper.SetDefault(string(APIMaxFilterSkip), 1000) // protects database (skip+limit pagination is not for bulk operations)
 	viper.SetDefault(string(APIRequestTimeout), "120s")
 	viper.SetDefault(string(APIShutdownTimeout), "10s")
+	viper.SetDefault(string(AuditEnabled), false)
+	viper.SetDefault(string(BatchFastpathNamespaces), []string{})
 	viper.SetDefault(string(BatchManagerReadPageSize), 100)
 	viper.SetDefault(string(BatchManagerReadPollTimeout), "30s")
+	viper.SetDefault(string(BatchPinAggregationEnabled), false)
+	viper.SetDefault(string(BatchPinAggregationMaxBatches), 20)
+	viper.SetDefault(string(BatchPinAggregationTimeout), "500ms")
 	viper.SetDefault(string(BatchRetryFactor), 2.0)
 	viper.SetDefault(string(BatchRetryFactor), 2.0)
 	viper.SetDefault(string(BatchRetryInitDelay), "250ms")
@@ -292,8 +422,15 @@ func Reset() {
 	viper.SetDefault(string(BatchRetryMaxDelay), "30s")
 	viper.SetDefault(string(BatchRetryMaxDelay), "30s")
 	viper.SetDefault(string(BroadcastBatchAgentTimeout), "2m")
+	viper.SetDefault(string(BroadcastBatchCompression), "none")
 	viper.SetDefault(string(BroadcastBatchSize), 200)
+	viper.SetDefault(string(BroadcastPseudonymResolvers), []string{})
 	viper.SetDefault(string(BroadcastBatchTimeout), "1s")
+	viper.SetDefault(string(BroadcastQuorumEnabled), false)
+	viper.SetDefault(string(BroadcastQuorumSize), 0)
+	viper.SetDefault(string(ConsistencyCheckOnStartup), false)
+	viper.SetDefault(string(ConsistencyPageSize), 100)
+	viper.SetDefault(string(ConsistencyRepair), false)
 	viper.SetDefault(string(CorsAllowCredentials), true)
 	viper.SetDefault(string(CorsAllowedHeaders), []string{"*"})
 	viper.SetDefault(string(CorsAllowedMethods), []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete})
@@ -305,17 +442,36 @@ func Reset() {
 	viper.SetDefault(string(EventAggregatorFirstEvent), fftypes.SubOptsFirstEventOldest)
 	viper.SetDefault(string(EventAggregatorBatchSize), 50)
 	viper.SetDefault(string(EventAggregatorBatchTimeout), "250ms")
+	viper.SetDefault(string(EventAggregatorPayloadRetryFactor), 2.0)
+	viper.SetDefault(string(EventAggregatorPayloadRetryInitDelay), "1s")
+	viper.SetDefault(string(EventAggregatorPayloadRetryMaxDelay), "5m")
+	viper.SetDefault(string(EventAggregatorPinPolicyAction), "none")
+	viper.SetDefault(string(EventAggregatorPinPolicyRules), fftypes.JSONObjectArray{})
 	viper.SetDefault(string(EventAggregatorPollTimeout), "30s")
 	viper.SetDefault(string(EventAggregatorRetryFactor), 2.0)
 	viper.SetDefault(string(EventAggregatorRetryInitDelay), "100ms")
 	viper.SetDefault(string(EventAggregatorRetryMaxDelay), "30s")
+	viper.SetDefault(string(EventAggregatorSnapshotInterval), "1m")
+	viper.SetDefault(string(EventAggregatorSnapshotMaxPending), 1000)
 	viper.SetDefault(string(EventAggregatorOpCorrelationRetries), 3)
 	viper.SetDefault(string(EventDBEventsBufferSize), 100)
+	viper.SetDefault(string(EventDedupWindow), "1h")
+	viper.SetDefault(string(EventDedupMaxEntries), 1000)
+	viper.SetDefault(string(EventDedupPersistInterval), "5s")
+	viper.SetDefault(string(EventReceiveMaxBatchMessages), 0)
+	viper.SetDefault(string(EventReceiveMaxBatchSize), "0")
+	viper.SetDefault(string(EventReceiveMaxBlobSize), "0")
+	viper.SetDefault(string(EventReceiveUnknownAuthorAction), "reject")
+	viper.SetDefault(string(EventReceiveUnknownAuthorNamespaces), fftypes.JSONObjectArray{})
+	viper.SetDefault(string(EventReceiveTimestampsAction), "none")
+	viper.SetDefault(string(EventReceiveTimestampsMaxSkew), "5m")
 	viper.SetDefault(string(EventDispatcherBufferLength), 5)
 	viper.SetDefault(string(EventDispatcherBatchTimeout), "0")
 	viper.SetDefault(string(EventDispatcherPollTimeout), "30s")
-	viper.SetDefault(string(EventTransportsEnabled), []string{"websockets", "webhooks"})
+	viper.SetDefault(string(EventTransportsEnabled), []string{"websockets", "webhooks", "sse"})
 	viper.SetDefault(string(EventTransportsDefault), "websockets")
+	viper.SetDefault(string(FeaturesDisabled), []string{})
+	viper.SetDefault(string(FeaturesNamespaces), fftypes.JSONObjectArray{})
 	viper.SetDefault(string(GroupCacheSize), "1Mb")
 	viper.SetDefault(string(GroupCacheTTL), "1h")
 	viper.SetDefault(string(AdminEnabled), false)
@@ -324,12 +480,18 @@ func Reset() {
 	viper.SetDefault(string(LogLevel), "info")
 	viper.SetDefault(string(LogTimeFormat), "2006-01-02T15:04:05.000Z07:00")
 	viper.SetDefault(string(LogUTC), false)
+	viper.SetDefault(string(LogJSONEnabled), false)
 	viper.SetDefault(string(LogFilesize), "100m")
 	viper.SetDefault(string(LogMaxAge), "24h")
 	viper.SetDefault(string(LogMaxBackups), 2)
+	viper.SetDefault(string(MetricsRollupInterval), "1m")
 	viper.SetDefault(string(NamespacesDefault), "default")
 	viper.SetDefault(string(NamespacesPredefined), fftypes.JSONObjectArray{{"name": "default", "description": "Default predefined namespace"}})
+	viper.SetDefault(string(NodeDXEndpointCheckInterval), "1m")
+	viper.SetDefault(string(NodeDXEndpointCheckAutoUpdate), true)
+	viper.SetDefault(string(NodeStandby), false)
 	viper.SetDefault(string(OrchestratorStartupAttempts), 5)
+	viper.SetDefault(string(PolicyType), "none")
 	viper.SetDefault(string(PrivateMessagingRetryFactor), 2.0)
 	viper.SetDefault(string(PrivateMessagingRetryInitDelay), "100ms")
 	viper.SetDefault(string(PrivateMessagingRetryMaxDelay), "30s")
@@ -337,6 +499,11 @@ func Reset() {
 	viper.SetDefault(string(PrivateMessagingBatchAgentTimeout), "2m")
 	viper.SetDefault(string(PrivateMessagingBatchSize), 200)
 	viper.SetDefault(string(PrivateMessagingBatchTimeout), "1s")
+	viper.SetDefault(string(OperationRedactInput), []string{})
+	viper.SetDefault(string(OperationRedactOutput), []string{})
+	viper.SetDefault(string(QuotaNamespaces), fftypes.JSONObjectArray{})
+	viper.SetDefault(string(QuotaWarningThreshold), 0.8)
+	viper.SetDefault(string(SubscriptionAdmins), []string{})
 	viper.SetDefault(string(SubscriptionDefaultsReadAhead), 0)
 	viper.SetDefault(string(SubscriptionMax), 500)
 	viper.SetDefault(string(SubscriptionsRetryInitialDelay), "250ms")
@@ -345,17 +512,34 @@ func Reset() {
 	viper.SetDefault(string(AssetManagerRetryInitialDelay), "250ms")
 	viper.SetDefault(string(AssetManagerRetryMaxDelay), "30s")
 	viper.SetDefault(string(AssetManagerRetryFactor), 2.0)
+	viper.SetDefault(string(TracingEnabled), false)
+	viper.SetDefault(string(TracingServiceName), "firefly")
+	viper.SetDefault(string(TracingEndpoint), "localhost:4318")
+	viper.SetDefault(string(TracingInsecure), false)
+	viper.SetDefault(string(TracingSampleRatio), 1.0)
+	viper.SetDefault(string(TransactionPreflightEnabled), false)
+	viper.SetDefault(string(TransactionPreflightMinBalance), "1")
 	viper.SetDefault(string(UIEnabled), true)
+	viper.SetDefault(string(UUIDVersion), "v4")
+	viper.SetDefault(string(DataImportRequestTimeout), "30m")
+	viper.SetDefault(string(DataImportAllowedHosts), []string{})
+	viper.SetDefault(string(DataImportAllowPrivateAddresses), false)
+	viper.SetDefault(string(DataBlobMaxSize), "0")
+	viper.SetDefault(string(DataTransformRules), fftypes.JSONObjectArray{})
 	viper.SetDefault(string(ValidatorCacheSize), "1Mb")
 	viper.SetDefault(string(ValidatorCacheTTL), "1h")
 	viper.SetDefault(string(IdentityManagerCacheLimit), 100 /* items */)
 	viper.SetDefault(string(IdentityManagerCacheTTL), "1h")
+	viperMutex.Unlock()
 
 	i18n.SetLang(GetString(Lang))
 }
 
 // ReadConfig initializes the config
 func ReadConfig(cfgFile string) error {
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
+
 	// Set precedence order for reading config location
 	viper.SetEnvPrefix("firefly")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -377,6 +561,8 @@ func ReadConfig(cfgFile string) error {
 }
 
 func MergeConfig(configRecords []*fftypes.ConfigRecord) error {
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
 	for _, c := range configRecords {
 		s := viper.New()
 		s.SetConfigType("json")
@@ -397,8 +583,98 @@ func MergeConfig(configRecords []*fftypes.ConfigRecord) error {
 	return nil
 }
 
+// PreviousValues are the values of the keys updated by MergeConfigSection, so the change can be reverted with RevertConfig
+type PreviousValues struct {
+	values   map[string]interface{}
+	replaced map[string]interface{}
+}
+
+// MergeConfigSection merges a set of values into a section of the configuration, such as "events.webhooks".
+// Every key must be a known configuration key. Each string value is passed to the replace function, in the
+// same way as ReplaceStringValues, so that secret references are resolved. The previous value of each key is
+// returned, so that the change can be reverted with RevertConfig.
+func MergeConfigSection(ctx context.Context, section string, values fftypes.JSONObject, replaceFn func(key, value string) (string, bool, error)) (*PreviousValues, error) {
+	// Read from a copy, as viper updates the keys of a map that is merged in
+	b, _ := json.Marshal(values)
+	s := viper.New()
+	s.SetConfigType("json")
+	_ = s.ReadConfig(bytes.NewBuffer(b))
+	keys := s.AllKeys()
+	for _, k := range keys {
+		if !isKnownKey(fmt.Sprintf("%s.%s", section, k)) {
+			return nil, i18n.NewError(ctx, i18n.MsgUnknownConfigKey, fmt.Sprintf("%s.%s", section, k))
+		}
+	}
+	// Values are replaced before the lock is taken, as the replace function can read the configuration
+	merged := make(map[string]interface{}, len(keys))
+	replaced := make(map[string]interface{})
+	for _, k := range keys {
+		key := strings.ToLower(fmt.Sprintf("%s.%s", section, k))
+		value, isReplaced, err := replaceStrings(key, s.Get(k), replaceFn)
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = value
+		if isReplaced {
+			replaced[key] = s.Get(k)
+		}
+	}
+
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	previous := &PreviousValues{
+		values:   make(map[string]interface{}, len(keys)),
+		replaced: make(map[string]interface{}),
+	}
+	for key, value := range merged {
+		previous.values[key] = viper.Get(key)
+		if original, ok := replacedValues[key]; ok {
+			previous.replaced[key] = original
+			delete(replacedValues, key)
+		}
+		viper.Set(key, value)
+	}
+	for key, original := range replaced {
+		replacedValues[key] = original
+	}
+	return previous, nil
+}
+
+// RevertConfig restores the previous values returned by MergeConfigSection
+func RevertConfig(previous *PreviousValues) {
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	for key, value := range previous.values {
+		viper.Set(key, value)
+		delete(replacedValues, key)
+	}
+	for key, original := range previous.replaced {
+		replacedValues[key] = original
+	}
+}
+
+func isKnownKey(key string) bool {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	// Viper keys are case insensitive
+	for k := range knownKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
 var knownKeys = map[string]bool{} // All keys go here, including those defined in sub prefixies
 var keysMutex sync.Mutex
+
+// viperMutex guards viper, which is not safe for concurrent use, as sections of the configuration
+// can be updated by the admin API while they are read. It is always taken before keysMutex.
+var viperMutex sync.RWMutex
 var root = &configPrefix{}
 
 // ark adds a root key, used to define the keys that are used within the core
@@ -421,7 +697,8 @@ func GetKnownKeys() []string {
 
 // configPrefix is the main config structure passed to plugins, and used for root to wrap viper
 type configPrefix struct {
-	prefix string
+	prefix  string
+	inArray bool
 }
 
 // configPrefixArray is a point in the config that supports an array
@@ -452,7 +729,8 @@ func (c *configPrefix) prefixKey(k string) string {
 
 func (c *configPrefix) SubPrefix(suffix string) Prefix {
 	return &configPrefix{
-		prefix: c.prefix + suffix + ".",
+		prefix:  c.prefix + suffix + ".",
+		inArray: c.inArray,
 	}
 }
 
@@ -464,7 +742,9 @@ func (c *configPrefix) Array() PrefixArray {
 }
 
 func (c *configPrefixArray) ArraySize() int {
+	viperMutex.RLock()
 	val := viper.Get(c.base)
+	viperMutex.RUnlock()
 	vt := reflect.TypeOf(val)
 	if vt != nil && vt.Kind() == reflect.Slice {
 		return reflect.ValueOf(val).Len()
@@ -475,19 +755,11 @@ func (c *configPrefixArray) ArraySize() int {
 // ArrayEntry must only be called after the config has been loaded
 func (c *configPrefixArray) ArrayEntry(i int) Prefix {
 	cp := &configPrefix{
-		prefix: c.base + fmt.Sprintf(".%d.", i),
+		prefix:  c.base + fmt.Sprintf(".%d.", i),
+		inArray: true,
 	}
 	for knownKey, defValue := range c.defaults {
 		cp.AddKnownKey(knownKey, defValue...)
-		// Sadly Viper can't handle defaults inside the array, when
-		// a value is set. So here we check/set the defaults.
-		if defValue != nil && cp.Get(knownKey) == nil {
-			if len(defValue) == 1 {
-				cp.Set(knownKey, defValue[0])
-			} else if len(defValue) > 0 {
-				cp.Set(knownKey, defValue)
-			}
-		}
 	}
 	return cp
 }
@@ -508,27 +780,128 @@ func (c *configPrefix) AddKnownKey(k string, defValue ...interface{}) {
 		c.SetDefault(k, defValue)
 	}
 	keysMutex.Lock()
-	defer keysMutex.Unlock()
 	knownKeys[key] = true
+	keysMutex.Unlock()
+	// Sadly Viper can't handle defaults inside the array, when a value is set. So within an
+	// array entry (including sub-sections of it, such as plugin config) we check/set the defaults.
+	if c.inArray && len(defValue) > 0 && c.Get(k) == nil {
+		if len(defValue) == 1 {
+			c.Set(k, defValue[0])
+		} else {
+			c.Set(k, defValue)
+		}
+	}
 }
 
 func (c *configPrefix) SetDefault(k string, defValue interface{}) {
 	key := c.prefix + k
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
 	viper.SetDefault(key, defValue)
 }
 
 func GetConfig() fftypes.JSONObject {
 	conf := fftypes.JSONObject{}
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
 	_ = viper.Unmarshal(&conf)
+	// Never return the resolved values of secrets, only the original references
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	for key, original := range replacedValues {
+		setPath(conf, strings.Split(key, "."), original)
+	}
 	return conf
 }
 
+func setPath(conf map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		conf[path[0]] = value
+		return
+	}
+	if child, ok := conf[path[0]].(map[string]interface{}); ok {
+		setPath(child, path[1:], value)
+	}
+}
+
+var replacedValues = map[string]interface{}{}
+
+// ReplaceStringValues calls the supplied function for every string value in the configuration, including
+// values within arrays of objects. The function returns the new value, and whether it should be replaced.
+// The original values of replaced keys are retained, and are returned in place of the replaced value by GetConfig.
+func ReplaceStringValues(fn func(key, value string) (string, bool, error)) error {
+	// The lock is not held while calling the function, as it can read the configuration
+	viperMutex.RLock()
+	keys := viper.AllKeys()
+	viperMutex.RUnlock()
+	for _, key := range keys {
+		viperMutex.RLock()
+		original := viper.Get(key)
+		viperMutex.RUnlock()
+		newValue, replaced, err := replaceStrings(key, original, fn)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			viperMutex.Lock()
+			viper.Set(key, newValue)
+			keysMutex.Lock()
+			replacedValues[key] = original
+			keysMutex.Unlock()
+			viperMutex.Unlock()
+		}
+	}
+	return nil
+}
+
+func replaceStrings(key string, value interface{}, fn func(key, value string) (string, bool, error)) (interface{}, bool, error) {
+	switch vt := value.(type) {
+	case string:
+		return fn(key, vt)
+	case []interface{}:
+		newArray := make([]interface{}, len(vt))
+		anyReplaced := false
+		for i, entry := range vt {
+			newEntry, replaced, err := replaceStrings(fmt.Sprintf("%s[%d]", key, i), entry, fn)
+			if err != nil {
+				return nil, false, err
+			}
+			anyReplaced = anyReplaced || replaced
+			newArray[i] = newEntry
+		}
+		return newArray, anyReplaced, nil
+	case map[string]interface{}:
+		newMap := make(map[string]interface{}, len(vt))
+		anyReplaced := false
+		for k, entry := range vt {
+			newEntry, replaced, err := replaceStrings(fmt.Sprintf("%s.%s", key, k), entry, fn)
+			if err != nil {
+				return nil, false, err
+			}
+			anyReplaced = anyReplaced || replaced
+			newMap[k] = newEntry
+		}
+		return newMap, anyReplaced, nil
+	case map[interface{}]interface{}:
+		newMap := make(map[string]interface{}, len(vt))
+		for k, entry := range vt {
+			newMap[fmt.Sprintf("%v", k)] = entry
+		}
+		return replaceStrings(key, newMap, fn)
+	default:
+		return value, false, nil
+	}
+}
+
 // GetString gets a configuration string
 func GetString(key RootKey) string {
 	return root.GetString(string(key))
 }
 func (c *configPrefix) GetString(key string) string {
-	return viper.GetString(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetString(key)
 }
 
 // GetStringSlice gets a configuration string array
@@ -536,7 +909,10 @@ func GetStringSlice(key RootKey) []string {
 	return root.GetStringSlice(string(key))
 }
 func (c *configPrefix) GetStringSlice(key string) []string {
-	return viper.GetStringSlice(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetStringSlice(key)
 }
 
 // GetBool gets a configuration bool
@@ -544,7 +920,10 @@ func GetBool(key RootKey) bool {
 	return root.GetBool(string(key))
 }
 func (c *configPrefix) GetBool(key string) bool {
-	return viper.GetBool(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetBool(key)
 }
 
 // GetDuration gets a configuration time duration with consistent semantics
@@ -552,7 +931,10 @@ func GetDuration(key RootKey) time.Duration {
 	return root.GetDuration(string(key))
 }
 func (c *configPrefix) GetDuration(key string) time.Duration {
-	return fftypes.ParseToDuration(viper.GetString(c.prefixKey(key)))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return fftypes.ParseToDuration(viper.GetString(key))
 }
 
 // GetByteSize get a size in bytes
@@ -568,7 +950,10 @@ func GetUint(key RootKey) uint {
 	return root.GetUint(string(key))
 }
 func (c *configPrefix) GetUint(key string) uint {
-	return viper.GetUint(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetUint(key)
 }
 
 // GetInt gets a configuration uint
@@ -576,7 +961,10 @@ func GetInt(key RootKey) int {
 	return root.GetInt(string(key))
 }
 func (c *configPrefix) GetInt(key string) int {
-	return viper.GetInt(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetInt(key)
 }
 
 // GetInt64 gets a configuration uint
@@ -584,7 +972,10 @@ func GetInt64(key RootKey) int64 {
 	return root.GetInt64(string(key))
 }
 func (c *configPrefix) GetInt64(key string) int64 {
-	return viper.GetInt64(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetInt64(key)
 }
 
 // GetFloat64 gets a configuration uint
@@ -592,7 +983,10 @@ func GetFloat64(key RootKey) float64 {
 	return root.GetFloat64(string(key))
 }
 func (c *configPrefix) GetFloat64(key string) float64 {
-	return viper.GetFloat64(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.GetFloat64(key)
 }
 
 // GetObject gets a configuration map
@@ -600,7 +994,10 @@ func GetObject(key RootKey) fftypes.JSONObject {
 	return root.GetObject(string(key))
 }
 func (c *configPrefix) GetObject(key string) fftypes.JSONObject {
-	return fftypes.JSONObject(viper.GetStringMap(c.prefixKey(key)))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return fftypes.JSONObject(viper.GetStringMap(key))
 }
 
 // GetObjectArray gets an array of configuration maps
@@ -608,7 +1005,10 @@ func GetObjectArray(key RootKey) fftypes.JSONObjectArray {
 	return root.GetObjectArray(string(key))
 }
 func (c *configPrefix) GetObjectArray(key string) fftypes.JSONObjectArray {
-	v, _ := fftypes.ToJSONObjectArray(viper.Get(c.prefixKey(key)))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	v, _ := fftypes.ToJSONObjectArray(viper.Get(key))
 	return v
 }
 
@@ -617,7 +1017,10 @@ func Get(key RootKey) interface{} {
 	return root.Get(string(key))
 }
 func (c *configPrefix) Get(key string) interface{} {
-	return viper.Get(c.prefixKey(key))
+	key = c.prefixKey(key)
+	viperMutex.RLock()
+	defer viperMutex.RUnlock()
+	return viper.Get(key)
 }
 
 // Set allows runtime setting of config (used in unit tests)
@@ -625,7 +1028,10 @@ func Set(key RootKey, value interface{}) {
 	root.Set(string(key), value)
 }
 func (c *configPrefix) Set(key string, value interface{}) {
-	viper.Set(c.prefixKey(key), value)
+	key = c.prefixKey(key)
+	viperMutex.Lock()
+	defer viperMutex.Unlock()
+	viper.Set(key, value)
 }
 
 // Resolve gives the fully qualified path of a key
@@ -633,6 +1039,17 @@ func (c *configPrefix) Resolve(key string) string {
 	return c.prefixKey(key)
 }
 
+// SetupLang loads any additional language catalogs, and sets the default language for messages
+func SetupLang() error {
+	if catalogDir := GetString(I18nCatalogDir); catalogDir != "" {
+		if err := i18n.LoadCatalogDir(catalogDir); err != nil {
+			return err
+		}
+	}
+	i18n.SetLang(GetString(Lang))
+	return nil
+}
+
 // SetupLogging initializes logging
 func SetupLogging(ctx context.Context) {
 	log.SetFormatting(log.Formatting{
@@ -640,6 +1057,7 @@ func SetupLogging(ctx context.Context) {
 		ForceColor:      GetBool(LogForceColor),
 		TimestampFormat: GetString(LogTimeFormat),
 		UTC:             GetBool(LogUTC),
+		JSON:            GetBool(LogJSONEnabled),
 	})
 	logFilename := GetString(LogFilename)
 	if logFilename != "" {
@@ -653,5 +1071,10 @@ func SetupLogging(ctx context.Context) {
 		logrus.SetOutput(lumberjack)
 	}
 	log.SetLevel(GetString(LogLevel))
-	log.L(ctx).Debugf("Log level: %s", logrus.GetLevel())
+	for component, level := range GetObject(LogComponents) {
+		if err := log.SetComponentLevel(ctx, component, fmt.Sprintf("%v", level)); err != nil {
+			log.L(ctx).Warnf("Ignoring log level for component '%s': %s", component, err)
+		}
+	}
+	log.L(ctx).Debugf("Log levels: %+v", log.GetLevels())
 }
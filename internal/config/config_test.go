@@ -18,6 +18,7 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -25,6 +26,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -146,6 +149,30 @@ tokens:
 	assert.Equal(t, []string{"arr1", "arr2"}, sally.GetStringSlice("key2"))
 }
 
+func TestArrayOfPluginsSubPrefixDefaults(t *testing.T) {
+	defer Reset()
+
+	biPlugins := NewPluginConfig("blockchains").Array()
+	biPlugins.AddKnownKey("name")
+	viper.SetConfigType("yaml")
+	err := viper.ReadConfig(strings.NewReader(`
+blockchains:
+- name: chain1
+  ethereum:
+    ethconnect:
+      url: http://localhost:12345
+`))
+	assert.NoError(t, err)
+	ethconnect := biPlugins.ArrayEntry(0).SubPrefix("ethereum").SubPrefix("ethconnect")
+	ethconnect.AddKnownKey("url")
+	ethconnect.AddKnownKey("batchSize", 50)
+	ethconnect.AddKnownKey("topics", "a", "b")
+	ethconnect.SetDefault("url", "http://localhost:23456")
+	assert.Equal(t, "http://localhost:12345", ethconnect.GetString("url"))
+	assert.Equal(t, 50, ethconnect.GetInt("batchSize"))
+	assert.Equal(t, []string{"a", "b"}, ethconnect.GetStringSlice("topics"))
+}
+
 func TestGetKnownKeys(t *testing.T) {
 	knownKeys := GetKnownKeys()
 	assert.NotEmpty(t, knownKeys)
@@ -174,6 +201,48 @@ func TestSetupLoggingToFile(t *testing.T) {
 	assert.Regexp(t, "Log level", string(b))
 }
 
+func TestSetupLoggingComponentsJSON(t *testing.T) {
+	Reset()
+	Set(LogJSONEnabled, true)
+	Set(LogComponents, map[string]interface{}{
+		"events": "debug",
+		"batch":  "wrong",
+	})
+	SetupLogging(context.Background())
+	defer log.SetComponentLevel(context.Background(), "events", "")
+
+	levels := log.GetLevels()
+	assert.Equal(t, "info", levels.Level)
+	assert.Equal(t, map[string]string{"events": "debug"}, levels.Components)
+
+	Reset()
+	SetupLogging(context.Background())
+}
+
+func TestSetupLang(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := ioutil.WriteFile(path.Join(tmpDir, "es.json"), []byte(`{"FF10101":"Error al leer la configuración"}`), 0644)
+	assert.NoError(t, err)
+	Reset()
+	Set(I18nCatalogDir, tmpDir)
+	Set(Lang, "es")
+	err = SetupLang()
+	assert.NoError(t, err)
+	assert.Equal(t, "Error al leer la configuración", i18n.Expand(context.Background(), i18n.MsgConfigFailed))
+	Reset()
+	err = SetupLang()
+	assert.NoError(t, err)
+	assert.Equal(t, "Failed to read config", i18n.Expand(context.Background(), i18n.MsgConfigFailed))
+}
+
+func TestSetupLangBadDir(t *testing.T) {
+	Reset()
+	Set(I18nCatalogDir, path.Join(t.TempDir(), "missing"))
+	err := SetupLang()
+	assert.Regexp(t, "FF10304", err)
+	Reset()
+}
+
 func TestSetupLogging(t *testing.T) {
 	SetupLogging(context.Background())
 }
@@ -219,8 +288,160 @@ func TestMergeConfigBadJSON(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestMergeConfigSectionAndRevert(t *testing.T) {
+	Reset()
+	prefix := NewPluginConfig("ut.section")
+	prefix.AddKnownKey("someKey", "default1")
+	prefix.AddKnownKey("nested.otherKey", 10)
+
+	previous, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+		"someKey": "value1",
+		"nested": map[string]interface{}{
+			"otherKey": 20,
+		},
+	}, noReplace)
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", prefix.GetString("someKey"))
+	assert.Equal(t, 20, prefix.GetInt("nested.otherKey"))
+
+	RevertConfig(previous)
+	assert.Equal(t, "default1", prefix.GetString("someKey"))
+	assert.Equal(t, 10, prefix.GetInt("nested.otherKey"))
+}
+
+func TestMergeConfigSectionReplaceAndRevert(t *testing.T) {
+	Reset()
+	prefix := NewPluginConfig("ut.section")
+	prefix.AddKnownKey("password")
+	prefix.AddKnownKey("username")
+	replaceFn := func(key, value string) (string, bool, error) {
+		if strings.HasPrefix(value, "secret://") {
+			return "resolved:" + key, true, nil
+		}
+		return value, false, nil
+	}
+
+	previous1, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+		"password": "secret://pass1",
+	}, replaceFn)
+	assert.NoError(t, err)
+	assert.Equal(t, "resolved:ut.section.password", prefix.GetString("password"))
+	assert.Equal(t, "secret://pass1", GetConfig().GetObject("ut").GetObject("section").GetString("password"))
+
+	// A plain value replaces the reference, and reverting restores the reference
+	previous2, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+		"password": "plain",
+		"username": "user1",
+	}, replaceFn)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", GetConfig().GetObject("ut").GetObject("section").GetString("password"))
+	RevertConfig(previous2)
+	assert.Equal(t, "resolved:ut.section.password", prefix.GetString("password"))
+	assert.Equal(t, "secret://pass1", GetConfig().GetObject("ut").GetObject("section").GetString("password"))
+
+	RevertConfig(previous1)
+	assert.Equal(t, "", prefix.GetString("password"))
+	assert.Nil(t, GetConfig().GetObject("ut").GetObject("section")["password"])
+}
+
+func TestMergeConfigSectionReplaceFail(t *testing.T) {
+	Reset()
+	prefix := NewPluginConfig("ut.section")
+	prefix.AddKnownKey("password", "default1")
+
+	_, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+		"password": "secret://pass1",
+	}, func(key, value string) (string, bool, error) {
+		return "", false, fmt.Errorf("pop")
+	})
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, "default1", prefix.GetString("password"))
+}
+
+func TestMergeConfigSectionConcurrentRead(t *testing.T) {
+	Reset()
+	prefix := NewPluginConfig("ut.section")
+	prefix.AddKnownKey("someKey", "default1")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = GetStringSlice(SubscriptionAdmins)
+			_ = prefix.GetString("someKey")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		previous, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+			"someKey": fmt.Sprintf("value%d", i),
+		}, noReplace)
+		assert.NoError(t, err)
+		RevertConfig(previous)
+	}
+	<-done
+}
+
+func TestMergeConfigSectionUnknownKey(t *testing.T) {
+	Reset()
+	prefix := NewPluginConfig("ut.section")
+	prefix.AddKnownKey("someKey", "default1")
+
+	_, err := MergeConfigSection(context.Background(), "ut.section", fftypes.JSONObject{
+		"someKey":  "value1",
+		"wrongKey": "value2",
+	}, noReplace)
+	assert.Regexp(t, "FF10446.*ut.section.wrongkey", err)
+	assert.Equal(t, "default1", prefix.GetString("someKey"))
+}
+
 func TestGetConfig(t *testing.T) {
 	Reset()
 	conf := GetConfig()
 	assert.Equal(t, "info", conf.GetObject("log").GetString("level"))
 }
+
+func TestReplaceStringValues(t *testing.T) {
+	Reset()
+	Set(OrgKey, "secret://orgkey")
+	Set(TokensList, []interface{}{
+		map[interface{}]interface{}{
+			"name":     "tokens1",
+			"password": "secret://token",
+			"port":     1234,
+		},
+	})
+	err := ReplaceStringValues(func(key, value string) (string, bool, error) {
+		if strings.HasPrefix(value, "secret://") {
+			return key, true, nil
+		}
+		return value, false, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "org.key", GetString(OrgKey))
+	tokens, _ := fftypes.ToJSONObjectArray(Get(TokensList))
+	assert.Equal(t, "tokens[0].password", tokens[0].GetString("password"))
+	assert.Equal(t, "tokens1", tokens[0].GetString("name"))
+
+	conf := GetConfig()
+	assert.Equal(t, "secret://orgkey", conf.GetObject("org").GetString("key"))
+}
+
+func TestReplaceStringValuesFail(t *testing.T) {
+	Reset()
+	Set(TokensList, []interface{}{
+		map[string]interface{}{
+			"password": "secret://token",
+		},
+	})
+	err := ReplaceStringValues(func(key, value string) (string, bool, error) {
+		if strings.HasPrefix(value, "secret://") {
+			return "", false, fmt.Errorf("pop")
+		}
+		return value, false, nil
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func noReplace(key, value string) (string, bool, error) {
+	return value, false, nil
+}
@@ -0,0 +1,210 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+const (
+	// TLSConfigEnabled whether TLS is enabled for the outbound connection
+	TLSConfigEnabled = "tls.enabled"
+	// TLSConfigCAFile the PEM bundle of certificate authorities to trust, instead of the system CAs
+	TLSConfigCAFile = "tls.caFile"
+	// TLSConfigCertFile the client certificate to present for mutual TLS
+	TLSConfigCertFile = "tls.certFile"
+	// TLSConfigKeyFile the private key of the client certificate for mutual TLS
+	TLSConfigKeyFile = "tls.keyFile"
+	// TLSConfigServerName overrides the server name used for SNI and verification of the server certificate
+	TLSConfigServerName = "tls.serverName"
+)
+
+// InitPrefix adds the TLS options to a config prefix for an outbound connection
+func InitPrefix(prefix config.KeySet) {
+	prefix.AddKnownKey(TLSConfigEnabled, false)
+	prefix.AddKnownKey(TLSConfigCAFile)
+	prefix.AddKnownKey(TLSConfigCertFile)
+	prefix.AddKnownKey(TLSConfigKeyFile)
+	prefix.AddKnownKey(TLSConfigServerName)
+}
+
+type watchedFile struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// certReloader holds the current CA bundle and client certificate, and reloads them
+// whenever the files on disk change. The check is made at the start of each TLS handshake,
+// so renewed certificates are picked up by new connections without a restart.
+type certReloader struct {
+	ctx     context.Context
+	mux     sync.Mutex
+	caFile  *watchedFile
+	cert    *watchedFile
+	key     *watchedFile
+	rootCAs *x509.CertPool
+	keyPair *tls.Certificate
+}
+
+// ConstructTLSConfig builds the TLS configuration for an outbound connection from the
+// config prefix, or returns nil if TLS is not enabled
+func ConstructTLSConfig(ctx context.Context, prefix config.Prefix) (*tls.Config, error) {
+	if !prefix.GetBool(TLSConfigEnabled) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: prefix.GetString(TLSConfigServerName),
+	}
+
+	r := &certReloader{ctx: ctx}
+	if caFile := prefix.GetString(TLSConfigCAFile); caFile != "" {
+		r.caFile = &watchedFile{name: caFile}
+	}
+	certFile := prefix.GetString(TLSConfigCertFile)
+	keyFile := prefix.GetString(TLSConfigKeyFile)
+	if certFile != "" || keyFile != "" {
+		r.cert = &watchedFile{name: certFile}
+		r.key = &watchedFile{name: keyFile}
+	}
+	if err := r.load(); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgTLSConfigFailed)
+	}
+
+	if r.caFile != nil {
+		// The standard verification is replaced with one against the latest CA bundle in verifyConnection
+		tlsConfig.InsecureSkipVerify = true // #nosec G402
+		tlsConfig.VerifyConnection = r.verifyConnection
+	}
+	if r.cert != nil {
+		tlsConfig.GetClientCertificate = r.getClientCertificate
+	}
+	return tlsConfig, nil
+}
+
+func (r *certReloader) changed(files ...*watchedFile) bool {
+	for _, f := range files {
+		fi, err := os.Stat(f.name)
+		if err != nil || !fi.ModTime().Equal(f.modTime) || fi.Size() != f.size {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) markLoaded(files ...*watchedFile) {
+	for _, f := range files {
+		if fi, err := os.Stat(f.name); err == nil {
+			f.modTime = fi.ModTime()
+			f.size = fi.Size()
+		}
+	}
+}
+
+func (r *certReloader) loadCAs() error {
+	caBytes, err := ioutil.ReadFile(r.caFile.name)
+	if err != nil {
+		return err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caBytes) {
+		return i18n.NewError(r.ctx, i18n.MsgInvalidCAFile)
+	}
+	r.rootCAs = rootCAs
+	r.markLoaded(r.caFile)
+	return nil
+}
+
+func (r *certReloader) loadKeyPair() error {
+	keyPair, err := tls.LoadX509KeyPair(r.cert.name, r.key.name)
+	if err != nil {
+		return err
+	}
+	r.keyPair = &keyPair
+	r.markLoaded(r.cert, r.key)
+	return nil
+}
+
+func (r *certReloader) load() error {
+	if r.caFile != nil {
+		if err := r.loadCAs(); err != nil {
+			return err
+		}
+	}
+	if r.cert != nil {
+		return r.loadKeyPair()
+	}
+	return nil
+}
+
+// reload is called before each handshake. A failure to load changed files is logged and the previously
+// loaded certificates are kept, as a cert and key that are renewed together are rarely written atomically.
+func (r *certReloader) reload() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.caFile != nil && r.changed(r.caFile) {
+		if err := r.loadCAs(); err != nil {
+			log.L(r.ctx).Warnf("Failed to reload CA file '%s': %s", r.caFile.name, err)
+		} else {
+			log.L(r.ctx).Infof("Reloaded CA file '%s'", r.caFile.name)
+		}
+	}
+	if r.cert != nil && r.changed(r.cert, r.key) {
+		if err := r.loadKeyPair(); err != nil {
+			log.L(r.ctx).Warnf("Failed to reload client certificate '%s': %s", r.cert.name, err)
+		} else {
+			log.L(r.ctx).Infof("Reloaded client certificate '%s'", r.cert.name)
+		}
+	}
+}
+
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.reload()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.keyPair, nil
+}
+
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	r.reload()
+	r.mux.Lock()
+	rootCAs := r.rootCAs
+	r.mux.Unlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         rootCAs,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
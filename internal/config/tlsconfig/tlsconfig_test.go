@@ -0,0 +1,295 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("tls_unit_tests")
+
+func resetConf() {
+	config.Reset()
+	InitPrefix(utConfPrefix)
+}
+
+var serial int64
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, cn string, issuer *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(atomic.AddInt64(&serial, 1)),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (tc *testCert) keyPair(t *testing.T) tls.Certificate {
+	kp, err := tls.X509KeyPair(tc.certPEM, tc.keyPEM)
+	assert.NoError(t, err)
+	return kp
+}
+
+// writeFile writes the file, and moves the modification time forwards so the change is
+// detected even on filesystems with a coarse timestamp resolution
+func writeFile(t *testing.T, name string, data []byte) {
+	err := ioutil.WriteFile(name, data, 0600)
+	assert.NoError(t, err)
+	next := time.Now().Add(time.Duration(atomic.AddInt64(&serial, 1)) * time.Second)
+	err = os.Chtimes(name, next, next)
+	assert.NoError(t, err)
+}
+
+type testServer struct {
+	server     *httptest.Server
+	serverCert atomic.Value
+	clientCN   atomic.Value
+}
+
+func newTestServer(t *testing.T, ca *testCert, serverCert *testCert) *testServer {
+	ts := &testServer{}
+	ts.serverCert.Store(serverCert.keyPair(t))
+	ts.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			ts.clientCN.Store(r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+		w.WriteHeader(204)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	ts.server.TLS = &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  clientCAs,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			kp := ts.serverCert.Load().(tls.Certificate)
+			return &kp, nil
+		},
+	}
+	ts.server.StartTLS()
+	return ts
+}
+
+func (ts *testServer) get(t *testing.T, tlsConfig *tls.Config) error {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   tlsConfig,
+			DisableKeepAlives: true,
+		},
+	}
+	// Connect by name, so SNI selects the certificate from GetCertificate rather than the httptest default
+	res, err := client.Get(strings.Replace(ts.server.URL, "127.0.0.1", "localhost", 1))
+	if err == nil {
+		res.Body.Close()
+	}
+	return err
+}
+
+func TestTLSDisabled(t *testing.T) {
+	resetConf()
+	tlsConfig, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestTLSSystemCAs(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigServerName, "firefly.example.com")
+	tlsConfig, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.GetClientCertificate)
+	assert.Equal(t, "firefly.example.com", tlsConfig.ServerName)
+}
+
+func TestTLSMissingCAFile(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCAFile, filepath.Join(t.TempDir(), "missing.pem"))
+	_, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestTLSBadCAFile(t *testing.T) {
+	resetConf()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, []byte("not a cert"))
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCAFile, caFile)
+	_, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105.*FF10106", err)
+}
+
+func TestTLSBadKeyPair(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCertFile, filepath.Join(t.TempDir(), "cert.pem"))
+	_, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestTLSMutualAuthWithRotation(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	ca1 := newTestCert(t, "ca1", nil, true)
+	ca2 := newTestCert(t, "ca2", nil, true)
+	client1 := newTestCert(t, "client1", ca1, false)
+	client2 := newTestCert(t, "client2", ca1, false)
+	ts := newTestServer(t, ca1, newTestCert(t, "localhost", ca1, false))
+	defer ts.server.Close()
+
+	writeFile(t, caFile, ca1.certPEM)
+	writeFile(t, certFile, client1.certPEM)
+	writeFile(t, keyFile, client1.keyPEM)
+
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCAFile, caFile)
+	utConfPrefix.Set(TLSConfigCertFile, certFile)
+	utConfPrefix.Set(TLSConfigKeyFile, keyFile)
+	tlsConfig, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	err = ts.get(t, tlsConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "client1", ts.clientCN.Load())
+
+	// Renew the client certificate - the cert is written before the key, so the first
+	// handshake after the cert changes keeps using the old pair
+	writeFile(t, certFile, client2.certPEM)
+	err = ts.get(t, tlsConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "client1", ts.clientCN.Load())
+	writeFile(t, keyFile, client2.keyPEM)
+	err = ts.get(t, tlsConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "client2", ts.clientCN.Load())
+
+	// Move the server to a certificate from a new CA, which is not yet trusted
+	ts.serverCert.Store(newTestCert(t, "localhost", ca2, false).keyPair(t))
+	err = ts.get(t, tlsConfig)
+	assert.Regexp(t, "unknown authority", err)
+
+	// A bad CA bundle is ignored
+	writeFile(t, caFile, []byte("not a cert"))
+	err = ts.get(t, tlsConfig)
+	assert.Regexp(t, "unknown authority", err)
+
+	// Trust the new CA
+	writeFile(t, caFile, ca2.certPEM)
+	err = ts.get(t, tlsConfig)
+	assert.NoError(t, err)
+}
+
+func TestTLSServerNameMismatch(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+
+	ca := newTestCert(t, "ca", nil, true)
+	ts := newTestServer(t, ca, newTestCert(t, "localhost", ca, false))
+	defer ts.server.Close()
+	writeFile(t, caFile, ca.certPEM)
+
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCAFile, caFile)
+	utConfPrefix.Set(TLSConfigServerName, "firefly.example.com")
+	tlsConfig, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	err = ts.get(t, tlsConfig)
+	assert.Regexp(t, "firefly.example.com", err)
+}
+
+func TestTLSIntermediateCA(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+
+	ca := newTestCert(t, "ca", nil, true)
+	intermediate := newTestCert(t, "intermediate", ca, true)
+	leaf := newTestCert(t, "localhost", intermediate, false)
+	leaf.certPEM = append(leaf.certPEM, intermediate.certPEM...)
+	ts := newTestServer(t, ca, leaf)
+	defer ts.server.Close()
+	writeFile(t, caFile, ca.certPEM)
+
+	resetConf()
+	utConfPrefix.Set(TLSConfigEnabled, true)
+	utConfPrefix.Set(TLSConfigCAFile, caFile)
+	tlsConfig, err := ConstructTLSConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	err = ts.get(t, tlsConfig)
+	assert.NoError(t, err)
+}
@@ -17,7 +17,10 @@
 package wsconfig
 
 import (
+	"context"
+
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/pkg/wsclient"
 )
@@ -25,6 +28,7 @@ import (
 const (
 	defaultIntialConnectAttempts = 5
 	defaultBufferSize            = "16Kb"
+	defaultReconnectJitter       = 0.2
 )
 
 const (
@@ -36,6 +40,8 @@ const (
 	WSConfigKeyReadBufferSize = "ws.readBufferSize"
 	// WSConfigKeyInitialConnectAttempts sets how many times the websocket should attempt to connect on startup, before failing (after initial connection, retry is indefinite)
 	WSConfigKeyInitialConnectAttempts = "ws.initialConnectAttempts"
+	// WSConfigKeyReconnectJitter is the fraction (0-1) by which each reconnect delay is randomly reduced, to avoid many clients reconnecting in lockstep
+	WSConfigKeyReconnectJitter = "ws.reconnectJitter"
 	// WSConfigKeyPath if set will define the path to connect to - allows sharing of the same URL between HTTP and WebSocket connection info
 	WSConfigKeyPath = "ws.path"
 )
@@ -47,20 +53,31 @@ func InitPrefix(prefix config.KeySet) {
 	prefix.AddKnownKey(WSConfigKeyWriteBufferSize, defaultBufferSize)
 	prefix.AddKnownKey(WSConfigKeyReadBufferSize, defaultBufferSize)
 	prefix.AddKnownKey(WSConfigKeyInitialConnectAttempts, defaultIntialConnectAttempts)
+	prefix.AddKnownKey(WSConfigKeyReconnectJitter, defaultReconnectJitter)
 	prefix.AddKnownKey(WSConfigKeyPath)
 }
 
-func GenerateConfigFromPrefix(prefix config.Prefix) *wsclient.WSConfig {
-	return &wsclient.WSConfig{
+func GenerateConfigFromPrefix(ctx context.Context, prefix config.Prefix) (*wsclient.WSConfig, error) {
+	tlsConfig, err := tlsconfig.ConstructTLSConfig(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	wsConfig := &wsclient.WSConfig{
 		HTTPURL:                prefix.GetString(restclient.HTTPConfigURL),
 		WSKeyPath:              prefix.GetString(WSConfigKeyPath),
 		ReadBufferSize:         int(prefix.GetByteSize(WSConfigKeyReadBufferSize)),
 		WriteBufferSize:        int(prefix.GetByteSize(WSConfigKeyWriteBufferSize)),
 		InitialDelay:           prefix.GetDuration(restclient.HTTPConfigRetryInitDelay),
 		MaximumDelay:           prefix.GetDuration(restclient.HTTPConfigRetryMaxDelay),
+		ReconnectJitter:        prefix.GetFloat64(WSConfigKeyReconnectJitter),
 		InitialConnectAttempts: prefix.GetInt(WSConfigKeyInitialConnectAttempts),
 		HTTPHeaders:            prefix.GetObject(restclient.HTTPConfigHeaders),
 		AuthUsername:           prefix.GetString(restclient.HTTPConfigAuthUsername),
 		AuthPassword:           prefix.GetString(restclient.HTTPConfigAuthPassword),
+		TLSClientConfig:        tlsConfig,
+	}
+	if tokenSource := restclient.NewOAuth2TokenSource(prefix, tlsConfig); tokenSource != nil {
+		wsConfig.AuthProvider = tokenSource.AuthorizationHeader
 	}
+	return wsConfig, nil
 }
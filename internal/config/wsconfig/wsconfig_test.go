@@ -1,10 +1,12 @@
 package wsconfig
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/stretchr/testify/assert"
 )
@@ -31,8 +33,10 @@ func TestWSConfigGeneration(t *testing.T) {
 	utConfPrefix.Set(WSConfigKeyWriteBufferSize, 1024)
 	utConfPrefix.Set(WSConfigKeyInitialConnectAttempts, 1)
 	utConfPrefix.Set(WSConfigKeyPath, "/websocket")
+	utConfPrefix.Set(WSConfigKeyReconnectJitter, 0.5)
 
-	wsConfig := GenerateConfigFromPrefix(utConfPrefix)
+	wsConfig, err := GenerateConfigFromPrefix(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
 
 	assert.Equal(t, "http://test:12345", wsConfig.HTTPURL)
 	assert.Equal(t, "user", wsConfig.AuthUsername)
@@ -40,8 +44,31 @@ func TestWSConfigGeneration(t *testing.T) {
 	assert.Equal(t, time.Duration(1000000), wsConfig.InitialDelay)
 	assert.Equal(t, time.Duration(1000000), wsConfig.MaximumDelay)
 	assert.Equal(t, 1, wsConfig.InitialConnectAttempts)
+	assert.Equal(t, 0.5, wsConfig.ReconnectJitter)
 	assert.Equal(t, "/websocket", wsConfig.WSKeyPath)
 	assert.Equal(t, "custom value", wsConfig.HTTPHeaders.GetString("custom-header"))
 	assert.Equal(t, 1024, wsConfig.ReadBufferSize)
 	assert.Equal(t, 1024, wsConfig.WriteBufferSize)
+	assert.Nil(t, wsConfig.AuthProvider)
+}
+
+func TestWSConfigGenerationOAuth2(t *testing.T) {
+	resetConf()
+
+	utConfPrefix.Set(restclient.HTTPConfigURL, "http://test:12345")
+	utConfPrefix.Set(restclient.HTTPConfigAuthOAuth2TokenURL, "http://auth:12345/token")
+
+	wsConfig, err := GenerateConfigFromPrefix(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.NotNil(t, wsConfig.AuthProvider)
+}
+
+func TestWSConfigGenerationBadTLS(t *testing.T) {
+	resetConf()
+
+	utConfPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	_, err := GenerateConfigFromPrefix(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105", err)
 }
@@ -0,0 +1,320 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contracts
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
+	"github.com/hyperledger/firefly/internal/broadcast"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/identity"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/syncasync"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+type Manager interface {
+	BroadcastFFI(ctx context.Context, ns string, ffi *fftypes.FFI, waitConfirm bool) (*fftypes.FFI, error)
+	GetFFI(ctx context.Context, ns, name, version string) (*fftypes.FFI, error)
+	GetFFIByID(ctx context.Context, ns, id string) (*fftypes.FFI, error)
+	GetFFIs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.FFI, *database.FilterResult, error)
+
+	// InvokeContract calls a method on a contract. An invocation returns the operation tracking the blockchain
+	// transaction (completed, if waitConfirm is set), and a query returns the result from the blockchain
+	InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (interface{}, error)
+
+	AddContractListener(ctx context.Context, ns string, req *fftypes.ContractListenerInput) (*fftypes.ContractListener, error)
+	GetContractListenerByID(ctx context.Context, ns, id string) (*fftypes.ContractListener, error)
+	GetContractListeners(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.ContractListener, *database.FilterResult, error)
+}
+
+type contractManager struct {
+	database       database.Plugin
+	broadcast      broadcast.Manager
+	identity       identity.Manager
+	blockchain     blockchain.Plugin
+	syncasync      syncasync.Bridge
+	preflight      txcommon.PreflightChecker
+	metricsEnabled bool
+}
+
+func NewContractManager(ctx context.Context, di database.Plugin, bm broadcast.Manager, im identity.Manager, bi blockchain.Plugin, sa syncasync.Bridge, pf txcommon.PreflightChecker) (Manager, error) {
+	if di == nil || bm == nil || im == nil || bi == nil || sa == nil || pf == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	cm := &contractManager{
+		database:       di,
+		broadcast:      bm,
+		identity:       im,
+		blockchain:     bi,
+		syncasync:      sa,
+		preflight:      pf,
+		metricsEnabled: config.GetBool(config.MetricsEnabled),
+	}
+	if cm.metricsEnabled {
+		metrics.Registry()
+	}
+	return cm, nil
+}
+
+func (cm *contractManager) scopeNS(ns string, filter database.AndFilter) database.AndFilter {
+	return filter.Condition(filter.Builder().Eq("namespace", ns))
+}
+
+func (cm *contractManager) BroadcastFFI(ctx context.Context, ns string, ffi *fftypes.FFI, waitConfirm bool) (*fftypes.FFI, error) {
+	ffi.ID = fftypes.NewUUID()
+	ffi.Namespace = ns
+	if err := ffi.Validate(ctx, false); err != nil {
+		return nil, err
+	}
+	msg, err := cm.broadcast.BroadcastDefinitionAsNode(ctx, ns, ffi, fftypes.SystemTagDefineFFI, waitConfirm)
+	if err != nil {
+		return nil, err
+	}
+	ffi.Message = msg.Header.ID
+	return ffi, nil
+}
+
+func (cm *contractManager) GetFFI(ctx context.Context, ns, name, version string) (*fftypes.FFI, error) {
+	ffi, err := cm.database.GetFFI(ctx, ns, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if ffi == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return ffi, nil
+}
+
+func (cm *contractManager) GetFFIByID(ctx context.Context, ns, id string) (*fftypes.FFI, error) {
+	ffiID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ffi, err := cm.database.GetFFIByID(ctx, ffiID)
+	if err != nil {
+		return nil, err
+	}
+	if ffi == nil || ffi.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return ffi, nil
+}
+
+func (cm *contractManager) GetFFIs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.FFI, *database.FilterResult, error) {
+	return cm.database.GetFFIs(ctx, cm.scopeNS(ns, filter))
+}
+
+// resolveMethod looks up the method from the broadcast interface if it was not supplied inline,
+// and checks every parameter of the method has been supplied in the input
+func (cm *contractManager) resolveMethod(ctx context.Context, ns string, req *fftypes.ContractCallRequest) error {
+	if req.Method == nil {
+		if req.Interface == nil || req.MethodPath == "" {
+			return i18n.NewError(ctx, i18n.MsgContractMethodNotSet)
+		}
+		ffi, err := cm.database.GetFFIByID(ctx, req.Interface)
+		if err != nil {
+			return err
+		}
+		if ffi == nil || ffi.Namespace != ns {
+			return i18n.NewError(ctx, i18n.MsgFFINotFound, req.Interface)
+		}
+		if req.Method = ffi.GetMethod(req.MethodPath); req.Method == nil {
+			return i18n.NewError(ctx, i18n.MsgContractMethodNotFound, req.MethodPath, req.Interface)
+		}
+	}
+	if err := req.Method.Validate(ctx); err != nil {
+		return err
+	}
+	for _, param := range req.Method.Params {
+		if _, ok := req.Input[param.Name]; !ok {
+			return i18n.NewError(ctx, i18n.MsgContractMissingInputArg, param.Name)
+		}
+	}
+	return nil
+}
+
+func (cm *contractManager) InvokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (res interface{}, err error) {
+	if req.Type == "" {
+		req.Type = fftypes.ContractCallTypeInvoke
+	}
+	if req.Type != fftypes.ContractCallTypeInvoke && req.Type != fftypes.ContractCallTypeQuery {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownFieldValue, "type", req.Type)
+	}
+	if err = cm.resolveMethod(ctx, ns, req); err != nil {
+		return nil, err
+	}
+	bi := bimux.ForNamespace(cm.blockchain, ns)
+	if req.Location, err = bi.NormalizeContractLocation(ctx, ns, req.Location); err != nil {
+		return nil, err
+	}
+
+	if req.Type == fftypes.ContractCallTypeQuery {
+		return bi.QueryContract(ctx, ns, req.Location, req.Method, req.Input)
+	}
+	return cm.invokeContract(ctx, ns, req, waitConfirm)
+}
+
+func (cm *contractManager) invokeContract(ctx context.Context, ns string, req *fftypes.ContractCallRequest, waitConfirm bool) (*fftypes.Operation, error) {
+	if req.Key == "" {
+		req.Key = cm.identity.GetOrgKey(ctx)
+	}
+	key, err := cm.identity.ResolveSigningKey(ctx, ns, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	req.Key = key
+
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Namespace: ns,
+			Type:      fftypes.TransactionTypeContractInvoke,
+			Signer:    req.Key,
+			Reference: req.Interface,
+		},
+		Created: fftypes.Now(),
+		Status:  fftypes.OpStatusPending,
+	}
+	tx.Hash = tx.Subject.Hash()
+
+	if err := cm.preflight.CheckBalance(ctx, ns, req.Key, tx.ID); err != nil {
+		return nil, err
+	}
+	if err := cm.preflight.CheckPolicy(ctx, &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeContractInvoke,
+		Namespace:  ns,
+		SigningKey: req.Key,
+		Reference:  tx.ID,
+		Input: fftypes.JSONObject{
+			"location": req.Location,
+			"method":   req.Method.Name,
+			"input":    req.Input,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	bi := bimux.ForNamespace(cm.blockchain, ns)
+	op := fftypes.NewTXOperation(
+		bi,
+		ns,
+		tx.ID,
+		"",
+		fftypes.OpTypeBlockchainInvoke,
+		fftypes.OpStatusPending)
+	txcommon.AddBlockchainInvokeInputs(op, req)
+
+	send := func(ctx context.Context) error {
+		err := cm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+			err = cm.database.UpsertTransaction(ctx, tx, false /* should be new, or idempotent replay */)
+			if err == nil {
+				err = cm.database.InsertOperation(ctx, op)
+			}
+			if err == nil {
+				err = cm.database.InsertSigningActivity(ctx, fftypes.NewSigningActivity(op, req.Key))
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		err = bi.InvokeContract(ctx, ns, op.ID, req.Key, req.Location, req.Method, req.Input)
+		if err == nil && cm.metricsEnabled {
+			metrics.BlockchainTransactionSubmittedCounter.WithLabelValues(ns, string(op.Type)).Inc()
+		}
+		return err
+	}
+
+	if waitConfirm {
+		return cm.syncasync.WaitForInvokeOperation(ctx, ns, op.ID, send)
+	}
+	return op, send(ctx)
+}
+
+// resolveEvent looks up the event from the broadcast interface if it was not supplied inline
+func (cm *contractManager) resolveEvent(ctx context.Context, ns string, req *fftypes.ContractListenerInput) error {
+	if req.Event == nil {
+		if req.Interface == nil || req.EventPath == "" {
+			return i18n.NewError(ctx, i18n.MsgContractEventNotSet)
+		}
+		ffi, err := cm.database.GetFFIByID(ctx, req.Interface)
+		if err != nil {
+			return err
+		}
+		if ffi == nil || ffi.Namespace != ns {
+			return i18n.NewError(ctx, i18n.MsgFFINotFound, req.Interface)
+		}
+		if req.Event = ffi.GetEvent(req.EventPath); req.Event == nil {
+			return i18n.NewError(ctx, i18n.MsgContractEventNotFound, req.EventPath, req.Interface)
+		}
+	}
+	if req.Event.Name == "" {
+		return i18n.NewFieldError(ctx, "name", i18n.MsgMissingRequiredField, "event.name")
+	}
+	return req.Event.Params.Validate(ctx)
+}
+
+func (cm *contractManager) AddContractListener(ctx context.Context, ns string, req *fftypes.ContractListenerInput) (listener *fftypes.ContractListener, err error) {
+	listener = &req.ContractListener
+	listener.ID = fftypes.NewUUID()
+	listener.Namespace = ns
+	if listener.Name != "" {
+		if err = fftypes.ValidateFFNameField(ctx, listener.Name, "name"); err != nil {
+			return nil, err
+		}
+	}
+	if err = cm.resolveEvent(ctx, ns, req); err != nil {
+		return nil, err
+	}
+	bi := bimux.ForNamespace(cm.blockchain, ns)
+	if listener.Location, err = bi.NormalizeContractLocation(ctx, ns, listener.Location); err != nil {
+		return nil, err
+	}
+	if err = bi.AddContractListener(ctx, listener); err != nil {
+		return nil, err
+	}
+	listener.Created = fftypes.Now()
+	if err = cm.database.InsertContractListener(ctx, listener); err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
+
+func (cm *contractManager) GetContractListenerByID(ctx context.Context, ns, id string) (*fftypes.ContractListener, error) {
+	listenerID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := cm.database.GetContractListenerByID(ctx, listenerID)
+	if err != nil {
+		return nil, err
+	}
+	if listener == nil || listener.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return listener, nil
+}
+
+func (cm *contractManager) GetContractListeners(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.ContractListener, *database.FilterResult, error) {
+	return cm.database.GetContractListeners(ctx, cm.scopeNS(ns, filter))
+}
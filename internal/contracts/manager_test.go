@@ -0,0 +1,776 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/syncasync"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestContractManager() *contractManager {
+	mdi := &databasemocks.Plugin{}
+	mbm := &broadcastmocks.Manager{}
+	mim := &identitymanagermocks.Manager{}
+	mbi := &blockchainmocks.Plugin{}
+	msa := &syncasyncmocks.Bridge{}
+	mpf := &txcommonmocks.PreflightChecker{}
+	mbi.On("Name").Return("mockblockchain").Maybe()
+	mpf.On("CheckBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mpf.On("CheckPolicy", mock.Anything, mock.Anything).Return(nil).Maybe()
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	cm, _ := NewContractManager(context.Background(), mdi, mbm, mim, mbi, msa, mpf)
+	return cm.(*contractManager)
+}
+
+func newTestMethod() *fftypes.FFIMethod {
+	return &fftypes.FFIMethod{
+		Name: "set",
+		Params: fftypes.FFIParams{
+			{Name: "x", Type: fftypes.FFIParamTypeInteger},
+		},
+	}
+}
+
+func TestNewContractManagerFail(t *testing.T) {
+	_, err := NewContractManager(context.Background(), nil, nil, nil, nil, nil, nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestBroadcastFFI(t *testing.T) {
+	cm := newTestContractManager()
+	mbm := cm.broadcast.(*broadcastmocks.Manager)
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.FFI"), fftypes.SystemTagDefineFFI, true).Return(msg, nil)
+
+	ffi, err := cm.BroadcastFFI(context.Background(), "ns1", &fftypes.FFI{
+		Name:    "math",
+		Version: "v1",
+		Methods: fftypes.FFIMethods{newTestMethod()},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", ffi.Namespace)
+	assert.NotNil(t, ffi.ID)
+	assert.Equal(t, msg.Header.ID, ffi.Message)
+
+	mbm.AssertExpectations(t)
+}
+
+func TestBroadcastFFIInvalid(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.BroadcastFFI(context.Background(), "ns1", &fftypes.FFI{Name: "!wrong"}, false)
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestBroadcastFFIFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbm := cm.broadcast.(*broadcastmocks.Manager)
+
+	mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.FFI"), fftypes.SystemTagDefineFFI, false).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.BroadcastFFI(context.Background(), "ns1", &fftypes.FFI{Name: "math", Version: "v1"}, false)
+	assert.EqualError(t, err, "pop")
+
+	mbm.AssertExpectations(t)
+}
+
+func TestGetFFI(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(&fftypes.FFI{Name: "math"}, nil)
+
+	ffi, err := cm.GetFFI(context.Background(), "ns1", "math", "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "math", ffi.Name)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetFFINotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, nil)
+
+	_, err := cm.GetFFI(context.Background(), "ns1", "math", "v1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetFFIFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.GetFFI(context.Background(), "ns1", "math", "v1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetFFIByID(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, id).Return(&fftypes.FFI{ID: id, Namespace: "ns1"}, nil)
+
+	ffi, err := cm.GetFFIByID(context.Background(), "ns1", id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, ffi.ID)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetFFIByIDBadID(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.GetFFIByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetFFIByIDWrongNamespace(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, id).Return(&fftypes.FFI{ID: id, Namespace: "ns2"}, nil)
+
+	_, err := cm.GetFFIByID(context.Background(), "ns1", id.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetFFIByIDFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, id).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.GetFFIByID(context.Background(), "ns1", id.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetFFIs(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetFFIs", mock.Anything, mock.Anything).Return([]*fftypes.FFI{}, nil, nil)
+
+	fb := database.FFIQueryFactory.NewFilter(context.Background())
+	_, _, err := cm.GetFFIs(context.Background(), "ns1", fb.And(fb.Eq("name", "math")))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestInvokeContract(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractCallRequest{
+		Location: location,
+		Method:   newTestMethod(),
+		Input:    fftypes.JSONObject{"x": 42},
+	}
+
+	mim.On("GetOrgKey", mock.Anything).Return("org-key")
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "org-key").Return("0xabcd", nil)
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeContractInvoke && tx.Subject.Signer == "0xabcd"
+	}), false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeBlockchainInvoke && op.Plugin == "mockblockchain"
+	})).Return(nil)
+	mdi.On("InsertSigningActivity", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), "0xabcd", location, req.Method, req.Input).Return(nil)
+
+	res, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpTypeBlockchainInvoke, res.(*fftypes.Operation).Type)
+	assert.Equal(t, fftypes.ContractCallTypeInvoke, req.Type)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestInvokeContractWithMetrics(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, true)
+	defer config.Reset()
+	defer metrics.Clear()
+
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractCallRequest{
+		Key:      "0xabcd",
+		Location: location,
+		Method:   newTestMethod(),
+		Input:    fftypes.JSONObject{"x": 42},
+	}
+
+	cm.identity.(*identitymanagermocks.Manager).On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), "0xabcd", location, req.Method, req.Input).Return(nil)
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.BlockchainTransactionSubmittedCounter.WithLabelValues("ns1", string(fftypes.OpTypeBlockchainInvoke))))
+}
+
+func TestInvokeContractWaitConfirm(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	msa := cm.syncasync.(*syncasyncmocks.Bridge)
+
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractCallRequest{
+		Type:     fftypes.ContractCallTypeInvoke,
+		Key:      "0xabcd",
+		Location: location,
+		Method:   newTestMethod(),
+		Input:    fftypes.JSONObject{"x": 42},
+	}
+
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), "0xabcd", location, req.Method, req.Input).Return(nil)
+	msa.On("WaitForInvokeOperation", mock.Anything, "ns1", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			send := args[3].(syncasync.RequestSender)
+			send(context.Background())
+		}).
+		Return(&fftypes.Operation{Status: fftypes.OpStatusSucceeded}, nil)
+
+	res, err := cm.InvokeContract(context.Background(), "ns1", req, true)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusSucceeded, res.(*fftypes.Operation).Status)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+	msa.AssertExpectations(t)
+}
+
+func TestInvokeContractFromInterface(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractCallRequest{
+		Key:        "0xabcd",
+		Interface:  ffiID,
+		MethodPath: "set",
+		Location:   location,
+		Input:      fftypes.JSONObject{"x": 42},
+	}
+
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(&fftypes.FFI{
+		ID:        ffiID,
+		Namespace: "ns1",
+		Methods:   fftypes.FFIMethods{newTestMethod()},
+	}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Reference.Equals(ffiID)
+	}), false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.UUID"), "0xabcd", location, mock.MatchedBy(func(method *fftypes.FFIMethod) bool {
+		return method.Name == "set"
+	}), req.Input).Return(nil)
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestInvokeContractBadType(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Type: "wrong"}, false)
+	assert.Regexp(t, "FF10132.*type", err)
+}
+
+func TestInvokeContractMethodNotSet(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{}, false)
+	assert.Regexp(t, "FF10365", err)
+}
+
+func TestInvokeContractInterfaceLookupFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Interface: ffiID, MethodPath: "set"}, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInvokeContractInterfaceNotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(&fftypes.FFI{ID: ffiID, Namespace: "ns2"}, nil)
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Interface: ffiID, MethodPath: "set"}, false)
+	assert.Regexp(t, "FF10363", err)
+}
+
+func TestInvokeContractMethodNotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(&fftypes.FFI{ID: ffiID, Namespace: "ns1"}, nil)
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Interface: ffiID, MethodPath: "set"}, false)
+	assert.Regexp(t, "FF10364", err)
+}
+
+func TestInvokeContractMethodInvalid(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Method: &fftypes.FFIMethod{}}, false)
+	assert.Regexp(t, "FF10140", err)
+}
+
+func TestInvokeContractMissingInput(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{Method: newTestMethod()}, false)
+	assert.Regexp(t, "FF10367.*x", err)
+}
+
+func TestInvokeContractBadLocation(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInvokeContractResolveKeyFail(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("", fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Key:    "0xabcd",
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInvokeContractBalanceFail(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mpf := &txcommonmocks.PreflightChecker{}
+	cm.preflight = mpf
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mpf.On("CheckBalance", mock.Anything, "ns1", "0xabcd", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Key:    "0xabcd",
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mpf.AssertExpectations(t)
+}
+
+func TestInvokeContractPolicyFail(t *testing.T) {
+	cm := newTestContractManager()
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+	mpf := &txcommonmocks.PreflightChecker{}
+	cm.preflight = mpf
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mpf.On("CheckBalance", mock.Anything, "ns1", "0xabcd", mock.Anything).Return(nil)
+	mpf.On("CheckPolicy", mock.Anything, mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeContractInvoke && req.Input["method"] == "set"
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Key:    "0xabcd",
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mpf.AssertExpectations(t)
+}
+
+func TestInvokeContractInsertFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Key:    "0xabcd",
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestInvokeContractSubmitFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mim := cm.identity.(*identitymanagermocks.Manager)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mim.On("ResolveSigningKey", mock.Anything, "ns1", "0xabcd").Return("0xabcd", nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertSigningActivity", mock.Anything, mock.Anything).Return(nil)
+	mbi.On("InvokeContract", mock.Anything, "ns1", mock.Anything, "0xabcd", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.InvokeContract(context.Background(), "ns1", &fftypes.ContractCallRequest{
+		Key:    "0xabcd",
+		Method: newTestMethod(),
+		Input:  fftypes.JSONObject{"x": 42},
+	}, false)
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+}
+
+func TestQueryContract(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractCallRequest{
+		Type:     fftypes.ContractCallTypeQuery,
+		Location: location,
+		Method:   newTestMethod(),
+		Input:    fftypes.JSONObject{"x": 42},
+	}
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mbi.On("QueryContract", mock.Anything, "ns1", location, req.Method, req.Input).Return(fftypes.JSONObject{"output": "42"}, nil)
+
+	res, err := cm.InvokeContract(context.Background(), "ns1", req, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", res.(fftypes.JSONObject)["output"])
+
+	mbi.AssertExpectations(t)
+}
+
+func newTestEvent() *fftypes.FFIEvent {
+	return &fftypes.FFIEvent{
+		Name: "Changed",
+		Params: fftypes.FFIParams{
+			{Name: "x", Type: fftypes.FFIParamTypeInteger},
+		},
+	}
+}
+
+func TestAddContractListenerInline(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	location := fftypes.JSONObject{"address": "0x12345"}
+	req := &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{
+			Name:     "changes",
+			Location: location,
+			Event:    newTestEvent(),
+		},
+	}
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", location).Return(location, nil)
+	mbi.On("AddContractListener", mock.Anything, &req.ContractListener).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.ContractListener).ProtocolID = "sb-12345"
+	}).Return(nil)
+	mdi.On("InsertContractListener", mock.Anything, &req.ContractListener).Return(nil)
+
+	listener, err := cm.AddContractListener(context.Background(), "ns1", req)
+	assert.NoError(t, err)
+	assert.NotNil(t, listener.ID)
+	assert.NotNil(t, listener.Created)
+	assert.Equal(t, "ns1", listener.Namespace)
+	assert.Equal(t, "sb-12345", listener.ProtocolID)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestAddContractListenerFromInterface(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	req := &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{
+			Interface: ffiID,
+		},
+		EventPath: "Changed",
+	}
+
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(&fftypes.FFI{
+		ID:        ffiID,
+		Namespace: "ns1",
+		Events:    fftypes.FFIEvents{newTestEvent()},
+	}, nil)
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mbi.On("AddContractListener", mock.Anything, mock.MatchedBy(func(listener *fftypes.ContractListener) bool {
+		return listener.Event.Name == "Changed"
+	})).Return(nil)
+	mdi.On("InsertContractListener", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", req)
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestAddContractListenerBadName(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Name: "!wrong"},
+	})
+	assert.Regexp(t, "FF10131.*name", err)
+}
+
+func TestAddContractListenerEventNotSet(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{})
+	assert.Regexp(t, "FF10372", err)
+}
+
+func TestAddContractListenerInterfaceLookupFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Interface: ffiID},
+		EventPath:        "Changed",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAddContractListenerInterfaceNotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(nil, nil)
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Interface: ffiID},
+		EventPath:        "Changed",
+	})
+	assert.Regexp(t, "FF10363", err)
+}
+
+func TestAddContractListenerEventNotFound(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	ffiID := fftypes.NewUUID()
+	mdi.On("GetFFIByID", mock.Anything, ffiID).Return(&fftypes.FFI{ID: ffiID, Namespace: "ns1"}, nil)
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Interface: ffiID},
+		EventPath:        "Changed",
+	})
+	assert.Regexp(t, "FF10371", err)
+}
+
+func TestAddContractListenerEventNameMissing(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Event: &fftypes.FFIEvent{}},
+	})
+	assert.Regexp(t, "FF10140.*event.name", err)
+}
+
+func TestAddContractListenerEventParamInvalid(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Event: &fftypes.FFIEvent{
+			Name:   "Changed",
+			Params: fftypes.FFIParams{{Name: "x", Type: "wrong"}},
+		}},
+	})
+	assert.Regexp(t, "FF10368", err)
+}
+
+func TestAddContractListenerBadLocation(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Event: newTestEvent()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAddContractListenerSubscribeFail(t *testing.T) {
+	cm := newTestContractManager()
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mbi.On("AddContractListener", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Event: newTestEvent()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAddContractListenerInsertFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+	mbi := cm.blockchain.(*blockchainmocks.Plugin)
+
+	mbi.On("NormalizeContractLocation", mock.Anything, "ns1", mock.Anything).Return(fftypes.JSONObject{}, nil)
+	mbi.On("AddContractListener", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertContractListener", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := cm.AddContractListener(context.Background(), "ns1", &fftypes.ContractListenerInput{
+		ContractListener: fftypes.ContractListener{Event: newTestEvent()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetContractListenerByID(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetContractListenerByID", mock.Anything, id).Return(&fftypes.ContractListener{ID: id, Namespace: "ns1"}, nil)
+
+	listener, err := cm.GetContractListenerByID(context.Background(), "ns1", id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, listener.ID)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetContractListenerByIDBadID(t *testing.T) {
+	cm := newTestContractManager()
+	_, err := cm.GetContractListenerByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetContractListenerByIDWrongNamespace(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetContractListenerByID", mock.Anything, id).Return(&fftypes.ContractListener{ID: id, Namespace: "ns2"}, nil)
+
+	_, err := cm.GetContractListenerByID(context.Background(), "ns1", id.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetContractListenerByIDFail(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	id := fftypes.NewUUID()
+	mdi.On("GetContractListenerByID", mock.Anything, id).Return(nil, fmt.Errorf("pop"))
+
+	_, err := cm.GetContractListenerByID(context.Background(), "ns1", id.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetContractListeners(t *testing.T) {
+	cm := newTestContractManager()
+	mdi := cm.database.(*databasemocks.Plugin)
+
+	mdi.On("GetContractListeners", mock.Anything, mock.Anything).Return([]*fftypes.ContractListener{}, nil, nil)
+
+	fb := database.ContractListenerQueryFactory.NewFilter(context.Background())
+	_, _, err := cm.GetContractListeners(context.Background(), "ns1", fb.And(fb.Eq("name", "changes")))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
@@ -21,6 +21,9 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
 
 	"github.com/docker/go-units"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -36,9 +39,36 @@ type blobStore struct {
 	publicstorage publicstorage.Plugin
 	database      database.Plugin
 	exchange      dataexchange.Plugin
+	maxBlobSize   int64
+}
+
+// sizeLimitReader fails the stream as soon as more than the max bytes have been read,
+// so an oversized blob is rejected without first being written in full to data exchange
+type sizeLimitReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	max      int64
+	read     int64
+	exceeded bool
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		l.exceeded = true
+		return n, i18n.NewError(l.ctx, i18n.MsgBlobTooLarge, l.max)
+	}
+	return n, err
 }
 
 func (bs *blobStore) uploadVerifyBLOB(ctx context.Context, ns string, id *fftypes.UUID, expectedHash *fftypes.Bytes32, reader io.Reader) (hash *fftypes.Bytes32, written int64, payloadRef string, err error) {
+	var limiter *sizeLimitReader
+	if bs.maxBlobSize > 0 {
+		limiter = &sizeLimitReader{ctx: ctx, reader: reader, max: bs.maxBlobSize}
+		reader = limiter
+	}
+
 	hashCalc := sha256.New()
 	dxReader, dx := io.Pipe()
 	storeAndHash := io.MultiWriter(hashCalc, dx)
@@ -48,13 +78,16 @@ func (bs *blobStore) uploadVerifyBLOB(ctx context.Context, ns string, id *fftype
 		var err error
 		written, err = io.Copy(storeAndHash, reader)
 		log.L(ctx).Debugf("Upload BLOB streamed %d bytes (err=%v)", written, err)
-		_ = dx.Close()
+		_ = dx.CloseWithError(err)
 		copyDone <- err
 	}()
 
 	payloadRef, uploadHash, dxErr := bs.exchange.UploadBLOB(ctx, ns, *id, dxReader)
 	dxReader.Close()
 	copyErr := <-copyDone
+	if limiter != nil && limiter.exceeded {
+		return nil, -1, "", copyErr
+	}
 	if dxErr != nil {
 		return nil, -1, "", dxErr
 	}
@@ -98,6 +131,7 @@ func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, inData *fftypes.
 	}
 	data.Blob = &fftypes.BlobRef{
 		Hash: hash,
+		Size: written,
 	}
 
 	// autoMeta will create/update JSON metadata with the upload details
@@ -108,39 +142,82 @@ func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, inData *fftypes.
 		do["size"] = float64(written)
 		data.Value, _ = json.Marshal(&do)
 	}
+	if err := bs.storeUploadedBLOB(ctx, data, payloadRef); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// storeUploadedBLOB validates and seals a data item, for which the blob has already been uploaded to data exchange,
+// then stores the data and blob together
+func (bs *blobStore) storeUploadedBLOB(ctx context.Context, data *fftypes.Data, payloadRef string) error {
 	if data.Validator == "" {
 		data.Validator = fftypes.ValidatorTypeJSON
 	}
 
-	err = bs.dm.checkValidation(ctx, ns, data.Validator, data.Datatype, data.Value)
+	err := bs.dm.checkValidation(ctx, data.Namespace, data.Validator, data.Datatype, data.Value)
 	if err == nil {
 		err = data.Seal(ctx)
 	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	log.L(ctx).Infof("Uploaded BLOB %.2fkb blobhash=%s hash=%s", float64(written)/1024, data.Blob.Hash, data.Hash)
+	log.L(ctx).Infof("Uploaded BLOB %.2fkb blobhash=%s hash=%s", float64(data.Blob.Size)/1024, data.Blob.Hash, data.Hash)
 
-	err = bs.database.RunAsGroup(ctx, func(ctx context.Context) error {
+	return bs.database.RunAsRetryableGroup(ctx, func(ctx context.Context) error {
 		err := bs.database.UpsertData(ctx, data, database.UpsertOptimizationNew)
 		if err == nil {
-			err = bs.database.InsertBlob(ctx, &fftypes.Blob{
-				Hash:       hash,
+			_, err = bs.StoreBlob(ctx, &fftypes.Blob{
+				Hash:       data.Blob.Hash,
 				PayloadRef: payloadRef,
 				Created:    fftypes.Now(),
 			})
 		}
 		return err
 	})
+}
+
+func (bs *blobStore) addBlobRef(ctx context.Context, existing *fftypes.Blob) (*fftypes.Blob, error) {
+	if err := bs.database.UpdateBlobRefs(ctx, existing.Sequence, 1); err != nil {
+		return nil, err
+	}
+	existing.Refs++
+	log.L(ctx).Infof("Blob '%s' already stored with ref '%s' - references=%d", existing.Hash, existing.PayloadRef, existing.Refs)
+	return existing, nil
+}
+
+// StoreBlob records a blob against its hash. If a blob with the same hash is already stored, a reference is
+// added to the existing blob and that is returned - so the data records attaching the same content share one blob.
+func (bs *blobStore) StoreBlob(ctx context.Context, blob *fftypes.Blob) (*fftypes.Blob, error) {
+	existing, err := bs.database.GetBlobMatchingHash(ctx, blob.Hash)
 	if err != nil {
 		return nil, err
 	}
+	if existing != nil {
+		return bs.addBlobRef(ctx, existing)
+	}
+	blob.Refs = 1
+	if err = bs.database.InsertBlob(ctx, blob); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
 
-	return data, nil
+func (bs *blobStore) GetBlobStats(ctx context.Context) (*fftypes.BlobStats, error) {
+	return bs.database.GetBlobStats(ctx)
 }
 
 func (bs *blobStore) CopyBlobPStoDX(ctx context.Context, data *fftypes.Data) (blob *fftypes.Blob, err error) {
 
+	// If we already have a blob with the same hash (attached to other data), there is no need to download it again
+	existing, err := bs.database.GetBlobMatchingHash(ctx, data.Blob.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return bs.addBlobRef(ctx, existing)
+	}
+
 	reader, err := bs.publicstorage.RetrieveData(ctx, data.Blob.Public)
 	if err != nil {
 		return nil, err
@@ -157,46 +234,128 @@ func (bs *blobStore) CopyBlobPStoDX(ctx context.Context, data *fftypes.Data) (bl
 	}
 	log.L(ctx).Infof("Transferred blob '%s' (%s) from public storage '%s' to local data exchange '%s'", hash, units.HumanSizeWithPrecision(float64(written), 2), data.Blob.Public, payloadRef)
 
-	blob = &fftypes.Blob{
+	return bs.StoreBlob(ctx, &fftypes.Blob{
 		Hash:       hash,
 		PayloadRef: payloadRef,
 		Created:    fftypes.Now(),
-	}
-	err = bs.database.InsertBlob(ctx, blob)
-	if err != nil {
-		return nil, err
-	}
-	return blob, nil
+	})
 }
 
-func (bs *blobStore) DownloadBLOB(ctx context.Context, ns, dataID string) (io.ReadCloser, error) {
+func (bs *blobStore) resolveBlob(ctx context.Context, ns, dataID string) (*fftypes.Data, *fftypes.Blob, error) {
 
 	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	id, err := fftypes.ParseUUID(ctx, dataID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	data, err := bs.database.GetDataByID(ctx, id, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if data == nil || data.Namespace != ns {
-		return nil, i18n.NewError(ctx, i18n.Msg404NoResult)
+		return nil, nil, i18n.NewError(ctx, i18n.Msg404NoResult)
 	}
 	if data.Blob == nil || data.Blob.Hash == nil {
-		return nil, i18n.NewError(ctx, i18n.MsgDataDoesNotHaveBlob)
+		return nil, nil, i18n.NewError(ctx, i18n.MsgDataDoesNotHaveBlob)
 	}
 
 	blob, err := bs.database.GetBlobMatchingHash(ctx, data.Blob.Hash)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if blob == nil {
-		return nil, i18n.NewError(ctx, i18n.MsgBlobNotFound, data.Blob.Hash)
+		return nil, nil, i18n.NewError(ctx, i18n.MsgBlobNotFound, data.Blob.Hash)
 	}
+	return data, blob, nil
+}
 
+func (bs *blobStore) DownloadBLOB(ctx context.Context, ns, dataID string) (io.ReadCloser, error) {
+	_, blob, err := bs.resolveBlob(ctx, ns, dataID)
+	if err != nil {
+		return nil, err
+	}
 	return bs.exchange.DownloadBLOB(ctx, blob.PayloadRef)
 }
+
+// parseByteRange parses a single range from an HTTP Range header value, such as "bytes=0-499", "bytes=500-" or "bytes=-500".
+// The whole blob is returned if no range is requested, the size of the blob is not known, or the range is in a different
+// unit or contains multiple ranges - all of which a server is allowed to ignore.
+func parseByteRange(ctx context.Context, rangeHeader string, size int64) (offset, length int64, partial bool, err error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(rangeHeader), "bytes=")
+	if rangeHeader == "" || size <= 0 || spec == rangeHeader || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+	bounds := strings.SplitN(spec, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, false, i18n.NewError(ctx, i18n.MsgInvalidRange, rangeHeader, size)
+	}
+	first, last := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+	if first == "" {
+		// A suffix range of the last N bytes
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, 0, false, i18n.NewError(ctx, i18n.MsgInvalidRange, rangeHeader, size)
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, suffix, true, nil
+	}
+	offset, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 || offset >= size {
+		return 0, 0, false, i18n.NewError(ctx, i18n.MsgInvalidRange, rangeHeader, size)
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < offset {
+			return 0, 0, false, i18n.NewError(ctx, i18n.MsgInvalidRange, rangeHeader, size)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return offset, end - offset + 1, true, nil
+}
+
+type rangeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// DownloadBLOBRange streams the range of a blob requested in an HTTP Range header (or the whole blob if there
+// is no range) from data exchange. The start of the range is found by seeking, if the data exchange plugin
+// returns a seekable stream, or by discarding the content before the range otherwise.
+func (bs *blobStore) DownloadBLOBRange(ctx context.Context, ns, dataID, rangeHeader string) (*fftypes.BlobDownload, error) {
+	data, blob, err := bs.resolveBlob(ctx, ns, dataID)
+	if err != nil {
+		return nil, err
+	}
+	download := &fftypes.BlobDownload{Size: data.Blob.Size}
+	if download.Offset, download.Length, download.Partial, err = parseByteRange(ctx, rangeHeader, data.Blob.Size); err != nil {
+		return nil, err
+	}
+
+	reader, err := bs.exchange.DownloadBLOB(ctx, blob.PayloadRef)
+	if err != nil {
+		return nil, err
+	}
+	if download.Offset > 0 {
+		if seeker, ok := reader.(io.Seeker); ok {
+			_, err = seeker.Seek(download.Offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(ioutil.Discard, reader, download.Offset)
+		}
+		if err != nil {
+			_ = reader.Close()
+			return nil, i18n.WrapError(ctx, err, i18n.MsgBlobStreamingFailed)
+		}
+	}
+	download.Reader = reader
+	if download.Partial {
+		download.Reader = &rangeReadCloser{Reader: io.LimitReader(reader, download.Length), Closer: reader}
+	}
+	return download, nil
+}
@@ -40,6 +40,7 @@ func TestUploadBlobOk(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	b := make([]byte, 10000+int(rand.Float32()*10000))
 	for i := 0; i < len(b); i++ {
@@ -47,7 +48,7 @@ func TestUploadBlobOk(t *testing.T) {
 	}
 
 	mdi := dm.database.(*databasemocks.Plugin)
-	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything)
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{
 			a[1].(func(context.Context) error)(a[0].(context.Context)),
@@ -76,6 +77,7 @@ func TestUploadBlobOk(t *testing.T) {
 	assert.Equal(t, [32]byte(sha256.Sum256(b)), [32]byte(*data.Hash))
 	assert.Equal(t, <-dxID, *data.ID)
 	assert.Equal(t, fftypes.ValidatorTypeJSON, data.Validator)
+	assert.Equal(t, int64(len(b)), data.Blob.Size)
 	assert.Nil(t, data.Datatype)
 
 	mdi.AssertExpectations(t)
@@ -87,9 +89,10 @@ func TestUploadBlobAutoMetaOk(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	mdi := dm.database.(*databasemocks.Plugin)
-	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything)
 	rag.RunFn = func(a mock.Arguments) {
 		rag.ReturnArguments = mock.Arguments{
 			a[1].(func(context.Context) error)(a[0].(context.Context)),
@@ -167,8 +170,9 @@ func TestUploadBlobReadFail(t *testing.T) {
 	mdx := dm.exchange.(*dataexchangemocks.Plugin)
 	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return("", fftypes.NewRandB32(), nil)
 	dxUpload.RunFn = func(a mock.Arguments) {
+		// The data exchange upload sees the error, so it does not store a truncated blob
 		_, err := ioutil.ReadAll(a[3].(io.Reader))
-		assert.NoError(t, err)
+		assert.Regexp(t, "pop", err)
 	}
 
 	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.DataRefOrValue{}, &fftypes.Multipart{Data: iotest.ErrReader(fmt.Errorf("pop"))}, false)
@@ -176,6 +180,47 @@ func TestUploadBlobReadFail(t *testing.T) {
 
 }
 
+func TestUploadBlobTooLarge(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.maxBlobSize = 10
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return("", nil, fmt.Errorf("aborted"))
+	dxUpload.RunFn = func(a mock.Arguments) {
+		_, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.Regexp(t, "FF10432", err)
+	}
+
+	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.DataRefOrValue{}, &fftypes.Multipart{Data: bytes.NewReader([]byte("more than ten bytes"))}, false)
+	assert.Regexp(t, "FF10432.*10", err)
+
+}
+
+func TestUploadBlobWithinMaxSize(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.maxBlobSize = 10
+
+	b := []byte("ten bytes!")
+	var hash fftypes.Bytes32 = sha256.Sum256(b)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return("", &hash, nil)
+	dxUpload.RunFn = func(a mock.Arguments) {
+		_, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.NoError(t, err)
+	}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("RunAsRetryableGroup", ctx, mock.Anything).Return(nil)
+
+	data, err := dm.UploadBLOB(ctx, "ns1", &fftypes.DataRefOrValue{}, &fftypes.Multipart{Data: bytes.NewReader(b)}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), data.Blob.Size)
+
+}
+
 func TestUploadBlobWriteFailDoesNotRead(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
@@ -221,7 +266,7 @@ func TestUploadBlobUpsertFail(t *testing.T) {
 		assert.Nil(t, err)
 	}
 	mdi := dm.database.(*databasemocks.Plugin)
-	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.DataRefOrValue{}, &fftypes.Multipart{Data: bytes.NewReader([]byte(b))}, false)
 	assert.Regexp(t, "pop", err)
@@ -232,6 +277,7 @@ func TestCopyBlobPStoDXOk(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -267,6 +313,7 @@ func TestCopyBlobPStoDXHashMismatch(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -300,6 +347,7 @@ func TestCopyBlobPStoPublicHashMismatch(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var correctHash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -333,6 +381,7 @@ func TestCopyBlobPStoDXInsertFail(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -366,6 +415,7 @@ func TestCopyBlobPStoUploadFail(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -396,6 +446,7 @@ func TestCopyBlobPStoDownloadFail(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -419,6 +470,7 @@ func TestCopyBlobPStoDownloadNotFound(t *testing.T) {
 
 	dm, ctx, cancel := newTestDataManager(t)
 	defer cancel()
+	dm.database.(*databasemocks.Plugin).On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
 
 	payload := []byte(`some data`)
 	var hash fftypes.Bytes32 = sha256.Sum256(payload)
@@ -593,3 +645,306 @@ func TestDownloadBlobBadID(t *testing.T) {
 	assert.Regexp(t, "FF10142", err)
 
 }
+
+type testSeekableBlob struct {
+	*bytes.Reader
+}
+
+func (tsb *testSeekableBlob) Close() error { return nil }
+
+func mockRangeBlob(ctx context.Context, dm *dataManager, size int64) *fftypes.UUID {
+	blobHash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns1",
+		Blob: &fftypes.BlobRef{
+			Hash: blobHash,
+			Size: size,
+		},
+	}, nil)
+	mdi.On("GetBlobMatchingHash", ctx, blobHash).Return(&fftypes.Blob{
+		Hash:       blobHash,
+		PayloadRef: "ns1/blob1",
+	}, nil)
+	return dataID
+}
+
+func TestDownloadBlobRangeWholeBlob(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 9)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(
+		ioutil.NopCloser(bytes.NewReader([]byte("some blob"))),
+		nil)
+
+	download, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "")
+	assert.NoError(t, err)
+	assert.False(t, download.Partial)
+	assert.Equal(t, int64(9), download.Size)
+	b, err := ioutil.ReadAll(download.Reader)
+	download.Reader.Close()
+	assert.Equal(t, "some blob", string(b))
+
+}
+
+func TestDownloadBlobRangeSeek(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 9)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(
+		&testSeekableBlob{Reader: bytes.NewReader([]byte("some blob"))},
+		nil)
+
+	download, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "bytes=2-5")
+	assert.NoError(t, err)
+	assert.True(t, download.Partial)
+	assert.Equal(t, int64(2), download.Offset)
+	assert.Equal(t, int64(4), download.Length)
+	b, err := ioutil.ReadAll(download.Reader)
+	download.Reader.Close()
+	assert.Equal(t, "me b", string(b))
+
+}
+
+func TestDownloadBlobRangeDiscard(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 9)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(
+		ioutil.NopCloser(bytes.NewReader([]byte("some blob"))),
+		nil)
+
+	download, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "bytes=-4")
+	assert.NoError(t, err)
+	assert.True(t, download.Partial)
+	b, err := ioutil.ReadAll(download.Reader)
+	download.Reader.Close()
+	assert.Equal(t, "blob", string(b))
+
+}
+
+func TestDownloadBlobRangeDiscardFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 100)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(
+		ioutil.NopCloser(bytes.NewReader([]byte("some blob"))),
+		nil)
+
+	_, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "bytes=50-")
+	assert.Regexp(t, "FF10217", err)
+
+}
+
+func TestDownloadBlobRangeDXFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 9)
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/blob1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "bytes=0-")
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestDownloadBlobRangeUnsatisfiable(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := mockRangeBlob(ctx, dm, 9)
+
+	_, err := dm.DownloadBLOBRange(ctx, "ns1", dataID.String(), "bytes=9-")
+	assert.Regexp(t, "FF10433", err)
+
+}
+
+func TestDownloadBlobRangeBadID(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	_, err := dm.DownloadBLOBRange(ctx, "ns1", "!uuid", "")
+	assert.Regexp(t, "FF10142", err)
+
+}
+
+func TestParseByteRange(t *testing.T) {
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		header  string
+		size    int64
+		offset  int64
+		length  int64
+		partial bool
+	}{
+		{"", 10, 0, 10, false},
+		{"bytes=0-1", 0, 0, 0, false},
+		{"items=0-1", 10, 0, 10, false},
+		{"bytes=0-1,5-6", 10, 0, 10, false},
+		{"bytes=0-4", 10, 0, 5, true},
+		{"bytes=5-", 10, 5, 5, true},
+		{"bytes=5-100", 10, 5, 5, true},
+		{"bytes=-3", 10, 7, 3, true},
+		{"bytes=-100", 10, 0, 10, true},
+	} {
+		offset, length, partial, err := parseByteRange(ctx, tc.header, tc.size)
+		assert.NoError(t, err, tc.header)
+		assert.Equal(t, tc.offset, offset, tc.header)
+		assert.Equal(t, tc.length, length, tc.header)
+		assert.Equal(t, tc.partial, partial, tc.header)
+	}
+
+	for _, header := range []string{"bytes=5", "bytes=-0", "bytes=-x", "bytes=x-", "bytes=10-", "bytes=5-x", "bytes=5-4"} {
+		_, _, _, err := parseByteRange(ctx, header, 10)
+		assert.Regexp(t, "FF10433", err, header)
+	}
+
+}
+
+func TestStoreBlobNew(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	blob := &fftypes.Blob{Hash: fftypes.NewRandB32(), PayloadRef: "/private/loc"}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, blob.Hash).Return(nil, nil)
+	mdi.On("InsertBlob", ctx, blob).Return(nil)
+
+	stored, err := dm.StoreBlob(ctx, blob)
+	assert.NoError(t, err)
+	assert.Equal(t, blob, stored)
+	assert.Equal(t, int64(1), stored.Refs)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestStoreBlobExisting(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	hash := fftypes.NewRandB32()
+	existing := &fftypes.Blob{Hash: hash, PayloadRef: "/private/loc1", Refs: 2, Sequence: 12345}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, hash).Return(existing, nil)
+	mdi.On("UpdateBlobRefs", ctx, int64(12345), int64(1)).Return(nil)
+
+	stored, err := dm.StoreBlob(ctx, &fftypes.Blob{Hash: hash, PayloadRef: "/private/loc2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/private/loc1", stored.PayloadRef)
+	assert.Equal(t, int64(3), stored.Refs)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestStoreBlobLookupFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := dm.StoreBlob(ctx, &fftypes.Blob{Hash: fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestStoreBlobUpdateRefsFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, mock.Anything).Return(&fftypes.Blob{Sequence: 12345}, nil)
+	mdi.On("UpdateBlobRefs", ctx, int64(12345), int64(1)).Return(fmt.Errorf("pop"))
+
+	_, err := dm.StoreBlob(ctx, &fftypes.Blob{Hash: fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCopyBlobPStoDXExisting(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	hash := fftypes.NewRandB32()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, hash).Return(&fftypes.Blob{Hash: hash, PayloadRef: "/private/loc", Refs: 1, Sequence: 12345}, nil)
+	mdi.On("UpdateBlobRefs", ctx, int64(12345), int64(1)).Return(nil)
+
+	blob, err := dm.CopyBlobPStoDX(ctx, &fftypes.Data{
+		Namespace: "ns1",
+		ID:        fftypes.NewUUID(),
+		Blob: &fftypes.BlobRef{
+			Hash:   hash,
+			Public: "public-ref",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/private/loc", blob.PayloadRef)
+	assert.Equal(t, int64(2), blob.Refs)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCopyBlobPStoDXLookupFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := dm.CopyBlobPStoDX(ctx, &fftypes.Data{
+		Namespace: "ns1",
+		ID:        fftypes.NewUUID(),
+		Blob: &fftypes.BlobRef{
+			Hash:   fftypes.NewRandB32(),
+			Public: "public-ref",
+		},
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetBlobStats(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	stats := &fftypes.BlobStats{Blobs: 1, References: 3, Deduplicated: 2}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobStats", ctx).Return(stats, nil)
+
+	res, err := dm.GetBlobStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, stats, res)
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,304 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// importBlockedNets are the loopback, link-local (including cloud metadata endpoints) and private network
+// ranges that data cannot be imported from, unless private addresses are allowed by config
+var importBlockedNets = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, nets[i], _ = net.ParseCIDR(cidr)
+	}
+	return nets
+}
+
+// newImportClient returns the client used to download imported data. The address is checked on every connection,
+// after DNS resolution, so it cannot be bypassed with a redirect or a host name that resolves to a private address.
+// A proxy is not used, as it would make the connection on the node's behalf.
+func (dm *dataManager) newImportClient() *resty.Client {
+	allowPrivate := config.GetBool(config.DataImportAllowPrivateAddresses)
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			return dm.checkImportAddress(allowPrivate, address)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return resty.New().
+		SetTransport(transport).
+		SetTimeout(config.GetDuration(config.DataImportRequestTimeout)).
+		SetRedirectPolicy(resty.FlexibleRedirectPolicy(10), resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+			return dm.checkImportURL(req.Context(), req.URL)
+		}))
+}
+
+// checkImportURL checks the URL of an import, or of a redirect, is an http or https URL on an allowed host
+func (dm *dataManager) checkImportURL(ctx context.Context, u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return i18n.NewError(ctx, i18n.MsgDataImportBadURL, u)
+	}
+	if len(dm.importHosts) == 0 {
+		return nil
+	}
+	for _, host := range dm.importHosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgDataImportHostNotAllowed, u.Hostname())
+}
+
+func (dm *dataManager) checkImportAddress(allowPrivate bool, address string) error {
+	if allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	blocked := ip == nil || ip.IsMulticast()
+	for _, ipNet := range importBlockedNets {
+		blocked = blocked || ipNet.Contains(ip)
+	}
+	if blocked {
+		return i18n.NewError(dm.ctx, i18n.MsgDataImportAddressNotAllowed, host)
+	}
+	return nil
+}
+
+// ImportData creates a data item with a blob that is downloaded and hashed by FireFly, rather than streamed
+// through the API. The work happens in the background, and the returned operation tracks its progress.
+// On success the output of the operation contains the ID of the new data item.
+func (dm *dataManager) ImportData(ctx context.Context, ns string, req *fftypes.DataImport) (*fftypes.Operation, error) {
+	if (req.URL == "") == (req.PayloadRef == "") {
+		return nil, i18n.NewError(ctx, i18n.MsgDataImportSource)
+	}
+	if req.URL != "" {
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgDataImportBadURL, req.URL)
+		}
+		if err := dm.checkImportURL(ctx, u); err != nil {
+			return nil, err
+		}
+	} else if !dm.exchange.IsLocalBLOB(ns, req.PayloadRef) {
+		return nil, i18n.NewError(ctx, i18n.MsgDataImportRefNotLocal, req.PayloadRef, ns)
+	}
+	if req.Validator == "" {
+		req.Validator = fftypes.ValidatorTypeJSON
+	}
+	if err := fftypes.CheckValidatorType(ctx, req.Validator); err != nil {
+		return nil, err
+	}
+
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: ns,
+		Created:   fftypes.Now(),
+		Validator: req.Validator,
+		Datatype:  req.Datatype,
+		Value:     req.Value,
+	}
+
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Namespace: ns,
+			Type:      fftypes.TransactionTypeDataImport,
+			Reference: data.ID,
+		},
+		Created: fftypes.Now(),
+		Status:  fftypes.OpStatusPending,
+	}
+	tx.Hash = tx.Subject.Hash()
+
+	op := fftypes.NewTXOperation(
+		dm.exchange,
+		ns,
+		tx.ID,
+		"",
+		fftypes.OpTypeDataImport,
+		fftypes.OpStatusPending)
+	op.Input = fftypes.JSONObject{
+		"data":       data.ID.String(),
+		"url":        req.URL,
+		"payloadRef": req.PayloadRef,
+	}
+
+	err := dm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		err = dm.database.UpsertTransaction(ctx, tx, false /* should be new */)
+		if err == nil {
+			err = dm.database.InsertOperation(ctx, op)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The import runs on the manager's context, as it outlives the API request
+	go dm.runDataImport(op, data, req)
+	return op, nil
+}
+
+func (dm *dataManager) runDataImport(op *fftypes.Operation, data *fftypes.Data, req *fftypes.DataImport) {
+	ctx := log.WithLogField(dm.ctx, "opid", op.ID.String())
+
+	status := fftypes.OpStatusSucceeded
+	errMsg := ""
+	var output fftypes.JSONObject
+	if err := dm.importData(ctx, data, req); err != nil {
+		log.L(ctx).Errorf("Data import '%s' failed: %s", data.ID, err)
+		status = fftypes.OpStatusFailed
+		errMsg = err.Error()
+	} else {
+		output = fftypes.JSONObject{
+			"data": data.ID.String(),
+			"hash": data.Blob.Hash.String(),
+			"size": data.Blob.Size,
+		}
+	}
+
+	update := database.OperationQueryFactory.NewUpdate(ctx).
+		Set("status", status).
+		Set("error", errMsg).
+		Set("output", output)
+	if err := dm.database.UpdateOperation(ctx, op.ID, update); err != nil {
+		log.L(ctx).Errorf("Failed to update data import operation '%s': %s", op.ID, err)
+	}
+}
+
+func (dm *dataManager) importData(ctx context.Context, data *fftypes.Data, req *fftypes.DataImport) (err error) {
+	var payloadRef string
+	autoMeta := fftypes.JSONObject{}
+	data.Blob = &fftypes.BlobRef{}
+	if req.URL != "" {
+		payloadRef, err = dm.importFromURL(ctx, data, req, autoMeta)
+	} else {
+		payloadRef, err = dm.importFromDX(ctx, data, req)
+	}
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Imported BLOB %d bytes for data '%s' hash=%s", data.Blob.Size, data.ID, data.Blob.Hash)
+
+	// autoMeta will create/update JSON metadata with the download details
+	if req.AutoMeta {
+		do := data.Value.JSONObject()
+		for k, v := range autoMeta {
+			do[k] = v
+		}
+		do["size"] = float64(data.Blob.Size)
+		data.Value, _ = json.Marshal(&do)
+	}
+
+	return dm.storeUploadedBLOB(ctx, data, payloadRef)
+}
+
+// importFromURL streams the content of the URL into data exchange, hashing it on the way through
+func (dm *dataManager) importFromURL(ctx context.Context, data *fftypes.Data, req *fftypes.DataImport, autoMeta fftypes.JSONObject) (string, error) {
+	res, err := dm.importClient.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(req.URL)
+	if res != nil && res.RawBody() != nil {
+		defer res.RawBody().Close()
+	}
+	if err != nil {
+		return "", err
+	}
+	if !res.IsSuccess() {
+		return "", i18n.NewError(ctx, i18n.MsgDataImportDownloadFailed, req.URL, res.StatusCode())
+	}
+
+	hash, written, payloadRef, err := dm.uploadVerifyBLOB(ctx, data.Namespace, data.ID, req.Hash, res.RawBody())
+	if err != nil {
+		return "", err
+	}
+	data.Blob.Hash = hash
+	data.Blob.Size = written
+
+	u, _ := url.Parse(req.URL)
+	if filename := path.Base(u.Path); filename != "/" && filename != "." {
+		autoMeta["filename"] = filename
+	}
+	if mimetype := res.Header().Get("Content-Type"); mimetype != "" {
+		autoMeta["mimetype"] = mimetype
+	}
+	return payloadRef, nil
+}
+
+// importFromDX hashes a payload that has already been uploaded to data exchange, so it can be attached to the data
+func (dm *dataManager) importFromDX(ctx context.Context, data *fftypes.Data, req *fftypes.DataImport) (string, error) {
+	reader, err := dm.exchange.DownloadBLOB(ctx, req.PayloadRef)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgDownloadBlobFailed, req.PayloadRef)
+	}
+	defer reader.Close()
+
+	hashCalc := sha256.New()
+	written, err := io.Copy(hashCalc, reader)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgBlobStreamingFailed)
+	}
+	hash := fftypes.HashResult(hashCalc)
+	if req.Hash != nil && !hash.Equals(req.Hash) {
+		return "", i18n.NewError(ctx, i18n.MsgDataImportHashMismatch, hash, req.Hash)
+	}
+	data.Blob.Hash = hash
+	data.Blob.Size = written
+	return req.PayloadRef, nil
+}
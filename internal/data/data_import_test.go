@@ -0,0 +1,329 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockDXUpload(t *testing.T, mdx *dataexchangemocks.Plugin, content string) {
+	dxUpload := mdx.On("UploadBLOB", mock.Anything, "ns1", mock.Anything, mock.Anything)
+	dxUpload.RunFn = func(a mock.Arguments) {
+		readBytes, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(readBytes))
+		var hash fftypes.Bytes32 = sha256.Sum256(readBytes)
+		dxUpload.ReturnArguments = mock.Arguments{"ns1/payload", &hash, nil}
+	}
+}
+
+func TestImportDataURLAutoMetaOk(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	httpmock.ActivateNonDefault(dm.importClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://example.com/files/doc.txt", func(req *http.Request) (*http.Response, error) {
+		res := httpmock.NewStringResponse(200, "some content")
+		res.Header.Set("Content-Type", "text/plain")
+		return res, nil
+	})
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mockDXUpload(t, mdx, "some content")
+	mdx.On("Name").Return("utdx")
+
+	var stored *fftypes.Data
+	done := make(chan struct{})
+	mdi := dm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	rrg := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything)
+	rrg.RunFn = func(a mock.Arguments) {
+		rrg.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertTransaction", ctx, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Subject.Type == fftypes.TransactionTypeDataImport
+	}), false).Return(nil)
+	mdi.On("InsertOperation", ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeDataImport && op.Input["url"] == "https://example.com/files/doc.txt"
+	})).Return(nil)
+	mdi.On("UpsertData", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Run(func(a mock.Arguments) {
+		stored = a[1].(*fftypes.Data)
+	}).Return(nil)
+	mdi.On("GetBlobMatchingHash", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("InsertBlob", mock.Anything, mock.MatchedBy(func(blob *fftypes.Blob) bool {
+		return blob.PayloadRef == "ns1/payload"
+	})).Return(nil)
+	mdi.On("UpdateOperation", mock.Anything, mock.Anything, mock.Anything).Run(func(a mock.Arguments) {
+		close(done)
+	}).Return(nil)
+
+	op, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{
+		URL:      "https://example.com/files/doc.txt",
+		Value:    fftypes.Byteable(`{"custom":"meta"}`),
+		AutoMeta: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusPending, op.Status)
+	<-done
+
+	var hash fftypes.Bytes32 = sha256.Sum256([]byte("some content"))
+	assert.Equal(t, op.Input["data"], stored.ID.String())
+	assert.Equal(t, hash, *stored.Blob.Hash)
+	assert.Equal(t, int64(12), stored.Blob.Size)
+	assert.Equal(t, fftypes.ValidatorTypeJSON, stored.Validator)
+	assert.Equal(t, "meta", stored.Value.JSONObject().GetString("custom"))
+	assert.Equal(t, "doc.txt", stored.Value.JSONObject().GetString("filename"))
+	assert.Equal(t, "text/plain", stored.Value.JSONObject().GetString("mimetype"))
+	assert.Equal(t, float64(12), stored.Value.JSONObject()["size"])
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestImportDataBadSource(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{})
+	assert.Regexp(t, "FF10385", err)
+	_, err = dm.ImportData(ctx, "ns1", &fftypes.DataImport{URL: "https://example.com", PayloadRef: "ns1/payload"})
+	assert.Regexp(t, "FF10385", err)
+}
+
+func TestImportDataBadURL(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{URL: "file:///etc/passwd"})
+	assert.Regexp(t, "FF10386", err)
+	_, err = dm.ImportData(ctx, "ns1", &fftypes.DataImport{URL: "://"})
+	assert.Regexp(t, "FF10386", err)
+}
+
+func TestImportDataHostNotAllowed(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.importHosts = []string{"files.example.com"}
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{URL: "https://example.com/doc.txt"})
+	assert.Regexp(t, "FF10461.*example.com", err)
+	err = dm.checkImportURL(ctx, &url.URL{Scheme: "https", Host: "FILES.example.com:8443"})
+	assert.NoError(t, err)
+}
+
+func TestImportDataPayloadRefNotLocal(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("IsLocalBLOB", "ns1", "peer1/ns1/payload").Return(false)
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{PayloadRef: "peer1/ns1/payload"})
+	assert.Regexp(t, "FF10463", err)
+}
+
+func TestImportDataBadValidator(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("IsLocalBLOB", "ns1", "ns1/payload").Return(true)
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{PayloadRef: "ns1/payload", Validator: "wrong"})
+	assert.Regexp(t, "FF10200", err)
+}
+
+func TestImportDataInsertFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("Name").Return("utdx")
+	mdx.On("IsLocalBLOB", "ns1", "ns1/payload").Return(true)
+	mdi := dm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertTransaction", ctx, mock.Anything, false).Return(nil)
+	mdi.On("InsertOperation", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := dm.ImportData(ctx, "ns1", &fftypes.DataImport{PayloadRef: "ns1/payload"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRunDataImportFail(t *testing.T) {
+	dm, _, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", mock.Anything, "ns1/payload").Return(nil, fmt.Errorf("pop"))
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("UpdateOperation", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	dm.runDataImport(&fftypes.Operation{ID: fftypes.NewUUID()}, &fftypes.Data{ID: fftypes.NewUUID()}, &fftypes.DataImport{PayloadRef: "ns1/payload"})
+	mdi.AssertExpectations(t)
+}
+
+func TestImportDataFromDXOk(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	var hash fftypes.Bytes32 = sha256.Sum256([]byte("some content"))
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/payload").Return(ioutil.NopCloser(strings.NewReader("some content")), nil)
+	mdi := dm.database.(*databasemocks.Plugin)
+	rrg := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything)
+	rrg.RunFn = func(a mock.Arguments) {
+		rrg.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertData", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("GetBlobMatchingHash", ctx, &hash).Return(nil, nil)
+	mdi.On("InsertBlob", ctx, mock.MatchedBy(func(blob *fftypes.Blob) bool {
+		return blob.PayloadRef == "ns1/payload"
+	})).Return(nil)
+
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1"}
+	err := dm.importData(ctx, data, &fftypes.DataImport{PayloadRef: "ns1/payload", Hash: &hash, AutoMeta: true})
+	assert.NoError(t, err)
+	assert.Equal(t, hash, *data.Blob.Hash)
+	assert.Equal(t, int64(12), data.Blob.Size)
+	assert.Equal(t, fftypes.JSONObject{"size": float64(12)}, data.Value.JSONObject())
+	assert.NotNil(t, data.Hash)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestImportDataFromDXHashMismatch(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/payload").Return(ioutil.NopCloser(strings.NewReader("some content")), nil)
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{PayloadRef: "ns1/payload", Hash: fftypes.NewRandB32()})
+	assert.Regexp(t, "FF10388", err)
+}
+
+func TestImportDataFromDXDownloadFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/payload").Return(nil, fmt.Errorf("pop"))
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{PayloadRef: "ns1/payload"})
+	assert.Regexp(t, "FF10240.*pop", err)
+}
+
+func TestImportDataFromDXReadFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1/payload").Return(ioutil.NopCloser(iotest.ErrReader(fmt.Errorf("pop"))), nil)
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{PayloadRef: "ns1/payload"})
+	assert.Regexp(t, "FF10217", err)
+}
+
+func TestImportDataFromURLNotFound(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	httpmock.ActivateNonDefault(dm.importClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com/", httpmock.NewStringResponder(404, "not found"))
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{URL: "https://example.com/"})
+	assert.Regexp(t, "FF10387.*404", err)
+}
+
+func TestImportDataFromURLRequestFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	httpmock.ActivateNonDefault(dm.importClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com/", httpmock.NewErrorResponder(fmt.Errorf("pop")))
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{URL: "https://example.com/"})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestImportDataFromURLUploadFail(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	httpmock.ActivateNonDefault(dm.importClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com", httpmock.NewStringResponder(200, "some content"))
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return("", nil, fmt.Errorf("pop"))
+	err := dm.importData(ctx, &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1"}, &fftypes.DataImport{URL: "https://example.com"})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestImportDataFromURLRedirectHostNotAllowed(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.importHosts = []string{"example.com"}
+	httpmock.ActivateNonDefault(dm.importClient.GetClient())
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://example.com/doc.txt", func(req *http.Request) (*http.Response, error) {
+		res := httpmock.NewStringResponse(302, "")
+		res.Header.Set("Location", "http://169.254.169.254/latest/meta-data")
+		return res, nil
+	})
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{URL: "https://example.com/doc.txt"})
+	assert.Regexp(t, "FF10461.*169.254.169.254", err)
+}
+
+func TestImportDataFromURLPrivateAddress(t *testing.T) {
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+	err := dm.importData(ctx, &fftypes.Data{}, &fftypes.DataImport{URL: server.URL})
+	assert.Regexp(t, "FF10462.*127.0.0.1", err)
+}
+
+func TestCheckImportAddress(t *testing.T) {
+	dm, _, cancel := newTestDataManager(t)
+	defer cancel()
+	assert.NoError(t, dm.checkImportAddress(false, "93.184.216.34:443"))
+	assert.NoError(t, dm.checkImportAddress(false, "[2606:2800:220:1::]:443"))
+	assert.NoError(t, dm.checkImportAddress(true, "127.0.0.1:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "10.1.2.3:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "169.254.169.254:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "[::1]:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "[::ffff:192.168.0.1]:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "224.0.0.1:80"))
+	assert.Regexp(t, "FF10462", dm.checkImportAddress(false, "localhost:80"))
+	assert.Error(t, dm.checkImportAddress(false, "no port"))
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
@@ -42,18 +43,27 @@ type Manager interface {
 
 	UploadJSON(ctx context.Context, ns string, inData *fftypes.DataRefOrValue) (*fftypes.Data, error)
 	UploadBLOB(ctx context.Context, ns string, inData *fftypes.DataRefOrValue, blob *fftypes.Multipart, autoMeta bool) (*fftypes.Data, error)
+	ImportData(ctx context.Context, ns string, req *fftypes.DataImport) (*fftypes.Operation, error)
 	CopyBlobPStoDX(ctx context.Context, data *fftypes.Data) (blob *fftypes.Blob, err error)
 	DownloadBLOB(ctx context.Context, ns, dataID string) (io.ReadCloser, error)
+	DownloadBLOBRange(ctx context.Context, ns, dataID, rangeHeader string) (*fftypes.BlobDownload, error)
+	StoreBlob(ctx context.Context, blob *fftypes.Blob) (*fftypes.Blob, error)
+	GetBlobStats(ctx context.Context) (*fftypes.BlobStats, error)
+	RegisterTransformer(t Transformer)
 }
 
 type dataManager struct {
 	blobStore
 
+	ctx               context.Context
 	database          database.Plugin
 	publicstorage     publicstorage.Plugin
 	exchange          dataexchange.Plugin
 	validatorCache    *ccache.Cache
 	validatorCacheTTL time.Duration
+	importClient      *resty.Client
+	importHosts       []string
+	transformers      []Transformer
 }
 
 func NewDataManager(ctx context.Context, di database.Plugin, pi publicstorage.Plugin, dx dataexchange.Plugin) (Manager, error) {
@@ -61,16 +71,21 @@ func NewDataManager(ctx context.Context, di database.Plugin, pi publicstorage.Pl
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	dm := &dataManager{
+		ctx:               ctx,
 		database:          di,
 		publicstorage:     pi,
 		exchange:          dx,
 		validatorCacheTTL: config.GetDuration(config.ValidatorCacheTTL),
+		importHosts:       config.GetStringSlice(config.DataImportAllowedHosts),
+		transformers:      newRuleTransformers(),
 	}
+	dm.importClient = dm.newImportClient()
 	dm.blobStore = blobStore{
 		dm:            dm,
 		database:      di,
 		publicstorage: pi,
 		exchange:      dx,
+		maxBlobSize:   config.GetByteSize(config.DataBlobMaxSize),
 	}
 	dm.validatorCache = ccache.New(
 		// We use a LRU cache with a size-aware max
@@ -242,6 +257,12 @@ func (dm *dataManager) checkValidation(ctx context.Context, ns string, validator
 
 func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validator fftypes.ValidatorType, datatype *fftypes.DatatypeRef, value fftypes.Byteable, blobRef *fftypes.BlobRef) (data *fftypes.Data, blob *fftypes.Blob, err error) {
 
+	// Transformations are applied first, so the value we validate is the one that is hashed, stored and sent
+	value, transform, err := dm.transform(ctx, ns, datatype, value)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if err := dm.checkValidation(ctx, ns, validator, datatype, value); err != nil {
 		return nil, nil, err
 	}
@@ -257,6 +278,7 @@ func (dm *dataManager) validateAndStore(ctx context.Context, ns string, validato
 		Namespace: ns,
 		Value:     value,
 		Blob:      blobRef,
+		Transform: transform,
 	}
 	err = data.Seal(ctx)
 	if err == nil {
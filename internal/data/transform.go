@@ -0,0 +1,128 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Transformer is a hook that can modify the value of outbound data, before it is validated, hashed and stored.
+// The name of each transformer that modifies a data item is recorded on the data, so the change can be traced.
+type Transformer interface {
+	Name() string
+	Transform(ctx context.Context, ns string, datatype *fftypes.DatatypeRef, value fftypes.Byteable) (result fftypes.Byteable, applied bool, err error)
+}
+
+// ruleTransformer is the built-in transformer configured in data.transform.rules, which can strip or redact fields
+// and set fields (such as a classification) on JSON object values, for a namespace and/or datatype.
+type ruleTransformer struct {
+	name      string
+	namespace string
+	datatype  string
+	strip     []string
+	redact    []string
+	set       fftypes.JSONObject
+}
+
+func newRuleTransformers() []Transformer {
+	rules := config.GetObjectArray(config.DataTransformRules)
+	transformers := make([]Transformer, 0, len(rules))
+	for i, ruleConf := range rules {
+		rt := &ruleTransformer{
+			name:      ruleConf.GetString("name"),
+			namespace: ruleConf.GetString("namespace"),
+			datatype:  ruleConf.GetString("datatype"),
+			strip:     ruleConf.GetStringArray("strip"),
+			redact:    ruleConf.GetStringArray("redact"),
+			set:       ruleConf.GetObject("set"),
+		}
+		if rt.name == "" {
+			rt.name = fmt.Sprintf("rule%d", i)
+		}
+		transformers = append(transformers, rt)
+	}
+	return transformers
+}
+
+func (rt *ruleTransformer) Name() string {
+	return rt.name
+}
+
+func (rt *ruleTransformer) matches(ns string, datatype *fftypes.DatatypeRef) bool {
+	if rt.namespace != "" && rt.namespace != ns {
+		return false
+	}
+	return rt.datatype == "" || (datatype != nil && rt.datatype == datatype.Name)
+}
+
+func stripPath(obj map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(obj, path[0])
+		return
+	}
+	if child, ok := obj[path[0]].(map[string]interface{}); ok {
+		stripPath(child, path[1:])
+	}
+}
+
+// Transform only applies to values that are JSON objects - other values are passed through unchanged
+func (rt *ruleTransformer) Transform(ctx context.Context, ns string, datatype *fftypes.DatatypeRef, value fftypes.Byteable) (fftypes.Byteable, bool, error) {
+	var obj fftypes.JSONObject
+	if !rt.matches(ns, datatype) || json.Unmarshal(value, &obj) != nil || obj == nil {
+		return value, false, nil
+	}
+	for _, path := range rt.strip {
+		stripPath(obj, strings.Split(path, "."))
+	}
+	obj = obj.Redact(rt.redact...)
+	for k, v := range rt.set {
+		obj[k] = v
+	}
+	result, err := json.Marshal(&obj)
+	return result, err == nil, err
+}
+
+// RegisterTransformer adds a transformer, which is applied after any configured transformation rules
+func (dm *dataManager) RegisterTransformer(t Transformer) {
+	dm.transformers = append(dm.transformers, t)
+}
+
+// transform applies each transformer in turn, returning the transformed value and the comma separated
+// names of the transformers that modified it
+func (dm *dataManager) transform(ctx context.Context, ns string, datatype *fftypes.DatatypeRef, value fftypes.Byteable) (fftypes.Byteable, string, error) {
+	if value == nil {
+		return nil, "", nil
+	}
+	var applied []string
+	for _, t := range dm.transformers {
+		result, ok, err := t.Transform(ctx, ns, datatype, value)
+		if err != nil {
+			return nil, "", err
+		}
+		if ok {
+			value = result
+			applied = append(applied, t.Name())
+		}
+	}
+	return value, strings.Join(applied, ","), nil
+}
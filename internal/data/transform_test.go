@@ -0,0 +1,148 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testTransformer struct {
+	err error
+}
+
+func (tt *testTransformer) Name() string {
+	return "test"
+}
+
+func (tt *testTransformer) Transform(ctx context.Context, ns string, datatype *fftypes.DatatypeRef, value fftypes.Byteable) (fftypes.Byteable, bool, error) {
+	return value, tt.err == nil, tt.err
+}
+
+func TestUploadJSONTransformed(t *testing.T) {
+
+	config.Reset()
+	config.Set(config.DataTransformRules, fftypes.JSONObjectArray{
+		{
+			"name":     "pii",
+			"datatype": "customer",
+			"strip":    []interface{}{"ssn", "address.street", "missing.field"},
+			"redact":   []interface{}{"name"},
+		},
+		{
+			"namespace": "ns1",
+			"set":       map[string]interface{}{"classification": "internal"},
+		},
+		{
+			"namespace": "ns2",
+			"set":       map[string]interface{}{"classification": "secret"},
+		},
+	})
+	defer config.Reset()
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.RegisterTransformer(&testTransformer{})
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+
+	data, err := dm.UploadJSON(ctx, "ns1", &fftypes.DataRefOrValue{
+		Validator: fftypes.ValidatorTypeNone,
+		Datatype: &fftypes.DatatypeRef{
+			Name:    "customer",
+			Version: "0.0.1",
+		},
+		Value: fftypes.Byteable(`{"name":"Jane","ssn":"123-45-6789","address":{"street":"1 Main St","country":"UK"}}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "pii,rule1,test", data.Transform)
+	assert.JSONEq(t, `{"name":"[redacted]","address":{"country":"UK"},"classification":"internal"}`, data.Value.String())
+	hash, _ := data.CalcHash(ctx)
+	assert.Equal(t, hash, data.Hash)
+
+}
+
+func TestUploadJSONNotTransformed(t *testing.T) {
+
+	config.Reset()
+	config.Set(config.DataTransformRules, fftypes.JSONObjectArray{
+		{
+			"datatype": "customer",
+			"strip":    []interface{}{"ssn"},
+		},
+	})
+	defer config.Reset()
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+
+	data, err := dm.UploadJSON(ctx, "ns1", &fftypes.DataRefOrValue{
+		Value: fftypes.Byteable(`{"ssn":"123-45-6789"}`),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, data.Transform)
+	assert.Equal(t, `{"ssn":"123-45-6789"}`, data.Value.String())
+
+	data, err = dm.UploadJSON(ctx, "ns1", &fftypes.DataRefOrValue{
+		Validator: fftypes.ValidatorTypeNone,
+		Datatype: &fftypes.DatatypeRef{
+			Name:    "customer",
+			Version: "0.0.1",
+		},
+		Value: fftypes.Byteable(`"just a string"`),
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, data.Transform)
+	assert.Equal(t, `"just a string"`, data.Value.String())
+
+}
+
+func TestUploadJSONTransformFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+	dm.RegisterTransformer(&testTransformer{err: fmt.Errorf("pop")})
+
+	_, err := dm.UploadJSON(ctx, "ns1", &fftypes.DataRefOrValue{
+		Value: fftypes.Byteable(`{}`),
+	})
+	assert.EqualError(t, err, "pop")
+
+}
+
+func TestRuleTransformerMarshalFail(t *testing.T) {
+
+	rt := &ruleTransformer{
+		set: fftypes.JSONObject{"bad": map[bool]bool{true: false}},
+	}
+	_, applied, err := rt.Transform(context.Background(), "ns1", nil, fftypes.Byteable(`{}`))
+	assert.Error(t, err)
+	assert.False(t, applied)
+
+}
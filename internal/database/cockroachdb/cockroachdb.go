@@ -0,0 +1,87 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/database/sqlcommon"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/lib/pq"
+)
+
+// pqSerializationFailure is returned by CockroachDB when a transaction conflicts with another, and must be retried
+const pqSerializationFailure = "40001"
+
+// CockroachDB uses the PostgreSQL wire protocol, so shares the same driver and SQL dialect
+type CockroachDB struct {
+	sqlcommon.SQLCommon
+	asOfSystemTime string
+}
+
+func (crdb *CockroachDB) Init(ctx context.Context, prefix config.Prefix, callbacks database.Callbacks) error {
+	crdb.asOfSystemTime = prefix.GetString(CRDBConfAsOfSystemTime)
+	capabilities := &database.Capabilities{}
+	return crdb.SQLCommon.Init(ctx, crdb, prefix, callbacks, capabilities)
+}
+
+func (crdb *CockroachDB) Name() string {
+	return "cockroachdb"
+}
+
+func (crdb *CockroachDB) MigrationsDir() string {
+	return crdb.Name()
+}
+
+func (crdb *CockroachDB) PlaceholderFormat() sq.PlaceholderFormat {
+	return sq.Dollar
+}
+
+func (crdb *CockroachDB) UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (sq.InsertBuilder, bool) {
+	return insert.Suffix(" RETURNING seq"), true
+}
+
+func (crdb *CockroachDB) Open(url string) (*sql.DB, error) {
+	return sql.Open("postgres", url)
+}
+
+func (crdb *CockroachDB) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
+	return postgres.WithInstance(db, &postgres.Config{})
+}
+
+func (crdb *CockroachDB) IsRetryableError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqSerializationFailure
+}
+
+func (crdb *CockroachDB) HistoricalReadTimeQuery() string {
+	if crdb.asOfSystemTime == "" {
+		return ""
+	}
+	return fmt.Sprintf("SELECT %s", crdb.asOfSystemTime)
+}
+
+func (crdb *CockroachDB) CurrentTimeQuery() string {
+	return "SELECT CAST(EXTRACT(EPOCH FROM clock_timestamp()) * 1000 AS BIGINT)"
+}
@@ -0,0 +1,76 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cockroachdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/database/sqlcommon"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDBProvider(t *testing.T) {
+	crdb := &CockroachDB{}
+	dcb := &databasemocks.Callbacks{}
+	prefix := config.NewPluginConfig("unittest")
+	crdb.InitPrefix(prefix)
+	prefix.Set(sqlcommon.SQLConfDatasourceURL, "!bad connection")
+	err := crdb.Init(context.Background(), prefix, dcb)
+	assert.NoError(t, err)
+	_, err = crdb.GetMigrationDriver(crdb.DB())
+	assert.Error(t, err)
+
+	assert.Equal(t, "cockroachdb", crdb.Name())
+	assert.Equal(t, sq.Dollar, crdb.PlaceholderFormat())
+
+	insert := sq.Insert("test").Columns("col1").Values("val1")
+	insert, query := crdb.UpdateInsertForSequenceReturn(insert)
+	sql, _, err := insert.ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO test (col1) VALUES (?)  RETURNING seq", sql)
+	assert.True(t, query)
+
+	assert.Equal(t, "SELECT follower_read_timestamp()", crdb.HistoricalReadTimeQuery())
+	assert.Contains(t, crdb.CurrentTimeQuery(), "clock_timestamp()")
+}
+
+func TestCockroachDBHistoricalReadsDisabled(t *testing.T) {
+	crdb := &CockroachDB{}
+	prefix := config.NewPluginConfig("unittest")
+	crdb.InitPrefix(prefix)
+	prefix.Set(sqlcommon.SQLConfDatasourceURL, "!bad connection")
+	prefix.Set(CRDBConfAsOfSystemTime, "")
+	err := crdb.Init(context.Background(), prefix, &databasemocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Empty(t, crdb.HistoricalReadTimeQuery())
+}
+
+func TestCockroachDBIsRetryableError(t *testing.T) {
+	crdb := &CockroachDB{}
+	ctx := context.Background()
+	assert.True(t, crdb.IsRetryableError(&pq.Error{Code: "40001"}))
+	assert.True(t, crdb.IsRetryableError(i18n.WrapError(ctx, &pq.Error{Code: "40001"}, i18n.MsgDBCommitFailed)))
+	assert.False(t, crdb.IsRetryableError(&pq.Error{Code: "23505"}))
+	assert.False(t, crdb.IsRetryableError(fmt.Errorf("pop")))
+}
@@ -0,0 +1,35 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cockroachdb
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+const (
+	// CRDBConfAsOfSystemTime is the timestamp expression API list queries, and the count of their total rows, are read AS OF SYSTEM TIME, to avoid contending with writes. Empty disables historical reads
+	CRDBConfAsOfSystemTime = "asOfSystemTime"
+)
+
+const (
+	defaultAsOfSystemTime = "follower_read_timestamp()"
+)
+
+func (crdb *CockroachDB) InitPrefix(prefix config.Prefix) {
+	crdb.SQLCommon.InitPrefix(crdb, prefix)
+	prefix.AddKnownKey(CRDBConfAsOfSystemTime, defaultAsOfSystemTime)
+}
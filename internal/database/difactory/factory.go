@@ -24,24 +24,26 @@ import (
 	"github.com/hyperledger/firefly/pkg/database"
 )
 
-var pluginsByName = make(map[string]database.Plugin)
+var pluginsByName = make(map[string]func() database.Plugin)
 
 func init() {
-	for _, p := range plugins {
-		pluginsByName[p.Name()] = p
+	for _, factory := range pluginFactories {
+		pluginsByName[factory().Name()] = factory
 	}
 }
 
 func InitPrefix(prefix config.Prefix) {
-	for _, plugin := range plugins {
+	for _, factory := range pluginFactories {
+		plugin := factory()
 		plugin.InitPrefix(prefix.SubPrefix(plugin.Name()))
 	}
 }
 
+// GetPlugin returns a new instance of the database plugin, as a namespace with isolated plugins has its own database
 func GetPlugin(ctx context.Context, pluginType string) (database.Plugin, error) {
-	plugin, ok := pluginsByName[pluginType]
+	factory, ok := pluginsByName[pluginType]
 	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownDatabasePlugin, pluginType)
 	}
-	return plugin, nil
+	return factory(), nil
 }
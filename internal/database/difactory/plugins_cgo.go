@@ -20,12 +20,14 @@
 package difactory
 
 import (
+	"github.com/hyperledger/firefly/internal/database/cockroachdb"
 	"github.com/hyperledger/firefly/internal/database/postgres"
 	"github.com/hyperledger/firefly/internal/database/sqlite3"
 	"github.com/hyperledger/firefly/pkg/database"
 )
 
-var plugins = []database.Plugin{
-	&postgres.Postgres{},
-	&sqlite3.SQLite3{}, // wrapper to the SQLite 3 C library
+var pluginFactories = []func() database.Plugin{
+	func() database.Plugin { return &postgres.Postgres{} },
+	func() database.Plugin { return &cockroachdb.CockroachDB{} },
+	func() database.Plugin { return &sqlite3.SQLite3{} }, // wrapper to the SQLite 3 C library
 }
@@ -19,10 +19,12 @@
 package difactory
 
 import (
+	"github.com/hyperledger/firefly/internal/database/cockroachdb"
 	"github.com/hyperledger/firefly/internal/database/postgres"
 	"github.com/hyperledger/firefly/pkg/database"
 )
 
-var plugins = []database.Plugin{
-	&postgres.Postgres{},
+var pluginFactories = []func() database.Plugin{
+	func() database.Plugin { return &postgres.Postgres{} },
+	func() database.Plugin { return &cockroachdb.CockroachDB{} },
 }
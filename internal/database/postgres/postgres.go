@@ -64,3 +64,15 @@ func (psql *Postgres) Open(url string) (*sql.DB, error) {
 func (psql *Postgres) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
 	return postgres.WithInstance(db, &postgres.Config{})
 }
+
+func (psql *Postgres) IsRetryableError(err error) bool {
+	return false
+}
+
+func (psql *Postgres) HistoricalReadTimeQuery() string {
+	return ""
+}
+
+func (psql *Postgres) CurrentTimeQuery() string {
+	return "SELECT CAST(EXTRACT(EPOCH FROM clock_timestamp()) * 1000 AS BIGINT)"
+}
@@ -18,6 +18,7 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	sq "github.com/Masterminds/squirrel"
@@ -47,4 +48,8 @@ func TestPostgresProvider(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "INSERT INTO test (col1) VALUES (?)  RETURNING seq", sql)
 	assert.True(t, query)
+
+	assert.False(t, psql.IsRetryableError(fmt.Errorf("pop")))
+	assert.Empty(t, psql.HistoricalReadTimeQuery())
+	assert.Contains(t, psql.CurrentTimeQuery(), "clock_timestamp()")
 }
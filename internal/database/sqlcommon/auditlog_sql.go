@@ -0,0 +1,141 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	auditRecordColumns = []string{
+		"id",
+		"namespace",
+		"identity",
+		"remote",
+		"method",
+		"path",
+		"route",
+		"status",
+		"payload_hash",
+		"created",
+	}
+	auditRecordFilterFieldMap = map[string]string{
+		"payloadhash": "payload_hash",
+	}
+)
+
+func (s *SQLCommon) InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("auditlog").
+			Columns(auditRecordColumns...).
+			Values(
+				record.ID,
+				record.Namespace,
+				record.Identity,
+				record.Remote,
+				record.Method,
+				record.Path,
+				record.Route,
+				record.Status,
+				record.PayloadHash,
+				record.Created,
+			),
+		nil, // no change events for the audit log
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) auditRecordResult(ctx context.Context, row *sql.Rows) (*fftypes.AuditRecord, error) {
+	var record fftypes.AuditRecord
+	err := row.Scan(
+		&record.ID,
+		&record.Namespace,
+		&record.Identity,
+		&record.Remote,
+		&record.Method,
+		&record.Path,
+		&record.Route,
+		&record.Status,
+		&record.PayloadHash,
+		&record.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "auditlog")
+	}
+	return &record, nil
+}
+
+func (s *SQLCommon) GetAuditRecordByID(ctx context.Context, id *fftypes.UUID) (*fftypes.AuditRecord, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(auditRecordColumns...).
+			From("auditlog").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Audit record '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.auditRecordResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetAuditRecords(ctx context.Context, filter database.Filter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(auditRecordColumns...).From("auditlog"), filter, auditRecordFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	records := []*fftypes.AuditRecord{}
+	for rows.Next() {
+		record, err := s.auditRecordResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, s.queryRes(ctx, tx, "auditlog", fop, fi), err
+}
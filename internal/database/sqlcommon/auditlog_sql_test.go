@@ -0,0 +1,156 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Record an API call
+	record := &fftypes.AuditRecord{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Identity:    "CN=org1",
+		Remote:      "127.0.0.1:12345",
+		Method:      "POST",
+		Path:        "/api/v1/namespaces/ns1/messages/broadcast",
+		Route:       "postNewMessageBroadcast",
+		Status:      202,
+		PayloadHash: fftypes.NewRandB32(),
+		Created:     fftypes.Now(),
+	}
+	err := s.InsertAuditRecord(ctx, record)
+	assert.NoError(t, err)
+
+	// Check we get the exact same record back
+	recordRead, err := s.GetAuditRecordByID(ctx, record.ID)
+	assert.NoError(t, err)
+	recordJson, _ := json.Marshal(&record)
+	recordReadJson, _ := json.Marshal(&recordRead)
+	assert.Equal(t, string(recordJson), string(recordReadJson))
+
+	// Query back the record
+	fb := database.AuditRecordQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("identity", "CN=org1"),
+		fb.Eq("payloadhash", record.PayloadHash),
+		fb.Gte("status", 200),
+	)
+	records, res, err := s.GetAuditRecords(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	recordReadJson, _ = json.Marshal(records[0])
+	assert.Equal(t, string(recordJson), string(recordReadJson))
+
+	// Negative test on filter
+	records, _, err = s.GetAuditRecords(ctx, fb.And(fb.Eq("method", "DELETE")))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(records))
+}
+
+func TestInsertAuditRecordFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertAuditRecordFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertAuditRecordFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertAuditRecord(context.Background(), &fftypes.AuditRecord{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetAuditRecordByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	record, err := s.GetAuditRecordByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetAuditRecordByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAuditRecordsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetAuditRecordsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.AuditRecordQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetAuditRecords(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
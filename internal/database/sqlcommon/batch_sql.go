@@ -43,6 +43,8 @@ var (
 		"tx_type",
 		"tx_id",
 		"node_id",
+		"manifest",
+		"hash_algorithm",
 	}
 	batchFilterFieldMap = map[string]string{
 		"type":             "btype",
@@ -51,6 +53,7 @@ var (
 		"transaction.id":   "tx_id",
 		"group":            "group_hash",
 		"node":             "node_id",
+		"hashalgorithm":    "hash_algorithm",
 	}
 )
 
@@ -101,6 +104,8 @@ func (s *SQLCommon) UpsertBatch(ctx context.Context, batch *fftypes.Batch, allow
 				Set("tx_type", batch.Payload.TX.Type).
 				Set("tx_id", batch.Payload.TX.ID).
 				Set("node_id", batch.Node).
+				Set("manifest", batch.Manifest).
+				Set("hash_algorithm", batch.HashAlgorithm).
 				Where(sq.Eq{"id": batch.ID}),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionBatches, fftypes.ChangeEventTypeUpdated, batch.Namespace, batch.ID)
@@ -128,6 +133,8 @@ func (s *SQLCommon) UpsertBatch(ctx context.Context, batch *fftypes.Batch, allow
 					batch.Payload.TX.Type,
 					batch.Payload.TX.ID,
 					batch.Node,
+					batch.Manifest,
+					batch.HashAlgorithm,
 				),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionBatches, fftypes.ChangeEventTypeCreated, batch.Namespace, batch.ID)
@@ -157,6 +164,8 @@ func (s *SQLCommon) batchResult(ctx context.Context, row *sql.Rows) (*fftypes.Ba
 		&batch.Payload.TX.Type,
 		&batch.Payload.TX.ID,
 		&batch.Node,
+		&batch.Manifest,
+		&batch.HashAlgorithm,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "batches")
@@ -99,9 +99,11 @@ func TestBatch2EWithDB(t *testing.T) {
 				{Header: fftypes.MessageHeader{ID: msgID2}},
 			},
 		},
-		PayloadRef: payloadRef,
-		Confirmed:  fftypes.Now(),
+		PayloadRef:    payloadRef,
+		Confirmed:     fftypes.Now(),
+		HashAlgorithm: fftypes.HashAlgorithmSHA256,
 	}
+	batchUpdated.Manifest = batchUpdated.Payload.Manifest()
 
 	// Rejects hash change
 	err = s.UpsertBatch(context.Background(), batchUpdated, false)
@@ -125,6 +127,7 @@ func TestBatch2EWithDB(t *testing.T) {
 		fb.Eq("author", batchUpdated.Author),
 		fb.Gt("created", "0"),
 		fb.Gt("confirmed", "0"),
+		fb.Eq("hashalgorithm", fftypes.HashAlgorithmSHA256),
 	)
 	batches, _, err := s.GetBatches(ctx, filter)
 	assert.NoError(t, err)
@@ -0,0 +1,192 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	batchQuarantineColumns = []string{
+		"id",
+		"namespace",
+		"author",
+		"key",
+		"peer",
+		"payload_ref",
+		"hash",
+		"reason",
+		"awaiting_identity",
+		"size",
+		"messages",
+		"batch",
+		"status",
+		"created",
+		"decided",
+		"decided_by",
+		"comment",
+	}
+	batchQuarantineFilterFieldMap = map[string]string{
+		"payloadref":       "payload_ref",
+		"awaitingidentity": "awaiting_identity",
+		"decidedby":        "decided_by",
+	}
+)
+
+func (s *SQLCommon) InsertBatchQuarantine(ctx context.Context, quarantine *fftypes.BatchQuarantine) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	batchBytes, _ := json.Marshal(quarantine.Batch)
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("batchquarantine").
+			Columns(batchQuarantineColumns...).
+			Values(
+				quarantine.ID,
+				quarantine.Namespace,
+				quarantine.Author,
+				quarantine.Key,
+				quarantine.Peer,
+				quarantine.PayloadRef,
+				quarantine.Hash,
+				quarantine.Reason,
+				quarantine.AwaitingIdentity,
+				quarantine.Size,
+				quarantine.Messages,
+				batchBytes,
+				quarantine.Status,
+				quarantine.Created,
+				quarantine.Decided,
+				quarantine.DecidedBy,
+				quarantine.Comment,
+			),
+		nil, // no change events for batch quarantines
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) batchQuarantineResult(ctx context.Context, row *sql.Rows) (*fftypes.BatchQuarantine, error) {
+	var quarantine fftypes.BatchQuarantine
+	var batchBytes []byte
+	err := row.Scan(
+		&quarantine.ID,
+		&quarantine.Namespace,
+		&quarantine.Author,
+		&quarantine.Key,
+		&quarantine.Peer,
+		&quarantine.PayloadRef,
+		&quarantine.Hash,
+		&quarantine.Reason,
+		&quarantine.AwaitingIdentity,
+		&quarantine.Size,
+		&quarantine.Messages,
+		&batchBytes,
+		&quarantine.Status,
+		&quarantine.Created,
+		&quarantine.Decided,
+		&quarantine.DecidedBy,
+		&quarantine.Comment,
+	)
+	if err == nil {
+		err = json.Unmarshal(batchBytes, &quarantine.Batch)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "batchquarantine")
+	}
+	return &quarantine, nil
+}
+
+func (s *SQLCommon) GetBatchQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BatchQuarantine, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(batchQuarantineColumns...).
+			From("batchquarantine").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Batch quarantine '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.batchQuarantineResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetBatchQuarantines(ctx context.Context, filter database.Filter) ([]*fftypes.BatchQuarantine, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(batchQuarantineColumns...).From("batchquarantine"), filter, batchQuarantineFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	quarantines := []*fftypes.BatchQuarantine{}
+	for rows.Next() {
+		quarantine, err := s.batchQuarantineResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		quarantines = append(quarantines, quarantine)
+	}
+
+	return quarantines, s.queryRes(ctx, tx, "batchquarantine", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateBatchQuarantine(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("batchquarantine"), update, batchQuarantineFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for batch quarantines */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
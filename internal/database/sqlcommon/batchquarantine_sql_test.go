@@ -0,0 +1,222 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchQuarantineE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new batch quarantine entry
+	batchID := fftypes.NewUUID()
+	quarantine := &fftypes.BatchQuarantine{
+		ID:               batchID,
+		Namespace:        "ns1",
+		Author:           "did:firefly:org/org1",
+		Key:              "0x12345",
+		Peer:             "peer1",
+		Reason:           "batch contains 3 messages, exceeding the limit of 2",
+		AwaitingIdentity: "0x12345",
+		Size:             12345,
+		Messages:         3,
+		Batch: &fftypes.Batch{
+			ID:        batchID,
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: "did:firefly:org/org1",
+				Key:    "0x12345",
+			},
+		},
+		Status:  fftypes.PolicyApprovalStatusPending,
+		Created: fftypes.Now(),
+	}
+	err := s.InsertBatchQuarantine(ctx, quarantine)
+	assert.NoError(t, err)
+
+	// Check we get the exact same quarantine back
+	quarantineRead, err := s.GetBatchQuarantineByID(ctx, quarantine.ID)
+	assert.NoError(t, err)
+	quarantineJson, _ := json.Marshal(&quarantine)
+	quarantineReadJson, _ := json.Marshal(&quarantineRead)
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Query back the quarantine
+	fb := database.BatchQuarantineQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("peer", "peer1"),
+		fb.Gt("messages", 2),
+		fb.Eq("awaitingidentity", "0x12345"),
+		fb.Eq("status", fftypes.PolicyApprovalStatusPending),
+	)
+	quarantines, res, err := s.GetBatchQuarantines(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(quarantines))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	quarantineReadJson, _ = json.Marshal(quarantines[0])
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Decide the quarantine
+	quarantine.Status = fftypes.PolicyApprovalStatusApproved
+	quarantine.Decided = fftypes.Now()
+	quarantine.DecidedBy = "admin2"
+	quarantine.Comment = "known large batch"
+	up := database.BatchQuarantineQueryFactory.NewUpdate(ctx).
+		Set("status", quarantine.Status).
+		Set("decided", quarantine.Decided).
+		Set("decidedby", quarantine.DecidedBy).
+		Set("comment", quarantine.Comment)
+	err = s.UpdateBatchQuarantine(ctx, quarantine.ID, up)
+	assert.NoError(t, err)
+
+	quarantineRead, err = s.GetBatchQuarantineByID(ctx, quarantine.ID)
+	assert.NoError(t, err)
+	quarantineJson, _ = json.Marshal(&quarantine)
+	quarantineReadJson, _ = json.Marshal(&quarantineRead)
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Negative test on filter
+	quarantines, _, err = s.GetBatchQuarantines(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(quarantines))
+}
+
+func TestInsertBatchQuarantineFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBatchQuarantine(context.Background(), &fftypes.BatchQuarantine{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBatchQuarantineFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertBatchQuarantine(context.Background(), &fftypes.BatchQuarantine{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBatchQuarantineFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBatchQuarantine(context.Background(), &fftypes.BatchQuarantine{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatchQuarantineByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetBatchQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatchQuarantineByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	quarantine, err := s.GetBatchQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, quarantine)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatchQuarantineByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetBatchQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatchQuarantinesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.BatchQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBatchQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBatchQuarantinesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.BatchQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetBatchQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetBatchQuarantinesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.BatchQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBatchQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchQuarantineUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.BatchQuarantineQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdateBatchQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestBatchQuarantineUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.BatchQuarantineQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateBatchQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestBatchQuarantineUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.BatchQuarantineQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdateBatchQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
+
+func TestGetBatchQuarantineByIDBadBatch(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(batchQuarantineColumns).
+		AddRow(fftypes.NewUUID().String(), "ns1", "", "", "", "", nil, "", "", 0, 0, []byte("!json"), "pending", nil, nil, "", ""))
+	_, err := s.GetBatchQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -32,6 +32,7 @@ var (
 		"hash",
 		"payload_ref",
 		"peer",
+		"refs",
 		"created",
 	}
 	blobFilterFieldMap = map[string]string{
@@ -53,6 +54,7 @@ func (s *SQLCommon) InsertBlob(ctx context.Context, blob *fftypes.Blob) (err err
 				blob.Hash,
 				blob.PayloadRef,
 				blob.Peer,
+				blob.Refs,
 				blob.Created,
 			),
 		nil, // no change events for blobs
@@ -71,6 +73,7 @@ func (s *SQLCommon) blobResult(ctx context.Context, row *sql.Rows) (*fftypes.Blo
 		&blob.Hash,
 		&blob.PayloadRef,
 		&blob.Peer,
+		&blob.Refs,
 		&blob.Created,
 		&blob.Sequence,
 	)
@@ -158,3 +161,41 @@ func (s *SQLCommon) DeleteBlob(ctx context.Context, sequence int64) (err error)
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+func (s *SQLCommon) UpdateBlobRefs(ctx context.Context, sequence int64, delta int64) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	_, err = s.updateTx(ctx, tx, sq.Update("blobs").
+		Set("refs", sq.Expr("refs + ?", delta)).
+		Where(sq.Eq{
+			sequenceColumn: sequence,
+		}), nil /* no change events for blobs */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) GetBlobStats(ctx context.Context) (stats *fftypes.BlobStats, err error) {
+
+	rows, _, err := s.query(ctx, sq.Select("COUNT(*)", "COALESCE(SUM(refs), 0)").From("blobs"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats = &fftypes.BlobStats{}
+	if rows.Next() {
+		if err = rows.Scan(&stats.Blobs, &stats.References); err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "blobs")
+		}
+	}
+	stats.Deduplicated = stats.References - stats.Blobs
+	return stats, nil
+}
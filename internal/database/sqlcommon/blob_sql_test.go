@@ -41,6 +41,7 @@ func TestBlobsE2EWithDB(t *testing.T) {
 		Hash:       fftypes.NewRandB32(),
 		PayloadRef: fftypes.NewRandB32().String(),
 		Peer:       "peer1",
+		Refs:       1,
 		Created:    fftypes.Now(),
 	}
 	err := s.InsertBlob(ctx, blob)
@@ -69,6 +70,16 @@ func TestBlobsE2EWithDB(t *testing.T) {
 	assert.Equal(t, string(blobJson), string(blobReadJson))
 	assert.Equal(t, blob.Sequence, blobRes[0].Sequence)
 
+	// Add a reference, and check the stats
+	err = s.UpdateBlobRefs(ctx, blob.Sequence, 2)
+	assert.NoError(t, err)
+	blobRead, err = s.GetBlobMatchingHash(ctx, blob.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), blobRead.Refs)
+	stats, err := s.GetBlobStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &fftypes.BlobStats{Blobs: 1, References: 3, Deduplicated: 2}, stats)
+
 	// Test delete
 	err = s.DeleteBlob(ctx, blob.Sequence)
 	assert.NoError(t, err)
@@ -171,3 +182,35 @@ func TestBlobDeleteFail(t *testing.T) {
 	err := s.DeleteBlob(context.Background(), 12345)
 	assert.Regexp(t, "FF10118", err)
 }
+
+func TestUpdateBlobRefsBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpdateBlobRefs(context.Background(), 12345, 1)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestUpdateBlobRefsFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpdateBlobRefs(context.Background(), 12345, 1)
+	assert.Regexp(t, "FF10117", err)
+}
+
+func TestGetBlobStatsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetBlobStats(context.Background())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlobStatsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("only one"))
+	_, err := s.GetBlobStats(context.Background())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
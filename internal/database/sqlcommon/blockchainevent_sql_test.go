@@ -0,0 +1,191 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBlockchainEventE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new blockchain event entry
+	eventID := fftypes.NewUUID()
+	event := &fftypes.BlockchainEvent{
+		ID:           eventID,
+		Source:       "ethereum",
+		Namespace:    "ns1",
+		Name:         "BatchPin",
+		ProtocolID:   "000000038011/000001/000050",
+		Address:      "0x12345",
+		BlockNumber:  38011,
+		ProtocolTxID: "0x0c50dff0893e795293189d9cc5ba0d63c4020d8758ace4a69d02c9d6d43cb695",
+		Listener:     "sb-1",
+		Output:       fftypes.JSONObject{"some": "output"},
+		Info:         fftypes.JSONObject{"some": "info"},
+		Created:      fftypes.Now(),
+	}
+
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionBlockchainEvents, fftypes.ChangeEventTypeCreated, "ns1", eventID, mock.Anything).Return()
+
+	err := s.InsertBlockchainEvent(ctx, event)
+	assert.NoError(t, err)
+
+	// Check we get the exact same event back
+	eventRead, err := s.GetBlockchainEventByID(ctx, eventID)
+	assert.NoError(t, err)
+	assert.NotNil(t, eventRead)
+	assert.Equal(t, event.Sequence, eventRead.Sequence)
+	eventJson, _ := json.Marshal(&event)
+	eventReadJson, _ := json.Marshal(&eventRead)
+	assert.Equal(t, string(eventJson), string(eventReadJson))
+
+	// Redelivery of the same event is ignored
+	duplicate := *event
+	duplicate.ID = fftypes.NewUUID()
+	err = s.InsertBlockchainEvent(ctx, &duplicate)
+	assert.NoError(t, err)
+
+	// Query back the event
+	fb := database.BlockchainEventQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("name", "BatchPin"),
+		fb.Eq("address", "0x12345"),
+		fb.Gte("blocknumber", 38000),
+		fb.Eq("protocoltxid", event.ProtocolTxID),
+		fb.Eq("listener", "sb-1"),
+	)
+	events, res, err := s.GetBlockchainEvents(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	eventReadJson, _ = json.Marshal(events[0])
+	assert.Equal(t, string(eventJson), string(eventReadJson))
+
+	// Negative test on filter
+	filter = fb.And(
+		fb.Eq("name", "BatchPin"),
+		fb.Lt("blocknumber", 38000),
+	)
+	events, _, err = s.GetBlockchainEvents(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(events))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestInsertBlockchainEventFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlockchainEvent(context.Background(), &fftypes.BlockchainEvent{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlockchainEventFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertBlockchainEvent(context.Background(), &fftypes.BlockchainEvent{})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlockchainEventFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertBlockchainEvent(context.Background(), &fftypes.BlockchainEvent{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlockchainEventFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlockchainEvent(context.Background(), &fftypes.BlockchainEvent{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockchainEventByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetBlockchainEventByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockchainEventByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	event, err := s.GetBlockchainEventByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockchainEventByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetBlockchainEventByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockchainEventsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.BlockchainEventQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBlockchainEvents(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockchainEventsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.BlockchainEventQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetBlockchainEvents(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetBlockchainEventsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.BlockchainEventQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBlockchainEvents(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
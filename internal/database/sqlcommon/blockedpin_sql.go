@@ -0,0 +1,184 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	blockedPinColumns = []string{
+		"id",
+		"namespace",
+		"payload_ref",
+		"hash",
+		"signer",
+		"timestamp",
+		"attempts",
+		"last_error",
+		"next_attempt",
+		"created",
+	}
+	blockedPinFilterFieldMap = map[string]string{
+		"payloadref":  "payload_ref",
+		"key":         "signer",
+		"lasterror":   "last_error",
+		"nextattempt": "next_attempt",
+	}
+)
+
+func (s *SQLCommon) InsertBlockedPin(ctx context.Context, blocked *fftypes.BlockedPin) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("blockedpins").
+			Columns(blockedPinColumns...).
+			Values(
+				blocked.ID,
+				blocked.Namespace,
+				blocked.PayloadRef,
+				blocked.Hash,
+				blocked.Key,
+				blocked.Timestamp,
+				blocked.Attempts,
+				blocked.LastError,
+				blocked.NextAttempt,
+				blocked.Created,
+			),
+		nil, // no change events for blocked pins
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) blockedPinResult(ctx context.Context, row *sql.Rows) (*fftypes.BlockedPin, error) {
+	var blocked fftypes.BlockedPin
+	err := row.Scan(
+		&blocked.ID,
+		&blocked.Namespace,
+		&blocked.PayloadRef,
+		&blocked.Hash,
+		&blocked.Key,
+		&blocked.Timestamp,
+		&blocked.Attempts,
+		&blocked.LastError,
+		&blocked.NextAttempt,
+		&blocked.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "blockedpins")
+	}
+	return &blocked, nil
+}
+
+func (s *SQLCommon) GetBlockedPinByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockedPin, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(blockedPinColumns...).
+			From("blockedpins").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Blocked pin '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.blockedPinResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetBlockedPins(ctx context.Context, filter database.Filter) ([]*fftypes.BlockedPin, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(blockedPinColumns...).From("blockedpins"), filter, blockedPinFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	blockedPins := []*fftypes.BlockedPin{}
+	for rows.Next() {
+		blocked, err := s.blockedPinResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		blockedPins = append(blockedPins, blocked)
+	}
+
+	return blockedPins, s.queryRes(ctx, tx, "blockedpins", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateBlockedPin(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("blockedpins"), update, blockedPinFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for blocked pins */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) DeleteBlockedPin(ctx context.Context, id *fftypes.UUID) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	err = s.deleteTx(ctx, tx, sq.Delete("blockedpins").Where(sq.Eq{
+		"id": id,
+	}), nil /* no change events for blocked pins */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
@@ -0,0 +1,222 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockedPinE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new blocked pin entry
+	blocked := &fftypes.BlockedPin{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		PayloadRef:  "Qm12345",
+		Hash:        fftypes.NewRandB32(),
+		Key:         "0x12345",
+		Timestamp:   fftypes.Now(),
+		Attempts:    1,
+		LastError:   "pop",
+		NextAttempt: fftypes.Now(),
+		Created:     fftypes.Now(),
+	}
+	err := s.InsertBlockedPin(ctx, blocked)
+	assert.NoError(t, err)
+
+	// Check we get the exact same blocked pin back
+	blockedRead, err := s.GetBlockedPinByID(ctx, blocked.ID)
+	assert.NoError(t, err)
+	blockedJson, _ := json.Marshal(&blocked)
+	blockedReadJson, _ := json.Marshal(&blockedRead)
+	assert.Equal(t, string(blockedJson), string(blockedReadJson))
+
+	// Query back the blocked pin
+	fb := database.BlockedPinQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("payloadref", "Qm12345"),
+		fb.Eq("attempts", 1),
+	)
+	blockedPins, res, err := s.GetBlockedPins(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(blockedPins))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	blockedReadJson, _ = json.Marshal(blockedPins[0])
+	assert.Equal(t, string(blockedJson), string(blockedReadJson))
+
+	// Back off the blocked pin
+	blocked.Attempts = 2
+	blocked.LastError = "pop again"
+	blocked.NextAttempt = fftypes.Now()
+	up := database.BlockedPinQueryFactory.NewUpdate(ctx).
+		Set("attempts", blocked.Attempts).
+		Set("lasterror", blocked.LastError).
+		Set("nextattempt", blocked.NextAttempt)
+	err = s.UpdateBlockedPin(ctx, blocked.ID, up)
+	assert.NoError(t, err)
+
+	blockedRead, err = s.GetBlockedPinByID(ctx, blocked.ID)
+	assert.NoError(t, err)
+	blockedJson, _ = json.Marshal(&blocked)
+	blockedReadJson, _ = json.Marshal(&blockedRead)
+	assert.Equal(t, string(blockedJson), string(blockedReadJson))
+
+	// Negative test on filter
+	blockedPins, _, err = s.GetBlockedPins(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(blockedPins))
+
+	// Delete the blocked pin
+	err = s.DeleteBlockedPin(ctx, blocked.ID)
+	assert.NoError(t, err)
+	blockedRead, err = s.GetBlockedPinByID(ctx, blocked.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, blockedRead)
+}
+
+func TestInsertBlockedPinFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlockedPin(context.Background(), &fftypes.BlockedPin{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlockedPinFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertBlockedPin(context.Background(), &fftypes.BlockedPin{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlockedPinFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlockedPin(context.Background(), &fftypes.BlockedPin{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockedPinByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetBlockedPinByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockedPinByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	blocked, err := s.GetBlockedPinByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, blocked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockedPinByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetBlockedPinByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockedPinsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.BlockedPinQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBlockedPins(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlockedPinsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.BlockedPinQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetBlockedPins(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetBlockedPinsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.BlockedPinQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetBlockedPins(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockedPinUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.BlockedPinQueryFactory.NewUpdate(context.Background()).Set("nextattempt", fftypes.Now())
+	err := s.UpdateBlockedPin(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestBlockedPinUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.BlockedPinQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateBlockedPin(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestBlockedPinUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.BlockedPinQueryFactory.NewUpdate(context.Background()).Set("nextattempt", fftypes.Now())
+	err := s.UpdateBlockedPin(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
+
+func TestBlockedPinDeleteBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.DeleteBlockedPin(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestBlockedPinDeleteFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.DeleteBlockedPin(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10118", err)
+}
@@ -59,6 +59,13 @@ func (s *SQLCommon) getTableNameFromCollection(ctx context.Context, collection d
 	}
 }
 
+var chartTypeColumns = map[string]string{
+	"messages":     "mtype",
+	"transactions": "ttype",
+	"operations":   "optype",
+	"events":       "etype",
+}
+
 func (s *SQLCommon) histogramResult(ctx context.Context, rows *sql.Rows, cols []*fftypes.ChartHistogram) ([]*fftypes.ChartHistogram, error) {
 	results := []interface{}{}
 
@@ -104,3 +111,177 @@ func (s *SQLCommon) GetChartHistogram(ctx context.Context, ns string, intervals
 
 	return s.histogramResult(ctx, rows, histogram)
 }
+
+func (s *SQLCommon) typeHistogramResult(ctx context.Context, rows *sql.Rows, histogram []*fftypes.ChartHistogramTyped) error {
+	var typeName string
+	counts := make([]int64, len(histogram))
+	results := []interface{}{&typeName}
+	for i := range counts {
+		results = append(results, &counts[i])
+	}
+	err := rows.Scan(results...)
+	if err != nil {
+		return i18n.NewError(ctx, i18n.MsgDBReadErr, "histogram")
+	}
+	for i, count := range counts {
+		if count > 0 {
+			histogram[i].Count += count
+			histogram[i].Types = append(histogram[i].Types, &fftypes.ChartHistogramTypeCount{
+				Type:  typeName,
+				Count: count,
+			})
+		}
+	}
+	return nil
+}
+
+func (s *SQLCommon) GetChartTypeHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection database.CollectionName) (histogram []*fftypes.ChartHistogramTyped, err error) {
+	tableName, err := s.getTableNameFromCollection(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if len(intervals) == 0 {
+		return []*fftypes.ChartHistogramTyped{}, nil
+	}
+
+	typeColumn := chartTypeColumns[tableName]
+	qb := sq.Select(typeColumn)
+
+	for i, caseQuery := range s.getCaseQueries(ns, intervals) {
+		query, args, _ := caseQuery.ToSql()
+
+		histogram = append(histogram, &fftypes.ChartHistogramTyped{
+			Timestamp: intervals[i].StartTime,
+			Types:     []*fftypes.ChartHistogramTypeCount{},
+		})
+
+		qb = qb.Column(sq.Alias(sq.Expr("SUM("+query+")", args...), fmt.Sprintf("case_%d", i)))
+	}
+
+	// Only scan the rows in the overall time range, and let the database group them by type
+	rows, _, err := s.query(ctx, qb.From(tableName).
+		Where(sq.And{
+			sq.Eq{"namespace": ns},
+			sq.GtOrEq{"created": intervals[0].StartTime},
+			sq.Lt{"created": intervals[len(intervals)-1].EndTime},
+		}).
+		GroupBy(typeColumn).
+		OrderBy(typeColumn))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = s.typeHistogramResult(ctx, rows, histogram); err != nil {
+			return nil, err
+		}
+	}
+
+	return histogram, nil
+}
+
+func (s *SQLCommon) getChartRanking(ctx context.Context, ns, column string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	where := sq.And{sq.Eq{"namespace": ns}}
+	if startTime != nil {
+		where = append(where, sq.GtOrEq{"created": startTime})
+	}
+	if endTime != nil {
+		where = append(where, sq.Lt{"created": endTime})
+	}
+
+	rows, _, err := s.query(ctx, sq.Select(column, "COUNT(*) AS total").
+		From("messages").
+		Where(where).
+		GroupBy(column).
+		OrderBy("total DESC", column).
+		Limit(uint64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ranking := []*fftypes.ChartRanking{}
+	for rows.Next() {
+		var r fftypes.ChartRanking
+		if err = rows.Scan(&r.Value, &r.Count); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgDBReadErr, "ranking")
+		}
+		ranking = append(ranking, &r)
+	}
+
+	return ranking, nil
+}
+
+// GetChartTopTopics ranks the topics of messages. A message with multiple topics is
+// counted once against its full list of topics.
+func (s *SQLCommon) GetChartTopTopics(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	return s.getChartRanking(ctx, ns, "topics", startTime, endTime, limit)
+}
+
+func (s *SQLCommon) GetChartTopAuthors(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	return s.getChartRanking(ctx, ns, "author", startTime, endTime, limit)
+}
+
+func usageConditions(prefix, ns string, startTime, endTime *fftypes.FFTime, conditions ...sq.Sqlizer) sq.And {
+	where := append(sq.And{sq.Eq{prefix + "namespace": ns}}, conditions...)
+	if startTime != nil {
+		where = append(where, sq.GtOrEq{prefix + "created": startTime})
+	}
+	if endTime != nil {
+		where = append(where, sq.Lt{prefix + "created": endTime})
+	}
+	return where
+}
+
+func (s *SQLCommon) usageTotal(ctx context.Context, q sq.SelectBuilder) (total int64, err error) {
+	rows, _, err := s.query(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err = rows.Scan(&total); err != nil {
+			return 0, i18n.NewError(ctx, i18n.MsgDBReadErr, "usage")
+		}
+	}
+	return total, nil
+}
+
+// GetIdentityUsage totals the messages (and the data they carry) sent by an author, and the token transfers
+// and contract invokes submitted with any of the supplied keys. A data item shared by multiple messages
+// is counted against each of them.
+func (s *SQLCommon) GetIdentityUsage(ctx context.Context, ns, author string, keys []string, startTime, endTime *fftypes.FFTime) (usage *fftypes.IdentityUsage, err error) {
+	usage = &fftypes.IdentityUsage{
+		Author:    author,
+		Keys:      keys,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+
+	msgWhere := usageConditions("m.", ns, startTime, endTime, sq.Eq{"m.author": author})
+	if usage.Messages, err = s.usageTotal(ctx, sq.Select("COUNT(*)").
+		From("messages AS m").
+		Where(msgWhere)); err != nil {
+		return nil, err
+	}
+	if usage.DataBytes, err = s.usageTotal(ctx, sq.Select("COALESCE(SUM(COALESCE(d.blob_size, 0) + LENGTH(d.value)), 0)").
+		From("messages AS m").
+		Join("messages_data AS md ON md.message_id = m.id").
+		Join("data AS d ON d.id = md.data_id").
+		Where(msgWhere)); err != nil {
+		return nil, err
+	}
+	if usage.TokenTransfers, err = s.usageTotal(ctx, sq.Select("COUNT(*)").
+		From("tokentransfer").
+		Where(usageConditions("", ns, startTime, endTime, sq.Eq{"key": keys}))); err != nil {
+		return nil, err
+	}
+	if usage.ContractInvokes, err = s.usageTotal(ctx, sq.Select("COUNT(*)").
+		From("transactions").
+		Where(usageConditions("", ns, startTime, endTime, sq.Eq{"ttype": fftypes.TransactionTypeContractInvoke, "signer": keys}))); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
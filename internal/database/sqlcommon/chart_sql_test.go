@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -107,3 +108,234 @@ func TestGetChartHistogramSuccess(t *testing.T) {
 	assert.Equal(t, expectedHistogramResult, histogram)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetChartTypeHistogramAndRankingsE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything, mock.Anything).Return()
+
+	newMsg := func(msgType fftypes.MessageType, author string, topics fftypes.FFNameArray, created int64) {
+		msg := &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        fftypes.NewUUID(),
+				Type:      msgType,
+				Namespace: "ns1",
+				Identity:  fftypes.Identity{Author: author, Key: "0x12345"},
+				Topics:    topics,
+				Created:   fftypes.UnixTime(created),
+				DataHash:  fftypes.NewRandB32(),
+			},
+			Hash: fftypes.NewRandB32(),
+		}
+		err := s.UpsertMessage(ctx, msg, database.UpsertOptimizationNew)
+		assert.NoError(t, err)
+	}
+	newMsg(fftypes.MessageTypeBroadcast, "org1", fftypes.FFNameArray{"topic1"}, 1000)
+	newMsg(fftypes.MessageTypeBroadcast, "org1", fftypes.FFNameArray{"topic1"}, 1001)
+	newMsg(fftypes.MessageTypePrivate, "org2", fftypes.FFNameArray{"topic2"}, 1001)
+	newMsg(fftypes.MessageTypePrivate, "org1", fftypes.FFNameArray{"topic1"}, 2000)
+
+	intervals := []fftypes.ChartHistogramInterval{
+		{StartTime: fftypes.UnixTime(1000), EndTime: fftypes.UnixTime(1001)},
+		{StartTime: fftypes.UnixTime(1001), EndTime: fftypes.UnixTime(1002)},
+	}
+	histogram, err := s.GetChartTypeHistogram(ctx, "ns1", intervals, database.CollectionName(database.CollectionMessages))
+	assert.NoError(t, err)
+	assert.Len(t, histogram, 2)
+	assert.Equal(t, int64(1), histogram[0].Count)
+	assert.Equal(t, []*fftypes.ChartHistogramTypeCount{
+		{Type: "broadcast", Count: 1},
+	}, histogram[0].Types)
+	assert.Equal(t, int64(2), histogram[1].Count)
+	assert.Equal(t, []*fftypes.ChartHistogramTypeCount{
+		{Type: "broadcast", Count: 1},
+		{Type: "private", Count: 1},
+	}, histogram[1].Types)
+
+	topics, err := s.GetChartTopTopics(ctx, "ns1", nil, nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.ChartRanking{
+		{Value: "topic1", Count: 3},
+		{Value: "topic2", Count: 1},
+	}, topics)
+
+	authors, err := s.GetChartTopAuthors(ctx, "ns1", fftypes.UnixTime(1001), fftypes.UnixTime(2000), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.ChartRanking{
+		{Value: "org1", Count: 1},
+	}, authors)
+}
+
+func TestGetChartTypeHistogramInvalidCollectionName(t *testing.T) {
+	s, _ := newMockProvider().init()
+	_, err := s.GetChartTypeHistogram(context.Background(), "ns1", mockHistogramInterval, database.CollectionName("abc"))
+	assert.Regexp(t, "FF10301", err)
+}
+
+func TestGetChartTypeHistogramNoIntervals(t *testing.T) {
+	s, _ := newMockProvider().init()
+	histogram, err := s.GetChartTypeHistogram(context.Background(), "ns1", []fftypes.ChartHistogramInterval{}, database.CollectionName("events"))
+	assert.NoError(t, err)
+	assert.Empty(t, histogram)
+}
+
+func TestGetChartTypeHistogramQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := s.GetChartTypeHistogram(context.Background(), "ns1", mockHistogramInterval, database.CollectionName("operations"))
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetChartTypeHistogramScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"ttype"}).AddRow("batch_pin"))
+
+	_, err := s.GetChartTypeHistogram(context.Background(), "ns1", mockHistogramInterval, database.CollectionName("transactions"))
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetChartTopTopicsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := s.GetChartTopTopics(context.Background(), "ns1", nil, nil, 10)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetChartTopAuthorsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"author"}).AddRow("org1"))
+
+	_, err := s.GetChartTopAuthors(context.Background(), "ns1", nil, nil, 10)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetIdentityUsageE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", mock.Anything, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", mock.Anything, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionEvent", mock.Anything, fftypes.ChangeEventTypeCreated, mock.Anything).Return()
+
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Hash:      fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+		Value:     fftypes.Byteable(`"hello"`),
+		Blob:      &fftypes.BlobRef{Hash: fftypes.NewRandB32(), Size: 100},
+	}
+	err := s.UpsertData(ctx, data, database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+
+	newMsg := func(author string, created int64, data fftypes.DataRefs) {
+		msg := &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        fftypes.NewUUID(),
+				Type:      fftypes.MessageTypeBroadcast,
+				Namespace: "ns1",
+				Identity:  fftypes.Identity{Author: author, Key: "0x12345"},
+				Created:   fftypes.UnixTime(created),
+				DataHash:  fftypes.NewRandB32(),
+			},
+			Hash: fftypes.NewRandB32(),
+			Data: data,
+		}
+		err := s.UpsertMessage(ctx, msg, database.UpsertOptimizationNew)
+		assert.NoError(t, err)
+	}
+	dataRefs := fftypes.DataRefs{{ID: data.ID, Hash: data.Hash}}
+	newMsg("org1", 1000, dataRefs)
+	newMsg("org1", 1001, dataRefs)
+	newMsg("org1", 2000, nil)
+	newMsg("org2", 1000, dataRefs)
+
+	err = s.UpsertTokenTransfer(ctx, &fftypes.TokenTransfer{
+		LocalID:    fftypes.NewUUID(),
+		Type:       fftypes.TokenTransferTypeTransfer,
+		Namespace:  "ns1",
+		Key:        "0x12345",
+		ProtocolID: "12345",
+	})
+	assert.NoError(t, err)
+
+	newTX := func(txType fftypes.TransactionType, signer string) {
+		err := s.UpsertTransaction(ctx, &fftypes.Transaction{
+			ID: fftypes.NewUUID(),
+			Subject: fftypes.TransactionSubject{
+				Namespace: "ns1",
+				Type:      txType,
+				Signer:    signer,
+			},
+			Hash:    fftypes.NewRandB32(),
+			Created: fftypes.Now(),
+		}, false)
+		assert.NoError(t, err)
+	}
+	newTX(fftypes.TransactionTypeContractInvoke, "0x12345")
+	newTX(fftypes.TransactionTypeContractInvoke, "0x23456")
+	newTX(fftypes.TransactionTypeBatchPin, "0x12345")
+
+	usage, err := s.GetIdentityUsage(ctx, "ns1", "org1", []string{"0x12345"}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), usage.Messages)
+	assert.Equal(t, int64(2*(100+len(`"hello"`))), usage.DataBytes)
+	assert.Equal(t, int64(1), usage.TokenTransfers)
+	assert.Equal(t, int64(1), usage.ContractInvokes)
+
+	usage, err = s.GetIdentityUsage(ctx, "ns1", "org1", []string{}, fftypes.UnixTime(1001), fftypes.UnixTime(2000))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), usage.Messages)
+	assert.Equal(t, int64(100+len(`"hello"`)), usage.DataBytes)
+	assert.Equal(t, int64(0), usage.TokenTransfers)
+	assert.Equal(t, int64(0), usage.ContractInvokes)
+}
+
+func TestGetIdentityUsageMessagesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := s.GetIdentityUsage(context.Background(), "ns1", "org1", []string{"0x12345"}, nil, nil)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetIdentityUsageDataScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow("abc"))
+
+	_, err := s.GetIdentityUsage(context.Background(), "ns1", "org1", []string{"0x12345"}, nil, nil)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetIdentityUsageTransfersQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(10))
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := s.GetIdentityUsage(context.Background(), "ns1", "org1", []string{"0x12345"}, nil, nil)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetIdentityUsageInvokesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(10))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := s.GetIdentityUsage(context.Background(), "ns1", "org1", []string{"0x12345"}, nil, nil)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
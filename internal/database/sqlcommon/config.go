@@ -31,10 +31,22 @@ const (
 	SQLConfDatasourceURL = "url"
 	// SQLConfMaxConnections maximum connections to the database
 	SQLConfMaxConnections = "maxConns"
+	// SQLConfMaxIdleConnections maximum idle connections retained in the pool
+	SQLConfMaxIdleConnections = "maxIdleConns"
+	// SQLConfMaxConnectionLifetime maximum amount of time a connection can be reused before it is closed
+	SQLConfMaxConnectionLifetime = "maxConnLifetime"
+	// SQLConfMaxConnectionIdleTime maximum amount of time a connection can sit idle in the pool before it is closed
+	SQLConfMaxConnectionIdleTime = "maxConnIdleTime"
+	// SQLConfHealthCheckInterval how often the database is pinged to detect, and recover from, lost connectivity
+	SQLConfHealthCheckInterval = "healthCheckInterval"
+	// SQLConfTxRetryMaxAttempts maximum attempts for an idempotent group of operations that fails with an error the database reports as safe to retry
+	SQLConfTxRetryMaxAttempts = "txRetry.maxAttempts"
 )
 
 const (
 	defaultMigrationsDirectoryTemplate = "./db/migrations/%s"
+	defaultHealthCheckInterval         = "30s"
+	defaultTxRetryMaxAttempts          = 5
 )
 
 func (s *SQLCommon) InitPrefix(provider Provider, prefix config.Prefix) {
@@ -42,4 +54,9 @@ func (s *SQLCommon) InitPrefix(provider Provider, prefix config.Prefix) {
 	prefix.AddKnownKey(SQLConfDatasourceURL)
 	prefix.AddKnownKey(SQLConfMigrationsDirectory, fmt.Sprintf(defaultMigrationsDirectoryTemplate, provider.MigrationsDir()))
 	prefix.AddKnownKey(SQLConfMaxConnections) // some providers may set a default
+	prefix.AddKnownKey(SQLConfMaxIdleConnections)
+	prefix.AddKnownKey(SQLConfMaxConnectionLifetime)
+	prefix.AddKnownKey(SQLConfMaxConnectionIdleTime)
+	prefix.AddKnownKey(SQLConfHealthCheckInterval, defaultHealthCheckInterval)
+	prefix.AddKnownKey(SQLConfTxRetryMaxAttempts, defaultTxRetryMaxAttempts)
 }
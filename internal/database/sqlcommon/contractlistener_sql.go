@@ -0,0 +1,146 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	contractListenerColumns = []string{
+		"id",
+		"interface_id",
+		"namespace",
+		"name",
+		"protocol_id",
+		"location",
+		"event",
+		"created",
+	}
+	contractListenerFilterFieldMap = map[string]string{
+		"interface":  "interface_id",
+		"protocolid": "protocol_id",
+	}
+)
+
+func (s *SQLCommon) InsertContractListener(ctx context.Context, listener *fftypes.ContractListener) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("contractlisteners").
+			Columns(contractListenerColumns...).
+			Values(
+				listener.ID,
+				listener.Interface,
+				listener.Namespace,
+				listener.Name,
+				listener.ProtocolID,
+				listener.Location,
+				listener.Event,
+				listener.Created,
+			),
+		func() {
+			s.callbacks.UUIDCollectionNSEvent(database.CollectionContractListeners, fftypes.ChangeEventTypeCreated, listener.Namespace, listener.ID)
+		},
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) contractListenerResult(ctx context.Context, row *sql.Rows) (*fftypes.ContractListener, error) {
+	var listener fftypes.ContractListener
+	err := row.Scan(
+		&listener.ID,
+		&listener.Interface,
+		&listener.Namespace,
+		&listener.Name,
+		&listener.ProtocolID,
+		&listener.Location,
+		&listener.Event,
+		&listener.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "contractlisteners")
+	}
+	return &listener, nil
+}
+
+func (s *SQLCommon) getContractListenerPred(ctx context.Context, desc string, pred interface{}) (*fftypes.ContractListener, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(contractListenerColumns...).
+			From("contractlisteners").
+			Where(pred),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Contract listener '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.contractListenerResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetContractListenerByID(ctx context.Context, id *fftypes.UUID) (*fftypes.ContractListener, error) {
+	return s.getContractListenerPred(ctx, id.String(), sq.Eq{"id": id})
+}
+
+func (s *SQLCommon) GetContractListenerByProtocolID(ctx context.Context, protocolID string) (*fftypes.ContractListener, error) {
+	return s.getContractListenerPred(ctx, protocolID, sq.Eq{"protocol_id": protocolID})
+}
+
+func (s *SQLCommon) GetContractListeners(ctx context.Context, filter database.Filter) ([]*fftypes.ContractListener, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(contractListenerColumns...).From("contractlisteners"), filter, contractListenerFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	listeners := []*fftypes.ContractListener{}
+	for rows.Next() {
+		listener, err := s.contractListenerResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, s.queryRes(ctx, tx, "contractlisteners", fop, fi), err
+
+}
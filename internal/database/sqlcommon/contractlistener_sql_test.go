@@ -0,0 +1,166 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContractListenerE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new contract listener
+	listenerID := fftypes.NewUUID()
+	listener := &fftypes.ContractListener{
+		ID:         listenerID,
+		Interface:  fftypes.NewUUID(),
+		Namespace:  "ns1",
+		Name:       "changes",
+		ProtocolID: "sb-12345",
+		Location:   fftypes.JSONObject{"address": "0x12345"},
+		Event: &fftypes.FFIEvent{
+			Name: "Changed",
+			Params: fftypes.FFIParams{
+				{Name: "x", Type: fftypes.FFIParamTypeInteger},
+			},
+		},
+		Created: fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionContractListeners, fftypes.ChangeEventTypeCreated, "ns1", listenerID, mock.Anything).Return()
+
+	err := s.InsertContractListener(ctx, listener)
+	assert.NoError(t, err)
+
+	// Check we get the exact same listener back, by ID and by protocol ID
+	listenerRead, err := s.GetContractListenerByID(ctx, listenerID)
+	assert.NoError(t, err)
+	listenerJson, _ := json.Marshal(&listener)
+	listenerReadJson, _ := json.Marshal(&listenerRead)
+	assert.Equal(t, string(listenerJson), string(listenerReadJson))
+
+	listenerRead, err = s.GetContractListenerByProtocolID(ctx, "sb-12345")
+	assert.NoError(t, err)
+	listenerReadJson, _ = json.Marshal(&listenerRead)
+	assert.Equal(t, string(listenerJson), string(listenerReadJson))
+
+	// Query back the listener
+	fb := database.ContractListenerQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("interface", listener.Interface),
+		fb.Eq("protocolid", "sb-12345"),
+	)
+	listeners, res, err := s.GetContractListeners(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(listeners))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	listenerReadJson, _ = json.Marshal(listeners[0])
+	assert.Equal(t, string(listenerJson), string(listenerReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestInsertContractListenerFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertContractListener(context.Background(), &fftypes.ContractListener{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertContractListenerFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertContractListener(context.Background(), &fftypes.ContractListener{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertContractListenerFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertContractListener(context.Background(), &fftypes.ContractListener{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetContractListenerByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetContractListenerByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetContractListenerByProtocolIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	listener, err := s.GetContractListenerByProtocolID(context.Background(), "sb-12345")
+	assert.NoError(t, err)
+	assert.Nil(t, listener)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetContractListenerByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetContractListenerByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetContractListenersQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.ContractListenerQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetContractListeners(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetContractListenersBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.ContractListenerQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetContractListeners(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetContractListenersReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.ContractListenerQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetContractListeners(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
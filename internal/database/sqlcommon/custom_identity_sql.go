@@ -0,0 +1,184 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	customIdentityColumns = []string{
+		"id",
+		"namespace",
+		"name",
+		"parent",
+		"key",
+		"description",
+		"profile",
+		"claim",
+		"verification",
+		"created",
+		"verified",
+	}
+	customIdentityFilterFieldMap = map[string]string{}
+)
+
+func (s *SQLCommon) UpsertCustomIdentity(ctx context.Context, identity *fftypes.CustomIdentity) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Do a select within the transaction to detemine if the UUID already exists
+	rows, _, err := s.queryTx(ctx, tx,
+		sq.Select("id").
+			From("identities").
+			Where(sq.Eq{"id": identity.ID}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := rows.Next()
+	rows.Close()
+
+	if existing {
+		// The identity itself is fixed by the claim - only the progress of the claim and verification is updated
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("identities").
+				Set("claim", identity.Claim).
+				Set("verification", identity.Verification).
+				Set("verified", identity.Verified).
+				Where(sq.Eq{"id": identity.ID}),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionCustomIdentities, fftypes.ChangeEventTypeUpdated, identity.Namespace, identity.ID)
+			},
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("identities").
+				Columns(customIdentityColumns...).
+				Values(
+					identity.ID,
+					identity.Namespace,
+					identity.Name,
+					identity.Parent,
+					identity.Key,
+					identity.Description,
+					identity.Profile,
+					identity.Claim,
+					identity.Verification,
+					identity.Created,
+					identity.Verified,
+				),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionCustomIdentities, fftypes.ChangeEventTypeCreated, identity.Namespace, identity.ID)
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) customIdentityResult(ctx context.Context, row *sql.Rows) (*fftypes.CustomIdentity, error) {
+	var identity fftypes.CustomIdentity
+	err := row.Scan(
+		&identity.ID,
+		&identity.Namespace,
+		&identity.Name,
+		&identity.Parent,
+		&identity.Key,
+		&identity.Description,
+		&identity.Profile,
+		&identity.Claim,
+		&identity.Verification,
+		&identity.Created,
+		&identity.Verified,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "identities")
+	}
+	return &identity, nil
+}
+
+func (s *SQLCommon) getCustomIdentityPred(ctx context.Context, desc string, pred interface{}) (*fftypes.CustomIdentity, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(customIdentityColumns...).
+			From("identities").
+			Where(pred),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Identity '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.customIdentityResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetCustomIdentityByID(ctx context.Context, id *fftypes.UUID) (*fftypes.CustomIdentity, error) {
+	return s.getCustomIdentityPred(ctx, id.String(), sq.Eq{"id": id})
+}
+
+// GetCustomIdentityByKey only returns a verified identity, as competing claims to a key can be pending verification
+func (s *SQLCommon) GetCustomIdentityByKey(ctx context.Context, key string) (*fftypes.CustomIdentity, error) {
+	return s.getCustomIdentityPred(ctx, key, sq.And{sq.Eq{"key": key}, sq.NotEq{"verified": nil}})
+}
+
+// GetCustomIdentityByName only returns a verified identity, as competing claims to a name can be pending verification
+func (s *SQLCommon) GetCustomIdentityByName(ctx context.Context, ns, name string) (*fftypes.CustomIdentity, error) {
+	return s.getCustomIdentityPred(ctx, ns+":"+name, sq.And{sq.Eq{"namespace": ns}, sq.Eq{"name": name}, sq.NotEq{"verified": nil}})
+}
+
+func (s *SQLCommon) GetCustomIdentities(ctx context.Context, filter database.Filter) (identities []*fftypes.CustomIdentity, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(customIdentityColumns...).From("identities"), filter, customIdentityFilterFieldMap, []interface{}{"seq"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	identities = []*fftypes.CustomIdentity{}
+	for rows.Next() {
+		identity, err := s.customIdentityResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, s.queryRes(ctx, tx, "identities", fop, fi), err
+}
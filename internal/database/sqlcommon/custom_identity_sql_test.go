@@ -0,0 +1,211 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCustomIdentityE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new identity, from a claim that has not yet been verified
+	identity := &fftypes.CustomIdentity{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Name:        "identity1",
+		Parent:      "did:firefly:org/" + fftypes.NewUUID().String(),
+		Key:         "0x12345",
+		Description: "a custom identity",
+		Profile:     fftypes.JSONObject{"some": "info"},
+		Claim:       fftypes.NewUUID(),
+		Created:     fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionCustomIdentities, fftypes.ChangeEventTypeCreated, "ns1", identity.ID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionCustomIdentities, fftypes.ChangeEventTypeUpdated, "ns1", identity.ID, mock.Anything).Return()
+
+	err := s.UpsertCustomIdentity(ctx, identity)
+	assert.NoError(t, err)
+
+	// Check we get the exact same identity back, by ID, key and name
+	identityRead, err := s.GetCustomIdentityByID(ctx, identity.ID)
+	assert.NoError(t, err)
+	identityJson, _ := json.Marshal(&identity)
+	identityReadJson, _ := json.Marshal(&identityRead)
+	assert.Equal(t, string(identityJson), string(identityReadJson))
+
+	// An unverified claim does not own the key or name, so a competing claim can be stored
+	identityRead, err = s.GetCustomIdentityByKey(ctx, "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, identityRead)
+
+	identityRead, err = s.GetCustomIdentityByName(ctx, "ns1", "identity1")
+	assert.NoError(t, err)
+	assert.Nil(t, identityRead)
+
+	competing := *identity
+	competing.ID = fftypes.NewUUID()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionCustomIdentities, fftypes.ChangeEventTypeCreated, "ns1", competing.ID, mock.Anything).Return()
+	err = s.UpsertCustomIdentity(ctx, &competing)
+	assert.NoError(t, err)
+
+	// Verify it
+	identity.Verification = fftypes.NewUUID()
+	identity.Verified = fftypes.Now()
+	err = s.UpsertCustomIdentity(ctx, identity)
+	assert.NoError(t, err)
+	identityJson, _ = json.Marshal(&identity)
+
+	identityRead, err = s.GetCustomIdentityByKey(ctx, "0x12345")
+	assert.NoError(t, err)
+	identityReadJson, _ = json.Marshal(&identityRead)
+	assert.Equal(t, string(identityJson), string(identityReadJson))
+
+	identityRead, err = s.GetCustomIdentityByName(ctx, "ns1", "identity1")
+	assert.NoError(t, err)
+	identityReadJson, _ = json.Marshal(&identityRead)
+	assert.Equal(t, string(identityJson), string(identityReadJson))
+
+	// Query back the identity
+	fb := database.CustomIdentityQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("verification", identity.Verification),
+	)
+	identities, res, err := s.GetCustomIdentities(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(identities))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	identityReadJson, _ = json.Marshal(identities[0])
+	assert.Equal(t, string(identityJson), string(identityReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestUpsertCustomIdentityFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertCustomIdentity(context.Background(), &fftypes.CustomIdentity{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertCustomIdentityFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertCustomIdentity(context.Background(), &fftypes.CustomIdentity{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertCustomIdentityFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertCustomIdentity(context.Background(), &fftypes.CustomIdentity{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertCustomIdentityFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	identityID := fftypes.NewUUID()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(identityID.String()))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertCustomIdentity(context.Background(), &fftypes.CustomIdentity{ID: identityID})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertCustomIdentityFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertCustomIdentity(context.Background(), &fftypes.CustomIdentity{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomIdentityByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetCustomIdentityByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomIdentityByKeyNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	identity, err := s.GetCustomIdentityByKey(context.Background(), "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, identity)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomIdentityByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetCustomIdentityByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomIdentitiesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.CustomIdentityQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetCustomIdentities(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomIdentitiesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.CustomIdentityQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetCustomIdentities(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetCustomIdentitiesReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.CustomIdentityQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetCustomIdentities(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -38,6 +38,9 @@ var (
 		"created",
 		"blob_hash",
 		"blob_public",
+		"blob_size",
+		"hash_algorithm",
+		"transform",
 	}
 	dataColumnsWithValue = append(append([]string{}, dataColumnsNoValue...), "value")
 	dataFilterFieldMap   = map[string]string{
@@ -46,6 +49,9 @@ var (
 		"datatype.version": "datatype_version",
 		"blob.hash":        "blob_hash",
 		"blob.public":      "blob_public",
+		"blob.size":        "blob_size",
+		"hashalgorithm":    "hash_algorithm",
+		"transform":        "transform",
 	}
 )
 
@@ -60,6 +66,9 @@ func (s *SQLCommon) attemptDataUpdate(ctx context.Context, tx *txWrapper, data *
 			Set("created", data.Created).
 			Set("blob_hash", blob.Hash).
 			Set("blob_public", blob.Public).
+			Set("blob_size", blob.Size).
+			Set("hash_algorithm", data.HashAlgorithm).
+			Set("transform", data.Transform).
 			Set("value", data.Value).
 			Where(sq.Eq{
 				"id":   data.ID,
@@ -84,6 +93,9 @@ func (s *SQLCommon) attemptDataInsert(ctx context.Context, tx *txWrapper, data *
 				data.Created,
 				blob.Hash,
 				blob.Public,
+				blob.Size,
+				data.HashAlgorithm,
+				data.Transform,
 				data.Value,
 			),
 		func() {
@@ -173,6 +185,9 @@ func (s *SQLCommon) dataResult(ctx context.Context, row *sql.Rows, withValue boo
 		&data.Created,
 		&data.Blob.Hash,
 		&data.Blob.Public,
+		&data.Blob.Size,
+		&data.HashAlgorithm,
+		&data.Transform,
 	}
 	if withValue {
 		results = append(results, &data.Value)
@@ -96,7 +96,10 @@ func TestDataE2EWithDB(t *testing.T) {
 		Blob: &fftypes.BlobRef{
 			Hash:   fftypes.NewRandB32(),
 			Public: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+			Size:   12345,
 		},
+		HashAlgorithm: fftypes.HashAlgorithmSHA256,
+		Transform:     "pii",
 	}
 
 	// Check disallows hash update, regardless of optimization
@@ -129,6 +132,8 @@ func TestDataE2EWithDB(t *testing.T) {
 		fb.Eq("datatype.name", dataUpdated.Datatype.Name),
 		fb.Eq("datatype.version", dataUpdated.Datatype.Version),
 		fb.Eq("hash", dataUpdated.Hash),
+		fb.Eq("hashalgorithm", fftypes.HashAlgorithmSHA256),
+		fb.Eq("transform", "pii"),
 		fb.Gt("created", 0),
 	)
 	dataRes, _, err := s.GetData(ctx, filter)
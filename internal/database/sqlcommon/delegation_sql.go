@@ -0,0 +1,163 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	delegationColumns = []string{
+		"id",
+		"message_id",
+		"namespace",
+		"delegator",
+		"delegate",
+		"created",
+		"revoked",
+	}
+	delegationFilterFieldMap = map[string]string{
+		"message": "message_id",
+	}
+)
+
+func (s *SQLCommon) UpsertDelegation(ctx context.Context, delegation *fftypes.Delegation) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Do a select within the transaction to detemine if the UUID already exists
+	rows, _, err := s.queryTx(ctx, tx,
+		sq.Select("id").
+			From("delegations").
+			Where(sq.Eq{"id": delegation.ID}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := rows.Next()
+	rows.Close()
+
+	if existing {
+		// The delegator and delegate of a delegation cannot change, only its revocation
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("delegations").
+				Set("message_id", delegation.Message).
+				Set("revoked", delegation.Revoked).
+				Where(sq.Eq{"id": delegation.ID}),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionDelegations, fftypes.ChangeEventTypeUpdated, delegation.Namespace, delegation.ID)
+			},
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("delegations").
+				Columns(delegationColumns...).
+				Values(
+					delegation.ID,
+					delegation.Message,
+					delegation.Namespace,
+					delegation.Delegator,
+					delegation.Delegate,
+					delegation.Created,
+					delegation.Revoked,
+				),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionDelegations, fftypes.ChangeEventTypeCreated, delegation.Namespace, delegation.ID)
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) delegationResult(ctx context.Context, row *sql.Rows) (*fftypes.Delegation, error) {
+	var delegation fftypes.Delegation
+	err := row.Scan(
+		&delegation.ID,
+		&delegation.Message,
+		&delegation.Namespace,
+		&delegation.Delegator,
+		&delegation.Delegate,
+		&delegation.Created,
+		&delegation.Revoked,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "delegations")
+	}
+	return &delegation, nil
+}
+
+func (s *SQLCommon) getDelegationPred(ctx context.Context, desc string, pred interface{}) (*fftypes.Delegation, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(delegationColumns...).
+			From("delegations").
+			Where(pred),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Delegation '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.delegationResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetDelegationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Delegation, error) {
+	return s.getDelegationPred(ctx, id.String(), sq.Eq{"id": id})
+}
+
+func (s *SQLCommon) GetDelegations(ctx context.Context, filter database.Filter) (delegations []*fftypes.Delegation, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(delegationColumns...).From("delegations"), filter, delegationFilterFieldMap, []interface{}{"seq"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	delegations = []*fftypes.Delegation{}
+	for rows.Next() {
+		delegation, err := s.delegationResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		delegations = append(delegations, delegation)
+	}
+
+	return delegations, s.queryRes(ctx, tx, "delegations", fop, fi), err
+}
@@ -0,0 +1,185 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDelegationE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new delegation
+	delegation := &fftypes.Delegation{
+		ID:        fftypes.NewUUID(),
+		Message:   fftypes.NewUUID(),
+		Namespace: "ns1",
+		Delegator: "did:firefly:org/org1",
+		Delegate:  "did:firefly:org/org2",
+		Created:   fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionDelegations, fftypes.ChangeEventTypeCreated, "ns1", delegation.ID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionDelegations, fftypes.ChangeEventTypeUpdated, "ns1", delegation.ID, mock.Anything).Return()
+
+	err := s.UpsertDelegation(ctx, delegation)
+	assert.NoError(t, err)
+
+	// Check we get the exact same delegation back
+	delegationRead, err := s.GetDelegationByID(ctx, delegation.ID)
+	assert.NoError(t, err)
+	delegationJson, _ := json.Marshal(&delegation)
+	delegationReadJson, _ := json.Marshal(&delegationRead)
+	assert.Equal(t, string(delegationJson), string(delegationReadJson))
+
+	// Revoke it, which cannot change the delegator or delegate
+	update := *delegation
+	update.Message = fftypes.NewUUID()
+	update.Delegate = "did:firefly:org/org3"
+	update.Revoked = fftypes.Now()
+	err = s.UpsertDelegation(ctx, &update)
+	assert.NoError(t, err)
+
+	// Query back the delegation
+	fb := database.DelegationQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("delegator", "did:firefly:org/org1"),
+		fb.Eq("message", update.Message),
+	)
+	delegations, res, err := s.GetDelegations(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(delegations))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	assert.Equal(t, "did:firefly:org/org2", delegations[0].Delegate)
+	assert.Equal(t, update.Revoked.String(), delegations[0].Revoked.String())
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestUpsertDelegationFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertDelegation(context.Background(), &fftypes.Delegation{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertDelegationFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertDelegation(context.Background(), &fftypes.Delegation{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertDelegationFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertDelegation(context.Background(), &fftypes.Delegation{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertDelegationFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	delegationID := fftypes.NewUUID()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(delegationID.String()))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertDelegation(context.Background(), &fftypes.Delegation{ID: delegationID})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertDelegationFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertDelegation(context.Background(), &fftypes.Delegation{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDelegationByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetDelegationByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDelegationByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	delegation, err := s.GetDelegationByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, delegation)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDelegationByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetDelegationByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDelegationsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.DelegationQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetDelegations(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDelegationsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.DelegationQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetDelegations(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetDelegationsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.DelegationQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetDelegations(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
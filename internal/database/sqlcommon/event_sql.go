@@ -33,6 +33,7 @@ var (
 		"etype",
 		"namespace",
 		"ref",
+		"topic",
 		"created",
 	}
 	eventFilterFieldMap = map[string]string{
@@ -57,6 +58,7 @@ func (s *SQLCommon) InsertEvent(ctx context.Context, event *fftypes.Event) (err
 				string(event.Type),
 				event.Namespace,
 				event.Reference,
+				event.Topic,
 				event.Created,
 			),
 		func() {
@@ -77,6 +79,7 @@ func (s *SQLCommon) eventResult(ctx context.Context, row *sql.Rows) (*fftypes.Ev
 		&event.Type,
 		&event.Namespace,
 		&event.Reference,
+		&event.Topic,
 		&event.Created,
 		// Must be added to the list of columns in all selects
 		&event.Sequence,
@@ -40,8 +40,9 @@ func TestEventE2EWithDB(t *testing.T) {
 	event := &fftypes.Event{
 		ID:        eventID,
 		Namespace: "ns1",
-		Type:      fftypes.EventTypeMessageConfirmed,
+		Type:      fftypes.EventTypeMessageTopicConfirmed,
 		Reference: fftypes.NewUUID(),
+		Topic:     "topic1",
 		Created:   fftypes.Now(),
 	}
 
@@ -65,6 +66,7 @@ func TestEventE2EWithDB(t *testing.T) {
 	filter := fb.And(
 		fb.Eq("id", eventRead.ID.String()),
 		fb.Eq("reference", eventRead.Reference.String()),
+		fb.Eq("topic", "topic1"),
 	)
 	events, res, err := s.GetEvents(ctx, filter.Count(true))
 	assert.NoError(t, err)
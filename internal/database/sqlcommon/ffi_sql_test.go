@@ -0,0 +1,204 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFFIE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new contract interface
+	ffiID := fftypes.NewUUID()
+	ffi := &fftypes.FFI{
+		ID:        ffiID,
+		Namespace: "ns1",
+		Name:      "math",
+		Version:   "v1.0.0",
+		Methods: fftypes.FFIMethods{
+			{
+				Name: "sum",
+				Params: fftypes.FFIParams{
+					{Name: "a", Type: fftypes.FFIParamTypeInteger},
+					{Name: "b", Type: fftypes.FFIParamTypeInteger, Details: fftypes.JSONObject{"type": "uint8"}},
+				},
+				Returns: fftypes.FFIParams{
+					{Name: "result", Type: fftypes.FFIParamTypeInteger},
+				},
+			},
+		},
+		Events: fftypes.FFIEvents{
+			{Name: "Summed", Params: fftypes.FFIParams{}},
+		},
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionFFIs, fftypes.ChangeEventTypeCreated, "ns1", ffiID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionFFIs, fftypes.ChangeEventTypeUpdated, "ns1", ffiID, mock.Anything).Return()
+
+	err := s.UpsertFFI(ctx, ffi)
+	assert.NoError(t, err)
+
+	// Check we get the exact same interface back, by ID and by name
+	ffiRead, err := s.GetFFIByID(ctx, ffiID)
+	assert.NoError(t, err)
+	ffiJson, _ := json.Marshal(&ffi)
+	ffiReadJson, _ := json.Marshal(&ffiRead)
+	assert.Equal(t, string(ffiJson), string(ffiReadJson))
+
+	ffiRead, err = s.GetFFI(ctx, "ns1", "math", "v1.0.0")
+	assert.NoError(t, err)
+	ffiReadJson, _ = json.Marshal(&ffiRead)
+	assert.Equal(t, string(ffiJson), string(ffiReadJson))
+
+	// Update it
+	ffi.Message = fftypes.NewUUID()
+	ffi.Description = "updated"
+	err = s.UpsertFFI(ctx, ffi)
+	assert.NoError(t, err)
+
+	// Query back the interface
+	fb := database.FFIQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("name", "math"),
+		fb.Eq("message", ffi.Message),
+	)
+	ffis, res, err := s.GetFFIs(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ffis))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	ffiJson, _ = json.Marshal(&ffi)
+	ffiReadJson, _ = json.Marshal(ffis[0])
+	assert.Equal(t, string(ffiJson), string(ffiReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestUpsertFFIFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertFFI(context.Background(), &fftypes.FFI{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertFFIFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertFFI(context.Background(), &fftypes.FFI{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertFFIFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertFFI(context.Background(), &fftypes.FFI{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertFFIFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	ffiID := fftypes.NewUUID()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(ffiID.String()))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertFFI(context.Background(), &fftypes.FFI{ID: ffiID})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertFFIFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertFFI(context.Background(), &fftypes.FFI{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFFIByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetFFIByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFFINotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	ffi, err := s.GetFFI(context.Background(), "ns1", "math", "v1.0.0")
+	assert.NoError(t, err)
+	assert.Nil(t, ffi)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFFIByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetFFIByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFFIsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.FFIQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetFFIs(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFFIsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.FFIQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetFFIs(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetFFIsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.FFIQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetFFIs(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,133 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	groupKeyColumns = []string{
+		"id",
+		"message_id",
+		"namespace",
+		"group_hash",
+		"epoch",
+		"symmetric_key",
+		"created",
+	}
+	groupKeyFilterFieldMap = map[string]string{
+		"message": "message_id",
+		"group":   "group_hash",
+	}
+)
+
+func (s *SQLCommon) InsertGroupKey(ctx context.Context, groupKey *fftypes.GroupKey) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("group_keys").
+			Columns(groupKeyColumns...).
+			Values(
+				groupKey.ID,
+				groupKey.Message,
+				groupKey.Namespace,
+				groupKey.Group,
+				groupKey.Epoch,
+				groupKey.Key,
+				groupKey.Created,
+			),
+		func() {
+			s.callbacks.UUIDCollectionNSEvent(database.CollectionGroupKeys, fftypes.ChangeEventTypeCreated, groupKey.Namespace, groupKey.ID)
+		},
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) groupKeyResult(ctx context.Context, row *sql.Rows) (*fftypes.GroupKey, error) {
+	var groupKey fftypes.GroupKey
+	err := row.Scan(
+		&groupKey.ID,
+		&groupKey.Message,
+		&groupKey.Namespace,
+		&groupKey.Group,
+		&groupKey.Epoch,
+		&groupKey.Key,
+		&groupKey.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "group_keys")
+	}
+	return &groupKey, nil
+}
+
+func (s *SQLCommon) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(groupKeyColumns...).
+			From("group_keys").
+			Where(sq.Eq{"group_hash": group, "epoch": epoch}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Group key %s:%d not found", group, epoch)
+		return nil, nil
+	}
+
+	return s.groupKeyResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetGroupKeys(ctx context.Context, filter database.Filter) (groupKeys []*fftypes.GroupKey, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(groupKeyColumns...).From("group_keys"), filter, groupKeyFilterFieldMap, []interface{}{"seq"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	groupKeys = []*fftypes.GroupKey{}
+	for rows.Next() {
+		groupKey, err := s.groupKeyResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		groupKeys = append(groupKeys, groupKey)
+	}
+
+	return groupKeys, s.queryRes(ctx, tx, "group_keys", fop, fi), err
+}
@@ -0,0 +1,177 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGroupKeysE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create two epochs of a key for a group
+	group := fftypes.NewRandB32()
+	groupKey1 := &fftypes.GroupKey{
+		ID:        fftypes.NewUUID(),
+		Message:   fftypes.NewUUID(),
+		Namespace: "ns1",
+		Group:     group,
+		Epoch:     1,
+		Key:       fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+	}
+	groupKey2 := &fftypes.GroupKey{
+		ID:        fftypes.NewUUID(),
+		Message:   fftypes.NewUUID(),
+		Namespace: "ns1",
+		Group:     group,
+		Epoch:     2,
+		Key:       fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionGroupKeys, fftypes.ChangeEventTypeCreated, "ns1", groupKey1.ID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionGroupKeys, fftypes.ChangeEventTypeCreated, "ns1", groupKey2.ID, mock.Anything).Return()
+
+	err := s.InsertGroupKey(ctx, groupKey1)
+	assert.NoError(t, err)
+	err = s.InsertGroupKey(ctx, groupKey2)
+	assert.NoError(t, err)
+
+	// The same epoch cannot be inserted twice
+	err = s.InsertGroupKey(ctx, &fftypes.GroupKey{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Group:     group,
+		Epoch:     2,
+		Key:       fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+	})
+	assert.Regexp(t, "FF10116", err)
+
+	// Check we get the exact same key back for the earlier epoch
+	groupKeyRead, err := s.GetGroupKey(ctx, group, 1)
+	assert.NoError(t, err)
+	groupKeyJson, _ := json.Marshal(&groupKey1)
+	groupKeyReadJson, _ := json.Marshal(&groupKeyRead)
+	assert.Equal(t, string(groupKeyJson), string(groupKeyReadJson))
+
+	// Query back the latest epoch
+	fb := database.GroupKeyQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("group", group),
+	).Sort("epoch").Descending().Limit(1)
+	groupKeys, res, err := s.GetGroupKeys(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(groupKeys))
+	assert.Equal(t, int64(2), *res.TotalCount)
+	groupKeyJson, _ = json.Marshal(&groupKey2)
+	groupKeyReadJson, _ = json.Marshal(groupKeys[0])
+	assert.Equal(t, string(groupKeyJson), string(groupKeyReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestInsertGroupKeyFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertGroupKey(context.Background(), &fftypes.GroupKey{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupKeyFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertGroupKey(context.Background(), &fftypes.GroupKey{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertGroupKeyFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertGroupKey(context.Background(), &fftypes.GroupKey{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupKeySelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetGroupKey(context.Background(), fftypes.NewRandB32(), 1)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupKeyNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	groupKey, err := s.GetGroupKey(context.Background(), fftypes.NewRandB32(), 1)
+	assert.NoError(t, err)
+	assert.Nil(t, groupKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupKeyScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetGroupKey(context.Background(), fftypes.NewRandB32(), 1)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupKeysQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.GroupKeyQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetGroupKeys(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGroupKeysBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.GroupKeyQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetGroupKeys(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetGroupKeysReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.GroupKeyQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetGroupKeys(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,172 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	legalHoldColumns = []string{
+		"id",
+		"namespace",
+		"hold_type",
+		"ref",
+		"topic",
+		"reason",
+		"active",
+		"placed",
+		"placed_by",
+		"removed",
+		"removed_by",
+		"comment",
+	}
+	legalHoldFilterFieldMap = map[string]string{
+		"type":      "hold_type",
+		"reference": "ref",
+		"placedby":  "placed_by",
+		"removedby": "removed_by",
+	}
+)
+
+func (s *SQLCommon) InsertLegalHold(ctx context.Context, hold *fftypes.LegalHold) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("legalholds").
+			Columns(legalHoldColumns...).
+			Values(
+				hold.ID,
+				hold.Namespace,
+				hold.Type,
+				hold.Reference,
+				hold.Topic,
+				hold.Reason,
+				hold.Active,
+				hold.Placed,
+				hold.PlacedBy,
+				hold.Removed,
+				hold.RemovedBy,
+				hold.Comment,
+			),
+		nil, // no change events for legal holds
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) legalHoldResult(ctx context.Context, row *sql.Rows) (*fftypes.LegalHold, error) {
+	var hold fftypes.LegalHold
+	err := row.Scan(
+		&hold.ID,
+		&hold.Namespace,
+		&hold.Type,
+		&hold.Reference,
+		&hold.Topic,
+		&hold.Reason,
+		&hold.Active,
+		&hold.Placed,
+		&hold.PlacedBy,
+		&hold.Removed,
+		&hold.RemovedBy,
+		&hold.Comment,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "legalholds")
+	}
+	return &hold, nil
+}
+
+func (s *SQLCommon) GetLegalHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.LegalHold, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(legalHoldColumns...).
+			From("legalholds").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Legal hold '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.legalHoldResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetLegalHolds(ctx context.Context, filter database.Filter) ([]*fftypes.LegalHold, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(legalHoldColumns...).From("legalholds"), filter, legalHoldFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	holds := []*fftypes.LegalHold{}
+	for rows.Next() {
+		hold, err := s.legalHoldResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, s.queryRes(ctx, tx, "legalholds", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateLegalHold(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("legalholds"), update, legalHoldFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for legal holds */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
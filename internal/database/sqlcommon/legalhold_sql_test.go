@@ -0,0 +1,202 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegalHoldE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new legal hold entry
+	msgID := fftypes.NewUUID()
+	hold := &fftypes.LegalHold{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Type:      fftypes.LegalHoldTypeMessage,
+		Reference: msgID,
+		Reason:    "case 1234",
+		Active:    true,
+		Placed:    fftypes.Now(),
+		PlacedBy:  "counsel1",
+	}
+	err := s.InsertLegalHold(ctx, hold)
+	assert.NoError(t, err)
+
+	// Check we get the exact same hold back
+	holdRead, err := s.GetLegalHoldByID(ctx, hold.ID)
+	assert.NoError(t, err)
+	holdJson, _ := json.Marshal(&hold)
+	holdReadJson, _ := json.Marshal(&holdRead)
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Query back the hold
+	fb := database.LegalHoldQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("type", fftypes.LegalHoldTypeMessage),
+		fb.Eq("reference", msgID),
+		fb.Eq("placedby", "counsel1"),
+		fb.Eq("active", true),
+	)
+	holds, res, err := s.GetLegalHolds(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(holds))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	holdReadJson, _ = json.Marshal(holds[0])
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Remove the hold
+	hold.Active = false
+	hold.Removed = fftypes.Now()
+	hold.RemovedBy = "counsel2"
+	hold.Comment = "case settled"
+	up := database.LegalHoldQueryFactory.NewUpdate(ctx).
+		Set("active", hold.Active).
+		Set("removed", hold.Removed).
+		Set("removedby", hold.RemovedBy).
+		Set("comment", hold.Comment)
+	err = s.UpdateLegalHold(ctx, hold.ID, up)
+	assert.NoError(t, err)
+
+	holdRead, err = s.GetLegalHoldByID(ctx, hold.ID)
+	assert.NoError(t, err)
+	holdJson, _ = json.Marshal(&hold)
+	holdReadJson, _ = json.Marshal(&holdRead)
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Negative test on filter
+	holds, _, err = s.GetLegalHolds(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(holds))
+}
+
+func TestInsertLegalHoldFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertLegalHold(context.Background(), &fftypes.LegalHold{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertLegalHoldFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertLegalHold(context.Background(), &fftypes.LegalHold{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertLegalHoldFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertLegalHold(context.Background(), &fftypes.LegalHold{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegalHoldByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetLegalHoldByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegalHoldByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	hold, err := s.GetLegalHoldByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, hold)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegalHoldByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetLegalHoldByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegalHoldsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.LegalHoldQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetLegalHolds(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegalHoldsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.LegalHoldQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetLegalHolds(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetLegalHoldsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.LegalHoldQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetLegalHolds(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLegalHoldUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.LegalHoldQueryFactory.NewUpdate(context.Background()).Set("active", false)
+	err := s.UpdateLegalHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestLegalHoldUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.LegalHoldQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateLegalHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestLegalHoldUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.LegalHoldQueryFactory.NewUpdate(context.Background()).Set("active", false)
+	err := s.UpdateLegalHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
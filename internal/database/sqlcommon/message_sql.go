@@ -47,12 +47,19 @@ var (
 		"confirmed",
 		"tx_type",
 		"batch_id",
+		"immediate",
+		"custom_headers",
+		"idempotency_key",
+		"delegation_id",
 	}
 	msgFilterFieldMap = map[string]string{
-		"type":   "mtype",
-		"txtype": "tx_type",
-		"batch":  "batch_id",
-		"group":  "group_hash",
+		"type":           "mtype",
+		"txtype":         "tx_type",
+		"batch":          "batch_id",
+		"group":          "group_hash",
+		"custom":         "custom_headers",
+		"idempotencykey": "idempotency_key",
+		"delegation":     "delegation_id",
 	}
 )
 
@@ -75,6 +82,9 @@ func (s *SQLCommon) attemptMessageUpdate(ctx context.Context, tx *txWrapper, mes
 			Set("confirmed", message.Confirmed).
 			Set("tx_type", message.Header.TxType).
 			Set("batch_id", message.BatchID).
+			Set("immediate", message.Immediate).
+			Set("custom_headers", message.Header.Custom).
+			Set("delegation_id", message.Header.Delegation).
 			Where(sq.Eq{
 				"id":   message.Header.ID,
 				"hash": message.Hash,
@@ -106,6 +116,10 @@ func (s *SQLCommon) attemptMessageInsert(ctx context.Context, tx *txWrapper, mes
 				message.Confirmed,
 				message.Header.TxType,
 				message.BatchID,
+				message.Immediate,
+				message.Header.Custom,
+				message.IdempotencyKey,
+				message.Header.Delegation,
 			),
 		func() {
 			s.callbacks.OrderedUUIDCollectionNSEvent(database.CollectionMessages, fftypes.ChangeEventTypeCreated, message.Header.Namespace, message.Header.ID, message.Sequence)
@@ -307,6 +321,10 @@ func (s *SQLCommon) msgResult(ctx context.Context, row *sql.Rows) (*fftypes.Mess
 		&msg.Confirmed,
 		&msg.Header.TxType,
 		&msg.BatchID,
+		&msg.Immediate,
+		&msg.Header.Custom,
+		&msg.IdempotencyKey,
+		&msg.Header.Delegation,
 		// Must be added to the list of columns in all selects
 		&msg.Sequence,
 	)
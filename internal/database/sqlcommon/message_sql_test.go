@@ -67,6 +67,7 @@ func TestUpsertE2EWithDB(t *testing.T) {
 			{ID: dataID1, Hash: rand1},
 			{ID: dataID2, Hash: rand2},
 		},
+		IdempotencyKey: "retry1",
 	}
 
 	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns12345", msgID, mock.Anything).Return()
@@ -99,23 +100,27 @@ func TestUpsertE2EWithDB(t *testing.T) {
 				Key:    "0x12345",
 				Author: "did:firefly:org/abcd",
 			},
-			Created:   fftypes.Now(),
-			Namespace: "ns12345",
-			Topics:    []string{"topic1", "topic2"},
-			Tag:       "tag1",
-			Group:     gid,
-			DataHash:  fftypes.NewRandB32(),
-			TxType:    fftypes.TransactionTypeBatchPin,
+			Created:    fftypes.Now(),
+			Namespace:  "ns12345",
+			Topics:     []string{"topic1", "topic2"},
+			Tag:        "tag1",
+			Group:      gid,
+			DataHash:   fftypes.NewRandB32(),
+			TxType:     fftypes.TransactionTypeBatchPin,
+			Custom:     fftypes.CustomHeaders{"region": "eu-west", "priority": "high"},
+			Delegation: fftypes.NewUUID(),
 		},
 		Hash:      fftypes.NewRandB32(),
 		Pins:      []string{fftypes.NewRandB32().String(), fftypes.NewRandB32().String()},
 		State:     fftypes.MessageStateRejected,
 		Confirmed: fftypes.Now(),
 		BatchID:   bid,
+		Immediate: true,
 		Data: []*fftypes.DataRef{
 			{ID: dataID1, Hash: rand1},
 			{ID: dataID2, Hash: rand2}, // Note the data refs cannot change, as it would affect the hash, and the hash is immutable
 		},
+		IdempotencyKey: "retry1", // Note the idempotency key is only set on insert
 	}
 
 	// Ensure hash change rejected, on any optimization
@@ -149,6 +154,8 @@ func TestUpsertE2EWithDB(t *testing.T) {
 		fb.Eq("topics", msgUpdated.Header.Topics),
 		fb.Eq("group", msgUpdated.Header.Group),
 		fb.Eq("cid", msgUpdated.Header.CID),
+		fb.Contains("custom", "region=eu-west"),
+		fb.Eq("idempotencykey", "retry1"),
 		fb.Gt("created", "0"),
 		fb.Gt("confirmed", "0"),
 	)
@@ -208,6 +215,45 @@ func TestUpsertE2EWithDB(t *testing.T) {
 	s.callbacks.AssertExpectations(t)
 }
 
+func TestUpsertMessageDuplicateIdempotencyKey(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything, mock.Anything).Return()
+
+	newMsg := func(ns string, key fftypes.IdempotencyKey) *fftypes.Message {
+		return &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.MessageTypeBroadcast,
+				Identity: fftypes.Identity{
+					Key:    "0x12345",
+					Author: "did:firefly:org/abcd",
+				},
+				Created:   fftypes.Now(),
+				Namespace: ns,
+				DataHash:  fftypes.NewRandB32(),
+				TxType:    fftypes.TransactionTypeNone,
+			},
+			State:          fftypes.MessageStateReady,
+			Hash:           fftypes.NewRandB32(),
+			IdempotencyKey: key,
+		}
+	}
+
+	// Messages without a key are not constrained
+	err := s.UpsertMessage(ctx, newMsg("ns1", ""), database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+	err = s.UpsertMessage(ctx, newMsg("ns1", ""), database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+
+	err = s.UpsertMessage(ctx, newMsg("ns1", "retry1"), database.UpsertOptimizationNew)
+	assert.NoError(t, err)
+	err = s.UpsertMessage(ctx, newMsg("ns1", "retry1"), database.UpsertOptimizationNew)
+	assert.Regexp(t, "FF10116", err)
+}
+
 func TestUpsertMessageFailBegin(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
@@ -403,7 +449,7 @@ func TestGetMessageByIDLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, false, "", nil, nil, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	_, err := s.GetMessageByID(context.Background(), msgID)
 	assert.Regexp(t, "FF10115", err)
@@ -450,7 +496,7 @@ func TestGetMessagesLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "author1", "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), "confirmed", 0, "pin", nil, false, "", nil, nil, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Gt("confirmed", "0")
 	_, _, err := s.GetMessages(context.Background(), f)
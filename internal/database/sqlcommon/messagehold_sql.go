@@ -0,0 +1,173 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	messageHoldColumns = []string{
+		"id",
+		"namespace",
+		"group_hash",
+		"tag",
+		"message",
+		"reason",
+		"status",
+		"created",
+		"decided",
+		"decided_by",
+		"comment",
+	}
+	messageHoldFilterFieldMap = map[string]string{
+		"group":     "group_hash",
+		"decidedby": "decided_by",
+	}
+)
+
+func (s *SQLCommon) InsertMessageHold(ctx context.Context, hold *fftypes.MessageHold) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	msgBytes, _ := json.Marshal(hold.Message)
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("messageholds").
+			Columns(messageHoldColumns...).
+			Values(
+				hold.ID,
+				hold.Namespace,
+				hold.Group,
+				hold.Tag,
+				msgBytes,
+				hold.Reason,
+				hold.Status,
+				hold.Created,
+				hold.Decided,
+				hold.DecidedBy,
+				hold.Comment,
+			),
+		nil, // no change events for message holds
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) messageHoldResult(ctx context.Context, row *sql.Rows) (*fftypes.MessageHold, error) {
+	var hold fftypes.MessageHold
+	var msgBytes []byte
+	err := row.Scan(
+		&hold.ID,
+		&hold.Namespace,
+		&hold.Group,
+		&hold.Tag,
+		&msgBytes,
+		&hold.Reason,
+		&hold.Status,
+		&hold.Created,
+		&hold.Decided,
+		&hold.DecidedBy,
+		&hold.Comment,
+	)
+	if err == nil {
+		err = json.Unmarshal(msgBytes, &hold.Message)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "messageholds")
+	}
+	return &hold, nil
+}
+
+func (s *SQLCommon) GetMessageHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.MessageHold, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(messageHoldColumns...).
+			From("messageholds").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Message hold '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.messageHoldResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetMessageHolds(ctx context.Context, filter database.Filter) ([]*fftypes.MessageHold, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(messageHoldColumns...).From("messageholds"), filter, messageHoldFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	holds := []*fftypes.MessageHold{}
+	for rows.Next() {
+		hold, err := s.messageHoldResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, s.queryRes(ctx, tx, "messageholds", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateMessageHold(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("messageholds"), update, messageHoldFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for message holds */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
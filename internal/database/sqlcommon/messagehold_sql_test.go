@@ -0,0 +1,220 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageHoldE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new message hold entry
+	msgID := fftypes.NewUUID()
+	group := fftypes.NewRandB32()
+	hold := &fftypes.MessageHold{
+		ID:        msgID,
+		Namespace: "ns1",
+		Group:     group,
+		Tag:       "restricted",
+		Message: &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        msgID,
+				Namespace: "ns1",
+				Type:      fftypes.MessageTypePrivate,
+				Group:     group,
+				Tag:       "restricted",
+			},
+			State: fftypes.MessageStateHeld,
+		},
+		Reason:  "tag 'restricted' requires approval",
+		Status:  fftypes.PolicyApprovalStatusPending,
+		Created: fftypes.Now(),
+	}
+	err := s.InsertMessageHold(ctx, hold)
+	assert.NoError(t, err)
+
+	// Check we get the exact same hold back
+	holdRead, err := s.GetMessageHoldByID(ctx, hold.ID)
+	assert.NoError(t, err)
+	holdJson, _ := json.Marshal(&hold)
+	holdReadJson, _ := json.Marshal(&holdRead)
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Query back the hold
+	fb := database.MessageHoldQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("group", group),
+		fb.Eq("tag", "restricted"),
+		fb.Eq("status", fftypes.PolicyApprovalStatusPending),
+	)
+	holds, res, err := s.GetMessageHolds(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(holds))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	holdReadJson, _ = json.Marshal(holds[0])
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Decide the hold
+	hold.Status = fftypes.PolicyApprovalStatusApproved
+	hold.Decided = fftypes.Now()
+	hold.DecidedBy = "admin2"
+	hold.Comment = "release approved"
+	up := database.MessageHoldQueryFactory.NewUpdate(ctx).
+		Set("status", hold.Status).
+		Set("decided", hold.Decided).
+		Set("decidedby", hold.DecidedBy).
+		Set("comment", hold.Comment)
+	err = s.UpdateMessageHold(ctx, hold.ID, up)
+	assert.NoError(t, err)
+
+	holdRead, err = s.GetMessageHoldByID(ctx, hold.ID)
+	assert.NoError(t, err)
+	holdJson, _ = json.Marshal(&hold)
+	holdReadJson, _ = json.Marshal(&holdRead)
+	assert.Equal(t, string(holdJson), string(holdReadJson))
+
+	// Negative test on filter
+	holds, _, err = s.GetMessageHolds(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(holds))
+}
+
+func TestInsertMessageHoldFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertMessageHold(context.Background(), &fftypes.MessageHold{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertMessageHoldFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertMessageHold(context.Background(), &fftypes.MessageHold{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertMessageHoldFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertMessageHold(context.Background(), &fftypes.MessageHold{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageHoldByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetMessageHoldByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageHoldByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	approval, err := s.GetMessageHoldByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, approval)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageHoldByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetMessageHoldByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageHoldsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.MessageHoldQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetMessageHolds(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageHoldsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.MessageHoldQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetMessageHolds(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetMessageHoldsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.MessageHoldQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetMessageHolds(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageHoldUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.MessageHoldQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdateMessageHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestMessageHoldUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.MessageHoldQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateMessageHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestMessageHoldUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.MessageHoldQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdateMessageHold(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
+
+func TestGetMessageHoldByIDBadMessage(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(messageHoldColumns).
+		AddRow(fftypes.NewUUID().String(), "ns1", nil, "", []byte("!json"), "", "pending", nil, nil, "", ""))
+	_, err := s.GetMessageHoldByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,108 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	msgTransitionColumns = []string{
+		"id",
+		"namespace",
+		"message_id",
+		"state",
+		"cause",
+		"created",
+	}
+	msgTransitionFilterFieldMap = map[string]string{
+		"message": "message_id",
+	}
+)
+
+func (s *SQLCommon) InsertMessageTransition(ctx context.Context, transition *fftypes.MessageTransition) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("messagetransitions").
+			Columns(msgTransitionColumns...).
+			Values(
+				transition.ID,
+				transition.Namespace,
+				transition.Message,
+				transition.State,
+				transition.Cause,
+				transition.Created,
+			),
+		nil, // no change events for message transitions
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) msgTransitionResult(ctx context.Context, row *sql.Rows) (*fftypes.MessageTransition, error) {
+	transition := fftypes.MessageTransition{}
+	err := row.Scan(
+		&transition.ID,
+		&transition.Namespace,
+		&transition.Message,
+		&transition.State,
+		&transition.Cause,
+		&transition.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "messagetransitions")
+	}
+	return &transition, nil
+}
+
+func (s *SQLCommon) GetMessageTransitions(ctx context.Context, filter database.Filter) ([]*fftypes.MessageTransition, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(msgTransitionColumns...).From("messagetransitions"), filter, msgTransitionFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	transitions := []*fftypes.MessageTransition{}
+	for rows.Next() {
+		transition, err := s.msgTransitionResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, s.queryRes(ctx, tx, "messagetransitions", fop, fi), err
+}
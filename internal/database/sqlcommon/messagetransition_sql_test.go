@@ -0,0 +1,135 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageTransitionsE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Record a couple of transitions for a message
+	msgID := fftypes.NewUUID()
+	transition1 := &fftypes.MessageTransition{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   msgID,
+		State:     fftypes.MessageStateReady,
+		Created:   fftypes.Now(),
+	}
+	err := s.InsertMessageTransition(ctx, transition1)
+	assert.NoError(t, err)
+	transition2 := &fftypes.MessageTransition{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   msgID,
+		State:     fftypes.MessageStateSent,
+		Cause:     "dispatched in batch",
+		Created:   fftypes.Now(),
+	}
+	err = s.InsertMessageTransition(ctx, transition2)
+	assert.NoError(t, err)
+	err = s.InsertMessageTransition(ctx, &fftypes.MessageTransition{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   fftypes.NewUUID(),
+		State:     fftypes.MessageStateReady,
+		Created:   fftypes.Now(),
+	})
+	assert.NoError(t, err)
+
+	// Query back the history of the message, in order
+	fb := database.MessageTransitionQueryFactory.NewFilter(ctx)
+	transitions, res, err := s.GetMessageTransitions(ctx, fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("message", msgID),
+	).Sort("sequence").Ascending().Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), *res.TotalCount)
+	assert.Len(t, transitions, 2)
+	transitionJson, _ := json.Marshal(&transition1)
+	transitionReadJson, _ := json.Marshal(transitions[0])
+	assert.Equal(t, string(transitionJson), string(transitionReadJson))
+	transitionJson, _ = json.Marshal(&transition2)
+	transitionReadJson, _ = json.Marshal(transitions[1])
+	assert.Equal(t, string(transitionJson), string(transitionReadJson))
+}
+
+func TestInsertMessageTransitionFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertMessageTransition(context.Background(), &fftypes.MessageTransition{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertMessageTransitionFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertMessageTransition(context.Background(), &fftypes.MessageTransition{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertMessageTransitionFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertMessageTransition(context.Background(), &fftypes.MessageTransition{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageTransitionsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.MessageTransitionQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetMessageTransitions(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMessageTransitionsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.MessageTransitionQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetMessageTransitions(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetMessageTransitionsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.MessageTransitionQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetMessageTransitions(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	metricRollupColumns = []string{
+		"namespace",
+		"metric",
+		"rtype",
+		"bucket",
+		"count",
+	}
+	metricRollupFilterFieldMap = map[string]string{
+		"type": "rtype",
+	}
+)
+
+// metricSource is the table counted for a metric, with the column holding the type of each record, and
+// the column holding the local time the record was created (messages are counted when they are confirmed)
+type metricSource struct {
+	table      string
+	typeColumn string
+	timeColumn string
+}
+
+var metricSources = map[fftypes.MetricRollupType]metricSource{
+	fftypes.MetricRollupTypeMessages:  {table: "messages", typeColumn: "mtype", timeColumn: "confirmed"},
+	fftypes.MetricRollupTypeTransfers: {table: "tokentransfer", typeColumn: "type", timeColumn: "created"},
+	fftypes.MetricRollupTypeEvents:    {table: "events", typeColumn: "etype", timeColumn: "created"},
+}
+
+func (s *SQLCommon) GetMetricCounts(ctx context.Context, metric fftypes.MetricRollupType, interval time.Duration, startTime, endTime *fftypes.FFTime) ([]*fftypes.MetricRollup, error) {
+	source, ok := metricSources[metric]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgUnsupportedCollection, metric)
+	}
+
+	// Times are stored as nanoseconds since the epoch, so integer division aligns each record to its bucket
+	bucket := fmt.Sprintf("(%s / %d) * %d", source.timeColumn, interval.Nanoseconds(), interval.Nanoseconds())
+	rows, _, err := s.query(ctx,
+		sq.Select("namespace", source.typeColumn, bucket+" AS bucket", "COUNT(*)").
+			From(source.table).
+			Where(sq.And{
+				sq.GtOrEq{source.timeColumn: startTime},
+				sq.Lt{source.timeColumn: endTime},
+			}).
+			GroupBy("namespace", source.typeColumn, "bucket").
+			OrderBy("bucket"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*fftypes.MetricRollup{}
+	for rows.Next() {
+		rollup := &fftypes.MetricRollup{Metric: metric}
+		var bucketTime int64
+		if err = rows.Scan(&rollup.Namespace, &rollup.Type, &bucketTime, &rollup.Count); err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, source.table)
+		}
+		rollup.Bucket = fftypes.UnixTime(bucketTime)
+		counts = append(counts, rollup)
+	}
+
+	return counts, nil
+}
+
+func (s *SQLCommon) UpsertMetricRollup(ctx context.Context, rollup *fftypes.MetricRollup) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	where := sq.Eq{
+		"namespace": rollup.Namespace,
+		"metric":    rollup.Metric,
+		"rtype":     rollup.Type,
+		"bucket":    rollup.Bucket,
+	}
+	rollupRows, _, err := s.queryTx(ctx, tx, sq.Select(sequenceColumn).From("metricrollups").Where(where))
+	if err != nil {
+		return err
+	}
+	existing := rollupRows.Next()
+	rollupRows.Close()
+
+	if existing {
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("metricrollups").
+				Set("count", rollup.Count).
+				Where(where),
+			nil, // no change events for metric rollups
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("metricrollups").
+				Columns(metricRollupColumns...).
+				Values(
+					rollup.Namespace,
+					rollup.Metric,
+					rollup.Type,
+					rollup.Bucket,
+					rollup.Count,
+				),
+			nil, // no change events for metric rollups
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) metricRollupResult(ctx context.Context, row *sql.Rows) (*fftypes.MetricRollup, error) {
+	var rollup fftypes.MetricRollup
+	err := row.Scan(
+		&rollup.Namespace,
+		&rollup.Metric,
+		&rollup.Type,
+		&rollup.Bucket,
+		&rollup.Count,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "metricrollups")
+	}
+	return &rollup, nil
+}
+
+func (s *SQLCommon) GetMetricRollups(ctx context.Context, filter database.Filter) ([]*fftypes.MetricRollup, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(metricRollupColumns...).From("metricrollups"), filter, metricRollupFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	rollups := []*fftypes.MetricRollup{}
+	for rows.Next() {
+		rollup, err := s.metricRollupResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups, s.queryRes(ctx, tx, "metricrollups", fop, fi), err
+}
@@ -0,0 +1,200 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetricRollupE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create some events across two one minute buckets
+	s.callbacks.On("OrderedUUIDCollectionNSEvent", database.CollectionEvents, fftypes.ChangeEventTypeCreated, "ns1", mock.Anything, mock.Anything).Return()
+	bucket1 := time.Unix(1600000020, 0)
+	bucket2 := bucket1.Add(time.Minute)
+	for _, e := range []struct {
+		etype   fftypes.EventType
+		created time.Time
+	}{
+		{fftypes.EventTypeMessageConfirmed, bucket1},
+		{fftypes.EventTypeMessageConfirmed, bucket1.Add(59 * time.Second)},
+		{fftypes.EventTypeTransferConfirmed, bucket1.Add(time.Second)},
+		{fftypes.EventTypeMessageConfirmed, bucket2.Add(time.Second)},
+	} {
+		created := fftypes.FFTime(e.created)
+		err := s.InsertEvent(ctx, &fftypes.Event{
+			ID:        fftypes.NewUUID(),
+			Type:      e.etype,
+			Namespace: "ns1",
+			Created:   &created,
+		})
+		assert.NoError(t, err)
+	}
+
+	// Count them into buckets
+	counts, err := s.GetMetricCounts(ctx, fftypes.MetricRollupTypeEvents, time.Minute, fftypes.UnixTime(bucket1.UnixNano()), fftypes.UnixTime(bucket2.Add(time.Minute).UnixNano()))
+	assert.NoError(t, err)
+	assert.Len(t, counts, 3)
+	for _, c := range counts {
+		assert.Equal(t, "ns1", c.Namespace)
+		assert.Equal(t, fftypes.MetricRollupTypeEvents, c.Metric)
+	}
+	assert.Equal(t, bucket1.UnixNano(), counts[0].Bucket.UnixNano())
+	assert.Equal(t, bucket1.UnixNano(), counts[1].Bucket.UnixNano())
+	assert.Equal(t, bucket2.UnixNano(), counts[2].Bucket.UnixNano())
+	assert.Equal(t, int64(3), counts[0].Count+counts[1].Count)
+	assert.Equal(t, fftypes.EventTypeMessageConfirmed.String(), counts[2].Type)
+	assert.Equal(t, int64(1), counts[2].Count)
+
+	// Store the rollups, then update one of them
+	for _, c := range counts {
+		err = s.UpsertMetricRollup(ctx, c)
+		assert.NoError(t, err)
+	}
+	counts[2].Count = 5
+	err = s.UpsertMetricRollup(ctx, counts[2])
+	assert.NoError(t, err)
+
+	// Query back the rollups
+	fb := database.MetricRollupQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("metric", fftypes.MetricRollupTypeEvents),
+		fb.Gt("bucket", bucket1.UnixNano()),
+	)
+	rollups, res, err := s.GetMetricRollups(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rollups))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	rollupJson, _ := json.Marshal(counts[2])
+	rollupReadJson, _ := json.Marshal(rollups[0])
+	assert.Equal(t, string(rollupJson), string(rollupReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestGetMetricCountsUnsupported(t *testing.T) {
+	s, _ := newMockProvider().init()
+	_, err := s.GetMetricCounts(context.Background(), "wrong", time.Minute, fftypes.Now(), fftypes.Now())
+	assert.Regexp(t, "FF10301", err)
+}
+
+func TestGetMetricCountsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetMetricCounts(context.Background(), fftypes.MetricRollupTypeMessages, time.Minute, fftypes.Now(), fftypes.Now())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMetricCountsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"namespace"}).AddRow("only one"))
+	_, err := s.GetMetricCounts(context.Background(), fftypes.MetricRollupTypeTransfers, time.Minute, fftypes.Now(), fftypes.Now())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMetricRollupFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertMetricRollup(context.Background(), &fftypes.MetricRollup{Bucket: fftypes.Now()})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMetricRollupFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertMetricRollup(context.Background(), &fftypes.MetricRollup{Bucket: fftypes.Now()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMetricRollupFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertMetricRollup(context.Background(), &fftypes.MetricRollup{Bucket: fftypes.Now()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMetricRollupFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow(12345))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertMetricRollup(context.Background(), &fftypes.MetricRollup{Bucket: fftypes.Now()})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMetricRollupFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertMetricRollup(context.Background(), &fftypes.MetricRollup{Bucket: fftypes.Now()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMetricRollupsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.MetricRollupQueryFactory.NewFilter(context.Background()).Eq("namespace", map[bool]bool{true: false})
+	_, _, err := s.GetMetricRollups(context.Background(), f)
+	assert.Regexp(t, "FF10149.*namespace", err)
+}
+
+func TestGetMetricRollupsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.MetricRollupQueryFactory.NewFilter(context.Background()).Eq("namespace", "")
+	_, _, err := s.GetMetricRollups(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMetricRollupsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"namespace"}).AddRow("only one"))
+	f := database.MetricRollupQueryFactory.NewFilter(context.Background()).Eq("namespace", "")
+	_, _, err := s.GetMetricRollups(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
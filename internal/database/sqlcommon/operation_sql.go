@@ -36,17 +36,21 @@ var (
 		"opstatus",
 		"plugin",
 		"backend_id",
+		"op_schema",
 		"created",
 		"updated",
 		"error",
 		"input",
 		"output",
+		"retry_id",
 	}
 	opFilterFieldMap = map[string]string{
 		"tx":        "tx_id",
 		"type":      "optype",
 		"status":    "opstatus",
 		"backendid": "backend_id",
+		"schema":    "op_schema",
+		"retry":     "retry_id",
 	}
 )
 
@@ -68,11 +72,13 @@ func (s *SQLCommon) InsertOperation(ctx context.Context, operation *fftypes.Oper
 				string(operation.Status),
 				operation.Plugin,
 				operation.BackendID,
+				operation.Schema,
 				operation.Created,
 				operation.Updated,
 				operation.Error,
 				operation.Input,
 				operation.Output,
+				operation.Retry,
 			),
 		func() {
 			s.callbacks.UUIDCollectionNSEvent(database.CollectionOperations, fftypes.ChangeEventTypeCreated, operation.Namespace, operation.ID)
@@ -94,11 +100,13 @@ func (s *SQLCommon) opResult(ctx context.Context, row *sql.Rows) (*fftypes.Opera
 		&op.Status,
 		&op.Plugin,
 		&op.BackendID,
+		&op.Schema,
 		&op.Created,
 		&op.Updated,
 		&op.Error,
 		&op.Input,
 		&op.Output,
+		&op.Retry,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "operations")
@@ -178,3 +186,28 @@ func (s *SQLCommon) UpdateOperation(ctx context.Context, id *fftypes.UUID, updat
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+func (s *SQLCommon) SetOperationRetry(ctx context.Context, id, retryID *fftypes.UUID) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Only one retry can win the race to link itself to the operation
+	ra, err := s.updateTx(ctx, tx,
+		sq.Update("operations").
+			Set("retry_id", retryID).
+			Set("updated", fftypes.Now()).
+			Where(sq.Eq{"id": id, "retry_id": nil}),
+		nil)
+	if err != nil {
+		return err
+	}
+	if ra < 1 {
+		return i18n.NewError(ctx, i18n.MsgOperationAlreadyRetried, id)
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
@@ -45,10 +45,12 @@ func TestOperationE2EWithDB(t *testing.T) {
 		Plugin:      "ethereum",
 		BackendID:   fftypes.NewRandB32().String(),
 		Error:       "pop",
+		Schema:      fftypes.OpSchema(fftypes.OpTypeBlockchainBatchPin),
 		Input:       fftypes.JSONObject{"some": "input-info"},
 		Output:      fftypes.JSONObject{"some": "output-info"},
 		Created:     fftypes.Now(),
 		Updated:     fftypes.Now(),
+		Retry:       fftypes.NewUUID(),
 	}
 	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionOperations, fftypes.ChangeEventTypeCreated, "ns1", operationID).Return()
 	err := s.InsertOperation(ctx, operation)
@@ -71,6 +73,7 @@ func TestOperationE2EWithDB(t *testing.T) {
 		fb.Eq("error", operation.Error),
 		fb.Eq("plugin", operation.Plugin),
 		fb.Eq("backendid", operation.BackendID),
+		fb.Eq("retry", operation.Retry),
 		fb.Gt("created", 0),
 		fb.Gt("updated", 0),
 	)
@@ -221,3 +224,48 @@ func TestOperationUpdateFail(t *testing.T) {
 	err := s.UpdateOperation(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestOperationSetRetryWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	operation := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        fftypes.OpTypeBlockchainBatchPin,
+		Status:      fftypes.OpStatusFailed,
+		Created:     fftypes.Now(),
+	}
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionOperations, fftypes.ChangeEventTypeCreated, "ns1", operation.ID).Return()
+	err := s.InsertOperation(ctx, operation)
+	assert.NoError(t, err)
+
+	retryID := fftypes.NewUUID()
+	err = s.SetOperationRetry(ctx, operation.ID, retryID)
+	assert.NoError(t, err)
+
+	err = s.SetOperationRetry(ctx, operation.ID, fftypes.NewUUID())
+	assert.Regexp(t, "FF10464", err)
+
+	operationRead, err := s.GetOperationByID(ctx, operation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, retryID, operationRead.Retry)
+}
+
+func TestOperationSetRetryBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.SetOperationRetry(context.Background(), fftypes.NewUUID(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestOperationSetRetryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.SetOperationRetry(context.Background(), fftypes.NewUUID(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10117", err)
+}
@@ -0,0 +1,161 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	pinQuarantineColumns = []string{
+		"id",
+		"namespace",
+		"batch_id",
+		"signer",
+		"status",
+		"created",
+		"decided",
+		"decided_by",
+		"comment",
+	}
+	pinQuarantineFilterFieldMap = map[string]string{
+		"batch":     "batch_id",
+		"decidedby": "decided_by",
+	}
+)
+
+func (s *SQLCommon) InsertPinQuarantine(ctx context.Context, quarantine *fftypes.PinQuarantine) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("pinquarantine").
+			Columns(pinQuarantineColumns...).
+			Values(
+				quarantine.ID,
+				quarantine.Namespace,
+				quarantine.Batch,
+				quarantine.Signer,
+				quarantine.Status,
+				quarantine.Created,
+				quarantine.Decided,
+				quarantine.DecidedBy,
+				quarantine.Comment,
+			),
+		nil, // no change events for pin quarantines
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) pinQuarantineResult(ctx context.Context, row *sql.Rows) (*fftypes.PinQuarantine, error) {
+	var quarantine fftypes.PinQuarantine
+	err := row.Scan(
+		&quarantine.ID,
+		&quarantine.Namespace,
+		&quarantine.Batch,
+		&quarantine.Signer,
+		&quarantine.Status,
+		&quarantine.Created,
+		&quarantine.Decided,
+		&quarantine.DecidedBy,
+		&quarantine.Comment,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "pinquarantine")
+	}
+	return &quarantine, nil
+}
+
+func (s *SQLCommon) GetPinQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PinQuarantine, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(pinQuarantineColumns...).
+			From("pinquarantine").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Pin quarantine '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.pinQuarantineResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetPinQuarantines(ctx context.Context, filter database.Filter) ([]*fftypes.PinQuarantine, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(pinQuarantineColumns...).From("pinquarantine"), filter, pinQuarantineFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	quarantines := []*fftypes.PinQuarantine{}
+	for rows.Next() {
+		quarantine, err := s.pinQuarantineResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		quarantines = append(quarantines, quarantine)
+	}
+
+	return quarantines, s.queryRes(ctx, tx, "pinquarantine", fop, fi), err
+}
+
+func (s *SQLCommon) UpdatePinQuarantine(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("pinquarantine"), update, pinQuarantineFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for pin quarantines */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
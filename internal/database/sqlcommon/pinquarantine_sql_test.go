@@ -0,0 +1,199 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinQuarantineE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new pin quarantine entry
+	batchID := fftypes.NewUUID()
+	quarantine := &fftypes.PinQuarantine{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Batch:     batchID,
+		Signer:    "0x12345",
+		Status:    fftypes.PolicyApprovalStatusPending,
+		Created:   fftypes.Now(),
+	}
+	err := s.InsertPinQuarantine(ctx, quarantine)
+	assert.NoError(t, err)
+
+	// Check we get the exact same quarantine back
+	quarantineRead, err := s.GetPinQuarantineByID(ctx, quarantine.ID)
+	assert.NoError(t, err)
+	quarantineJson, _ := json.Marshal(&quarantine)
+	quarantineReadJson, _ := json.Marshal(&quarantineRead)
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Query back the quarantine
+	fb := database.PinQuarantineQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("batch", batchID),
+		fb.Eq("signer", "0x12345"),
+		fb.Eq("status", fftypes.PolicyApprovalStatusPending),
+	)
+	quarantines, res, err := s.GetPinQuarantines(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(quarantines))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	quarantineReadJson, _ = json.Marshal(quarantines[0])
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Decide the quarantine
+	quarantine.Status = fftypes.PolicyApprovalStatusRejected
+	quarantine.Decided = fftypes.Now()
+	quarantine.DecidedBy = "admin2"
+	quarantine.Comment = "unknown sender"
+	up := database.PinQuarantineQueryFactory.NewUpdate(ctx).
+		Set("status", quarantine.Status).
+		Set("decided", quarantine.Decided).
+		Set("decidedby", quarantine.DecidedBy).
+		Set("comment", quarantine.Comment)
+	err = s.UpdatePinQuarantine(ctx, quarantine.ID, up)
+	assert.NoError(t, err)
+
+	quarantineRead, err = s.GetPinQuarantineByID(ctx, quarantine.ID)
+	assert.NoError(t, err)
+	quarantineJson, _ = json.Marshal(&quarantine)
+	quarantineReadJson, _ = json.Marshal(&quarantineRead)
+	assert.Equal(t, string(quarantineJson), string(quarantineReadJson))
+
+	// Negative test on filter
+	quarantines, _, err = s.GetPinQuarantines(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(quarantines))
+}
+
+func TestInsertPinQuarantineFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertPinQuarantine(context.Background(), &fftypes.PinQuarantine{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertPinQuarantineFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertPinQuarantine(context.Background(), &fftypes.PinQuarantine{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertPinQuarantineFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertPinQuarantine(context.Background(), &fftypes.PinQuarantine{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPinQuarantineByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetPinQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPinQuarantineByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	approval, err := s.GetPinQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, approval)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPinQuarantineByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetPinQuarantineByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPinQuarantinesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.PinQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPinQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPinQuarantinesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.PinQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetPinQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetPinQuarantinesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.PinQuarantineQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPinQuarantines(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinQuarantineUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.PinQuarantineQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdatePinQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestPinQuarantineUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.PinQuarantineQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdatePinQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestPinQuarantineUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.PinQuarantineQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdatePinQuarantine(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
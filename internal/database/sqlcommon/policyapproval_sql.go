@@ -0,0 +1,173 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	policyApprovalColumns = []string{
+		"id",
+		"namespace",
+		"type",
+		"signing_key",
+		"reference",
+		"input",
+		"hash",
+		"reason",
+		"status",
+		"created",
+		"decided",
+		"decided_by",
+		"comment",
+	}
+	policyApprovalFilterFieldMap = map[string]string{
+		"signingkey": "signing_key",
+		"decidedby":  "decided_by",
+	}
+)
+
+func (s *SQLCommon) InsertPolicyApproval(ctx context.Context, approval *fftypes.PolicyApproval) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("policyapprovals").
+			Columns(policyApprovalColumns...).
+			Values(
+				approval.ID,
+				approval.Namespace,
+				approval.Type,
+				approval.SigningKey,
+				approval.Reference,
+				approval.Input,
+				approval.Hash,
+				approval.Reason,
+				approval.Status,
+				approval.Created,
+				approval.Decided,
+				approval.DecidedBy,
+				approval.Comment,
+			),
+		nil, // no change events for policy approvals
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) policyApprovalResult(ctx context.Context, row *sql.Rows) (*fftypes.PolicyApproval, error) {
+	var approval fftypes.PolicyApproval
+	err := row.Scan(
+		&approval.ID,
+		&approval.Namespace,
+		&approval.Type,
+		&approval.SigningKey,
+		&approval.Reference,
+		&approval.Input,
+		&approval.Hash,
+		&approval.Reason,
+		&approval.Status,
+		&approval.Created,
+		&approval.Decided,
+		&approval.DecidedBy,
+		&approval.Comment,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "policyapprovals")
+	}
+	return &approval, nil
+}
+
+func (s *SQLCommon) GetPolicyApprovalByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PolicyApproval, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(policyApprovalColumns...).
+			From("policyapprovals").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Policy approval '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.policyApprovalResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetPolicyApprovals(ctx context.Context, filter database.Filter) ([]*fftypes.PolicyApproval, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(policyApprovalColumns...).From("policyapprovals"), filter, policyApprovalFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	approvals := []*fftypes.PolicyApproval{}
+	for rows.Next() {
+		approval, err := s.policyApprovalResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, s.queryRes(ctx, tx, "policyapprovals", fop, fi), err
+}
+
+func (s *SQLCommon) UpdatePolicyApproval(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("policyapprovals"), update, policyApprovalFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for policy approvals */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
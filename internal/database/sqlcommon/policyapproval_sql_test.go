@@ -0,0 +1,205 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyApprovalE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new policy approval entry
+	req := fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenTransfer,
+		Namespace:  "ns1",
+		SigningKey: "0x12345",
+		Reference:  fftypes.NewUUID(),
+		Input:      fftypes.JSONObject{"amount": "10"},
+	}
+	approval := &fftypes.PolicyApproval{
+		ID:            fftypes.NewUUID(),
+		PolicyRequest: req,
+		Hash:          req.Hash(),
+		Reason:        "manual approval required",
+		Status:        fftypes.PolicyApprovalStatusPending,
+		Created:       fftypes.Now(),
+	}
+	err := s.InsertPolicyApproval(ctx, approval)
+	assert.NoError(t, err)
+
+	// Check we get the exact same approval back
+	approvalRead, err := s.GetPolicyApprovalByID(ctx, approval.ID)
+	assert.NoError(t, err)
+	approvalJson, _ := json.Marshal(&approval)
+	approvalReadJson, _ := json.Marshal(&approvalRead)
+	assert.Equal(t, string(approvalJson), string(approvalReadJson))
+
+	// Query back the approval
+	fb := database.PolicyApprovalQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("hash", approval.Hash),
+		fb.Eq("status", fftypes.PolicyApprovalStatusPending),
+		fb.Eq("signingkey", "0x12345"),
+	)
+	approvals, res, err := s.GetPolicyApprovals(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(approvals))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	approvalReadJson, _ = json.Marshal(approvals[0])
+	assert.Equal(t, string(approvalJson), string(approvalReadJson))
+
+	// Decide the approval
+	approval.Status = fftypes.PolicyApprovalStatusApproved
+	approval.Decided = fftypes.Now()
+	approval.DecidedBy = "admin2"
+	approval.Comment = "looks good"
+	up := database.PolicyApprovalQueryFactory.NewUpdate(ctx).
+		Set("status", approval.Status).
+		Set("decided", approval.Decided).
+		Set("decidedby", approval.DecidedBy).
+		Set("comment", approval.Comment)
+	err = s.UpdatePolicyApproval(ctx, approval.ID, up)
+	assert.NoError(t, err)
+
+	approvalRead, err = s.GetPolicyApprovalByID(ctx, approval.ID)
+	assert.NoError(t, err)
+	approvalJson, _ = json.Marshal(&approval)
+	approvalReadJson, _ = json.Marshal(&approvalRead)
+	assert.Equal(t, string(approvalJson), string(approvalReadJson))
+
+	// Negative test on filter
+	approvals, _, err = s.GetPolicyApprovals(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(approvals))
+}
+
+func TestInsertPolicyApprovalFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertPolicyApproval(context.Background(), &fftypes.PolicyApproval{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertPolicyApprovalFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertPolicyApproval(context.Background(), &fftypes.PolicyApproval{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertPolicyApprovalFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertPolicyApproval(context.Background(), &fftypes.PolicyApproval{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPolicyApprovalByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetPolicyApprovalByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPolicyApprovalByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	approval, err := s.GetPolicyApprovalByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, approval)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPolicyApprovalByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetPolicyApprovalByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPolicyApprovalsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.PolicyApprovalQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPolicyApprovals(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPolicyApprovalsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.PolicyApprovalQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetPolicyApprovals(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetPolicyApprovalsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.PolicyApprovalQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPolicyApprovals(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPolicyApprovalUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.PolicyApprovalQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdatePolicyApproval(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestPolicyApprovalUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.PolicyApprovalQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdatePolicyApproval(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestPolicyApprovalUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.PolicyApprovalQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.PolicyApprovalStatusApproved)
+	err := s.UpdatePolicyApproval(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
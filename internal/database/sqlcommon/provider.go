@@ -47,4 +47,13 @@ type Provider interface {
 
 	// UpdateInsertForSequenceReturn updates the INSERT query for returning the Sequence, and returns whether it needs to be run as a query to return the Sequence field
 	UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (updatedInsert sq.InsertBuilder, runAsQuery bool)
+
+	// IsRetryableError returns true if a failed transaction can safely be re-run from the start, such as after a serialization conflict
+	IsRetryableError(err error) bool
+
+	// HistoricalReadTimeQuery is a query returning a recent point in time, that reads for API queries can be served from without contending with writes, or empty if not supported
+	HistoricalReadTimeQuery() string
+
+	// CurrentTimeQuery is a query returning the current time of the database as milliseconds since the epoch, used to detect clock skew, or empty if not supported
+	CurrentTimeQuery() string
 }
@@ -42,6 +42,9 @@ type mockProvider struct {
 	openError               error
 	getMigrationDriverError error
 	individualSort          bool
+	retryableError          error
+	historicalReadTimeQuery string
+	currentTimeQuery        string
 }
 
 func newMockProvider() *mockProvider {
@@ -85,3 +88,15 @@ func (mp *mockProvider) Open(url string) (*sql.DB, error) {
 func (mp *mockProvider) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
 	return nil, mp.getMigrationDriverError
 }
+
+func (mp *mockProvider) IsRetryableError(err error) bool {
+	return mp.retryableError != nil && err != nil && err.Error() == mp.retryableError.Error()
+}
+
+func (mp *mockProvider) HistoricalReadTimeQuery() string {
+	return mp.historicalReadTimeQuery
+}
+
+func (mp *mockProvider) CurrentTimeQuery() string {
+	return mp.currentTimeQuery
+}
@@ -94,3 +94,15 @@ func (tp *sqliteGoTestProvider) Open(url string) (*sql.DB, error) {
 func (tp *sqliteGoTestProvider) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
 	return sqlite3.WithInstance(db, &sqlite3.Config{})
 }
+
+func (tp *sqliteGoTestProvider) IsRetryableError(err error) bool {
+	return false
+}
+
+func (tp *sqliteGoTestProvider) HistoricalReadTimeQuery() string {
+	return ""
+}
+
+func (tp *sqliteGoTestProvider) CurrentTimeQuery() string {
+	return "SELECT CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+}
@@ -0,0 +1,163 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	pseudonymColumns = []string{
+		"id",
+		"message_id",
+		"namespace",
+		"key",
+		"owner",
+		"created",
+	}
+	pseudonymFilterFieldMap = map[string]string{
+		"message": "message_id",
+	}
+)
+
+func (s *SQLCommon) UpsertPseudonym(ctx context.Context, pseudonym *fftypes.Pseudonym) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Do a select within the transaction to detemine if the UUID already exists
+	rows, _, err := s.queryTx(ctx, tx,
+		sq.Select("id").
+			From("pseudonym").
+			Where(sq.Eq{"id": pseudonym.ID}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := rows.Next()
+	rows.Close()
+
+	if existing {
+		// The owner is deliberately not updated, as it is only known to the node that registered the pseudonym
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("pseudonym").
+				Set("message_id", pseudonym.Message).
+				Where(sq.Eq{"id": pseudonym.ID}),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionPseudonyms, fftypes.ChangeEventTypeUpdated, pseudonym.Namespace, pseudonym.ID)
+			},
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("pseudonym").
+				Columns(pseudonymColumns...).
+				Values(
+					pseudonym.ID,
+					pseudonym.Message,
+					pseudonym.Namespace,
+					pseudonym.Key,
+					pseudonym.Owner,
+					pseudonym.Created,
+				),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionPseudonyms, fftypes.ChangeEventTypeCreated, pseudonym.Namespace, pseudonym.ID)
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) pseudonymResult(ctx context.Context, row *sql.Rows) (*fftypes.Pseudonym, error) {
+	var pseudonym fftypes.Pseudonym
+	err := row.Scan(
+		&pseudonym.ID,
+		&pseudonym.Message,
+		&pseudonym.Namespace,
+		&pseudonym.Key,
+		&pseudonym.Owner,
+		&pseudonym.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "pseudonym")
+	}
+	return &pseudonym, nil
+}
+
+func (s *SQLCommon) getPseudonymPred(ctx context.Context, desc string, pred interface{}) (*fftypes.Pseudonym, error) {
+	rows, _, err := s.query(ctx,
+		sq.Select(pseudonymColumns...).
+			From("pseudonym").
+			Where(pred),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Pseudonym '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.pseudonymResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetPseudonymByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Pseudonym, error) {
+	return s.getPseudonymPred(ctx, id.String(), sq.Eq{"id": id})
+}
+
+func (s *SQLCommon) GetPseudonymByKey(ctx context.Context, key string) (*fftypes.Pseudonym, error) {
+	return s.getPseudonymPred(ctx, key, sq.Eq{"key": key})
+}
+
+func (s *SQLCommon) GetPseudonyms(ctx context.Context, filter database.Filter) (pseudonyms []*fftypes.Pseudonym, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(pseudonymColumns...).From("pseudonym"), filter, pseudonymFilterFieldMap, []interface{}{"seq"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	pseudonyms = []*fftypes.Pseudonym{}
+	for rows.Next() {
+		pseudonym, err := s.pseudonymResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		pseudonyms = append(pseudonyms, pseudonym)
+	}
+
+	return pseudonyms, s.queryRes(ctx, tx, "pseudonym", fop, fi), err
+}
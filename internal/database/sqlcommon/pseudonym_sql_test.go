@@ -0,0 +1,187 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPseudonymE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new pseudonym, with a locally known owner
+	pseudonym := &fftypes.Pseudonym{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+		Owner:     "did:firefly:org/org1",
+		Created:   fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionPseudonyms, fftypes.ChangeEventTypeCreated, "ns1", pseudonym.ID, mock.Anything).Return()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionPseudonyms, fftypes.ChangeEventTypeUpdated, "ns1", pseudonym.ID, mock.Anything).Return()
+
+	err := s.UpsertPseudonym(ctx, pseudonym)
+	assert.NoError(t, err)
+
+	// Check we get the exact same pseudonym back, by ID and by key
+	pseudonymRead, err := s.GetPseudonymByID(ctx, pseudonym.ID)
+	assert.NoError(t, err)
+	pseudonymJson, _ := json.Marshal(&pseudonym)
+	pseudonymReadJson, _ := json.Marshal(&pseudonymRead)
+	assert.Equal(t, string(pseudonymJson), string(pseudonymReadJson))
+
+	pseudonymRead, err = s.GetPseudonymByKey(ctx, "0x12345")
+	assert.NoError(t, err)
+	pseudonymReadJson, _ = json.Marshal(&pseudonymRead)
+	assert.Equal(t, string(pseudonymJson), string(pseudonymReadJson))
+
+	// Update it from a confirmed broadcast, which does not carry the owner
+	update := *pseudonym
+	update.Message = fftypes.NewUUID()
+	update.Owner = ""
+	err = s.UpsertPseudonym(ctx, &update)
+	assert.NoError(t, err)
+
+	// Query back the pseudonym, and check the owner was retained
+	fb := database.PseudonymQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("message", update.Message),
+	)
+	pseudonyms, res, err := s.GetPseudonyms(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pseudonyms))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	assert.Equal(t, "did:firefly:org/org1", pseudonyms[0].Owner)
+	assert.Equal(t, *update.Message, *pseudonyms[0].Message)
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestUpsertPseudonymFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertPseudonym(context.Background(), &fftypes.Pseudonym{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPseudonymFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertPseudonym(context.Background(), &fftypes.Pseudonym{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPseudonymFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertPseudonym(context.Background(), &fftypes.Pseudonym{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPseudonymFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	pseudonymID := fftypes.NewUUID()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(pseudonymID.String()))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertPseudonym(context.Background(), &fftypes.Pseudonym{ID: pseudonymID})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPseudonymFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertPseudonym(context.Background(), &fftypes.Pseudonym{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPseudonymByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetPseudonymByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPseudonymByKeyNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	pseudonym, err := s.GetPseudonymByKey(context.Background(), "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, pseudonym)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPseudonymByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetPseudonymByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPseudonymsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.PseudonymQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPseudonyms(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPseudonymsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.PseudonymQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetPseudonyms(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetPseudonymsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.PseudonymQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetPseudonyms(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,143 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	receiptColumns = []string{
+		"id",
+		"namespace",
+		"message_id",
+		"author",
+		"key",
+		"created",
+	}
+	receiptFilterFieldMap = map[string]string{
+		"message": "message_id",
+	}
+)
+
+func (s *SQLCommon) UpsertReceipt(ctx context.Context, receipt *fftypes.MessageReceipt) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Do a select within the transaction to detemine if the org has already sent a receipt for the message
+	receiptRows, _, err := s.queryTx(ctx, tx,
+		sq.Select(sequenceColumn).
+			From("receipts").
+			Where(sq.Eq{
+				"message_id": receipt.Message,
+				"author":     receipt.Author,
+			}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := receiptRows.Next()
+	var rowID int64
+	if existing {
+		if err = receiptRows.Scan(&rowID); err != nil {
+			receiptRows.Close()
+			return i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "receipts")
+		}
+	}
+	receiptRows.Close()
+
+	if existing {
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("receipts").
+				Set("id", receipt.ID).
+				Set("key", receipt.Key).
+				Set("created", receipt.Created).
+				Where(sq.Eq{sequenceColumn: rowID}),
+			nil, // receipts do not have events
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("receipts").
+				Columns(receiptColumns...).
+				Values(
+					receipt.ID,
+					receipt.Namespace,
+					receipt.Message,
+					receipt.Author,
+					receipt.Key,
+					receipt.Created,
+				),
+			nil, // receipts do not have events
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) receiptResult(ctx context.Context, row *sql.Rows) (*fftypes.MessageReceipt, error) {
+	receipt := fftypes.MessageReceipt{}
+	err := row.Scan(
+		&receipt.ID,
+		&receipt.Namespace,
+		&receipt.Message,
+		&receipt.Author,
+		&receipt.Key,
+		&receipt.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "receipts")
+	}
+	return &receipt, nil
+}
+
+func (s *SQLCommon) GetReceipts(ctx context.Context, filter database.Filter) ([]*fftypes.MessageReceipt, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(receiptColumns...).From("receipts"), filter, receiptFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	receipts := []*fftypes.MessageReceipt{}
+	for rows.Next() {
+		receipt, err := s.receiptResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, s.queryRes(ctx, tx, "receipts", fop, fi), err
+}
@@ -0,0 +1,182 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiptsE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new receipt
+	msgID := fftypes.NewUUID()
+	receipt := &fftypes.MessageReceipt{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   msgID,
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/org2",
+			Key:    "0x12345",
+		},
+		Created: fftypes.Now(),
+	}
+	err := s.UpsertReceipt(ctx, receipt)
+	assert.NoError(t, err)
+
+	fb := database.ReceiptQueryFactory.NewFilter(ctx)
+	receipts, res, err := s.GetReceipts(ctx, fb.And(fb.Eq("message", msgID)).Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), *res.TotalCount)
+	receiptJson, _ := json.Marshal(&receipt)
+	receiptReadJson, _ := json.Marshal(receipts[0])
+	assert.Equal(t, string(receiptJson), string(receiptReadJson))
+
+	// A second receipt from the same org replaces the first
+	receiptUpdated := &fftypes.MessageReceipt{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   msgID,
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/org2",
+			Key:    "0x23456",
+		},
+		Created: fftypes.Now(),
+	}
+	err = s.UpsertReceipt(ctx, receiptUpdated)
+	assert.NoError(t, err)
+
+	// A receipt from another org is added
+	receipt2 := &fftypes.MessageReceipt{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   msgID,
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/org3",
+			Key:    "0x34567",
+		},
+		Created: fftypes.Now(),
+	}
+	err = s.UpsertReceipt(ctx, receipt2)
+	assert.NoError(t, err)
+
+	receipts, _, err = s.GetReceipts(ctx, fb.And(fb.Eq("message", msgID)).Sort("author"))
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 2)
+	receiptJson, _ = json.Marshal(&receiptUpdated)
+	receiptReadJson, _ = json.Marshal(receipts[0])
+	assert.Equal(t, string(receiptJson), string(receiptReadJson))
+	assert.Equal(t, receipt2.ID, receipts[1].ID)
+}
+
+func TestUpsertReceiptFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertReceiptFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{Message: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertReceiptScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}).AddRow())
+	mock.ExpectRollback()
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{Message: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertReceiptFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{Message: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertReceiptFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{sequenceColumn}).
+		AddRow(int64(12345)))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{Message: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertReceiptFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{sequenceColumn}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertReceipt(context.Background(), &fftypes.MessageReceipt{Message: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetReceiptsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.ReceiptQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetReceiptsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.ReceiptQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetReceiptsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.ReceiptQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
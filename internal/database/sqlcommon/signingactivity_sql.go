@@ -0,0 +1,116 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	signingActivityColumns = []string{
+		"id",
+		"namespace",
+		"key",
+		"optype",
+		"plugin",
+		"op_id",
+		"tx_id",
+		"created",
+	}
+	signingActivityFilterFieldMap = map[string]string{
+		"type":      "optype",
+		"operation": "op_id",
+		"tx":        "tx_id",
+	}
+)
+
+func (s *SQLCommon) InsertSigningActivity(ctx context.Context, activity *fftypes.SigningActivity) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("signingactivity").
+			Columns(signingActivityColumns...).
+			Values(
+				activity.ID,
+				activity.Namespace,
+				activity.Key,
+				activity.Type,
+				activity.Plugin,
+				activity.Operation,
+				activity.Transaction,
+				activity.Created,
+			),
+		nil, // no change events for signing activity
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) signingActivityResult(ctx context.Context, row *sql.Rows) (*fftypes.SigningActivity, error) {
+	activity := fftypes.SigningActivity{}
+	err := row.Scan(
+		&activity.ID,
+		&activity.Namespace,
+		&activity.Key,
+		&activity.Type,
+		&activity.Plugin,
+		&activity.Operation,
+		&activity.Transaction,
+		&activity.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "signingactivity")
+	}
+	return &activity, nil
+}
+
+func (s *SQLCommon) GetSigningActivity(ctx context.Context, filter database.Filter) ([]*fftypes.SigningActivity, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(signingActivityColumns...).From("signingactivity"), filter, signingActivityFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	activity := []*fftypes.SigningActivity{}
+	for rows.Next() {
+		a, err := s.signingActivityResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		activity = append(activity, a)
+	}
+
+	return activity, s.queryRes(ctx, tx, "signingactivity", fop, fi), err
+}
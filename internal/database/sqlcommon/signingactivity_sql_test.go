@@ -0,0 +1,135 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningActivityE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Record some activity for two keys
+	activity1 := &fftypes.SigningActivity{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Key:         "0x12345",
+		Type:        fftypes.OpTypeBlockchainBatchPin,
+		Plugin:      "ethereum",
+		Operation:   fftypes.NewUUID(),
+		Transaction: fftypes.NewUUID(),
+		Created:     fftypes.Now(),
+	}
+	err := s.InsertSigningActivity(ctx, activity1)
+	assert.NoError(t, err)
+	activity2 := &fftypes.SigningActivity{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x23456",
+		Type:      fftypes.OpTypeTokenTransfer,
+		Plugin:    "erc1155",
+		Operation: fftypes.NewUUID(),
+		Created:   fftypes.Now(),
+	}
+	err = s.InsertSigningActivity(ctx, activity2)
+	assert.NoError(t, err)
+
+	// Query back by key
+	fb := database.SigningActivityQueryFactory.NewFilter(ctx)
+	activity, res, err := s.GetSigningActivity(ctx, fb.And(
+		fb.Eq("key", "0x12345"),
+		fb.Eq("type", fftypes.OpTypeBlockchainBatchPin),
+		fb.Gte("created", activity1.Created),
+	).Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), *res.TotalCount)
+	activityJson, _ := json.Marshal(&activity1)
+	activityReadJson, _ := json.Marshal(activity[0])
+	assert.Equal(t, string(activityJson), string(activityReadJson))
+
+	// Query back by operation
+	activity, _, err = s.GetSigningActivity(ctx, fb.And(fb.Eq("operation", activity2.Operation)))
+	assert.NoError(t, err)
+	assert.Len(t, activity, 1)
+	activityJson, _ = json.Marshal(&activity2)
+	activityReadJson, _ = json.Marshal(activity[0])
+	assert.Equal(t, string(activityJson), string(activityReadJson))
+}
+
+func TestInsertSigningActivityFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertSigningActivity(context.Background(), &fftypes.SigningActivity{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSigningActivityFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertSigningActivity(context.Background(), &fftypes.SigningActivity{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSigningActivityFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertSigningActivity(context.Background(), &fftypes.SigningActivity{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSigningActivityQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.SigningActivityQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetSigningActivity(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSigningActivityBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.SigningActivityQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetSigningActivity(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetSigningActivityScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.SigningActivityQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetSigningActivity(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,139 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	snapshotColumns = []string{
+		"id",
+		"stype",
+		"name",
+		"current",
+		"state",
+		"created",
+	}
+)
+
+func (s *SQLCommon) UpsertSnapshot(ctx context.Context, snapshot *fftypes.Snapshot) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	// Do a select within the transaction to detemine if there is an existing snapshot to replace
+	snapshotRows, _, err := s.queryTx(ctx, tx,
+		sq.Select(sequenceColumn).
+			From("snapshots").
+			Where(sq.Eq{
+				"stype": snapshot.Type,
+				"name":  snapshot.Name,
+			}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := snapshotRows.Next()
+	var rowID int64
+	if existing {
+		if err = snapshotRows.Scan(&rowID); err != nil {
+			snapshotRows.Close()
+			return i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "snapshots")
+		}
+	}
+	snapshotRows.Close()
+
+	if existing {
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("snapshots").
+				Set("id", snapshot.ID).
+				Set("current", snapshot.Offset).
+				Set("state", snapshot.State).
+				Set("created", snapshot.Created).
+				Where(sq.Eq{sequenceColumn: rowID}),
+			nil, // snapshots do not have events
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("snapshots").
+				Columns(snapshotColumns...).
+				Values(
+					snapshot.ID,
+					snapshot.Type,
+					snapshot.Name,
+					snapshot.Offset,
+					snapshot.State,
+					snapshot.Created,
+				),
+			nil, // snapshots do not have events
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) snapshotResult(ctx context.Context, row *sql.Rows) (*fftypes.Snapshot, error) {
+	snapshot := fftypes.Snapshot{}
+	err := row.Scan(
+		&snapshot.ID,
+		&snapshot.Type,
+		&snapshot.Name,
+		&snapshot.Offset,
+		&snapshot.State,
+		&snapshot.Created,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "snapshots")
+	}
+	return &snapshot, nil
+}
+
+func (s *SQLCommon) GetSnapshot(ctx context.Context, t fftypes.SnapshotType, name string) (*fftypes.Snapshot, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(snapshotColumns...).
+			From("snapshots").
+			Where(sq.Eq{
+				"stype": t,
+				"name":  name,
+			}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Snapshot '%s:%s' not found", t, name)
+		return nil, nil
+	}
+
+	return s.snapshotResult(ctx, rows)
+}
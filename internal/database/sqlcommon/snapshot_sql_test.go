@@ -0,0 +1,153 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotsE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Nothing before the first snapshot
+	snapshotRead, err := s.GetSnapshot(ctx, fftypes.SnapshotTypeAggregator, "ff_aggregator")
+	assert.NoError(t, err)
+	assert.Nil(t, snapshotRead)
+
+	// Create the first snapshot
+	snapshot := &fftypes.Snapshot{
+		ID:      fftypes.NewUUID(),
+		Type:    fftypes.SnapshotTypeAggregator,
+		Name:    "ff_aggregator",
+		Offset:  12345,
+		State:   fftypes.JSONObject{"blocked": []interface{}{"ctx1"}},
+		Created: fftypes.Now(),
+	}
+	err = s.UpsertSnapshot(ctx, snapshot)
+	assert.NoError(t, err)
+
+	snapshotRead, err = s.GetSnapshot(ctx, snapshot.Type, snapshot.Name)
+	assert.NoError(t, err)
+	snapshotJson, _ := json.Marshal(&snapshot)
+	snapshotReadJson, _ := json.Marshal(&snapshotRead)
+	assert.Equal(t, string(snapshotJson), string(snapshotReadJson))
+
+	// Replace it with a newer one
+	snapshotUpdated := &fftypes.Snapshot{
+		ID:      fftypes.NewUUID(),
+		Type:    fftypes.SnapshotTypeAggregator,
+		Name:    "ff_aggregator",
+		Offset:  23456,
+		Created: fftypes.Now(),
+	}
+	err = s.UpsertSnapshot(ctx, snapshotUpdated)
+	assert.NoError(t, err)
+
+	snapshotRead, err = s.GetSnapshot(ctx, snapshot.Type, snapshot.Name)
+	assert.NoError(t, err)
+	snapshotJson, _ = json.Marshal(&snapshotUpdated)
+	snapshotReadJson, _ = json.Marshal(&snapshotRead)
+	assert.Equal(t, string(snapshotJson), string(snapshotReadJson))
+}
+
+func TestUpsertSnapshotFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSnapshotFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{Name: "name1"})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSnapshotScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}).AddRow())
+	mock.ExpectRollback()
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{Name: "name1"})
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSnapshotFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{Name: "name1"})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSnapshotFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{sequenceColumn}).
+		AddRow(int64(12345)))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{Name: "name1"})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSnapshotFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{sequenceColumn}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertSnapshot(context.Background(), &fftypes.Snapshot{Name: "name1"})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSnapshotSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetSnapshot(context.Background(), fftypes.SnapshotTypeAggregator, "name1")
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSnapshotScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetSnapshot(context.Background(), fftypes.SnapshotTypeAggregator, "name1")
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
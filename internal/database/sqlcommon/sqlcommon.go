@@ -20,12 +20,15 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 
@@ -34,10 +37,22 @@ import (
 )
 
 type SQLCommon struct {
-	db           *sql.DB
-	capabilities *database.Capabilities
-	callbacks    database.Callbacks
-	provider     Provider
+	db              *sql.DB
+	capabilities    *database.Capabilities
+	callbacks       database.Callbacks
+	provider        Provider
+	healthMux       sync.Mutex
+	health          connHealth
+	txRetryAttempts int
+	metricsEnabled  bool
+}
+
+type connHealth struct {
+	healthy    bool
+	lastError  string
+	lastCheck  *fftypes.FFTime
+	reconnects int64
+	clockSkew  *fftypes.FFDuration
 }
 
 type txContextKey struct{}
@@ -63,6 +78,25 @@ func (s *SQLCommon) Init(ctx context.Context, provider Provider, prefix config.P
 	if connLimit > 0 {
 		s.db.SetMaxOpenConns(connLimit)
 	}
+	idleLimit := prefix.GetInt(SQLConfMaxIdleConnections)
+	if idleLimit > 0 {
+		s.db.SetMaxIdleConns(idleLimit)
+	}
+	if lifetime := prefix.GetDuration(SQLConfMaxConnectionLifetime); lifetime > 0 {
+		s.db.SetConnMaxLifetime(lifetime)
+	}
+	if idleTime := prefix.GetDuration(SQLConfMaxConnectionIdleTime); idleTime > 0 {
+		s.db.SetConnMaxIdleTime(idleTime)
+	}
+	s.txRetryAttempts = prefix.GetInt(SQLConfTxRetryMaxAttempts)
+	s.health.healthy = true
+	s.metricsEnabled = config.GetBool(config.MetricsEnabled)
+	if s.metricsEnabled {
+		metrics.RegisterDBStats(provider.Name(), s.db)
+	}
+	if interval := prefix.GetDuration(SQLConfHealthCheckInterval); interval > 0 {
+		go s.healthCheckLoop(ctx, interval)
+	}
 
 	if prefix.GetBool(SQLConfMigrationsAuto) {
 		if err = s.applyDBMigrations(ctx, prefix, provider); err != nil {
@@ -75,12 +109,113 @@ func (s *SQLCommon) Init(ctx context.Context, provider Provider, prefix config.P
 
 func (s *SQLCommon) Capabilities() *database.Capabilities { return s.capabilities }
 
+func (s *SQLCommon) ConnectionStatus(ctx context.Context) *fftypes.NodeStatusDatabase {
+	stats := s.db.Stats()
+	s.healthMux.Lock()
+	defer s.healthMux.Unlock()
+	return &fftypes.NodeStatusDatabase{
+		Provider:           s.provider.Name(),
+		Healthy:            s.health.healthy,
+		LastError:          s.health.lastError,
+		LastCheck:          s.health.lastCheck,
+		Reconnects:         s.health.reconnects,
+		ClockSkew:          s.health.clockSkew,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       fftypes.FFDuration(stats.WaitDuration),
+	}
+}
+
+func (s *SQLCommon) healthCheckLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkHealth(ctx)
+		case <-ctx.Done():
+			log.L(ctx).Debugf("Database health check loop exiting")
+			return
+		}
+	}
+}
+
+func (s *SQLCommon) checkHealth(ctx context.Context) {
+	// The sql.DB pool discards broken connections and dials new ones on demand,
+	// so a successful ping after a failure means connectivity has been re-established
+	err := s.db.PingContext(ctx)
+	var clockSkew *fftypes.FFDuration
+	if err == nil {
+		clockSkew = s.measureClockSkew(ctx)
+	}
+	s.healthMux.Lock()
+	defer s.healthMux.Unlock()
+	s.health.lastCheck = fftypes.Now()
+	switch {
+	case err != nil:
+		if s.health.healthy {
+			log.L(ctx).Errorf("Database connection lost: %s", err)
+		}
+		s.health.healthy = false
+		s.health.lastError = err.Error()
+	case !s.health.healthy:
+		log.L(ctx).Infof("Database connection re-established")
+		s.health.healthy = true
+		s.health.lastError = ""
+		s.health.reconnects++
+	}
+	if clockSkew != nil {
+		s.health.clockSkew = clockSkew
+	}
+}
+
+// measureClockSkew compares the clock of the database to the local clock, at the midpoint of the query.
+// A positive skew means the local clock is ahead of the database.
+func (s *SQLCommon) measureClockSkew(ctx context.Context) *fftypes.FFDuration {
+	query := s.provider.CurrentTimeQuery()
+	if query == "" {
+		return nil
+	}
+	var dbMillis int64
+	before := time.Now()
+	if err := s.db.QueryRowContext(ctx, query).Scan(&dbMillis); err != nil {
+		log.L(ctx).Warnf("Failed to query database time: %s", err)
+		return nil
+	}
+	local := before.Add(time.Since(before) / 2)
+	skew := fftypes.FFDuration(local.Sub(time.Unix(0, dbMillis*int64(time.Millisecond))))
+	return &skew
+}
+
 func (s *SQLCommon) RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) error {
 	if tx := getTXFromContext(ctx); tx != nil {
 		// transaction already exists - just continue using it
 		return fn(ctx)
 	}
+	return s.runGroupTx(ctx, fn)
+}
+
+func (s *SQLCommon) RunAsRetryableGroup(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx := getTXFromContext(ctx); tx != nil {
+		// transaction already exists - just continue using it
+		return fn(ctx)
+	}
+
+	// Some databases (such as CockroachDB) can abort a transaction due to a conflict with another
+	// transaction, in which case an idempotent group is safe to re-run from the start
+	for attempt := 1; ; attempt++ {
+		err := s.runGroupTx(ctx, fn)
+		if err == nil || attempt >= s.txRetryAttempts || !s.provider.IsRetryableError(err) {
+			return err
+		}
+		log.L(ctx).Warnf("Retrying group of database operations after attempt %d failed: %s", attempt, err)
+	}
+}
 
+func (s *SQLCommon) runGroupTx(ctx context.Context, fn func(ctx context.Context) error) error {
 	ctx, tx, _, err := s.beginOrUseTx(ctx)
 	if err != nil {
 		return err
@@ -146,6 +281,33 @@ func (s *SQLCommon) beginOrUseTx(ctx context.Context) (ctx1 context.Context, tx
 	return ctx1, tx, false, err
 }
 
+// historicalReadClause returns the AS OF SYSTEM TIME clause for a read made for an API query (see
+// database.WithHistoricalReads), which follows any joins. Empty is returned for reads in a transaction,
+// for event processing, and for databases that do not support historical reads.
+func (s *SQLCommon) historicalReadClause(ctx context.Context, tx *txWrapper) string {
+	query := s.provider.HistoricalReadTimeQuery()
+	if tx != nil || query == "" {
+		return ""
+	}
+	return database.HistoricalReadClause(ctx, func() string {
+		var asOf time.Time
+		if err := s.db.QueryRowContext(ctx, query).Scan(&asOf); err != nil {
+			log.L(ctx).Warnf("Failed to query the time for historical reads - reading latest: %s", err)
+			return ""
+		}
+		return fmt.Sprintf("AS OF SYSTEM TIME '%s'", asOf.UTC().Format(time.RFC3339Nano))
+	})
+}
+
+// dbError wraps an error from the database, reporting a conflict with a concurrent transaction (such as a
+// serialization failure on CockroachDB) as an error the caller can retry, rather than an internal error
+func (s *SQLCommon) dbError(ctx context.Context, err error, msg i18n.MessageKey) error {
+	if s.provider.IsRetryableError(err) {
+		return i18n.WrapError(ctx, err, i18n.MsgDBTransactionConflict)
+	}
+	return i18n.WrapError(ctx, err, msg)
+}
+
 func (s *SQLCommon) queryTx(ctx context.Context, tx *txWrapper, q sq.SelectBuilder) (*sql.Rows, *txWrapper, error) {
 	if tx == nil {
 		// If there is a transaction in the context, we should use it to provide consistency
@@ -153,6 +315,10 @@ func (s *SQLCommon) queryTx(ctx context.Context, tx *txWrapper, q sq.SelectBuild
 		tx = getTXFromContext(ctx)
 	}
 
+	if clause := s.historicalReadClause(ctx, tx); clause != "" {
+		q = q.JoinClause(clause)
+	}
+
 	l := log.L(ctx)
 	sqlQuery, args, err := q.PlaceholderFormat(s.provider.PlaceholderFormat()).ToSql()
 	if err != nil {
@@ -160,6 +326,7 @@ func (s *SQLCommon) queryTx(ctx context.Context, tx *txWrapper, q sq.SelectBuild
 	}
 	l.Debugf(`SQL-> query: %s`, sqlQuery)
 	l.Tracef(`SQL-> query args: %+v`, args)
+	defer s.observeQuery("query", time.Now())
 	var rows *sql.Rows
 	if tx != nil {
 		rows, err = tx.sqlTX.QueryContext(ctx, sqlQuery, args...)
@@ -168,7 +335,7 @@ func (s *SQLCommon) queryTx(ctx context.Context, tx *txWrapper, q sq.SelectBuild
 	}
 	if err != nil {
 		l.Errorf(`SQL query failed: %s sql=[ %s ]`, err, sqlQuery)
-		return nil, tx, i18n.WrapError(ctx, err, i18n.MsgDBQueryFailed)
+		return nil, tx, s.dbError(ctx, err, i18n.MsgDBQueryFailed)
 	}
 	l.Debugf(`SQL<- query`)
 	return rows, tx, nil
@@ -189,13 +356,18 @@ func (s *SQLCommon) countQuery(ctx context.Context, tx *txWrapper, tableName str
 	if countExpr == "" {
 		countExpr = "*"
 	}
-	q := sq.Select(fmt.Sprintf("COUNT(%s)", countExpr)).From(tableName).Where(fop)
+	q := sq.Select(fmt.Sprintf("COUNT(%s)", countExpr)).From(tableName)
+	if clause := s.historicalReadClause(ctx, tx); clause != "" {
+		q = q.JoinClause(clause)
+	}
+	q = q.Where(fop)
 	sqlQuery, args, err := q.PlaceholderFormat(s.provider.PlaceholderFormat()).ToSql()
 	if err != nil {
 		return count, i18n.WrapError(ctx, err, i18n.MsgDBQueryBuildFailed)
 	}
 	l.Debugf(`SQL-> count query: %s`, sqlQuery)
 	l.Tracef(`SQL-> count query args: %+v`, args)
+	defer s.observeQuery("count", time.Now())
 	var rows *sql.Rows
 	if tx != nil {
 		rows, err = tx.sqlTX.QueryContext(ctx, sqlQuery, args...)
@@ -204,7 +376,7 @@ func (s *SQLCommon) countQuery(ctx context.Context, tx *txWrapper, tableName str
 	}
 	if err != nil {
 		l.Errorf(`SQL count query failed: %s sql=[ %s ]`, err, sqlQuery)
-		return count, i18n.WrapError(ctx, err, i18n.MsgDBQueryFailed)
+		return count, s.dbError(ctx, err, i18n.MsgDBQueryFailed)
 	}
 	defer rows.Close()
 	if rows.Next() {
@@ -239,18 +411,19 @@ func (s *SQLCommon) insertTx(ctx context.Context, tx *txWrapper, q sq.InsertBuil
 	}
 	l.Debugf(`SQL-> insert: %s`, sqlQuery)
 	l.Tracef(`SQL-> insert args: %+v`, args)
+	defer s.observeQuery("insert", time.Now())
 	var sequence int64
 	if useQuery {
 		err := tx.sqlTX.QueryRowContext(ctx, sqlQuery, args...).Scan(&sequence)
 		if err != nil {
 			l.Errorf(`SQL insert failed: %s sql=[ %s ]: %s`, err, sqlQuery, err)
-			return -1, i18n.WrapError(ctx, err, i18n.MsgDBInsertFailed)
+			return -1, s.dbError(ctx, err, i18n.MsgDBInsertFailed)
 		}
 	} else {
 		res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
 		if err != nil {
 			l.Errorf(`SQL insert failed: %s sql=[ %s ]: %s`, err, sqlQuery, err)
-			return -1, i18n.WrapError(ctx, err, i18n.MsgDBInsertFailed)
+			return -1, s.dbError(ctx, err, i18n.MsgDBInsertFailed)
 		}
 		sequence, _ = res.LastInsertId()
 	}
@@ -270,10 +443,11 @@ func (s *SQLCommon) deleteTx(ctx context.Context, tx *txWrapper, q sq.DeleteBuil
 	}
 	l.Debugf(`SQL-> delete: %s`, sqlQuery)
 	l.Tracef(`SQL-> delete args: %+v`, args)
+	defer s.observeQuery("delete", time.Now())
 	res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
 	if err != nil {
 		l.Errorf(`SQL delete failed: %s sql=[ %s ]: %s`, err, sqlQuery, err)
-		return i18n.WrapError(ctx, err, i18n.MsgDBDeleteFailed)
+		return s.dbError(ctx, err, i18n.MsgDBDeleteFailed)
 	}
 	ra, _ := res.RowsAffected()
 	l.Debugf(`SQL<- delete affected=%d`, ra)
@@ -295,10 +469,11 @@ func (s *SQLCommon) updateTx(ctx context.Context, tx *txWrapper, q sq.UpdateBuil
 	}
 	l.Debugf(`SQL-> update: %s`, sqlQuery)
 	l.Tracef(`SQL-> update args: %+v`, args)
+	defer s.observeQuery("update", time.Now())
 	res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
 	if err != nil {
 		l.Errorf(`SQL update failed: %s sql=[ %s ]`, err, sqlQuery)
-		return -1, i18n.WrapError(ctx, err, i18n.MsgDBUpdateFailed)
+		return -1, s.dbError(ctx, err, i18n.MsgDBUpdateFailed)
 	}
 	ra, _ := res.RowsAffected()
 	l.Debugf(`SQL<- update affected=%d`, ra)
@@ -309,6 +484,13 @@ func (s *SQLCommon) updateTx(ctx context.Context, tx *txWrapper, q sq.UpdateBuil
 	return ra, nil
 }
 
+// observeQuery records the duration of a database operation, including any failures
+func (s *SQLCommon) observeQuery(operation string, start time.Time) {
+	if s.metricsEnabled {
+		metrics.DBQueryDurationHistogram.WithLabelValues(s.provider.Name(), operation).Observe(time.Since(start).Seconds())
+	}
+}
+
 func (s *SQLCommon) postCommitEvent(tx *txWrapper, fn func()) {
 	tx.postCommit = append(tx.postCommit, fn)
 }
@@ -340,7 +522,7 @@ func (s *SQLCommon) commitTx(ctx context.Context, tx *txWrapper, autoCommit bool
 	err := tx.sqlTX.Commit()
 	if err != nil {
 		l.Errorf(`SQL commit failed: %s`, err)
-		return i18n.WrapError(ctx, err, i18n.MsgDBCommitFailed)
+		return s.dbError(ctx, err, i18n.MsgDBCommitFailed)
 	}
 	l.Debugf(`SQL<- commit`)
 
@@ -21,11 +21,15 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -57,6 +61,130 @@ func TestInitSQLCommonMigrationOpenFailed(t *testing.T) {
 	assert.Regexp(t, "FF10163.*pop", err)
 }
 
+func TestInitSQLCommonPoolOptions(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, true)
+	defer config.Reset()
+	defer metrics.Clear()
+	mp := newMockProvider()
+	mp.prefix.Set(SQLConfMaxConnections, 10)
+	mp.prefix.Set(SQLConfMaxIdleConnections, 5)
+	mp.prefix.Set(SQLConfMaxConnectionLifetime, "5m")
+	mp.prefix.Set(SQLConfMaxConnectionIdleTime, "1m")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := mp.SQLCommon.Init(ctx, mp, mp.prefix, mp.callbacks, mp.capabilities)
+	assert.NoError(t, err)
+
+	// Registering a second time replaces the existing collector
+	err = mp.SQLCommon.Init(ctx, mp, mp.prefix, mp.callbacks, mp.capabilities)
+	assert.NoError(t, err)
+
+	status := mp.ConnectionStatus(ctx)
+	assert.Equal(t, "mockdb", status.Provider)
+	assert.True(t, status.Healthy)
+	assert.Equal(t, 10, status.MaxOpenConnections)
+}
+
+func TestQueryDurationMetrics(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, true)
+	defer config.Reset()
+	defer metrics.Clear()
+	s, mdb := newMockProvider().init()
+	mdb.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	rows, _, err := s.query(context.Background(), sq.Select("id").From("table1"))
+	assert.NoError(t, err)
+	rows.Close()
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.DBQueryDurationHistogram))
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestCheckHealthLostAndReconnected(t *testing.T) {
+	mp := newMockProvider()
+	mp.mockDB, mp.mdb, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+	mp.prefix.Set(SQLConfHealthCheckInterval, "0")
+	s, mdb := mp.init()
+	ctx := context.Background()
+
+	mdb.ExpectPing().WillReturnError(fmt.Errorf("pop"))
+	s.checkHealth(ctx)
+	status := s.ConnectionStatus(ctx)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "pop", status.LastError)
+	assert.NotNil(t, status.LastCheck)
+
+	mdb.ExpectPing().WillReturnError(fmt.Errorf("pop"))
+	s.checkHealth(ctx)
+	assert.False(t, s.ConnectionStatus(ctx).Healthy)
+
+	mdb.ExpectPing()
+	s.checkHealth(ctx)
+	status = s.ConnectionStatus(ctx)
+	assert.True(t, status.Healthy)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, int64(1), status.Reconnects)
+
+	mdb.ExpectPing()
+	s.checkHealth(ctx)
+	assert.Equal(t, int64(1), s.ConnectionStatus(ctx).Reconnects)
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestCheckHealthClockSkew(t *testing.T) {
+	mp := newMockProvider()
+	mp.mockDB, mp.mdb, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+	mp.prefix.Set(SQLConfHealthCheckInterval, "0")
+	mp.currentTimeQuery = "SELECT NOW()"
+	s, mdb := mp.init()
+	ctx := context.Background()
+
+	dbTime := time.Now().Add(-1*time.Hour).UnixNano() / int64(time.Millisecond)
+	mdb.ExpectPing()
+	mdb.ExpectQuery("SELECT NOW()").WillReturnRows(sqlmock.NewRows([]string{"now"}).AddRow(dbTime))
+	s.checkHealth(ctx)
+	skew := s.ConnectionStatus(ctx).ClockSkew
+	assert.NotNil(t, skew)
+	assert.GreaterOrEqual(t, int64(*skew), int64(time.Hour))
+
+	// A failed measurement keeps the last skew
+	mdb.ExpectPing()
+	mdb.ExpectQuery("SELECT NOW()").WillReturnError(fmt.Errorf("pop"))
+	s.checkHealth(ctx)
+	assert.Equal(t, skew, s.ConnectionStatus(ctx).ClockSkew)
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestCheckHealthClockSkewSQLite(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	s.checkHealth(context.Background())
+	skew := s.ConnectionStatus(context.Background()).ClockSkew
+	assert.NotNil(t, skew)
+	assert.InDelta(t, 0, int64(*skew), float64(time.Minute))
+}
+
+func TestHealthCheckLoop(t *testing.T) {
+	mp := newMockProvider()
+	mp.mockDB, mp.mdb, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+	mp.prefix.Set(SQLConfHealthCheckInterval, "0")
+	s, mdb := mp.init()
+	mdb.ExpectPing().WillReturnError(fmt.Errorf("pop"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.healthCheckLoop(ctx, 1*time.Millisecond)
+		close(done)
+	}()
+	for s.ConnectionStatus(ctx).LastCheck == nil {
+		time.Sleep(1 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	assert.False(t, s.ConnectionStatus(ctx).Healthy)
+}
+
 func TestMigrationUpDown(t *testing.T) {
 	tp, cleanup := newSQLiteTestProvider(t)
 	defer cleanup()
@@ -217,6 +345,77 @@ func TestRunAsGroupCommitFail(t *testing.T) {
 	assert.Regexp(t, "FF10119", err)
 }
 
+func TestRunAsGroupNotRetried(t *testing.T) {
+	mp := newMockProvider()
+	mp.retryableError = fmt.Errorf("restart transaction")
+	s, mock := mp.init()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	attempts := 0
+	err := s.RunAsGroup(context.Background(), func(ctx context.Context) (err error) {
+		attempts++
+		return fmt.Errorf("restart transaction")
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Regexp(t, "restart transaction", err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRunAsRetryableGroup(t *testing.T) {
+	mp := newMockProvider()
+	mp.retryableError = fmt.Errorf("restart transaction")
+	s, mock := mp.init()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	attempts := 0
+	err := s.RunAsRetryableGroup(context.Background(), func(ctx context.Context) (err error) {
+		attempts++
+		if attempts == 1 {
+			return fmt.Errorf("restart transaction")
+		}
+		return nil
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRunAsRetryableGroupNested(t *testing.T) {
+	mp := newMockProvider()
+	mp.retryableError = fmt.Errorf("restart transaction")
+	s, mock := mp.init()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	attempts := 0
+	err := s.RunAsGroup(context.Background(), func(ctx context.Context) (err error) {
+		return s.RunAsRetryableGroup(ctx, func(ctx context.Context) (err error) {
+			attempts++
+			return fmt.Errorf("restart transaction")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Regexp(t, "restart transaction", err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRunAsRetryableGroupExhausted(t *testing.T) {
+	mp := newMockProvider()
+	mp.retryableError = fmt.Errorf("restart transaction")
+	mp.prefix.Set(SQLConfTxRetryMaxAttempts, 2)
+	s, mock := mp.init()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err := s.RunAsRetryableGroup(context.Background(), func(ctx context.Context) (err error) {
+		return fmt.Errorf("restart transaction")
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Regexp(t, "restart transaction", err)
+}
+
 func TestRollbackFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
@@ -308,3 +507,92 @@ func TestQueryResSwallowError(t *testing.T) {
 	})
 	assert.Equal(t, int64(-1), *res.TotalCount)
 }
+
+func TestHistoricalReadsListAndCount(t *testing.T) {
+	mp := newMockProvider()
+	mp.historicalReadTimeQuery = "SELECT follower_read_timestamp()"
+	s, mdb := mp.init()
+	asOf := time.Date(2021, 1, 1, 0, 0, 0, 500, time.UTC)
+	mdb.ExpectQuery("^SELECT follower_read_timestamp").WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(asOf))
+	mdb.ExpectQuery("^SELECT col1 FROM table1 AS OF SYSTEM TIME '2021-01-01T00:00:00.0000005Z' WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}))
+	mdb.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM table1 AS OF SYSTEM TIME '2021-01-01T00:00:00.0000005Z' WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow(10))
+
+	// The point in time is resolved once for the request, so the page and count agree
+	ctx := database.WithHistoricalReads(context.Background())
+	rows, _, err := s.query(ctx, sq.Select("col1").From("table1").Where(sq.Eq{"col1": "val1"}))
+	assert.NoError(t, err)
+	rows.Close()
+	count, err := s.countQuery(ctx, nil, "table1", sq.Eq{"col1": "val1"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), count)
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestHistoricalReadsAfterJoins(t *testing.T) {
+	mp := newMockProvider()
+	mp.historicalReadTimeQuery = "SELECT follower_read_timestamp()"
+	s, mdb := mp.init()
+	mdb.ExpectQuery("^SELECT follower_read_timestamp").WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(time.Unix(0, 0)))
+	mdb.ExpectQuery("^SELECT md.col1 FROM table1 AS md LEFT JOIN table2 AS m ON m.id = md.id AS OF SYSTEM TIME '1970-01-01T00:00:00Z' WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}))
+
+	ctx := database.WithHistoricalReads(context.Background())
+	rows, _, err := s.query(ctx, sq.Select("md.col1").From("table1 AS md").LeftJoin("table2 AS m ON m.id = md.id").Where(sq.Eq{"md.col1": "val1"}))
+	assert.NoError(t, err)
+	rows.Close()
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestHistoricalReadsTimeQueryFail(t *testing.T) {
+	mp := newMockProvider()
+	mp.historicalReadTimeQuery = "SELECT follower_read_timestamp()"
+	s, mdb := mp.init()
+	mdb.ExpectQuery("^SELECT follower_read_timestamp").WillReturnError(fmt.Errorf("pop"))
+	mdb.ExpectQuery("^SELECT col1 FROM table1 WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}))
+	mdb.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM table1 WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow(10))
+
+	// Both the page and the count fall back to reading the latest rows
+	ctx := database.WithHistoricalReads(context.Background())
+	rows, _, err := s.query(ctx, sq.Select("col1").From("table1").Where(sq.Eq{"col1": "val1"}))
+	assert.NoError(t, err)
+	rows.Close()
+	_, err = s.countQuery(ctx, nil, "table1", sq.Eq{"col1": "val1"}, "")
+	assert.NoError(t, err)
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestHistoricalReadsNotInTx(t *testing.T) {
+	mp := newMockProvider()
+	mp.historicalReadTimeQuery = "SELECT follower_read_timestamp()"
+	s, mdb := mp.init()
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("^SELECT col1 FROM table1 WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}))
+
+	ctx, _, _, err := s.beginOrUseTx(database.WithHistoricalReads(context.Background()))
+	assert.NoError(t, err)
+	rows, _, err := s.query(ctx, sq.Select("col1").From("table1").Where(sq.Eq{"col1": "val1"}))
+	assert.NoError(t, err)
+	rows.Close()
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestHistoricalReadsNotForEventProcessing(t *testing.T) {
+	mp := newMockProvider()
+	mp.historicalReadTimeQuery = "SELECT follower_read_timestamp()"
+	s, mdb := mp.init()
+	mdb.ExpectQuery("^SELECT col1 FROM table1 WHERE").WillReturnRows(sqlmock.NewRows([]string{"col1"}))
+
+	rows, _, err := s.query(context.Background(), sq.Select("col1").From("table1").Where(sq.Eq{"col1": "val1"}))
+	assert.NoError(t, err)
+	rows.Close()
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestQueryTransactionConflict(t *testing.T) {
+	mp := newMockProvider()
+	mp.retryableError = fmt.Errorf("restart transaction")
+	s, mdb := mp.init()
+	mdb.ExpectQuery("^SELECT col1").WillReturnError(fmt.Errorf("restart transaction"))
+	_, _, err := s.query(context.Background(), sq.Select("col1").From("table1"))
+	assert.Regexp(t, "FF10470.*restart transaction", err)
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
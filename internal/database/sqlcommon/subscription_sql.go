@@ -38,7 +38,11 @@ var (
 		"filter_topics",
 		"filter_tag",
 		"filter_group",
+		"filter_custom",
+		"filter_blockchainevent",
+		"filter_operation",
 		"options",
+		"owner",
 		"created",
 		"updated",
 	}
@@ -91,7 +95,7 @@ func (s *SQLCommon) UpsertSubscription(ctx context.Context, subscription *fftype
 		// Update the subscription
 		if _, err = s.updateTx(ctx, tx,
 			sq.Update("subscriptions").
-				// Note we do not update ID
+				// Note we do not update ID or owner
 				Set("namespace", subscription.Namespace).
 				Set("name", subscription.Name).
 				Set("transport", subscription.Transport).
@@ -99,6 +103,9 @@ func (s *SQLCommon) UpsertSubscription(ctx context.Context, subscription *fftype
 				Set("filter_topics", subscription.Filter.Topics).
 				Set("filter_tag", subscription.Filter.Tag).
 				Set("filter_group", subscription.Filter.Group).
+				Set("filter_custom", subscription.Filter.Custom).
+				Set("filter_blockchainevent", subscription.Filter.BlockchainEvent).
+				Set("filter_operation", subscription.Filter.Operation).
 				Set("options", subscription.Options).
 				Set("created", subscription.Created).
 				Set("updated", subscription.Updated).
@@ -129,7 +136,11 @@ func (s *SQLCommon) UpsertSubscription(ctx context.Context, subscription *fftype
 					subscription.Filter.Topics,
 					subscription.Filter.Tag,
 					subscription.Filter.Group,
+					subscription.Filter.Custom,
+					subscription.Filter.BlockchainEvent,
+					subscription.Filter.Operation,
 					subscription.Options,
+					subscription.Owner,
 					subscription.Created,
 					subscription.Updated,
 				),
@@ -156,7 +167,11 @@ func (s *SQLCommon) subscriptionResult(ctx context.Context, row *sql.Rows) (*fft
 		&subscription.Filter.Topics,
 		&subscription.Filter.Tag,
 		&subscription.Filter.Group,
+		&subscription.Filter.Custom,
+		&subscription.Filter.BlockchainEvent,
+		&subscription.Filter.Operation,
 		&subscription.Options,
+		&subscription.Owner,
 		&subscription.Created,
 		&subscription.Updated,
 	)
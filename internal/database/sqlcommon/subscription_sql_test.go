@@ -42,6 +42,7 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 			Namespace: "ns1",
 			Name:      "subscription1",
 		},
+		Owner:   "CN=app1",
 		Created: fftypes.Now(),
 	}
 
@@ -81,8 +82,19 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 			Topics: "topics.*",
 			Tag:    "tag.*",
 			Group:  "group.*",
+			Custom: fftypes.CustomHeaders{"region": "eu-.*"},
+			BlockchainEvent: &fftypes.BlockchainEventFilter{
+				Address: "^0x1234",
+				Name:    "Changed",
+				Params:  map[string]string{"from": "^0xabcd"},
+			},
+			Operation: &fftypes.OperationFilter{
+				Type:   "^dataexchange_",
+				Status: "Failed",
+			},
 		},
 		Options: subOpts,
+		Owner:   "CN=app1", // the owner is not updated
 		Created: fftypes.Now(),
 		Updated: fftypes.Now(),
 	}
@@ -110,6 +122,7 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 	filter := fb.And(
 		fb.Eq("namespace", subscriptionUpdated.Namespace),
 		fb.Eq("name", subscriptionUpdated.Name),
+		fb.Eq("owner", "CN=app1"),
 	)
 	subscriptionRes, res, err := s.GetSubscriptions(ctx, filter.Count(true))
 	assert.NoError(t, err)
@@ -258,7 +271,7 @@ func TestSubscriptionUpdateBuildQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(subscriptionColumns).AddRow(
-		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", `{}`, fftypes.Now(), fftypes.Now()),
+		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", "", nil, nil, `{}`, "", fftypes.Now(), fftypes.Now()),
 	)
 	u := database.SubscriptionQueryFactory.NewUpdate(context.Background()).Set("name", map[bool]bool{true: false})
 	err := s.UpdateSubscription(context.Background(), "ns1", "name1", u)
@@ -289,7 +302,7 @@ func TestSubscriptionUpdateFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(subscriptionColumns).AddRow(
-		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", `{}`, fftypes.Now(), fftypes.Now()),
+		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", "", nil, nil, `{}`, "", fftypes.Now(), fftypes.Now()),
 	)
 	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
 	mock.ExpectRollback()
@@ -309,7 +322,7 @@ func TestSubscriptionDeleteFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(subscriptionColumns).AddRow(
-		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", `{}`, fftypes.Now(), fftypes.Now()),
+		fftypes.NewUUID(), "ns1", "sub1", "websockets", "", "", "", "", "", nil, nil, `{}`, "", fftypes.Now(), fftypes.Now()),
 	)
 	mock.ExpectExec("DELETE .*").WillReturnError(fmt.Errorf("pop"))
 	err := s.DeleteSubscriptionByID(context.Background(), fftypes.NewUUID())
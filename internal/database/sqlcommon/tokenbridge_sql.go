@@ -0,0 +1,199 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	tokenBridgeColumns = []string{
+		"id",
+		"namespace",
+		"key",
+		"from_key",
+		"to_key",
+		"escrow",
+		"amount",
+		"source_connector",
+		"source_pool",
+		"source_index",
+		"target_connector",
+		"target_pool",
+		"target_index",
+		"status",
+		"error",
+		"tx_type",
+		"tx_id",
+		"created",
+		"updated",
+	}
+	tokenBridgeFilterFieldMap = map[string]string{
+		"from":              "from_key",
+		"to":                "to_key",
+		"source.connector":  "source_connector",
+		"source.pool":       "source_pool",
+		"source.tokenindex": "source_index",
+		"target.connector":  "target_connector",
+		"target.pool":       "target_pool",
+		"target.tokenindex": "target_index",
+		"tx.type":           "tx_type",
+		"tx.id":             "tx_id",
+	}
+)
+
+func (s *SQLCommon) InsertTokenBridge(ctx context.Context, bridge *fftypes.TokenBridge) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("tokenbridges").
+			Columns(tokenBridgeColumns...).
+			Values(
+				bridge.ID,
+				bridge.Namespace,
+				bridge.Key,
+				bridge.From,
+				bridge.To,
+				bridge.Escrow,
+				bridge.Amount,
+				bridge.Source.Connector,
+				bridge.Source.Pool,
+				bridge.Source.TokenIndex,
+				bridge.Target.Connector,
+				bridge.Target.Pool,
+				bridge.Target.TokenIndex,
+				bridge.Status,
+				bridge.Error,
+				bridge.TX.Type,
+				bridge.TX.ID,
+				bridge.Created,
+				bridge.Updated,
+			),
+		nil, // no change events for token bridges
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) tokenBridgeResult(ctx context.Context, row *sql.Rows) (*fftypes.TokenBridge, error) {
+	var bridge fftypes.TokenBridge
+	err := row.Scan(
+		&bridge.ID,
+		&bridge.Namespace,
+		&bridge.Key,
+		&bridge.From,
+		&bridge.To,
+		&bridge.Escrow,
+		&bridge.Amount,
+		&bridge.Source.Connector,
+		&bridge.Source.Pool,
+		&bridge.Source.TokenIndex,
+		&bridge.Target.Connector,
+		&bridge.Target.Pool,
+		&bridge.Target.TokenIndex,
+		&bridge.Status,
+		&bridge.Error,
+		&bridge.TX.Type,
+		&bridge.TX.ID,
+		&bridge.Created,
+		&bridge.Updated,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "tokenbridges")
+	}
+	return &bridge, nil
+}
+
+func (s *SQLCommon) GetTokenBridgeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.TokenBridge, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(tokenBridgeColumns...).
+			From("tokenbridges").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Token bridge '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.tokenBridgeResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetTokenBridges(ctx context.Context, filter database.Filter) ([]*fftypes.TokenBridge, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(tokenBridgeColumns...).From("tokenbridges"), filter, tokenBridgeFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	bridges := []*fftypes.TokenBridge{}
+	for rows.Next() {
+		bridge, err := s.tokenBridgeResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		bridges = append(bridges, bridge)
+	}
+
+	return bridges, s.queryRes(ctx, tx, "tokenbridges", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateTokenBridge(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("tokenbridges"), update, tokenBridgeFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for token bridges */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
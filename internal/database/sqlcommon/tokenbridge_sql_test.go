@@ -0,0 +1,213 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBridgeE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new token bridge entry
+	bridge := &fftypes.TokenBridge{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+		From:      "0x12345",
+		To:        "0x23456",
+		Escrow:    "0x99999",
+		Source: fftypes.TokenBridgeLeg{
+			Connector: "chain-a",
+			Pool:      fftypes.NewUUID(),
+		},
+		Target: fftypes.TokenBridgeLeg{
+			Connector:  "chain-b",
+			Pool:       fftypes.NewUUID(),
+			TokenIndex: "1",
+		},
+		Status: fftypes.TokenBridgeStatusPending,
+		TX: fftypes.TransactionRef{
+			Type: fftypes.TransactionTypeTokenBridge,
+			ID:   fftypes.NewUUID(),
+		},
+		Created: fftypes.Now(),
+	}
+	bridge.Amount.Int().SetInt64(10)
+	err := s.InsertTokenBridge(ctx, bridge)
+	assert.NoError(t, err)
+
+	// Check we get the exact same bridge back
+	bridgeRead, err := s.GetTokenBridgeByID(ctx, bridge.ID)
+	assert.NoError(t, err)
+	bridgeJson, _ := json.Marshal(&bridge)
+	bridgeReadJson, _ := json.Marshal(&bridgeRead)
+	assert.Equal(t, string(bridgeJson), string(bridgeReadJson))
+
+	// Query back the bridge
+	fb := database.TokenBridgeQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", "ns1"),
+		fb.Eq("source.connector", "chain-a"),
+		fb.Eq("target.pool", bridge.Target.Pool),
+		fb.Eq("status", fftypes.TokenBridgeStatusPending),
+		fb.Eq("tx.id", bridge.TX.ID),
+	)
+	bridges, res, err := s.GetTokenBridges(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(bridges))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	bridgeReadJson, _ = json.Marshal(bridges[0])
+	assert.Equal(t, string(bridgeJson), string(bridgeReadJson))
+
+	// Update the bridge
+	bridge.Status = fftypes.TokenBridgeStatusCompensated
+	bridge.Error = "mint failed"
+	bridge.Updated = fftypes.Now()
+	up := database.TokenBridgeQueryFactory.NewUpdate(ctx).
+		Set("status", bridge.Status).
+		Set("error", bridge.Error).
+		Set("updated", bridge.Updated)
+	err = s.UpdateTokenBridge(ctx, bridge.ID, up)
+	assert.NoError(t, err)
+
+	bridgeRead, err = s.GetTokenBridgeByID(ctx, bridge.ID)
+	assert.NoError(t, err)
+	bridgeJson, _ = json.Marshal(&bridge)
+	bridgeReadJson, _ = json.Marshal(&bridgeRead)
+	assert.Equal(t, string(bridgeJson), string(bridgeReadJson))
+
+	// Negative test on filter
+	bridges, _, err = s.GetTokenBridges(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(bridges))
+}
+
+func TestInsertTokenBridgeFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertTokenBridge(context.Background(), &fftypes.TokenBridge{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertTokenBridgeFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertTokenBridge(context.Background(), &fftypes.TokenBridge{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertTokenBridgeFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertTokenBridge(context.Background(), &fftypes.TokenBridge{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenBridgeByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetTokenBridgeByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenBridgeByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	approval, err := s.GetTokenBridgeByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, approval)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenBridgeByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetTokenBridgeByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenBridgesQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.TokenBridgeQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetTokenBridges(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenBridgesBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.TokenBridgeQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetTokenBridges(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetTokenBridgesScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.TokenBridgeQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetTokenBridges(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTokenBridgeUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.TokenBridgeQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.TokenBridgeStatusCompleted)
+	err := s.UpdateTokenBridge(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestTokenBridgeUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.TokenBridgeQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateTokenBridge(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestTokenBridgeUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.TokenBridgeQueryFactory.NewUpdate(context.Background()).Set("status", fftypes.TokenBridgeStatusCompleted)
+	err := s.UpdateTokenBridge(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
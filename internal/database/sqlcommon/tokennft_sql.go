@@ -0,0 +1,177 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	tokenNFTColumns = []string{
+		"id",
+		"pool_id",
+		"token_index",
+		"uri",
+		"connector",
+		"namespace",
+		"owner_key",
+		"created",
+		"updated",
+	}
+	tokenNFTFilterFieldMap = map[string]string{
+		"pool":       "pool_id",
+		"tokenindex": "token_index",
+		"owner":      "owner_key",
+	}
+)
+
+func (s *SQLCommon) UpsertTokenNFT(ctx context.Context, nft *fftypes.TokenNFT) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	rows, _, err := s.queryTx(ctx, tx,
+		sq.Select("seq").
+			From("tokennft").
+			Where(sq.Eq{
+				"pool_id":     nft.Pool,
+				"token_index": nft.TokenIndex,
+			}),
+	)
+	if err != nil {
+		return err
+	}
+	existing := rows.Next()
+	rows.Close()
+
+	if existing {
+		if _, err = s.updateTx(ctx, tx,
+			sq.Update("tokennft").
+				Set("uri", nft.URI).
+				Set("owner_key", nft.Owner).
+				Set("updated", nft.Updated).
+				Where(sq.Eq{
+					"pool_id":     nft.Pool,
+					"token_index": nft.TokenIndex,
+				}),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionTokenNFTs, fftypes.ChangeEventTypeUpdated, nft.Namespace, nft.ID)
+			},
+		); err != nil {
+			return err
+		}
+	} else {
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("tokennft").
+				Columns(tokenNFTColumns...).
+				Values(
+					nft.ID,
+					nft.Pool,
+					nft.TokenIndex,
+					nft.URI,
+					nft.Connector,
+					nft.Namespace,
+					nft.Owner,
+					nft.Created,
+					nft.Updated,
+				),
+			func() {
+				s.callbacks.UUIDCollectionNSEvent(database.CollectionTokenNFTs, fftypes.ChangeEventTypeCreated, nft.Namespace, nft.ID)
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) tokenNFTResult(ctx context.Context, row *sql.Rows) (*fftypes.TokenNFT, error) {
+	nft := fftypes.TokenNFT{}
+	err := row.Scan(
+		&nft.ID,
+		&nft.Pool,
+		&nft.TokenIndex,
+		&nft.URI,
+		&nft.Connector,
+		&nft.Namespace,
+		&nft.Owner,
+		&nft.Created,
+		&nft.Updated,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "tokennft")
+	}
+	return &nft, nil
+}
+
+func (s *SQLCommon) GetTokenNFT(ctx context.Context, poolID *fftypes.UUID, tokenIndex string) (*fftypes.TokenNFT, error) {
+	desc := fmt.Sprintf("%s:%s", poolID, tokenIndex)
+	rows, _, err := s.query(ctx,
+		sq.Select(tokenNFTColumns...).
+			From("tokennft").
+			Where(sq.Eq{
+				"pool_id":     poolID,
+				"token_index": tokenIndex,
+			}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Token NFT '%s' not found", desc)
+		return nil, nil
+	}
+
+	return s.tokenNFTResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetTokenNFTs(ctx context.Context, filter database.Filter) ([]*fftypes.TokenNFT, *database.FilterResult, error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(tokenNFTColumns...).From("tokennft"), filter, tokenNFTFilterFieldMap, []interface{}{"seq"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	nfts := []*fftypes.TokenNFT{}
+	for rows.Next() {
+		d, err := s.tokenNFTResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		nfts = append(nfts, d)
+	}
+
+	return nfts, s.queryRes(ctx, tx, "tokennft", fop, fi), err
+}
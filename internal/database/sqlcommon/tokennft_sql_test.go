@@ -0,0 +1,196 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTokenNFTE2EWithDB(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new token NFT entry
+	nft := &fftypes.TokenNFT{
+		ID:         fftypes.NewUUID(),
+		Pool:       fftypes.NewUUID(),
+		TokenIndex: "1",
+		URI:        "firefly://token/1",
+		Connector:  "erc1155",
+		Namespace:  "ns1",
+		Owner:      "0x01",
+		Created:    fftypes.Now(),
+		Updated:    fftypes.Now(),
+	}
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTokenNFTs, fftypes.ChangeEventTypeCreated, "ns1", nft.ID, mock.Anything).
+		Return().Once()
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTokenNFTs, fftypes.ChangeEventTypeUpdated, "ns1", nft.ID, mock.Anything).
+		Return().Once()
+
+	err := s.UpsertTokenNFT(ctx, nft)
+	assert.NoError(t, err)
+	nftJson, _ := json.Marshal(&nft)
+
+	// Query back the token NFT (by pool and index)
+	nftRead, err := s.GetTokenNFT(ctx, nft.Pool, nft.TokenIndex)
+	assert.NoError(t, err)
+	assert.NotNil(t, nftRead)
+	nftReadJson, _ := json.Marshal(&nftRead)
+	assert.Equal(t, string(nftJson), string(nftReadJson))
+
+	// Query back the token NFT (by query filter)
+	fb := database.TokenNFTQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("pool", nft.Pool),
+		fb.Eq("tokenindex", nft.TokenIndex),
+		fb.Eq("owner", nft.Owner),
+	)
+	nfts, res, err := s.GetTokenNFTs(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nfts))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	nftReadJson, _ = json.Marshal(nfts[0])
+	assert.Equal(t, string(nftJson), string(nftReadJson))
+
+	// Update the token NFT
+	nft.URI = "firefly://token/1/v2"
+	nft.Owner = "0x02"
+	nft.Updated = fftypes.Now()
+	err = s.UpsertTokenNFT(ctx, nft)
+	assert.NoError(t, err)
+
+	// Query back the token NFT
+	nftRead, err = s.GetTokenNFT(ctx, nft.Pool, nft.TokenIndex)
+	assert.NoError(t, err)
+	assert.NotNil(t, nftRead)
+	nftJson, _ = json.Marshal(&nft)
+	nftReadJson, _ = json.Marshal(&nftRead)
+	assert.Equal(t, string(nftJson), string(nftReadJson))
+
+	s.callbacks.AssertExpectations(t)
+}
+
+func TestUpsertTokenNFTFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertTokenNFT(context.Background(), &fftypes.TokenNFT{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertTokenNFTFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertTokenNFT(context.Background(), &fftypes.TokenNFT{})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertTokenNFTFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{}))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertTokenNFT(context.Background(), &fftypes.TokenNFT{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertTokenNFTFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"seq"}).AddRow("1"))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.UpsertTokenNFT(context.Background(), &fftypes.TokenNFT{})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertTokenNFTFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"seq"}))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.UpsertTokenNFT(context.Background(), &fftypes.TokenNFT{})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenNFTSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetTokenNFT(context.Background(), fftypes.NewUUID(), "1")
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenNFTNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	nft, err := s.GetTokenNFT(context.Background(), fftypes.NewUUID(), "1")
+	assert.NoError(t, err)
+	assert.Nil(t, nft)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenNFTScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetTokenNFT(context.Background(), fftypes.NewUUID(), "1")
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenNFTsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.TokenNFTQueryFactory.NewFilter(context.Background()).Eq("tokenindex", "")
+	_, _, err := s.GetTokenNFTs(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTokenNFTsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.TokenNFTQueryFactory.NewFilter(context.Background()).Eq("tokenindex", map[bool]bool{true: false})
+	_, _, err := s.GetTokenNFTs(context.Background(), f)
+	assert.Regexp(t, "FF10149.*tokenindex", err)
+}
+
+func TestGetTokenNFTsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.TokenNFTQueryFactory.NewFilter(context.Background()).Eq("tokenindex", "")
+	_, _, err := s.GetTokenNFTs(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
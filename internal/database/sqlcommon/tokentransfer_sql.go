@@ -41,6 +41,7 @@ var (
 		"to_key",
 		"amount",
 		"protocol_id",
+		"pool_sequence",
 		"message_id",
 		"message_hash",
 		"tx_type",
@@ -54,6 +55,7 @@ var (
 		"from":             "from_key",
 		"to":               "to_key",
 		"protocolid":       "protocol_id",
+		"poolsequence":     "pool_sequence",
 		"message":          "message_id",
 		"messagehash":      "message_hash",
 		"transaction.type": "tx_type",
@@ -93,6 +95,7 @@ func (s *SQLCommon) UpsertTokenTransfer(ctx context.Context, transfer *fftypes.T
 				Set("from_key", transfer.From).
 				Set("to_key", transfer.To).
 				Set("amount", transfer.Amount).
+				Set("pool_sequence", transfer.PoolSequence).
 				Set("message_id", transfer.Message).
 				Set("message_hash", transfer.MessageHash).
 				Set("tx_type", transfer.TX.Type).
@@ -122,6 +125,7 @@ func (s *SQLCommon) UpsertTokenTransfer(ctx context.Context, transfer *fftypes.T
 					transfer.To,
 					transfer.Amount,
 					transfer.ProtocolID,
+					transfer.PoolSequence,
 					transfer.Message,
 					transfer.MessageHash,
 					transfer.TX.Type,
@@ -154,6 +158,7 @@ func (s *SQLCommon) tokenTransferResult(ctx context.Context, row *sql.Rows) (*ff
 		&transfer.To,
 		&transfer.Amount,
 		&transfer.ProtocolID,
+		&transfer.PoolSequence,
 		&transfer.Message,
 		&transfer.MessageHash,
 		&transfer.TX.Type,
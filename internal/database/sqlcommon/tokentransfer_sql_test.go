@@ -52,6 +52,7 @@ func TestTokenTransferE2EWithDB(t *testing.T) {
 			Type: fftypes.TransactionTypeTokenTransfer,
 			ID:   fftypes.NewUUID(),
 		},
+		PoolSequence: "000000000010/000002/000003",
 	}
 	transfer.Amount.Int().SetInt64(10)
 
@@ -88,6 +89,7 @@ func TestTokenTransferE2EWithDB(t *testing.T) {
 		fb.Eq("from", transfer.From),
 		fb.Eq("to", transfer.To),
 		fb.Eq("protocolid", transfer.ProtocolID),
+		fb.Gt("poolsequence", "000000000010/000002/000002"),
 		fb.Eq("created", transfer.Created),
 	)
 	transfers, res, err := s.GetTokenTransfers(ctx, filter.Count(true))
@@ -39,11 +39,15 @@ var (
 		"protocol_id",
 		"status",
 		"info",
+		"fee_gas_used",
+		"fee_amount",
+		"idempotency_key",
 	}
 	transactionFilterFieldMap = map[string]string{
-		"type":       "ttype",
-		"protocolid": "protocol_id",
-		"reference":  "ref",
+		"type":           "ttype",
+		"protocolid":     "protocol_id",
+		"reference":      "ref",
+		"idempotencykey": "idempotency_key",
 	}
 )
 
@@ -54,6 +58,11 @@ func (s *SQLCommon) UpsertTransaction(ctx context.Context, transaction *fftypes.
 	}
 	defer s.rollbackTx(ctx, tx, autoCommit)
 
+	fee := transaction.Fee
+	if fee == nil {
+		fee = &fftypes.TransactionFee{}
+	}
+
 	// Do a select within the transaction to detemine if the UUID already exists
 	transactionRows, _, err := s.queryTx(ctx, tx,
 		sq.Select("hash").
@@ -90,6 +99,8 @@ func (s *SQLCommon) UpsertTransaction(ctx context.Context, transaction *fftypes.
 				Set("protocol_id", transaction.ProtocolID).
 				Set("status", transaction.Status).
 				Set("info", transaction.Info).
+				Set("fee_gas_used", fee.GasUsed).
+				Set("fee_amount", fee.Amount).
 				Where(sq.Eq{"id": transaction.ID}),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionTransactions, fftypes.ChangeEventTypeUpdated, transaction.Subject.Namespace, transaction.ID)
@@ -113,6 +124,9 @@ func (s *SQLCommon) UpsertTransaction(ctx context.Context, transaction *fftypes.
 					transaction.ProtocolID,
 					transaction.Status,
 					transaction.Info,
+					fee.GasUsed,
+					fee.Amount,
+					transaction.IdempotencyKey,
 				),
 			func() {
 				s.callbacks.UUIDCollectionNSEvent(database.CollectionTransactions, fftypes.ChangeEventTypeCreated, transaction.Subject.Namespace, transaction.ID)
@@ -127,6 +141,7 @@ func (s *SQLCommon) UpsertTransaction(ctx context.Context, transaction *fftypes.
 
 func (s *SQLCommon) transactionResult(ctx context.Context, row *sql.Rows) (*fftypes.Transaction, error) {
 	var transaction fftypes.Transaction
+	var fee fftypes.TransactionFee
 	err := row.Scan(
 		&transaction.ID,
 		&transaction.Subject.Type,
@@ -138,10 +153,16 @@ func (s *SQLCommon) transactionResult(ctx context.Context, row *sql.Rows) (*ffty
 		&transaction.ProtocolID,
 		&transaction.Status,
 		&transaction.Info,
+		&fee.GasUsed,
+		&fee.Amount,
+		&transaction.IdempotencyKey,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "transactions")
 	}
+	if fee.GasUsed != nil {
+		transaction.Fee = &fee
+	}
 	return &transaction, nil
 }
 
@@ -46,8 +46,9 @@ func TestTransactionE2EWithDB(t *testing.T) {
 			Signer:    "0x12345",
 			Reference: fftypes.NewUUID(),
 		},
-		Created: fftypes.Now(),
-		Status:  fftypes.OpStatusPending,
+		Created:        fftypes.Now(),
+		Status:         fftypes.OpStatusPending,
+		IdempotencyKey: "retry1",
 	}
 
 	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTransactions, fftypes.ChangeEventTypeCreated, "ns1", transactionID, mock.Anything).Return()
@@ -81,6 +82,11 @@ func TestTransactionE2EWithDB(t *testing.T) {
 		Info: fftypes.JSONObject{
 			"some": "data",
 		},
+		Fee: &fftypes.TransactionFee{
+			GasUsed: fftypes.NewBigInt(21000),
+			Amount:  fftypes.NewBigInt(21000000000000),
+		},
+		IdempotencyKey: "retry1", // Note the idempotency key is only set on insert
 	}
 
 	// Check reject hash update
@@ -103,6 +109,7 @@ func TestTransactionE2EWithDB(t *testing.T) {
 		fb.Eq("id", transactionUpdated.ID.String()),
 		fb.Eq("protocolid", transactionUpdated.ProtocolID),
 		fb.Eq("signer", transactionUpdated.Subject.Signer),
+		fb.Eq("idempotencykey", "retry1"),
 		fb.Gt("created", "0"),
 	)
 	transactions, res, err := s.GetTransactions(ctx, filter.Count(true))
@@ -137,6 +144,37 @@ func TestTransactionE2EWithDB(t *testing.T) {
 	assert.Equal(t, 1, len(transactions))
 }
 
+func TestUpsertTransactionDuplicateIdempotencyKey(t *testing.T) {
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	s.callbacks.On("UUIDCollectionNSEvent", database.CollectionTransactions, fftypes.ChangeEventTypeCreated, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	newTX := func(ns string, key fftypes.IdempotencyKey) *fftypes.Transaction {
+		return &fftypes.Transaction{
+			ID:   fftypes.NewUUID(),
+			Hash: fftypes.NewRandB32(),
+			Subject: fftypes.TransactionSubject{
+				Type:      fftypes.TransactionTypeTokenTransfer,
+				Namespace: ns,
+				Signer:    "0x12345",
+				Reference: fftypes.NewUUID(),
+			},
+			Created:        fftypes.Now(),
+			Status:         fftypes.OpStatusPending,
+			IdempotencyKey: key,
+		}
+	}
+
+	err := s.UpsertTransaction(ctx, newTX("ns1", "retry1"), false)
+	assert.NoError(t, err)
+	err = s.UpsertTransaction(ctx, newTX("ns2", "retry1"), false)
+	assert.NoError(t, err)
+	err = s.UpsertTransaction(ctx, newTX("ns1", "retry1"), false)
+	assert.Regexp(t, "FF10116", err)
+}
+
 func TestUpsertTransactionFailBegin(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
@@ -0,0 +1,161 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var (
+	unmatchedReceiptColumns = []string{
+		"id",
+		"plugin",
+		"request_id",
+		"status",
+		"error",
+		"output",
+		"created",
+		"operation_id",
+		"reconciled",
+	}
+	unmatchedReceiptFilterFieldMap = map[string]string{
+		"requestid": "request_id",
+		"operation": "operation_id",
+	}
+)
+
+func (s *SQLCommon) InsertUnmatchedReceipt(ctx context.Context, receipt *fftypes.UnmatchedReceipt) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("unmatchedreceipts").
+			Columns(unmatchedReceiptColumns...).
+			Values(
+				receipt.ID,
+				receipt.Plugin,
+				receipt.RequestID,
+				receipt.Status,
+				receipt.Error,
+				receipt.Output,
+				receipt.Created,
+				receipt.Operation,
+				receipt.Reconciled,
+			),
+		nil, // no change events for unmatched receipts
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) unmatchedReceiptResult(ctx context.Context, row *sql.Rows) (*fftypes.UnmatchedReceipt, error) {
+	var receipt fftypes.UnmatchedReceipt
+	err := row.Scan(
+		&receipt.ID,
+		&receipt.Plugin,
+		&receipt.RequestID,
+		&receipt.Status,
+		&receipt.Error,
+		&receipt.Output,
+		&receipt.Created,
+		&receipt.Operation,
+		&receipt.Reconciled,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "unmatchedreceipts")
+	}
+	return &receipt, nil
+}
+
+func (s *SQLCommon) GetUnmatchedReceiptByID(ctx context.Context, id *fftypes.UUID) (*fftypes.UnmatchedReceipt, error) {
+
+	rows, _, err := s.query(ctx,
+		sq.Select(unmatchedReceiptColumns...).
+			From("unmatchedreceipts").
+			Where(sq.Eq{"id": id}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Unmatched receipt '%s' not found", id)
+		return nil, nil
+	}
+
+	return s.unmatchedReceiptResult(ctx, rows)
+}
+
+func (s *SQLCommon) GetUnmatchedReceipts(ctx context.Context, filter database.Filter) ([]*fftypes.UnmatchedReceipt, *database.FilterResult, error) {
+
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(unmatchedReceiptColumns...).From("unmatchedreceipts"), filter, unmatchedReceiptFilterFieldMap, []interface{}{"sequence"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, tx, err := s.query(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	receipts := []*fftypes.UnmatchedReceipt{}
+	for rows.Next() {
+		receipt, err := s.unmatchedReceiptResult(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, s.queryRes(ctx, tx, "unmatchedreceipts", fop, fi), err
+}
+
+func (s *SQLCommon) UpdateUnmatchedReceipt(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("unmatchedreceipts"), update, unmatchedReceiptFilterFieldMap)
+	if err != nil {
+		return err
+	}
+	query = query.Where(sq.Eq{"id": id})
+
+	_, err = s.updateTx(ctx, tx, query, nil /* no change events for unmatched receipts */)
+	if err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
@@ -0,0 +1,195 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmatchedReceiptE2EWithDB(t *testing.T) {
+
+	s, cleanup := newSQLiteTestProvider(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Create a new unmatched receipt entry
+	receipt := &fftypes.UnmatchedReceipt{
+		ID:        fftypes.NewUUID(),
+		Plugin:    "ethereum",
+		RequestID: "req12345",
+		Status:    fftypes.OpStatusSucceeded,
+		Output: fftypes.JSONObject{
+			"transactionHash": "0x12345",
+		},
+		Created: fftypes.Now(),
+	}
+	err := s.InsertUnmatchedReceipt(ctx, receipt)
+	assert.NoError(t, err)
+
+	// Check we get the exact same receipt back
+	receiptRead, err := s.GetUnmatchedReceiptByID(ctx, receipt.ID)
+	assert.NoError(t, err)
+	receiptJson, _ := json.Marshal(&receipt)
+	receiptReadJson, _ := json.Marshal(&receiptRead)
+	assert.Equal(t, string(receiptJson), string(receiptReadJson))
+
+	// Query back the receipt
+	fb := database.UnmatchedReceiptQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("plugin", "ethereum"),
+		fb.Eq("requestid", "req12345"),
+		fb.Eq("reconciled", nil),
+	)
+	receipts, res, err := s.GetUnmatchedReceipts(ctx, filter.Count(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(receipts))
+	assert.Equal(t, int64(1), *res.TotalCount)
+	receiptReadJson, _ = json.Marshal(receipts[0])
+	assert.Equal(t, string(receiptJson), string(receiptReadJson))
+
+	// Reconcile the receipt
+	receipt.Operation = fftypes.NewUUID()
+	receipt.Reconciled = fftypes.Now()
+	up := database.UnmatchedReceiptQueryFactory.NewUpdate(ctx).
+		Set("operation", receipt.Operation).
+		Set("reconciled", receipt.Reconciled)
+	err = s.UpdateUnmatchedReceipt(ctx, receipt.ID, up)
+	assert.NoError(t, err)
+
+	receiptRead, err = s.GetUnmatchedReceiptByID(ctx, receipt.ID)
+	assert.NoError(t, err)
+	receiptJson, _ = json.Marshal(&receipt)
+	receiptReadJson, _ = json.Marshal(&receiptRead)
+	assert.Equal(t, string(receiptJson), string(receiptReadJson))
+
+	// Negative test on filter
+	receipts, _, err = s.GetUnmatchedReceipts(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(receipts))
+}
+
+func TestInsertUnmatchedReceiptFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertUnmatchedReceipt(context.Background(), &fftypes.UnmatchedReceipt{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertUnmatchedReceiptFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertUnmatchedReceipt(context.Background(), &fftypes.UnmatchedReceipt{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertUnmatchedReceiptFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertUnmatchedReceipt(context.Background(), &fftypes.UnmatchedReceipt{ID: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnmatchedReceiptByIDSelectFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetUnmatchedReceiptByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnmatchedReceiptByIDNotFound(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	receipt, err := s.GetUnmatchedReceiptByID(context.Background(), fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, receipt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnmatchedReceiptByIDScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	_, err := s.GetUnmatchedReceiptByID(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnmatchedReceiptsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.UnmatchedReceiptQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetUnmatchedReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUnmatchedReceiptsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.UnmatchedReceiptQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
+	_, _, err := s.GetUnmatchedReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestGetUnmatchedReceiptsScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
+	f := database.UnmatchedReceiptQueryFactory.NewFilter(context.Background()).Eq("id", "")
+	_, _, err := s.GetUnmatchedReceipts(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnmatchedReceiptUpdateBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.UnmatchedReceiptQueryFactory.NewUpdate(context.Background()).Set("reconciled", fftypes.Now())
+	err := s.UpdateUnmatchedReceipt(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestUnmatchedReceiptUpdateBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.UnmatchedReceiptQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	err := s.UpdateUnmatchedReceipt(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestUnmatchedReceiptUpdateFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.UnmatchedReceiptQueryFactory.NewUpdate(context.Background()).Set("reconciled", fftypes.Now())
+	err := s.UpdateUnmatchedReceipt(context.Background(), fftypes.NewUUID(), u)
+	assert.Regexp(t, "FF10117", err)
+}
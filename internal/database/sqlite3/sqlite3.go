@@ -67,3 +67,15 @@ func (sqlite *SQLite3) Open(url string) (*sql.DB, error) {
 func (sqlite *SQLite3) GetMigrationDriver(db *sql.DB) (migratedb.Driver, error) {
 	return migratesqlite3.WithInstance(db, &migratesqlite3.Config{})
 }
+
+func (sqlite *SQLite3) IsRetryableError(err error) bool {
+	return false
+}
+
+func (sqlite *SQLite3) HistoricalReadTimeQuery() string {
+	return ""
+}
+
+func (sqlite *SQLite3) CurrentTimeQuery() string {
+	return "SELECT CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+}
@@ -20,6 +20,7 @@ package sqlite3
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	sq "github.com/Masterminds/squirrel"
@@ -49,4 +50,8 @@ func TestSQLite3GoProvider(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "INSERT INTO test (col1) VALUES (?)", sql)
 	assert.False(t, query)
+
+	assert.False(t, sqlite.IsRetryableError(fmt.Errorf("pop")))
+	assert.Empty(t, sqlite.HistoricalReadTimeQuery())
+	assert.Contains(t, sqlite.CurrentTimeQuery(), "julianday")
 }
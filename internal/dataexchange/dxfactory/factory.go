@@ -21,32 +21,36 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/dataexchange/dxhttps"
+	"github.com/hyperledger/firefly/internal/dataexchange/dxmtls"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 )
 
-var plugins = []dataexchange.Plugin{
-	&dxhttps.HTTPS{},
+var pluginFactories = []func() dataexchange.Plugin{
+	func() dataexchange.Plugin { return &dxhttps.HTTPS{} },
+	func() dataexchange.Plugin { return &dxmtls.MTLS{} },
 }
 
-var pluginsByName = make(map[string]dataexchange.Plugin)
+var pluginsByName = make(map[string]func() dataexchange.Plugin)
 
 func init() {
-	for _, p := range plugins {
-		pluginsByName[p.Name()] = p
+	for _, factory := range pluginFactories {
+		pluginsByName[factory().Name()] = factory
 	}
 }
 
 func InitPrefix(prefix config.Prefix) {
-	for _, plugin := range plugins {
+	for _, factory := range pluginFactories {
+		plugin := factory()
 		plugin.InitPrefix(prefix.SubPrefix(plugin.Name()))
 	}
 }
 
+// GetPlugin returns a new instance of the data exchange plugin, so each isolated namespace has its own connection
 func GetPlugin(ctx context.Context, pluginType string) (dataexchange.Plugin, error) {
-	plugin, ok := pluginsByName[pluginType]
+	factory, ok := pluginsByName[pluginType]
 	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownDataExchangePlugin, pluginType)
 	}
-	return plugin, nil
+	return factory(), nil
 }
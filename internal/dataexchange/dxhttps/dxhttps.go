@@ -21,7 +21,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"path"
+	"strings"
+	"sync"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
@@ -29,9 +33,11 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/wsclient"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type HTTPS struct {
@@ -40,6 +46,7 @@ type HTTPS struct {
 	callbacks    dataexchange.Callbacks
 	client       *resty.Client
 	wsconn       wsclient.WSClient
+	connMux      sync.Mutex
 }
 
 type wsEvent struct {
@@ -80,11 +87,13 @@ type uploadBlob struct {
 type sendMessage struct {
 	Message   string `json:"message"`
 	Recipient string `json:"recipient"`
+	RequestID string `json:"requestId"`
 }
 
 type transferBlob struct {
 	Path      string `json:"path"`
 	Recipient string `json:"recipient"`
+	RequestID string `json:"requestId"`
 }
 
 func (h *HTTPS) Name() string {
@@ -92,23 +101,27 @@ func (h *HTTPS) Name() string {
 }
 
 func (h *HTTPS) Init(ctx context.Context, prefix config.Prefix, callbacks dataexchange.Callbacks) (err error) {
-	h.ctx = log.WithLogField(ctx, "dx", "https")
+	h.ctx = log.WithComponent(log.WithLogField(ctx, "dx", "https"), "dataexchange")
 	h.callbacks = callbacks
 
 	if prefix.GetString(restclient.HTTPConfigURL) == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "dataexchange.https")
 	}
 
-	h.client = restclient.New(h.ctx, prefix)
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(h.ctx, prefix)
+	if err == nil {
+		h.client, err = restclient.New(h.ctx, prefix)
+	}
+	if err != nil {
+		return err
+	}
 	h.capabilities = &dataexchange.Capabilities{}
 
-	wsConfig := wsconfig.GenerateConfigFromPrefix(prefix)
-
 	h.wsconn, err = wsclient.New(ctx, wsConfig, nil)
 	if err != nil {
 		return err
 	}
-	go h.eventLoop()
+	go h.eventLoop(h.wsconn)
 	return nil
 }
 
@@ -116,12 +129,54 @@ func (h *HTTPS) Start() error {
 	return h.wsconn.Connect()
 }
 
+// ReloadConfig connects to data exchange using the current configuration, such as a new URL, and only
+// switches over once the new WebSocket is connected. Closing the previous WebSocket stops its event loop,
+// and data exchange redelivers any events that had not been acknowledged on the new connection.
+func (h *HTTPS) ReloadConfig(ctx context.Context, prefix config.Prefix) error {
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "dataexchange.https")
+	}
+
+	var client *resty.Client
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(h.ctx, prefix)
+	if err == nil {
+		client, err = restclient.New(h.ctx, prefix)
+	}
+	if err != nil {
+		return err
+	}
+	wsconn, err := wsclient.New(h.ctx, wsConfig, nil)
+	if err != nil {
+		return err
+	}
+	if err = wsconn.Connect(); err != nil {
+		wsconn.Close()
+		return err
+	}
+
+	h.connMux.Lock()
+	previous := h.wsconn
+	h.client = client
+	h.wsconn = wsconn
+	h.connMux.Unlock()
+
+	go h.eventLoop(wsconn)
+	previous.Close()
+	return nil
+}
+
+func (h *HTTPS) restClient() *resty.Client {
+	h.connMux.Lock()
+	defer h.connMux.Unlock()
+	return h.client
+}
+
 func (h *HTTPS) Capabilities() *dataexchange.Capabilities {
 	return h.capabilities
 }
 
 func (h *HTTPS) GetEndpointInfo(ctx context.Context) (peerID string, endpoint fftypes.JSONObject, err error) {
-	res, err := h.client.R().SetContext(ctx).
+	res, err := h.restClient().R().SetContext(ctx).
 		SetResult(&endpoint).
 		Get("/api/v1/id")
 	if err != nil || !res.IsSuccess() {
@@ -131,7 +186,7 @@ func (h *HTTPS) GetEndpointInfo(ctx context.Context) (peerID string, endpoint ff
 }
 
 func (h *HTTPS) AddPeer(ctx context.Context, peerID string, endpoint fftypes.JSONObject) (err error) {
-	res, err := h.client.R().SetContext(ctx).
+	res, err := h.restClient().R().SetContext(ctx).
 		SetBody(endpoint).
 		Put(fmt.Sprintf("/api/v1/peers/%s", peerID))
 	if err != nil || !res.IsSuccess() {
@@ -142,9 +197,27 @@ func (h *HTTPS) AddPeer(ctx context.Context, peerID string, endpoint fftypes.JSO
 
 func (h *HTTPS) UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, content io.Reader) (payloadRef string, hash *fftypes.Bytes32, err error) {
 	payloadRef = fmt.Sprintf("%s/%s", ns, &id)
+
+	// Resty buffers the whole body of a multi-part request in memory, so we build the
+	// multi-part body ourselves and stream it through a pipe
+	bodyReader, bodyWriter := io.Pipe()
+	mpw := multipart.NewWriter(bodyWriter)
+	go func() {
+		part, err := mpw.CreateFormFile("file", id.String())
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = mpw.Close()
+		}
+		_ = bodyWriter.CloseWithError(err)
+	}()
+	defer bodyReader.Close()
+
 	var upload uploadBlob
-	res, err := h.client.R().SetContext(ctx).
-		SetFileReader("file", id.String(), content).
+	res, err := h.restClient().R().SetContext(ctx).
+		SetHeader("Content-Type", mpw.FormDataContentType()).
+		SetBody(bodyReader).
 		SetResult(&upload).
 		Put(fmt.Sprintf("/api/v1/blobs/%s", payloadRef))
 	if err != nil || !res.IsSuccess() {
@@ -157,8 +230,14 @@ func (h *HTTPS) UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, cont
 	return payloadRef, hash, nil
 }
 
+// IsLocalBLOB checks the payloadRef is of the form used by UploadBLOB, as blobs received from
+// other nodes are stored by data exchange under the ID of the sending peer
+func (h *HTTPS) IsLocalBLOB(ns, payloadRef string) bool {
+	return strings.HasPrefix(payloadRef, ns+"/") && path.Clean(payloadRef) == payloadRef
+}
+
 func (h *HTTPS) DownloadBLOB(ctx context.Context, payloadRef string) (content io.ReadCloser, err error) {
-	res, err := h.client.R().SetContext(ctx).
+	res, err := h.restClient().R().SetContext(ctx).
 		SetDoNotParseResponse(true).
 		Get(fmt.Sprintf("/api/v1/blobs/%s", payloadRef))
 	if err != nil || !res.IsSuccess() {
@@ -170,39 +249,41 @@ func (h *HTTPS) DownloadBLOB(ctx context.Context, payloadRef string) (content io
 	return res.RawBody(), nil
 }
 
-func (h *HTTPS) SendMessage(ctx context.Context, peerID string, data []byte) (trackingID string, err error) {
-	var responseData responseWithRequestID
-	res, err := h.client.R().SetContext(ctx).
+func (h *HTTPS) SendMessage(ctx context.Context, opID *fftypes.UUID, peerID string, data []byte) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "dx.sendMessage", attribute.String("dx.peer", peerID), attribute.String("dx.requestId", opID.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+	res, err := h.restClient().R().SetContext(ctx).
 		SetBody(&sendMessage{
 			Message:   string(data),
 			Recipient: peerID,
+			RequestID: opID.String(),
 		}).
-		SetResult(&responseData).
 		Post("/api/v1/messages")
 	if err != nil || !res.IsSuccess() {
-		return "", restclient.WrapRestErr(ctx, res, err, i18n.MsgDXRESTErr)
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgDXRESTErr)
 	}
-	return responseData.RequestID, nil
+	return nil
 }
 
-func (h *HTTPS) TransferBLOB(ctx context.Context, peerID, payloadRef string) (trackingID string, err error) {
-	var responseData responseWithRequestID
-	res, err := h.client.R().SetContext(ctx).
+func (h *HTTPS) TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID, payloadRef string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "dx.transferBlob", attribute.String("dx.peer", peerID), attribute.String("dx.requestId", opID.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+	res, err := h.restClient().R().SetContext(ctx).
 		SetBody(&transferBlob{
 			Path:      fmt.Sprintf("/%s", payloadRef),
 			Recipient: peerID,
+			RequestID: opID.String(),
 		}).
-		SetResult(&responseData).
 		Post("/api/v1/transfers")
 	if err != nil || !res.IsSuccess() {
-		return "", restclient.WrapRestErr(ctx, res, err, i18n.MsgDXRESTErr)
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgDXRESTErr)
 	}
-	return responseData.RequestID, nil
+	return nil
 }
 
 func (h *HTTPS) CheckBLOBReceived(ctx context.Context, peerID, ns string, id fftypes.UUID) (hash *fftypes.Bytes32, err error) {
 	var responseData responseWithRequestID
-	res, err := h.client.R().SetContext(ctx).
+	res, err := h.restClient().R().SetContext(ctx).
 		SetResult(&responseData).
 		Head(fmt.Sprintf("/api/v1/blobs/%s/%s/%s", peerID, ns, id.String()))
 	if err == nil && res.StatusCode() == http.StatusNotFound {
@@ -218,8 +299,8 @@ func (h *HTTPS) CheckBLOBReceived(ctx context.Context, peerID, ns string, id fft
 	return hash, nil
 }
 
-func (h *HTTPS) eventLoop() {
-	defer h.wsconn.Close()
+func (h *HTTPS) eventLoop(wsconn wsclient.WSClient) {
+	defer wsconn.Close()
 	l := log.L(h.ctx).WithField("role", "event-loop")
 	ctx := log.WithLogger(h.ctx, l)
 	ack, _ := json.Marshal(map[string]string{"action": "commit"})
@@ -228,7 +309,7 @@ func (h *HTTPS) eventLoop() {
 		case <-ctx.Done():
 			l.Debugf("Event loop exiting (context cancelled)")
 			return
-		case msgBytes, ok := <-h.wsconn.Receive():
+		case msgBytes, ok := <-wsconn.Receive():
 			if !ok {
 				l.Debugf("Event loop exiting (receive channel closed)")
 				return
@@ -269,7 +350,7 @@ func (h *HTTPS) eventLoop() {
 			// Send the ack - as long as we didn't fail processing (which should only happen in core
 			// if core itself is shutting down)
 			if err == nil {
-				err = h.wsconn.Send(ctx, ack)
+				err = wsconn.Send(ctx, ack)
 			}
 			if err != nil {
 				l.Errorf("Event loop exiting: %s", err)
@@ -19,13 +19,17 @@ package dxhttps
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"testing"
+	"testing/iotest"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
+	"github.com/hyperledger/firefly/internal/config/wsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/wsmocks"
@@ -74,6 +78,17 @@ func TestInitBadURL(t *testing.T) {
 	assert.Regexp(t, "FF10162", err)
 }
 
+func TestInitBadTLS(t *testing.T) {
+	config.Reset()
+	h := &HTTPS{}
+	h.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(restclient.HTTPConfigURL, "https://localhost:12345")
+	utConfPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := h.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
 func TestInitMissingURL(t *testing.T) {
 	config.Reset()
 	h := &HTTPS{}
@@ -153,6 +168,15 @@ func TestUploadBLOB(t *testing.T) {
 	hash := fftypes.NewRandB32()
 	httpmock.RegisterResponder("PUT", fmt.Sprintf("%s/api/v1/blobs/ns1/%s", httpURL, u),
 		func(r *http.Request) (*http.Response, error) {
+			mpr, err := r.MultipartReader()
+			assert.NoError(t, err)
+			part, err := mpr.NextPart()
+			assert.NoError(t, err)
+			assert.Equal(t, "file", part.FormName())
+			assert.Equal(t, u.String(), part.FileName())
+			b, err := ioutil.ReadAll(part)
+			assert.NoError(t, err)
+			assert.Equal(t, `{}`, string(b))
 			res := &http.Response{
 				Body: ioutil.NopCloser(bytes.NewReader([]byte(fmt.Sprintf(`{
 					"hash": "%s"
@@ -194,6 +218,21 @@ func TestUploadBLOBBadHash(t *testing.T) {
 	assert.Regexp(t, "FF10237", err)
 }
 
+func TestUploadBLOBReadError(t *testing.T) {
+	h, _, _, httpURL, done := newTestHTTPS(t)
+	defer done()
+
+	u := fftypes.NewUUID()
+	httpmock.RegisterResponder("PUT", fmt.Sprintf("%s/api/v1/blobs/ns1/%s", httpURL, u),
+		func(r *http.Request) (*http.Response, error) {
+			_, err := ioutil.ReadAll(r.Body)
+			return nil, err
+		})
+
+	_, _, err := h.UploadBLOB(context.Background(), "ns1", *u, iotest.ErrReader(fmt.Errorf("pop")))
+	assert.Regexp(t, "FF10229.*pop", err)
+}
+
 func TestUploadBLOBError(t *testing.T) {
 	h, _, _, httpURL, done := newTestHTTPS(t)
 	defer done()
@@ -303,6 +342,17 @@ func TestDownloadBLOB(t *testing.T) {
 	assert.Equal(t, `some data`, string(b))
 }
 
+func TestIsLocalBLOB(t *testing.T) {
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	u := fftypes.NewUUID()
+	assert.True(t, h.IsLocalBLOB("ns1", fmt.Sprintf("ns1/%s", u)))
+	assert.False(t, h.IsLocalBLOB("ns2", fmt.Sprintf("ns1/%s", u)))
+	assert.False(t, h.IsLocalBLOB("ns1", fmt.Sprintf("peer1/ns1/%s", u)))
+	assert.False(t, h.IsLocalBLOB("ns1", fmt.Sprintf("ns1/../peer1/ns1/%s", u)))
+}
+
 func TestDownloadBLOBError(t *testing.T) {
 	h, _, _, httpURL, done := newTestHTTPS(t)
 	defer done()
@@ -319,14 +369,20 @@ func TestSendMessage(t *testing.T) {
 	h, _, _, httpURL, done := newTestHTTPS(t)
 	defer done()
 
+	opID := fftypes.NewUUID()
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/messages", httpURL),
-		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
-			"requestID": "abcd1234",
-		}))
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, opID.String(), body["requestId"])
+			assert.Equal(t, "peer1", body["recipient"])
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+				"requestId": opID.String(),
+			})(req)
+		})
 
-	trackingID, err := h.SendMessage(context.Background(), "peer1", []byte(`some data`))
+	err := h.SendMessage(context.Background(), opID, "peer1", []byte(`some data`))
 	assert.NoError(t, err)
-	assert.Equal(t, "abcd1234", trackingID)
 }
 
 func TestSendMessageError(t *testing.T) {
@@ -336,7 +392,7 @@ func TestSendMessageError(t *testing.T) {
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/message", httpURL),
 		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
 
-	_, err := h.SendMessage(context.Background(), "peer1", []byte(`some data`))
+	err := h.SendMessage(context.Background(), fftypes.NewUUID(), "peer1", []byte(`some data`))
 	assert.Regexp(t, "FF10229", err)
 }
 
@@ -345,14 +401,20 @@ func TestTransferBLOB(t *testing.T) {
 	h, _, _, httpURL, done := newTestHTTPS(t)
 	defer done()
 
+	opID := fftypes.NewUUID()
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
-		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
-			"requestID": "abcd1234",
-		}))
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, opID.String(), body["requestId"])
+			assert.Equal(t, "peer1", body["recipient"])
+			return httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{
+				"requestId": opID.String(),
+			})(req)
+		})
 
-	trackingID, err := h.TransferBLOB(context.Background(), "peer1", "ns1/id1")
+	err := h.TransferBLOB(context.Background(), opID, "peer1", "ns1/id1")
 	assert.NoError(t, err)
-	assert.Equal(t, "abcd1234", trackingID)
 }
 
 func TestTransferBLOBError(t *testing.T) {
@@ -362,7 +424,7 @@ func TestTransferBLOBError(t *testing.T) {
 	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/transfers", httpURL),
 		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
 
-	_, err := h.TransferBLOB(context.Background(), "peer1", "ns1/id1")
+	err := h.TransferBLOB(context.Background(), fftypes.NewUUID(), "peer1", "ns1/id1")
 	assert.Regexp(t, "FF10229", err)
 }
 
@@ -426,6 +488,82 @@ func TestEvents(t *testing.T) {
 	mcb.AssertExpectations(t)
 
 }
+func TestReloadConfig(t *testing.T) {
+
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	err := h.Start()
+	assert.NoError(t, err)
+
+	toServer2, fromServer2, wsURL2, cancel2 := wsclient.NewTestWSServer(nil)
+	defer cancel2()
+	u, _ := url.Parse(wsURL2)
+	u.Scheme = "http"
+	httpURL2 := u.String()
+	utConfPrefix.Set(restclient.HTTPConfigURL, httpURL2)
+
+	err = h.ReloadConfig(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	// REST calls go to the new URL
+	httpmock.RegisterResponder("PUT", fmt.Sprintf("%s/api/v1/peers/peer1", httpURL2),
+		httpmock.NewJsonResponderOrPanic(200, fftypes.JSONObject{}))
+	err = h.AddPeer(context.Background(), "peer1", fftypes.JSONObject{})
+	assert.NoError(t, err)
+
+	// Events are received, and acknowledged, on the new connection
+	mcb := h.callbacks.(*dataexchangemocks.Callbacks)
+	mcb.On("MessageReceived", "peer1", []byte("message1")).Return(nil)
+	fromServer2 <- `{"type":"message-received","sender":"peer1","message":"message1"}`
+	msg := <-toServer2
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+
+	mcb.AssertExpectations(t)
+}
+
+func TestReloadConfigMissingURL(t *testing.T) {
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	utConfPrefix.Set(restclient.HTTPConfigURL, "")
+	err := h.ReloadConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10138", err)
+}
+
+func TestReloadConfigBadTLS(t *testing.T) {
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	utConfPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := h.ReloadConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestReloadConfigBadURL(t *testing.T) {
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	utConfPrefix.Set(restclient.HTTPConfigURL, "::::////")
+	err := h.ReloadConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10162", err)
+}
+
+func TestReloadConfigConnectFail(t *testing.T) {
+	h, _, _, _, done := newTestHTTPS(t)
+	defer done()
+
+	previous := h.wsconn
+	_, _, wsURL2, cancel2 := wsclient.NewTestWSServer(nil)
+	cancel2()
+	utConfPrefix.Set(restclient.HTTPConfigURL, wsURL2)
+	utConfPrefix.Set(wsconfig.WSConfigKeyInitialConnectAttempts, 1)
+	err := h.ReloadConfig(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10161", err)
+	assert.Equal(t, previous, h.wsconn)
+}
+
 func TestEventLoopReceiveClosed(t *testing.T) {
 	dxc := &dataexchangemocks.Callbacks{}
 	wsm := &wsmocks.WSClient{}
@@ -438,7 +576,7 @@ func TestEventLoopReceiveClosed(t *testing.T) {
 	close(r)
 	wsm.On("Close").Return()
 	wsm.On("Receive").Return((<-chan []byte)(r))
-	h.eventLoop() // we're simply looking for it exiting
+	h.eventLoop(wsm) // we're simply looking for it exiting
 }
 
 func TestEventLoopSendClosed(t *testing.T) {
@@ -454,7 +592,7 @@ func TestEventLoopSendClosed(t *testing.T) {
 	wsm.On("Close").Return()
 	wsm.On("Receive").Return((<-chan []byte)(r))
 	wsm.On("Send", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
-	h.eventLoop() // we're simply looking for it exiting
+	h.eventLoop(wsm) // we're simply looking for it exiting
 }
 
 func TestEventLoopClosedContext(t *testing.T) {
@@ -472,5 +610,5 @@ func TestEventLoopClosedContext(t *testing.T) {
 	wsm.On("Close").Return()
 	wsm.On("Receive").Return((<-chan []byte)(r))
 	wsm.On("Send", mock.Anything, mock.Anything).Return(nil)
-	h.eventLoop() // we're simply looking for it exiting
+	h.eventLoop(wsm) // we're simply looking for it exiting
 }
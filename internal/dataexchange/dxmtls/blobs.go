@@ -0,0 +1,125 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	localBlobsDir    = "local"
+	receivedBlobsDir = "receive"
+	hashSuffix       = ".hash"
+)
+
+// resolveBlobPath maps a payloadRef to a file, ensuring it cannot escape the blobs directory
+func (m *MTLS) resolveBlobPath(ctx context.Context, payloadRef string) (string, error) {
+	root := filepath.Join(m.dataDir, blobsDir)
+	path := filepath.Join(root, filepath.FromSlash(payloadRef))
+	if !strings.HasPrefix(path, root+string(os.PathSeparator)) || strings.HasSuffix(path, hashSuffix) {
+		return "", i18n.NewError(ctx, i18n.MsgDXMTLSInvalidBlobPath, payloadRef)
+	}
+	return path, nil
+}
+
+// parsePayloadRef extracts the namespace and ID from the last two segments of a payloadRef
+func (m *MTLS) parsePayloadRef(ctx context.Context, payloadRef string) (ns string, id *fftypes.UUID, err error) {
+	segments := strings.Split(payloadRef, "/")
+	if len(segments) >= 2 {
+		ns = segments[len(segments)-2]
+		id, err = fftypes.ParseUUID(ctx, segments[len(segments)-1])
+	}
+	if ns == "" || id == nil {
+		return "", nil, i18n.NewError(ctx, i18n.MsgDXMTLSInvalidBlobPath, payloadRef)
+	}
+	return ns, id, nil
+}
+
+// writeBlob stores the content, then the hash alongside it. The hash is written last,
+// so a blob is only reported as received once it is complete.
+func (m *MTLS) writeBlob(ctx context.Context, payloadRef string, content io.Reader) (*fftypes.Bytes32, error) {
+	path, err := m.resolveBlobPath(ctx, payloadRef)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.Create(path)
+	}
+	hasher := sha256.New()
+	if err == nil {
+		_, err = io.Copy(io.MultiWriter(f, hasher), content)
+		_ = f.Close()
+		if err != nil {
+			// Do not leave a partial blob behind, such as one cut off at the size limit
+			_ = os.Remove(path)
+		}
+	}
+	hash := fftypes.HashResult(hasher)
+	if err == nil {
+		err = ioutil.WriteFile(path+hashSuffix, []byte(hash.String()), 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hash, nil
+}
+
+func (m *MTLS) UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, content io.Reader) (payloadRef string, hash *fftypes.Bytes32, err error) {
+	payloadRef = strings.Join([]string{localBlobsDir, ns, id.String()}, "/")
+	if hash, err = m.writeBlob(ctx, payloadRef, content); err != nil {
+		return "", nil, err
+	}
+	return payloadRef, hash, nil
+}
+
+func (m *MTLS) IsLocalBLOB(ns, payloadRef string) bool {
+	return strings.HasPrefix(payloadRef, strings.Join([]string{localBlobsDir, ns, ""}, "/")) && path.Clean(payloadRef) == payloadRef
+}
+
+func (m *MTLS) DownloadBLOB(ctx context.Context, payloadRef string) (content io.ReadCloser, err error) {
+	path, err := m.resolveBlobPath(ctx, payloadRef)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (m *MTLS) CheckBLOBReceived(ctx context.Context, peerID, ns string, id fftypes.UUID) (hash *fftypes.Bytes32, err error) {
+	path, err := m.resolveBlobPath(ctx, strings.Join([]string{receivedBlobsDir, peerID, ns, id.String()}, "/"))
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path + hashSuffix)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fftypes.ParseBytes32(ctx, string(b))
+}
@@ -0,0 +1,146 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+type errorReader struct{}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("pop")
+}
+
+func TestUploadDownloadBLOB(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	ctx := context.Background()
+	blobID := fftypes.NewUUID()
+	payloadRef, hash, err := m.UploadBLOB(ctx, "ns1", *blobID, bytes.NewReader([]byte("some data")))
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.Bytes32(sha256.Sum256([]byte("some data"))), *hash)
+
+	reader, err := m.DownloadBLOB(ctx, payloadRef)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, "some data", string(data))
+}
+
+func TestIsLocalBLOB(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	u := fftypes.NewUUID()
+	assert.True(t, m.IsLocalBLOB("ns1", "local/ns1/"+u.String()))
+	assert.False(t, m.IsLocalBLOB("ns2", "local/ns1/"+u.String()))
+	assert.False(t, m.IsLocalBLOB("ns1", "receive/node2/ns1/"+u.String()))
+	assert.False(t, m.IsLocalBLOB("ns1", "local/ns1/../../receive/node2/ns1/"+u.String()))
+}
+
+func TestDownloadBLOBInvalidPath(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	_, err := m.DownloadBLOB(context.Background(), "../peers/node2.json")
+	assert.Regexp(t, "FF10430", err)
+	_, err = m.DownloadBLOB(context.Background(), "local/ns1/id.hash")
+	assert.Regexp(t, "FF10430", err)
+}
+
+func TestUploadBLOBReadFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	_, _, err := m.UploadBLOB(context.Background(), "ns1", *fftypes.NewUUID(), &errorReader{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestUploadBLOBCreateFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	blobID := fftypes.NewUUID()
+	err := os.MkdirAll(filepath.Join(m.dataDir, blobsDir, localBlobsDir, "ns1", blobID.String()), 0700)
+	assert.NoError(t, err)
+	_, _, err = m.UploadBLOB(context.Background(), "ns1", *blobID, bytes.NewReader([]byte("some data")))
+	assert.Error(t, err)
+}
+
+func TestUploadBLOBWriteHashFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	blobID := fftypes.NewUUID()
+	err := os.MkdirAll(filepath.Join(m.dataDir, blobsDir, localBlobsDir, "ns1", blobID.String()+hashSuffix), 0700)
+	assert.NoError(t, err)
+	_, _, err = m.UploadBLOB(context.Background(), "ns1", *blobID, bytes.NewReader([]byte("some data")))
+	assert.Error(t, err)
+}
+
+func TestCheckBLOBReceivedNotFound(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	hash, err := m.CheckBLOBReceived(context.Background(), "node2", "ns1", *fftypes.NewUUID())
+	assert.NoError(t, err)
+	assert.Nil(t, hash)
+}
+
+func TestCheckBLOBReceivedInvalidPath(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	_, err := m.CheckBLOBReceived(context.Background(), "../..", "..", *fftypes.NewUUID())
+	assert.Regexp(t, "FF10430", err)
+}
+
+func TestCheckBLOBReceivedReadFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	blobID := fftypes.NewUUID()
+	err := os.MkdirAll(filepath.Join(m.dataDir, blobsDir, receivedBlobsDir, "node2", "ns1", blobID.String()+hashSuffix), 0700)
+	assert.NoError(t, err)
+	_, err = m.CheckBLOBReceived(context.Background(), "node2", "ns1", *blobID)
+	assert.Error(t, err)
+}
+
+func TestCheckBLOBReceivedBadHash(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	blobID := fftypes.NewUUID()
+	dir := filepath.Join(m.dataDir, blobsDir, receivedBlobsDir, "node2", "ns1")
+	err := os.MkdirAll(dir, 0700)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, blobID.String()+hashSuffix), []byte("!hash"), 0600)
+	assert.NoError(t, err)
+	_, err = m.CheckBLOBReceived(context.Background(), "node2", "ns1", *blobID)
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestParsePayloadRefInvalid(t *testing.T) {
+	m := &MTLS{}
+	_, _, err := m.parsePayloadRef(context.Background(), "noslash")
+	assert.Regexp(t, "FF10430", err)
+	_, _, err = m.parsePayloadRef(context.Background(), fmt.Sprintf("/%s", fftypes.NewUUID()))
+	assert.Regexp(t, "FF10430", err)
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+const (
+	// MTLSConfAddress the local address to listen on for connections from other peers
+	MTLSConfAddress = "address"
+	// MTLSConfPort the local port to listen on for connections from other peers
+	MTLSConfPort = "port"
+	// MTLSConfPublicURL the URL other peers use to connect to this node, which is published in the endpoint info
+	MTLSConfPublicURL = "publicURL"
+	// MTLSConfCertFile the PEM certificate used for both the server and client sides of every connection
+	MTLSConfCertFile = "certFile"
+	// MTLSConfKeyFile the PEM private key for the certificate
+	MTLSConfKeyFile = "keyFile"
+	// MTLSConfDataDir the directory used to store blobs and the endpoints of known peers
+	MTLSConfDataDir = "dataDir"
+	// MTLSConfRequestTimeout the timeout for each transfer to another peer
+	MTLSConfRequestTimeout = "requestTimeout"
+	// MTLSConfReadHeaderTimeout the time allowed for a peer to send the headers of a request to this node
+	MTLSConfReadHeaderTimeout = "readHeaderTimeout"
+	// MTLSConfReadTimeout the time allowed for a peer to send a whole request to this node, including a blob
+	MTLSConfReadTimeout = "readTimeout"
+	// MTLSConfMaxMessageSize the largest message a peer can send to this node (0 disables)
+	MTLSConfMaxMessageSize = "maxMessageSize"
+	// MTLSConfMaxBlobSize the largest blob a peer can transfer to this node (0 disables)
+	MTLSConfMaxBlobSize = "maxBlobSize"
+)
+
+func (m *MTLS) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(MTLSConfAddress, "0.0.0.0")
+	prefix.AddKnownKey(MTLSConfPort, 5443)
+	prefix.AddKnownKey(MTLSConfPublicURL)
+	prefix.AddKnownKey(MTLSConfCertFile)
+	prefix.AddKnownKey(MTLSConfKeyFile)
+	prefix.AddKnownKey(MTLSConfDataDir, "dx")
+	prefix.AddKnownKey(MTLSConfRequestTimeout, "2m")
+	prefix.AddKnownKey(MTLSConfReadHeaderTimeout, "30s")
+	prefix.AddKnownKey(MTLSConfReadTimeout, "2m")
+	prefix.AddKnownKey(MTLSConfMaxMessageSize, "50Mb")
+	prefix.AddKnownKey(MTLSConfMaxBlobSize, "1Gb")
+}
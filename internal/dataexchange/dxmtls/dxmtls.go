@@ -0,0 +1,336 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/dataexchange"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// MTLS is a built-in data exchange, that transfers messages and blobs directly between
+// FireFly nodes over HTTPS connections, where both sides present a certificate.
+//
+// Certificates are not verified against a CA. Instead each peer is trusted only if it presents
+// exactly the certificate it published in its endpoint info, via the network map.
+type MTLS struct {
+	ctx               context.Context
+	capabilities      *dataexchange.Capabilities
+	callbacks         dataexchange.Callbacks
+	peerID            string
+	publicURL         string
+	cert              tls.Certificate
+	certPEM           string
+	dataDir           string
+	requestTimeout    time.Duration
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	maxMessageSize    int64
+	maxBlobSize       int64
+	listener          net.Listener
+	server            *http.Server
+	peerMux           sync.Mutex
+	peers             map[string]*peer
+}
+
+type peer struct {
+	id     string
+	url    string
+	cert   []byte
+	client *http.Client
+}
+
+const (
+	peersDir = "peers"
+	blobsDir = "blobs"
+)
+
+func (m *MTLS) Name() string {
+	return "mtls"
+}
+
+func (m *MTLS) Init(ctx context.Context, prefix config.Prefix, callbacks dataexchange.Callbacks) (err error) {
+	m.ctx = log.WithComponent(log.WithLogField(ctx, "dx", "mtls"), "dataexchange")
+	m.callbacks = callbacks
+	m.capabilities = &dataexchange.Capabilities{}
+	m.peers = make(map[string]*peer)
+	m.requestTimeout = prefix.GetDuration(MTLSConfRequestTimeout)
+	m.readHeaderTimeout = prefix.GetDuration(MTLSConfReadHeaderTimeout)
+	m.readTimeout = prefix.GetDuration(MTLSConfReadTimeout)
+	m.maxMessageSize = prefix.GetByteSize(MTLSConfMaxMessageSize)
+	m.maxBlobSize = prefix.GetByteSize(MTLSConfMaxBlobSize)
+	m.dataDir = prefix.GetString(MTLSConfDataDir)
+
+	for _, key := range []string{MTLSConfPublicURL, MTLSConfCertFile, MTLSConfKeyFile} {
+		if prefix.GetString(key) == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, key, "dataexchange.mtls")
+		}
+	}
+	m.publicURL = strings.TrimSuffix(prefix.GetString(MTLSConfPublicURL), "/")
+
+	if err = m.loadCert(ctx, prefix.GetString(MTLSConfCertFile), prefix.GetString(MTLSConfKeyFile)); err != nil {
+		return err
+	}
+
+	for _, dir := range []string{peersDir, blobsDir} {
+		if err = os.MkdirAll(filepath.Join(m.dataDir, dir), 0700); err != nil {
+			return err
+		}
+	}
+	if err = m.loadPeers(ctx); err != nil {
+		return err
+	}
+
+	listenAddr := fmt.Sprintf("%s:%d", prefix.GetString(MTLSConfAddress), prefix.GetUint(MTLSConfPort))
+	if m.listener, err = net.Listen("tcp", listenAddr); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDXMTLSListenFailed, listenAddr)
+	}
+	m.server = m.createServer()
+	return nil
+}
+
+func (m *MTLS) loadCert(ctx context.Context, certFile, keyFile string) error {
+	certPEM, err := ioutil.ReadFile(certFile)
+	var keyPEM []byte
+	if err == nil {
+		keyPEM, err = ioutil.ReadFile(keyFile)
+	}
+	if err == nil {
+		m.cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	}
+	var leaf *x509.Certificate
+	if err == nil {
+		leaf, err = x509.ParseCertificate(m.cert.Certificate[0])
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgDXMTLSCertLoadFailed, certFile, keyFile)
+	}
+	m.peerID = leaf.Subject.CommonName
+	if m.peerID == "" {
+		return i18n.NewError(ctx, i18n.MsgDXMTLSNoCommonName)
+	}
+	if err = fftypes.ValidateFFNameField(ctx, m.peerID, "commonName"); err != nil {
+		return err
+	}
+	m.certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))
+	return nil
+}
+
+// loadPeers restores the peers added in previous runs, as the network map only calls AddPeer
+// when it first processes the definition of each node
+func (m *MTLS) loadPeers(ctx context.Context) error {
+	files, err := ioutil.ReadDir(filepath.Join(m.dataDir, peersDir))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		peerID := strings.TrimSuffix(f.Name(), ".json")
+		var endpoint fftypes.JSONObject
+		b, err := ioutil.ReadFile(filepath.Join(m.dataDir, peersDir, f.Name()))
+		if err == nil {
+			err = json.Unmarshal(b, &endpoint)
+		}
+		var p *peer
+		if err == nil {
+			p, err = m.newPeer(ctx, peerID, endpoint)
+		}
+		if err != nil {
+			return err
+		}
+		m.peers[peerID] = p
+	}
+	log.L(ctx).Infof("Loaded %d data exchange peers", len(m.peers))
+	return nil
+}
+
+func (m *MTLS) Start() error {
+	go m.serve()
+	return nil
+}
+
+func (m *MTLS) Capabilities() *dataexchange.Capabilities {
+	return m.capabilities
+}
+
+func (m *MTLS) GetEndpointInfo(ctx context.Context) (peerID string, endpoint fftypes.JSONObject, err error) {
+	return m.peerID, fftypes.JSONObject{
+		"id":       m.peerID,
+		"endpoint": m.publicURL,
+		"cert":     m.certPEM,
+	}, nil
+}
+
+func (m *MTLS) newPeer(ctx context.Context, peerID string, endpoint fftypes.JSONObject) (*peer, error) {
+	if err := fftypes.ValidateFFNameField(ctx, peerID, "peer"); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(endpoint.GetString("endpoint"))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgDXMTLSBadPeerEndpoint, peerID)
+	}
+	block, _ := pem.Decode([]byte(endpoint.GetString("cert")))
+	if block == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgDXMTLSBadPeerEndpoint, peerID)
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDXMTLSBadPeerEndpoint, peerID)
+	}
+	p := &peer{
+		id:   peerID,
+		url:  strings.TrimSuffix(u.String(), "/"),
+		cert: block.Bytes,
+	}
+	p.client = &http.Client{
+		Timeout: m.requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{m.cert},
+				// The usual chain verification is replaced by pinning the exact certificate the peer published
+				InsecureSkipVerify: true, // #nosec G402
+				VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+					if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], p.cert) {
+						return i18n.NewError(m.ctx, i18n.MsgDXMTLSUntrustedPeer)
+					}
+					return nil
+				},
+			},
+		},
+	}
+	return p, nil
+}
+
+func (m *MTLS) AddPeer(ctx context.Context, peerID string, endpoint fftypes.JSONObject) (err error) {
+	p, err := m.newPeer(ctx, peerID, endpoint)
+	if err != nil {
+		return err
+	}
+	b, _ := json.Marshal(endpoint)
+	if err = ioutil.WriteFile(filepath.Join(m.dataDir, peersDir, peerID+".json"), b, 0600); err != nil {
+		return err
+	}
+	m.peerMux.Lock()
+	defer m.peerMux.Unlock()
+	m.peers[peerID] = p
+	log.L(ctx).Infof("Added data exchange peer '%s' at %s", peerID, p.url)
+	return nil
+}
+
+func (m *MTLS) getPeer(ctx context.Context, peerID string) (*peer, error) {
+	m.peerMux.Lock()
+	defer m.peerMux.Unlock()
+	p, ok := m.peers[peerID]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgDXMTLSUnknownPeer, peerID)
+	}
+	return p, nil
+}
+
+func (m *MTLS) getPeerByCert(rawCert []byte) *peer {
+	m.peerMux.Lock()
+	defer m.peerMux.Unlock()
+	for _, p := range m.peers {
+		if bytes.Equal(p.cert, rawCert) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *MTLS) SendMessage(ctx context.Context, opID *fftypes.UUID, peerID string, data []byte) error {
+	p, err := m.getPeer(ctx, peerID)
+	if err != nil {
+		return err
+	}
+	go m.transfer(opID.String(), p, http.MethodPost, "/api/v1/messages", ioutil.NopCloser(bytes.NewReader(data)))
+	return nil
+}
+
+func (m *MTLS) TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID, payloadRef string) error {
+	p, err := m.getPeer(ctx, peerID)
+	if err != nil {
+		return err
+	}
+	ns, id, err := m.parsePayloadRef(ctx, payloadRef)
+	if err != nil {
+		return err
+	}
+	content, err := m.DownloadBLOB(ctx, payloadRef)
+	if err != nil {
+		return err
+	}
+	go m.transfer(opID.String(), p, http.MethodPut, fmt.Sprintf("/api/v1/blobs/%s/%s", ns, id), content)
+	return nil
+}
+
+// transfer delivers a request to a peer in the background, and reports the result against the ID of the operation
+func (m *MTLS) transfer(trackingID string, p *peer, method, path string, body io.ReadCloser) {
+	l := log.L(m.ctx).WithField("request", trackingID)
+	status := fftypes.OpStatusSucceeded
+	info := ""
+	if err := m.send(p, method, path, body); err != nil {
+		l.Errorf("Transfer to peer '%s' failed: %s", p.id, err)
+		status = fftypes.OpStatusFailed
+		info = err.Error()
+	} else {
+		l.Debugf("Transfer to peer '%s' complete", p.id)
+	}
+	if err := m.callbacks.TransferResult(trackingID, status, info, nil); err != nil {
+		l.Errorf("Failed to record result of transfer: %s", err)
+	}
+}
+
+func (m *MTLS) send(p *peer, method, path string, body io.ReadCloser) error {
+	req, err := http.NewRequestWithContext(m.ctx, method, p.url+path, body)
+	if err != nil {
+		_ = body.Close()
+		return err
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return i18n.NewError(m.ctx, i18n.MsgDXMTLSTransferFailed, p.id, res.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var utConfPrefix = config.NewPluginConfig("dxmtls_unit_tests")
+
+func writeTestCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certFile = filepath.Join(dir, fmt.Sprintf("%s.crt", commonName))
+	keyFile = filepath.Join(dir, fmt.Sprintf("%s.key", commonName))
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	assert.NoError(t, err)
+	return certFile, keyFile
+}
+
+func resetTestConfig(t *testing.T, dataDir, commonName string) {
+	certFile, keyFile := writeTestCert(t, dataDir, commonName)
+	config.Reset()
+	(&MTLS{}).InitPrefix(utConfPrefix)
+	utConfPrefix.Set(MTLSConfAddress, "127.0.0.1")
+	utConfPrefix.Set(MTLSConfPort, 0)
+	utConfPrefix.Set(MTLSConfPublicURL, "https://localhost:5443/")
+	utConfPrefix.Set(MTLSConfCertFile, certFile)
+	utConfPrefix.Set(MTLSConfKeyFile, keyFile)
+	utConfPrefix.Set(MTLSConfDataDir, dataDir)
+}
+
+func newTestMTLS(t *testing.T, peerID string) (m *MTLS, mcb *dataexchangemocks.Callbacks, cancel func()) {
+	dataDir := t.TempDir()
+	resetTestConfig(t, dataDir, peerID)
+	ctx, cancel := context.WithCancel(context.Background())
+	mcb = &dataexchangemocks.Callbacks{}
+	m = &MTLS{}
+	err := m.Init(ctx, utConfPrefix, mcb)
+	assert.NoError(t, err)
+	assert.Equal(t, "mtls", m.Name())
+	assert.NotNil(t, m.Capabilities())
+	m.publicURL = fmt.Sprintf("https://%s", m.listener.Addr())
+	return m, mcb, cancel
+}
+
+// connectTestPeers adds each node as a peer of the other, and starts both
+func connectTestPeers(t *testing.T, m1, m2 *MTLS) {
+	ctx := context.Background()
+	peer1, endpoint1, err := m1.GetEndpointInfo(ctx)
+	assert.NoError(t, err)
+	peer2, endpoint2, err := m2.GetEndpointInfo(ctx)
+	assert.NoError(t, err)
+	err = m1.AddPeer(ctx, peer2, endpoint2)
+	assert.NoError(t, err)
+	err = m2.AddPeer(ctx, peer1, endpoint1)
+	assert.NoError(t, err)
+	assert.NoError(t, m1.Start())
+	assert.NoError(t, m2.Start())
+}
+
+func TestInitMissingConfig(t *testing.T) {
+	resetTestConfig(t, t.TempDir(), "node1")
+	utConfPrefix.Set(MTLSConfPublicURL, "")
+	m := &MTLS{}
+	err := m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*publicURL", err)
+}
+
+func TestInitBadCert(t *testing.T) {
+	resetTestConfig(t, t.TempDir(), "node1")
+	utConfPrefix.Set(MTLSConfKeyFile, "/does/not/exist")
+	m := &MTLS{}
+	err := m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10424", err)
+}
+
+func TestInitNoCommonName(t *testing.T) {
+	dataDir := t.TempDir()
+	resetTestConfig(t, dataDir, "")
+	m := &MTLS{}
+	err := m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10425", err)
+}
+
+func TestInitBadCommonName(t *testing.T) {
+	resetTestConfig(t, t.TempDir(), "not a valid name")
+	m := &MTLS{}
+	err := m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10131.*commonName", err)
+}
+
+func TestInitBadDataDir(t *testing.T) {
+	dataDir := t.TempDir()
+	resetTestConfig(t, dataDir, "node1")
+	err := ioutil.WriteFile(filepath.Join(dataDir, "file"), []byte{}, 0600)
+	assert.NoError(t, err)
+	utConfPrefix.Set(MTLSConfDataDir, filepath.Join(dataDir, "file"))
+	m := &MTLS{}
+	err = m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Error(t, err)
+}
+
+func TestInitBadPeerFile(t *testing.T) {
+	dataDir := t.TempDir()
+	resetTestConfig(t, dataDir, "node1")
+	err := os.MkdirAll(filepath.Join(dataDir, peersDir), 0700)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dataDir, peersDir, "README"), []byte("ignored"), 0600)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dataDir, peersDir, "node2.json"), []byte("!json"), 0600)
+	assert.NoError(t, err)
+	m := &MTLS{}
+	err = m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Error(t, err)
+}
+
+func TestLoadPeersReadDirFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	err := os.RemoveAll(filepath.Join(m.dataDir, peersDir))
+	assert.NoError(t, err)
+	err = m.loadPeers(context.Background())
+	assert.Error(t, err)
+}
+
+func TestInitListenFail(t *testing.T) {
+	resetTestConfig(t, t.TempDir(), "node1")
+	utConfPrefix.Set(MTLSConfAddress, "::::")
+	m := &MTLS{}
+	err := m.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.Regexp(t, "FF10431", err)
+}
+
+func TestGetEndpointInfo(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	m.publicURL = "https://node1.example.com:5443"
+	peerID, endpoint, err := m.GetEndpointInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "node1", peerID)
+	assert.Equal(t, "node1", endpoint.GetString("id"))
+	assert.Equal(t, "https://node1.example.com:5443", endpoint.GetString("endpoint"))
+	assert.Regexp(t, "BEGIN CERTIFICATE", endpoint.GetString("cert"))
+}
+
+func TestAddPeerReloaded(t *testing.T) {
+	m1, _, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, _, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	peerID, endpoint, _ := m2.GetEndpointInfo(context.Background())
+	err := m1.AddPeer(context.Background(), peerID, endpoint)
+	assert.NoError(t, err)
+	m1.listener.Close()
+
+	utConfPrefix.Set(MTLSConfDataDir, m1.dataDir)
+	m3 := &MTLS{}
+	err = m3.Init(context.Background(), utConfPrefix, &dataexchangemocks.Callbacks{})
+	assert.NoError(t, err)
+	p, err := m3.getPeer(context.Background(), "node2")
+	assert.NoError(t, err)
+	assert.Equal(t, m2.publicURL, p.url)
+	m3.listener.Close()
+}
+
+func TestAddPeerBadEndpoint(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	_, endpoint, _ := m.GetEndpointInfo(context.Background())
+
+	err := m.AddPeer(context.Background(), "!bad", endpoint)
+	assert.Regexp(t, "FF10131", err)
+
+	err = m.AddPeer(context.Background(), "node2", fftypes.JSONObject{
+		"endpoint": "http://node2:5443",
+		"cert":     endpoint.GetString("cert"),
+	})
+	assert.Regexp(t, "FF10427", err)
+
+	err = m.AddPeer(context.Background(), "node2", fftypes.JSONObject{
+		"endpoint": "https://node2:5443",
+		"cert":     "not a cert",
+	})
+	assert.Regexp(t, "FF10427", err)
+
+	err = m.AddPeer(context.Background(), "node2", fftypes.JSONObject{
+		"endpoint": "https://node2:5443",
+		"cert":     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")})),
+	})
+	assert.Regexp(t, "FF10427", err)
+}
+
+func TestAddPeerWriteFail(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	_, endpoint, _ := m.GetEndpointInfo(context.Background())
+	err := os.RemoveAll(filepath.Join(m.dataDir, peersDir))
+	assert.NoError(t, err)
+	err = m.AddPeer(context.Background(), "node1", endpoint)
+	assert.Error(t, err)
+}
+
+func TestSendMessageAndTransferBLOB(t *testing.T) {
+	m1, mcb1, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, mcb2, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	connectTestPeers(t, m1, m2)
+
+	results := make(chan string)
+	mcb1.On("TransferResult", mock.Anything, fftypes.OpStatusSucceeded, "", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		results <- args[0].(string)
+	})
+	mcb2.On("MessageReceived", "node1", []byte("hello")).Return(nil)
+
+	opID := fftypes.NewUUID()
+	err := m1.SendMessage(context.Background(), opID, "node2", []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, opID.String(), <-results)
+
+	ctx := context.Background()
+	blobID := fftypes.NewUUID()
+	payloadRef, hash, err := m1.UploadBLOB(ctx, "ns1", *blobID, bytes.NewReader([]byte("some data")))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("local/ns1/%s", blobID), payloadRef)
+	mcb2.On("BLOBReceived", "node1", *hash, fmt.Sprintf("receive/node1/ns1/%s", blobID)).Return(nil)
+
+	opID = fftypes.NewUUID()
+	err = m1.TransferBLOB(ctx, opID, "node2", payloadRef)
+	assert.NoError(t, err)
+	assert.Equal(t, opID.String(), <-results)
+
+	receivedHash, err := m2.CheckBLOBReceived(ctx, "node1", "ns1", *blobID)
+	assert.NoError(t, err)
+	assert.Equal(t, *hash, *receivedHash)
+	reader, err := m2.DownloadBLOB(ctx, fmt.Sprintf("receive/node1/ns1/%s", blobID))
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, "some data", string(data))
+
+	mcb1.AssertExpectations(t)
+	mcb2.AssertExpectations(t)
+}
+
+func TestSendMessageErrorStatus(t *testing.T) {
+	m1, mcb1, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, mcb2, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	connectTestPeers(t, m1, m2)
+
+	done := make(chan struct{})
+	mcb1.On("TransferResult", mock.Anything, fftypes.OpStatusFailed, mock.MatchedBy(func(info string) bool {
+		return assert.Regexp(t, "FF10429.*500.*pop", info)
+	}), mock.Anything).Return(fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		close(done)
+	})
+	mcb2.On("MessageReceived", "node1", []byte("hello")).Return(fmt.Errorf("pop"))
+
+	err := m1.SendMessage(context.Background(), fftypes.NewUUID(), "node2", []byte("hello"))
+	assert.NoError(t, err)
+	<-done
+}
+
+func TestSendMessageUntrustedClient(t *testing.T) {
+	m1, mcb1, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, _, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	peerID, endpoint, _ := m2.GetEndpointInfo(context.Background())
+	err := m1.AddPeer(context.Background(), peerID, endpoint)
+	assert.NoError(t, err)
+	assert.NoError(t, m2.Start())
+
+	done := make(chan struct{})
+	mcb1.On("TransferResult", mock.Anything, fftypes.OpStatusFailed, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		close(done)
+	})
+	err = m1.SendMessage(context.Background(), fftypes.NewUUID(), "node2", []byte("hello"))
+	assert.NoError(t, err)
+	<-done
+}
+
+func TestSendMessageUntrustedServer(t *testing.T) {
+	m1, mcb1, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, _, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	m3, _, cancel3 := newTestMTLS(t, "node3")
+	defer cancel3()
+	connectTestPeers(t, m1, m2)
+
+	// Point node2 at an endpoint that presents a different certificate
+	_, endpoint3, _ := m3.GetEndpointInfo(context.Background())
+	m1.peers["node2"].url = endpoint3.GetString("endpoint")
+	assert.NoError(t, m3.Start())
+
+	done := make(chan struct{})
+	mcb1.On("TransferResult", mock.Anything, fftypes.OpStatusFailed, mock.MatchedBy(func(info string) bool {
+		return assert.Regexp(t, "FF10428", info)
+	}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		close(done)
+	})
+	err := m1.SendMessage(context.Background(), fftypes.NewUUID(), "node2", []byte("hello"))
+	assert.NoError(t, err)
+	<-done
+}
+
+func TestSendBadURL(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	err := m.send(&peer{id: "node2", url: "::bad"}, http.MethodPost, "/api/v1/messages", ioutil.NopCloser(bytes.NewReader([]byte{})))
+	assert.Error(t, err)
+}
+
+func TestSendMessageUnknownPeer(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	defer cancel()
+	err := m.SendMessage(context.Background(), fftypes.NewUUID(), "node2", []byte("hello"))
+	assert.Regexp(t, "FF10426", err)
+}
+
+func TestTransferBLOBErrors(t *testing.T) {
+	m1, _, cancel1 := newTestMTLS(t, "node1")
+	defer cancel1()
+	m2, _, cancel2 := newTestMTLS(t, "node2")
+	defer cancel2()
+	connectTestPeers(t, m1, m2)
+
+	err := m1.TransferBLOB(context.Background(), fftypes.NewUUID(), "node3", "local/ns1/id")
+	assert.Regexp(t, "FF10426", err)
+
+	err = m1.TransferBLOB(context.Background(), fftypes.NewUUID(), "node2", "local/ns1/!uuid")
+	assert.Regexp(t, "FF10430", err)
+
+	err = m1.TransferBLOB(context.Background(), fftypes.NewUUID(), "node2", fmt.Sprintf("../ns1/%s", fftypes.NewUUID()))
+	assert.Regexp(t, "FF10430", err)
+
+	err = m1.TransferBLOB(context.Background(), fftypes.NewUUID(), "node2", fmt.Sprintf("local/ns1/%s", fftypes.NewUUID()))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestServerShutdown(t *testing.T) {
+	m, _, cancel := newTestMTLS(t, "node1")
+	assert.NoError(t, m.Start())
+	cancel()
+	for {
+		conn, err := net.Dial("tcp", m.listener.Addr().String())
+		if err != nil {
+			break
+		}
+		conn.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (m *MTLS) createServer() *http.Server {
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/messages", m.receiveMessage).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/blobs/{ns}/{id}", m.receiveBlob).Methods(http.MethodPut)
+	return &http.Server{
+		Handler: r,
+		// Peers are authenticated by certificate, but still must not be able to hold connections open indefinitely
+		ReadHeaderTimeout: m.readHeaderTimeout,
+		ReadTimeout:       m.readTimeout,
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{m.cert},
+			// Any client certificate is accepted by the TLS layer, but only if it is exactly
+			// the certificate published by one of the known peers
+			ClientAuth: tls.RequireAnyClientCert,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if m.getPeerByCert(rawCerts[0]) == nil {
+					return i18n.NewError(m.ctx, i18n.MsgDXMTLSUntrustedPeer)
+				}
+				return nil
+			},
+		},
+		ConnContext: func(newCtx context.Context, c net.Conn) context.Context {
+			l := log.L(m.ctx).WithField("req", fftypes.ShortID())
+			l.Debugf("New data exchange connection: remote=%s local=%s", c.RemoteAddr().String(), c.LocalAddr().String())
+			return log.WithLogger(newCtx, l)
+		},
+	}
+}
+
+func (m *MTLS) serve() {
+	serverEnded := make(chan struct{})
+	go func() {
+		select {
+		case <-m.ctx.Done():
+			log.L(m.ctx).Infof("Data exchange context cancelled - shutting down")
+			_ = m.server.Close()
+		case <-serverEnded:
+		}
+	}()
+	log.L(m.ctx).Infof("Data exchange listening for peers on %s", m.listener.Addr())
+	err := m.server.ServeTLS(m.listener, "", "")
+	close(serverEnded)
+	log.L(m.ctx).Infof("Data exchange server complete: %s", err)
+}
+
+func (m *MTLS) respondError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&fftypes.RESTError{
+		Status: status,
+		Error:  err.Error(),
+	})
+}
+
+// sender identifies the peer from the client certificate presented on the connection
+func (m *MTLS) sender(w http.ResponseWriter, req *http.Request) *peer {
+	var p *peer
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		p = m.getPeerByCert(req.TLS.PeerCertificates[0].Raw)
+	}
+	if p == nil {
+		m.respondError(w, http.StatusUnauthorized, i18n.NewError(req.Context(), i18n.MsgDXMTLSUntrustedPeer))
+	}
+	return p
+}
+
+// limitBody rejects a request that declares a body over the limit, and caps the body of any other request
+// so a peer cannot stream more than the limit. A zero limit is unlimited.
+func (m *MTLS) limitBody(w http.ResponseWriter, req *http.Request, limit int64) bool {
+	if limit <= 0 {
+		return true
+	}
+	if req.ContentLength > limit {
+		m.respondError(w, http.StatusRequestEntityTooLarge, i18n.NewError(req.Context(), i18n.MsgDXMTLSRequestTooLarge, req.ContentLength, limit))
+		return false
+	}
+	req.Body = http.MaxBytesReader(w, req.Body, limit)
+	return true
+}
+
+func (m *MTLS) receiveMessage(w http.ResponseWriter, req *http.Request) {
+	p := m.sender(w, req)
+	if p == nil || !m.limitBody(w, req, m.maxMessageSize) {
+		return
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		m.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	log.L(req.Context()).Debugf("Received message from peer '%s' (%d bytes)", p.id, len(data))
+	if err := m.callbacks.MessageReceived(p.id, data); err != nil {
+		m.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *MTLS) receiveBlob(w http.ResponseWriter, req *http.Request) {
+	p := m.sender(w, req)
+	if p == nil || !m.limitBody(w, req, m.maxBlobSize) {
+		return
+	}
+	vars := mux.Vars(req)
+	ns := vars["ns"]
+	if err := fftypes.ValidateFFNameField(req.Context(), ns, "namespace"); err != nil {
+		m.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := fftypes.ParseUUID(req.Context(), vars["id"])
+	if err != nil {
+		m.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	payloadRef := strings.Join([]string{receivedBlobsDir, p.id, ns, id.String()}, "/")
+	hash, err := m.writeBlob(req.Context(), payloadRef, req.Body)
+	if err != nil {
+		m.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	log.L(req.Context()).Debugf("Received blob '%s' from peer '%s' hash=%s", payloadRef, p.id, hash)
+	if err := m.callbacks.BLOBReceived(p.id, *hash, payloadRef); err != nil {
+		m.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,178 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dxmtls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestServer returns node1, with node2 added as a known peer that can be used to make requests
+func newTestServer(t *testing.T) (m *MTLS, mcb *dataexchangemocks.Callbacks, cancel func()) {
+	m, mcb, cancel1 := newTestMTLS(t, "node1")
+	m2, _, cancel2 := newTestMTLS(t, "node2")
+	peerID, endpoint, _ := m2.GetEndpointInfo(m2.ctx)
+	err := m.AddPeer(m.ctx, peerID, endpoint)
+	assert.NoError(t, err)
+	return m, mcb, func() {
+		cancel1()
+		cancel2()
+	}
+}
+
+func serveTestRequest(m *MTLS, peerID, method, path string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	if p, ok := m.peers[peerID]; ok {
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Raw: p.cert}},
+		}
+	}
+	res := httptest.NewRecorder()
+	m.server.Handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestReceiveMessageUnknownSender(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node3", http.MethodPost, "/api/v1/messages", bytes.NewReader([]byte("hello")))
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Regexp(t, "FF10428", res.Body.String())
+}
+
+func TestReceiveMessageReadFail(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node2", http.MethodPost, "/api/v1/messages", &errorReader{})
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestReceiveMessage(t *testing.T) {
+	m, mcb, cancel := newTestServer(t)
+	defer cancel()
+	mcb.On("MessageReceived", "node2", []byte("hello")).Return(nil)
+	res := serveTestRequest(m, "node2", http.MethodPost, "/api/v1/messages", bytes.NewReader([]byte("hello")))
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	mcb.AssertExpectations(t)
+}
+
+func TestReceiveMessageUnlimited(t *testing.T) {
+	m, mcb, cancel := newTestServer(t)
+	defer cancel()
+	m.maxMessageSize = 0
+	mcb.On("MessageReceived", "node2", []byte("hello")).Return(nil)
+	res := serveTestRequest(m, "node2", http.MethodPost, "/api/v1/messages", bytes.NewReader([]byte("hello")))
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	mcb.AssertExpectations(t)
+}
+
+func TestReceiveMessageTooLarge(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	m.maxMessageSize = 4
+	res := serveTestRequest(m, "node2", http.MethodPost, "/api/v1/messages", bytes.NewReader([]byte("hello")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	assert.Regexp(t, "FF10469", res.Body.String())
+}
+
+func TestReceiveMessageStreamTooLarge(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	m.maxMessageSize = 4
+	// A reader of unknown length is sent without a content length, so is cut off while reading
+	res := serveTestRequest(m, "node2", http.MethodPost, "/api/v1/messages", io.MultiReader(bytes.NewReader([]byte("hello"))))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestReceiveBlobUnknownSender(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node3", http.MethodPut, fmt.Sprintf("/api/v1/blobs/ns1/%s", fftypes.NewUUID()), bytes.NewReader([]byte("data")))
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestReceiveBlobBadNamespace(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node2", http.MethodPut, fmt.Sprintf("/api/v1/blobs/..../%s", fftypes.NewUUID()), bytes.NewReader([]byte("data")))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Regexp(t, "FF10131", res.Body.String())
+}
+
+func TestReceiveBlobBadID(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node2", http.MethodPut, "/api/v1/blobs/ns1/!uuid", bytes.NewReader([]byte("data")))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Regexp(t, "FF10142", res.Body.String())
+}
+
+func TestReceiveBlobWriteFail(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	res := serveTestRequest(m, "node2", http.MethodPut, fmt.Sprintf("/api/v1/blobs/ns1/%s", fftypes.NewUUID()), &errorReader{})
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestReceiveBlobTooLarge(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	m.maxBlobSize = 3
+	res := serveTestRequest(m, "node2", http.MethodPut, fmt.Sprintf("/api/v1/blobs/ns1/%s", fftypes.NewUUID()), bytes.NewReader([]byte("data")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	assert.Regexp(t, "FF10469", res.Body.String())
+}
+
+func TestReceiveBlobStreamTooLarge(t *testing.T) {
+	m, _, cancel := newTestServer(t)
+	defer cancel()
+	m.maxBlobSize = 3
+	blobID := fftypes.NewUUID()
+	res := serveTestRequest(m, "node2", http.MethodPut, fmt.Sprintf("/api/v1/blobs/ns1/%s", blobID), io.MultiReader(bytes.NewReader([]byte("data"))))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	// The partial blob is removed
+	_, err := os.Stat(filepath.Join(m.dataDir, blobsDir, receivedBlobsDir, "node2", "ns1", blobID.String()))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReceiveBlobCallbackFail(t *testing.T) {
+	m, mcb, cancel := newTestServer(t)
+	defer cancel()
+	blobID := fftypes.NewUUID()
+	mcb.On("BLOBReceived", "node2", mock.Anything, fmt.Sprintf("receive/node2/ns1/%s", blobID)).Return(fmt.Errorf("pop"))
+	res := serveTestRequest(m, "node2", http.MethodPut, fmt.Sprintf("/api/v1/blobs/ns1/%s", blobID), bytes.NewReader([]byte("data")))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Regexp(t, "pop", res.Body.String())
+
+	_, err := os.Stat(filepath.Join(m.dataDir, blobsDir, receivedBlobsDir, "node2", "ns1", blobID.String()))
+	assert.NoError(t, err)
+	mcb.AssertExpectations(t)
+}
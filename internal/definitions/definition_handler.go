@@ -86,6 +86,14 @@ func (dh *definitionHandlers) EnsureLocalGroup(ctx context.Context, group *fftyp
 	return dh.messaging.EnsureLocalGroup(ctx, group)
 }
 
+func (dh *definitionHandlers) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	return dh.messaging.GetGroupKey(ctx, group, epoch)
+}
+
+func (dh *definitionHandlers) GetLatestGroupKey(ctx context.Context, group *fftypes.Bytes32) (*fftypes.GroupKey, error) {
+	return dh.messaging.GetLatestGroupKey(ctx, group)
+}
+
 func (dh *definitionHandlers) HandleSystemBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (SystemBroadcastAction, error) {
 	l := log.L(ctx)
 	l.Infof("Confirming system broadcast '%s' [%s]", msg.Header.Tag, msg.Header.ID)
@@ -102,6 +110,18 @@ func (dh *definitionHandlers) HandleSystemBroadcast(ctx context.Context, msg *ff
 		valid, err = dh.handleNodeBroadcast(ctx, msg, data)
 	case fftypes.SystemTagDefinePool:
 		return dh.handleTokenPoolBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefineFFI:
+		valid, err = dh.handleFFIBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefinePseudonym:
+		valid, err = dh.handlePseudonymBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefineDelegation:
+		valid, err = dh.handleDelegationBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefineIdentityClaim:
+		valid, err = dh.handleIdentityClaimBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefineIdentityVerification:
+		valid, err = dh.handleIdentityVerificationBroadcast(ctx, msg, data)
+	case fftypes.SystemTagDefineGroupKey:
+		valid, err = dh.handleGroupKeyUpdate(ctx, msg, data)
 	default:
 		l.Warnf("Unknown SystemTag '%s' for definition ID '%s'", msg.Header.Tag, msg.Header.ID)
 		return ActionReject, nil
@@ -0,0 +1,91 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// resolveOrgDID returns the registered organization with the supplied DID, or nil if it is not found
+func (dh *definitionHandlers) resolveOrgDID(ctx context.Context, did string) (*fftypes.Organization, error) {
+	if !strings.HasPrefix(did, fftypes.FireflyOrgDIDPrefix) {
+		return nil, nil
+	}
+	orgID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(did, fftypes.FireflyOrgDIDPrefix))
+	if err != nil {
+		return nil, nil // an invalid DID cannot resolve to an org
+	}
+	return dh.database.GetOrganizationByID(ctx, orgID)
+}
+
+func (dh *definitionHandlers) handleDelegationBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var delegation fftypes.Delegation
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &delegation)
+	if !valid {
+		return false, nil
+	}
+
+	// The delegation (and its revocation) must be signed by the delegator, in its own namespace
+	if delegation.ID == nil ||
+		delegation.Delegator != msg.Header.Author ||
+		delegation.Delegate == delegation.Delegator ||
+		delegation.Namespace != msg.Header.Namespace {
+		l.Warnf("Unable to process delegation broadcast %s - author '%s' does not match delegation '%s' from '%s' to '%s' in namespace '%s'", msg.Header.ID, msg.Header.Author, delegation.ID, delegation.Delegator, delegation.Delegate, delegation.Namespace)
+		return false, nil
+	}
+
+	existing, err := dh.database.GetDelegationByID(ctx, delegation.ID)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	eventType := fftypes.EventTypeDelegationConfirmed
+	if existing != nil {
+		// The only valid update to a delegation is its revocation, which is final
+		if existing.Namespace != delegation.Namespace || existing.Delegator != delegation.Delegator ||
+			existing.Delegate != delegation.Delegate || existing.Revoked != nil || delegation.Revoked == nil {
+			l.Warnf("Unable to process delegation broadcast %s - mismatch with existing delegation %s", msg.Header.ID, existing.ID)
+			return false, nil
+		}
+		eventType = fftypes.EventTypeDelegationRevoked
+	} else {
+		delegate, err := dh.resolveOrgDID(ctx, delegation.Delegate)
+		if err != nil {
+			return false, err
+		}
+		if delegate == nil {
+			l.Warnf("Unable to process delegation broadcast %s - delegate '%s' not found", msg.Header.ID, delegation.Delegate)
+			return false, nil
+		}
+	}
+
+	if err = dh.database.UpsertDelegation(ctx, &delegation); err != nil {
+		return false, err
+	}
+
+	event := fftypes.NewEvent(eventType, delegation.Namespace, delegation.ID)
+	if err = dh.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,242 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testDelegationBroadcast(t *testing.T, delegation *fftypes.Delegation) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&delegation)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: delegation.Namespace,
+			Tag:       string(fftypes.SystemTagDefineDelegation),
+			Identity: fftypes.Identity{
+				Author: delegation.Delegator,
+				Key:    "0x12345",
+			},
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func testDelegation() (*fftypes.Delegation, *fftypes.Organization) {
+	delegate := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org2",
+		Identity: "0x23456",
+	}
+	return &fftypes.Delegation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Delegator: "did:firefly:org/" + fftypes.NewUUID().String(),
+		Delegate:  delegate.GetDID(),
+		Created:   fftypes.Now(),
+	}, delegate
+}
+
+func TestHandleDefinitionBroadcastDelegationOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, delegate := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, delegate.ID).Return(delegate, nil)
+	mdi.On("UpsertDelegation", mock.Anything, mock.MatchedBy(func(d *fftypes.Delegation) bool {
+		return d.ID.Equals(delegation.ID) && d.Message.Equals(msg.Header.ID) && d.Revoked == nil
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeDelegationConfirmed && e.Reference.Equals(delegation.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationRevokedOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, _ := testDelegation()
+	existing := *delegation
+	delegation.Revoked = fftypes.Now()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(&existing, nil)
+	mdi.On("UpsertDelegation", mock.Anything, mock.MatchedBy(func(d *fftypes.Delegation) bool {
+		return d.ID.Equals(delegation.ID) && d.Revoked != nil
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeDelegationRevoked && e.Reference.Equals(delegation.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, _ := testDelegation()
+	msg, _ := testDelegationBroadcast(t, delegation)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastDelegationWrongSigner(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, _ := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+	msg.Header.Author = delegation.Delegate
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastDelegationLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, _ := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationDuplicate(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, _ := testDelegation()
+	existing := *delegation
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(&existing, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationDelegateLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, delegate := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, delegate.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationDelegateNotFound(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, delegate := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, delegate.ID).Return(nil, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationDelegateNotOrg(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	for _, delegate := range []string{"org2", "did:firefly:org/bad"} {
+		delegation, _ := testDelegation()
+		delegation.Delegate = delegate
+		msg, data := testDelegationBroadcast(t, delegation)
+
+		mdi := dh.database.(*databasemocks.Plugin)
+		mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+		action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+		assert.Equal(t, ActionReject, action)
+		assert.NoError(t, err)
+	}
+}
+
+func TestHandleDefinitionBroadcastDelegationUpsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, delegate := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, delegate.ID).Return(delegate, nil)
+	mdi.On("UpsertDelegation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastDelegationEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	delegation, delegate := testDelegation()
+	msg, data := testDelegationBroadcast(t, delegation)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", mock.Anything, delegation.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, delegate.ID).Return(delegate, nil)
+	mdi.On("UpsertDelegation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
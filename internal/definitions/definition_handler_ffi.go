@@ -0,0 +1,59 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (dh *definitionHandlers) handleFFIBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var ffi fftypes.FFI
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &ffi)
+	if !valid {
+		return false, nil
+	}
+
+	if err = ffi.Validate(ctx, true); err != nil {
+		l.Warnf("Unable to process contract interface broadcast %s - validate failed: %s", msg.Header.ID, err)
+		return false, nil
+	}
+
+	existing, err := dh.database.GetFFI(ctx, ffi.Namespace, ffi.Name, ffi.Version)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	if existing != nil {
+		l.Warnf("Unable to process contract interface broadcast %s (%s:%s:%s) - duplicate of %v", msg.Header.ID, ffi.Namespace, ffi.Name, ffi.Version, existing.ID)
+		return false, nil
+	}
+
+	if err = dh.database.UpsertFFI(ctx, &ffi); err != nil {
+		return false, err
+	}
+
+	event := fftypes.NewEvent(fftypes.EventTypeContractInterfaceConfirmed, ffi.Namespace, ffi.ID)
+	if err = dh.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
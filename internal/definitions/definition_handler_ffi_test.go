@@ -0,0 +1,151 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testFFIBroadcast(t *testing.T, ffi *fftypes.FFI) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&ffi)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:  fftypes.NewUUID(),
+			Tag: string(fftypes.SystemTagDefineFFI),
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func testFFI() *fftypes.FFI {
+	return &fftypes.FFI{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Name:      "math",
+		Version:   "v1",
+		Methods: fftypes.FFIMethods{
+			{Name: "sum", Params: fftypes.FFIParams{{Name: "a", Type: fftypes.FFIParamTypeInteger}}},
+		},
+	}
+}
+
+func TestHandleDefinitionBroadcastFFIOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testFFIBroadcast(t, testFFI())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, nil)
+	mdi.On("UpsertFFI", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeContractInterfaceConfirmed
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastFFIBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, _ := testFFIBroadcast(t, testFFI())
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastFFIValidateFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	ffi := testFFI()
+	ffi.ID = nil
+	msg, data := testFFIBroadcast(t, ffi)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastFFILookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testFFIBroadcast(t, testFFI())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastFFIDuplicate(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testFFIBroadcast(t, testFFI())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(testFFI(), nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastFFIUpsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testFFIBroadcast(t, testFFI())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, nil)
+	mdi.On("UpsertFFI", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastFFIEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testFFIBroadcast(t, testFFI())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetFFI", mock.Anything, "ns1", "math", "v1").Return(nil, nil)
+	mdi.On("UpsertFFI", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,81 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (dh *definitionHandlers) handleGroupKeyUpdate(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var groupKey fftypes.GroupKey
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &groupKey)
+	if !valid {
+		return false, nil
+	}
+
+	// The key must be sent privately to the group it belongs to, in its own namespace
+	if groupKey.ID == nil || groupKey.Key == nil || groupKey.Epoch < 1 ||
+		msg.Header.Group == nil || !msg.Header.Group.Equals(groupKey.Group) ||
+		groupKey.Namespace != msg.Header.Namespace {
+		l.Warnf("Unable to process group key update %s - invalid key '%s' epoch %d for group '%s' in namespace '%s'", msg.Header.ID, groupKey.ID, groupKey.Epoch, groupKey.Group, groupKey.Namespace)
+		return false, nil
+	}
+
+	// Only a member of the group can rotate its key
+	group, err := dh.database.GetGroupByHash(ctx, groupKey.Group)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	isMember := false
+	if group != nil {
+		for _, member := range group.Members {
+			if member.Identity == msg.Header.Author {
+				isMember = true
+				break
+			}
+		}
+	}
+	if !isMember {
+		l.Warnf("Unable to process group key update %s - author '%s' is not a member of group '%s'", msg.Header.ID, msg.Header.Author, groupKey.Group)
+		return false, nil
+	}
+
+	// The first key confirmed for an epoch wins, and any concurrent rotation to the same epoch is rejected
+	existing, err := dh.database.GetGroupKey(ctx, groupKey.Group, groupKey.Epoch)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if existing.ID.Equals(groupKey.ID) {
+			return true, nil
+		}
+		l.Warnf("Unable to process group key update %s - epoch %d of group '%s' already set by key %s", msg.Header.ID, groupKey.Epoch, groupKey.Group, existing.ID)
+		return false, nil
+	}
+
+	groupKey.Created = fftypes.Now()
+	if err = dh.database.InsertGroupKey(ctx, &groupKey); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
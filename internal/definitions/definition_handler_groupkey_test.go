@@ -0,0 +1,239 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testGroupKeyUpdate(t *testing.T, groupKey *fftypes.GroupKey) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&groupKey)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: groupKey.Namespace,
+			Type:      fftypes.MessageTypeGroupUpdate,
+			Tag:       string(fftypes.SystemTagDefineGroupKey),
+			Group:     groupKey.Group,
+			Identity: fftypes.Identity{
+				Author: "did:firefly:org/org1",
+				Key:    "0x12345",
+			},
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func testGroupKey() *fftypes.GroupKey {
+	return &fftypes.GroupKey{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Group:     fftypes.NewRandB32(),
+		Epoch:     2,
+		Key:       fftypes.NewRandB32(),
+	}
+}
+
+func testKeyGroup(groupKey *fftypes.GroupKey) *fftypes.Group {
+	return &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: groupKey.Namespace,
+			Members: fftypes.Members{
+				{Identity: "did:firefly:org/org1", Node: fftypes.NewUUID()},
+				{Identity: "did:firefly:org/org2", Node: fftypes.NewUUID()},
+			},
+		},
+		Hash: groupKey.Group,
+	}
+}
+
+func TestHandleGroupKeyUpdateOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	mdi.On("GetGroupKey", mock.Anything, groupKey.Group, int64(2)).Return(nil, nil)
+	mdi.On("InsertGroupKey", mock.Anything, mock.MatchedBy(func(gk *fftypes.GroupKey) bool {
+		return gk.ID.Equals(groupKey.ID) && gk.Key.Equals(groupKey.Key) && gk.Message.Equals(msg.Header.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateReplayOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	mdi.On("GetGroupKey", mock.Anything, groupKey.Group, int64(2)).Return(groupKey, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateEpochConflict(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	mdi.On("GetGroupKey", mock.Anything, groupKey.Group, int64(2)).Return(testGroupKey(), nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:  fftypes.NewUUID(),
+			Tag: string(fftypes.SystemTagDefineGroupKey),
+		},
+	}, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleGroupKeyUpdateWrongGroup(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+	msg.Header.Group = fftypes.NewRandB32()
+
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleGroupKeyUpdateMissingKey(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	groupKey.Key = nil
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleGroupKeyUpdateNotMember(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+	msg.Header.Author = "did:firefly:org/org3"
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateGroupNotFound(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(nil, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateGetGroupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateGetGroupKeyFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	mdi.On("GetGroupKey", mock.Anything, groupKey.Group, int64(2)).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupKeyUpdateInsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	groupKey := testGroupKey()
+	msg, data := testGroupKeyUpdate(t, groupKey)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", mock.Anything, groupKey.Group).Return(testKeyGroup(groupKey), nil)
+	mdi.On("GetGroupKey", mock.Anything, groupKey.Group, int64(2)).Return(nil, nil)
+	mdi.On("InsertGroupKey", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,228 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (dh *definitionHandlers) rejectIdentity(ctx context.Context, msg *fftypes.Message, id *fftypes.UUID) (valid bool, err error) {
+	if id != nil {
+		event := fftypes.NewEvent(fftypes.EventTypeIdentityRejected, msg.Header.Namespace, id)
+		err = dh.database.InsertEvent(ctx, event)
+	}
+	return false, err
+}
+
+// resolveParentKey returns the signing key of the parent of a custom identity, which is either an organization
+// or a verified custom identity in the same namespace. An empty string is returned if the parent is not found.
+func (dh *definitionHandlers) resolveParentKey(ctx context.Context, identity *fftypes.CustomIdentity) (string, error) {
+	switch {
+	case strings.HasPrefix(identity.Parent, fftypes.FireflyOrgDIDPrefix):
+		orgID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(identity.Parent, fftypes.FireflyOrgDIDPrefix))
+		if err != nil {
+			return "", nil // an invalid DID cannot resolve to a parent
+		}
+		org, err := dh.database.GetOrganizationByID(ctx, orgID)
+		if err != nil || org == nil {
+			return "", err
+		}
+		return org.Identity, nil
+	case strings.HasPrefix(identity.Parent, fftypes.FireflyIdentityDIDPrefix):
+		parentID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(identity.Parent, fftypes.FireflyIdentityDIDPrefix))
+		if err != nil {
+			return "", nil // an invalid DID cannot resolve to a parent
+		}
+		parent, err := dh.database.GetCustomIdentityByID(ctx, parentID)
+		if err != nil || parent == nil || parent.Verified == nil || parent.Namespace != identity.Namespace {
+			return "", err
+		}
+		return parent.Key, nil
+	default:
+		return "", nil
+	}
+}
+
+func (dh *definitionHandlers) handleIdentityClaimBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var identity fftypes.CustomIdentity
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &identity)
+	if !valid {
+		return false, nil
+	}
+
+	// Only the verification broadcast by the parent can complete the registration
+	identity.Verification = nil
+	identity.Verified = nil
+
+	if err = identity.Validate(ctx); err != nil || identity.ID == nil {
+		l.Warnf("Unable to process identity claim %s - validate failed: %v", msg.Header.ID, err)
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	// The claim must be self-signed by the key of the identity, in its own namespace
+	if identity.Key != msg.Header.Key ||
+		identity.GetDID() != msg.Header.Author ||
+		identity.Namespace != msg.Header.Namespace {
+		l.Warnf("Unable to process identity claim %s - key/author '%s'/'%s' does not match identity '%s' in namespace '%s'", msg.Header.ID, msg.Header.Key, msg.Header.Author, identity.ID, identity.Namespace)
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	existing, err := dh.database.GetCustomIdentityByID(ctx, identity.ID)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	if existing != nil {
+		if existing.Namespace != identity.Namespace || existing.Name != identity.Name ||
+			existing.Key != identity.Key || existing.Parent != identity.Parent {
+			l.Warnf("Unable to process identity claim %s - mismatch with existing %s", msg.Header.ID, existing.ID)
+			return dh.rejectIdentity(ctx, msg, identity.ID)
+		}
+		// A replay of a claim we have already processed
+		return true, nil
+	}
+
+	parentKey, err := dh.resolveParentKey(ctx, &identity)
+	if err != nil {
+		return false, err
+	}
+	if parentKey == "" {
+		l.Warnf("Unable to process identity claim %s - parent identity not found: %s", msg.Header.ID, identity.Parent)
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	// Only a verified identity owns its key and name, so an unverified claim cannot squat on them.
+	// Competing claims are stored until one is verified by its parent, and the rest are rejected then.
+	registered, err := dh.identityRegistered(ctx, msg, &identity)
+	if err != nil {
+		return false, err
+	}
+	if registered {
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	if err = dh.database.UpsertCustomIdentity(ctx, &identity); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (dh *definitionHandlers) handleIdentityVerificationBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var verification fftypes.IdentityVerification
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &verification)
+	if !valid {
+		return false, nil
+	}
+
+	if verification.Identity == nil || verification.Namespace != msg.Header.Namespace {
+		l.Warnf("Unable to process identity verification %s - invalid identity '%s' in namespace '%s'", msg.Header.ID, verification.Identity, verification.Namespace)
+		return dh.rejectIdentity(ctx, msg, verification.Identity)
+	}
+
+	// The claim must have been processed first, and must match what is being verified
+	identity, err := dh.database.GetCustomIdentityByID(ctx, verification.Identity)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	if identity == nil ||
+		identity.Namespace != verification.Namespace ||
+		identity.Key != verification.Key ||
+		identity.Parent != verification.Parent ||
+		!identity.Claim.Equals(verification.Claim) {
+		l.Warnf("Unable to process identity verification %s - no matching claim for identity %s", msg.Header.ID, verification.Identity)
+		return dh.rejectIdentity(ctx, msg, verification.Identity)
+	}
+
+	// The verification must be signed by the parent
+	parentKey, err := dh.resolveParentKey(ctx, identity)
+	if err != nil {
+		return false, err
+	}
+	if msg.Header.Author != identity.Parent || parentKey == "" || msg.Header.Key != parentKey {
+		l.Warnf("Unable to process identity verification %s - incorrect signature. Expected=%s/%s Received=%s/%s", msg.Header.ID, identity.Parent, parentKey, msg.Header.Author, msg.Header.Key)
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	if identity.Verified != nil {
+		if identity.Verification.Equals(msg.Header.ID) {
+			// A replay of a verification we have already processed
+			return true, nil
+		}
+		l.Warnf("Unable to process identity verification %s - identity %s already verified by %s", msg.Header.ID, identity.ID, identity.Verification)
+		return false, nil
+	}
+
+	// Another claim to the key or name might have been verified since this one was made
+	registered, err := dh.identityRegistered(ctx, msg, identity)
+	if err != nil {
+		return false, err
+	}
+	if registered {
+		return dh.rejectIdentity(ctx, msg, identity.ID)
+	}
+
+	identity.Verification = msg.Header.ID
+	identity.Verified = fftypes.Now()
+	if err = dh.database.UpsertCustomIdentity(ctx, identity); err != nil {
+		return false, err
+	}
+
+	event := fftypes.NewEvent(fftypes.EventTypeIdentityConfirmed, identity.Namespace, identity.ID)
+	if err = dh.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// identityRegistered checks whether the key of the identity is already registered to an organization, or
+// its key or name to a different verified identity. Conflicts are logged against the message.
+func (dh *definitionHandlers) identityRegistered(ctx context.Context, msg *fftypes.Message, identity *fftypes.CustomIdentity) (bool, error) {
+	l := log.L(ctx)
+
+	org, err := dh.database.GetOrganizationByIdentity(ctx, identity.Key)
+	if err != nil {
+		return false, err
+	}
+	keyOwner, err := dh.database.GetCustomIdentityByKey(ctx, identity.Key)
+	if err != nil {
+		return false, err
+	}
+	if org != nil || (keyOwner != nil && !keyOwner.ID.Equals(identity.ID)) {
+		l.Warnf("Unable to process identity %s in message %s - key '%s' is already registered", identity.ID, msg.Header.ID, identity.Key)
+		return true, nil
+	}
+
+	nameOwner, err := dh.database.GetCustomIdentityByName(ctx, identity.Namespace, identity.Name)
+	if err != nil {
+		return false, err
+	}
+	if nameOwner != nil && !nameOwner.ID.Equals(identity.ID) {
+		l.Warnf("Unable to process identity %s in message %s - name '%s' is already registered as %s", identity.ID, msg.Header.ID, identity.Name, nameOwner.ID)
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,750 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testParentOrg() *fftypes.Organization {
+	return &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0xparent",
+	}
+}
+
+func testCustomIdentity(parent *fftypes.Organization) *fftypes.CustomIdentity {
+	return &fftypes.CustomIdentity{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Name:      "identity1",
+		Parent:    parent.GetDID(),
+		Key:       "0x12345",
+		Created:   fftypes.Now(),
+	}
+}
+
+func testIdentityClaimBroadcast(t *testing.T, identity *fftypes.CustomIdentity) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&identity)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: identity.Namespace,
+			Tag:       string(fftypes.SystemTagDefineIdentityClaim),
+			Identity: fftypes.Identity{
+				Author: identity.GetDID(),
+				Key:    identity.Key,
+			},
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func testIdentityVerificationBroadcast(t *testing.T, identity *fftypes.CustomIdentity, parentKey string) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&fftypes.IdentityVerification{
+		Identity:  identity.ID,
+		Namespace: identity.Namespace,
+		Parent:    identity.Parent,
+		Key:       identity.Key,
+		Claim:     identity.Claim,
+	})
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: identity.Namespace,
+			Tag:       string(fftypes.SystemTagDefineIdentityVerification),
+			Identity: fftypes.Identity{
+				Author: identity.Parent,
+				Key:    parentKey,
+			},
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func TestHandleIdentityClaimOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Verified = fftypes.Now() // spoofed
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.MatchedBy(func(ci *fftypes.CustomIdentity) bool {
+		return ci.ID.Equals(identity.ID) && ci.Claim.Equals(msg.Header.ID) && ci.Verified == nil
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimCustomParentOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	parent := testCustomIdentity(testParentOrg())
+	parent.Verified = fftypes.Now()
+	identity := testCustomIdentity(testParentOrg())
+	identity.Name = "identity2"
+	identity.Key = "0xabcde"
+	identity.Parent = parent.GetDID()
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetCustomIdentityByID", mock.Anything, parent.ID).Return(parent, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0xabcde").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0xabcde").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity2").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, _ := testIdentityClaimBroadcast(t, testCustomIdentity(testParentOrg()))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleIdentityClaimInvalid(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	identity.Name = "!bad"
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeIdentityRejected && e.Reference.Equals(identity.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimMissingID(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityClaimBroadcast(t, identity)
+	identity.ID = nil
+	_, data = testIdentityClaimBroadcast(t, identity)
+
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleIdentityClaimRejectEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityClaimBroadcast(t, identity)
+	msg.Header.Key = "0xabcde"
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimReplay(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimExistingMismatch(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityClaimBroadcast(t, identity)
+	existing := *identity
+	existing.Name = "identity2"
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(&existing, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimParentLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimParentNotFound(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	for _, parent := range []string{
+		"did:firefly:org/!bad",
+		"did:firefly:identity/!bad",
+		"did:firefly:node/12345",
+		"did:firefly:org/" + fftypes.NewUUID().String(),
+	} {
+		identity := testCustomIdentity(testParentOrg())
+		identity.Parent = parent
+		msg, data := testIdentityClaimBroadcast(t, identity)
+
+		mdi := dh.database.(*databasemocks.Plugin)
+		mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+		mdi.On("GetOrganizationByID", mock.Anything, mock.Anything).Return(nil, nil)
+		mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+		action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+		assert.Equal(t, ActionReject, action)
+		assert.NoError(t, err)
+	}
+}
+
+func TestHandleIdentityClaimParentNotVerified(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	parent := testCustomIdentity(testParentOrg())
+	identity := testCustomIdentity(testParentOrg())
+	identity.Parent = parent.GetDID()
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetCustomIdentityByID", mock.Anything, parent.ID).Return(parent, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimOrgKeyLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimIdentityKeyLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimKeyInUse(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{}, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimNameLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimNameInUse(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(&fftypes.CustomIdentity{ID: fftypes.NewUUID()}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimUpsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.MatchedBy(func(ci *fftypes.CustomIdentity) bool {
+		return ci.Verification.Equals(msg.Header.ID) && ci.Verified != nil
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeIdentityConfirmed && e.Reference.Equals(identity.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, _ := testIdentityVerificationBroadcast(t, testCustomIdentity(testParentOrg()), "0xparent")
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleIdentityVerificationMissingIdentity(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	identity.ID = nil
+	msg, data := testIdentityVerificationBroadcast(t, identity, "0xparent")
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleIdentityVerificationLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	msg, data := testIdentityVerificationBroadcast(t, identity, "0xparent")
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationNoClaim(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, "0xparent")
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeIdentityRejected && e.Reference.Equals(identity.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationClaimMismatch(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	identity := testCustomIdentity(testParentOrg())
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, "0xparent")
+	existing := *identity
+	existing.Claim = fftypes.NewUUID()
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(&existing, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationParentLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationWrongSigner(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, "0xnotparent")
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationReplay(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+	identity.Verification = msg.Header.ID
+	identity.Verified = fftypes.Now()
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationAlreadyVerified(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+	identity.Verification = fftypes.NewUUID()
+	identity.Verified = fftypes.Now()
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationUpsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(nil, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityClaimKeyVerifiedElsewhere(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	msg, data := testIdentityClaimBroadcast(t, identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(&fftypes.CustomIdentity{ID: fftypes.NewUUID()}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationOwnKeyAndName(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(&fftypes.CustomIdentity{ID: identity.ID}, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(&fftypes.CustomIdentity{ID: identity.ID}, nil)
+	mdi.On("UpsertCustomIdentity", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationKeyVerifiedElsewhere(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(&fftypes.CustomIdentity{ID: fftypes.NewUUID()}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeIdentityRejected && e.Reference.Equals(identity.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationNameVerifiedElsewhere(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "identity1").Return(&fftypes.CustomIdentity{ID: fftypes.NewUUID()}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleIdentityVerificationUniqueLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := testParentOrg()
+	identity := testCustomIdentity(org)
+	identity.Claim = fftypes.NewUUID()
+	msg, data := testIdentityVerificationBroadcast(t, identity, org.Identity)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", mock.Anything, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
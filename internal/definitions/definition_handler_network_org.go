@@ -23,6 +23,14 @@ import (
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
+func (dh *definitionHandlers) rejectOrganization(ctx context.Context, msg *fftypes.Message, org *fftypes.Organization) (valid bool, err error) {
+	if org.ID != nil {
+		event := fftypes.NewEvent(fftypes.EventTypeIdentityRejected, msg.Header.Namespace, org.ID)
+		err = dh.database.InsertEvent(ctx, event)
+	}
+	return false, err
+}
+
 func (dh *definitionHandlers) handleOrganizationBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
 	l := log.L(ctx)
 
@@ -34,7 +42,7 @@ func (dh *definitionHandlers) handleOrganizationBroadcast(ctx context.Context, m
 
 	if err = org.Validate(ctx, true); err != nil {
 		l.Warnf("Unable to process organization broadcast %s - validate failed: %s", msg.Header.ID, err)
-		return false, nil
+		return dh.rejectOrganization(ctx, msg, &org)
 	}
 
 	if org.Parent != "" {
@@ -44,12 +52,12 @@ func (dh *definitionHandlers) handleOrganizationBroadcast(ctx context.Context, m
 		}
 		if parent == nil {
 			l.Warnf("Unable to process organization broadcast %s - parent identity not found: %s", msg.Header.ID, org.Parent)
-			return false, nil
+			return dh.rejectOrganization(ctx, msg, &org)
 		}
 
 		if msg.Header.Key != parent.Identity {
 			l.Warnf("Unable to process organization broadcast %s - incorrect signature. Expected=%s Received=%s", msg.Header.ID, parent.Identity, msg.Header.Author)
-			return false, nil
+			return dh.rejectOrganization(ctx, msg, &org)
 		}
 	}
 
@@ -66,7 +74,7 @@ func (dh *definitionHandlers) handleOrganizationBroadcast(ctx context.Context, m
 	if existing != nil {
 		if existing.Parent != org.Parent {
 			l.Warnf("Unable to process organization broadcast %s - mismatch with existing %v", msg.Header.ID, existing.ID)
-			return false, nil
+			return dh.rejectOrganization(ctx, msg, &org)
 		}
 		org.ID = nil // we keep the existing ID
 	}
@@ -75,5 +83,10 @@ func (dh *definitionHandlers) handleOrganizationBroadcast(ctx context.Context, m
 		return false, err
 	}
 
+	event := fftypes.NewEvent(fftypes.EventTypeIdentityConfirmed, msg.Header.Namespace, org.ID)
+	if err = dh.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
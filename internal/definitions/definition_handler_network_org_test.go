@@ -58,6 +58,9 @@ func TestHandleDefinitionBroadcastChildOrgOk(t *testing.T) {
 	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
 	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
 	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityConfirmed
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -102,6 +105,9 @@ func TestHandleDefinitionBroadcastChildOrgDupOk(t *testing.T) {
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(parentOrg, nil)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(org, nil)
 	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityConfirmed
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -144,6 +150,9 @@ func TestHandleDefinitionBroadcastChildOrgBadKey(t *testing.T) {
 
 	mdi := dh.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(parentOrg, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityRejected
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -179,6 +188,9 @@ func TestHandleDefinitionBroadcastOrgDupMismatch(t *testing.T) {
 
 	mdi := dh.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x12345", Parent: "0x9999"}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityRejected
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -282,6 +294,9 @@ func TestHandleDefinitionBroadcastOrgAuthorMismatch(t *testing.T) {
 
 	mdi := dh.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x12345", Parent: "0x9999"}, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityRejected
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -352,6 +367,9 @@ func TestHandleDefinitionBroadcastGetParentNotFound(t *testing.T) {
 
 	mdi := dh.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityRejected
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -383,6 +401,10 @@ func TestHandleDefinitionBroadcastValidateFail(t *testing.T) {
 		Value: fftypes.Byteable(b),
 	}
 
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeIdentityRejected && *event.Reference == *org.ID
+	})).Return(nil)
 	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -395,6 +417,8 @@ func TestHandleDefinitionBroadcastValidateFail(t *testing.T) {
 	}, []*fftypes.Data{data})
 	assert.Equal(t, ActionReject, action)
 	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
 }
 
 func TestHandleDefinitionBroadcastUnmarshalFail(t *testing.T) {
@@ -417,3 +441,74 @@ func TestHandleDefinitionBroadcastUnmarshalFail(t *testing.T) {
 	assert.Equal(t, ActionReject, action)
 	assert.NoError(t, err)
 }
+
+func TestHandleDefinitionBroadcastOrgConfirmEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Description: "my org",
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: "0x12345",
+			},
+			Tag: string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastOrgRejectEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Parent:      "0x23456",
+		Description: "my org",
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: "did:firefly:org/0x23456",
+				Key:    "0x23456",
+			},
+			Tag: string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (dh *definitionHandlers) handlePseudonymBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var pseudonym fftypes.Pseudonym
+	valid = dh.getSystemBroadcastPayload(ctx, msg, data, &pseudonym)
+	if !valid {
+		return false, nil
+	}
+
+	// The owner is only known to the registering node, so we never accept one from the network
+	pseudonym.Owner = ""
+
+	// The registration must be self-signed by the pseudonymous key, in its own namespace
+	if pseudonym.ID == nil || pseudonym.Key == "" ||
+		pseudonym.Key != msg.Header.Key ||
+		pseudonym.GetDID() != msg.Header.Author ||
+		pseudonym.Namespace != msg.Header.Namespace {
+		l.Warnf("Unable to process pseudonym broadcast %s - key/author '%s'/'%s' does not match pseudonym '%s' in namespace '%s'", msg.Header.ID, msg.Header.Key, msg.Header.Author, pseudonym.ID, pseudonym.Namespace)
+		return false, nil
+	}
+
+	existing, err := dh.database.GetPseudonymByKey(ctx, pseudonym.Key)
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	if existing != nil && !existing.ID.Equals(pseudonym.ID) {
+		l.Warnf("Unable to process pseudonym broadcast %s - key '%s' already registered as %s", msg.Header.ID, pseudonym.Key, existing.ID)
+		return false, nil
+	}
+
+	if err = dh.database.UpsertPseudonym(ctx, &pseudonym); err != nil {
+		return false, err
+	}
+
+	event := fftypes.NewEvent(fftypes.EventTypePseudonymConfirmed, pseudonym.Namespace, pseudonym.ID)
+	if err = dh.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,173 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package definitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testPseudonymBroadcast(t *testing.T, pseudonym *fftypes.Pseudonym) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(&pseudonym)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: pseudonym.Namespace,
+			Tag:       string(fftypes.SystemTagDefinePseudonym),
+			Identity: fftypes.Identity{
+				Author: pseudonym.GetDID(),
+				Key:    pseudonym.Key,
+			},
+		},
+	}, []*fftypes.Data{{
+		Value: fftypes.Byteable(b),
+	}}
+}
+
+func testPseudonym() *fftypes.Pseudonym {
+	return &fftypes.Pseudonym{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+		Created:   fftypes.Now(),
+	}
+}
+
+func TestHandleDefinitionBroadcastPseudonymOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	pseudonym := testPseudonym()
+	pseudonym.Owner = "did:firefly:org/spoofed"
+	msg, data := testPseudonymBroadcast(t, pseudonym)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.MatchedBy(func(p *fftypes.Pseudonym) bool {
+		return p.ID.Equals(pseudonym.ID) && p.Owner == "" && p.Message.Equals(msg.Header.ID)
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePseudonymConfirmed && e.Reference.Equals(pseudonym.ID)
+	})).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastPseudonymLocalOk(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	pseudonym := testPseudonym()
+	msg, data := testPseudonymBroadcast(t, pseudonym)
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(pseudonym, nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionConfirm, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastPseudonymBadPayload(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, _ := testPseudonymBroadcast(t, testPseudonym())
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{})
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastPseudonymWrongSigner(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testPseudonymBroadcast(t, testPseudonym())
+	msg.Header.Key = "0xabcde"
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+}
+
+func TestHandleDefinitionBroadcastPseudonymLookupFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testPseudonymBroadcast(t, testPseudonym())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastPseudonymDuplicateKey(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testPseudonymBroadcast(t, testPseudonym())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(testPseudonym(), nil)
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionReject, action)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastPseudonymUpsertFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testPseudonymBroadcast(t, testPseudonym())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleDefinitionBroadcastPseudonymEventFail(t *testing.T) {
+	dh := newTestDefinitionHandlers(t)
+
+	msg, data := testPseudonymBroadcast(t, testPseudonym())
+
+	mdi := dh.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByKey", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("UpsertPseudonym", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	action, err := dh.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.Equal(t, ActionRetry, action)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -81,11 +81,15 @@ func TestPrivateMessagingPassthroughs(t *testing.T) {
 	mpm.On("GetGroups", ctx, mock.Anything).Return(nil, nil, nil)
 	mpm.On("ResolveInitGroup", ctx, mock.Anything).Return(nil, nil)
 	mpm.On("EnsureLocalGroup", ctx, mock.Anything).Return(false, nil)
+	mpm.On("GetGroupKey", ctx, mock.Anything, int64(1)).Return(nil, nil)
+	mpm.On("GetLatestGroupKey", ctx, mock.Anything).Return(nil, nil)
 
 	_, _ = dh.GetGroupByID(ctx, fftypes.NewUUID().String())
 	_, _, _ = dh.GetGroups(ctx, nil)
 	_, _ = dh.ResolveInitGroup(ctx, nil)
 	_, _ = dh.EnsureLocalGroup(ctx, nil)
+	_, _ = dh.GetGroupKey(ctx, nil, 1)
+	_, _ = dh.GetLatestGroupKey(ctx, nil)
 
 	mpm.AssertExpectations(t)
 
@@ -91,7 +91,7 @@ func (dh *definitionHandlers) handleTokenPoolBroadcast(ctx context.Context, msg
 	// Check if pool has already been confirmed on chain (and confirm the message if so)
 	if existingPool, err := dh.database.GetTokenPoolByID(ctx, pool.ID); err != nil {
 		return ActionRetry, err
-	} else if existingPool != nil && existingPool.State == fftypes.TokenPoolStateConfirmed {
+	} else if existingPool != nil && existingPool.Confirmed() {
 		return ActionConfirm, nil
 	}
 
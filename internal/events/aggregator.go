@@ -18,14 +18,19 @@ package events
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -45,9 +50,35 @@ type aggregator struct {
 	offchainBatches chan *fftypes.UUID
 	queuedRewinds   chan *fftypes.UUID
 	retry           *retry.Retry
+	pinPolicy       *pinPolicy
+	// parked and blocked track the pins behind the committed offset that are still pending, by sequence,
+	// so that on restart the aggregator can re-check them (via a snapshot) rather than re-scanning. Each is
+	// limited to maxPending entries, beyond which pins are only re-processed when their batch arrives.
+	parked     map[int64]string
+	blocked    map[int64]string
+	maxPending int
+	// quorum mode tracks receipts for broadcasts, and queues the receipts to send until the current group of pins commits
+	identity      identity.Manager
+	messaging     privatemessaging.Manager
+	quorumEnabled bool
+	quorumSize    int
+	receipts      []*fftypes.Message
+	// receipts are only sent once dispatch has started, as the primary node of the org sends them while we are a standby
+	dispatchMux sync.Mutex
+	dispatching bool
+	// batches quarantined until their author arrived are released when the identity definition is confirmed, as are
+	// messages pending a delegation when it is confirmed, and the pins parked for them are re-processed once the
+	// current group of pins commits
+	releaseAwaitingIdentity func(ctx context.Context, key string) ([]*fftypes.UUID, error)
+	released                []*fftypes.UUID
+	// pins for batches whose payload could not be retrieved from shared storage are blocked, and the
+	// retrieval is retried in the background with an exponential backoff
+	payloadRetry    retry.Retry
+	blockedPinsTap  chan bool
+	retryBlockedPin func(ctx context.Context, bp *fftypes.BlockedPin) error
 }
 
-func newAggregator(ctx context.Context, di database.Plugin, sh definitions.DefinitionHandlers, dm data.Manager, en *eventNotifier) *aggregator {
+func newAggregator(ctx context.Context, di database.Plugin, sh definitions.DefinitionHandlers, dm data.Manager, im identity.Manager, pm privatemessaging.Manager, en *eventNotifier) *aggregator {
 	batchSize := config.GetInt(config.EventAggregatorBatchSize)
 	ag := &aggregator{
 		ctx:             log.WithLogField(ctx, "role", "aggregator"),
@@ -57,6 +88,20 @@ func newAggregator(ctx context.Context, di database.Plugin, sh definitions.Defin
 		newPins:         make(chan int64),
 		offchainBatches: make(chan *fftypes.UUID, 1), // hops to queuedRewinds with a shouldertab on the event poller
 		queuedRewinds:   make(chan *fftypes.UUID, batchSize),
+		pinPolicy:       newPinPolicy(ctx),
+		parked:          make(map[int64]string),
+		blocked:         make(map[int64]string),
+		maxPending:      config.GetInt(config.EventAggregatorSnapshotMaxPending),
+		identity:        im,
+		messaging:       pm,
+		quorumEnabled:   config.GetBool(config.BroadcastQuorumEnabled),
+		quorumSize:      config.GetInt(config.BroadcastQuorumSize),
+		payloadRetry: retry.Retry{
+			InitialDelay: config.GetDuration(config.EventAggregatorPayloadRetryInitDelay),
+			MaximumDelay: config.GetDuration(config.EventAggregatorPayloadRetryMaxDelay),
+			Factor:       config.GetFloat64(config.EventAggregatorPayloadRetryFactor),
+		},
+		blockedPinsTap: make(chan bool, 1),
 	}
 	firstEvent := fftypes.SubOptsFirstEvent(config.GetString(config.EventAggregatorFirstEvent))
 	ag.eventPoller = newEventPoller(ctx, di, en, &eventPollerConf{
@@ -79,7 +124,11 @@ func newAggregator(ctx context.Context, di database.Plugin, sh definitions.Defin
 		addCriteria: func(af database.AndFilter) database.AndFilter {
 			return af.Condition(af.Builder().Eq("dispatched", false))
 		},
-		maybeRewind: ag.rewindOffchainBatches,
+		maybeRewind:      ag.rewindOffchainBatches,
+		snapshotType:     fftypes.SnapshotTypeAggregator,
+		snapshotInterval: config.GetDuration(config.EventAggregatorSnapshotInterval),
+		snapshotState:    ag.snapshotState,
+		restoreSnapshot:  ag.restoreSnapshot,
 	})
 	ag.retry = &ag.eventPoller.conf.retry
 	return ag
@@ -87,9 +136,16 @@ func newAggregator(ctx context.Context, di database.Plugin, sh definitions.Defin
 
 func (ag *aggregator) start() {
 	go ag.offchainListener()
+	go ag.blockedPinsLoop()
 	ag.eventPoller.start()
 }
 
+func (ag *aggregator) startDispatch() {
+	ag.dispatchMux.Lock()
+	defer ag.dispatchMux.Unlock()
+	ag.dispatching = true
+}
+
 func (ag *aggregator) offchainListener() {
 	for {
 		select {
@@ -136,16 +192,114 @@ func (ag *aggregator) rewindOffchainBatches() (rewind bool, offset int64) {
 	return rewind, offset
 }
 
+func pendingState(pending map[int64]string) fftypes.JSONObject {
+	state := fftypes.JSONObject{}
+	for sequence, ref := range pending {
+		state[strconv.FormatInt(sequence, 10)] = ref
+	}
+	return state
+}
+
+func (ag *aggregator) restorePending(pending map[int64]string, state fftypes.JSONObject) {
+	for k := range state {
+		sequence, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			continue
+		}
+		ag.trackPending(pending, sequence, state.GetString(k))
+	}
+}
+
+// trackPending records a pending pin to re-check on restart, unless the limit has been reached. This stops
+// a backlog of pins for batches that never arrive growing the memory and snapshots of the aggregator without bound.
+// A pin that is not tracked is still processed when its batch arrives, or when the earlier message blocking it
+// is dispatched, as both rewind the offset to before it. It is only missed if that happens while we are not
+// running, in which case it waits for the next rewind over it, or a restart without a snapshot.
+func (ag *aggregator) trackPending(pending map[int64]string, sequence int64, ref string) {
+	if _, ok := pending[sequence]; !ok && len(pending) >= ag.maxPending {
+		log.L(ag.ctx).Warnf("Pin %d for %s is not tracked to re-check on restart, as the limit of %d pending pins has been reached", sequence, ref, ag.maxPending)
+		return
+	}
+	pending[sequence] = ref
+}
+
+// snapshotState records the pins (by sequence) that are parked waiting for their batch to arrive, and those
+// blocked waiting for an earlier message on the same context
+func (ag *aggregator) snapshotState() fftypes.JSONObject {
+	return fftypes.JSONObject{
+		"parked":  pendingState(ag.parked),
+		"blocked": pendingState(ag.blocked),
+	}
+}
+
+// restoreSnapshot restores the pending pins from the snapshot. The batches of the parked pins are checked in
+// the background, so any that arrived without the rewind to process their pins completing before we stopped
+// are rewound to through the normal off-chain path. Polling resumes from the oldest blocked pin, as the
+// message blocking it might have been dispatched before we stopped. Only pins that are not yet dispatched
+// are polled, so this re-checks the pending pins rather than re-scanning.
+func (ag *aggregator) restoreSnapshot(snapshot *fftypes.Snapshot) (rewind bool, offset int64) {
+	ag.restorePending(ag.parked, snapshot.State.GetObject("parked"))
+	ag.restorePending(ag.blocked, snapshot.State.GetObject("blocked"))
+	log.L(ag.ctx).Infof("Restored aggregator snapshot parked=%d blocked=%d", len(ag.parked), len(ag.blocked))
+
+	for sequence := range ag.blocked {
+		if !rewind || sequence-1 < offset {
+			rewind, offset = true, sequence-1
+		}
+	}
+
+	uniqueBatchIDs := make(map[string]bool)
+	var batchIDs []driver.Value
+	for _, batchID := range ag.parked {
+		if !uniqueBatchIDs[batchID] {
+			uniqueBatchIDs[batchID] = true
+			batchIDs = append(batchIDs, batchID)
+		}
+	}
+	if len(batchIDs) > 0 {
+		go ag.recheckParkedBatches(batchIDs)
+	}
+	if !rewind {
+		offset = -1
+	}
+	return rewind, offset
+}
+
+func (ag *aggregator) recheckParkedBatches(batchIDs []driver.Value) {
+	var batches []*fftypes.Batch
+	err := ag.retry.Do(ag.ctx, "check for parked batch deliveries", func(attempt int) (retry bool, err error) {
+		fb := database.BatchQueryFactory.NewFilter(ag.ctx)
+		batches, _, err = ag.database.GetBatches(ag.ctx, fb.In("id", batchIDs))
+		return true, err
+	})
+	if err != nil {
+		return // context closed
+	}
+	for _, batch := range batches {
+		select {
+		case ag.offchainBatches <- batch.ID:
+		case <-ag.ctx.Done():
+			return
+		}
+	}
+}
+
 func (ag *aggregator) processPinsDBGroup(items []fftypes.LocallySequenced) (repoll bool, err error) {
 	pins := make([]*fftypes.Pin, len(items))
 	for i, item := range items {
 		pins[i] = item.(*fftypes.Pin)
 	}
+	ag.receipts = nil
+	ag.released = nil
 	err = ag.database.RunAsGroup(ag.ctx, func(ctx context.Context) (err error) {
 		err = ag.processPins(ctx, pins)
 		return err
 	})
-	return false, err
+	if err == nil {
+		ag.sendReceipts()
+		repoll = ag.rewindReleased()
+	}
+	return repoll, err
 }
 
 func (ag *aggregator) getPins(ctx context.Context, filter database.Filter) ([]fftypes.LocallySequenced, error) {
@@ -167,6 +321,8 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin) (err
 	dupMsgCheck := make(map[fftypes.UUID]bool)
 	for _, pin := range pins {
 		l.Debugf("Aggregating pin %.10d batch=%s hash=%s masked=%t", pin.Sequence, pin.Batch, pin.Hash, pin.Masked)
+		delete(ag.parked, pin.Sequence)
+		delete(ag.blocked, pin.Sequence)
 
 		if batch == nil || *batch.ID != *pin.Batch {
 			batch, err = ag.database.GetBatchByID(ctx, pin.Batch)
@@ -175,6 +331,7 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin) (err
 			}
 			if batch == nil {
 				l.Debugf("Batch %s not available - pin %s is parked", pin.Batch, pin.Hash)
+				ag.trackPending(ag.parked, pin.Sequence, pin.Batch.String())
 				continue
 			}
 		}
@@ -214,7 +371,7 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin) (err
 }
 
 func (ag *aggregator) calcHash(topic string, groupID *fftypes.Bytes32, identity string, nonce int64) *fftypes.Bytes32 {
-	h := sha256.New()
+	h := fftypes.NewDefaultHash()
 	h.Write([]byte(topic))
 	h.Write((*groupID)[:])
 	h.Write([]byte(identity))
@@ -227,8 +384,10 @@ func (ag *aggregator) calcHash(topic string, groupID *fftypes.Bytes32, identity
 func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch, masked bool, pinnedSequence int64, msg *fftypes.Message) (err error) {
 	l := log.L(ctx)
 
-	// Check if it's ready to be processed
+	// Check if it's ready to be processed, on each of the topics of the message
 	nextPins := make([]*fftypes.NextPin, len(msg.Pins))
+	topicsReady := make([]bool, len(msg.Header.Topics))
+	var blockedBy string
 	if masked {
 		// Private messages have one or more masked "pin" hashes that allow us to work
 		// out if it's the next message in the sequence, given the previous messages
@@ -244,16 +403,23 @@ func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch,
 				return nil
 			}
 			nextPin, err := ag.checkMaskedContextReady(ctx, msg, msg.Header.Topics[i], pinnedSequence, &pin)
-			if err != nil || nextPin == nil {
+			if err != nil {
 				return err
 			}
+			if nextPin == nil {
+				if blockedBy == "" {
+					blockedBy = pinStr
+				}
+				continue
+			}
 			nextPins[i] = nextPin
+			topicsReady[i] = true
 		}
 	} else {
 		// We just need to check there's no earlier sequences with the same unmasked context
 		unmaskedContexts := make([]driver.Value, len(msg.Header.Topics))
 		for i, topic := range msg.Header.Topics {
-			h := sha256.New()
+			h := fftypes.NewDefaultHash()
 			h.Write([]byte(topic))
 			unmaskedContexts[i] = fftypes.HashResult(h)
 		}
@@ -267,13 +433,42 @@ func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch,
 		if err != nil {
 			return err
 		}
+		earlierContexts := make(map[fftypes.Bytes32]bool)
+		for _, pin := range earlier {
+			earlierContexts[*pin.Hash] = true
+		}
+		for i, context := range unmaskedContexts {
+			topicsReady[i] = !earlierContexts[*context.(*fftypes.Bytes32)]
+		}
 		if len(earlier) > 0 {
 			l.Debugf("Message %s pinned at sequence %d blocked by earlier context %s at sequence %d", msg.Header.ID, pinnedSequence, earlier[0].Hash, earlier[0].Sequence)
-			return nil
+			blockedBy = earlier[0].Hash.String()
 		}
 	}
 
-	dispatched, err := ag.attemptMessageDispatch(ctx, msg)
+	// A multi-topic message can be ready on some topics, while blocked on others
+	if len(msg.Header.Topics) > 1 {
+		if err = ag.confirmTopics(ctx, msg, topicsReady); err != nil {
+			return err
+		}
+	}
+	if blockedBy != "" {
+		ag.trackPending(ag.blocked, pinnedSequence, blockedBy)
+		return nil
+	}
+
+	// Verify the on-chain signer of the batch is permitted to send this message
+	action, err := ag.checkPinPolicy(ctx, batch, msg)
+	if err != nil || action == pinPolicyActionQuarantine {
+		return err
+	}
+
+	var dispatched bool
+	if action == pinPolicyActionReject {
+		dispatched, err = true, ag.confirmMessage(ctx, msg, false, "signing key not permitted by pin policy")
+	} else {
+		dispatched, err = ag.attemptMessageDispatch(ctx, msg)
+	}
 	if err != nil || !dispatched {
 		return err
 	}
@@ -297,13 +492,54 @@ func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch,
 	return ag.database.SetPinDispatched(ctx, pinnedSequence)
 }
 
+// confirmTopics emits an event for each topic of a multi-topic message, the first time the message is found to be next
+// in the ordering context of that topic. Applications can then see the message is confirmed on that topic, even while
+// it remains pending on its other topics
+func (ag *aggregator) confirmTopics(ctx context.Context, msg *fftypes.Message, topicsReady []bool) error {
+	anyReady := false
+	for _, ready := range topicsReady {
+		anyReady = anyReady || ready
+	}
+	if !anyReady {
+		return nil
+	}
+
+	fb := database.EventQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("type", fftypes.EventTypeMessageTopicConfirmed),
+		fb.Eq("reference", msg.Header.ID),
+	)
+	existing, _, err := ag.database.GetEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+	confirmed := make(map[string]bool, len(existing))
+	for _, event := range existing {
+		confirmed[event.Topic] = true
+	}
+
+	for i, topic := range msg.Header.Topics {
+		if !topicsReady[i] || confirmed[topic] {
+			continue
+		}
+		event := fftypes.NewEvent(fftypes.EventTypeMessageTopicConfirmed, msg.Header.Namespace, msg.Header.ID)
+		event.Topic = topic
+		if err = ag.database.InsertEvent(ctx, event); err != nil {
+			return err
+		}
+		confirmed[topic] = true
+		log.L(ctx).Infof("Emitting %s for message %s:%s topic=%s", event.Type, msg.Header.Namespace, msg.Header.ID, topic)
+	}
+	return nil
+}
+
 func (ag *aggregator) checkMaskedContextReady(ctx context.Context, msg *fftypes.Message, topic string, pinnedSequence int64, pin *fftypes.Bytes32) (*fftypes.NextPin, error) {
 	l := log.L(ctx)
 
 	// For masked pins, we can only process if:
 	// - it is the next sequence on this context for one of the members of the group
 	// - there are no undispatched messages on this context earlier in the stream
-	h := sha256.New()
+	h := fftypes.NewDefaultHash()
 	h.Write([]byte(topic))
 	h.Write((*msg.Header.Group)[:])
 	contextUnmasked := fftypes.HashResult(h)
@@ -429,17 +665,41 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 
 	// We're going to dispatch it at this point, but we need to validate the data first
 	valid := true
-	eventType := fftypes.EventTypeMessageConfirmed
+	cause := fmt.Sprintf("aggregated from batch %s", msg.BatchID)
+	if msg.Header.Delegation != nil {
+		var pending bool
+		if valid, pending, err = ag.checkDelegation(ctx, msg); err != nil || pending {
+			// The message stays pending until the delegation is confirmed, which rewinds to it
+			return false, err
+		}
+		if !valid {
+			cause = "delegation not valid for author and signing key"
+		}
+	}
 	switch {
-	case msg.Header.Type == fftypes.MessageTypeDefinition:
+	case !valid:
+		// Rejected without processing the content of the message
+
+	case msg.Header.Type == fftypes.MessageTypeDefinition || msg.Header.Type == fftypes.MessageTypeGroupUpdate:
 		// We handle definition events in-line on the aggregator, as it would be confusing for apps to be
 		// dispatched subsequent events before we have processed the definition events they depend on.
+		// Group updates are definitions sent privately to the members of a group, and are handled the same way.
 		var action definitions.SystemBroadcastAction
 		action, err = ag.definitions.HandleSystemBroadcast(ctx, msg, data)
 		if action == definitions.ActionRetry || action == definitions.ActionWait {
 			return false, err
 		}
 		valid = action == definitions.ActionConfirm
+		if valid {
+			if err = ag.releaseIdentityQuarantines(ctx, msg, data); err != nil {
+				return false, err
+			}
+			if err = ag.releaseAwaitingDelegation(ctx, msg, data); err != nil {
+				return false, err
+			}
+		} else {
+			cause = "definition rejected"
+		}
 
 	case msg.Header.Type == fftypes.MessageTypeGroupInit:
 		// Already handled as part of resolving the context - do nothing.
@@ -449,19 +709,94 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 		if err != nil {
 			return false, err
 		}
+		if !valid {
+			cause = "data validation failed"
+		}
+	}
+
+	if err = ag.finalizeMessage(ctx, msg, valid, cause); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkDelegation verifies a message sent on behalf of its author is covered by a delegation from the author, to the org
+// that owns the signing key. Definitions cannot be sent under a delegation, as their handlers authorize the author.
+// A message sent under a delegation that has not been confirmed yet is pending, as the definition can arrive after it.
+func (ag *aggregator) checkDelegation(ctx context.Context, msg *fftypes.Message) (valid, pending bool, err error) {
+	if msg.Header.Type != fftypes.MessageTypeDefinition && msg.Header.Type != fftypes.MessageTypeGroupUpdate {
+		valid, pending, err = ag.identity.VerifyDelegatedIdentity(ctx, msg.Header.Namespace, &msg.Header.Identity, msg.Header.Delegation)
+		if err != nil {
+			return false, false, err
+		}
+	}
+	switch {
+	case pending:
+		log.L(ctx).Infof("Message %s from '%s' is pending confirmation of delegation %s", msg.Header.ID, msg.Header.Author, msg.Header.Delegation)
+	case !valid:
+		log.L(ctx).Warnf("Message %s from '%s' signed by '%s' is not covered by delegation %s", msg.Header.ID, msg.Header.Author, msg.Header.Key, msg.Header.Delegation)
+	}
+	return valid, pending, nil
+}
+
+// releaseAwaitingDelegation re-processes the messages left pending for a delegation, once the delegation is confirmed.
+// The earliest pin of their batches is parked and released, so the aggregator rewinds to it when the current group commits.
+func (ag *aggregator) releaseAwaitingDelegation(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) error {
+	if fftypes.SystemTag(msg.Header.Tag) != fftypes.SystemTagDefineDelegation || len(data) == 0 {
+		return nil
+	}
+	var delegation fftypes.Delegation
+	if err := json.Unmarshal(data[0].Value, &delegation); err != nil || delegation.ID == nil || delegation.Revoked != nil {
+		return nil
 	}
 
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	msgs, _, err := ag.database.GetMessages(ctx, fb.And(
+		fb.Eq("delegation", delegation.ID),
+		fb.Eq("state", fftypes.MessageStatePending),
+	))
+	if err != nil || len(msgs) == 0 {
+		return err
+	}
+	batchIDs := make([]driver.Value, 0, len(msgs))
+	for _, m := range msgs {
+		if m.BatchID != nil {
+			batchIDs = append(batchIDs, m.BatchID)
+		}
+	}
+
+	pfb := database.PinQueryFactory.NewFilter(ctx)
+	pins, _, err := ag.database.GetPins(ctx, pfb.And(
+		pfb.Eq("dispatched", false),
+		pfb.In("batch", batchIDs),
+	).Sort("sequence").Limit(1))
+	if err != nil || len(pins) == 0 {
+		return err
+	}
+	log.L(ctx).Infof("Delegation %s confirmed - rewinding to pin %d to process %d pending messages", delegation.ID, pins[0].Sequence, len(msgs))
+	// Parked directly, as the rewind must happen even when the limit on tracked pins has been reached
+	ag.parked[pins[0].Sequence] = pins[0].Batch.String()
+	ag.released = append(ag.released, pins[0].Batch)
+	return nil
+}
+
+// confirmMessage marks a message as confirmed or rejected, records the cause in its history, and emits the corresponding event
+func (ag *aggregator) confirmMessage(ctx context.Context, msg *fftypes.Message, valid bool, cause string) error {
 	// This message is now confirmed
-	state := fftypes.MessageStateConfirmed
+	eventType := fftypes.EventTypeMessageConfirmed
+	msg.State = fftypes.MessageStateConfirmed
 	if !valid {
-		state = fftypes.MessageStateRejected
+		msg.State = fftypes.MessageStateRejected
 	}
 	setConfirmed := database.MessageQueryFactory.NewUpdate(ctx).
 		Set("confirmed", fftypes.Now()). // the timestamp of the aggregator provides ordering
-		Set("state", state)              // mark if the message was confirmed or rejected
-	err = ag.database.UpdateMessage(ctx, msg.Header.ID, setConfirmed)
+		Set("state", msg.State)          // mark if the message was confirmed or rejected
+	err := ag.database.UpdateMessage(ctx, msg.Header.ID, setConfirmed)
+	if err == nil {
+		err = ag.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, cause))
+	}
 	if err != nil {
-		return false, err
+		return err
 	}
 	if !valid {
 		// An message with invalid (but complete) data is still considered dispatched.
@@ -472,11 +807,10 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 	// Generate the appropriate event
 	event := fftypes.NewEvent(eventType, msg.Header.Namespace, msg.Header.ID)
 	if err = ag.database.InsertEvent(ctx, event); err != nil {
-		return false, err
+		return err
 	}
 	log.L(ctx).Infof("Emitting %s for message %s:%s", eventType, msg.Header.Namespace, msg.Header.ID)
-
-	return true, nil
+	return nil
 }
 
 // resolveBlobs ensures that the blobs for all the attachments in the data array, have been received into the
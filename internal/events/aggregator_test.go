@@ -19,7 +19,10 @@ package events
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
@@ -27,6 +30,8 @@ import (
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -37,8 +42,12 @@ func newTestAggregator() (*aggregator, func()) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
 	msh := &definitionsmocks.DefinitionHandlers{}
+	mim := &identitymanagermocks.Manager{}
+	mpm := &privatemessagingmocks.Manager{}
 	ctx, cancel := context.WithCancel(context.Background())
-	ag := newAggregator(ctx, mdi, msh, mdm, newEventNotifier(ctx, "ut"))
+	ag := newAggregator(ctx, mdi, msh, mdm, mim, mpm, newEventNotifier(ctx, "ut"))
+	ag.releaseAwaitingIdentity = func(ctx context.Context, key string) ([]*fftypes.UUID, error) { return nil, nil }
+	ag.maxPending = 100
 	return ag, cancel
 }
 
@@ -154,6 +163,7 @@ func TestAggregationMaskedZeroNonceMatch(t *testing.T) {
 
 		return true
 	})).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	// Confirm the offset
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -249,6 +259,7 @@ func TestAggregationMaskedNextSequenceMatch(t *testing.T) {
 	mdi.On("SetPinDispatched", ag.ctx, int64(10001)).Return(nil)
 	// Update the message
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	// Confirm the offset
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -320,6 +331,7 @@ func TestAggregationBroadcast(t *testing.T) {
 	mdi.On("SetPinDispatched", ag.ctx, int64(10001)).Return(nil)
 	// Update the message
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	// Confirm the offset
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -341,6 +353,7 @@ func TestAggregationBroadcast(t *testing.T) {
 func TestShutdownOnCancel(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, aggregatorOffsetName).Return(nil, nil).Maybe()
 	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(&fftypes.Offset{
 		Type:    fftypes.OffsetTypeAggregator,
 		Name:    aggregatorOffsetName,
@@ -348,6 +361,7 @@ func TestShutdownOnCancel(t *testing.T) {
 		RowID:   333333,
 	}, nil)
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetBlockedPins", mock.Anything, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil).Maybe()
 	ag.start()
 	assert.Equal(t, int64(12345), ag.eventPoller.pollingOffset)
 	ag.eventPoller.eventNotifier.newEvents <- 12345
@@ -472,7 +486,8 @@ func TestProcessSkipDupMsg(t *testing.T) {
 		},
 	}, nil).Once()
 	mdi.On("GetPins", mock.Anything, mock.Anything).Return([]*fftypes.Pin{
-		{Sequence: 1111}, // blocks the context
+		{Sequence: 1111, Hash: unmaskedContext("topic1")}, // blocks the contexts
+		{Sequence: 1112, Hash: unmaskedContext("topic2")},
 	}, nil, nil)
 	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -485,6 +500,109 @@ func TestProcessSkipDupMsg(t *testing.T) {
 
 }
 
+func unmaskedContext(topic string) *fftypes.Bytes32 {
+	h := sha256.New()
+	h.Write([]byte(topic))
+	return fftypes.HashResult(h)
+}
+
+func TestProcessMsgConfirmedOnOneTopic(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Topics:    fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{
+		{Sequence: 1111, Hash: unmaskedContext("topic2")},
+	}, nil, nil)
+	mdi.On("GetEvents", ag.ctx, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageTopicConfirmed && *e.Reference == *msg.Header.ID && e.Topic == "topic1"
+	})).Return(nil)
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{ID: fftypes.NewUUID()}, false, 12345, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, unmaskedContext("topic2").String(), ag.blocked[12345])
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMsgTopicAlreadyConfirmed(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Topics:    fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{
+		{Sequence: 1111, Hash: unmaskedContext("topic2")},
+	}, nil, nil)
+	mdi.On("GetEvents", ag.ctx, mock.Anything).Return([]*fftypes.Event{
+		{Type: fftypes.EventTypeMessageTopicConfirmed, Reference: msg.Header.ID, Topic: "topic1"},
+	}, nil, nil)
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{ID: fftypes.NewUUID()}, false, 12345, msg)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMsgConfirmTopicsGetEventsFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetEvents", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{ID: fftypes.NewUUID()}, false, 12345, msg)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMsgConfirmTopicsInsertEventFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetEvents", ag.ctx, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{ID: fftypes.NewUUID()}, false, 12345, msg)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
 func TestProcessMsgFailGetPins(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -589,6 +707,7 @@ func TestProcessMsgFailPinUpdate(t *testing.T) {
 	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(false, nil)
 	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	mdi.On("UpdateNextPin", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{
@@ -856,6 +975,363 @@ func TestAttemptMessageDispatchFailValidateData(t *testing.T) {
 
 }
 
+func TestAttemptMessageDispatchDataInvalidTransitionFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(false, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateRejected && transition.Cause == "data validation failed"
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID()},
+		},
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchDelegated(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeBroadcast,
+			Namespace:  "ns1",
+			Identity:   fftypes.Identity{Author: "did:firefly:org/delegator", Key: "0x12345"},
+			Delegation: fftypes.NewUUID(),
+		},
+	}
+
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", &msg.Header.Identity, msg.Header.Delegation).Return(true, false, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateConfirmed
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, msg)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchDelegationInvalid(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeBroadcast,
+			Namespace:  "ns1",
+			Identity:   fftypes.Identity{Author: "did:firefly:org/delegator", Key: "0x12345"},
+			Delegation: fftypes.NewUUID(),
+		},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID()},
+		},
+	}
+
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", &msg.Header.Identity, msg.Header.Delegation).Return(false, false, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateRejected && transition.Cause == "delegation not valid for author and signing key"
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, msg)
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t) // the data is not validated
+}
+
+func TestAttemptMessageDispatchDelegationVerifyFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", mock.Anything, mock.Anything).Return(false, false, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeBroadcast,
+			Namespace:  "ns1",
+			Delegation: fftypes.NewUUID(),
+		},
+	})
+	assert.EqualError(t, err, "pop")
+
+	mim.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchDelegationPending(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", mock.Anything, mock.Anything).Return(false, true, nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeBroadcast,
+			Namespace:  "ns1",
+			Delegation: fftypes.NewUUID(),
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, dispatched)
+
+	mim.AssertExpectations(t)
+}
+
+func filterContains(s string) interface{} {
+	return mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return strings.Contains(fi.String(), s)
+	})
+}
+
+func TestProcessPinsDelegatedMessageWaitsForDelegation(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	ag.eventPoller.pollingOffset = 1000
+
+	delegation := &fftypes.Delegation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Delegator: "did:firefly:org/delegator",
+		Delegate:  "did:firefly:org/delegate",
+	}
+	delegationJSON, _ := json.Marshal(delegation)
+	msgBatchID := fftypes.NewUUID()
+	defBatchID := fftypes.NewUUID()
+	delegatedMsg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeBroadcast,
+			Namespace:  "ns1",
+			Topics:     []string{"topic1"},
+			Identity:   fftypes.Identity{Author: delegation.Delegator, Key: "0x23456"},
+			Delegation: delegation.ID,
+		},
+		BatchID: msgBatchID,
+	}
+	defMsg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.MessageTypeDefinition,
+			Namespace: "ns1",
+			Topics:    []string{"ff_definition"},
+			Tag:       string(fftypes.SystemTagDefineDelegation),
+			Identity:  fftypes.Identity{Author: delegation.Delegator, Key: "0x12345"},
+		},
+		BatchID: defBatchID,
+		Data:    fftypes.DataRefs{{ID: fftypes.NewUUID()}},
+	}
+	msgPin := &fftypes.Pin{Sequence: 10, Hash: fftypes.NewRandB32(), Batch: msgBatchID}
+	defPin := &fftypes.Pin{Sequence: 11, Hash: fftypes.NewRandB32(), Batch: defBatchID}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	rag := mdi.On("RunAsGroup", ag.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	mdi.On("GetBatchByID", ag.ctx, msgBatchID).Return(&fftypes.Batch{ID: msgBatchID, Payload: fftypes.BatchPayload{Messages: []*fftypes.Message{delegatedMsg}}}, nil)
+	mdi.On("GetBatchByID", ag.ctx, defBatchID).Return(&fftypes.Batch{ID: defBatchID, Payload: fftypes.BatchPayload{Messages: []*fftypes.Message{defMsg}}}, nil)
+	mdi.On("GetPins", ag.ctx, filterContains("batch")).Return([]*fftypes.Pin{msgPin}, nil, nil)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetMessages", ag.ctx, filterContains(delegation.ID.String())).Return([]*fftypes.Message{delegatedMsg}, nil, nil)
+	mdm.On("GetMessageData", ag.ctx, delegatedMsg, true).Return([]*fftypes.Data{}, true, nil)
+	mdm.On("GetMessageData", ag.ctx, defMsg, true).Return([]*fftypes.Data{{Value: fftypes.Byteable(delegationJSON)}}, true, nil)
+	msh.On("HandleSystemBroadcast", ag.ctx, defMsg, mock.Anything).Return(definitions.ActionConfirm, nil)
+	var confirmed []*fftypes.UUID
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Run(func(a mock.Arguments) {
+		confirmed = append(confirmed, a[1].(*fftypes.UUID))
+	}).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("SetPinDispatched", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// The delegation has not arrived when the message is first processed, so it stays pending
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", &delegatedMsg.Header.Identity, delegation.ID).Return(false, true, nil).Once()
+	repoll, err := ag.processPinsDBGroup([]fftypes.LocallySequenced{msgPin, defPin})
+	assert.NoError(t, err)
+	assert.True(t, repoll)
+	assert.Equal(t, int64(9), ag.eventPoller.getPollingOffset())
+	assert.Equal(t, []*fftypes.UUID{defMsg.Header.ID}, confirmed)
+	mdi.AssertCalled(t, "SetPinDispatched", ag.ctx, int64(11))
+	mdi.AssertNotCalled(t, "SetPinDispatched", ag.ctx, int64(10))
+
+	// Once the confirmation of the delegation rewinds to the message, it is confirmed
+	mim.On("VerifyDelegatedIdentity", ag.ctx, "ns1", &delegatedMsg.Header.Identity, delegation.ID).Return(true, false, nil).Once()
+	_, err = ag.processPinsDBGroup([]fftypes.LocallySequenced{msgPin})
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.UUID{defMsg.Header.ID, delegatedMsg.Header.ID}, confirmed)
+	mdi.AssertCalled(t, "SetPinDispatched", ag.ctx, int64(10))
+
+	mim.AssertExpectations(t)
+	msh.AssertExpectations(t)
+}
+
+func TestReleaseAwaitingDelegationIgnored(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDelegation)}}
+	assert.NoError(t, ag.releaseAwaitingDelegation(ag.ctx, &fftypes.Message{}, []*fftypes.Data{{}}))
+	assert.NoError(t, ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{}))
+	assert.NoError(t, ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}}))
+	revoked, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID(), Revoked: fftypes.Now()})
+	assert.NoError(t, ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(revoked)}}))
+	assert.Empty(t, ag.released)
+}
+
+func TestReleaseAwaitingDelegationNoMessages(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDelegation)}}
+	delegation, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID()})
+	err := ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(delegation)}})
+	assert.NoError(t, err)
+	assert.Empty(t, ag.released)
+}
+
+func TestReleaseAwaitingDelegationGetMessagesFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDelegation)}}
+	delegation, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID()})
+	err := ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(delegation)}})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReleaseAwaitingDelegationNoPins(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{{}, {BatchID: fftypes.NewUUID()}}, nil, nil)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDelegation)}}
+	delegation, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID()})
+	err := ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(delegation)}})
+	assert.NoError(t, err)
+	assert.Empty(t, ag.released)
+}
+
+func TestReleaseAwaitingDelegationGetPinsFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{{BatchID: fftypes.NewUUID()}}, nil, nil)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDelegation)}}
+	delegation, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID()})
+	err := ag.releaseAwaitingDelegation(ag.ctx, msg, []*fftypes.Data{{Value: fftypes.Byteable(delegation)}})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchReleaseAwaitingDelegationFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("HandleSystemBroadcast", mock.Anything, mock.Anything, mock.Anything).Return(definitions.ActionConfirm, nil)
+
+	delegation, _ := json.Marshal(&fftypes.Delegation{ID: fftypes.NewUUID()})
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{Value: fftypes.Byteable(delegation)},
+	}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Type:      fftypes.MessageTypeDefinition,
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Tag:       string(fftypes.SystemTagDefineDelegation),
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchDelegatedDefinition(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateRejected
+	})).Return(fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:         fftypes.NewUUID(),
+			Type:       fftypes.MessageTypeDefinition,
+			Namespace:  "ns1",
+			Delegation: fftypes.NewUUID(),
+		},
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
 func TestAttemptMessageDispatchMissingBlobs(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -992,6 +1468,7 @@ func TestAttemptMessageDispatchFailValidateBadSystem(t *testing.T) {
 
 		return true
 	})).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
@@ -1008,6 +1485,40 @@ func TestAttemptMessageDispatchFailValidateBadSystem(t *testing.T) {
 
 }
 
+func TestAttemptMessageDispatchGroupUpdate(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("HandleSystemBroadcast", mock.Anything, mock.Anything, mock.Anything).Return(definitions.ActionConfirm, nil)
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageConfirmed
+	})).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Type:      fftypes.MessageTypeGroupUpdate,
+			ID:        fftypes.NewUUID(),
+			Namespace: "any",
+			Tag:       string(fftypes.SystemTagDefineGroupKey),
+		},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID()},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	msh.AssertExpectations(t)
+}
+
 func TestAttemptMessageDispatchFailValidateSystemFail(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
@@ -1041,6 +1552,7 @@ func TestAttemptMessageDispatchEventFail(t *testing.T) {
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
 	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
@@ -1059,6 +1571,7 @@ func TestAttemptMessageDispatchGroupInit(t *testing.T) {
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
 	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
 	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
@@ -1282,3 +1795,145 @@ func TestResolveBlobsCopyOk(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, resolved)
 }
+
+func TestProcessMsgMaskedBlockedRecorded(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	pin := fftypes.NewRandB32()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetNextPins", ag.ctx, mock.Anything).Return([]*fftypes.NextPin{
+		{Hash: fftypes.NewRandB32()},
+	}, nil, nil)
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Topics: fftypes.FFNameArray{"topic1"},
+		},
+		Pins: fftypes.FFNameArray{pin.String()},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pin.String(), ag.blocked[12345])
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessPinsParkedRecordedAndCleared(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	batchID := fftypes.NewUUID()
+	ag.blocked[12345] = "earlier"
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", ag.ctx, mock.Anything).Return(nil, nil).Once()
+	mdi.On("GetBatchByID", ag.ctx, mock.Anything).Return(&fftypes.Batch{ID: batchID}, nil).Once()
+	mdi.On("UpdateOffset", ag.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := ag.processPins(ag.ctx, []*fftypes.Pin{
+		{Sequence: 12345, Batch: batchID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, batchID.String(), ag.parked[12345])
+	assert.Empty(t, ag.blocked)
+
+	err = ag.processPins(ag.ctx, []*fftypes.Pin{
+		{Sequence: 12345, Batch: batchID, Index: 1},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, ag.parked)
+	mdi.AssertExpectations(t)
+}
+
+func TestSnapshotStateRestore(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	batchID1 := fftypes.NewUUID()
+	batchID2 := fftypes.NewUUID()
+	ag.parked[200] = batchID1.String()
+	ag.parked[201] = batchID1.String()
+	ag.parked[202] = batchID2.String()
+	ag.blocked[100] = "context1"
+	ag.blocked[300] = "context2"
+	state := ag.snapshotState()
+
+	b, err := json.Marshal(state)
+	assert.NoError(t, err)
+	var restoredState fftypes.JSONObject
+	err = json.Unmarshal(b, &restoredState)
+	assert.NoError(t, err)
+
+	ag2, cancel2 := newTestAggregator()
+	defer cancel2()
+	mdi := ag2.database.(*databasemocks.Plugin)
+	mdi.On("GetBatches", ag2.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		f, _ := filter.Finalize()
+		return len(f.Values) == 2
+	})).Return(nil, nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetBatches", ag2.ctx, mock.Anything).Return([]*fftypes.Batch{{ID: batchID2}}, nil, nil)
+
+	// The offset is rewound to re-check the oldest blocked pin, and the batches that have arrived are rewound to
+	rewind, offset := ag2.restoreSnapshot(&fftypes.Snapshot{State: restoredState})
+	assert.True(t, rewind)
+	assert.Equal(t, int64(99), offset)
+	assert.Equal(t, ag.parked, ag2.parked)
+	assert.Equal(t, ag.blocked, ag2.blocked)
+	assert.Equal(t, batchID2, <-ag2.offchainBatches)
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreSnapshotMaxPending(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	ag.maxPending = 2
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBatches", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	rewind, _ := ag.restoreSnapshot(&fftypes.Snapshot{State: fftypes.JSONObject{
+		"parked": map[string]interface{}{
+			"100": "batch1",
+			"200": "batch1",
+			"300": "batch1",
+		},
+	}})
+	assert.False(t, rewind)
+	assert.Len(t, ag.parked, 2)
+
+	// An entry that is already tracked can be updated when the limit is reached
+	ag.trackPending(ag.parked, 12345, "batch2")
+	assert.Len(t, ag.parked, 2)
+	for sequence := range ag.parked {
+		ag.trackPending(ag.parked, sequence, "batch2")
+		assert.Equal(t, "batch2", ag.parked[sequence])
+	}
+
+	<-ag.ctx.Done()
+}
+
+func TestRecheckParkedBatchesContextClosed(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBatches", ag.ctx, mock.Anything).Return([]*fftypes.Batch{
+		{ID: fftypes.NewUUID()},
+		{ID: fftypes.NewUUID()},
+	}, nil, nil)
+
+	cancel()
+	ag.recheckParkedBatches([]driver.Value{"batch1"})
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreSnapshotEmptyOrBadKeys(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	rewind, _ := ag.restoreSnapshot(&fftypes.Snapshot{State: fftypes.JSONObject{
+		"parked": map[string]interface{}{
+			"not a number": "batch1",
+		},
+	}})
+	assert.False(t, rewind)
+	assert.Empty(t, ag.parked)
+}
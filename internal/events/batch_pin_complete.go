@@ -19,9 +19,13 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -34,16 +38,31 @@ import (
 // sequence, and also persist all the data.
 func (em *eventManager) BatchPinComplete(bi blockchain.Plugin, batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 
+	eventHash := eventHash("batchpin", protocolTxID, batchPin)
+	if em.dedup.isDuplicate(eventHash) {
+		log.L(em.ctx).Debugf("Skipping duplicate BatchPinComplete batch=%s txn=%s", batchPin.BatchID, protocolTxID)
+		return nil
+	}
+
 	log.L(em.ctx).Infof("-> BatchPinComplete batch=%s txn=%s signingIdentity=%s", batchPin.BatchID, protocolTxID, signingIdentity)
 	defer func() {
 		log.L(em.ctx).Infof("<- BatchPinComplete batch=%s txn=%s signingIdentity=%s", batchPin.BatchID, protocolTxID, signingIdentity)
 	}()
 	log.L(em.ctx).Tracef("BatchPinComplete batch=%s info: %+v", batchPin.BatchID, additionalInfo)
+	if batchPin.Timestamp != nil {
+		em.timestamps.recordBlockTime(batchPin.Timestamp)
+	}
 
+	var err error
 	if batchPin.BatchPaylodRef != "" {
-		return em.handleBroadcastPinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+		err = em.handleBroadcastPinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+	} else {
+		err = em.handlePrivatePinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+	}
+	if err == nil {
+		em.dedup.record(eventHash)
 	}
-	return em.handlePrivatePinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+	return err
 }
 
 func (em *eventManager) handlePrivatePinComplete(batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
@@ -93,28 +112,30 @@ func (em *eventManager) persistContexts(ctx context.Context, batchPin *blockchai
 }
 
 func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
-	var body io.ReadCloser
-	if err := em.retry.Do(em.ctx, "retrieve data", func(attempt int) (retry bool, err error) {
-		body, err = em.publicstorage.RetrieveData(em.ctx, batchPin.BatchPaylodRef)
-		return err != nil, err // retry indefinitely (until context closes)
-	}); err != nil {
-		return err
+	// If the payload cannot be retrieved, we park the pins and leave the aggregator to retry the retrieval,
+	// rather than holding up all subsequent events on the blockchain
+	body, err := em.publicstorage.RetrieveData(em.ctx, batchPin.BatchPaylodRef)
+	if err != nil {
+		return em.blockBroadcastPin(batchPin, signingIdentity, protocolTxID, additionalInfo, err)
 	}
-	defer body.Close()
 
-	var batch *fftypes.Batch
-	err := json.NewDecoder(body).Decode(&batch)
+	batch, size, err := em.parseBroadcastPayload(body)
 	if err != nil {
 		log.L(em.ctx).Errorf("Failed to parse payload referred in batch ID '%s' from transaction '%s'", batchPin.BatchID, protocolTxID)
 		return nil // log and swallow unprocessable data
 	}
-	body.Close()
 
 	// At this point the batch is parsed, so any errors in processing need to be considered as:
 	// 1) Retryable - any transient error returned by processBatch is retried indefinitely
 	// 2) Swallowable - the data is invalid, and we have to move onto subsequent messages
 	// 3) Server shutting down - the context is cancelled (handled by retry)
-	return em.retry.Do(em.ctx, "persist batch", func(attempt int) (bool, error) {
+	traced := batch
+	if traced == nil {
+		// The payload exceeded the receive limit, so only the details from the pin are available
+		traced = &fftypes.Batch{ID: batchPin.BatchID, Namespace: batchPin.Namespace}
+	}
+	span := em.traceBatchReceived(traced)
+	err = em.retry.Do(em.ctx, "persist batch", func(attempt int) (bool, error) {
 		// We process the batch into the DB as a single transaction (if transactions are supported), both for
 		// efficiency and to minimize the chance of duplicates (although at-least-once delivery is the core model)
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
@@ -122,7 +143,7 @@ func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin
 			// Note that in the case of a bad batch broadcast, we don't store the pin. Because we know we
 			// are never going to be able to process it (we retrieved it successfully, it's just invalid).
 			if valid && err == nil {
-				valid, err = em.persistBatchFromBroadcast(ctx, batch, batchPin.BatchHash, signingIdentity)
+				valid, err = em.persistRetrievedBatch(ctx, batchPin.BatchID, batch, size, batchPin.Namespace, signingIdentity, batchPin.BatchPaylodRef, batchPin.BatchHash, batchPin.Timestamp)
 				if valid && err == nil {
 					err = em.persistContexts(ctx, batchPin, false)
 				}
@@ -131,4 +152,66 @@ func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin
 		})
 		return err != nil, err // retry indefinitely (until context closes)
 	})
+	tracing.EndSpan(span, err)
+	return err
+}
+
+// parseBroadcastPayload reads a batch from shared storage, within the receive size limit. A payload that exceeds
+// the limit is not read in full, and is returned as a nil batch with a size over the limit.
+func (em *eventManager) parseBroadcastPayload(body io.ReadCloser) (batch *fftypes.Batch, size int64, err error) {
+	return readBatchPayload(em.ctx, body, em.receiveLimits.maxSize)
+}
+
+func readBatchPayload(ctx context.Context, body io.ReadCloser, maxSize int64) (batch *fftypes.Batch, size int64, err error) {
+	defer body.Close()
+	var r io.Reader = body
+	if maxSize > 0 {
+		r = io.LimitReader(body, maxSize+1)
+	}
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	size = int64(len(payload))
+	if maxSize > 0 && size > maxSize {
+		return nil, size, nil
+	}
+	if err = json.Unmarshal(payload, &batch); err != nil {
+		return nil, 0, err
+	}
+	if batch == nil {
+		return nil, 0, i18n.NewError(ctx, i18n.MsgJSONObjectParseFailed, "batch")
+	}
+	// The header of the batch tells us if the sender compressed the payload. The receive limit applies to the
+	// decompressed size, so a batch that decompresses beyond it is left compressed to be quarantined.
+	decompressed, err := batch.DecompressPayload(ctx, maxSize)
+	if decompressed > 0 {
+		size = decompressed
+	}
+	return batch, size, err
+}
+
+// persistRetrievedBatch persists a batch retrieved from shared storage. A batch over the receive limits is
+// quarantined, but is still valid so the pins are stored - parked until the batch is accepted.
+func (em *eventManager) persistRetrievedBatch(ctx context.Context /* db TX context*/, batchID *fftypes.UUID, batch *fftypes.Batch, size int64, ns, signingIdentity, payloadRef string, hash *fftypes.Bytes32, blockTime *fftypes.FFTime) (valid bool, err error) {
+	quarantine := &fftypes.BatchQuarantine{
+		Namespace:  ns,
+		Key:        signingIdentity,
+		PayloadRef: payloadRef,
+		Hash:       hash,
+		Size:       size,
+	}
+	if batch == nil {
+		// The payload was not read beyond the limit, so it is retrieved again if the batch is accepted
+		quarantine.ID = batchID
+		quarantine.Reason = fmt.Sprintf("batch payload exceeds the limit of %d bytes", em.receiveLimits.maxSize)
+		return true, em.insertQuarantine(ctx, quarantine)
+	}
+	if quarantine.Reason = em.receiveLimits.check(batch, quarantine.Size); quarantine.Reason != "" {
+		return true, em.quarantineBatch(ctx, batch, quarantine)
+	}
+	if valid, err = em.checkTimestamps(ctx, batch, blockTime); !valid || err != nil {
+		return valid, err
+	}
+	return em.persistBatchFromBroadcast(ctx, batch, hash, signingIdentity, quarantine)
 }
@@ -22,7 +22,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
@@ -98,6 +100,291 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestBatchPinCompleteOkBroadcastCompressed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPaylodRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+			Messages: []*fftypes.Message{},
+			Data:     []*fftypes.Data{},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	err := batchData.CompressPayload(context.Background(), fftypes.BatchCompressionGzip)
+	assert.NoError(t, err)
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(batchReadCloser, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetTransactionByID", mock.Anything, batch.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.MatchedBy(func(b *fftypes.Batch) bool {
+		return *b.Payload.TX.ID == *batch.TransactionID && b.Compression == "" && b.CompressedPayload == nil
+	}), false).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", mock.Anything, "0x12345").Return("author1", nil)
+
+	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteBroadcastQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits.maxMessages = 1
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPaylodRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+			Messages: []*fftypes.Message{{}, {}},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(batchReadCloser, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchData.Payload.TX.ID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBatchQuarantineByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", mock.Anything, mock.MatchedBy(func(bq *fftypes.BatchQuarantine) bool {
+		return *bq.ID == *batch.BatchID && bq.Key == "0x12345" && bq.PayloadRef == batch.BatchPaylodRef &&
+			*bq.Hash == *batch.BatchHash && bq.Messages == 2 && bq.Size == int64(len(batchDataBytes))
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBatchPinCompleteBroadcastCompressedQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits.maxSize = 1000
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPaylodRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+			Data: []*fftypes.Data{{Value: fftypes.Byteable(`"` + strings.Repeat("a", 10000) + `"`)}},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	err := batchData.CompressPayload(context.Background(), fftypes.BatchCompressionGzip)
+	assert.NoError(t, err)
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	assert.Less(t, len(batchDataBytes), 1000)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(batchReadCloser, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batch.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBatchQuarantineByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", mock.Anything, mock.MatchedBy(func(bq *fftypes.BatchQuarantine) bool {
+		return *bq.ID == *batch.BatchID && bq.Size == 1001 &&
+			bq.Batch.Compression == fftypes.BatchCompressionGzip && bq.Batch.CompressedPayload != nil
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBatchPinCompleteBroadcastOversizeQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits.maxSize = 10
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		BatchHash:      fftypes.NewRandB32(),
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader([]byte(`{"id":"` + batch.BatchID.String() + `"}`)))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(batchReadCloser, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batch.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBatchQuarantineByID", mock.Anything, batch.BatchID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", mock.Anything, mock.MatchedBy(func(bq *fftypes.BatchQuarantine) bool {
+		return *bq.ID == *batch.BatchID && bq.Key == "0x12345" && bq.PayloadRef == batch.BatchPaylodRef &&
+			*bq.Hash == *batch.BatchHash && bq.Size == 11 && bq.Batch == nil
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteBroadcastNullPayload(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+	}
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(ioutil.NopCloser(bytes.NewReader([]byte(`null`))), nil)
+
+	err := em.BatchPinComplete(&blockchainmocks.Plugin{}, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+}
+
+func TestBatchPinCompleteBroadcastTimestampRejected(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionReject
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+		Timestamp:      fftypes.Now(),
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		PayloadRef: batch.BatchPaylodRef,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   batch.TransactionID,
+			},
+		},
+		Created: fftypes.UnixTime(1620576488),
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).Return(batchReadCloser, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchData.Payload.TX.ID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTimestampSkew && e.Reference.Equals(batch.BatchID)
+	})).Return(nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, em.BlockchainClockSkew())
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything, mock.Anything)
+	mdi.AssertNotCalled(t, "UpsertPin", mock.Anything, mock.Anything)
+}
+
 func TestBatchPinCompleteOkPrivate(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -149,8 +436,9 @@ func TestBatchPinCompleteOkPrivate(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
-func TestSequencedBroadcastRetrieveIPFSFail(t *testing.T) {
+func TestBatchPinCompleteBadData(t *testing.T) {
 	em, cancel := newTestEventManager(t)
+	defer cancel()
 
 	batch := &blockchain.BatchPin{
 		TransactionID:  fftypes.NewUUID(),
@@ -158,18 +446,17 @@ func TestSequencedBroadcastRetrieveIPFSFail(t *testing.T) {
 		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
 		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
 	}
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader([]byte(`!json`)))
 
-	cancel() // to avoid retry
 	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
-	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(batchReadCloser, nil)
 	mbi := &blockchainmocks.Plugin{}
 
 	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
-	mpi.AssertExpectations(t)
-	assert.Regexp(t, "FF10158", err)
+	assert.NoError(t, err) // We do not return a blocking error in the case of bad data stored in IPFS
 }
 
-func TestBatchPinCompleteBadData(t *testing.T) {
+func TestBatchPinCompleteBadCompression(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
 
@@ -179,14 +466,34 @@ func TestBatchPinCompleteBadData(t *testing.T) {
 		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
 		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
 	}
-	batchReadCloser := ioutil.NopCloser(bytes.NewReader([]byte(`!json`)))
+	batchReadCloser := ioutil.NopCloser(bytes.NewReader([]byte(`{"compression":"lzw"}`)))
 
 	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
 	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(batchReadCloser, nil)
 	mbi := &blockchainmocks.Plugin{}
 
 	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
-	assert.NoError(t, err) // We do not return a blocking error in the case of bad data stored in IPFS
+	assert.NoError(t, err) // We do not return a blocking error for a batch we cannot decompress
+}
+
+func TestBatchPinCompleteReadFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+	batchReadCloser := ioutil.NopCloser(iotest.ErrReader(fmt.Errorf("pop")))
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(batchReadCloser, nil)
+	mbi := &blockchainmocks.Plugin{}
+
+	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err) // We do not return a blocking error in the case of unreadable data in IPFS
 }
 
 func TestPersistBatchMissingID(t *testing.T) {
@@ -218,7 +525,7 @@ func TestPersistBatchAuthorResolveFail(t *testing.T) {
 	mim := em.identity.(*identitymanagermocks.Manager)
 	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("", fmt.Errorf("pop"))
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
+	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345", &fftypes.BatchQuarantine{})
 	assert.NoError(t, err) // retryable
 	assert.False(t, valid)
 }
@@ -243,8 +550,9 @@ func TestPersistBatchBadAuthor(t *testing.T) {
 	}
 	mim := em.identity.(*identitymanagermocks.Manager)
 	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("author2", nil)
+	mim.On("VerifySigningKeyAuthor", mock.Anything, "0x12345", "author1").Return(false, nil)
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
+	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345", &fftypes.BatchQuarantine{})
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
@@ -269,7 +577,7 @@ func TestPersistBatchMismatchChainHash(t *testing.T) {
 	mim := em.identity.(*identitymanagermocks.Manager)
 	mim.On("ResolveSigningKeyIdentity", mock.Anything, mock.Anything).Return("author1", nil)
 	batch.Hash = batch.Payload.Hash()
-	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, fftypes.NewRandB32(), "0x12345")
+	valid, err := em.persistBatchFromBroadcast(context.Background(), batch, fftypes.NewRandB32(), "0x12345", &fftypes.BatchQuarantine{})
 	assert.NoError(t, err)
 	assert.False(t, valid)
 }
@@ -324,6 +632,26 @@ func TestPersistBatchBadHash(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPersistBatchUnknownHashAlgorithm(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := &fftypes.Batch{
+		ID:            fftypes.NewUUID(),
+		HashAlgorithm: "md5",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	valid, err := em.persistBatch(context.Background(), batch)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
 func TestPersistBatchUpsertBatchFail(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -477,7 +805,9 @@ func TestPersistBatchGoodDataMessageFail(t *testing.T) {
 	batch.Hash = batch.Payload.Hash()
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("UpsertBatch", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.MatchedBy(func(b *fftypes.Batch) bool {
+		return len(b.Manifest.Messages) == 1 && b.Manifest.Messages[0].Hash.Equals(batch.Payload.Messages[0].Hash)
+	}), false).Return(nil)
 	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationSkip).Return(fmt.Errorf("pop"))
 
 	valid, err := em.persistBatch(context.Background(), batch)
@@ -664,6 +994,31 @@ func TestPersistBatchMessageUpsertMessageFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestPersistBatchMessageTransitionFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	msg.Header.DataHash = msg.Data.Hash()
+	msg.Hash = msg.Header.Hash()
+	assert.NoError(t, msg.Verify(context.Background()))
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationSkip).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStatePending && transition.Cause == fmt.Sprintf("received in batch %s", batch.ID)
+	})).Return(fmt.Errorf("pop"))
+
+	err := em.persistBatchMessage(context.Background(), batch, 0, msg, database.UpsertOptimizationSkip)
+	assert.EqualError(t, err, "pop")
+}
+
 func TestPersistBatchMessageOK(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -681,6 +1036,7 @@ func TestPersistBatchMessageOK(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationSkip).Return(nil)
+	mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(nil)
 
 	err := em.persistBatchMessage(context.Background(), batch, 0, msg, database.UpsertOptimizationSkip)
 	assert.NoError(t, err)
@@ -702,3 +1058,30 @@ func TestPersistContextsFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 	mdi.AssertExpectations(t)
 }
+
+func TestBatchPinCompleteDuplicateSkipped(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:     "ns1",
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		Contexts:      []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batch.TransactionID).Return(nil, nil).Once()
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil).Once()
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil).Once()
+	mbi := &blockchainmocks.Plugin{}
+
+	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	// Redelivery of the same event is skipped
+	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
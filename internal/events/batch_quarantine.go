@@ -0,0 +1,230 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// receiveLimits are applied to every inbound batch, whether it arrives from data exchange or shared storage.
+// A zero limit is not enforced.
+type receiveLimits struct {
+	maxMessages int64
+	maxSize     int64
+	maxBlobSize int64
+}
+
+func newReceiveLimits() *receiveLimits {
+	return &receiveLimits{
+		maxMessages: config.GetInt64(config.EventReceiveMaxBatchMessages),
+		maxSize:     config.GetByteSize(config.EventReceiveMaxBatchSize),
+		maxBlobSize: config.GetByteSize(config.EventReceiveMaxBlobSize),
+	}
+}
+
+// check returns a non-empty reason if the batch must be quarantined
+func (rl *receiveLimits) check(batch *fftypes.Batch, size int64) string {
+	if messages := int64(len(batch.Payload.Messages)); rl.maxMessages > 0 && messages > rl.maxMessages {
+		return fmt.Sprintf("batch contains %d messages, exceeding the limit of %d", messages, rl.maxMessages)
+	}
+	if rl.maxSize > 0 && size > rl.maxSize {
+		return fmt.Sprintf("batch payload is %d bytes, exceeding the limit of %d", size, rl.maxSize)
+	}
+	if rl.maxBlobSize > 0 {
+		for _, d := range batch.Payload.Data {
+			if d.Blob != nil && d.Blob.Size > rl.maxBlobSize {
+				return fmt.Sprintf("blob '%s' is %d bytes, exceeding the limit of %d", d.Blob.Hash, d.Blob.Size, rl.maxBlobSize)
+			}
+		}
+	}
+	return ""
+}
+
+// quarantineBatch stores the batch for an operator to decide, instead of processing it. Any pins for the
+// batch are parked by the aggregator until it is accepted.
+func (em *eventManager) quarantineBatch(ctx context.Context /* db TX context*/, batch *fftypes.Batch, quarantine *fftypes.BatchQuarantine) error {
+	l := log.L(ctx)
+	if batch.ID == nil {
+		l.Errorf("Invalid batch. Missing ID")
+		return nil // This is not retryable. skip this batch
+	}
+
+	quarantine.ID = batch.ID
+	quarantine.Author = batch.Author
+	quarantine.Messages = int64(len(batch.Payload.Messages))
+	quarantine.Batch = batch
+	return em.insertQuarantine(ctx, quarantine)
+}
+
+// insertQuarantine records the quarantine, unless the batch is already quarantined. The batch is nil
+// if the payload could not be read within the receive limits.
+func (em *eventManager) insertQuarantine(ctx context.Context /* db TX context*/, quarantine *fftypes.BatchQuarantine) error {
+	l := log.L(ctx)
+	existing, err := em.database.GetBatchQuarantineByID(ctx, quarantine.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		l.Debugf("Batch '%s' is already quarantined (status=%s)", quarantine.ID, existing.Status)
+		return nil
+	}
+
+	quarantine.Status = fftypes.PolicyApprovalStatusPending
+	quarantine.Created = fftypes.Now()
+	if err = em.database.InsertBatchQuarantine(ctx, quarantine); err != nil {
+		return err
+	}
+	l.Warnf("Batch '%s' from '%s' quarantined: %s", quarantine.ID, quarantine.Author, quarantine.Reason)
+	event := fftypes.NewEvent(fftypes.EventTypeBatchQuarantined, quarantine.Namespace, quarantine.ID)
+	return em.database.InsertEvent(ctx, event)
+}
+
+func (em *eventManager) GetBatchQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.BatchQuarantine, *database.FilterResult, error) {
+	return em.database.GetBatchQuarantines(ctx, filter)
+}
+
+func (em *eventManager) GetBatchQuarantineByID(ctx context.Context, id string) (*fftypes.BatchQuarantine, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return em.database.GetBatchQuarantineByID(ctx, u)
+}
+
+// DecideBatchQuarantine accepts or discards a quarantined batch. An accepted batch is persisted exactly
+// as it would have been on receipt, and the aggregator is notified to process any parked pins.
+func (em *eventManager) DecideBatchQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.BatchQuarantine, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	status := decision.Status.Lower()
+	if status != fftypes.PolicyApprovalStatusApproved && status != fftypes.PolicyApprovalStatusRejected {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalBadDecision, fftypes.PolicyApprovalStatusApproved, fftypes.PolicyApprovalStatusRejected)
+	}
+	if decision.DecidedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "decidedBy")
+	}
+
+	var quarantine *fftypes.BatchQuarantine
+	valid := false
+	err = em.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		quarantine, err = em.database.GetBatchQuarantineByID(ctx, u)
+		if err != nil {
+			return err
+		}
+		if quarantine == nil {
+			return i18n.NewError(ctx, i18n.MsgBatchQuarantineNotFound, u)
+		}
+		if quarantine.Status != fftypes.PolicyApprovalStatusPending {
+			return i18n.NewError(ctx, i18n.MsgBatchQuarantineNotPending, u, quarantine.Status)
+		}
+
+		quarantine.Status = status
+		quarantine.Decided = fftypes.Now()
+		quarantine.DecidedBy = decision.DecidedBy
+		quarantine.Comment = decision.Comment
+		if err = em.updateQuarantineDecision(ctx, quarantine); err != nil {
+			return err
+		}
+		if quarantine.Status != fftypes.PolicyApprovalStatusApproved {
+			return nil
+		}
+		valid, err = em.persistQuarantinedBatch(ctx, quarantine)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if valid {
+		em.aggregator.offchainBatches <- quarantine.ID
+	}
+	log.L(ctx).Infof("Batch quarantine '%s' decided status=%s by '%s' (valid=%t)", u, quarantine.Status, quarantine.DecidedBy, valid)
+	return quarantine, nil
+}
+
+func (em *eventManager) updateQuarantineDecision(ctx context.Context /* db TX context*/, quarantine *fftypes.BatchQuarantine) error {
+	update := database.BatchQuarantineQueryFactory.NewUpdate(ctx).
+		Set("status", quarantine.Status).
+		Set("decided", quarantine.Decided).
+		Set("decidedby", quarantine.DecidedBy).
+		Set("comment", quarantine.Comment)
+	return em.database.UpdateBatchQuarantine(ctx, quarantine.ID, update)
+}
+
+// persistQuarantinedBatch persists an accepted batch exactly as it would have been on receipt
+func (em *eventManager) persistQuarantinedBatch(ctx context.Context /* db TX context*/, quarantine *fftypes.BatchQuarantine) (valid bool, err error) {
+	if quarantine.Batch == nil {
+		// The payload exceeded the receive limit before it was read, and accepting it lifts the limit
+		body, err := em.publicstorage.RetrieveData(ctx, quarantine.PayloadRef)
+		if err != nil {
+			return false, err
+		}
+		if quarantine.Batch, _, err = readBatchPayload(ctx, body, 0); err != nil {
+			log.L(ctx).Errorf("Failed to parse payload of quarantined batch '%s': %s", quarantine.ID, err)
+			return false, nil
+		}
+	}
+	// A batch quarantined for its decompressed size is stored compressed, and accepting it lifts the limit
+	if _, err := quarantine.Batch.DecompressPayload(ctx, 0); err != nil {
+		log.L(ctx).Errorf("Invalid quarantined batch '%s': %s", quarantine.ID, err)
+		return false, nil
+	}
+	if quarantine.PayloadRef != "" {
+		return em.persistBatchFromBroadcast(ctx, quarantine.Batch, quarantine.Hash, quarantine.Key, nil)
+	}
+	return em.persistBatch(ctx, quarantine.Batch)
+}
+
+// releaseAwaitingIdentity accepts the batches quarantined until an identity with the given signing key arrived
+// in the network map, now that its definition has been confirmed. The IDs of the batches that were persisted
+// are returned, so the aggregator can process the pins parked for them.
+func (em *eventManager) releaseAwaitingIdentity(ctx context.Context /* db TX context*/, key string) (released []*fftypes.UUID, err error) {
+	fb := database.BatchQuarantineQueryFactory.NewFilter(ctx)
+	quarantines, _, err := em.database.GetBatchQuarantines(ctx, fb.And(
+		fb.Eq("awaitingidentity", key),
+		fb.Eq("status", fftypes.PolicyApprovalStatusPending),
+	))
+	if err != nil {
+		return nil, err
+	}
+	for _, quarantine := range quarantines {
+		quarantine.Status = fftypes.PolicyApprovalStatusApproved
+		quarantine.Decided = fftypes.Now()
+		quarantine.DecidedBy = fftypes.SystemNamespace
+		quarantine.Comment = fmt.Sprintf("identity with key '%s' arrived", key)
+		if err = em.updateQuarantineDecision(ctx, quarantine); err != nil {
+			return nil, err
+		}
+		valid, err := em.persistQuarantinedBatch(ctx, quarantine)
+		if err != nil {
+			return nil, err
+		}
+		if valid {
+			released = append(released, quarantine.ID)
+		}
+		log.L(ctx).Infof("Batch quarantine '%s' released on arrival of identity '%s' (valid=%t)", quarantine.ID, key, valid)
+	}
+	return released, nil
+}
@@ -0,0 +1,568 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestQuarantinedBatch(payloadRef string) *fftypes.BatchQuarantine {
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "author1",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	return &fftypes.BatchQuarantine{
+		ID:         batch.ID,
+		Namespace:  "ns1",
+		Author:     "author1",
+		Key:        "0x12345",
+		PayloadRef: payloadRef,
+		Hash:       batch.Hash,
+		Reason:     "too big",
+		Batch:      batch,
+		Status:     fftypes.PolicyApprovalStatusPending,
+	}
+}
+
+func TestReceiveLimitsCheck(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventReceiveMaxBatchMessages, 2)
+	config.Set(config.EventReceiveMaxBatchSize, "1Kb")
+	config.Set(config.EventReceiveMaxBlobSize, "2Kb")
+	rl := newReceiveLimits()
+
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{}, {}},
+			Data: []*fftypes.Data{
+				{Value: fftypes.Byteable(`"inline"`)},
+				{Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32(), Size: 2048}},
+			},
+		},
+	}
+	assert.Empty(t, rl.check(batch, 1024))
+	assert.Regexp(t, "1025 bytes, exceeding the limit of 1024", rl.check(batch, 1025))
+
+	batch.Payload.Data[1].Blob.Size = 2049
+	assert.Regexp(t, "2049 bytes, exceeding the limit of 2048", rl.check(batch, 1024))
+
+	batch.Payload.Messages = append(batch.Payload.Messages, &fftypes.Message{})
+	assert.Regexp(t, "3 messages, exceeding the limit of 2", rl.check(batch, 1024))
+}
+
+func TestReceiveLimitsDisabledByDefault(t *testing.T) {
+	config.Reset()
+	rl := newReceiveLimits()
+
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Messages: make([]*fftypes.Message, 1000),
+			Data: []*fftypes.Data{
+				{Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32(), Size: 1024 * 1024 * 1024}},
+			},
+		},
+	}
+	assert.Empty(t, rl.check(batch, 1024*1024*1024))
+}
+
+func TestQuarantineBatchMissingID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	err := em.quarantineBatch(em.ctx, &fftypes.Batch{}, &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+}
+
+func TestQuarantineBatchGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	q := newTestQuarantinedBatch("")
+	err := em.quarantineBatch(em.ctx, q.Batch, &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestQuarantineBatchAlreadyQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+
+	err := em.quarantineBatch(em.ctx, q.Batch, &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestQuarantineBatchInsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.quarantineBatch(em.ctx, q.Batch, &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestQuarantineBatchOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", em.ctx, mock.MatchedBy(func(bq *fftypes.BatchQuarantine) bool {
+		return *bq.ID == *q.ID && bq.Author == "author1" && bq.Batch == q.Batch &&
+			bq.Status == fftypes.PolicyApprovalStatusPending && bq.Created != nil
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBatchQuarantined && e.Namespace == "ns1" && *e.Reference == *q.ID
+	})).Return(nil)
+
+	err := em.quarantineBatch(em.ctx, q.Batch, &fftypes.BatchQuarantine{Namespace: "ns1", Reason: "too big"})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetBatchQuarantines(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantines", em.ctx, mock.Anything).Return([]*fftypes.BatchQuarantine{}, nil, nil)
+
+	f := database.BatchQuarantineQueryFactory.NewFilter(em.ctx).And()
+	_, _, err := em.GetBatchQuarantines(em.ctx, f)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetBatchQuarantineByID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+
+	res, err := em.GetBatchQuarantineByID(em.ctx, q.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, q, res)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetBatchQuarantineByIDBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.GetBatchQuarantineByID(em.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideBatchQuarantineBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecideBatchQuarantine(em.ctx, "bad", &fftypes.PolicyApprovalDecision{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideBatchQuarantineBadStatus(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecideBatchQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusPending,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10348", err)
+}
+
+func TestDecideBatchQuarantineMissingDecidedBy(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecideBatchQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status: fftypes.PolicyApprovalStatusApproved,
+	})
+	assert.Regexp(t, "FF10140.*decidedBy", err)
+}
+
+func TestDecideBatchQuarantineGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(func(context.Context) error)(args[0].(context.Context))
+	}).Return(fmt.Errorf("pop"))
+	mdi.On("GetBatchQuarantineByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.DecideBatchQuarantine(context.Background(), fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10373", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineNotPending(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	q.Status = fftypes.PolicyApprovalStatusRejected
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10374", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineUpdateFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineDiscard(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	res, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    "Rejected",
+		DecidedBy: "admin1",
+		Comment:   "abusive peer",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusRejected, res.Status)
+	assert.Equal(t, "admin1", res.DecidedBy)
+	assert.Equal(t, "abusive peer", res.Comment)
+	assert.NotNil(t, res.Decided)
+	assert.Empty(t, em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptPrivate(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", em.ctx, q.Batch, false).Return(nil)
+
+	res, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, res.Status)
+	assert.Equal(t, *q.ID, *<-em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptBroadcast(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", em.ctx, q.Batch, false).Return(nil)
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("author1", nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, *q.ID, *<-em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	q.Batch.Hash = fftypes.NewRandB32()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	res, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, res.Status)
+	assert.Empty(t, em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptBadCompression(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	q.Batch.Compression = fftypes.BatchCompressionGzip
+	q.Batch.CompressedPayload = []byte("not gzip")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	res, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, res.Status)
+	assert.Empty(t, em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptOversize(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits.maxSize = 10
+
+	q := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	batch := q.Batch
+	q.Batch = nil
+	batchBytes, _ := json.Marshal(batch)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, q.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader(batchBytes)), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", em.ctx, mock.MatchedBy(func(b *fftypes.Batch) bool {
+		return *b.ID == *batch.ID
+	}), false).Return(nil)
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("author1", nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, *q.ID, *<-em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+	mpi.AssertExpectations(t)
+}
+
+func TestDecideBatchQuarantineAcceptOversizeRetrieveFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	q.Batch = nil
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, q.PayloadRef).Return(nil, fmt.Errorf("pop"))
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDecideBatchQuarantineAcceptOversizeBadPayload(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	q.Batch = nil
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, q.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader([]byte(`!json`))), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	_, err := em.DecideBatchQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, em.aggregator.offchainBatches)
+}
+
+func TestReleaseAwaitingIdentityOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q1 := newTestQuarantinedBatch("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	q2 := newTestQuarantinedBatch("")
+	q2.Batch.Hash = fftypes.NewRandB32() // invalid
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantines", em.ctx, mock.Anything).Return([]*fftypes.BatchQuarantine{q1, q2}, nil, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q1.ID, mock.Anything).Return(nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q2.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", em.ctx, q1.Batch, false).Return(nil)
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("author1", nil)
+
+	released, err := em.releaseAwaitingIdentity(em.ctx, "0x12345")
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.UUID{q1.ID}, released)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, q1.Status)
+	assert.Equal(t, fftypes.SystemNamespace, q1.DecidedBy)
+
+	filter, err := mdi.Calls[0].Arguments[1].(database.Filter).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( awaitingidentity == '0x12345' ) && ( status == 'pending' )", filter.String())
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestReleaseAwaitingIdentityGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantines", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.releaseAwaitingIdentity(em.ctx, "0x12345")
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReleaseAwaitingIdentityUpdateFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantines", em.ctx, mock.Anything).Return([]*fftypes.BatchQuarantine{q}, nil, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.releaseAwaitingIdentity(em.ctx, "0x12345")
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReleaseAwaitingIdentityPersistFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := newTestQuarantinedBatch("")
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantines", em.ctx, mock.Anything).Return([]*fftypes.BatchQuarantine{q}, nil, nil)
+	mdi.On("UpdateBatchQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", em.ctx, q.Batch, false).Return(fmt.Errorf("pop"))
+
+	_, err := em.releaseAwaitingIdentity(em.ctx, "0x12345")
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,71 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// BlockchainEvent records an event received from a blockchain plugin in the index of blockchain events.
+// Events delivered for a contract listener are recorded against the namespace of the listener, and emitted
+// to applications as a blockchain_event. Other events that are not associated with a namespace on-chain
+// are recorded against the system namespace.
+func (em *eventManager) BlockchainEvent(bi blockchain.Plugin, event *fftypes.BlockchainEvent) error {
+	event.ID = fftypes.NewUUID()
+	event.Source = bi.Name()
+	if event.Namespace == "" {
+		event.Namespace = fftypes.SystemNamespace
+	}
+	event.Created = fftypes.Now()
+	log.L(em.ctx).Debugf("Recording blockchain event '%s' from '%s' protocolId=%s", event.Name, event.Source, event.ProtocolID)
+
+	return em.retry.Do(em.ctx, "persist blockchain event", func(attempt int) (bool, error) {
+		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			return em.persistBlockchainEvent(ctx, event)
+		})
+		return err != nil, err // retry indefinitely (until context closes)
+	})
+}
+
+func (em *eventManager) persistBlockchainEvent(ctx context.Context, event *fftypes.BlockchainEvent) error {
+	var listener *fftypes.ContractListener
+	if event.Listener != "" {
+		var err error
+		if listener, err = em.database.GetContractListenerByProtocolID(ctx, event.Listener); err != nil {
+			return err
+		}
+		if listener != nil {
+			event.Namespace = listener.Namespace
+		}
+	}
+
+	if err := em.database.InsertBlockchainEvent(ctx, event); err != nil {
+		return err
+	}
+
+	// The sequence is only allocated if this is the first delivery of the event, so a redelivery
+	// does not emit the event to applications a second time
+	if listener != nil && event.Sequence > 0 {
+		log.L(ctx).Infof("Blockchain event '%s' received for listener '%s'", event.Name, listener.ID)
+		return em.database.InsertEvent(ctx, fftypes.NewEvent(fftypes.EventTypeBlockchainEvent, listener.Namespace, event.ID))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBlockchainEventSystemNamespaceWithRetry(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Name:       "Changed",
+		ProtocolID: "000000000001/000000/000000",
+	}
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Return(fmt.Errorf("pop")).Once()
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Return(nil).Once()
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.NoError(t, err)
+	assert.NotNil(t, event.ID)
+	assert.NotNil(t, event.Created)
+	assert.Equal(t, "ethereum", event.Source)
+	assert.Equal(t, fftypes.SystemNamespace, event.Namespace)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestBlockchainEventNamespaceExitOnCancel(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Namespace: "ns1",
+		Name:      "BatchPin",
+	}
+	mdi.On("InsertBlockchainEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.Regexp(t, "FF10158", err)
+	assert.Equal(t, "ns1", event.Namespace)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockchainEventForContractListener(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	listener := &fftypes.ContractListener{
+		ID:         fftypes.NewUUID(),
+		Namespace:  "ns1",
+		ProtocolID: "sb-12345",
+	}
+	event := &fftypes.BlockchainEvent{
+		Name:       "Changed",
+		ProtocolID: "000000000001/000000/000000",
+		Listener:   "sb-12345",
+	}
+	mdi.On("GetContractListenerByProtocolID", em.ctx, "sb-12345").Return(listener, nil)
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.BlockchainEvent).Sequence = 10
+	}).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainEvent && e.Namespace == "ns1" && e.Reference.Equals(event.ID)
+	})).Return(nil)
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", event.Namespace)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockchainEventForContractListenerRedelivery(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Name:       "Changed",
+		ProtocolID: "000000000001/000000/000000",
+		Listener:   "sb-12345",
+	}
+	mdi.On("GetContractListenerByProtocolID", em.ctx, "sb-12345").Return(&fftypes.ContractListener{Namespace: "ns1"}, nil)
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Return(nil)
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockchainEventUnknownListener(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Name:       "BatchPin",
+		Namespace:  "ns1",
+		ProtocolID: "000000000001/000000/000000",
+		Listener:   "sb-batchpin",
+	}
+	mdi.On("GetContractListenerByProtocolID", em.ctx, "sb-batchpin").Return(nil, nil)
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.BlockchainEvent).Sequence = 10
+	}).Return(nil)
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", event.Namespace)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockchainEventListenerLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Name:     "Changed",
+		Listener: "sb-12345",
+	}
+	mdi.On("GetContractListenerByProtocolID", em.ctx, "sb-12345").Return(nil, fmt.Errorf("pop"))
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockchainEventInsertEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("ethereum")
+
+	event := &fftypes.BlockchainEvent{
+		Name:     "Changed",
+		Listener: "sb-12345",
+	}
+	mdi.On("GetContractListenerByProtocolID", em.ctx, "sb-12345").Return(&fftypes.ContractListener{Namespace: "ns1"}, nil)
+	mdi.On("InsertBlockchainEvent", em.ctx, event).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.BlockchainEvent).Sequence = 10
+	}).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.BlockchainEvent(mbi, event)
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
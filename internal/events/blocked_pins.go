@@ -0,0 +1,196 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// blockBroadcastPin records a broadcast batch pin whose payload could not be retrieved from shared storage.
+// The pins are stored (so they are parked by the aggregator), and the retrieval is retried in the background
+func (em *eventManager) blockBroadcastPin(batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject, retrieveErr error) error {
+	log.L(em.ctx).Errorf("Failed to retrieve payload '%s' for batch '%s' from transaction '%s': %s", batchPin.BatchPaylodRef, batchPin.BatchID, protocolTxID, retrieveErr)
+	err := em.retry.Do(em.ctx, "persist blocked batch pin", func(attempt int) (bool, error) {
+		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			valid, err := em.persistBatchTransaction(ctx, batchPin, signingIdentity, protocolTxID, additionalInfo)
+			if !valid || err != nil {
+				return err
+			}
+			existing, err := em.database.GetBlockedPinByID(ctx, batchPin.BatchID)
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				now := fftypes.Now()
+				if err = em.database.InsertBlockedPin(ctx, &fftypes.BlockedPin{
+					ID:          batchPin.BatchID,
+					Namespace:   batchPin.Namespace,
+					PayloadRef:  batchPin.BatchPaylodRef,
+					Hash:        batchPin.BatchHash,
+					Key:         signingIdentity,
+					Timestamp:   batchPin.Timestamp,
+					Attempts:    1,
+					LastError:   retrieveErr.Error(),
+					NextAttempt: em.aggregator.nextPayloadRetry(1),
+					Created:     now,
+				}); err != nil {
+					return err
+				}
+			}
+			return em.persistContexts(ctx, batchPin, false)
+		})
+		return err != nil, err // retry indefinitely (until context closes)
+	})
+	if err == nil {
+		em.aggregator.tapBlockedPins()
+	}
+	return err
+}
+
+// retryBlockedPin is called by the aggregator to attempt to retrieve the payload of a blocked pin again.
+// An error means the payload is still unavailable, and the retrieval will be retried later.
+func (em *eventManager) retryBlockedPin(ctx context.Context, bp *fftypes.BlockedPin) error {
+	body, err := em.publicstorage.RetrieveData(ctx, bp.PayloadRef)
+	if err != nil {
+		return err
+	}
+	batch, size, err := em.parseBroadcastPayload(body)
+	return em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if err != nil {
+			log.L(ctx).Errorf("Failed to parse payload referred in blocked batch ID '%s': %s", bp.ID, err)
+		} else if _, err := em.persistRetrievedBatch(ctx, bp.ID, batch, size, bp.Namespace, bp.Key, bp.PayloadRef, bp.Hash, bp.Timestamp); err != nil {
+			return err
+		}
+		return em.database.DeleteBlockedPin(ctx, bp.ID)
+	})
+}
+
+func (em *eventManager) GetBlockedPins(ctx context.Context, filter database.AndFilter) ([]*fftypes.BlockedPin, *database.FilterResult, error) {
+	return em.database.GetBlockedPins(ctx, filter)
+}
+
+func (em *eventManager) GetBlockedPinByID(ctx context.Context, id string) (*fftypes.BlockedPin, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return em.database.GetBlockedPinByID(ctx, u)
+}
+
+// RetryBlockedPin brings the next attempt to retrieve the payload of a blocked pin forwards to now
+func (em *eventManager) RetryBlockedPin(ctx context.Context, id string) (*fftypes.BlockedPin, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := em.database.GetBlockedPinByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if bp == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgBlockedPinNotFound, u)
+	}
+	bp.NextAttempt = fftypes.Now()
+	update := database.BlockedPinQueryFactory.NewUpdate(ctx).Set("nextattempt", bp.NextAttempt)
+	if err = em.database.UpdateBlockedPin(ctx, bp.ID, update); err != nil {
+		return nil, err
+	}
+	em.aggregator.tapBlockedPins()
+	return bp, nil
+}
+
+// nextPayloadRetry is the time of the next retrieval after the given number of attempts, with exponential backoff
+func (ag *aggregator) nextPayloadRetry(attempts int64) *fftypes.FFTime {
+	delay := float64(ag.payloadRetry.InitialDelay) * math.Pow(ag.payloadRetry.Factor, float64(attempts-1))
+	if delay > float64(ag.payloadRetry.MaximumDelay) {
+		delay = float64(ag.payloadRetry.MaximumDelay)
+	}
+	next := fftypes.FFTime(time.Now().Add(time.Duration(delay)))
+	return &next
+}
+
+func (ag *aggregator) tapBlockedPins() {
+	select {
+	case ag.blockedPinsTap <- true:
+	default:
+	}
+}
+
+func (ag *aggregator) blockedPinsLoop() {
+	for {
+		timer := time.NewTimer(ag.retryBlockedPins())
+		select {
+		case <-timer.C:
+		case <-ag.blockedPinsTap:
+			timer.Stop()
+		case <-ag.ctx.Done():
+			timer.Stop()
+			log.L(ag.ctx).Debugf("Blocked pins loop exiting")
+			return
+		}
+	}
+}
+
+// retryBlockedPins attempts the retrieval of each blocked pin that is due, and returns how long to wait
+// before the next one is due. Pins that are successfully retrieved are rewound, as an off-chain batch arrival
+func (ag *aggregator) retryBlockedPins() time.Duration {
+	fb := database.BlockedPinQueryFactory.NewFilter(ag.ctx)
+	filter := fb.And().Sort("nextattempt").Limit(uint64(ag.eventPoller.conf.eventBatchSize))
+	blocked, _, err := ag.database.GetBlockedPins(ag.ctx, filter)
+	if err != nil {
+		log.L(ag.ctx).Errorf("Failed to query blocked pins: %s", err)
+		return ag.payloadRetry.InitialDelay
+	}
+	for _, bp := range blocked {
+		if wait := time.Until(time.Time(*bp.NextAttempt)); wait > 0 {
+			return wait
+		}
+		if err := ag.retryBlockedPin(ag.ctx, bp); err != nil {
+			bp.Attempts++
+			bp.LastError = err.Error()
+			bp.NextAttempt = ag.nextPayloadRetry(bp.Attempts)
+			log.L(ag.ctx).Warnf("Retrieval of blocked batch '%s' attempt %d failed (next attempt %s): %s", bp.ID, bp.Attempts, bp.NextAttempt, err)
+			update := database.BlockedPinQueryFactory.NewUpdate(ag.ctx).
+				Set("attempts", bp.Attempts).
+				Set("lasterror", bp.LastError).
+				Set("nextattempt", bp.NextAttempt)
+			if err = ag.database.UpdateBlockedPin(ag.ctx, bp.ID, update); err != nil {
+				log.L(ag.ctx).Errorf("Failed to update blocked pin '%s': %s", bp.ID, err)
+				return ag.payloadRetry.InitialDelay
+			}
+			continue
+		}
+		log.L(ag.ctx).Infof("Retrieved blocked batch '%s' after %d failed attempts", bp.ID, bp.Attempts)
+		select {
+		case ag.offchainBatches <- bp.ID:
+		case <-ag.ctx.Done():
+			return 0
+		}
+	}
+	if len(blocked) == ag.eventPoller.conf.eventBatchSize {
+		return 0 // there might be more due
+	}
+	return ag.payloadRetry.MaximumDelay
+}
@@ -0,0 +1,443 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestBlockedBatchPin() *blockchain.BatchPin {
+	return &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchHash:      fftypes.NewRandB32(),
+		BatchPaylodRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+	}
+}
+
+func newTestBlockedPin(nextAttempt time.Duration) *fftypes.BlockedPin {
+	next := fftypes.FFTime(time.Now().Add(nextAttempt))
+	return &fftypes.BlockedPin{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		PayloadRef:  "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Hash:        fftypes.NewRandB32(),
+		Key:         "0x12345",
+		Attempts:    1,
+		LastError:   "pop",
+		NextAttempt: &next,
+		Created:     fftypes.Now(),
+	}
+}
+
+func newTestBlockedPinPayload(t *testing.T) []byte {
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}},
+		},
+	}
+	b, err := json.Marshal(batch)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestBlockBroadcastPin(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchPin := newTestBlockedBatchPin()
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batchPin.BatchPaylodRef).Return(nil, fmt.Errorf("pop"))
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchPin.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBlockedPinByID", mock.Anything, batchPin.BatchID).Return(nil, nil)
+	mdi.On("InsertBlockedPin", mock.Anything, mock.MatchedBy(func(bp *fftypes.BlockedPin) bool {
+		return *bp.ID == *batchPin.BatchID && bp.Attempts == 1 && bp.LastError == "pop" &&
+			time.Time(*bp.NextAttempt).After(time.Time(*bp.Created))
+	})).Return(nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+
+	err := em.BatchPinComplete(&blockchainmocks.Plugin{}, batchPin, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+	assert.Len(t, em.aggregator.blockedPinsTap, 1)
+
+	mpi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockBroadcastPinAlreadyBlocked(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchPin := newTestBlockedBatchPin()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchPin.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBlockedPinByID", mock.Anything, batchPin.BatchID).Return(&fftypes.BlockedPin{ID: batchPin.BatchID}, nil)
+	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+
+	err := em.blockBroadcastPin(batchPin, "0x12345", "tx1", nil, fmt.Errorf("pop"))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBlockBroadcastPinInvalidTX(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchPin := newTestBlockedBatchPin()
+	batchPin.Namespace = ""
+
+	err := em.blockBroadcastPin(batchPin, "0x12345", "tx1", nil, fmt.Errorf("pop"))
+	assert.NoError(t, err)
+}
+
+func TestBlockBroadcastPinGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid retry
+
+	batchPin := newTestBlockedBatchPin()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchPin.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBlockedPinByID", mock.Anything, batchPin.BatchID).Return(nil, fmt.Errorf("pop"))
+
+	err := em.blockBroadcastPin(batchPin, "0x12345", "tx1", nil, fmt.Errorf("pop"))
+	assert.Regexp(t, "FF10158", err)
+	assert.Len(t, em.aggregator.blockedPinsTap, 0)
+}
+
+func TestBlockBroadcastPinInsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to avoid retry
+
+	batchPin := newTestBlockedBatchPin()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, batchPin.TransactionID).Return(nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetBlockedPinByID", mock.Anything, batchPin.BatchID).Return(nil, nil)
+	mdi.On("InsertBlockedPin", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.blockBroadcastPin(batchPin, "0x12345", "tx1", nil, fmt.Errorf("pop"))
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestRetryBlockedPinRetrieveFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	bp := newTestBlockedPin(0)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, bp.PayloadRef).Return(nil, fmt.Errorf("pop"))
+
+	err := em.retryBlockedPin(em.ctx, bp)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryBlockedPinUnparseable(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	bp := newTestBlockedPin(0)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, bp.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader([]byte("!json"))), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("DeleteBlockedPin", em.ctx, bp.ID).Return(nil)
+
+	err := em.retryBlockedPin(em.ctx, bp)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinPersisted(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits = &receiveLimits{maxMessages: 0, maxSize: 1}
+
+	bp := newTestBlockedPin(0)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, bp.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader(newTestBlockedPinPayload(t))), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, mock.Anything).Return(&fftypes.BatchQuarantine{}, nil)
+	mdi.On("DeleteBlockedPin", em.ctx, bp.ID).Return(nil)
+
+	err := em.retryBlockedPin(em.ctx, bp)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinPersistFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits = &receiveLimits{maxMessages: 0, maxSize: 1}
+
+	bp := newTestBlockedPin(0)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, bp.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader(newTestBlockedPinPayload(t))), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.retryBlockedPin(em.ctx, bp)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetBlockedPins(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", em.ctx, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil)
+
+	f := database.BlockedPinQueryFactory.NewFilter(em.ctx).And()
+	_, _, err := em.GetBlockedPins(em.ctx, f)
+	assert.NoError(t, err)
+}
+
+func TestGetBlockedPinByID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	bp := newTestBlockedPin(0)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPinByID", em.ctx, bp.ID).Return(bp, nil)
+
+	res, err := em.GetBlockedPinByID(em.ctx, bp.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, bp, res)
+}
+
+func TestGetBlockedPinByIDBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.GetBlockedPinByID(em.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRetryBlockedPin(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	bp := newTestBlockedPin(time.Hour)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPinByID", em.ctx, bp.ID).Return(bp, nil)
+	mdi.On("UpdateBlockedPin", em.ctx, bp.ID, mock.Anything).Return(nil)
+
+	res, err := em.RetryBlockedPin(em.ctx, bp.ID.String())
+	assert.NoError(t, err)
+	assert.False(t, time.Time(*res.NextAttempt).After(time.Now()))
+	assert.Len(t, em.aggregator.blockedPinsTap, 1)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.RetryBlockedPin(em.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRetryBlockedPinGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	u := fftypes.NewUUID()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPinByID", em.ctx, u).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.RetryBlockedPin(em.ctx, u.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryBlockedPinNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	u := fftypes.NewUUID()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPinByID", em.ctx, u).Return(nil, nil)
+
+	_, err := em.RetryBlockedPin(em.ctx, u.String())
+	assert.Regexp(t, "FF10438", err)
+}
+
+func TestRetryBlockedPinUpdateFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	bp := newTestBlockedPin(time.Hour)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPinByID", em.ctx, bp.ID).Return(bp, nil)
+	mdi.On("UpdateBlockedPin", em.ctx, bp.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.RetryBlockedPin(em.ctx, bp.ID.String())
+	assert.EqualError(t, err, "pop")
+	assert.Len(t, em.aggregator.blockedPinsTap, 0)
+}
+
+func TestNextPayloadRetryBackoff(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	ag.payloadRetry.InitialDelay = time.Second
+	ag.payloadRetry.MaximumDelay = time.Minute
+	ag.payloadRetry.Factor = 2
+
+	now := time.Now()
+	assert.WithinDuration(t, now.Add(time.Second), time.Time(*ag.nextPayloadRetry(1)), 500*time.Millisecond)
+	assert.WithinDuration(t, now.Add(4*time.Second), time.Time(*ag.nextPayloadRetry(3)), 500*time.Millisecond)
+	assert.WithinDuration(t, now.Add(time.Minute), time.Time(*ag.nextPayloadRetry(100)), 500*time.Millisecond)
+}
+
+func TestTapBlockedPinsNonBlocking(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.tapBlockedPins()
+	ag.tapBlockedPins()
+	assert.Len(t, ag.blockedPinsTap, 1)
+}
+
+func TestBlockedPinsLoop(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	ag.payloadRetry.InitialDelay = time.Millisecond
+	ag.payloadRetry.MaximumDelay = time.Hour
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil).Once() // woken by the tap
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()       // woken by the timer
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil).Once().Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	ag.tapBlockedPins()
+	ag.blockedPinsLoop()
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinsBackoff(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	due := newTestBlockedPin(-time.Second)
+	notDue := newTestBlockedPin(time.Hour)
+	ag.retryBlockedPin = func(ctx context.Context, bp *fftypes.BlockedPin) error {
+		assert.Equal(t, due, bp)
+		return fmt.Errorf("still missing")
+	}
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{due, notDue}, nil, nil)
+	mdi.On("UpdateBlockedPin", ag.ctx, due.ID, mock.Anything).Return(nil)
+
+	wait := ag.retryBlockedPins()
+	assert.Greater(t, int64(wait), int64(59*time.Minute))
+	assert.Equal(t, int64(2), due.Attempts)
+	assert.Equal(t, "still missing", due.LastError)
+	assert.True(t, time.Time(*due.NextAttempt).After(time.Now()))
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinsUpdateFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.retryBlockedPin = func(ctx context.Context, bp *fftypes.BlockedPin) error {
+		return fmt.Errorf("still missing")
+	}
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{newTestBlockedPin(0)}, nil, nil)
+	mdi.On("UpdateBlockedPin", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	wait := ag.retryBlockedPins()
+	assert.Equal(t, ag.payloadRetry.InitialDelay, wait)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinsRewind(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	ag.eventPoller.conf.eventBatchSize = 1
+
+	bp := newTestBlockedPin(0)
+	ag.retryBlockedPin = func(ctx context.Context, bp *fftypes.BlockedPin) error { return nil }
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{bp}, nil, nil)
+
+	wait := ag.retryBlockedPins()
+	assert.Equal(t, time.Duration(0), wait)
+	assert.Equal(t, bp.ID, <-ag.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinsRewindCancelled(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	cancel()
+
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.retryBlockedPin = func(ctx context.Context, bp *fftypes.BlockedPin) error { return nil }
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{newTestBlockedPin(0), newTestBlockedPin(0)}, nil, nil)
+
+	wait := ag.retryBlockedPins()
+	assert.Equal(t, time.Duration(0), wait)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRetryBlockedPinsNoneDue(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetBlockedPins", ag.ctx, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil)
+
+	wait := ag.retryBlockedPins()
+	assert.Equal(t, ag.payloadRetry.MaximumDelay, wait)
+
+	mdi.AssertExpectations(t)
+}
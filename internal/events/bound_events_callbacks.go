@@ -17,6 +17,9 @@
 package events
 
 import (
+	"context"
+	"io"
+
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -30,6 +33,10 @@ func (bc *boundCallbacks) RegisterConnection(connID string, matcher events.Subsc
 	return bc.sm.registerConnection(bc.ei, connID, matcher)
 }
 
+func (bc *boundCallbacks) RegisterSubscriptionConnection(connID, namespace string, id *fftypes.UUID, identity string, lastSequence *int64) error {
+	return bc.sm.registerSubscriptionConnection(bc.ei, connID, namespace, id, identity, lastSequence)
+}
+
 func (bc *boundCallbacks) EphemeralSubscription(connID, namespace string, filter *fftypes.SubscriptionFilter, options *fftypes.SubscriptionOptions) error {
 	return bc.sm.ephemeralSubscription(bc.ei, connID, namespace, filter, options)
 }
@@ -41,3 +48,7 @@ func (bc *boundCallbacks) DeliveryResponse(connID string, inflight *fftypes.Even
 func (bc *boundCallbacks) ConnnectionClosed(connID string) {
 	bc.sm.connnectionClosed(bc.ei, connID)
 }
+
+func (bc *boundCallbacks) DownloadBLOB(ctx context.Context, ns string, dataID *fftypes.UUID) (io.ReadCloser, error) {
+	return bc.sm.data.DownloadBLOB(ctx, ns, dataID.String())
+}
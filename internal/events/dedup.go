@@ -0,0 +1,217 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const eventDedupSnapshotName = "blockchain_events"
+
+// eventDedup remembers a hash of the content of each blockchain event processed within a time window,
+// so that events redelivered by a connector (for example after a reconnect) can be skipped without
+// repeating the processing. The recent hashes are persisted as a snapshot, so they survive a restart.
+//
+// This is an optimization only - processing of each event must still be idempotent, as hashes expire,
+// and those recorded since the last persist are lost on a crash.
+type eventDedup struct {
+	ctx             context.Context
+	database        database.Plugin
+	window          time.Duration
+	maxEntries      int
+	persistInterval time.Duration
+	mux             sync.Mutex
+	recent          map[string]time.Time
+	order           []string
+	dirty           bool
+	lastPersist     time.Time
+}
+
+func newEventDedup(ctx context.Context, di database.Plugin) *eventDedup {
+	return &eventDedup{
+		ctx:             ctx,
+		database:        di,
+		window:          config.GetDuration(config.EventDedupWindow),
+		maxEntries:      config.GetInt(config.EventDedupMaxEntries),
+		persistInterval: config.GetDuration(config.EventDedupPersistInterval),
+		recent:          make(map[string]time.Time),
+		lastPersist:     time.Now(),
+	}
+}
+
+// eventHash calculates a hash over the identifying content of a blockchain event
+func eventHash(kind, protocolTxID string, payload interface{}) string {
+	b, _ := json.Marshal(payload)
+	hash := sha256.New()
+	hash.Write([]byte(kind))
+	hash.Write([]byte{0})
+	hash.Write([]byte(protocolTxID))
+	hash.Write([]byte{0})
+	hash.Write(b)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (ed *eventDedup) enabled() bool {
+	return ed.window > 0 && ed.maxEntries > 0
+}
+
+// prune removes entries that have expired, or that exceed the maximum size - must be called with the lock held
+func (ed *eventDedup) prune(now time.Time) {
+	drop := 0
+	for drop < len(ed.order) {
+		hash := ed.order[drop]
+		if now.Sub(ed.recent[hash]) < ed.window && len(ed.order)-drop <= ed.maxEntries {
+			break
+		}
+		delete(ed.recent, hash)
+		drop++
+	}
+	if drop > 0 {
+		ed.order = ed.order[drop:]
+		ed.dirty = true
+	}
+}
+
+func (ed *eventDedup) isDuplicate(hash string) bool {
+	if !ed.enabled() {
+		return false
+	}
+	ed.mux.Lock()
+	defer ed.mux.Unlock()
+	ed.prune(time.Now())
+	_, seen := ed.recent[hash]
+	return seen
+}
+
+// record should be called only once an event has been successfully processed
+func (ed *eventDedup) record(hash string) {
+	if !ed.enabled() {
+		return
+	}
+	ed.mux.Lock()
+	now := time.Now()
+	if _, seen := ed.recent[hash]; !seen {
+		ed.recent[hash] = now
+		ed.order = append(ed.order, hash)
+		ed.dirty = true
+	}
+	ed.prune(now)
+	var snapshot *fftypes.Snapshot
+	if ed.dirty && now.Sub(ed.lastPersist) >= ed.persistInterval {
+		snapshot = ed.snapshot()
+		ed.dirty = false
+		ed.lastPersist = now
+	}
+	ed.mux.Unlock()
+
+	if snapshot != nil {
+		if err := ed.database.UpsertSnapshot(ed.ctx, snapshot); err != nil {
+			log.L(ed.ctx).Warnf("Failed to persist recent event hashes: %s", err)
+			ed.mux.Lock()
+			ed.dirty = true
+			ed.mux.Unlock()
+		}
+	}
+}
+
+// clear forgets every recent hash, and persists the empty set immediately, so that all events redelivered
+// by a replay of the blockchain are processed again
+func (ed *eventDedup) clear(ctx context.Context) error {
+	if !ed.enabled() {
+		return nil
+	}
+	ed.mux.Lock()
+	ed.recent = make(map[string]time.Time)
+	ed.order = nil
+	snapshot := ed.snapshot()
+	ed.dirty = false
+	ed.lastPersist = time.Now()
+	ed.mux.Unlock()
+
+	if err := ed.database.UpsertSnapshot(ctx, snapshot); err != nil {
+		ed.mux.Lock()
+		ed.dirty = true
+		ed.mux.Unlock()
+		return err
+	}
+	return nil
+}
+
+// snapshot builds the persisted state - must be called with the lock held
+func (ed *eventDedup) snapshot() *fftypes.Snapshot {
+	hashes := fftypes.JSONObject{}
+	for hash, seen := range ed.recent {
+		hashes[hash] = seen.UTC().Format(time.RFC3339Nano)
+	}
+	return &fftypes.Snapshot{
+		ID:      fftypes.NewUUID(),
+		Type:    fftypes.SnapshotTypeEventDedup,
+		Name:    eventDedupSnapshotName,
+		State:   fftypes.JSONObject{"hashes": hashes},
+		Created: fftypes.Now(),
+	}
+}
+
+// restore loads the recent hashes persisted before a restart. Failure is not fatal, as the
+// processing of each event is idempotent - we just might process a duplicate.
+func (ed *eventDedup) restore() {
+	if !ed.enabled() {
+		return
+	}
+	snapshot, err := ed.database.GetSnapshot(ed.ctx, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName)
+	if err != nil {
+		log.L(ed.ctx).Warnf("Failed to restore recent event hashes: %s", err)
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+
+	ed.mux.Lock()
+	defer ed.mux.Unlock()
+	hashes := snapshot.State.GetObject("hashes")
+	for hash := range hashes {
+		seen, err := time.Parse(time.RFC3339Nano, hashes.GetString(hash))
+		if err != nil {
+			continue
+		}
+		if _, exists := ed.recent[hash]; !exists {
+			ed.recent[hash] = seen
+			ed.order = append(ed.order, hash)
+		}
+	}
+	sort.SliceStable(ed.order, func(i, j int) bool {
+		return ed.recent[ed.order[i]].Before(ed.recent[ed.order[j]])
+	})
+	ed.prune(time.Now())
+	log.L(ed.ctx).Infof("Restored %d recent event hashes", len(ed.order))
+}
+
+func (em *eventManager) ResetEventDedup(ctx context.Context) error {
+	return em.dedup.clear(ctx)
+}
@@ -0,0 +1,188 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestEventDedup() (*eventDedup, *databasemocks.Plugin) {
+	config.Reset()
+	mdi := &databasemocks.Plugin{}
+	return newEventDedup(context.Background(), mdi), mdi
+}
+
+func TestEventHash(t *testing.T) {
+	h1 := eventHash("transfer", "tx1", fftypes.JSONObject{"id": "1"})
+	assert.Len(t, h1, 64)
+	assert.Equal(t, h1, eventHash("transfer", "tx1", fftypes.JSONObject{"id": "1"}))
+	assert.NotEqual(t, h1, eventHash("transfer", "tx2", fftypes.JSONObject{"id": "1"}))
+	assert.NotEqual(t, h1, eventHash("batchpin", "tx1", fftypes.JSONObject{"id": "1"}))
+	assert.NotEqual(t, h1, eventHash("transfer", "tx1", fftypes.JSONObject{"id": "2"}))
+}
+
+func TestEventDedupRecordAndExpire(t *testing.T) {
+	ed, _ := newTestEventDedup()
+
+	assert.False(t, ed.isDuplicate("h1"))
+	ed.record("h1")
+	ed.record("h1")
+	assert.True(t, ed.isDuplicate("h1"))
+	assert.Len(t, ed.order, 1)
+
+	ed.recent["h1"] = time.Now().Add(-2 * time.Hour)
+	assert.False(t, ed.isDuplicate("h1"))
+	assert.Empty(t, ed.order)
+	assert.Empty(t, ed.recent)
+}
+
+func TestEventDedupMaxEntries(t *testing.T) {
+	ed, _ := newTestEventDedup()
+	ed.maxEntries = 2
+
+	ed.record("h1")
+	ed.record("h2")
+	ed.record("h3")
+	assert.False(t, ed.isDuplicate("h1"))
+	assert.True(t, ed.isDuplicate("h2"))
+	assert.True(t, ed.isDuplicate("h3"))
+}
+
+func TestEventDedupDisabled(t *testing.T) {
+	ed, _ := newTestEventDedup()
+	ed.window = 0
+
+	ed.restore()
+	ed.record("h1")
+	assert.False(t, ed.isDuplicate("h1"))
+}
+
+func TestEventDedupPersist(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+	ed.persistInterval = 0
+
+	mdi.On("UpsertSnapshot", mock.Anything, mock.MatchedBy(func(snapshot *fftypes.Snapshot) bool {
+		return snapshot.Type == fftypes.SnapshotTypeEventDedup &&
+			snapshot.Name == eventDedupSnapshotName &&
+			snapshot.State.GetObject("hashes").GetString("h1") != ""
+	})).Return(nil)
+
+	ed.record("h1")
+	assert.False(t, ed.dirty)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupPersistFail(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+	ed.persistInterval = 0
+
+	mdi.On("UpsertSnapshot", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	ed.record("h1")
+	assert.True(t, ed.dirty)
+	assert.True(t, ed.isDuplicate("h1"))
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupRestore(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+
+	now := time.Now()
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName).Return(&fftypes.Snapshot{
+		State: fftypes.JSONObject{
+			"hashes": fftypes.JSONObject{
+				"h1":      now.Add(-1 * time.Minute).Format(time.RFC3339Nano),
+				"h2":      now.Add(-2 * time.Minute).Format(time.RFC3339Nano),
+				"expired": now.Add(-2 * time.Hour).Format(time.RFC3339Nano),
+				"bad":     "not a time",
+			},
+		},
+	}, nil)
+
+	ed.restore()
+	assert.Equal(t, []string{"h2", "h1"}, ed.order)
+	assert.True(t, ed.isDuplicate("h1"))
+	assert.True(t, ed.isDuplicate("h2"))
+	assert.False(t, ed.isDuplicate("expired"))
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupRestoreNotFound(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName).Return(nil, nil)
+
+	ed.restore()
+	assert.Empty(t, ed.order)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupRestoreFail(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName).Return(nil, fmt.Errorf("pop"))
+
+	ed.restore()
+	assert.Empty(t, ed.order)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupClear(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+	ed.record("h1")
+
+	mdi.On("UpsertSnapshot", mock.Anything, mock.MatchedBy(func(snapshot *fftypes.Snapshot) bool {
+		return len(snapshot.State.GetObject("hashes")) == 0
+	})).Return(nil)
+	em := &eventManager{dedup: ed}
+	err := em.ResetEventDedup(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ed.isDuplicate("h1"))
+	assert.False(t, ed.dirty)
+	mdi.AssertExpectations(t)
+}
+
+func TestEventDedupClearFail(t *testing.T) {
+	ed, mdi := newTestEventDedup()
+	ed.record("h1")
+
+	mdi.On("UpsertSnapshot", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := ed.clear(context.Background())
+	assert.EqualError(t, err, "pop")
+	assert.True(t, ed.dirty)
+}
+
+func TestEventDedupClearDisabled(t *testing.T) {
+	ed, _ := newTestEventDedup()
+	ed.window = 0
+	err := ed.clear(context.Background())
+	assert.NoError(t, err)
+}
@@ -19,9 +19,11 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -47,7 +49,7 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 			l.Errorf("Invalid transmission: nil batch")
 			return nil
 		}
-		return em.pinedBatchReceived(peerID, wrapper.Batch)
+		return em.pinedBatchReceived(peerID, wrapper.Batch, int64(len(data)))
 	case fftypes.TransportPayloadTypeMessage:
 		if wrapper.Message == nil {
 			l.Errorf("Invalid transmission: nil message")
@@ -58,6 +60,12 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 			return nil
 		}
 		return em.unpinnedMessageReceived(peerID, wrapper.Message, wrapper.Group, wrapper.Data)
+	case fftypes.TransportPayloadTypeReceipt:
+		if wrapper.Receipt == nil || wrapper.Receipt.ID == nil || wrapper.Receipt.Message == nil {
+			l.Errorf("Invalid transmission: invalid receipt")
+			return nil
+		}
+		return em.receiptReceived(peerID, wrapper.Receipt)
 	default:
 		l.Errorf("Invalid transmission: unknonwn type '%s'", wrapper.Type)
 		return nil
@@ -116,25 +124,93 @@ func (em *eventManager) checkReceivedIdentity(ctx context.Context, peerID, autho
 	return node, nil
 }
 
-func (em *eventManager) pinedBatchReceived(peerID string, batch *fftypes.Batch) error {
+func (em *eventManager) receiptReceived(peerID string, receipt *fftypes.MessageReceipt) error {
 
 	// Retry for persistence errors (not validation errors)
-	return em.retry.Do(em.ctx, "private batch received", func(attempt int) (bool, error) {
+	return em.retry.Do(em.ctx, "receipt received", func(attempt int) (bool, error) {
 		return true, em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			l := log.L(ctx)
 
-			node, err := em.checkReceivedIdentity(ctx, peerID, batch.Author, batch.Key)
+			node, err := em.checkReceivedIdentity(ctx, peerID, receipt.Author, receipt.Key)
 			if err != nil {
 				return err
 			}
 			if node == nil {
-				l.Errorf("Batch received from invalid author '%s' for peer ID '%s'", batch.Author, peerID)
+				l.Errorf("Receipt received from invalid author '%s' for peer ID '%s'", receipt.Author, peerID)
+				return nil
+			}
+
+			if err = em.database.UpsertReceipt(ctx, receipt); err != nil {
+				return err
+			}
+
+			// If the message has not been aggregated yet, the receipt will be counted when it is
+			msg, err := em.database.GetMessageByID(ctx, receipt.Message)
+			if err != nil {
+				return err
+			}
+			if msg == nil || msg.Header.Namespace != receipt.Namespace || msg.State != fftypes.MessageStateAwaitingQuorum {
+				l.Debugf("Receipt %s from '%s' recorded for message %s", receipt.ID, receipt.Author, receipt.Message)
 				return nil
 			}
+			return em.aggregator.checkQuorum(ctx, msg)
+		})
+	})
+}
+
+func (em *eventManager) pinedBatchReceived(peerID string, batch *fftypes.Batch, size int64) error {
 
-			valid, err := em.persistBatch(ctx, batch)
+	// Retry for persistence errors (not validation errors)
+	span := em.traceBatchReceived(batch)
+	err := em.retry.Do(em.ctx, "private batch received", func(attempt int) (bool, error) {
+		return true, em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			l := log.L(ctx)
+
+			node, err := em.checkReceivedIdentity(ctx, peerID, batch.Author, batch.Key)
 			if err != nil {
-				l.Errorf("Batch received from %s/%s invalid: %s", node.Owner, node.Name, err)
+				return err
+			}
+			if node == nil {
+				action, err := em.unknownAuthorAction(ctx, batch)
+				if err != nil {
+					return err
+				}
+				switch action {
+				case unknownAuthorActionAccept:
+					l.Warnf("Accepting batch from unknown author '%s' with key '%s' for peer ID '%s'", batch.Author, batch.Key, peerID)
+				case unknownAuthorActionQuarantine:
+					return em.quarantineBatch(ctx, batch, &fftypes.BatchQuarantine{
+						Namespace:        batch.Namespace,
+						Key:              batch.Key,
+						Peer:             peerID,
+						Reason:           fmt.Sprintf("author '%s' with key '%s' is not in the network map", batch.Author, batch.Key),
+						AwaitingIdentity: batch.Key,
+						Size:             size,
+					})
+				default:
+					l.Errorf("Batch received from invalid author '%s' for peer ID '%s'", batch.Author, peerID)
+					return nil
+				}
+			}
+
+			if reason := em.receiveLimits.check(batch, size); reason != "" {
+				return em.quarantineBatch(ctx, batch, &fftypes.BatchQuarantine{
+					Namespace: batch.Namespace,
+					Key:       batch.Key,
+					Peer:      peerID,
+					Reason:    reason,
+					Size:      size,
+				})
+			}
+
+			valid, err := em.checkTimestamps(ctx, batch, nil)
+			if err != nil || !valid {
+				return err
+			}
+
+			valid, err = em.persistBatch(ctx, batch)
+			if err != nil {
+				l.Errorf("Batch received from peer ID '%s' invalid: %s", peerID, err)
 				return err // retry - persistBatch only returns retryable errors
 			}
 
@@ -144,7 +220,8 @@ func (em *eventManager) pinedBatchReceived(peerID string, batch *fftypes.Batch)
 			return nil
 		})
 	})
-
+	tracing.EndSpan(span, err)
+	return err
 }
 
 func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash fftypes.Bytes32, payloadRef string) error {
@@ -163,8 +240,8 @@ func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash
 		batchIDs := make(map[fftypes.UUID]bool)
 
 		err = em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
-			// Insert the blob into the detabase
-			err := em.database.InsertBlob(ctx, &fftypes.Blob{
+			// Insert the blob into the detabase (or add a reference, if we already have the same content)
+			_, err := em.data.StoreBlob(ctx, &fftypes.Blob{
 				Peer:       peerID,
 				PayloadRef: payloadRef,
 				Hash:       &hash,
@@ -221,41 +298,37 @@ func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash
 func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string, status fftypes.OpStatus, info string, opOutput fftypes.JSONObject) error {
 	log.L(em.ctx).Infof("Transfer result %s=%s info='%s'", trackingID, status, info)
 
+	// The data exchange reports the result against the ID of the operation that tracked the transfer
+	opID, err := fftypes.ParseUUID(em.ctx, trackingID)
+	if err != nil {
+		return em.quarantineReceipt(dx, trackingID, status, info, opOutput)
+	}
+
 	// We process the event in a retry loop (which will break only if the context is closed), so that
 	// we only confirm consumption of the event to the plugin once we've processed it.
-	return em.retry.Do(em.ctx, "blob reference insert", func(attempt int) (retry bool, err error) {
+	return em.retry.Do(em.ctx, "transfer result", func(attempt int) (retry bool, err error) {
 
-		// Find a matching operation, for this plugin, with the specified ID.
+		// Find the matching operation, for this plugin.
 		// We retry a few times, as there's an outside possibility of the event arriving before we're finished persisting the operation itself
-		var operations []*fftypes.Operation
-		fb := database.OperationQueryFactory.NewFilter(em.ctx)
-		filter := fb.And(
-			fb.Eq("backendid", trackingID),
-			fb.Eq("plugin", dx.Name()),
-		)
-		operations, _, err = em.database.GetOperations(em.ctx, filter)
+		op, err := em.database.GetOperationByID(em.ctx, opID)
 		if err != nil {
 			return true, err
 		}
-		if len(operations) == 0 {
+		if op == nil || op.Plugin != dx.Name() {
 			// we have a limit on how long we wait to correlate an operation if we don't have a DB erro,
 			// as it should only be a short window where the DB transaction to insert the operation is still
 			// outstanding
 			if attempt >= em.opCorrelationRetries {
-				log.L(em.ctx).Warnf("Unable to correlate %s event %s", dx.Name(), trackingID)
-				return false, nil // just skip this
+				if err = em.quarantineReceipt(dx, trackingID, status, info, opOutput); err != nil {
+					return true, err
+				}
+				return false, nil
 			}
 			return true, i18n.NewError(em.ctx, i18n.Msg404NotFound)
 		}
 
-		update := database.OperationQueryFactory.NewUpdate(em.ctx).
-			Set("status", status).
-			Set("error", info).
-			Set("output", opOutput)
-		for _, op := range operations {
-			if err := em.database.UpdateOperation(em.ctx, op.ID, update); err != nil {
-				return true, err // this is always retryable
-			}
+		if err := em.operationUpdate(dx, op, status, info, opOutput, false); err != nil {
+			return true, err // this is always retryable
 		}
 		return false, nil
 	})
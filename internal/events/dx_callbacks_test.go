@@ -23,6 +23,7 @@ import (
 
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -71,6 +72,85 @@ func TestMessageReceiveOK(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestMessageReceiveQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.receiveLimits.maxSize = 10
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "signingOrg",
+			Key:    "0x12345",
+		},
+	}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "0x12345"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(&fftypes.Organization{
+		Identity: "0x12345",
+	}, nil)
+	mdi.On("GetBatchQuarantineByID", em.ctx, batch.ID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", em.ctx, mock.MatchedBy(func(bq *fftypes.BatchQuarantine) bool {
+		return *bq.ID == *batch.ID && bq.Namespace == "ns1" && bq.Peer == "peer1" && bq.Key == "0x12345" &&
+			bq.Size == int64(len(b)) && bq.PayloadRef == ""
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+	err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestMessageReceiveTimestampRejected(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionReject
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "signingOrg",
+			Key:    "0x12345",
+		},
+		Created: fftypes.UnixTime(1620576488),
+	}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "0x12345"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(&fftypes.Organization{
+		Identity: "0x12345",
+	}, nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTimestampSkew && e.Reference.Equals(batch.ID)
+	})).Return(nil)
+	err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Empty(t, em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything, mock.Anything)
+	mdx.AssertExpectations(t)
+}
+
 func TestMessageReceiveOkBadBatchIgnored(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -386,7 +466,8 @@ func TestBLOBReceivedTriggersRewindOk(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(nil)
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("StoreBlob", em.ctx, mock.Anything).Return(&fftypes.Blob{}, nil)
 	mdi.On("GetDataRefs", em.ctx, mock.Anything).Return(fftypes.DataRefs{
 		{ID: dataID},
 	}, nil, nil)
@@ -420,7 +501,8 @@ func TestBLOBReceivedGetMessagesFail(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(nil)
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("StoreBlob", em.ctx, mock.Anything).Return(&fftypes.Blob{}, nil)
 	mdi.On("GetDataRefs", em.ctx, mock.Anything).Return(fftypes.DataRefs{
 		{ID: dataID},
 	}, nil, nil)
@@ -440,7 +522,8 @@ func TestBLOBReceivedGetDataRefsFail(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(nil)
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("StoreBlob", em.ctx, mock.Anything).Return(&fftypes.Blob{}, nil)
 	mdi.On("GetDataRefs", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 
 	err := em.BLOBReceived(mdx, "peer1", *hash, "ns1/path1")
@@ -449,20 +532,20 @@ func TestBLOBReceivedGetDataRefsFail(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
-func TestBLOBReceivedInsertBlobFails(t *testing.T) {
+func TestBLOBReceivedStoreBlobFails(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	cancel() // retryable error
 	hash := fftypes.NewRandB32()
 
 	mdx := &dataexchangemocks.Plugin{}
 
-	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("StoreBlob", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
 	err := em.BLOBReceived(mdx, "peer1", *hash, "ns1/path1")
 	assert.Regexp(t, "FF10158", err)
 
-	mdi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
 }
 
 func TestTransferResultOk(t *testing.T) {
@@ -471,35 +554,104 @@ func TestTransferResultOk(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	id := fftypes.NewUUID()
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
-		{
-			ID:        id,
-			BackendID: "tracking12345",
-		},
-	}, nil, nil)
+	mdi.On("GetOperationByID", mock.Anything, id).Return(&fftypes.Operation{
+		ID:        id,
+		Namespace: "ns1",
+		Plugin:    "utdx",
+		Status:    fftypes.OpStatusPending,
+	}, nil)
 	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationUpdated && *e.Reference == *id && e.Namespace == "ns1"
+	})).Return(nil)
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
-	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 	assert.NoError(t, err)
 
+	mdi.AssertExpectations(t)
 }
 
-func TestTransferResultNotCorrelated(t *testing.T) {
+func TestTransferResultStatusUnchanged(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
 
 	mdi := em.database.(*databasemocks.Plugin)
 	id := fftypes.NewUUID()
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("GetOperationByID", mock.Anything, id).Return(&fftypes.Operation{
+		ID:     id,
+		Plugin: "utdx",
+		Status: fftypes.OpStatusFailed,
+	}, nil)
 	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(nil)
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
-	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 	assert.NoError(t, err)
 
+	mdi.AssertExpectations(t)
+}
+
+func TestTransferResultInsertEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	id := fftypes.NewUUID()
+	mdi.On("GetOperationByID", mock.Anything, id).Return(&fftypes.Operation{
+		ID:     id,
+		Plugin: "utdx",
+		Status: fftypes.OpStatusPending,
+	}, nil)
+	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	assert.Regexp(t, "FF10158", err)
+
+}
+
+func TestTransferResultNotCorrelated(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	id := fftypes.NewUUID()
+	mdi.On("GetOperationByID", mock.Anything, id).Return(&fftypes.Operation{
+		ID:     id,
+		Plugin: "otherdx",
+	}, nil)
+	mdi.On("InsertUnmatchedReceipt", mock.Anything, mock.MatchedBy(func(r *fftypes.UnmatchedReceipt) bool {
+		return r.Plugin == "utdx" && r.RequestID == id.String() && r.Status == fftypes.OpStatusFailed &&
+			r.Error == "error info" && r.Output.GetString("extra") == "info"
+	})).Return(nil)
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTransferResultNotCorrelatedQuarantineFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("InsertUnmatchedReceipt", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	em.opCorrelationRetries = 0
+	err := em.TransferResult(mdx, fftypes.NewUUID().String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	assert.Regexp(t, "FF10158", err)
+
 }
 
 func TestTransferResultNotFound(t *testing.T) {
@@ -507,25 +659,43 @@ func TestTransferResultNotFound(t *testing.T) {
 	cancel() // avoid retries
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, nil)
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
-	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	em.opCorrelationRetries = 5
+	err := em.TransferResult(mdx, fftypes.NewUUID().String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 	assert.Regexp(t, "FF10158", err)
 
 }
 
+func TestTransferResultNotOperationID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertUnmatchedReceipt", mock.Anything, mock.MatchedBy(func(r *fftypes.UnmatchedReceipt) bool {
+		return r.Plugin == "utdx" && r.RequestID == "tracking12345"
+	})).Return(nil)
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusSucceeded, "", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestTransferGetOpFail(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	cancel() // retryable error
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
-	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	err := em.TransferResult(mdx, fftypes.NewUUID().String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 	assert.Regexp(t, "FF10158", err)
 
 }
@@ -536,17 +706,15 @@ func TestTransferUpdateFail(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	id := fftypes.NewUUID()
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
-		{
-			ID:        id,
-			BackendID: "tracking12345",
-		},
-	}, nil, nil)
+	mdi.On("GetOperationByID", mock.Anything, id).Return(&fftypes.Operation{
+		ID:     id,
+		Plugin: "utdx",
+	}, nil)
 	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(fmt.Errorf("pop"))
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
-	err := em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
+	err := em.TransferResult(mdx, id.String(), fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 	assert.Regexp(t, "FF10158", err)
 
 }
@@ -784,6 +952,7 @@ func TestMessageReceiveMessagePersistEventFail(t *testing.T) {
 	}, nil)
 	mdi.On("UpsertData", em.ctx, mock.Anything, database.UpsertOptimizationSkip).Return(nil)
 	mdi.On("UpsertMessage", em.ctx, mock.Anything, database.UpsertOptimizationSkip).Return(nil)
+	mdi.On("InsertMessageTransition", em.ctx, mock.Anything).Return(nil)
 	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
 	err = em.MessageReceived(mdx, "peer1", b)
@@ -876,3 +1045,238 @@ func TestMessageReceiveMessageEnsureLocalGroupReject(t *testing.T) {
 	mdi.AssertExpectations(t)
 	mdx.AssertExpectations(t)
 }
+
+func newTestReceiptTransport(receipt *fftypes.MessageReceipt) []byte {
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:    fftypes.TransportPayloadTypeReceipt,
+		Receipt: receipt,
+	})
+	return b
+}
+
+func newTestReceipt() *fftypes.MessageReceipt {
+	return &fftypes.MessageReceipt{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Message:   fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "org2",
+			Key:    "0x12345",
+		},
+	}
+}
+
+func mockReceiptIdentity(mdi *databasemocks.Plugin, em *eventManager) {
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node2", Owner: "0x12345"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(&fftypes.Organization{
+		Identity: "0x12345",
+	}, nil)
+}
+
+func TestMessageReceiveReceiptQuorumReached(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.aggregator.quorumSize = 1
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mockReceiptIdentity(mdi, em)
+	mdi.On("UpsertReceipt", em.ctx, receipt).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, receipt.Message).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: receipt.Message, Namespace: "ns1"},
+		State:  fftypes.MessageStateAwaitingQuorum,
+	}, nil)
+	mdi.On("GetReceipts", em.ctx, mock.Anything).Return([]*fftypes.MessageReceipt{receipt}, nil, nil)
+	mdi.On("UpdateMessage", em.ctx, receipt.Message, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", em.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageConfirmed
+	})).Return(nil)
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptNotAwaiting(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mockReceiptIdentity(mdi, em)
+	mdi.On("UpsertReceipt", em.ctx, receipt).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, receipt.Message).Return(nil, nil)
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptGetMessageFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mockReceiptIdentity(mdi, em)
+	mdi.On("UpsertReceipt", em.ctx, receipt).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, receipt.Message).Return(nil, fmt.Errorf("pop"))
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptUpsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mockReceiptIdentity(mdi, em)
+	mdi.On("UpsertReceipt", em.ctx, receipt).Return(fmt.Errorf("pop"))
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptBadIdentity(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptIdentityFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+
+	receipt := newTestReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(receipt))
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveReceiptInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdx := &dataexchangemocks.Plugin{}
+	err := em.MessageReceived(mdx, "peer2", newTestReceiptTransport(&fftypes.MessageReceipt{}))
+	assert.NoError(t, err)
+}
+
+func TestMessageReceiveUnknownAuthorAccepted(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.action = unknownAuthorActionAccept
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "signingOrg",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(nil, nil)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(nil, nil)
+	err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+	assert.Equal(t, *batch.ID, *<-em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveUnknownAuthorQuarantined(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.namespaces["ns1"] = unknownAuthorActionQuarantine
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "signingOrg",
+			Key:    "0x12345",
+		},
+	}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "org1"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(nil, nil)
+	mdi.On("GetBatchQuarantineByID", em.ctx, batch.ID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", em.ctx, mock.MatchedBy(func(q *fftypes.BatchQuarantine) bool {
+		return *q.ID == *batch.ID && q.Peer == "peer1" && q.AwaitingIdentity == "0x12345"
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+	err := em.MessageReceived(mdx, "peer1", b)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestMessageReceiveUnknownAuthorLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // to stop retry
+	em.unknownAuthors.action = unknownAuthorActionQuarantine
+
+	batch := &fftypes.Batch{}
+	b, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	err := em.MessageReceived(mdx, "peer1", b)
+	assert.Regexp(t, "FF10158", err)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/events/sse"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/events/webhooks"
 	"github.com/hyperledger/firefly/internal/events/websockets"
@@ -27,30 +28,33 @@ import (
 	"github.com/hyperledger/firefly/pkg/events"
 )
 
-var plugins = []events.Plugin{
-	&websockets.WebSockets{},
-	&webhooks.WebHooks{},
-	&system.Events{},
+var pluginFactories = []func() events.Plugin{
+	func() events.Plugin { return &websockets.WebSockets{} },
+	func() events.Plugin { return &webhooks.WebHooks{} },
+	func() events.Plugin { return &sse.SSE{} },
+	func() events.Plugin { return &system.Events{} },
 }
 
-var pluginsByName = make(map[string]events.Plugin)
+var pluginsByName = make(map[string]func() events.Plugin)
 
 func init() {
-	for _, p := range plugins {
-		pluginsByName[p.Name()] = p
+	for _, factory := range pluginFactories {
+		pluginsByName[factory().Name()] = factory
 	}
 }
 
 func InitPrefix(prefix config.Prefix) {
-	for _, plugin := range plugins {
+	for _, factory := range pluginFactories {
+		plugin := factory()
 		plugin.InitPrefix(prefix.SubPrefix(plugin.Name()))
 	}
 }
 
+// GetPlugin returns a new instance of the transport, so every event manager has its own set of connections
 func GetPlugin(ctx context.Context, pluginType string) (events.Plugin, error) {
-	plugin, ok := pluginsByName[pluginType]
+	factory, ok := pluginsByName[pluginType]
 	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownEventTransportPlugin, pluginType)
 	}
-	return plugin, nil
+	return factory(), nil
 }
@@ -20,14 +20,18 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"regexp"
 	"sync"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/retry"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -63,6 +67,8 @@ type eventDispatcher struct {
 	subscription  *subscription
 	cel           *changeEventListener
 	changeEvents  chan *fftypes.ChangeEvent
+
+	metricsEnabled bool
 }
 
 func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugin, dm data.Manager, sh definitions.DefinitionHandlers, connID string, sub *subscription, en *eventNotifier, cel *changeEventListener) *eventDispatcher {
@@ -93,6 +99,11 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 		acksNacks:     make(chan ackNack),
 		closed:        make(chan struct{}),
 		cel:           cel,
+
+		metricsEnabled: config.GetBool(config.MetricsEnabled),
+	}
+	if ed.metricsEnabled {
+		metrics.Registry()
 	}
 
 	pollerConf := &eventPollerConf{
@@ -178,6 +189,21 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 		return nil, err
 	}
 
+	blockchainEvents, err := ed.getBlockchainEvents(events)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := ed.getTokenTransfers(events)
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := ed.getOperations(events)
+	if err != nil {
+		return nil, err
+	}
+
 	enriched := make([]*fftypes.EventDelivery, len(events))
 	for i, ls := range events {
 		e := ls.(*fftypes.Event)
@@ -191,12 +217,103 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 				break
 			}
 		}
+		if e.Type == fftypes.EventTypeBlockchainEvent {
+			for _, be := range blockchainEvents {
+				if *e.Reference == *be.ID {
+					enriched[i].BlockchainEvent = be
+					break
+				}
+			}
+		}
+		if e.Type == fftypes.EventTypeTransferConfirmed {
+			for _, transfer := range transfers {
+				if *e.Reference == *transfer.LocalID {
+					enriched[i].TokenTransfer = transfer
+					break
+				}
+			}
+		}
+		if e.Type == fftypes.EventTypeOperationUpdated {
+			for _, op := range operations {
+				if *e.Reference == *op.ID {
+					enriched[i].Operation = txcommon.RedactOperation(op)
+					break
+				}
+			}
+		}
 	}
 
 	return enriched, nil
 
 }
 
+func (ed *eventDispatcher) getBlockchainEvents(events []fftypes.LocallySequenced) ([]*fftypes.BlockchainEvent, error) {
+	// Blockchain events are only looked up if the page contains events that refer to them
+	refIDs := make([]driver.Value, 0)
+	for _, ls := range events {
+		e := ls.(*fftypes.Event)
+		if e.Type == fftypes.EventTypeBlockchainEvent && e.Reference != nil {
+			refIDs = append(refIDs, *e.Reference)
+		}
+	}
+	if len(refIDs) == 0 {
+		return nil, nil
+	}
+
+	fb := database.BlockchainEventQueryFactory.NewFilter(ed.ctx)
+	filter := fb.And(
+		fb.In("id", refIDs),
+		fb.Eq("namespace", ed.namespace),
+	)
+	blockchainEvents, _, err := ed.database.GetBlockchainEvents(ed.ctx, filter)
+	return blockchainEvents, err
+}
+
+func (ed *eventDispatcher) getTokenTransfers(events []fftypes.LocallySequenced) ([]*fftypes.TokenTransfer, error) {
+	// Token transfers are only looked up if the page contains confirmed transfers, so applications get the
+	// pool sequence of each transfer to order them
+	refIDs := make([]driver.Value, 0)
+	for _, ls := range events {
+		e := ls.(*fftypes.Event)
+		if e.Type == fftypes.EventTypeTransferConfirmed && e.Reference != nil {
+			refIDs = append(refIDs, *e.Reference)
+		}
+	}
+	if len(refIDs) == 0 {
+		return nil, nil
+	}
+
+	fb := database.TokenTransferQueryFactory.NewFilter(ed.ctx)
+	filter := fb.And(
+		fb.In("localid", refIDs),
+		fb.Eq("namespace", ed.namespace),
+	)
+	transfers, _, err := ed.database.GetTokenTransfers(ed.ctx, filter)
+	return transfers, err
+}
+
+func (ed *eventDispatcher) getOperations(events []fftypes.LocallySequenced) ([]*fftypes.Operation, error) {
+	// Operations are only looked up if the page contains operation updates
+	refIDs := make([]driver.Value, 0)
+	for _, ls := range events {
+		e := ls.(*fftypes.Event)
+		if e.Type == fftypes.EventTypeOperationUpdated && e.Reference != nil {
+			refIDs = append(refIDs, *e.Reference)
+		}
+	}
+	if len(refIDs) == 0 {
+		return nil, nil
+	}
+
+	fb := database.OperationQueryFactory.NewFilter(ed.ctx)
+	filter := fb.And(
+		fb.In("id", refIDs),
+		fb.Eq("namespace", ed.namespace),
+	)
+	operations, _, err := ed.database.GetOperations(ed.ctx, filter)
+	return operations, err
+}
+
 func (ed *eventDispatcher) filterEvents(candidates []*fftypes.EventDelivery) []*fftypes.EventDelivery {
 	matchingEvents := make([]*fftypes.EventDelivery, 0, len(candidates))
 	for _, event := range candidates {
@@ -209,14 +326,20 @@ func (ed *eventDispatcher) filterEvents(candidates []*fftypes.EventDelivery) []*
 		group := ""
 		author := ""
 		var topics []string
+		var custom fftypes.CustomHeaders
 		if msg != nil {
 			tag = msg.Header.Tag
+			custom = msg.Header.Custom
 			topics = msg.Header.Topics
 			author = msg.Header.Author
 			if msg.Header.Group != nil {
 				group = msg.Header.Group.String()
 			}
 		}
+		if event.Topic != "" {
+			// Events for a single topic of a message only match on that topic
+			topics = []string{event.Topic}
+		}
 		if filter.tagFilter != nil && !filter.tagFilter.MatchString(tag) {
 			continue
 		}
@@ -238,11 +361,73 @@ func (ed *eventDispatcher) filterEvents(candidates []*fftypes.EventDelivery) []*
 		if filter.groupFilter != nil && !filter.groupFilter.MatchString(group) {
 			continue
 		}
+		if !customHeadersMatch(filter.customFilters, custom) {
+			continue
+		}
+		if filter.blockchainFilter != nil && !filter.blockchainFilter.matches(event.BlockchainEvent) {
+			continue
+		}
+		if filter.operationFilter != nil && !filter.operationFilter.matches(event.Operation) {
+			continue
+		}
 		matchingEvents = append(matchingEvents, event)
 	}
 	return matchingEvents
 }
 
+func customHeadersMatch(filters map[string]*regexp.Regexp, custom fftypes.CustomHeaders) bool {
+	for k, f := range filters {
+		if !f.MatchString(custom[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (bf *blockchainEventFilter) matches(be *fftypes.BlockchainEvent) bool {
+	address := ""
+	name := ""
+	var output fftypes.JSONObject
+	if be != nil {
+		address = be.Address
+		name = be.Name
+		output = be.Output
+	}
+	if bf.addressFilter != nil && !bf.addressFilter.MatchString(address) {
+		return false
+	}
+	if bf.nameFilter != nil && !bf.nameFilter.MatchString(name) {
+		return false
+	}
+	for k, f := range bf.paramFilters {
+		if !f.MatchString(output.GetString(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (of *operationFilter) matches(op *fftypes.Operation) bool {
+	opType := ""
+	plugin := ""
+	status := ""
+	if op != nil {
+		opType = string(op.Type)
+		plugin = op.Plugin
+		status = string(op.Status)
+	}
+	if of.typeFilter != nil && !of.typeFilter.MatchString(opType) {
+		return false
+	}
+	if of.pluginFilter != nil && !of.pluginFilter.MatchString(plugin) {
+		return false
+	}
+	if of.statusFilter != nil && !of.statusFilter.MatchString(status) {
+		return false
+	}
+	return true
+}
+
 func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (bool, error) {
 	// At this point, the page of messages we've been given are loaded from the DB into memory,
 	// but we can only make them in-flight and push them to the client up to the maximum
@@ -366,6 +551,13 @@ func (ed *eventDispatcher) dispatchChangeEvent(ce *fftypes.ChangeEvent) {
 	}
 }
 
+// recordDispatchLag observes how long an event waited between being created, and being dispatched
+func (ed *eventDispatcher) recordDispatchLag(event *fftypes.EventDelivery) {
+	if ed.metricsEnabled && event.Created != nil {
+		metrics.EventDispatchLagHistogram.WithLabelValues(ed.namespace, ed.transport.Name()).Observe(time.Since(*event.Created.Time()).Seconds())
+	}
+}
+
 func (ed *eventDispatcher) deliverEvents() {
 	if ed.transport.Capabilities().ChangeEvents && ed.subscription.definition.Options.ChangeEvents {
 		ed.cel.addDispatcher(*ed.subscription.definition.ID, ed)
@@ -385,6 +577,7 @@ func (ed *eventDispatcher) deliverEvents() {
 				data, _, err = ed.data.GetMessageData(ed.ctx, event.Message, true)
 			}
 			if err == nil {
+				ed.recordDispatchLag(event)
 				err = ed.transport.DeliveryRequest(ed.connID, ed.subscription.definition, event, data)
 			}
 			if err != nil {
@@ -427,6 +620,15 @@ func (ed *eventDispatcher) deliveryResponse(response *fftypes.EventDeliveryRespo
 		ed.definitions.SendReply(ed.ctx, event, response.Reply)
 	}
 
+	// A transport that gave up on the event has it recorded as failed, before it is acknowledged.
+	// If we cannot record it, the event is redelivered.
+	if response.DeadLetter {
+		if err := ed.recordDeliveryFailed(event); err != nil {
+			l.Errorf("Failed to record failed delivery of event %s: %s", event.ID, err)
+			an.isNack = true
+		}
+	}
+
 	l.Debugf("Response for %s event: %.10d/%s [%s]: ref=%s/%s rejected=%t info='%s'", ed.transport.Name(), event.Sequence, event.ID, event.Type, event.Namespace, event.Reference, response.Rejected, response.Info)
 	// We don't do any meaningful work in this call, we just set things up so the right thing
 	// will happen when the poller wakes up. So we need to pass it over
@@ -438,6 +640,15 @@ func (ed *eventDispatcher) deliveryResponse(response *fftypes.EventDeliveryRespo
 	}
 }
 
+func (ed *eventDispatcher) recordDeliveryFailed(event *fftypes.Event) error {
+	if event.Type == fftypes.EventTypeDeliveryFailed {
+		// We do not cascade failures to deliver the delivery_failed events themselves
+		log.L(ed.ctx).Warnf("Delivery failed for %s event %s", event.Type, event.ID)
+		return nil
+	}
+	return ed.database.InsertEvent(ed.ctx, fftypes.NewEvent(fftypes.EventTypeDeliveryFailed, event.Namespace, event.ID))
+}
+
 func (ed *eventDispatcher) close() {
 	log.L(ed.ctx).Infof("Dispatcher closing for conn=%s subscription=%s", ed.connID, ed.subscription.definition.ID)
 	ed.cancelCtx()
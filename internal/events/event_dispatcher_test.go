@@ -24,6 +24,7 @@ import (
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
@@ -31,6 +32,7 @@ import (
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -407,6 +409,267 @@ func TestEnrichEventsFailGetMessages(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestEnrichEventsBlockchainEvents(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	beID := fftypes.NewUUID()
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{
+		{ID: fftypes.NewUUID()},
+		{ID: beID, Name: "Changed"},
+	}, nil, nil)
+
+	events, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeBlockchainEvent, Reference: beID},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, events[0].BlockchainEvent)
+	assert.Equal(t, "Changed", events[1].BlockchainEvent.Name)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEnrichEventsFailGetBlockchainEvents(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeBlockchainEvent, Reference: fftypes.NewUUID()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestEnrichEventsTokenTransfers(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	transferID := fftypes.NewUUID()
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetTokenTransfers", mock.Anything, mock.Anything).Return([]*fftypes.TokenTransfer{
+		{LocalID: fftypes.NewUUID()},
+		{LocalID: transferID, PoolSequence: "000000000010/000002/000003"},
+	}, nil, nil)
+
+	events, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransferConfirmed, Reference: transferID},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, events[0].TokenTransfer)
+	assert.Equal(t, "000000000010/000002/000003", events[1].TokenTransfer.PoolSequence)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEnrichEventsFailGetTokenTransfers(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetTokenTransfers", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransferConfirmed, Reference: fftypes.NewUUID()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestEnrichEventsOperations(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{ID: fftypes.NewUUID()},
+		{ID: opID, Type: fftypes.OpTypeDataExchangeBatchSend, Status: fftypes.OpStatusFailed},
+	}, nil, nil)
+
+	events, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()},
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationUpdated, Reference: opID},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, events[0].Operation)
+	assert.Equal(t, fftypes.OpStatusFailed, events[1].Operation.Status)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestEnrichEventsFailGetOperations(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	mdi := ed.database.(*databasemocks.Plugin)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationUpdated, Reference: fftypes.NewUUID()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFilterEventsOperations(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+		operationFilter: &operationFilter{
+			typeFilter:   regexp.MustCompile("^dataexchange_"),
+			pluginFilter: regexp.MustCompile("^https$"),
+			statusFilter: regexp.MustCompile("^Failed$"),
+		},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	id1 := fftypes.NewUUID()
+	events := ed.filterEvents([]*fftypes.EventDelivery{
+		{
+			Event:     fftypes.Event{ID: id1, Type: fftypes.EventTypeOperationUpdated},
+			Operation: &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend, Plugin: "https", Status: fftypes.OpStatusFailed},
+		},
+		{
+			Event:     fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationUpdated},
+			Operation: &fftypes.Operation{Type: fftypes.OpTypeBlockchainBatchPin, Plugin: "https", Status: fftypes.OpStatusFailed},
+		},
+		{
+			Event:     fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationUpdated},
+			Operation: &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend, Plugin: "other", Status: fftypes.OpStatusFailed},
+		},
+		{
+			Event:     fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationUpdated},
+			Operation: &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend, Plugin: "https", Status: fftypes.OpStatusSucceeded},
+		},
+		{
+			Event: fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed},
+		},
+	})
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, *id1, *events[0].ID)
+}
+
+func TestFilterEventsBlockchainEvents(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+		blockchainFilter: &blockchainEventFilter{
+			addressFilter: regexp.MustCompile("^0x1234$"),
+			nameFilter:    regexp.MustCompile("^Changed$"),
+			paramFilters: map[string]*regexp.Regexp{
+				"from": regexp.MustCompile("^0x5678$"),
+			},
+		},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	id1 := fftypes.NewUUID()
+	events := ed.filterEvents([]*fftypes.EventDelivery{
+		{
+			Event: fftypes.Event{ID: id1, Type: fftypes.EventTypeBlockchainEvent},
+			BlockchainEvent: &fftypes.BlockchainEvent{
+				Address: "0x1234",
+				Name:    "Changed",
+				Output:  fftypes.JSONObject{"from": "0x5678"},
+			},
+		},
+		{
+			Event: fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeBlockchainEvent},
+			BlockchainEvent: &fftypes.BlockchainEvent{
+				Address: "0xabcd",
+				Name:    "Changed",
+				Output:  fftypes.JSONObject{"from": "0x5678"},
+			},
+		},
+		{
+			Event: fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeBlockchainEvent},
+			BlockchainEvent: &fftypes.BlockchainEvent{
+				Address: "0x1234",
+				Name:    "Other",
+				Output:  fftypes.JSONObject{"from": "0x5678"},
+			},
+		},
+		{
+			Event: fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeBlockchainEvent},
+			BlockchainEvent: &fftypes.BlockchainEvent{
+				Address: "0x1234",
+				Name:    "Changed",
+				Output:  fftypes.JSONObject{"from": "0x9999"},
+			},
+		},
+		{
+			Event: fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed},
+		},
+	})
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, *id1, *events[0].ID)
+}
+
+func TestFilterEventsTopicConfirmed(t *testing.T) {
+
+	sub := &subscription{
+		definition:   &fftypes.Subscription{},
+		topicsFilter: regexp.MustCompile("^topic2$"),
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+	id1 := fftypes.NewUUID()
+	id2 := fftypes.NewUUID()
+	matched := ed.filterEvents([]*fftypes.EventDelivery{
+		{
+			Event:   fftypes.Event{ID: id1, Type: fftypes.EventTypeMessageTopicConfirmed, Topic: "topic1"},
+			Message: msg,
+		},
+		{
+			Event:   fftypes.Event{ID: id2, Type: fftypes.EventTypeMessageTopicConfirmed, Topic: "topic2"},
+			Message: msg,
+		},
+	})
+	assert.Equal(t, 1, len(matched))
+	assert.Equal(t, *id2, *matched[0].ID)
+}
+
 func TestFilterEventsMatch(t *testing.T) {
 
 	sub := &subscription{
@@ -451,6 +714,9 @@ func TestFilterEventsMatch(t *testing.T) {
 						Author: "org2",
 						Key:    "0x23456",
 					},
+					Custom: fftypes.CustomHeaders{
+						"region": "eu-west",
+					},
 				},
 			},
 		},
@@ -524,6 +790,22 @@ func TestFilterEventsMatch(t *testing.T) {
 	assert.Equal(t, 1, len(matched))
 	assert.Equal(t, *id2, *matched[0].ID)
 
+	ed.subscription.authorFilter = nil
+	ed.subscription.customFilters = map[string]*regexp.Regexp{
+		"region": regexp.MustCompile("^eu-"),
+	}
+	matched = ed.filterEvents(events)
+	assert.Equal(t, 1, len(matched))
+	assert.Equal(t, *id2, *matched[0].ID)
+
+	ed.subscription.customFilters = map[string]*regexp.Regexp{
+		"region": regexp.MustCompile("^$"),
+	}
+	matched = ed.filterEvents(events)
+	assert.Equal(t, 2, len(matched))
+	assert.Equal(t, *id1, *matched[0].ID)
+	assert.Equal(t, *id3, *matched[1].ID)
+
 }
 
 func TestBufferedDeliveryNoEvents(t *testing.T) {
@@ -840,6 +1122,25 @@ func TestDeliverEventsWithDataFail(t *testing.T) {
 
 }
 
+func TestRecordDispatchLagMetrics(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, true)
+	defer metrics.Clear()
+	sub := &subscription{
+		definition: &fftypes.Subscription{
+			SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1", Name: "sub1"},
+		},
+	}
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+
+	ed.recordDispatchLag(&fftypes.EventDelivery{Event: fftypes.Event{ID: fftypes.NewUUID()}})
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.EventDispatchLagHistogram))
+
+	ed.recordDispatchLag(&fftypes.EventDelivery{Event: fftypes.Event{ID: fftypes.NewUUID(), Created: fftypes.Now()}})
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.EventDispatchLagHistogram))
+}
+
 func TestEventDispatcherWithReply(t *testing.T) {
 	log.SetLevel("debug")
 	var two = uint16(5)
@@ -908,6 +1209,48 @@ func TestEventDispatcherWithReply(t *testing.T) {
 	msh.AssertExpectations(t)
 }
 
+func TestEventDispatcherDeadLetter(t *testing.T) {
+	sub := &subscription{
+		dispatcherElection: make(chan bool, 1),
+		definition: &fftypes.Subscription{
+			SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1", Name: "sub1"},
+		},
+	}
+
+	ed, cancel := newTestEventDispatcher(sub)
+	defer cancel()
+	ed.acksNacks = make(chan ackNack, 3)
+	mdi := ed.database.(*databasemocks.Plugin)
+
+	event1 := fftypes.NewUUID()
+	event2 := fftypes.NewUUID()
+	event3 := fftypes.NewUUID()
+	ed.inflight[*event1] = &fftypes.Event{ID: event1, Namespace: "ns1", Type: fftypes.EventTypeMessageConfirmed}
+	ed.inflight[*event2] = &fftypes.Event{ID: event2, Namespace: "ns1", Type: fftypes.EventTypeMessageConfirmed}
+	ed.inflight[*event3] = &fftypes.Event{ID: event3, Namespace: "ns1", Type: fftypes.EventTypeDeliveryFailed}
+	mdi.On("InsertEvent", ed.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeDeliveryFailed && e.Reference.Equals(event1)
+	})).Return(nil)
+	mdi.On("InsertEvent", ed.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeDeliveryFailed && e.Reference.Equals(event2)
+	})).Return(fmt.Errorf("pop"))
+
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event1, DeadLetter: true})
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event2, DeadLetter: true})
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event3, DeadLetter: true})
+
+	an := <-ed.acksNacks
+	assert.Equal(t, *event1, an.id)
+	assert.False(t, an.isNack)
+	an = <-ed.acksNacks
+	assert.Equal(t, *event2, an.id)
+	assert.True(t, an.isNack)
+	an = <-ed.acksNacks
+	assert.Equal(t, *event3, an.id)
+	assert.False(t, an.isNack)
+
+	mdi.AssertExpectations(t)
+}
 func TestDispatchChangeEventBlockedClose(t *testing.T) {
 	yes := true
 	sub := &subscription{
@@ -23,15 +23,17 @@ import (
 	"strconv"
 
 	"github.com/hyperledger/firefly/internal/assets"
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
-	"github.com/hyperledger/firefly/internal/events/eifactory"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/policy"
 	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
@@ -39,6 +41,7 @@ import (
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
+	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
@@ -54,11 +57,15 @@ type EventManager interface {
 	DeleteDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error)
 	CreateUpdateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription, mustNew bool) (err error)
 	Start() error
+	// StartDispatch starts delivering events to subscriptions, and sending receipts for broadcasts. These are
+	// not started with the rest of the event manager while the node is a standby, until it is promoted.
+	StartDispatch() error
 	WaitStop()
 
 	// Bound blockchain callbacks
 	OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState blockchain.TransactionStatus, errorMessage string, opOutput fftypes.JSONObject) error
 	BatchPinComplete(bi blockchain.Plugin, batch *blockchain.BatchPin, author string, protocolTxID string, additionalInfo fftypes.JSONObject) error
+	BlockchainEvent(bi blockchain.Plugin, event *fftypes.BlockchainEvent) error
 
 	// Bound dataexchange callbacks
 	TransferResult(dx dataexchange.Plugin, trackingID string, status fftypes.OpStatus, info string, opOutput fftypes.JSONObject) error
@@ -68,6 +75,43 @@ type EventManager interface {
 	// Bound token callbacks
 	TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPool, protocolTxID string, additionalInfo fftypes.JSONObject) error
 	TokensTransferred(ti tokens.Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error
+	TokensApproved(ti tokens.Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error
+	TokenPoolGovernance(ti tokens.Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error
+
+	// ApplyApproved applies a governance action on a token pool once an administrator approves it
+	ApplyApproved(ctx context.Context, approval *fftypes.PolicyApproval) error
+
+	// Quarantined inbound batches
+	GetBatchQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.BatchQuarantine, *database.FilterResult, error)
+	GetBatchQuarantineByID(ctx context.Context, id string) (*fftypes.BatchQuarantine, error)
+	DecideBatchQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.BatchQuarantine, error)
+
+	// Messages quarantined by the pin policy
+	GetPinQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.PinQuarantine, *database.FilterResult, error)
+	GetPinQuarantineByID(ctx context.Context, id string) (*fftypes.PinQuarantine, error)
+	DecidePinQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.PinQuarantine, error)
+
+	// Connector receipts that did not match an operation
+	GetUnmatchedReceipts(ctx context.Context, filter database.AndFilter) ([]*fftypes.UnmatchedReceipt, *database.FilterResult, error)
+	GetUnmatchedReceiptByID(ctx context.Context, id string) (*fftypes.UnmatchedReceipt, error)
+	ReconcileUnmatchedReceipt(ctx context.Context, id string, input *fftypes.UnmatchedReceiptReconcile) (*fftypes.UnmatchedReceipt, error)
+
+	// Broadcast pins blocked on retrieval of the batch payload from shared storage
+	GetBlockedPins(ctx context.Context, filter database.AndFilter) ([]*fftypes.BlockedPin, *database.FilterResult, error)
+	GetBlockedPinByID(ctx context.Context, id string) (*fftypes.BlockedPin, error)
+	RetryBlockedPin(ctx context.Context, id string) (*fftypes.BlockedPin, error)
+
+	// Clock skew of the local node against the blockchain
+	BlockchainClockSkew() *fftypes.FFDuration
+
+	// ResetEventDedup forgets the blockchain events processed recently, before a replay of the blockchain
+	ResetEventDedup(ctx context.Context) error
+
+	// ReloadTransportConfig re-initializes an event transport with the current configuration, without a restart
+	ReloadTransportConfig(ctx context.Context, transport string) error
+
+	// GetTransport returns the instance of an enabled event transport owned by this event manager, or nil
+	GetTransport(transport string) events.Plugin
 
 	// Internal events
 	sysmessaging.SystemEvents
@@ -88,21 +132,27 @@ type eventManager struct {
 	broadcast            broadcast.Manager
 	messaging            privatemessaging.Manager
 	assets               assets.Manager
+	policy               policy.Manager
 	newEventNotifier     *eventNotifier
 	newPinNotifier       *eventNotifier
 	opCorrelationRetries int
 	defaultTransport     string
 	internalEvents       *system.Events
+	dedup                *eventDedup
+	receiveLimits        *receiveLimits
+	unknownAuthors       *unknownAuthorPolicy
+	timestamps           *timestampPolicy
+	metricsEnabled       bool
 }
 
-func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publicstorage.Plugin, di database.Plugin, im identity.Manager, dh definitions.DefinitionHandlers, dm data.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager) (EventManager, error) {
-	if ni == nil || pi == nil || di == nil || im == nil || dm == nil || bm == nil || pm == nil || am == nil {
+func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publicstorage.Plugin, di database.Plugin, im identity.Manager, dh definitions.DefinitionHandlers, dm data.Manager, bm broadcast.Manager, pm privatemessaging.Manager, am assets.Manager, pol policy.Manager) (EventManager, error) {
+	if ni == nil || pi == nil || di == nil || im == nil || dm == nil || bm == nil || pm == nil || am == nil || pol == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 	newPinNotifier := newEventNotifier(ctx, "pins")
 	newEventNotifier := newEventNotifier(ctx, "events")
 	em := &eventManager{
-		ctx:           log.WithLogField(ctx, "role", "event-manager"),
+		ctx:           log.WithComponent(log.WithLogField(ctx, "role", "event-manager"), "events"),
 		ni:            ni,
 		publicstorage: pi,
 		database:      di,
@@ -112,6 +162,7 @@ func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publ
 		broadcast:     bm,
 		messaging:     pm,
 		assets:        am,
+		policy:        pol,
 		retry: retry.Retry{
 			InitialDelay: config.GetDuration(config.EventAggregatorRetryInitDelay),
 			MaximumDelay: config.GetDuration(config.EventAggregatorRetryMaxDelay),
@@ -122,23 +173,40 @@ func NewEventManager(ctx context.Context, ni sysmessaging.LocalNodeInfo, pi publ
 		opCorrelationRetries: config.GetInt(config.EventAggregatorOpCorrelationRetries),
 		newEventNotifier:     newEventNotifier,
 		newPinNotifier:       newPinNotifier,
-		aggregator:           newAggregator(ctx, di, dh, dm, newPinNotifier),
+		aggregator:           newAggregator(ctx, di, dh, dm, im, pm, newPinNotifier),
+		dedup:                newEventDedup(ctx, di),
+		receiveLimits:        newReceiveLimits(),
+		unknownAuthors:       newUnknownAuthorPolicy(ctx),
+		timestamps:           newTimestampPolicy(ctx),
+		metricsEnabled:       config.GetBool(config.MetricsEnabled),
+	}
+	if em.metricsEnabled {
+		metrics.Registry()
 	}
-	ie, _ := eifactory.GetPlugin(ctx, system.SystemEventsTransport)
-	em.internalEvents = ie.(*system.Events)
+	em.aggregator.releaseAwaitingIdentity = em.releaseAwaitingIdentity
+	em.aggregator.retryBlockedPin = em.retryBlockedPin
 
 	var err error
 	if em.subManager, err = newSubscriptionManager(ctx, di, dm, newEventNotifier, dh); err != nil {
 		return nil, err
 	}
+	if em.internalEvents, err = em.subManager.systemEvents(); err != nil {
+		return nil, err
+	}
 
 	return em, nil
 }
 
 func (em *eventManager) Start() (err error) {
+	em.dedup.restore()
+	em.aggregator.start()
+	return nil
+}
+
+func (em *eventManager) StartDispatch() (err error) {
 	err = em.subManager.start()
 	if err == nil {
-		em.aggregator.start()
+		em.aggregator.startDispatch()
 	}
 	return err
 }
@@ -172,6 +240,14 @@ func (em *eventManager) WaitStop() {
 	<-em.aggregator.eventPoller.closed
 }
 
+func (em *eventManager) ReloadTransportConfig(ctx context.Context, transport string) error {
+	return em.subManager.reloadTransportConfig(ctx, transport)
+}
+
+func (em *eventManager) GetTransport(transport string) events.Plugin {
+	return em.subManager.transports[transport]
+}
+
 func (em *eventManager) CreateUpdateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription, mustNew bool) (err error) {
 	if subDef.Namespace == "" || subDef.Name == "" || subDef.ID == nil {
 		return i18n.NewError(ctx, i18n.MsgInvalidSubscription)
@@ -192,9 +268,14 @@ func (em *eventManager) CreateUpdateDurableSubscription(ctx context.Context, sub
 		if mustNew {
 			return i18n.NewError(ctx, i18n.MsgAlreadyExists, "subscription", subDef.Namespace, subDef.Name)
 		}
+		// Only the owner of a subscription (or an admin) can modify it
+		if !auth.CanAccessSubscription(subDef.Owner, existing) {
+			return i18n.NewError(ctx, i18n.MsgSubscriptionNotOwner, subDef.Namespace, subDef.Name)
+		}
 		// Copy over the generated fields, so we can do a compare
 		subDef.Created = existing.Created
 		subDef.ID = existing.ID
+		subDef.Owner = existing.Owner
 		subDef.Updated = fftypes.Now()
 		subDef.Options.FirstEvent = existing.Options.FirstEvent // we do not reset the sub position
 		existing.Updated = subDef.Updated
@@ -30,6 +30,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/policymanagermocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/sysmessagingmocks"
@@ -41,7 +42,16 @@ import (
 var testNodeID = fftypes.NewUUID()
 
 func newTestEventManager(t *testing.T) (*eventManager, func()) {
+	return newTestEventManagerCommon(t, false)
+}
+
+func newTestEventManagerWithMetrics(t *testing.T) (*eventManager, func()) {
+	return newTestEventManagerCommon(t, true)
+}
+
+func newTestEventManagerCommon(t *testing.T, metrics bool) (*eventManager, func()) {
 	config.Reset()
+	config.Set(config.MetricsEnabled, metrics)
 	ctx, cancel := context.WithCancel(context.Background())
 	mdi := &databasemocks.Plugin{}
 	mim := &identitymanagermocks.Manager{}
@@ -52,10 +62,11 @@ func newTestEventManager(t *testing.T) (*eventManager, func()) {
 	mbm := &broadcastmocks.Manager{}
 	mpm := &privatemessagingmocks.Manager{}
 	mam := &assetmocks.Manager{}
+	mpo := &policymanagermocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mni.On("GetNodeUUID", mock.Anything).Return(testNodeID).Maybe()
 	met.On("Name").Return("ut").Maybe()
-	emi, err := NewEventManager(ctx, mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam)
+	emi, err := NewEventManager(ctx, mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam, mpo)
 	em := emi.(*eventManager)
 	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything).Maybe()
 	rag.RunFn = func(a mock.Arguments) {
@@ -68,6 +79,8 @@ func newTestEventManager(t *testing.T) (*eventManager, func()) {
 func TestStartStop(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, aggregatorOffsetName).Return(nil, nil).Maybe()
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName).Return(nil, nil)
 	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(&fftypes.Offset{
 		Type:    fftypes.OffsetTypeAggregator,
 		Name:    aggregatorOffsetName,
@@ -75,8 +88,13 @@ func TestStartStop(t *testing.T) {
 		RowID:   333333,
 	}, nil)
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetBlockedPins", mock.Anything, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil).Maybe()
 	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	assert.NoError(t, em.Start())
+	assert.False(t, em.subManager.started)
+	assert.NoError(t, em.StartDispatch())
+	assert.True(t, em.subManager.started)
+	assert.True(t, em.aggregator.dispatching)
 	em.NewEvents() <- 12345
 	em.NewPins() <- 12345
 	assert.Equal(t, chan<- *fftypes.ChangeEvent(em.subManager.cel.changeEvents), em.ChangeEvents())
@@ -84,8 +102,31 @@ func TestStartStop(t *testing.T) {
 	em.WaitStop()
 }
 
+func TestStartDispatchFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	assert.EqualError(t, em.StartDispatch(), "pop")
+	assert.False(t, em.aggregator.dispatching)
+}
+
+func TestEventManagerReloadTransportConfig(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	err := em.ReloadTransportConfig(em.ctx, "wrong")
+	assert.Regexp(t, "FF10445", err)
+}
+
+func TestEventManagerGetTransport(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	assert.Equal(t, em.internalEvents, em.GetTransport(system.SystemEventsTransport))
+	assert.Nil(t, em.GetTransport("wrong"))
+}
+
 func TestStartStopBadDependencies(t *testing.T) {
-	_, err := NewEventManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewEventManager(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 
 }
@@ -102,13 +143,16 @@ func TestStartStopBadTransports(t *testing.T) {
 	mpm := &privatemessagingmocks.Manager{}
 	mni := &sysmessagingmocks.LocalNodeInfo{}
 	mam := &assetmocks.Manager{}
-	_, err := NewEventManager(context.Background(), mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam)
+	mpo := &policymanagermocks.Manager{}
+	_, err := NewEventManager(context.Background(), mni, mpi, mdi, mim, msh, mdm, mbm, mpm, mam, mpo)
 	assert.Regexp(t, "FF10172", err)
 }
 
 func TestEmitSubscriptionEventsNoops(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, aggregatorOffsetName).Return(nil, nil).Maybe()
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeEventDedup, eventDedupSnapshotName).Return(nil, nil)
 	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeAggregator, aggregatorOffsetName).Return(&fftypes.Offset{
 		Type:    fftypes.OffsetTypeAggregator,
 		Name:    aggregatorOffsetName,
@@ -116,6 +160,7 @@ func TestEmitSubscriptionEventsNoops(t *testing.T) {
 		RowID:   333333,
 	}, nil)
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetBlockedPins", mock.Anything, mock.Anything).Return([]*fftypes.BlockedPin{}, nil, nil).Maybe()
 	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 
 	getSubCallReady := make(chan bool, 1)
@@ -133,6 +178,7 @@ func TestEmitSubscriptionEventsNoops(t *testing.T) {
 	}
 
 	assert.NoError(t, em.Start())
+	assert.NoError(t, em.StartDispatch())
 	defer cancel()
 
 	// Wait until the gets occur for these events, which will return nil
@@ -304,6 +350,28 @@ func TestUpdateDurableSubscriptionOk(t *testing.T) {
 	assert.Equal(t, "12345", string(*sub.Options.FirstEvent))
 }
 
+func TestUpdateDurableSubscriptionNotOwner(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Owner: "CN=app2",
+	}
+	mdi.On("GetSubscriptionByName", mock.Anything, "ns1", "sub1").Return(&fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID: fftypes.NewUUID(),
+		},
+		Owner: "CN=app1",
+	}, nil)
+	err := em.CreateUpdateDurableSubscription(em.ctx, sub, false)
+	assert.Regexp(t, "FF10329", err)
+}
+
 func TestUpdateDurableSubscriptionNoOp(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
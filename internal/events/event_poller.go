@@ -38,6 +38,7 @@ type eventPoller struct {
 	pollingOffset int64
 	mux           sync.Mutex
 	conf          *eventPollerConf
+	lastSnapshot  time.Time
 }
 
 type newEventsHandler func(events []fftypes.LocallySequenced) (bool, error)
@@ -58,6 +59,10 @@ type eventPollerConf struct {
 	offsetType                 fftypes.OffsetType
 	retry                      retry.Retry
 	startupOffsetRetryAttempts int
+	snapshotType               fftypes.SnapshotType
+	snapshotInterval           time.Duration
+	snapshotState              func() fftypes.JSONObject
+	restoreSnapshot            func(snapshot *fftypes.Snapshot) (rewind bool, offset int64)
 }
 
 func newEventPoller(ctx context.Context, di database.Plugin, en *eventNotifier, conf *eventPollerConf) *eventPoller {
@@ -68,6 +73,7 @@ func newEventPoller(ctx context.Context, di database.Plugin, en *eventNotifier,
 		eventNotifier: en,
 		closed:        make(chan struct{}),
 		conf:          conf,
+		lastSnapshot:  time.Now(),
 	}
 	if ep.conf.maybeRewind == nil {
 		ep.conf.maybeRewind = func() (bool, int64) { return false, -1 }
@@ -89,7 +95,7 @@ func (ep *eventPoller) restoreOffset() error {
 				return retry, err
 			}
 			if offset == nil {
-				firstOffset, err := calcFirstOffset(ep.ctx, ep.database, ep.conf.firstEvent)
+				firstOffset, err := ep.calcFirstOffset()
 				if err != nil {
 					return retry, err
 				}
@@ -105,11 +111,68 @@ func (ep *eventPoller) restoreOffset() error {
 		}
 		ep.offsetID = offset.RowID
 		ep.pollingOffset = offset.Current
+		if err = ep.restoreSnapshot(); err != nil {
+			return retry, err
+		}
 		log.L(ep.ctx).Infof("Event offset restored %d", ep.pollingOffset)
 		return false, nil
 	})
 }
 
+// calcFirstOffset uses the offset from the latest snapshot, if there is one, to avoid
+// re-scanning from the configured first event when the offset record is missing
+func (ep *eventPoller) calcFirstOffset() (int64, error) {
+	if ep.conf.snapshotState != nil {
+		snapshot, err := ep.database.GetSnapshot(ep.ctx, ep.conf.snapshotType, ep.conf.offsetName)
+		if err != nil {
+			return -1, err
+		}
+		if snapshot != nil {
+			log.L(ep.ctx).Infof("Event offset initialized from snapshot %d", snapshot.Offset)
+			return snapshot.Offset, nil
+		}
+	}
+	return calcFirstOffset(ep.ctx, ep.database, ep.conf.firstEvent)
+}
+
+func (ep *eventPoller) restoreSnapshot() error {
+	if ep.conf.snapshotState == nil {
+		return nil
+	}
+	snapshot, err := ep.database.GetSnapshot(ep.ctx, ep.conf.snapshotType, ep.conf.offsetName)
+	if err != nil || snapshot == nil {
+		return err
+	}
+	rewind, offset := ep.conf.restoreSnapshot(snapshot)
+	if rewind && offset < ep.pollingOffset {
+		ep.pollingOffset = offset
+	}
+	log.L(ep.ctx).Infof("Restored snapshot %s taken at %s (offset=%d rewind=%t)", snapshot.ID, snapshot.Created, snapshot.Offset, rewind)
+	return nil
+}
+
+// maybeSnapshot records the state of the poller, if the snapshot interval has passed since the last one.
+// Must be called from the event polling routine, as the state is not protected by a mutex.
+func (ep *eventPoller) maybeSnapshot(ctx context.Context) error {
+	if ep.conf.snapshotState == nil || ep.conf.snapshotInterval <= 0 || time.Since(ep.lastSnapshot) < ep.conf.snapshotInterval {
+		return nil
+	}
+	snapshot := &fftypes.Snapshot{
+		ID:      fftypes.NewUUID(),
+		Type:    ep.conf.snapshotType,
+		Name:    ep.conf.offsetName,
+		Offset:  ep.pollingOffset,
+		State:   ep.conf.snapshotState(),
+		Created: fftypes.Now(),
+	}
+	if err := ep.database.UpsertSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+	ep.lastSnapshot = time.Now()
+	log.L(ctx).Debugf("Snapshot %s recorded at offset %d", snapshot.ID, snapshot.Offset)
+	return nil
+}
+
 func (ep *eventPoller) start() {
 	err := ep.conf.retry.Do(ep.ctx, "restore offset", func(attempt int) (retry bool, err error) {
 		return true, ep.restoreOffset()
@@ -150,6 +213,9 @@ func (ep *eventPoller) commitOffset(ctx context.Context, offset int64) error {
 		if err := ep.database.UpdateOffset(ctx, ep.offsetID, u); err != nil {
 			return err
 		}
+		if err := ep.maybeSnapshot(ctx); err != nil {
+			return err
+		}
 	}
 	l.Debugf("Event polling offset committed %d", ep.pollingOffset)
 	return nil
@@ -311,3 +311,129 @@ func TestDoubleTap(t *testing.T) {
 	ep.shoulderTap()
 	ep.shoulderTap() // this should not block
 }
+
+func newTestSnapshotEventPoller(t *testing.T, mdi *databasemocks.Plugin, restore func(*fftypes.Snapshot) (bool, int64)) (ep *eventPoller, cancel func()) {
+	ep, cancel = newTestEventPoller(t, mdi, nil, nil)
+	ep.conf.snapshotType = fftypes.SnapshotTypeAggregator
+	ep.conf.snapshotInterval = 1 * time.Millisecond
+	ep.conf.snapshotState = func() fftypes.JSONObject { return fftypes.JSONObject{"some": "state"} }
+	ep.conf.restoreSnapshot = restore
+	return ep, cancel
+}
+
+func TestRestoreOffsetSnapshotRewind(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	var restored *fftypes.Snapshot
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, func(s *fftypes.Snapshot) (bool, int64) {
+		restored = s
+		return true, 100
+	})
+	defer cancel()
+	snapshot := &fftypes.Snapshot{ID: fftypes.NewUUID(), Offset: 12345}
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(&fftypes.Offset{Current: 12345}, nil)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(snapshot, nil)
+	err := ep.restoreOffset()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), ep.pollingOffset)
+	assert.Equal(t, snapshot, restored)
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreOffsetSnapshotNoRewind(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, func(s *fftypes.Snapshot) (bool, int64) {
+		return false, -1
+	})
+	defer cancel()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(&fftypes.Offset{Current: 12345}, nil)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(&fftypes.Snapshot{Offset: 12345}, nil)
+	err := ep.restoreOffset()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), ep.pollingOffset)
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreOffsetSnapshotFail(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, nil)
+	defer cancel()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(&fftypes.Offset{Current: 12345}, nil)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(nil, fmt.Errorf("pop"))
+	err := ep.restoreOffset()
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreOffsetFirstFromSnapshot(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, func(s *fftypes.Snapshot) (bool, int64) {
+		return false, -1
+	})
+	defer cancel()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(nil, nil).Once()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(&fftypes.Offset{Current: 555}, nil).Once()
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(&fftypes.Snapshot{Offset: 555}, nil)
+	mdi.On("UpsertOffset", mock.Anything, mock.MatchedBy(func(offset *fftypes.Offset) bool {
+		return offset.Current == 555
+	}), false).Return(nil)
+	err := ep.restoreOffset()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(555), ep.pollingOffset)
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreOffsetFirstSnapshotFail(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, nil)
+	defer cancel()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(nil, nil)
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(nil, fmt.Errorf("pop"))
+	err := ep.restoreOffset()
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestRestoreOffsetFirstNoSnapshot(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, nil)
+	defer cancel()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(nil, nil).Once()
+	mdi.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "test").Return(&fftypes.Offset{Current: 12345}, nil).Once()
+	mdi.On("GetSnapshot", mock.Anything, fftypes.SnapshotTypeAggregator, "test").Return(nil, nil)
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{{Sequence: 12345}}, nil, nil)
+	mdi.On("UpsertOffset", mock.Anything, mock.Anything, false).Return(nil)
+	err := ep.restoreOffset()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), ep.pollingOffset)
+	mdi.AssertExpectations(t)
+}
+
+func TestCommitOffsetSnapshot(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, nil)
+	defer cancel()
+	ep.lastSnapshot = time.Now().Add(-1 * time.Hour)
+	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertSnapshot", mock.Anything, mock.MatchedBy(func(s *fftypes.Snapshot) bool {
+		return s.Offset == 12345 && s.Name == "test" && s.State.GetString("some") == "state"
+	})).Return(nil).Once()
+	err := ep.commitOffset(ep.ctx, 12345)
+	assert.NoError(t, err)
+	// Not due again yet
+	ep.conf.snapshotInterval = 1 * time.Hour
+	err = ep.commitOffset(ep.ctx, 12346)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCommitOffsetSnapshotFail(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	ep, cancel := newTestSnapshotEventPoller(t, mdi, nil)
+	defer cancel()
+	ep.lastSnapshot = time.Now().Add(-1 * time.Hour)
+	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertSnapshot", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := ep.commitOffset(ep.ctx, 12345)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
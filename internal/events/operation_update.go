@@ -17,25 +17,72 @@
 package events
 
 import (
+	"math/big"
+
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 func (em *eventManager) OperationUpdate(plugin fftypes.Named, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
 	op, err := em.database.GetOperationByID(em.ctx, operationID)
-	if err != nil || op == nil {
+	if err == nil && op == nil {
+		// Batch pins submitted together in a single transaction are tracked by the ID of the submission
+		return em.aggregatedBatchPinUpdate(plugin, operationID, txState, errorMessage, opOutput)
+	}
+	if err != nil {
 		log.L(em.ctx).Warnf("Operation update '%s' ignored, as it was not submitted by this node", operationID)
 		return nil
 	}
+	return em.operationUpdate(plugin, op, txState, errorMessage, opOutput, true)
+}
+
+func (em *eventManager) aggregatedBatchPinUpdate(plugin fftypes.Named, trackingID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
+	fb := database.OperationQueryFactory.NewFilter(em.ctx)
+	ops, _, err := em.database.GetOperations(em.ctx, fb.And(
+		fb.Eq("backendid", trackingID.String()),
+		fb.Eq("type", fftypes.OpTypeBlockchainBatchPin),
+	))
+	if err != nil {
+		log.L(em.ctx).Warnf("Operation update '%s' ignored, as it was not submitted by this node", trackingID)
+		return nil
+	}
+	if len(ops) == 0 {
+		return em.quarantineReceipt(plugin, trackingID.String(), txState, errorMessage, opOutput)
+	}
+	for i, op := range ops {
+		// The fee of the shared transaction is only recorded once, against the first batch
+		if err := em.operationUpdate(plugin, op, txState, errorMessage, opOutput, i == 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func (em *eventManager) operationUpdate(plugin fftypes.Named, op *fftypes.Operation, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject, recordFee bool) error {
 	update := database.OperationQueryFactory.NewUpdate(em.ctx).
 		Set("status", txState).
 		Set("error", errorMessage).
-		Set("output", opOutput)
+		Set("output", txcommon.RedactOperationOutput(op.Type, opOutput))
 	if err := em.database.UpdateOperation(em.ctx, op.ID, update); err != nil {
 		return err
 	}
+	if err := em.operationStatusChanged(op, txState); err != nil {
+		return err
+	}
+	em.recordTransactionCompleted(op, txState)
+
+	// Record the fee from the receipt the first time the operation completes - a redelivered receipt is not counted again
+	if bi, ok := plugin.(blockchain.Plugin); ok && recordFee && op.Transaction != nil && op.Status == fftypes.OpStatusPending && txState != fftypes.OpStatusPending {
+		if fee := bi.GetTransactionFee(op.Namespace, opOutput); fee != nil {
+			if err := em.recordTransactionFee(op.Transaction, fee); err != nil {
+				return err
+			}
+		}
+	}
 
 	// Special handling for OpTypeTokenTransfer, which writes an event when it fails
 	if op.Type == fftypes.OpTypeTokenTransfer && txState == fftypes.OpStatusFailed {
@@ -44,5 +91,83 @@ func (em *eventManager) OperationUpdate(plugin fftypes.Named, operationID *fftyp
 			return err
 		}
 	}
+
+	// Token approvals likewise write an event when they fail
+	if op.Type == fftypes.OpTypeTokenApproval && txState == fftypes.OpStatusFailed {
+		event := fftypes.NewEvent(fftypes.EventTypeApprovalOpFailed, op.Namespace, op.ID)
+		if err := em.database.InsertEvent(em.ctx, event); err != nil {
+			return err
+		}
+	}
+
+	// Contract invocations write an event when they complete, so the result can be awaited
+	if op.Type == fftypes.OpTypeBlockchainInvoke && txState != fftypes.OpStatusPending {
+		eventType := fftypes.EventTypeBlockchainInvokeOpSucceeded
+		if txState == fftypes.OpStatusFailed {
+			eventType = fftypes.EventTypeBlockchainInvokeOpFailed
+		}
+		if err := em.database.InsertEvent(em.ctx, fftypes.NewEvent(eventType, op.Namespace, op.ID)); err != nil {
+			return err
+		}
+	}
+
+	// The linked operations of a token bridge drive it through to completion, or compensation
+	switch op.Type {
+	case fftypes.OpTypeTokenBridgeLock, fftypes.OpTypeTokenBridgeMint, fftypes.OpTypeTokenBridgeUnlock:
+		return em.assets.TokenBridgeOpUpdate(em.ctx, op, txState, errorMessage)
+	}
 	return nil
 }
+
+// operationStatusChanged writes an operation_updated event when an update moves an operation to a new status,
+// so applications can subscribe to the outcome of operations rather than polling them
+func (em *eventManager) operationStatusChanged(op *fftypes.Operation, txState fftypes.OpStatus) error {
+	if op.Status == txState {
+		return nil
+	}
+	return em.database.InsertEvent(em.ctx, fftypes.NewEvent(fftypes.EventTypeOperationUpdated, op.Namespace, op.ID))
+}
+
+// recordTransactionCompleted counts blockchain transactions the first time their operation completes
+func (em *eventManager) recordTransactionCompleted(op *fftypes.Operation, txState fftypes.OpStatus) {
+	if !em.metricsEnabled || op.Status != fftypes.OpStatusPending || txState == fftypes.OpStatusPending {
+		return
+	}
+	switch op.Type {
+	case fftypes.OpTypeBlockchainBatchPin, fftypes.OpTypeBlockchainInvoke:
+		metrics.BlockchainTransactionCompletedCounter.WithLabelValues(op.Namespace, string(op.Type), string(txState)).Inc()
+	}
+}
+
+func addBigInt(a, b *fftypes.BigInt) *fftypes.BigInt {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	default:
+		return (*fftypes.BigInt)(new(big.Int).Add(a.Int(), b.Int()))
+	}
+}
+
+// recordTransactionFee adds the fee to the transaction, as a transaction can be made up of
+// several blockchain operations (such as the linked operations of a token bridge)
+func (em *eventManager) recordTransactionFee(txID *fftypes.UUID, fee *fftypes.TransactionFee) error {
+	tx, err := em.database.GetTransactionByID(em.ctx, txID)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		log.L(em.ctx).Warnf("Fee for transaction '%s' ignored, as the transaction was not found", txID)
+		return nil
+	}
+	if tx.Fee == nil {
+		tx.Fee = fee
+	} else {
+		tx.Fee = &fftypes.TransactionFee{
+			GasUsed: addBigInt(tx.Fee.GasUsed, fee.GasUsed),
+			Amount:  addBigInt(tx.Fee.Amount, fee.Amount),
+		}
+	}
+	return em.database.UpsertTransaction(em.ctx, tx, false)
+}
@@ -20,13 +20,23 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/hyperledger/firefly/mocks/assetmocks"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+func isOperationUpdatedEvent(e *fftypes.Event) bool {
+	return e.Type == fftypes.EventTypeOperationUpdated
+}
+
 func TestOperationUpdateSuccess(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -36,6 +46,7 @@ func TestOperationUpdateSuccess(t *testing.T) {
 	opID := fftypes.NewUUID()
 	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID}, nil)
 	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
 
 	info := fftypes.JSONObject{"some": "info"}
 	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", info)
@@ -45,6 +56,59 @@ func TestOperationUpdateSuccess(t *testing.T) {
 	mbi.AssertExpectations(t)
 }
 
+func TestOperationUpdateTransactionMetrics(t *testing.T) {
+	em, cancel := newTestEventManagerWithMetrics(t)
+	defer cancel()
+	defer metrics.Clear()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{
+		ID:        opID,
+		Namespace: "ns1",
+		Type:      fftypes.OpTypeBlockchainBatchPin,
+		Status:    fftypes.OpStatusPending,
+	}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.BlockchainTransactionCompletedCounter.WithLabelValues("ns1", string(fftypes.OpTypeBlockchainBatchPin), string(fftypes.OpStatusSucceeded))))
+
+	// Operations that are not blockchain transactions are not counted
+	em.recordTransactionCompleted(&fftypes.Operation{Namespace: "ns1", Type: fftypes.OpTypeTokenTransfer, Status: fftypes.OpStatusPending}, fftypes.OpStatusSucceeded)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.BlockchainTransactionCompletedCounter))
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateRedactsOutput(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	config.Set(config.OperationRedactOutput, []string{"blockchain_batch_pin:headers.Authorization"})
+	defer config.Reset()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Type: fftypes.OpTypeBlockchainBatchPin}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.MatchedBy(func(update database.Update) bool {
+		ui, _ := update.Finalize()
+		assert.Equal(t, "output", ui.SetOperations[2].Field)
+		v, _ := ui.SetOperations[2].Value.Value()
+		return string(v.([]byte)) == `{"headers":{"Authorization":"[redacted]"}}`
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+
+	info := fftypes.JSONObject{"headers": fftypes.JSONObject{"Authorization": "Bearer secret"}}
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestOperationUpdateNotFound(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -95,6 +159,7 @@ func TestOperationUpdateTransferFail(t *testing.T) {
 
 	mdi.On("GetOperationByID", em.ctx, opID).Return(op, nil)
 	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
 	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
 		return e.Type == fftypes.EventTypeTransferOpFailed && e.Namespace == "ns1"
 	})).Return(nil)
@@ -122,6 +187,7 @@ func TestOperationUpdateTransferEventFail(t *testing.T) {
 
 	mdi.On("GetOperationByID", em.ctx, opID).Return(op, nil)
 	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
 	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
 		return e.Type == fftypes.EventTypeTransferOpFailed && e.Namespace == "ns1"
 	})).Return(fmt.Errorf("pop"))
@@ -133,3 +199,401 @@ func TestOperationUpdateTransferEventFail(t *testing.T) {
 	mdi.AssertExpectations(t)
 	mbi.AssertExpectations(t)
 }
+
+func TestOperationUpdateApprovalFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:        opID,
+		Type:      fftypes.OpTypeTokenApproval,
+		Namespace: "ns1",
+	}
+
+	mdi.On("GetOperationByID", em.ctx, opID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeApprovalOpFailed && e.Namespace == "ns1"
+	})).Return(nil)
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateApprovalEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:        opID,
+		Type:      fftypes.OpTypeTokenApproval,
+		Namespace: "ns1",
+	}
+
+	mdi.On("GetOperationByID", em.ctx, opID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeApprovalOpFailed && e.Namespace == "ns1"
+	})).Return(fmt.Errorf("pop"))
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", info)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateTokenBridge(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mam := em.assets.(*assetmocks.Manager)
+	mti := &tokenmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		ID:        opID,
+		Type:      fftypes.OpTypeTokenBridgeMint,
+		Namespace: "ns1",
+	}
+
+	mdi.On("GetOperationByID", em.ctx, opID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mam.On("TokenBridgeOpUpdate", em.ctx, op, fftypes.OpStatusFailed, "some error").Return(fmt.Errorf("pop"))
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.OperationUpdate(mti, opID, fftypes.OpStatusFailed, "some error", info)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mam.AssertExpectations(t)
+}
+
+func TestOperationUpdateInvokeEvents(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Type: fftypes.OpTypeBlockchainInvoke}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainInvokeOpSucceeded && e.Reference.Equals(opID)
+	})).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainInvokeOpFailed && e.Reference.Equals(opID)
+	})).Return(nil).Once()
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusPending, "", fftypes.JSONObject{})
+	assert.NoError(t, err)
+	err = em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.NoError(t, err)
+	err = em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "reverted", fftypes.JSONObject{})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateInvokeEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Type: fftypes.OpTypeBlockchainInvoke}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateRecordsFee(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	receipt := fftypes.JSONObject{"gasUsed": "21000"}
+	tx := &fftypes.Transaction{ID: txID}
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: txID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(tx, nil)
+	mdi.On("UpsertTransaction", em.ctx, tx, false).Return(nil)
+	mbi.On("GetTransactionFee", "ns1", receipt).Return(&fftypes.TransactionFee{
+		GasUsed: fftypes.NewBigInt(21000),
+		Amount:  fftypes.NewBigInt(42000),
+	})
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21000), tx.Fee.GasUsed.Int().Int64())
+	assert.Equal(t, int64(42000), tx.Fee.Amount.Int().Int64())
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateAddsFee(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	receipt := fftypes.JSONObject{"gasUsed": "21000"}
+	tx := &fftypes.Transaction{ID: txID, Fee: &fftypes.TransactionFee{
+		GasUsed: fftypes.NewBigInt(50000),
+	}}
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: txID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(tx, nil)
+	mdi.On("UpsertTransaction", em.ctx, tx, false).Return(nil)
+	mbi.On("GetTransactionFee", "ns1", receipt).Return(&fftypes.TransactionFee{
+		GasUsed: fftypes.NewBigInt(21000),
+		Amount:  fftypes.NewBigInt(42000),
+	})
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "reverted", receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(71000), tx.Fee.GasUsed.Int().Int64())
+	assert.Equal(t, int64(42000), tx.Fee.Amount.Int().Int64())
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateFeeIgnoredWhenNotPending(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: fftypes.NewUUID(), Status: fftypes.OpStatusSucceeded}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{"gasUsed": "21000"})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateNoFee(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: fftypes.NewUUID(), Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mbi.On("GetTransactionFee", "ns1", mock.Anything).Return(nil)
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateFeeTxNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: txID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(nil, nil)
+	mbi.On("GetTransactionFee", "ns1", mock.Anything).Return(&fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(21000)})
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateFeeTxLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Namespace: "ns1", Transaction: txID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(nil, fmt.Errorf("pop"))
+	mbi.On("GetTransactionFee", "ns1", mock.Anything).Return(&fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(21000)})
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateAggregatedBatchPins(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	trackingID := fftypes.NewUUID()
+	tx1 := &fftypes.Transaction{ID: fftypes.NewUUID()}
+	ops := []*fftypes.Operation{
+		{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.OpTypeBlockchainBatchPin, Transaction: tx1.ID, Status: fftypes.OpStatusPending},
+		{ID: fftypes.NewUUID(), Namespace: "ns1", Type: fftypes.OpTypeBlockchainBatchPin, Transaction: fftypes.NewUUID(), Status: fftypes.OpStatusPending},
+	}
+	receipt := fftypes.JSONObject{"gasUsed": "21000"}
+	mdi.On("GetOperationByID", em.ctx, trackingID).Return(nil, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(ops, nil, nil)
+	mdi.On("UpdateOperation", em.ctx, ops[0].ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("UpdateOperation", em.ctx, ops[1].ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, tx1.ID).Return(tx1, nil)
+	mdi.On("UpsertTransaction", em.ctx, tx1, false).Return(nil)
+	mbi.On("GetTransactionFee", "ns1", receipt).Return(&fftypes.TransactionFee{
+		GasUsed: fftypes.NewBigInt(21000),
+		Amount:  fftypes.NewBigInt(42000),
+	}).Once()
+
+	err := em.OperationUpdate(mbi, trackingID, fftypes.OpStatusSucceeded, "", receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21000), tx1.Fee.GasUsed.Int().Int64())
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestOperationUpdateAggregatedBatchPinsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	trackingID := fftypes.NewUUID()
+	ops := []*fftypes.Operation{
+		{ID: fftypes.NewUUID(), Type: fftypes.OpTypeBlockchainBatchPin},
+	}
+	mdi.On("GetOperationByID", em.ctx, trackingID).Return(nil, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(ops, nil, nil)
+	mdi.On("UpdateOperation", em.ctx, ops[0].ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.OperationUpdate(mbi, trackingID, fftypes.OpStatusFailed, "some error", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateNoOperations(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("utblockchain")
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(nil, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("InsertUnmatchedReceipt", em.ctx, mock.MatchedBy(func(r *fftypes.UnmatchedReceipt) bool {
+		return r.Plugin == "utblockchain" && r.RequestID == opID.String() && r.Status == fftypes.OpStatusFailed && r.Error == "some error"
+	})).Return(nil)
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", fftypes.JSONObject{})
+	assert.NoError(t, err) // quarantined
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateGetOperationsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(nil, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", fftypes.JSONObject{})
+	assert.NoError(t, err) // ignored
+
+	mdi.AssertExpectations(t)
+}
+
+func TestOperationUpdateNoOperationsQuarantineFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	mbi.On("Name").Return("utblockchain")
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(nil, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("InsertUnmatchedReceipt", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAddBigInt(t *testing.T) {
+	assert.Nil(t, addBigInt(nil, nil))
+	assert.Equal(t, int64(1), addBigInt(fftypes.NewBigInt(1), nil).Int().Int64())
+	assert.Equal(t, int64(2), addBigInt(nil, fftypes.NewBigInt(2)).Int().Int64())
+	assert.Equal(t, int64(3), addBigInt(fftypes.NewBigInt(1), fftypes.NewBigInt(2)).Int().Int64())
+}
+
+func TestOperationUpdateStatusEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	opID := fftypes.NewUUID()
+	mdi.On("GetOperationByID", em.ctx, opID).Return(&fftypes.Operation{ID: opID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("UpdateOperation", em.ctx, opID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(isOperationUpdatedEvent)).Return(fmt.Errorf("pop"))
+
+	err := em.OperationUpdate(mbi, opID, fftypes.OpStatusFailed, "some error", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
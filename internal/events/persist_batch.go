@@ -19,13 +19,30 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX context*/, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, signingKey string) (valid bool, err error) {
+// traceBatchReceived starts a span for processing a batch received from another node,
+// continuing the trace the sending node dispatched the batch in
+func (em *eventManager) traceBatchReceived(batch *fftypes.Batch) trace.Span {
+	_, span := tracing.StartSpan(tracing.ExtractCarrier(em.ctx, batch.Trace), "batch.receive",
+		attribute.String("ff.namespace", batch.Namespace),
+		attribute.String("ff.batch.id", batch.ID.String()),
+	)
+	return span
+}
+
+// persistBatchFromBroadcast verifies the author of a broadcast batch against the key that pinned it, before persisting it.
+// The quarantine details are used if the author is unknown and the unknown author policy is to quarantine the batch,
+// and are nil if the batch is being released from quarantine.
+func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX context*/, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, signingKey string, quarantine *fftypes.BatchQuarantine) (valid bool, err error) {
 	l := log.L(ctx)
 
 	// Verify that we can resolve the signing key back to this identity.
@@ -37,6 +54,7 @@ func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX c
 	}
 
 	// The special case of a root org broadcast is allowed to not have a resolved author, because it's not in the database yet
+	unknownAuthor := false
 	if (resolvedAuthor == "" || resolvedAuthor != batch.Author) || signingKey != batch.Key {
 		if resolvedAuthor == "" && signingKey == batch.Key && em.isRootOrgBroadcast(batch) {
 
@@ -44,6 +62,31 @@ func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX c
 			// identities joining the network
 			l.Infof("New root org broadcast: %s", batch.Author)
 
+		} else if resolvedAuthor == "" && signingKey == batch.Key && em.isPseudonymBroadcast(batch) {
+
+			// A pseudonym is self-signed by its own key, which is not in the database until this definition is processed
+			l.Infof("New pseudonym broadcast: %s", batch.Author)
+
+		} else if resolvedAuthor == "" && signingKey == batch.Key && em.isIdentityClaimBroadcast(batch) {
+
+			// A custom identity claim is self-signed by its own key, which is not resolvable until the parent verifies it
+			l.Infof("New identity claim broadcast: %s", batch.Author)
+
+		} else if resolvedAuthor == "" && signingKey == batch.Key {
+
+			// The author is not in the network map (yet) - the unknown author policy decides what happens
+			unknownAuthor = true
+
+		} else if resolvedAuthor != "" && signingKey == batch.Key && em.isChildOrgKey(ctx, batch, signingKey) {
+
+			// The author pinned the send to the key of an org registered beneath it
+			l.Infof("Batch '%s' from author '%s' signed with child org key '%s'", batch.ID, batch.Author, signingKey)
+
+		} else if resolvedAuthor != "" && signingKey == batch.Key && isDelegatedBatch(batch) {
+
+			// The signer sent on behalf of the author - each message is checked against its delegation during aggregation
+			l.Infof("Batch '%s' from author '%s' signed with delegated key '%s'", batch.ID, batch.Author, signingKey)
+
 		} else {
 
 			l.Errorf("Invalid batch '%s'. Key/author in batch '%s' / '%s' does not match resolved key/author '%s' / '%s'", batch.ID, batch.Key, batch.Author, signingKey, resolvedAuthor)
@@ -57,10 +100,49 @@ func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX c
 		return false, nil // This is not retryable. skip this batch
 	}
 
+	if unknownAuthor {
+		action := em.unknownAuthors.actionFor(batch.Namespace)
+		if action == unknownAuthorActionQuarantine && quarantine == nil {
+			// The batch has already been released from quarantine
+			action = unknownAuthorActionAccept
+		}
+		switch action {
+		case unknownAuthorActionAccept:
+			l.Warnf("Accepting batch '%s' from unknown author '%s' with key '%s'", batch.ID, batch.Author, signingKey)
+		case unknownAuthorActionQuarantine:
+			// The pins are stored, and parked until the identity arrives
+			quarantine.Reason = fmt.Sprintf("author '%s' with key '%s' is not in the network map", batch.Author, signingKey)
+			quarantine.AwaitingIdentity = signingKey
+			return true, em.quarantineBatch(ctx, batch, quarantine)
+		default:
+			l.Errorf("Invalid batch '%s'. Author '%s' with key '%s' is not in the network map", batch.ID, batch.Author, signingKey)
+			return false, nil // This is not retryable. skip this batch
+		}
+	}
+
 	valid, err = em.persistBatch(ctx, batch)
 	return valid, err
 }
 
+func (em *eventManager) isChildOrgKey(ctx context.Context, batch *fftypes.Batch, signingKey string) bool {
+	valid, err := em.identity.VerifySigningKeyAuthor(ctx, signingKey, batch.Author)
+	if err != nil {
+		log.L(ctx).Errorf("Invalid batch '%s'. Failed to verify key '%s' for author '%s': %s", batch.ID, signingKey, batch.Author, err)
+		return false
+	}
+	return valid
+}
+
+// isDelegatedBatch returns true if every message in the batch is sent on behalf of its author, under a delegation
+func isDelegatedBatch(batch *fftypes.Batch) bool {
+	for _, msg := range batch.Payload.Messages {
+		if msg == nil || msg.Header.Delegation == nil {
+			return false
+		}
+	}
+	return len(batch.Payload.Messages) > 0
+}
+
 func (em *eventManager) isRootOrgBroadcast(batch *fftypes.Batch) bool {
 	// Look into batch to see if it contains a message that contains a data item that is a root organization definition
 	if len(batch.Payload.Messages) > 0 {
@@ -89,6 +171,46 @@ func (em *eventManager) isRootOrgBroadcast(batch *fftypes.Batch) bool {
 	return false
 }
 
+// selfSignedDefinitionData returns the data of the definition message at the start of the batch, if it has the supplied tag
+func selfSignedDefinitionData(batch *fftypes.Batch, tag fftypes.SystemTag) *fftypes.Data {
+	if len(batch.Payload.Messages) > 0 && len(batch.Payload.Data) > 0 {
+		message := batch.Payload.Messages[0]
+		batchDataItem := batch.Payload.Data[0]
+		if message.Header.Type == fftypes.MessageTypeDefinition &&
+			message.Header.Tag == string(tag) &&
+			len(message.Data) > 0 && batchDataItem.ID.Equals(message.Data[0].ID) {
+			return batchDataItem
+		}
+	}
+	return nil
+}
+
+func (em *eventManager) isPseudonymBroadcast(batch *fftypes.Batch) bool {
+	// Look into batch to see if it contains a message that contains a data item that is a pseudonym definition,
+	// signed by the pseudonymous key it registers
+	if batchDataItem := selfSignedDefinitionData(batch, fftypes.SystemTagDefinePseudonym); batchDataItem != nil {
+		var pseudonym *fftypes.Pseudonym
+		if err := json.Unmarshal(batchDataItem.Value, &pseudonym); err != nil {
+			return false
+		}
+		return pseudonym != nil && pseudonym.ID != nil && pseudonym.Key == batch.Key && pseudonym.GetDID() == batch.Author
+	}
+	return false
+}
+
+func (em *eventManager) isIdentityClaimBroadcast(batch *fftypes.Batch) bool {
+	// Look into batch to see if it contains a message that contains a data item that is the claim of a custom identity,
+	// signed by the key of that identity
+	if batchDataItem := selfSignedDefinitionData(batch, fftypes.SystemTagDefineIdentityClaim); batchDataItem != nil {
+		var identity *fftypes.CustomIdentity
+		if err := json.Unmarshal(batchDataItem.Value, &identity); err != nil {
+			return false
+		}
+		return identity != nil && identity.ID != nil && identity.Key == batch.Key && identity.GetDID() == batch.Author
+	}
+	return false
+}
+
 // persistBatch performs very simple validation on each message/data element (hashes) and either persists
 // or discards them. Errors are returned only in the case of database failures, which should be retried.
 func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, batch *fftypes.Batch) (valid bool, err error) {
@@ -101,12 +223,19 @@ func (em *eventManager) persistBatch(ctx context.Context /* db TX context*/, bat
 	}
 
 	// Verify the hash calculation
-	hash := batch.Payload.Hash()
+	hash, err := batch.CalcHash(ctx)
+	if err != nil {
+		l.Errorf("Invalid batch '%s': %s", batch.ID, err)
+		return false, nil // This is not retryable. skip this batch
+	}
 	if batch.Hash == nil || *batch.Hash != *hash {
 		l.Errorf("Invalid batch '%s'. Hash does not match payload. Found=%s Expected=%s", batch.ID, hash, batch.Hash)
 		return false, nil // This is not retryable. skip this batch
 	}
 
+	// The manifest is always derived from the verified payload, rather than trusting any supplied by the sender
+	batch.Manifest = batch.Payload.Manifest()
+
 	// Set confirmed on the batch (the messages should not be confirmed at this point - that's the aggregator's job)
 	batch.Confirmed = now
 
@@ -227,6 +356,9 @@ func (em *eventManager) persistReceivedMessage(ctx context.Context /* db TX cont
 		l.Errorf("Failed to insert message entry %d in %s '%s': %s", i, mType, mID, err)
 		return false, err // a persistence failure here is considered retryable (so returned)
 	}
+	if err = em.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, fmt.Sprintf("received in %s %s", mType, mID))); err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
@@ -17,10 +17,12 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -28,6 +30,21 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+func TestTraceBatchReceived(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	err := tracing.Init(context.Background())
+	assert.NoError(t, err)
+
+	span := em.traceBatchReceived(&fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Trace:     map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	})
+	defer span.End()
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+}
+
 func TestPersistBatchFromBroadcastRootOrg(t *testing.T) {
 
 	em, cancel := newTestEventManager(t)
@@ -88,7 +105,7 @@ func TestPersistBatchFromBroadcastRootOrg(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	_, err = em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	_, err = em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
 	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
 
 }
@@ -141,7 +158,221 @@ func TestPersistBatchFromBroadcastRootOrgBadData(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+}
+
+func testPseudonymBatch(t *testing.T, pseudonym *fftypes.Pseudonym, value fftypes.Byteable) *fftypes.Batch {
+	if value == nil {
+		b, err := json.Marshal(&pseudonym)
+		assert.NoError(t, err)
+		value = b
+	}
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Value:     value,
+		Validator: fftypes.MessageTypeDefinition,
+	}
+	identity := fftypes.Identity{
+		Author: pseudonym.GetDID(),
+		Key:    "0x12345",
+	}
+	batch := &fftypes.Batch{
+		ID:       fftypes.NewUUID(),
+		Identity: identity,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.TransactionTypeBatchPin,
+			},
+			Messages: []*fftypes.Message{
+				{
+					Header: fftypes.MessageHeader{
+						ID:       fftypes.NewUUID(),
+						Type:     fftypes.MessageTypeDefinition,
+						Tag:      string(fftypes.SystemTagDefinePseudonym),
+						Identity: identity,
+					},
+					Data: fftypes.DataRefs{
+						{
+							ID:   data.ID,
+							Hash: data.Hash,
+						},
+					},
+				},
+			},
+			Data: []*fftypes.Data{
+				data,
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	return batch
+}
+
+func TestPersistBatchFromBroadcastPseudonym(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := testPseudonymBatch(t, &fftypes.Pseudonym{
+		ID:  fftypes.NewUUID(),
+		Key: "0x12345",
+	}, nil)
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
+}
+
+func TestPersistBatchFromBroadcastPseudonymWrongKey(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	batch := testPseudonymBatch(t, &fftypes.Pseudonym{
+		ID:  fftypes.NewUUID(),
+		Key: "0xabcde",
+	}, nil)
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+}
+
+func TestPersistBatchFromBroadcastPseudonymBadData(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	batch := testPseudonymBatch(t, &fftypes.Pseudonym{
+		ID:  fftypes.NewUUID(),
+		Key: "0x12345",
+	}, fftypes.Byteable("!badness"))
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+}
+
+func testIdentityClaimBatch(t *testing.T, identity *fftypes.CustomIdentity, value fftypes.Byteable) *fftypes.Batch {
+	if value == nil {
+		b, err := json.Marshal(&identity)
+		assert.NoError(t, err)
+		value = b
+	}
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Value:     value,
+		Validator: fftypes.MessageTypeDefinition,
+	}
+	signer := fftypes.Identity{
+		Author: identity.GetDID(),
+		Key:    "0x12345",
+	}
+	batch := &fftypes.Batch{
+		ID:       fftypes.NewUUID(),
+		Identity: signer,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.TransactionTypeBatchPin,
+			},
+			Messages: []*fftypes.Message{
+				{
+					Header: fftypes.MessageHeader{
+						ID:       fftypes.NewUUID(),
+						Type:     fftypes.MessageTypeDefinition,
+						Tag:      string(fftypes.SystemTagDefineIdentityClaim),
+						Identity: signer,
+					},
+					Data: fftypes.DataRefs{
+						{
+							ID:   data.ID,
+							Hash: data.Hash,
+						},
+					},
+				},
+			},
+			Data: []*fftypes.Data{
+				data,
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	return batch
+}
+
+func TestPersistBatchFromBroadcastIdentityClaim(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := testIdentityClaimBatch(t, &fftypes.CustomIdentity{
+		ID:  fftypes.NewUUID(),
+		Key: "0x12345",
+	}, nil)
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
+}
+
+func TestPersistBatchFromBroadcastIdentityClaimWrongKey(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	batch := testIdentityClaimBatch(t, &fftypes.CustomIdentity{
+		ID:  fftypes.NewUUID(),
+		Key: "0xabcde",
+	}, nil)
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+}
+
+func TestPersistBatchFromBroadcastIdentityClaimBadData(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	batch := testIdentityClaimBatch(t, &fftypes.CustomIdentity{
+		ID:  fftypes.NewUUID(),
+		Key: "0x12345",
+	}, fftypes.Byteable("!badness"))
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
 	assert.NoError(t, err)
 	assert.False(t, valid)
 
@@ -163,8 +394,225 @@ func TestPersistBatchFromBroadcastNoRootOrgBadIdentity(t *testing.T) {
 	}
 	batch.Hash = batch.Payload.Hash()
 
-	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345")
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+}
+
+func TestPersistBatchFromBroadcastChildOrgKey(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("did:firefly:org/child", nil)
+	mim.On("VerifySigningKeyAuthor", em.ctx, "0x12345", "did:firefly:org/parent").Return(true, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/parent",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
+	mim.AssertExpectations(t)
+}
+
+func TestPersistBatchFromBroadcastChildOrgKeyVerifyFail(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("did:firefly:org/child", nil)
+	mim.On("VerifySigningKeyAuthor", em.ctx, "0x12345", "did:firefly:org/parent").Return(false, fmt.Errorf("pop"))
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/parent",
+			Key:    "0x12345",
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	mim.AssertExpectations(t)
+}
+
+func TestPersistBatchFromBroadcastDelegatedKey(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("did:firefly:org/delegate", nil)
+	mim.On("VerifySigningKeyAuthor", em.ctx, "0x12345", "did:firefly:org/delegator").Return(false, nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/delegator",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{Delegation: fftypes.NewUUID()}},
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
+	mim.AssertExpectations(t)
+}
+
+func TestPersistBatchFromBroadcastDelegatedKeyMissingDelegation(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, "0x12345").Return("did:firefly:org/delegate", nil)
+	mim.On("VerifySigningKeyAuthor", em.ctx, "0x12345", "did:firefly:org/delegator").Return(false, nil)
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/delegator",
+			Key:    "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{Delegation: fftypes.NewUUID()}},
+				{Header: fftypes.MessageHeader{}},
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
 	assert.NoError(t, err)
 	assert.False(t, valid)
 
+	mim.AssertExpectations(t)
+}
+
+func TestPersistBatchFromBroadcastUnknownAuthorAccepted(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.action = unknownAuthorActionAccept
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Key: "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{})
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
+}
+
+func TestPersistBatchFromBroadcastUnknownAuthorQuarantined(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.action = unknownAuthorActionQuarantine
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Identity: fftypes.Identity{
+			Author: "did:firefly:org/unknown",
+			Key:    "0x12345",
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchQuarantineByID", em.ctx, batch.ID).Return(nil, nil)
+	mdi.On("InsertBatchQuarantine", em.ctx, mock.MatchedBy(func(q *fftypes.BatchQuarantine) bool {
+		return q.PayloadRef == "ref1" && q.AwaitingIdentity == "0x12345" &&
+			q.Reason == "author 'did:firefly:org/unknown' with key '0x12345' is not in the network map"
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+
+	valid, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", &fftypes.BatchQuarantine{PayloadRef: "ref1"})
+	assert.NoError(t, err)
+	assert.True(t, valid) // the pins are stored, and parked
+
+	mdi.AssertExpectations(t)
+}
+
+func TestPersistBatchFromBroadcastUnknownAuthorReleased(t *testing.T) {
+
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.action = unknownAuthorActionQuarantine
+
+	mim := em.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKeyIdentity", em.ctx, mock.Anything).Return("", nil)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, false).Return(fmt.Errorf(("pop")))
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Identity: fftypes.Identity{
+			Key: "0x12345",
+		},
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+
+	_, err := em.persistBatchFromBroadcast(em.ctx, batch, batch.Hash, "0x12345", nil)
+	assert.EqualError(t, err, "pop") // Confirms we got to upserting the batch
+
 }
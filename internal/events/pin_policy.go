@@ -0,0 +1,254 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+type pinPolicyAction string
+
+const (
+	// pinPolicyActionNone disables verification of batch pin signers during aggregation
+	pinPolicyActionNone pinPolicyAction = "none"
+	// pinPolicyActionReject rejects messages in batches pinned by a key that is not permitted
+	pinPolicyActionReject pinPolicyAction = "reject"
+	// pinPolicyActionQuarantine leaves messages in batches pinned by a key that is not permitted pending, blocking their context,
+	// until an operator decides the pin quarantine recorded for the message
+	pinPolicyActionQuarantine pinPolicyAction = "quarantine"
+)
+
+// pinPolicyRule restricts the identities that can send on a namespace, or on specific topics in a namespace.
+// Identities can be specified as either the name, or the signing key, of a registered organization.
+type pinPolicyRule struct {
+	namespace  string
+	topics     []string
+	identities []string
+}
+
+type pinPolicy struct {
+	action pinPolicyAction
+	rules  []*pinPolicyRule
+}
+
+func newPinPolicy(ctx context.Context) *pinPolicy {
+	pp := &pinPolicy{
+		action: pinPolicyAction(strings.ToLower(config.GetString(config.EventAggregatorPinPolicyAction))),
+	}
+	switch pp.action {
+	case "":
+		pp.action = pinPolicyActionNone
+	case pinPolicyActionNone, pinPolicyActionReject, pinPolicyActionQuarantine:
+	default:
+		log.L(ctx).Errorf("Unknown pin policy action '%s' - rejecting all messages that violate the pin policy", pp.action)
+		pp.action = pinPolicyActionReject
+	}
+	for _, ruleConf := range config.GetObjectArray(config.EventAggregatorPinPolicyRules) {
+		rule := &pinPolicyRule{
+			namespace:  ruleConf.GetString("namespace"),
+			identities: ruleConf.GetStringArray("identities"),
+		}
+		if _, ok := ruleConf["topics"]; ok {
+			rule.topics = ruleConf.GetStringArray("topics")
+		}
+		pp.rules = append(pp.rules, rule)
+	}
+	return pp
+}
+
+func (r *pinPolicyRule) matches(msg *fftypes.Message) bool {
+	if r.namespace != msg.Header.Namespace {
+		return false
+	}
+	if len(r.topics) == 0 {
+		return true
+	}
+	for _, topic := range msg.Header.Topics {
+		for _, ruleTopic := range r.topics {
+			if topic == ruleTopic {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *pinPolicyRule) allows(org *fftypes.Organization) bool {
+	for _, identity := range r.identities {
+		if identity == org.Name || identity == org.Identity {
+			return true
+		}
+	}
+	return false
+}
+
+// permits checks every rule for the namespace and topics of the message allows the organization to send it.
+// Any registered organization can send on namespaces and topics that do not have a rule.
+func (pp *pinPolicy) permits(org *fftypes.Organization, msg *fftypes.Message) bool {
+	for _, rule := range pp.rules {
+		if rule.matches(msg) && !rule.allows(org) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPinPolicy verifies that the key that signed the batch pin on-chain resolves to a registered organization,
+// that is permitted by the pin policy to send the message. If not, a policy violation event is emitted and the
+// configured action is returned (pinPolicyActionNone is returned for permitted messages).
+func (ag *aggregator) checkPinPolicy(ctx context.Context, batch *fftypes.Batch, msg *fftypes.Message) (pinPolicyAction, error) {
+	if ag.pinPolicy.action == pinPolicyActionNone {
+		return pinPolicyActionNone, nil
+	}
+
+	// Organization definitions are allowed through, as the first broadcast of a root org is signed by a key that is
+	// not yet registered. The definition handler performs its own verification of the parent/signing key.
+	if msg.Header.Type == fftypes.MessageTypeDefinition && msg.Header.Tag == string(fftypes.SystemTagDefineOrganization) {
+		return pinPolicyActionNone, nil
+	}
+
+	// The signer of the transaction is the key that pinned the batch on-chain
+	tx, err := ag.database.GetTransactionByID(ctx, batch.Payload.TX.ID)
+	if err != nil {
+		return "", err
+	}
+	signer := ""
+	if tx != nil {
+		signer = tx.Subject.Signer
+	}
+	var org *fftypes.Organization
+	if signer != "" {
+		if org, err = ag.database.GetOrganizationByIdentity(ctx, signer); err != nil {
+			return "", err
+		}
+	}
+	if org != nil && ag.pinPolicy.permits(org, msg) {
+		return pinPolicyActionNone, nil
+	}
+
+	if ag.pinPolicy.action == pinPolicyActionQuarantine {
+		return ag.quarantinePin(ctx, batch, msg, signer)
+	}
+	return pinPolicyActionReject, ag.pinPolicyViolation(ctx, batch, msg, signer)
+}
+
+// quarantinePin records a pin quarantine for the message the first time it is processed, and on each subsequent
+// pass applies the decision of the operator - dispatching the message if approved, or rejecting it if rejected.
+// A message is processed again every time the aggregator rewinds to its pin, so the violation is recorded only once.
+func (ag *aggregator) quarantinePin(ctx context.Context, batch *fftypes.Batch, msg *fftypes.Message, signer string) (pinPolicyAction, error) {
+	quarantine, err := ag.database.GetPinQuarantineByID(ctx, msg.Header.ID)
+	if err != nil {
+		return "", err
+	}
+	if quarantine != nil {
+		switch quarantine.Status {
+		case fftypes.PolicyApprovalStatusApproved:
+			log.L(ctx).Infof("Pin policy quarantine of message %s was approved by '%s'", msg.Header.ID, quarantine.DecidedBy)
+			return pinPolicyActionNone, nil
+		case fftypes.PolicyApprovalStatusRejected:
+			return pinPolicyActionReject, nil
+		default:
+			log.L(ctx).Debugf("Message %s is awaiting a decision on its pin policy quarantine", msg.Header.ID)
+			return pinPolicyActionQuarantine, nil
+		}
+	}
+
+	if err = ag.database.InsertPinQuarantine(ctx, &fftypes.PinQuarantine{
+		ID:        msg.Header.ID,
+		Namespace: msg.Header.Namespace,
+		Batch:     batch.ID,
+		Signer:    signer,
+		Status:    fftypes.PolicyApprovalStatusPending,
+		Created:   fftypes.Now(),
+	}); err != nil {
+		return "", err
+	}
+	return pinPolicyActionQuarantine, ag.pinPolicyViolation(ctx, batch, msg, signer)
+}
+
+func (ag *aggregator) pinPolicyViolation(ctx context.Context, batch *fftypes.Batch, msg *fftypes.Message, signer string) error {
+	log.L(ctx).Warnf("Pin policy violation for message %s in batch %s signed by '%s' (action=%s)", msg.Header.ID, batch.ID, signer, ag.pinPolicy.action)
+	event := fftypes.NewEvent(fftypes.EventTypePinPolicyViolation, msg.Header.Namespace, msg.Header.ID)
+	return ag.database.InsertEvent(ctx, event)
+}
+
+func (em *eventManager) GetPinQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.PinQuarantine, *database.FilterResult, error) {
+	return em.database.GetPinQuarantines(ctx, filter)
+}
+
+func (em *eventManager) GetPinQuarantineByID(ctx context.Context, id string) (*fftypes.PinQuarantine, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return em.database.GetPinQuarantineByID(ctx, u)
+}
+
+// DecidePinQuarantine approves or rejects a message quarantined by the pin policy. The aggregator is notified
+// to process the pins of the batch again, so the message is dispatched (if approved) or rejected, and the
+// context it was blocking moves on.
+func (em *eventManager) DecidePinQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.PinQuarantine, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	status := decision.Status.Lower()
+	if status != fftypes.PolicyApprovalStatusApproved && status != fftypes.PolicyApprovalStatusRejected {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalBadDecision, fftypes.PolicyApprovalStatusApproved, fftypes.PolicyApprovalStatusRejected)
+	}
+	if decision.DecidedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "decidedBy")
+	}
+
+	var quarantine *fftypes.PinQuarantine
+	err = em.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		quarantine, err = em.database.GetPinQuarantineByID(ctx, u)
+		if err != nil {
+			return err
+		}
+		if quarantine == nil {
+			return i18n.NewError(ctx, i18n.MsgPinQuarantineNotFound, u)
+		}
+		if quarantine.Status != fftypes.PolicyApprovalStatusPending {
+			return i18n.NewError(ctx, i18n.MsgPinQuarantineNotPending, u, quarantine.Status)
+		}
+
+		quarantine.Status = status
+		quarantine.Decided = fftypes.Now()
+		quarantine.DecidedBy = decision.DecidedBy
+		quarantine.Comment = decision.Comment
+		update := database.PinQuarantineQueryFactory.NewUpdate(ctx).
+			Set("status", quarantine.Status).
+			Set("decided", quarantine.Decided).
+			Set("decidedby", quarantine.DecidedBy).
+			Set("comment", quarantine.Comment)
+		return em.database.UpdatePinQuarantine(ctx, quarantine.ID, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	em.aggregator.offchainBatches <- quarantine.Batch
+	log.L(ctx).Infof("Pin quarantine for message '%s' decided status=%s by '%s'", u, quarantine.Status, quarantine.DecidedBy)
+	return quarantine, nil
+}
@@ -0,0 +1,480 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPinPolicyAggregator(action pinPolicyAction, rules fftypes.JSONObjectArray) (*aggregator, func()) {
+	config.Reset()
+	config.Set(config.EventAggregatorPinPolicyAction, string(action))
+	config.Set(config.EventAggregatorPinPolicyRules, rules)
+	return newTestAggregator()
+}
+
+func newTestPinPolicyBatch() (*fftypes.Batch, *fftypes.Message) {
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Topics:    fftypes.FFNameArray{"topic1"},
+		},
+	}
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			TX:       fftypes.TransactionRef{ID: fftypes.NewUUID()},
+			Messages: []*fftypes.Message{msg},
+		},
+	}
+	return batch, msg
+}
+
+func TestNewPinPolicy(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator("Quarantine", fftypes.JSONObjectArray{
+		{"namespace": "ns1", "identities": []string{"org1"}},
+		{"namespace": "ns1", "topics": []string{"topic1"}, "identities": []string{"0x12345"}},
+	})
+	defer cancel()
+	assert.Equal(t, pinPolicyActionQuarantine, ag.pinPolicy.action)
+	assert.Equal(t, []*pinPolicyRule{
+		{namespace: "ns1", identities: []string{"org1"}},
+		{namespace: "ns1", topics: []string{"topic1"}, identities: []string{"0x12345"}},
+	}, ag.pinPolicy.rules)
+}
+
+func TestNewPinPolicyUnknownAction(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator("unknown", fftypes.JSONObjectArray{})
+	defer cancel()
+	assert.Equal(t, pinPolicyActionReject, ag.pinPolicy.action)
+}
+
+func TestNewPinPolicyUnset(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorPinPolicyAction, "")
+	ag, cancel := newTestAggregator()
+	defer cancel()
+	assert.Equal(t, pinPolicyActionNone, ag.pinPolicy.action)
+}
+
+func TestPinPolicyPermits(t *testing.T) {
+	pp := &pinPolicy{
+		rules: []*pinPolicyRule{
+			{namespace: "ns1", identities: []string{"org1", "org2"}},
+			{namespace: "ns1", topics: []string{"topic1"}, identities: []string{"0x11111"}},
+		},
+	}
+	org1 := &fftypes.Organization{Name: "org1", Identity: "0x11111"}
+	org2 := &fftypes.Organization{Name: "org2", Identity: "0x22222"}
+	org3 := &fftypes.Organization{Name: "org3", Identity: "0x33333"}
+	msg := func(ns string, topics ...string) *fftypes.Message {
+		return &fftypes.Message{Header: fftypes.MessageHeader{Namespace: ns, Topics: topics}}
+	}
+
+	assert.True(t, pp.permits(org1, msg("ns1", "topic1")))
+	assert.False(t, pp.permits(org2, msg("ns1", "topic1")))
+	assert.True(t, pp.permits(org2, msg("ns1", "topic2")))
+	assert.False(t, pp.permits(org3, msg("ns1", "topic2")))
+	assert.True(t, pp.permits(org3, msg("ns2", "topic1")))
+}
+
+func TestCheckPinPolicyDisabled(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionNone, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionNone, action)
+}
+
+func TestCheckPinPolicyOrgDefinition(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+	msg.Header.Type = fftypes.MessageTypeDefinition
+	msg.Header.Tag = string(fftypes.SystemTagDefineOrganization)
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionNone, action)
+}
+
+func TestCheckPinPolicyPermitted(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{
+		{"namespace": "ns1", "identities": []string{"org1"}},
+	})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(&fftypes.Organization{Name: "org1", Identity: "0x12345"}, nil)
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionNone, action)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckPinPolicyNotPermitted(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionQuarantine, fftypes.JSONObjectArray{
+		{"namespace": "ns1", "identities": []string{"org1"}},
+	})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(&fftypes.Organization{Name: "org2", Identity: "0x12345"}, nil)
+	mdi.On("GetPinQuarantineByID", ag.ctx, msg.Header.ID).Return(nil, nil)
+	mdi.On("InsertPinQuarantine", ag.ctx, mock.MatchedBy(func(q *fftypes.PinQuarantine) bool {
+		return *q.ID == *msg.Header.ID && *q.Batch == *batch.ID && q.Signer == "0x12345" && q.Status == fftypes.PolicyApprovalStatusPending
+	})).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePinPolicyViolation && e.Namespace == "ns1" && *e.Reference == *msg.Header.ID
+	})).Return(nil)
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionQuarantine, action)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckPinPolicyMissingTransaction(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(nil, nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionReject, action)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckPinPolicyGetTransactionFail(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckPinPolicyGetOrgFail(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckPinPolicyInsertEventFail(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(nil, nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestProcessMessagePinPolicyReject(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionReject, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(nil, nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePinPolicyViolation
+	})).Return(nil)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageRejected
+	})).Return(nil)
+	mdi.On("SetPinDispatched", ag.ctx, int64(12345)).Return(nil)
+
+	err := ag.processMessage(ag.ctx, batch, false, 12345, msg)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMessagePinPolicyQuarantine(t *testing.T) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionQuarantine, fftypes.JSONObjectArray{})
+	defer cancel()
+	batch, msg := newTestPinPolicyBatch()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil, nil)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(nil, nil)
+	mdi.On("GetPinQuarantineByID", ag.ctx, msg.Header.ID).Return(nil, nil)
+	mdi.On("InsertPinQuarantine", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePinPolicyViolation
+	})).Return(nil)
+
+	err := ag.processMessage(ag.ctx, batch, false, 12345, msg)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func newTestPinQuarantineAggregator(status fftypes.PolicyApprovalStatus) (*aggregator, func(), *fftypes.Batch, *fftypes.Message) {
+	ag, cancel := newTestPinPolicyAggregator(pinPolicyActionQuarantine, fftypes.JSONObjectArray{})
+	batch, msg := newTestPinPolicyBatch()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", ag.ctx, batch.Payload.TX.ID).Return(&fftypes.Transaction{
+		Subject: fftypes.TransactionSubject{Signer: "0x12345"},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", ag.ctx, "0x12345").Return(nil, nil)
+	if status != "" {
+		mdi.On("GetPinQuarantineByID", ag.ctx, msg.Header.ID).Return(&fftypes.PinQuarantine{
+			ID:        msg.Header.ID,
+			Batch:     batch.ID,
+			Status:    status,
+			DecidedBy: "admin1",
+		}, nil)
+	}
+	return ag, cancel, batch, msg
+}
+
+func TestCheckPinPolicyQuarantinePending(t *testing.T) {
+	ag, cancel, batch, msg := newTestPinQuarantineAggregator(fftypes.PolicyApprovalStatusPending)
+	defer cancel()
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionQuarantine, action)
+
+	// The violation is only recorded the first time the message is processed
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.AssertNotCalled(t, "InsertPinQuarantine", mock.Anything, mock.Anything)
+	mdi.AssertNotCalled(t, "InsertEvent", mock.Anything, mock.Anything)
+}
+
+func TestCheckPinPolicyQuarantineApproved(t *testing.T) {
+	ag, cancel, batch, msg := newTestPinQuarantineAggregator(fftypes.PolicyApprovalStatusApproved)
+	defer cancel()
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionNone, action)
+}
+
+func TestCheckPinPolicyQuarantineRejected(t *testing.T) {
+	ag, cancel, batch, msg := newTestPinQuarantineAggregator(fftypes.PolicyApprovalStatusRejected)
+	defer cancel()
+
+	action, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, pinPolicyActionReject, action)
+
+	ag.database.(*databasemocks.Plugin).AssertNotCalled(t, "InsertEvent", mock.Anything, mock.Anything)
+}
+
+func TestCheckPinPolicyQuarantineGetFail(t *testing.T) {
+	ag, cancel, batch, msg := newTestPinQuarantineAggregator("")
+	defer cancel()
+	ag.database.(*databasemocks.Plugin).On("GetPinQuarantineByID", ag.ctx, msg.Header.ID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckPinPolicyQuarantineInsertFail(t *testing.T) {
+	ag, cancel, batch, msg := newTestPinQuarantineAggregator("")
+	defer cancel()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", ag.ctx, msg.Header.ID).Return(nil, nil)
+	mdi.On("InsertPinQuarantine", ag.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := ag.checkPinPolicy(ag.ctx, batch, msg)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetPinQuarantines(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantines", em.ctx, mock.Anything).Return([]*fftypes.PinQuarantine{}, nil, nil)
+
+	fb := database.PinQuarantineQueryFactory.NewFilter(em.ctx)
+	_, _, err := em.GetPinQuarantines(em.ctx, fb.And())
+	assert.NoError(t, err)
+}
+
+func TestGetPinQuarantineByID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	u := fftypes.NewUUID()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", em.ctx, u).Return(&fftypes.PinQuarantine{ID: u}, nil)
+
+	q, err := em.GetPinQuarantineByID(em.ctx, u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, q.ID)
+}
+
+func TestGetPinQuarantineByIDBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.GetPinQuarantineByID(em.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecidePinQuarantineApprove(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := &fftypes.PinQuarantine{ID: fftypes.NewUUID(), Batch: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusPending}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", em.ctx, q.ID).Return(q, nil)
+	mdi.On("UpdatePinQuarantine", em.ctx, q.ID, mock.Anything).Return(nil)
+
+	res, err := em.DecidePinQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    "Approved",
+		DecidedBy: "admin1",
+		Comment:   "new partner",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, res.Status)
+	assert.Equal(t, "admin1", res.DecidedBy)
+	assert.Equal(t, "new partner", res.Comment)
+	assert.NotNil(t, res.Decided)
+	assert.Equal(t, *q.Batch, *<-em.aggregator.offchainBatches)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecidePinQuarantineBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecidePinQuarantine(em.ctx, "bad", &fftypes.PolicyApprovalDecision{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecidePinQuarantineBadStatus(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecidePinQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusPending,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10348", err)
+}
+
+func TestDecidePinQuarantineMissingDecidedBy(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.DecidePinQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status: fftypes.PolicyApprovalStatusRejected,
+	})
+	assert.Regexp(t, "FF10140.*decidedBy", err)
+}
+
+func TestDecidePinQuarantineGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.DecidePinQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDecidePinQuarantineNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", em.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := em.DecidePinQuarantine(em.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10467", err)
+}
+
+func TestDecidePinQuarantineNotPending(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	q := &fftypes.PinQuarantine{ID: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusApproved}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetPinQuarantineByID", em.ctx, q.ID).Return(q, nil)
+
+	_, err := em.DecidePinQuarantine(em.ctx, q.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10468", err)
+	assert.Empty(t, em.aggregator.offchainBatches)
+}
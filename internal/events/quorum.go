@@ -0,0 +1,103 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// finalizeMessage confirms or rejects a message. In quorum mode, valid broadcasts authored by the local org
+// are only confirmed once enough receipts have arrived, and those from other orgs are acknowledged with a receipt.
+func (ag *aggregator) finalizeMessage(ctx context.Context, msg *fftypes.Message, valid bool, cause string) error {
+	if !valid || !ag.quorumEnabled || msg.Header.Type != fftypes.MessageTypeBroadcast {
+		return ag.confirmMessage(ctx, msg, valid, cause)
+	}
+	localOrgDID, err := ag.identity.ResolveLocalOrgDID(ctx)
+	if err != nil {
+		return err
+	}
+	if msg.Header.Author != localOrgDID {
+		ag.receipts = append(ag.receipts, msg)
+		return ag.confirmMessage(ctx, msg, true, cause)
+	}
+	return ag.checkQuorum(ctx, msg)
+}
+
+// quorumRequired is the number of orgs, other than the author, that must send a receipt for a broadcast
+func (ag *aggregator) quorumRequired(ctx context.Context) (int, error) {
+	if ag.quorumSize > 0 {
+		return ag.quorumSize, nil
+	}
+	orgs, _, err := ag.database.GetOrganizations(ctx, database.OrganizationQueryFactory.NewFilter(ctx).Eq("parent", ""))
+	if err != nil {
+		return -1, err
+	}
+	return len(orgs) - 1, nil
+}
+
+// checkQuorum confirms a locally authored broadcast if a quorum of member orgs have sent receipts for it,
+// otherwise marking it as awaiting quorum, to be checked again as each receipt arrives
+func (ag *aggregator) checkQuorum(ctx context.Context, msg *fftypes.Message) error {
+	required, err := ag.quorumRequired(ctx)
+	if err != nil {
+		return err
+	}
+	fb := database.ReceiptQueryFactory.NewFilter(ctx)
+	receipts, _, err := ag.database.GetReceipts(ctx, fb.And(
+		fb.Eq("message", msg.Header.ID),
+		fb.Neq("author", msg.Header.Author),
+	))
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Debugf("Message %s has %d receipts of %d required for quorum", msg.Header.ID, len(receipts), required)
+	if len(receipts) >= required {
+		return ag.confirmMessage(ctx, msg, true, fmt.Sprintf("quorum of %d receipts received", required))
+	}
+	if msg.State == fftypes.MessageStateAwaitingQuorum {
+		return nil
+	}
+	msg.State = fftypes.MessageStateAwaitingQuorum
+	err = ag.database.UpdateMessage(ctx, msg.Header.ID, database.MessageQueryFactory.NewUpdate(ctx).Set("state", msg.State))
+	if err != nil {
+		return err
+	}
+	return ag.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, fmt.Sprintf("%d of %d receipts received", len(receipts), required)))
+}
+
+// sendReceipts acknowledges the broadcasts from other orgs that were confirmed in the last group of pins.
+// Receipts are best-effort, so failures are logged rather than retried.
+func (ag *aggregator) sendReceipts() {
+	ag.dispatchMux.Lock()
+	dispatching := ag.dispatching
+	ag.dispatchMux.Unlock()
+	if !dispatching {
+		ag.receipts = nil
+		return
+	}
+	for _, msg := range ag.receipts {
+		if err := ag.messaging.SendReceipt(ag.ctx, msg); err != nil {
+			log.L(ag.ctx).Errorf("Failed to send receipt for message %s to '%s': %s", msg.Header.ID, msg.Header.Author, err)
+		}
+	}
+	ag.receipts = nil
+}
@@ -0,0 +1,240 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestQuorumAggregator(size int) (*aggregator, func()) {
+	ag, cancel := newTestAggregator()
+	ag.quorumEnabled = true
+	ag.quorumSize = size
+	ag.startDispatch()
+	return ag, cancel
+}
+
+func newTestQuorumMessage(author string) *fftypes.Message {
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.MessageTypeBroadcast,
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: author,
+			},
+		},
+	}
+}
+
+func TestFinalizeMessageQuorumDisabled(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msg := newTestQuorumMessage("org1")
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageConfirmed
+	})).Return(nil)
+
+	err := ag.finalizeMessage(ag.ctx, msg, true, "aggregated")
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestFinalizeMessageQuorumRemoteAuthor(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org2")
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveLocalOrgDID", ag.ctx).Return("org1", nil)
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
+
+	err := ag.finalizeMessage(ag.ctx, msg, true, "aggregated")
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.Message{msg}, ag.receipts)
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestFinalizeMessageQuorumResolveLocalOrgFail(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveLocalOrgDID", ag.ctx).Return("", fmt.Errorf("pop"))
+
+	err := ag.finalizeMessage(ag.ctx, newTestQuorumMessage("org1"), true, "aggregated")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFinalizeMessageQuorumLocalAwaiting(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(2)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org1")
+	mim := ag.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveLocalOrgDID", ag.ctx).Return("org1", nil)
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetReceipts", ag.ctx, mock.Anything).Return([]*fftypes.MessageReceipt{
+		{ID: fftypes.NewUUID()},
+	}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateAwaitingQuorum && transition.Cause == "1 of 2 receipts received"
+	})).Return(nil)
+
+	err := ag.finalizeMessage(ag.ctx, msg, true, "aggregated")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateAwaitingQuorum, msg.State)
+	assert.Empty(t, ag.receipts)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckQuorumAwaitingUpdateFail(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(2)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org1")
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetReceipts", ag.ctx, mock.Anything).Return([]*fftypes.MessageReceipt{}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := ag.checkQuorum(ag.ctx, msg)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckQuorumAlreadyAwaiting(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(2)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org1")
+	msg.State = fftypes.MessageStateAwaitingQuorum
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetReceipts", ag.ctx, mock.Anything).Return([]*fftypes.MessageReceipt{}, nil, nil)
+
+	err := ag.checkQuorum(ag.ctx, msg)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckQuorumAllOrgsReached(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(0)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org1")
+	msg.State = fftypes.MessageStateAwaitingQuorum
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizations", ag.ctx, mock.Anything).Return([]*fftypes.Organization{
+		{Name: "org1"}, {Name: "org2"}, {Name: "org3"},
+	}, nil, nil)
+	mdi.On("GetReceipts", ag.ctx, mock.Anything).Return([]*fftypes.MessageReceipt{
+		{ID: fftypes.NewUUID()}, {ID: fftypes.NewUUID()},
+	}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeMessageConfirmed && *e.Reference == *msg.Header.ID
+	})).Return(nil)
+
+	err := ag.checkQuorum(ag.ctx, msg)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckQuorumGetOrgsFail(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(0)
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizations", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := ag.checkQuorum(ag.ctx, newTestQuorumMessage("org1"))
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckQuorumGetReceiptsFail(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetReceipts", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := ag.checkQuorum(ag.ctx, newTestQuorumMessage("org1"))
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendReceipts(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+
+	msg1 := newTestQuorumMessage("org2")
+	msg2 := newTestQuorumMessage("org3")
+	ag.receipts = []*fftypes.Message{msg1, msg2}
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("SendReceipt", ag.ctx, msg1).Return(fmt.Errorf("pop"))
+	mpm.On("SendReceipt", ag.ctx, msg2).Return(nil)
+
+	ag.sendReceipts()
+	assert.Nil(t, ag.receipts)
+	mpm.AssertExpectations(t)
+}
+
+func TestSendReceiptsStandby(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+	ag.dispatching = false
+
+	ag.receipts = []*fftypes.Message{newTestQuorumMessage("org2")}
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+
+	ag.sendReceipts()
+	assert.Nil(t, ag.receipts)
+	mpm.AssertNotCalled(t, "SendReceipt", mock.Anything, mock.Anything)
+}
+
+func TestProcessPinsDBGroupSendsReceipts(t *testing.T) {
+	ag, cancel := newTestQuorumAggregator(1)
+	defer cancel()
+
+	msg := newTestQuorumMessage("org2")
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("RunAsGroup", ag.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		ag.receipts = append(ag.receipts, msg)
+	}).Return(nil)
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("SendReceipt", ag.ctx, msg).Return(nil)
+
+	_, err := ag.processPinsDBGroup([]fftypes.LocallySequenced{})
+	assert.NoError(t, err)
+	mpm.AssertExpectations(t)
+}
@@ -0,0 +1,28 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import "github.com/hyperledger/firefly/internal/config"
+
+const (
+	// HeartbeatInterval is how often a comment line is sent on an idle stream, to keep proxies from closing it
+	HeartbeatInterval = "heartbeatInterval"
+)
+
+func (s *SSE) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(HeartbeatInterval, "30s")
+}
@@ -0,0 +1,275 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/events"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// SSE is a "connect-in" transport, where a browser application streams the events of a single durable
+// subscription over a long-lived HTTP GET, using the standard EventSource API. The sequence of each event
+// is sent as the SSE event ID, so a reconnecting client resumes after the last event it received.
+type SSE struct {
+	ctx          context.Context
+	capabilities *events.Capabilities
+	callbacks    events.Callbacks
+	connections  map[string]*sseConnection
+	connMux      sync.Mutex
+	heartbeat    time.Duration
+}
+
+type sseConnection struct {
+	connID string
+	events chan *fftypes.EventDelivery
+	closed chan struct{}
+}
+
+// sseStream writes to the client, either over a hijacked connection that is free of the server write timeout,
+// or over a flushed response where hijacking is not possible (such as HTTP/2)
+type sseStream struct {
+	w     *bufio.Writer
+	done  <-chan struct{}
+	close func()
+}
+
+func (s *SSE) Name() string { return "sse" }
+
+func (s *SSE) Init(ctx context.Context, prefix config.Prefix, callbacks events.Callbacks) error {
+	*s = SSE{
+		ctx:          ctx,
+		capabilities: &events.Capabilities{},
+		callbacks:    callbacks,
+		connections:  make(map[string]*sseConnection),
+		heartbeat:    prefix.GetDuration(HeartbeatInterval),
+	}
+	return nil
+}
+
+func (s *SSE) Capabilities() *events.Capabilities {
+	return s.capabilities
+}
+
+func (s *SSE) GetOptionsSchema(ctx context.Context) string {
+	return `{}` // no extra options currently
+}
+
+func (s *SSE) ValidateOptions(options *fftypes.SubscriptionOptions) error {
+	// As with websockets, only the references are streamed - not the full data
+	if options.WithData != nil && *options.WithData {
+		return i18n.NewError(s.ctx, i18n.MsgSSENoData)
+	}
+	forceFalse := false
+	options.WithData = &forceFalse
+	return nil
+}
+
+func (s *SSE) DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery, data []*fftypes.Data) error {
+	s.connMux.Lock()
+	conn, ok := s.connections[connID]
+	s.connMux.Unlock()
+	if ok {
+		select {
+		case conn.events <- event:
+			return nil
+		case <-conn.closed:
+		}
+	}
+	return i18n.NewError(s.ctx, i18n.MsgSSEConnectionNotActive, connID)
+}
+
+// ServeSubscription streams the events of a durable subscription to the client, until it disconnects.
+// Each event is acknowledged once it has been written to the stream.
+func (s *SSE) ServeSubscription(res http.ResponseWriter, req *http.Request, namespace, id string) {
+	ctx := req.Context()
+	if s.connections == nil {
+		writeError(res, http.StatusNotFound, i18n.NewError(ctx, i18n.Msg404NotFound))
+		return
+	}
+	subID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		writeError(res, http.StatusBadRequest, err)
+		return
+	}
+	var lastSequence *int64
+	if lastEventID := req.Header.Get("Last-Event-ID"); lastEventID != "" {
+		sequence, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			writeError(res, http.StatusBadRequest, i18n.NewError(ctx, i18n.MsgSSEBadLastEventID, lastEventID))
+			return
+		}
+		lastSequence = &sequence
+	}
+
+	conn := &sseConnection{
+		connID: fftypes.NewUUID().String(),
+		events: make(chan *fftypes.EventDelivery),
+		closed: make(chan struct{}),
+	}
+	s.connMux.Lock()
+	s.connections[conn.connID] = conn
+	s.connMux.Unlock()
+	defer s.connClosed(conn)
+
+	if err := s.callbacks.RegisterSubscriptionConnection(conn.connID, namespace, subID, auth.RequestIdentity(req), lastSequence); err != nil {
+		status := http.StatusInternalServerError
+		var ffErr i18n.FFError
+		if errors.As(err, &ffErr) {
+			switch ffErr.MessageKey() {
+			case i18n.MsgSSESubscriptionNotFound:
+				status = http.StatusNotFound
+			case i18n.MsgSubscriptionNotOwner:
+				status = http.StatusForbidden
+			}
+		}
+		writeError(res, status, err)
+		return
+	}
+
+	stream, err := openStream(ctx, res)
+	if err != nil {
+		writeError(res, http.StatusInternalServerError, err)
+		return
+	}
+	defer stream.close()
+	log.L(s.ctx).Infof("SSE connection %s streaming subscription %s:%s", conn.connID, namespace, subID)
+	s.streamEvents(conn, stream)
+}
+
+func (s *SSE) streamEvents(conn *sseConnection, stream *sseStream) {
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case event := <-conn.events:
+			if err = stream.writeEvent(event); err == nil {
+				s.callbacks.DeliveryResponse(conn.connID, &fftypes.EventDeliveryResponse{
+					ID:           event.ID,
+					Subscription: event.Subscription,
+				})
+			}
+		case <-heartbeat.C:
+			err = stream.write([]byte(":\n\n"))
+		case <-stream.done:
+			log.L(s.ctx).Infof("SSE connection %s closed by client", conn.connID)
+			return
+		case <-s.ctx.Done():
+			return
+		}
+		if err != nil {
+			log.L(s.ctx).Errorf("SSE connection %s write failed: %s", conn.connID, err)
+			return
+		}
+	}
+}
+
+func (s *SSE) connClosed(conn *sseConnection) {
+	close(conn.closed)
+	s.connMux.Lock()
+	delete(s.connections, conn.connID)
+	s.connMux.Unlock()
+	// Drop lock before calling back
+	s.callbacks.ConnnectionClosed(conn.connID)
+}
+
+func writeError(res http.ResponseWriter, status int, err error) {
+	http.Error(res, err.Error(), status)
+}
+
+func openStream(ctx context.Context, res http.ResponseWriter) (*sseStream, error) {
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+
+	if hijacker, ok := res.(http.Hijacker); ok {
+		netConn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return nil, err
+		}
+		// The stream is long-lived, so must not be bound by the deadlines of the HTTP server
+		_ = netConn.SetDeadline(time.Time{})
+		res.Header().Set("Connection", "close")
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\n")
+		_ = res.Header().Write(rw)
+		_, _ = rw.WriteString("\r\n")
+		// Nothing more is expected from the client, so a read only returns when the connection closes
+		done := make(chan struct{})
+		go func() {
+			_, _ = io.Copy(ioutil.Discard, rw)
+			close(done)
+		}()
+		// A failure to send the headers is detected when the connection closes
+		_ = rw.Flush()
+		return &sseStream{
+			w:     rw.Writer,
+			done:  done,
+			close: func() { _ = netConn.Close() },
+		}, nil
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgSSEStreamingUnsupported)
+	}
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseStream{
+		w:     bufio.NewWriter(&flushWriter{res: res, flusher: flusher}),
+		done:  ctx.Done(),
+		close: func() {},
+	}, nil
+}
+
+// flushWriter flushes the response after every write, so events are not held in the server's buffers
+type flushWriter struct {
+	res     http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (fw *flushWriter) Write(b []byte) (int, error) {
+	n, err := fw.res.Write(b)
+	fw.flusher.Flush()
+	return n, err
+}
+
+func (ss *sseStream) write(b []byte) error {
+	if _, err := ss.w.Write(b); err != nil {
+		return err
+	}
+	return ss.w.Flush()
+}
+
+func (ss *sseStream) writeEvent(event *fftypes.EventDelivery) error {
+	b, _ := json.Marshal(event)
+	return ss.write([]byte(fmt.Sprintf("id: %d\ndata: %s\n\n", event.Sequence, b)))
+}
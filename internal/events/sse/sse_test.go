@@ -0,0 +1,358 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sse
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/mocks/eventsmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestSSE(t *testing.T, cbs *eventsmocks.Callbacks) (*SSE, func()) {
+	config.Reset()
+
+	s := &SSE{}
+	ctx, cancel := context.WithCancel(context.Background())
+	prefix := config.NewPluginConfig("ut.sse")
+	s.InitPrefix(prefix)
+	prefix.Set(HeartbeatInterval, "1h")
+	err := s.Init(ctx, prefix, cbs)
+	assert.NoError(t, err)
+	assert.Equal(t, "sse", s.Name())
+	assert.NotNil(t, s.Capabilities())
+	assert.Equal(t, "{}", s.GetOptionsSchema(ctx))
+	return s, cancel
+}
+
+func newTestServer(s *SSE) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		s.ServeSubscription(res, req, "ns1", strings.TrimPrefix(req.URL.Path, "/"))
+	}))
+}
+
+func testEvent(sequence int64) *fftypes.EventDelivery {
+	return &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID:       fftypes.NewUUID(),
+			Sequence: sequence,
+		},
+		Subscription: fftypes.SubscriptionRef{
+			ID: fftypes.NewUUID(),
+		},
+	}
+}
+
+// deliverOnRegister delivers the event to the connection once it registers, as a dispatcher would
+func deliverOnRegister(s *SSE, cbs *eventsmocks.Callbacks, subID *fftypes.UUID, lastSequence mock.AnythingOfTypeArgument, event *fftypes.EventDelivery) {
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", subID, "", lastSequence).
+		Run(func(args mock.Arguments) {
+			go func() {
+				_ = s.DeliveryRequest(args[0].(string), nil, event, nil)
+			}()
+		}).
+		Return(nil)
+}
+
+type noFlushWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *noFlushWriter) Header() http.Header         { return w.header }
+func (w *noFlushWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *noFlushWriter) WriteHeader(status int)      { w.status = status }
+
+type badHijackWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *badHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, fmt.Errorf("pop")
+}
+
+type failWriter struct{}
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("pop")
+}
+
+func TestValidateOptionsFail(t *testing.T) {
+	s, cancel := newTestSSE(t, &eventsmocks.Callbacks{})
+	defer cancel()
+
+	yes := true
+	err := s.ValidateOptions(&fftypes.SubscriptionOptions{
+		SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
+			WithData: &yes,
+		},
+	})
+	assert.Regexp(t, "FF10400", err)
+}
+
+func TestValidateOptionsOk(t *testing.T) {
+	s, cancel := newTestSSE(t, &eventsmocks.Callbacks{})
+	defer cancel()
+
+	opts := &fftypes.SubscriptionOptions{}
+	err := s.ValidateOptions(opts)
+	assert.NoError(t, err)
+	assert.False(t, *opts.WithData)
+}
+
+func TestStreamHijacked(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+	svr := newTestServer(s)
+	defer svr.Close()
+
+	subID := fftypes.NewUUID()
+	event := testEvent(6)
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", subID, "", mock.MatchedBy(func(seq *int64) bool {
+		return seq != nil && *seq == 5
+	})).Run(func(args mock.Arguments) {
+		go func() {
+			_ = s.DeliveryRequest(args[0].(string), nil, event, nil)
+		}()
+	}).Return(nil)
+	acked := make(chan struct{})
+	cbs.On("DeliveryResponse", mock.Anything, mock.MatchedBy(func(inflight *fftypes.EventDeliveryResponse) bool {
+		return inflight.ID.Equals(event.ID) && inflight.Subscription.ID.Equals(event.Subscription.ID)
+	})).Run(func(args mock.Arguments) {
+		close(acked)
+	})
+	closed := make(chan struct{})
+	cbs.On("ConnnectionClosed", mock.Anything).Run(func(args mock.Arguments) {
+		close(closed)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s", svr.URL, subID), nil)
+	req.Header.Set("Last-Event-ID", "5")
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 6\n", line)
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Contains(t, line, fmt.Sprintf(`"id":"%s"`, event.ID))
+	<-acked
+
+	res.Body.Close()
+	<-closed
+	cbs.AssertExpectations(t)
+}
+
+func TestStreamFlushed(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	subID := fftypes.NewUUID()
+	event := testEvent(1)
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", subID, "", (*int64)(nil)).Run(func(args mock.Arguments) {
+		go func() {
+			_ = s.DeliveryRequest(args[0].(string), nil, event, nil)
+		}()
+	}).Return(nil)
+	ctx, cancelReq := context.WithCancel(context.Background())
+	cbs.On("DeliveryResponse", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		cancelReq()
+	})
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, req, "ns1", subID.String())
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no-cache", res.Header().Get("Cache-Control"))
+	assert.Regexp(t, "^id: 1\ndata: {.*}\n\n$", res.Body.String())
+	assert.True(t, res.Flushed)
+
+	cbs.AssertExpectations(t)
+}
+
+func TestStreamHeartbeatWriteFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+	s.heartbeat = 1
+
+	conn := &sseConnection{connID: "conn1"}
+	s.streamEvents(conn, &sseStream{w: bufio.NewWriter(&failWriter{})})
+}
+
+func TestStreamEventWriteFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	conn := &sseConnection{
+		connID: "conn1",
+		events: make(chan *fftypes.EventDelivery, 1),
+	}
+	conn.events <- testEvent(1)
+	s.streamEvents(conn, &sseStream{w: bufio.NewWriterSize(&failWriter{}, 16)})
+	cbs.AssertNotCalled(t, "DeliveryResponse", mock.Anything, mock.Anything)
+}
+
+func TestStreamPluginClosed(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	cancel()
+
+	s.streamEvents(&sseConnection{connID: "conn1"}, &sseStream{})
+}
+
+func TestServeSubscriptionNotEnabled(t *testing.T) {
+	s := &SSE{}
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", fftypes.NewUUID().String())
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Regexp(t, "FF10109", res.Body.String())
+}
+
+func TestServeSubscriptionBadID(t *testing.T) {
+	s, cancel := newTestSSE(t, &eventsmocks.Callbacks{})
+	defer cancel()
+
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", "!uuid")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestServeSubscriptionBadLastEventID(t *testing.T) {
+	s, cancel := newTestSSE(t, &eventsmocks.Callbacks{})
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Last-Event-ID", "!sequence")
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, req, "ns1", fftypes.NewUUID().String())
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Regexp(t, "FF10401", res.Body.String())
+}
+
+func TestServeSubscriptionNotFound(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	subID := fftypes.NewUUID()
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", subID, "", (*int64)(nil)).
+		Return(i18n.NewError(context.Background(), i18n.MsgSSESubscriptionNotFound, subID, "ns1"))
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", subID.String())
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Empty(t, s.connections)
+}
+
+func TestServeSubscriptionNotOwner(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "user2"}}}}
+	subID := fftypes.NewUUID()
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", subID, "CN=user2", (*int64)(nil)).
+		Return(i18n.NewError(context.Background(), i18n.MsgSubscriptionNotOwner, "ns1", "sub1"))
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, req, "ns1", subID.String())
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Regexp(t, "FF10329", res.Body.String())
+}
+
+func TestServeSubscriptionRegisterFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", mock.Anything, "", (*int64)(nil)).Return(fmt.Errorf("pop"))
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	res := httptest.NewRecorder()
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", fftypes.NewUUID().String())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestServeSubscriptionNoFlush(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", mock.Anything, "", (*int64)(nil)).Return(nil)
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	res := &noFlushWriter{header: http.Header{}}
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", fftypes.NewUUID().String())
+	assert.Equal(t, http.StatusInternalServerError, res.status)
+}
+
+func TestServeSubscriptionHijackFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	s, cancel := newTestSSE(t, cbs)
+	defer cancel()
+
+	cbs.On("RegisterSubscriptionConnection", mock.Anything, "ns1", mock.Anything, "", (*int64)(nil)).Return(nil)
+	cbs.On("ConnnectionClosed", mock.Anything).Return()
+
+	res := &badHijackWriter{ResponseRecorder: httptest.NewRecorder()}
+	s.ServeSubscription(res, httptest.NewRequest(http.MethodGet, "/", nil), "ns1", fftypes.NewUUID().String())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestDeliveryRequestNotActive(t *testing.T) {
+	s, cancel := newTestSSE(t, &eventsmocks.Callbacks{})
+	defer cancel()
+
+	err := s.DeliveryRequest("conn1", nil, testEvent(1), nil)
+	assert.Regexp(t, "FF10399", err)
+
+	conn := &sseConnection{
+		connID: "conn1",
+		events: make(chan *fftypes.EventDelivery),
+		closed: make(chan struct{}),
+	}
+	close(conn.closed)
+	s.connections["conn1"] = conn
+	err = s.DeliveryRequest("conn1", nil, testEvent(1), nil)
+	assert.Regexp(t, "FF10399", err)
+}
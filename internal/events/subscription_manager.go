@@ -18,9 +18,11 @@ package events
 
 import (
 	"context"
+	"fmt"
 	"regexp"
 	"sync"
 
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/definitions"
@@ -43,6 +45,21 @@ type subscription struct {
 	tagFilter          *regexp.Regexp
 	topicsFilter       *regexp.Regexp
 	authorFilter       *regexp.Regexp
+	customFilters      map[string]*regexp.Regexp
+	blockchainFilter   *blockchainEventFilter
+	operationFilter    *operationFilter
+}
+
+type blockchainEventFilter struct {
+	addressFilter *regexp.Regexp
+	nameFilter    *regexp.Regexp
+	paramFilters  map[string]*regexp.Regexp
+}
+
+type operationFilter struct {
+	typeFilter   *regexp.Regexp
+	pluginFilter *regexp.Regexp
+	statusFilter *regexp.Regexp
 }
 
 type connection struct {
@@ -69,6 +86,7 @@ type subscriptionManager struct {
 	deletedSubscriptions      chan *fftypes.UUID
 	cel                       *changeEventListener
 	retry                     retry.Retry
+	started                   bool
 }
 
 func newSubscriptionManager(ctx context.Context, di database.Plugin, dm data.Manager, en *eventNotifier, sh definitions.DefinitionHandlers) (*subscriptionManager, error) {
@@ -132,6 +150,28 @@ func (sm *subscriptionManager) initTransports() error {
 	return nil
 }
 
+// systemEvents returns the internal transport used to dispatch events to listeners within this process
+func (sm *subscriptionManager) systemEvents() (*system.Events, error) {
+	se, ok := sm.transports[system.SystemEventsTransport].(*system.Events)
+	if !ok {
+		return nil, i18n.NewError(sm.ctx, i18n.MsgUnknownEventTransportPlugin, system.SystemEventsTransport)
+	}
+	return se, nil
+}
+
+// reloadTransportConfig re-initializes a transport with the current configuration, if the transport supports it
+func (sm *subscriptionManager) reloadTransportConfig(ctx context.Context, transport string) error {
+	ei, ok := sm.transports[transport]
+	var reloader events.ConfigReloader
+	if ok {
+		reloader, ok = ei.(events.ConfigReloader)
+	}
+	if !ok {
+		return i18n.NewError(ctx, i18n.MsgConfigNotReloadable, fmt.Sprintf("events.%s", transport))
+	}
+	return reloader.ReloadConfig(ctx, config.NewPluginConfig("events").SubPrefix(ei.Name()))
+}
+
 func (sm *subscriptionManager) start() error {
 	fb := database.SubscriptionQueryFactory.NewFilter(sm.ctx)
 	filter := fb.And().Limit(sm.maxSubs)
@@ -153,6 +193,7 @@ func (sm *subscriptionManager) start() error {
 			sm.matchSubToConnLocked(conn, newSub)
 		}
 	}
+	sm.started = true
 	log.L(sm.ctx).Infof("Subscription manager started - loaded %d durable subscriptions", len(sm.durableSubs))
 	go sm.subscriptionEventListener()
 	go sm.cel.changeEventListener()
@@ -307,6 +348,35 @@ func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef
 		}
 	}
 
+	var customFilters map[string]*regexp.Regexp
+	if len(filter.Custom) > 0 {
+		if err := filter.Custom.Validate(ctx, "filter.custom"); err != nil {
+			return nil, err
+		}
+		customFilters = make(map[string]*regexp.Regexp, len(filter.Custom))
+		for k, v := range filter.Custom {
+			fieldName := fmt.Sprintf("filter.custom.%s", k)
+			customFilters[k], err = regexp.Compile(v)
+			if err != nil {
+				return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, fieldName, v)
+			}
+		}
+	}
+
+	var blockchainFilter *blockchainEventFilter
+	if filter.BlockchainEvent != nil {
+		if blockchainFilter, err = parseBlockchainEventFilter(ctx, filter.BlockchainEvent); err != nil {
+			return nil, err
+		}
+	}
+
+	var opFilter *operationFilter
+	if filter.Operation != nil {
+		if opFilter, err = parseOperationFilter(ctx, filter.Operation); err != nil {
+			return nil, err
+		}
+	}
+
 	sub = &subscription{
 		dispatcherElection: make(chan bool, 1),
 		definition:         subDef,
@@ -315,10 +385,63 @@ func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef
 		tagFilter:          tagFilter,
 		topicsFilter:       topicsFilter,
 		authorFilter:       authorFilter,
+		customFilters:      customFilters,
+		blockchainFilter:   blockchainFilter,
+		operationFilter:    opFilter,
 	}
 	return sub, err
 }
 
+func parseBlockchainEventFilter(ctx context.Context, filter *fftypes.BlockchainEventFilter) (bf *blockchainEventFilter, err error) {
+	bf = &blockchainEventFilter{}
+	if filter.Address != "" {
+		bf.addressFilter, err = regexp.Compile(filter.Address)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, "filter.blockchainEvent.address", filter.Address)
+		}
+	}
+	if filter.Name != "" {
+		bf.nameFilter, err = regexp.Compile(filter.Name)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, "filter.blockchainEvent.name", filter.Name)
+		}
+	}
+	if len(filter.Params) > 0 {
+		bf.paramFilters = make(map[string]*regexp.Regexp, len(filter.Params))
+		for k, v := range filter.Params {
+			fieldName := fmt.Sprintf("filter.blockchainEvent.params.%s", k)
+			bf.paramFilters[k], err = regexp.Compile(v)
+			if err != nil {
+				return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, fieldName, v)
+			}
+		}
+	}
+	return bf, nil
+}
+
+func parseOperationFilter(ctx context.Context, filter *fftypes.OperationFilter) (of *operationFilter, err error) {
+	of = &operationFilter{}
+	if filter.Type != "" {
+		of.typeFilter, err = regexp.Compile(filter.Type)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, "filter.operation.type", filter.Type)
+		}
+	}
+	if filter.Plugin != "" {
+		of.pluginFilter, err = regexp.Compile(filter.Plugin)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, "filter.operation.plugin", filter.Plugin)
+		}
+	}
+	if filter.Status != "" {
+		of.statusFilter, err = regexp.Compile(filter.Status)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRegexpCompileFailed, "filter.operation.status", filter.Status)
+		}
+	}
+	return of, nil
+}
+
 func (sm *subscriptionManager) close() {
 	sm.mux.Lock()
 	conns := make([]*connection, 0, len(sm.connections))
@@ -349,7 +472,10 @@ func (sm *subscriptionManager) getCreateConnLocked(ei events.Plugin, connID stri
 func (sm *subscriptionManager) registerConnection(ei events.Plugin, connID string, matcher events.SubscriptionMatcher) error {
 	sm.mux.Lock()
 	defer sm.mux.Unlock()
+	return sm.registerConnectionLocked(ei, connID, matcher)
+}
 
+func (sm *subscriptionManager) registerConnectionLocked(ei events.Plugin, connID string, matcher events.SubscriptionMatcher) error {
 	// Check if there are existing dispatchers
 	conn := sm.getCreateConnLocked(ei, connID)
 	if conn.ei != ei {
@@ -361,7 +487,7 @@ func (sm *subscriptionManager) registerConnection(ei events.Plugin, connID strin
 
 	// Make sure we don't have dispatchers now for any that don't match
 	for subID, d := range conn.dispatchers {
-		if !d.subscription.definition.Ephemeral && !conn.matcher(d.subscription.definition.SubscriptionRef) {
+		if !d.subscription.definition.Ephemeral && !conn.matcher(d.subscription.definition) {
 			d.close()
 			delete(conn.dispatchers, subID)
 		}
@@ -374,12 +500,59 @@ func (sm *subscriptionManager) registerConnection(ei events.Plugin, connID strin
 	return nil
 }
 
+func (sm *subscriptionManager) registerSubscriptionConnection(ei events.Plugin, connID, namespace string, id *fftypes.UUID, identity string, lastSequence *int64) error {
+	// The lock is held until the connection is registered, so no other connection can start consuming
+	// the subscription between the check for an active dispatcher and the offset being moved
+	sm.mux.Lock()
+	defer sm.mux.Unlock()
+	sub, ok := sm.durableSubs[*id]
+	if !ok || sub.definition.Namespace != namespace || sub.definition.Transport != ei.Name() {
+		return i18n.NewError(sm.ctx, i18n.MsgSSESubscriptionNotFound, id, namespace)
+	}
+	if !auth.CanAccessSubscription(identity, sub.definition) {
+		return i18n.NewError(sm.ctx, i18n.MsgSubscriptionNotOwner, sub.definition.Namespace, sub.definition.Name)
+	}
+
+	// The offset is shared by every consumer of the subscription, so it is only moved by the owner,
+	// and only when no other connection is consuming the subscription from its own position
+	if lastSequence != nil {
+		switch {
+		case sub.definition.Owner != identity:
+			log.L(sm.ctx).Warnf("Ignoring last event sequence %d for subscription %s:%s from non-owner '%s' connID=%s", *lastSequence, namespace, id, identity, connID)
+		case sm.isDispatchedLocked(id):
+			log.L(sm.ctx).Warnf("Ignoring last event sequence %d for subscription %s:%s as it is active on another connection connID=%s", *lastSequence, namespace, id, connID)
+		default:
+			log.L(sm.ctx).Infof("Resuming subscription %s:%s after event sequence %d for connID=%s", namespace, id, *lastSequence, connID)
+			if err := sm.database.UpsertOffset(sm.ctx, &fftypes.Offset{
+				Type:    fftypes.OffsetTypeSubscription,
+				Name:    id.String(),
+				Current: *lastSequence,
+			}, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return sm.registerConnectionLocked(ei, connID, func(sub *fftypes.Subscription) bool {
+		return sub.ID.Equals(id)
+	})
+}
+
+func (sm *subscriptionManager) isDispatchedLocked(id *fftypes.UUID) bool {
+	for _, conn := range sm.connections {
+		if _, ok := conn.dispatchers[*id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (sm *subscriptionManager) matchSubToConnLocked(conn *connection, sub *subscription) {
 	if conn == nil || sub == nil || sub.definition == nil || conn.matcher == nil {
 		log.L(sm.ctx).Warnf("Invalid connection/subscription registered: conn=%+v sub=%+v", conn, sub)
 		return
 	}
-	if conn.transport == sub.definition.Transport && conn.matcher(sub.definition.SubscriptionRef) {
+	if conn.transport == sub.definition.Transport && conn.matcher(sub.definition) {
 		if _, ok := conn.dispatchers[*sub.definition.ID]; !ok {
 			dispatcher := newEventDispatcher(sm.ctx, conn.ei, sm.database, sm.data, sm.definitions, conn.id, sub, sm.eventNotifier, sm.cel)
 			conn.dispatchers[*sub.definition.ID] = dispatcher
@@ -392,6 +565,10 @@ func (sm *subscriptionManager) ephemeralSubscription(ei events.Plugin, connID, n
 	sm.mux.Lock()
 	defer sm.mux.Unlock()
 
+	if !sm.started {
+		return i18n.NewError(sm.ctx, i18n.MsgEventDispatchNotStarted)
+	}
+
 	conn := sm.getCreateConnLocked(ei, connID)
 
 	if conn.ei != ei {
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/definitionsmocks"
@@ -52,6 +53,7 @@ func newTestSubManager(t *testing.T, mei *eventsmocks.PluginAll) (*subscriptionM
 	sm.transports = map[string]events.Plugin{
 		"ut": mei,
 	}
+	sm.started = true
 	return sm, cancel
 }
 
@@ -92,11 +94,11 @@ func TestRegisterDurableSubscriptions(t *testing.T) {
 	}
 	be := &boundCallbacks{sm: sm, ei: mei}
 
-	be.RegisterConnection("conn1", func(sr fftypes.SubscriptionRef) bool {
-		return *sr.ID == *sub2
+	be.RegisterConnection("conn1", func(sub *fftypes.Subscription) bool {
+		return *sub.ID == *sub2
 	})
-	be.RegisterConnection("conn2", func(sr fftypes.SubscriptionRef) bool {
-		return *sr.ID == *sub1
+	be.RegisterConnection("conn2", func(sub *fftypes.Subscription) bool {
+		return *sub.ID == *sub1
 	})
 
 	assert.Equal(t, 1, len(sm.connections["conn1"].dispatchers))
@@ -110,6 +112,143 @@ func TestRegisterDurableSubscriptions(t *testing.T) {
 	assert.Nil(t, sm.connections["conn2"])
 }
 
+func TestRegisterSubscriptionConnection(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	sub1 := fftypes.NewUUID()
+	mdi := sm.database.(*databasemocks.Plugin)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{
+		{SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        sub1,
+			Namespace: "ns1",
+		}, Transport: "ut"},
+	}, nil, nil)
+	mdi.On("UpsertOffset", mock.Anything, mock.MatchedBy(func(offset *fftypes.Offset) bool {
+		return offset.Type == fftypes.OffsetTypeSubscription && offset.Name == sub1.String() && offset.Current == 12345
+	}), true).Return(nil)
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	err := sm.start()
+	assert.NoError(t, err)
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	lastSequence := int64(12345)
+	err = be.RegisterSubscriptionConnection("conn1", "ns1", sub1, "", &lastSequence)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sm.connections["conn1"].dispatchers))
+	assert.NotNil(t, sm.connections["conn1"].dispatchers[*sub1])
+
+	be.ConnnectionClosed("conn1")
+	mdi.AssertExpectations(t)
+}
+
+func TestRegisterSubscriptionConnectionNotFound(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	sub1 := fftypes.NewUUID()
+	sm.durableSubs[*sub1] = &subscription{definition: &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: sub1, Namespace: "ns1"},
+		Transport:       "ut",
+	}}
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	err := be.RegisterSubscriptionConnection("conn1", "ns1", fftypes.NewUUID(), "", nil)
+	assert.Regexp(t, "FF10398", err)
+	err = be.RegisterSubscriptionConnection("conn1", "ns2", sub1, "", nil)
+	assert.Regexp(t, "FF10398", err)
+	assert.Nil(t, sm.connections["conn1"])
+}
+
+func TestRegisterSubscriptionConnectionOffsetFail(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	sub1 := fftypes.NewUUID()
+	sm.durableSubs[*sub1] = &subscription{definition: &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: sub1, Namespace: "ns1"},
+		Transport:       "ut",
+	}}
+	mdi := sm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertOffset", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	lastSequence := int64(12345)
+	err := be.RegisterSubscriptionConnection("conn1", "ns1", sub1, "", &lastSequence)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, sm.connections["conn1"])
+}
+
+func TestRegisterSubscriptionConnectionNotOwner(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	sub1 := fftypes.NewUUID()
+	sm.durableSubs[*sub1] = &subscription{definition: &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: sub1, Namespace: "ns1", Name: "sub1"},
+		Transport:       "ut",
+		Owner:           "user1",
+	}}
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	lastSequence := int64(12345)
+	err := be.RegisterSubscriptionConnection("conn1", "ns1", sub1, "user2", &lastSequence)
+	assert.Regexp(t, "FF10329", err)
+	assert.Nil(t, sm.connections["conn1"])
+}
+
+func TestRegisterSubscriptionConnectionAdminIgnoresLastSequence(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	config.Set(config.SubscriptionAdmins, []string{"admin1"})
+
+	sub1 := fftypes.NewUUID()
+	sm.durableSubs[*sub1] = &subscription{definition: &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: sub1, Namespace: "ns1", Name: "sub1"},
+		Transport:       "ut",
+		Owner:           "user1",
+	}}
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	lastSequence := int64(12345)
+	err := be.RegisterSubscriptionConnection("conn1", "ns1", sub1, "admin1", &lastSequence)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sm.connections["conn1"].dispatchers))
+
+	be.ConnnectionClosed("conn1")
+	// No UpsertOffset expected on the mock
+}
+
+func TestRegisterSubscriptionConnectionActiveIgnoresLastSequence(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	sub1 := fftypes.NewUUID()
+	sm.durableSubs[*sub1] = &subscription{definition: &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{ID: sub1, Namespace: "ns1", Name: "sub1"},
+		Transport:       "ut",
+		Owner:           "user1",
+	}}
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	err := be.RegisterSubscriptionConnection("conn1", "ns1", sub1, "user1", nil)
+	assert.NoError(t, err)
+	lastSequence := int64(12345)
+	err = be.RegisterSubscriptionConnection("conn2", "ns1", sub1, "user1", &lastSequence)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sm.connections["conn2"].dispatchers))
+
+	be.ConnnectionClosed("conn1")
+	be.ConnnectionClosed("conn2")
+	// No UpsertOffset expected on the mock
+}
+
 func TestRegisterEphemeralSubscriptions(t *testing.T) {
 	mei := &eventsmocks.PluginAll{}
 	sm, cancel := newTestSubManager(t, mei)
@@ -158,6 +297,21 @@ func TestRegisterEphemeralSubscriptionsFail(t *testing.T) {
 
 }
 
+func TestBoundCallbacksDownloadBLOB(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mdm := sm.data.(*datamocks.Manager)
+	be := &boundCallbacks{sm: sm, ei: mei}
+
+	dataID := fftypes.NewUUID()
+	mdm.On("DownloadBLOB", mock.Anything, "ns1", dataID.String()).Return(nil, fmt.Errorf("pop"))
+
+	_, err := be.DownloadBLOB(context.Background(), "ns1", dataID)
+	assert.Regexp(t, "pop", err)
+	mdm.AssertExpectations(t)
+}
+
 func TestSubManagerBadPlugin(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
@@ -180,6 +334,46 @@ func TestSubManagerTransportInitError(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestReloadTransportConfig(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	mei.On("ReloadConfig", mock.Anything, mock.Anything).Return(nil)
+	err := sm.reloadTransportConfig(sm.ctx, "ut")
+	assert.NoError(t, err)
+	mei.AssertCalled(t, "ReloadConfig", mock.Anything, mock.Anything)
+}
+
+func TestReloadTransportConfigUnknownTransport(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+
+	err := sm.reloadTransportConfig(sm.ctx, "wrong")
+	assert.Regexp(t, "FF10445.*events.wrong", err)
+}
+
+func TestReloadTransportConfigNotSupported(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	sm.transports["ut"] = &eventsmocks.Plugin{}
+
+	err := sm.reloadTransportConfig(sm.ctx, "ut")
+	assert.Regexp(t, "FF10445.*events.ut", err)
+}
+
+func TestSystemEventsTransportMissing(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	sm.transports[system.SystemEventsTransport] = &eventsmocks.Plugin{}
+
+	_, err := sm.systemEvents()
+	assert.Regexp(t, "FF10172.*system", err)
+}
+
 func TestStartSubRestoreFail(t *testing.T) {
 	mei := &eventsmocks.PluginAll{}
 	sm, cancel := newTestSubManager(t, mei)
@@ -325,6 +519,173 @@ func TestCreateSubscriptionBadAuthorFilter(t *testing.T) {
 	assert.Regexp(t, "FF10171.*author", err)
 }
 
+func TestCreateSubscriptionBadCustomFilter(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Custom: fftypes.CustomHeaders{
+				"region": "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.custom.region", err)
+}
+
+func TestCreateSubscriptionBadBlockchainEventFilters(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			BlockchainEvent: &fftypes.BlockchainEventFilter{
+				Address: "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.blockchainEvent.address", err)
+
+	_, err = sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			BlockchainEvent: &fftypes.BlockchainEventFilter{
+				Name: "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.blockchainEvent.name", err)
+
+	_, err = sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			BlockchainEvent: &fftypes.BlockchainEventFilter{
+				Params: map[string]string{
+					"from": "[[[[! badness",
+				},
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.blockchainEvent.params.from", err)
+}
+
+func TestCreateSubscriptionBlockchainEventFilters(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	sub, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			BlockchainEvent: &fftypes.BlockchainEventFilter{
+				Address: "0x1234",
+				Name:    "Changed",
+				Params: map[string]string{
+					"from": "0x5678",
+				},
+			},
+		},
+		Transport: "ut",
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, sub.blockchainFilter.addressFilter)
+	assert.NotNil(t, sub.blockchainFilter.nameFilter)
+	assert.NotNil(t, sub.blockchainFilter.paramFilters["from"])
+}
+
+func TestCreateSubscriptionBadOperationFilters(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Operation: &fftypes.OperationFilter{
+				Type: "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.operation.type", err)
+
+	_, err = sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Operation: &fftypes.OperationFilter{
+				Plugin: "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.operation.plugin", err)
+
+	_, err = sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Operation: &fftypes.OperationFilter{
+				Status: "[[[[! badness",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10171.*filter.operation.status", err)
+}
+
+func TestCreateSubscriptionOperationFilters(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	sub, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Operation: &fftypes.OperationFilter{
+				Type:   "^dataexchange_",
+				Plugin: "https",
+				Status: "Failed",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, sub.operationFilter.typeFilter)
+	assert.NotNil(t, sub.operationFilter.pluginFilter)
+	assert.NotNil(t, sub.operationFilter.statusFilter)
+}
+
+func TestCreateSubscriptionBadCustomFilterKey(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Custom: fftypes.CustomHeaders{
+				"!bad": ".*",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.Regexp(t, "FF10131.*filter.custom", err)
+}
+
+func TestCreateSubscriptionCustomFilter(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	mei.On("ValidateOptions", mock.Anything).Return(nil)
+	sub, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{
+		Filter: fftypes.SubscriptionFilter{
+			Custom: fftypes.CustomHeaders{
+				"region": "^eu-",
+			},
+		},
+		Transport: "ut",
+	})
+	assert.NoError(t, err)
+	assert.True(t, sub.customFilters["region"].MatchString("eu-west"))
+}
+
 func TestDispatchDeliveryResponseOK(t *testing.T) {
 	mei := &eventsmocks.PluginAll{}
 	sm, cancel := newTestSubManager(t, mei)
@@ -374,6 +735,17 @@ func TestDispatchDeliveryResponseInvalidSubscription(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestEphemeralSubscriptionNotStarted(t *testing.T) {
+	mei := &eventsmocks.PluginAll{}
+	sm, cancel := newTestSubManager(t, mei)
+	defer cancel()
+	sm.started = false
+
+	err := sm.ephemeralSubscription(mei, "conn1", "ns1", &fftypes.SubscriptionFilter{}, &fftypes.SubscriptionOptions{})
+	assert.Regexp(t, "FF10466", err)
+	assert.Nil(t, sm.connections["conn1"])
+}
+
 func TestConnIDSafetyChecking(t *testing.T) {
 	mei1 := &eventsmocks.PluginAll{}
 	sm, cancel := newTestSubManager(t, mei1)
@@ -389,7 +761,7 @@ func TestConnIDSafetyChecking(t *testing.T) {
 		dispatchers: map[fftypes.UUID]*eventDispatcher{},
 	}
 
-	err := be2.RegisterConnection("conn1", func(sr fftypes.SubscriptionRef) bool { return true })
+	err := be2.RegisterConnection("conn1", func(sub *fftypes.Subscription) bool { return true })
 	assert.Regexp(t, "FF10190", err)
 
 	err = be2.EphemeralSubscription("conn1", "ns1", &fftypes.SubscriptionFilter{}, &fftypes.SubscriptionOptions{})
@@ -430,8 +802,8 @@ func TestNewDurableSubscriptionUnknownTransport(t *testing.T) {
 		ei:        mei,
 		id:        "conn1",
 		transport: "ut",
-		matcher: func(sr fftypes.SubscriptionRef) bool {
-			return sr.Namespace == "ns1" && sr.Name == "sub1"
+		matcher: func(sub *fftypes.Subscription) bool {
+			return sub.Namespace == "ns1" && sub.Name == "sub1"
 		},
 		dispatchers: map[fftypes.UUID]*eventDispatcher{},
 	}
@@ -462,8 +834,8 @@ func TestNewDurableSubscriptionOK(t *testing.T) {
 		ei:        mei,
 		id:        "conn1",
 		transport: "ut",
-		matcher: func(sr fftypes.SubscriptionRef) bool {
-			return sr.Namespace == "ns1" && sr.Name == "sub1"
+		matcher: func(sub *fftypes.Subscription) bool {
+			return sub.Namespace == "ns1" && sub.Name == "sub1"
 		},
 		dispatchers: map[fftypes.UUID]*eventDispatcher{},
 	}
@@ -510,8 +882,8 @@ func TestUpdatedDurableSubscriptionNoOp(t *testing.T) {
 		ei:        mei,
 		id:        "conn1",
 		transport: "ut",
-		matcher: func(sr fftypes.SubscriptionRef) bool {
-			return sr.Namespace == "ns1" && sr.Name == "sub1"
+		matcher: func(sub *fftypes.Subscription) bool {
+			return sub.Namespace == "ns1" && sub.Name == "sub1"
 		},
 		dispatchers: map[fftypes.UUID]*eventDispatcher{
 			*subID: ed,
@@ -555,8 +927,8 @@ func TestUpdatedDurableSubscriptionOK(t *testing.T) {
 		ei:        mei,
 		id:        "conn1",
 		transport: "ut",
-		matcher: func(sr fftypes.SubscriptionRef) bool {
-			return sr.Namespace == "ns1" && sr.Name == "sub1"
+		matcher: func(sub *fftypes.Subscription) bool {
+			return sub.Namespace == "ns1" && sub.Name == "sub1"
 		},
 		dispatchers: map[fftypes.UUID]*eventDispatcher{
 			*subID: ed,
@@ -578,7 +950,7 @@ func TestMatchedSubscriptionWithLockUnknownTransport(t *testing.T) {
 	defer cancel()
 
 	conn := &connection{
-		matcher: func(sr fftypes.SubscriptionRef) bool { return true },
+		matcher: func(sub *fftypes.Subscription) bool { return true },
 	}
 	sm.matchSubToConnLocked(conn, &subscription{definition: &fftypes.Subscription{Transport: "Wrong!"}})
 	assert.Nil(t, conn.dispatchers)
@@ -622,8 +994,8 @@ func TestDeleteDurableSubscriptionOk(t *testing.T) {
 		ei:        mei,
 		id:        "conn1",
 		transport: "ut",
-		matcher: func(sr fftypes.SubscriptionRef) bool {
-			return sr.Namespace == "ns1" && sr.Name == "sub1"
+		matcher: func(sub *fftypes.Subscription) bool {
+			return sub.Namespace == "ns1" && sub.Name == "sub1"
 		},
 		dispatchers: map[fftypes.UUID]*eventDispatcher{
 			*subID: ed,
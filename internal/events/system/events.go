@@ -55,7 +55,7 @@ func (se *Events) Init(ctx context.Context, prefix config.Prefix, callbacks even
 		connID:       fftypes.ShortID(),
 	}
 	// We have a single logical connection, that matches all subscriptions
-	return callbacks.RegisterConnection(se.connID, func(sr fftypes.SubscriptionRef) bool { return true })
+	return callbacks.RegisterConnection(se.connID, func(sub *fftypes.Subscription) bool { return true })
 }
 
 func (se *Events) Capabilities() *events.Capabilities {
@@ -35,7 +35,7 @@ func newTestEvents(t *testing.T) (se *Events, cancel func()) {
 	cbs := &eventsmocks.Callbacks{}
 	rc := cbs.On("RegisterConnection", mock.Anything, mock.Anything).Return(nil)
 	rc.RunFn = func(a mock.Arguments) {
-		assert.Equal(t, true, a[1].(events.SubscriptionMatcher)(fftypes.SubscriptionRef{}))
+		assert.Equal(t, true, a[1].(events.SubscriptionMatcher)(&fftypes.Subscription{}))
 	}
 	se = &Events{}
 	ctx, cancelCtx := context.WithCancel(context.Background())
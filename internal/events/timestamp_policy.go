@@ -0,0 +1,134 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+type timestampPolicyAction string
+
+const (
+	// timestampPolicyActionNone disables verification of the timestamps declared in inbound batches
+	timestampPolicyActionNone timestampPolicyAction = "none"
+	// timestampPolicyActionAnnotate accepts inbound batches with skewed timestamps, emitting an event that refers to the batch
+	timestampPolicyActionAnnotate timestampPolicyAction = "annotate"
+	// timestampPolicyActionReject discards inbound batches with skewed timestamps, emitting an event that refers to the batch
+	timestampPolicyActionReject timestampPolicyAction = "reject"
+)
+
+// timestampPolicy checks the timestamps declared by the sender of an inbound batch, against the time of the block
+// that pinned it (or the local time, if the block time is not known). It also tracks the skew of the local clock
+// against the latest block time, for the node status.
+type timestampPolicy struct {
+	action         timestampPolicyAction
+	maxSkew        time.Duration
+	mux            sync.Mutex
+	blockchainSkew *fftypes.FFDuration
+}
+
+func newTimestampPolicy(ctx context.Context) *timestampPolicy {
+	tp := &timestampPolicy{
+		action:  timestampPolicyAction(strings.ToLower(config.GetString(config.EventReceiveTimestampsAction))),
+		maxSkew: config.GetDuration(config.EventReceiveTimestampsMaxSkew),
+	}
+	switch tp.action {
+	case "":
+		tp.action = timestampPolicyActionNone
+	case timestampPolicyActionNone, timestampPolicyActionAnnotate, timestampPolicyActionReject:
+	default:
+		log.L(ctx).Errorf("Unknown timestamp policy action '%s' - rejecting all batches that violate the timestamp policy", tp.action)
+		tp.action = timestampPolicyActionReject
+	}
+	return tp
+}
+
+// recordBlockTime records the skew of the local clock against the time of a block that has just been received.
+// A positive skew means the local clock is ahead, and includes the confirmation latency of the block.
+func (tp *timestampPolicy) recordBlockTime(blockTime *fftypes.FFTime) {
+	skew := fftypes.FFDuration(time.Since(*blockTime.Time()))
+	tp.mux.Lock()
+	defer tp.mux.Unlock()
+	tp.blockchainSkew = &skew
+}
+
+func (tp *timestampPolicy) getBlockchainSkew() *fftypes.FFDuration {
+	tp.mux.Lock()
+	defer tp.mux.Unlock()
+	return tp.blockchainSkew
+}
+
+func (tp *timestampPolicy) exceeds(declared *fftypes.FFTime, reference time.Time) bool {
+	if declared == nil {
+		return false
+	}
+	skew := declared.Time().Sub(reference)
+	return skew > tp.maxSkew || skew < -tp.maxSkew
+}
+
+// skewedTimestamp returns a description of the first timestamp declared in the batch that deviates from the
+// reference time by more than the max skew, or an empty string if all are within it
+func (tp *timestampPolicy) skewedTimestamp(batch *fftypes.Batch, reference *fftypes.FFTime) string {
+	ref := *reference.Time()
+	if tp.exceeds(batch.Created, ref) {
+		return fmt.Sprintf("batch created '%s'", batch.Created)
+	}
+	for _, msg := range batch.Payload.Messages {
+		if msg != nil && tp.exceeds(msg.Header.Created, ref) {
+			return fmt.Sprintf("message '%s' created '%s'", msg.Header.ID, msg.Header.Created)
+		}
+	}
+	return ""
+}
+
+// checkTimestamps applies the timestamp policy to an inbound batch, using the time of the block that pinned the batch
+// as the reference if known, and the local time otherwise. If a timestamp deviates by more than the max skew, a timestamp
+// skew event is emitted that refers to the batch, and the batch is only valid if the policy is to annotate.
+func (em *eventManager) checkTimestamps(ctx context.Context /* db TX context*/, batch *fftypes.Batch, blockTime *fftypes.FFTime) (valid bool, err error) {
+	tp := em.timestamps
+	if tp.action == timestampPolicyActionNone {
+		return true, nil
+	}
+	reference := blockTime
+	if reference == nil {
+		reference = fftypes.Now()
+	}
+	skewed := tp.skewedTimestamp(batch, reference)
+	if skewed == "" {
+		return true, nil
+	}
+
+	log.L(ctx).Warnf("Timestamp policy violation for batch '%s' from '%s': %s deviates from reference time '%s' by more than %s (action=%s)", batch.ID, batch.Author, skewed, reference, tp.maxSkew, tp.action)
+	event := fftypes.NewEvent(fftypes.EventTypeTimestampSkew, batch.Namespace, batch.ID)
+	if err := em.database.InsertEvent(ctx, event); err != nil {
+		return false, err
+	}
+	return tp.action == timestampPolicyActionAnnotate, nil
+}
+
+// BlockchainClockSkew returns the skew of the local clock against the time of the last block received, if known
+func (em *eventManager) BlockchainClockSkew() *fftypes.FFDuration {
+	return em.timestamps.getBlockchainSkew()
+}
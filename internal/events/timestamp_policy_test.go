@@ -0,0 +1,151 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func timeOffset(d time.Duration) *fftypes.FFTime {
+	t := fftypes.FFTime(time.Now().Add(d))
+	return &t
+}
+
+func TestNewTimestampPolicy(t *testing.T) {
+	config.Reset()
+	tp := newTimestampPolicy(context.Background())
+	assert.Equal(t, timestampPolicyActionNone, tp.action)
+	assert.Equal(t, 5*time.Minute, tp.maxSkew)
+
+	config.Set(config.EventReceiveTimestampsAction, "")
+	tp = newTimestampPolicy(context.Background())
+	assert.Equal(t, timestampPolicyActionNone, tp.action)
+
+	config.Set(config.EventReceiveTimestampsAction, "Annotate")
+	tp = newTimestampPolicy(context.Background())
+	assert.Equal(t, timestampPolicyActionAnnotate, tp.action)
+
+	config.Set(config.EventReceiveTimestampsAction, "unknown")
+	tp = newTimestampPolicy(context.Background())
+	assert.Equal(t, timestampPolicyActionReject, tp.action)
+}
+
+func TestBlockchainClockSkew(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	assert.Nil(t, em.BlockchainClockSkew())
+	em.timestamps.recordBlockTime(timeOffset(-1 * time.Hour))
+	assert.GreaterOrEqual(t, int64(*em.BlockchainClockSkew()), int64(time.Hour))
+}
+
+func TestCheckTimestampsNone(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	valid, err := em.checkTimestamps(em.ctx, &fftypes.Batch{Created: timeOffset(-24 * time.Hour)}, nil)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestCheckTimestampsWithinSkew(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionReject
+
+	valid, err := em.checkTimestamps(em.ctx, &fftypes.Batch{
+		Created: timeOffset(-1 * time.Minute),
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{Created: timeOffset(-2 * time.Minute)}},
+				{Header: fftypes.MessageHeader{}},
+			},
+		},
+	}, nil)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestCheckTimestampsAnnotateBlockTime(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionAnnotate
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Created:   timeOffset(-1 * time.Minute),
+	}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTimestampSkew && e.Reference.Equals(batch.ID) && e.Namespace == "ns1"
+	})).Return(nil)
+
+	// The block was an hour earlier than the batch was declared to be created
+	valid, err := em.checkTimestamps(em.ctx, batch, timeOffset(-1*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckTimestampsRejectMessage(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionReject
+
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Created: timeOffset(1 * time.Hour)}},
+			},
+		},
+	}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(nil)
+
+	valid, err := em.checkTimestamps(em.ctx, batch, nil)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckTimestampsInsertEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.timestamps.action = timestampPolicyActionAnnotate
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("InsertEvent", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := em.checkTimestamps(em.ctx, &fftypes.Batch{Created: timeOffset(1 * time.Hour)}, nil)
+	assert.Regexp(t, "pop", err)
+	assert.False(t, valid)
+
+	mdi.AssertExpectations(t)
+}
@@ -149,7 +149,7 @@ func (em *eventManager) TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPoo
 			if existingPool, err := em.shouldConfirm(ctx, pool); err != nil {
 				return err
 			} else if existingPool != nil {
-				if existingPool.State == fftypes.TokenPoolStateConfirmed {
+				if existingPool.Confirmed() {
 					return nil // already confirmed
 				}
 				if msg, err := em.database.GetMessageByID(ctx, existingPool.Message); err != nil {
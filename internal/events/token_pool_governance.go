@@ -0,0 +1,135 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+)
+
+func governancePolicyRequest(ns string, change *fftypes.TokenPoolGovernance) *fftypes.PolicyRequest {
+	return &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenPoolGovernance,
+		Namespace:  ns,
+		SigningKey: change.Key,
+		Reference:  change.Pool,
+		Input: fftypes.JSONObject{
+			"action":       change.Action.String(),
+			"pool":         change.Pool.String(),
+			"connector":    change.Connector,
+			"protocolTxId": change.ProtocolTxID,
+			"details":      change.Details,
+		},
+	}
+}
+
+func governanceFromPolicyRequest(ctx context.Context, req *fftypes.PolicyRequest) (*fftypes.TokenPoolGovernance, error) {
+	poolID, err := fftypes.ParseUUID(ctx, req.Input.GetString("pool"))
+	if err != nil {
+		return nil, err
+	}
+	return &fftypes.TokenPoolGovernance{
+		Action:       fftypes.FFEnum(req.Input.GetString("action")),
+		Pool:         poolID,
+		Connector:    req.Input.GetString("connector"),
+		Key:          req.SigningKey,
+		ProtocolTxID: req.Input.GetString("protocolTxId"),
+		Details:      req.Input.GetObject("details"),
+	}, nil
+}
+
+func (em *eventManager) applyGovernance(ctx context.Context, change *fftypes.TokenPoolGovernance) error {
+	// The pool is read again, as a held change is applied some time after it was detected
+	pool, err := em.database.GetTokenPoolByID(ctx, change.Pool)
+	if err != nil {
+		return err
+	}
+	if pool == nil {
+		log.L(ctx).Warnf("Token pool '%s' not found for %s governance action - ignoring", change.Pool, change.Action)
+		return nil
+	}
+
+	if state := pool.ApplyGovernance(change.Action); state != pool.State {
+		pool.State = state
+		if err := em.database.UpsertTokenPool(ctx, pool); err != nil {
+			return err
+		}
+	}
+	log.L(ctx).Infof("Token pool governance applied id=%s action=%s key=%s state=%s", pool.ID, change.Action, change.Key, pool.State)
+
+	eventType := fftypes.EventTypePoolConfigChanged
+	if change.Action == fftypes.TokenPoolGovernanceDeactivated {
+		eventType = fftypes.EventTypePoolDeactivated
+	}
+	return em.database.InsertEvent(ctx, fftypes.NewEvent(eventType, pool.Namespace, pool.ID))
+}
+
+// ApplyApproved is the policy approval handler for governance actions on token pools that were held for approval
+func (em *eventManager) ApplyApproved(ctx context.Context, approval *fftypes.PolicyApproval) error {
+	change, err := governanceFromPolicyRequest(ctx, &approval.PolicyRequest)
+	if err != nil {
+		return err
+	}
+	return em.applyGovernance(ctx, change)
+}
+
+// TokenPoolGovernance is invoked when the connector detects a governance action on the contract underlying a pool.
+// The action has already happened on chain, so the policy plugin decides whether it is applied to the pool in
+// FireFly (which may stop the pool being used), and a held action is only applied once an administrator approves it.
+func (em *eventManager) TokenPoolGovernance(ti tokens.Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	eventHash := eventHash("governance", protocolTxID, fftypes.JSONObject{
+		"poolId":  poolProtocolID,
+		"action":  change.Action,
+		"key":     change.Key,
+		"details": change.Details,
+	})
+	if em.dedup.isDuplicate(eventHash) {
+		log.L(em.ctx).Debugf("Skipping duplicate token pool governance action '%s' for pool '%s'", change.Action, poolProtocolID)
+		return nil
+	}
+
+	err := em.retry.Do(em.ctx, "apply token pool governance", func(attempt int) (bool, error) {
+		pool, err := em.database.GetTokenPoolByProtocolID(em.ctx, change.Connector, poolProtocolID)
+		if err != nil {
+			return true, err
+		}
+		if pool == nil {
+			log.L(em.ctx).Infof("Token pool governance action received for unknown pool '%s' - ignoring: %s", poolProtocolID, protocolTxID)
+			return false, nil
+		}
+		change.Pool = pool.ID
+		change.ProtocolTxID = protocolTxID
+
+		// Policy must be checked outside of the database group, as holding the action records a pending approval
+		apply, err := em.policy.CheckDetected(em.ctx, governancePolicyRequest(pool.Namespace, change))
+		if err != nil || !apply {
+			return err != nil, err
+		}
+		err = em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			return em.applyGovernance(ctx, change)
+		})
+		return err != nil, err // retry indefinitely (until context closes)
+	})
+
+	if err == nil {
+		em.dedup.record(eventHash)
+	}
+	return err
+}
@@ -0,0 +1,246 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/policymanagermocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestGovernance(action fftypes.TokenPoolGovernanceAction) *fftypes.TokenPoolGovernance {
+	return &fftypes.TokenPoolGovernance{
+		Action:    action,
+		Connector: "erc1155",
+		Key:       "0x12345",
+		Details:   fftypes.JSONObject{"some": "details"},
+	}
+}
+
+func TestTokenPoolGovernanceDeactivatedWithRetries(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mpo := em.policy.(*policymanagermocks.Manager)
+	mti := &tokenmocks.Plugin{}
+
+	change := newTestGovernance(fftypes.TokenPoolGovernanceDeactivated)
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		State:     fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mpo.On("CheckDetected", em.ctx, mock.MatchedBy(func(req *fftypes.PolicyRequest) bool {
+		return req.Type == fftypes.PolicySubmissionTypeTokenPoolGovernance &&
+			req.Namespace == "ns1" &&
+			req.SigningKey == "0x12345" &&
+			req.Reference == pool.ID &&
+			req.Input.GetString("protocolTxId") == "tx1"
+	})).Return(false, fmt.Errorf("pop")).Once()
+	mpo.On("CheckDetected", em.ctx, mock.Anything).Return(true, nil).Once()
+	mdi.On("GetTokenPoolByID", em.ctx, pool.ID).Return(pool, nil)
+	mdi.On("UpsertTokenPool", em.ctx, mock.MatchedBy(func(p *fftypes.TokenPool) bool {
+		return p.State == fftypes.TokenPoolStateDeactivated
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypePoolDeactivated && ev.Reference == pool.ID && ev.Namespace == "ns1"
+	})).Return(nil)
+
+	err := em.TokenPoolGovernance(mti, "F1", change, "tx1", fftypes.JSONObject{"some": "info"})
+	assert.NoError(t, err)
+	assert.Equal(t, pool.ID, change.Pool)
+
+	mdi.AssertExpectations(t)
+	mpo.AssertExpectations(t)
+}
+
+func TestTokenPoolGovernanceOwnershipTransferred(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mpo := em.policy.(*policymanagermocks.Manager)
+	mti := &tokenmocks.Plugin{}
+
+	change := newTestGovernance(fftypes.TokenPoolGovernanceOwnershipTransferred)
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		State:     fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil)
+	mpo.On("CheckDetected", em.ctx, mock.Anything).Return(true, nil)
+	mdi.On("GetTokenPoolByID", em.ctx, pool.ID).Return(pool, nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypePoolConfigChanged && ev.Reference == pool.ID
+	})).Return(nil)
+
+	err := em.TokenPoolGovernance(mti, "F1", change, "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertTokenPool", mock.Anything, mock.Anything)
+}
+
+func TestTokenPoolGovernanceHeldDuplicateSkipped(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mpo := em.policy.(*policymanagermocks.Manager)
+	mti := &tokenmocks.Plugin{}
+
+	change := newTestGovernance(fftypes.TokenPoolGovernancePaused)
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		State:     fftypes.TokenPoolStateConfirmed,
+	}
+
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Once()
+	mpo.On("CheckDetected", em.ctx, mock.Anything).Return(false, nil).Once()
+
+	err := em.TokenPoolGovernance(mti, "F1", change, "tx1", nil)
+	assert.NoError(t, err)
+
+	// Redelivery of the same event is skipped, without querying the database
+	err = em.TokenPoolGovernance(mti, "F1", change, "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mpo.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "GetTokenPoolByID", mock.Anything, mock.Anything)
+}
+
+func TestTokenPoolGovernanceUnknownPool(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mpo := em.policy.(*policymanagermocks.Manager)
+	mti := &tokenmocks.Plugin{}
+
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(nil, nil)
+
+	err := em.TokenPoolGovernance(mti, "F1", newTestGovernance(fftypes.TokenPoolGovernancePaused), "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mpo.AssertNotCalled(t, "CheckDetected", mock.Anything, mock.Anything)
+}
+
+func TestApplyApprovedPaused(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		State:     fftypes.TokenPoolStateConfirmed,
+	}
+	change := newTestGovernance(fftypes.TokenPoolGovernancePaused)
+	change.Pool = pool.ID
+	approval := &fftypes.PolicyApproval{
+		ID:            fftypes.NewUUID(),
+		PolicyRequest: *governancePolicyRequest("ns1", change),
+	}
+
+	mdi.On("GetTokenPoolByID", mock.Anything, pool.ID).Return(pool, nil)
+	mdi.On("UpsertTokenPool", mock.Anything, mock.MatchedBy(func(p *fftypes.TokenPool) bool {
+		return p.State == fftypes.TokenPoolStatePaused
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypePoolConfigChanged && ev.Reference == pool.ID
+	})).Return(nil)
+
+	err := em.ApplyApproved(context.Background(), approval)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestApplyApprovedBadPoolID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	approval := &fftypes.PolicyApproval{
+		PolicyRequest: fftypes.PolicyRequest{
+			Type:  fftypes.PolicySubmissionTypeTokenPoolGovernance,
+			Input: fftypes.JSONObject{"pool": "!uuid"},
+		},
+	}
+
+	err := em.ApplyApproved(context.Background(), approval)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestApplyGovernancePoolNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	change := newTestGovernance(fftypes.TokenPoolGovernancePaused)
+	change.Pool = fftypes.NewUUID()
+	mdi.On("GetTokenPoolByID", mock.Anything, change.Pool).Return(nil, nil)
+
+	err := em.applyGovernance(context.Background(), change)
+	assert.NoError(t, err)
+}
+
+func TestApplyGovernancePoolLookupFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	change := newTestGovernance(fftypes.TokenPoolGovernancePaused)
+	change.Pool = fftypes.NewUUID()
+	mdi.On("GetTokenPoolByID", mock.Anything, change.Pool).Return(nil, fmt.Errorf("pop"))
+
+	err := em.applyGovernance(context.Background(), change)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestApplyGovernanceUpsertFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	pool := &fftypes.TokenPool{
+		ID:    fftypes.NewUUID(),
+		State: fftypes.TokenPoolStateConfirmed,
+	}
+	change := newTestGovernance(fftypes.TokenPoolGovernanceDeactivated)
+	change.Pool = pool.ID
+	mdi.On("GetTokenPoolByID", mock.Anything, pool.ID).Return(pool, nil)
+	mdi.On("UpsertTokenPool", mock.Anything, pool).Return(fmt.Errorf("pop"))
+
+	err := em.applyGovernance(context.Background(), change)
+	assert.EqualError(t, err, "pop")
+}
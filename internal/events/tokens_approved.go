@@ -0,0 +1,126 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+)
+
+func (em *eventManager) loadApprovalOperation(ctx context.Context, approval *fftypes.TokenApproval) error {
+	approval.LocalID = nil
+
+	// Find a matching operation within this transaction
+	fb := database.OperationQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("tx", approval.TX.ID),
+		fb.Eq("type", fftypes.OpTypeTokenApproval),
+	)
+	operations, _, err := em.database.GetOperations(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if len(operations) > 0 {
+		if err = txcommon.RetrieveTokenApprovalInputs(ctx, operations[0], approval); err != nil {
+			log.L(ctx).Warnf("Failed to read operation inputs for token approval '%s': %s", approval.ProtocolID, err)
+		}
+	}
+
+	if approval.LocalID == nil {
+		approval.LocalID = fftypes.NewUUID()
+	}
+	return nil
+}
+
+func (em *eventManager) persistApprovalTransaction(ctx context.Context, ns string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) (valid bool, err error) {
+	transaction := &fftypes.Transaction{
+		ID:     approval.TX.ID,
+		Status: fftypes.OpStatusSucceeded,
+		Subject: fftypes.TransactionSubject{
+			Namespace: ns,
+			Type:      approval.TX.Type,
+			Signer:    approval.Key,
+			Reference: approval.LocalID,
+		},
+		ProtocolID: protocolTxID,
+		Info:       additionalInfo,
+	}
+	return em.txhelper.PersistTransaction(ctx, transaction)
+}
+
+func (em *eventManager) TokensApproved(ti tokens.Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	eventHash := eventHash("approval", protocolTxID, approval)
+	if em.dedup.isDuplicate(eventHash) {
+		log.L(em.ctx).Debugf("Skipping duplicate token approval '%s'", approval.ProtocolID)
+		return nil
+	}
+
+	err := em.retry.Do(em.ctx, "persist token approval", func(attempt int) (bool, error) {
+		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			// Check that approval has not already been recorded
+			if existing, err := em.database.GetTokenApprovalByProtocolID(ctx, approval.Connector, approval.ProtocolID); err != nil {
+				return err
+			} else if existing != nil {
+				log.L(ctx).Warnf("Token approval '%s' has already been recorded - ignoring", approval.ProtocolID)
+				return nil
+			}
+
+			// Check that this is from a known pool
+			pool, err := em.database.GetTokenPoolByProtocolID(ctx, approval.Connector, poolProtocolID)
+			if err != nil {
+				return err
+			}
+			if pool == nil {
+				log.L(ctx).Infof("Token approval received for unknown pool '%s' - ignoring: %s", poolProtocolID, protocolTxID)
+				return nil
+			}
+			approval.Namespace = pool.Namespace
+			approval.Pool = pool.ID
+
+			if approval.TX.ID != nil {
+				if err := em.loadApprovalOperation(ctx, approval); err != nil {
+					return err
+				}
+				if valid, err := em.persistApprovalTransaction(ctx, pool.Namespace, approval, protocolTxID, additionalInfo); err != nil || !valid {
+					return err
+				}
+			} else {
+				approval.LocalID = fftypes.NewUUID()
+			}
+
+			if err := em.database.UpsertTokenApproval(ctx, approval); err != nil {
+				log.L(ctx).Errorf("Failed to record token approval '%s': %s", approval.ProtocolID, err)
+				return err
+			}
+			log.L(ctx).Infof("Token approval recorded id=%s author=%s operator=%s approved=%t", approval.ProtocolID, approval.Key, approval.Operator, approval.Approved)
+
+			event := fftypes.NewEvent(fftypes.EventTypeApprovalConfirmed, pool.Namespace, approval.LocalID)
+			return em.database.InsertEvent(ctx, event)
+		})
+		return err != nil, err // retry indefinitely (until context closes)
+	})
+
+	if err == nil {
+		em.dedup.record(eventHash)
+	}
+	return err
+}
@@ -0,0 +1,222 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestApproval() *fftypes.TokenApproval {
+	return &fftypes.TokenApproval{
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		Operator:   "0x2",
+		Approved:   true,
+		ProtocolID: "123",
+	}
+}
+
+func TestTokensApprovedSucceedWithRetries(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+	}
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(3)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mdi.On("UpsertTokenApproval", em.ctx, approval).Return(fmt.Errorf("pop")).Once()
+	mdi.On("UpsertTokenApproval", em.ctx, approval).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeApprovalConfirmed && ev.Reference == approval.LocalID && ev.Namespace == pool.Namespace
+	})).Return(nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+	assert.Equal(t, pool.ID, approval.Pool)
+	assert.Equal(t, "ns1", approval.Namespace)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensApprovedIgnoreExisting(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(&fftypes.TokenApproval{}, nil)
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensApprovedUnknownPool(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(nil, nil)
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensApprovedWithTransactionRetries(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+	approval.TX = fftypes.TransactionRef{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.TransactionTypeTokenApproval,
+	}
+	pool := &fftypes.TokenPool{
+		Namespace: "ns1",
+	}
+	operationsBad := []*fftypes.Operation{{
+		Input: fftypes.JSONObject{
+			"id": "bad",
+		},
+	}}
+	operationsGood := []*fftypes.Operation{{
+		Input: fftypes.JSONObject{
+			"id": fftypes.NewUUID().String(),
+		},
+	}}
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(3)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(3)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(operationsBad, nil, nil).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(operationsGood, nil, nil).Once()
+	mdi.On("GetTransactionByID", em.ctx, approval.TX.ID).Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTransactionByID", em.ctx, approval.TX.ID).Return(nil, nil).Once()
+	mdi.On("UpsertTransaction", em.ctx, mock.MatchedBy(func(t *fftypes.Transaction) bool {
+		return *t.ID == *approval.TX.ID && t.Subject.Type == fftypes.TransactionTypeTokenApproval && t.ProtocolID == "tx1"
+	}), false).Return(database.HashMismatch).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensApprovedWithTransactionLoadLocalID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+	approval.TX = fftypes.TransactionRef{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.TransactionTypeTokenApproval,
+	}
+	pool := &fftypes.TokenPool{
+		Namespace: "ns1",
+	}
+	localID := fftypes.NewUUID()
+	operations := []*fftypes.Operation{{
+		Input: fftypes.JSONObject{
+			"id": localID.String(),
+		},
+	}}
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Once()
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(operations, nil, nil).Once()
+	mdi.On("GetTransactionByID", em.ctx, approval.TX.ID).Return(nil, nil).Once()
+	mdi.On("UpsertTransaction", em.ctx, mock.MatchedBy(func(t *fftypes.Transaction) bool {
+		return *t.ID == *approval.TX.ID && t.Subject.Type == fftypes.TransactionTypeTokenApproval && t.ProtocolID == "tx1"
+	}), false).Return(nil).Once()
+	mdi.On("UpsertTokenApproval", em.ctx, approval).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeApprovalConfirmed && *ev.Reference == *localID && ev.Namespace == pool.Namespace
+	})).Return(nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+	assert.Equal(t, *localID, *approval.LocalID)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensApprovedDuplicateSkipped(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	approval := newTestApproval()
+
+	mdi.On("GetTokenApprovalByProtocolID", em.ctx, "erc1155", "123").Return(&fftypes.TokenApproval{}, nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+
+	// Redelivery of the same event is skipped, without querying the database
+	err = em.TokensApproved(mti, "F1", approval, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
@@ -18,6 +18,8 @@ package events
 
 import (
 	"context"
+	"database/sql/driver"
+	"fmt"
 
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/txcommon"
@@ -33,7 +35,12 @@ func (em *eventManager) loadTransferOperation(ctx context.Context, transfer *fft
 	fb := database.OperationQueryFactory.NewFilter(ctx)
 	filter := fb.And(
 		fb.Eq("tx", transfer.TX.ID),
-		fb.Eq("type", fftypes.OpTypeTokenTransfer),
+		fb.In("type", []driver.Value{
+			fftypes.OpTypeTokenTransfer,
+			fftypes.OpTypeTokenBridgeLock,
+			fftypes.OpTypeTokenBridgeMint,
+			fftypes.OpTypeTokenBridgeUnlock,
+		}),
 	)
 	operations, _, err := em.database.GetOperations(ctx, filter)
 	if err != nil {
@@ -43,6 +50,11 @@ func (em *eventManager) loadTransferOperation(ctx context.Context, transfer *fft
 		if err = txcommon.RetrieveTokenTransferInputs(ctx, operations[0], transfer); err != nil {
 			log.L(ctx).Warnf("Failed to read operation inputs for token transfer '%s': %s", transfer.ProtocolID, err)
 		}
+		switch operations[0].Type {
+		case fftypes.OpTypeTokenBridgeLock, fftypes.OpTypeTokenBridgeMint, fftypes.OpTypeTokenBridgeUnlock:
+			// Each leg of a token bridge belongs to the transaction of the bridge, which it references
+			transfer.TX.Type = fftypes.TransactionTypeTokenBridge
+		}
 	}
 
 	if transfer.LocalID == nil {
@@ -67,9 +79,55 @@ func (em *eventManager) persistTokenTransaction(ctx context.Context, ns string,
 	return em.txhelper.PersistTransaction(ctx, transaction)
 }
 
+// updateTokenNFT records the new owner of a non-fungible token index, and its URI if the connector
+// supplied one - raising an event when the URI of a previously seen token changes
+func (em *eventManager) updateTokenNFT(ctx context.Context, pool *fftypes.TokenPool, transfer *fftypes.TokenTransfer) error {
+	nft, err := em.database.GetTokenNFT(ctx, pool.ID, transfer.TokenIndex)
+	if err != nil {
+		return err
+	}
+	uriChanged := false
+	if nft == nil {
+		nft = &fftypes.TokenNFT{
+			ID:         fftypes.NewUUID(),
+			Pool:       pool.ID,
+			TokenIndex: transfer.TokenIndex,
+			Connector:  pool.Connector,
+			Namespace:  pool.Namespace,
+			Created:    fftypes.Now(),
+		}
+	} else if transfer.URI != "" && transfer.URI != nft.URI {
+		uriChanged = true
+	}
+	if transfer.Type == fftypes.TokenTransferTypeBurn {
+		nft.Owner = ""
+	} else {
+		nft.Owner = transfer.To
+	}
+	if transfer.URI != "" {
+		nft.URI = transfer.URI
+	}
+	nft.Updated = fftypes.Now()
+	if err := em.database.UpsertTokenNFT(ctx, nft); err != nil {
+		log.L(ctx).Errorf("Failed to update token index '%s' in pool '%s': %s", nft.TokenIndex, pool.ID, err)
+		return err
+	}
+	if uriChanged {
+		event := fftypes.NewEvent(fftypes.EventTypeTokenMetadataChanged, pool.Namespace, nft.ID)
+		return em.database.InsertEvent(ctx, event)
+	}
+	return nil
+}
+
 func (em *eventManager) TokensTransferred(ti tokens.Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	var batchID *fftypes.UUID
 
+	eventHash := eventHash("transfer", protocolTxID, transfer)
+	if em.dedup.isDuplicate(eventHash) {
+		log.L(em.ctx).Debugf("Skipping duplicate token transfer '%s'", transfer.ProtocolID)
+		return nil
+	}
+
 	err := em.retry.Do(em.ctx, "persist token transfer", func(attempt int) (bool, error) {
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			// Check that transfer has not already been recorded
@@ -119,6 +177,11 @@ func (em *eventManager) TokensTransferred(ti tokens.Plugin, poolProtocolID strin
 				log.L(ctx).Errorf("Failed to update accounts %s -> %s for token transfer '%s': %s", transfer.From, transfer.To, transfer.ProtocolID, err)
 				return err
 			}
+			if pool.Type == fftypes.TokenTypeNonFungible {
+				if err := em.updateTokenNFT(ctx, pool, transfer); err != nil {
+					return err
+				}
+			}
 			log.L(ctx).Infof("Token transfer recorded id=%s author=%s", transfer.ProtocolID, transfer.Key)
 
 			if transfer.Message != nil {
@@ -131,6 +194,9 @@ func (em *eventManager) TokensTransferred(ti tokens.Plugin, poolProtocolID strin
 						if err := em.database.UpsertMessage(ctx, msg, database.UpsertOptimizationExisting); err != nil {
 							return err
 						}
+						if err := em.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, fmt.Sprintf("token transfer %s confirmed", transfer.LocalID))); err != nil {
+							return err
+						}
 					} else {
 						// Message was already received - aggregator will need to be rewound
 						batchID = msg.BatchID
@@ -144,6 +210,10 @@ func (em *eventManager) TokensTransferred(ti tokens.Plugin, poolProtocolID strin
 		return err != nil, err // retry indefinitely (until context closes)
 	})
 
+	if err == nil {
+		em.dedup.record(eventHash)
+	}
+
 	// Initiate a rewind if a batch was potentially completed by the arrival of this transfer
 	if err == nil && batchID != nil {
 		log.L(em.ctx).Infof("Batch '%s' contains reference to received transfer. Transfer='%s' Message='%s'", batchID, transfer.ProtocolID, transfer.Message)
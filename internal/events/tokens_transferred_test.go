@@ -264,6 +264,64 @@ func TestTokensTransferredWithTransactionRegenerateLocalID(t *testing.T) {
 	mti.AssertExpectations(t)
 }
 
+func TestTokensTransferredTokenBridgeLeg(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeTransfer,
+		TokenIndex: "0",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		To:         "0x2",
+		ProtocolID: "123",
+		Amount:     *fftypes.NewBigInt(1),
+		TX: fftypes.TransactionRef{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.TransactionTypeTokenTransfer,
+		},
+	}
+	pool := &fftypes.TokenPool{
+		Namespace: "ns1",
+	}
+	localID := fftypes.NewUUID() // the ID of the bridge
+	operations := []*fftypes.Operation{{
+		Type: fftypes.OpTypeTokenBridgeLock,
+		Input: fftypes.JSONObject{
+			"id": localID.String(),
+		},
+	}}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(2)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(operations, nil, nil).Times(2)
+	mdi.On("GetTransactionByID", em.ctx, transfer.TX.ID).Return(nil, nil).Times(2)
+	mdi.On("UpsertTransaction", em.ctx, mock.MatchedBy(func(t *fftypes.Transaction) bool {
+		return *t.ID == *transfer.TX.ID && t.Subject.Type == fftypes.TransactionTypeTokenBridge && *t.Subject.Reference == *localID && t.ProtocolID == "tx1"
+	}), false).Return(nil).Times(2)
+	mdi.On("GetTokenTransfer", em.ctx, localID).Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTokenTransfer", em.ctx, localID).Return(nil, nil).Once()
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil).Once()
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed && ev.Reference == transfer.LocalID && ev.Namespace == pool.Namespace
+	})).Return(nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", info)
+	assert.NoError(t, err)
+
+	assert.Equal(t, *localID, *transfer.LocalID)
+	assert.Equal(t, fftypes.TransactionTypeTokenBridge, transfer.TX.Type)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
 func TestTokensTransferredBadPool(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -386,3 +444,279 @@ func TestTokensTransferredWithMessageSend(t *testing.T) {
 	mdi.AssertExpectations(t)
 	mti.AssertExpectations(t)
 }
+
+func TestTokensTransferredWithMessageSendTransitionFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeTransfer,
+		TokenIndex: "0",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		To:         "0x2",
+		ProtocolID: "123",
+		Message:    fftypes.NewUUID(),
+		Amount:     *fftypes.NewBigInt(1),
+	}
+	pool := &fftypes.TokenPool{
+		Namespace: "ns1",
+	}
+	message := &fftypes.Message{
+		BatchID: fftypes.NewUUID(),
+		State:   fftypes.MessageStateStaged,
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(2)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("GetMessageByID", em.ctx, mock.Anything).Return(message, nil).Times(2)
+	mdi.On("UpsertMessage", em.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.State == fftypes.MessageStateReady
+	}), database.UpsertOptimizationExisting).Return(nil).Once()
+	mdi.On("InsertMessageTransition", em.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateReady && transition.Cause == fmt.Sprintf("token transfer %s confirmed", transfer.LocalID)
+	})).Return(fmt.Errorf("pop")).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed && ev.Reference == transfer.LocalID && ev.Namespace == pool.Namespace
+	})).Return(nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensTransferredWithMessageReady(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeTransfer,
+		TokenIndex: "0",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		To:         "0x2",
+		ProtocolID: "123",
+		Message:    fftypes.NewUUID(),
+		Amount:     *fftypes.NewBigInt(1),
+	}
+	pool := &fftypes.TokenPool{
+		Namespace: "ns1",
+	}
+	message := &fftypes.Message{
+		State: fftypes.MessageStateStaged,
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil)
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil)
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil)
+	mdi.On("GetMessageByID", em.ctx, mock.Anything).Return(message, nil)
+	mdi.On("UpsertMessage", em.ctx, message, database.UpsertOptimizationExisting).Return(nil)
+	mdi.On("InsertMessageTransition", em.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateReady
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed
+	})).Return(nil)
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensTransferredDuplicateSkipped(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeTransfer,
+		TokenIndex: "0",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		To:         "0x2",
+		ProtocolID: "123",
+		Amount:     *fftypes.NewBigInt(1),
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(&fftypes.TokenTransfer{}, nil).Once()
+
+	info := fftypes.JSONObject{"some": "info"}
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", info)
+	assert.NoError(t, err)
+
+	// Redelivery of the same event is skipped, without querying the database
+	err = em.TokensTransferred(mti, "F1", transfer, "tx1", info)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensTransferredNewNFT(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeMint,
+		TokenIndex: "1",
+		URI:        "firefly://token/1",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		To:         "0x2",
+		ProtocolID: "123",
+		Amount:     *fftypes.NewBigInt(1),
+	}
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Connector: "erc1155",
+		Type:      fftypes.TokenTypeNonFungible,
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(2)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("GetTokenNFT", em.ctx, pool.ID, "1").Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetTokenNFT", em.ctx, pool.ID, "1").Return(nil, nil).Once()
+	mdi.On("UpsertTokenNFT", em.ctx, mock.MatchedBy(func(nft *fftypes.TokenNFT) bool {
+		return *nft.Pool == *pool.ID && nft.TokenIndex == "1" && nft.URI == "firefly://token/1" &&
+			nft.Owner == "0x2" && nft.Connector == "erc1155" && nft.Namespace == "ns1" && nft.Created != nil
+	})).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed
+	})).Return(nil).Once()
+
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensTransferredNFTMetadataChanged(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeTransfer,
+		TokenIndex: "1",
+		URI:        "firefly://token/1/v2",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		To:         "0x2",
+		ProtocolID: "123",
+		Amount:     *fftypes.NewBigInt(1),
+	}
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Connector: "erc1155",
+		Type:      fftypes.TokenTypeNonFungible,
+	}
+	nft := &fftypes.TokenNFT{
+		ID:         fftypes.NewUUID(),
+		Pool:       pool.ID,
+		TokenIndex: "1",
+		URI:        "firefly://token/1",
+		Owner:      "0x1",
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Once()
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Once()
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil).Once()
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil).Once()
+	mdi.On("GetTokenNFT", em.ctx, pool.ID, "1").Return(nft, nil).Once()
+	mdi.On("UpsertTokenNFT", em.ctx, nft).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTokenMetadataChanged && *ev.Reference == *nft.ID && ev.Namespace == "ns1"
+	})).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed
+	})).Return(nil).Once()
+
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2", nft.Owner)
+	assert.Equal(t, "firefly://token/1/v2", nft.URI)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
+
+func TestTokensTransferredNFTBurn(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mti := &tokenmocks.Plugin{}
+
+	transfer := &fftypes.TokenTransfer{
+		Type:       fftypes.TokenTransferTypeBurn,
+		TokenIndex: "1",
+		Connector:  "erc1155",
+		Key:        "0x12345",
+		From:       "0x1",
+		ProtocolID: "123",
+		Amount:     *fftypes.NewBigInt(1),
+	}
+	pool := &fftypes.TokenPool{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Connector: "erc1155",
+		Type:      fftypes.TokenTypeNonFungible,
+	}
+	nft := &fftypes.TokenNFT{
+		ID:         fftypes.NewUUID(),
+		Pool:       pool.ID,
+		TokenIndex: "1",
+		URI:        "firefly://token/1",
+		Owner:      "0x1",
+	}
+
+	mdi.On("GetTokenTransferByProtocolID", em.ctx, "erc1155", "123").Return(nil, nil).Times(2)
+	mdi.On("GetTokenPoolByProtocolID", em.ctx, "erc1155", "F1").Return(pool, nil).Times(2)
+	mdi.On("UpsertTokenTransfer", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("UpdateTokenBalances", em.ctx, transfer).Return(nil).Times(2)
+	mdi.On("GetTokenNFT", em.ctx, pool.ID, "1").Return(nft, nil).Times(2)
+	mdi.On("UpsertTokenNFT", em.ctx, nft).Return(fmt.Errorf("pop")).Once()
+	mdi.On("UpsertTokenNFT", em.ctx, nft).Return(nil).Once()
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(ev *fftypes.Event) bool {
+		return ev.Type == fftypes.EventTypeTransferConfirmed
+	})).Return(nil).Once()
+
+	err := em.TokensTransferred(mti, "F1", transfer, "tx1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", nft.Owner)
+	assert.Equal(t, "firefly://token/1", nft.URI)
+
+	mdi.AssertExpectations(t)
+	mti.AssertExpectations(t)
+}
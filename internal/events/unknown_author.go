@@ -0,0 +1,152 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+type unknownAuthorAction string
+
+const (
+	// unknownAuthorActionReject discards batches from identities that are not in the network map
+	unknownAuthorActionReject unknownAuthorAction = "reject"
+	// unknownAuthorActionQuarantine quarantines batches from identities that are not in the network map, until the identity arrives
+	unknownAuthorActionQuarantine unknownAuthorAction = "quarantine"
+	// unknownAuthorActionAccept processes batches from identities that are not in the network map
+	unknownAuthorActionAccept unknownAuthorAction = "accept"
+)
+
+// unknownAuthorPolicy decides what happens to an inbound batch, when the identity that sent it is not
+// in the network map. The action can be overridden for individual namespaces.
+type unknownAuthorPolicy struct {
+	action     unknownAuthorAction
+	namespaces map[string]unknownAuthorAction
+}
+
+func parseUnknownAuthorAction(ctx context.Context, action string) unknownAuthorAction {
+	switch a := unknownAuthorAction(strings.ToLower(action)); a {
+	case unknownAuthorActionReject, unknownAuthorActionQuarantine, unknownAuthorActionAccept:
+		return a
+	default:
+		log.L(ctx).Errorf("Unknown action '%s' for unknown authors - rejecting batches from unknown authors", action)
+		return unknownAuthorActionReject
+	}
+}
+
+func newUnknownAuthorPolicy(ctx context.Context) *unknownAuthorPolicy {
+	uap := &unknownAuthorPolicy{
+		action:     parseUnknownAuthorAction(ctx, config.GetString(config.EventReceiveUnknownAuthorAction)),
+		namespaces: make(map[string]unknownAuthorAction),
+	}
+	for _, nsConf := range config.GetObjectArray(config.EventReceiveUnknownAuthorNamespaces) {
+		uap.namespaces[nsConf.GetString("namespace")] = parseUnknownAuthorAction(ctx, nsConf.GetString("action"))
+	}
+	return uap
+}
+
+func (uap *unknownAuthorPolicy) actionFor(ns string) unknownAuthorAction {
+	if action, ok := uap.namespaces[ns]; ok {
+		return action
+	}
+	return uap.action
+}
+
+// unknownAuthorAction returns the action for a private batch whose author could not be verified. Only an author
+// that is not in the network map at all is subject to the unknown author policy - any other failure is rejected.
+func (em *eventManager) unknownAuthorAction(ctx context.Context, batch *fftypes.Batch) (unknownAuthorAction, error) {
+	action := em.unknownAuthors.actionFor(batch.Namespace)
+	if action == unknownAuthorActionReject {
+		return action, nil
+	}
+	org, err := em.database.GetOrganizationByIdentity(ctx, batch.Key)
+	if err != nil {
+		return "", err
+	}
+	if org != nil {
+		return unknownAuthorActionReject, nil
+	}
+	return action, nil
+}
+
+// identityDefinitionKey returns the signing key registered by an organization or pseudonym definition, or made
+// usable by the verification of a custom identity
+func identityDefinitionKey(msg *fftypes.Message, data []*fftypes.Data) string {
+	if len(data) == 0 {
+		return ""
+	}
+	switch fftypes.SystemTag(msg.Header.Tag) {
+	case fftypes.SystemTagDefineOrganization:
+		var org fftypes.Organization
+		if err := json.Unmarshal(data[0].Value, &org); err == nil {
+			return org.Identity
+		}
+	case fftypes.SystemTagDefinePseudonym:
+		var pseudonym fftypes.Pseudonym
+		if err := json.Unmarshal(data[0].Value, &pseudonym); err == nil {
+			return pseudonym.Key
+		}
+	case fftypes.SystemTagDefineIdentityVerification:
+		var verification fftypes.IdentityVerification
+		if err := json.Unmarshal(data[0].Value, &verification); err == nil {
+			return verification.Key
+		}
+	}
+	return ""
+}
+
+// releaseIdentityQuarantines releases any batches quarantined until the identity registered by a confirmed
+// definition arrived in the network map
+func (ag *aggregator) releaseIdentityQuarantines(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) error {
+	key := identityDefinitionKey(msg, data)
+	if key == "" {
+		return nil
+	}
+	released, err := ag.releaseAwaitingIdentity(ctx, key)
+	if err != nil {
+		return err
+	}
+	ag.released = append(ag.released, released...)
+	return nil
+}
+
+// rewindReleased rewinds the aggregator to the earliest parked pin of the batches released from quarantine
+// in the last group of pins, once that group has committed
+func (ag *aggregator) rewindReleased() (rewind bool) {
+	released := make(map[string]bool, len(ag.released))
+	for _, batchID := range ag.released {
+		released[batchID.String()] = true
+	}
+	ag.released = nil
+	var lowest int64 = -1
+	for sequence, batchID := range ag.parked {
+		if released[batchID] && (lowest < 0 || sequence < lowest) {
+			lowest = sequence
+		}
+	}
+	if lowest < 0 {
+		return false
+	}
+	ag.eventPoller.rewindPollingOffset(lowest - 1)
+	return true
+}
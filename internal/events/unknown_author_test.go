@@ -0,0 +1,186 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/definitions"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/definitionsmocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewUnknownAuthorPolicy(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventReceiveUnknownAuthorAction, "Quarantine")
+	config.Set(config.EventReceiveUnknownAuthorNamespaces, fftypes.JSONObjectArray{
+		{"namespace": "ns1", "action": "accept"},
+		{"namespace": "ns2", "action": "unknown"},
+	})
+	uap := newUnknownAuthorPolicy(context.Background())
+	assert.Equal(t, unknownAuthorActionAccept, uap.actionFor("ns1"))
+	assert.Equal(t, unknownAuthorActionReject, uap.actionFor("ns2"))
+	assert.Equal(t, unknownAuthorActionQuarantine, uap.actionFor("ns3"))
+}
+
+func TestNewUnknownAuthorPolicyDefault(t *testing.T) {
+	config.Reset()
+	uap := newUnknownAuthorPolicy(context.Background())
+	assert.Equal(t, unknownAuthorActionReject, uap.actionFor("ns1"))
+}
+
+func TestUnknownAuthorActionKnownOrg(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	em.unknownAuthors.action = unknownAuthorActionAccept
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	action, err := em.unknownAuthorAction(em.ctx, &fftypes.Batch{Identity: fftypes.Identity{Key: "0x12345"}})
+	assert.NoError(t, err)
+	assert.Equal(t, unknownAuthorActionReject, action)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestIdentityDefinitionKey(t *testing.T) {
+	orgMsg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineOrganization)}}
+	pseudonymMsg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefinePseudonym)}}
+	verificationMsg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineIdentityVerification)}}
+	otherMsg := &fftypes.Message{Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineDatatype)}}
+
+	assert.Equal(t, "0x12345", identityDefinitionKey(orgMsg, []*fftypes.Data{{Value: fftypes.Byteable(`{"identity":"0x12345"}`)}}))
+	assert.Equal(t, "0x23456", identityDefinitionKey(pseudonymMsg, []*fftypes.Data{{Value: fftypes.Byteable(`{"key":"0x23456"}`)}}))
+	assert.Equal(t, "", identityDefinitionKey(orgMsg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}}))
+	assert.Equal(t, "", identityDefinitionKey(pseudonymMsg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}}))
+	assert.Equal(t, "0x34567", identityDefinitionKey(verificationMsg, []*fftypes.Data{{Value: fftypes.Byteable(`{"key":"0x34567"}`)}}))
+	assert.Equal(t, "", identityDefinitionKey(verificationMsg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}}))
+	assert.Equal(t, "", identityDefinitionKey(otherMsg, []*fftypes.Data{{Value: fftypes.Byteable(`{}`)}}))
+	assert.Equal(t, "", identityDefinitionKey(orgMsg, []*fftypes.Data{}))
+}
+
+func TestAttemptMessageDispatchReleasesAwaitingIdentity(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	batchID := fftypes.NewUUID()
+	ag.releaseAwaitingIdentity = func(ctx context.Context, key string) ([]*fftypes.UUID, error) {
+		assert.Equal(t, "0x12345", key)
+		return []*fftypes.UUID{batchID}, nil
+	}
+
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("HandleSystemBroadcast", mock.Anything, mock.Anything, mock.Anything).Return(definitions.ActionConfirm, nil)
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{Value: fftypes.Byteable(`{"identity":"0x12345"}`)},
+	}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", ag.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", ag.ctx, mock.Anything).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Type:      fftypes.MessageTypeDefinition,
+			ID:        fftypes.NewUUID(),
+			Namespace: fftypes.SystemNamespace,
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	assert.Equal(t, []*fftypes.UUID{batchID}, ag.released)
+
+	msh.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchReleaseAwaitingIdentityFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.releaseAwaitingIdentity = func(ctx context.Context, key string) ([]*fftypes.UUID, error) {
+		return nil, fmt.Errorf("pop")
+	}
+
+	msh := ag.definitions.(*definitionsmocks.DefinitionHandlers)
+	msh.On("HandleSystemBroadcast", mock.Anything, mock.Anything, mock.Anything).Return(definitions.ActionConfirm, nil)
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{Value: fftypes.Byteable(`{"key":"0x12345"}`)},
+	}, true, nil)
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Type:      fftypes.MessageTypeDefinition,
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Tag:       string(fftypes.SystemTagDefinePseudonym),
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestProcessPinsDBGroupRewindsReleased(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	batchID := fftypes.NewUUID()
+	ag.parked[100] = batchID.String()
+	ag.parked[90] = batchID.String()
+	ag.parked[80] = fftypes.NewUUID().String()
+	ag.eventPoller.pollingOffset = 1000
+	ag.releaseAwaitingIdentity = func(ctx context.Context, key string) ([]*fftypes.UUID, error) {
+		return []*fftypes.UUID{batchID}, nil
+	}
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", ag.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		// Simulate an identity definition being confirmed in the group of pins
+		err := ag.releaseIdentityQuarantines(ag.ctx, &fftypes.Message{
+			Header: fftypes.MessageHeader{Tag: string(fftypes.SystemTagDefineOrganization)},
+		}, []*fftypes.Data{{Value: fftypes.Byteable(`{"identity":"0x12345"}`)}})
+		rag.ReturnArguments = mock.Arguments{err}
+	}
+
+	repoll, err := ag.processPinsDBGroup([]fftypes.LocallySequenced{})
+	assert.NoError(t, err)
+	assert.True(t, repoll)
+	assert.Equal(t, int64(89), ag.eventPoller.getPollingOffset())
+	assert.Empty(t, ag.released)
+}
+
+func TestRewindReleasedNotParked(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ag.parked[100] = fftypes.NewUUID().String()
+	ag.released = []*fftypes.UUID{fftypes.NewUUID()}
+	assert.False(t, ag.rewindReleased())
+}
@@ -0,0 +1,105 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// quarantineReceipt stores a receipt from a connector that does not match an operation submitted by this node,
+// so it can be reconciled by an operator rather than being dropped
+func (em *eventManager) quarantineReceipt(plugin fftypes.Named, requestID string, status fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
+	receipt := &fftypes.UnmatchedReceipt{
+		ID:        fftypes.NewUUID(),
+		Plugin:    plugin.Name(),
+		RequestID: requestID,
+		Status:    status,
+		Error:     errorMessage,
+		Output:    txcommon.RedactOperationOutput("", opOutput),
+		Created:   fftypes.Now(),
+	}
+	if err := em.database.InsertUnmatchedReceipt(em.ctx, receipt); err != nil {
+		return err
+	}
+	log.L(em.ctx).Warnf("Receipt for request '%s' from '%s' does not match an operation submitted by this node. Quarantined as '%s'", requestID, receipt.Plugin, receipt.ID)
+	return nil
+}
+
+func (em *eventManager) GetUnmatchedReceipts(ctx context.Context, filter database.AndFilter) ([]*fftypes.UnmatchedReceipt, *database.FilterResult, error) {
+	return em.database.GetUnmatchedReceipts(ctx, filter)
+}
+
+func (em *eventManager) GetUnmatchedReceiptByID(ctx context.Context, id string) (*fftypes.UnmatchedReceipt, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return em.database.GetUnmatchedReceiptByID(ctx, u)
+}
+
+// ReconcileUnmatchedReceipt applies a quarantined receipt to the operation it belongs to, exactly as if the receipt
+// had carried the ID of that operation, and records the operation against the receipt
+func (em *eventManager) ReconcileUnmatchedReceipt(ctx context.Context, id string, input *fftypes.UnmatchedReceiptReconcile) (*fftypes.UnmatchedReceipt, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Operation == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "operation")
+	}
+
+	receipt, err := em.database.GetUnmatchedReceiptByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgUnmatchedReceiptNotFound, u)
+	}
+	if receipt.Reconciled != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgUnmatchedReceiptReconciled, u, receipt.Operation)
+	}
+	op, err := em.database.GetOperationByID(ctx, input.Operation)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if op.Plugin != receipt.Plugin {
+		return nil, i18n.NewError(ctx, i18n.MsgUnmatchedReceiptPlugin, u, receipt.Plugin, op.ID, op.Plugin)
+	}
+
+	if err = em.operationUpdate(nil, op, receipt.Status, receipt.Error, receipt.Output, false); err != nil {
+		return nil, err
+	}
+	receipt.Operation = op.ID
+	receipt.Reconciled = fftypes.Now()
+	update := database.UnmatchedReceiptQueryFactory.NewUpdate(ctx).
+		Set("operation", receipt.Operation).
+		Set("reconciled", receipt.Reconciled)
+	if err = em.database.UpdateUnmatchedReceipt(ctx, receipt.ID, update); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Unmatched receipt '%s' reconciled to operation '%s' status=%s", u, op.ID, receipt.Status)
+	return receipt, nil
+}
@@ -0,0 +1,231 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestUnmatchedReceipt() *fftypes.UnmatchedReceipt {
+	return &fftypes.UnmatchedReceipt{
+		ID:        fftypes.NewUUID(),
+		Plugin:    "ethereum",
+		RequestID: "req12345",
+		Status:    fftypes.OpStatusSucceeded,
+		Output:    fftypes.JSONObject{"transactionHash": "0x12345"},
+		Created:   fftypes.Now(),
+	}
+}
+
+func TestGetUnmatchedReceipts(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceipts", em.ctx, mock.Anything).Return([]*fftypes.UnmatchedReceipt{}, nil, nil)
+
+	f := database.UnmatchedReceiptQueryFactory.NewFilter(em.ctx).And()
+	_, _, err := em.GetUnmatchedReceipts(em.ctx, f)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetUnmatchedReceiptByID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+
+	res, err := em.GetUnmatchedReceiptByID(em.ctx, r.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, r, res)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetUnmatchedReceiptByIDBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.GetUnmatchedReceiptByID(em.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestReconcileUnmatchedReceiptOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	op := &fftypes.Operation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Plugin:    "ethereum",
+		Type:      fftypes.OpTypeBlockchainInvoke,
+		Status:    fftypes.OpStatusPending,
+	}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, op.ID, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationUpdated && *e.Reference == *op.ID
+	})).Return(nil)
+	mdi.On("InsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeBlockchainInvokeOpSucceeded && *e.Reference == *op.ID
+	})).Return(nil)
+	mdi.On("UpdateUnmatchedReceipt", em.ctx, r.ID, mock.Anything).Return(nil)
+
+	res, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: op.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, op.ID, res.Operation)
+	assert.NotNil(t, res.Reconciled)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReconcileUnmatchedReceiptBadID(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, "bad", &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestReconcileUnmatchedReceiptMissingOperation(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, fftypes.NewUUID().String(), &fftypes.UnmatchedReceiptReconcile{})
+	assert.Regexp(t, "FF10140.*operation", err)
+}
+
+func TestReconcileUnmatchedReceiptGetFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, fftypes.NewUUID().String(), &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReconcileUnmatchedReceiptNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, fftypes.NewUUID().String(), &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10435", err)
+}
+
+func TestReconcileUnmatchedReceiptAlreadyReconciled(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	r.Operation = fftypes.NewUUID()
+	r.Reconciled = fftypes.Now()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10436", err)
+}
+
+func TestReconcileUnmatchedReceiptGetOpFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReconcileUnmatchedReceiptOpNotFound(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: fftypes.NewUUID()})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestReconcileUnmatchedReceiptPluginMismatch(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Plugin: "fabric"}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: op.ID})
+	assert.Regexp(t, "FF10437", err)
+}
+
+func TestReconcileUnmatchedReceiptUpdateOpFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Plugin: "ethereum"}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, op.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: op.ID})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReconcileUnmatchedReceiptUpdateReceiptFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	r := newTestUnmatchedReceipt()
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Plugin: "ethereum", Status: fftypes.OpStatusSucceeded}
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetUnmatchedReceiptByID", em.ctx, r.ID).Return(r, nil)
+	mdi.On("GetOperationByID", em.ctx, op.ID).Return(op, nil)
+	mdi.On("UpdateOperation", em.ctx, op.ID, mock.Anything).Return(nil)
+	mdi.On("UpdateUnmatchedReceipt", em.ctx, r.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := em.ReconcileUnmatchedReceipt(em.ctx, r.ID.String(), &fftypes.UnmatchedReceiptReconcile{Operation: op.ID})
+	assert.EqualError(t, err, "pop")
+}
@@ -19,13 +19,20 @@ package webhooks
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
@@ -36,11 +43,35 @@ import (
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
+const (
+	// HeaderSignature is set to "sha256=" followed by the hex HMAC-SHA256 of the signed payload, when a secret is configured
+	HeaderSignature = "X-FireFly-Signature"
+	// HeaderTimestamp is the unix time in seconds at which the request was signed
+	HeaderTimestamp = "X-FireFly-Timestamp"
+	// HeaderNonce is a unique value for each request, that receivers can use to reject replays
+	HeaderNonce = "X-FireFly-Nonce"
+)
+
+const (
+	// DeadLetterPark holds the subscription at an event that could not be delivered, until it is restarted
+	DeadLetterPark = "park"
+	// DeadLetterEvent records a delivery_failed event for an event that could not be delivered, and moves on
+	DeadLetterEvent = "event"
+)
+
+const (
+	defaultRetryCount        = 5
+	defaultRetryInitialDelay = 250 * time.Millisecond
+	defaultRetryMaxDelay     = 30 * time.Second
+	defaultRetryFactor       = 2.0
+)
+
 type WebHooks struct {
 	ctx          context.Context
 	capabilities *events.Capabilities
 	callbacks    events.Callbacks
 	client       *resty.Client
+	clientMux    sync.Mutex
 	connID       string
 }
 
@@ -51,6 +82,16 @@ type whRequest struct {
 	body      fftypes.JSONObject
 	forceJSON bool
 	replyTx   string
+	secret    string
+}
+
+type whRetry struct {
+	enabled      bool
+	count        int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	factor       float64
+	deadLetter   string
 }
 
 type whResponse struct {
@@ -62,15 +103,32 @@ type whResponse struct {
 func (wh *WebHooks) Name() string { return "webhooks" }
 
 func (wh *WebHooks) Init(ctx context.Context, prefix config.Prefix, callbacks events.Callbacks) (err error) {
+	client, err := restclient.New(ctx, prefix)
+	if err != nil {
+		return err
+	}
 	*wh = WebHooks{
 		ctx:          ctx,
 		capabilities: &events.Capabilities{},
 		callbacks:    callbacks,
-		client:       restclient.New(ctx, prefix),
+		client:       client,
 		connID:       fftypes.ShortID(),
 	}
 	// We have a single logical connection, that matches all subscriptions
-	return callbacks.RegisterConnection(wh.connID, func(sr fftypes.SubscriptionRef) bool { return true })
+	return callbacks.RegisterConnection(wh.connID, func(sub *fftypes.Subscription) bool { return true })
+}
+
+// ReloadConfig replaces the HTTP client used to call webhooks, for example to change timeouts or TLS settings.
+// Calls already in flight complete using the previous client.
+func (wh *WebHooks) ReloadConfig(ctx context.Context, prefix config.Prefix) error {
+	client, err := restclient.New(wh.ctx, prefix)
+	if err != nil {
+		return err
+	}
+	wh.clientMux.Lock()
+	wh.client = client
+	wh.clientMux.Unlock()
+	return nil
 }
 
 func (wh *WebHooks) Capabilities() *events.Capabilities {
@@ -108,6 +166,42 @@ func (wh *WebHooks) GetOptionsSchema(ctx context.Context) string {
 				"type": "string",
 				"description": "%s"
 			},
+			"secret": {
+				"type": "string",
+				"writeOnly": true,
+				"description": "%s"
+			},
+			"retry": {
+				"type": "object",
+				"description": "%s",
+				"properties": {
+					"enabled": {
+						"type": "boolean",
+						"description": "%s"
+					},
+					"count": {
+						"type": "integer",
+						"description": "%s"
+					},
+					"initialDelay": {
+						"type": "string",
+						"description": "%s"
+					},
+					"maxDelay": {
+						"type": "string",
+						"description": "%s"
+					},
+					"factor": {
+						"type": "number",
+						"description": "%s"
+					}
+				}
+			},
+			"deadletter": {
+				"type": "string",
+				"enum": ["park", "event"],
+				"description": "%s"
+			},
 			"headers": {
 				"type": "object",
 				"description": "%s",
@@ -157,6 +251,14 @@ func (wh *WebHooks) GetOptionsSchema(ctx context.Context) string {
 		i18n.Expand(ctx, i18n.MsgWebhooksOptReply),
 		i18n.Expand(ctx, i18n.MsgWebhooksOptReplyTag),
 		i18n.Expand(ctx, i18n.MsgWebhooksOptReplyTx),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptSecret),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetry),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetryEnabled),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetryCount),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetryInitDelay),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetryMaxDelay),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptRetryFactor),
+		i18n.Expand(ctx, i18n.MsgWebhooksOptDeadLetter),
 		i18n.Expand(ctx, i18n.MsgWebhooksOptHeaders),
 		i18n.Expand(ctx, i18n.MsgWebhooksOptQuery),
 		i18n.Expand(ctx, i18n.MsgWebhooksOptInput),
@@ -169,12 +271,16 @@ func (wh *WebHooks) GetOptionsSchema(ctx context.Context) string {
 }
 
 func (wh *WebHooks) buildRequest(options fftypes.JSONObject, firstData fftypes.JSONObject) (req *whRequest, err error) {
+	wh.clientMux.Lock()
+	client := wh.client
+	wh.clientMux.Unlock()
 	req = &whRequest{
-		r:         wh.client.R().SetDoNotParseResponse(true),
+		r:         client.R().SetDoNotParseResponse(true),
 		url:       options.GetString("url"),
 		method:    options.GetString("method"),
 		forceJSON: options.GetBool("json"),
 		replyTx:   options.GetString("replytx"),
+		secret:    options.GetString("secret"),
 	}
 	if req.url == "" {
 		return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookURLEmpty)
@@ -252,11 +358,58 @@ func (wh *WebHooks) buildRequest(options fftypes.JSONObject, firstData fftypes.J
 	return req, err
 }
 
+func (wh *WebHooks) parseRetry(options fftypes.JSONObject) (retry *whRetry, err error) {
+	retry = &whRetry{
+		count:        defaultRetryCount,
+		initialDelay: defaultRetryInitialDelay,
+		maxDelay:     defaultRetryMaxDelay,
+		factor:       defaultRetryFactor,
+		deadLetter:   options.GetString("deadletter"),
+	}
+	if retry.deadLetter != "" && retry.deadLetter != DeadLetterPark && retry.deadLetter != DeadLetterEvent {
+		return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookInvalidDeadLetter, retry.deadLetter)
+	}
+	retryOptions := options.GetObject("retry")
+	retry.enabled = retryOptions.GetBool("enabled")
+	if s, ok := retryOptions.GetStringOk("count"); ok {
+		if retry.count, err = strconv.Atoi(s); err != nil || retry.count < 0 {
+			return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookInvalidRetryOption, "count", s)
+		}
+	}
+	for name, d := range map[string]*time.Duration{"initialDelay": &retry.initialDelay, "maxDelay": &retry.maxDelay} {
+		if s, ok := retryOptions.GetStringOk(name); ok {
+			ffd, err := fftypes.ParseDurationString(s, time.Millisecond)
+			if err != nil || ffd < 0 {
+				return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookInvalidRetryOption, name, s)
+			}
+			*d = time.Duration(ffd)
+		}
+	}
+	if s, ok := retryOptions.GetStringOk("factor"); ok {
+		if retry.factor, err = strconv.ParseFloat(s, 64); err != nil || retry.factor < 1 {
+			return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookInvalidRetryOption, "factor", s)
+		}
+	}
+	return retry, nil
+}
+
+// delay returns the exponential backoff before the given retry, starting at 1
+func (r *whRetry) delay(retry int) time.Duration {
+	delay := float64(r.initialDelay) * math.Pow(r.factor, float64(retry-1))
+	if delay > float64(r.maxDelay) {
+		return r.maxDelay
+	}
+	return time.Duration(delay)
+}
+
 func (wh *WebHooks) ValidateOptions(options *fftypes.SubscriptionOptions) error {
 	if options.WithData == nil {
 		defaultTrue := true
 		options.WithData = &defaultTrue
 	}
+	if _, err := wh.parseRetry(options.TransportOptions()); err != nil {
+		return err
+	}
 	_, err := wh.buildRequest(options.TransportOptions(), fftypes.JSONObject{})
 	return err
 }
@@ -291,22 +444,34 @@ func (wh *WebHooks) attemptRequest(sub *fftypes.Subscription, event *fftypes.Eve
 		return nil, nil, err
 	}
 
+	var body interface{}
 	if req.method == http.MethodPost || req.method == http.MethodPatch || req.method == http.MethodPut {
 		switch {
 		case !withData:
 			// We are just sending the event itself
-			req.r.SetBody(event)
+			body = event
 		case req.body != nil:
 			// We might have been told to extract a body from the first data record
-			req.r.SetBody(req.body)
+			body = req.body
 		case len(allData) > 1:
 			// We've got an array of data to POST
-			req.r.SetBody(allData)
+			body = allData
 		default:
 			// Otherwise just send the first object directly
-			req.r.SetBody(firstData)
+			body = firstData
 		}
 	}
+	if req.secret != "" {
+		// Serialize the body ourselves, so the signature covers the exact bytes sent
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+			req.r.SetBody(payload)
+		}
+		signRequest(req, time.Now(), fftypes.NewUUID().String(), payload)
+	} else if body != nil {
+		req.r.SetBody(body)
+	}
 
 	resp, err := req.r.Execute(req.method, req.url)
 	if err != nil {
@@ -349,8 +514,59 @@ func (wh *WebHooks) attemptRequest(sub *fftypes.Subscription, event *fftypes.Eve
 	return req, res, nil
 }
 
+// Signature calculates the value of the signature header for a webhook request, which
+// is an HMAC-SHA256 using the subscription secret over "<timestamp>.<nonce>.<body>".
+// Receivers should recalculate it with the received headers and raw body, and compare
+// it in constant time.
+func Signature(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func signRequest(req *whRequest, now time.Time, nonce string, body []byte) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.r.Header.Set(HeaderTimestamp, timestamp)
+	req.r.Header.Set(HeaderNonce, nonce)
+	req.r.Header.Set(HeaderSignature, Signature(req.secret, timestamp, nonce, body))
+}
+
+// requestFailed is true if the webhook request could not be made, or the endpoint was unavailable.
+// Other error statuses are a valid response from the endpoint, that are passed back as the reply
+func requestFailed(res *whResponse, gwErr error) bool {
+	return gwErr != nil || res.Status == http.StatusTooManyRequests || res.Status >= http.StatusInternalServerError
+}
+
+func failureInfo(res *whResponse, gwErr error) string {
+	if gwErr != nil {
+		return gwErr.Error()
+	}
+	return fmt.Sprintf("status %d", res.Status)
+}
+
 func (wh *WebHooks) doDelivery(connID string, reply bool, sub *fftypes.Subscription, event *fftypes.EventDelivery, data []*fftypes.Data) error {
+	retry, err := wh.parseRetry(sub.Options.TransportOptions())
+	if err != nil {
+		return err
+	}
 	req, res, gwErr := wh.attemptRequest(sub, event, data)
+	for i := 1; retry.enabled && i <= retry.count && requestFailed(res, gwErr); i++ {
+		delay := retry.delay(i)
+		log.L(wh.ctx).Warnf("Webhook request for event '%s' failed (%s) - retry %d/%d in %s", event.ID, failureInfo(res, gwErr), i, retry.count, delay)
+		select {
+		case <-time.After(delay):
+		case <-wh.ctx.Done():
+			return i18n.NewError(wh.ctx, i18n.MsgContextCanceled)
+		}
+		req, res, gwErr = wh.attemptRequest(sub, event, data)
+	}
+	if retry.deadLetter != "" && requestFailed(res, gwErr) {
+		return wh.deadLetter(connID, retry.deadLetter, sub, event, res, gwErr)
+	}
 	if gwErr != nil {
 		// Generate a bad-gateway error response - we always want to send something back,
 		// rather than just causing timeouts
@@ -398,6 +614,24 @@ func (wh *WebHooks) doDelivery(connID string, reply bool, sub *fftypes.Subscript
 	return nil
 }
 
+func (wh *WebHooks) deadLetter(connID, action string, sub *fftypes.Subscription, event *fftypes.EventDelivery, res *whResponse, gwErr error) error {
+	info := fmt.Sprintf("Webhook request failed: %s", failureInfo(res, gwErr))
+	if action == DeadLetterPark {
+		// We do not respond, so the subscription does not move past this event until it is restarted
+		log.L(wh.ctx).Errorf("Webhook subscription '%s' parked at event '%s': %s", sub.ID, event.ID, info)
+		return nil
+	}
+	log.L(wh.ctx).Errorf("Webhook subscription '%s' gave up on event '%s': %s", sub.ID, event.ID, info)
+	wh.callbacks.DeliveryResponse(connID, &fftypes.EventDeliveryResponse{
+		ID:           event.ID,
+		Rejected:     false,
+		Info:         info,
+		Subscription: event.Subscription,
+		DeadLetter:   true,
+	})
+	return nil
+}
+
 func (wh *WebHooks) DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery, data []*fftypes.Data) error {
 	if event.Message == nil && sub.Options.WithData != nil && *sub.Options.WithData {
 		log.L(wh.ctx).Debugf("Webhook withData=true subscription called with non-message event '%s'", event.ID)
@@ -20,12 +20,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
+	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
 	"github.com/hyperledger/firefly/pkg/events"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -39,7 +43,7 @@ func newTestWebHooks(t *testing.T) (wh *WebHooks, cancel func()) {
 	cbs := &eventsmocks.Callbacks{}
 	rc := cbs.On("RegisterConnection", mock.Anything, mock.Anything).Return(nil)
 	rc.RunFn = func(a mock.Arguments) {
-		assert.Equal(t, true, a[1].(events.SubscriptionMatcher)(fftypes.SubscriptionRef{}))
+		assert.Equal(t, true, a[1].(events.SubscriptionMatcher)(&fftypes.Subscription{}))
 	}
 	wh = &WebHooks{}
 	ctx, cancelCtx := context.WithCancel(context.Background())
@@ -52,6 +56,44 @@ func newTestWebHooks(t *testing.T) (wh *WebHooks, cancel func()) {
 	return wh, cancelCtx
 }
 
+func TestInitBadTLS(t *testing.T) {
+	config.Reset()
+
+	wh := &WebHooks{}
+	svrPrefix := config.NewPluginConfig("ut.webhooks")
+	wh.InitPrefix(svrPrefix)
+	svrPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	svrPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := wh.Init(context.Background(), svrPrefix, &eventsmocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestReloadConfig(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	oldClient := wh.client
+	svrPrefix := config.NewPluginConfig("ut.webhooks")
+	svrPrefix.Set(restclient.HTTPConfigRequestTimeout, "1s")
+	err := wh.ReloadConfig(context.Background(), svrPrefix)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldClient, wh.client)
+	assert.Equal(t, 1*time.Second, wh.client.GetClient().Timeout)
+}
+
+func TestReloadConfigBadTLS(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	oldClient := wh.client
+	svrPrefix := config.NewPluginConfig("ut.webhooks")
+	svrPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	svrPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := wh.ReloadConfig(context.Background(), svrPrefix)
+	assert.Regexp(t, "FF10105", err)
+	assert.Equal(t, oldClient, wh.client)
+}
+
 func TestValidateOptionsWithDataFalse(t *testing.T) {
 	wh, cancel := newTestWebHooks(t)
 	defer cancel()
@@ -711,3 +753,299 @@ func TestDeliveryRequestReplyToReply(t *testing.T) {
 	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
 	assert.NoError(t, err)
 }
+
+func TestRequestSignedWithSecret(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+
+	called := false
+	r := mux.NewRouter()
+	r.HandleFunc("/myapi", func(res http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		timestamp := req.Header.Get(HeaderTimestamp)
+		nonce := req.Header.Get(HeaderNonce)
+		assert.NotEmpty(t, timestamp)
+		assert.NotEmpty(t, nonce)
+		assert.Equal(t, Signature("secret1", timestamp, nonce, body), req.Header.Get(HeaderSignature))
+		var parsed fftypes.JSONObject
+		err = json.Unmarshal(body, &parsed)
+		assert.NoError(t, err)
+		assert.Equal(t, msgID.String(), parsed.GetObject("message").GetObject("header").GetString("id"))
+		res.WriteHeader(200)
+		called = true
+	}).Methods(http.MethodPost)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub := &fftypes.Subscription{}
+	to := sub.Options.TransportOptions()
+	to["url"] = fmt.Sprintf("http://%s/myapi", server.Listener.Addr())
+	to["secret"] = "secret1"
+	event := &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID: fftypes.NewUUID(),
+		},
+		Subscription: fftypes.SubscriptionRef{
+			ID: sub.ID,
+		},
+		Message: &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:   msgID,
+				Type: fftypes.MessageTypeBroadcast,
+			},
+		},
+	}
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, []*fftypes.Data{})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestRequestSignedWithSecretNoBody(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	called := false
+	r := mux.NewRouter()
+	r.HandleFunc("/myapi", func(res http.ResponseWriter, req *http.Request) {
+		timestamp := req.Header.Get(HeaderTimestamp)
+		nonce := req.Header.Get(HeaderNonce)
+		assert.Equal(t, Signature("secret1", timestamp, nonce, nil), req.Header.Get(HeaderSignature))
+		res.WriteHeader(200)
+		called = true
+	}).Methods(http.MethodGet)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub := &fftypes.Subscription{}
+	to := sub.Options.TransportOptions()
+	to["url"] = fmt.Sprintf("http://%s/myapi", server.Listener.Addr())
+	to["method"] = http.MethodGet
+	to["secret"] = "secret1"
+	event := &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID: fftypes.NewUUID(),
+		},
+		Message: &fftypes.Message{},
+	}
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, []*fftypes.Data{})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestSignRequest(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	req := &whRequest{
+		r:      wh.client.R(),
+		secret: "secret1",
+	}
+	signRequest(req, time.Unix(1000000000, 0), "nonce1", []byte(`{"some":"body"}`))
+	assert.Equal(t, "1000000000", req.r.Header.Get(HeaderTimestamp))
+	assert.Equal(t, "nonce1", req.r.Header.Get(HeaderNonce))
+	assert.Equal(t, Signature("secret1", "1000000000", "nonce1", []byte(`{"some":"body"}`)), req.r.Header.Get(HeaderSignature))
+	assert.NotEqual(t, Signature("secret2", "1000000000", "nonce1", []byte(`{"some":"body"}`)), req.r.Header.Get(HeaderSignature))
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", req.r.Header.Get(HeaderSignature))
+}
+
+func TestValidateOptionsBadRetry(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	for _, retry := range []fftypes.JSONObject{
+		{"count": float64(-1)},
+		{"count": "many"},
+		{"initialDelay": "soon"},
+		{"maxDelay": "-1s"},
+		{"factor": 0.5},
+	} {
+		opts := &fftypes.SubscriptionOptions{}
+		opts.TransportOptions()["url"] = "/anything"
+		opts.TransportOptions()["retry"] = retry
+		err := wh.ValidateOptions(opts)
+		assert.Regexp(t, "FF10389", err)
+	}
+
+	opts := &fftypes.SubscriptionOptions{}
+	opts.TransportOptions()["url"] = "/anything"
+	opts.TransportOptions()["deadletter"] = "discard"
+	err := wh.ValidateOptions(opts)
+	assert.Regexp(t, "FF10390", err)
+}
+
+func TestRetryDelay(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	retry, err := wh.parseRetry(fftypes.JSONObject{
+		"retry": fftypes.JSONObject{
+			"enabled":      true,
+			"count":        float64(10),
+			"initialDelay": "100ms",
+			"maxDelay":     "1s",
+			"factor":       float64(3),
+		},
+		"deadletter": "event",
+	})
+	assert.NoError(t, err)
+	assert.True(t, retry.enabled)
+	assert.Equal(t, 10, retry.count)
+	assert.Equal(t, DeadLetterEvent, retry.deadLetter)
+	assert.Equal(t, 100*time.Millisecond, retry.delay(1))
+	assert.Equal(t, 300*time.Millisecond, retry.delay(2))
+	assert.Equal(t, 900*time.Millisecond, retry.delay(3))
+	assert.Equal(t, 1*time.Second, retry.delay(4))
+}
+
+func newTestRetrySubscription(url string, options fftypes.JSONObject) (*fftypes.Subscription, *fftypes.EventDelivery) {
+	sub := &fftypes.Subscription{}
+	to := sub.Options.TransportOptions()
+	to["url"] = url
+	for k, v := range options {
+		to[k] = v
+	}
+	event := &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID: fftypes.NewUUID(),
+		},
+		Subscription: fftypes.SubscriptionRef{
+			ID: sub.ID,
+		},
+		Message: &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:   fftypes.NewUUID(),
+				Type: fftypes.MessageTypeBroadcast,
+			},
+		},
+	}
+	return sub, event
+}
+
+func TestRequestRetryThenSuccess(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	calls := 0
+	r := mux.NewRouter()
+	r.HandleFunc("/myapi", func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			res.WriteHeader(503)
+			return
+		}
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(200)
+		res.Write([]byte(`{"ok":true}`))
+	}).Methods(http.MethodPost)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub, event := newTestRetrySubscription(fmt.Sprintf("http://%s/myapi", server.Listener.Addr()), fftypes.JSONObject{
+		"reply": true,
+		"retry": fftypes.JSONObject{
+			"enabled":      true,
+			"initialDelay": "1ms",
+		},
+		"deadletter": "event",
+	})
+
+	mcb := wh.callbacks.(*eventsmocks.Callbacks)
+	mcb.On("DeliveryResponse", mock.Anything, mock.MatchedBy(func(response *fftypes.EventDeliveryResponse) bool {
+		return !response.DeadLetter && response.Reply.InlineData[0].Value.JSONObject()["status"] == float64(200)
+	})).Return(nil)
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestRequestRetryExhaustedDeadLetterEvent(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	calls := 0
+	r := mux.NewRouter()
+	r.HandleFunc("/myapi", func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.WriteHeader(429)
+	}).Methods(http.MethodPost)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub, event := newTestRetrySubscription(fmt.Sprintf("http://%s/myapi", server.Listener.Addr()), fftypes.JSONObject{
+		"retry": fftypes.JSONObject{
+			"enabled":      true,
+			"count":        float64(2),
+			"initialDelay": "1ms",
+		},
+		"deadletter": "event",
+	})
+
+	mcb := wh.callbacks.(*eventsmocks.Callbacks)
+	mcb.On("DeliveryResponse", mock.Anything, mock.MatchedBy(func(response *fftypes.EventDeliveryResponse) bool {
+		return response.DeadLetter && !response.Rejected && response.ID.Equals(event.ID) && response.Info == "Webhook request failed: status 429"
+	})).Return(nil)
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestRequestDeadLetterPark(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	server := httptest.NewServer(mux.NewRouter())
+	server.Close()
+
+	sub, event := newTestRetrySubscription(fmt.Sprintf("http://%s/myapi", server.Listener.Addr()), fftypes.JSONObject{
+		"reply":      true,
+		"deadletter": "park",
+	})
+
+	// No response is sent - not even the reply
+	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
+	assert.NoError(t, err)
+
+	mcb := wh.callbacks.(*eventsmocks.Callbacks)
+	mcb.AssertNotCalled(t, "DeliveryResponse", mock.Anything, mock.Anything)
+}
+
+func TestRequestRetryCancelled(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+
+	server := httptest.NewServer(mux.NewRouter())
+	server.Close()
+
+	sub, event := newTestRetrySubscription(fmt.Sprintf("http://%s/myapi", server.Listener.Addr()), fftypes.JSONObject{
+		"retry": fftypes.JSONObject{
+			"enabled":      true,
+			"initialDelay": "10s",
+		},
+	})
+
+	cancel()
+	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestRequestBadRetryOptions(t *testing.T) {
+	wh, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	sub, event := newTestRetrySubscription("/anything", fftypes.JSONObject{
+		"deadletter": "discard",
+	})
+
+	err := wh.DeliveryRequest(mock.Anything, sub, event, nil)
+	assert.Regexp(t, "FF10390", err)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websockets
+
+import (
+	"io"
+
+	"github.com/gorilla/websocket"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	// blobFrameHeaderLen is the length of the header on each binary frame of blob content - the 16 byte data ID, then a flags byte
+	blobFrameHeaderLen = 17
+	// blobFrameFinal is set in the flags byte of the last frame of each blob
+	blobFrameFinal byte = 0x01
+)
+
+// blobDelivery is an event with data, to be followed on the socket by the content of the blobs attached to the data
+type blobDelivery struct {
+	event   *fftypes.WSEventDeliveryWithData
+	readers []io.ReadCloser
+}
+
+func (bd *blobDelivery) close() {
+	for _, r := range bd.readers {
+		_ = r.Close()
+	}
+}
+
+// writeBlobDelivery writes the event, then streams each blob in turn. The client relies on the frames of each
+// blob following the event with nothing in between, which holds as this is only called from the send loop.
+func (wc *websocketConnection) writeBlobDelivery(bd *blobDelivery) error {
+	defer bd.close()
+	if err := wc.writeMessage(bd.event); err != nil {
+		return err
+	}
+	for i, reader := range bd.readers {
+		if err := wc.streamBlob(bd.event.Blobs[i].Data, reader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wc *websocketConnection) streamBlob(dataID *fftypes.UUID, reader io.Reader) error {
+	frame := make([]byte, blobFrameHeaderLen+wc.blobChunk)
+	copy(frame, dataID[:])
+	for {
+		n, err := io.ReadFull(reader, frame[blobFrameHeaderLen:])
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			return err
+		}
+		if final {
+			frame[blobFrameHeaderLen-1] = blobFrameFinal
+		}
+		if err := wc.wsConn.WriteMessage(websocket.BinaryMessage, frame[:blobFrameHeaderLen+n]); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
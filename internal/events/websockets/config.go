@@ -19,7 +19,9 @@ package websockets
 import "github.com/hyperledger/firefly/internal/config"
 
 const (
-	bufferSizeDefault = "16Kb"
+	bufferSizeDefault       = "16Kb"
+	compressionLevelDefault = 1
+	blobChunkSizeDefault    = "64Kb"
 )
 
 const (
@@ -27,10 +29,18 @@ const (
 	ReadBufferSize = "readBufferSize"
 	// WriteBufferSize is the write buffer size for the socket
 	WriteBufferSize = "writeBufferSize"
+	// EnableCompression negotiates permessage-deflate compression with clients that request it
+	EnableCompression = "enableCompression"
+	// CompressionLevel is the flate compression level used when compression is negotiated (1-9)
+	CompressionLevel = "compressionLevel"
+	// BlobChunkSize is the maximum amount of blob content sent in each binary frame, for subscriptions with data enabled
+	BlobChunkSize = "blobChunkSize"
 )
 
 func (ws *WebSockets) InitPrefix(prefix config.Prefix) {
 	prefix.AddKnownKey(ReadBufferSize, bufferSizeDefault)
 	prefix.AddKnownKey(WriteBufferSize, bufferSizeDefault)
-
+	prefix.AddKnownKey(EnableCompression, false)
+	prefix.AddKnownKey(CompressionLevel, compressionLevelDefault)
+	prefix.AddKnownKey(BlobChunkSize, blobChunkSizeDefault)
 }
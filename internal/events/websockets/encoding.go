@@ -0,0 +1,319 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websockets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// EncodingJSON is the default encoding, with each payload sent as a JSON text frame
+	EncodingJSON = "json"
+	// EncodingCBOR sends each payload as a CBOR (RFC 8949) binary frame
+	EncodingCBOR = "cbor"
+	// EncodingMsgPack sends each payload as a MessagePack binary frame
+	EncodingMsgPack = "msgpack"
+)
+
+// supportedEncodings are offered as websocket sub-protocols, in order of server preference
+var supportedEncodings = []string{EncodingJSON, EncodingCBOR, EncodingMsgPack}
+
+// messageEncoding writes payloads sent from the server to the client. Payloads received from
+// the client (start and ack actions) are always JSON, regardless of the encoding.
+type messageEncoding interface {
+	name() string
+	messageType() int
+	encode(w io.Writer, msg interface{}) error
+}
+
+func getEncoding(name string) (messageEncoding, bool) {
+	switch name {
+	case "", EncodingJSON:
+		return jsonEncoding{}, true
+	case EncodingCBOR:
+		return binaryEncoding{encName: EncodingCBOR, newWriter: func(buff *bytes.Buffer) valueWriter { return &cborWriter{buff} }}, true
+	case EncodingMsgPack:
+		return binaryEncoding{encName: EncodingMsgPack, newWriter: func(buff *bytes.Buffer) valueWriter { return &msgpackWriter{buff} }}, true
+	default:
+		return nil, false
+	}
+}
+
+type jsonEncoding struct{}
+
+func (jsonEncoding) name() string { return EncodingJSON }
+
+func (jsonEncoding) messageType() int { return websocket.TextMessage }
+
+func (jsonEncoding) encode(w io.Writer, msg interface{}) error {
+	return json.NewEncoder(w).Encode(msg)
+}
+
+// binaryEncoding re-uses the JSON serialization of each payload (so field names and value
+// formats are identical across encodings), then writes the generic JSON value tree in the
+// target binary format.
+type binaryEncoding struct {
+	encName   string
+	newWriter func(buff *bytes.Buffer) valueWriter
+}
+
+func (be binaryEncoding) name() string { return be.encName }
+
+func (be binaryEncoding) messageType() int { return websocket.BinaryMessage }
+
+func (be binaryEncoding) encode(w io.Writer, msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var value interface{}
+	if err = decoder.Decode(&value); err != nil {
+		return err
+	}
+	buff := &bytes.Buffer{}
+	writeValue(be.newWriter(buff), value)
+	_, err = w.Write(buff.Bytes())
+	return err
+}
+
+type valueWriter interface {
+	writeNil()
+	writeBool(v bool)
+	writeInt(v int64)
+	writeUint(v uint64)
+	writeFloat(v float64)
+	writeString(v string)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+}
+
+func writeValue(vw valueWriter, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		vw.writeNil()
+	case bool:
+		vw.writeBool(v)
+	case string:
+		vw.writeString(v)
+	case json.Number:
+		writeNumber(vw, v)
+	case []interface{}:
+		vw.writeArrayHeader(len(v))
+		for _, entry := range v {
+			writeValue(vw, entry)
+		}
+	case map[string]interface{}:
+		// Sort the keys, so the output is deterministic
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		vw.writeMapHeader(len(keys))
+		for _, k := range keys {
+			vw.writeString(k)
+			writeValue(vw, v[k])
+		}
+	}
+}
+
+func writeNumber(vw valueWriter, n json.Number) {
+	if i, err := n.Int64(); err == nil {
+		if i < 0 {
+			vw.writeInt(i)
+		} else {
+			vw.writeUint(uint64(i))
+		}
+		return
+	}
+	f, _ := n.Float64()
+	vw.writeFloat(f)
+}
+
+type cborWriter struct {
+	buff *bytes.Buffer
+}
+
+const (
+	cborMajorUint   = 0
+	cborMajorNegInt = 1
+	cborMajorText   = 3
+	cborMajorArray  = 4
+	cborMajorMap    = 5
+)
+
+func (cw *cborWriter) writeHead(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		cw.buff.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		cw.buff.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		cw.buff.WriteByte(major | 25)
+		_ = binary.Write(cw.buff, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		cw.buff.WriteByte(major | 26)
+		_ = binary.Write(cw.buff, binary.BigEndian, uint32(n))
+	default:
+		cw.buff.WriteByte(major | 27)
+		_ = binary.Write(cw.buff, binary.BigEndian, n)
+	}
+}
+
+func (cw *cborWriter) writeNil() { cw.buff.WriteByte(0xf6) }
+
+func (cw *cborWriter) writeBool(v bool) {
+	if v {
+		cw.buff.WriteByte(0xf5)
+	} else {
+		cw.buff.WriteByte(0xf4)
+	}
+}
+
+func (cw *cborWriter) writeInt(v int64) {
+	if v < 0 {
+		cw.writeHead(cborMajorNegInt, uint64(-(v + 1)))
+	} else {
+		cw.writeHead(cborMajorUint, uint64(v))
+	}
+}
+
+func (cw *cborWriter) writeUint(v uint64) { cw.writeHead(cborMajorUint, v) }
+
+func (cw *cborWriter) writeFloat(v float64) {
+	cw.buff.WriteByte(0xfb)
+	_ = binary.Write(cw.buff, binary.BigEndian, math.Float64bits(v))
+}
+
+func (cw *cborWriter) writeString(v string) {
+	cw.writeHead(cborMajorText, uint64(len(v)))
+	cw.buff.WriteString(v)
+}
+
+func (cw *cborWriter) writeArrayHeader(n int) { cw.writeHead(cborMajorArray, uint64(n)) }
+
+func (cw *cborWriter) writeMapHeader(n int) { cw.writeHead(cborMajorMap, uint64(n)) }
+
+type msgpackWriter struct {
+	buff *bytes.Buffer
+}
+
+func (mw *msgpackWriter) writeNil() { mw.buff.WriteByte(0xc0) }
+
+func (mw *msgpackWriter) writeBool(v bool) {
+	if v {
+		mw.buff.WriteByte(0xc3)
+	} else {
+		mw.buff.WriteByte(0xc2)
+	}
+}
+
+func (mw *msgpackWriter) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		mw.writeUint(uint64(v))
+	case v >= -32:
+		mw.buff.WriteByte(byte(int8(v)))
+	case v >= math.MinInt8:
+		mw.buff.Write([]byte{0xd0, byte(int8(v))})
+	case v >= math.MinInt16:
+		mw.buff.WriteByte(0xd1)
+		_ = binary.Write(mw.buff, binary.BigEndian, int16(v))
+	case v >= math.MinInt32:
+		mw.buff.WriteByte(0xd2)
+		_ = binary.Write(mw.buff, binary.BigEndian, int32(v))
+	default:
+		mw.buff.WriteByte(0xd3)
+		_ = binary.Write(mw.buff, binary.BigEndian, v)
+	}
+}
+
+func (mw *msgpackWriter) writeUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		mw.buff.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		mw.buff.Write([]byte{0xcc, byte(v)})
+	case v <= math.MaxUint16:
+		mw.buff.WriteByte(0xcd)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint16(v))
+	case v <= math.MaxUint32:
+		mw.buff.WriteByte(0xce)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint32(v))
+	default:
+		mw.buff.WriteByte(0xcf)
+		_ = binary.Write(mw.buff, binary.BigEndian, v)
+	}
+}
+
+func (mw *msgpackWriter) writeFloat(v float64) {
+	mw.buff.WriteByte(0xcb)
+	_ = binary.Write(mw.buff, binary.BigEndian, math.Float64bits(v))
+}
+
+func (mw *msgpackWriter) writeString(v string) {
+	l := len(v)
+	switch {
+	case l < 32:
+		mw.buff.WriteByte(0xa0 | byte(l))
+	case l <= math.MaxUint8:
+		mw.buff.Write([]byte{0xd9, byte(l)})
+	case l <= math.MaxUint16:
+		mw.buff.WriteByte(0xda)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint16(l))
+	default:
+		mw.buff.WriteByte(0xdb)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint32(l))
+	}
+	mw.buff.WriteString(v)
+}
+
+func (mw *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		mw.buff.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		mw.buff.WriteByte(0xdc)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint16(n))
+	default:
+		mw.buff.WriteByte(0xdd)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint32(n))
+	}
+}
+
+func (mw *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		mw.buff.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		mw.buff.WriteByte(0xde)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint16(n))
+	default:
+		mw.buff.WriteByte(0xdf)
+		_ = binary.Write(mw.buff, binary.BigEndian, uint32(n))
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/gorilla/websocket"
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -50,9 +51,15 @@ type websocketConnection struct {
 	mux                sync.Mutex
 	closed             bool
 	changeEventMatcher *regexp.Regexp
+	remoteAddr         string
+	userAgent          string
+	identity           string
+	connected          *fftypes.FFTime
+	encoding           messageEncoding
+	blobChunk          int
 }
 
-func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn) *websocketConnection {
+func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn, req *http.Request, encoding messageEncoding) *websocketConnection {
 	connID := fftypes.NewUUID().String()
 	ctx := log.WithLogField(pCtx, "websocket", connID)
 	ctx, cancelCtx := context.WithCancel(ctx)
@@ -64,6 +71,12 @@ func newConnection(pCtx context.Context, ws *WebSockets, wsConn *websocket.Conn)
 		connID:       connID,
 		sendMessages: make(chan interface{}),
 		senderDone:   make(chan struct{}),
+		remoteAddr:   req.RemoteAddr,
+		userAgent:    req.UserAgent(),
+		connected:    fftypes.Now(),
+		identity:     auth.RequestIdentity(req),
+		encoding:     encoding,
+		blobChunk:    ws.blobChunk,
 	}
 	go wc.sendLoop()
 	go wc.receiveLoop()
@@ -110,10 +123,11 @@ func (wc *websocketConnection) sendLoop() {
 				return
 			}
 			l.Tracef("Sending: %+v", msg)
-			writer, err := wc.wsConn.NextWriter(websocket.TextMessage)
-			if err == nil {
-				err = json.NewEncoder(writer).Encode(msg)
-				_ = writer.Close()
+			var err error
+			if delivery, isBlobDelivery := msg.(*blobDelivery); isBlobDelivery {
+				err = wc.writeBlobDelivery(delivery)
+			} else {
+				err = wc.writeMessage(msg)
 			}
 			if err != nil {
 				l.Errorf("Write failed on socket: %s", err)
@@ -126,6 +140,15 @@ func (wc *websocketConnection) sendLoop() {
 	}
 }
 
+func (wc *websocketConnection) writeMessage(msg interface{}) error {
+	writer, err := wc.wsConn.NextWriter(wc.encoding.messageType())
+	if err == nil {
+		err = wc.encoding.encode(writer, msg)
+		_ = writer.Close()
+	}
+	return err
+}
+
 func (wc *websocketConnection) receiveLoop() {
 	l := log.L(wc.ctx)
 	defer close(wc.sendMessages)
@@ -186,6 +209,42 @@ func (wc *websocketConnection) dispatchChangeEvent(ce *fftypes.ChangeEvent) erro
 }
 
 func (wc *websocketConnection) dispatch(event *fftypes.EventDelivery) error {
+	return wc.deliver(event, event)
+}
+
+// dispatchWithData sends the data of the message along with the event. The blobs are opened before the event is sent,
+// so a blob that cannot be retrieved fails the delivery (and the event is redelivered) rather than breaking the stream.
+func (wc *websocketConnection) dispatchWithData(event *fftypes.EventDelivery, data []*fftypes.Data) error {
+	delivery := &blobDelivery{
+		event: &fftypes.WSEventDeliveryWithData{
+			EventDelivery: event,
+			Data:          data,
+		},
+	}
+	for _, d := range data {
+		if d.Blob == nil || d.Blob.Hash == nil {
+			continue
+		}
+		reader, err := wc.ws.callbacks.DownloadBLOB(wc.ctx, d.Namespace, d.ID)
+		if err != nil {
+			delivery.close()
+			return err
+		}
+		delivery.readers = append(delivery.readers, reader)
+		delivery.event.Blobs = append(delivery.event.Blobs, &fftypes.WSBlobStream{
+			Data: d.ID,
+			Hash: d.Blob.Hash,
+			Size: d.Blob.Size,
+		})
+	}
+	err := wc.deliver(event, delivery)
+	if err != nil {
+		delivery.close()
+	}
+	return err
+}
+
+func (wc *websocketConnection) deliver(event *fftypes.EventDelivery, msg interface{}) error {
 	inflight := &fftypes.EventDeliveryResponse{
 		ID:           event.ID,
 		Subscription: event.Subscription,
@@ -199,7 +258,7 @@ func (wc *websocketConnection) dispatch(event *fftypes.EventDelivery) error {
 	}
 	wc.mux.Unlock()
 
-	err := wc.send(event)
+	err := wc.send(msg)
 	if err != nil {
 		return err
 	}
@@ -267,11 +326,15 @@ func (wc *websocketConnection) handleStart(start *fftypes.WSClientActionStartPay
 	return nil
 }
 
-func (wc *websocketConnection) durableSubMatcher(sr fftypes.SubscriptionRef) bool {
+func (wc *websocketConnection) durableSubMatcher(sub *fftypes.Subscription) bool {
+	// Applications can only attach to durable subscriptions owned by their identity
+	if !auth.CanAccessSubscription(wc.identity, sub) {
+		return false
+	}
 	wc.mux.Lock()
 	defer wc.mux.Unlock()
 	for _, startedSub := range wc.started {
-		if !startedSub.ephemeral && startedSub.namespace == sr.Namespace && startedSub.name == sr.Name {
+		if !startedSub.ephemeral && startedSub.namespace == sub.Namespace && startedSub.name == sub.Name {
 			return true
 		}
 	}
@@ -359,6 +422,36 @@ func (wc *websocketConnection) close() {
 	}
 }
 
+func (wc *websocketConnection) getStatus() *fftypes.WSConnectionStatus {
+	wc.mux.Lock()
+	defer wc.mux.Unlock()
+	status := &fftypes.WSConnectionStatus{
+		ID:            wc.connID,
+		RemoteAddress: wc.remoteAddr,
+		UserAgent:     wc.userAgent,
+		Identity:      wc.identity,
+		Connected:     wc.connected,
+		AutoAck:       wc.autoAck,
+		Encoding:      wc.encoding.name(),
+		Subscriptions: make([]*fftypes.WSSubscriptionStatus, len(wc.started)),
+		Inflight:      make([]*fftypes.WSInflightEvent, len(wc.inflight)),
+	}
+	for i, s := range wc.started {
+		status.Subscriptions[i] = &fftypes.WSSubscriptionStatus{
+			Ephemeral: s.ephemeral,
+			Namespace: s.namespace,
+			Name:      s.name,
+		}
+	}
+	for i, inflight := range wc.inflight {
+		status.Inflight[i] = &fftypes.WSInflightEvent{
+			ID:           inflight.ID,
+			Subscription: inflight.Subscription,
+		}
+	}
+	return status
+}
+
 func (wc *websocketConnection) waitClose() {
 	<-wc.senderDone
 	<-wc.sendMessages
@@ -19,6 +19,7 @@ package websockets
 import (
 	"context"
 	"net/http"
+	"sort"
 	"sync"
 
 	"github.com/gorilla/websocket"
@@ -36,6 +37,9 @@ type WebSockets struct {
 	connections  map[string]*websocketConnection
 	connMux      sync.Mutex
 	upgrader     websocket.Upgrader
+	compression  bool
+	compressLvl  int
+	blobChunk    int
 }
 
 func (ws *WebSockets) Name() string { return "websockets" }
@@ -47,10 +51,15 @@ func (ws *WebSockets) Init(ctx context.Context, prefix config.Prefix, callbacks
 		capabilities: &events.Capabilities{
 			ChangeEvents: true,
 		},
-		callbacks: callbacks,
+		callbacks:   callbacks,
+		compression: prefix.GetBool(EnableCompression),
+		compressLvl: prefix.GetInt(CompressionLevel),
+		blobChunk:   int(prefix.GetByteSize(BlobChunkSize)),
 		upgrader: websocket.Upgrader{
-			ReadBufferSize:  int(prefix.GetByteSize(ReadBufferSize)),
-			WriteBufferSize: int(prefix.GetByteSize(WriteBufferSize)),
+			ReadBufferSize:    int(prefix.GetByteSize(ReadBufferSize)),
+			WriteBufferSize:   int(prefix.GetByteSize(WriteBufferSize)),
+			EnableCompression: prefix.GetBool(EnableCompression),
+			Subprotocols:      supportedEncodings,
 			CheckOrigin: func(r *http.Request) bool {
 				// Cors is handled by the API server that wraps this handler
 				return true
@@ -60,6 +69,20 @@ func (ws *WebSockets) Init(ctx context.Context, prefix config.Prefix, callbacks
 	return nil
 }
 
+// ReloadConfig applies changes to the buffer sizes, compression and blob chunk size.
+// Existing connections continue with the settings they were established with.
+func (ws *WebSockets) ReloadConfig(ctx context.Context, prefix config.Prefix) error {
+	ws.connMux.Lock()
+	defer ws.connMux.Unlock()
+	ws.upgrader.ReadBufferSize = int(prefix.GetByteSize(ReadBufferSize))
+	ws.upgrader.WriteBufferSize = int(prefix.GetByteSize(WriteBufferSize))
+	ws.upgrader.EnableCompression = prefix.GetBool(EnableCompression)
+	ws.compression = prefix.GetBool(EnableCompression)
+	ws.compressLvl = prefix.GetInt(CompressionLevel)
+	ws.blobChunk = int(prefix.GetByteSize(BlobChunkSize))
+	return nil
+}
+
 func (ws *WebSockets) Capabilities() *events.Capabilities {
 	return ws.capabilities
 }
@@ -69,12 +92,11 @@ func (ws *WebSockets) GetOptionsSchema(ctx context.Context) string {
 }
 
 func (ws *WebSockets) ValidateOptions(options *fftypes.SubscriptionOptions) error {
-	// We don't support streaming the full data over websockets
-	if options.WithData != nil && *options.WithData {
-		return i18n.NewError(ws.ctx, i18n.MsgWebsocketsNoData)
+	// Data is delivered along with the event, with the content of any blobs streamed in binary frames after it
+	if options.WithData == nil {
+		forceFalse := false
+		options.WithData = &forceFalse
 	}
-	forceFalse := false
-	options.WithData = &forceFalse
 	return nil
 }
 
@@ -85,6 +107,9 @@ func (ws *WebSockets) DeliveryRequest(connID string, sub *fftypes.Subscription,
 	if !ok {
 		return i18n.NewError(ws.ctx, i18n.MsgWSConnectionNotActive, connID)
 	}
+	if sub != nil && sub.Options.WithData != nil && *sub.Options.WithData {
+		return conn.dispatchWithData(event, data)
+	}
 	return conn.dispatch(event)
 }
 
@@ -101,14 +126,50 @@ func (ws *WebSockets) ChangeEvent(connID string, ce *fftypes.ChangeEvent) {
 }
 
 func (ws *WebSockets) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	wsConn, err := ws.upgrader.Upgrade(res, req, nil)
+	// Browsers cannot set headers on a WebSocket upgrade, so the "lang" query parameter
+	// is supported in addition to Accept-Language
+	ctx := ws.ctx
+	lang := req.URL.Query().Get("lang")
+	if lang == "" {
+		lang = req.Header.Get("Accept-Language")
+	}
+	if lang != "" {
+		ctx = i18n.WithLang(ctx, i18n.NegotiateLang(lang))
+	}
+
+	// An explicit encoding on the query string must be one we support, as unlike a
+	// sub-protocol we cannot fall back to the default without the client knowing
+	queryEncoding := req.URL.Query().Get("encoding")
+	if _, ok := getEncoding(queryEncoding); !ok {
+		err := i18n.NewError(ctx, i18n.MsgWSUnsupportedEncoding, queryEncoding)
+		log.L(ctx).Errorf("WebSocket upgrade failed: %s", err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws.connMux.Lock()
+	upgrader, compression, compressLvl := ws.upgrader, ws.compression, ws.compressLvl
+	ws.connMux.Unlock()
+
+	wsConn, err := upgrader.Upgrade(res, req, nil)
 	if err != nil {
 		log.L(ws.ctx).Errorf("WebSocket upgrade failed: %s", err)
 		return
 	}
+	if compression {
+		// Only takes effect if the client negotiated permessage-deflate
+		_ = wsConn.SetCompressionLevel(compressLvl)
+	}
+
+	// A negotiated sub-protocol takes precedence over the query string
+	encodingName := wsConn.Subprotocol()
+	if encodingName == "" {
+		encodingName = queryEncoding
+	}
+	encoding, _ := getEncoding(encodingName)
 
 	ws.connMux.Lock()
-	wc := newConnection(ws.ctx, ws, wsConn)
+	wc := newConnection(ctx, ws, wsConn, req, encoding)
 	ws.connections[wc.connID] = wc
 	ws.connMux.Unlock()
 
@@ -121,14 +182,14 @@ func (ws *WebSockets) ack(connID string, inflight *fftypes.EventDeliveryResponse
 
 func (ws *WebSockets) start(wc *websocketConnection, start *fftypes.WSClientActionStartPayload) error {
 	if start.Namespace == "" || (!start.Ephemeral && start.Name == "") {
-		return i18n.NewError(ws.ctx, i18n.MsgWSInvalidStartAction)
+		return i18n.NewError(wc.ctx, i18n.MsgWSInvalidStartAction)
 	}
 	if start.Ephemeral {
 		return ws.callbacks.EphemeralSubscription(wc.connID, start.Namespace, &start.Filter, &start.Options)
 	}
 	// We can have multiple subscriptions on a single
-	return ws.callbacks.RegisterConnection(wc.connID, func(sr fftypes.SubscriptionRef) bool {
-		return wc.durableSubMatcher(sr)
+	return ws.callbacks.RegisterConnection(wc.connID, func(sub *fftypes.Subscription) bool {
+		return wc.durableSubMatcher(sub)
 	})
 }
 
@@ -140,6 +201,36 @@ func (ws *WebSockets) connClosed(connID string) {
 	ws.callbacks.ConnnectionClosed(connID)
 }
 
+// GetStatus returns the status of all connected websockets, sorted by the time they connected
+func (ws *WebSockets) GetStatus() *fftypes.WebSocketStatus {
+	status := &fftypes.WebSocketStatus{
+		Connections: make([]*fftypes.WSConnectionStatus, 0),
+	}
+	ws.connMux.Lock()
+	for _, wc := range ws.connections {
+		status.Connections = append(status.Connections, wc.getStatus())
+	}
+	ws.connMux.Unlock()
+	sort.Slice(status.Connections, func(i, j int) bool {
+		return status.Connections[i].Connected.UnixNano() < status.Connections[j].Connected.UnixNano()
+	})
+	return status
+}
+
+// CloseConnection forcibly closes a websocket connection. Any events in flight are
+// returned to the subscription for redelivery, as with any other disconnect.
+func (ws *WebSockets) CloseConnection(ctx context.Context, connID string) error {
+	ws.connMux.Lock()
+	wc, ok := ws.connections[connID]
+	ws.connMux.Unlock()
+	if !ok {
+		return i18n.NewError(ctx, i18n.MsgWSConnectionNotFound, connID)
+	}
+	log.L(ctx).Infof("Closing websocket connection '%s' on request", connID)
+	wc.close()
+	return nil
+}
+
 func (ws *WebSockets) WaitClosed() {
 	closedConnections := []*websocketConnection{}
 	ws.connMux.Lock()
@@ -17,17 +17,24 @@
 package websockets
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/config/wsconfig"
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/eventsmocks"
@@ -60,9 +67,10 @@ func newTestWebsockets(t *testing.T, cbs *eventsmocks.Callbacks, queryParams ...
 		qs = fmt.Sprintf("?%s", strings.Join(queryParams, "&"))
 	}
 	clientPrefix.Set(restclient.HTTPConfigURL, fmt.Sprintf("http://%s%s", svr.Listener.Addr(), qs))
-	wsConfig := wsconfig.GenerateConfigFromPrefix(clientPrefix)
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(ctx, clientPrefix)
+	assert.NoError(t, err)
 
-	wsc, err := wsclient.New(ctx, wsConfig, nil)
+	wsc, err = wsclient.New(ctx, wsConfig, nil)
 	assert.NoError(t, err)
 	err = wsc.Connect()
 	assert.NoError(t, err)
@@ -79,18 +87,20 @@ func newTestWebsockets(t *testing.T, cbs *eventsmocks.Callbacks, queryParams ...
 	}
 }
 
-func TestValidateOptionsFail(t *testing.T) {
+func TestValidateOptionsWithData(t *testing.T) {
 	cbs := &eventsmocks.Callbacks{}
 	ws, _, cancel := newTestWebsockets(t, cbs)
 	defer cancel()
 
 	yes := true
-	err := ws.ValidateOptions(&fftypes.SubscriptionOptions{
+	opts := &fftypes.SubscriptionOptions{
 		SubscriptionCoreOptions: fftypes.SubscriptionCoreOptions{
 			WithData: &yes,
 		},
-	})
-	assert.Regexp(t, "FF10244", err)
+	}
+	err := ws.ValidateOptions(opts)
+	assert.NoError(t, err)
+	assert.True(t, *opts.WithData)
 }
 
 func TestValidateOptionsOk(t *testing.T) {
@@ -153,6 +163,23 @@ func TestSendEmptyStartAction(t *testing.T) {
 	assert.Regexp(t, "FF10176", res.Error)
 }
 
+func TestSendEmptyStartActionLang(t *testing.T) {
+	err := i18n.LoadCatalog("de", map[string]string{string(i18n.MsgWSInvalidStartAction): "Ungültige Startaktion"})
+	assert.NoError(t, err)
+	cbs := &eventsmocks.Callbacks{}
+	_, wsc, cancel := newTestWebsockets(t, cbs, "lang=de")
+	defer cancel()
+
+	err = wsc.Send(context.Background(), []byte(`{"type":"start"}`))
+	assert.NoError(t, err)
+	b := <-wsc.Receive()
+	var res fftypes.WSProtocolErrorPayload
+	err = json.Unmarshal(b, &res)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.WSProtocolErrorEventType, res.Type)
+	assert.Regexp(t, "FF10178: Ungültige Startaktion", res.Error)
+}
+
 func TestStartReceiveAckEphemeral(t *testing.T) {
 	log.SetLevel("trace")
 
@@ -206,9 +233,10 @@ func TestStartReceiveDurable(t *testing.T) {
 	sub := cbs.On("RegisterConnection",
 		mock.MatchedBy(func(s string) bool { connID = s; return true }),
 		mock.MatchedBy(func(subMatch events.SubscriptionMatcher) bool {
-			return subMatch(fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub1"}) &&
-				!subMatch(fftypes.SubscriptionRef{Namespace: "ns2", Name: "sub1"}) &&
-				!subMatch(fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub2"})
+			return subMatch(&fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub1"}}) &&
+				!subMatch(&fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{Namespace: "ns2", Name: "sub1"}}) &&
+				!subMatch(&fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub2"}}) &&
+				!subMatch(&fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{Namespace: "ns1", Name: "sub1"}, Owner: "CN=other"})
 		}),
 	).Return(nil)
 	ack := cbs.On("DeliveryResponse",
@@ -630,3 +658,518 @@ func TestWebsocketSendAfterClose(t *testing.T) {
 	err = connection.send(map[string]string{"foo": "bar"})
 	assert.Regexp(t, "FF10290", err)
 }
+
+func TestGetStatusAndCloseConnection(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ws, wsc, cancel := newTestWebsockets(t, cbs)
+	defer cancel()
+
+	subscribedConn := make(chan string, 1)
+	cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool {
+			subscribedConn <- s
+			return true
+		}),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+
+	err := wsc.Send(context.Background(), []byte(`{"type":"start","namespace":"ns1","ephemeral":true}`))
+	assert.NoError(t, err)
+	connID := <-subscribedConn
+
+	eventID := fftypes.NewUUID()
+	subRef := fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1", Name: "ephemeral1"}
+	err = ws.DeliveryRequest(connID, nil, &fftypes.EventDelivery{
+		Event:        fftypes.Event{ID: eventID},
+		Subscription: subRef,
+	}, nil)
+	assert.NoError(t, err)
+	<-wsc.Receive()
+
+	// An older connection, directly in the map, to check ordering
+	oldConn := &websocketConnection{
+		connID:    "old",
+		connected: fftypes.UnixTime(0),
+		encoding:  jsonEncoding{},
+	}
+	ws.connMux.Lock()
+	ws.connections[oldConn.connID] = oldConn
+	ws.connMux.Unlock()
+
+	status := ws.GetStatus()
+	assert.Len(t, status.Connections, 2)
+	assert.Equal(t, "old", status.Connections[0].ID)
+	conn := status.Connections[1]
+	assert.Equal(t, connID, conn.ID)
+	assert.NotEmpty(t, conn.RemoteAddress)
+	assert.NotNil(t, conn.Connected)
+	assert.Equal(t, EncodingJSON, conn.Encoding)
+	assert.Equal(t, []*fftypes.WSSubscriptionStatus{{Ephemeral: true, Namespace: "ns1"}}, conn.Subscriptions)
+	assert.Equal(t, []*fftypes.WSInflightEvent{{ID: eventID, Subscription: subRef}}, conn.Inflight)
+
+	ws.connMux.Lock()
+	delete(ws.connections, oldConn.connID)
+	ws.connMux.Unlock()
+
+	err = ws.CloseConnection(context.Background(), connID)
+	assert.NoError(t, err)
+	assert.Empty(t, ws.GetStatus().Connections)
+
+	err = ws.CloseConnection(context.Background(), connID)
+	assert.Regexp(t, "FF10310", err)
+}
+
+func TestGetStatusTLSIdentity(t *testing.T) {
+	config.Reset()
+	cbs := &eventsmocks.Callbacks{}
+	cbs.On("ConnnectionClosed", mock.Anything).Return(nil).Maybe()
+
+	ws := &WebSockets{}
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	svrPrefix := config.NewPluginConfig("ut.websockets")
+	ws.InitPrefix(svrPrefix)
+	ws.Init(ctx, svrPrefix, cbs)
+
+	svr := httptest.NewUnstartedServer(ws)
+	svr.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	svr.StartTLS()
+	defer svr.Close()
+
+	// The httptest certificate is presented by the client too
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(svr.Certificate())
+	wsConfig := &wsclient.WSConfig{
+		HTTPURL: svr.URL,
+		TLSClientConfig: &tls.Config{
+			RootCAs:      rootCAs,
+			Certificates: svr.TLS.Certificates,
+		},
+	}
+	wsc, err := wsclient.New(ctx, wsConfig, nil)
+	assert.NoError(t, err)
+	err = wsc.Connect()
+	assert.NoError(t, err)
+	defer wsc.Close()
+
+	// The server registers the connection after the upgrade completes
+	status := ws.GetStatus()
+	for len(status.Connections) == 0 {
+		time.Sleep(1 * time.Millisecond)
+		status = ws.GetStatus()
+	}
+	assert.Len(t, status.Connections, 1)
+	assert.Equal(t, "O=Acme Co", status.Connections[0].Identity)
+	assert.Equal(t, "Go-http-client/1.1", status.Connections[0].UserAgent)
+}
+
+func TestEncodingCBOR(t *testing.T) {
+	enc, ok := getEncoding(EncodingCBOR)
+	assert.True(t, ok)
+	assert.Equal(t, websocket.BinaryMessage, enc.messageType())
+
+	buff := &bytes.Buffer{}
+	err := enc.encode(buff, map[string]interface{}{
+		"a": 1,
+		"b": []interface{}{true, false, nil, "x"},
+		"c": -2,
+		"d": 1.5,
+		"e": 300,
+		"f": -200,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xa6,
+		0x61, 'a', 0x01,
+		0x61, 'b', 0x84, 0xf5, 0xf4, 0xf6, 0x61, 'x',
+		0x61, 'c', 0x21,
+		0x61, 'd', 0xfb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x61, 'e', 0x19, 0x01, 0x2c,
+		0x61, 'f', 0x38, 0xc7,
+	}, buff.Bytes())
+}
+
+func TestEncodingMsgPack(t *testing.T) {
+	enc, ok := getEncoding(EncodingMsgPack)
+	assert.True(t, ok)
+	assert.Equal(t, websocket.BinaryMessage, enc.messageType())
+
+	buff := &bytes.Buffer{}
+	err := enc.encode(buff, map[string]interface{}{
+		"a": 1,
+		"b": []interface{}{true, false, nil, "x"},
+		"c": -2,
+		"d": 1.5,
+		"e": 300,
+		"f": -200,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x86,
+		0xa1, 'a', 0x01,
+		0xa1, 'b', 0x94, 0xc3, 0xc2, 0xc0, 0xa1, 'x',
+		0xa1, 'c', 0xfe,
+		0xa1, 'd', 0xcb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xa1, 'e', 0xcd, 0x01, 0x2c,
+		0xa1, 'f', 0xd1, 0xff, 0x38,
+	}, buff.Bytes())
+}
+
+func TestEncodingLargeValues(t *testing.T) {
+	longString := strings.Repeat("z", 70000)
+	longArray := make([]interface{}, 70000)
+	longMap := make(map[string]interface{}, 20)
+	for i := 0; i < 20; i++ {
+		longMap[fmt.Sprintf("k%.2d", i)] = i
+	}
+	values := []interface{}{
+		int64(5000000000), int64(-5000000000), int64(-70000), int64(-100), int64(100000),
+		longString, longArray, longMap, strings.Repeat("y", 40), strings.Repeat("w", 300),
+		make([]interface{}, 20),
+	}
+
+	for _, name := range []string{EncodingCBOR, EncodingMsgPack} {
+		enc, _ := getEncoding(name)
+		buff := &bytes.Buffer{}
+		err := enc.encode(buff, values)
+		assert.NoError(t, err)
+		assert.Greater(t, buff.Len(), len(longString)+len(longArray))
+	}
+}
+
+func TestEncodingBadData(t *testing.T) {
+	for _, name := range []string{EncodingCBOR, EncodingMsgPack} {
+		enc, _ := getEncoding(name)
+		err := enc.encode(&bytes.Buffer{}, map[bool]bool{false: true})
+		assert.Error(t, err)
+	}
+	_, ok := getEncoding("xml")
+	assert.False(t, ok)
+}
+
+func TestUpgradeBadEncoding(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	_, wsc, cancel := newTestWebsockets(t, cbs)
+	defer cancel()
+
+	u, _ := url.Parse(wsc.URL())
+	u.RawQuery = "encoding=xml"
+	_, res, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+}
+
+func TestStartReceiveNegotiatedEncodingWithCompression(t *testing.T) {
+	config.Reset()
+	cbs := &eventsmocks.Callbacks{}
+	cbs.On("ConnnectionClosed", mock.Anything).Return(nil).Maybe()
+
+	ws := &WebSockets{}
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	svrPrefix := config.NewPluginConfig("ut.websockets")
+	ws.InitPrefix(svrPrefix)
+	svrPrefix.Set(EnableCompression, true)
+	ws.Init(ctx, svrPrefix, cbs)
+
+	svr := httptest.NewServer(ws)
+	defer svr.Close()
+
+	subscribedConn := make(chan string, 1)
+	cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool {
+			subscribedConn <- s
+			return true
+		}),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+
+	// The sub-protocol takes precedence over the query string
+	dialer := &websocket.Dialer{
+		Subprotocols:      []string{EncodingMsgPack},
+		EnableCompression: true,
+	}
+	conn, res, err := dialer.Dial(fmt.Sprintf("ws://%s?encoding=cbor&ephemeral&namespace=ns1&autoack", svr.Listener.Addr()), nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, EncodingMsgPack, conn.Subprotocol())
+	assert.Contains(t, res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	connID := <-subscribedConn
+	cbs.On("DeliveryResponse", connID, mock.Anything).Return(nil)
+	err = ws.DeliveryRequest(connID, nil, &fftypes.EventDelivery{
+		Event: fftypes.Event{ID: fftypes.NewUUID()},
+	}, nil)
+	assert.NoError(t, err)
+
+	msgType, b, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, byte(0x80), b[0]&0xf0) // fixmap
+
+	status := ws.GetStatus()
+	assert.Equal(t, EncodingMsgPack, status.Connections[0].Encoding)
+}
+
+func TestStartReceiveQueryEncoding(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ws, wsc, cancel := newTestWebsockets(t, cbs, "encoding=cbor")
+	defer cancel()
+
+	subscribedConn := make(chan string, 1)
+	cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool {
+			subscribedConn <- s
+			return true
+		}),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+
+	err := wsc.Send(context.Background(), []byte(`{"type":"start","namespace":"ns1","ephemeral":true}`))
+	assert.NoError(t, err)
+	connID := <-subscribedConn
+
+	err = ws.DeliveryRequest(connID, nil, &fftypes.EventDelivery{
+		Event: fftypes.Event{ID: fftypes.NewUUID()},
+	}, nil)
+	assert.NoError(t, err)
+	b := <-wsc.Receive()
+	assert.Equal(t, byte(0xa0), b[0]&0xe0) // CBOR map
+	assert.Equal(t, EncodingCBOR, ws.GetStatus().Connections[0].Encoding)
+}
+
+type testBlobReader struct {
+	io.Reader
+	closed chan struct{}
+}
+
+func newTestBlobReader(r io.Reader) *testBlobReader {
+	return &testBlobReader{Reader: r, closed: make(chan struct{})}
+}
+
+func (r *testBlobReader) Close() error {
+	close(r.closed)
+	return nil
+}
+
+type errReader struct{}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("pop")
+}
+
+func startEphemeralWithData(t *testing.T, cbs *eventsmocks.Callbacks, wsc wsclient.WSClient) (string, *fftypes.Subscription) {
+	subscribedConn := make(chan string, 1)
+	cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool {
+			subscribedConn <- s
+			return true
+		}),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+
+	err := wsc.Send(context.Background(), []byte(`{"type":"start","namespace":"ns1","ephemeral":true,"autoack":true}`))
+	assert.NoError(t, err)
+
+	yes := true
+	sub := &fftypes.Subscription{}
+	sub.Options.WithData = &yes
+	return <-subscribedConn, sub
+}
+
+func TestReloadConfigAppliesToNewConnections(t *testing.T) {
+	config.Reset()
+	cbs := &eventsmocks.Callbacks{}
+	cbs.On("ConnnectionClosed", mock.Anything).Return(nil).Maybe()
+
+	ws := &WebSockets{}
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+	svrPrefix := config.NewPluginConfig("ut.websockets")
+	ws.InitPrefix(svrPrefix)
+	ws.Init(ctx, svrPrefix, cbs)
+
+	svrPrefix.Set(EnableCompression, true)
+	svrPrefix.Set(CompressionLevel, 9)
+	svrPrefix.Set(BlobChunkSize, "4")
+	err := ws.ReloadConfig(ctx, svrPrefix)
+	assert.NoError(t, err)
+	assert.True(t, ws.upgrader.EnableCompression)
+	assert.Equal(t, 9, ws.compressLvl)
+
+	svr := httptest.NewServer(ws)
+	defer svr.Close()
+
+	subscribedConn := make(chan string, 1)
+	cbs.On("EphemeralSubscription",
+		mock.MatchedBy(func(s string) bool {
+			subscribedConn <- s
+			return true
+		}),
+		"ns1", mock.Anything, mock.Anything).Return(nil)
+
+	dialer := &websocket.Dialer{
+		EnableCompression: true,
+	}
+	conn, res, err := dialer.Dial(fmt.Sprintf("ws://%s?ephemeral&namespace=ns1&autoack", svr.Listener.Addr()), nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.Contains(t, res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	connID := <-subscribedConn
+	ws.connMux.Lock()
+	assert.Equal(t, 4, ws.connections[connID].blobChunk)
+	ws.connMux.Unlock()
+}
+
+func TestStartReceiveWithDataBlobs(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ws, wsc, cancel := newTestWebsockets(t, cbs)
+	defer cancel()
+
+	connID, sub := startEphemeralWithData(t, cbs, wsc)
+	cbs.On("DeliveryResponse", connID, mock.Anything).Return(nil)
+	ws.connMux.Lock()
+	ws.connections[connID].blobChunk = 4
+	ws.connMux.Unlock()
+
+	valueData := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Value: fftypes.Byteable(`"value"`)}
+	blobData := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Blob: &fftypes.BlobRef{
+		Hash: fftypes.NewRandB32(),
+		Size: 11,
+	}}
+	reader := newTestBlobReader(strings.NewReader("hello world"))
+	cbs.On("DownloadBLOB", mock.Anything, "ns1", blobData.ID).Return(reader, nil)
+
+	err := ws.DeliveryRequest(connID, sub, &fftypes.EventDelivery{
+		Event:        fftypes.Event{ID: fftypes.NewUUID(), Namespace: "ns1"},
+		Subscription: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"},
+	}, []*fftypes.Data{valueData, blobData})
+	assert.NoError(t, err)
+
+	b := <-wsc.Receive()
+	var res fftypes.WSEventDeliveryWithData
+	err = json.Unmarshal(b, &res)
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 2)
+	assert.Len(t, res.Blobs, 1)
+	assert.Equal(t, *blobData.ID, *res.Blobs[0].Data)
+	assert.Equal(t, *blobData.Blob.Hash, *res.Blobs[0].Hash)
+	assert.Equal(t, int64(11), res.Blobs[0].Size)
+
+	var content []byte
+	for _, expected := range []struct {
+		content string
+		flags   byte
+	}{{"hell", 0}, {"o wo", 0}, {"rld", blobFrameFinal}} {
+		b = <-wsc.Receive()
+		assert.Equal(t, blobData.ID[:], b[0:16])
+		assert.Equal(t, expected.flags, b[16])
+		assert.Equal(t, expected.content, string(b[blobFrameHeaderLen:]))
+		content = append(content, b[blobFrameHeaderLen:]...)
+	}
+	assert.Equal(t, "hello world", string(content))
+	<-reader.closed
+
+	cbs.AssertExpectations(t)
+}
+
+func TestStartReceiveWithDataBlobReadFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ws, wsc, cancel := newTestWebsockets(t, cbs)
+	defer cancel()
+
+	connID, sub := startEphemeralWithData(t, cbs, wsc)
+	cbs.On("DeliveryResponse", connID, mock.Anything).Return(nil)
+
+	blobData := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Blob: &fftypes.BlobRef{
+		Hash: fftypes.NewRandB32(),
+	}}
+	reader := newTestBlobReader(&errReader{})
+	cbs.On("DownloadBLOB", mock.Anything, "ns1", blobData.ID).Return(reader, nil)
+
+	connection := ws.connections[connID]
+	err := ws.DeliveryRequest(connID, sub, &fftypes.EventDelivery{
+		Event: fftypes.Event{ID: fftypes.NewUUID(), Namespace: "ns1"},
+	}, []*fftypes.Data{blobData})
+	assert.NoError(t, err)
+
+	<-wsc.Receive()
+	<-reader.closed
+	<-connection.senderDone
+}
+
+func TestDispatchWithDataDownloadFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	wsc := &websocketConnection{
+		ctx: context.Background(),
+		ws: &WebSockets{
+			ctx:       context.Background(),
+			callbacks: cbs,
+		},
+	}
+	blob1 := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32()}}
+	blob2 := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32()}}
+	reader := newTestBlobReader(strings.NewReader("data"))
+	cbs.On("DownloadBLOB", mock.Anything, "ns1", blob1.ID).Return(reader, nil)
+	cbs.On("DownloadBLOB", mock.Anything, "ns1", blob2.ID).Return(nil, fmt.Errorf("pop"))
+
+	err := wsc.dispatchWithData(&fftypes.EventDelivery{}, []*fftypes.Data{blob1, blob2})
+	assert.Regexp(t, "pop", err)
+	<-reader.closed
+	cbs.AssertExpectations(t)
+}
+
+func TestDispatchWithDataAfterClose(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wsc := &websocketConnection{
+		ctx: ctx,
+		ws: &WebSockets{
+			ctx:       context.Background(),
+			callbacks: cbs,
+		},
+	}
+	blob := &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1", Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32()}}
+	reader := newTestBlobReader(strings.NewReader("data"))
+	cbs.On("DownloadBLOB", mock.Anything, "ns1", blob.ID).Return(reader, nil)
+
+	err := wsc.dispatchWithData(&fftypes.EventDelivery{}, []*fftypes.Data{blob})
+	assert.Regexp(t, "FF10160", err)
+	<-reader.closed
+	cbs.AssertExpectations(t)
+}
+
+func TestWriteBlobDeliveryFail(t *testing.T) {
+	cbs := &eventsmocks.Callbacks{}
+	ws, wsc, cancel := newTestWebsockets(t, cbs)
+	defer cancel()
+
+	connID, _ := startEphemeralWithData(t, cbs, wsc)
+	connection := ws.connections[connID]
+
+	reader := newTestBlobReader(strings.NewReader("data"))
+	err := connection.writeBlobDelivery(&blobDelivery{
+		event: &fftypes.WSEventDeliveryWithData{
+			EventDelivery: &fftypes.EventDelivery{},
+			Data:          []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}},
+		},
+		readers: []io.ReadCloser{reader},
+	})
+	assert.Error(t, err)
+	<-reader.closed
+
+	connection.wsConn.Close()
+	<-connection.senderDone
+
+	reader = newTestBlobReader(strings.NewReader("data"))
+	err = connection.writeBlobDelivery(&blobDelivery{
+		event: &fftypes.WSEventDeliveryWithData{
+			EventDelivery: &fftypes.EventDelivery{},
+			Blobs:         []*fftypes.WSBlobStream{{Data: fftypes.NewUUID()}},
+		},
+		readers: []io.ReadCloser{reader},
+	})
+	assert.Error(t, err)
+	<-reader.closed
+
+	err = connection.streamBlob(fftypes.NewUUID(), strings.NewReader("data"))
+	assert.Error(t, err)
+}
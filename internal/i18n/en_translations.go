@@ -161,7 +161,6 @@ var (
 	MsgDataDoesNotHaveBlob         = ffm("FF10241", "Data does not have a blob attachment", 404)
 	MsgWebhookURLEmpty             = ffm("FF10242", "Webhook subscription option 'url' cannot be empty", 400)
 	MsgWebhookInvalidStringMap     = ffm("FF10243", "Webhook subscription option '%s' must be map of string values. %s=%T", 400)
-	MsgWebsocketsNoData            = ffm("FF10244", "Websockets subscriptions do not support streaming the full data payload, just the references (withData must be false)", 400)
 	MsgWebhooksWithData            = ffm("FF10245", "Webhook subscriptions require the full data payload (withData must be true)", 400)
 	MsgWebhooksOptURL              = ffm("FF10246", "Webhook url to invoke. Can be relative if a base URL is set in the webhook plugin config")
 	MsgWebhooksOptMethod           = ffm("FF10247", "Webhook method to invoke. Default=POST")
@@ -219,4 +218,173 @@ var (
 	MsgInvalidChartNumberParam     = ffm("FF10299", "Invalid %s. Must be a number.", 400)
 	MsgHistogramInvalidTimes       = ffm("FF10300", "Start time must be before end time", 400)
 	MsgUnsupportedCollection       = ffm("FF10301", "%s collection is not supported", 400)
+	MsgInvalidLangCatalog          = ffm("FF10302", "Invalid language catalog '%s': %s")
+	MsgUnknownLangCatalogKey       = ffm("FF10303", "Unknown message key '%s' in language catalog '%s'")
+	MsgLangCatalogDirReadFailed    = ffm("FF10304", "Failed to read language catalogs from directory '%s'")
+	MsgUnknownSecretsResolver      = ffm("FF10305", "Unknown secrets resolver '%s'")
+	MsgInvalidSecretReference      = ffm("FF10306", "Invalid secret reference in config key '%s'")
+	MsgSecretResolveFailed         = ffm("FF10307", "Failed to resolve secret for config key '%s' using '%s'")
+	MsgVaultRESTErr                = ffm("FF10308", "Error from Vault: %s")
+	MsgVaultSecretKeyMissing       = ffm("FF10309", "Secret reference '%s' must specify a key that exists in the secret as the URL fragment")
+	MsgWSConnectionNotFound        = ffm("FF10310", "Websocket connection '%s' not found", 404)
+	MsgMessageNotPinned            = ffm("FF10311", "Message '%s' has not been pinned to the blockchain", 404)
+	MsgMessageNotInBatch           = ffm("FF10312", "Message '%s' not found in batch '%s'")
+	MsgInvalidMessageProof         = ffm("FF10313", "Message proof must include the message, batch and transaction", 400)
+	MsgProofMismatch               = ffm("FF10314", "The %s in the proof '%v' does not match '%v' recorded by this node")
+	MsgProofNotFound               = ffm("FF10315", "The %s '%s' in the proof is not recorded by this node")
+	MsgProofPinMissing             = ffm("FF10316", "The pin '%s' for topic '%s' was not pinned by the batch")
+	MsgAsOfDesc                    = ffm("FF10317", "Return the state as of a point in history - either an event sequence number, or an RFC3339 timestamp")
+	MsgInvalidAsOf                 = ffm("FF10318", "Invalid asOf '%s' - must be an event sequence number, or an RFC3339 timestamp", 400)
+	MsgAsOfEventNotFound           = ffm("FF10319", "No event found with sequence %d in namespace '%s'", 404)
+	MsgAsyncAPIWebSocketsChannel   = ffm("FF10320", "Websocket connection for applications to start subscriptions, and receive and acknowledge events. The start options can also be supplied as query parameters on connection")
+	MsgAsyncAPIWebhooksChannel     = ffm("FF10321", "Events are sent as HTTP requests to the URL configured in the webhooks options of the subscription")
+	MsgAsyncAPIWebhooksURL         = ffm("FF10322", "The URL configured in the options of the webhook subscription")
+	MsgAsyncAPIStart               = ffm("FF10323", "Start delivery of events on a durable subscription, or on a new ephemeral subscription")
+	MsgAsyncAPIAck                 = ffm("FF10324", "Acknowledge an event, allowing the next event on the subscription to be delivered")
+	MsgAsyncAPIEventDelivery       = ffm("FF10325", "An event delivered on a subscription, which must be acknowledged unless autoack is set")
+	MsgAsyncAPIProtocolError       = ffm("FF10326", "Sent by the server when it receives an invalid payload from the client")
+	MsgAsyncAPIChangeNotification  = ffm("FF10327", "A change to the local database, when changeEvents are enabled on an ephemeral subscription. Does not require an acknowledgement")
+	MsgAsyncAPIWebhookData         = ffm("FF10328", "When withData is set, the value of the first data item of the message is sent instead of the event - or an array of values if the message has multiple data items")
+	MsgSubscriptionNotOwner        = ffm("FF10329", "Subscription '%s:%s' is owned by another identity", 403)
+	MsgMineDesc                    = ffm("FF10330", "Only return subscriptions owned by the identity of the caller")
+	MsgHarnessWaitTimeout          = ffm("FF10331", "Timed out after %s waiting for the expected state")
+	MsgHarnessPayloadNotFound      = ffm("FF10332", "Payload '%s' not found", 404)
+	MsgHarnessInvalidPayloadRef    = ffm("FF10333", "Invalid payload reference '%s'")
+	MsgHarnessInvalidStep          = ffm("FF10334", "Invalid step %d with type '%s'")
+	MsgHarnessUnexpectedStatus     = ffm("FF10335", "API call %s %s returned status %d when %d was expected: %s")
+	MsgHarnessRecordingReadFailed  = ffm("FF10336", "Failed to read recording '%s'")
+	MsgQuotaExceeded               = ffm("FF10337", "Quota %s=%d exceeded for '%s'", 429)
+	MsgInvalidCustomHeaderValue    = ffm("FF10338", "Invalid value for '%s': must be no more than %d characters, with no control characters", 400)
+	MsgCustomHeadersTooLarge       = ffm("FF10339", "Combined size of %s exceeds the maximum of %d characters (supplied=%d)", 400)
+	MsgInsufficientGasFunds        = ffm("FF10340", "Insufficient gas funds for signing key '%s' (balance=%s minimum=%s)", 400)
+	MsgInvalidPreflightMinBalance  = ffm("FF10341", "Invalid minimum balance '%s' for transaction pre-flight checks")
+	MsgEthereumRPCErr              = ffm("FF10342", "Invalid response from ethereum JSON/RPC: %s")
+	MsgUnknownPolicyPlugin         = ffm("FF10343", "Unknown policy plugin '%s'")
+	MsgPolicyRejected              = ffm("FF10344", "Submission of %s rejected by policy: %s", 403)
+	MsgPolicyApprovalPending       = ffm("FF10345", "Submission of %s is held by policy pending manual approval '%s'", 409)
+	MsgPolicyApprovalRejected      = ffm("FF10346", "Submission of %s was rejected by '%s' under manual approval '%s'", 403)
+	MsgPolicyApprovalNotPending    = ffm("FF10347", "Approval '%s' has already been decided (status=%s)", 409)
+	MsgPolicyApprovalBadDecision   = ffm("FF10348", "Decision status must be '%s' or '%s'", 400)
+	MsgPolicyApprovalNotFound      = ffm("FF10349", "Approval '%s' not found", 404)
+	MsgMessageHoldNotFound         = ffm("FF10350", "Message hold '%s' not found", 404)
+	MsgMessageHoldNotPending       = ffm("FF10351", "Message hold '%s' has already been decided (status=%s)", 409)
+	MsgTokenBridgeSamePool         = ffm("FF10352", "The source and target pools of a token bridge must be different", 400)
+	MsgInvalidLogLevel             = ffm("FF10353", "Invalid log level '%s'", 400)
+	MsgInvalidTimestampParam       = ffm("FF10354", "Invalid %s. Must be a timestamp.", 400)
+	MsgInvalidChartRankingLimit    = ffm("FF10355", "Limit must be between 1 and %d", 400)
+	MsgChartRankingLimitParam      = ffm("FF10356", "Maximum number of results to return")
+	MsgWebhooksOptSecret           = ffm("FF10357", "Secret used to sign each request with an HMAC-SHA256 signature header, along with timestamp and nonce headers for replay protection. Write-only - it is not returned when the subscription is queried")
+	MsgWSUnsupportedEncoding       = ffm("FF10358", "Unsupported websocket encoding '%s'", 400)
+	MsgIdentityRejected            = ffm("FF10359", "Identity with ID '%s' was rejected. Please check the FireFly logs for more information")
+	MsgConfirmTimeoutQueryParam    = ffm("FF10360", "Maximum time to block when confirm=true (milliseconds, or set a custom suffix like 10s). Limited by api.requestMaxTimeout")
+	MsgRESTClientQueueFull         = ffm("FF10361", "Too many requests queued for '%s' (maxQueuedRequests=%d)", 429)
+	MsgRESTClientQueueTimeout      = ffm("FF10362", "Request to '%s' timed out waiting for a free slot (maxConcurrentRequests reached)", 408)
+	MsgFFINotFound                 = ffm("FF10363", "Contract interface '%s' not found", 404)
+	MsgContractMethodNotFound      = ffm("FF10364", "Method '%s' not found in contract interface '%s'", 400)
+	MsgContractMethodNotSet        = ffm("FF10365", "Either an inline method definition, or an interface and a method path must be supplied", 400)
+	MsgContractLocationInvalid     = ffm("FF10366", "Invalid contract location: %s", 400)
+	MsgContractMissingInputArg     = ffm("FF10367", "Missing required input argument '%s'", 400)
+	MsgFFIParamTypeInvalid         = ffm("FF10368", "Invalid type '%s' for parameter '%s' - must be one of: %s", 400)
+	MsgContractInvokeFailed        = ffm("FF10369", "Contract invocation operation '%s' failed: %s")
+	MsgContractParamTypeUnmapped   = ffm("FF10370", "Parameter '%s' of type '%s' cannot be mapped to a blockchain type - set details.type", 400)
+	MsgContractEventNotFound       = ffm("FF10371", "Event '%s' not found in contract interface '%s'", 400)
+	MsgContractEventNotSet         = ffm("FF10372", "Either an inline event definition, or an interface and an event path must be supplied", 400)
+	MsgBatchQuarantineNotFound     = ffm("FF10373", "Batch quarantine '%s' not found", 404)
+	MsgBatchQuarantineNotPending   = ffm("FF10374", "Batch quarantine '%s' has already been decided (status=%s)", 409)
+	MsgTxnCostBadGroupBy           = ffm("FF10375", "Invalid groupBy '%s' - must be one of: %s", 400)
+	MsgOAuth2TokenErr              = ffm("FF10376", "Error from OAuth2 token endpoint: %s")
+	MsgTokenApprovalFailed         = ffm("FF10377", "Token approval with ID '%s' failed. Please check the FireFly logs for more information")
+	MsgUnknownBatchCompression     = ffm("FF10378", "Unknown batch compression '%s'")
+	MsgBatchDecompressFailed       = ffm("FF10379", "Failed to decompress batch payload with compression '%s'")
+	MsgPseudonymNotFound           = ffm("FF10380", "Pseudonym '%s' not found in namespace '%s'", 404)
+	MsgPseudonymNotOwned           = ffm("FF10381", "Pseudonym '%s' was not registered by the local organization", 400)
+	MsgPseudonymKeyInUse           = ffm("FF10382", "Signing key '%s' is already registered to '%s', and cannot be used as a pseudonym", 409)
+	MsgPseudonymKeyMismatch        = ffm("FF10383", "Signing key '%s' does not match the key of pseudonym '%s'", 400)
+	MsgPseudonymResolveForbidden   = ffm("FF10384", "Identity '%s' is not authorized to resolve the author of pseudonyms", 403)
+	MsgDataImportSource            = ffm("FF10385", "Exactly one of 'url' or 'payloadRef' must be supplied to import data", 400)
+	MsgDataImportBadURL            = ffm("FF10386", "Invalid URL '%s' to import data - must be an absolute http or https URL", 400)
+	MsgDataImportDownloadFailed    = ffm("FF10387", "Failed to download data from '%s' [%d]")
+	MsgDataImportHashMismatch      = ffm("FF10388", "Hash of imported data %s does not match the expected hash %s")
+	MsgWebhookInvalidRetryOption   = ffm("FF10389", "Webhook subscription option 'retry.%s' is invalid: '%s'", 400)
+	MsgWebhookInvalidDeadLetter    = ffm("FF10390", "Webhook subscription option 'deadletter' must be 'park' or 'event': '%s'", 400)
+	MsgWebhooksOptRetry            = ffm("FF10391", "Options for retrying a failed webhook request, where it could not be sent or returned a 429 or 5xx status")
+	MsgWebhooksOptRetryEnabled     = ffm("FF10392", "Whether to retry failed webhook requests")
+	MsgWebhooksOptRetryCount       = ffm("FF10393", "The maximum number of retries after the first attempt. Default=5")
+	MsgWebhooksOptRetryInitDelay   = ffm("FF10394", "The delay before the first retry. Default=250ms")
+	MsgWebhooksOptRetryMaxDelay    = ffm("FF10395", "The maximum delay between retries. Default=30s")
+	MsgWebhooksOptRetryFactor      = ffm("FF10396", "The factor the delay is multiplied by after each retry. Default=2")
+	MsgWebhooksOptDeadLetter       = ffm("FF10397", "What to do when the webhook request still fails after all retries. 'park' holds the subscription at the event until it is restarted, 'event' records a delivery_failed event and moves on. Default is to acknowledge the event, sending the failure as the reply if enabled")
+	MsgSSESubscriptionNotFound     = ffm("FF10398", "Subscription '%s' not found in namespace '%s' for the sse transport", 404)
+	MsgSSEConnectionNotActive      = ffm("FF10399", "SSE connection '%s' no longer active")
+	MsgSSENoData                   = ffm("FF10400", "SSE subscriptions do not support streaming the full data payload, just the references (withData must be false)", 400)
+	MsgSSEBadLastEventID           = ffm("FF10401", "Invalid Last-Event-ID '%s' - must be the sequence of the last event received", 400)
+	MsgSSEStreamingUnsupported     = ffm("FF10402", "The HTTP connection does not support streaming events", 500)
+	MsgOperationNotFailed          = ffm("FF10403", "Operation '%s' has status '%s' - only failed operations can be retried", 409)
+	MsgOperationRetryNotSupported  = ffm("FF10404", "Retry is not supported for operations of type '%s'", 400)
+	MsgOperationInputsMissing      = ffm("FF10405", "Operation '%s' is missing the inputs required to retry it: %s", 400)
+	MsgUnknownHashAlgorithm        = ffm("FF10406", "Unknown hash algorithm '%s'", 400)
+	MsgIdempotencyKeyDuplicateMsg  = ffm("FF10407", "Idempotency key '%s' has already been used for message '%s'", 409)
+	MsgIdempotencyKeyDuplicateTX   = ffm("FF10408", "Idempotency key '%s' has already been used for transaction '%s' of type '%s' with reference '%s'", 409)
+	MsgLegalHoldNotFound           = ffm("FF10409", "Legal hold '%s' not found in namespace '%s'", 404)
+	MsgLegalHoldRemoved            = ffm("FF10410", "Legal hold '%s' was already removed by '%s'", 409)
+	MsgLegalHoldTargetNotFound     = ffm("FF10411", "Cannot place a legal hold on %s '%s', as it was not found in namespace '%s'", 404)
+	MsgFeatureDisabled             = ffm("FF10412", "Feature '%s' is not enabled in namespace '%s'", 501)
+	MsgBatchPinsNotSupported       = ffm("FF10413", "Blockchain plugin '%s' does not support pinning multiple batches in a single transaction")
+	MsgIdentityNotFound            = ffm("FF10414", "Identity '%s' not found", 404)
+	MsgIdentityNotVerified         = ffm("FF10415", "Identity '%s' has not been verified by its parent '%s'", 409)
+	MsgIdentityKeyInUse            = ffm("FF10416", "Signing key '%s' is already registered to '%s', and cannot be used for a new identity", 409)
+	MsgIdentityKeyMismatch         = ffm("FF10417", "Signing key '%s' does not match the key of identity '%s'", 400)
+	MsgIdentityNameInUse           = ffm("FF10418", "An identity named '%s' already exists in namespace '%s'", 409)
+	MsgS3RESTErr                   = ffm("FF10419", "Error from S3: %s")
+	MsgIPFSPinRESTErr              = ffm("FF10420", "Error from IPFS pinning service: %s")
+	MsgIPFSPinFailed               = ffm("FF10421", "Pinning service reported status '%s' for '%s'")
+	MsgNodeStandby                 = ffm("FF10422", "This node is a standby, and its API is read-only until it is promoted", 503)
+	MsgNodeNotStandby              = ffm("FF10423", "This node is not a standby", 409)
+	MsgDXMTLSCertLoadFailed        = ffm("FF10424", "Failed to load the data exchange certificate '%s' and key '%s'")
+	MsgDXMTLSNoCommonName          = ffm("FF10425", "The data exchange certificate must have a subject common name, which is used as the peer ID")
+	MsgDXMTLSUnknownPeer           = ffm("FF10426", "Unknown data exchange peer '%s'", 404)
+	MsgDXMTLSBadPeerEndpoint       = ffm("FF10427", "Invalid endpoint for data exchange peer '%s'", 400)
+	MsgDXMTLSUntrustedPeer         = ffm("FF10428", "Certificate does not match the certificate published for any known data exchange peer", 401)
+	MsgDXMTLSTransferFailed        = ffm("FF10429", "Transfer to data exchange peer '%s' failed with status %d: %s")
+	MsgDXMTLSInvalidBlobPath       = ffm("FF10430", "Invalid blob path '%s'", 400)
+	MsgDXMTLSListenFailed          = ffm("FF10431", "Failed to listen for data exchange peers on %s")
+	MsgBlobTooLarge                = ffm("FF10432", "Blob exceeds the maximum size of %d bytes", 413)
+	MsgInvalidRange                = ffm("FF10433", "Invalid or unsatisfiable range '%s' for a blob of %d bytes", 416)
+	MsgInvalidReplayBlock          = ffm("FF10434", "Invalid block '%s' to replay blockchain events from - must be a block number", 400)
+	MsgUnmatchedReceiptNotFound    = ffm("FF10435", "Unmatched receipt '%s' not found", 404)
+	MsgUnmatchedReceiptReconciled  = ffm("FF10436", "Unmatched receipt '%s' has already been reconciled to operation '%s'", 409)
+	MsgUnmatchedReceiptPlugin      = ffm("FF10437", "Unmatched receipt '%s' from plugin '%s' cannot be reconciled to operation '%s' of plugin '%s'", 400)
+	MsgBlockedPinNotFound          = ffm("FF10438", "Blocked pin for batch '%s' not found", 404)
+	MsgRollupsDisabled             = ffm("FF10439", "Metrics rollups are disabled", 400)
+	MsgInvalidRollupInterval       = ffm("FF10440", "Interval '%s' must be a multiple of the metrics rollup interval '%s'", 400)
+	MsgRollupIntervalParam         = ffm("FF10441", "Interval of each bucket, as a duration string or millisecond number. Defaults to the metrics rollup interval")
+	MsgRollupTypesParam            = ffm("FF10442", "Comma separated list of types to include. Defaults to all types")
+	MsgUsageIdentityParam          = ffm("FF10443", "Name or DID of the org, or DID of the custom identity, to report the usage of")
+	MsgTracingInitFailed           = ffm("FF10444", "Failed to initialize tracing exporter")
+	MsgConfigNotReloadable         = ffm("FF10445", "Configuration section '%s' cannot be reloaded without a restart", 400)
+	MsgUnknownConfigKey            = ffm("FF10446", "Unknown configuration key '%s'", 400)
+	MsgUnknownUUIDVersion          = ffm("FF10447", "Unknown UUID version '%s' - must be one of: %v")
+	MsgDelegationNotFound          = ffm("FF10448", "Delegation '%s' not found in namespace '%s'", 404)
+	MsgDelegationRevoked           = ffm("FF10449", "Delegation '%s' has been revoked", 409)
+	MsgDelegationNotToLocalOrg     = ffm("FF10450", "Delegation '%s' was granted to '%s', not to the local organization '%s'", 403)
+	MsgDelegationAuthorMismatch    = ffm("FF10451", "Author '%s' is not the delegator '%s' of delegation '%s'", 400)
+	MsgDelegationKeyMismatch       = ffm("FF10452", "Signing key '%s' does not belong to the delegate '%s'", 400)
+	MsgDelegationToSelf            = ffm("FF10453", "Organization '%s' cannot delegate to itself", 400)
+	MsgMissingBlockchainConfig     = ffm("FF10454", "Invalid blockchains configuration - name and type are required", 400)
+	MsgUnknownBlockchainConnector  = ffm("FF10455", "Unknown blockchain connector '%s'", 400)
+	MsgMissingNamespacePlugin      = ffm("FF10456", "Invalid plugins configuration for namespace '%s' - a type is required for the %s plugin", 400)
+	MsgAsOfHistoricField           = ffm("FF10457", "The '%s' field cannot be used to filter or sort a query with asOf, as only its current value is stored", 400)
+	MsgInvalidSyncTimeout          = ffm("FF10458", "Invalid timeout '%s'", 400)
+	MsgAWSKMSRESTErr               = ffm("FF10459", "Error from AWS KMS: %s")
+	MsgAWSKMSCiphertextMissing     = ffm("FF10460", "Secret reference '%s' must specify the base64 encoded ciphertext as the URL fragment")
+	MsgDataImportHostNotAllowed    = ffm("FF10461", "Host '%s' is not allowed for data import", 400)
+	MsgDataImportAddressNotAllowed = ffm("FF10462", "Data import from the private address '%s' is not allowed")
+	MsgDataImportRefNotLocal       = ffm("FF10463", "Payload reference '%s' is not a blob stored by this node in namespace '%s'", 400)
+	MsgOperationAlreadyRetried     = ffm("FF10464", "Operation '%s' has already been retried", 409)
+	MsgOperationInputsRedacted     = ffm("FF10465", "Operation '%s' cannot be retried as its inputs have been redacted: %s", 400)
+	MsgEventDispatchNotStarted     = ffm("FF10466", "Events are not delivered to subscriptions until this standby node is promoted", 503)
+	MsgPinQuarantineNotFound       = ffm("FF10467", "Pin quarantine for message '%s' not found", 404)
+	MsgPinQuarantineNotPending     = ffm("FF10468", "Pin quarantine for message '%s' has already been decided (status=%s)", 409)
+	MsgDXMTLSRequestTooLarge       = ffm("FF10469", "Request of %d bytes exceeds the limit of %d bytes for data exchange peers", 413)
+	MsgDBTransactionConflict       = ffm("FF10470", "Database transaction conflicted with a concurrent transaction - the request can be retried", 409)
 )
@@ -18,16 +18,96 @@ package i18n
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/pkg/errors"
 )
 
+// FFError is an error raised by FireFly, carrying the stable machine-readable code
+// of the message, and optionally the path of the input field that caused the error
+type FFError interface {
+	error
+	MessageKey() MessageKey
+	Field() string
+}
+
+type ffError struct {
+	error
+	msgKey MessageKey
+	field  string
+}
+
+func (e *ffError) MessageKey() MessageKey {
+	return e.msgKey
+}
+
+func (e *ffError) Field() string {
+	return e.field
+}
+
+func (e *ffError) Unwrap() error {
+	return e.error
+}
+
+// Format delegates to the wrapped error, so stack traces are still available with %+v
+func (e *ffError) Format(s fmt.State, verb rune) {
+	if f, ok := e.error.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = fmt.Fprint(s, e.Error())
+}
+
 // NewError creates a new error
 func NewError(ctx context.Context, msg MessageKey, inserts ...interface{}) error {
-	return errors.Errorf(SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048))
+	return &ffError{
+		error:  errors.Errorf(SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048)),
+		msgKey: msg,
+	}
+}
+
+// NewFieldError creates a new error that relates to a specific field in the input
+func NewFieldError(ctx context.Context, field string, msg MessageKey, inserts ...interface{}) error {
+	return &ffError{
+		error:  errors.Errorf(SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048)),
+		msgKey: msg,
+		field:  field,
+	}
 }
 
 // WrapError wraps an error
 func WrapError(ctx context.Context, err error, msg MessageKey, inserts ...interface{}) error {
-	return errors.Wrap(err, SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048))
+	return &ffError{
+		error:  errors.Wrap(err, SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048)),
+		msgKey: msg,
+	}
+}
+
+// WrapFieldError wraps an error, recording the specific field in the input that caused it
+func WrapFieldError(ctx context.Context, field string, err error, msg MessageKey, inserts ...interface{}) error {
+	return &ffError{
+		error:  errors.Wrap(err, SanitizeLimit(ExpandWithCode(ctx, msg, inserts...), 2048)),
+		msgKey: msg,
+		field:  field,
+	}
+}
+
+// ErrorCode returns the code of the outermost FireFly error in the chain
+func ErrorCode(err error) (string, bool) {
+	var ffErr FFError
+	if errors.As(err, &ffErr) {
+		return string(ffErr.MessageKey()), true
+	}
+	return "", false
+}
+
+// ErrorField returns the first field path recorded on any FireFly error in the chain
+func ErrorField(err error) string {
+	for err != nil {
+		if ffErr, ok := err.(FFError); ok && ffErr.Field() != "" {
+			return ffErr.Field()
+		}
+		err = errors.Unwrap(err)
+	}
+	return ""
 }
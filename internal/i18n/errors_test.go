@@ -33,3 +33,37 @@ func TestWrapError(t *testing.T) {
 	err := WrapError(context.Background(), fmt.Errorf("some error"), MsgConfigFailed)
 	assert.Error(t, err)
 }
+
+func TestNewFieldError(t *testing.T) {
+	err := NewFieldError(context.Background(), "name", MsgMissingRequiredField, "name")
+	assert.Regexp(t, "FF10140", err)
+	code, ok := ErrorCode(err)
+	assert.True(t, ok)
+	assert.Equal(t, "FF10140", code)
+	assert.Equal(t, "name", ErrorField(err))
+}
+
+func TestWrapFieldErrorNested(t *testing.T) {
+	inner := NewFieldError(context.Background(), "header.topics", MsgInvalidName, "header.topics")
+	err := WrapError(context.Background(), fmt.Errorf("context: %w", inner), MsgConfigFailed)
+	code, ok := ErrorCode(err)
+	assert.True(t, ok)
+	assert.Equal(t, "FF10101", code)
+	assert.Equal(t, "header.topics", ErrorField(err))
+
+	err = WrapFieldError(context.Background(), "input", fmt.Errorf("pop"), MsgJSONDecodeFailed)
+	assert.Equal(t, "input", ErrorField(err))
+}
+
+func TestErrorCodeNonFF(t *testing.T) {
+	_, ok := ErrorCode(fmt.Errorf("pop"))
+	assert.False(t, ok)
+	assert.Empty(t, ErrorField(fmt.Errorf("pop")))
+}
+
+func TestErrorFormat(t *testing.T) {
+	err := NewError(context.Background(), MsgConfigFailed)
+	assert.Regexp(t, "(?s)FF10101.*TestErrorFormat", fmt.Sprintf("%+v", err))
+	err = &ffError{error: fmt.Errorf("pop")}
+	assert.Equal(t, "pop", fmt.Sprintf("%+v", err))
+}
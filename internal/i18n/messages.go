@@ -18,7 +18,12 @@ package i18n
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -30,12 +35,12 @@ type MessageKey string
 
 // Expand for use in docs and logging - returns a translated message, translated the language of the context
 func Expand(ctx context.Context, key MessageKey, inserts ...interface{}) string {
-	return pFor(ctx).Sprintf(string(key), inserts...)
+	return pFor(ctx, key).Sprintf(string(key), inserts...)
 }
 
 // ExpandWithCode for use in error scenarios - returns a translated message with a "MSG012345:" prefix, translated the language of the context
 func ExpandWithCode(ctx context.Context, key MessageKey, inserts ...interface{}) string {
-	return string(key) + ": " + pFor(ctx).Sprintf(string(key), inserts...)
+	return string(key) + ": " + pFor(ctx, key).Sprintf(string(key), inserts...)
 }
 
 // WithLang sets the language on the context
@@ -57,18 +62,26 @@ type lang struct {
 	messages []*msg
 }
 
+// serverLangs is the list of languages with a loaded catalog. English is always first,
+// and is used for any message that does not have a translation in the selected language.
 var serverLangs = []language.Tag{
-	language.AmericanEnglish, // Only English currently supported
+	language.AmericanEnglish,
 }
 
 var langMatcher = language.NewMatcher(serverLangs)
 
+// langKeys records which messages have been translated in each of the non-English catalogs
+var langKeys = map[language.Tag]map[MessageKey]bool{}
+
+var langMux sync.RWMutex
+
 // enTranslations are special, as new messages are added here first using the en_translations.go file
 // and are allocated their IDs there
 var enTranslations = []*msg{}
 
 var statusHints = map[string]int{}
 var msgIDUniq = map[string]bool{}
+var knownMsgKeys = map[MessageKey]bool{}
 
 // ffm is the enTranslations helper to define a new message (not used in translation files)
 func ffm(key, enTranslation string, statusHint ...int) MessageKey {
@@ -84,14 +97,20 @@ func ffm(key, enTranslation string, statusHint ...int) MessageKey {
 	return m.msgid
 }
 
-var defaultLangPrinter *message.Printer
+var defaultLang = language.AmericanEnglish
+var enLangPrinter = message.NewPrinter(language.AmericanEnglish)
 
-func pFor(ctx context.Context) *message.Printer {
-	lang := ctx.Value(ctxLangKey{})
-	if lang == nil {
-		return defaultLangPrinter
+func pFor(ctx context.Context, key MessageKey) *message.Printer {
+	langMux.RLock()
+	defer langMux.RUnlock()
+	tag := defaultLang
+	if ctxLang := ctx.Value(ctxLangKey{}); ctxLang != nil {
+		tag = ctxLang.(language.Tag)
+	}
+	if !langKeys[tag][key] {
+		return enLangPrinter
 	}
-	return message.NewPrinter(lang.(language.Tag))
+	return message.NewPrinter(tag)
 }
 
 func init() {
@@ -102,16 +121,81 @@ func init() {
 		tag := language.MustParse(e.tag)
 		for _, msg := range e.messages {
 			_ = message.Set(tag, string(msg.msgid), msg.localString)
+			knownMsgKeys[msg.msgid] = true
 		}
 	}
 	SetLang("en")
 	msgIDUniq = map[string]bool{} // Clear out that memory as no longer needed
 }
 
+// LoadCatalog adds translations for a language, keyed by message code (FF12345).
+// Any message missing from the catalog continues to be returned in English.
+func LoadCatalog(langStr string, translations map[string]string) error {
+	tag, err := language.Parse(langStr)
+	if err != nil {
+		return NewError(context.Background(), MsgInvalidLangCatalog, langStr, err)
+	}
+	for key := range translations {
+		if !knownMsgKeys[MessageKey(key)] {
+			return NewError(context.Background(), MsgUnknownLangCatalogKey, key, langStr)
+		}
+	}
+	langMux.Lock()
+	defer langMux.Unlock()
+	keys := langKeys[tag]
+	if keys == nil {
+		keys = map[MessageKey]bool{}
+		langKeys[tag] = keys
+		serverLangs = append(serverLangs, tag)
+		langMatcher = language.NewMatcher(serverLangs)
+	}
+	for key, translation := range translations {
+		_ = message.Set(tag, key, catalog.String(translation))
+		keys[MessageKey(key)] = true
+	}
+	return nil
+}
+
+// LoadCatalogDir loads every "<lang>.json" file in a directory as a catalog, where each
+// file contains a JSON object mapping message codes to translated strings
+func LoadCatalogDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return WrapError(context.Background(), err, MsgLangCatalogDirReadFailed, dir)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		translations := make(map[string]string)
+		if err == nil {
+			err = json.Unmarshal(b, &translations)
+		}
+		if err != nil {
+			return WrapError(context.Background(), err, MsgInvalidLangCatalog, f.Name(), err)
+		}
+		if err = LoadCatalog(strings.TrimSuffix(f.Name(), ".json"), translations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NegotiateLang picks the best of the loaded languages for an HTTP Accept-Language header value
+func NegotiateLang(acceptLanguage string) language.Tag {
+	langMux.RLock()
+	defer langMux.RUnlock()
+	_, idx := language.MatchStrings(langMatcher, acceptLanguage)
+	return serverLangs[idx]
+}
+
 func SetLang(lang string) {
 	// Allow a lang var to be used
-	tag, _, _ := langMatcher.Match(language.Make(lang))
-	defaultLangPrinter = message.NewPrinter(tag)
+	langMux.Lock()
+	defer langMux.Unlock()
+	_, idx, _ := langMatcher.Match(language.Make(lang))
+	defaultLang = serverLangs[idx]
 }
 
 func GetStatusHint(code string) (int, bool) {
@@ -18,6 +18,9 @@ package i18n
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,3 +53,71 @@ func TestDuplicateKey(t *testing.T) {
 		ffm("ABCD1234", "test2")
 	})
 }
+
+func TestLoadCatalogTranslateWithFallback(t *testing.T) {
+	err := LoadCatalog("fr", map[string]string{
+		string(MsgWebsocketClientError): "Erreur reçue du client WebSocket: %s",
+	})
+	assert.NoError(t, err)
+
+	ctx := WithLang(context.Background(), NegotiateLang("fr-CH, fr;q=0.9, en;q=0.8"))
+	assert.Equal(t, "FF10108: Erreur reçue du client WebSocket: myinsert", ExpandWithCode(ctx, MsgWebsocketClientError, "myinsert"))
+	assert.Equal(t, "Failed to read config", Expand(ctx, MsgConfigFailed))
+
+	SetLang("fr")
+	defer SetLang("en")
+	assert.Equal(t, "Erreur reçue du client WebSocket: myinsert", Expand(context.Background(), MsgWebsocketClientError, "myinsert"))
+	ctx = WithLang(context.Background(), NegotiateLang("en-GB"))
+	assert.Equal(t, "Error received from WebSocket client: myinsert", Expand(ctx, MsgWebsocketClientError, "myinsert"))
+}
+
+func TestLoadCatalogBadLang(t *testing.T) {
+	err := LoadCatalog("!!!", map[string]string{})
+	assert.Regexp(t, "FF10302", err)
+}
+
+func TestLoadCatalogUnknownKey(t *testing.T) {
+	err := LoadCatalog("de", map[string]string{"FF99999": "unbekannt"})
+	assert.Regexp(t, "FF10303", err)
+}
+
+func TestNegotiateLangDefault(t *testing.T) {
+	assert.Equal(t, language.AmericanEnglish, NegotiateLang("zz"))
+	assert.Equal(t, language.AmericanEnglish, NegotiateLang(""))
+}
+
+func TestLoadCatalogDir(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "es.json"), []byte(`{"FF10101":"Error al leer la configuración"}`), 0644)
+	assert.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(`ignored`), 0644)
+	assert.NoError(t, err)
+	err = os.Mkdir(filepath.Join(dir, "subdir.json"), 0755)
+	assert.NoError(t, err)
+
+	err = LoadCatalogDir(dir)
+	assert.NoError(t, err)
+	ctx := WithLang(context.Background(), NegotiateLang("es"))
+	assert.Equal(t, "Error al leer la configuración", Expand(ctx, MsgConfigFailed))
+}
+
+func TestLoadCatalogDirBadJSON(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "es.json"), []byte(`!json`), 0644)
+	assert.NoError(t, err)
+	err = LoadCatalogDir(dir)
+	assert.Regexp(t, "FF10302", err)
+}
+
+func TestLoadCatalogDirBadKey(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "es.json"), []byte(`{"FF99999":"unknown"}`), 0644)
+	assert.NoError(t, err)
+	err = LoadCatalogDir(dir)
+	assert.Regexp(t, "FF10303", err)
+}
+
+func TestLoadCatalogDirMissing(t *testing.T) {
+	err := LoadCatalogDir(filepath.Join(t.TempDir(), "missing"))
+	assert.Regexp(t, "FF10304", err)
+}
@@ -34,8 +34,13 @@ import (
 
 type Manager interface {
 	ResolveInputIdentity(ctx context.Context, identity *fftypes.Identity) (err error)
-	ResolveSigningKey(ctx context.Context, inputKey string) (outputKey string, err error)
+	ResolveSigningKey(ctx context.Context, ns, inputKey string) (outputKey string, err error)
 	ResolveSigningKeyIdentity(ctx context.Context, signingKey string) (author string, err error)
+	VerifySigningKeyAuthor(ctx context.Context, signingKey, author string) (valid bool, err error)
+	ResolvePseudonymIdentity(ctx context.Context, ns string, identity *fftypes.Identity) (err error)
+	ResolveDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) (err error)
+	VerifyDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) (valid, pending bool, err error)
+	GetCustomIdentityByDID(ctx context.Context, did string) (*fftypes.CustomIdentity, error)
 	ResolveLocalOrgDID(ctx context.Context) (localOrgDID string, err error)
 	GetOrgKey(ctx context.Context) string
 	OrgDID(org *fftypes.Organization) string
@@ -93,7 +98,7 @@ func (im *identityManager) OrgDID(org *fftypes.Organization) string {
 func (im *identityManager) ResolveInputIdentity(ctx context.Context, identity *fftypes.Identity) (err error) {
 	log.L(ctx).Debugf("Resolving identity input: key='%s' author='%s'", identity.Key, identity.Author)
 
-	identity.Key, err = im.ResolveSigningKey(ctx, identity.Key)
+	identity.Key, err = im.ResolveSigningKey(ctx, fftypes.SystemNamespace, identity.Key)
 	if err != nil {
 		return err
 	}
@@ -109,7 +114,7 @@ func (im *identityManager) ResolveInputIdentity(ctx context.Context, identity *f
 
 func (im *identityManager) ResolveSigningKeyIdentity(ctx context.Context, signingKey string) (author string, err error) {
 
-	signingKey, err = im.ResolveSigningKey(ctx, signingKey)
+	signingKey, err = im.ResolveSigningKey(ctx, fftypes.SystemNamespace, signingKey)
 	if err != nil {
 		return "", err
 	}
@@ -119,11 +124,190 @@ func (im *identityManager) ResolveSigningKeyIdentity(ctx context.Context, signin
 	if err != nil {
 		return "", err
 	}
+	if org == nil {
+		// A key that is not registered to an org might be registered as a pseudonym, or to a verified custom identity
+		pseudonym, err := im.database.GetPseudonymByKey(ctx, signingKey)
+		if err != nil || pseudonym != nil {
+			return pseudonym.GetDID(), err
+		}
+		identity, err := im.database.GetCustomIdentityByKey(ctx, signingKey)
+		if err != nil || identity == nil || identity.Verified == nil {
+			return "", err
+		}
+		return identity.GetDID(), nil
+	}
 
 	return im.OrgDID(org), nil
 
 }
 
+// VerifySigningKeyAuthor checks whether a signing key that resolves to a different identity than the author, belongs
+// to an org registered beneath the author org in its identity chain - so a multi-key org can sign with any of those keys
+func (im *identityManager) VerifySigningKeyAuthor(ctx context.Context, signingKey, author string) (valid bool, err error) {
+	org, err := im.cachedOrgLookupByAuthor(ctx, author)
+	if err != nil {
+		return false, err
+	}
+	return im.keyInOrgChain(ctx, signingKey, org)
+}
+
+// keyInOrgChain returns true if the signing key is that of an org beneath the supplied org, anywhere in its identity chain
+func (im *identityManager) keyInOrgChain(ctx context.Context, signingKey string, org *fftypes.Organization) (bool, error) {
+	candidate, err := im.cachedOrgLookupBySigningKey(ctx, signingKey)
+	for err == nil && candidate != nil && candidate.Parent != "" {
+		if candidate.Parent == org.Identity {
+			return true, nil
+		}
+		candidate, err = im.cachedOrgLookupBySigningKey(ctx, candidate.Parent)
+	}
+	return false, err
+}
+
+// ResolvePseudonymIdentity resolves an input identity that uses the DID of a pseudonym as the author. Only pseudonyms
+// registered by the local org in the namespace can be used, as we must be able to sign with the key.
+func (im *identityManager) ResolvePseudonymIdentity(ctx context.Context, ns string, identity *fftypes.Identity) (err error) {
+	pseudonymID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(identity.Author, fftypes.FireflyPseudonymDIDPrefix))
+	if err != nil {
+		return err
+	}
+	pseudonym, err := im.database.GetPseudonymByID(ctx, pseudonymID)
+	if err != nil {
+		return err
+	}
+	if pseudonym == nil || pseudonym.Namespace != ns {
+		return i18n.NewError(ctx, i18n.MsgPseudonymNotFound, identity.Author, ns)
+	}
+	localOrgDID, err := im.ResolveLocalOrgDID(ctx)
+	if err != nil {
+		return err
+	}
+	if pseudonym.Owner != localOrgDID {
+		return i18n.NewError(ctx, i18n.MsgPseudonymNotOwned, identity.Author)
+	}
+	if identity.Key != "" {
+		if identity.Key, err = im.ResolveSigningKey(ctx, ns, identity.Key); err != nil {
+			return err
+		}
+		if identity.Key != pseudonym.Key {
+			return i18n.NewError(ctx, i18n.MsgPseudonymKeyMismatch, identity.Key, identity.Author)
+		}
+	}
+	identity.Key = pseudonym.Key
+	identity.Author = pseudonym.GetDID()
+	return nil
+}
+
+// ResolveDelegatedIdentity resolves an input identity that sends on behalf of another org, under a delegation that org
+// has granted to the local org in the namespace. The author is the delegator, and the key is that of the local org
+// (or of an org registered beneath it), as that is the key this node can sign with.
+func (im *identityManager) ResolveDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) (err error) {
+	delegation, err := im.database.GetDelegationByID(ctx, delegationID)
+	if err != nil {
+		return err
+	}
+	if delegation == nil || delegation.Namespace != ns {
+		return i18n.NewError(ctx, i18n.MsgDelegationNotFound, delegationID, ns)
+	}
+	if delegation.Revoked != nil {
+		return i18n.NewError(ctx, i18n.MsgDelegationRevoked, delegationID)
+	}
+	if identity.Author != "" {
+		org, err := im.cachedOrgLookupByAuthor(ctx, identity.Author)
+		if err != nil {
+			return err
+		}
+		if org.GetDID() != delegation.Delegator {
+			return i18n.NewError(ctx, i18n.MsgDelegationAuthorMismatch, identity.Author, delegation.Delegator, delegationID)
+		}
+	}
+	localOrg, err := im.GetLocalOrganization(ctx)
+	if err != nil {
+		return err
+	}
+	if localOrg.GetDID() != delegation.Delegate {
+		return i18n.NewError(ctx, i18n.MsgDelegationNotToLocalOrg, delegationID, delegation.Delegate, localOrg.GetDID())
+	}
+	if identity.Key == "" {
+		identity.Key = localOrg.Identity
+	} else {
+		if identity.Key, err = im.ResolveSigningKey(ctx, ns, identity.Key); err != nil {
+			return err
+		}
+		isDelegate, err := im.keyBelongsToOrg(ctx, identity.Key, delegation.Delegate)
+		if err != nil {
+			return err
+		}
+		if !isDelegate {
+			return i18n.NewError(ctx, i18n.MsgDelegationKeyMismatch, identity.Key, delegation.Delegate)
+		}
+	}
+	identity.Author = delegation.Delegator
+	return nil
+}
+
+// VerifyDelegatedIdentity checks the author and key of a message received from the network are covered by a delegation in
+// the namespace, that has not been revoked. A delegation that has not been confirmed yet is pending, as the definition can
+// arrive after the messages sent under it. Only database errors are returned, as a failed verification is not retryable.
+func (im *identityManager) VerifyDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) (valid, pending bool, err error) {
+	delegation, err := im.database.GetDelegationByID(ctx, delegationID)
+	if err != nil || delegation == nil {
+		return false, err == nil, err
+	}
+	if delegation.Namespace != ns || delegation.Revoked != nil || delegation.Delegator != identity.Author {
+		return false, false, nil
+	}
+	valid, err = im.keyBelongsToOrg(ctx, identity.Key, delegation.Delegate)
+	return valid, false, err
+}
+
+// keyBelongsToOrg returns true if the signing key is that of the org with the supplied DID, or of an org beneath it in its identity chain
+func (im *identityManager) keyBelongsToOrg(ctx context.Context, signingKey, orgDID string) (bool, error) {
+	candidate, err := im.cachedOrgLookupBySigningKey(ctx, signingKey)
+	for err == nil && candidate != nil {
+		if candidate.GetDID() == orgDID {
+			return true, nil
+		}
+		if candidate.Parent == "" {
+			break
+		}
+		candidate, err = im.cachedOrgLookupBySigningKey(ctx, candidate.Parent)
+	}
+	return false, err
+}
+
+// GetCustomIdentityByDID resolves the DID of a custom identity, which can only be used once its parent has verified it
+func (im *identityManager) GetCustomIdentityByDID(ctx context.Context, did string) (*fftypes.CustomIdentity, error) {
+	identityID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(did, fftypes.FireflyIdentityDIDPrefix))
+	if err != nil {
+		return nil, err
+	}
+	identity, err := im.database.GetCustomIdentityByID(ctx, identityID)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgIdentityNotFound, did)
+	}
+	if identity.Verified == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgIdentityNotVerified, did, identity.Parent)
+	}
+	return identity, nil
+}
+
+func (im *identityManager) resolveCustomIdentityAuthor(ctx context.Context, identity *fftypes.Identity) error {
+	customIdentity, err := im.GetCustomIdentityByDID(ctx, identity.Author)
+	if err != nil {
+		return err
+	}
+	if identity.Key == "" {
+		identity.Key = customIdentity.Key
+	} else if identity.Key != customIdentity.Key {
+		return i18n.NewError(ctx, i18n.MsgIdentityKeyMismatch, identity.Key, identity.Author)
+	}
+	identity.Author = customIdentity.GetDID()
+	return nil
+}
+
 func (im *identityManager) GetOrgKey(ctx context.Context) string {
 	orgKey := config.GetString(config.OrgKey)
 	if orgKey == "" {
@@ -148,18 +332,21 @@ func (im *identityManager) ResolveLocalOrgDID(ctx context.Context) (localOrgDID
 	return im.localOrgDID, err
 }
 
-func (im *identityManager) ResolveSigningKey(ctx context.Context, inputKey string) (outputKey string, err error) {
+// ResolveSigningKey resolves a key with the blockchain connector the namespace is bound to. Org identities are
+// not scoped to a namespace, so keys resolved for them use the system namespace.
+func (im *identityManager) ResolveSigningKey(ctx context.Context, ns, inputKey string) (outputKey string, err error) {
 	// Resolve the signing key
 	if inputKey != "" {
-		if cached := im.signingKeyCache.Get(inputKey); cached != nil {
+		cacheKey := ns + "/" + inputKey
+		if cached := im.signingKeyCache.Get(cacheKey); cached != nil {
 			cached.Extend(im.identityCacheTTL)
 			outputKey = cached.Value().(string)
 		} else {
-			outputKey, err = im.blockchain.ResolveSigningKey(ctx, inputKey)
+			outputKey, err = im.blockchain.ResolveSigningKey(ctx, ns, inputKey)
 			if err != nil {
 				return "", err
 			}
-			im.signingKeyCache.Set(inputKey, outputKey, im.identityCacheTTL)
+			im.signingKeyCache.Set(cacheKey, outputKey, im.identityCacheTTL)
 		}
 	}
 	return
@@ -218,6 +405,10 @@ func (im *identityManager) cachedOrgLookupByAuthor(ctx context.Context, author s
 
 func (im *identityManager) resolveInputAuthor(ctx context.Context, identity *fftypes.Identity) (err error) {
 
+	if strings.HasPrefix(identity.Author, fftypes.FireflyIdentityDIDPrefix) {
+		return im.resolveCustomIdentityAuthor(ctx, identity)
+	}
+
 	var org *fftypes.Organization
 	if identity.Author == "" {
 		// We allow lookup of an org by signing key (this convenience mechanism is currently not cached)
@@ -238,12 +429,17 @@ func (im *identityManager) resolveInputAuthor(ctx context.Context, identity *fft
 		}
 	}
 
-	// TODO: Organizations should be able to have multiple signing keys. See notes below about whether a level of
-	//       indirection is needed in front of orgs (likely it is).
+	// An org signs with its own key by default, but can be pinned to the key of any org registered beneath it
 	if identity.Key == "" {
 		identity.Key = org.Identity
 	} else if org.Identity != identity.Key {
-		return i18n.NewError(ctx, i18n.MsgAuthorOrgSigningKeyMismatch, org.ID, identity.Key)
+		inChain, err := im.keyInOrgChain(ctx, identity.Key, org)
+		if err != nil {
+			return err
+		}
+		if !inChain {
+			return i18n.NewError(ctx, i18n.MsgAuthorOrgSigningKeyMismatch, org.ID, identity.Key)
+		}
 	}
 
 	// We normalize the author to the DID
@@ -27,6 +27,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/identitymocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func newTestIdentityManager(t *testing.T) (context.Context, *identityManager) {
@@ -87,7 +88,7 @@ func TestResolveInputIdentityBlankShortKeyNameResolved(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x12345", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x12345", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "0x12345").Return(org, nil).Once()
 
@@ -115,7 +116,7 @@ func TestResolveInputIdentityBlankShortKeyNameUnresolved(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x12345", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x12345", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "0x12345").Return(nil, nil).Once()
 	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
@@ -140,7 +141,7 @@ func TestResolveInputIdentityBlankShortKeyNameFail(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x12345", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x12345", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "0x12345").Return(nil, fmt.Errorf("pop")).Once()
 
@@ -168,7 +169,7 @@ func TestResolveInputIdentityOrgIdShortKeyName(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x12345", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x12345", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
 
@@ -202,9 +203,15 @@ func TestResolveInputIdentityOrgKeyMismatch(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("0x111111", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x111111", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x111111", Parent: "0x333333",
+	}, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x333333",
+	}, nil).Once()
 
 	err := im.ResolveInputIdentity(ctx, identity)
 	assert.Regexp(t, "FF10279", err)
@@ -213,6 +220,100 @@ func TestResolveInputIdentityOrgKeyMismatch(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestResolveInputIdentityOrgChildKey(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "org1key",
+		Author: "org1",
+	}
+	org := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x222222",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x111111", nil).Once()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x111111", Parent: "0x222222",
+	}, nil).Once()
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x111111", identity.Key)
+	assert.Equal(t, org.GetDID(), identity.Author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityOrgChildKeyLookupFail(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Key:    "org1key",
+		Author: "org1",
+	}
+	org := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x222222",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("0x111111", nil).Once()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(org, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(nil, fmt.Errorf("pop")).Once()
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.EqualError(t, err, "pop")
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifySigningKeyAuthorChildKey(t *testing.T) {
+
+	org := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x222222",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", ctx, org.ID).Return(org, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x111111", Parent: "0x333333",
+	}, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x333333", Parent: "0x222222",
+	}, nil).Once()
+
+	valid, err := im.VerifySigningKeyAuthor(ctx, "0x111111", org.GetDID())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifySigningKeyAuthorNotFound(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(nil, nil).Once()
+
+	valid, err := im.VerifySigningKeyAuthor(ctx, "0x111111", "org1")
+	assert.Regexp(t, "FF10278", err)
+	assert.False(t, valid)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestResolveInputIdentityResolveKeyFail(t *testing.T) {
 
 	identity := &fftypes.Identity{
@@ -221,7 +322,7 @@ func TestResolveInputIdentityResolveKeyFail(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "org1key").Return("", fmt.Errorf("pop"))
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "org1key").Return("", fmt.Errorf("pop"))
 
 	err := im.ResolveInputIdentity(ctx, identity)
 	assert.Regexp(t, err, "pop")
@@ -306,7 +407,7 @@ func TestResolveSigningKeyIdentityBadSigningKey(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "badness").Return("", fmt.Errorf("pop"))
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "badness").Return("", fmt.Errorf("pop"))
 
 	_, err := im.ResolveSigningKeyIdentity(ctx, "badness")
 	assert.Regexp(t, "pop", err)
@@ -317,7 +418,7 @@ func TestResolveSigningKeyIdentityOrgLookupFail(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, fmt.Errorf("pop"))
 
@@ -335,7 +436,7 @@ func TestResolveSigningKeyIdentityOrgLookupOkCached(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(org, nil).Once()
 
@@ -355,9 +456,11 @@ func TestResolveSigningKeyIdentityOrgLookupUnresolved(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", ctx, "key1resolved").Return(nil, nil)
 
 	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
 	assert.NoError(t, err)
@@ -366,6 +469,585 @@ func TestResolveSigningKeyIdentityOrgLookupUnresolved(t *testing.T) {
 	mbi.AssertExpectations(t)
 }
 
+func TestResolveSigningKeyIdentityPseudonym(t *testing.T) {
+
+	pseudonym := &fftypes.Pseudonym{
+		ID:  fftypes.NewUUID(),
+		Key: "key1resolved",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(pseudonym, nil)
+
+	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, pseudonym.GetDID(), author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyIdentityPseudonymLookupFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, fmt.Errorf("pop"))
+
+	_, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.Regexp(t, "pop", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyIdentityCustomIdentity(t *testing.T) {
+
+	identity := &fftypes.CustomIdentity{
+		ID:       fftypes.NewUUID(),
+		Key:      "key1resolved",
+		Verified: fftypes.Now(),
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", ctx, "key1resolved").Return(identity, nil)
+
+	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, identity.GetDID(), author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyIdentityCustomIdentityNotVerified(t *testing.T) {
+
+	identity := &fftypes.CustomIdentity{
+		ID:  fftypes.NewUUID(),
+		Key: "key1resolved",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", ctx, "key1resolved").Return(identity, nil)
+
+	author, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", author)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveSigningKeyIdentityCustomIdentityLookupFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, nil)
+	mdi.On("GetCustomIdentityByKey", ctx, "key1resolved").Return(nil, fmt.Errorf("pop"))
+
+	_, err := im.ResolveSigningKeyIdentity(ctx, "key1")
+	assert.Regexp(t, "pop", err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityCustomIdentity(t *testing.T) {
+
+	customIdentity := &fftypes.CustomIdentity{
+		ID:       fftypes.NewUUID(),
+		Key:      "0x12345",
+		Verified: fftypes.Now(),
+	}
+	identity := &fftypes.Identity{
+		Author: customIdentity.GetDID(),
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", ctx, customIdentity.ID).Return(customIdentity, nil)
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", identity.Key)
+	assert.Equal(t, customIdentity.GetDID(), identity.Author)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityCustomIdentityKeyMismatch(t *testing.T) {
+
+	customIdentity := &fftypes.CustomIdentity{
+		ID:       fftypes.NewUUID(),
+		Key:      "0x12345",
+		Verified: fftypes.Now(),
+	}
+	identity := &fftypes.Identity{
+		Author: customIdentity.GetDID(),
+		Key:    "0xabcde",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "0xabcde").Return("0xabcde", nil)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", ctx, customIdentity.ID).Return(customIdentity, nil)
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.Regexp(t, "FF10417", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInputIdentityCustomIdentityBadDID(t *testing.T) {
+
+	identity := &fftypes.Identity{
+		Author: fftypes.FireflyIdentityDIDPrefix + "!bad",
+	}
+
+	ctx, im := newTestIdentityManager(t)
+
+	err := im.ResolveInputIdentity(ctx, identity)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetCustomIdentityByDIDLookupFail(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	identityID := fftypes.NewUUID()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", ctx, identityID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := im.GetCustomIdentityByDID(ctx, fftypes.FireflyIdentityDIDPrefix+identityID.String())
+	assert.Regexp(t, "pop", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetCustomIdentityByDIDNotFound(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	identityID := fftypes.NewUUID()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", ctx, identityID).Return(nil, nil)
+
+	_, err := im.GetCustomIdentityByDID(ctx, fftypes.FireflyIdentityDIDPrefix+identityID.String())
+	assert.Regexp(t, "FF10414", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetCustomIdentityByDIDNotVerified(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	customIdentity := &fftypes.CustomIdentity{
+		ID:     fftypes.NewUUID(),
+		Parent: "did:firefly:org/" + fftypes.NewUUID().String(),
+	}
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", ctx, customIdentity.ID).Return(customIdentity, nil)
+
+	_, err := im.GetCustomIdentityByDID(ctx, customIdentity.GetDID())
+	assert.Regexp(t, "FF10415", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func newTestPseudonymIdentityManager(t *testing.T) (context.Context, *identityManager, *fftypes.Pseudonym) {
+	org := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Identity: "orgkey",
+	}
+	pseudonym := &fftypes.Pseudonym{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+		Owner:     org.GetDID(),
+	}
+	ctx, im := newTestIdentityManager(t)
+	im.localOrgDID = org.GetDID()
+	return ctx, im, pseudonym
+}
+
+func TestResolvePseudonymIdentityOk(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("0x12345", nil)
+
+	identity := &fftypes.Identity{Author: pseudonym.GetDID(), Key: "key1"}
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", identity.Key)
+	assert.Equal(t, pseudonym.GetDID(), identity.Author)
+
+	identity = &fftypes.Identity{Author: pseudonym.GetDID()}
+	err = im.ResolvePseudonymIdentity(ctx, "ns1", identity)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", identity.Key)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityBadID(t *testing.T) {
+
+	ctx, im, _ := newTestPseudonymIdentityManager(t)
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: "did:firefly:pseudonym/bad"})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestResolvePseudonymIdentityLookupFail(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(nil, fmt.Errorf("pop"))
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: pseudonym.GetDID()})
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityWrongNamespace(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns2", &fftypes.Identity{Author: pseudonym.GetDID()})
+	assert.Regexp(t, "FF10380", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityLocalOrgFail(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	im.localOrgDID = ""
+	config.Set(config.OrgKey, "orgkey")
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "orgkey").Return(nil, fmt.Errorf("pop"))
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "orgkey").Return("orgkey", nil)
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: pseudonym.GetDID()})
+	assert.Regexp(t, "FF10281", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityNotOwned(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	pseudonym.Owner = ""
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: pseudonym.GetDID()})
+	assert.Regexp(t, "FF10381", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityResolveKeyFail(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("", fmt.Errorf("pop"))
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: pseudonym.GetDID(), Key: "key1"})
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolvePseudonymIdentityKeyMismatch(t *testing.T) {
+
+	ctx, im, pseudonym := newTestPseudonymIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetPseudonymByID", ctx, pseudonym.ID).Return(pseudonym, nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "orgkey").Return("orgkey", nil)
+
+	err := im.ResolvePseudonymIdentity(ctx, "ns1", &fftypes.Identity{Author: pseudonym.GetDID(), Key: "orgkey"})
+	assert.Regexp(t, "FF10383", err)
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func newTestDelegationIdentityManager(t *testing.T) (context.Context, *identityManager, *fftypes.Delegation, *fftypes.Organization, *fftypes.Organization) {
+	delegator := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x111111",
+	}
+	delegate := &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org2",
+		Identity: "0x222222",
+	}
+	delegation := &fftypes.Delegation{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Delegator: delegator.GetDID(),
+		Delegate:  delegate.GetDID(),
+	}
+	ctx, im := newTestIdentityManager(t)
+	im.localOrgDID = delegate.GetDID()
+	return ctx, im, delegation, delegator, delegate
+}
+
+func TestResolveDelegatedIdentityOk(t *testing.T) {
+
+	ctx, im, delegation, delegator, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(delegator, nil).Once()
+	mdi.On("GetOrganizationByID", ctx, delegate.ID).Return(delegate, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(), Identity: "0x333333", Parent: "0x222222",
+	}, nil).Once()
+	mdi.On("GetOrganizationByIdentity", ctx, "0x222222").Return(delegate, nil).Once()
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key3").Return("0x333333", nil)
+
+	identity := &fftypes.Identity{Author: "org1"}
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", identity, delegation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, delegator.GetDID(), identity.Author)
+	assert.Equal(t, "0x222222", identity.Key)
+
+	// The key of an org beneath the delegate can be used
+	identity = &fftypes.Identity{Key: "key3"}
+	err = im.ResolveDelegatedIdentity(ctx, "ns1", identity, delegation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, delegator.GetDID(), identity.Author)
+	assert.Equal(t, "0x333333", identity.Key)
+
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityLookupFail(t *testing.T) {
+
+	ctx, im, delegation, _, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(nil, fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{}, delegation.ID)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityWrongNamespace(t *testing.T) {
+
+	ctx, im, delegation, _, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns2", &fftypes.Identity{}, delegation.ID)
+	assert.Regexp(t, "FF10448", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityRevoked(t *testing.T) {
+
+	ctx, im, delegation, _, _ := newTestDelegationIdentityManager(t)
+	delegation.Revoked = fftypes.Now()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{}, delegation.ID)
+	assert.Regexp(t, "FF10449", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityAuthorNotFound(t *testing.T) {
+
+	ctx, im, delegation, _, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByName", ctx, "org1").Return(nil, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: "org1"}, delegation.ID)
+	assert.Regexp(t, "FF10278", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityAuthorMismatch(t *testing.T) {
+
+	ctx, im, delegation, _, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByName", ctx, "org2").Return(delegate, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: "org2"}, delegation.ID)
+	assert.Regexp(t, "FF10451", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityLocalOrgFail(t *testing.T) {
+
+	ctx, im, delegation, _, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByID", ctx, delegate.ID).Return(nil, fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{}, delegation.ID)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityNotToLocalOrg(t *testing.T) {
+
+	ctx, im, delegation, delegator, _ := newTestDelegationIdentityManager(t)
+	im.localOrgDID = delegator.GetDID()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByID", ctx, delegator.ID).Return(delegator, nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{}, delegation.ID)
+	assert.Regexp(t, "FF10450", err)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityResolveKeyFail(t *testing.T) {
+
+	ctx, im, delegation, _, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByID", ctx, delegate.ID).Return(delegate, nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key3").Return("", fmt.Errorf("pop"))
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Key: "key3"}, delegation.ID)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityKeyLookupFail(t *testing.T) {
+
+	ctx, im, delegation, _, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByID", ctx, delegate.ID).Return(delegate, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x333333").Return(nil, fmt.Errorf("pop"))
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key3").Return("0x333333", nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Key: "key3"}, delegation.ID)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestResolveDelegatedIdentityKeyMismatch(t *testing.T) {
+
+	ctx, im, delegation, delegator, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByID", ctx, delegate.ID).Return(delegate, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(delegator, nil)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("0x111111", nil)
+
+	err := im.ResolveDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Key: "key1"}, delegation.ID)
+	assert.Regexp(t, "FF10452", err)
+	mdi.AssertExpectations(t)
+	mbi.AssertExpectations(t)
+}
+
+func TestVerifyDelegatedIdentityOk(t *testing.T) {
+
+	ctx, im, delegation, delegator, delegate := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x222222").Return(delegate, nil)
+
+	valid, pending, err := im.VerifyDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: delegator.GetDID(), Key: "0x222222"}, delegation.ID)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.False(t, pending)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifyDelegatedIdentityNotFound(t *testing.T) {
+
+	ctx, im, delegation, delegator, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(nil, nil)
+
+	valid, pending, err := im.VerifyDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: delegator.GetDID(), Key: "0x222222"}, delegation.ID)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.True(t, pending)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifyDelegatedIdentityRevoked(t *testing.T) {
+
+	ctx, im, delegation, delegator, _ := newTestDelegationIdentityManager(t)
+	delegation.Revoked = fftypes.Now()
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+
+	valid, pending, err := im.VerifyDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: delegator.GetDID(), Key: "0x222222"}, delegation.ID)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.False(t, pending)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifyDelegatedIdentityKeyNotDelegate(t *testing.T) {
+
+	ctx, im, delegation, delegator, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(delegation, nil)
+	mdi.On("GetOrganizationByIdentity", ctx, "0x111111").Return(delegator, nil)
+
+	valid, pending, err := im.VerifyDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: delegator.GetDID(), Key: "0x111111"}, delegation.ID)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.False(t, pending)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifyDelegatedIdentityNotFoundFail(t *testing.T) {
+
+	ctx, im, delegation, delegator, _ := newTestDelegationIdentityManager(t)
+	mdi := im.database.(*databasemocks.Plugin)
+	mdi.On("GetDelegationByID", ctx, delegation.ID).Return(nil, fmt.Errorf("pop"))
+
+	valid, pending, err := im.VerifyDelegatedIdentity(ctx, "ns1", &fftypes.Identity{Author: delegator.GetDID(), Key: "0x222222"}, delegation.ID)
+	assert.EqualError(t, err, "pop")
+	assert.False(t, valid)
+	assert.False(t, pending)
+	mdi.AssertExpectations(t)
+}
+
 func TestResolveLocalOrgDIDSuccess(t *testing.T) {
 
 	org := &fftypes.Organization{
@@ -376,7 +1058,7 @@ func TestResolveLocalOrgDIDSuccess(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(org, nil).Once()
 
@@ -404,7 +1086,7 @@ func TestResolveLocalOrgDIDFail(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, fmt.Errorf("pop")).Twice()
 
@@ -424,9 +1106,11 @@ func TestResolveLocalOrgDIDNotFound(t *testing.T) {
 
 	ctx, im := newTestIdentityManager(t)
 	mbi := im.blockchain.(*blockchainmocks.Plugin)
-	mbi.On("ResolveSigningKey", ctx, "key1").Return("key1resolved", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, mock.Anything, "key1").Return("key1resolved", nil).Once()
 	mdi := im.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", ctx, "key1resolved").Return(nil, nil).Once()
+	mdi.On("GetPseudonymByKey", ctx, "key1resolved").Return(nil, nil).Once()
+	mdi.On("GetCustomIdentityByKey", ctx, "key1resolved").Return(nil, nil).Once()
 
 	config.Set(config.OrgIdentityDeprecated, "key1")
 
@@ -436,3 +1120,23 @@ func TestResolveLocalOrgDIDNotFound(t *testing.T) {
 	mbi.AssertExpectations(t)
 
 }
+
+func TestResolveSigningKeyCachedPerNamespace(t *testing.T) {
+
+	ctx, im := newTestIdentityManager(t)
+	mbi := im.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("ResolveSigningKey", ctx, "ns1", "key1").Return("0x11111", nil).Once()
+	mbi.On("ResolveSigningKey", ctx, "ns2", "key1").Return("0x22222", nil).Once()
+
+	key, err := im.ResolveSigningKey(ctx, "ns1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x11111", key)
+	key, err = im.ResolveSigningKey(ctx, "ns2", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x22222", key)
+	key, err = im.ResolveSigningKey(ctx, "ns1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x11111", key)
+
+	mbi.AssertExpectations(t)
+}
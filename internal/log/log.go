@@ -19,16 +19,26 @@ package log
 import (
 	"context"
 	"strings"
+	"sync"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
 )
 
+// ComponentField is the log field used to look up per-component level overrides
+const ComponentField = "component"
+
 var (
 	rootLogger = logrus.NewEntry(logrus.StandardLogger())
 
 	// L accesses the current logger from the context
 	L = loggerFromContext
+
+	levels = &levelControl{
+		base:       logrus.InfoLevel,
+		components: make(map[string]logrus.Level),
+	}
 )
 
 type (
@@ -48,6 +58,11 @@ func WithLogField(ctx context.Context, key, value string) context.Context {
 	return WithLogger(ctx, loggerFromContext(ctx).WithField(key, value))
 }
 
+// WithComponent tags all logging in the context with a component, that can have its own log level
+func WithComponent(ctx context.Context, component string) context.Context {
+	return WithLogger(ctx, loggerFromContext(ctx).WithField(ComponentField, component))
+}
+
 // LoggerFromContext returns the logger for the current context, or no logger if there is no context
 func loggerFromContext(ctx context.Context) *logrus.Entry {
 	logger := ctx.Value(ctxLogKey{})
@@ -57,17 +72,118 @@ func loggerFromContext(ctx context.Context) *logrus.Entry {
 	return logger.(*logrus.Entry)
 }
 
-func SetLevel(level string) {
+// Levels is the base log level, and any per-component overrides
+type Levels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// ComponentLevel is the level to set for a single component - empty to remove the override
+type ComponentLevel struct {
+	Level string `json:"level"`
+}
+
+type levelControl struct {
+	mux        sync.RWMutex
+	base       logrus.Level
+	components map[string]logrus.Level
+}
+
+func parseLevel(level string) (logrus.Level, bool) {
 	switch strings.ToLower(level) {
 	case "error":
-		logrus.SetLevel(logrus.ErrorLevel)
+		return logrus.ErrorLevel, true
+	case "warn", "warning":
+		return logrus.WarnLevel, true
+	case "info":
+		return logrus.InfoLevel, true
 	case "debug":
-		logrus.SetLevel(logrus.DebugLevel)
+		return logrus.DebugLevel, true
 	case "trace":
-		logrus.SetLevel(logrus.TraceLevel)
+		return logrus.TraceLevel, true
 	default:
-		logrus.SetLevel(logrus.InfoLevel)
+		return logrus.InfoLevel, false
+	}
+}
+
+// apply sets logrus to the most verbose of the configured levels, with the filtering
+// formatter discarding entries that are more verbose than their component allows
+func (lc *levelControl) apply() {
+	maxLevel := lc.base
+	for _, l := range lc.components {
+		if l > maxLevel {
+			maxLevel = l
+		}
+	}
+	logger := logrus.StandardLogger()
+	if len(lc.components) > 0 {
+		if _, ok := logger.Formatter.(*levelFilter); !ok {
+			logger.SetFormatter(&levelFilter{f: logger.Formatter})
+		}
+	}
+	logger.SetLevel(maxLevel)
+}
+
+func (lc *levelControl) enabled(e *logrus.Entry) bool {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+	level := lc.base
+	if component, ok := e.Data[ComponentField].(string); ok {
+		if l, ok := lc.components[component]; ok {
+			level = l
+		}
+	}
+	return e.Level <= level
+}
+
+type levelFilter struct {
+	f logrus.Formatter
+}
+
+func (lf *levelFilter) Format(e *logrus.Entry) ([]byte, error) {
+	if !levels.enabled(e) {
+		return nil, nil
+	}
+	return lf.f.Format(e)
+}
+
+// SetLevel sets the base log level, defaulting to info for unknown levels
+func SetLevel(level string) {
+	levels.mux.Lock()
+	defer levels.mux.Unlock()
+	levels.base, _ = parseLevel(level)
+	levels.apply()
+}
+
+// SetComponentLevel overrides the log level for a component, or removes the override if the level is empty
+func SetComponentLevel(ctx context.Context, component, level string) error {
+	levels.mux.Lock()
+	defer levels.mux.Unlock()
+	if level == "" {
+		delete(levels.components, component)
+	} else {
+		l, ok := parseLevel(level)
+		if !ok {
+			return i18n.NewError(ctx, i18n.MsgInvalidLogLevel, level)
+		}
+		levels.components[component] = l
+	}
+	levels.apply()
+	return nil
+}
+
+// GetLevels returns the base log level, and all per-component overrides
+func GetLevels() *Levels {
+	levels.mux.RLock()
+	defer levels.mux.RUnlock()
+	result := &Levels{
+		Level:      levels.base.String(),
+		Components: make(map[string]string, len(levels.components)),
+	}
+	for component, l := range levels.components {
+		result.Components[component] = l.String()
 	}
+	return result
 }
 
 type Formatting struct {
@@ -75,6 +191,7 @@ type Formatting struct {
 	ForceColor      bool
 	TimestampFormat string
 	UTC             bool
+	JSON            bool
 }
 
 type utcFormat struct {
@@ -87,16 +204,24 @@ func (utc *utcFormat) Format(e *logrus.Entry) ([]byte, error) {
 }
 
 func SetFormatting(format Formatting) {
-	var formatter logrus.Formatter = &prefixed.TextFormatter{
-		DisableColors:   format.DisableColor,
-		ForceColors:     format.ForceColor,
-		TimestampFormat: format.TimestampFormat,
-		DisableSorting:  false,
-		ForceFormatting: true,
-		FullTimestamp:   true,
+	var formatter logrus.Formatter
+	if format.JSON {
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: format.TimestampFormat,
+		}
+	} else {
+		formatter = &prefixed.TextFormatter{
+			DisableColors:   format.DisableColor,
+			ForceColors:     format.ForceColor,
+			TimestampFormat: format.TimestampFormat,
+			DisableSorting:  false,
+			ForceFormatting: true,
+			FullTimestamp:   true,
+		}
 	}
 	if format.UTC {
 		formatter = &utcFormat{f: formatter}
 	}
-	logrus.SetFormatter(formatter)
+	// Per-component levels are always filtered, as overrides can be added at runtime
+	logrus.SetFormatter(&levelFilter{f: formatter})
 }
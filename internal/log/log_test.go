@@ -66,3 +66,54 @@ func TestSetFormatting(t *testing.T) {
 	})
 	L(context.Background()).Infof("time in UTC")
 }
+
+func TestSetFormattingJSON(t *testing.T) {
+	SetFormatting(Formatting{
+		JSON: true,
+	})
+	L(context.Background()).Infof("JSON formatted")
+	SetFormatting(Formatting{})
+}
+
+func TestComponentLevels(t *testing.T) {
+	ctx := context.Background()
+	SetLevel("info")
+	logrus.SetFormatter(&logrus.TextFormatter{})
+
+	err := SetComponentLevel(ctx, "events", "debug")
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.IsType(t, &levelFilter{}, logrus.StandardLogger().Formatter)
+	assert.Equal(t, &Levels{
+		Level:      "info",
+		Components: map[string]string{"events": "debug"},
+	}, GetLevels())
+
+	entry := L(WithComponent(ctx, "batch")).Dup()
+	entry.Level = logrus.DebugLevel
+	b, err := logrus.StandardLogger().Formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+
+	entry = L(WithComponent(ctx, "events")).Dup()
+	entry.Level = logrus.DebugLevel
+	b, err = logrus.StandardLogger().Formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, b)
+
+	err = SetComponentLevel(ctx, "events", "")
+	assert.NoError(t, err)
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.Empty(t, GetLevels().Components)
+}
+
+func TestComponentLevelsBadLevel(t *testing.T) {
+	err := SetComponentLevel(context.Background(), "events", "loud")
+	assert.Regexp(t, "FF10353", err)
+}
+
+func TestSettingWarnLevel(t *testing.T) {
+	SetLevel("warn")
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	SetLevel("info")
+}
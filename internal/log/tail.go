@@ -0,0 +1,162 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// tailBufferSize is the number of entries buffered for each tail, before entries are dropped
+const tailBufferSize = 1000
+
+var (
+	tails        = &tailHook{tails: make(map[*tailReader]bool)}
+	tailHookOnce sync.Once
+)
+
+// TailEntry is a single structured log line delivered to a tail
+type TailEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+type tailHook struct {
+	mux   sync.Mutex
+	tails map[*tailReader]bool
+}
+
+type tailReader struct {
+	ctx           context.Context
+	correlationID string
+	entries       chan []byte
+	pending       []byte
+	closeOnce     sync.Once
+}
+
+// Tail returns a stream of newline delimited JSON log entries, for every log line that references
+// the correlation ID in its message or fields. The stream ends when the context is done, or it is closed.
+// Entries are dropped rather than blocking logging, if the reader cannot keep up.
+func Tail(ctx context.Context, correlationID string) io.ReadCloser {
+	tailHookOnce.Do(func() {
+		logrus.StandardLogger().AddHook(tails)
+	})
+	tr := &tailReader{
+		ctx:           ctx,
+		correlationID: correlationID,
+		entries:       make(chan []byte, tailBufferSize),
+	}
+	tails.mux.Lock()
+	tails.tails[tr] = true
+	tails.mux.Unlock()
+	return tr
+}
+
+func (th *tailHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (th *tailHook) Fire(e *logrus.Entry) error {
+	if !levels.enabled(e) {
+		return nil
+	}
+	th.mux.Lock()
+	defer th.mux.Unlock()
+	var line []byte
+	for tr := range th.tails {
+		if !tr.matches(e) {
+			continue
+		}
+		if line == nil {
+			line = tailLine(e)
+		}
+		select {
+		case tr.entries <- line:
+		default:
+		}
+	}
+	return nil
+}
+
+func tailLine(e *logrus.Entry) []byte {
+	entry := &TailEntry{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Message: e.Message,
+		Fields:  make(map[string]interface{}, len(e.Data)),
+	}
+	for k, v := range e.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		entry.Fields[k] = v
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		entry.Fields = nil
+		b, _ = json.Marshal(entry)
+	}
+	return append(b, '\n')
+}
+
+func (tr *tailReader) matches(e *logrus.Entry) bool {
+	if strings.Contains(e.Message, tr.correlationID) {
+		return true
+	}
+	for _, v := range e.Data {
+		if strings.Contains(fmt.Sprintf("%v", v), tr.correlationID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tr *tailReader) Read(p []byte) (int, error) {
+	if len(tr.pending) == 0 {
+		// Entries already buffered are delivered, even once the context is done
+		select {
+		case tr.pending = <-tr.entries:
+		default:
+			select {
+			case tr.pending = <-tr.entries:
+			case <-tr.ctx.Done():
+				return 0, io.EOF
+			}
+		}
+	}
+	n := copy(p, tr.pending)
+	tr.pending = tr.pending[n:]
+	return n, nil
+}
+
+func (tr *tailReader) Close() error {
+	tr.closeOnce.Do(func() {
+		tails.mux.Lock()
+		delete(tails.tails, tr)
+		tails.mux.Unlock()
+	})
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailMatchingEntries(t *testing.T) {
+	out := logrus.StandardLogger().Out
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(out)
+	SetLevel("info")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := Tail(ctx, "tx1234")
+	defer tr.Close()
+
+	L(context.Background()).Infof("Processing tx1234")
+	L(context.Background()).Infof("Unrelated")
+	L(context.Background()).Debugf("Too verbose tx1234")
+	err := SetComponentLevel(context.Background(), "quiet", "error")
+	assert.NoError(t, err)
+	L(WithComponent(context.Background(), "quiet")).Infof("Too verbose for component tx1234")
+	err = SetComponentLevel(context.Background(), "quiet", "")
+	assert.NoError(t, err)
+	L(WithLogField(context.Background(), "tx", "tx1234")).WithError(fmt.Errorf("pop")).Errorf("Failed")
+	L(context.Background()).WithField("ch", make(chan bool)).Warnf("Unmarshallable tx1234")
+	cancel()
+
+	scanner := bufio.NewScanner(tr)
+	var entries []*TailEntry
+	for scanner.Scan() {
+		var entry TailEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		assert.NoError(t, err)
+		entries = append(entries, &entry)
+	}
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "Processing tx1234", entries[0].Message)
+	assert.Equal(t, "info", entries[0].Level)
+	assert.Equal(t, "Failed", entries[1].Message)
+	assert.Equal(t, "tx1234", entries[1].Fields["tx"])
+	assert.Equal(t, "pop", entries[1].Fields["error"])
+	assert.Equal(t, "Unmarshallable tx1234", entries[2].Message)
+	assert.Nil(t, entries[2].Fields)
+}
+
+func TestTailPartialReads(t *testing.T) {
+	out := logrus.StandardLogger().Out
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := Tail(ctx, "msg1234")
+
+	L(context.Background()).Infof("msg1234")
+	cancel()
+
+	buf := make([]byte, 1)
+	b, err := ioutil.ReadAll(io.LimitReader(tr, 1000))
+	assert.NoError(t, err)
+	assert.Regexp(t, `"message":"msg1234"`, string(b))
+	_, err = tr.Read(buf)
+	assert.Equal(t, io.EOF, err)
+
+	tr.Close()
+	tr.Close()
+	assert.NotContains(t, tails.tails, tr)
+}
+
+func TestTailDropsWhenFull(t *testing.T) {
+	out := logrus.StandardLogger().Out
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(out)
+
+	tr := Tail(context.Background(), "op1234")
+	defer tr.Close()
+
+	for i := 0; i < tailBufferSize+1; i++ {
+		L(context.Background()).Infof("op1234")
+	}
+	assert.Equal(t, tailBufferSize, len(tr.(*tailReader).entries))
+}
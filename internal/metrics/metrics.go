@@ -17,16 +17,58 @@
 package metrics
 
 import (
+	"database/sql"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 var registry *prometheus.Registry
 var BatchPinCounter prometheus.Counter
+var RESTClientInflightGauge *prometheus.GaugeVec
+var RESTClientQueuedGauge *prometheus.GaugeVec
+var SyncAsyncInflightGauge *prometheus.GaugeVec
+var SyncAsyncDurationHistogram *prometheus.HistogramVec
+var BatchSealedCounter *prometheus.CounterVec
+var BatchMessagesCounter *prometheus.CounterVec
+var BlockchainTransactionSubmittedCounter *prometheus.CounterVec
+var BlockchainTransactionCompletedCounter *prometheus.CounterVec
+var EventDispatchLagHistogram *prometheus.HistogramVec
+var DBQueryDurationHistogram *prometheus.HistogramVec
 
 // MetricsBatchPin is the prometheus metric for total number of batch pins submitted
 var MetricsBatchPin = "ff_batchpin_total"
 
+// MetricsRESTClientInflight is the prometheus metric for requests in flight to a connector with a concurrency limit
+var MetricsRESTClientInflight = "ff_restclient_inflight"
+
+// MetricsRESTClientQueued is the prometheus metric for requests waiting on the concurrency limit of a connector
+var MetricsRESTClientQueued = "ff_restclient_queued"
+
+// MetricsSyncAsyncInflight is the prometheus metric for API requests waiting on a synchronous confirmation
+var MetricsSyncAsyncInflight = "ff_syncasync_inflight"
+
+// MetricsSyncAsyncDuration is the prometheus metric for the time API requests wait on a synchronous confirmation
+var MetricsSyncAsyncDuration = "ff_syncasync_duration_seconds"
+
+// MetricsBatchSealed is the prometheus metric for total number of batches sealed for dispatch
+var MetricsBatchSealed = "ff_batch_sealed_total"
+
+// MetricsBatchMessages is the prometheus metric for total number of messages assembled into sealed batches
+var MetricsBatchMessages = "ff_batch_messages_total"
+
+// MetricsBlockchainTransactionSubmitted is the prometheus metric for total number of blockchain transactions submitted
+var MetricsBlockchainTransactionSubmitted = "ff_blockchain_transactions_submitted_total"
+
+// MetricsBlockchainTransactionCompleted is the prometheus metric for total number of blockchain transactions confirmed or failed
+var MetricsBlockchainTransactionCompleted = "ff_blockchain_transactions_completed_total"
+
+// MetricsEventDispatchLag is the prometheus metric for the time between an event being created, and dispatched to a subscription
+var MetricsEventDispatchLag = "ff_event_dispatch_lag_seconds"
+
+// MetricsDBQueryDuration is the prometheus metric for the time taken by database operations
+var MetricsDBQueryDuration = "ff_db_query_duration_seconds"
+
 // Registry returns FireFly's customized Prometheus registry
 func Registry() *prometheus.Registry {
 	if registry == nil {
@@ -43,12 +85,78 @@ func initMetricsCollectors() {
 		Name: MetricsBatchPin,
 		Help: "Number of batch pins submitted",
 	})
+	RESTClientInflightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricsRESTClientInflight,
+		Help: "Number of requests in flight to a connector",
+	}, []string{"client"})
+	RESTClientQueuedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricsRESTClientQueued,
+		Help: "Number of requests queued waiting for a connector",
+	}, []string{"client"})
+	SyncAsyncInflightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: MetricsSyncAsyncInflight,
+		Help: "Number of API requests waiting on a synchronous confirmation",
+	}, []string{"ns", "type"})
+	SyncAsyncDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    MetricsSyncAsyncDuration,
+		Help:    "Time API requests waited on a synchronous confirmation",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"ns", "type"})
+	BatchSealedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricsBatchSealed,
+		Help: "Number of batches sealed for dispatch",
+	}, []string{"ns", "type"})
+	BatchMessagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricsBatchMessages,
+		Help: "Number of messages assembled into sealed batches",
+	}, []string{"ns", "type"})
+	BlockchainTransactionSubmittedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricsBlockchainTransactionSubmitted,
+		Help: "Number of blockchain transactions submitted",
+	}, []string{"ns", "type"})
+	BlockchainTransactionCompletedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: MetricsBlockchainTransactionCompleted,
+		Help: "Number of blockchain transactions confirmed, or failed, by the connector",
+	}, []string{"ns", "type", "status"})
+	EventDispatchLagHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    MetricsEventDispatchLag,
+		Help:    "Time between an event being created, and being dispatched to a subscription",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"ns", "transport"})
+	DBQueryDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    MetricsDBQueryDuration,
+		Help:    "Time taken by database operations",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"db", "operation"})
 }
 
 func registerMetricsCollectors() {
 	registry.MustRegister(collectors.NewGoCollector())
 	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 	registry.MustRegister(BatchPinCounter)
+	registry.MustRegister(RESTClientInflightGauge)
+	registry.MustRegister(RESTClientQueuedGauge)
+	registry.MustRegister(SyncAsyncInflightGauge)
+	registry.MustRegister(SyncAsyncDurationHistogram)
+	registry.MustRegister(BatchSealedCounter)
+	registry.MustRegister(BatchMessagesCounter)
+	registry.MustRegister(BlockchainTransactionSubmittedCounter)
+	registry.MustRegister(BlockchainTransactionCompletedCounter)
+	registry.MustRegister(EventDispatchLagHistogram)
+	registry.MustRegister(DBQueryDurationHistogram)
+}
+
+// RegisterDBStats registers a collector for the connection pool statistics of a database,
+// replacing any collector previously registered for a database with the same name
+func RegisterDBStats(dbName string, db *sql.DB) {
+	r := Registry()
+	c := collectors.NewDBStatsCollector(db, dbName)
+	if err := r.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			r.Unregister(are.ExistingCollector)
+			r.MustRegister(c)
+		}
+	}
 }
 
 // Clear will reset the Prometheus metrics registry, useful for testing
@@ -0,0 +1,129 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// organizationHistoricFields and nodeHistoricFields are the fields that are replaced each time the identity
+// is re-broadcast, so cannot be used to filter or sort a query for the network map at a point in history
+var organizationHistoricFields = map[string]bool{"message": true, "description": true, "profile": true, "created": true}
+var nodeHistoricFields = map[string]bool{"message": true, "description": true, "dx.peer": true, "dx.endpoint": true, "created": true}
+
+// definitionAsOf walks back through the confirmed definitions broadcast on a topic, up to the point in
+// history, and returns the message of the first one that match accepts. Each payload is passed to match
+// to be unmarshalled, as the nodes of an organization share its topic.
+func (nm *networkMap) definitionAsOf(ctx context.Context, topic string, tag fftypes.SystemTag, asOf *fftypes.FFTime, match func(value []byte) bool) (*fftypes.Message, error) {
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	msgs, _, err := nm.database.GetMessages(ctx, fb.And(
+		fb.Eq("namespace", fftypes.SystemNamespace),
+		fb.Eq("topics", topic),
+		fb.Eq("tag", string(tag)),
+		fb.Eq("state", fftypes.MessageStateConfirmed),
+		fb.Lte("confirmed", asOf),
+	).Sort("confirmed").Descending())
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		if len(msg.Data) != 1 {
+			continue
+		}
+		data, err := nm.database.GetDataByID(ctx, msg.Data[0].ID, true)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil && match(data.Value) {
+			return msg, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetOrganizationsAsOf returns the organizations matching the filter, as they were defined at a point in
+// history, from the latest definition of each that was confirmed by then. The filter selects from the
+// current organizations, and those that were not yet defined are removed from the page.
+func (nm *networkMap) GetOrganizationsAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
+	fi, err := filter.Finalize()
+	if err != nil {
+		return nil, nil, err
+	}
+	if field := fi.FieldIn(organizationHistoricFields); field != "" {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgAsOfHistoricField, field)
+	}
+	orgs, fr, err := nm.database.GetOrganizations(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := make([]*fftypes.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		var def *fftypes.Organization
+		msg, err := nm.definitionAsOf(ctx, org.Topic(), fftypes.SystemTagDefineOrganization, asOf, func(value []byte) bool {
+			def = &fftypes.Organization{}
+			return json.Unmarshal(value, def) == nil && def.Identity == org.Identity
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if msg != nil {
+			def.ID = org.ID
+			def.Message = msg.Header.ID
+			results = append(results, def)
+		}
+	}
+	return results, fr, nil
+}
+
+// GetNodesAsOf returns the nodes matching the filter, as they were defined at a point in history, from
+// the latest definition of each that was confirmed by then. The filter selects from the current nodes,
+// and those that were not yet defined are removed from the page.
+func (nm *networkMap) GetNodesAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error) {
+	fi, err := filter.Finalize()
+	if err != nil {
+		return nil, nil, err
+	}
+	if field := fi.FieldIn(nodeHistoricFields); field != "" {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgAsOfHistoricField, field)
+	}
+	nodes, fr, err := nm.database.GetNodes(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := make([]*fftypes.Node, 0, len(nodes))
+	for _, node := range nodes {
+		var def *fftypes.Node
+		msg, err := nm.definitionAsOf(ctx, node.Topic(), fftypes.SystemTagDefineNode, asOf, func(value []byte) bool {
+			def = &fftypes.Node{}
+			return json.Unmarshal(value, def) == nil && def.Owner == node.Owner && def.Name == node.Name
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if msg != nil {
+			def.ID = node.ID
+			def.Message = msg.Header.ID
+			results = append(results, def)
+		}
+	}
+	return results, fr, nil
+}
@@ -0,0 +1,180 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func filterContains(s string) interface{} {
+	return mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return strings.Contains(fi.String(), s)
+	})
+}
+
+func TestGetOrganizationsAsOf(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+
+	org1 := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x1", Name: "org1", Description: "current"}
+	org2 := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x2", Name: "org2"}
+	mdi.On("GetOrganizations", nm.ctx, mock.Anything).Return([]*fftypes.Organization{org1, org2}, nil, nil)
+
+	oldDef := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{"identity":"0x1","name":"org1","description":"old"}`)}
+	otherDef := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{"identity":"0x9","name":"org9"}`)}
+	oldMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Data: fftypes.DataRefs{{ID: oldDef.ID}}}
+	otherMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Data: fftypes.DataRefs{{ID: otherDef.ID}}}
+	badMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mdi.On("GetMessages", nm.ctx, filterContains(org1.Topic())).Return([]*fftypes.Message{badMsg, otherMsg, oldMsg}, nil, nil)
+	mdi.On("GetMessages", nm.ctx, filterContains(org2.Topic())).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetDataByID", nm.ctx, oldDef.ID, true).Return(oldDef, nil)
+	mdi.On("GetDataByID", nm.ctx, otherDef.ID, true).Return(otherDef, nil)
+
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	res, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And(fb.Eq("parent", "")))
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, org1.ID, res[0].ID)
+	assert.Equal(t, oldMsg.Header.ID, res[0].Message)
+	assert.Equal(t, "old", res[0].Description)
+	mdi.AssertExpectations(t)
+}
+
+func TestGetOrganizationsAsOfHistoricFilter(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And(fb.Eq("description", "old")))
+	assert.Regexp(t, "FF10457.*description", err)
+}
+
+func TestGetOrganizationsAsOfBadFilter(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And(fb.Eq("wrong", "0x1")))
+	assert.Regexp(t, "FF10148", err)
+}
+
+func TestGetOrganizationsAsOfFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	nm.database.(*databasemocks.Plugin).On("GetOrganizations", nm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetOrganizationsAsOfMessagesFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizations", nm.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x1"}}, nil, nil)
+	mdi.On("GetMessages", nm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetOrganizationsAsOfDataFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizations", nm.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x1"}}, nil, nil)
+	mdi.On("GetMessages", nm.ctx, mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Data: fftypes.DataRefs{{ID: fftypes.NewUUID()}}},
+	}, nil, nil)
+	mdi.On("GetDataByID", nm.ctx, mock.Anything, true).Return(nil, fmt.Errorf("pop"))
+	fb := database.OrganizationQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetOrganizationsAsOf(nm.ctx, fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetNodesAsOf(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+
+	node1 := &fftypes.Node{ID: fftypes.NewUUID(), Owner: "0x1", Name: "node1", DX: fftypes.DXInfo{Peer: "peer1-new"}}
+	node2 := &fftypes.Node{ID: fftypes.NewUUID(), Owner: "0x1", Name: "node2"}
+	mdi.On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{node1, node2}, nil, nil)
+
+	node1Def := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{"owner":"0x1","name":"node1","dx":{"peer":"peer1-old"}}`)}
+	node3Def := &fftypes.Data{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{"owner":"0x1","name":"node3"}`)}
+	node1Msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Data: fftypes.DataRefs{{ID: node1Def.ID}}}
+	node3Msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Data: fftypes.DataRefs{{ID: node3Def.ID}}}
+	mdi.On("GetMessages", nm.ctx, filterContains("tag == 'ff_define_node'")).Return([]*fftypes.Message{node3Msg, node1Msg}, nil, nil)
+	mdi.On("GetDataByID", nm.ctx, node1Def.ID, true).Return(node1Def, nil)
+	mdi.On("GetDataByID", nm.ctx, node3Def.ID, true).Return(node3Def, nil)
+
+	fb := database.NodeQueryFactory.NewFilter(nm.ctx)
+	res, _, err := nm.GetNodesAsOf(nm.ctx, fftypes.Now(), fb.And(fb.Eq("owner", "0x1")))
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, node1.ID, res[0].ID)
+	assert.Equal(t, node1Msg.Header.ID, res[0].Message)
+	assert.Equal(t, "peer1-old", res[0].DX.Peer)
+	mdi.AssertExpectations(t)
+}
+
+func TestGetNodesAsOfHistoricFilter(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	fb := database.NodeQueryFactory.NewFilter(nm.ctx)
+	f := fb.And()
+	f.Sort("dx.peer")
+	_, _, err := nm.GetNodesAsOf(nm.ctx, fftypes.Now(), f)
+	assert.Regexp(t, "FF10457.*dx.peer", err)
+}
+
+func TestGetNodesAsOfBadFilter(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	fb := database.NodeQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetNodesAsOf(nm.ctx, fftypes.Now(), fb.And(fb.Eq("wrong", "0x1")))
+	assert.Regexp(t, "FF10148", err)
+}
+
+func TestGetNodesAsOfFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	nm.database.(*databasemocks.Plugin).On("GetNodes", nm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.NodeQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetNodesAsOf(nm.ctx, fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetNodesAsOfMessagesFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{{Owner: "0x1", Name: "node1"}}, nil, nil)
+	mdi.On("GetMessages", nm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.NodeQueryFactory.NewFilter(nm.ctx)
+	_, _, err := nm.GetNodesAsOf(nm.ctx, fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
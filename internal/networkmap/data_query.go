@@ -19,6 +19,7 @@ package networkmap
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -31,6 +32,13 @@ func (nm *networkMap) GetOrganizationByID(ctx context.Context, id string) (*ffty
 	return nm.database.GetOrganizationByID(ctx, u)
 }
 
+func (nm *networkMap) GetOrganizationByName(ctx context.Context, name string) (*fftypes.Organization, error) {
+	if err := fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
+		return nil, err
+	}
+	return nm.database.GetOrganizationByName(ctx, name)
+}
+
 func (nm *networkMap) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	return nm.database.GetOrganizations(ctx, filter)
 }
@@ -46,3 +54,22 @@ func (nm *networkMap) GetNodeByID(ctx context.Context, id string) (*fftypes.Node
 func (nm *networkMap) GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error) {
 	return nm.database.GetNodes(ctx, filter)
 }
+
+func (nm *networkMap) GetIdentityByID(ctx context.Context, ns, id string) (*fftypes.CustomIdentity, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := nm.database.GetCustomIdentityByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil || identity.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return identity, nil
+}
+
+func (nm *networkMap) GetIdentities(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.CustomIdentity, *database.FilterResult, error) {
+	return nm.database.GetCustomIdentities(ctx, filter.Condition(filter.Builder().Eq("namespace", ns)))
+}
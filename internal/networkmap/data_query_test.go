@@ -17,6 +17,7 @@
 package networkmap
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/hyperledger/firefly/mocks/databasemocks"
@@ -43,6 +44,22 @@ func TestGetOrganizationByIDBadUUID(t *testing.T) {
 	assert.Regexp(t, "FF10142", err)
 }
 
+func TestGetOrganizationByNameOk(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	nm.database.(*databasemocks.Plugin).On("GetOrganizationByName", nm.ctx, "org1").Return(&fftypes.Organization{Name: "org1"}, nil)
+	res, err := nm.GetOrganizationByName(nm.ctx, "org1")
+	assert.NoError(t, err)
+	assert.Equal(t, "org1", res.Name)
+}
+
+func TestGetOrganizationByNameBadName(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.GetOrganizationByName(nm.ctx, "!bad")
+	assert.Regexp(t, "FF10131", err)
+}
+
 func TestGetNodeByIDOk(t *testing.T) {
 	nm, cancel := newTestNetworkmap(t)
 	defer cancel()
@@ -77,3 +94,47 @@ func TestGetNodes(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, res)
 }
+
+func TestGetIdentityByIDOk(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	id := fftypes.NewUUID()
+	nm.database.(*databasemocks.Plugin).On("GetCustomIdentityByID", nm.ctx, id).Return(&fftypes.CustomIdentity{ID: id, Namespace: "ns1"}, nil)
+	res, err := nm.GetIdentityByID(nm.ctx, "ns1", id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, *id, *res.ID)
+}
+
+func TestGetIdentityByIDWrongNS(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	id := fftypes.NewUUID()
+	nm.database.(*databasemocks.Plugin).On("GetCustomIdentityByID", nm.ctx, id).Return(&fftypes.CustomIdentity{ID: id, Namespace: "ns2"}, nil)
+	_, err := nm.GetIdentityByID(nm.ctx, "ns1", id.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetIdentityByIDFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	id := fftypes.NewUUID()
+	nm.database.(*databasemocks.Plugin).On("GetCustomIdentityByID", nm.ctx, id).Return(nil, fmt.Errorf("pop"))
+	_, err := nm.GetIdentityByID(nm.ctx, "ns1", id.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityByIDBadUUID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.GetIdentityByID(nm.ctx, "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetIdentities(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	nm.database.(*databasemocks.Plugin).On("GetCustomIdentities", nm.ctx, mock.Anything).Return([]*fftypes.CustomIdentity{}, nil, nil)
+	res, _, err := nm.GetIdentities(nm.ctx, "ns1", database.CustomIdentityQueryFactory.NewFilter(nm.ctx).And())
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
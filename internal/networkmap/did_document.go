@@ -0,0 +1,123 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	didContextV1           = "https://www.w3.org/ns/did/v1"
+	didContextSecp256k1    = "https://w3id.org/security/suites/secp256k1recovery-2020/v2"
+	didMethodEthAddress    = "EcdsaSecp256k1RecoveryMethod2020"
+	didMethodMSPIdentity   = "HyperledgerFabricMSPIdentity"
+	didMethodDXPeerX509    = "DataExchangePeerX509Certificate"
+	didVerifierFragmentKey = "#key"
+	didVerifierFragmentDX  = "#dx-"
+)
+
+var ethAddressRegex = regexp.MustCompile(`^0x[0-9a-f]{40}$`)
+
+// GetIdentityDIDDocument assembles a DID document for a custom identity, from the signing key registered against
+// the identity and the data exchange peers of the nodes owned by the organization at the root of its hierarchy
+func (nm *networkMap) GetIdentityDIDDocument(ctx context.Context, ns, id string) (*fftypes.DIDDocument, error) {
+	identity, err := nm.GetIdentityByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if identity.Verified == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgIdentityNotVerified, identity.GetDID(), identity.Parent)
+	}
+	org, err := nm.getIdentityOrganization(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	did := identity.GetDID()
+	keyMethod := nm.keyVerificationMethod(did, identity.Key)
+	doc := &fftypes.DIDDocument{
+		Context:            []string{didContextV1, didContextSecp256k1},
+		ID:                 did,
+		Controller:         identity.Parent,
+		VerificationMethod: []*fftypes.DIDVerificationMethod{keyMethod},
+		Authentication:     []string{keyMethod.ID},
+	}
+
+	// The data exchange peers belong to the nodes of the org, so are controlled by the org rather than the identity
+	fb := database.NodeQueryFactory.NewFilter(ctx)
+	nodes, _, err := nm.database.GetNodes(ctx, fb.And(fb.Eq("owner", org.Identity)))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.DX.Peer != "" {
+			doc.VerificationMethod = append(doc.VerificationMethod, &fftypes.DIDVerificationMethod{
+				ID:                 did + didVerifierFragmentDX + node.DX.Peer,
+				Type:               didMethodDXPeerX509,
+				Controller:         org.GetDID(),
+				DataExchangePeerID: node.DX.Peer,
+				PublicKeyPem:       node.DX.Endpoint.GetString("cert"),
+			})
+		}
+	}
+	return doc, nil
+}
+
+func (nm *networkMap) keyVerificationMethod(did, key string) *fftypes.DIDVerificationMethod {
+	method := &fftypes.DIDVerificationMethod{
+		ID:         did + didVerifierFragmentKey,
+		Controller: did,
+	}
+	if ethAddressRegex.MatchString(key) {
+		method.Type = didMethodEthAddress
+		method.BlockchainAccountID = key
+	} else {
+		method.Type = didMethodMSPIdentity
+		method.MSPIdentityString = key
+	}
+	return method
+}
+
+// getIdentityOrganization walks up the parents of a custom identity, to the organization at the root of the hierarchy
+func (nm *networkMap) getIdentityOrganization(ctx context.Context, identity *fftypes.CustomIdentity) (*fftypes.Organization, error) {
+	parent := identity.Parent
+	for strings.HasPrefix(parent, fftypes.FireflyIdentityDIDPrefix) {
+		parentIdentity, err := nm.identity.GetCustomIdentityByDID(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		parent = parentIdentity.Parent
+	}
+	orgID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(parent, fftypes.FireflyOrgDIDPrefix))
+	if err != nil {
+		return nil, err
+	}
+	org, err := nm.database.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgParentIdentityNotFound, parent, "identity", identity.GetDID())
+	}
+	return org, nil
+}
@@ -0,0 +1,198 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetIdentityDIDDocumentEthereumOk(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}
+	parent := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Parent: org.GetDID()}
+	identity := &fftypes.CustomIdentity{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Parent:    parent.GetDID(),
+		Key:       "0x1234567890abcdef1234567890abcdef12345678",
+		Verified:  fftypes.Now(),
+	}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{
+		{DX: fftypes.DXInfo{Peer: "peer1", Endpoint: fftypes.JSONObject{"cert": "cert data..."}}},
+		{},
+	}, nil, nil)
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("GetCustomIdentityByDID", nm.ctx, parent.GetDID()).Return(parent, nil)
+
+	doc, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.NoError(t, err)
+	did := identity.GetDID()
+	assert.Equal(t, did, doc.ID)
+	assert.Equal(t, parent.GetDID(), doc.Controller)
+	assert.Equal(t, []string{did + "#key"}, doc.Authentication)
+	assert.Equal(t, []*fftypes.DIDVerificationMethod{
+		{
+			ID:                  did + "#key",
+			Type:                "EcdsaSecp256k1RecoveryMethod2020",
+			Controller:          did,
+			BlockchainAccountID: identity.Key,
+		},
+		{
+			ID:                 did + "#dx-peer1",
+			Type:               "DataExchangePeerX509Certificate",
+			Controller:         org.GetDID(),
+			DataExchangePeerID: "peer1",
+			PublicKeyPem:       "cert data...",
+		},
+	}, doc.VerificationMethod)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestGetIdentityDIDDocumentFabricOk(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "org1MSP::x509::CN=org1"}
+	identity := &fftypes.CustomIdentity{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Parent:    org.GetDID(),
+		Key:       "org1MSP::x509::CN=user1",
+		Verified:  fftypes.Now(),
+	}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+
+	doc, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, doc.VerificationMethod, 1)
+	assert.Equal(t, "HyperledgerFabricMSPIdentity", doc.VerificationMethod[0].Type)
+	assert.Equal(t, identity.Key, doc.VerificationMethod[0].MSPIdentityString)
+}
+
+func TestGetIdentityDIDDocumentGetNodesFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Parent: org.GetDID(), Verified: fftypes.Now()}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", nm.ctx, org.ID).Return(org, nil)
+	mdi.On("GetNodes", nm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityDIDDocumentOrgNotFound(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	orgID := fftypes.NewUUID()
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Parent: fftypes.FireflyOrgDIDPrefix + orgID.String(), Verified: fftypes.Now()}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", nm.ctx, orgID).Return(nil, nil)
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.Regexp(t, "FF10214", err)
+}
+
+func TestGetIdentityDIDDocumentOrgLookupFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	orgID := fftypes.NewUUID()
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Parent: fftypes.FireflyOrgDIDPrefix + orgID.String(), Verified: fftypes.Now()}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mdi.On("GetOrganizationByID", nm.ctx, orgID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityDIDDocumentBadOrgDID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Parent: "did:firefly:org/bad", Verified: fftypes.Now()}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetIdentityDIDDocumentParentFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Parent: "did:firefly:identity/parent", Verified: fftypes.Now()}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("GetCustomIdentityByDID", nm.ctx, identity.Parent).Return(nil, fmt.Errorf("pop"))
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityDIDDocumentNotVerified(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	identity := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1"}
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByID", nm.ctx, identity.ID).Return(identity, nil)
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", identity.ID.String())
+	assert.Regexp(t, "FF10415", err)
+}
+
+func TestGetIdentityDIDDocumentNotFound(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	_, err := nm.GetIdentityDIDDocument(nm.ctx, "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
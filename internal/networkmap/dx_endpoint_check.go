@@ -0,0 +1,91 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+// Start begins periodically checking the local data exchange endpoint against the network map, as other
+// members can only deliver private messages to the endpoint registered for the node. A redeployment of the
+// data exchange with a new endpoint or certificate would otherwise cause delivery to fail silently.
+func (nm *networkMap) Start() error {
+	if interval := config.GetDuration(config.NodeDXEndpointCheckInterval); interval > 0 {
+		go nm.dxEndpointCheckLoop(interval)
+	}
+	return nil
+}
+
+func (nm *networkMap) dxEndpointCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := nm.checkDXEndpoint(nm.ctx); err != nil {
+				log.L(nm.ctx).Errorf("Data exchange endpoint check failed: %s", err)
+			}
+		case <-nm.ctx.Done():
+			log.L(nm.ctx).Debugf("Data exchange endpoint check loop exiting")
+			return
+		}
+	}
+}
+
+func (nm *networkMap) checkDXEndpoint(ctx context.Context) error {
+	owner, err := nm.getLocalOrgSigningKey(ctx)
+	if err != nil {
+		return err
+	}
+	nodeName := localNodeName()
+	node, err := nm.database.GetNode(ctx, owner, nodeName)
+	if err != nil || node == nil {
+		// Nothing to compare against until the node has been registered
+		return err
+	}
+
+	peer, endpoint, err := nm.exchange.GetEndpointInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if node.DX.Peer == peer && node.DX.Endpoint.String() == endpoint.String() {
+		nm.dxEndpointBroadcast = ""
+		return nil
+	}
+
+	if !config.GetBool(config.NodeDXEndpointCheckAutoUpdate) {
+		log.L(ctx).Warnf("Data exchange endpoint for node '%s' differs from the network map. Register the node again to update it", nodeName)
+		return nil
+	}
+	// Only broadcast once for each change, as the update is not visible until the broadcast is confirmed
+	current := fmt.Sprintf("%s/%s", peer, endpoint)
+	if nm.dxEndpointBroadcast == current {
+		log.L(ctx).Debugf("Updated data exchange endpoint for node '%s' already broadcast", nodeName)
+		return nil
+	}
+	log.L(ctx).Infof("Data exchange endpoint for node '%s' differs from the network map. Broadcasting updated node identity", nodeName)
+	if _, _, err = nm.RegisterNode(ctx, false); err != nil {
+		return err
+	}
+	nm.dxEndpointBroadcast = current
+	return nil
+}
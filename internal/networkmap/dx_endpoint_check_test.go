@@ -0,0 +1,186 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDXEndpointCheck(t *testing.T) (*networkMap, func()) {
+	nm, cancel := newTestNetworkmap(t)
+	config.Set(config.OrgKey, "0x23456")
+	config.Set(config.OrgName, "org1")
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
+	return nm, cancel
+}
+
+func TestStartDisabled(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	config.Set(config.NodeDXEndpointCheckInterval, "0")
+
+	err := nm.Start()
+	assert.NoError(t, err)
+}
+
+func TestDXEndpointCheckLoop(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	config.Set(config.NodeDXEndpointCheckInterval, "1ms")
+
+	checked := make(chan struct{})
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(nil, fmt.Errorf("pop")).Once().Run(func(args mock.Arguments) {
+		close(checked)
+	})
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(nil, nil).Maybe()
+
+	err := nm.Start()
+	assert.NoError(t, err)
+	<-checked
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestCheckDXEndpointNoOrgKey(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "").Return("", nil)
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.Regexp(t, "FF10216", err)
+}
+
+func TestCheckDXEndpointNotRegistered(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(nil, nil)
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.NoError(t, err)
+}
+
+func TestCheckDXEndpointInfoFail(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(&fftypes.Node{}, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("", nil, fmt.Errorf("pop"))
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckDXEndpointUnchanged(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+	nm.dxEndpointBroadcast = "peer1/{}"
+
+	node := &fftypes.Node{}
+	node.DX.Peer = "peer1"
+	node.DX.Endpoint = fftypes.JSONObject{"endpoint": "details", "cert": "cert1"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(node, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"cert": "cert1", "endpoint": "details"}, nil)
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, nm.dxEndpointBroadcast)
+}
+
+func TestCheckDXEndpointChangedManual(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+	config.Set(config.NodeDXEndpointCheckAutoUpdate, false)
+
+	node := &fftypes.Node{}
+	node.DX.Peer = "peer1"
+	node.DX.Endpoint = fftypes.JSONObject{"cert": "cert1"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(node, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"cert": "cert2"}, nil)
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.NoError(t, err)
+	nm.broadcast.(*broadcastmocks.Manager).AssertNotCalled(t, "BroadcastDefinitionAsNode", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckDXEndpointChangedAuto(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+
+	node := &fftypes.Node{}
+	node.DX.Peer = "peer1"
+	node.DX.Endpoint = fftypes.JSONObject{"cert": "cert1"}
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(node, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"cert": "cert2"}, nil)
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinitionAsNode", nm.ctx, fftypes.SystemNamespace, mock.MatchedBy(func(n *fftypes.Node) bool {
+		return n.Name == "org1.node" && n.DX.Endpoint.GetString("cert") == "cert2"
+	}), fftypes.SystemTagDefineNode, false).Return(&fftypes.Message{}, nil).Once()
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, `peer1/{"cert":"cert2"}`, nm.dxEndpointBroadcast)
+
+	// Not broadcast again until the change has been confirmed
+	err = nm.checkDXEndpoint(nm.ctx)
+	assert.NoError(t, err)
+	mbm.AssertExpectations(t)
+}
+
+func TestCheckDXEndpointChangedAutoBroadcastFail(t *testing.T) {
+	nm, cancel := newTestDXEndpointCheck(t)
+	defer cancel()
+
+	node := &fftypes.Node{}
+	node.DX.Peer = "peer1"
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNode", nm.ctx, "0x23456", "org1.node").Return(node, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("peer2", fftypes.JSONObject{}, nil)
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinitionAsNode", nm.ctx, fftypes.SystemNamespace, mock.Anything, fftypes.SystemTagDefineNode, false).Return(nil, fmt.Errorf("pop"))
+
+	err := nm.checkDXEndpoint(nm.ctx)
+	assert.EqualError(t, err, "pop")
+	assert.Empty(t, nm.dxEndpointBroadcast)
+}
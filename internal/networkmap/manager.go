@@ -28,14 +28,23 @@ import (
 )
 
 type Manager interface {
+	Start() error
+
 	RegisterOrganization(ctx context.Context, org *fftypes.Organization, waitConfirm bool) (msg *fftypes.Message, err error)
 	RegisterNode(ctx context.Context, waitConfirm bool) (node *fftypes.Node, msg *fftypes.Message, err error)
 	RegisterNodeOrganization(ctx context.Context, waitConfirm bool) (org *fftypes.Organization, msg *fftypes.Message, err error)
+	RegisterIdentity(ctx context.Context, ns string, identity *fftypes.CustomIdentity, waitConfirm bool) (*fftypes.CustomIdentity, error)
 
 	GetOrganizationByID(ctx context.Context, id string) (*fftypes.Organization, error)
+	GetOrganizationByName(ctx context.Context, name string) (*fftypes.Organization, error)
 	GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error)
+	GetOrganizationsAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error)
 	GetNodeByID(ctx context.Context, id string) (*fftypes.Node, error)
 	GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error)
+	GetNodesAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error)
+	GetIdentityByID(ctx context.Context, ns, id string) (*fftypes.CustomIdentity, error)
+	GetIdentities(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.CustomIdentity, *database.FilterResult, error)
+	GetIdentityDIDDocument(ctx context.Context, ns, id string) (*fftypes.DIDDocument, error)
 }
 
 type networkMap struct {
@@ -44,6 +53,9 @@ type networkMap struct {
 	broadcast broadcast.Manager
 	exchange  dataexchange.Plugin
 	identity  identity.Manager
+
+	// dxEndpointBroadcast is the last data exchange endpoint we broadcast an updated node identity for
+	dxEndpointBroadcast string
 }
 
 func NewNetworkMap(ctx context.Context, di database.Plugin, bm broadcast.Manager, dx dataexchange.Plugin, im identity.Manager) (Manager, error) {
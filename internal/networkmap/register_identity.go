@@ -0,0 +1,87 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// RegisterIdentity registers a custom identity beneath a parent this node can sign for (the local org by default).
+// The claim is broadcast signed by the key of the new identity, and is confirmed before the verification is
+// broadcast signed by the key of the parent - so every member of the network processes the claim first.
+func (nm *networkMap) RegisterIdentity(ctx context.Context, ns string, identity *fftypes.CustomIdentity, waitConfirm bool) (*fftypes.CustomIdentity, error) {
+
+	identity.ID = fftypes.NewUUID()
+	identity.Namespace = ns
+	identity.Claim = nil
+	identity.Verification = nil
+	identity.Created = fftypes.Now()
+	identity.Verified = nil
+
+	// Resolve the parent to its DID, and the key that will sign the verification
+	parent := &fftypes.Identity{Author: identity.Parent}
+	if err := nm.identity.ResolveInputIdentity(ctx, parent); err != nil {
+		return nil, err
+	}
+	identity.Parent = parent.Author
+
+	key, err := nm.identity.ResolveSigningKey(ctx, ns, identity.Key)
+	if err != nil {
+		return nil, err
+	}
+	identity.Key = key
+	if err = identity.Validate(ctx); err != nil {
+		return nil, err
+	}
+
+	// The key must not already resolve to another identity, and the name must be unique in the namespace
+	existing, err := nm.identity.ResolveSigningKeyIdentity(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, i18n.NewError(ctx, i18n.MsgIdentityKeyInUse, key, existing)
+	}
+	nameOwner, err := nm.database.GetCustomIdentityByName(ctx, ns, identity.Name)
+	if err != nil {
+		return nil, err
+	}
+	if nameOwner != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgIdentityNameInUse, identity.Name, ns)
+	}
+
+	claim, err := nm.broadcast.BroadcastIdentityClaim(ctx, ns, identity, &fftypes.Identity{Key: key}, fftypes.SystemTagDefineIdentityClaim, true)
+	if err != nil {
+		return nil, err
+	}
+	identity.Claim = claim.Header.ID
+
+	verification, err := nm.broadcast.BroadcastDefinition(ctx, ns, &fftypes.IdentityVerification{
+		Identity:  identity.ID,
+		Namespace: ns,
+		Parent:    identity.Parent,
+		Key:       identity.Key,
+		Claim:     identity.Claim,
+	}, parent, fftypes.SystemTagDefineIdentityVerification, waitConfirm)
+	if verification != nil {
+		identity.Verification = verification.Header.ID
+	}
+	return identity, err
+}
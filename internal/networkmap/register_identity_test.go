@@ -0,0 +1,210 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestCustomIdentity() *fftypes.CustomIdentity {
+	return &fftypes.CustomIdentity{
+		Name:        "ident1",
+		Parent:      "org1",
+		Key:         "0x12345",
+		Description: "my identity",
+	}
+}
+
+func mockResolveParent(nm *networkMap) *identitymanagermocks.Manager {
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", nm.ctx, mock.MatchedBy(func(i *fftypes.Identity) bool { return i.Author == "org1" })).Run(func(args mock.Arguments) {
+		i := args[1].(*fftypes.Identity)
+		i.Author = "did:firefly:org/org1"
+		i.Key = "0x23456"
+	}).Return(nil)
+	return mim
+}
+
+func TestRegisterIdentityOk(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", nil)
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByName", nm.ctx, "ns1", "ident1").Return(nil, nil)
+
+	claimMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	verifyMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastIdentityClaim", nm.ctx, "ns1", mock.MatchedBy(func(ci *fftypes.CustomIdentity) bool {
+		return ci.Parent == "did:firefly:org/org1" && ci.Namespace == "ns1"
+	}), mock.MatchedBy(func(i *fftypes.Identity) bool { return i.Key == "0x12345" }), fftypes.SystemTagDefineIdentityClaim, true).Return(claimMsg, nil)
+	mbm.On("BroadcastDefinition", nm.ctx, "ns1", mock.MatchedBy(func(iv *fftypes.IdentityVerification) bool {
+		return *iv.Claim == *claimMsg.Header.ID && iv.Key == "0x12345" && iv.Parent == "did:firefly:org/org1"
+	}), mock.MatchedBy(func(i *fftypes.Identity) bool { return i.Key == "0x23456" }), fftypes.SystemTagDefineIdentityVerification, false).Return(verifyMsg, nil)
+
+	identity, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.NoError(t, err)
+	assert.Equal(t, *claimMsg.Header.ID, *identity.Claim)
+	assert.Equal(t, *verifyMsg.Header.ID, *identity.Verification)
+	assert.NotNil(t, identity.ID)
+	assert.Nil(t, identity.Verified)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mbm.AssertExpectations(t)
+}
+
+func TestRegisterIdentityVerificationFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", nil)
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByName", nm.ctx, "ns1", "ident1").Return(nil, nil)
+
+	claimMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastIdentityClaim", nm.ctx, "ns1", mock.Anything, mock.Anything, fftypes.SystemTagDefineIdentityClaim, true).Return(claimMsg, nil)
+	mbm.On("BroadcastDefinition", nm.ctx, "ns1", mock.Anything, mock.Anything, fftypes.SystemTagDefineIdentityVerification, true).Return(nil, fmt.Errorf("pop"))
+
+	identity, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), true)
+	assert.EqualError(t, err, "pop")
+	assert.Equal(t, *claimMsg.Header.ID, *identity.Claim)
+	assert.Nil(t, identity.Verification)
+}
+
+func TestRegisterIdentityClaimFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", nil)
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByName", nm.ctx, "ns1", "ident1").Return(nil, nil)
+
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastIdentityClaim", nm.ctx, "ns1", mock.Anything, mock.Anything, fftypes.SystemTagDefineIdentityClaim, true).Return(nil, fmt.Errorf("pop"))
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRegisterIdentityNameInUse(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", nil)
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByName", nm.ctx, "ns1", "ident1").Return(&fftypes.CustomIdentity{}, nil)
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.Regexp(t, "FF10418", err)
+}
+
+func TestRegisterIdentityNameLookupFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", nil)
+
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetCustomIdentityByName", nm.ctx, "ns1", "ident1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRegisterIdentityKeyInUse(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("did:firefly:org/org2", nil)
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.Regexp(t, "FF10416", err)
+}
+
+func TestRegisterIdentityKeyLookupFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKeyIdentity", nm.ctx, "0x12345").Return("", fmt.Errorf("pop"))
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRegisterIdentityBadName(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("0x12345", nil)
+
+	identity := newTestCustomIdentity()
+	identity.Name = "!bad"
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", identity, false)
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestRegisterIdentityBadKey(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := mockResolveParent(nm)
+	mim.On("ResolveSigningKey", nm.ctx, "ns1", "0x12345").Return("", fmt.Errorf("pop"))
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRegisterIdentityBadParent(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mim := nm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", nm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := nm.RegisterIdentity(nm.ctx, "ns1", newTestCustomIdentity(), false)
+	assert.EqualError(t, err, "pop")
+}
@@ -36,15 +36,9 @@ func (nm *networkMap) RegisterNode(ctx context.Context, waitConfirm bool) (node
 		ID:          fftypes.NewUUID(),
 		Created:     fftypes.Now(),
 		Owner:       localOrgSigningKey, // TODO: Switch hierarchy to DID based, not signing key. Introducing an intermediate identity object
-		Name:        config.GetString(config.NodeName),
+		Name:        localNodeName(),
 		Description: config.GetString(config.NodeDescription),
 	}
-	if node.Name == "" {
-		orgName := config.GetString(config.OrgName)
-		if orgName != "" {
-			node.Name = fmt.Sprintf("%s.node", orgName)
-		}
-	}
 	if node.Owner == "" || node.Name == "" {
 		return nil, nil, i18n.NewError(ctx, i18n.MsgNodeAndOrgIDMustBeSet)
 	}
@@ -69,3 +63,15 @@ func (nm *networkMap) RegisterNode(ctx context.Context, waitConfirm bool) (node
 	}
 	return node, msg, err
 }
+
+// localNodeName is the configured name of the local node, defaulting to one derived from the org name
+func localNodeName() string {
+	nodeName := config.GetString(config.NodeName)
+	if nodeName == "" {
+		orgName := config.GetString(config.OrgName)
+		if orgName != "" {
+			nodeName = fmt.Sprintf("%s.node", orgName)
+		}
+	}
+	return nodeName
+}
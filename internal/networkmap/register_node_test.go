@@ -46,7 +46,7 @@ func TestRegisterNodeOk(t *testing.T) {
 	}, nil)
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
 
 	mdx := nm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"endpoint": "details"}, nil)
@@ -78,7 +78,7 @@ func TestRegisterNodeBadParentID(t *testing.T) {
 	}, nil)
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("", fmt.Errorf("pop"))
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("", fmt.Errorf("pop"))
 
 	mdx := nm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"endpoint": "details"}, nil)
@@ -103,7 +103,7 @@ func TestRegisterNodeMissingNodeName(t *testing.T) {
 	}, nil)
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
 
 	_, _, err := nm.RegisterNode(nm.ctx, false)
 	assert.Regexp(t, "FF10216", err)
@@ -124,7 +124,7 @@ func TestRegisterNodeBadNodeID(t *testing.T) {
 	}, nil)
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "").Return("", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "").Return("", nil)
 
 	_, _, err := nm.RegisterNode(nm.ctx, false)
 	assert.Regexp(t, "FF10216", err)
@@ -144,7 +144,7 @@ func TestRegisterNodeParentNotFound(t *testing.T) {
 	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(nil, nil)
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
 
 	mdx := nm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"endpoint": "details"}, nil)
@@ -164,7 +164,7 @@ func TestRegisterNodeParentBadNode(t *testing.T) {
 	config.Set(config.NodeName, "node1")
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
 
 	mdx := nm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("GetEndpointInfo", nm.ctx).Return("peer1", fftypes.JSONObject{"endpoint": "details"}, nil)
@@ -187,7 +187,7 @@ func TestRegisterNodeParentDXEndpointFail(t *testing.T) {
 	mdx.On("GetEndpointInfo", nm.ctx).Return("", nil, fmt.Errorf("pop"))
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x23456").Return("0x23456", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x23456").Return("0x23456", nil)
 
 	_, _, err := nm.RegisterNode(nm.ctx, false)
 	assert.Regexp(t, "pop", err)
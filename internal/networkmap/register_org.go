@@ -47,7 +47,7 @@ func (nm *networkMap) getLocalOrgSigningKey(ctx context.Context) (localOrgSignin
 		log.L(ctx).Warnf("The %s config key has been deprecated. Use %s instead.", config.OrgIdentityDeprecated, config.OrgKey)
 		localOrgSigningKey = config.GetString(config.OrgIdentityDeprecated)
 	}
-	localOrgSigningKey, err = nm.identity.ResolveSigningKey(ctx, localOrgSigningKey)
+	localOrgSigningKey, err = nm.identity.ResolveSigningKey(ctx, fftypes.SystemNamespace, localOrgSigningKey)
 	if err != nil {
 		return "", err
 	}
@@ -70,7 +70,7 @@ func TestRegisterNodeOrganizationRootOk(t *testing.T) {
 	config.Set(config.OrgDescription, "my organization")
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x12345").Return("0x12345", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x12345").Return("0x12345", nil)
 	mim.On("ResolveInputIdentity", nm.ctx, mock.MatchedBy(func(i *fftypes.Identity) bool { return i.Key == "0x12345" })).Return(nil)
 
 	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
@@ -90,7 +90,7 @@ func TestRegisterNodeOrganizationMissingOrgKey(t *testing.T) {
 	defer cancel()
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "").Return("", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "").Return("", nil)
 
 	_, _, err := nm.RegisterNodeOrganization(nm.ctx, true)
 	assert.Regexp(t, "FF10216", err)
@@ -105,7 +105,7 @@ func TestRegisterNodeOrganizationMissingName(t *testing.T) {
 	config.Set(config.OrgKey, "0x2345")
 
 	mim := nm.identity.(*identitymanagermocks.Manager)
-	mim.On("ResolveSigningKey", nm.ctx, "0x2345").Return("0x2345", nil)
+	mim.On("ResolveSigningKey", nm.ctx, fftypes.SystemNamespace, "0x2345").Return("0x2345", nil)
 
 	_, _, err := nm.RegisterNodeOrganization(nm.ctx, true)
 	assert.Regexp(t, "FF10216", err)
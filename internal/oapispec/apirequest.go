@@ -36,4 +36,6 @@ type APIRequest struct {
 	Input         interface{}
 	Part          *fftypes.Multipart
 	SuccessStatus int
+	// ResponseHeaders can be set by a route, to add headers to the response
+	ResponseHeaders http.Header
 }
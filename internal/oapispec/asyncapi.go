@@ -0,0 +1,210 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oapispec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	asyncAPIVersion      = "2.2.0"
+	asyncAPISchemaFormat = "application/vnd.oai.openapi+json;version=3.0.0"
+)
+
+// AsyncAPI is the subset of an AsyncAPI document used to describe the event interfaces of FireFly
+type AsyncAPI struct {
+	AsyncAPI   string                      `json:"asyncapi"`
+	Info       *openapi3.Info              `json:"info"`
+	Servers    map[string]*AsyncAPIServer  `json:"servers,omitempty"`
+	Channels   map[string]*AsyncAPIChannel `json:"channels"`
+	Components *AsyncAPIComponents         `json:"components,omitempty"`
+}
+
+type AsyncAPIServer struct {
+	URL      string `json:"url"`
+	Protocol string `json:"protocol"`
+}
+
+type AsyncAPIChannel struct {
+	Description string                        `json:"description,omitempty"`
+	Servers     []string                      `json:"servers,omitempty"`
+	Parameters  map[string]*AsyncAPIParameter `json:"parameters,omitempty"`
+	Subscribe   *AsyncAPIOperation            `json:"subscribe,omitempty"`
+	Publish     *AsyncAPIOperation            `json:"publish,omitempty"`
+	Bindings    map[string]interface{}        `json:"bindings,omitempty"`
+}
+
+type AsyncAPIParameter struct {
+	Description string              `json:"description,omitempty"`
+	Schema      *openapi3.SchemaRef `json:"schema,omitempty"`
+}
+
+type AsyncAPIOperation struct {
+	OperationID string                 `json:"operationId"`
+	Message     *AsyncAPIMessageRef    `json:"message"`
+	Bindings    map[string]interface{} `json:"bindings,omitempty"`
+}
+
+type AsyncAPIMessageRef struct {
+	Ref   string                `json:"$ref,omitempty"`
+	OneOf []*AsyncAPIMessageRef `json:"oneOf,omitempty"`
+}
+
+type AsyncAPIMessage struct {
+	Name         string              `json:"name"`
+	Summary      string              `json:"summary,omitempty"`
+	ContentType  string              `json:"contentType"`
+	SchemaFormat string              `json:"schemaFormat"`
+	Payload      *openapi3.SchemaRef `json:"payload"`
+}
+
+type AsyncAPIComponents struct {
+	Messages map[string]*AsyncAPIMessage `json:"messages"`
+	Schemas  openapi3.Schemas            `json:"schemas,omitempty"`
+}
+
+func messageRefs(names ...string) *AsyncAPIMessageRef {
+	refs := make([]*AsyncAPIMessageRef, len(names))
+	for i, name := range names {
+		refs[i] = &AsyncAPIMessageRef{Ref: "#/components/messages/" + name}
+	}
+	return &AsyncAPIMessageRef{OneOf: refs}
+}
+
+func valueSchema(value interface{}) *openapi3.SchemaRef {
+	schemaRef, _ := openapi3gen.NewSchemaRefForValue(value, openapi3.Schemas{}, openapi3gen.SchemaCustomizer(ffTagHandler))
+	return schemaRef
+}
+
+func stringSchema() *openapi3.SchemaRef {
+	return &openapi3.SchemaRef{Value: openapi3.NewStringSchema()}
+}
+
+func boolSchema() *openapi3.SchemaRef {
+	return &openapi3.SchemaRef{Value: openapi3.NewBoolSchema()}
+}
+
+// AsyncAPIGen generates an AsyncAPI document for the websocket and webhook event interfaces, with the
+// supplied JSON schemas for the transport specific subscription options of each event plugin
+func AsyncAPIGen(ctx context.Context, url string, transportOptions map[string]string) *AsyncAPI {
+	wsURL := strings.Replace(strings.Replace(url, "https://", "wss://", 1), "http://", "ws://", 1)
+
+	schemas := openapi3.Schemas{
+		"subscriptionFilter":      valueSchema(&fftypes.SubscriptionFilter{}),
+		"subscriptionCoreOptions": valueSchema(&fftypes.SubscriptionCoreOptions{}),
+	}
+	for name, schemaDef := range transportOptions {
+		var schemaRef *openapi3.SchemaRef
+		if err := json.Unmarshal([]byte(schemaDef), &schemaRef); err != nil {
+			panic(fmt.Sprintf("invalid options schema for %s: %s", name, err))
+		}
+		schemas[name+"Options"] = schemaRef
+	}
+
+	newMessage := func(name string, summary i18n.MessageKey, payload *openapi3.SchemaRef) *AsyncAPIMessage {
+		return &AsyncAPIMessage{
+			Name:         name,
+			Summary:      i18n.Expand(ctx, summary),
+			ContentType:  "application/json",
+			SchemaFormat: asyncAPISchemaFormat,
+			Payload:      payload,
+		}
+	}
+
+	return &AsyncAPI{
+		AsyncAPI: asyncAPIVersion,
+		Info: &openapi3.Info{
+			Title:       "FireFly Events",
+			Version:     "1.0",
+			Description: "Copyright © 2021 Kaleido, Inc.",
+		},
+		Servers: map[string]*AsyncAPIServer{
+			"websockets": {URL: wsURL, Protocol: "ws"},
+		},
+		Channels: map[string]*AsyncAPIChannel{
+			"/ws": {
+				Description: i18n.Expand(ctx, i18n.MsgAsyncAPIWebSocketsChannel),
+				Servers:     []string{"websockets"},
+				Publish: &AsyncAPIOperation{
+					OperationID: "wsClientAction",
+					Message:     messageRefs("start", "ack"),
+				},
+				Subscribe: &AsyncAPIOperation{
+					OperationID: "wsEventDelivery",
+					Message:     messageRefs("eventDelivery", "changeNotification", "protocolError"),
+				},
+				Bindings: map[string]interface{}{
+					"ws": map[string]interface{}{
+						"query": &openapi3.Schema{
+							Type: "object",
+							Properties: openapi3.Schemas{
+								"namespace":     stringSchema(),
+								"name":          stringSchema(),
+								"ephemeral":     boolSchema(),
+								"autoack":       boolSchema(),
+								"filter.events": stringSchema(),
+								"filter.topics": stringSchema(),
+								"filter.group":  stringSchema(),
+								"filter.tag":    stringSchema(),
+								"changeevents":  stringSchema(),
+								"encoding":      stringSchema(),
+							},
+						},
+					},
+				},
+			},
+			"{url}": {
+				Description: i18n.Expand(ctx, i18n.MsgAsyncAPIWebhooksChannel),
+				Parameters: map[string]*AsyncAPIParameter{
+					"url": {
+						Description: i18n.Expand(ctx, i18n.MsgAsyncAPIWebhooksURL),
+						Schema:      stringSchema(),
+					},
+				},
+				Subscribe: &AsyncAPIOperation{
+					OperationID: "webhookEventDelivery",
+					Message:     messageRefs("eventDelivery", "webhookData"),
+					Bindings: map[string]interface{}{
+						"http": map[string]interface{}{
+							"type":   "request",
+							"method": "POST",
+						},
+					},
+				},
+			},
+		},
+		Components: &AsyncAPIComponents{
+			Messages: map[string]*AsyncAPIMessage{
+				"start":              newMessage("start", i18n.MsgAsyncAPIStart, valueSchema(&fftypes.WSClientActionStartPayload{})),
+				"ack":                newMessage("ack", i18n.MsgAsyncAPIAck, valueSchema(&fftypes.WSClientActionAckPayload{})),
+				"eventDelivery":      newMessage("eventDelivery", i18n.MsgAsyncAPIEventDelivery, valueSchema(&fftypes.EventDelivery{})),
+				"protocolError":      newMessage("protocolError", i18n.MsgAsyncAPIProtocolError, valueSchema(&fftypes.WSProtocolErrorPayload{})),
+				"changeNotification": newMessage("changeNotification", i18n.MsgAsyncAPIChangeNotification, valueSchema(&fftypes.WSChangeNotification{})),
+				"webhookData":        newMessage("webhookData", i18n.MsgAsyncAPIWebhookData, &openapi3.SchemaRef{Value: &openapi3.Schema{}}),
+			},
+			Schemas: schemas,
+		},
+	}
+}
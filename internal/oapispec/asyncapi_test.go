@@ -0,0 +1,76 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oapispec
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncAPIGen(t *testing.T) {
+	config.Reset()
+
+	doc := AsyncAPIGen(context.Background(), "https://localhost:12345", map[string]string{
+		"websockets": `{}`,
+		"webhooks":   `{"type":"object","properties":{"url":{"type":"string"}}}`,
+	})
+	assert.Equal(t, "2.2.0", doc.AsyncAPI)
+	assert.Equal(t, "wss://localhost:12345", doc.Servers["websockets"].URL)
+	assert.Len(t, doc.Channels["/ws"].Publish.Message.OneOf, 2)
+	assert.Equal(t, "#/components/messages/start", doc.Channels["/ws"].Publish.Message.OneOf[0].Ref)
+	for _, ch := range doc.Channels {
+		for _, op := range []*AsyncAPIOperation{ch.Publish, ch.Subscribe} {
+			if op != nil {
+				for _, ref := range op.Message.OneOf {
+					assert.NotNil(t, doc.Components.Messages[ref.Ref[len("#/components/messages/"):]], ref.Ref)
+				}
+			}
+		}
+	}
+	assert.Contains(t, doc.Components.Messages["eventDelivery"].Payload.Value.Properties, "subscription")
+	assert.Contains(t, doc.Components.Schemas["webhooksOptions"].Value.Properties, "url")
+	assert.NotNil(t, doc.Components.Schemas["websocketsOptions"])
+
+	b, err := yaml.Marshal(doc)
+	assert.NoError(t, err)
+	var parsed map[string]interface{}
+	err = yaml.Unmarshal(b, &parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2.0", parsed["asyncapi"])
+
+	b, err = json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.Regexp(t, `"\$ref":"#/components/messages/eventDelivery"`, string(b))
+}
+
+func TestAsyncAPIGenInsecure(t *testing.T) {
+	doc := AsyncAPIGen(context.Background(), "http://localhost:12345", map[string]string{})
+	assert.Equal(t, "ws://localhost:12345", doc.Servers["websockets"].URL)
+}
+
+func TestAsyncAPIGenBadOptionsSchema(t *testing.T) {
+	assert.Panics(t, func() {
+		_ = AsyncAPIGen(context.Background(), "http://localhost:12345", map[string]string{
+			"webhooks": `!json`,
+		})
+	})
+}
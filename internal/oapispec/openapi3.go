@@ -31,6 +31,7 @@ import (
 	"github.com/getkin/kin-openapi/openapi3gen"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -225,6 +226,9 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 			example = config.GetString(q.ExampleFromConf)
 		}
 		addParam(ctx, op, "query", q.Name, q.Default, example, q.Description, q.Deprecated)
+		if q.Name == "confirm" {
+			addParam(ctx, op, "query", "timeout", "", "", i18n.MsgConfirmTimeoutQueryParam, false)
+		}
 	}
 	addParam(ctx, op, "header", "Request-Timeout", config.GetString(config.APIRequestTimeout), "", i18n.MsgRequestTimeoutDesc, false)
 	if route.FilterFactory != nil {
@@ -233,12 +237,12 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 		for _, field := range fields {
 			addParam(ctx, op, "query", field, "", "", i18n.MsgFilterParamDesc, false)
 		}
-		addParam(ctx, op, "query", "sort", "", "", i18n.MsgFilterSortDesc, false)
-		addParam(ctx, op, "query", "ascending", "", "", i18n.MsgFilterAscendingDesc, false)
-		addParam(ctx, op, "query", "descending", "", "", i18n.MsgFilterDescendingDesc, false)
-		addParam(ctx, op, "query", "skip", "", "", i18n.MsgFilterSkipDesc, false, config.GetUint(config.APIMaxFilterSkip))
-		addParam(ctx, op, "query", "limit", "", config.GetString(config.APIDefaultFilterLimit), i18n.MsgFilterLimitDesc, false, config.GetUint(config.APIMaxFilterLimit))
-		addParam(ctx, op, "query", "count", "", "", i18n.MsgFilterCountDesc, false)
+		addParam(ctx, op, "query", database.QueryParamSort, "", "", i18n.MsgFilterSortDesc, false)
+		addParam(ctx, op, "query", database.QueryParamAscending, "", "", i18n.MsgFilterAscendingDesc, false)
+		addParam(ctx, op, "query", database.QueryParamDescending, "", "", i18n.MsgFilterDescendingDesc, false)
+		addParam(ctx, op, "query", database.QueryParamSkip, "", "", i18n.MsgFilterSkipDesc, false, config.GetUint(config.APIMaxFilterSkip))
+		addParam(ctx, op, "query", database.QueryParamLimit, "", config.GetString(config.APIDefaultFilterLimit), i18n.MsgFilterLimitDesc, false, config.GetUint(config.APIMaxFilterLimit))
+		addParam(ctx, op, "query", database.QueryParamCount, "", "", i18n.MsgFilterCountDesc, false)
 	}
 	switch route.Method {
 	case http.MethodGet:
@@ -22,6 +22,7 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 // Route defines each API operation on the REST API of Firefly
@@ -61,6 +62,8 @@ type Route struct {
 	FormUploadHandler func(r *APIRequest) (output interface{}, err error)
 	// Deprecated whether this route is deprecated
 	Deprecated bool
+	// Feature is the feature that must be enabled in the namespace of the request, for the route to be available
+	Feature fftypes.Feature
 }
 
 // PathParam is a description of a path parameter
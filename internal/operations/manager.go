@@ -0,0 +1,166 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// OperationHandler is implemented by the components that submit operations, to re-execute them
+type OperationHandler interface {
+	// RetryOperation re-executes a new operation, which carries the type and inputs of the failed original
+	RetryOperation(ctx context.Context, op *fftypes.Operation) error
+}
+
+// Manager provides the retry of failed operations, by delegating to the handler registered for each operation type
+type Manager interface {
+	RegisterHandler(handler OperationHandler, ops []fftypes.OpType)
+
+	// RetryOperation creates a new operation, linked from the original with a retry pointer, and submits it.
+	// An operation that has already been retried has the latest retry in its chain retried.
+	// Operations with redacted inputs cannot be retried, and only one of two concurrent retries of the same operation succeeds.
+	RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error)
+}
+
+type operationsManager struct {
+	database database.Plugin
+	handlers map[fftypes.OpType]OperationHandler
+}
+
+func NewOperationsManager(ctx context.Context, di database.Plugin) (Manager, error) {
+	if di == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	return &operationsManager{
+		database: di,
+		handlers: make(map[fftypes.OpType]OperationHandler),
+	}, nil
+}
+
+func (om *operationsManager) RegisterHandler(handler OperationHandler, ops []fftypes.OpType) {
+	for _, opType := range ops {
+		om.handlers[opType] = handler
+	}
+}
+
+func (om *operationsManager) getOperation(ctx context.Context, ns string, id *fftypes.UUID) (*fftypes.Operation, error) {
+	op, err := om.database.GetOperationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil || op.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return op, nil
+}
+
+// findRedacted returns the path of the first redacted value in the inputs of an operation,
+// as those inputs can no longer be used to resubmit it
+func findRedacted(v interface{}, path string) string {
+	switch vt := v.(type) {
+	case string:
+		if vt == fftypes.RedactedValue {
+			return path
+		}
+	case fftypes.JSONObject:
+		return findRedacted(map[string]interface{}(vt), path)
+	case map[string]interface{}:
+		for k, child := range vt {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if found := findRedacted(child, childPath); found != "" {
+				return found
+			}
+		}
+	case []interface{}:
+		for i, child := range vt {
+			if found := findRedacted(child, fmt.Sprintf("%s[%d]", path, i)); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+func (om *operationsManager) RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error) {
+	opID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var op *fftypes.Operation
+	var handler OperationHandler
+	err = om.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		if op, err = om.getOperation(ctx, ns, opID); err != nil {
+			return err
+		}
+		for op.Retry != nil {
+			if op, err = om.getOperation(ctx, ns, op.Retry); err != nil {
+				return err
+			}
+		}
+		if op.Status != fftypes.OpStatusFailed {
+			return i18n.NewError(ctx, i18n.MsgOperationNotFailed, op.ID, op.Status)
+		}
+		var ok bool
+		if handler, ok = om.handlers[op.Type]; !ok {
+			return i18n.NewError(ctx, i18n.MsgOperationRetryNotSupported, op.Type)
+		}
+		if path := findRedacted(op.Input, ""); path != "" {
+			return i18n.NewError(ctx, i18n.MsgOperationInputsRedacted, op.ID, path)
+		}
+
+		// The retry is a copy of the original, which the handler submits with the same inputs
+		originalID := op.ID
+		op.ID = fftypes.NewUUID()
+		op.Status = fftypes.OpStatusPending
+		op.Error = ""
+		op.BackendID = ""
+		op.Output = nil
+		op.Created = fftypes.Now()
+		op.Updated = nil
+		if err = om.database.InsertOperation(ctx, op); err != nil {
+			return err
+		}
+		log.L(ctx).Infof("Retrying operation %s of type %s as %s", originalID, op.Type, op.ID)
+		// Fails if a concurrent retry of the same operation has already linked itself
+		return om.database.SetOperationRetry(ctx, originalID, op.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = handler.RetryOperation(ctx, op); err != nil {
+		// Mark the retry as failed, so that it can itself be retried
+		update := database.OperationQueryFactory.NewUpdate(ctx).
+			Set("status", fftypes.OpStatusFailed).
+			Set("error", err.Error())
+		if updateErr := om.database.UpdateOperation(ctx, op.ID, update); updateErr != nil {
+			log.L(ctx).Errorf("Operation update failed: %s update=[ %s ]", updateErr, update)
+		}
+		return nil, err
+	}
+	return op, nil
+}
@@ -0,0 +1,233 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/batchpinmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestOperations(t *testing.T) (*operationsManager, *databasemocks.Plugin, *batchpinmocks.Submitter) {
+	mdi := &databasemocks.Plugin{}
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	om, err := NewOperationsManager(context.Background(), mdi)
+	assert.NoError(t, err)
+	mbp := &batchpinmocks.Submitter{}
+	om.RegisterHandler(mbp, []fftypes.OpType{fftypes.OpTypeBlockchainBatchPin})
+	return om.(*operationsManager), mdi, mbp
+}
+
+func failedOp(opType fftypes.OpType) *fftypes.Operation {
+	return &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        opType,
+		Status:      fftypes.OpStatusFailed,
+		Error:       "pop",
+		BackendID:   "tracking1",
+		Input:       fftypes.JSONObject{"batch": "b1"},
+		Output:      fftypes.JSONObject{"some": "output"},
+		Created:     fftypes.Now(),
+		Updated:     fftypes.Now(),
+	}
+}
+
+func retryLink(retryID **fftypes.UUID) interface{} {
+	return mock.MatchedBy(func(id *fftypes.UUID) bool {
+		return id.Equals(*retryID)
+	})
+}
+
+func TestNewOperationsManagerMissingDeps(t *testing.T) {
+	_, err := NewOperationsManager(context.Background(), nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestRetryOperationOk(t *testing.T) {
+	om, mdi, mbp := newTestOperations(t)
+
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	origID := op.ID
+	txID := op.Transaction
+	var retryID *fftypes.UUID
+	mdi.On("GetOperationByID", mock.Anything, origID).Return(op, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(newOp *fftypes.Operation) bool {
+		retryID = newOp.ID
+		return !newOp.ID.Equals(origID) &&
+			newOp.Transaction.Equals(txID) &&
+			newOp.Status == fftypes.OpStatusPending &&
+			newOp.Error == "" &&
+			newOp.BackendID == "" &&
+			newOp.Output == nil &&
+			newOp.Updated == nil &&
+			newOp.Input.GetString("batch") == "b1"
+	})).Return(nil)
+	mdi.On("SetOperationRetry", mock.Anything, origID, retryLink(&retryID)).Return(nil)
+	mbp.On("RetryOperation", mock.Anything, mock.MatchedBy(func(newOp *fftypes.Operation) bool {
+		return newOp.ID.Equals(retryID)
+	})).Return(nil)
+
+	newOp, err := om.RetryOperation(context.Background(), "ns1", origID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, retryID, newOp.ID)
+
+	mdi.AssertExpectations(t)
+	mbp.AssertExpectations(t)
+}
+
+func TestRetryOperationFollowsRetries(t *testing.T) {
+	om, mdi, mbp := newTestOperations(t)
+
+	latest := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	op.Retry = latest.ID
+	latestID := latest.ID
+	var retryID *fftypes.UUID
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mdi.On("GetOperationByID", mock.Anything, latestID).Return(latest, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.MatchedBy(func(newOp *fftypes.Operation) bool {
+		retryID = newOp.ID
+		return true
+	})).Return(nil)
+	mdi.On("SetOperationRetry", mock.Anything, latestID, retryLink(&retryID)).Return(nil)
+	mbp.On("RetryOperation", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mbp.AssertExpectations(t)
+}
+
+func TestRetryOperationBadID(t *testing.T) {
+	om, _, _ := newTestOperations(t)
+	_, err := om.RetryOperation(context.Background(), "ns1", "!uuid")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRetryOperationGetFail(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := om.RetryOperation(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationNotFound(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err := om.RetryOperation(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationWrongNamespace(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := om.RetryOperation(context.Background(), "ns2", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationRetryNotFound(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	op.Retry = fftypes.NewUUID()
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mdi.On("GetOperationByID", mock.Anything, op.Retry).Return(nil, nil)
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationNotFailed(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	op.Status = fftypes.OpStatusPending
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10403", err)
+}
+
+func TestRetryOperationNotSupported(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeTokenCreatePool)
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10404", err)
+}
+
+func TestRetryOperationInsertFail(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationRedactedInputs(t *testing.T) {
+	om, mdi, _ := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	op.Input = fftypes.JSONObject{
+		"batch": "b1",
+		"contexts": []interface{}{
+			"c1",
+			map[string]interface{}{"data": fftypes.RedactedValue},
+		},
+	}
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10465.*contexts\\[1\\]\\.data", err)
+}
+
+func TestRetryOperationAlreadyRetried(t *testing.T) {
+	om, mdi, mbp := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("SetOperationRetry", mock.Anything, op.ID, mock.Anything).Return(fmt.Errorf("FF10464"))
+	_, err := om.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10464", err)
+	mbp.AssertNotCalled(t, "RetryOperation", mock.Anything, mock.Anything)
+}
+
+func TestRetryOperationHandlerFail(t *testing.T) {
+	om, mdi, mbp := newTestOperations(t)
+	op := failedOp(fftypes.OpTypeBlockchainBatchPin)
+	origID := op.ID
+	mdi.On("GetOperationByID", mock.Anything, origID).Return(op, nil)
+	mdi.On("InsertOperation", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("SetOperationRetry", mock.Anything, origID, mock.Anything).Return(nil)
+	mdi.On("UpdateOperation", mock.Anything, mock.MatchedBy(func(id *fftypes.UUID) bool {
+		return !id.Equals(origID)
+	}), mock.Anything).Return(fmt.Errorf("pop2"))
+	mbp.On("RetryOperation", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := om.RetryOperation(context.Background(), "ns1", origID.String())
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// ResolveAsOf converts a point in history supplied on a query into a timestamp. Integers are
+// treated as the sequence of an event in the namespace, and resolve to the time that event was created.
+// Anything else must be an RFC3339 timestamp.
+func (or *orchestrator) ResolveAsOf(ctx context.Context, ns, asOf string) (*fftypes.FFTime, error) {
+	if sequence, err := strconv.ParseInt(asOf, 10, 64); err == nil {
+		fb := database.EventQueryFactory.NewFilter(ctx)
+		events, _, err := or.database.GetEvents(ctx, fb.And(fb.Eq("namespace", ns), fb.Eq("sequence", sequence)).Limit(1))
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			return nil, i18n.NewError(ctx, i18n.MsgAsOfEventNotFound, sequence, ns)
+		}
+		return events[0].Created, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, asOf)
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidAsOf, asOf)
+	}
+	ft := fftypes.FFTime(t)
+	return &ft, nil
+}
+
+// messageAsOf rolls back the confirmation of a message, if it happened after the point in history
+func messageAsOf(msg *fftypes.Message, asOf *fftypes.FFTime) {
+	if msg.Confirmed != nil && msg.Confirmed.Time().After(*asOf.Time()) {
+		msg.Confirmed = nil
+		msg.State = fftypes.MessageStatePending
+	}
+}
+
+// messageHistoricFields are the fields of a message that change when it is confirmed, so cannot be used
+// to filter or sort a query for the messages at a point in history
+var messageHistoricFields = map[string]bool{"state": true, "confirmed": true}
+
+func (or *orchestrator) getMessagesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	fi, err := filter.Finalize()
+	if err != nil {
+		return nil, nil, err
+	}
+	if field := fi.FieldIn(messageHistoricFields); field != "" {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgAsOfHistoricField, field)
+	}
+	filter = or.scopeNS(ns, filter)
+	filter = filter.Condition(filter.Builder().Lte("created", asOf))
+	msgs, fr, err := or.database.GetMessages(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, msg := range msgs {
+		messageAsOf(msg, asOf)
+	}
+	return msgs, fr, nil
+}
+
+func (or *orchestrator) GetMessagesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	return or.getMessagesAsOf(ctx, ns, asOf, filter)
+}
+
+func (or *orchestrator) GetMessagesWithDataAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.MessageInOut, *database.FilterResult, error) {
+	msgs, fr, err := or.getMessagesAsOf(ctx, ns, asOf, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	msgsData, err := or.fetchMessagesData(ctx, msgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msgsData, fr, nil
+}
@@ -0,0 +1,155 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveAsOfSequence(t *testing.T) {
+	or := newTestOrchestrator()
+	created := fftypes.Now()
+	or.mdi.On("GetEvents", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return fi.String() == "( namespace == 'ns1' ) && ( sequence == 12345 ) limit=1"
+	})).Return([]*fftypes.Event{{Sequence: 12345, Created: created}}, nil, nil)
+	asOf, err := or.ResolveAsOf(context.Background(), "ns1", "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, created, asOf)
+}
+
+func TestResolveAsOfSequenceFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, err := or.ResolveAsOf(context.Background(), "ns1", "12345")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestResolveAsOfSequenceNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	_, err := or.ResolveAsOf(context.Background(), "ns1", "12345")
+	assert.Regexp(t, "FF10319", err)
+}
+
+func TestResolveAsOfTimestamp(t *testing.T) {
+	or := newTestOrchestrator()
+	asOf, err := or.ResolveAsOf(context.Background(), "ns1", "2021-11-01T12:30:00.5Z")
+	assert.NoError(t, err)
+	assert.Equal(t, "2021-11-01T12:30:00.5Z", asOf.String())
+}
+
+func TestResolveAsOfBad(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.ResolveAsOf(context.Background(), "ns1", "yesterday")
+	assert.Regexp(t, "FF10318", err)
+}
+
+func TestGetMessagesAsOf(t *testing.T) {
+	or := newTestOrchestrator()
+	asOf := fftypes.Now()
+	later := fftypes.FFTime(asOf.Time().Add(1 * time.Second))
+	earlier := fftypes.FFTime(asOf.Time().Add(-1 * time.Second))
+	msgs := []*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, State: fftypes.MessageStateConfirmed, Confirmed: &earlier},
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, State: fftypes.MessageStateConfirmed, Confirmed: &later},
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, State: fftypes.MessageStatePending},
+	}
+	or.mdi.On("GetMessages", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return len(fi.Children) == 2 && fi.Children[1].Field == "created" && fi.Children[1].Op == database.FilterOpLte
+	})).Return(msgs, nil, nil)
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	res, _, err := or.GetMessagesAsOf(context.Background(), "ns1", asOf, fb.And())
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, fftypes.MessageStateConfirmed, res[0].State)
+	assert.Equal(t, &earlier, res[0].Confirmed)
+	assert.Equal(t, fftypes.MessageStatePending, res[1].State)
+	assert.Nil(t, res[1].Confirmed)
+	assert.Equal(t, fftypes.MessageStatePending, res[2].State)
+}
+
+func TestGetMessagesAsOfFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessagesAsOf(context.Background(), "ns1", fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessagesAsOfHistoricFilter(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessagesAsOf(context.Background(), "ns1", fftypes.Now(), fb.And(fb.Eq("state", fftypes.MessageStateConfirmed)))
+	assert.Regexp(t, "FF10457.*state", err)
+
+	fb = database.MessageQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("tag", "tag1"))
+	f.Sort("confirmed")
+	_, _, err = or.GetMessagesAsOf(context.Background(), "ns1", fftypes.Now(), f)
+	assert.Regexp(t, "FF10457.*confirmed", err)
+}
+
+func TestGetMessagesAsOfBadFilter(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessagesAsOf(context.Background(), "ns1", fftypes.Now(), fb.And(fb.Eq("wrong", "0x1")))
+	assert.Regexp(t, "FF10148", err)
+}
+
+func TestGetMessagesWithDataAsOf(t *testing.T) {
+	or := newTestOrchestrator()
+	asOf := fftypes.Now()
+	later := fftypes.FFTime(asOf.Time().Add(1 * time.Second))
+	msgs := []*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, State: fftypes.MessageStateConfirmed, Confirmed: &later},
+	}
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return(msgs, nil, nil)
+	or.mdm.On("GetMessageData", mock.Anything, msgs[0], true).Return([]*fftypes.Data{}, true, nil)
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	res, _, err := or.GetMessagesWithDataAsOf(context.Background(), "ns1", asOf, fb.And())
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, fftypes.MessageStatePending, res[0].State)
+}
+
+func TestGetMessagesWithDataAsOfFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessagesWithDataAsOf(context.Background(), "ns1", fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessagesWithDataAsOfDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	msgs := []*fftypes.Message{{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}}
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return(msgs, nil, nil)
+	or.mdm.On("GetMessageData", mock.Anything, msgs[0], true).Return(nil, false, fmt.Errorf("pop"))
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessagesWithDataAsOf(context.Background(), "ns1", fftypes.Now(), fb.And())
+	assert.EqualError(t, err, "pop")
+}
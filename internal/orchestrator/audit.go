@@ -0,0 +1,47 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// RecordAudit stores a record of a state-changing API call. The record is written against the
+// orchestrator context rather than the request context, so calls that time out are still audited.
+func (or *orchestrator) RecordAudit(ctx context.Context, record *fftypes.AuditRecord) error {
+	return or.database.InsertAuditRecord(or.ctx, record)
+}
+
+func (or *orchestrator) GetAuditRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
+	return or.database.GetAuditRecords(ctx, filter)
+}
+
+func (or *orchestrator) GetAuditRecordByID(ctx context.Context, id string) (*fftypes.AuditRecord, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := or.database.GetAuditRecordByID(ctx, u)
+	if err == nil && record == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return record, err
+}
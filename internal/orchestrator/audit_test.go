@@ -0,0 +1,67 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecordAudit(t *testing.T) {
+	or := newTestOrchestrator()
+	record := &fftypes.AuditRecord{ID: fftypes.NewUUID()}
+	or.mdi.On("InsertAuditRecord", or.ctx, record).Return(nil)
+	err := or.RecordAudit(context.Background(), record)
+	assert.NoError(t, err)
+}
+
+func TestGetAuditRecords(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetAuditRecords", mock.Anything, mock.Anything).Return([]*fftypes.AuditRecord{}, nil, nil)
+	fb := database.AuditRecordQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("method", "POST"))
+	_, _, err := or.GetAuditRecords(context.Background(), f)
+	assert.NoError(t, err)
+}
+
+func TestGetAuditRecordByID(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetAuditRecordByID", mock.Anything, id).Return(&fftypes.AuditRecord{ID: id}, nil)
+	record, err := or.GetAuditRecordByID(context.Background(), id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, record.ID)
+}
+
+func TestGetAuditRecordByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetAuditRecordByID(context.Background(), "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetAuditRecordByIDNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	id := fftypes.NewUUID()
+	or.mdi.On("GetAuditRecordByID", mock.Anything, id).Return(nil, nil)
+	_, err := or.GetAuditRecordByID(context.Background(), id.String())
+	assert.Regexp(t, "FF10109", err)
+}
@@ -17,10 +17,15 @@
 package orchestrator
 
 import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
 	"github.com/hyperledger/firefly/internal/events"
+	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
 )
 
@@ -28,6 +33,13 @@ type boundCallbacks struct {
 	bi blockchain.Plugin
 	dx dataexchange.Plugin
 	ei events.EventManager
+
+	// Set on the callbacks of each connector, when multiple blockchain connectors are configured
+	connector string
+	mux       *bimux.Multiplexer
+
+	// Filters the namespaces sequenced by the orchestrator, when there are namespaces with isolated plugins
+	sequenced func(ns string) bool
 }
 
 func (bc *boundCallbacks) BlockchainOpUpdate(operationID *fftypes.UUID, txState blockchain.TransactionStatus, errorMessage string, opOutput fftypes.JSONObject) error {
@@ -38,10 +50,27 @@ func (bc *boundCallbacks) TokenOpUpdate(plugin tokens.Plugin, operationID *fftyp
 	return bc.ei.OperationUpdate(plugin, operationID, txState, errorMessage, opOutput)
 }
 
+func (bc *boundCallbacks) PublicStorageOpUpdate(plugin publicstorage.Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
+	return bc.ei.OperationUpdate(plugin, operationID, txState, errorMessage, opOutput)
+}
+
 func (bc *boundCallbacks) BatchPinComplete(batch *blockchain.BatchPin, author string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	if bc.mux != nil && bc.mux.ConnectorName(batch.Namespace) != bc.connector {
+		// A namespace is only sequenced by the chain of the connector it is bound to
+		log.L(context.Background()).Warnf("Ignoring batch pin for namespace '%s' from blockchain connector '%s'", batch.Namespace, bc.connector)
+		return nil
+	}
+	if bc.sequenced != nil && !bc.sequenced(batch.Namespace) {
+		log.L(context.Background()).Debugf("Ignoring batch pin for namespace '%s' sequenced by another orchestrator", batch.Namespace)
+		return nil
+	}
 	return bc.ei.BatchPinComplete(bc.bi, batch, author, protocolTxID, additionalInfo)
 }
 
+func (bc *boundCallbacks) BlockchainEvent(event *fftypes.BlockchainEvent) error {
+	return bc.ei.BlockchainEvent(bc.bi, event)
+}
+
 func (bc *boundCallbacks) TransferResult(trackingID string, status fftypes.OpStatus, info string, opOutput fftypes.JSONObject) error {
 	return bc.ei.TransferResult(bc.dx, trackingID, status, info, opOutput)
 }
@@ -61,3 +90,11 @@ func (bc *boundCallbacks) TokenPoolCreated(plugin tokens.Plugin, pool *tokens.To
 func (bc *boundCallbacks) TokensTransferred(plugin tokens.Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	return bc.ei.TokensTransferred(plugin, poolProtocolID, transfer, protocolTxID, additionalInfo)
 }
+
+func (bc *boundCallbacks) TokensApproved(plugin tokens.Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	return bc.ei.TokensApproved(plugin, poolProtocolID, approval, protocolTxID, additionalInfo)
+}
+
+func (bc *boundCallbacks) TokenPoolGovernance(plugin tokens.Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	return bc.ei.TokenPoolGovernance(plugin, poolProtocolID, change, protocolTxID, additionalInfo)
+}
@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -35,6 +37,7 @@ func TestBoundCallbacks(t *testing.T) {
 	mbi := &blockchainmocks.Plugin{}
 	mdx := &dataexchangemocks.Plugin{}
 	mti := &tokenmocks.Plugin{}
+	mps := &publicstoragemocks.Plugin{}
 	bc := boundCallbacks{bi: mbi, dx: mdx, ei: mei}
 
 	info := fftypes.JSONObject{"hello": "world"}
@@ -48,6 +51,11 @@ func TestBoundCallbacks(t *testing.T) {
 	err := bc.BatchPinComplete(batch, "0x12345", "tx12345", info)
 	assert.EqualError(t, err, "pop")
 
+	event := &fftypes.BlockchainEvent{Name: "Changed"}
+	mei.On("BlockchainEvent", mbi, event).Return(fmt.Errorf("pop"))
+	err = bc.BlockchainEvent(event)
+	assert.EqualError(t, err, "pop")
+
 	mei.On("OperationUpdate", mbi, opID, fftypes.OpStatusFailed, "error info", info).Return(fmt.Errorf("pop"))
 	err = bc.BlockchainOpUpdate(opID, fftypes.OpStatusFailed, "error info", info)
 	assert.EqualError(t, err, "pop")
@@ -56,6 +64,10 @@ func TestBoundCallbacks(t *testing.T) {
 	err = bc.TokenOpUpdate(mti, opID, fftypes.OpStatusFailed, "error info", info)
 	assert.EqualError(t, err, "pop")
 
+	mei.On("OperationUpdate", mps, opID, fftypes.OpStatusFailed, "error info", info).Return(fmt.Errorf("pop"))
+	err = bc.PublicStorageOpUpdate(mps, opID, fftypes.OpStatusFailed, "error info", info)
+	assert.EqualError(t, err, "pop")
+
 	mei.On("TransferResult", mdx, "tracking12345", fftypes.OpStatusFailed, "error info", info).Return(fmt.Errorf("pop"))
 	err = bc.TransferResult("tracking12345", fftypes.OpStatusFailed, "error info", info)
 	assert.EqualError(t, err, "pop")
@@ -75,4 +87,55 @@ func TestBoundCallbacks(t *testing.T) {
 	mei.On("TokensTransferred", mti, "N1", transfer, "tx12345", info).Return(fmt.Errorf("pop"))
 	err = bc.TokensTransferred(mti, "N1", transfer, "tx12345", info)
 	assert.EqualError(t, err, "pop")
+
+	approval := &fftypes.TokenApproval{}
+	mei.On("TokensApproved", mti, "N1", approval, "tx12345", info).Return(fmt.Errorf("pop"))
+	err = bc.TokensApproved(mti, "N1", approval, "tx12345", info)
+	assert.EqualError(t, err, "pop")
+
+	change := &fftypes.TokenPoolGovernance{}
+	mei.On("TokenPoolGovernance", mti, "N1", change, "tx12345", info).Return(fmt.Errorf("pop"))
+	err = bc.TokenPoolGovernance(mti, "N1", change, "tx12345", info)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBoundCallbacksConnector(t *testing.T) {
+	mei := &eventmocks.EventManager{}
+	mbi1 := &blockchainmocks.Plugin{}
+	mbi2 := &blockchainmocks.Plugin{}
+	mux := bimux.NewMultiplexer("chain1", map[string]blockchain.Plugin{
+		"chain1": mbi1,
+		"chain2": mbi2,
+	}, map[string]string{"ns2": "chain2"})
+	bc := boundCallbacks{bi: mbi2, ei: mei, connector: "chain2", mux: mux}
+
+	info := fftypes.JSONObject{"hello": "world"}
+	batch := &blockchain.BatchPin{Namespace: "ns2", TransactionID: fftypes.NewUUID()}
+	mei.On("BatchPinComplete", mbi2, batch, "0x12345", "tx12345", info).Return(nil)
+	err := bc.BatchPinComplete(batch, "0x12345", "tx12345", info)
+	assert.NoError(t, err)
+
+	// Pins for namespaces bound to another connector are ignored
+	err = bc.BatchPinComplete(&blockchain.BatchPin{Namespace: "ns1"}, "0x12345", "tx12345", info)
+	assert.NoError(t, err)
+
+	mei.AssertExpectations(t)
+}
+
+func TestBoundCallbacksSequencedNamespaces(t *testing.T) {
+	mei := &eventmocks.EventManager{}
+	mbi := &blockchainmocks.Plugin{}
+	bc := boundCallbacks{bi: mbi, ei: mei, sequenced: func(ns string) bool { return ns == "ns1" }}
+
+	info := fftypes.JSONObject{"hello": "world"}
+	batch := &blockchain.BatchPin{Namespace: "ns1", TransactionID: fftypes.NewUUID()}
+	mei.On("BatchPinComplete", mbi, batch, "0x12345", "tx12345", info).Return(nil)
+	err := bc.BatchPinComplete(batch, "0x12345", "tx12345", info)
+	assert.NoError(t, err)
+
+	// Pins for namespaces sequenced by another orchestrator are ignored
+	err = bc.BatchPinComplete(&blockchain.BatchPin{Namespace: "ns2"}, "0x12345", "tx12345", info)
+	assert.NoError(t, err)
+
+	mei.AssertExpectations(t)
 }
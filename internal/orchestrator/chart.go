@@ -37,12 +37,32 @@ func (or *orchestrator) getHistogramIntervals(startTime int64, endTime int64, nu
 	return intervals
 }
 
-func (or *orchestrator) GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, collection database.CollectionName) ([]*fftypes.ChartHistogram, error) {
+func (or *orchestrator) checkHistogramParams(ctx context.Context, startTime int64, endTime int64, buckets int64) error {
 	if buckets > fftypes.ChartHistogramMaxBuckets || buckets < fftypes.ChartHistogramMinBuckets {
-		return nil, i18n.NewError(ctx, i18n.MsgInvalidNumberOfIntervals, fftypes.ChartHistogramMinBuckets, fftypes.ChartHistogramMaxBuckets)
+		return i18n.NewError(ctx, i18n.MsgInvalidNumberOfIntervals, fftypes.ChartHistogramMinBuckets, fftypes.ChartHistogramMaxBuckets)
 	}
 	if startTime > endTime {
-		return nil, i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+		return i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+	}
+	return nil
+}
+
+func (or *orchestrator) checkRankingParams(ctx context.Context, startTime, endTime *fftypes.FFTime, limit int) (int, error) {
+	if limit == 0 {
+		limit = fftypes.ChartRankingDefaultLimit
+	}
+	if limit < 0 || limit > fftypes.ChartRankingMaxLimit {
+		return 0, i18n.NewError(ctx, i18n.MsgInvalidChartRankingLimit, fftypes.ChartRankingMaxLimit)
+	}
+	if startTime != nil && endTime != nil && startTime.UnixNano() > endTime.UnixNano() {
+		return 0, i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+	}
+	return limit, nil
+}
+
+func (or *orchestrator) GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, collection database.CollectionName) ([]*fftypes.ChartHistogram, error) {
+	if err := or.checkHistogramParams(ctx, startTime, endTime, buckets); err != nil {
+		return nil, err
 	}
 
 	intervals := or.getHistogramIntervals(startTime, endTime, buckets)
@@ -54,3 +74,40 @@ func (or *orchestrator) GetChartHistogram(ctx context.Context, ns string, startT
 
 	return histogram, nil
 }
+
+func (or *orchestrator) GetChartTypeHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, collection database.CollectionName) ([]*fftypes.ChartHistogramTyped, error) {
+	if err := or.checkHistogramParams(ctx, startTime, endTime, buckets); err != nil {
+		return nil, err
+	}
+
+	intervals := or.getHistogramIntervals(startTime, endTime, buckets)
+
+	return or.database.GetChartTypeHistogram(ctx, ns, intervals, collection)
+}
+
+func (or *orchestrator) GetChartTopTopics(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	limit, err := or.checkRankingParams(ctx, startTime, endTime, limit)
+	if err != nil {
+		return nil, err
+	}
+	return or.database.GetChartTopTopics(ctx, ns, startTime, endTime, limit)
+}
+
+func (or *orchestrator) GetChartTopAuthors(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	limit, err := or.checkRankingParams(ctx, startTime, endTime, limit)
+	if err != nil {
+		return nil, err
+	}
+	return or.database.GetChartTopAuthors(ctx, ns, startTime, endTime, limit)
+}
+
+// GetChartActivity returns the most recent events in the namespace, newest first
+func (or *orchestrator) GetChartActivity(ctx context.Context, ns string, limit int) ([]*fftypes.Event, error) {
+	limit, err := or.checkRankingParams(ctx, nil, nil, limit)
+	if err != nil {
+		return nil, err
+	}
+	fb := database.EventQueryFactory.NewFilter(ctx)
+	events, _, err := or.database.GetEvents(ctx, fb.And(fb.Eq("namespace", ns)).Sort("sequence").Descending().Limit(uint64(limit)))
+	return events, err
+}
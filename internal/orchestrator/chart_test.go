@@ -72,3 +72,63 @@ func TestGetHistogramSuccess(t *testing.T) {
 	_, err := or.GetChartHistogram(context.Background(), "ns1", 1000000000, 1000000010, 10, database.CollectionName("test"))
 	assert.NoError(t, err)
 }
+
+func TestGetTypeHistogramBadIntervals(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetChartTypeHistogram(context.Background(), "ns1", 1234567890, 9876543210, fftypes.ChartHistogramMaxBuckets+1, database.CollectionName("test"))
+	assert.Regexp(t, "FF10298", err)
+}
+
+func TestGetTypeHistogramSuccess(t *testing.T) {
+	or := newTestOrchestrator()
+	intervals := makeTestIntervals(1000000000, 10)
+	mockHistogram := []*fftypes.ChartHistogramTyped{}
+
+	or.mdi.On("GetChartTypeHistogram", mock.Anything, "ns1", intervals, database.CollectionName("test")).Return(mockHistogram, nil)
+	_, err := or.GetChartTypeHistogram(context.Background(), "ns1", 1000000000, 1000000010, 10, database.CollectionName("test"))
+	assert.NoError(t, err)
+}
+
+func TestGetTopTopicsDefaultLimit(t *testing.T) {
+	or := newTestOrchestrator()
+	start := fftypes.UnixTime(1000)
+	end := fftypes.UnixTime(2000)
+	or.mdi.On("GetChartTopTopics", mock.Anything, "ns1", start, end, fftypes.ChartRankingDefaultLimit).Return([]*fftypes.ChartRanking{}, nil)
+	_, err := or.GetChartTopTopics(context.Background(), "ns1", start, end, 0)
+	assert.NoError(t, err)
+}
+
+func TestGetTopTopicsBadLimit(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetChartTopTopics(context.Background(), "ns1", nil, nil, fftypes.ChartRankingMaxLimit+1)
+	assert.Regexp(t, "FF10355", err)
+}
+
+func TestGetTopAuthors(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetChartTopAuthors", mock.Anything, "ns1", (*fftypes.FFTime)(nil), (*fftypes.FFTime)(nil), 5).Return([]*fftypes.ChartRanking{}, nil)
+	_, err := or.GetChartTopAuthors(context.Background(), "ns1", nil, nil, 5)
+	assert.NoError(t, err)
+}
+
+func TestGetTopAuthorsBadTimes(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetChartTopAuthors(context.Background(), "ns1", fftypes.UnixTime(2000), fftypes.UnixTime(1000), 5)
+	assert.Regexp(t, "FF10300", err)
+}
+
+func TestGetChartActivity(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetEvents", mock.Anything, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.String() == "( namespace == 'ns1' ) sort=-sequence limit=10"
+	})).Return([]*fftypes.Event{}, nil, nil)
+	_, err := or.GetChartActivity(context.Background(), "ns1", 0)
+	assert.NoError(t, err)
+}
+
+func TestGetChartActivityBadLimit(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetChartActivity(context.Background(), "ns1", -1)
+	assert.Regexp(t, "FF10355", err)
+}
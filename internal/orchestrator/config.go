@@ -18,9 +18,17 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/secrets"
 	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -55,6 +63,139 @@ func (or *orchestrator) ResetConfig(ctx context.Context) {
 	}()
 }
 
+// configReloadSection is a section of the configuration that belongs to a plugin that can be re-initialized without a restart
+type configReloadSection struct {
+	key    string
+	values fftypes.JSONObject
+	reload func(ctx context.Context) error
+}
+
+// ReloadConfig applies a configuration update to selected plugins without a restart. The update is structured
+// in the same way as the config file, and only the "events.<transport>" and "dataexchange.<type>" sections can
+// be reloaded. Each section is merged into the running configuration and the plugin re-initialized - reverting
+// the section if the plugin rejects it. Once applied, the section is stored as a config record, so the change
+// is retained over a restart. Sections are applied in order, so a failure leaves any earlier sections applied.
+// Secret references (such as "vault://...") are resolved for the running configuration, but the record stores
+// the reference, so credentials should be supplied as references to keep them out of the database.
+func (or *orchestrator) ReloadConfig(ctx context.Context, update fftypes.JSONObject) (fftypes.JSONObject, error) {
+	sections, err := or.getConfigReloadSections(ctx, update)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range sections {
+		if err := or.reloadConfigSection(ctx, section); err != nil {
+			return nil, err
+		}
+	}
+	return config.GetConfig(), nil
+}
+
+// getConfigReloadSections validates the structure of the whole update, before any of it is applied
+func (or *orchestrator) getConfigReloadSections(ctx context.Context, update fftypes.JSONObject) ([]*configReloadSection, error) {
+	sections := []*configReloadSection{}
+	for _, root := range sortedKeys(update) {
+		plugins, ok := update[root].(map[string]interface{})
+		if !ok || (root != "events" && root != "dataexchange") {
+			return nil, i18n.NewError(ctx, i18n.MsgConfigNotReloadable, root)
+		}
+		for _, name := range sortedKeys(plugins) {
+			key := fmt.Sprintf("%s.%s", root, name)
+			values, ok := plugins[name].(map[string]interface{})
+			if !ok {
+				return nil, i18n.NewError(ctx, i18n.MsgConfigNotReloadable, key)
+			}
+			section := &configReloadSection{key: key, values: values}
+			if root == "events" {
+				transport := name
+				section.reload = func(ctx context.Context) error {
+					return or.events.ReloadTransportConfig(ctx, transport)
+				}
+			} else {
+				dx, ok := or.dataexchange.(dataexchange.ConfigReloader)
+				if !ok || name != or.dataexchange.Name() {
+					return nil, i18n.NewError(ctx, i18n.MsgConfigNotReloadable, key)
+				}
+				section.reload = func(ctx context.Context) error {
+					return dx.ReloadConfig(ctx, dataexchangeConfig.SubPrefix(name))
+				}
+			}
+			sections = append(sections, section)
+		}
+	}
+	return sections, nil
+}
+
+func (or *orchestrator) reloadConfigSection(ctx context.Context, section *configReloadSection) error {
+	// Any existing config record for the section is updated, rather than replaced
+	record, err := or.database.GetConfigRecord(ctx, section.key)
+	if err != nil {
+		return err
+	}
+	stored := fftypes.JSONObject{}
+	if record != nil {
+		_ = json.Unmarshal(record.Value, &stored)
+	}
+
+	previous, err := config.MergeConfigSection(ctx, section.key, section.values, func(key, value string) (string, bool, error) {
+		return secrets.Resolve(ctx, key, value)
+	})
+	if err != nil {
+		return err
+	}
+	if err = section.reload(ctx); err != nil {
+		config.RevertConfig(previous)
+		return err
+	}
+	log.L(ctx).Infof("Reloaded configuration section '%s'", section.key)
+
+	value, _ := json.Marshal(mergeConfigValues(stored, section.values))
+	return or.database.UpsertConfigRecord(ctx, &fftypes.ConfigRecord{
+		Key:   section.key,
+		Value: value,
+	}, true)
+}
+
+func mergeConfigValues(base, update map[string]interface{}) map[string]interface{} {
+	for k, v := range update {
+		updateChild, updateIsMap := v.(map[string]interface{})
+		baseChild, baseIsMap := base[k].(map[string]interface{})
+		if updateIsMap && baseIsMap {
+			v = mergeConfigValues(baseChild, updateChild)
+		}
+		base[k] = v
+	}
+	return base
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (or *orchestrator) DeleteConfigRecord(ctx context.Context, key string) (err error) {
 	return or.database.DeleteConfigRecord(ctx, key)
 }
+
+func (or *orchestrator) GetLogLevels(ctx context.Context) *log.Levels {
+	return log.GetLevels()
+}
+
+func (or *orchestrator) SetComponentLogLevel(ctx context.Context, component string, level *log.ComponentLevel) (*log.Levels, error) {
+	if err := log.SetComponentLevel(ctx, component, level.Level); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Log level for component '%s' set to '%s'", component, level.Level)
+	return log.GetLevels(), nil
+}
+
+// TailLogs streams the log lines that reference a transaction, message or operation ID, until the context is done
+func (or *orchestrator) TailLogs(ctx context.Context, correlationID string) (io.ReadCloser, error) {
+	if _, err := fftypes.ParseUUID(ctx, correlationID); err != nil {
+		return nil, err
+	}
+	return log.Tail(ctx, correlationID), nil
+}
@@ -0,0 +1,383 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// consistencyCheck validates the references between the messages, data, batches, pins and events in the database,
+// a page at a time. The only repair it makes is to restore messages and data that are missing from the database,
+// from a batch whose hash verifies - storing them exactly as they would have been stored when the batch arrived.
+type consistencyCheck struct {
+	ctx      context.Context
+	database database.Plugin
+	pageSize uint64
+	report   *fftypes.ConsistencyReport
+}
+
+func (or *orchestrator) CheckConsistency(ctx context.Context, input *fftypes.ConsistencyCheckInput) (*fftypes.ConsistencyReport, error) {
+	// Only one check runs at a time, but the last report remains available while it does
+	or.consistencyCheckMux.Lock()
+	defer or.consistencyCheckMux.Unlock()
+
+	cc := &consistencyCheck{
+		ctx:      ctx,
+		database: or.database,
+		pageSize: uint64(config.GetUint(config.ConsistencyPageSize)),
+		report: &fftypes.ConsistencyReport{
+			ID:      fftypes.NewUUID(),
+			Repair:  input.Repair,
+			Started: fftypes.Now(),
+			Issues:  []*fftypes.ConsistencyIssue{},
+		},
+	}
+	log.L(ctx).Infof("Starting consistency check %s (repair=%t)", cc.report.ID, cc.report.Repair)
+
+	// Batches are checked first, so any messages and data restored from them are then checked like any other
+	err := cc.checkBatches()
+	if err == nil {
+		err = cc.checkMessages()
+	}
+	if err == nil {
+		err = cc.checkPins()
+	}
+	if err == nil {
+		err = cc.checkEvents()
+	}
+	if err != nil {
+		return nil, err
+	}
+	cc.report.Completed = fftypes.Now()
+	log.L(ctx).Infof("Completed consistency check %s: batches=%d messages=%d pins=%d events=%d issues=%d", cc.report.ID,
+		cc.report.Checked.Batches, cc.report.Checked.Messages, cc.report.Checked.Pins, cc.report.Checked.Events, len(cc.report.Issues))
+
+	or.consistencyMux.Lock()
+	or.consistencyReport = cc.report
+	or.consistencyMux.Unlock()
+	return cc.report, nil
+}
+
+func (or *orchestrator) GetConsistencyReport(ctx context.Context) (*fftypes.ConsistencyReport, error) {
+	or.consistencyMux.Lock()
+	defer or.consistencyMux.Unlock()
+	if or.consistencyReport == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return or.consistencyReport, nil
+}
+
+func (cc *consistencyCheck) addIssue(issueType fftypes.ConsistencyIssueType, collection, ns, id string, ref *fftypes.UUID) *fftypes.ConsistencyIssue {
+	log.L(cc.ctx).Warnf("Consistency check %s found %s on %s '%s' (reference=%s)", cc.report.ID, issueType, collection, id, ref)
+	issue := &fftypes.ConsistencyIssue{
+		Type:       issueType,
+		Collection: collection,
+		Namespace:  ns,
+		ID:         id,
+		Reference:  ref,
+	}
+	cc.report.Issues = append(cc.report.Issues, issue)
+	return issue
+}
+
+func (cc *consistencyCheck) checkBatches() error {
+	for skip := uint64(0); ; skip += cc.pageSize {
+		fb := database.BatchQueryFactory.NewFilter(cc.ctx)
+		batches, _, err := cc.database.GetBatches(cc.ctx, fb.And().Skip(skip).Limit(cc.pageSize))
+		if err != nil {
+			return err
+		}
+		for _, batch := range batches {
+			if err := cc.checkBatch(batch); err != nil {
+				return err
+			}
+		}
+		cc.report.Checked.Batches += int64(len(batches))
+		if uint64(len(batches)) < cc.pageSize {
+			return nil
+		}
+	}
+}
+
+func (cc *consistencyCheck) checkBatch(batch *fftypes.Batch) error {
+	hash, err := batch.CalcHash(cc.ctx)
+	if err != nil || !hash.Equals(batch.Hash) {
+		// Nothing in the payload can be trusted, so we do not check (or restore) the contents
+		cc.addIssue(fftypes.ConsistencyIssueBatchHashMismatch, string(database.CollectionBatches), batch.Namespace, batch.ID.String(), nil)
+		return nil
+	}
+
+	dataIDs := make([]driver.Value, 0, len(batch.Payload.Data))
+	for _, data := range batch.Payload.Data {
+		if data != nil {
+			dataIDs = append(dataIDs, data.ID)
+		}
+	}
+	dataHashes, err := cc.dataHashes(dataIDs)
+	if err != nil {
+		return err
+	}
+	for _, data := range batch.Payload.Data {
+		if data == nil {
+			continue
+		}
+		existing, ok := dataHashes[*data.ID]
+		switch {
+		case !ok:
+			issue := cc.addIssue(fftypes.ConsistencyIssueDataMissing, string(database.CollectionBatches), batch.Namespace, batch.ID.String(), data.ID)
+			if err := cc.restoreData(batch, data, issue); err != nil {
+				return err
+			}
+		case !existing.Equals(data.Hash):
+			cc.addIssue(fftypes.ConsistencyIssueDataHashMismatch, string(database.CollectionBatches), batch.Namespace, batch.ID.String(), data.ID)
+		}
+	}
+
+	msgIDs := make([]driver.Value, 0, len(batch.Payload.Messages))
+	for _, msg := range batch.Payload.Messages {
+		if msg != nil {
+			msgIDs = append(msgIDs, msg.Header.ID)
+		}
+	}
+	msgHashes, err := cc.messageHashes(msgIDs)
+	if err != nil {
+		return err
+	}
+	for _, msg := range batch.Payload.Messages {
+		if msg == nil {
+			continue
+		}
+		existing, ok := msgHashes[*msg.Header.ID]
+		switch {
+		case !ok:
+			issue := cc.addIssue(fftypes.ConsistencyIssueMessageMissing, string(database.CollectionBatches), batch.Namespace, batch.ID.String(), msg.Header.ID)
+			if err := cc.restoreMessage(batch, msg, issue); err != nil {
+				return err
+			}
+		case !existing.Equals(msg.Hash):
+			cc.addIssue(fftypes.ConsistencyIssueMessageHashMismatch, string(database.CollectionBatches), batch.Namespace, batch.ID.String(), msg.Header.ID)
+		}
+	}
+	return nil
+}
+
+func (cc *consistencyCheck) restoreData(batch *fftypes.Batch, data *fftypes.Data, issue *fftypes.ConsistencyIssue) error {
+	if !cc.report.Repair {
+		return nil
+	}
+	// The batch hash only covers the hash of each data, so the value must be verified separately
+	hash, err := data.CalcHash(cc.ctx)
+	if err != nil || !hash.Equals(data.Hash) {
+		log.L(cc.ctx).Warnf("Unable to restore data '%s' from batch '%s', as the hash does not match the value", data.ID, batch.ID)
+		return nil
+	}
+	if err := cc.database.UpsertData(cc.ctx, data, database.UpsertOptimizationNew); err != nil {
+		return err
+	}
+	issue.Repaired = true
+	return nil
+}
+
+func (cc *consistencyCheck) restoreMessage(batch *fftypes.Batch, msg *fftypes.Message, issue *fftypes.ConsistencyIssue) error {
+	if !cc.report.Repair {
+		return nil
+	}
+	if err := msg.Verify(cc.ctx); err != nil {
+		log.L(cc.ctx).Warnf("Unable to restore message '%s' from batch '%s': %s", msg.Header.ID, batch.ID, err)
+		return nil
+	}
+	// As on receipt of the batch, confirmation is left to the aggregator (or a replay of the blockchain events)
+	msg.State = fftypes.MessageStatePending
+	if err := cc.database.UpsertMessage(cc.ctx, msg, database.UpsertOptimizationNew); err != nil {
+		return err
+	}
+	if err := cc.database.InsertMessageTransition(cc.ctx, fftypes.NewMessageTransition(msg, fmt.Sprintf("restored from batch %s by consistency check %s", batch.ID, cc.report.ID))); err != nil {
+		return err
+	}
+	issue.Repaired = true
+	return nil
+}
+
+func (cc *consistencyCheck) checkMessages() error {
+	for skip := uint64(0); ; skip += cc.pageSize {
+		fb := database.MessageQueryFactory.NewFilter(cc.ctx)
+		msgs, _, err := cc.database.GetMessages(cc.ctx, fb.And().Skip(skip).Limit(cc.pageSize))
+		if err != nil {
+			return err
+		}
+		if err := cc.checkMessagePage(msgs); err != nil {
+			return err
+		}
+		cc.report.Checked.Messages += int64(len(msgs))
+		if uint64(len(msgs)) < cc.pageSize {
+			return nil
+		}
+	}
+}
+
+func (cc *consistencyCheck) checkMessagePage(msgs []*fftypes.Message) error {
+	dataIDs := make([]driver.Value, 0, len(msgs))
+	batchIDs := make([]driver.Value, 0, len(msgs))
+	for _, msg := range msgs {
+		if !msg.Header.Hash().Equals(msg.Hash) || !msg.Data.Hash().Equals(msg.Header.DataHash) {
+			cc.addIssue(fftypes.ConsistencyIssueMessageHashMismatch, string(database.CollectionMessages), msg.Header.Namespace, msg.Header.ID.String(), nil)
+		}
+		for _, ref := range msg.Data {
+			dataIDs = append(dataIDs, ref.ID)
+		}
+		if msg.BatchID != nil {
+			batchIDs = append(batchIDs, msg.BatchID)
+		}
+	}
+	dataHashes, err := cc.dataHashes(dataIDs)
+	if err != nil {
+		return err
+	}
+	batches, err := cc.batchesExist(batchIDs)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		for _, ref := range msg.Data {
+			existing, ok := dataHashes[*ref.ID]
+			switch {
+			case !ok:
+				cc.addIssue(fftypes.ConsistencyIssueDataMissing, string(database.CollectionMessages), msg.Header.Namespace, msg.Header.ID.String(), ref.ID)
+			case !existing.Equals(ref.Hash):
+				cc.addIssue(fftypes.ConsistencyIssueDataHashMismatch, string(database.CollectionMessages), msg.Header.Namespace, msg.Header.ID.String(), ref.ID)
+			}
+		}
+		if msg.BatchID != nil && !batches[*msg.BatchID] {
+			cc.addIssue(fftypes.ConsistencyIssueBatchMissing, string(database.CollectionMessages), msg.Header.Namespace, msg.Header.ID.String(), msg.BatchID)
+		}
+	}
+	return nil
+}
+
+func (cc *consistencyCheck) checkPins() error {
+	for skip := uint64(0); ; skip += cc.pageSize {
+		// Until a pin is dispatched, it is normal for the batch to not have arrived yet
+		fb := database.PinQueryFactory.NewFilter(cc.ctx)
+		pins, _, err := cc.database.GetPins(cc.ctx, fb.And(fb.Eq("dispatched", true)).Skip(skip).Limit(cc.pageSize))
+		if err != nil {
+			return err
+		}
+		batchIDs := make([]driver.Value, 0, len(pins))
+		for _, pin := range pins {
+			batchIDs = append(batchIDs, pin.Batch)
+		}
+		batches, err := cc.batchesExist(batchIDs)
+		if err != nil {
+			return err
+		}
+		for _, pin := range pins {
+			if !batches[*pin.Batch] {
+				cc.addIssue(fftypes.ConsistencyIssueBatchMissing, string(database.CollectionPins), "", strconv.FormatInt(pin.Sequence, 10), pin.Batch)
+			}
+		}
+		cc.report.Checked.Pins += int64(len(pins))
+		if uint64(len(pins)) < cc.pageSize {
+			return nil
+		}
+	}
+}
+
+func (cc *consistencyCheck) checkEvents() error {
+	for skip := uint64(0); ; skip += cc.pageSize {
+		fb := database.EventQueryFactory.NewFilter(cc.ctx)
+		events, _, err := cc.database.GetEvents(cc.ctx, fb.And(
+			fb.In("type", []driver.Value{fftypes.EventTypeMessageConfirmed, fftypes.EventTypeMessageRejected}),
+		).Skip(skip).Limit(cc.pageSize))
+		if err != nil {
+			return err
+		}
+		msgIDs := make([]driver.Value, 0, len(events))
+		for _, event := range events {
+			msgIDs = append(msgIDs, event.Reference)
+		}
+		msgHashes, err := cc.messageHashes(msgIDs)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if _, ok := msgHashes[*event.Reference]; !ok {
+				cc.addIssue(fftypes.ConsistencyIssueMessageMissing, string(database.CollectionEvents), event.Namespace, event.ID.String(), event.Reference)
+			}
+		}
+		cc.report.Checked.Events += int64(len(events))
+		if uint64(len(events)) < cc.pageSize {
+			return nil
+		}
+	}
+}
+
+func (cc *consistencyCheck) messageHashes(ids []driver.Value) (map[fftypes.UUID]*fftypes.Bytes32, error) {
+	hashes := make(map[fftypes.UUID]*fftypes.Bytes32, len(ids))
+	if len(ids) == 0 {
+		return hashes, nil
+	}
+	fb := database.MessageQueryFactory.NewFilter(cc.ctx)
+	msgs, _, err := cc.database.GetMessages(cc.ctx, fb.In("id", ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		hashes[*msg.Header.ID] = msg.Hash
+	}
+	return hashes, nil
+}
+
+func (cc *consistencyCheck) dataHashes(ids []driver.Value) (map[fftypes.UUID]*fftypes.Bytes32, error) {
+	hashes := make(map[fftypes.UUID]*fftypes.Bytes32, len(ids))
+	if len(ids) == 0 {
+		return hashes, nil
+	}
+	fb := database.DataQueryFactory.NewFilter(cc.ctx)
+	refs, _, err := cc.database.GetDataRefs(cc.ctx, fb.In("id", ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		hashes[*ref.ID] = ref.Hash
+	}
+	return hashes, nil
+}
+
+func (cc *consistencyCheck) batchesExist(ids []driver.Value) (map[fftypes.UUID]bool, error) {
+	exists := make(map[fftypes.UUID]bool, len(ids))
+	if len(ids) == 0 {
+		return exists, nil
+	}
+	fb := database.BatchQueryFactory.NewFilter(cc.ctx)
+	batches, _, err := cc.database.GetBatches(cc.ctx, fb.In("id", ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		exists[*batch.ID] = true
+	}
+	return exists, nil
+}
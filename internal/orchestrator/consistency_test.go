@@ -0,0 +1,369 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// pageFilter matches the paged queries of each collection, rather than the lookups by ID
+func pageFilter(paged bool) interface{} {
+	return mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return strings.Contains(fi.String(), "limit=") == paged
+	})
+}
+
+func newTestData(t *testing.T, value string) *fftypes.Data {
+	data := &fftypes.Data{Namespace: "ns1", Value: fftypes.Byteable(value)}
+	err := data.Seal(context.Background())
+	assert.NoError(t, err)
+	return data
+}
+
+func newTestMessage(t *testing.T, data ...*fftypes.Data) *fftypes.Message {
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Namespace: "ns1"}}
+	for _, d := range data {
+		msg.Data = append(msg.Data, &fftypes.DataRef{ID: d.ID, Hash: d.Hash})
+	}
+	err := msg.Seal(context.Background())
+	assert.NoError(t, err)
+	return msg
+}
+
+func newTestBatch(t *testing.T, msgs []*fftypes.Message, data []*fftypes.Data) *fftypes.Batch {
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Payload:   fftypes.BatchPayload{Messages: msgs, Data: data},
+	}
+	batch.Hash, _ = batch.CalcHash(context.Background())
+	return batch
+}
+
+func mockEmptyConsistencyCheck(or *testOrchestrator) {
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{}, nil, nil)
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return([]*fftypes.Event{}, nil, nil)
+}
+
+func issuesOfType(report *fftypes.ConsistencyReport, issueType fftypes.ConsistencyIssueType) []*fftypes.ConsistencyIssue {
+	issues := []*fftypes.ConsistencyIssue{}
+	for _, issue := range report.Issues {
+		if issue.Type == issueType {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func TestCheckConsistencyEmpty(t *testing.T) {
+	or := newTestOrchestrator()
+	mockEmptyConsistencyCheck(or)
+
+	_, err := or.GetConsistencyReport(or.ctx)
+	assert.Regexp(t, "FF10109", err)
+
+	report, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.NotNil(t, report.Completed)
+
+	last, err := or.GetConsistencyReport(or.ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, report, last)
+	or.mdi.AssertExpectations(t)
+}
+
+func TestCheckConsistencyRepair(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.ConsistencyPageSize, 2)
+
+	// Batch contents - d1/m1 missing and restored, d2/m2 with different hashes, d3/m3 missing and invalid
+	d1 := newTestData(t, `"d1"`)
+	d2 := newTestData(t, `"d2"`)
+	d3 := newTestData(t, `"d3"`)
+	d3.Value = fftypes.Byteable(`"tampered"`)
+	m1 := newTestMessage(t, d1)
+	m2 := newTestMessage(t, d2)
+	m3 := newTestMessage(t, d3)
+	m3.Header.Topics = fftypes.FFNameArray{"!invalid"}
+	b1 := newTestBatch(t, []*fftypes.Message{m1, m2, m3, nil}, []*fftypes.Data{d1, d2, d3, nil})
+	b2 := newTestBatch(t, []*fftypes.Message{}, []*fftypes.Data{})
+	b2.Hash = fftypes.NewRandB32()
+	b3 := newTestBatch(t, []*fftypes.Message{}, []*fftypes.Data{})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1, b2}, nil, nil).Once()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b3}, nil, nil).Once()
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(fftypes.DataRefs{
+		{ID: d2.ID, Hash: fftypes.NewRandB32()},
+	}, nil, nil).Once()
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: m2.Header.ID}, Hash: fftypes.NewRandB32()},
+	}, nil, nil).Once()
+	or.mdi.On("UpsertData", mock.Anything, d1, database.UpsertOptimizationNew).Return(nil)
+	or.mdi.On("UpsertMessage", mock.Anything, m1, database.UpsertOptimizationNew).Return(nil)
+	or.mdi.On("InsertMessageTransition", mock.Anything, mock.MatchedBy(func(mt *fftypes.MessageTransition) bool {
+		return mt.Message.Equals(m1.Header.ID)
+	})).Return(nil)
+
+	// Messages - m4 with a bad hash, m5 referring to missing/mismatched data and a missing batch
+	m4 := newTestMessage(t)
+	m4.Header.Tag = "changed"
+	d4 := newTestData(t, `"d4"`)
+	m5 := newTestMessage(t, d4, d1)
+	m5.BatchID = fftypes.NewUUID()
+	m6 := newTestMessage(t, d1)
+	m6.BatchID = b1.ID
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{m4, m5}, nil, nil).Once()
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{m6}, nil, nil).Once()
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(fftypes.DataRefs{
+		{ID: d1.ID, Hash: fftypes.NewRandB32()},
+	}, nil, nil).Once()
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(fftypes.DataRefs{
+		{ID: d1.ID, Hash: d1.Hash},
+	}, nil, nil).Once()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(false)).Return([]*fftypes.Batch{}, nil, nil).Once()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(false)).Return([]*fftypes.Batch{b1}, nil, nil).Once()
+
+	// Pins - one referring to a missing batch
+	missingBatch := fftypes.NewUUID()
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{
+		{Sequence: 10, Batch: b1.ID},
+		{Sequence: 11, Batch: missingBatch},
+	}, nil, nil).Once()
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{}, nil, nil).Once()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(false)).Return([]*fftypes.Batch{b1}, nil, nil).Once()
+
+	// Events - one referring to a missing message
+	e1 := &fftypes.Event{ID: fftypes.NewUUID(), Namespace: "ns1", Reference: m1.Header.ID}
+	e2 := &fftypes.Event{ID: fftypes.NewUUID(), Namespace: "ns1", Reference: fftypes.NewUUID()}
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return([]*fftypes.Event{e1, e2}, nil, nil).Once()
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return([]*fftypes.Event{}, nil, nil).Once()
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return([]*fftypes.Message{m1}, nil, nil).Once()
+
+	report, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{Repair: true})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.ConsistencyCheckCounts{Batches: 3, Messages: 3, Pins: 2, Events: 2}, report.Checked)
+	assert.Equal(t, fftypes.MessageStatePending, m1.State)
+
+	batchHash := issuesOfType(report, fftypes.ConsistencyIssueBatchHashMismatch)
+	assert.Len(t, batchHash, 1)
+	assert.Equal(t, b2.ID.String(), batchHash[0].ID)
+
+	dataMissing := issuesOfType(report, fftypes.ConsistencyIssueDataMissing)
+	assert.Len(t, dataMissing, 3)
+	assert.Equal(t, d1.ID, dataMissing[0].Reference)
+	assert.True(t, dataMissing[0].Repaired)
+	assert.Equal(t, d3.ID, dataMissing[1].Reference)
+	assert.False(t, dataMissing[1].Repaired)
+	assert.Equal(t, "messages", dataMissing[2].Collection)
+	assert.Equal(t, d4.ID, dataMissing[2].Reference)
+
+	dataHash := issuesOfType(report, fftypes.ConsistencyIssueDataHashMismatch)
+	assert.Len(t, dataHash, 2)
+	assert.Equal(t, d2.ID, dataHash[0].Reference)
+	assert.Equal(t, m5.Header.ID.String(), dataHash[1].ID)
+
+	msgMissing := issuesOfType(report, fftypes.ConsistencyIssueMessageMissing)
+	assert.Len(t, msgMissing, 3)
+	assert.Equal(t, m1.Header.ID, msgMissing[0].Reference)
+	assert.True(t, msgMissing[0].Repaired)
+	assert.Equal(t, m3.Header.ID, msgMissing[1].Reference)
+	assert.False(t, msgMissing[1].Repaired)
+	assert.Equal(t, "events", msgMissing[2].Collection)
+	assert.Equal(t, e2.ID.String(), msgMissing[2].ID)
+
+	msgHash := issuesOfType(report, fftypes.ConsistencyIssueMessageHashMismatch)
+	assert.Len(t, msgHash, 2)
+	assert.Equal(t, m2.Header.ID, msgHash[0].Reference)
+	assert.Equal(t, m4.Header.ID.String(), msgHash[1].ID)
+
+	batchMissing := issuesOfType(report, fftypes.ConsistencyIssueBatchMissing)
+	assert.Len(t, batchMissing, 2)
+	assert.Equal(t, m5.BatchID, batchMissing[0].Reference)
+	assert.Equal(t, "pins", batchMissing[1].Collection)
+	assert.Equal(t, "11", batchMissing[1].ID)
+
+	or.mdi.AssertExpectations(t)
+}
+
+func TestCheckConsistencyNoRepair(t *testing.T) {
+	or := newTestOrchestrator()
+	d1 := newTestData(t, `"d1"`)
+	m1 := newTestMessage(t, d1)
+	b1 := newTestBatch(t, []*fftypes.Message{m1}, []*fftypes.Data{d1})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{}, nil, nil)
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return([]*fftypes.Event{}, nil, nil)
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(fftypes.DataRefs{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return([]*fftypes.Message{}, nil, nil)
+
+	report, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.NoError(t, err)
+	assert.Len(t, report.Issues, 2)
+	assert.False(t, report.Issues[0].Repaired)
+	assert.False(t, report.Issues[1].Repaired)
+	or.mdi.AssertExpectations(t)
+}
+
+func TestCheckConsistencyGetBatchesFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyBatchDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	b1 := newTestBatch(t, []*fftypes.Message{}, []*fftypes.Data{newTestData(t, `"d1"`)})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyRestoreDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	b1 := newTestBatch(t, []*fftypes.Message{}, []*fftypes.Data{newTestData(t, `"d1"`)})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(fftypes.DataRefs{}, nil, nil)
+	or.mdi.On("UpsertData", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{Repair: true})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyBatchMessagesFail(t *testing.T) {
+	or := newTestOrchestrator()
+	b1 := newTestBatch(t, []*fftypes.Message{newTestMessage(t)}, []*fftypes.Data{})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyRestoreMessageFail(t *testing.T) {
+	or := newTestOrchestrator()
+	b1 := newTestBatch(t, []*fftypes.Message{newTestMessage(t)}, []*fftypes.Data{})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{Repair: true})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyRestoreMessageTransitionFail(t *testing.T) {
+	or := newTestOrchestrator()
+	b1 := newTestBatch(t, []*fftypes.Message{newTestMessage(t)}, []*fftypes.Data{})
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{b1}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("UpsertMessage", mock.Anything, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	or.mdi.On("InsertMessageTransition", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{Repair: true})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyGetMessagesFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyMessageDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{newTestMessage(t, newTestData(t, `"d1"`))}, nil, nil)
+	or.mdi.On("GetDataRefs", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyMessageBatchFail(t *testing.T) {
+	or := newTestOrchestrator()
+	msg := newTestMessage(t)
+	msg.BatchID = fftypes.NewUUID()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{msg}, nil, nil)
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyGetPinsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyPinBatchFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{{Batch: fftypes.NewUUID()}}, nil, nil)
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyGetEventsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{}, nil, nil)
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckConsistencyEventMessagesFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetBatches", mock.Anything, pageFilter(true)).Return([]*fftypes.Batch{}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(true)).Return([]*fftypes.Message{}, nil, nil)
+	or.mdi.On("GetPins", mock.Anything, pageFilter(true)).Return([]*fftypes.Pin{}, nil, nil)
+	or.mdi.On("GetEvents", mock.Anything, pageFilter(true)).Return([]*fftypes.Event{{Reference: fftypes.NewUUID()}}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, pageFilter(false)).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{})
+	assert.EqualError(t, err, "pop")
+}
@@ -21,10 +21,19 @@ import (
 	"database/sql/driver"
 
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
+// redactOperations applies the configured redaction rules to operations being returned on the API
+func redactOperations(ops []*fftypes.Operation, fr *database.FilterResult, err error) ([]*fftypes.Operation, *database.FilterResult, error) {
+	for _, op := range ops {
+		txcommon.RedactOperation(op)
+	}
+	return ops, fr, err
+}
+
 func (or *orchestrator) verifyNamespaceSyntax(ctx context.Context, ns string) error {
 	return fftypes.ValidateFFNameField(ctx, ns, "namespace")
 }
@@ -60,7 +69,7 @@ func (or *orchestrator) GetTransactionOperations(ctx context.Context, ns, id str
 		fb.Eq("tx", u),
 		fb.Eq("namespace", ns),
 	)
-	return or.database.GetOperations(ctx, filter)
+	return redactOperations(or.database.GetOperations(ctx, filter))
 }
 
 func (or *orchestrator) getMessageByID(ctx context.Context, ns, id string) (*fftypes.Message, error) {
@@ -108,6 +117,22 @@ func (or *orchestrator) GetBatchByID(ctx context.Context, ns, id string) (*fftyp
 	return or.database.GetBatchByID(ctx, u)
 }
 
+// GetBatchManifest returns the manifest of a batch, deriving it from the payload for any batch
+// that was stored before manifests were recorded
+func (or *orchestrator) GetBatchManifest(ctx context.Context, ns, id string) (*fftypes.BatchManifest, error) {
+	batch, err := or.GetBatchByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil || batch.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if batch.Manifest == nil {
+		return batch.Payload.Manifest(), nil
+	}
+	return batch.Manifest, nil
+}
+
 func (or *orchestrator) GetDataByID(ctx context.Context, ns, id string) (*fftypes.Data, error) {
 	u, err := or.verifyIDAndNamespace(ctx, ns, id)
 	if err != nil {
@@ -139,7 +164,11 @@ func (or *orchestrator) GetOperationByID(ctx context.Context, ns, id string) (*f
 	if err != nil {
 		return nil, err
 	}
-	return or.database.GetOperationByID(ctx, u)
+	op, err := or.database.GetOperationByID(ctx, u)
+	if err != nil || op == nil {
+		return nil, err
+	}
+	return txcommon.RedactOperation(op), nil
 }
 
 func (or *orchestrator) GetEventByID(ctx context.Context, ns, id string) (*fftypes.Event, error) {
@@ -150,6 +179,14 @@ func (or *orchestrator) GetEventByID(ctx context.Context, ns, id string) (*fftyp
 	return or.database.GetEventByID(ctx, u)
 }
 
+func (or *orchestrator) GetBlockchainEventByID(ctx context.Context, ns, id string) (*fftypes.BlockchainEvent, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	return or.database.GetBlockchainEventByID(ctx, u)
+}
+
 func (or *orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	return or.database.GetNamespaces(ctx, filter)
 }
@@ -174,13 +211,21 @@ func (or *orchestrator) GetMessagesWithData(ctx context.Context, ns string, filt
 	if err != nil {
 		return nil, nil, err
 	}
-	msgsData := make([]*fftypes.MessageInOut, len(msgs))
+	msgsData, err := or.fetchMessagesData(ctx, msgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msgsData, fr, err
+}
+
+func (or *orchestrator) fetchMessagesData(ctx context.Context, msgs []*fftypes.Message) (msgsData []*fftypes.MessageInOut, err error) {
+	msgsData = make([]*fftypes.MessageInOut, len(msgs))
 	for i, msg := range msgs {
 		if msgsData[i], err = or.fetchMessageData(ctx, msg); err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
-	return msgsData, fr, err
+	return msgsData, nil
 }
 
 func (or *orchestrator) GetMessageData(ctx context.Context, ns, id string) ([]*fftypes.Data, error) {
@@ -233,7 +278,7 @@ func (or *orchestrator) GetMessageOperations(ctx context.Context, ns, id string)
 		return nil, nil, err
 	}
 	filter := database.OperationQueryFactory.NewFilter(ctx).Eq("tx", txID)
-	return or.database.GetOperations(ctx, filter)
+	return redactOperations(or.database.GetOperations(ctx, filter))
 }
 
 func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
@@ -253,6 +298,66 @@ func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, fil
 	return or.database.GetEvents(ctx, filter)
 }
 
+func (or *orchestrator) GetMessageReceipts(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.MessageReceipt, *database.FilterResult, error) {
+	msg, err := or.getMessageByID(ctx, ns, id)
+	if err != nil || msg == nil {
+		return nil, nil, err
+	}
+	filter = filter.Condition(filter.Builder().Eq("message", msg.Header.ID))
+	return or.database.GetReceipts(ctx, filter)
+}
+
+// GetMessageHistory returns the state transitions of a message. This does not require the message itself
+// to be stored, so the history of a message held for approval (and perhaps rejected) is also available.
+func (or *orchestrator) GetMessageHistory(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.MessageTransition, *database.FilterResult, error) {
+	msgID, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter = filter.Condition(filter.Builder().Eq("namespace", ns)).Condition(filter.Builder().Eq("message", msgID))
+	return or.database.GetMessageTransitions(ctx, filter)
+}
+
+// GetMessageTopics returns the confirmation state of a message on each of its topics. A multi-topic message
+// that is pending overall, might already be confirmed in the ordering context of some of its topics
+func (or *orchestrator) GetMessageTopics(ctx context.Context, ns, id string) ([]*fftypes.MessageTopicStatus, error) {
+	msg, err := or.getMessageByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	fb := database.EventQueryFactory.NewFilter(ctx)
+	events, _, err := or.database.GetEvents(ctx, fb.And(
+		fb.Eq("type", fftypes.EventTypeMessageTopicConfirmed),
+		fb.Eq("reference", msg.Header.ID),
+	))
+	if err != nil {
+		return nil, err
+	}
+	// Once the message has been aggregated, it has been confirmed on all topics
+	aggregated := msg.State == fftypes.MessageStateConfirmed ||
+		msg.State == fftypes.MessageStateRejected ||
+		msg.State == fftypes.MessageStateAwaitingQuorum
+	statuses := make([]*fftypes.MessageTopicStatus, len(msg.Header.Topics))
+	for i, topic := range msg.Header.Topics {
+		status := &fftypes.MessageTopicStatus{
+			Topic: topic,
+			State: fftypes.MessageTopicStatePending,
+		}
+		for _, event := range events {
+			if event.Topic == topic {
+				status.State = fftypes.MessageTopicStateConfirmed
+				status.Event = event.ID
+				break
+			}
+		}
+		if aggregated {
+			status.State = fftypes.MessageTopicStateConfirmed
+		}
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
 func (or *orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetBatches(ctx, filter)
@@ -279,10 +384,15 @@ func (or *orchestrator) GetDatatypes(ctx context.Context, ns string, filter data
 
 func (or *orchestrator) GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
-	return or.database.GetOperations(ctx, filter)
+	return redactOperations(or.database.GetOperations(ctx, filter))
 }
 
 func (or *orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetEvents(ctx, filter)
 }
+
+func (or *orchestrator) GetBlockchainEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.BlockchainEvent, *database.FilterResult, error) {
+	filter = or.scopeNS(ns, filter)
+	return or.database.GetBlockchainEvents(ctx, filter)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -433,6 +434,117 @@ func TestGetMessageEventsBadMsgID(t *testing.T) {
 	assert.Nil(t, ev)
 }
 
+func TestGetMessageReceiptsOk(t *testing.T) {
+	or := newTestOrchestrator()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(msg, nil)
+	or.mdi.On("GetReceipts", mock.Anything, mock.Anything).Return([]*fftypes.MessageReceipt{}, nil, nil)
+	fb := database.ReceiptQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("author", "org2"))
+	_, _, err := or.GetMessageReceipts(context.Background(), "ns1", fftypes.NewUUID().String(), f)
+	assert.NoError(t, err)
+	calculatedFilter, err := or.mdi.Calls[1].Arguments[1].(database.Filter).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(`( author == 'org2' ) && ( message == '%s' )`, msg.Header.ID), calculatedFilter.String())
+}
+
+func TestGetMessageReceiptsBadMsgID(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.ReceiptQueryFactory.NewFilter(context.Background())
+	f := fb.And()
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, nil)
+	receipts, _, err := or.GetMessageReceipts(context.Background(), "ns1", fftypes.NewUUID().String(), f)
+	assert.Regexp(t, "FF10109", err)
+	assert.Nil(t, receipts)
+}
+
+func TestGetMessageHistoryOk(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	or.mdi.On("GetMessageTransitions", mock.Anything, mock.Anything).Return([]*fftypes.MessageTransition{}, nil, nil)
+	fb := database.MessageTransitionQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("state", fftypes.MessageStateSent))
+	_, _, err := or.GetMessageHistory(context.Background(), "ns1", msgID.String(), f)
+	assert.NoError(t, err)
+	calculatedFilter, err := or.mdi.Calls[0].Arguments[1].(database.Filter).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(`( state == 'sent' ) && ( namespace == 'ns1' ) && ( message == '%s' )`, msgID), calculatedFilter.String())
+}
+
+func TestGetMessageHistoryBadMsgID(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.MessageTransitionQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessageHistory(context.Background(), "ns1", "!bad", fb.And())
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetMessageTopicsPartiallyConfirmed(t *testing.T) {
+	or := newTestOrchestrator()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+		State: fftypes.MessageStatePending,
+	}
+	eventID := fftypes.NewUUID()
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(msg, nil)
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{ID: eventID, Type: fftypes.EventTypeMessageTopicConfirmed, Reference: msg.Header.ID, Topic: "topic1"},
+	}, nil, nil)
+	statuses, err := or.GetMessageTopics(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.MessageTopicStatus{
+		{Topic: "topic1", State: fftypes.MessageTopicStateConfirmed, Event: eventID},
+		{Topic: "topic2", State: fftypes.MessageTopicStatePending},
+	}, statuses)
+	calculatedFilter, err := or.mdi.Calls[1].Arguments[1].(database.Filter).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(`( type == 'message_topic_confirmed' ) && ( reference == '%s' )`, msg.Header.ID), calculatedFilter.String())
+}
+
+func TestGetMessageTopicsConfirmed(t *testing.T) {
+	or := newTestOrchestrator()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Topics: fftypes.FFNameArray{"topic1"},
+		},
+		State: fftypes.MessageStateConfirmed,
+	}
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(msg, nil)
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+	statuses, err := or.GetMessageTopics(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.MessageTopicStatus{
+		{Topic: "topic1", State: fftypes.MessageTopicStateConfirmed},
+	}, statuses)
+}
+
+func TestGetMessageTopicsBadMsgID(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err := or.GetMessageTopics(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetMessageTopicsGetEventsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	}
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(msg, nil)
+	or.mdi.On("GetEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, err := or.GetMessageTopics(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestGetBatchByID(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -447,6 +559,58 @@ func TestGetBatchByIDBadID(t *testing.T) {
 	assert.Regexp(t, "FF10142", err)
 }
 
+func TestGetBatchManifest(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	manifest := &fftypes.BatchManifest{
+		Messages: []*fftypes.MessageRef{{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()}},
+	}
+	or.mdi.On("GetBatchByID", mock.Anything, u).Return(&fftypes.Batch{
+		ID:        u,
+		Namespace: "ns1",
+		Manifest:  manifest,
+	}, nil)
+	res, err := or.GetBatchManifest(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, res)
+}
+
+func TestGetBatchManifestFromPayload(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}, Hash: fftypes.NewRandB32()}
+	data := &fftypes.Data{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()}
+	or.mdi.On("GetBatchByID", mock.Anything, u).Return(&fftypes.Batch{
+		ID:        u,
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{msg},
+			Data:     []*fftypes.Data{data},
+		},
+	}, nil)
+	res, err := or.GetBatchManifest(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, msg.Hash, res.Messages[0].Hash)
+	assert.Equal(t, data.Hash, res.Data[0].Hash)
+}
+
+func TestGetBatchManifestNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetBatchByID", mock.Anything, u).Return(&fftypes.Batch{
+		ID:        u,
+		Namespace: "ns2",
+	}, nil)
+	_, err := or.GetBatchManifest(context.Background(), "ns1", u.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetBatchManifestBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetBatchManifest(context.Background(), "", "")
+	assert.Regexp(t, "FF10142", err)
+}
+
 func TestGetBatches(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -522,6 +686,21 @@ func TestGetOperationByID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetOperationByIDRedacted(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.OperationRedactOutput, []string{"token_transfer:receipt.headers"})
+	defer config.Reset()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetOperationByID", mock.Anything, u).Return(&fftypes.Operation{
+		ID:     u,
+		Type:   fftypes.OpTypeTokenTransfer,
+		Output: fftypes.JSONObject{"receipt": map[string]interface{}{"headers": "secret", "status": 200}},
+	}, nil)
+	op, err := or.GetOperationByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"receipt":{"headers":"[redacted]","status":200}}`, op.Output.String())
+}
+
 func TestGetOperationIDBadID(t *testing.T) {
 	or := newTestOrchestrator()
 	_, err := or.GetOperationByID(context.Background(), "", "")
@@ -542,6 +721,20 @@ func TestGetEventIDBadID(t *testing.T) {
 	assert.Regexp(t, "FF10142", err)
 }
 
+func TestGetBlockchainEventByID(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetBlockchainEventByID", mock.Anything, u).Return(nil, nil)
+	_, err := or.GetBlockchainEventByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+}
+
+func TestGetBlockchainEventByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetBlockchainEventByID(context.Background(), "", "")
+	assert.Regexp(t, "FF10142", err)
+}
+
 func TestGetDatatypes(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -562,6 +755,19 @@ func TestGetOperations(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetOperationsRedacted(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.OperationRedactInput, []string{"config.key"})
+	defer config.Reset()
+	or.mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{Type: fftypes.OpTypeTokenCreatePool, Input: fftypes.JSONObject{"config": fftypes.JSONObject{"key": "secret"}}},
+	}, nil, nil)
+	fb := database.OperationQueryFactory.NewFilter(context.Background())
+	ops, _, err := or.GetOperations(context.Background(), "ns1", fb.And())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"config":{"key":"[redacted]"}}`, ops[0].Input.String())
+}
+
 func TestGetEvents(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -571,3 +777,13 @@ func TestGetEvents(t *testing.T) {
 	_, _, err := or.GetEvents(context.Background(), "ns1", f)
 	assert.NoError(t, err)
 }
+
+func TestGetBlockchainEvents(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetBlockchainEvents", mock.Anything, mock.Anything).Return([]*fftypes.BlockchainEvent{}, nil, nil)
+	fb := database.BlockchainEventQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("id", u))
+	_, _, err := or.GetBlockchainEvents(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
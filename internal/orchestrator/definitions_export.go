@@ -0,0 +1,224 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) ExportDefinitions(ctx context.Context, ns string) (export *fftypes.DefinitionsExport, err error) {
+	namespace, err := or.database.GetNamespace(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	export = &fftypes.DefinitionsExport{
+		Namespace: namespace,
+		Exported:  fftypes.Now(),
+	}
+
+	dtf := database.DatatypeQueryFactory.NewFilter(ctx)
+	if export.Datatypes, _, err = or.database.GetDatatypes(ctx, dtf.Eq("namespace", ns).Sort("created")); err != nil {
+		return nil, err
+	}
+	if export.FFIs, _, err = or.database.GetFFIs(ctx, database.FFIQueryFactory.NewFilter(ctx).Eq("namespace", ns)); err != nil {
+		return nil, err
+	}
+	tpf := database.TokenPoolQueryFactory.NewFilter(ctx)
+	if export.TokenPools, _, err = or.database.GetTokenPools(ctx, tpf.And(
+		tpf.Eq("namespace", ns),
+		tpf.Eq("state", fftypes.TokenPoolStateConfirmed),
+	).Sort("created")); err != nil {
+		return nil, err
+	}
+
+	// Identities are sorted by creation, so a parent is always imported before its children
+	cif := database.CustomIdentityQueryFactory.NewFilter(ctx)
+	identities, _, err := or.database.GetCustomIdentities(ctx, cif.Eq("namespace", ns).Sort("created"))
+	if err != nil {
+		return nil, err
+	}
+	export.Identities = make([]*fftypes.CustomIdentity, 0, len(identities))
+	for _, identity := range identities {
+		if identity.Verified != nil {
+			export.Identities = append(export.Identities, identity)
+		}
+	}
+	return export, nil
+}
+
+// ImportDefinitions broadcasts each exported definition into a namespace on this network. Definitions that already
+// exist are skipped, so an import that partially failed can be safely submitted again.
+func (or *orchestrator) ImportDefinitions(ctx context.Context, ns string, export *fftypes.DefinitionsExport) ([]*fftypes.DefinitionImportResult, error) {
+	if err := or.data.VerifyNamespaceExists(ctx, ns); err != nil {
+		return nil, err
+	}
+	results := make([]*fftypes.DefinitionImportResult, 0)
+	for _, dt := range export.Datatypes {
+		res := &fftypes.DefinitionImportResult{Type: fftypes.DefinitionImportTypeDatatype, Name: dt.Name, Version: dt.Version, ExportedID: dt.ID}
+		results = append(results, importResult(ctx, res, or.importDatatype(ctx, ns, dt, res)))
+	}
+	for _, ffi := range export.FFIs {
+		res := &fftypes.DefinitionImportResult{Type: fftypes.DefinitionImportTypeFFI, Name: ffi.Name, Version: ffi.Version, ExportedID: ffi.ID}
+		results = append(results, importResult(ctx, res, or.importFFI(ctx, ns, ffi, res)))
+	}
+	for _, pool := range export.TokenPools {
+		res := &fftypes.DefinitionImportResult{Type: fftypes.DefinitionImportTypeTokenPool, Name: pool.Name, ExportedID: pool.ID}
+		results = append(results, importResult(ctx, res, or.importTokenPool(ctx, ns, pool, res)))
+	}
+	for _, identity := range export.Identities {
+		res := &fftypes.DefinitionImportResult{Type: fftypes.DefinitionImportTypeIdentity, Name: identity.Name, ExportedID: identity.ID}
+		results = append(results, importResult(ctx, res, or.importIdentity(ctx, ns, identity, res)))
+	}
+	return results, nil
+}
+
+func importResult(ctx context.Context, res *fftypes.DefinitionImportResult, err error) *fftypes.DefinitionImportResult {
+	if err != nil {
+		log.L(ctx).Errorf("Failed to import %s '%s': %s", res.Type, res.Name, err)
+		res.Status = fftypes.DefinitionImportStatusFailed
+		res.Error = err.Error()
+	} else if res.Status == "" {
+		res.Status = fftypes.DefinitionImportStatusImported
+	}
+	return res
+}
+
+func (or *orchestrator) importDatatype(ctx context.Context, ns string, dt *fftypes.Datatype, res *fftypes.DefinitionImportResult) error {
+	existing, err := or.database.GetDatatypeByName(ctx, ns, dt.Name, dt.Version)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		res.Status = fftypes.DefinitionImportStatusExists
+		res.ID = existing.ID
+		return nil
+	}
+
+	if dt.ID == nil {
+		dt.ID = fftypes.NewUUID()
+	} else if existing, err = or.database.GetDatatypeByID(ctx, dt.ID); err != nil {
+		return err
+	} else if existing != nil {
+		dt.ID = fftypes.NewUUID()
+	}
+	if hash := dt.Value.Hash(); dt.Hash == nil || !dt.Hash.Equals(hash) {
+		log.L(ctx).Warnf("Replacing invalid hash of datatype '%s:%s' (%v) with %s", dt.Name, dt.Version, dt.Hash, hash)
+		dt.Hash = hash
+	}
+	if dt.Validator == "" {
+		dt.Validator = fftypes.ValidatorTypeJSON
+	}
+	dt.Namespace = ns
+	dt.Message = nil
+	dt.Created = fftypes.Now()
+	if err = dt.Validate(ctx, true); err != nil {
+		return err
+	}
+	if err = or.data.CheckDatatype(ctx, ns, dt); err != nil {
+		return err
+	}
+	res.ID = dt.ID
+	_, err = or.broadcast.BroadcastDefinitionAsNode(ctx, ns, dt, fftypes.SystemTagDefineDatatype, false)
+	return err
+}
+
+func (or *orchestrator) importFFI(ctx context.Context, ns string, ffi *fftypes.FFI, res *fftypes.DefinitionImportResult) error {
+	existing, err := or.database.GetFFI(ctx, ns, ffi.Name, ffi.Version)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		res.Status = fftypes.DefinitionImportStatusExists
+		res.ID = existing.ID
+		return nil
+	}
+
+	if ffi.ID == nil {
+		ffi.ID = fftypes.NewUUID()
+	} else if existing, err = or.database.GetFFIByID(ctx, ffi.ID); err != nil {
+		return err
+	} else if existing != nil {
+		ffi.ID = fftypes.NewUUID()
+	}
+	ffi.Namespace = ns
+	ffi.Message = nil
+	if err = ffi.Validate(ctx, true); err != nil {
+		return err
+	}
+	res.ID = ffi.ID
+	_, err = or.broadcast.BroadcastDefinitionAsNode(ctx, ns, ffi, fftypes.SystemTagDefineFFI, false)
+	return err
+}
+
+func (or *orchestrator) importTokenPool(ctx context.Context, ns string, pool *fftypes.TokenPool, res *fftypes.DefinitionImportResult) error {
+	existing, err := or.database.GetTokenPool(ctx, ns, pool.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		res.Status = fftypes.DefinitionImportStatusExists
+		res.ID = existing.ID
+		return nil
+	}
+
+	// The pool must be created again by the token connector on the new chain
+	created, err := or.assets.CreateTokenPoolByType(ctx, ns, pool.Connector, &fftypes.TokenPool{
+		Type:   pool.Type,
+		Name:   pool.Name,
+		Symbol: pool.Symbol,
+	}, false)
+	if err != nil {
+		return err
+	}
+	res.ID = created.ID
+	return nil
+}
+
+func (or *orchestrator) importIdentity(ctx context.Context, ns string, identity *fftypes.CustomIdentity, res *fftypes.DefinitionImportResult) error {
+	existing, err := or.database.GetCustomIdentityByName(ctx, ns, identity.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		res.Status = fftypes.DefinitionImportStatusExists
+		res.ID = existing.ID
+		return nil
+	}
+
+	// The claim must be signed again by the key of the identity, so this node must be able to sign for both
+	// the identity and its parent
+	registered, err := or.networkmap.RegisterIdentity(ctx, ns, &fftypes.CustomIdentity{
+		Name:        identity.Name,
+		Parent:      identity.Parent,
+		Key:         identity.Key,
+		Description: identity.Description,
+		Profile:     identity.Profile,
+	}, false)
+	if err != nil {
+		return err
+	}
+	res.ID = registered.ID
+	return nil
+}
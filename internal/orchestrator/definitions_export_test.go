@@ -0,0 +1,257 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportDefinitions(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Name: "ns1"}, nil)
+	or.mdi.On("GetDatatypes", mock.Anything, mock.Anything).Return([]*fftypes.Datatype{{Name: "dt1"}}, nil, nil)
+	or.mdi.On("GetFFIs", mock.Anything, mock.Anything).Return([]*fftypes.FFI{{Name: "ffi1"}}, nil, nil)
+	or.mdi.On("GetTokenPools", mock.Anything, mock.Anything).Return([]*fftypes.TokenPool{{Name: "pool1"}}, nil, nil)
+	or.mdi.On("GetCustomIdentities", mock.Anything, mock.Anything).Return([]*fftypes.CustomIdentity{
+		{Name: "id1", Verified: fftypes.Now()},
+		{Name: "unverified"},
+	}, nil, nil)
+	export, err := or.ExportDefinitions(context.Background(), "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", export.Namespace.Name)
+	assert.Len(t, export.Datatypes, 1)
+	assert.Len(t, export.FFIs, 1)
+	assert.Len(t, export.TokenPools, 1)
+	assert.Len(t, export.Identities, 1)
+	assert.Equal(t, "id1", export.Identities[0].Name)
+	assert.NotNil(t, export.Exported)
+}
+
+func TestExportDefinitionsNamespaceNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, nil)
+	_, err := or.ExportDefinitions(context.Background(), "ns1")
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestExportDefinitionsNamespaceFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(nil, fmt.Errorf("pop"))
+	_, err := or.ExportDefinitions(context.Background(), "ns1")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestExportDefinitionsQueryFail(t *testing.T) {
+	for _, failing := range []string{"GetDatatypes", "GetFFIs", "GetTokenPools", "GetCustomIdentities"} {
+		or := newTestOrchestrator()
+		or.mdi.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{Name: "ns1"}, nil)
+		for _, method := range []string{"GetDatatypes", "GetFFIs", "GetTokenPools", "GetCustomIdentities"} {
+			if method == failing {
+				or.mdi.On(method, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+				break
+			}
+			or.mdi.On(method, mock.Anything, mock.Anything).Return(nil, nil, nil)
+		}
+		_, err := or.ExportDefinitions(context.Background(), "ns1")
+		assert.EqualError(t, err, "pop", failing)
+	}
+}
+
+func TestImportDefinitionsNamespaceFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(fmt.Errorf("pop"))
+	_, err := or.ImportDefinitions(context.Background(), "ns1", &fftypes.DefinitionsExport{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestImportDefinitions(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns2").Return(nil)
+
+	value := fftypes.Byteable(`{"type":"object"}`)
+	dt1 := &fftypes.Datatype{ID: fftypes.NewUUID(), Name: "dt1", Version: "1", Value: value, Hash: value.Hash(), Validator: fftypes.ValidatorTypeJSON}
+	dt2 := &fftypes.Datatype{Name: "dt2", Version: "1", Value: value, Hash: fftypes.NewRandB32()}
+	dt3 := &fftypes.Datatype{ID: fftypes.NewUUID(), Name: "dt3", Version: "1", Value: value}
+	dtExists := &fftypes.Datatype{ID: fftypes.NewUUID(), Name: "dt4", Version: "1"}
+	dt3ID := *dt3.ID
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns2", "dt1", "1").Return(nil, nil)
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns2", "dt2", "1").Return(nil, nil)
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns2", "dt3", "1").Return(nil, nil)
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns2", "dt4", "1").Return(&fftypes.Datatype{ID: dtExists.ID}, nil)
+	or.mdi.On("GetDatatypeByID", mock.Anything, dt1.ID).Return(nil, nil)
+	or.mdi.On("GetDatatypeByID", mock.Anything, dt3.ID).Return(&fftypes.Datatype{}, nil)
+	or.mdm.On("CheckDatatype", mock.Anything, "ns2", mock.Anything).Return(nil)
+	or.mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns2", mock.Anything, fftypes.SystemTagDefineDatatype, false).Return(&fftypes.Message{}, nil)
+
+	ffi1 := &fftypes.FFI{ID: fftypes.NewUUID(), Name: "ffi1", Version: "1"}
+	ffi2 := &fftypes.FFI{Name: "ffi2", Version: "1"}
+	ffi3 := &fftypes.FFI{ID: fftypes.NewUUID(), Name: "ffi3", Version: "1"}
+	ffiExists := &fftypes.FFI{ID: fftypes.NewUUID(), Name: "ffi4", Version: "1"}
+	ffi3ID := *ffi3.ID
+	or.mdi.On("GetFFI", mock.Anything, "ns2", "ffi1", "1").Return(nil, nil)
+	or.mdi.On("GetFFI", mock.Anything, "ns2", "ffi2", "1").Return(nil, nil)
+	or.mdi.On("GetFFI", mock.Anything, "ns2", "ffi3", "1").Return(nil, nil)
+	or.mdi.On("GetFFI", mock.Anything, "ns2", "ffi4", "1").Return(&fftypes.FFI{ID: ffiExists.ID}, nil)
+	or.mdi.On("GetFFIByID", mock.Anything, ffi1.ID).Return(nil, nil)
+	or.mdi.On("GetFFIByID", mock.Anything, ffi3.ID).Return(&fftypes.FFI{}, nil)
+	or.mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns2", mock.Anything, fftypes.SystemTagDefineFFI, false).Return(&fftypes.Message{}, nil)
+
+	pool1 := &fftypes.TokenPool{ID: fftypes.NewUUID(), Name: "pool1", Type: fftypes.TokenTypeFungible, Connector: "erc1155"}
+	poolExists := &fftypes.TokenPool{ID: fftypes.NewUUID(), Name: "pool2"}
+	newPoolID := fftypes.NewUUID()
+	or.mdi.On("GetTokenPool", mock.Anything, "ns2", "pool1").Return(nil, nil)
+	or.mdi.On("GetTokenPool", mock.Anything, "ns2", "pool2").Return(&fftypes.TokenPool{ID: poolExists.ID}, nil)
+	or.mam.On("CreateTokenPoolByType", mock.Anything, "ns2", "erc1155", mock.MatchedBy(func(pool *fftypes.TokenPool) bool {
+		return pool.Name == "pool1" && pool.Type == fftypes.TokenTypeFungible
+	}), false).Return(&fftypes.TokenPool{ID: newPoolID}, nil)
+
+	id1 := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Name: "id1", Parent: "did:firefly:org/org1", Key: "0x12345"}
+	idExists := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Name: "id2"}
+	newIdentityID := fftypes.NewUUID()
+	or.mdi.On("GetCustomIdentityByName", mock.Anything, "ns2", "id1").Return(nil, nil)
+	or.mdi.On("GetCustomIdentityByName", mock.Anything, "ns2", "id2").Return(&fftypes.CustomIdentity{ID: idExists.ID}, nil)
+	or.mnm.On("RegisterIdentity", mock.Anything, "ns2", mock.MatchedBy(func(identity *fftypes.CustomIdentity) bool {
+		return identity.Name == "id1" && identity.Parent == "did:firefly:org/org1" && identity.Key == "0x12345"
+	}), false).Return(&fftypes.CustomIdentity{ID: newIdentityID}, nil)
+
+	results, err := or.ImportDefinitions(context.Background(), "ns2", &fftypes.DefinitionsExport{
+		Datatypes:  []*fftypes.Datatype{dt1, dt2, dt3, dtExists},
+		FFIs:       []*fftypes.FFI{ffi1, ffi2, ffi3, ffiExists},
+		TokenPools: []*fftypes.TokenPool{pool1, poolExists},
+		Identities: []*fftypes.CustomIdentity{id1, idExists},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 12)
+	for _, res := range results {
+		assert.Empty(t, res.Error)
+	}
+
+	// IDs and valid hashes are preserved
+	assert.Equal(t, fftypes.DefinitionImportStatusImported, results[0].Status)
+	assert.Equal(t, *dt1.ID, *results[0].ID)
+	assert.Equal(t, "ns2", dt1.Namespace)
+	assert.Equal(t, *value.Hash(), *dt1.Hash)
+	// Missing IDs are generated, and invalid hashes replaced
+	assert.NotNil(t, results[1].ID)
+	assert.Equal(t, *value.Hash(), *dt2.Hash)
+	assert.Equal(t, fftypes.ValidatorTypeJSON, dt2.Validator)
+	// IDs already in use are replaced
+	assert.NotEqual(t, dt3ID, *results[2].ID)
+	assert.Equal(t, fftypes.DefinitionImportStatusExists, results[3].Status)
+	assert.Equal(t, *dtExists.ID, *results[3].ID)
+
+	assert.Equal(t, *ffi1.ID, *results[4].ID)
+	assert.NotNil(t, results[5].ID)
+	assert.NotEqual(t, ffi3ID, *results[6].ID)
+	assert.Equal(t, fftypes.DefinitionImportStatusExists, results[7].Status)
+
+	assert.Equal(t, fftypes.DefinitionImportStatusImported, results[8].Status)
+	assert.Equal(t, *newPoolID, *results[8].ID)
+	assert.Equal(t, *pool1.ID, *results[8].ExportedID)
+	assert.Equal(t, fftypes.DefinitionImportStatusExists, results[9].Status)
+
+	assert.Equal(t, fftypes.DefinitionImportStatusImported, results[10].Status)
+	assert.Equal(t, *newIdentityID, *results[10].ID)
+	assert.Equal(t, fftypes.DefinitionImportStatusExists, results[11].Status)
+
+	or.mdi.AssertExpectations(t)
+	or.mbm.AssertExpectations(t)
+	or.mam.AssertExpectations(t)
+	or.mnm.AssertExpectations(t)
+}
+
+func TestImportDefinitionsDatatypeFailures(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+
+	value := fftypes.Byteable(`{}`)
+	dtLookupFail := &fftypes.Datatype{Name: "lookup", Version: "1"}
+	dtIDFail := &fftypes.Datatype{ID: fftypes.NewUUID(), Name: "idlookup", Version: "1"}
+	dtInvalid := &fftypes.Datatype{Name: "invalid", Version: "1", Validator: fftypes.ValidatorTypeNone, Value: value}
+	dtCheckFail := &fftypes.Datatype{Name: "check", Version: "1", Value: value}
+	dtBroadcastFail := &fftypes.Datatype{Name: "broadcast", Version: "1", Value: value}
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns1", "lookup", "1").Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetDatatypeByName", mock.Anything, "ns1", mock.Anything, "1").Return(nil, nil)
+	or.mdi.On("GetDatatypeByID", mock.Anything, dtIDFail.ID).Return(nil, fmt.Errorf("pop"))
+	or.mdm.On("CheckDatatype", mock.Anything, "ns1", dtCheckFail).Return(fmt.Errorf("pop"))
+	or.mdm.On("CheckDatatype", mock.Anything, "ns1", dtBroadcastFail).Return(nil)
+	or.mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns1", dtBroadcastFail, fftypes.SystemTagDefineDatatype, false).Return(nil, fmt.Errorf("pop"))
+
+	results, err := or.ImportDefinitions(context.Background(), "ns1", &fftypes.DefinitionsExport{
+		Datatypes: []*fftypes.Datatype{dtLookupFail, dtIDFail, dtInvalid, dtCheckFail, dtBroadcastFail},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 5)
+	for _, res := range results {
+		assert.Equal(t, fftypes.DefinitionImportStatusFailed, res.Status)
+	}
+	assert.Regexp(t, "FF10132", results[2].Error)
+	assert.Equal(t, "pop", results[4].Error)
+}
+
+func TestImportDefinitionsFFIFailures(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+
+	ffiLookupFail := &fftypes.FFI{Name: "lookup", Version: "1"}
+	ffiIDFail := &fftypes.FFI{ID: fftypes.NewUUID(), Name: "idlookup", Version: "1"}
+	ffiInvalid := &fftypes.FFI{Name: "invalid", Version: "!bad"}
+	ffiBroadcastFail := &fftypes.FFI{Name: "broadcast", Version: "1"}
+	or.mdi.On("GetFFI", mock.Anything, "ns1", "lookup", "1").Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetFFI", mock.Anything, "ns1", mock.Anything, mock.Anything).Return(nil, nil)
+	or.mdi.On("GetFFIByID", mock.Anything, ffiIDFail.ID).Return(nil, fmt.Errorf("pop"))
+	or.mbm.On("BroadcastDefinitionAsNode", mock.Anything, "ns1", ffiBroadcastFail, fftypes.SystemTagDefineFFI, false).Return(nil, fmt.Errorf("pop"))
+
+	results, err := or.ImportDefinitions(context.Background(), "ns1", &fftypes.DefinitionsExport{
+		FFIs: []*fftypes.FFI{ffiLookupFail, ffiIDFail, ffiInvalid, ffiBroadcastFail},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	for _, res := range results {
+		assert.Equal(t, fftypes.DefinitionImportStatusFailed, res.Status)
+	}
+	assert.Regexp(t, "FF10131", results[2].Error)
+}
+
+func TestImportDefinitionsPoolAndIdentityFailures(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+
+	or.mdi.On("GetTokenPool", mock.Anything, "ns1", "lookup").Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetTokenPool", mock.Anything, "ns1", "create").Return(nil, nil)
+	or.mam.On("CreateTokenPoolByType", mock.Anything, "ns1", "erc1155", mock.Anything, false).Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "lookup").Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetCustomIdentityByName", mock.Anything, "ns1", "register").Return(nil, nil)
+	or.mnm.On("RegisterIdentity", mock.Anything, "ns1", mock.Anything, false).Return(nil, fmt.Errorf("pop"))
+
+	results, err := or.ImportDefinitions(context.Background(), "ns1", &fftypes.DefinitionsExport{
+		TokenPools: []*fftypes.TokenPool{{Name: "lookup"}, {Name: "create", Connector: "erc1155"}},
+		Identities: []*fftypes.CustomIdentity{{Name: "lookup"}, {Name: "register"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	for _, res := range results {
+		assert.Equal(t, fftypes.DefinitionImportStatusFailed, res.Status)
+		assert.Equal(t, "pop", res.Error)
+	}
+}
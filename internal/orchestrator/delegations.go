@@ -0,0 +1,45 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) GetDelegations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Delegation, *database.FilterResult, error) {
+	filter = or.scopeNS(ns, filter)
+	return or.database.GetDelegations(ctx, filter)
+}
+
+func (or *orchestrator) GetDelegationByID(ctx context.Context, ns, id string) (*fftypes.Delegation, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	delegation, err := or.database.GetDelegationByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if delegation == nil || delegation.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgDelegationNotFound, id, ns)
+	}
+	return delegation, nil
+}
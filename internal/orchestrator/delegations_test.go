@@ -0,0 +1,72 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetDelegations(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetDelegations", mock.Anything, mock.Anything).Return([]*fftypes.Delegation{}, nil, nil)
+	fb := database.DelegationQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("delegator", "did:firefly:org/org1"))
+	_, _, err := or.GetDelegations(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetDelegationByID(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetDelegationByID", mock.Anything, u).Return(&fftypes.Delegation{
+		ID: u, Namespace: "ns1",
+	}, nil)
+	delegation, err := or.GetDelegationByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, delegation.ID)
+}
+
+func TestGetDelegationByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetDelegationByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetDelegationByIDFail(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetDelegationByID", mock.Anything, u).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetDelegationByID(context.Background(), "ns1", u.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetDelegationByIDWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetDelegationByID", mock.Anything, u).Return(&fftypes.Delegation{
+		ID: u, Namespace: "ns2",
+	}, nil)
+	_, err := or.GetDelegationByID(context.Background(), "ns1", u.String())
+	assert.Regexp(t, "FF10448", err)
+}
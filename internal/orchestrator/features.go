@@ -0,0 +1,111 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+var allFeatures = []fftypes.Feature{
+	fftypes.FeatureTokens,
+	fftypes.FeatureContracts,
+	fftypes.FeaturePrivateMessaging,
+	fftypes.FeatureSyncRequests,
+}
+
+// featureFlags records the features disabled in every namespace, and the overrides for individual namespaces.
+// Every feature is enabled unless disabled in config.
+type featureFlags struct {
+	disabled   map[fftypes.Feature]bool
+	namespaces map[string]map[fftypes.Feature]bool
+}
+
+func parseFeature(ctx context.Context, name string) (fftypes.Feature, bool) {
+	feature := fftypes.Feature(name).Lower()
+	for _, f := range allFeatures {
+		if f == feature {
+			return f, true
+		}
+	}
+	log.L(ctx).Errorf("Ignoring unknown feature '%s'", name)
+	return "", false
+}
+
+func newFeatureFlags(ctx context.Context) *featureFlags {
+	ff := &featureFlags{
+		disabled:   make(map[fftypes.Feature]bool),
+		namespaces: make(map[string]map[fftypes.Feature]bool),
+	}
+	for _, name := range config.GetStringSlice(config.FeaturesDisabled) {
+		if feature, ok := parseFeature(ctx, name); ok {
+			ff.disabled[feature] = true
+		}
+	}
+	for _, nsConf := range config.GetObjectArray(config.FeaturesNamespaces) {
+		ns := nsConf.GetString("name")
+		if ns == "" {
+			log.L(ctx).Errorf("Ignoring feature configuration without a namespace name: %s", nsConf)
+			continue
+		}
+		overrides := make(map[fftypes.Feature]bool)
+		for _, name := range nsConf.GetStringArray("enabled") {
+			if feature, ok := parseFeature(ctx, name); ok {
+				overrides[feature] = true
+			}
+		}
+		for _, name := range nsConf.GetStringArray("disabled") {
+			if feature, ok := parseFeature(ctx, name); ok {
+				overrides[feature] = false
+			}
+		}
+		ff.namespaces[ns] = overrides
+	}
+	return ff
+}
+
+func (ff *featureFlags) enabled(ns string, feature fftypes.Feature) bool {
+	if enabled, ok := ff.namespaces[ns][feature]; ok {
+		return enabled
+	}
+	return !ff.disabled[feature]
+}
+
+func (or *orchestrator) CheckFeature(ctx context.Context, ns string, feature fftypes.Feature) error {
+	if !or.features.enabled(ns, feature) {
+		return i18n.NewError(ctx, i18n.MsgFeatureDisabled, feature, ns)
+	}
+	return nil
+}
+
+func (or *orchestrator) GetNamespaceFeatures(ctx context.Context, ns string) (*fftypes.NamespaceFeatures, error) {
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, err
+	}
+	nf := &fftypes.NamespaceFeatures{
+		Namespace: ns,
+		Features:  make(map[fftypes.Feature]bool),
+	}
+	for _, feature := range allFeatures {
+		nf.Features[feature] = or.features.enabled(ns, feature)
+	}
+	return nf, nil
+}
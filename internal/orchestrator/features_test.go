@@ -0,0 +1,69 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeaturesDefaultEnabled(t *testing.T) {
+	or := newTestOrchestrator()
+	or.features = newFeatureFlags(or.ctx)
+
+	err := or.CheckFeature(or.ctx, "ns1", fftypes.FeatureTokens)
+	assert.NoError(t, err)
+
+	nf, err := or.GetNamespaceFeatures(or.ctx, "ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", nf.Namespace)
+	assert.Equal(t, map[fftypes.Feature]bool{
+		fftypes.FeatureTokens:           true,
+		fftypes.FeatureContracts:        true,
+		fftypes.FeaturePrivateMessaging: true,
+		fftypes.FeatureSyncRequests:     true,
+	}, nf.Features)
+}
+
+func TestFeaturesNamespaceOverrides(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.FeaturesDisabled, []string{"tokens", "Contracts", "unknown"})
+	config.Set(config.FeaturesNamespaces, fftypes.JSONObjectArray{
+		{"name": "ns1", "enabled": []interface{}{"tokens"}, "disabled": []interface{}{"syncrequests", "unknown"}},
+		{"enabled": []interface{}{"contracts"}},
+	})
+	or.features = newFeatureFlags(or.ctx)
+
+	assert.NoError(t, or.CheckFeature(or.ctx, "ns1", fftypes.FeatureTokens))
+	assert.Regexp(t, "FF10412.*contracts.*ns1", or.CheckFeature(or.ctx, "ns1", fftypes.FeatureContracts))
+	assert.NoError(t, or.CheckFeature(or.ctx, "ns1", fftypes.FeaturePrivateMessaging))
+	assert.Regexp(t, "FF10412.*syncrequests.*ns1", or.CheckFeature(or.ctx, "ns1", fftypes.FeatureSyncRequests))
+
+	assert.Regexp(t, "FF10412.*tokens.*ns2", or.CheckFeature(or.ctx, "ns2", fftypes.FeatureTokens))
+	assert.NoError(t, or.CheckFeature(or.ctx, "ns2", fftypes.FeatureSyncRequests))
+}
+
+func TestGetNamespaceFeaturesBadNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	or.features = newFeatureFlags(or.ctx)
+
+	_, err := or.GetNamespaceFeatures(or.ctx, "!bad")
+	assert.Regexp(t, "FF10131", err)
+}
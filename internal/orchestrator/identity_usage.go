@@ -0,0 +1,79 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// resolveUsageIdentity resolves an org (by name or DID) or a custom identity (by DID), to the author
+// its messages are sent as, and the key it signs transactions with
+func (or *orchestrator) resolveUsageIdentity(ctx context.Context, ns, identity string) (author string, keys []string, err error) {
+	if strings.HasPrefix(identity, fftypes.FireflyIdentityDIDPrefix) {
+		customIdentity, err := or.identity.GetCustomIdentityByDID(ctx, identity)
+		if err != nil {
+			return "", nil, err
+		}
+		if customIdentity.Namespace != ns {
+			return "", nil, i18n.NewError(ctx, i18n.MsgIdentityNotFound, identity)
+		}
+		return customIdentity.GetDID(), []string{customIdentity.Key}, nil
+	}
+
+	var org *fftypes.Organization
+	if strings.HasPrefix(identity, fftypes.FireflyOrgDIDPrefix) {
+		orgID, err := fftypes.ParseUUID(ctx, strings.TrimPrefix(identity, fftypes.FireflyOrgDIDPrefix))
+		if err != nil {
+			return "", nil, err
+		}
+		org, err = or.database.GetOrganizationByID(ctx, orgID)
+		if err != nil {
+			return "", nil, err
+		}
+	} else if org, err = or.database.GetOrganizationByName(ctx, identity); err != nil {
+		return "", nil, err
+	}
+	if org == nil {
+		return "", nil, i18n.NewError(ctx, i18n.MsgIdentityNotFound, identity)
+	}
+	return org.GetDID(), []string{org.Identity}, nil
+}
+
+// GetIdentityUsage reports the messages, data volume, token transfers and contract invokes of an org
+// or custom identity, optionally bounded to a time range (inclusive of the start time, exclusive of the end time)
+func (or *orchestrator) GetIdentityUsage(ctx context.Context, ns, identity string, startTime, endTime *fftypes.FFTime) (*fftypes.IdentityUsage, error) {
+	if identity == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "identity")
+	}
+	if startTime != nil && endTime != nil && startTime.UnixNano() > endTime.UnixNano() {
+		return nil, i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+	}
+	author, keys, err := or.resolveUsageIdentity(ctx, ns, identity)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := or.database.GetIdentityUsage(ctx, ns, author, keys, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	usage.Identity = identity
+	return usage, nil
+}
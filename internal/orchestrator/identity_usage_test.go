@@ -0,0 +1,133 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetIdentityUsageOrgByName(t *testing.T) {
+	or := newTestOrchestrator()
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	or.mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(org, nil)
+	or.mdi.On("GetIdentityUsage", mock.Anything, "ns1", org.GetDID(), []string{"0x12345"}, (*fftypes.FFTime)(nil), (*fftypes.FFTime)(nil)).
+		Return(&fftypes.IdentityUsage{Messages: 10}, nil)
+
+	usage, err := or.GetIdentityUsage(context.Background(), "ns1", "org1", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "org1", usage.Identity)
+	assert.Equal(t, int64(10), usage.Messages)
+}
+
+func TestGetIdentityUsageOrgByDID(t *testing.T) {
+	or := newTestOrchestrator()
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	startTime := fftypes.UnixTime(1000)
+	endTime := fftypes.UnixTime(2000)
+	or.mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(org, nil)
+	or.mdi.On("GetIdentityUsage", mock.Anything, "ns1", org.GetDID(), []string{"0x12345"}, startTime, endTime).
+		Return(&fftypes.IdentityUsage{}, nil)
+
+	usage, err := or.GetIdentityUsage(context.Background(), "ns1", org.GetDID(), startTime, endTime)
+	assert.NoError(t, err)
+	assert.Equal(t, org.GetDID(), usage.Identity)
+}
+
+func TestGetIdentityUsageOrgBadDID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", fftypes.FireflyOrgDIDPrefix+"bad", nil, nil)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetIdentityUsageOrgByDIDFail(t *testing.T) {
+	or := newTestOrchestrator()
+	orgID := fftypes.NewUUID()
+	or.mdi.On("GetOrganizationByID", mock.Anything, orgID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", fftypes.FireflyOrgDIDPrefix+orgID.String(), nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityUsageOrgByNameFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", "org1", nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityUsageOrgNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", "org1", nil, nil)
+	assert.Regexp(t, "FF10414", err)
+}
+
+func TestGetIdentityUsageCustomIdentity(t *testing.T) {
+	or := newTestOrchestrator()
+	ci := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns1", Name: "user1", Key: "0x23456"}
+	or.mim.On("GetCustomIdentityByDID", mock.Anything, ci.GetDID()).Return(ci, nil)
+	or.mdi.On("GetIdentityUsage", mock.Anything, "ns1", ci.GetDID(), []string{"0x23456"}, (*fftypes.FFTime)(nil), (*fftypes.FFTime)(nil)).
+		Return(&fftypes.IdentityUsage{TokenTransfers: 5}, nil)
+
+	usage, err := or.GetIdentityUsage(context.Background(), "ns1", ci.GetDID(), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), usage.TokenTransfers)
+}
+
+func TestGetIdentityUsageCustomIdentityFail(t *testing.T) {
+	or := newTestOrchestrator()
+	did := fftypes.FireflyIdentityDIDPrefix + fftypes.NewUUID().String()
+	or.mim.On("GetCustomIdentityByDID", mock.Anything, did).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", did, nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityUsageCustomIdentityWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	ci := &fftypes.CustomIdentity{ID: fftypes.NewUUID(), Namespace: "ns2", Name: "user1", Key: "0x23456"}
+	or.mim.On("GetCustomIdentityByDID", mock.Anything, ci.GetDID()).Return(ci, nil)
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", ci.GetDID(), nil, nil)
+	assert.Regexp(t, "FF10414", err)
+}
+
+func TestGetIdentityUsageQueryFail(t *testing.T) {
+	or := newTestOrchestrator()
+	org := &fftypes.Organization{ID: fftypes.NewUUID(), Name: "org1", Identity: "0x12345"}
+	or.mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(org, nil)
+	or.mdi.On("GetIdentityUsage", mock.Anything, "ns1", org.GetDID(), []string{"0x12345"}, (*fftypes.FFTime)(nil), (*fftypes.FFTime)(nil)).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", "org1", nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetIdentityUsageBadTimes(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", "org1", fftypes.UnixTime(2000), fftypes.UnixTime(1000))
+	assert.Regexp(t, "FF10300", err)
+}
+
+func TestGetIdentityUsageMissingIdentity(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetIdentityUsage(context.Background(), "ns1", "", nil, nil)
+	assert.Regexp(t, "FF10140", err)
+}
@@ -0,0 +1,206 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/database/difactory"
+	"github.com/hyperledger/firefly/internal/dataexchange/dxfactory"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/publicstorage/psfactory"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/tokens"
+)
+
+const (
+	// namespaceConfigName is the name of a predefined namespace
+	namespaceConfigName = "name"
+	// namespaceConfigDescription is the description of a predefined namespace
+	namespaceConfigDescription = "description"
+	// namespaceConfigPlugins is the section of a predefined namespace containing the plugins it uses in isolation from other namespaces
+	namespaceConfigPlugins = "plugins"
+	// namespaceConfigPluginType is the type of each of the plugins of an isolated namespace
+	namespaceConfigPluginType = "type"
+)
+
+// The plugins of an isolated namespace, each in a section containing its type and the config specific to
+// that type. Identity and policy plugins are shared by all namespaces.
+var isolatedPluginSections = []string{"database", "blockchain", "dataexchange", "publicstorage"}
+
+// The node-wide section of config for each plugin, which an isolated namespace inherits for any plugin
+// it does not configure itself
+var globalPluginSections = map[string]config.Prefix{
+	"database":      databaseConfig,
+	"blockchain":    blockchainConfig,
+	"dataexchange":  dataexchangeConfig,
+	"publicstorage": publicstorageConfig,
+}
+
+type configurablePlugin interface {
+	Name() string
+	InitPrefix(prefix config.Prefix)
+}
+
+func initNamespacesPrefixArray(prefix config.PrefixArray) {
+	prefix.AddKnownKey(namespaceConfigName)
+	prefix.AddKnownKey(namespaceConfigDescription)
+	prefix.AddKnownKey(namespaceConfigPlugins)
+	for _, section := range isolatedPluginSections {
+		prefix.AddKnownKey(fmt.Sprintf("%s.%s.%s", namespaceConfigPlugins, section, namespaceConfigPluginType))
+	}
+}
+
+// ForNamespace returns the orchestrator for a namespace with isolated plugins, or this orchestrator for
+// all other namespaces
+func (or *orchestrator) ForNamespace(ns string) Orchestrator {
+	if child, ok := or.isolated[ns]; ok {
+		return child
+	}
+	return or
+}
+
+// sequencesNamespace returns true if the batch pins of the namespace are processed by this orchestrator. Each
+// isolated namespace is processed only by its own orchestrator, along with its own copy of the system namespace.
+func (or *orchestrator) sequencesNamespace(ns string) bool {
+	if or.namespace != "" {
+		return ns == or.namespace || ns == fftypes.SystemNamespace
+	}
+	return or.isolated[ns] == nil
+}
+
+// initIsolatedNamespaces constructs a separate stack of plugins and managers for each predefined namespace
+// that configures its own plugins, so no state is shared with the other namespaces on this node
+func (or *orchestrator) initIsolatedNamespaces(ctx context.Context) error {
+	or.isolated = make(map[string]*orchestrator)
+	arraySize := namespacesConfig.ArraySize()
+	for i := 0; i < arraySize; i++ {
+		prefix := namespacesConfig.ArrayEntry(i)
+		if prefix.Get(namespaceConfigPlugins) == nil {
+			continue
+		}
+		name := prefix.GetString(namespaceConfigName)
+		if err := fftypes.ValidateFFNameField(ctx, name, fmt.Sprintf("namespaces.predefined[%d].name", i)); err != nil {
+			return err
+		}
+		if or.isolated[name] != nil {
+			return i18n.NewError(ctx, i18n.MsgDuplicateArrayEntry, "namespaces.predefined.name", i, name)
+		}
+
+		child := &orchestrator{
+			namespace:       name,
+			namespaceConfig: prefix,
+			identityPlugin:  or.identityPlugin,
+			policyPlugin:    or.policyPlugin,
+			tokens:          make(map[string]tokens.Plugin),
+		}
+		if plugins := or.isolatedPlugins[name]; plugins != nil {
+			child.database = plugins.Database
+			child.blockchain = plugins.Blockchain
+			child.publicstorage = plugins.PublicStorage
+			child.dataexchange = plugins.DataExchange
+		}
+		log.L(ctx).Infof("Initializing isolated plugins for namespace '%s'", name)
+		if err := child.Init(log.WithLogField(ctx, "ns", name), or.cancelCtx); err != nil {
+			return err
+		}
+		or.isolated[name] = child
+	}
+	return nil
+}
+
+// initIsolatedPlugins loads the plugins of an isolated namespace from its own config. Config records are not
+// merged from the database of the namespace, as those apply to the whole node.
+func (or *orchestrator) initIsolatedPlugins(ctx context.Context) (err error) {
+	pluginsConfig := or.namespaceConfig.SubPrefix(namespaceConfigPlugins)
+	sections := make(map[string]*isolatedPluginSection)
+	for _, name := range isolatedPluginSections {
+		section := &isolatedPluginSection{prefix: pluginsConfig.SubPrefix(name)}
+		if section.pluginType = section.prefix.GetString(namespaceConfigPluginType); section.pluginType == "" {
+			// Sections the namespace omits are inherited from the node-wide config
+			section.prefix = globalPluginSections[name]
+			section.inherited = true
+			if section.pluginType = section.prefix.GetString(namespaceConfigPluginType); section.pluginType == "" {
+				return i18n.NewError(ctx, i18n.MsgMissingNamespacePlugin, or.namespace, name)
+			}
+		}
+		sections[name] = section
+	}
+
+	if or.database == nil {
+		if or.database, err = difactory.GetPlugin(ctx, sections["database"].pluginType); err != nil {
+			return err
+		}
+	}
+	if err = or.database.Init(ctx, sections["database"].pluginPrefix(or.database), or); err != nil {
+		return err
+	}
+
+	if or.blockchain == nil {
+		if or.blockchain, err = bifactory.GetPlugin(ctx, sections["blockchain"].pluginType); err != nil {
+			return err
+		}
+	}
+	if err = or.blockchain.Init(ctx, sections["blockchain"].pluginPrefix(or.blockchain), &or.bc); err != nil {
+		return err
+	}
+
+	if or.publicstorage == nil {
+		if or.publicstorage, err = psfactory.GetPlugin(ctx, sections["publicstorage"].pluginType); err != nil {
+			return err
+		}
+	}
+	if err = or.publicstorage.Init(ctx, sections["publicstorage"].pluginPrefix(or.publicstorage), &or.bc); err != nil {
+		return err
+	}
+
+	if or.dataexchange == nil {
+		if or.dataexchange, err = dxfactory.GetPlugin(ctx, sections["dataexchange"].pluginType); err != nil {
+			return err
+		}
+	}
+	return or.dataexchange.Init(ctx, sections["dataexchange"].pluginPrefix(or.dataexchange), &or.bc)
+}
+
+type isolatedPluginSection struct {
+	prefix     config.Prefix
+	pluginType string
+	inherited  bool
+}
+
+// pluginPrefix returns the config of a plugin within its section. The keys of a plugin configured by the
+// namespace are initialized here, as that can only be done once the array entry of the namespace exists.
+func (s *isolatedPluginSection) pluginPrefix(plugin configurablePlugin) config.Prefix {
+	prefix := s.prefix.SubPrefix(plugin.Name())
+	if !s.inherited {
+		plugin.InitPrefix(prefix)
+	}
+	return prefix
+}
+
+func (or *orchestrator) startIsolatedNamespaces() error {
+	for _, child := range or.isolated {
+		if err := child.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
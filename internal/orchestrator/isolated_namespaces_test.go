@@ -0,0 +1,294 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testIsolatedNamespaceConf = `
+namespaces:
+  predefined:
+  - name: default
+  - name: ns2
+    description: Isolated namespace
+    plugins:
+      database:
+        type: sqlite3
+      blockchain:
+        type: ethereum
+      dataexchange:
+        type: https
+      publicstorage:
+        type: ipfs
+`
+
+type testIsolatedPlugins struct {
+	mdi *databasemocks.Plugin
+	mbi *blockchainmocks.Plugin
+	mps *publicstoragemocks.Plugin
+	mdx *dataexchangemocks.Plugin
+}
+
+func newTestIsolatedNamespace(or *testOrchestrator, conf string) *testIsolatedPlugins {
+	namespacesConfig = config.NewPluginConfig("namespaces.predefined").Array()
+	initNamespacesPrefixArray(namespacesConfig)
+	viper.SetConfigType("yaml")
+	err := viper.ReadConfig(strings.NewReader(conf))
+	if err != nil {
+		panic(err)
+	}
+	tip := &testIsolatedPlugins{
+		mdi: &databasemocks.Plugin{},
+		mbi: &blockchainmocks.Plugin{},
+		mps: &publicstoragemocks.Plugin{},
+		mdx: &dataexchangemocks.Plugin{},
+	}
+	or.isolatedPlugins = map[string]*Plugins{
+		"ns2": {
+			Database:      tip.mdi,
+			Blockchain:    tip.mbi,
+			PublicStorage: tip.mps,
+			DataExchange:  tip.mdx,
+		},
+	}
+	tip.mdi.On("Name").Return("mock-di").Maybe()
+	tip.mbi.On("Name").Return("mock-bi").Maybe()
+	tip.mps.On("Name").Return("mock-ps").Maybe()
+	tip.mdx.On("Name").Return("mock-dx").Maybe()
+	tip.mdi.On("InitPrefix", mock.Anything).Maybe()
+	tip.mbi.On("InitPrefix", mock.Anything).Maybe()
+	tip.mps.On("InitPrefix", mock.Anything).Maybe()
+	tip.mdx.On("InitPrefix", mock.Anything).Maybe()
+	return tip
+}
+
+func TestInitIsolatedNamespaces(t *testing.T) {
+	or := newTestOrchestrator()
+	tip := newTestIsolatedNamespace(or, testIsolatedNamespaceConf)
+	tip.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mdi.On("GetNamespace", mock.Anything, fftypes.SystemNamespace).Return(nil, nil)
+	tip.mdi.On("GetNamespace", mock.Anything, "ns2").Return(nil, nil)
+	tip.mdi.On("UpsertNamespace", mock.Anything, mock.MatchedBy(func(ns *fftypes.Namespace) bool {
+		return ns.Name == fftypes.SystemNamespace || (ns.Name == "ns2" && ns.Description == "Isolated namespace")
+	}), true).Return(nil)
+
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.NoError(t, err)
+
+	child := or.isolated["ns2"]
+	assert.NotNil(t, child)
+	assert.Equal(t, child, or.ForNamespace("ns2"))
+	assert.Equal(t, &or.orchestrator, or.ForNamespace("default"))
+	assert.Equal(t, tip.mdi, child.database)
+	assert.Equal(t, or.mii, child.identityPlugin)
+	assert.Empty(t, child.tokens)
+	assert.NotNil(t, child.events)
+	assert.Nil(t, child.isolated)
+
+	// Each orchestrator only sequences its own namespaces
+	assert.True(t, or.sequencesNamespace("default"))
+	assert.True(t, or.sequencesNamespace(fftypes.SystemNamespace))
+	assert.False(t, or.sequencesNamespace("ns2"))
+	assert.True(t, child.sequencesNamespace("ns2"))
+	assert.True(t, child.sequencesNamespace(fftypes.SystemNamespace))
+	assert.False(t, child.sequencesNamespace("default"))
+
+	tip.mdi.AssertExpectations(t)
+}
+
+func TestInitIsolatedNamespacesBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestIsolatedNamespace(or, strings.Replace(testIsolatedNamespaceConf, "name: ns2", "name: '!bad'", 1))
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.Regexp(t, "FF10131.*predefined\\[1\\]", err)
+}
+
+func TestInitIsolatedNamespacesDuplicate(t *testing.T) {
+	or := newTestOrchestrator()
+	tip := newTestIsolatedNamespace(or, testIsolatedNamespaceConf+`
+  - name: ns2
+    plugins:
+      database:
+        type: sqlite3
+`)
+	tip.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mdi.On("GetNamespace", mock.Anything, mock.Anything).Return(&fftypes.Namespace{}, nil)
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.Regexp(t, "FF10228.*ns2", err)
+}
+
+func TestInitIsolatedNamespacesInheritGlobalPlugins(t *testing.T) {
+	or := newTestOrchestrator()
+	tip := newTestIsolatedNamespace(or, `
+namespaces:
+  predefined:
+  - name: ns2
+    plugins:
+      database:
+        type: sqlite3
+`)
+	or.isolatedPlugins["ns2"].DataExchange = nil
+	config.Set(config.BlockchainType, "ethereum")
+	config.Set(config.PublicStorageType, "ipfs")
+	tip.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	// The plugin types of the omitted sections come from the node-wide config
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.EqualError(t, err, "pop")
+	tip.mdi.AssertCalled(t, "InitPrefix", mock.Anything)
+	tip.mbi.AssertNotCalled(t, "InitPrefix", mock.Anything)
+	tip.mps.AssertNotCalled(t, "InitPrefix", mock.Anything)
+
+}
+
+func TestInitIsolatedNamespacesInheritGlobalPluginType(t *testing.T) {
+	or := newTestOrchestrator()
+	tip := newTestIsolatedNamespace(or, strings.Replace(testIsolatedNamespaceConf, "type: https", "{}", 1))
+	or.isolatedPlugins["ns2"].DataExchange = nil
+	config.Set(config.DataexchangeType, "wrong")
+	tip.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	tip.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.Regexp(t, "FF10213.*wrong", err)
+}
+
+func TestInitIsolatedNamespacesMissingType(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestIsolatedNamespace(or, strings.Replace(testIsolatedNamespaceConf, "type: https", "{}", 1))
+	config.Set(config.DataexchangeType, "")
+	err := or.initIsolatedNamespaces(context.Background())
+	assert.Regexp(t, "FF10456.*ns2.*dataexchange", err)
+}
+
+func TestInitIsolatedNamespacesBadPluginTypes(t *testing.T) {
+	for _, test := range []struct {
+		pluginType string
+		errCode    string
+		unset      func(p *Plugins)
+	}{
+		{"sqlite3", "FF10122", func(p *Plugins) { p.Database = nil }},
+		{"ethereum", "FF10110", func(p *Plugins) { p.Blockchain = nil }},
+		{"ipfs", "FF10134", func(p *Plugins) { p.PublicStorage = nil }},
+		{"https", "FF10213", func(p *Plugins) { p.DataExchange = nil }},
+	} {
+		or := newTestOrchestrator()
+		tip := newTestIsolatedNamespace(or, strings.Replace(testIsolatedNamespaceConf, "type: "+test.pluginType, "type: wrong", 1))
+		test.unset(or.isolatedPlugins["ns2"])
+		tip.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		tip.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		tip.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		err := or.initIsolatedNamespaces(context.Background())
+		assert.Regexp(t, test.errCode+".*wrong", err)
+	}
+}
+
+func TestInitIsolatedNamespacesPluginInitFail(t *testing.T) {
+	for failing := 0; failing < 4; failing++ {
+		or := newTestOrchestrator()
+		tip := newTestIsolatedNamespace(or, testIsolatedNamespaceConf)
+		for i, m := range []*mock.Mock{&tip.mdi.Mock, &tip.mbi.Mock, &tip.mps.Mock, &tip.mdx.Mock} {
+			if i < failing {
+				m.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			} else if i == failing {
+				m.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+			}
+		}
+		err := or.initIsolatedNamespaces(context.Background())
+		assert.EqualError(t, err, "pop")
+	}
+}
+
+func TestGetPredefinedNamespacesExcludesIsolated(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "default"},
+		{"name": "ns2"},
+	})
+	or.isolated = map[string]*orchestrator{"ns2": {namespace: "ns2"}}
+	nsList, err := or.getPrefdefinedNamespaces(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, nsList, 2)
+	assert.Equal(t, fftypes.SystemNamespace, nsList[0].Name)
+	assert.Equal(t, "default", nsList[1].Name)
+}
+
+func TestStartStopIsolatedNamespaces(t *testing.T) {
+	or := newTestOrchestrator()
+	child := newTestOrchestrator()
+	or.isolated = map[string]*orchestrator{"ns2": &child.orchestrator}
+	for _, tor := range []*testOrchestrator{or, child} {
+		tor.mbi.On("Start").Return(nil)
+		tor.mba.On("Start").Return(nil)
+		tor.mnm.On("Start").Return(nil)
+		tor.mem.On("StartDispatch").Return(nil)
+		tor.mem.On("Start").Return(nil)
+		tor.mbm.On("Start").Return(nil)
+		tor.mpm.On("Start").Return(nil)
+		tor.mrm.On("Start").Return(nil)
+		tor.mti.On("Start").Return(nil)
+		tor.mba.On("WaitStop").Return(nil)
+		tor.mbm.On("WaitStop").Return(nil)
+	}
+	err := or.Start()
+	assert.NoError(t, err)
+	assert.True(t, child.started)
+	or.WaitStop()
+	assert.False(t, child.started)
+	child.mba.AssertExpectations(t)
+}
+
+func TestStartIsolatedNamespaceFail(t *testing.T) {
+	or := newTestOrchestrator()
+	child := newTestOrchestrator()
+	or.isolated = map[string]*orchestrator{"ns2": &child.orchestrator}
+	or.mbi.On("Start").Return(nil)
+	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(nil)
+	or.mem.On("StartDispatch").Return(nil)
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
+	or.mpm.On("Start").Return(nil)
+	or.mrm.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+	child.mbi.On("Start").Return(fmt.Errorf("pop"))
+	err := or.Start()
+	assert.EqualError(t, err, "pop")
+}
@@ -0,0 +1,159 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// legalHoldActor is the identity recorded against placing or removing a legal hold. An authenticated API caller
+// cannot record the action against another identity
+func legalHoldActor(ctx context.Context, supplied string) string {
+	if identity := auth.GetIdentity(ctx); identity != "" {
+		return identity
+	}
+	return supplied
+}
+
+func (or *orchestrator) verifyLegalHoldTarget(ctx context.Context, ns string, hold *fftypes.LegalHold, input *fftypes.LegalHoldInput) error {
+	switch hold.Type {
+	case fftypes.LegalHoldTypeMessage, fftypes.LegalHoldTypeData:
+		if input.Reference == nil {
+			return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "reference")
+		}
+		hold.Reference = input.Reference
+		targetNS := ""
+		if hold.Type == fftypes.LegalHoldTypeMessage {
+			msg, err := or.database.GetMessageByID(ctx, hold.Reference)
+			if err != nil {
+				return err
+			}
+			if msg != nil {
+				targetNS = msg.Header.Namespace
+			}
+		} else {
+			data, err := or.database.GetDataByID(ctx, hold.Reference, false)
+			if err != nil {
+				return err
+			}
+			if data != nil {
+				targetNS = data.Namespace
+			}
+		}
+		if targetNS != ns {
+			return i18n.NewError(ctx, i18n.MsgLegalHoldTargetNotFound, hold.Type, hold.Reference, ns)
+		}
+		return nil
+	case fftypes.LegalHoldTypeTopic:
+		hold.Topic = input.Topic
+		return fftypes.ValidateFFNameField(ctx, hold.Topic, "topic")
+	default:
+		return i18n.NewError(ctx, i18n.MsgUnknownFieldValue, "type", input.Type)
+	}
+}
+
+func (or *orchestrator) PlaceLegalHold(ctx context.Context, ns string, input *fftypes.LegalHoldInput) (*fftypes.LegalHold, error) {
+	if err := or.data.VerifyNamespaceExists(ctx, ns); err != nil {
+		return nil, err
+	}
+	hold := &fftypes.LegalHold{
+		ID:        fftypes.NewUUID(),
+		Namespace: ns,
+		Type:      input.Type.Lower(),
+		Reason:    input.Reason,
+		Active:    true,
+		Placed:    fftypes.Now(),
+		PlacedBy:  legalHoldActor(ctx, input.PlacedBy),
+	}
+	if hold.PlacedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "placedBy")
+	}
+	if err := or.verifyLegalHoldTarget(ctx, ns, hold, input); err != nil {
+		return nil, err
+	}
+	if err := or.database.InsertLegalHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Legal hold '%s' placed on %s ref=%s topic=%s by '%s'", hold.ID, hold.Type, hold.Reference, hold.Topic, hold.PlacedBy)
+	return hold, nil
+}
+
+func (or *orchestrator) RemoveLegalHold(ctx context.Context, ns, id string, removal *fftypes.LegalHoldRemoval) (*fftypes.LegalHold, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	removedBy := legalHoldActor(ctx, removal.RemovedBy)
+	if removedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "removedBy")
+	}
+
+	var hold *fftypes.LegalHold
+	err = or.database.RunAsRetryableGroup(ctx, func(ctx context.Context) (err error) {
+		if hold, err = or.getLegalHoldByID(ctx, ns, u); err != nil {
+			return err
+		}
+		if !hold.Active {
+			return i18n.NewError(ctx, i18n.MsgLegalHoldRemoved, u, hold.RemovedBy)
+		}
+
+		hold.Active = false
+		hold.Removed = fftypes.Now()
+		hold.RemovedBy = removedBy
+		hold.Comment = removal.Comment
+		update := database.LegalHoldQueryFactory.NewUpdate(ctx).
+			Set("active", hold.Active).
+			Set("removed", hold.Removed).
+			Set("removedby", hold.RemovedBy).
+			Set("comment", hold.Comment)
+		return or.database.UpdateLegalHold(ctx, u, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Legal hold '%s' removed by '%s'", u, hold.RemovedBy)
+	return hold, nil
+}
+
+func (or *orchestrator) getLegalHoldByID(ctx context.Context, ns string, id *fftypes.UUID) (*fftypes.LegalHold, error) {
+	hold, err := or.database.GetLegalHoldByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil || hold.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgLegalHoldNotFound, id, ns)
+	}
+	return hold, nil
+}
+
+func (or *orchestrator) GetLegalHoldByID(ctx context.Context, ns, id string) (*fftypes.LegalHold, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	return or.getLegalHoldByID(ctx, ns, u)
+}
+
+func (or *orchestrator) GetLegalHolds(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.LegalHold, *database.FilterResult, error) {
+	return or.database.GetLegalHolds(ctx, or.scopeNS(ns, filter))
+}
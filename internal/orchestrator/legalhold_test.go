@@ -0,0 +1,305 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func passthroughRunAsGroup(mdi *databasemocks.Plugin) {
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	rrg := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything).Maybe()
+	rrg.RunFn = func(a mock.Arguments) {
+		rrg.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+}
+
+func TestPlaceLegalHoldMessage(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID, Namespace: "ns1"},
+	}, nil)
+	or.mdi.On("InsertLegalHold", mock.Anything, mock.MatchedBy(func(hold *fftypes.LegalHold) bool {
+		return hold.Active && *hold.Reference == *msgID && hold.PlacedBy == "counsel1"
+	})).Return(nil)
+	hold, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      "Message",
+		Reference: msgID,
+		Reason:    "case 1234",
+		PlacedBy:  "counsel1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.LegalHoldTypeMessage, hold.Type)
+	or.mdi.AssertExpectations(t)
+}
+
+func TestPlaceLegalHoldDataAuthenticated(t *testing.T) {
+	or := newTestOrchestrator()
+	dataID := fftypes.NewUUID()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetDataByID", mock.Anything, dataID, false).Return(&fftypes.Data{ID: dataID, Namespace: "ns1"}, nil)
+	or.mdi.On("InsertLegalHold", mock.Anything, mock.Anything).Return(nil)
+	ctx := auth.WithIdentity(context.Background(), "CN=counsel2")
+	hold, err := or.PlaceLegalHold(ctx, "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeData,
+		Reference: dataID,
+		PlacedBy:  "someone-else",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "CN=counsel2", hold.PlacedBy)
+}
+
+func TestPlaceLegalHoldTopic(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("InsertLegalHold", mock.Anything, mock.Anything).Return(nil)
+	hold, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeTopic,
+		Reference: fftypes.NewUUID(),
+		Topic:     "topic1",
+		PlacedBy:  "counsel1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "topic1", hold.Topic)
+	assert.Nil(t, hold.Reference)
+}
+
+func TestPlaceLegalHoldBadNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "!wrong").Return(fmt.Errorf("pop"))
+	_, err := or.PlaceLegalHold(context.Background(), "!wrong", &fftypes.LegalHoldInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPlaceLegalHoldMissingPlacedBy(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:  fftypes.LegalHoldTypeTopic,
+		Topic: "topic1",
+	})
+	assert.Regexp(t, "FF10140.*placedBy", err)
+}
+
+func TestPlaceLegalHoldBadType(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:     "batch",
+		PlacedBy: "counsel1",
+	})
+	assert.Regexp(t, "FF10132.*batch", err)
+}
+
+func TestPlaceLegalHoldBadTopic(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:     fftypes.LegalHoldTypeTopic,
+		Topic:    "!bad",
+		PlacedBy: "counsel1",
+	})
+	assert.Regexp(t, "FF10131.*topic", err)
+}
+
+func TestPlaceLegalHoldMissingReference(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:     fftypes.LegalHoldTypeMessage,
+		PlacedBy: "counsel1",
+	})
+	assert.Regexp(t, "FF10140.*reference", err)
+}
+
+func TestPlaceLegalHoldGetMessageFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeMessage,
+		Reference: fftypes.NewUUID(),
+		PlacedBy:  "counsel1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPlaceLegalHoldMessageWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	msgID := fftypes.NewUUID()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID, Namespace: "ns2"},
+	}, nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeMessage,
+		Reference: msgID,
+		PlacedBy:  "counsel1",
+	})
+	assert.Regexp(t, "FF10411.*message", err)
+}
+
+func TestPlaceLegalHoldGetDataFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetDataByID", mock.Anything, mock.Anything, false).Return(nil, fmt.Errorf("pop"))
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeData,
+		Reference: fftypes.NewUUID(),
+		PlacedBy:  "counsel1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPlaceLegalHoldDataNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("GetDataByID", mock.Anything, mock.Anything, false).Return(nil, nil)
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:      fftypes.LegalHoldTypeData,
+		Reference: fftypes.NewUUID(),
+		PlacedBy:  "counsel1",
+	})
+	assert.Regexp(t, "FF10411.*data", err)
+}
+
+func TestPlaceLegalHoldInsertFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mdi.On("InsertLegalHold", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := or.PlaceLegalHold(context.Background(), "ns1", &fftypes.LegalHoldInput{
+		Type:     fftypes.LegalHoldTypeTopic,
+		Topic:    "topic1",
+		PlacedBy: "counsel1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRemoveLegalHold(t *testing.T) {
+	or := newTestOrchestrator()
+	passthroughRunAsGroup(or.mdi)
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(&fftypes.LegalHold{
+		ID: u, Namespace: "ns1", Active: true, PlacedBy: "counsel1",
+	}, nil)
+	or.mdi.On("UpdateLegalHold", mock.Anything, u, mock.Anything).Return(nil)
+	hold, err := or.RemoveLegalHold(context.Background(), "ns1", u.String(), &fftypes.LegalHoldRemoval{
+		RemovedBy: "counsel2",
+		Comment:   "case settled",
+	})
+	assert.NoError(t, err)
+	assert.False(t, hold.Active)
+	assert.NotNil(t, hold.Removed)
+	assert.Equal(t, "counsel2", hold.RemovedBy)
+	assert.Equal(t, "case settled", hold.Comment)
+}
+
+func TestRemoveLegalHoldBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RemoveLegalHold(context.Background(), "ns1", "bad", &fftypes.LegalHoldRemoval{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRemoveLegalHoldMissingRemovedBy(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RemoveLegalHold(context.Background(), "ns1", fftypes.NewUUID().String(), &fftypes.LegalHoldRemoval{})
+	assert.Regexp(t, "FF10140.*removedBy", err)
+}
+
+func TestRemoveLegalHoldNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	passthroughRunAsGroup(or.mdi)
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(&fftypes.LegalHold{ID: u, Namespace: "ns2", Active: true}, nil)
+	_, err := or.RemoveLegalHold(context.Background(), "ns1", u.String(), &fftypes.LegalHoldRemoval{RemovedBy: "counsel2"})
+	assert.Regexp(t, "FF10409", err)
+}
+
+func TestRemoveLegalHoldAlreadyRemoved(t *testing.T) {
+	or := newTestOrchestrator()
+	passthroughRunAsGroup(or.mdi)
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(&fftypes.LegalHold{
+		ID: u, Namespace: "ns1", Active: false, RemovedBy: "counsel2",
+	}, nil)
+	_, err := or.RemoveLegalHold(context.Background(), "ns1", u.String(), &fftypes.LegalHoldRemoval{RemovedBy: "counsel3"})
+	assert.Regexp(t, "FF10410.*counsel2", err)
+}
+
+func TestRemoveLegalHoldUpdateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	passthroughRunAsGroup(or.mdi)
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(&fftypes.LegalHold{ID: u, Namespace: "ns1", Active: true}, nil)
+	or.mdi.On("UpdateLegalHold", mock.Anything, u, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := or.RemoveLegalHold(context.Background(), "ns1", u.String(), &fftypes.LegalHoldRemoval{RemovedBy: "counsel2"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetLegalHoldByID(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(&fftypes.LegalHold{ID: u, Namespace: "ns1"}, nil)
+	hold, err := or.GetLegalHoldByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, hold.ID)
+}
+
+func TestGetLegalHoldByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetLegalHoldByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetLegalHoldByIDFail(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetLegalHoldByID(context.Background(), "ns1", u.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetLegalHoldByIDNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetLegalHoldByID", mock.Anything, u).Return(nil, nil)
+	_, err := or.GetLegalHoldByID(context.Background(), "ns1", u.String())
+	assert.Regexp(t, "FF10409", err)
+}
+
+func TestGetLegalHolds(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetLegalHolds", mock.Anything, mock.Anything).Return([]*fftypes.LegalHold{}, nil, nil)
+	fb := database.LegalHoldQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("active", true))
+	_, _, err := or.GetLegalHolds(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,279 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	proofCheckMessageHash   = "message_hash"
+	proofCheckMessageStored = "message_stored"
+	proofCheckBatchManifest = "batch_manifest"
+	proofCheckBatchHash     = "batch_hash"
+	proofCheckPins          = "pins"
+	proofCheckTransaction   = "transaction"
+)
+
+func (or *orchestrator) getBatchPins(ctx context.Context, batchID *fftypes.UUID) ([]*fftypes.Bytes32, error) {
+	fb := database.PinQueryFactory.NewFilter(ctx)
+	pins, _, err := or.database.GetPins(ctx, fb.And(fb.Eq("batch", batchID)).Sort("index"))
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]*fftypes.Bytes32, len(pins))
+	for i, pin := range pins {
+		hashes[i] = pin.Hash
+	}
+	return hashes, nil
+}
+
+// batchManifest is always derived from the payload, so that the check of the batch hash also covers the manifest
+func batchManifest(batch *fftypes.Batch) []*fftypes.MessageRef {
+	return batch.Payload.Manifest().Messages
+}
+
+// messageContexts returns the pins that must have been written to the chain for each topic of the message.
+// For broadcast these are the hash of the topic, and for private messages the masked pins recorded on the message.
+func messageContexts(msg *fftypes.Message) []string {
+	if msg.Header.Group != nil {
+		return msg.Pins
+	}
+	contexts := make([]string, len(msg.Header.Topics))
+	for i, topic := range msg.Header.Topics {
+		hashBuilder := fftypes.NewDefaultHash()
+		hashBuilder.Write([]byte(topic))
+		contexts[i] = fftypes.HashResult(hashBuilder).String()
+	}
+	return contexts
+}
+
+// GetMessageProof gathers everything needed to show a message was pinned to the blockchain: the
+// position of the message in the batch manifest, the batch hash and pins, and the pinning transaction.
+func (or *orchestrator) GetMessageProof(ctx context.Context, ns, id string) (*fftypes.MessageProof, error) {
+	msg, err := or.getMessageByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Header.TxType != fftypes.TransactionTypeBatchPin || msg.BatchID == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgMessageNotPinned, msg.Header.ID)
+	}
+	batch, err := or.database.GetBatchByID(ctx, msg.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgBatchNotFound, msg.BatchID)
+	}
+	tx, err := or.database.GetTransactionByID(ctx, batch.Payload.TX.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.ProtocolID == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMessageNotPinned, msg.Header.ID)
+	}
+	pins, err := or.getBatchPins(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := batchManifest(batch)
+	index := -1
+	for i, ref := range manifest {
+		if ref.ID.Equals(msg.Header.ID) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgMessageNotInBatch, msg.Header.ID, batch.ID)
+	}
+
+	return &fftypes.MessageProof{
+		Message: msg,
+		Batch: &fftypes.MessageProofBatch{
+			ID:         batch.ID,
+			Hash:       batch.Hash,
+			PayloadRef: batch.PayloadRef,
+			Manifest:   manifest,
+			Index:      index,
+			Pins:       pins,
+		},
+		Transaction: &fftypes.MessageProofTransaction{
+			ID:          tx.ID,
+			Signer:      tx.Subject.Signer,
+			ProtocolID:  tx.ProtocolID,
+			BlockNumber: tx.Info.GetString("blockNumber"),
+			Info:        tx.Info,
+		},
+	}, nil
+}
+
+type proofVerifier struct {
+	ctx          context.Context
+	verification *fftypes.MessageProofVerification
+}
+
+func (pv *proofVerifier) check(name string, err error) bool {
+	check := &fftypes.MessageProofCheck{
+		Name:  name,
+		Valid: err == nil,
+	}
+	if err != nil {
+		check.Error = err.Error()
+		pv.verification.Valid = false
+	}
+	pv.verification.Checks = append(pv.verification.Checks, check)
+	return check.Valid
+}
+
+func (pv *proofVerifier) mismatch(what string, inProof, recorded interface{}) error {
+	return i18n.NewError(pv.ctx, i18n.MsgProofMismatch, what, inProof, recorded)
+}
+
+// VerifyMessageProof checks a proof (typically supplied by a third party) against the messages, batches,
+// pins and transactions recorded by this node from the blockchain. The individual checks are all
+// reported in the result, and it is only valid if every check passes.
+func (or *orchestrator) VerifyMessageProof(ctx context.Context, ns string, proof *fftypes.MessageProof) (*fftypes.MessageProofVerification, error) {
+	if err := or.verifyNamespaceSyntax(ctx, ns); err != nil {
+		return nil, err
+	}
+	if proof.Message == nil || proof.Batch == nil || proof.Transaction == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidMessageProof)
+	}
+	pv := &proofVerifier{
+		ctx:          ctx,
+		verification: &fftypes.MessageProofVerification{Valid: true},
+	}
+
+	pv.check(proofCheckMessageHash, proof.Message.Verify(ctx))
+
+	msg, err := or.database.GetMessageByID(ctx, proof.Message.Header.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !pv.check(proofCheckMessageStored, pv.checkStoredMessage(ns, proof, msg)) {
+		return pv.verification, nil
+	}
+
+	batch, err := or.database.GetBatchByID(ctx, msg.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	if !pv.check(proofCheckBatchManifest, pv.checkManifest(proof, batch)) {
+		return pv.verification, nil
+	}
+	pv.check(proofCheckBatchHash, pv.checkBatchHash(proof, batch))
+
+	pins, err := or.getBatchPins(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	pv.check(proofCheckPins, pv.checkPins(proof, batch.Payload.Messages[proof.Batch.Index], pins))
+
+	tx, err := or.database.GetTransactionByID(ctx, batch.Payload.TX.ID)
+	if err != nil {
+		return nil, err
+	}
+	pv.check(proofCheckTransaction, pv.checkTransaction(proof, batch, tx))
+
+	return pv.verification, nil
+}
+
+func (pv *proofVerifier) checkStoredMessage(ns string, proof *fftypes.MessageProof, msg *fftypes.Message) error {
+	switch {
+	case msg == nil || msg.Header.Namespace != ns:
+		return i18n.NewError(pv.ctx, i18n.MsgProofNotFound, "message", proof.Message.Header.ID)
+	case !msg.Hash.Equals(proof.Message.Hash):
+		return pv.mismatch("message hash", proof.Message.Hash, msg.Hash)
+	case msg.BatchID == nil || !msg.BatchID.Equals(proof.Batch.ID):
+		return pv.mismatch("batch ID", proof.Batch.ID, msg.BatchID)
+	}
+	return nil
+}
+
+func (pv *proofVerifier) checkManifest(proof *fftypes.MessageProof, batch *fftypes.Batch) error {
+	if batch == nil {
+		return i18n.NewError(pv.ctx, i18n.MsgProofNotFound, "batch", proof.Batch.ID)
+	}
+	manifest := batchManifest(batch)
+	if len(manifest) != len(proof.Batch.Manifest) {
+		return pv.mismatch("manifest length", len(proof.Batch.Manifest), len(manifest))
+	}
+	for i, ref := range manifest {
+		if proof.Batch.Manifest[i] == nil || !ref.ID.Equals(proof.Batch.Manifest[i].ID) || !ref.Hash.Equals(proof.Batch.Manifest[i].Hash) {
+			return pv.mismatch("manifest entry", proof.Batch.Manifest[i], ref)
+		}
+	}
+	if proof.Batch.Index < 0 || proof.Batch.Index >= len(manifest) || !manifest[proof.Batch.Index].ID.Equals(proof.Message.Header.ID) {
+		return i18n.NewError(pv.ctx, i18n.MsgMessageNotInBatch, proof.Message.Header.ID, batch.ID)
+	}
+	return nil
+}
+
+func (pv *proofVerifier) checkBatchHash(proof *fftypes.MessageProof, batch *fftypes.Batch) error {
+	payloadHash, err := batch.CalcHash(pv.ctx)
+	if err != nil {
+		return err
+	}
+	if !payloadHash.Equals(batch.Hash) {
+		return pv.mismatch("batch payload hash", payloadHash, batch.Hash)
+	}
+	if !batch.Hash.Equals(proof.Batch.Hash) {
+		return pv.mismatch("batch hash", proof.Batch.Hash, batch.Hash)
+	}
+	return nil
+}
+
+func (pv *proofVerifier) checkPins(proof *fftypes.MessageProof, batchMsg *fftypes.Message, pins []*fftypes.Bytes32) error {
+	if len(pins) != len(proof.Batch.Pins) {
+		return pv.mismatch("number of pins", len(proof.Batch.Pins), len(pins))
+	}
+	pinned := make(map[string]bool)
+	for i, pin := range pins {
+		if !pin.Equals(proof.Batch.Pins[i]) {
+			return pv.mismatch("pin", proof.Batch.Pins[i], pin)
+		}
+		pinned[pin.String()] = true
+	}
+	for i, context := range messageContexts(batchMsg) {
+		if !pinned[context] {
+			return i18n.NewError(pv.ctx, i18n.MsgProofPinMissing, context, batchMsg.Header.Topics[i])
+		}
+	}
+	return nil
+}
+
+func (pv *proofVerifier) checkTransaction(proof *fftypes.MessageProof, batch *fftypes.Batch, tx *fftypes.Transaction) error {
+	switch {
+	case tx == nil || tx.ProtocolID == "":
+		return i18n.NewError(pv.ctx, i18n.MsgProofNotFound, "transaction", proof.Transaction.ID)
+	case !tx.ID.Equals(proof.Transaction.ID):
+		return pv.mismatch("transaction ID", proof.Transaction.ID, tx.ID)
+	case tx.Subject.Type != fftypes.TransactionTypeBatchPin || !tx.Subject.Reference.Equals(batch.ID):
+		return pv.mismatch("transaction reference", batch.ID, tx.Subject.Reference)
+	case tx.ProtocolID != proof.Transaction.ProtocolID:
+		return pv.mismatch("protocol ID", proof.Transaction.ProtocolID, tx.ProtocolID)
+	case tx.Subject.Signer != proof.Transaction.Signer:
+		return pv.mismatch("signer", proof.Transaction.Signer, tx.Subject.Signer)
+	}
+	return nil
+}
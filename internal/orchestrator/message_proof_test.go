@@ -0,0 +1,529 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testProofFixture struct {
+	msg   *fftypes.Message
+	batch *fftypes.Batch
+	tx    *fftypes.Transaction
+	pins  []*fftypes.Pin
+}
+
+func topicHash(topic string) *fftypes.Bytes32 {
+	var b32 fftypes.Bytes32 = sha256.Sum256([]byte(topic))
+	return &b32
+}
+
+func newTestProofFixture(t *testing.T) *testProofFixture {
+	batchID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	msgs := make([]*fftypes.Message, 2)
+	for i := range msgs {
+		msgs[i] = &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        fftypes.NewUUID(),
+				Namespace: "ns1",
+				Type:      fftypes.MessageTypeBroadcast,
+				TxType:    fftypes.TransactionTypeBatchPin,
+				Identity:  fftypes.Identity{Author: "did:firefly:org/org1", Key: "0x12345"},
+				Topics:    fftypes.FFNameArray{fmt.Sprintf("topic%d", i)},
+			},
+			BatchID: batchID,
+		}
+		err := msgs[i].Seal(context.Background())
+		assert.NoError(t, err)
+	}
+	batch := &fftypes.Batch{
+		ID:         batchID,
+		Namespace:  "ns1",
+		PayloadRef: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeBatchPin,
+				ID:   txID,
+			},
+			Messages: msgs,
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	return &testProofFixture{
+		msg:   msgs[1],
+		batch: batch,
+		tx: &fftypes.Transaction{
+			ID: txID,
+			Subject: fftypes.TransactionSubject{
+				Type:      fftypes.TransactionTypeBatchPin,
+				Namespace: "ns1",
+				Signer:    "0x12345",
+				Reference: batchID,
+			},
+			ProtocolID: "0x2a3b4c",
+			Info: fftypes.JSONObject{
+				"blockNumber":      "12345",
+				"transactionIndex": "0",
+			},
+		},
+		pins: []*fftypes.Pin{
+			{Hash: topicHash("topic0"), Batch: batchID, Index: 0},
+			{Hash: topicHash("topic1"), Batch: batchID, Index: 1},
+		},
+	}
+}
+
+func (f *testProofFixture) mock(or *testOrchestrator) {
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(f.tx, nil)
+	or.mdi.On("GetPins", mock.Anything, mock.Anything).Return(f.pins, nil, nil)
+}
+
+func (f *testProofFixture) proof(t *testing.T) *fftypes.MessageProof {
+	or := newTestOrchestrator()
+	f.mock(or)
+	proof, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.NoError(t, err)
+	return proof
+}
+
+func failedChecks(v *fftypes.MessageProofVerification) []string {
+	failed := []string{}
+	for _, c := range v.Checks {
+		if !c.Valid {
+			failed = append(failed, c.Name)
+		}
+	}
+	return failed
+}
+
+func TestGetMessageProofOk(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	f.mock(or)
+
+	proof, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, f.msg, proof.Message)
+	assert.Equal(t, f.batch.ID, proof.Batch.ID)
+	assert.Equal(t, f.batch.Hash, proof.Batch.Hash)
+	assert.Equal(t, f.batch.PayloadRef, proof.Batch.PayloadRef)
+	assert.Equal(t, 1, proof.Batch.Index)
+	assert.Len(t, proof.Batch.Manifest, 2)
+	assert.Equal(t, *f.msg.Hash, *proof.Batch.Manifest[1].Hash)
+	assert.Equal(t, []*fftypes.Bytes32{topicHash("topic0"), topicHash("topic1")}, proof.Batch.Pins)
+	assert.Equal(t, f.tx.ID, proof.Transaction.ID)
+	assert.Equal(t, "0x12345", proof.Transaction.Signer)
+	assert.Equal(t, "0x2a3b4c", proof.Transaction.ProtocolID)
+	assert.Equal(t, "12345", proof.Transaction.BlockNumber)
+	or.mdi.AssertExpectations(t)
+}
+
+func TestGetMessageProofBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetMessageProof(context.Background(), "ns1", "!bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetMessageProofNotBatchPin(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	f.msg.Header.TxType = fftypes.TransactionTypeNone
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.Regexp(t, "FF10311", err)
+}
+
+func TestGetMessageProofBatchFail(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessageProofBatchNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(nil, nil)
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.Regexp(t, "FF10209", err)
+}
+
+func TestGetMessageProofTXFail(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessageProofTXNotConfirmed(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	f.tx.ProtocolID = ""
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(f.tx, nil)
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.Regexp(t, "FF10311", err)
+}
+
+func TestGetMessageProofPinsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(f.tx, nil)
+	or.mdi.On("GetPins", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessageProofNotInBatch(t *testing.T) {
+	or := newTestOrchestrator()
+	f := newTestProofFixture(t)
+	f.batch.Payload.Messages = f.batch.Payload.Messages[0:1]
+	f.mock(or)
+	_, err := or.GetMessageProof(context.Background(), "ns1", f.msg.Header.ID.String())
+	assert.Regexp(t, "FF10312", err)
+}
+
+func TestVerifyMessageProofOk(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.True(t, v.Valid)
+	assert.Len(t, v.Checks, 6)
+	assert.Empty(t, failedChecks(v))
+	or.mdi.AssertExpectations(t)
+}
+
+func TestVerifyMessageProofPrivateOk(t *testing.T) {
+	f := newTestProofFixture(t)
+	f.msg.Header.Group = fftypes.NewRandB32()
+	maskedPin := fftypes.NewRandB32()
+	f.msg.Pins = fftypes.FFNameArray{maskedPin.String()}
+	f.pins[1].Hash = maskedPin
+	err := f.msg.Seal(context.Background())
+	assert.NoError(t, err)
+	f.batch.Hash = f.batch.Payload.Hash()
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.True(t, v.Valid)
+}
+
+func TestVerifyMessageProofBadNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.VerifyMessageProof(context.Background(), "!wrong", &fftypes.MessageProof{})
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestVerifyMessageProofMissingParts(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.VerifyMessageProof(context.Background(), "ns1", &fftypes.MessageProof{
+		Message: &fftypes.Message{},
+	})
+	assert.Regexp(t, "FF10313", err)
+}
+
+func TestVerifyMessageProofMessageFail(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestVerifyMessageProofMessageNotStored(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(nil, nil)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.False(t, v.Valid)
+	assert.Len(t, v.Checks, 2)
+	assert.Equal(t, []string{"message_stored"}, failedChecks(v))
+	assert.Regexp(t, "FF10315", v.Checks[1].Error)
+}
+
+func TestVerifyMessageProofMessageHashMismatch(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Message = &fftypes.Message{
+		Header: f.msg.Header,
+		Hash:   fftypes.NewRandB32(),
+	}
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.False(t, v.Valid)
+	assert.Equal(t, []string{"message_hash", "message_stored"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*message hash", v.Checks[1].Error)
+}
+
+func TestVerifyMessageProofBatchIDMismatch(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.ID = fftypes.NewUUID()
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"message_stored"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*batch ID", v.Checks[1].Error)
+}
+
+func TestVerifyMessageProofBatchFail(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestVerifyMessageProofBatchNotFound(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(nil, nil)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Len(t, v.Checks, 3)
+	assert.Equal(t, []string{"batch_manifest"}, failedChecks(v))
+	assert.Regexp(t, "FF10315", v.Checks[2].Error)
+}
+
+func TestVerifyMessageProofManifestLength(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Manifest = proof.Batch.Manifest[1:]
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_manifest"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*manifest length", v.Checks[2].Error)
+}
+
+func TestVerifyMessageProofManifestEntry(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Manifest[0] = nil
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_manifest"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*manifest entry", v.Checks[2].Error)
+}
+
+func TestVerifyMessageProofManifestIndex(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Index = 0
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_manifest"}, failedChecks(v))
+	assert.Regexp(t, "FF10312", v.Checks[2].Error)
+}
+
+func TestVerifyMessageProofBatchPayloadTampered(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	f.batch.PayloadRef = "changed"
+	f.batch.Payload.TX.Type = fftypes.TransactionTypeNone
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_hash"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*batch payload hash", v.Checks[3].Error)
+}
+
+func TestVerifyMessageProofBatchHashAlgorithmUnknown(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	f.batch.HashAlgorithm = "md5"
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_hash"}, failedChecks(v))
+	assert.Regexp(t, "FF10406", v.Checks[3].Error)
+}
+
+func TestVerifyMessageProofBatchHashMismatch(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Hash = fftypes.NewRandB32()
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"batch_hash"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*batch hash", v.Checks[3].Error)
+}
+
+func TestVerifyMessageProofPinsFail(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetPins", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestVerifyMessageProofPinCount(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Pins = proof.Batch.Pins[1:]
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pins"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*number of pins", v.Checks[4].Error)
+}
+
+func TestVerifyMessageProofPinMismatch(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+	proof.Batch.Pins[0] = fftypes.NewRandB32()
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pins"}, failedChecks(v))
+	assert.Regexp(t, "FF10314.*pin", v.Checks[4].Error)
+}
+
+func TestVerifyMessageProofPinMissing(t *testing.T) {
+	f := newTestProofFixture(t)
+	f.pins[1].Hash = fftypes.NewRandB32()
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	f.mock(or)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pins"}, failedChecks(v))
+	assert.Regexp(t, "FF10316.*topic1", v.Checks[4].Error)
+}
+
+func TestVerifyMessageProofTXFail(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetPins", mock.Anything, mock.Anything).Return(f.pins, nil, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(nil, fmt.Errorf("pop"))
+	_, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestVerifyMessageProofTXNotFound(t *testing.T) {
+	f := newTestProofFixture(t)
+	proof := f.proof(t)
+
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, f.msg.Header.ID).Return(f.msg, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, f.batch.ID).Return(f.batch, nil)
+	or.mdi.On("GetPins", mock.Anything, mock.Anything).Return(f.pins, nil, nil)
+	or.mdi.On("GetTransactionByID", mock.Anything, f.tx.ID).Return(nil, nil)
+	v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"transaction"}, failedChecks(v))
+	assert.Regexp(t, "FF10315", v.Checks[5].Error)
+}
+
+func TestVerifyMessageProofTXMismatches(t *testing.T) {
+	tamper := map[string]func(proof *fftypes.MessageProof, tx *fftypes.Transaction){
+		"transaction ID": func(proof *fftypes.MessageProof, tx *fftypes.Transaction) {
+			proof.Transaction.ID = fftypes.NewUUID()
+		},
+		"transaction reference": func(proof *fftypes.MessageProof, tx *fftypes.Transaction) {
+			tx.Subject.Reference = fftypes.NewUUID()
+		},
+		"protocol ID": func(proof *fftypes.MessageProof, tx *fftypes.Transaction) {
+			proof.Transaction.ProtocolID = "0xfeedbeef"
+		},
+		"signer": func(proof *fftypes.MessageProof, tx *fftypes.Transaction) {
+			proof.Transaction.Signer = "0x67890"
+		},
+	}
+	for what, fn := range tamper {
+		f := newTestProofFixture(t)
+		proof := f.proof(t)
+		fn(proof, f.tx)
+
+		or := newTestOrchestrator()
+		f.mock(or)
+		v, err := or.VerifyMessageProof(context.Background(), "ns1", proof)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"transaction"}, failedChecks(v), what)
+		assert.Regexp(t, "FF10314.*"+what, v.Checks[5].Error)
+	}
+}
@@ -19,13 +19,17 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/hyperledger/firefly/internal/assets"
 	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/batchpin"
 	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
 	"github.com/hyperledger/firefly/internal/broadcast"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/contracts"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/database/difactory"
 	"github.com/hyperledger/firefly/internal/dataexchange/dxfactory"
@@ -36,26 +40,37 @@ import (
 	"github.com/hyperledger/firefly/internal/identity/iifactory"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/networkmap"
+	"github.com/hyperledger/firefly/internal/operations"
+	"github.com/hyperledger/firefly/internal/policy"
+	"github.com/hyperledger/firefly/internal/policy/pefactory"
 	"github.com/hyperledger/firefly/internal/privatemessaging"
 	"github.com/hyperledger/firefly/internal/publicstorage/psfactory"
+	"github.com/hyperledger/firefly/internal/quota"
+	"github.com/hyperledger/firefly/internal/rollup"
+	"github.com/hyperledger/firefly/internal/secrets"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/tokens/tifactory"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	idplugin "github.com/hyperledger/firefly/pkg/identity"
+	policyplugin "github.com/hyperledger/firefly/pkg/policy"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 	"github.com/hyperledger/firefly/pkg/tokens"
 )
 
 var (
 	blockchainConfig    = config.NewPluginConfig("blockchain")
+	blockchainsConfig   = config.NewPluginConfig("blockchains").Array()
 	databaseConfig      = config.NewPluginConfig("database")
 	identityConfig      = config.NewPluginConfig("identity")
 	publicstorageConfig = config.NewPluginConfig("publicstorage")
 	dataexchangeConfig  = config.NewPluginConfig("dataexchange")
+	policyConfig        = config.NewPluginConfig("policy")
 	tokensConfig        = config.NewPluginConfig("tokens").Array()
+	namespacesConfig    = config.NewPluginConfig("namespaces.predefined").Array()
 )
 
 // Orchestrator is the main interface behind the API, implementing the actions
@@ -69,7 +84,13 @@ type Orchestrator interface {
 	NetworkMap() networkmap.Manager
 	Data() data.Manager
 	Assets() assets.Manager
+	Contracts() contracts.Manager
+	Operations() operations.Manager
+	Policy() policy.Manager
+	Rollup() rollup.Manager
 	IsPreInit() bool
+	IsStandby() bool
+	Promote(ctx context.Context) (*fftypes.NodeStatus, error)
 
 	// Status
 	GetStatus(ctx context.Context) (*fftypes.NodeStatus, error)
@@ -91,12 +112,19 @@ type Orchestrator interface {
 	GetMessageByIDWithData(ctx context.Context, ns, id string) (*fftypes.MessageInOut, error)
 	GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
 	GetMessagesWithData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.MessageInOut, *database.FilterResult, error)
+	GetMessagesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
+	GetMessagesWithDataAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.MessageInOut, *database.FilterResult, error)
 	GetMessageTransaction(ctx context.Context, ns, id string) (*fftypes.Transaction, error)
 	GetMessageOperations(ctx context.Context, ns, id string) ([]*fftypes.Operation, *database.FilterResult, error)
 	GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error)
+	GetMessageReceipts(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.MessageReceipt, *database.FilterResult, error)
+	GetMessageHistory(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.MessageTransition, *database.FilterResult, error)
+	GetMessageTopics(ctx context.Context, ns, id string) ([]*fftypes.MessageTopicStatus, error)
 	GetMessageData(ctx context.Context, ns, id string) ([]*fftypes.Data, error)
+	GetMessageProof(ctx context.Context, ns, id string) (*fftypes.MessageProof, error)
 	GetMessagesForData(ctx context.Context, ns, dataID string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
 	GetBatchByID(ctx context.Context, ns, id string) (*fftypes.Batch, error)
+	GetBatchManifest(ctx context.Context, ns, id string) (*fftypes.BatchManifest, error)
 	GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error)
 	GetDataByID(ctx context.Context, ns, id string) (*fftypes.Data, error)
 	GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error)
@@ -107,9 +135,41 @@ type Orchestrator interface {
 	GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error)
 	GetEventByID(ctx context.Context, ns, id string) (*fftypes.Event, error)
 	GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error)
+	GetBlockchainEventByID(ctx context.Context, ns, id string) (*fftypes.BlockchainEvent, error)
+	GetBlockchainEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.BlockchainEvent, *database.FilterResult, error)
+	ResolveAsOf(ctx context.Context, ns, asOf string) (*fftypes.FFTime, error)
 
 	// Charts
 	GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogram, error)
+	GetChartTypeHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogramTyped, error)
+	GetChartTopTopics(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error)
+	GetChartTopAuthors(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error)
+	GetChartActivity(ctx context.Context, ns string, limit int) ([]*fftypes.Event, error)
+
+	// Signing activity
+	GetSigningActivity(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.SigningActivity, *database.FilterResult, error)
+	GetSigningKeyReport(ctx context.Context, ns, key string, startTime, endTime *fftypes.FFTime) (*fftypes.SigningKeyReport, error)
+
+	// Identity usage
+	GetIdentityUsage(ctx context.Context, ns, identity string, startTime, endTime *fftypes.FFTime) (*fftypes.IdentityUsage, error)
+
+	// Pseudonyms
+	GetPseudonyms(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Pseudonym, *database.FilterResult, error)
+	GetPseudonymByID(ctx context.Context, ns, id string) (*fftypes.Pseudonym, error)
+	ResolvePseudonymAuthor(ctx context.Context, ns, id string) (*fftypes.PseudonymAuthor, error)
+
+	// Delegations
+	GetDelegations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Delegation, *database.FilterResult, error)
+	GetDelegationByID(ctx context.Context, ns, id string) (*fftypes.Delegation, error)
+
+	// Legal holds
+	PlaceLegalHold(ctx context.Context, ns string, input *fftypes.LegalHoldInput) (*fftypes.LegalHold, error)
+	RemoveLegalHold(ctx context.Context, ns, id string, removal *fftypes.LegalHoldRemoval) (*fftypes.LegalHold, error)
+	GetLegalHoldByID(ctx context.Context, ns, id string) (*fftypes.LegalHold, error)
+	GetLegalHolds(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.LegalHold, *database.FilterResult, error)
+
+	// Transaction costs
+	GetTransactionCostReport(ctx context.Context, ns, groupBy string, startTime, endTime *fftypes.FFTime) (*fftypes.TransactionCostReport, error)
 
 	// Config Management
 	GetConfig(ctx context.Context) fftypes.JSONObject
@@ -118,9 +178,41 @@ type Orchestrator interface {
 	PutConfigRecord(ctx context.Context, key string, configRecord fftypes.Byteable) (outputValue fftypes.Byteable, err error)
 	DeleteConfigRecord(ctx context.Context, key string) (err error)
 	ResetConfig(ctx context.Context)
+	ReloadConfig(ctx context.Context, update fftypes.JSONObject) (fftypes.JSONObject, error)
+	GetLogLevels(ctx context.Context) *log.Levels
+	SetComponentLogLevel(ctx context.Context, component string, level *log.ComponentLevel) (*log.Levels, error)
+	TailLogs(ctx context.Context, correlationID string) (io.ReadCloser, error)
+
+	// Feature flags
+	CheckFeature(ctx context.Context, ns string, feature fftypes.Feature) error
+	GetNamespaceFeatures(ctx context.Context, ns string) (*fftypes.NamespaceFeatures, error)
+
+	// Definition export/import, for migrating a namespace to a new network
+	ExportDefinitions(ctx context.Context, ns string) (*fftypes.DefinitionsExport, error)
+	ImportDefinitions(ctx context.Context, ns string, export *fftypes.DefinitionsExport) ([]*fftypes.DefinitionImportResult, error)
+
+	// Disaster recovery
+	ReplayBlockchain(ctx context.Context, replay *fftypes.BlockchainReplay) (*fftypes.BlockchainReplay, error)
+	CheckConsistency(ctx context.Context, input *fftypes.ConsistencyCheckInput) (*fftypes.ConsistencyReport, error)
+	GetConsistencyReport(ctx context.Context) (*fftypes.ConsistencyReport, error)
+
+	// Audit log of state-changing API calls
+	RecordAudit(ctx context.Context, record *fftypes.AuditRecord) error
+	GetAuditRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.AuditRecord, *database.FilterResult, error)
+	GetAuditRecordByID(ctx context.Context, id string) (*fftypes.AuditRecord, error)
+
+	// WebSocket Management
+	GetWebSocketStatus(ctx context.Context) *fftypes.WebSocketStatus
+	CloseWebSocketConnection(ctx context.Context, id string) error
 
 	// Message Routing
 	RequestReply(ctx context.Context, ns string, msg *fftypes.MessageInOut) (reply *fftypes.MessageInOut, err error)
+
+	// Message Proofs
+	VerifyMessageProof(ctx context.Context, ns string, proof *fftypes.MessageProof) (*fftypes.MessageProofVerification, error)
+
+	// Isolated namespaces
+	ForNamespace(ns string) Orchestrator
 }
 
 type orchestrator struct {
@@ -129,6 +221,7 @@ type orchestrator struct {
 	started        bool
 	database       database.Plugin
 	blockchain     blockchain.Plugin
+	connectors     map[string]blockchain.Plugin
 	identity       identity.Manager
 	identityPlugin idplugin.Plugin
 	publicstorage  publicstorage.Plugin
@@ -142,22 +235,77 @@ type orchestrator struct {
 	data           data.Manager
 	syncasync      syncasync.Bridge
 	batchpin       batchpin.Submitter
+	quota          quota.Manager
+	policyPlugin   policyplugin.Plugin
+	policy         policy.Manager
+	preflight      txcommon.PreflightChecker
 	assets         assets.Manager
+	contracts      contracts.Manager
+	operations     operations.Manager
+	rollup         rollup.Manager
 	tokens         map[string]tokens.Plugin
+	features       *featureFlags
 	bc             boundCallbacks
+	connectorCBs   map[string]*boundCallbacks
 	preInitMode    bool
+	standbyMux     sync.Mutex
+	standby        bool
 	node           *fftypes.UUID
+
+	// Namespaces with isolated plugins each have a child orchestrator, with its own stack of plugins and managers
+	namespace       string
+	namespaceConfig config.Prefix
+	isolated        map[string]*orchestrator
+	isolatedPlugins map[string]*Plugins
+
+	consistencyCheckMux sync.Mutex
+	consistencyMux      sync.Mutex
+	consistencyReport   *fftypes.ConsistencyReport
+}
+
+// Plugins are plugin instances supplied directly to the orchestrator, rather than being
+// loaded from the factories by the type set in config. Nil plugins are loaded as normal.
+// BlockchainConnectors are keyed by the name of the connector in the blockchains config, and IsolatedNamespaces
+// by the name of a predefined namespace with its own plugins (only the database, blockchain, shared storage and
+// data exchange plugins are used for an isolated namespace).
+type Plugins struct {
+	Database             database.Plugin
+	Blockchain           blockchain.Plugin
+	BlockchainConnectors map[string]blockchain.Plugin
+	Identity             idplugin.Plugin
+	PublicStorage        publicstorage.Plugin
+	DataExchange         dataexchange.Plugin
+	Policy               policyplugin.Plugin
+	IsolatedNamespaces   map[string]*Plugins
 }
 
 func NewOrchestrator() Orchestrator {
-	or := &orchestrator{}
+	return NewOrchestratorWithPlugins(&Plugins{})
+}
+
+// NewOrchestratorWithPlugins creates an orchestrator using the supplied plugins, such as in-memory
+// implementations used for testing
+func NewOrchestratorWithPlugins(plugins *Plugins) Orchestrator {
+	or := &orchestrator{
+		database:        plugins.Database,
+		blockchain:      plugins.Blockchain,
+		connectors:      plugins.BlockchainConnectors,
+		identityPlugin:  plugins.Identity,
+		publicstorage:   plugins.PublicStorage,
+		dataexchange:    plugins.DataExchange,
+		policyPlugin:    plugins.Policy,
+		isolatedPlugins: plugins.IsolatedNamespaces,
+	}
 
 	// Initialize the config on all the factories
 	bifactory.InitPrefix(blockchainConfig)
+	bifactory.InitPrefixArray(blockchainsConfig)
 	difactory.InitPrefix(databaseConfig)
 	psfactory.InitPrefix(publicstorageConfig)
 	dxfactory.InitPrefix(dataexchangeConfig)
 	tifactory.InitPrefix(tokensConfig)
+	pefactory.InitPrefix(policyConfig)
+	initNamespacesPrefixArray(namespacesConfig)
 
 	return or
 }
@@ -165,13 +313,20 @@ func NewOrchestrator() Orchestrator {
 func (or *orchestrator) Init(ctx context.Context, cancelCtx context.CancelFunc) (err error) {
 	or.ctx = ctx
 	or.cancelCtx = cancelCtx
+	or.standby = config.GetBool(config.NodeStandby)
 	err = or.initPlugins(ctx)
 	if or.preInitMode {
 		return nil
 	}
+	if err == nil {
+		err = or.initUUIDVersion(ctx)
+	}
 	if err == nil {
 		err = or.initComponents(ctx)
 	}
+	if err == nil && or.namespace == "" {
+		err = or.initIsolatedNamespaces(ctx)
+	}
 	if err == nil {
 		err = or.initNamespaces(ctx)
 	}
@@ -179,6 +334,12 @@ func (or *orchestrator) Init(ctx context.Context, cancelCtx context.CancelFunc)
 	or.bc.bi = or.blockchain
 	or.bc.ei = or.events
 	or.bc.dx = or.dataexchange
+	or.bc.sequenced = or.sequencesNamespace
+	for _, cb := range or.connectorCBs {
+		cb.ei = or.events
+		cb.dx = or.dataexchange
+		cb.sequenced = or.sequencesNamespace
+	}
 	return err
 }
 
@@ -187,9 +348,21 @@ func (or *orchestrator) Start() error {
 		log.L(or.ctx).Infof("Orchestrator in pre-init mode, waiting for initialization")
 		return nil
 	}
-	err := or.blockchain.Start()
+	var err error
+	if config.GetBool(config.ConsistencyCheckOnStartup) {
+		_, err = or.CheckConsistency(or.ctx, &fftypes.ConsistencyCheckInput{
+			Repair: config.GetBool(config.ConsistencyRepair),
+		})
+	}
 	if err == nil {
-		err = or.batch.Start()
+		err = or.blockchain.Start()
+	}
+	if err == nil {
+		if or.IsStandby() {
+			log.L(or.ctx).Infof("Orchestrator in standby mode, batches and events will not be dispatched until promoted")
+		} else {
+			err = or.startOutbound()
+		}
 	}
 	if err == nil {
 		err = or.events.Start()
@@ -200,6 +373,9 @@ func (or *orchestrator) Start() error {
 	if err == nil {
 		err = or.messaging.Start()
 	}
+	if err == nil {
+		err = or.rollup.Start()
+	}
 	if err == nil {
 		for _, el := range or.tokens {
 			if err = el.Start(); err != nil {
@@ -207,14 +383,33 @@ func (or *orchestrator) Start() error {
 			}
 		}
 	}
+	if err == nil {
+		err = or.startIsolatedNamespaces()
+	}
 	or.started = true
 	return err
 }
 
+// startOutbound starts the components that send to the network, or deliver events to applications,
+// which do not run while in standby
+func (or *orchestrator) startOutbound() error {
+	err := or.batch.Start()
+	if err == nil {
+		err = or.networkmap.Start()
+	}
+	if err == nil {
+		err = or.events.StartDispatch()
+	}
+	return err
+}
+
 func (or *orchestrator) WaitStop() {
 	if !or.started {
 		return
 	}
+	for _, child := range or.isolated {
+		child.WaitStop()
+	}
 	if or.batch != nil {
 		or.batch.WaitStop()
 		or.batch = nil
@@ -254,6 +449,22 @@ func (or *orchestrator) Assets() assets.Manager {
 	return or.assets
 }
 
+func (or *orchestrator) Contracts() contracts.Manager {
+	return or.contracts
+}
+
+func (or *orchestrator) Operations() operations.Manager {
+	return or.operations
+}
+
+func (or *orchestrator) Policy() policy.Manager {
+	return or.policy
+}
+
+func (or *orchestrator) Rollup() rollup.Manager {
+	return or.rollup
+}
+
 func (or *orchestrator) initDatabaseCheckPreinit(ctx context.Context) (err error) {
 
 	if or.database == nil {
@@ -276,11 +487,34 @@ func (or *orchestrator) initDatabaseCheckPreinit(ctx context.Context) (err error
 		or.preInitMode = true
 		return nil
 	}
-	return config.MergeConfig(configRecords)
+	if err = config.MergeConfig(configRecords); err != nil {
+		return err
+	}
+	// Secret references in the records are resolved in the same way as those in the config file
+	return config.ReplaceStringValues(func(key, value string) (string, bool, error) {
+		return secrets.Resolve(ctx, key, value)
+	})
+}
+
+// initUUIDVersion selects the version of UUID generated for new objects, after any config records are merged.
+// Existing objects keep their IDs, and UUIDs of every version are accepted, so it can be changed at any time.
+func (or *orchestrator) initUUIDVersion(ctx context.Context) error {
+	version := fftypes.UUIDVersion(config.GetString(config.UUIDVersion)).Lower()
+	switch version {
+	case fftypes.UUIDVersion4, fftypes.UUIDVersion7:
+		fftypes.DefaultUUIDVersion = version
+		return nil
+	default:
+		return i18n.NewError(ctx, i18n.MsgUnknownUUIDVersion, version, fftypes.FFEnumValues("uuidversion"))
+	}
 }
 
 func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 
+	if or.namespace != "" {
+		return or.initIsolatedPlugins(ctx)
+	}
+
 	if err = or.initDatabaseCheckPreinit(ctx); err != nil {
 		return err
 	} else if or.preInitMode {
@@ -297,13 +531,17 @@ func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 		return err
 	}
 
-	if or.blockchain == nil {
-		biType := config.GetString(config.BlockchainType)
-		if or.blockchain, err = bifactory.GetPlugin(ctx, biType); err != nil {
+	if or.policyPlugin == nil {
+		peType := config.GetString(config.PolicyType)
+		if or.policyPlugin, err = pefactory.GetPlugin(ctx, peType); err != nil {
 			return err
 		}
 	}
-	if err = or.blockchain.Init(ctx, blockchainConfig.SubPrefix(or.blockchain.Name()), &or.bc); err != nil {
+	if err = or.policyPlugin.Init(ctx, policyConfig.SubPrefix(or.policyPlugin.Name())); err != nil {
+		return err
+	}
+
+	if err = or.initBlockchainPlugins(ctx); err != nil {
 		return err
 	}
 
@@ -313,7 +551,7 @@ func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 			return err
 		}
 	}
-	if err = or.publicstorage.Init(ctx, publicstorageConfig.SubPrefix(or.publicstorage.Name()), or); err != nil {
+	if err = or.publicstorage.Init(ctx, publicstorageConfig.SubPrefix(or.publicstorage.Name()), &or.bc); err != nil {
 		return err
 	}
 
@@ -371,8 +609,95 @@ func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 	return nil
 }
 
+func (or *orchestrator) initBlockchainPlugins(ctx context.Context) (err error) {
+	if or.blockchain == nil && blockchainsConfig.ArraySize() > 0 {
+		return or.initBlockchainConnectors(ctx)
+	}
+	if or.blockchain == nil {
+		biType := config.GetString(config.BlockchainType)
+		if or.blockchain, err = bifactory.GetPlugin(ctx, biType); err != nil {
+			return err
+		}
+	}
+	return or.blockchain.Init(ctx, blockchainConfig.SubPrefix(or.blockchain.Name()), &or.bc)
+}
+
+// initBlockchainConnectors loads each of the named blockchain connectors in the blockchains config, and multiplexes
+// them so each namespace uses the connector it is bound to. The first connector is used by unbound namespaces.
+func (or *orchestrator) initBlockchainConnectors(ctx context.Context) (err error) {
+	namespaces, err := getNamespaceConnectors(ctx)
+	if err != nil {
+		return err
+	}
+	connectors := make(map[string]blockchain.Plugin)
+	or.connectorCBs = make(map[string]*boundCallbacks)
+	defaultConnector := ""
+	arraySize := blockchainsConfig.ArraySize()
+	for i := 0; i < arraySize; i++ {
+		prefix := blockchainsConfig.ArrayEntry(i)
+		name := prefix.GetString(blockchain.BlockchainConfigName)
+		pluginType := prefix.GetString(blockchain.BlockchainConfigType)
+		if name == "" || pluginType == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingBlockchainConfig)
+		}
+		if err = fftypes.ValidateFFNameField(ctx, name, "name"); err != nil {
+			return err
+		}
+		if connectors[name] != nil {
+			return i18n.NewError(ctx, i18n.MsgDuplicateArrayEntry, "blockchains.name", i, name)
+		}
+
+		log.L(ctx).Infof("Loading blockchain connector name=%s type=%s", name, pluginType)
+		plugin := or.connectors[name]
+		if plugin == nil {
+			if plugin, err = bifactory.GetPlugin(ctx, pluginType); err != nil {
+				return err
+			}
+		}
+		pluginPrefix := prefix.SubPrefix(plugin.Name())
+		plugin.InitPrefix(pluginPrefix)
+		cb := &boundCallbacks{bi: plugin, connector: name}
+		if err = plugin.Init(ctx, pluginPrefix, cb); err != nil {
+			return err
+		}
+		connectors[name] = plugin
+		or.connectorCBs[name] = cb
+		if defaultConnector == "" {
+			defaultConnector = name
+		}
+	}
+	for _, name := range namespaces {
+		if connectors[name] == nil {
+			return i18n.NewError(ctx, i18n.MsgUnknownBlockchainConnector, name)
+		}
+	}
+
+	mux := bimux.NewMultiplexer(defaultConnector, connectors, namespaces)
+	for _, cb := range or.connectorCBs {
+		cb.mux = mux
+	}
+	or.blockchain = mux
+	return nil
+}
+
+// getNamespaceConnectors returns the blockchain connector each predefined namespace is bound to, where set
+func getNamespaceConnectors(ctx context.Context) (map[string]string, error) {
+	namespaces := make(map[string]string)
+	for i, nsObject := range config.GetObjectArray(config.NamespacesPredefined) {
+		if connector := nsObject.GetString("blockchain"); connector != "" {
+			if err := fftypes.ValidateFFNameField(ctx, connector, fmt.Sprintf("namespaces.predefined[%d].blockchain", i)); err != nil {
+				return nil, err
+			}
+			namespaces[nsObject.GetString("name")] = connector
+		}
+	}
+	return namespaces, nil
+}
+
 func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 
+	or.features = newFeatureFlags(ctx)
+
 	if or.identity == nil {
 		or.identity, err = identity.NewIdentityManager(ctx, or.database, or.identityPlugin, or.blockchain)
 		if err != nil {
@@ -395,35 +720,76 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 	}
 
 	or.syncasync = syncasync.NewSyncAsyncBridge(ctx, or.database, or.data)
-	or.batchpin = batchpin.NewBatchPinSubmitter(or.database, or.identity, or.blockchain)
+	if or.policy == nil {
+		if or.policy, err = policy.NewPolicyManager(ctx, or.database, or.policyPlugin); err != nil {
+			return err
+		}
+	}
+
+	if or.preflight == nil {
+		if or.preflight, err = txcommon.NewPreflightChecker(ctx, or.database, or.blockchain, or.policy); err != nil {
+			return err
+		}
+	}
+
+	or.batchpin = batchpin.NewBatchPinSubmitter(ctx, or.database, or.identity, or.blockchain, or.preflight)
+
+	if or.quota == nil {
+		if or.quota, err = quota.NewQuotaManager(ctx, or.database); err != nil {
+			return err
+		}
+	}
 
 	if or.messaging == nil {
-		if or.messaging, err = privatemessaging.NewPrivateMessaging(ctx, or.database, or.identity, or.dataexchange, or.blockchain, or.batch, or.data, or.syncasync, or.batchpin); err != nil {
+		if or.messaging, err = privatemessaging.NewPrivateMessaging(ctx, or.database, or.identity, or.dataexchange, or.blockchain, or.batch, or.data, or.syncasync, or.batchpin, or.quota); err != nil {
 			return err
 		}
 	}
 
 	if or.broadcast == nil {
-		if or.broadcast, err = broadcast.NewBroadcastManager(ctx, or.database, or.identity, or.data, or.blockchain, or.dataexchange, or.publicstorage, or.batch, or.syncasync, or.batchpin); err != nil {
+		if or.broadcast, err = broadcast.NewBroadcastManager(ctx, or.database, or.identity, or.data, or.blockchain, or.dataexchange, or.publicstorage, or.batch, or.syncasync, or.batchpin, or.quota); err != nil {
 			return err
 		}
 	}
 
 	if or.assets == nil {
-		or.assets, err = assets.NewAssetManager(ctx, or.database, or.identity, or.data, or.syncasync, or.broadcast, or.messaging, or.tokens)
+		or.assets, err = assets.NewAssetManager(ctx, or.database, or.identity, or.data, or.syncasync, or.broadcast, or.messaging, or.tokens, or.preflight)
 		if err != nil {
 			return err
 		}
 	}
 
+	if or.contracts == nil {
+		or.contracts, err = contracts.NewContractManager(ctx, or.database, or.broadcast, or.identity, or.blockchain, or.syncasync, or.preflight)
+		if err != nil {
+			return err
+		}
+	}
+
+	if or.operations == nil {
+		if or.operations, err = operations.NewOperationsManager(ctx, or.database); err != nil {
+			return err
+		}
+	}
+	or.operations.RegisterHandler(or.batchpin, []fftypes.OpType{fftypes.OpTypeBlockchainBatchPin})
+	or.operations.RegisterHandler(or.messaging, []fftypes.OpType{fftypes.OpTypeDataExchangeBatchSend, fftypes.OpTypeDataExchangeBlobSend})
+	or.operations.RegisterHandler(or.assets, []fftypes.OpType{fftypes.OpTypeTokenTransfer})
+
+	if or.rollup == nil {
+		if or.rollup, err = rollup.NewRollupManager(ctx, or.database); err != nil {
+			return err
+		}
+	}
+
 	or.definitions = definitions.NewDefinitionHandlers(or.database, or.dataexchange, or.data, or.broadcast, or.messaging, or.assets)
 
 	if or.events == nil {
-		or.events, err = events.NewEventManager(ctx, or, or.publicstorage, or.database, or.identity, or.definitions, or.data, or.broadcast, or.messaging, or.assets)
+		or.events, err = events.NewEventManager(ctx, or, or.publicstorage, or.database, or.identity, or.definitions, or.data, or.broadcast, or.messaging, or.assets, or.policy)
 		if err != nil {
 			return err
 		}
 	}
+	or.policy.RegisterHandler(fftypes.PolicySubmissionTypeTokenPoolGovernance, or.events)
 
 	if or.networkmap == nil {
 		or.networkmap, err = networkmap.NewNetworkMap(ctx, or.database, or.broadcast, or.dataexchange, or.identity)
@@ -438,6 +804,21 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 }
 
 func (or *orchestrator) getPrefdefinedNamespaces(ctx context.Context) ([]*fftypes.Namespace, error) {
+	if or.namespace != "" {
+		// The database of an isolated namespace only contains the namespace itself, and the system namespace
+		return []*fftypes.Namespace{
+			{
+				Name:        fftypes.SystemNamespace,
+				Type:        fftypes.NamespaceTypeSystem,
+				Description: i18n.Expand(ctx, i18n.MsgSystemNSDescription),
+			},
+			{
+				Name:        or.namespace,
+				Type:        fftypes.NamespaceTypeLocal,
+				Description: or.namespaceConfig.GetString(namespaceConfigDescription),
+			},
+		}, nil
+	}
 	defaultNS := config.GetString(config.NamespacesDefault)
 	predefined := config.GetObjectArray(config.NamespacesPredefined)
 	namespaces := []*fftypes.Namespace{
@@ -454,6 +835,10 @@ func (or *orchestrator) getPrefdefinedNamespaces(ctx context.Context) ([]*fftype
 		if err != nil {
 			return nil, err
 		}
+		if or.isolated[name] != nil {
+			// Stored in the database of the isolated namespace instead
+			continue
+		}
 		foundDefault = foundDefault || name == defaultNS
 		description := nsObject.GetString("description")
 		dup := false
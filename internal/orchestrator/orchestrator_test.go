@@ -19,15 +19,20 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/blockchain/bifactory"
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/internal/secrets"
 	"github.com/hyperledger/firefly/internal/tokens/tifactory"
 	"github.com/hyperledger/firefly/mocks/assetmocks"
 	"github.com/hyperledger/firefly/mocks/batchmocks"
 	"github.com/hyperledger/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger/firefly/mocks/contractmocks"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
@@ -35,11 +40,19 @@ import (
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
 	"github.com/hyperledger/firefly/mocks/identitymocks"
 	"github.com/hyperledger/firefly/mocks/networkmapmocks"
+	"github.com/hyperledger/firefly/mocks/operationmocks"
+	"github.com/hyperledger/firefly/mocks/policymanagermocks"
+	"github.com/hyperledger/firefly/mocks/policymocks"
 	"github.com/hyperledger/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger/firefly/mocks/quotamocks"
+	"github.com/hyperledger/firefly/mocks/rollupmocks"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
+	"github.com/hyperledger/firefly/mocks/txcommonmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/tokens"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -62,7 +75,14 @@ type testOrchestrator struct {
 	mim *identitymanagermocks.Manager
 	mdx *dataexchangemocks.Plugin
 	mam *assetmocks.Manager
+	mcm *contractmocks.Manager
 	mti *tokenmocks.Plugin
+	mqm *quotamocks.Manager
+	mom *operationmocks.Manager
+	mrm *rollupmocks.Manager
+	mpf *txcommonmocks.PreflightChecker
+	mpp *policymocks.Plugin
+	mpe *policymanagermocks.Manager
 }
 
 func newTestOrchestrator() *testOrchestrator {
@@ -86,7 +106,14 @@ func newTestOrchestrator() *testOrchestrator {
 		mim: &identitymanagermocks.Manager{},
 		mdx: &dataexchangemocks.Plugin{},
 		mam: &assetmocks.Manager{},
+		mcm: &contractmocks.Manager{},
 		mti: &tokenmocks.Plugin{},
+		mqm: &quotamocks.Manager{},
+		mom: &operationmocks.Manager{},
+		mrm: &rollupmocks.Manager{},
+		mpf: &txcommonmocks.PreflightChecker{},
+		mpp: &policymocks.Plugin{},
+		mpe: &policymanagermocks.Manager{},
 	}
 	tor.orchestrator.database = tor.mdi
 	tor.orchestrator.data = tor.mdm
@@ -101,8 +128,17 @@ func newTestOrchestrator() *testOrchestrator {
 	tor.orchestrator.identityPlugin = tor.mii
 	tor.orchestrator.dataexchange = tor.mdx
 	tor.orchestrator.assets = tor.mam
+	tor.orchestrator.contracts = tor.mcm
+	tor.orchestrator.quota = tor.mqm
+	tor.orchestrator.operations = tor.mom
+	tor.orchestrator.rollup = tor.mrm
+	tor.orchestrator.preflight = tor.mpf
+	tor.orchestrator.policyPlugin = tor.mpp
+	tor.orchestrator.policy = tor.mpe
 	tor.orchestrator.tokens = map[string]tokens.Plugin{"token": tor.mti}
 	tor.mdi.On("Name").Return("mock-di").Maybe()
+	tor.mom.On("RegisterHandler", mock.Anything, mock.Anything).Maybe()
+	tor.mpe.On("RegisterHandler", mock.Anything, mock.Anything).Maybe()
 	tor.mem.On("Name").Return("mock-ei").Maybe()
 	tor.mps.On("Name").Return("mock-ps").Maybe()
 	tor.mbi.On("Name").Return("mock-bi").Maybe()
@@ -110,6 +146,8 @@ func newTestOrchestrator() *testOrchestrator {
 	tor.mdx.On("Name").Return("mock-dx").Maybe()
 	tor.mam.On("Name").Return("mock-am").Maybe()
 	tor.mti.On("Name").Return("mock-tk").Maybe()
+	tor.mpp.On("Name").Return("mock-pp").Maybe()
+	tor.mpp.On("Init", mock.Anything, mock.Anything).Return(nil).Maybe()
 	return tor
 }
 
@@ -118,6 +156,18 @@ func TestNewOrchestrator(t *testing.T) {
 	assert.NotNil(t, or)
 }
 
+func TestNewOrchestratorWithPlugins(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	or := NewOrchestratorWithPlugins(&Plugins{
+		Database:   mdi,
+		Blockchain: mbi,
+	}).(*orchestrator)
+	assert.Equal(t, mdi, or.database)
+	assert.Equal(t, mbi, or.blockchain)
+	assert.Nil(t, or.dataexchange)
+}
+
 func TestBadDatabasePlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.DatabaseType, "wrong")
@@ -169,6 +219,32 @@ func TestBadIdentityInitFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestBadPolicyPlugin(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	config.Set(config.PolicyType, "wrong")
+	or.policyPlugin = nil
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.Regexp(t, "FF10343.*wrong", err)
+}
+
+func TestBadPolicyInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mpp = &policymocks.Plugin{}
+	or.policyPlugin = or.mpp
+	or.mpp.On("Name").Return("mock-pp")
+	or.mpp.On("Init", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.EqualError(t, err, "pop")
+}
+
 func TestBadBlockchainPlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.BlockchainType, "wrong")
@@ -192,6 +268,162 @@ func TestBlockchaiInitFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func newTestBlockchainConnectors(or *testOrchestrator, conf string) *blockchainmocks.Plugin {
+	blockchainsConfig = config.NewPluginConfig("blockchains").Array()
+	bifactory.InitPrefixArray(blockchainsConfig)
+	viper.SetConfigType("yaml")
+	err := viper.ReadConfig(strings.NewReader(conf))
+	if err != nil {
+		panic(err)
+	}
+	mbi2 := &blockchainmocks.Plugin{}
+	or.blockchain = nil
+	or.connectors = map[string]blockchain.Plugin{
+		"chain1": or.mbi,
+		"chain2": mbi2,
+	}
+	mbi2.On("Name").Return("mock-bi2").Maybe()
+	mbi2.On("InitPrefix", mock.Anything).Maybe()
+	or.mbi.On("InitPrefix", mock.Anything).Maybe()
+	return mbi2
+}
+
+func TestInitBlockchainConnectors(t *testing.T) {
+	or := newTestOrchestrator()
+	mbi2 := newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+  type: ethereum
+- name: chain2
+  type: ethereum
+`)
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "default"},
+		{"name": "ns2", "blockchain": "chain2"},
+	})
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mbi2.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("GetNamespace", mock.Anything, mock.Anything).Return(nil, nil)
+	or.mdi.On("UpsertNamespace", mock.Anything, mock.Anything, true).Return(nil)
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.NoError(t, err)
+
+	mux := or.blockchain.(*bimux.Multiplexer)
+	assert.Equal(t, or.mbi, mux.ForNamespace("default"))
+	assert.Equal(t, mbi2, mux.ForNamespace("ns2"))
+	assert.Equal(t, or.mem, or.connectorCBs["chain2"].ei)
+	assert.Equal(t, mbi2, or.connectorCBs["chain2"].bi)
+	or.mbi.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestInitBlockchainConnectorsMissingType(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+`)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10454", err)
+}
+
+func TestInitBlockchainConnectorsBadName(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: "!wrong"
+  type: ethereum
+`)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10131.*'name'", err)
+}
+
+func TestInitBlockchainConnectorsDuplicate(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+  type: ethereum
+- name: chain1
+  type: ethereum
+`)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10228.*chain1", err)
+}
+
+func TestInitBlockchainConnectorsBadType(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain3
+  type: wrong
+`)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10110.*wrong", err)
+}
+
+func TestInitBlockchainConnectorsFromFactory(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain3
+  type: ethereum
+  ethereum:
+    ethconnect:
+      instance: /contracts/firefly
+`)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitBlockchainConnectorsInitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+  type: ethereum
+`)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInitBlockchainConnectorsUnknownNamespaceConnector(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+  type: ethereum
+`)
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns2", "blockchain": "chain2"},
+	})
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10455.*chain2", err)
+}
+
+func TestInitBlockchainConnectorsBadNamespaceConnector(t *testing.T) {
+	or := newTestOrchestrator()
+	newTestBlockchainConnectors(or, `
+blockchains:
+- name: chain1
+  type: ethereum
+`)
+	config.Set(config.NamespacesPredefined, fftypes.JSONObjectArray{
+		{"name": "ns2", "blockchain": "!wrong"},
+	})
+	err := or.initBlockchainPlugins(or.ctx)
+	assert.Regexp(t, "FF10131.*blockchain", err)
+}
+
 func TestBlockchaiInitGetConfigRecordsFail(t *testing.T) {
 	or := newTestOrchestrator()
 	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
@@ -220,6 +452,26 @@ func TestBlockchaiInitMergeConfigRecordsFail(t *testing.T) {
 	assert.EqualError(t, err, "invalid character 'c' looking for beginning of value")
 }
 
+func TestBlockchaiInitResolveConfigRecordsFail(t *testing.T) {
+	or := newTestOrchestrator()
+	secrets.InitConfig()
+	err := secrets.ResolveConfig(context.Background())
+	assert.NoError(t, err)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{
+		{
+			Key:   "pizza.toppings",
+			Value: []byte(`"file:///not/a/file"`),
+		},
+	}, nil, nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err = or.Init(ctx, cancelCtx)
+	assert.Regexp(t, "FF10307.*pizza.toppings", err)
+}
+
 func TestBadPublicStoragePlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.PublicStorageType, "wrong")
@@ -433,6 +685,30 @@ func TestInitBatchComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitPolicyComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.policy = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestInitPreflightComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.preflight = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestInitQuotaComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.quota = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
 func TestInitBroadcastComponentFail(t *testing.T) {
 	or := newTestOrchestrator()
 	or.database = nil
@@ -465,6 +741,51 @@ func TestInitAssetsComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitContractsComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.contracts = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestInitOperationsComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.operations = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestInitRollupComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.rollup = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestStartConsistencyCheck(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.ConsistencyCheckOnStartup, true)
+	config.Set(config.ConsistencyRepair, true)
+	mockEmptyConsistencyCheck(or)
+	or.mbi.On("Start").Return(fmt.Errorf("pop"))
+	err := or.Start()
+	assert.EqualError(t, err, "pop")
+	report, err := or.GetConsistencyReport(or.ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Repair)
+}
+
+func TestStartConsistencyCheckFail(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.ConsistencyCheckOnStartup, true)
+	or.mdi.On("GetBatches", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := or.Start()
+	assert.EqualError(t, err, "pop")
+}
+
 func TestStartBatchFail(t *testing.T) {
 	config.Reset()
 	or := newTestOrchestrator()
@@ -474,14 +795,27 @@ func TestStartBatchFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestStartNetworkMapFail(t *testing.T) {
+	config.Reset()
+	or := newTestOrchestrator()
+	or.mbi.On("Start").Return(nil)
+	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(fmt.Errorf("pop"))
+	err := or.Start()
+	assert.EqualError(t, err, "pop")
+}
+
 func TestStartTokensFail(t *testing.T) {
 	config.Reset()
 	or := newTestOrchestrator()
 	or.mbi.On("Start").Return(nil)
 	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(nil)
+	or.mem.On("StartDispatch").Return(nil)
 	or.mem.On("Start").Return(nil)
 	or.mbm.On("Start").Return(nil)
 	or.mpm.On("Start").Return(nil)
+	or.mrm.On("Start").Return(nil)
 	or.mam.On("Start").Return(nil)
 	or.mti.On("Start").Return(fmt.Errorf("pop"))
 	err := or.Start()
@@ -493,9 +827,12 @@ func TestStartStopOk(t *testing.T) {
 	or := newTestOrchestrator()
 	or.mbi.On("Start").Return(nil)
 	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(nil)
+	or.mem.On("StartDispatch").Return(nil)
 	or.mem.On("Start").Return(nil)
 	or.mbm.On("Start").Return(nil)
 	or.mpm.On("Start").Return(nil)
+	or.mrm.On("Start").Return(nil)
 	or.mam.On("Start").Return(nil)
 	or.mti.On("Start").Return(nil)
 	or.mbi.On("WaitStop").Return(nil)
@@ -591,4 +928,46 @@ func TestInitOK(t *testing.T) {
 	assert.Equal(t, or.mnm, or.NetworkMap())
 	assert.Equal(t, or.mdm, or.Data())
 	assert.Equal(t, or.mam, or.Assets())
+	assert.Equal(t, or.mcm, or.Contracts())
+	assert.Equal(t, or.mom, or.Operations())
+	assert.Equal(t, or.mpe, or.Policy())
+	assert.Equal(t, or.mrm, or.Rollup())
+}
+
+func TestInitUUIDVersion(t *testing.T) {
+	or := newTestOrchestrator()
+	defer func() { fftypes.DefaultUUIDVersion = fftypes.UUIDVersion4 }()
+
+	config.Set(config.UUIDVersion, "V7")
+	err := or.initUUIDVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.UUIDVersion7, fftypes.DefaultUUIDVersion)
+	assert.Equal(t, 7, fftypes.NewUUID().Version())
+
+	config.Set(config.UUIDVersion, "v4")
+	err = or.initUUIDVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, fftypes.NewUUID().Version())
+}
+
+func TestInitUUIDVersionUnknown(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.UUIDVersion, "v1")
+	err := or.initUUIDVersion(context.Background())
+	assert.Regexp(t, "FF10447.*v1", err)
+}
+
+func TestInitBadUUIDVersion(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mps.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mdx.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	or.mti.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	config.Set(config.UUIDVersion, "v1")
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	err := or.Init(ctx, cancelCtx)
+	assert.Regexp(t, "FF10447", err)
 }
@@ -35,7 +35,10 @@ func (or *orchestrator) attemptChangeEventDispatch(ev *fftypes.ChangeEvent) {
 func (or *orchestrator) OrderedUUIDCollectionNSEvent(resType database.OrderedUUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID, sequence int64) {
 	switch {
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionMessages:
-		or.batch.NewMessages() <- sequence
+		// The batch manager is not running in standby, so would not drain the notifications
+		if !or.IsStandby() {
+			or.batch.NewMessages() <- sequence
+		}
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionEvents:
 		or.events.NewEvents() <- sequence
 	}
@@ -66,6 +69,8 @@ func (or *orchestrator) OrderedCollectionEvent(resType database.OrderedCollectio
 
 func (or *orchestrator) UUIDCollectionNSEvent(resType database.UUIDCollectionNS, eventType fftypes.ChangeEventType, ns string, id *fftypes.UUID) {
 	switch {
+	case resType == database.CollectionSubscriptions && or.IsStandby():
+		// The subscription manager is not running in standby, and loads all subscriptions when promoted
 	case eventType == fftypes.ChangeEventTypeCreated && resType == database.CollectionSubscriptions:
 		or.events.NewSubscriptions() <- id
 	case eventType == fftypes.ChangeEventTypeDeleted && resType == database.CollectionSubscriptions:
@@ -0,0 +1,87 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// The owner of a pseudonym is only ever returned through ResolvePseudonymAuthor, so that
+// the masking of authors cannot be bypassed by applications that are not authorized
+func maskPseudonymOwner(pseudonym *fftypes.Pseudonym) *fftypes.Pseudonym {
+	pseudonym.Owner = ""
+	return pseudonym
+}
+
+func (or *orchestrator) getPseudonymByID(ctx context.Context, ns, id string) (*fftypes.Pseudonym, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	pseudonym, err := or.database.GetPseudonymByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if pseudonym == nil || pseudonym.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgPseudonymNotFound, id, ns)
+	}
+	return pseudonym, nil
+}
+
+func (or *orchestrator) GetPseudonyms(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Pseudonym, *database.FilterResult, error) {
+	filter = or.scopeNS(ns, filter)
+	pseudonyms, res, err := or.database.GetPseudonyms(ctx, filter)
+	for _, pseudonym := range pseudonyms {
+		maskPseudonymOwner(pseudonym)
+	}
+	return pseudonyms, res, err
+}
+
+func (or *orchestrator) GetPseudonymByID(ctx context.Context, ns, id string) (*fftypes.Pseudonym, error) {
+	pseudonym, err := or.getPseudonymByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	return maskPseudonymOwner(pseudonym), nil
+}
+
+// ResolvePseudonymAuthor reveals the real author behind a pseudonym registered by this node, to
+// identities that are configured as resolvers (or admins)
+func (or *orchestrator) ResolvePseudonymAuthor(ctx context.Context, ns, id string) (*fftypes.PseudonymAuthor, error) {
+	identity := auth.GetIdentity(ctx)
+	if !auth.CanResolvePseudonym(identity) {
+		return nil, i18n.NewError(ctx, i18n.MsgPseudonymResolveForbidden, identity)
+	}
+	pseudonym, err := or.getPseudonymByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if pseudonym.Owner == "" {
+		// Pseudonyms registered by other members of the network cannot be resolved
+		return nil, i18n.NewError(ctx, i18n.MsgPseudonymNotFound, id, ns)
+	}
+	return &fftypes.PseudonymAuthor{
+		Pseudonym: pseudonym.ID,
+		DID:       pseudonym.GetDID(),
+		Author:    pseudonym.Owner,
+	}, nil
+}
@@ -0,0 +1,116 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetPseudonyms(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetPseudonyms", mock.Anything, mock.Anything).Return([]*fftypes.Pseudonym{
+		{ID: fftypes.NewUUID(), Owner: "did:firefly:org/org1"},
+	}, nil, nil)
+	fb := database.PseudonymQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("key", "0x12345"))
+	pseudonyms, _, err := or.GetPseudonyms(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+	assert.Empty(t, pseudonyms[0].Owner)
+}
+
+func TestGetPseudonymByID(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetPseudonymByID", mock.Anything, u).Return(&fftypes.Pseudonym{
+		ID: u, Namespace: "ns1", Owner: "did:firefly:org/org1",
+	}, nil)
+	pseudonym, err := or.GetPseudonymByID(context.Background(), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Empty(t, pseudonym.Owner)
+}
+
+func TestGetPseudonymByIDBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetPseudonymByID(context.Background(), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetPseudonymByIDFail(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetPseudonymByID", mock.Anything, u).Return(nil, fmt.Errorf("pop"))
+	_, err := or.GetPseudonymByID(context.Background(), "ns1", u.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetPseudonymByIDWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetPseudonymByID", mock.Anything, u).Return(&fftypes.Pseudonym{
+		ID: u, Namespace: "ns2",
+	}, nil)
+	_, err := or.GetPseudonymByID(context.Background(), "ns1", u.String())
+	assert.Regexp(t, "FF10380", err)
+}
+
+func TestResolvePseudonymAuthor(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.BroadcastPseudonymResolvers, []string{"CN=auditor"})
+	u := fftypes.NewUUID()
+	or.mdi.On("GetPseudonymByID", mock.Anything, u).Return(&fftypes.Pseudonym{
+		ID: u, Namespace: "ns1", Owner: "did:firefly:org/org1",
+	}, nil)
+	author, err := or.ResolvePseudonymAuthor(auth.WithIdentity(context.Background(), "CN=auditor"), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, author.Pseudonym)
+	assert.Equal(t, "did:firefly:pseudonym/"+u.String(), author.DID)
+	assert.Equal(t, "did:firefly:org/org1", author.Author)
+}
+
+func TestResolvePseudonymAuthorForbidden(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.BroadcastPseudonymResolvers, []string{"CN=auditor"})
+	_, err := or.ResolvePseudonymAuthor(auth.WithIdentity(context.Background(), "CN=app1"), "ns1", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10384", err)
+}
+
+func TestResolvePseudonymAuthorBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.BroadcastPseudonymResolvers, []string{"CN=auditor"})
+	_, err := or.ResolvePseudonymAuthor(auth.WithIdentity(context.Background(), "CN=auditor"), "ns1", "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestResolvePseudonymAuthorRemote(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.BroadcastPseudonymResolvers, []string{"CN=auditor"})
+	u := fftypes.NewUUID()
+	or.mdi.On("GetPseudonymByID", mock.Anything, u).Return(&fftypes.Pseudonym{
+		ID: u, Namespace: "ns1",
+	}, nil)
+	_, err := or.ResolvePseudonymAuthor(auth.WithIdentity(context.Background(), "CN=auditor"), "ns1", u.String())
+	assert.Regexp(t, "FF10380", err)
+}
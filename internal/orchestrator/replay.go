@@ -0,0 +1,57 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// ReplayBlockchain rewinds the blockchain plugin, so that it redelivers every BatchPin event from the supplied
+// block onwards. When multiple blockchain connectors are configured, the connector to rewind can be named,
+// otherwise every connector is rewound. The events pass through the same idempotent processing as when they
+// were first delivered, so anything missing from the database (for example after a restore from backup) is
+// rebuilt by the aggregator.
+func (or *orchestrator) ReplayBlockchain(ctx context.Context, replay *fftypes.BlockchainReplay) (*fftypes.BlockchainReplay, error) {
+	if _, err := strconv.ParseUint(replay.FromBlock, 10, 64); err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidReplayBlock, replay.FromBlock)
+	}
+	bi := or.blockchain
+	if replay.Connector != "" {
+		mux, ok := or.blockchain.(*bimux.Multiplexer)
+		if !ok || mux.Connector(replay.Connector) == nil {
+			return nil, i18n.NewError(ctx, i18n.MsgUnknownBlockchainConnector, replay.Connector)
+		}
+		bi = mux.Connector(replay.Connector)
+	}
+	// Forget the events processed recently first, otherwise their redelivery would be skipped
+	if err := or.events.ResetEventDedup(ctx); err != nil {
+		return nil, err
+	}
+	if err := bi.ResetEventStream(ctx, replay.FromBlock); err != nil {
+		return nil, err
+	}
+	replay.Plugin = bi.Name()
+	replay.Started = fftypes.Now()
+	log.L(ctx).Infof("Replaying blockchain events from block %s", replay.FromBlock)
+	return replay, nil
+}
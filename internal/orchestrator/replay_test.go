@@ -0,0 +1,90 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayBlockchain(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("ResetEventDedup", or.ctx).Return(nil)
+	or.mbi.On("ResetEventStream", or.ctx, "12345").Return(nil)
+
+	replay, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "12345"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mock-bi", replay.Plugin)
+	assert.NotNil(t, replay.Started)
+	or.mem.AssertExpectations(t)
+	or.mbi.AssertExpectations(t)
+}
+
+func TestReplayBlockchainBadBlock(t *testing.T) {
+	or := newTestOrchestrator()
+
+	_, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "latest"})
+	assert.Regexp(t, "FF10434", err)
+}
+
+func TestReplayBlockchainResetDedupFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("ResetEventDedup", or.ctx).Return(fmt.Errorf("pop"))
+
+	_, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "0"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReplayBlockchainResetStreamFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("ResetEventDedup", or.ctx).Return(nil)
+	or.mbi.On("ResetEventStream", or.ctx, "0").Return(fmt.Errorf("pop"))
+
+	_, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "0"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestReplayBlockchainConnector(t *testing.T) {
+	or := newTestOrchestrator()
+	mbi2 := &blockchainmocks.Plugin{}
+	or.blockchain = bimux.NewMultiplexer("chain1", map[string]blockchain.Plugin{
+		"chain1": or.mbi,
+		"chain2": mbi2,
+	}, map[string]string{})
+	or.mem.On("ResetEventDedup", or.ctx).Return(nil)
+	mbi2.On("ResetEventStream", or.ctx, "12345").Return(nil)
+	mbi2.On("Name").Return("mock-bi2")
+
+	replay, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "12345", Connector: "chain2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mock-bi2", replay.Plugin)
+	or.mem.AssertExpectations(t)
+	mbi2.AssertExpectations(t)
+}
+
+func TestReplayBlockchainUnknownConnector(t *testing.T) {
+	or := newTestOrchestrator()
+
+	_, err := or.ReplayBlockchain(or.ctx, &fftypes.BlockchainReplay{FromBlock: "0", Connector: "chain2"})
+	assert.Regexp(t, "FF10455", err)
+}
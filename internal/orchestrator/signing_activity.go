@@ -0,0 +1,68 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) GetSigningActivity(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.SigningActivity, *database.FilterResult, error) {
+	filter = or.scopeNS(ns, filter)
+	return or.database.GetSigningActivity(ctx, filter)
+}
+
+// GetSigningKeyReport summarizes all recorded uses of a signing key, optionally bounded
+// to a time range (inclusive of the start time, exclusive of the end time)
+func (or *orchestrator) GetSigningKeyReport(ctx context.Context, ns, key string, startTime, endTime *fftypes.FFTime) (*fftypes.SigningKeyReport, error) {
+	if startTime != nil && endTime != nil && startTime.UnixNano() > endTime.UnixNano() {
+		return nil, i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+	}
+
+	fb := database.SigningActivityQueryFactory.NewFilter(ctx)
+	conditions := []database.Filter{
+		fb.Eq("namespace", ns),
+		fb.Eq("key", key),
+	}
+	if startTime != nil {
+		conditions = append(conditions, fb.Gte("created", startTime))
+	}
+	if endTime != nil {
+		conditions = append(conditions, fb.Lt("created", endTime))
+	}
+	activity, _, err := or.database.GetSigningActivity(ctx, fb.And(conditions...).Sort("created").Ascending())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &fftypes.SigningKeyReport{
+		Key:   key,
+		Types: make(map[fftypes.OpType]int64),
+	}
+	for _, a := range activity {
+		if report.First == nil {
+			report.First = a.Created
+		}
+		report.Last = a.Created
+		report.Total++
+		report.Types[a.Type]++
+	}
+	return report, nil
+}
@@ -0,0 +1,88 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSigningActivity(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetSigningActivity", mock.Anything, mock.Anything).Return([]*fftypes.SigningActivity{}, nil, nil)
+	fb := database.SigningActivityQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("key", "0x12345"))
+	_, _, err := or.GetSigningActivity(context.Background(), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetSigningKeyReport(t *testing.T) {
+	or := newTestOrchestrator()
+	t1 := fftypes.UnixTime(1000)
+	t2 := fftypes.UnixTime(2000)
+	t3 := fftypes.UnixTime(3000)
+	or.mdi.On("GetSigningActivity", mock.Anything, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.String() == "( namespace == 'ns1' ) && ( key == '0x12345' ) && ( created >= 1000000000000 ) && ( created < 4000000000000 ) sort=created"
+	})).Return([]*fftypes.SigningActivity{
+		{Key: "0x12345", Type: fftypes.OpTypeBlockchainBatchPin, Created: t1},
+		{Key: "0x12345", Type: fftypes.OpTypeTokenTransfer, Created: t2},
+		{Key: "0x12345", Type: fftypes.OpTypeBlockchainBatchPin, Created: t3},
+	}, nil, nil)
+
+	report, err := or.GetSigningKeyReport(context.Background(), "ns1", "0x12345", t1, fftypes.UnixTime(4000))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", report.Key)
+	assert.Equal(t, int64(3), report.Total)
+	assert.Equal(t, t1, report.First)
+	assert.Equal(t, t3, report.Last)
+	assert.Equal(t, int64(2), report.Types[fftypes.OpTypeBlockchainBatchPin])
+	assert.Equal(t, int64(1), report.Types[fftypes.OpTypeTokenTransfer])
+}
+
+func TestGetSigningKeyReportNoActivity(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetSigningActivity", mock.Anything, mock.Anything).Return([]*fftypes.SigningActivity{}, nil, nil)
+
+	report, err := or.GetSigningKeyReport(context.Background(), "ns1", "0x12345", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), report.Total)
+	assert.Nil(t, report.First)
+	assert.Nil(t, report.Last)
+	assert.Empty(t, report.Types)
+}
+
+func TestGetSigningKeyReportBadTimes(t *testing.T) {
+	or := newTestOrchestrator()
+	now := time.Now()
+	_, err := or.GetSigningKeyReport(context.Background(), "ns1", "0x12345", fftypes.UnixTime(now.Unix()), fftypes.UnixTime(now.Unix()-1))
+	assert.Regexp(t, "FF10300", err)
+}
+
+func TestGetSigningKeyReportFailDB(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetSigningActivity", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	_, err := or.GetSigningKeyReport(context.Background(), "ns1", "0x12345", nil, nil)
+	assert.EqualError(t, err, "pop")
+}
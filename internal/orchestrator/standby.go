@@ -0,0 +1,67 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// IsStandby returns true while the node is a warm standby. A standby receives the same blockchain and
+// data exchange inputs as the primary node for the org, so its database stays in sync, but it does not
+// dispatch any batches, deliver events to subscriptions or send receipts, and its API is read-only.
+func (or *orchestrator) IsStandby() bool {
+	or.standbyMux.Lock()
+	defer or.standbyMux.Unlock()
+	return or.standby
+}
+
+// Promote takes the node out of standby, so it starts dispatching batches and accepts writes on its API.
+// It is the responsibility of the operator to ensure the primary has stopped before promoting.
+func (or *orchestrator) Promote(ctx context.Context) (*fftypes.NodeStatus, error) {
+	if err := or.promote(ctx); err != nil {
+		return nil, err
+	}
+	return or.GetStatus(ctx)
+}
+
+func (or *orchestrator) promote(ctx context.Context) error {
+	or.standbyMux.Lock()
+	defer or.standbyMux.Unlock()
+	if !or.standby {
+		return i18n.NewError(ctx, i18n.MsgNodeNotStandby)
+	}
+	for _, child := range or.isolated {
+		if !child.IsStandby() {
+			continue // promoted on an earlier attempt that failed part way through
+		}
+		if err := child.promote(ctx); err != nil {
+			return err
+		}
+	}
+	// The batch manager and the subscriptions resume from their persisted offsets, so they
+	// pick up any messages and events that were written while we were not notifying them
+	if err := or.startOutbound(); err != nil {
+		return err
+	}
+	or.standby = false
+	log.L(ctx).Infof("Node promoted from standby")
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/batchmocks"
+	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStartStandbyPromote(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Reset()
+	config.Set(config.OrgName, "org1")
+	or.standby = true
+	or.mbi.On("Start").Return(nil)
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
+	or.mpm.On("Start").Return(nil)
+	or.mrm.On("Start").Return(nil)
+	or.mti.On("Start").Return(nil)
+	err := or.Start()
+	assert.NoError(t, err)
+	or.mba.AssertNotCalled(t, "Start")
+	or.mnm.AssertNotCalled(t, "Start")
+	or.mem.AssertNotCalled(t, "StartDispatch")
+
+	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(nil)
+	or.mem.On("StartDispatch").Return(nil)
+	or.mdi.On("ConnectionStatus", mock.Anything).Return(&fftypes.NodeStatusDatabase{Provider: "sqlite3", Healthy: true})
+	or.mem.On("BlockchainClockSkew").Return(nil)
+	or.mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
+	or.mim.On("GetOrgKey", mock.Anything).Return("0x1111111")
+	status, err := or.Promote(or.ctx)
+	assert.NoError(t, err)
+	assert.False(t, status.Node.Standby)
+	assert.False(t, or.IsStandby())
+	or.mba.AssertExpectations(t)
+	or.mnm.AssertExpectations(t)
+	or.mem.AssertExpectations(t)
+
+	_, err = or.Promote(or.ctx)
+	assert.Regexp(t, "FF10423", err)
+}
+
+func TestPromoteBatchStartFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.standby = true
+	or.mba.On("Start").Return(fmt.Errorf("pop"))
+	_, err := or.Promote(or.ctx)
+	assert.EqualError(t, err, "pop")
+	assert.True(t, or.IsStandby())
+}
+
+func TestPromoteEventDispatchFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.standby = true
+	or.mba.On("Start").Return(nil)
+	or.mnm.On("Start").Return(nil)
+	or.mem.On("StartDispatch").Return(fmt.Errorf("pop"))
+	_, err := or.Promote(or.ctx)
+	assert.EqualError(t, err, "pop")
+	assert.True(t, or.IsStandby())
+}
+
+func TestPromoteIsolatedNamespaces(t *testing.T) {
+	or := newTestOrchestrator()
+	or.standby = true
+	child := newTestOrchestrator()
+	child.standby = true
+	promoted := newTestOrchestrator()
+	or.isolated = map[string]*orchestrator{"ns2": &child.orchestrator, "ns3": &promoted.orchestrator}
+	for _, tor := range []*testOrchestrator{or, child} {
+		tor.mba.On("Start").Return(nil)
+		tor.mnm.On("Start").Return(nil)
+		tor.mem.On("StartDispatch").Return(nil)
+	}
+	err := or.promote(or.ctx)
+	assert.NoError(t, err)
+	assert.False(t, child.IsStandby())
+	child.mem.AssertExpectations(t)
+	promoted.mem.AssertNotCalled(t, "StartDispatch")
+}
+
+func TestPromoteIsolatedNamespaceFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.standby = true
+	child := newTestOrchestrator()
+	child.standby = true
+	or.isolated = map[string]*orchestrator{"ns2": &child.orchestrator}
+	child.mba.On("Start").Return(fmt.Errorf("pop"))
+	err := or.promote(or.ctx)
+	assert.EqualError(t, err, "pop")
+	assert.True(t, or.IsStandby())
+	or.mba.AssertNotCalled(t, "Start")
+}
+
+func TestSubscriptionChangesStandby(t *testing.T) {
+	mem := &eventmocks.EventManager{}
+	o := &orchestrator{
+		events:  mem,
+		standby: true,
+	}
+	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 3)))
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeCreated, "ns1", fftypes.NewUUID())
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeUpdated, "ns1", fftypes.NewUUID())
+	o.UUIDCollectionNSEvent(database.CollectionSubscriptions, fftypes.ChangeEventTypeDeleted, "ns1", fftypes.NewUUID())
+	mem.AssertNotCalled(t, "NewSubscriptions")
+	mem.AssertNotCalled(t, "SubscriptionUpdates")
+	mem.AssertNotCalled(t, "DeletedSubscriptions")
+}
+
+func TestMessageCreatedStandby(t *testing.T) {
+	mb := &batchmocks.Manager{}
+	mem := &eventmocks.EventManager{}
+	o := &orchestrator{
+		batch:   mb,
+		events:  mem,
+		standby: true,
+	}
+	mem.On("ChangeEvents").Return((chan<- *fftypes.ChangeEvent)(make(chan *fftypes.ChangeEvent, 1)))
+	o.OrderedUUIDCollectionNSEvent(database.CollectionMessages, fftypes.ChangeEventTypeCreated, "ns1", fftypes.NewUUID(), 12345)
+	mb.AssertNotCalled(t, "NewMessages")
+}
@@ -18,6 +18,7 @@ package orchestrator
 
 import (
 	"context"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/log"
@@ -46,7 +47,8 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 	orgKey := or.identity.GetOrgKey(ctx)
 	status = &fftypes.NodeStatus{
 		Node: fftypes.NodeStatusNode{
-			Name: config.GetString(config.NodeName),
+			Name:    config.GetString(config.NodeName),
+			Standby: or.IsStandby(),
 		},
 		Org: fftypes.NodeStatusOrg{
 			Name:     config.GetString(config.OrgName),
@@ -55,7 +57,9 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 		Defaults: fftypes.NodeStatusDefaults{
 			Namespace: config.GetString(config.NamespacesDefault),
 		},
+		Database: or.database.ConnectionStatus(ctx),
 	}
+	status.Clock = or.clockStatus(status.Database)
 
 	org, err := or.database.GetOrganizationByName(ctx, status.Org.Name)
 	if err != nil {
@@ -79,3 +83,27 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 
 	return status, nil
 }
+
+// clockStatus reports the skew of the local clock against the database and blockchain. The database is skewed if
+// it deviates either way by more than the max skew, but the blockchain is only skewed if the last block time is ahead
+// of the local clock, as a local clock that is behind cannot be distinguished from confirmation latency.
+func (or *orchestrator) clockStatus(db *fftypes.NodeStatusDatabase) *fftypes.NodeStatusClock {
+	maxSkew := config.GetDuration(config.EventReceiveTimestampsMaxSkew)
+	clock := &fftypes.NodeStatusClock{
+		Time:           fftypes.Now(),
+		MaxSkew:        fftypes.FFDuration(maxSkew),
+		BlockchainSkew: or.events.BlockchainClockSkew(),
+	}
+	if db != nil {
+		clock.DatabaseSkew = db.ClockSkew
+	}
+	if maxSkew > 0 {
+		if skew := clock.DatabaseSkew; skew != nil && (time.Duration(*skew) > maxSkew || time.Duration(*skew) < -maxSkew) {
+			clock.Skewed = true
+		}
+		if skew := clock.BlockchainSkew; skew != nil && time.Duration(*skew) < -maxSkew {
+			clock.Skewed = true
+		}
+	}
+	return clock
+}
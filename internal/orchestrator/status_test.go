@@ -19,6 +19,7 @@ package orchestrator
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/mocks/databasemocks"
@@ -40,6 +41,10 @@ func TestGetStatusRegistered(t *testing.T) {
 	nodeID := fftypes.NewUUID()
 
 	mdi := or.database.(*databasemocks.Plugin)
+	dbSkew := fftypes.FFDuration(1 * time.Second)
+	mdi.On("ConnectionStatus", or.ctx).Return(&fftypes.NodeStatusDatabase{Provider: "sqlite3", Healthy: true, ClockSkew: &dbSkew})
+	bcSkew := fftypes.FFDuration(10 * time.Second)
+	or.mem.On("BlockchainClockSkew").Return(&bcSkew)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(&fftypes.Organization{
 		ID:       orgID,
 		Identity: "0x1111111",
@@ -55,6 +60,11 @@ func TestGetStatusRegistered(t *testing.T) {
 
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
+	assert.True(t, status.Database.Healthy)
+	assert.Equal(t, dbSkew, *status.Clock.DatabaseSkew)
+	assert.Equal(t, bcSkew, *status.Clock.BlockchainSkew)
+	assert.Equal(t, fftypes.FFDuration(5*time.Minute), status.Clock.MaxSkew)
+	assert.False(t, status.Clock.Skewed)
 
 	assert.Equal(t, "default", status.Defaults.Namespace)
 
@@ -81,6 +91,8 @@ func TestGetStatusUnregistered(t *testing.T) {
 	config.Set(config.NodeName, "node1")
 
 	mdi := or.database.(*databasemocks.Plugin)
+	mdi.On("ConnectionStatus", or.ctx).Return(&fftypes.NodeStatusDatabase{})
+	or.mem.On("BlockchainClockSkew").Return(nil)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(nil, nil)
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetOrgKey", mock.Anything).Return("0x1111111")
@@ -111,6 +123,8 @@ func TestGetStatusOrgOnlyRegistered(t *testing.T) {
 	orgID := fftypes.NewUUID()
 
 	mdi := or.database.(*databasemocks.Plugin)
+	mdi.On("ConnectionStatus", or.ctx).Return(&fftypes.NodeStatusDatabase{})
+	or.mem.On("BlockchainClockSkew").Return(nil)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(&fftypes.Organization{
 		ID:       orgID,
 		Identity: "0x1111111",
@@ -145,6 +159,8 @@ func TestGetStatuOrgError(t *testing.T) {
 	config.Set(config.NodeName, "node1")
 
 	mdi := or.database.(*databasemocks.Plugin)
+	mdi.On("ConnectionStatus", or.ctx).Return(&fftypes.NodeStatusDatabase{})
+	or.mem.On("BlockchainClockSkew").Return(nil)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(nil, fmt.Errorf("pop"))
 	mim := or.identity.(*identitymanagermocks.Manager)
 	mim.On("GetOrgKey", mock.Anything).Return("0x1111111")
@@ -164,6 +180,8 @@ func TestGetStatusNodeError(t *testing.T) {
 	orgID := fftypes.NewUUID()
 
 	mdi := or.database.(*databasemocks.Plugin)
+	mdi.On("ConnectionStatus", or.ctx).Return(&fftypes.NodeStatusDatabase{})
+	or.mem.On("BlockchainClockSkew").Return(nil)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(&fftypes.Organization{
 		ID:       orgID,
 		Identity: "0x1111111",
@@ -179,3 +197,26 @@ func TestGetStatusNodeError(t *testing.T) {
 	assert.Nil(t, or.GetNodeUUID(or.ctx))
 
 }
+
+func TestClockStatusSkewed(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Reset()
+	config.Set(config.EventReceiveTimestampsMaxSkew, "1m")
+
+	dbSkew := fftypes.FFDuration(-2 * time.Minute)
+	or.mem.On("BlockchainClockSkew").Return(nil).Once()
+	clock := or.clockStatus(&fftypes.NodeStatusDatabase{ClockSkew: &dbSkew})
+	assert.True(t, clock.Skewed)
+
+	// A block time behind the local clock could be confirmation latency
+	bcSkew := fftypes.FFDuration(2 * time.Minute)
+	or.mem.On("BlockchainClockSkew").Return(&bcSkew).Once()
+	clock = or.clockStatus(nil)
+	assert.False(t, clock.Skewed)
+	assert.Nil(t, clock.DatabaseSkew)
+
+	bcSkew = fftypes.FFDuration(-2 * time.Minute)
+	or.mem.On("BlockchainClockSkew").Return(&bcSkew).Once()
+	clock = or.clockStatus(nil)
+	assert.True(t, clock.Skewed)
+}
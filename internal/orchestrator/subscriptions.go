@@ -19,6 +19,7 @@ package orchestrator
 import (
 	"context"
 
+	"github.com/hyperledger/firefly/internal/auth"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/database"
@@ -38,6 +39,7 @@ func (or *orchestrator) createUpdateSubscription(ctx context.Context, ns string,
 	subDef.Created = fftypes.Now()
 	subDef.Namespace = ns
 	subDef.Ephemeral = false
+	subDef.Owner = auth.GetIdentity(ctx)
 	if err := or.data.VerifyNamespaceExists(ctx, subDef.Namespace); err != nil {
 		return nil, err
 	}
@@ -48,7 +50,10 @@ func (or *orchestrator) createUpdateSubscription(ctx context.Context, ns string,
 		return nil, i18n.NewError(ctx, i18n.MsgSystemTransportInternal)
 	}
 
-	return subDef, or.events.CreateUpdateDurableSubscription(ctx, subDef, mustNew)
+	if err := or.events.CreateUpdateDurableSubscription(ctx, subDef, mustNew); err != nil {
+		return nil, err
+	}
+	return subDef.Redacted(), nil
 }
 
 func (or *orchestrator) DeleteSubscription(ctx context.Context, ns, id string) error {
@@ -63,12 +68,25 @@ func (or *orchestrator) DeleteSubscription(ctx context.Context, ns, id string) e
 	if sub == nil || sub.Namespace != ns {
 		return i18n.NewError(ctx, i18n.Msg404NotFound)
 	}
+	if !auth.CanAccessSubscription(auth.GetIdentity(ctx), sub) {
+		return i18n.NewError(ctx, i18n.MsgSubscriptionNotOwner, sub.Namespace, sub.Name)
+	}
 	return or.events.DeleteDurableSubscription(ctx, sub)
 }
 
 func (or *orchestrator) GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
-	return or.database.GetSubscriptions(ctx, filter)
+	// Subscriptions owned by other identities are only visible to admins
+	identity := auth.GetIdentity(ctx)
+	if !auth.IsAdmin(identity) {
+		fb := filter.Builder()
+		filter = filter.Condition(fb.Or(fb.Eq("owner", identity), fb.Eq("owner", "")))
+	}
+	subs, fr, err := or.database.GetSubscriptions(ctx, filter)
+	for i, sub := range subs {
+		subs[i] = sub.Redacted()
+	}
+	return subs, fr, err
 }
 
 func (or *orchestrator) GetSubscriptionByID(ctx context.Context, ns, id string) (*fftypes.Subscription, error) {
@@ -76,5 +94,12 @@ func (or *orchestrator) GetSubscriptionByID(ctx context.Context, ns, id string)
 	if err != nil {
 		return nil, err
 	}
-	return or.database.GetSubscriptionByID(ctx, u)
+	sub, err := or.database.GetSubscriptionByID(ctx, u)
+	if err != nil || sub == nil {
+		return sub, err
+	}
+	if !auth.CanAccessSubscription(auth.GetIdentity(ctx), sub) {
+		return nil, i18n.NewError(ctx, i18n.MsgSubscriptionNotOwner, sub.Namespace, sub.Name)
+	}
+	return sub.Redacted(), nil
 }
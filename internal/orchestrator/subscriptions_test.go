@@ -18,9 +18,12 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/firefly/internal/auth"
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/events/system"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -77,6 +80,47 @@ func TestCreateSubscriptionOk(t *testing.T) {
 	assert.Equal(t, "ns1", sub.Namespace)
 }
 
+func TestCreateSubscriptionSecretRedacted(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := &fftypes.Subscription{}
+	err := json.Unmarshal([]byte(`{"name":"sub1","transport":"webhooks","options":{"secret":"s3cr3t"}}`), sub)
+	assert.NoError(t, err)
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mem.On("CreateUpdateDurableSubscription", mock.Anything, mock.MatchedBy(func(s *fftypes.Subscription) bool {
+		return s.Options.TransportOptions().GetString("secret") == "s3cr3t"
+	}), true).Return(nil)
+	s1, err := or.CreateSubscription(or.ctx, "ns1", sub)
+	assert.NoError(t, err)
+	assert.NotContains(t, s1.Options.TransportOptions(), "secret")
+}
+
+func TestCreateSubscriptionFail(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			Name: "sub1",
+		},
+	}
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mem.On("CreateUpdateDurableSubscription", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+	_, err := or.CreateSubscription(or.ctx, "ns1", sub)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCreateSubscriptionOwner(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			Name: "sub1",
+		},
+	}
+	or.mdm.On("VerifyNamespaceExists", mock.Anything, "ns1").Return(nil)
+	or.mem.On("CreateUpdateDurableSubscription", mock.Anything, mock.Anything, true).Return(nil)
+	s1, err := or.CreateSubscription(auth.WithIdentity(or.ctx, "CN=app1"), "ns1", sub)
+	assert.NoError(t, err)
+	assert.Equal(t, "CN=app1", s1.Owner)
+}
+
 func TestCreateUpdateSubscriptionOk(t *testing.T) {
 	or := newTestOrchestrator()
 	sub := &fftypes.Subscription{
@@ -134,6 +178,38 @@ func TestDeleteSubscription(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDeleteSubscriptionNotOwner(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Name:      "sub1",
+			Namespace: "ns1",
+		},
+		Owner: "CN=app1",
+	}
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	err := or.DeleteSubscription(auth.WithIdentity(or.ctx, "CN=app2"), "ns1", sub.ID.String())
+	assert.Regexp(t, "FF10329", err)
+}
+
+func TestDeleteSubscriptionAdmin(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.SubscriptionAdmins, []string{"CN=admin"})
+	sub := &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Name:      "sub1",
+			Namespace: "ns1",
+		},
+		Owner: "CN=app1",
+	}
+	or.mdi.On("GetSubscriptionByID", mock.Anything, sub.ID).Return(sub, nil)
+	or.mem.On("DeleteDurableSubscription", mock.Anything, sub).Return(nil)
+	err := or.DeleteSubscription(auth.WithIdentity(or.ctx, "CN=admin"), "ns1", sub.ID.String())
+	assert.NoError(t, err)
+}
+
 func TestGetSubscriptions(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -144,6 +220,61 @@ func TestGetSubscriptions(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetSubscriptionsSecretRedacted(t *testing.T) {
+	or := newTestOrchestrator()
+	sub := &fftypes.Subscription{}
+	err := json.Unmarshal([]byte(`{"name":"sub1","transport":"webhooks","options":{"secret":"s3cr3t"}}`), sub)
+	assert.NoError(t, err)
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{sub}, nil, nil)
+	fb := database.SubscriptionQueryFactory.NewFilter(context.Background())
+	subs, _, err := or.GetSubscriptions(context.Background(), "ns1", fb.And())
+	assert.NoError(t, err)
+	assert.Len(t, subs, 1)
+	assert.NotContains(t, subs[0].Options.TransportOptions(), "secret")
+}
+
+func TestGetSubscriptionsOwnerScoped(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return fi.String() == "( name == 'sub1' ) && ( namespace == 'ns1' ) && ( ( owner == 'CN=app1' ) || ( owner == '' ) )"
+	})).Return([]*fftypes.Subscription{}, nil, nil)
+	fb := database.SubscriptionQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("name", "sub1"))
+	_, _, err := or.GetSubscriptions(auth.WithIdentity(context.Background(), "CN=app1"), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetSubscriptionsAdmin(t *testing.T) {
+	or := newTestOrchestrator()
+	config.Set(config.SubscriptionAdmins, []string{"CN=admin"})
+	or.mdi.On("GetSubscriptions", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
+		fi, _ := filter.Finalize()
+		return fi.String() == "( name == 'sub1' ) && ( namespace == 'ns1' )"
+	})).Return([]*fftypes.Subscription{}, nil, nil)
+	fb := database.SubscriptionQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("name", "sub1"))
+	_, _, err := or.GetSubscriptions(auth.WithIdentity(context.Background(), "CN=admin"), "ns1", f)
+	assert.NoError(t, err)
+}
+
+func TestGetSubscriptionByIDOwner(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, u).Return(&fftypes.Subscription{Owner: "CN=app1"}, nil)
+	sub, err := or.GetSubscriptionByID(auth.WithIdentity(context.Background(), "CN=app1"), "ns1", u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "CN=app1", sub.Owner)
+}
+
+func TestGetSubscriptionByIDNotOwner(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetSubscriptionByID", mock.Anything, u).Return(&fftypes.Subscription{Owner: "CN=app1"}, nil)
+	_, err := or.GetSubscriptionByID(auth.WithIdentity(context.Background(), "CN=app2"), "ns1", u.String())
+	assert.Regexp(t, "FF10329", err)
+}
+
 func TestGetSGetSubscriptionsByID(t *testing.T) {
 	or := newTestOrchestrator()
 	u := fftypes.NewUUID()
@@ -0,0 +1,176 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	txnCostGroupBySigner = "signer"
+	txnCostGroupByType   = "type"
+	txnCostGroupByTag    = "tag"
+)
+
+var txnCostGroupByValues = []string{txnCostGroupBySigner, txnCostGroupByType, txnCostGroupByTag}
+
+type txnCostAccumulator struct {
+	report *fftypes.TransactionCostReport
+	groups map[string]*fftypes.TransactionCostGroup
+}
+
+func addCost(total, cost *big.Int) *fftypes.BigInt {
+	return (*fftypes.BigInt)(new(big.Int).Add(total, cost))
+}
+
+func (a *txnCostAccumulator) add(value string, txCount int64, gasUsed, amount *big.Int) {
+	group, ok := a.groups[value]
+	if !ok {
+		group = &fftypes.TransactionCostGroup{
+			Value:   value,
+			GasUsed: fftypes.NewBigInt(0),
+			Amount:  fftypes.NewBigInt(0),
+		}
+		a.groups[value] = group
+		a.report.Groups = append(a.report.Groups, group)
+	}
+	group.Transactions += txCount
+	group.GasUsed = addCost(group.GasUsed.Int(), gasUsed)
+	group.Amount = addCost(group.Amount.Int(), amount)
+}
+
+// splitCost divides a cost evenly across a number of shares, with any remainder going to the first share
+func splitCost(cost *big.Int, shares int) (share, first *big.Int) {
+	share, rem := new(big.Int).QuoRem(cost, big.NewInt(int64(shares)), new(big.Int))
+	return share, new(big.Int).Add(share, rem)
+}
+
+// attributeByTag splits the cost of a batch pin transaction evenly across the messages in the batch,
+// and attributes each share to the tag of the message
+func (or *orchestrator) attributeByTag(ctx context.Context, ns string, tx *fftypes.Transaction, gasUsed, amount *big.Int, acc *txnCostAccumulator) error {
+	var msgs []*fftypes.Message
+	if tx.Subject.Type == fftypes.TransactionTypeBatchPin && tx.Subject.Reference != nil {
+		fb := database.MessageQueryFactory.NewFilter(ctx)
+		var err error
+		msgs, _, err = or.database.GetMessages(ctx, fb.And(
+			fb.Eq("namespace", ns),
+			fb.Eq("batch", tx.Subject.Reference),
+		))
+		if err != nil {
+			return err
+		}
+	}
+	if len(msgs) == 0 {
+		acc.add("", 1, gasUsed, amount)
+		return nil
+	}
+
+	gasShare, gasFirst := splitCost(gasUsed, len(msgs))
+	amountShare, amountFirst := splitCost(amount, len(msgs))
+	for i, msg := range msgs {
+		// The transaction is counted once, against the tag of the first message
+		if i == 0 {
+			acc.add(msg.Header.Tag, 1, gasFirst, amountFirst)
+		} else {
+			acc.add(msg.Header.Tag, 0, gasShare, amountShare)
+		}
+	}
+	return nil
+}
+
+// GetTransactionCostReport totals the fees recorded against transactions in the namespace, grouped by
+// the signing key, the transaction type, or the tag of the messages pinned in each transaction.
+// The report is optionally bounded to a time range (inclusive of the start time, exclusive of the end time)
+func (or *orchestrator) GetTransactionCostReport(ctx context.Context, ns, groupBy string, startTime, endTime *fftypes.FFTime) (*fftypes.TransactionCostReport, error) {
+	if groupBy == "" {
+		groupBy = txnCostGroupBySigner
+	}
+	switch groupBy {
+	case txnCostGroupBySigner, txnCostGroupByType, txnCostGroupByTag:
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgTxnCostBadGroupBy, groupBy, strings.Join(txnCostGroupByValues, ","))
+	}
+	if startTime != nil && endTime != nil && startTime.UnixNano() > endTime.UnixNano() {
+		return nil, i18n.NewError(ctx, i18n.MsgHistogramInvalidTimes)
+	}
+
+	fb := database.TransactionQueryFactory.NewFilter(ctx)
+	conditions := []database.Filter{
+		fb.Eq("namespace", ns),
+	}
+	if startTime != nil {
+		conditions = append(conditions, fb.Gte("created", startTime))
+	}
+	if endTime != nil {
+		conditions = append(conditions, fb.Lt("created", endTime))
+	}
+	txns, _, err := or.database.GetTransactions(ctx, fb.And(conditions...).Sort("created").Ascending())
+	if err != nil {
+		return nil, err
+	}
+
+	acc := &txnCostAccumulator{
+		report: &fftypes.TransactionCostReport{
+			GroupBy: groupBy,
+			GasUsed: fftypes.NewBigInt(0),
+			Amount:  fftypes.NewBigInt(0),
+			Groups:  []*fftypes.TransactionCostGroup{},
+		},
+		groups: make(map[string]*fftypes.TransactionCostGroup),
+	}
+	for _, tx := range txns {
+		if tx.Fee == nil || tx.Fee.GasUsed == nil {
+			continue
+		}
+		gasUsed := tx.Fee.GasUsed.Int()
+		amount := new(big.Int)
+		if tx.Fee.Amount != nil {
+			amount = tx.Fee.Amount.Int()
+		}
+		acc.report.Transactions++
+		acc.report.GasUsed = addCost(acc.report.GasUsed.Int(), gasUsed)
+		acc.report.Amount = addCost(acc.report.Amount.Int(), amount)
+
+		switch groupBy {
+		case txnCostGroupByTag:
+			if err := or.attributeByTag(ctx, ns, tx, gasUsed, amount, acc); err != nil {
+				return nil, err
+			}
+		case txnCostGroupByType:
+			acc.add(string(tx.Subject.Type), 1, gasUsed, amount)
+		default:
+			acc.add(tx.Subject.Signer, 1, gasUsed, amount)
+		}
+	}
+
+	// Most expensive first
+	groups := acc.report.Groups
+	sort.Slice(groups, func(i, j int) bool {
+		if c := groups[i].Amount.Int().Cmp(groups[j].Amount.Int()); c != 0 {
+			return c > 0
+		}
+		return groups[i].Value < groups[j].Value
+	})
+	return acc.report, nil
+}
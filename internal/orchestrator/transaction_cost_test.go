@@ -0,0 +1,164 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testTxnsWithFees() []*fftypes.Transaction {
+	return []*fftypes.Transaction{
+		{
+			ID:      fftypes.NewUUID(),
+			Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeBatchPin, Signer: "0x111", Reference: fftypes.NewUUID()},
+			Fee:     &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(100), Amount: fftypes.NewBigInt(1000)},
+		},
+		{
+			ID:      fftypes.NewUUID(),
+			Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeTokenTransfer, Signer: "0x222"},
+			Fee:     &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(50)},
+		},
+		{
+			ID:      fftypes.NewUUID(),
+			Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeBatchPin, Signer: "0x222", Reference: fftypes.NewUUID()},
+			Fee:     &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(20), Amount: fftypes.NewBigInt(200)},
+		},
+		{
+			ID:      fftypes.NewUUID(),
+			Subject: fftypes.TransactionSubject{Type: fftypes.TransactionTypeBatchPin, Signer: "0x333"},
+		},
+	}
+}
+
+func TestGetTransactionCostReportBySigner(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetTransactions", mock.Anything, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.String() == "( namespace == 'ns1' ) && ( created >= 1000000000000 ) && ( created < 4000000000000 ) sort=created"
+	})).Return(testTxnsWithFees(), nil, nil)
+
+	report, err := or.GetTransactionCostReport(context.Background(), "ns1", "", fftypes.UnixTime(1000), fftypes.UnixTime(4000))
+	assert.NoError(t, err)
+	assert.Equal(t, "signer", report.GroupBy)
+	assert.Equal(t, int64(3), report.Transactions)
+	assert.Equal(t, int64(170), report.GasUsed.Int().Int64())
+	assert.Equal(t, int64(1200), report.Amount.Int().Int64())
+	assert.Len(t, report.Groups, 2)
+	assert.Equal(t, "0x111", report.Groups[0].Value)
+	assert.Equal(t, int64(1), report.Groups[0].Transactions)
+	assert.Equal(t, int64(1000), report.Groups[0].Amount.Int().Int64())
+	assert.Equal(t, "0x222", report.Groups[1].Value)
+	assert.Equal(t, int64(2), report.Groups[1].Transactions)
+	assert.Equal(t, int64(70), report.Groups[1].GasUsed.Int().Int64())
+	assert.Equal(t, int64(200), report.Groups[1].Amount.Int().Int64())
+}
+
+func TestGetTransactionCostReportByType(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetTransactions", mock.Anything, mock.Anything).Return(testTxnsWithFees(), nil, nil)
+
+	report, err := or.GetTransactionCostReport(context.Background(), "ns1", "type", nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, report.Groups, 2)
+	assert.Equal(t, "batch_pin", report.Groups[0].Value)
+	assert.Equal(t, int64(2), report.Groups[0].Transactions)
+	assert.Equal(t, int64(120), report.Groups[0].GasUsed.Int().Int64())
+	assert.Equal(t, "token_transfer", report.Groups[1].Value)
+	assert.Equal(t, int64(0), report.Groups[1].Amount.Int().Int64())
+}
+
+func TestGetTransactionCostReportByTag(t *testing.T) {
+	or := newTestOrchestrator()
+	txns := testTxnsWithFees()
+	or.mdi.On("GetTransactions", mock.Anything, mock.Anything).Return(txns, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.String() == fmt.Sprintf("( namespace == 'ns1' ) && ( batch == '%s' )", txns[0].Subject.Reference)
+	})).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{Tag: "orders"}},
+		{Header: fftypes.MessageHeader{Tag: "invoices"}},
+		{Header: fftypes.MessageHeader{Tag: "orders"}},
+	}, nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+
+	report, err := or.GetTransactionCostReport(context.Background(), "ns1", "tag", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), report.Transactions)
+	assert.Len(t, report.Groups, 3)
+	assert.Equal(t, "orders", report.Groups[0].Value)
+	assert.Equal(t, int64(1), report.Groups[0].Transactions)
+	assert.Equal(t, int64(67), report.Groups[0].GasUsed.Int().Int64())
+	assert.Equal(t, int64(667), report.Groups[0].Amount.Int().Int64())
+	assert.Equal(t, "invoices", report.Groups[1].Value)
+	assert.Equal(t, int64(0), report.Groups[1].Transactions)
+	assert.Equal(t, int64(33), report.Groups[1].GasUsed.Int().Int64())
+	assert.Equal(t, int64(333), report.Groups[1].Amount.Int().Int64())
+	assert.Equal(t, "", report.Groups[2].Value)
+	assert.Equal(t, int64(2), report.Groups[2].Transactions)
+	assert.Equal(t, int64(70), report.Groups[2].GasUsed.Int().Int64())
+}
+
+func TestGetTransactionCostReportByTagFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetTransactions", mock.Anything, mock.Anything).Return(testTxnsWithFees(), nil, nil)
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.GetTransactionCostReport(context.Background(), "ns1", "tag", nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetTransactionCostReportQueryFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetTransactions", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := or.GetTransactionCostReport(context.Background(), "ns1", "signer", nil, nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetTransactionCostReportBadGroupBy(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.GetTransactionCostReport(context.Background(), "ns1", "wrong", nil, nil)
+	assert.Regexp(t, "FF10375", err)
+}
+
+func TestGetTransactionCostReportBadTimes(t *testing.T) {
+	or := newTestOrchestrator()
+	now := time.Now()
+	_, err := or.GetTransactionCostReport(context.Background(), "ns1", "", fftypes.UnixTime(now.Unix()), fftypes.UnixTime(now.Unix()-1))
+	assert.Regexp(t, "FF10300", err)
+}
+
+func TestGetTransactionCostReportSameAmount(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetTransactions", mock.Anything, mock.Anything).Return([]*fftypes.Transaction{
+		{Subject: fftypes.TransactionSubject{Signer: "0x222"}, Fee: &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(10)}},
+		{Subject: fftypes.TransactionSubject{Signer: "0x111"}, Fee: &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(10)}},
+	}, nil, nil)
+
+	report, err := or.GetTransactionCostReport(context.Background(), "ns1", "signer", nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x111", report.Groups[0].Value)
+	assert.Equal(t, "0x222", report.Groups[1].Value)
+}
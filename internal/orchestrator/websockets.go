@@ -0,0 +1,40 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/events/websockets"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (or *orchestrator) websockets() *websockets.WebSockets {
+	if ws, ok := or.events.GetTransport("websockets").(*websockets.WebSockets); ok {
+		return ws
+	}
+	// The websockets transport is not enabled, so there are no connections
+	return &websockets.WebSockets{}
+}
+
+func (or *orchestrator) GetWebSocketStatus(ctx context.Context) *fftypes.WebSocketStatus {
+	return or.websockets().GetStatus()
+}
+
+func (or *orchestrator) CloseWebSocketConnection(ctx context.Context, id string) error {
+	return or.websockets().CloseConnection(ctx, id)
+}
@@ -0,0 +1,45 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/events/websockets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebSocketStatus(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("GetTransport", "websockets").Return(&websockets.WebSockets{})
+	status := or.GetWebSocketStatus(or.ctx)
+	assert.Empty(t, status.Connections)
+}
+
+func TestGetWebSocketStatusNotEnabled(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("GetTransport", "websockets").Return(nil)
+	status := or.GetWebSocketStatus(or.ctx)
+	assert.Empty(t, status.Connections)
+}
+
+func TestCloseWebSocketConnectionNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mem.On("GetTransport", "websockets").Return(&websockets.WebSockets{})
+	err := or.CloseWebSocketConnection(or.ctx, "unknown")
+	assert.Regexp(t, "FF10310", err)
+}
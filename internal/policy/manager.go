@@ -0,0 +1,260 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	policyplugin "github.com/hyperledger/firefly/pkg/policy"
+)
+
+// Manager applies the policy plugin to the transactions this node submits to the blockchain,
+// and tracks the submissions the plugin holds for manual approval
+type Manager interface {
+	// CheckSubmission fails if the policy plugin rejects the submission, or holds it for manual approval.
+	// A held submission creates a pending approval, and once an administrator approves it the next identical
+	// submission proceeds. Must not be called inside a database group.
+	CheckSubmission(ctx context.Context, req *fftypes.PolicyRequest) error
+
+	// CheckDetected evaluates a change detected on the blockchain, rather than submitted by this node, and returns
+	// true if it can be applied immediately. A held change creates a pending approval, and is applied by the handler
+	// registered for its type once an administrator approves it. Must not be called inside a database group.
+	CheckDetected(ctx context.Context, req *fftypes.PolicyRequest) (bool, error)
+
+	// RegisterHandler sets the handler that applies detected changes of the given type when they are approved
+	RegisterHandler(reqType fftypes.PolicySubmissionType, handler ApprovalHandler)
+
+	GetApprovals(ctx context.Context, filter database.AndFilter) ([]*fftypes.PolicyApproval, *database.FilterResult, error)
+	GetApprovalByID(ctx context.Context, id string) (*fftypes.PolicyApproval, error)
+	DecideApproval(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.PolicyApproval, error)
+}
+
+// ApprovalHandler applies a detected change that was held for approval, once an administrator approves it.
+// It is called inside the database group that records the decision.
+type ApprovalHandler interface {
+	ApplyApproved(ctx context.Context, approval *fftypes.PolicyApproval) error
+}
+
+type policyManager struct {
+	database database.Plugin
+	plugin   policyplugin.Plugin
+	handlers map[fftypes.PolicySubmissionType]ApprovalHandler
+	mux      sync.Mutex
+}
+
+func NewPolicyManager(ctx context.Context, di database.Plugin, pp policyplugin.Plugin) (Manager, error) {
+	if di == nil || pp == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	return &policyManager{
+		database: di,
+		plugin:   pp,
+		handlers: make(map[fftypes.PolicySubmissionType]ApprovalHandler),
+	}, nil
+}
+
+func (pm *policyManager) RegisterHandler(reqType fftypes.PolicySubmissionType, handler ApprovalHandler) {
+	pm.handlers[reqType] = handler
+}
+
+func (pm *policyManager) CheckSubmission(ctx context.Context, req *fftypes.PolicyRequest) error {
+	hash := req.Hash()
+
+	pm.mux.Lock()
+	defer pm.mux.Unlock()
+
+	// A decision on an identical held submission takes precedence over the plugin
+	fb := database.PolicyApprovalQueryFactory.NewFilter(ctx)
+	existing, _, err := pm.database.GetPolicyApprovals(ctx, fb.And(
+		fb.Eq("namespace", req.Namespace),
+		fb.Eq("hash", hash),
+		fb.Neq("status", fftypes.PolicyApprovalStatusConsumed),
+	).Limit(1))
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return pm.checkExisting(ctx, req, existing[0])
+	}
+
+	decision, reason, err := pm.plugin.Evaluate(ctx, req)
+	if err != nil {
+		return err
+	}
+	switch decision {
+	case fftypes.PolicyDecisionApprove:
+		return nil
+	case fftypes.PolicyDecisionHold:
+		return pm.hold(ctx, req, hash, reason)
+	default:
+		log.L(ctx).Warnf("Policy rejected %s submission ref=%s: %s", req.Type, req.Reference, reason)
+		return i18n.NewError(ctx, i18n.MsgPolicyRejected, req.Type, reason)
+	}
+}
+
+func (pm *policyManager) CheckDetected(ctx context.Context, req *fftypes.PolicyRequest) (bool, error) {
+	hash := req.Hash()
+
+	pm.mux.Lock()
+	defer pm.mux.Unlock()
+
+	// A change that was already held is applied (or not) by the decision on its approval
+	fb := database.PolicyApprovalQueryFactory.NewFilter(ctx)
+	existing, _, err := pm.database.GetPolicyApprovals(ctx, fb.And(
+		fb.Eq("namespace", req.Namespace),
+		fb.Eq("hash", hash),
+	).Limit(1))
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		log.L(ctx).Debugf("Detected %s ref=%s already recorded in approval '%s'", req.Type, req.Reference, existing[0].ID)
+		return false, nil
+	}
+
+	decision, reason, err := pm.plugin.Evaluate(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	switch decision {
+	case fftypes.PolicyDecisionApprove:
+		return true, nil
+	case fftypes.PolicyDecisionHold:
+		approval, err := pm.insertApproval(ctx, req, hash, reason)
+		if err != nil {
+			return false, err
+		}
+		log.L(ctx).Infof("Detected %s ref=%s held pending approval '%s': %s", req.Type, req.Reference, approval.ID, reason)
+		return false, nil
+	default:
+		log.L(ctx).Warnf("Policy rejected detected %s ref=%s - not applying: %s", req.Type, req.Reference, reason)
+		return false, nil
+	}
+}
+
+func (pm *policyManager) checkExisting(ctx context.Context, req *fftypes.PolicyRequest, approval *fftypes.PolicyApproval) error {
+	switch approval.Status {
+	case fftypes.PolicyApprovalStatusApproved:
+		// Each approval allows a single submission
+		log.L(ctx).Infof("Submission of %s ref=%s proceeding under approval '%s' by '%s'", req.Type, req.Reference, approval.ID, approval.DecidedBy)
+		update := database.PolicyApprovalQueryFactory.NewUpdate(ctx).Set("status", fftypes.PolicyApprovalStatusConsumed)
+		return pm.database.UpdatePolicyApproval(ctx, approval.ID, update)
+	case fftypes.PolicyApprovalStatusRejected:
+		return i18n.NewError(ctx, i18n.MsgPolicyApprovalRejected, req.Type, approval.DecidedBy, approval.ID)
+	default:
+		return i18n.NewError(ctx, i18n.MsgPolicyApprovalPending, req.Type, approval.ID)
+	}
+}
+
+func (pm *policyManager) hold(ctx context.Context, req *fftypes.PolicyRequest, hash *fftypes.Bytes32, reason string) error {
+	approval, err := pm.insertApproval(ctx, req, hash, reason)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Submission of %s ref=%s held pending approval '%s': %s", req.Type, req.Reference, approval.ID, reason)
+	return i18n.NewError(ctx, i18n.MsgPolicyApprovalPending, req.Type, approval.ID)
+}
+
+func (pm *policyManager) insertApproval(ctx context.Context, req *fftypes.PolicyRequest, hash *fftypes.Bytes32, reason string) (*fftypes.PolicyApproval, error) {
+	approval := &fftypes.PolicyApproval{
+		ID:            fftypes.NewUUID(),
+		PolicyRequest: *req,
+		Hash:          hash,
+		Reason:        reason,
+		Status:        fftypes.PolicyApprovalStatusPending,
+		Created:       fftypes.Now(),
+	}
+	err := pm.database.RunAsRetryableGroup(ctx, func(ctx context.Context) error {
+		if err := pm.database.InsertPolicyApproval(ctx, approval); err != nil {
+			return err
+		}
+		event := fftypes.NewEvent(fftypes.EventTypePolicyApprovalPending, req.Namespace, approval.ID)
+		return pm.database.InsertEvent(ctx, event)
+	})
+	return approval, err
+}
+
+func (pm *policyManager) GetApprovals(ctx context.Context, filter database.AndFilter) ([]*fftypes.PolicyApproval, *database.FilterResult, error) {
+	return pm.database.GetPolicyApprovals(ctx, filter)
+}
+
+func (pm *policyManager) GetApprovalByID(ctx context.Context, id string) (*fftypes.PolicyApproval, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return pm.database.GetPolicyApprovalByID(ctx, u)
+}
+
+func (pm *policyManager) DecideApproval(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.PolicyApproval, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	status := decision.Status.Lower()
+	if status != fftypes.PolicyApprovalStatusApproved && status != fftypes.PolicyApprovalStatusRejected {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalBadDecision, fftypes.PolicyApprovalStatusApproved, fftypes.PolicyApprovalStatusRejected)
+	}
+	if decision.DecidedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "decidedBy")
+	}
+
+	pm.mux.Lock()
+	defer pm.mux.Unlock()
+
+	approval, err := pm.database.GetPolicyApprovalByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalNotFound, u)
+	}
+	if approval.Status != fftypes.PolicyApprovalStatusPending {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalNotPending, u, approval.Status)
+	}
+
+	err = pm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		if handler := pm.handlers[approval.Type]; handler != nil && status == fftypes.PolicyApprovalStatusApproved {
+			// Detected changes are not resubmitted, so the approval is used as soon as the change is applied
+			if err := handler.ApplyApproved(ctx, approval); err != nil {
+				return err
+			}
+			status = fftypes.PolicyApprovalStatusConsumed
+		}
+
+		approval.Status = status
+		approval.Decided = fftypes.Now()
+		approval.DecidedBy = decision.DecidedBy
+		approval.Comment = decision.Comment
+		update := database.PolicyApprovalQueryFactory.NewUpdate(ctx).
+			Set("status", approval.Status).
+			Set("decided", approval.Decided).
+			Set("decidedby", approval.DecidedBy).
+			Set("comment", approval.Comment)
+		return pm.database.UpdatePolicyApproval(ctx, u, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Policy approval '%s' for %s submission decided status=%s by '%s'", u, approval.Type, approval.Status, approval.DecidedBy)
+	return approval, nil
+}
@@ -0,0 +1,433 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/eventmocks"
+	"github.com/hyperledger/firefly/mocks/policymocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPolicyManager(t *testing.T) (*policyManager, *databasemocks.Plugin, *policymocks.Plugin) {
+	mdi := &databasemocks.Plugin{}
+	mpp := &policymocks.Plugin{}
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	rrg := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything).Maybe()
+	rrg.RunFn = func(a mock.Arguments) {
+		rrg.ReturnArguments = mock.Arguments{a[1].(func(context.Context) error)(a[0].(context.Context))}
+	}
+	pm, err := NewPolicyManager(context.Background(), mdi, mpp)
+	assert.NoError(t, err)
+	return pm.(*policyManager), mdi, mpp
+}
+
+func newTestRequest() *fftypes.PolicyRequest {
+	return &fftypes.PolicyRequest{
+		Type:       fftypes.PolicySubmissionTypeTokenTransfer,
+		Namespace:  "ns1",
+		SigningKey: "0x12345",
+		Reference:  fftypes.NewUUID(),
+		Input:      fftypes.JSONObject{"amount": "10"},
+	}
+}
+
+func TestNewPolicyManagerFail(t *testing.T) {
+	_, err := NewPolicyManager(context.Background(), nil, nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestCheckSubmissionApproved(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionApprove, "", nil)
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+	mpp.AssertExpectations(t)
+}
+
+func TestCheckSubmissionRejected(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionReject, "too much", nil)
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.Regexp(t, "FF10344.*too much", err)
+}
+
+func TestCheckSubmissionEvaluateFail(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionReject, "", fmt.Errorf("pop"))
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckSubmissionQueryFail(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := pm.CheckSubmission(context.Background(), newTestRequest())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckSubmissionHold(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	var approval *fftypes.PolicyApproval
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionHold, "needs review", nil)
+	mdi.On("InsertPolicyApproval", mock.Anything, mock.MatchedBy(func(a *fftypes.PolicyApproval) bool {
+		approval = a
+		return a.Status == fftypes.PolicyApprovalStatusPending &&
+			a.Reason == "needs review" &&
+			*a.Hash == *req.Hash() &&
+			a.Reference == req.Reference
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePolicyApprovalPending && e.Namespace == "ns1" && e.Reference == approval.ID
+	})).Return(nil)
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.Regexp(t, "FF10345", err)
+	assert.Regexp(t, approval.ID.String(), err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckSubmissionHoldInsertFail(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionHold, "needs review", nil)
+	mdi.On("InsertPolicyApproval", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckSubmissionHoldEventFail(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionHold, "needs review", nil)
+	mdi.On("InsertPolicyApproval", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.CheckSubmission(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckSubmissionExistingPending(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	approval := &fftypes.PolicyApproval{ID: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusPending}
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{approval}, nil, nil)
+
+	err := pm.CheckSubmission(context.Background(), newTestRequest())
+	assert.Regexp(t, "FF10345.*"+approval.ID.String(), err)
+}
+
+func TestCheckSubmissionExistingRejected(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	approval := &fftypes.PolicyApproval{ID: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusRejected, DecidedBy: "admin2"}
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{approval}, nil, nil)
+
+	err := pm.CheckSubmission(context.Background(), newTestRequest())
+	assert.Regexp(t, "FF10346.*admin2", err)
+}
+
+func TestCheckSubmissionExistingApprovedConsumes(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	approval := &fftypes.PolicyApproval{ID: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusApproved, DecidedBy: "admin2"}
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{approval}, nil, nil)
+	mdi.On("UpdatePolicyApproval", mock.Anything, approval.ID, mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		return info.String() == "status='consumed'"
+	})).Return(nil)
+
+	err := pm.CheckSubmission(context.Background(), newTestRequest())
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckDetectedApproved(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionApprove, "", nil)
+
+	apply, err := pm.CheckDetected(context.Background(), req)
+	assert.NoError(t, err)
+	assert.True(t, apply)
+	mdi.AssertExpectations(t)
+	mpp.AssertExpectations(t)
+}
+
+func TestCheckDetectedRejected(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionReject, "not allowed", nil)
+
+	apply, err := pm.CheckDetected(context.Background(), req)
+	assert.NoError(t, err)
+	assert.False(t, apply)
+}
+
+func TestCheckDetectedHold(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionHold, "needs review", nil)
+	mdi.On("InsertPolicyApproval", mock.Anything, mock.MatchedBy(func(a *fftypes.PolicyApproval) bool {
+		return a.Status == fftypes.PolicyApprovalStatusPending && a.Reason == "needs review"
+	})).Return(nil)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypePolicyApprovalPending
+	})).Return(nil)
+
+	apply, err := pm.CheckDetected(context.Background(), req)
+	assert.NoError(t, err)
+	assert.False(t, apply)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckDetectedHoldFail(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionHold, "needs review", nil)
+	mdi.On("InsertPolicyApproval", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.CheckDetected(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckDetectedEvaluateFail(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	req := newTestRequest()
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	mpp.On("Evaluate", mock.Anything, req).Return(fftypes.PolicyDecisionReject, "", fmt.Errorf("pop"))
+
+	_, err := pm.CheckDetected(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckDetectedQueryFail(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := pm.CheckDetected(context.Background(), newTestRequest())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckDetectedExisting(t *testing.T) {
+	pm, mdi, mpp := newTestPolicyManager(t)
+	approval := &fftypes.PolicyApproval{ID: fftypes.NewUUID(), Status: fftypes.PolicyApprovalStatusConsumed}
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{approval}, nil, nil)
+
+	apply, err := pm.CheckDetected(context.Background(), newTestRequest())
+	assert.NoError(t, err)
+	assert.False(t, apply)
+	mpp.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+}
+
+func TestGetApprovals(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mdi.On("GetPolicyApprovals", mock.Anything, mock.Anything).Return([]*fftypes.PolicyApproval{}, nil, nil)
+	f := database.PolicyApprovalQueryFactory.NewFilter(context.Background()).And()
+	_, _, err := pm.GetApprovals(context.Background(), f)
+	assert.NoError(t, err)
+}
+
+func TestGetApprovalByID(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	u := fftypes.NewUUID()
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(&fftypes.PolicyApproval{ID: u}, nil)
+	approval, err := pm.GetApprovalByID(context.Background(), u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, approval.ID)
+}
+
+func TestGetApprovalByIDBadID(t *testing.T) {
+	pm, _, _ := newTestPolicyManager(t)
+	_, err := pm.GetApprovalByID(context.Background(), "!uuid")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideApprovalApprove(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	u := fftypes.NewUUID()
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(&fftypes.PolicyApproval{ID: u, Status: fftypes.PolicyApprovalStatusPending}, nil)
+	mdi.On("UpdatePolicyApproval", mock.Anything, u, mock.Anything).Return(nil)
+	approval, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    "Approved",
+		DecidedBy: "admin2",
+		Comment:   "checked",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, approval.Status)
+	assert.Equal(t, "admin2", approval.DecidedBy)
+	assert.Equal(t, "checked", approval.Comment)
+	assert.NotNil(t, approval.Decided)
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideApprovalAppliesDetected(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mah := &eventmocks.EventManager{}
+	pm.RegisterHandler(fftypes.PolicySubmissionTypeTokenPoolGovernance, mah)
+	u := fftypes.NewUUID()
+	approval := &fftypes.PolicyApproval{
+		ID:            u,
+		PolicyRequest: fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeTokenPoolGovernance},
+		Status:        fftypes.PolicyApprovalStatusPending,
+	}
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(approval, nil)
+	mah.On("ApplyApproved", mock.Anything, approval).Return(nil)
+	mdi.On("UpdatePolicyApproval", mock.Anything, u, mock.Anything).Return(nil)
+	result, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusConsumed, result.Status)
+	mdi.AssertExpectations(t)
+	mah.AssertExpectations(t)
+}
+
+func TestDecideApprovalRejectsDetected(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mah := &eventmocks.EventManager{}
+	pm.RegisterHandler(fftypes.PolicySubmissionTypeTokenPoolGovernance, mah)
+	u := fftypes.NewUUID()
+	approval := &fftypes.PolicyApproval{
+		ID:            u,
+		PolicyRequest: fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeTokenPoolGovernance},
+		Status:        fftypes.PolicyApprovalStatusPending,
+	}
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(approval, nil)
+	mdi.On("UpdatePolicyApproval", mock.Anything, u, mock.Anything).Return(nil)
+	result, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusRejected, result.Status)
+	mah.AssertNotCalled(t, "ApplyApproved", mock.Anything, mock.Anything)
+}
+
+func TestDecideApprovalApplyDetectedFail(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mah := &eventmocks.EventManager{}
+	pm.RegisterHandler(fftypes.PolicySubmissionTypeTokenPoolGovernance, mah)
+	u := fftypes.NewUUID()
+	approval := &fftypes.PolicyApproval{
+		ID:            u,
+		PolicyRequest: fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeTokenPoolGovernance},
+		Status:        fftypes.PolicyApprovalStatusPending,
+	}
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(approval, nil)
+	mah.On("ApplyApproved", mock.Anything, approval).Return(fmt.Errorf("pop"))
+	_, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin2",
+	})
+	assert.EqualError(t, err, "pop")
+	mdi.AssertNotCalled(t, "UpdatePolicyApproval", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDecideApprovalBadID(t *testing.T) {
+	pm, _, _ := newTestPolicyManager(t)
+	_, err := pm.DecideApproval(context.Background(), "!uuid", &fftypes.PolicyApprovalDecision{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideApprovalBadStatus(t *testing.T) {
+	pm, _, _ := newTestPolicyManager(t)
+	_, err := pm.DecideApproval(context.Background(), fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusConsumed,
+		DecidedBy: "admin2",
+	})
+	assert.Regexp(t, "FF10348", err)
+}
+
+func TestDecideApprovalMissingDecidedBy(t *testing.T) {
+	pm, _, _ := newTestPolicyManager(t)
+	_, err := pm.DecideApproval(context.Background(), fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status: fftypes.PolicyApprovalStatusRejected,
+	})
+	assert.Regexp(t, "FF10140.*decidedBy", err)
+}
+
+func TestDecideApprovalGetFail(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mdi.On("GetPolicyApprovalByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := pm.DecideApproval(context.Background(), fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin2",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDecideApprovalNotFound(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	mdi.On("GetPolicyApprovalByID", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err := pm.DecideApproval(context.Background(), fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin2",
+	})
+	assert.Regexp(t, "FF10349", err)
+}
+
+func TestDecideApprovalAlreadyDecided(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	u := fftypes.NewUUID()
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(&fftypes.PolicyApproval{ID: u, Status: fftypes.PolicyApprovalStatusConsumed}, nil)
+	_, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin2",
+	})
+	assert.Regexp(t, "FF10347.*consumed", err)
+}
+
+func TestDecideApprovalUpdateFail(t *testing.T) {
+	pm, mdi, _ := newTestPolicyManager(t)
+	u := fftypes.NewUUID()
+	mdi.On("GetPolicyApprovalByID", mock.Anything, u).Return(&fftypes.PolicyApproval{ID: u, Status: fftypes.PolicyApprovalStatusPending}, nil)
+	mdi.On("UpdatePolicyApproval", mock.Anything, u, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := pm.DecideApproval(context.Background(), u.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin2",
+	})
+	assert.EqualError(t, err, "pop")
+}
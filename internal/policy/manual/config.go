@@ -0,0 +1,39 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manual
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+const (
+	// ManualConfigTypes is the list of submission types that are held for manual approval
+	ManualConfigTypes = "types"
+	// ManualConfigNamespaces restricts holds to submissions in the listed namespaces. All namespaces are included if empty
+	ManualConfigNamespaces = "namespaces"
+)
+
+func (m *Manual) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(ManualConfigTypes, []string{
+		string(fftypes.PolicySubmissionTypeBatchPin),
+		string(fftypes.PolicySubmissionTypeTokenPool),
+		string(fftypes.PolicySubmissionTypeTokenTransfer),
+		string(fftypes.PolicySubmissionTypeContractInvoke),
+	})
+	prefix.AddKnownKey(ManualConfigNamespaces)
+}
@@ -0,0 +1,68 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manual
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/policy"
+)
+
+// Manual is a policy plugin that holds the configured types of submission for an administrator
+// to approve, so that a second person must confirm each transaction (four-eyes control)
+type Manual struct {
+	capabilities *policy.Capabilities
+	types        map[string]bool
+	namespaces   map[string]bool
+}
+
+func (m *Manual) Name() string {
+	return "manual"
+}
+
+func (m *Manual) Init(ctx context.Context, prefix config.Prefix) (err error) {
+	m.capabilities = &policy.Capabilities{}
+	m.types = make(map[string]bool)
+	for _, t := range prefix.GetStringSlice(ManualConfigTypes) {
+		m.types[strings.ToLower(t)] = true
+	}
+	m.namespaces = make(map[string]bool)
+	for _, ns := range prefix.GetStringSlice(ManualConfigNamespaces) {
+		m.namespaces[ns] = true
+	}
+	log.L(ctx).Infof("Manual approval policy types=%v namespaces=%v", m.types, m.namespaces)
+	return nil
+}
+
+func (m *Manual) Capabilities() *policy.Capabilities {
+	return m.capabilities
+}
+
+func (m *Manual) Evaluate(ctx context.Context, req *fftypes.PolicyRequest) (fftypes.PolicyDecision, string, error) {
+	if !m.types[string(req.Type)] {
+		return fftypes.PolicyDecisionApprove, "", nil
+	}
+	if len(m.namespaces) > 0 && !m.namespaces[req.Namespace] {
+		return fftypes.PolicyDecisionApprove, "", nil
+	}
+	return fftypes.PolicyDecisionHold, fmt.Sprintf("manual approval is required for %s submissions", req.Type), nil
+}
@@ -0,0 +1,77 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manual
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("manual_unit_tests")
+
+func resetConf() {
+	config.Reset()
+	m := &Manual{}
+	m.InitPrefix(utConfPrefix)
+}
+
+func TestInitDefaultsHoldsAll(t *testing.T) {
+	resetConf()
+	var m policy.Plugin = &Manual{}
+	err := m.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "manual", m.Name())
+	assert.NotNil(t, m.Capabilities())
+
+	for _, st := range []fftypes.PolicySubmissionType{
+		fftypes.PolicySubmissionTypeBatchPin,
+		fftypes.PolicySubmissionTypeTokenPool,
+		fftypes.PolicySubmissionTypeTokenTransfer,
+		fftypes.PolicySubmissionTypeContractInvoke,
+	} {
+		decision, reason, err := m.Evaluate(context.Background(), &fftypes.PolicyRequest{Type: st, Namespace: "ns1"})
+		assert.NoError(t, err)
+		assert.Equal(t, fftypes.PolicyDecisionHold, decision)
+		assert.Contains(t, reason, string(st))
+	}
+}
+
+func TestEvaluateFilteredTypesAndNamespaces(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(ManualConfigTypes, []string{"Token_Transfer"})
+	utConfPrefix.Set(ManualConfigNamespaces, []string{"ns1"})
+	m := &Manual{}
+	err := m.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	decision, _, err := m.Evaluate(context.Background(), &fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeTokenTransfer, Namespace: "ns1"})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyDecisionHold, decision)
+
+	decision, _, err = m.Evaluate(context.Background(), &fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeTokenTransfer, Namespace: "ns2"})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyDecisionApprove, decision)
+
+	decision, _, err = m.Evaluate(context.Background(), &fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeBatchPin, Namespace: "ns1"})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyDecisionApprove, decision)
+}
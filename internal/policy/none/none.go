@@ -0,0 +1,50 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package none
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/policy"
+)
+
+// None is the default policy plugin, which approves every submission
+type None struct {
+	capabilities *policy.Capabilities
+}
+
+func (n *None) Name() string {
+	return "none"
+}
+
+func (n *None) InitPrefix(prefix config.Prefix) {
+}
+
+func (n *None) Init(ctx context.Context, prefix config.Prefix) (err error) {
+	n.capabilities = &policy.Capabilities{}
+	return nil
+}
+
+func (n *None) Capabilities() *policy.Capabilities {
+	return n.capabilities
+}
+
+func (n *None) Evaluate(ctx context.Context, req *fftypes.PolicyRequest) (fftypes.PolicyDecision, string, error) {
+	return fftypes.PolicyDecisionApprove, "", nil
+}
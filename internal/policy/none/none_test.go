@@ -0,0 +1,43 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package none
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("none_unit_tests")
+
+func TestInitAndEvaluate(t *testing.T) {
+	var n policy.Plugin = &None{}
+	n.InitPrefix(utConfPrefix)
+	err := n.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", n.Name())
+	assert.NotNil(t, n.Capabilities())
+
+	decision, reason, err := n.Evaluate(context.Background(), &fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeBatchPin})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyDecisionApprove, decision)
+	assert.Empty(t, reason)
+}
@@ -0,0 +1,142 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (gm *groupManager) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	return gm.database.GetGroupKey(ctx, group, epoch)
+}
+
+// GetLatestGroupKey returns the highest confirmed epoch of the key for a group, or nil if the key has never been set
+func (gm *groupManager) GetLatestGroupKey(ctx context.Context, group *fftypes.Bytes32) (*fftypes.GroupKey, error) {
+	fb := database.GroupKeyQueryFactory.NewFilter(ctx)
+	filter := fb.And(fb.Eq("group", group)).Sort("epoch").Descending().Limit(1)
+	groupKeys, _, err := gm.database.GetGroupKeys(ctx, filter)
+	if err != nil || len(groupKeys) == 0 {
+		return nil, err
+	}
+	return groupKeys[0], nil
+}
+
+// RotateGroupKey generates the next epoch of the key for a group, and sends it to all members of the group in a
+// group update message. The key only comes into use once the message is confirmed, on every node including this one,
+// so that members that rotate concurrently agree on which key wins the epoch.
+// The returned object does not include the key itself.
+func (pm *privateMessaging) RotateGroupKey(ctx context.Context, ns, hash string) (*fftypes.GroupKey, error) {
+	groupHash, err := fftypes.ParseBytes32(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	group, err := pm.database.GetGroupByHash(ctx, groupHash)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || group.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupNotFound, groupHash)
+	}
+
+	latest, err := pm.GetLatestGroupKey(ctx, groupHash)
+	if err != nil {
+		return nil, err
+	}
+	groupKey := &fftypes.GroupKey{
+		ID:        fftypes.NewUUID(),
+		Namespace: ns,
+		Group:     groupHash,
+		Epoch:     1,
+		Key:       fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+	}
+	if latest != nil {
+		groupKey.Epoch = latest.Epoch + 1
+	}
+
+	msg, err := pm.sendGroupUpdate(ctx, group, groupKey, fftypes.SystemTagDefineGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Rotating key for group %s to epoch %d in message %s", groupHash, groupKey.Epoch, msg.Header.ID)
+
+	groupKey.Key = nil
+	groupKey.Message = msg.Header.ID
+	return groupKey, nil
+}
+
+// sendGroupUpdate sends a definition to all members of an existing group, signed by the local org
+func (pm *privateMessaging) sendGroupUpdate(ctx context.Context, group *fftypes.Group, def fftypes.Definition, tag fftypes.SystemTag) (msg *fftypes.Message, err error) {
+	signer := &fftypes.Identity{}
+	if err = pm.identity.ResolveInputIdentity(ctx, signer); err != nil {
+		return nil, err
+	}
+
+	// Serialize it into a data object, as a piece of data we can write to a message
+	data := &fftypes.Data{
+		Validator: fftypes.ValidatorTypeSystemDefinition,
+		ID:        fftypes.NewUUID(),
+		Namespace: group.Namespace,
+		Created:   fftypes.Now(),
+	}
+	data.Value, err = json.Marshal(&def)
+	if err == nil {
+		err = data.Seal(ctx)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+	}
+
+	msg = &fftypes.Message{
+		State: fftypes.MessageStateReady,
+		Header: fftypes.MessageHeader{
+			Group:     group.Hash,
+			Namespace: group.Namespace,
+			Type:      fftypes.MessageTypeGroupUpdate,
+			Identity:  *signer,
+			Tag:       string(tag),
+			Topics:    fftypes.FFNameArray{def.Topic()},
+			TxType:    fftypes.TransactionTypeBatchPin,
+		},
+		Data: fftypes.DataRefs{
+			{ID: data.ID, Hash: data.Hash},
+		},
+	}
+	err = msg.Seal(ctx)
+	if err == nil {
+		// Store the data and message - this asynchronously triggers the send to the group
+		err = pm.database.RunAsGroup(ctx, func(ctx context.Context) error {
+			if err := pm.database.UpsertData(ctx, data, database.UpsertOptimizationNew); err != nil {
+				return err
+			}
+			if err := pm.database.UpsertMessage(ctx, msg, database.UpsertOptimizationNew); err != nil {
+				return err
+			}
+			return pm.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, "group update submitted"))
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,227 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type badDefinition struct {
+	Unserializable chan bool
+}
+
+func (bd *badDefinition) Topic() string                           { return "" }
+func (bd *badDefinition) SetBroadcastMessage(msgID *fftypes.UUID) {}
+
+func TestRotateGroupKeyFirstEpoch(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns1"},
+		Hash:          fftypes.NewRandB32(),
+	}
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, group.Hash).Return(group, nil)
+	mdi.On("GetGroupKeys", pm.ctx, mock.Anything).Return([]*fftypes.GroupKey{}, nil, nil)
+	mdi.On("UpsertData", pm.ctx, mock.MatchedBy(func(d *fftypes.Data) bool {
+		return d.Validator == fftypes.ValidatorTypeSystemDefinition
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Type == fftypes.MessageTypeGroupUpdate &&
+			msg.Header.Tag == string(fftypes.SystemTagDefineGroupKey) &&
+			msg.Header.Group.Equals(group.Hash) &&
+			msg.Header.Topics[0] == group.Hash.String() &&
+			msg.Header.Author == "did:firefly:org/org1"
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.Anything).Return(nil)
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(*fftypes.Identity).Author = "did:firefly:org/org1"
+	}).Return(nil)
+
+	groupKey, err := pm.RotateGroupKey(pm.ctx, "ns1", group.Hash.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), groupKey.Epoch)
+	assert.Nil(t, groupKey.Key)
+	assert.NotNil(t, groupKey.Message)
+
+	mdi.AssertExpectations(t)
+	mim.AssertExpectations(t)
+}
+
+func TestRotateGroupKeyNextEpoch(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns1"},
+		Hash:          fftypes.NewRandB32(),
+	}
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, group.Hash).Return(group, nil)
+	mdi.On("GetGroupKeys", pm.ctx, mock.Anything).Return([]*fftypes.GroupKey{{Epoch: 3}}, nil, nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.Anything).Return(nil)
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	groupKey, err := pm.RotateGroupKey(pm.ctx, "ns1", group.Hash.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), groupKey.Epoch)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestRotateGroupKeyBadHash(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.RotateGroupKey(pm.ctx, "ns1", "!bad")
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestRotateGroupKeyGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.RotateGroupKey(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRotateGroupKeyGroupWrongNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns2"},
+	}, nil)
+
+	_, err := pm.RotateGroupKey(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.Regexp(t, "FF10226", err)
+}
+
+func TestRotateGroupKeyGetLatestFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns1"},
+	}, nil)
+	mdi.On("GetGroupKeys", pm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := pm.RotateGroupKey(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRotateGroupKeySendFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns1"},
+	}, nil)
+	mdi.On("GetGroupKeys", pm.ctx, mock.Anything).Return([]*fftypes.GroupKey{}, nil, nil)
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.RotateGroupKey(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendGroupUpdateSerializeFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Group{}, &badDefinition{}, fftypes.SystemTagDefineGroupKey)
+	assert.Regexp(t, "FF10137", err)
+}
+
+func TestSendGroupUpdateUpsertDataFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Group{Hash: fftypes.NewRandB32()}, &fftypes.GroupKey{Group: fftypes.NewRandB32()}, fftypes.SystemTagDefineGroupKey)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendGroupUpdateUpsertMessageFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Group{Hash: fftypes.NewRandB32()}, &fftypes.GroupKey{Group: fftypes.NewRandB32()}, fftypes.SystemTagDefineGroupKey)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetGroupKey(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group := fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupKey", pm.ctx, group, int64(1)).Return(&fftypes.GroupKey{Epoch: 1}, nil)
+
+	groupKey, err := pm.GetGroupKey(pm.ctx, group, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), groupKey.Epoch)
+}
+
+func TestGetLatestGroupKeyNone(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupKeys", pm.ctx, mock.Anything).Return([]*fftypes.GroupKey{}, nil, nil)
+
+	groupKey, err := pm.GetLatestGroupKey(pm.ctx, fftypes.NewRandB32())
+	assert.NoError(t, err)
+	assert.Nil(t, groupKey)
+}
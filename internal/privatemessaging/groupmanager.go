@@ -34,6 +34,8 @@ type GroupManager interface {
 	GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error)
 	ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error)
 	EnsureLocalGroup(ctx context.Context, group *fftypes.Group) (ok bool, err error)
+	GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error)
+	GetLatestGroupKey(ctx context.Context, group *fftypes.Bytes32) (*fftypes.GroupKey, error)
 }
 
 type groupManager struct {
@@ -129,6 +131,9 @@ func (gm *groupManager) groupInit(ctx context.Context, signer *fftypes.Identity,
 		// Store the message - this asynchronously triggers the next step in process
 		err = gm.database.UpsertMessage(ctx, msg, database.UpsertOptimizationNew)
 	}
+	if err == nil {
+		err = gm.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, "group init submitted"))
+	}
 	if err == nil {
 		log.L(ctx).Infof("Created new group %s", group.Hash)
 	}
@@ -0,0 +1,149 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// holdReason returns a non-empty reason if the outbound message must be held until an operator approves its release
+func (pm *privateMessaging) holdReason(msg *fftypes.Message) string {
+	if msg.Header.Type != fftypes.MessageTypePrivate {
+		return ""
+	}
+	if msg.Header.Group != nil && pm.holdGroups[msg.Header.Group.String()] {
+		return fmt.Sprintf("group '%s' requires approval", msg.Header.Group)
+	}
+	if msg.Header.Tag != "" && pm.holdTags[msg.Header.Tag] {
+		return fmt.Sprintf("tag '%s' requires approval", msg.Header.Tag)
+	}
+	return ""
+}
+
+func (pm *privateMessaging) holdMessage(ctx context.Context, msg *fftypes.Message, reason string) error {
+	msg.State = fftypes.MessageStateHeld
+	hold := &fftypes.MessageHold{
+		ID:        msg.Header.ID,
+		Namespace: msg.Header.Namespace,
+		Group:     msg.Header.Group,
+		Tag:       msg.Header.Tag,
+		Message:   msg,
+		Reason:    reason,
+		Status:    fftypes.PolicyApprovalStatusPending,
+		Created:   fftypes.Now(),
+	}
+	return pm.database.RunAsRetryableGroup(ctx, func(ctx context.Context) error {
+		if err := pm.database.InsertMessageHold(ctx, hold); err != nil {
+			return err
+		}
+		if err := pm.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(msg, reason)); err != nil {
+			return err
+		}
+		log.L(ctx).Infof("Message '%s' held pending approval: %s", msg.Header.ID, reason)
+		event := fftypes.NewEvent(fftypes.EventTypeMessageHeld, msg.Header.Namespace, msg.Header.ID)
+		return pm.database.InsertEvent(ctx, event)
+	})
+}
+
+func (pm *privateMessaging) GetMessageHolds(ctx context.Context, filter database.AndFilter) ([]*fftypes.MessageHold, *database.FilterResult, error) {
+	return pm.database.GetMessageHolds(ctx, filter)
+}
+
+func (pm *privateMessaging) GetMessageHoldByID(ctx context.Context, id string) (*fftypes.MessageHold, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return pm.database.GetMessageHoldByID(ctx, u)
+}
+
+func (pm *privateMessaging) DecideMessageHold(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.MessageHold, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	status := decision.Status.Lower()
+	if status != fftypes.PolicyApprovalStatusApproved && status != fftypes.PolicyApprovalStatusRejected {
+		return nil, i18n.NewError(ctx, i18n.MsgPolicyApprovalBadDecision, fftypes.PolicyApprovalStatusApproved, fftypes.PolicyApprovalStatusRejected)
+	}
+	if decision.DecidedBy == "" {
+		return nil, i18n.NewError(ctx, i18n.MsgMissingRequiredField, "decidedBy")
+	}
+
+	var hold *fftypes.MessageHold
+	err = pm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		hold, err = pm.database.GetMessageHoldByID(ctx, u)
+		if err != nil {
+			return err
+		}
+		if hold == nil {
+			return i18n.NewError(ctx, i18n.MsgMessageHoldNotFound, u)
+		}
+		if hold.Status != fftypes.PolicyApprovalStatusPending {
+			return i18n.NewError(ctx, i18n.MsgMessageHoldNotPending, u, hold.Status)
+		}
+
+		hold.Status = status
+		hold.Decided = fftypes.Now()
+		hold.DecidedBy = decision.DecidedBy
+		hold.Comment = decision.Comment
+		update := database.MessageHoldQueryFactory.NewUpdate(ctx).
+			Set("status", hold.Status).
+			Set("decided", hold.Decided).
+			Set("decidedby", hold.DecidedBy).
+			Set("comment", hold.Comment)
+		if err = pm.database.UpdateMessageHold(ctx, u, update); err != nil {
+			return err
+		}
+		if hold.Status == fftypes.PolicyApprovalStatusApproved {
+			return pm.releaseMessage(ctx, hold.Message, fmt.Sprintf("hold approved by '%s'", hold.DecidedBy))
+		}
+		// A rejected message is never stored, so only its history records the rejection
+		transition := fftypes.NewMessageTransition(hold.Message, fmt.Sprintf("hold rejected by '%s'", hold.DecidedBy))
+		transition.State = fftypes.MessageStateRejected
+		return pm.database.InsertMessageTransition(ctx, transition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Message hold '%s' decided status=%s by '%s'", u, hold.Status, hold.DecidedBy)
+	return hold, nil
+}
+
+// releaseMessage stores an approved message, so that it flows through the normal send path
+func (pm *privateMessaging) releaseMessage(ctx context.Context, msg *fftypes.Message, cause string) error {
+	msg.State = fftypes.MessageStateReady
+	s := &messageSender{
+		mgr:       pm,
+		namespace: msg.Header.Namespace,
+		msg:       &fftypes.MessageInOut{Message: *msg},
+		resolved:  true,
+	}
+	method := methodSend
+	if msg.Header.TxType == fftypes.TransactionTypeNone {
+		method = methodSendImmediate
+	}
+	err := s.store(ctx, method, cause)
+	*msg = s.msg.Message
+	return err
+}
@@ -0,0 +1,353 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/datamocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestHold(txType fftypes.TransactionType) *fftypes.MessageHold {
+	msgID := fftypes.NewUUID()
+	return &fftypes.MessageHold{
+		ID:        msgID,
+		Namespace: "ns1",
+		Tag:       "restricted",
+		Message: &fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        msgID,
+				Namespace: "ns1",
+				Type:      fftypes.MessageTypePrivate,
+				TxType:    txType,
+				Group:     fftypes.NewRandB32(),
+				Tag:       "restricted",
+			},
+			State: fftypes.MessageStateHeld,
+		},
+		Status: fftypes.PolicyApprovalStatusPending,
+	}
+}
+
+func TestNewPrivateMessagingHoldConfig(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group := fftypes.NewRandB32()
+	config.Set(config.PrivateMessagingHoldGroups, []string{strings.ToUpper(group.String())})
+	config.Set(config.PrivateMessagingHoldTags, []string{"restricted"})
+	pmi, err := NewPrivateMessaging(pm.ctx, pm.database, pm.identity, pm.exchange, pm.blockchain, pm.batch, pm.data, pm.syncasync, pm.batchpin, pm.quota)
+	assert.NoError(t, err)
+	pm = pmi.(*privateMessaging)
+
+	assert.True(t, pm.holdGroups[group.String()])
+	assert.True(t, pm.holdTags["restricted"])
+}
+
+func TestHoldReason(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group := fftypes.NewRandB32()
+	pm.holdGroups[group.String()] = true
+	pm.holdTags["restricted"] = true
+
+	assert.Regexp(t, "group", pm.holdReason(&fftypes.Message{Header: fftypes.MessageHeader{
+		Type: fftypes.MessageTypePrivate, Group: group,
+	}}))
+	assert.Regexp(t, "tag 'restricted'", pm.holdReason(&fftypes.Message{Header: fftypes.MessageHeader{
+		Type: fftypes.MessageTypePrivate, Group: fftypes.NewRandB32(), Tag: "restricted",
+	}}))
+	assert.Empty(t, pm.holdReason(&fftypes.Message{Header: fftypes.MessageHeader{
+		Type: fftypes.MessageTypePrivate, Group: fftypes.NewRandB32(), Tag: "other",
+	}}))
+	assert.Empty(t, pm.holdReason(&fftypes.Message{Header: fftypes.MessageHeader{
+		Type: fftypes.MessageTypeGroupInit, Group: group,
+	}}))
+}
+
+func TestSendMessageHeld(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+	pm.holdTags["restricted"] = true
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInlineDataPrivate", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, nil)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("InsertMessageHold", pm.ctx, mock.MatchedBy(func(hold *fftypes.MessageHold) bool {
+		return hold.Status == fftypes.PolicyApprovalStatusPending && hold.Tag == "restricted" &&
+			hold.Message.State == fftypes.MessageStateHeld
+	})).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateHeld && transition.Cause == "tag 'restricted' requires approval"
+	})).Return(nil)
+	mdi.On("InsertEvent", pm.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageHeld
+	})).Return(nil)
+
+	// We do not wait for confirmation of a held message
+	msg, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Group: fftypes.NewRandB32(),
+				Tag:   "restricted",
+			},
+		},
+		InlineData: fftypes.InlineData{
+			{Value: fftypes.Byteable(`{"some": "data"}`)},
+		},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.MessageStateHeld, msg.State)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHoldMessageInsertFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("InsertMessageHold", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.holdMessage(pm.ctx, newTestHold(fftypes.TransactionTypeBatchPin).Message, "reason")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHoldMessageInsertTransitionFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("InsertMessageHold", pm.ctx, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.holdMessage(pm.ctx, newTestHold(fftypes.TransactionTypeBatchPin).Message, "reason")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetMessageHolds(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHolds", pm.ctx, mock.Anything).Return([]*fftypes.MessageHold{}, nil, nil)
+
+	f := database.MessageHoldQueryFactory.NewFilter(pm.ctx).And()
+	_, _, err := pm.GetMessageHolds(pm.ctx, f)
+	assert.NoError(t, err)
+}
+
+func TestGetMessageHoldByID(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeBatchPin)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+
+	res, err := pm.GetMessageHoldByID(pm.ctx, hold.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, hold, res)
+}
+
+func TestGetMessageHoldByIDBadID(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.GetMessageHoldByID(pm.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideMessageHoldApprove(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeBatchPin)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+	mdi.On("UpdateMessageHold", pm.ctx, hold.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.State == fftypes.MessageStateReady && msg.Confirmed == nil
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateReady && transition.Cause == "hold approved by 'admin1'"
+	})).Return(nil)
+
+	res, err := pm.DecideMessageHold(pm.ctx, hold.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    "Approved",
+		DecidedBy: "admin1",
+		Comment:   "release approved",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusApproved, res.Status)
+	assert.Equal(t, "admin1", res.DecidedBy)
+	assert.Equal(t, fftypes.MessageStateReady, res.Message.State)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideMessageHoldApproveUnpinned(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeNone)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+	mdi.On("UpdateMessageHold", pm.ctx, hold.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertMessage", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.State == fftypes.MessageStateReady && msg.Confirmed != nil
+	}), database.UpsertOptimizationNew).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.Anything).Return(nil)
+	mdi.On("GetGroupByHash", pm.ctx, hold.Message.Header.Group).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.DecideMessageHold(pm.ctx, hold.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "pop", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDecideMessageHoldReject(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeBatchPin)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+	mdi.On("UpdateMessageHold", pm.ctx, hold.ID, mock.Anything).Return(nil)
+	mdi.On("InsertMessageTransition", pm.ctx, mock.MatchedBy(func(transition *fftypes.MessageTransition) bool {
+		return transition.State == fftypes.MessageStateRejected && transition.Cause == "hold rejected by 'admin1'"
+	})).Return(nil)
+
+	res, err := pm.DecideMessageHold(pm.ctx, hold.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusRejected,
+		DecidedBy: "admin1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.PolicyApprovalStatusRejected, res.Status)
+	assert.Equal(t, fftypes.MessageStateHeld, res.Message.State)
+
+	mdi.AssertExpectations(t)
+	pm.exchange.(*dataexchangemocks.Plugin).AssertExpectations(t)
+}
+
+func TestDecideMessageHoldBadID(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.DecideMessageHold(pm.ctx, "bad", &fftypes.PolicyApprovalDecision{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestDecideMessageHoldBadStatus(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.DecideMessageHold(pm.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status: fftypes.PolicyApprovalStatusConsumed,
+	})
+	assert.Regexp(t, "FF10348", err)
+}
+
+func TestDecideMessageHoldMissingDecidedBy(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.DecideMessageHold(pm.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status: fftypes.PolicyApprovalStatusApproved,
+	})
+	assert.Regexp(t, "FF10140.*decidedBy", err)
+}
+
+func TestDecideMessageHoldGetFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.DecideMessageHold(pm.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestDecideMessageHoldNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := pm.DecideMessageHold(pm.ctx, fftypes.NewUUID().String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10350", err)
+}
+
+func TestDecideMessageHoldNotPending(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeBatchPin)
+	hold.Status = fftypes.PolicyApprovalStatusRejected
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+
+	_, err := pm.DecideMessageHold(pm.ctx, hold.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.Regexp(t, "FF10351", err)
+}
+
+func TestDecideMessageHoldUpdateFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	hold := newTestHold(fftypes.TransactionTypeBatchPin)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageHoldByID", pm.ctx, hold.ID).Return(hold, nil)
+	mdi.On("UpdateMessageHold", pm.ctx, hold.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.DecideMessageHold(pm.ctx, hold.ID.String(), &fftypes.PolicyApprovalDecision{
+		Status:    fftypes.PolicyApprovalStatusApproved,
+		DecidedBy: "admin1",
+	})
+	assert.EqualError(t, err, "pop")
+}
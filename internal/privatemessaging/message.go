@@ -21,7 +21,9 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/quota"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
@@ -60,10 +62,11 @@ func (pm *privateMessaging) RequestReply(ctx context.Context, ns string, in *fft
 // sendMethod is the specific operation requested of the messageSender.
 // To minimize duplication and group database operations, there is a single internal flow with subtle differences for each method.
 type messageSender struct {
-	mgr       *privateMessaging
-	namespace string
-	msg       *fftypes.MessageInOut
-	resolved  bool
+	mgr         *privateMessaging
+	namespace   string
+	msg         *fftypes.MessageInOut
+	resolved    bool
+	reservation *quota.Reservation
 }
 
 type sendMethod int
@@ -109,11 +112,18 @@ func (s *messageSender) setDefaults() {
 	}
 }
 
-func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) error {
+func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) (err error) {
 	sent := false
+	defer func() {
+		// The usage reserved against quotas is only kept if the message was stored
+		if err != nil {
+			s.reservation.Release()
+		}
+		s.reservation = nil
+	}()
 
 	// We optimize the DB storage of all the parts of the message using transaction semantics (assuming those are supported by the DB plugin)
-	err := s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+	err = s.mgr.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
 		if !s.resolved {
 			if err := s.resolve(ctx); err != nil {
 				return err
@@ -121,6 +131,11 @@ func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) e
 			s.resolved = true
 		}
 
+		// A held message cannot be confirmed until it is released, so we do not wait for it
+		if method == methodSendAndWait && s.mgr.holdReason(&s.msg.Message) != "" {
+			method = methodSend
+		}
+
 		// If we aren't waiting for blockchain confirmation, insert the local message immediately within the same DB transaction.
 		if method != methodSendAndWait {
 			err = s.sendInternal(ctx, method)
@@ -137,8 +152,17 @@ func (s *messageSender) resolveAndSend(ctx context.Context, method sendMethod) e
 }
 
 func (s *messageSender) resolve(ctx context.Context) error {
-	// Resolve the sending identity
-	if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
+	// Reject a retried submission, before any of its data is stored
+	if err := txcommon.CheckMessageIdempotencyKey(ctx, s.mgr.database, s.namespace, s.msg.IdempotencyKey); err != nil {
+		return err
+	}
+
+	// Resolve the sending identity, which can be on behalf of another org under a delegation
+	if s.msg.Header.Delegation != nil {
+		if err := s.mgr.identity.ResolveDelegatedIdentity(ctx, s.namespace, &s.msg.Header.Identity, s.msg.Header.Delegation); err != nil {
+			return i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+		}
+	} else if err := s.mgr.identity.ResolveInputIdentity(ctx, &s.msg.Header.Identity); err != nil {
 		return i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
 	}
 
@@ -172,6 +196,21 @@ func (s *messageSender) sendInternal(ctx context.Context, method sendMethod) err
 		return nil
 	}
 
+	// Enforce any quotas configured for the namespace and topics - we are within the database group that stores the message
+	var err error
+	if s.reservation, err = s.mgr.quota.CheckMessage(ctx, s.msg); err != nil {
+		return err
+	}
+
+	// Hold the message, rather than storing it, if an operator must approve its release
+	if reason := s.mgr.holdReason(&s.msg.Message); reason != "" {
+		return s.mgr.holdMessage(ctx, &s.msg.Message, reason)
+	}
+
+	return s.store(ctx, method, "private message submitted")
+}
+
+func (s *messageSender) store(ctx context.Context, method sendMethod, cause string) error {
 	if method == methodSendImmediate {
 		s.msg.Confirmed = fftypes.Now()
 		// msg.Header.Key = "" // there is no on-chain signing assurance with this message
@@ -181,6 +220,9 @@ func (s *messageSender) sendInternal(ctx context.Context, method sendMethod) err
 	if err := s.mgr.database.UpsertMessage(ctx, &s.msg.Message, database.UpsertOptimizationNew); err != nil {
 		return err
 	}
+	if err := s.mgr.database.InsertMessageTransition(ctx, fftypes.NewMessageTransition(&s.msg.Message, cause)); err != nil {
+		return err
+	}
 
 	if method == methodSendImmediate {
 		if err := s.sendUnpinned(ctx); err != nil {
@@ -0,0 +1,77 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func (pm *privateMessaging) RetryOperation(ctx context.Context, op *fftypes.Operation) (err error) {
+	// The data exchange reports the result against the operation ID, so it is reused for the retry
+	switch op.Type {
+	case fftypes.OpTypeDataExchangeBatchSend:
+		return pm.retryBatchSend(ctx, op)
+	case fftypes.OpTypeDataExchangeBlobSend:
+		return pm.retryBlobSend(ctx, op)
+	default:
+		return i18n.NewError(ctx, i18n.MsgOperationRetryNotSupported, op.Type)
+	}
+}
+
+func (pm *privateMessaging) retryBatchSend(ctx context.Context, op *fftypes.Operation) error {
+	peer, batchID, err := txcommon.RetrieveDataExchangeBatchSendInputs(ctx, op)
+	if err != nil {
+		return err
+	}
+	batch, err := pm.database.GetBatchByID(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	if batch == nil {
+		return i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	// Rebuild the payload from the sealed batch
+	payload, err := json.Marshal(&fftypes.TransportWrapper{
+		Type:  fftypes.TransportPayloadTypeBatch,
+		Batch: batch,
+	})
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+	}
+	return pm.exchange.SendMessage(ctx, op.ID, peer, payload)
+}
+
+func (pm *privateMessaging) retryBlobSend(ctx context.Context, op *fftypes.Operation) error {
+	peer, hash, err := txcommon.RetrieveDataExchangeBlobSendInputs(ctx, op)
+	if err != nil {
+		return err
+	}
+	blob, err := pm.database.GetBlobMatchingHash(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if blob == nil {
+		return i18n.NewError(ctx, i18n.MsgBlobNotFound, hash)
+	}
+	return pm.exchange.TransferBLOB(ctx, op.ID, peer, blob.PayloadRef)
+}
@@ -0,0 +1,193 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetryBatchSend(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	batch := &fftypes.Batch{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+	}
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeDataExchangeBatchSend,
+	}
+	txcommon.AddDataExchangeBatchSendInputs(op, "peer1", batch.ID)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdi.On("GetBatchByID", pm.ctx, batch.ID).Return(batch, nil)
+	mdx.On("SendMessage", pm.ctx, op.ID, "peer1", mock.MatchedBy(func(payload []byte) bool {
+		return assert.Contains(t, string(payload), batch.ID.String())
+	})).Return(nil)
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestRetryBatchSendBadInputs(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	err := pm.RetryOperation(pm.ctx, &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend})
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestRetryBatchSendGetBatchFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend}
+	txcommon.AddDataExchangeBatchSendInputs(op, "peer1", fftypes.NewUUID())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryBatchSendBatchNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend}
+	txcommon.AddDataExchangeBatchSendInputs(op, "peer1", fftypes.NewUUID())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", pm.ctx, mock.Anything).Return(nil, nil)
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryBatchSendBadData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend}
+	txcommon.AddDataExchangeBatchSendInputs(op, "peer1", fftypes.NewUUID())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetBatchByID", pm.ctx, mock.Anything).Return(&fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{Value: fftypes.Byteable(`{!json}`)},
+			},
+		},
+	}, nil)
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.Regexp(t, "FF10137", err)
+}
+
+func TestRetryBatchSendFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBatchSend}
+	txcommon.AddDataExchangeBatchSendInputs(op, "peer1", fftypes.NewUUID())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdi.On("GetBatchByID", pm.ctx, mock.Anything).Return(&fftypes.Batch{}, nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryBlobSend(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	blob := &fftypes.Blob{
+		Hash:       fftypes.NewRandB32(),
+		PayloadRef: "blob/1",
+	}
+	op := &fftypes.Operation{
+		ID:   fftypes.NewUUID(),
+		Type: fftypes.OpTypeDataExchangeBlobSend,
+	}
+	txcommon.AddDataExchangeBlobSendInputs(op, "peer1", blob.Hash)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", pm.ctx, blob.Hash).Return(blob, nil)
+	mdx.On("TransferBLOB", pm.ctx, op.ID, "peer1", "blob/1").Return(nil)
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestRetryBlobSendBadInputs(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	err := pm.RetryOperation(pm.ctx, &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBlobSend})
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestRetryBlobSendGetBlobFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBlobSend}
+	txcommon.AddDataExchangeBlobSendInputs(op, "peer1", fftypes.NewRandB32())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryBlobSendBlobNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeBlobSend}
+	txcommon.AddDataExchangeBlobSendInputs(op, "peer1", fftypes.NewRandB32())
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobMatchingHash", pm.ctx, mock.Anything).Return(nil, nil)
+
+	err := pm.RetryOperation(pm.ctx, op)
+	assert.Regexp(t, "FF10239", err)
+}
+
+func TestRetryOperationUnsupported(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	err := pm.RetryOperation(pm.ctx, &fftypes.Operation{Type: fftypes.OpTypeBlockchainBatchPin})
+	assert.Regexp(t, "FF10404", err)
+}
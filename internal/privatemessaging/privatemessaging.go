@@ -19,6 +19,7 @@ package privatemessaging
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/hyperledger/firefly/internal/batch"
 	"github.com/hyperledger/firefly/internal/batchpin"
@@ -27,9 +28,11 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/identity"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/quota"
 	"github.com/hyperledger/firefly/internal/retry"
 	"github.com/hyperledger/firefly/internal/syncasync"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/blockchain"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/dataexchange"
@@ -44,6 +47,14 @@ type Manager interface {
 	NewMessage(ns string, msg *fftypes.MessageInOut) sysmessaging.MessageSender
 	SendMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (out *fftypes.Message, err error)
 	RequestReply(ctx context.Context, ns string, request *fftypes.MessageInOut) (reply *fftypes.MessageInOut, err error)
+	GetMessageHolds(ctx context.Context, filter database.AndFilter) ([]*fftypes.MessageHold, *database.FilterResult, error)
+	GetMessageHoldByID(ctx context.Context, id string) (*fftypes.MessageHold, error)
+	DecideMessageHold(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.MessageHold, error)
+	SendReceipt(ctx context.Context, msg *fftypes.Message) error
+	RotateGroupKey(ctx context.Context, ns, hash string) (*fftypes.GroupKey, error)
+
+	// RetryOperation resends the payload of a failed dataexchange_batch_send or dataexchange_blob_send operation to the peer
+	RetryOperation(ctx context.Context, op *fftypes.Operation) error
 }
 
 type privateMessaging struct {
@@ -58,14 +69,17 @@ type privateMessaging struct {
 	data                 data.Manager
 	syncasync            syncasync.Bridge
 	batchpin             batchpin.Submitter
+	quota                quota.Manager
 	retry                retry.Retry
 	localNodeName        string
 	localNodeID          *fftypes.UUID // lookup and cached on first use, as might not be registered at startup
 	opCorrelationRetries int
+	holdGroups           map[string]bool
+	holdTags             map[string]bool
 }
 
-func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Manager, dx dataexchange.Plugin, bi blockchain.Plugin, ba batch.Manager, dm data.Manager, sa syncasync.Bridge, bp batchpin.Submitter) (Manager, error) {
-	if di == nil || im == nil || dx == nil || bi == nil || ba == nil || dm == nil {
+func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Manager, dx dataexchange.Plugin, bi blockchain.Plugin, ba batch.Manager, dm data.Manager, sa syncasync.Bridge, bp batchpin.Submitter, qm quota.Manager) (Manager, error) {
+	if di == nil || im == nil || dx == nil || bi == nil || ba == nil || dm == nil || qm == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
 	}
 
@@ -79,6 +93,7 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Ma
 		data:          dm,
 		syncasync:     sa,
 		batchpin:      bp,
+		quota:         qm,
 		localNodeName: config.GetString(config.NodeName),
 		groupManager: groupManager{
 			database:      di,
@@ -91,6 +106,14 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Ma
 			Factor:       config.GetFloat64(config.PrivateMessagingRetryFactor),
 		},
 		opCorrelationRetries: config.GetInt(config.PrivateMessagingOpCorrelationRetries),
+		holdGroups:           make(map[string]bool),
+		holdTags:             make(map[string]bool),
+	}
+	for _, group := range config.GetStringSlice(config.PrivateMessagingHoldGroups) {
+		pm.holdGroups[strings.ToLower(group)] = true
+	}
+	for _, tag := range config.GetStringSlice(config.PrivateMessagingHoldTags) {
+		pm.holdTags[tag] = true
 	}
 	pm.groupManager.groupCache = ccache.New(
 		// We use a LRU cache with a size-aware max
@@ -106,6 +129,7 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, im identity.Ma
 
 	ba.RegisterDispatcher([]fftypes.MessageType{
 		fftypes.MessageTypeGroupInit,
+		fftypes.MessageTypeGroupUpdate,
 		fftypes.MessageTypePrivate,
 		fftypes.MessageTypeTransferPrivate,
 	}, pm.dispatchBatch, bo)
@@ -119,6 +143,11 @@ func (pm *privateMessaging) Start() error {
 
 func (pm *privateMessaging) dispatchBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
 
+	// Fail fast if the pin cannot be submitted, before sending the payload
+	if err := pm.batchpin.PreflightCheck(ctx, batch); err != nil {
+		return err
+	}
+
 	// Serialize the full payload, which has already been sealed for us by the BatchManager
 	payload, err := json.Marshal(&fftypes.TransportWrapper{
 		Type:  fftypes.TransportPayloadTypeBatch,
@@ -140,10 +169,18 @@ func (pm *privateMessaging) dispatchBatch(ctx context.Context, batch *fftypes.Ba
 }
 
 func (pm *privateMessaging) transferBlobs(ctx context.Context, data []*fftypes.Data, txid *fftypes.UUID, node *fftypes.Node) error {
-	// Send all the blobs associated with this batch
+	// Send all the blobs associated with this batch - each unique blob is only sent once,
+	// even if it is attached to multiple pieces of data
+	sent := make(map[fftypes.Bytes32]bool)
 	for _, d := range data {
 		// We only need to send a blob if there is one, and it's not been uploaded to the public storage
 		if d.Blob != nil && d.Blob.Hash != nil && d.Blob.Public == "" {
+			if sent[*d.Blob.Hash] {
+				log.L(ctx).Debugf("Blob '%s' for data '%s' already sent to '%s'", d.Blob.Hash, d.ID, node.DX.Peer)
+				continue
+			}
+			sent[*d.Blob.Hash] = true
+
 			blob, err := pm.database.GetBlobMatchingHash(ctx, d.Blob.Hash)
 			if err != nil {
 				return err
@@ -152,22 +189,21 @@ func (pm *privateMessaging) transferBlobs(ctx context.Context, data []*fftypes.D
 				return i18n.NewError(ctx, i18n.MsgBlobNotFound, d.Blob)
 			}
 
-			trackingID, err := pm.exchange.TransferBLOB(ctx, node.DX.Peer, blob.PayloadRef)
-			if err != nil {
+			// The operation is recorded before the transfer, so the receipt can be matched by its ID
+			op := fftypes.NewTXOperation(
+				pm.exchange,
+				d.Namespace,
+				txid,
+				"",
+				fftypes.OpTypeDataExchangeBlobSend,
+				fftypes.OpStatusPending)
+			txcommon.AddDataExchangeBlobSendInputs(op, node.DX.Peer, blob.Hash)
+			if err = pm.database.InsertOperation(ctx, op); err != nil {
 				return err
 			}
 
-			if txid != nil {
-				op := fftypes.NewTXOperation(
-					pm.exchange,
-					d.Namespace,
-					txid,
-					trackingID,
-					fftypes.OpTypeDataExchangeBlobSend,
-					fftypes.OpStatusPending)
-				if err = pm.database.InsertOperation(ctx, op); err != nil {
-					return err
-				}
+			if err = pm.exchange.TransferBLOB(ctx, op.ID, node.DX.Peer, blob.PayloadRef); err != nil {
+				return err
 			}
 		}
 	}
@@ -197,23 +233,29 @@ func (pm *privateMessaging) sendData(ctx context.Context, mType string, mID *fft
 			return err
 		}
 
-		// Send the payload itself
-		trackingID, err := pm.exchange.SendMessage(ctx, node.DX.Peer, payload)
-		if err != nil {
+		// Send the payload itself - sends outside of a transaction are tracked by a standalone operation
+		opType := fftypes.OpTypeDataExchangeBatchSend
+		if txid == nil {
+			opType = fftypes.OpTypeDataExchangeMessageSend
+		}
+		op := fftypes.NewTXOperation(
+			pm.exchange,
+			ns,
+			txid,
+			"",
+			opType,
+			fftypes.OpStatusPending)
+		if txid != nil {
+			txcommon.AddDataExchangeBatchSendInputs(op, node.DX.Peer, mID)
+		} else {
+			txcommon.AddDataExchangeMessageSendInputs(op, node.DX.Peer, mID)
+		}
+		if err = pm.database.InsertOperation(ctx, op); err != nil {
 			return err
 		}
 
-		if txid != nil {
-			op := fftypes.NewTXOperation(
-				pm.exchange,
-				ns,
-				txid,
-				trackingID,
-				fftypes.OpTypeDataExchangeBatchSend,
-				fftypes.OpStatusPending)
-			if err = pm.database.InsertOperation(ctx, op); err != nil {
-				return err
-			}
+		if err = pm.exchange.SendMessage(ctx, op.ID, node.DX.Peer, payload); err != nil {
+			return err
 		}
 
 	}
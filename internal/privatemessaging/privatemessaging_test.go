@@ -29,6 +29,7 @@ import (
 	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger/firefly/mocks/datamocks"
 	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/mocks/quotamocks"
 	"github.com/hyperledger/firefly/mocks/syncasyncmocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -49,9 +50,11 @@ func newTestPrivateMessaging(t *testing.T) (*privateMessaging, func()) {
 	mdm := &datamocks.Manager{}
 	msa := &syncasyncmocks.Bridge{}
 	mbp := &batchpinmocks.Submitter{}
+	mqm := &quotamocks.Manager{}
 
 	mba.On("RegisterDispatcher", []fftypes.MessageType{
 		fftypes.MessageTypeGroupInit,
+		fftypes.MessageTypeGroupUpdate,
 		fftypes.MessageTypePrivate,
 		fftypes.MessageTypeTransferPrivate,
 	}, mock.Anything, mock.Anything).Return()
@@ -62,14 +65,22 @@ func newTestPrivateMessaging(t *testing.T) (*privateMessaging, func()) {
 			a[1].(func(context.Context) error)(a[0].(context.Context)),
 		}
 	}
+	rrg := mdi.On("RunAsRetryableGroup", mock.Anything, mock.Anything).Maybe()
+	rrg.RunFn = func(a mock.Arguments) {
+		rrg.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	pm, err := NewPrivateMessaging(ctx, mdi, mim, mdx, mbi, mba, mdm, msa, mbp)
+	pm, err := NewPrivateMessaging(ctx, mdi, mim, mdx, mbi, mba, mdm, msa, mbp, mqm)
 	assert.NoError(t, err)
 
 	// Default mocks to save boilerplate in the tests
 	mdx.On("Name").Return("utdx").Maybe()
 	mbi.On("Name").Return("utblk").Maybe()
+	mqm.On("CheckMessage", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	mbp.On("PreflightCheck", mock.Anything, mock.Anything).Return(nil).Maybe()
 
 	return pm.(*privateMessaging), cancel
 }
@@ -129,22 +140,26 @@ func TestDispatchBatchWithBlobs(t *testing.T) {
 		Hash:       blob1,
 		PayloadRef: "/blob/1",
 	}, nil)
-	mdx.On("TransferBLOB", pm.ctx, "node1", "/blob/1").Return("tracking1", nil)
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "node1", "/blob/1").Return(nil)
 	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
-		return op.BackendID == "tracking1" && op.Type == fftypes.OpTypeDataExchangeBlobSend
+		return op.Type == fftypes.OpTypeDataExchangeBlobSend &&
+			op.Input.GetString("peer") == "node1" && op.Input.GetString("hash") == blob1.String()
 	})).Return(nil, nil)
-	mdx.On("TransferBLOB", pm.ctx, "node2", "/blob/1").Return("tracking2", nil)
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "node2", "/blob/1").Return(nil)
 	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
-		return op.BackendID == "tracking2" && op.Type == fftypes.OpTypeDataExchangeBlobSend
+		return op.Type == fftypes.OpTypeDataExchangeBlobSend &&
+			op.Input.GetString("peer") == "node2" && op.Input.GetString("hash") == blob1.String()
 	})).Return(nil, nil)
 
-	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("tracking3", nil).Once()
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
-		return op.BackendID == "tracking3" && op.Type == fftypes.OpTypeDataExchangeBatchSend
+		return op.Type == fftypes.OpTypeDataExchangeBatchSend &&
+			op.Input.GetString("peer") == "node1" && op.Input.GetString("batch") == batchID.String()
 	})).Return(nil, nil)
-	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("tracking4", nil).Once()
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
-		return op.BackendID == "tracking4" && op.Type == fftypes.OpTypeDataExchangeBatchSend
+		return op.Type == fftypes.OpTypeDataExchangeBatchSend &&
+			op.Input.GetString("peer") == "node2" && op.Input.GetString("batch") == batchID.String()
 	})).Return(nil, nil)
 
 	mbp.On("SubmitPinnedBatch", pm.ctx, mock.Anything, mock.Anything).Return(nil)
@@ -173,7 +188,7 @@ func TestDispatchBatchWithBlobs(t *testing.T) {
 }
 
 func TestNewPrivateMessagingMissingDeps(t *testing.T) {
-	_, err := NewPrivateMessaging(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil)
+	_, err := NewPrivateMessaging(context.Background(), nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
 }
 
@@ -191,6 +206,19 @@ func TestDispatchBatchBadData(t *testing.T) {
 	assert.Regexp(t, "FF10137", err)
 }
 
+func TestDispatchBatchPreflightFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mbp := &batchpinmocks.Submitter{}
+	pm.batchpin = mbp
+	mbp.On("PreflightCheck", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.dispatchBatch(pm.ctx, &fftypes.Batch{}, []*fftypes.Bytes32{})
+	assert.Regexp(t, "pop", err)
+	mbp.AssertExpectations(t)
+}
+
 func TestDispatchErrorFindingGroup(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -252,8 +280,13 @@ func TestSendImmediateFail(t *testing.T) {
 	mim := pm.identity.(*identitymanagermocks.Manager)
 	mim.On("ResolveLocalOrgDID", pm.ctx).Return("localorg", nil)
 
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeDataExchangeMessageSend && op.Transaction == nil
+	})).Return(nil)
+
 	mdx := pm.exchange.(*dataexchangemocks.Plugin)
-	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("", fmt.Errorf("pop"))
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
 		Identity: fftypes.Identity{
@@ -277,9 +310,6 @@ func TestSendSubmitInsertOperationFail(t *testing.T) {
 	mim := pm.identity.(*identitymanagermocks.Manager)
 	mim.On("ResolveLocalOrgDID", pm.ctx).Return("localorg", nil)
 
-	mdx := pm.exchange.(*dataexchangemocks.Plugin)
-	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("tracking1", nil)
-
 	mdi := pm.database.(*databasemocks.Plugin)
 	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
@@ -370,8 +400,9 @@ func TestTransferBlobsFail(t *testing.T) {
 
 	mdi := pm.database.(*databasemocks.Plugin)
 	mdi.On("GetBlobMatchingHash", pm.ctx, mock.Anything).Return(&fftypes.Blob{PayloadRef: "blob/1"}, nil)
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(nil)
 	mdx := pm.exchange.(*dataexchangemocks.Plugin)
-	mdx.On("TransferBLOB", pm.ctx, "peer1", "blob/1").Return("", fmt.Errorf("pop"))
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "peer1", "blob/1").Return(fmt.Errorf("pop"))
 
 	err := pm.transferBlobs(pm.ctx, []*fftypes.Data{
 		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blob: &fftypes.BlobRef{Hash: fftypes.NewRandB32()}},
@@ -384,10 +415,8 @@ func TestTransferBlobsOpInsertFail(t *testing.T) {
 	defer cancel()
 
 	mdi := pm.database.(*databasemocks.Plugin)
-	mdx := pm.exchange.(*dataexchangemocks.Plugin)
 
 	mdi.On("GetBlobMatchingHash", pm.ctx, mock.Anything).Return(&fftypes.Blob{PayloadRef: "blob/1"}, nil)
-	mdx.On("TransferBLOB", pm.ctx, "peer1", "blob/1").Return("tracking1", nil)
 	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
 
 	err := pm.transferBlobs(pm.ctx, []*fftypes.Data{
@@ -396,6 +425,28 @@ func TestTransferBlobsOpInsertFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestTransferBlobsDuplicateOnce(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+
+	blobHash := fftypes.NewRandB32()
+	mdi.On("GetBlobMatchingHash", pm.ctx, blobHash).Return(&fftypes.Blob{PayloadRef: "blob/1"}, nil).Once()
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "peer1", "blob/1").Return(nil).Once()
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(nil).Once()
+
+	err := pm.transferBlobs(pm.ctx, []*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blob: &fftypes.BlobRef{Hash: blobHash}},
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blob: &fftypes.BlobRef{Hash: blobHash}},
+	}, fftypes.NewUUID(), &fftypes.Node{ID: fftypes.NewUUID(), DX: fftypes.DXInfo{Peer: "peer1"}})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
 func TestStart(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
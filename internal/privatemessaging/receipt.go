@@ -0,0 +1,67 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/txcommon"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// SendReceipt acknowledges a confirmed broadcast message, by sending a receipt signed by the local org
+// over data exchange, to a node of the org that authored the message
+func (pm *privateMessaging) SendReceipt(ctx context.Context, msg *fftypes.Message) error {
+	receipt := &fftypes.MessageReceipt{
+		ID:        fftypes.NewUUID(),
+		Namespace: msg.Header.Namespace,
+		Message:   msg.Header.ID,
+		Created:   fftypes.Now(),
+	}
+	if err := pm.identity.ResolveInputIdentity(ctx, &receipt.Identity); err != nil {
+		return err
+	}
+
+	org, err := pm.resolveOrg(ctx, msg.Header.Author)
+	if err != nil {
+		return err
+	}
+	node, err := pm.resolveNode(ctx, org, "")
+	if err != nil {
+		return err
+	}
+
+	payload, _ := json.Marshal(&fftypes.TransportWrapper{
+		Type:    fftypes.TransportPayloadTypeReceipt,
+		Receipt: receipt,
+	})
+	op := fftypes.NewTXOperation(
+		pm.exchange,
+		receipt.Namespace,
+		nil,
+		"",
+		fftypes.OpTypeDataExchangeMessageSend,
+		fftypes.OpStatusPending)
+	txcommon.AddDataExchangeMessageSendInputs(op, node.DX.Peer, receipt.ID)
+	if err = pm.database.InsertOperation(ctx, op); err != nil {
+		return err
+	}
+	log.L(ctx).Debugf("Sending receipt %s for message %s:%s to node=%s", receipt.ID, receipt.Namespace, receipt.Message, node.ID)
+	return pm.exchange.SendMessage(ctx, op.ID, node.DX.Peer, payload)
+}
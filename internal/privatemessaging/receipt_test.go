@@ -0,0 +1,137 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/mocks/identitymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestReceiptMessage() *fftypes.Message {
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Identity: fftypes.Identity{
+				Author: "org2",
+			},
+		},
+	}
+}
+
+func TestSendReceiptOk(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	msg := newTestReceiptMessage()
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		identity := args[1].(*fftypes.Identity)
+		identity.Author = "did:firefly:org/org1"
+		identity.Key = "0x12345"
+	}).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: fftypes.NewUUID(), DX: fftypes.DXInfo{Peer: "peer2"}},
+	}, nil, nil)
+	mdi.On("InsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.Type == fftypes.OpTypeDataExchangeMessageSend && op.Transaction == nil &&
+			op.Input.GetString("peer") == "peer2"
+	})).Return(nil)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, "peer2", mock.MatchedBy(func(payload []byte) bool {
+		var wrapper fftypes.TransportWrapper
+		err := json.Unmarshal(payload, &wrapper)
+		assert.NoError(t, err)
+		return wrapper.Type == fftypes.TransportPayloadTypeReceipt &&
+			*wrapper.Receipt.Message == *msg.Header.ID &&
+			wrapper.Receipt.Namespace == "ns1" &&
+			wrapper.Receipt.Author == "did:firefly:org/org1" &&
+			wrapper.Receipt.Key == "0x12345"
+	})).Return(nil)
+
+	err := pm.SendReceipt(pm.ctx, msg)
+	assert.NoError(t, err)
+
+	mim.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestSendReceiptResolveIdentityFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.SendReceipt(pm.ctx, newTestReceiptMessage())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendReceiptResolveOrgFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(nil, fmt.Errorf("pop"))
+
+	err := pm.SendReceipt(pm.ctx, newTestReceiptMessage())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendReceiptResolveNodeFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdi.On("GetNodes", pm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := pm.SendReceipt(pm.ctx, newTestReceiptMessage())
+	assert.Regexp(t, "FF10233", err)
+}
+
+func TestSendReceiptInsertOperationFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mim := pm.identity.(*identitymanagermocks.Manager)
+	mim.On("ResolveInputIdentity", pm.ctx, mock.Anything).Return(nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "org2").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: fftypes.NewUUID(), DX: fftypes.DXInfo{Peer: "peer2"}},
+	}, nil, nil)
+	mdi.On("InsertOperation", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pm.SendReceipt(pm.ctx, newTestReceiptMessage())
+	assert.EqualError(t, err, "pop")
+}
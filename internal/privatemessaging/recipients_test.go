@@ -84,6 +84,7 @@ func TestResolveMemberListNewGroupE2E(t *testing.T) {
 		dataID = data.ID
 	}
 	um := mdi.On("UpsertMessage", pm.ctx, mock.Anything, database.UpsertOptimizationNew).Return(nil).Once()
+	mdi.On("InsertMessageTransition", pm.ctx, mock.Anything).Return(nil).Once()
 	um.RunFn = func(a mock.Arguments) {
 		msg := a[1].(*fftypes.Message)
 		assert.Equal(t, fftypes.MessageTypeGroupInit, msg.Header.Type)
@@ -26,9 +26,26 @@ const (
 	IPFSConfAPISubconf = "api"
 	// IPFSConfGatewaySubconf is the http configuration to connect to the Gateway endpoint of IPFS
 	IPFSConfGatewaySubconf = "gateway"
+	// IPFSConfPinningSubconf is an array of remote pinning services (IPFS Pinning Service API), each with http configuration
+	IPFSConfPinningSubconf = "pinning"
+	// IPFSConfPinningName is an optional name for the pinning service, used in operation output. Defaults to the URL
+	IPFSConfPinningName = "name"
+	// IPFSConfPinningToken is the access token sent as a bearer token to authenticate to the pinning service
+	IPFSConfPinningToken = "token"
 )
 
 func (i *IPFS) InitPrefix(prefix config.Prefix) {
 	restclient.InitPrefix(prefix.SubPrefix(IPFSConfAPISubconf))
 	restclient.InitPrefix(prefix.SubPrefix(IPFSConfGatewaySubconf))
+	pinningConf(prefix)
+}
+
+// pinningConf returns the array of pinning service configuration, with the known keys and defaults
+// registered. The defaults are held on the returned array, so Init must use this too when iterating
+func pinningConf(prefix config.Prefix) config.PrefixArray {
+	pinPrefix := prefix.SubPrefix(IPFSConfPinningSubconf).Array()
+	pinPrefix.AddKnownKey(IPFSConfPinningName)
+	pinPrefix.AddKnownKey(IPFSConfPinningToken)
+	restclient.InitPrefix(pinPrefix)
+	return pinPrefix
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"io"
 
@@ -28,15 +29,32 @@ import (
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
 	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 )
 
 type IPFS struct {
-	ctx          context.Context
-	capabilities *publicstorage.Capabilities
-	callbacks    publicstorage.Callbacks
-	apiClient    *resty.Client
-	gwClient     *resty.Client
+	ctx             context.Context
+	capabilities    *publicstorage.Capabilities
+	callbacks       publicstorage.Callbacks
+	apiClient       *resty.Client
+	gwClient        *resty.Client
+	pinningServices []*pinningService
+}
+
+// pinningService is a remote service implementing the IPFS Pinning Service API, such as Pinata or web3.storage
+type pinningService struct {
+	name   string
+	client *resty.Client
+}
+
+type ipfsPinRequest struct {
+	CID string `json:"cid"`
+}
+
+type ipfsPinStatus struct {
+	RequestID string `json:"requestid"`
+	Status    string `json:"status"`
 }
 
 type ipfsUploadResponse struct {
@@ -49,22 +67,51 @@ func (i *IPFS) Name() string {
 	return "ipfs"
 }
 
-func (i *IPFS) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) error {
+func (i *IPFS) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) (err error) {
 
-	i.ctx = log.WithLogField(ctx, "publicstorage", "ipfs")
+	i.ctx = log.WithComponent(log.WithLogField(ctx, "publicstorage", "ipfs"), "publicstorage")
 	i.callbacks = callbacks
 
 	apiPrefix := prefix.SubPrefix(IPFSConfAPISubconf)
 	if apiPrefix.GetString(restclient.HTTPConfigURL) == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, apiPrefix.Resolve(restclient.HTTPConfigURL), "ipfs")
 	}
-	i.apiClient = restclient.New(i.ctx, apiPrefix)
+	i.apiClient, err = restclient.New(i.ctx, apiPrefix)
+	if err != nil {
+		return err
+	}
 	gwPrefix := prefix.SubPrefix(IPFSConfGatewaySubconf)
 	if gwPrefix.GetString(restclient.HTTPConfigURL) == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, gwPrefix.Resolve(restclient.HTTPConfigURL), "ipfs")
 	}
-	i.gwClient = restclient.New(i.ctx, gwPrefix)
-	i.capabilities = &publicstorage.Capabilities{}
+	i.gwClient, err = restclient.New(i.ctx, gwPrefix)
+	if err != nil {
+		return err
+	}
+	i.pinningServices = nil
+	pinPrefixArray := pinningConf(prefix)
+	pinPrefixArraySize := pinPrefixArray.ArraySize()
+	for idx := 0; idx < pinPrefixArraySize; idx++ {
+		pinPrefix := pinPrefixArray.ArrayEntry(idx)
+		url := pinPrefix.GetString(restclient.HTTPConfigURL)
+		if url == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, pinPrefix.Resolve(restclient.HTTPConfigURL), "ipfs")
+		}
+		ps := &pinningService{name: pinPrefix.GetString(IPFSConfPinningName)}
+		if ps.name == "" {
+			ps.name = url
+		}
+		if ps.client, err = restclient.New(i.ctx, pinPrefix); err != nil {
+			return err
+		}
+		if token := pinPrefix.GetString(IPFSConfPinningToken); token != "" {
+			ps.client.SetAuthToken(token)
+		}
+		i.pinningServices = append(i.pinningServices, ps)
+	}
+	i.capabilities = &publicstorage.Capabilities{
+		Pinning: len(i.pinningServices) > 0,
+	}
 	return nil
 }
 
@@ -101,3 +148,52 @@ func (i *IPFS) RetrieveData(ctx context.Context, payloadRef string) (data io.Rea
 	log.L(ctx).Infof("IPFS retrieved %s", payloadRef)
 	return res.RawBody(), nil
 }
+
+// PinData requests every configured pinning service pins the data, in the background.
+// The operation succeeds only if all services accept the request, with the output recording the
+// request ID and status from each service so failures can be retried or investigated individually.
+func (i *IPFS) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) {
+	go i.pinData(operationID, payloadRef)
+}
+
+func (i *IPFS) pinData(operationID *fftypes.UUID, payloadRef string) {
+	output := fftypes.JSONObject{}
+	var failures []string
+	for _, ps := range i.pinningServices {
+		status, err := ps.pin(i.ctx, payloadRef)
+		if err != nil {
+			log.L(i.ctx).Errorf("IPFS pinning service '%s' failed to pin %s: %s", ps.name, payloadRef, err)
+			output[ps.name] = fftypes.JSONObject{"error": err.Error()}
+			failures = append(failures, fmt.Sprintf("%s: %s", ps.name, err))
+			continue
+		}
+		log.L(i.ctx).Infof("IPFS pinning service '%s' accepted %s RequestID=%s Status=%s", ps.name, payloadRef, status.RequestID, status.Status)
+		output[ps.name] = fftypes.JSONObject{"requestid": status.RequestID, "status": status.Status}
+	}
+
+	txState := fftypes.OpStatusSucceeded
+	errorMessage := ""
+	if len(failures) > 0 {
+		txState = fftypes.OpStatusFailed
+		errorMessage = strings.Join(failures, "; ")
+	}
+	if err := i.callbacks.PublicStorageOpUpdate(i, operationID, txState, errorMessage, output); err != nil {
+		log.L(i.ctx).Errorf("Failed to update pin operation %s: %s", operationID, err)
+	}
+}
+
+func (ps *pinningService) pin(ctx context.Context, payloadRef string) (*ipfsPinStatus, error) {
+	var status ipfsPinStatus
+	res, err := ps.client.R().
+		SetContext(ctx).
+		SetBody(&ipfsPinRequest{CID: payloadRef}).
+		SetResult(&status).
+		Post("/pins")
+	if err != nil || !res.IsSuccess() {
+		return nil, restclient.WrapRestErr(ctx, res, err, i18n.MsgIPFSPinRESTErr)
+	}
+	if status.Status == "failed" {
+		return nil, i18n.NewError(ctx, i18n.MsgIPFSPinFailed, status.Status, payloadRef)
+	}
+	return &status, nil
+}
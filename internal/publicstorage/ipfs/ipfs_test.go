@@ -22,14 +22,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 	"github.com/jarcoal/httpmock"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var utConfPrefix = config.NewPluginConfig("ipfs_unit_tests")
@@ -58,6 +62,31 @@ func TestInitMissingGWURL(t *testing.T) {
 	assert.Regexp(t, "FF10138", err)
 }
 
+func TestInitBadAPITLS(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+
+	apiPrefix := utConfPrefix.SubPrefix(IPFSConfAPISubconf)
+	apiPrefix.Set(restclient.HTTPConfigURL, "https://localhost:12345")
+	apiPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	apiPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
+func TestInitBadGWTLS(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	gwPrefix := utConfPrefix.SubPrefix(IPFSConfGatewaySubconf)
+	gwPrefix.Set(restclient.HTTPConfigURL, "https://localhost:12345")
+	gwPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	gwPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
 func TestInit(t *testing.T) {
 	i := &IPFS{}
 	resetConf()
@@ -195,3 +224,137 @@ func TestIPFSDownloadError(t *testing.T) {
 	assert.Regexp(t, "FF10136", err)
 
 }
+
+func setPinningConf(t *testing.T, yaml string) {
+	viper.SetConfigType("yaml")
+	err := viper.ReadConfig(strings.NewReader(yaml))
+	assert.NoError(t, err)
+}
+
+func TestInitPinningMissingURL(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	setPinningConf(t, `
+ipfs_unit_tests:
+  pinning:
+  - name: pinata
+`)
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*pinning.0.url", err)
+}
+
+func TestInitPinningBadTLS(t *testing.T) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	setPinningConf(t, `
+ipfs_unit_tests:
+  pinning:
+  - url: https://localhost:12345
+    tls:
+      enabled: true
+      cafile: /not/a/file
+`)
+
+	err := i.Init(context.Background(), utConfPrefix, &publicstoragemocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
+func newTestPinningIPFS(t *testing.T, mcb *publicstoragemocks.Callbacks) (*IPFS, func()) {
+	i := &IPFS{}
+	resetConf()
+	utConfPrefix.SubPrefix(IPFSConfAPISubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.SubPrefix(IPFSConfGatewaySubconf).Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	setPinningConf(t, `
+ipfs_unit_tests:
+  pinning:
+  - name: pinata
+    token: token1
+    url: http://pinata.example.com/psa
+  - url: http://web3.example.com
+`)
+
+	err := i.Init(context.Background(), utConfPrefix, mcb)
+	assert.NoError(t, err)
+	assert.True(t, i.Capabilities().Pinning)
+	assert.Len(t, i.pinningServices, 2)
+	assert.Equal(t, "pinata", i.pinningServices[0].name)
+	assert.Equal(t, "http://web3.example.com", i.pinningServices[1].name)
+
+	for _, ps := range i.pinningServices {
+		httpmock.ActivateNonDefault(ps.client.GetClient())
+	}
+	return i, httpmock.DeactivateAndReset
+}
+
+func TestIPFSPinDataSuccess(t *testing.T) {
+	mcb := &publicstoragemocks.Callbacks{}
+	i, done := newTestPinningIPFS(t, mcb)
+	defer done()
+
+	httpmock.RegisterResponder("POST", "http://pinata.example.com/psa/pins",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "Bearer token1", req.Header.Get("Authorization"))
+			var body ipfsPinRequest
+			err := json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", body.CID)
+			return httpmock.NewJsonResponderOrPanic(202, map[string]interface{}{
+				"requestid": "req1",
+				"status":    "queued",
+			})(req)
+		})
+	httpmock.RegisterResponder("POST", "http://web3.example.com/pins",
+		httpmock.NewJsonResponderOrPanic(202, map[string]interface{}{
+			"requestid": "req2",
+			"status":    "pinned",
+		}))
+
+	opID := fftypes.NewUUID()
+	updated := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", i, opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{
+		"pinata":                  fftypes.JSONObject{"requestid": "req1", "status": "queued"},
+		"http://web3.example.com": fftypes.JSONObject{"requestid": "req2", "status": "pinned"},
+	}).Return(nil).Run(func(args mock.Arguments) {
+		close(updated)
+	})
+
+	i.PinData(context.Background(), opID, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	<-updated
+
+	mcb.AssertExpectations(t)
+}
+
+func TestIPFSPinDataFail(t *testing.T) {
+	mcb := &publicstoragemocks.Callbacks{}
+	i, done := newTestPinningIPFS(t, mcb)
+	defer done()
+
+	httpmock.RegisterResponder("POST", "http://pinata.example.com/psa/pins",
+		httpmock.NewJsonResponderOrPanic(401, map[string]interface{}{"error": "pop"}))
+	httpmock.RegisterResponder("POST", "http://web3.example.com/pins",
+		httpmock.NewJsonResponderOrPanic(202, map[string]interface{}{
+			"requestid": "req2",
+			"status":    "failed",
+		}))
+
+	opID := fftypes.NewUUID()
+	updated := make(chan struct{})
+	mcb.On("PublicStorageOpUpdate", i, opID, fftypes.OpStatusFailed, mock.MatchedBy(func(errorMessage string) bool {
+		return strings.Contains(errorMessage, "pinata: FF10420") && strings.Contains(errorMessage, "http://web3.example.com: FF10421")
+	}), mock.MatchedBy(func(output fftypes.JSONObject) bool {
+		return output.GetObject("pinata").GetString("error") != "" &&
+			output.GetObject("http://web3.example.com").GetString("error") != ""
+	})).Return(fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		close(updated)
+	})
+
+	i.PinData(context.Background(), opID, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+	<-updated
+
+	mcb.AssertExpectations(t)
+}
@@ -22,31 +22,35 @@ import (
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/publicstorage/ipfs"
+	"github.com/hyperledger/firefly/internal/publicstorage/s3"
 	"github.com/hyperledger/firefly/pkg/publicstorage"
 )
 
-var plugins = []publicstorage.Plugin{
-	&ipfs.IPFS{},
+var pluginFactories = []func() publicstorage.Plugin{
+	func() publicstorage.Plugin { return &ipfs.IPFS{} },
+	func() publicstorage.Plugin { return &s3.S3{} },
 }
 
-var pluginsByName = make(map[string]publicstorage.Plugin)
+var pluginsByName = make(map[string]func() publicstorage.Plugin)
 
 func init() {
-	for _, p := range plugins {
-		pluginsByName[p.Name()] = p
+	for _, factory := range pluginFactories {
+		pluginsByName[factory().Name()] = factory
 	}
 }
 
 func InitPrefix(prefix config.Prefix) {
-	for _, plugin := range plugins {
+	for _, factory := range pluginFactories {
+		plugin := factory()
 		plugin.InitPrefix(prefix.SubPrefix(plugin.Name()))
 	}
 }
 
+// GetPlugin returns a new instance of the shared storage plugin, each time it is called
 func GetPlugin(ctx context.Context, pluginType string) (publicstorage.Plugin, error) {
-	plugin, ok := pluginsByName[pluginType]
+	factory, ok := pluginsByName[pluginType]
 	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownPublicStoragePlugin, pluginType)
 	}
-	return plugin, nil
+	return factory(), nil
 }
@@ -0,0 +1,48 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+const (
+	defaultRegion = "us-east-1"
+)
+
+const (
+	// S3ConfigBucket is the bucket the payloads are stored in, which must be shared by all members of the network
+	S3ConfigBucket = "bucket"
+	// S3ConfigRegion is the region used to sign requests, and to build the endpoint URL if no URL is configured
+	S3ConfigRegion = "region"
+	// S3ConfigAccessKeyID is the access key ID of the credentials used to sign requests
+	S3ConfigAccessKeyID = "accessKeyId"
+	// S3ConfigSecretAccessKey is the secret access key of the credentials used to sign requests
+	S3ConfigSecretAccessKey = "secretAccessKey"
+	// S3ConfigKeyPrefix is prepended to the content hash, to build the key of each object
+	S3ConfigKeyPrefix = "keyPrefix"
+)
+
+func (s *S3) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(S3ConfigBucket)
+	prefix.AddKnownKey(S3ConfigRegion, defaultRegion)
+	prefix.AddKnownKey(S3ConfigAccessKeyID)
+	prefix.AddKnownKey(S3ConfigSecretAccessKey)
+	prefix.AddKnownKey(S3ConfigKeyPrefix)
+}
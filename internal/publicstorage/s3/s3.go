@@ -0,0 +1,136 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// S3 stores payloads in an AWS S3 bucket, or a bucket in an S3 compatible object store such as MinIO.
+// Objects are keyed by the hash of their content, using path-style requests signed with AWS Signature Version 4
+type S3 struct {
+	ctx          context.Context
+	capabilities *publicstorage.Capabilities
+	callbacks    publicstorage.Callbacks
+	client       *resty.Client
+	bucket       string
+	keyPrefix    string
+	signer       *restclient.SigV4Signer
+}
+
+func (s *S3) Name() string {
+	return "s3"
+}
+
+func (s *S3) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) (err error) {
+
+	s.ctx = log.WithComponent(log.WithLogField(ctx, "publicstorage", "s3"), "publicstorage")
+	s.callbacks = callbacks
+
+	s.bucket = prefix.GetString(S3ConfigBucket)
+	if s.bucket == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(S3ConfigBucket), "s3")
+	}
+	s.signer = &restclient.SigV4Signer{
+		Region:          prefix.GetString(S3ConfigRegion),
+		Service:         "s3",
+		AccessKeyID:     prefix.GetString(S3ConfigAccessKeyID),
+		SecretAccessKey: prefix.GetString(S3ConfigSecretAccessKey),
+		Now:             time.Now,
+	}
+	if s.signer.AccessKeyID == "" || s.signer.SecretAccessKey == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(S3ConfigAccessKeyID), "s3")
+	}
+	s.keyPrefix = prefix.GetString(S3ConfigKeyPrefix)
+
+	s.client, err = restclient.New(s.ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		s.client.SetBaseURL(fmt.Sprintf("https://s3.%s.amazonaws.com", s.signer.Region))
+	}
+	s.client.SetPreRequestHook(s.signer.SignRequest)
+	s.capabilities = &publicstorage.Capabilities{}
+	return nil
+}
+
+func (s *S3) Capabilities() *publicstorage.Capabilities {
+	return s.capabilities
+}
+
+// PublishData stores the data under a key derived from its hash, so publishing the same payload twice is idempotent.
+// The data is read fully into memory, as the hash is needed both for the key and to sign the request
+func (s *S3) PublishData(ctx context.Context, data io.Reader) (string, error) {
+	payload, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(hash[:])
+	key := s.keyPrefix + payloadHash
+
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetHeader(restclient.AmzContentSHA256Header, payloadHash).
+		SetHeader("Content-Type", "application/octet-stream").
+		SetBody(bytes.NewReader(payload)).
+		Put(s.objectPath(key))
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(s.ctx, res, err, i18n.MsgS3RESTErr)
+	}
+	log.L(ctx).Infof("S3 published %s Size=%d", key, len(payload))
+	return key, nil
+}
+
+func (s *S3) RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error) {
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(s.objectPath(payloadRef))
+	restclient.OnAfterResponse(s.client, res) // required using SetDoNotParseResponse
+	if err != nil || !res.IsSuccess() {
+		if res != nil && res.RawBody() != nil {
+			_ = res.RawBody().Close()
+		}
+		return nil, restclient.WrapRestErr(s.ctx, res, err, i18n.MsgS3RESTErr)
+	}
+	log.L(ctx).Infof("S3 retrieved %s", payloadRef)
+	return res.RawBody(), nil
+}
+
+// PinData is a no-op, as S3 does not advertise the Pinning capability
+func (s *S3) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) {}
+
+func (s *S3) objectPath(key string) string {
+	return fmt.Sprintf("/%s/%s", s.bucket, key)
+}
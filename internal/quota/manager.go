@@ -0,0 +1,284 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Manager enforces the throughput quotas configured for namespaces, and topics within namespaces,
+// on the messages sent by this node
+type Manager interface {
+	// CheckMessage reserves the usage of a message against all quotas that apply to it, or returns an ExceededError (reported as HTTP 429) if it would exceed one.
+	// A quota warning event is inserted, referring to the message, the first time the usage of a quota passes the warning threshold in each window - so it must
+	// be called in the database group that stores the message. The returned reservation must be released if the message is not stored.
+	CheckMessage(ctx context.Context, msg *fftypes.MessageInOut) (*Reservation, error)
+}
+
+type limitType string
+
+const (
+	limitMessagesPerMinute limitType = "messagesPerMinute"
+	limitBytesPerDay       limitType = "bytesPerDay"
+)
+
+var limitWindows = map[limitType]time.Duration{
+	limitMessagesPerMinute: time.Minute,
+	limitBytesPerDay:       24 * time.Hour,
+}
+
+// usage tracks consumption of a single limit, in fixed windows aligned to the window duration
+type usage struct {
+	scope       string
+	limit       limitType
+	max         int64
+	windowStart time.Time
+	used        int64
+	warned      bool
+}
+
+type quotaManager struct {
+	database  database.Plugin
+	threshold float64
+	mux       sync.Mutex
+	scopes    map[string][]*usage
+}
+
+// ExceededError is returned when a message would exceed a quota, with the details required
+// to inform the caller when they can retry
+type ExceededError struct {
+	Err       error
+	Limit     int64
+	Remaining int64
+	Reset     time.Time
+}
+
+func (e *ExceededError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExceededError) Unwrap() error {
+	return e.Err
+}
+
+// SetHeaders sets the standard rate limiting headers on an HTTP response
+func (e *ExceededError) SetHeaders(header http.Header) {
+	retryAfter := int64(time.Until(e.Reset).Seconds()) + 1
+	header.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(e.Limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(e.Remaining, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(e.Reset.Unix(), 10))
+}
+
+func NewQuotaManager(ctx context.Context, di database.Plugin) (Manager, error) {
+	if di == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	qm := &quotaManager{
+		database:  di,
+		threshold: config.GetFloat64(config.QuotaWarningThreshold),
+		scopes:    make(map[string][]*usage),
+	}
+	for _, nsConf := range config.GetObjectArray(config.QuotaNamespaces) {
+		ns := nsConf.GetString("name")
+		if ns == "" {
+			log.L(ctx).Errorf("Ignoring quota configuration without a namespace name: %s", nsConf)
+			continue
+		}
+		qm.addScope(ns, nsConf)
+		for _, topicConf := range nsConf.GetObjectArray("topics") {
+			if topic := topicConf.GetString("name"); topic != "" {
+				qm.addScope(topicScope(ns, topic), topicConf)
+			}
+		}
+	}
+	return qm, nil
+}
+
+func topicScope(ns, topic string) string {
+	return fmt.Sprintf("%s/%s", ns, topic)
+}
+
+// limitValue reads a limit, which might be a number or a string (with an optional size unit for bytes)
+func limitValue(conf fftypes.JSONObject, key limitType) int64 {
+	switch v := conf[string(key)].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		return fftypes.ParseToByteSize(v)
+	default:
+		return 0
+	}
+}
+
+func (qm *quotaManager) addScope(scope string, conf fftypes.JSONObject) {
+	for _, limit := range []limitType{limitMessagesPerMinute, limitBytesPerDay} {
+		if max := limitValue(conf, limit); max > 0 {
+			qm.scopes[scope] = append(qm.scopes[scope], &usage{
+				scope: scope,
+				limit: limit,
+				max:   max,
+			})
+		}
+	}
+}
+
+// Reservation is the usage recorded against quotas for a message, which is released if the message is not stored
+type Reservation struct {
+	qm     *quotaManager
+	usages []*reservedUsage
+}
+
+type reservedUsage struct {
+	usage       *usage
+	windowStart time.Time
+	amount      int64
+	warned      bool
+}
+
+// Release returns the usage reserved for a message that could not be stored, and re-arms any warning emitted
+// for it (as the warning event was not stored either). It is a no-op for a nil reservation.
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+	r.qm.mux.Lock()
+	defer r.qm.mux.Unlock()
+	for _, ru := range r.usages {
+		// Usage from a previous window has already been reset
+		if ru.usage.windowStart.Equal(ru.windowStart) {
+			ru.usage.used -= ru.amount
+			if ru.warned {
+				ru.usage.warned = false
+			}
+		}
+	}
+}
+
+// messageSize is the size of all the data of a message, as stored by the data manager - including any blobs
+func (qm *quotaManager) messageSize(ctx context.Context, msg *fftypes.MessageInOut) (size int64, err error) {
+	for _, dataRef := range msg.Message.Data {
+		d, err := qm.database.GetDataByID(ctx, dataRef.ID, true)
+		if err != nil {
+			return 0, err
+		}
+		if d != nil {
+			size += int64(len(d.Value))
+			if d.Blob != nil {
+				size += d.Blob.Size
+			}
+		}
+	}
+	return size, nil
+}
+
+func (u *usage) roll(now time.Time) {
+	windowStart := now.Truncate(limitWindows[u.limit])
+	if !windowStart.Equal(u.windowStart) {
+		u.windowStart = windowStart
+		u.used = 0
+		u.warned = false
+	}
+}
+
+func (qm *quotaManager) CheckMessage(ctx context.Context, msg *fftypes.MessageInOut) (*Reservation, error) {
+	ns := msg.Header.Namespace
+	applicable := append([]*usage{}, qm.scopes[ns]...)
+	for _, topic := range msg.Header.Topics {
+		applicable = append(applicable, qm.scopes[topicScope(ns, topic)]...)
+	}
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+
+	amounts := map[limitType]int64{
+		limitMessagesPerMinute: 1,
+	}
+	for _, u := range applicable {
+		if u.limit == limitBytesPerDay {
+			size, err := qm.messageSize(ctx, msg)
+			if err != nil {
+				return nil, err
+			}
+			amounts[limitBytesPerDay] = size
+			break
+		}
+	}
+
+	qm.mux.Lock()
+	defer qm.mux.Unlock()
+
+	// Check all the quotas before recording usage against any of them
+	now := time.Now()
+	var warnings []*usage
+	for _, u := range applicable {
+		u.roll(now)
+		newUsed := u.used + amounts[u.limit]
+		if newUsed > u.max {
+			log.L(ctx).Warnf("Message rejected for namespace '%s' topics=%v: quota %s=%d exceeded for '%s'", ns, msg.Header.Topics, u.limit, u.max, u.scope)
+			return nil, &ExceededError{
+				Err:       i18n.NewError(ctx, i18n.MsgQuotaExceeded, u.limit, u.max, u.scope),
+				Limit:     u.max,
+				Remaining: u.max - u.used,
+				Reset:     u.windowStart.Add(limitWindows[u.limit]),
+			}
+		}
+		if !u.warned && float64(newUsed) >= qm.threshold*float64(u.max) {
+			warnings = append(warnings, u)
+		}
+	}
+
+	for _, u := range warnings {
+		log.L(ctx).Warnf("Quota %s=%d for '%s' has passed the warning threshold of %.2f", u.limit, u.max, u.scope, qm.threshold)
+		event := fftypes.NewEvent(fftypes.EventTypeQuotaWarning, ns, msg.Header.ID)
+		if err := qm.database.InsertEvent(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	reservation := &Reservation{qm: qm}
+	for _, u := range applicable {
+		u.used += amounts[u.limit]
+		reservation.usages = append(reservation.usages, &reservedUsage{
+			usage:       u,
+			windowStart: u.windowStart,
+			amount:      amounts[u.limit],
+			warned:      !u.warned && float64(u.used) >= qm.threshold*float64(u.max),
+		})
+	}
+	for _, ru := range reservation.usages {
+		if ru.warned {
+			ru.usage.warned = true
+		}
+	}
+	return reservation, nil
+}
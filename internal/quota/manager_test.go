@@ -0,0 +1,242 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestQuotaManager(t *testing.T, quotas fftypes.JSONObjectArray) (*quotaManager, *databasemocks.Plugin) {
+	config.Reset()
+	config.Set(config.QuotaNamespaces, quotas)
+	mdi := &databasemocks.Plugin{}
+	qm, err := NewQuotaManager(context.Background(), mdi)
+	assert.NoError(t, err)
+	return qm.(*quotaManager), mdi
+}
+
+func testMessage(ns string, topics ...string) *fftypes.MessageInOut {
+	return &fftypes.MessageInOut{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				ID:        fftypes.NewUUID(),
+				Namespace: ns,
+				Topics:    topics,
+			},
+			Data: fftypes.DataRefs{
+				{ID: fftypes.NewUUID()},
+			},
+		},
+	}
+}
+
+func TestNewQuotaManagerMissingDeps(t *testing.T) {
+	_, err := NewQuotaManager(context.Background(), nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestNewQuotaManagerConfig(t *testing.T) {
+	qm, _ := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"messagesPerMinute": 10}, // no name
+		{
+			"name":              "ns1",
+			"messagesPerMinute": 10,
+			"bytesPerDay":       "1Kb",
+			"topics": []interface{}{
+				map[string]interface{}{"name": "topic1", "messagesPerMinute": float64(5)},
+				map[string]interface{}{"messagesPerMinute": int64(5)},
+				map[string]interface{}{"name": "topic2", "messagesPerMinute": true, "bytesPerDay": int64(100)},
+			},
+		},
+	})
+	assert.Len(t, qm.scopes, 3)
+	assert.Len(t, qm.scopes["ns1"], 2)
+	assert.Equal(t, int64(10), qm.scopes["ns1"][0].max)
+	assert.Equal(t, int64(1024), qm.scopes["ns1"][1].max)
+	assert.Len(t, qm.scopes["ns1/topic1"], 1)
+	assert.Equal(t, int64(5), qm.scopes["ns1/topic1"][0].max)
+	assert.Len(t, qm.scopes["ns1/topic2"], 1)
+	assert.Equal(t, limitBytesPerDay, qm.scopes["ns1/topic2"][0].limit)
+}
+
+func TestCheckMessageNoQuotas(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{})
+	reservation, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.Nil(t, reservation)
+	reservation.Release()
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMessageNamespaceMessagesPerMinute(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "messagesPerMinute": 5},
+	})
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeQuotaWarning && e.Namespace == "ns1"
+	})).Return(nil).Once()
+
+	for i := 0; i < 5; i++ {
+		_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+		assert.NoError(t, err)
+	}
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.Regexp(t, "FF10337.*messagesPerMinute=5.*ns1", err)
+	qe := err.(*ExceededError)
+	assert.Equal(t, int64(5), qe.Limit)
+	assert.Equal(t, int64(0), qe.Remaining)
+	assert.Equal(t, qm.scopes["ns1"][0].windowStart.Add(time.Minute), qe.Reset)
+
+	// Other namespaces are unaffected
+	_, err = qm.CheckMessage(context.Background(), testMessage("ns2"))
+	assert.NoError(t, err)
+
+	// The quota resets in the next window, as does the warning
+	qm.scopes["ns1"][0].windowStart = qm.scopes["ns1"][0].windowStart.Add(-time.Minute)
+	_, err = qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), qm.scopes["ns1"][0].used)
+	assert.False(t, qm.scopes["ns1"][0].warned)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMessageTopicBytesPerDay(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{
+			"name": "ns1",
+			"topics": []interface{}{
+				map[string]interface{}{"name": "topic1", "bytesPerDay": "8"},
+			},
+		},
+	})
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil).Once()
+	mdi.On("GetDataByID", mock.Anything, mock.Anything, true).Return(&fftypes.Data{
+		Value: fftypes.Byteable(`"12"`),
+		Blob:  &fftypes.BlobRef{Size: 3},
+	}, nil)
+
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1", "topic1"))
+	assert.NoError(t, err)
+	_, err = qm.CheckMessage(context.Background(), testMessage("ns1", "topic2"))
+	assert.NoError(t, err)
+	_, err = qm.CheckMessage(context.Background(), testMessage("ns1", "topic1"))
+	assert.Regexp(t, "FF10337.*bytesPerDay=8.*ns1/topic1", err)
+	assert.Equal(t, int64(1), err.(*ExceededError).Remaining)
+	assert.Equal(t, int64(7), qm.scopes["ns1/topic1"][0].used)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMessageWarningEventFail(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "messagesPerMinute": 1},
+	})
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.EqualError(t, err, "pop")
+	assert.Equal(t, int64(0), qm.scopes["ns1"][0].used)
+	assert.False(t, qm.scopes["ns1"][0].warned)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMessageDataMissing(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "bytesPerDay": 100},
+	})
+	mdi.On("GetDataByID", mock.Anything, mock.Anything, true).Return(nil, nil)
+
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), qm.scopes["ns1"][0].used)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckMessageDataLookupFail(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "bytesPerDay": 100},
+	})
+	mdi.On("GetDataByID", mock.Anything, mock.Anything, true).Return(nil, fmt.Errorf("pop"))
+
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestReleaseReservation(t *testing.T) {
+	qm, mdi := newTestQuotaManager(t, fftypes.JSONObjectArray{
+		{"name": "ns1", "messagesPerMinute": 2},
+	})
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	_, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.False(t, qm.scopes["ns1"][0].warned)
+
+	// The message that passes the warning threshold is not stored, so the warning is re-armed
+	reservation, err := qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.True(t, qm.scopes["ns1"][0].warned)
+	reservation.Release()
+	assert.Equal(t, int64(1), qm.scopes["ns1"][0].used)
+	assert.False(t, qm.scopes["ns1"][0].warned)
+
+	reservation, err = qm.CheckMessage(context.Background(), testMessage("ns1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), qm.scopes["ns1"][0].used)
+
+	// Usage from a previous window is not released from the current one
+	qm.scopes["ns1"][0].windowStart = qm.scopes["ns1"][0].windowStart.Add(time.Minute)
+	reservation.Release()
+	assert.Equal(t, int64(2), qm.scopes["ns1"][0].used)
+	assert.True(t, qm.scopes["ns1"][0].warned)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestExceededErrorHeaders(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second)
+	qe := &ExceededError{
+		Err:       fmt.Errorf("pop"),
+		Limit:     100,
+		Remaining: 10,
+		Reset:     reset,
+	}
+	assert.EqualError(t, qe, "pop")
+	assert.EqualError(t, qe.Unwrap(), "pop")
+
+	header := http.Header{}
+	qe.SetHeaders(header)
+	assert.Equal(t, "100", header.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "10", header.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, fmt.Sprintf("%d", reset.Unix()), header.Get("X-RateLimit-Reset"))
+	assert.Regexp(t, "^(30|31)$", header.Get("Retry-After"))
+}
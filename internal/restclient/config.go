@@ -16,7 +16,10 @@
 
 package restclient
 
-import "github.com/hyperledger/firefly/internal/config"
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
+)
 
 const (
 	defaultRetryEnabled     = false
@@ -24,6 +27,9 @@ const (
 	defaultRetryWaitTime    = "250ms"
 	defaultRetryMaxWaitTime = "30s"
 	defaultRequestTimeout   = "30s"
+	defaultMaxConcurrent    = 0
+	defaultMaxQueued        = 0
+	defaultRefreshBefore    = "30s"
 )
 
 const (
@@ -37,6 +43,16 @@ const (
 	HTTPConfigAuthUsername = "auth.username"
 	// HTTPConfigAuthPassword HTTPS Basic Auth configuration - secret / password
 	HTTPConfigAuthPassword = "auth.password"
+	// HTTPConfigAuthOAuth2TokenURL the OAuth2 token endpoint - if set, a bearer token is fetched using the client credentials flow
+	HTTPConfigAuthOAuth2TokenURL = "auth.oauth2.tokenUrl"
+	// HTTPConfigAuthOAuth2ClientID the OAuth2 client ID
+	HTTPConfigAuthOAuth2ClientID = "auth.oauth2.clientId"
+	// HTTPConfigAuthOAuth2ClientSecret the OAuth2 client secret
+	HTTPConfigAuthOAuth2ClientSecret = "auth.oauth2.clientSecret"
+	// HTTPConfigAuthOAuth2Scopes the scopes to request for the OAuth2 token
+	HTTPConfigAuthOAuth2Scopes = "auth.oauth2.scopes"
+	// HTTPConfigAuthOAuth2RefreshBefore how long before the OAuth2 token expires that a new token is fetched
+	HTTPConfigAuthOAuth2RefreshBefore = "auth.oauth2.refreshBefore"
 	// HTTPConfigRetryEnabled whether retry is enabled on the actions performed over this HTTP request (does not disable retry at higher layers)
 	HTTPConfigRetryEnabled = "retry.enabled"
 	// HTTPConfigRetryCount the maximum number of retries
@@ -47,6 +63,10 @@ const (
 	HTTPConfigRetryMaxDelay = "retry.maxWaitTime"
 	// HTTPConfigRequestTimeout the request timeout
 	HTTPConfigRequestTimeout = "requestTimeout"
+	// HTTPConfigMaxConcurrentRequests limits the number of requests in flight at any one time (0 for no limit)
+	HTTPConfigMaxConcurrentRequests = "maxConcurrentRequests"
+	// HTTPConfigMaxQueuedRequests limits the number of requests waiting for one of the concurrent slots (0 for no limit)
+	HTTPConfigMaxQueuedRequests = "maxQueuedRequests"
 
 	// HTTPCustomClient - unit test only - allows injection of a custom HTTP client to resty
 	HTTPCustomClient = "customClient"
@@ -58,11 +78,19 @@ func InitPrefix(prefix config.KeySet) {
 	prefix.AddKnownKey(HTTPConfigHeaders)
 	prefix.AddKnownKey(HTTPConfigAuthUsername)
 	prefix.AddKnownKey(HTTPConfigAuthPassword)
+	prefix.AddKnownKey(HTTPConfigAuthOAuth2TokenURL)
+	prefix.AddKnownKey(HTTPConfigAuthOAuth2ClientID)
+	prefix.AddKnownKey(HTTPConfigAuthOAuth2ClientSecret)
+	prefix.AddKnownKey(HTTPConfigAuthOAuth2Scopes)
+	prefix.AddKnownKey(HTTPConfigAuthOAuth2RefreshBefore, defaultRefreshBefore)
 	prefix.AddKnownKey(HTTPConfigRetryEnabled, defaultRetryEnabled)
 	prefix.AddKnownKey(HTTPConfigRetryCount, defaultRetryCount)
 	prefix.AddKnownKey(HTTPConfigRetryInitDelay, defaultRetryWaitTime)
 	prefix.AddKnownKey(HTTPConfigRetryMaxDelay, defaultRetryMaxWaitTime)
 	prefix.AddKnownKey(HTTPConfigRequestTimeout, defaultRequestTimeout)
+	prefix.AddKnownKey(HTTPConfigMaxConcurrentRequests, defaultMaxConcurrent)
+	prefix.AddKnownKey(HTTPConfigMaxQueuedRequests, defaultMaxQueued)
+	tlsconfig.InitPrefix(prefix)
 
 	prefix.AddKnownKey(HTTPCustomClient)
 }
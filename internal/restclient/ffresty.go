@@ -27,8 +27,10 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
@@ -59,7 +61,7 @@ func OnAfterResponse(c *resty.Client, resp *resty.Response) {
 //
 // You can use the normal Resty builder pattern, to set per-instance configuration
 // as required.
-func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
+func New(ctx context.Context, staticConfig config.Prefix) (*resty.Client, error) {
 
 	var client *resty.Client
 
@@ -86,6 +88,14 @@ func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
 
 	client.SetTimeout(staticConfig.GetDuration(HTTPConfigRequestTimeout))
 
+	tlsConfig, err := tlsconfig.ConstructTLSConfig(ctx, staticConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		client.SetTLSClientConfig(tlsConfig)
+	}
+
 	client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
 		rctx := req.Context()
 		rc := rctx.Value(retryCtxKey{})
@@ -102,6 +112,8 @@ func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
 			req.SetContext(rctx)
 		}
 		log.L(rctx).Infof("==> %s %s%s", req.Method, url, req.URL)
+		// Propagate any trace the request is part of, so the connector can continue it
+		tracing.InjectHeaders(rctx, req.Header)
 		return nil
 	})
 
@@ -121,6 +133,22 @@ func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
 		client.SetHeader("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", authUsername, authPassword)))))
 	}
 
+	if tokenSource := NewOAuth2TokenSource(staticConfig, tlsConfig); tokenSource != nil {
+		client.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
+			authHeader, err := tokenSource.AuthorizationHeader(req.Context())
+			if err == nil {
+				req.SetHeader("Authorization", authHeader)
+			}
+			return err
+		})
+		client.OnAfterResponse(func(c *resty.Client, r *resty.Response) error {
+			if r.StatusCode() == http.StatusUnauthorized {
+				tokenSource.Invalidate()
+			}
+			return nil
+		})
+	}
+
 	if staticConfig.GetBool(HTTPConfigRetryEnabled) {
 		retryCount := staticConfig.GetInt(HTTPConfigRetryCount)
 		minTimeout := staticConfig.GetDuration(HTTPConfigRetryInitDelay)
@@ -141,7 +169,14 @@ func New(ctx context.Context, staticConfig config.Prefix) *resty.Client {
 			})
 	}
 
-	return client
+	// Must be applied last, as resty requires an *http.Transport to apply the TLS and proxy settings above
+	if maxConcurrent := staticConfig.GetInt(HTTPConfigMaxConcurrentRequests); maxConcurrent > 0 {
+		name := strings.TrimSuffix(staticConfig.Resolve(HTTPConfigURL), "."+HTTPConfigURL)
+		httpClient := client.GetClient()
+		httpClient.Transport = newLimitedTransport(name, httpClient.Transport, maxConcurrent, staticConfig.GetInt(HTTPConfigMaxQueuedRequests))
+	}
+
+	return client, nil
 }
 
 func WrapRestErr(ctx context.Context, res *resty.Response, err error, key i18n.MessageKey) error {
@@ -18,13 +18,19 @@ package restclient
 
 import (
 	"context"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 )
@@ -50,7 +56,8 @@ func TestRequestOK(t *testing.T) {
 	utConfPrefix.Set(HTTPConfigRetryEnabled, true)
 	utConfPrefix.Set(HTTPCustomClient, customClient)
 
-	c := New(context.Background(), utConfPrefix)
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
 	httpmock.ActivateNonDefault(customClient)
 	defer httpmock.DeactivateAndReset()
 
@@ -69,6 +76,35 @@ func TestRequestOK(t *testing.T) {
 	assert.Equal(t, 1, httpmock.GetTotalCallCount())
 }
 
+func TestRequestPropagatesTrace(t *testing.T) {
+
+	customClient := &http.Client{}
+
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(HTTPCustomClient, customClient)
+	err := tracing.Init(context.Background())
+	assert.NoError(t, err)
+
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	httpmock.ActivateNonDefault(customClient)
+	defer httpmock.DeactivateAndReset()
+
+	traceParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, traceParent, req.Header.Get("traceparent"))
+			return httpmock.NewStringResponder(200, `{}`)(req)
+		})
+
+	ctx := tracing.ExtractCarrier(context.Background(), map[string]string{"traceparent": traceParent})
+	resp, err := c.R().SetContext(ctx).Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
 func TestRequestRetry(t *testing.T) {
 
 	ctx := context.Background()
@@ -78,7 +114,8 @@ func TestRequestRetry(t *testing.T) {
 	utConfPrefix.Set(HTTPConfigRetryEnabled, true)
 	utConfPrefix.Set(HTTPConfigRetryInitDelay, 1)
 
-	c := New(ctx, utConfPrefix)
+	c, err := New(ctx, utConfPrefix)
+	assert.NoError(t, err)
 	httpmock.ActivateNonDefault(c.GetClient())
 	defer httpmock.DeactivateAndReset()
 
@@ -104,7 +141,8 @@ func TestConfWithProxy(t *testing.T) {
 	utConfPrefix.Set(HTTPConfigProxyURL, "http://myproxy.example.com:12345")
 	utConfPrefix.Set(HTTPConfigRetryEnabled, false)
 
-	c := New(ctx, utConfPrefix)
+	c, err := New(ctx, utConfPrefix)
+	assert.NoError(t, err)
 	assert.True(t, c.IsProxySet())
 }
 
@@ -116,7 +154,8 @@ func TestLongResponse(t *testing.T) {
 	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
 	utConfPrefix.Set(HTTPConfigRetryEnabled, false)
 
-	c := New(ctx, utConfPrefix)
+	c, err := New(ctx, utConfPrefix)
+	assert.NoError(t, err)
 	httpmock.ActivateNonDefault(c.GetClient())
 	defer httpmock.DeactivateAndReset()
 
@@ -140,7 +179,8 @@ func TestErrResponse(t *testing.T) {
 	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
 	utConfPrefix.Set(HTTPConfigRetryEnabled, false)
 
-	c := New(ctx, utConfPrefix)
+	c, err := New(ctx, utConfPrefix)
+	assert.NoError(t, err)
 	httpmock.ActivateNonDefault(c.GetClient())
 	defer httpmock.DeactivateAndReset()
 
@@ -159,3 +199,34 @@ func TestErrResponse(t *testing.T) {
 func TestOnAfterResponseNil(t *testing.T) {
 	OnAfterResponse(nil, nil)
 }
+
+func TestRequestTLS(t *testing.T) {
+
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer svr.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw}), 0600)
+	assert.NoError(t, err)
+
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, svr.URL)
+	utConfPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.Set(tlsconfig.TLSConfigCAFile, caFile)
+
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	resp, err := c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+}
+
+func TestNewBadTLS(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	_, err := New(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10105", err)
+}
@@ -0,0 +1,121 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// limitedTransport bounds the number of requests in flight to a connector. Requests beyond
+// the limit queue (up to an optional maximum) until a slot is released, or their context ends.
+// A slot is held until the response body is closed, so streamed responses count as in-flight.
+type limitedTransport struct {
+	name      string
+	next      http.RoundTripper
+	slots     chan struct{}
+	maxQueued int
+	queueMux  sync.Mutex
+	queued    int
+	inflightG prometheus.Gauge
+	queuedG   prometheus.Gauge
+}
+
+func newLimitedTransport(name string, next http.RoundTripper, maxConcurrent, maxQueued int) *limitedTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	metrics.Registry()
+	return &limitedTransport{
+		name:      name,
+		next:      next,
+		slots:     make(chan struct{}, maxConcurrent),
+		maxQueued: maxQueued,
+		inflightG: metrics.RESTClientInflightGauge.WithLabelValues(name),
+		queuedG:   metrics.RESTClientQueuedGauge.WithLabelValues(name),
+	}
+}
+
+func (lt *limitedTransport) acquire(req *http.Request) error {
+	ctx := req.Context()
+	select {
+	case lt.slots <- struct{}{}:
+		lt.inflightG.Inc()
+		return nil
+	default:
+	}
+
+	lt.queueMux.Lock()
+	if lt.maxQueued > 0 && lt.queued >= lt.maxQueued {
+		lt.queueMux.Unlock()
+		return i18n.NewError(ctx, i18n.MsgRESTClientQueueFull, lt.name, lt.maxQueued)
+	}
+	lt.queued++
+	lt.queueMux.Unlock()
+	lt.queuedG.Inc()
+	log.L(ctx).Debugf("Queued request to %s %s (concurrency limit %d reached)", req.Method, req.URL, cap(lt.slots))
+
+	defer func() {
+		lt.queueMux.Lock()
+		lt.queued--
+		lt.queueMux.Unlock()
+		lt.queuedG.Dec()
+	}()
+	select {
+	case lt.slots <- struct{}{}:
+		lt.inflightG.Inc()
+		return nil
+	case <-ctx.Done():
+		return i18n.NewError(ctx, i18n.MsgRESTClientQueueTimeout, lt.name)
+	}
+}
+
+func (lt *limitedTransport) release() {
+	<-lt.slots
+	lt.inflightG.Dec()
+}
+
+func (lt *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := lt.acquire(req); err != nil {
+		return nil, err
+	}
+	res, err := lt.next.RoundTrip(req)
+	if err != nil || res.Body == nil {
+		lt.release()
+		return res, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: lt.release}
+	return res, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (rb *releasingBody) Close() error {
+	err := rb.ReadCloser.Close()
+	rb.once.Do(rb.release)
+	return err
+}
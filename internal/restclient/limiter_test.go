@@ -0,0 +1,104 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitedRequestsQueueAndComplete(t *testing.T) {
+
+	unblock := make(chan struct{})
+	received := make(chan struct{}, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		res.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, server.URL)
+	utConfPrefix.Set(HTTPConfigMaxConcurrentRequests, 1)
+	utConfPrefix.Set(HTTPConfigMaxQueuedRequests, 1)
+
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	lt := c.GetClient().Transport.(*limitedTransport)
+	assert.Equal(t, "http_unit_tests", lt.name)
+
+	results := make(chan error, 2)
+	call := func() {
+		res, err := c.R().Get("/test")
+		if err == nil && res.StatusCode() != 204 {
+			err = fmt.Errorf("status %d", res.StatusCode())
+		}
+		results <- err
+	}
+
+	// First request takes the only slot, second one queues
+	go call()
+	<-received
+	go call()
+	for {
+		lt.queueMux.Lock()
+		queued := lt.queued
+		lt.queueMux.Unlock()
+		if queued == 1 {
+			break
+		}
+	}
+
+	// Third request is rejected, as the queue is full
+	_, err = c.R().Get("/test")
+	assert.Regexp(t, "FF10361", err)
+
+	close(unblock)
+	assert.NoError(t, <-results)
+	assert.NoError(t, <-results)
+	assert.Len(t, received, 1) // the queued request reached the server only after the first completed
+	assert.Empty(t, lt.slots)
+}
+
+func TestLimitedRequestContextCancelledWhileQueued(t *testing.T) {
+
+	lt := newLimitedTransport("ut", nil, 1, 0)
+	lt.slots <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:12345", nil)
+	_, err := lt.RoundTrip(req)
+	assert.Regexp(t, "FF10362", err)
+	assert.Equal(t, 0, lt.queued)
+}
+
+func TestLimitedRequestTransportError(t *testing.T) {
+
+	lt := newLimitedTransport("ut", nil, 1, 0)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:0", nil)
+	_, err := lt.RoundTrip(req)
+	assert.Error(t, err)
+	assert.Empty(t, lt.slots)
+}
@@ -0,0 +1,128 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+// OAuth2TokenSource fetches bearer tokens from an OAuth2 token endpoint using the client credentials flow.
+// The token is cached, and refreshed shortly before it expires (or after the server rejects it).
+type OAuth2TokenSource struct {
+	client        *resty.Client
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	refreshBefore time.Duration
+	mux           sync.Mutex
+	token         string
+	expiry        time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// NewOAuth2TokenSource returns a token source if an OAuth2 token URL is configured in the prefix, or nil otherwise.
+// The TLS configuration constructed from the same prefix is used to connect to the token endpoint.
+func NewOAuth2TokenSource(staticConfig config.Prefix, tlsConfig *tls.Config) *OAuth2TokenSource {
+	tokenURL := staticConfig.GetString(HTTPConfigAuthOAuth2TokenURL)
+	if tokenURL == "" {
+		return nil
+	}
+
+	var client *resty.Client
+	if httpClient, ok := staticConfig.Get(HTTPCustomClient).(*http.Client); ok {
+		client = resty.NewWithClient(httpClient)
+	} else {
+		client = resty.New()
+	}
+	client.SetTimeout(staticConfig.GetDuration(HTTPConfigRequestTimeout))
+	if tlsConfig != nil {
+		client.SetTLSClientConfig(tlsConfig)
+	}
+
+	return &OAuth2TokenSource{
+		client:        client,
+		tokenURL:      tokenURL,
+		clientID:      staticConfig.GetString(HTTPConfigAuthOAuth2ClientID),
+		clientSecret:  staticConfig.GetString(HTTPConfigAuthOAuth2ClientSecret),
+		scopes:        staticConfig.GetStringSlice(HTTPConfigAuthOAuth2Scopes),
+		refreshBefore: staticConfig.GetDuration(HTTPConfigAuthOAuth2RefreshBefore),
+	}
+}
+
+// AuthorizationHeader returns the value for the Authorization header, fetching a new token if required
+func (ts *OAuth2TokenSource) AuthorizationHeader(ctx context.Context) (string, error) {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+
+	if ts.token != "" && time.Now().Before(ts.expiry) {
+		return fmt.Sprintf("Bearer %s", ts.token), nil
+	}
+
+	form := map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     ts.clientID,
+		"client_secret": ts.clientSecret,
+	}
+	if len(ts.scopes) > 0 {
+		form["scope"] = strings.Join(ts.scopes, " ")
+	}
+	var tokenRes oauth2TokenResponse
+	res, err := ts.client.R().
+		SetContext(ctx).
+		SetFormData(form).
+		SetResult(&tokenRes).
+		Post(ts.tokenURL)
+	if err != nil || !res.IsSuccess() {
+		return "", WrapRestErr(ctx, res, err, i18n.MsgOAuth2TokenErr)
+	}
+	if tokenRes.AccessToken == "" {
+		return "", i18n.NewError(ctx, i18n.MsgOAuth2TokenErr, "missing access_token")
+	}
+
+	ts.token = tokenRes.AccessToken
+	// A token without an expiry is used until the server rejects it
+	ts.expiry = time.Unix(1<<62, 0)
+	if tokenRes.ExpiresIn > 0 {
+		ts.expiry = time.Now().Add(time.Duration(tokenRes.ExpiresIn)*time.Second - ts.refreshBefore)
+	}
+	log.L(ctx).Debugf("Obtained OAuth2 token from %s (expires_in=%ds)", ts.tokenURL, tokenRes.ExpiresIn)
+	return fmt.Sprintf("Bearer %s", ts.token), nil
+}
+
+// Invalidate discards the cached token, so a new one is fetched for the next request
+func (ts *OAuth2TokenSource) Invalidate() {
+	ts.mux.Lock()
+	defer ts.mux.Unlock()
+	ts.token = ""
+}
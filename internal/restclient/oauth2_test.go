@@ -0,0 +1,168 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOAuth2TestClient(t *testing.T) *http.Client {
+	customClient := &http.Client{}
+	resetConf()
+	utConfPrefix.Set(HTTPConfigURL, "http://localhost:12345")
+	utConfPrefix.Set(HTTPConfigAuthOAuth2TokenURL, "http://localhost:23456/oauth/token")
+	utConfPrefix.Set(HTTPConfigAuthOAuth2ClientID, "client1")
+	utConfPrefix.Set(HTTPConfigAuthOAuth2ClientSecret, "secret1")
+	utConfPrefix.Set(HTTPCustomClient, customClient)
+	httpmock.ActivateNonDefault(customClient)
+	return customClient
+}
+
+func TestRequestOAuth2TokenCachedAndRefreshed(t *testing.T) {
+	newOAuth2TestClient(t)
+	defer httpmock.DeactivateAndReset()
+	utConfPrefix.Set(HTTPConfigAuthOAuth2Scopes, []string{"read", "write"})
+	utConfPrefix.Set(HTTPConfigAuthOAuth2RefreshBefore, "0")
+
+	tokens := 0
+	httpmock.RegisterResponder("POST", "http://localhost:23456/oauth/token",
+		func(req *http.Request) (*http.Response, error) {
+			assert.NoError(t, req.ParseForm())
+			assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"))
+			assert.Equal(t, "client1", req.PostForm.Get("client_id"))
+			assert.Equal(t, "secret1", req.PostForm.Get("client_secret"))
+			assert.Equal(t, "read write", req.PostForm.Get("scope"))
+			tokens++
+			return httpmock.NewJsonResponse(200, map[string]interface{}{
+				"access_token": fmt.Sprintf("token%d", tokens),
+				"token_type":   "bearer",
+				"expires_in":   3600,
+			})
+		})
+	status := 200
+	httpmock.RegisterResponder("GET", "http://localhost:12345/test",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, fmt.Sprintf("Bearer token%d", tokens), req.Header.Get("Authorization"))
+			return httpmock.NewStringResponder(status, `{}`)(req)
+		})
+
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	resp, err := c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	resp, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.Equal(t, 1, tokens)
+
+	// A rejected token is discarded, and a new one fetched for the next request
+	status = 401
+	resp, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode())
+	status = 200
+	resp, err = c.R().Get("/test")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.Equal(t, 2, tokens)
+}
+
+func TestRequestOAuth2TokenExpired(t *testing.T) {
+	newOAuth2TestClient(t)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:23456/oauth/token",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"access_token": "token1",
+			"expires_in":   1, // less than refreshBefore
+		}))
+
+	ts := NewOAuth2TokenSource(utConfPrefix, nil)
+	authHeader, err := ts.AuthorizationHeader(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token1", authHeader)
+	_, err = ts.AuthorizationHeader(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestRequestOAuth2TokenNoExpiry(t *testing.T) {
+	newOAuth2TestClient(t)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:23456/oauth/token",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"access_token": "token1",
+		}))
+
+	ts := NewOAuth2TokenSource(utConfPrefix, nil)
+	_, err := ts.AuthorizationHeader(context.Background())
+	assert.NoError(t, err)
+	authHeader, err := ts.AuthorizationHeader(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token1", authHeader)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestRequestOAuth2TokenFail(t *testing.T) {
+	newOAuth2TestClient(t)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:23456/oauth/token",
+		httpmock.NewStringResponder(400, `{"error": "invalid_client"}`))
+
+	c, err := New(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	_, err = c.R().Get("/test")
+	assert.Regexp(t, "FF10376.*invalid_client", err)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestRequestOAuth2TokenMissing(t *testing.T) {
+	newOAuth2TestClient(t)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:23456/oauth/token",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{}))
+
+	ts := NewOAuth2TokenSource(utConfPrefix, nil)
+	_, err := ts.AuthorizationHeader(context.Background())
+	assert.Regexp(t, "FF10376.*access_token", err)
+}
+
+func TestNewOAuth2TokenSourceNotConfigured(t *testing.T) {
+	resetConf()
+	assert.Nil(t, NewOAuth2TokenSource(utConfPrefix, nil))
+}
+
+func TestNewOAuth2TokenSourceTLS(t *testing.T) {
+	resetConf()
+	utConfPrefix.Set(HTTPConfigAuthOAuth2TokenURL, "https://localhost:23456/oauth/token")
+	tlsConfig := &tls.Config{}
+	ts := NewOAuth2TokenSource(utConfPrefix, tlsConfig)
+	assert.Equal(t, tlsConfig, ts.client.GetClient().Transport.(*http.Transport).TLSClientConfig)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4Terminator  = "aws4_request"
+	amzDateHeader    = "X-Amz-Date"
+	amzTargetHeader  = "X-Amz-Target"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// AmzContentSHA256Header is the header that must contain the hex SHA-256 hash of the payload of a request signed by a SigV4Signer
+	AmzContentSHA256Header = "X-Amz-Content-Sha256"
+)
+
+// SigV4Signer signs requests to AWS services with Signature Version 4, using static credentials
+type SigV4Signer struct {
+	Region          string
+	Service         string
+	AccessKeyID     string
+	SecretAccessKey string
+	Now             func() time.Time
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+// SignRequest adds an authorization header to each request (including retries) just before it is sent, when set as the
+// pre-request hook of a client. The hash of the payload must have been set in the x-amz-content-sha256 header, unless the
+// body is empty. The x-amz-target header used by JSON APIs is signed, if set.
+func (s *SigV4Signer) SignRequest(c *resty.Client, req *http.Request) error {
+	now := s.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/%s/%s", now.Format("20060102"), s.Region, s.Service, sigV4Terminator)
+
+	payloadHash := req.Header.Get(AmzContentSHA256Header)
+	if payloadHash == "" {
+		payloadHash = emptyPayloadHash
+		req.Header.Set(AmzContentSHA256Header, payloadHash)
+	}
+	req.Header.Set(amzDateHeader, amzDate)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if target := req.Header.Get(amzTargetHeader); target != "" {
+		headers["x-amz-target"] = target
+	}
+	signedHeaders := make([]string, 0, len(headers))
+	for name := range headers {
+		signedHeaders = append(signedHeaders, name)
+	}
+	sort.Strings(signedHeaders)
+	canonicalHeaders := ""
+	for _, name := range signedHeaders {
+		canonicalHeaders += fmt.Sprintf("%s:%s\n", name, headers[name])
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), now.Format("20060102"))
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, s.Service)
+	signingKey = hmacSHA256(signingKey, sigV4Terminator)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.AccessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+	return nil
+}
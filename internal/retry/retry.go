@@ -18,6 +18,7 @@ package retry
 
 import (
 	"context"
+	"math/rand"
 	"time"
 
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -33,6 +34,8 @@ type Retry struct {
 	InitialDelay time.Duration
 	MaximumDelay time.Duration
 	Factor       float64
+	// Jitter randomly reduces each delay by up to this fraction (0-1), so clients do not retry in lockstep
+	Jitter float64
 }
 
 // DoCustomLog disables the automatic attempt logging, so the caller should do logging for each attempt
@@ -81,7 +84,18 @@ func (r *Retry) Do(ctx context.Context, logDescription string, f func(attempt in
 		}
 
 		// Sleep and set the delay for next time
-		time.Sleep(delay)
+		time.Sleep(r.jittered(delay))
 		delay = time.Duration(float64(delay) * factor)
 	}
 }
+
+func (r *Retry) jittered(delay time.Duration) time.Duration {
+	jitter := r.Jitter
+	if jitter <= 0 {
+		return delay
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return delay - time.Duration(rand.Float64()*jitter*float64(delay))
+}
@@ -60,3 +60,23 @@ func TestRetryContextCancellled(t *testing.T) {
 	})
 	assert.Regexp(t, "FF10158", err)
 }
+
+func TestRetryJitter(t *testing.T) {
+	r := Retry{
+		MaximumDelay: 3 * time.Microsecond,
+		InitialDelay: 1 * time.Microsecond,
+		Jitter:       0.5,
+	}
+	r.Do(context.Background(), "unit test", func(i int) (retry bool, err error) {
+		return i < 10, fmt.Errorf("pop")
+	})
+
+	delay := r.jittered(10 * time.Second)
+	assert.LessOrEqual(t, int64(delay), int64(10*time.Second))
+	assert.GreaterOrEqual(t, int64(delay), int64(5*time.Second))
+
+	r.Jitter = 2
+	delay = r.jittered(10 * time.Second)
+	assert.LessOrEqual(t, int64(delay), int64(10*time.Second))
+	assert.GreaterOrEqual(t, int64(delay), int64(0))
+}
@@ -0,0 +1,186 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollup
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Manager periodically rolls up the counts of messages, token transfers and events into fixed time buckets,
+// so that charts over long time ranges can be served without scanning the underlying collections
+type Manager interface {
+	Start() error
+
+	// GetChartRollup returns a histogram of the rolled up counts of a metric from the start time, broken down by type.
+	// The interval of each bucket defaults to, and must be a multiple of, the configured rollup interval
+	GetChartRollup(ctx context.Context, ns, metric string, startTime *fftypes.FFTime, interval time.Duration, buckets int64, types []string) ([]*fftypes.ChartHistogramTyped, error)
+}
+
+var rollupMetrics = []fftypes.MetricRollupType{
+	fftypes.MetricRollupTypeMessages,
+	fftypes.MetricRollupTypeTransfers,
+	fftypes.MetricRollupTypeEvents,
+}
+
+type rollupManager struct {
+	ctx      context.Context
+	database database.Plugin
+	interval time.Duration
+}
+
+func NewRollupManager(ctx context.Context, di database.Plugin) (Manager, error) {
+	if di == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	return &rollupManager{
+		ctx:      log.WithLogField(ctx, "role", "rollup"),
+		database: di,
+		interval: config.GetDuration(config.MetricsRollupInterval),
+	}, nil
+}
+
+func (rm *rollupManager) Start() error {
+	if rm.interval > 0 {
+		go rm.rollupLoop()
+	}
+	return nil
+}
+
+func (rm *rollupManager) rollupLoop() {
+	ticker := time.NewTicker(rm.interval)
+	defer ticker.Stop()
+	for {
+		for _, metric := range rollupMetrics {
+			if err := rm.rollup(rm.ctx, metric); err != nil {
+				log.L(rm.ctx).Errorf("Rollup of %s failed: %s", metric, err)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-rm.ctx.Done():
+			log.L(rm.ctx).Debugf("Rollup loop exiting")
+			return
+		}
+	}
+}
+
+// rollup counts the records of a metric since the offset into buckets, replacing the previous count of each bucket.
+// The offset is left at the start of the bucket before the current one, so both are counted again next time - the
+// current bucket is incomplete, and the previous one might have been missing records still being committed.
+func (rm *rollupManager) rollup(ctx context.Context, metric fftypes.MetricRollupType) error {
+	offset, err := rm.database.GetOffset(ctx, fftypes.OffsetTypeRollup, metric.String())
+	if err != nil {
+		return err
+	}
+	var from int64
+	if offset != nil {
+		from = offset.Current
+	}
+	now := time.Now().UnixNano()
+	counts, err := rm.database.GetMetricCounts(ctx, metric, rm.interval, fftypes.UnixTime(from), fftypes.UnixTime(now))
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		if err = rm.database.UpsertMetricRollup(ctx, count); err != nil {
+			return err
+		}
+	}
+	next := now - now%rm.interval.Nanoseconds() - rm.interval.Nanoseconds()
+	if next <= from {
+		return nil
+	}
+	log.L(ctx).Debugf("Rolled up %d %s buckets since %d", len(counts), metric, from)
+	return rm.database.UpsertOffset(ctx, &fftypes.Offset{
+		Type:    fftypes.OffsetTypeRollup,
+		Name:    metric.String(),
+		Current: next,
+	}, true)
+}
+
+func (rm *rollupManager) GetChartRollup(ctx context.Context, ns, metric string, startTime *fftypes.FFTime, interval time.Duration, buckets int64, types []string) ([]*fftypes.ChartHistogramTyped, error) {
+	if rm.interval <= 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgRollupsDisabled)
+	}
+	metricType := fftypes.MetricRollupType(strings.ToLower(metric))
+	valid := false
+	for _, m := range rollupMetrics {
+		valid = valid || m == metricType
+	}
+	if !valid {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownFieldValue, "metric", metric)
+	}
+	if interval == 0 {
+		interval = rm.interval
+	}
+	if interval < 0 || interval%rm.interval != 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidRollupInterval, interval, rm.interval)
+	}
+	if buckets > fftypes.ChartHistogramMaxBuckets || buckets < fftypes.ChartHistogramMinBuckets {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidNumberOfIntervals, fftypes.ChartHistogramMinBuckets, fftypes.ChartHistogramMaxBuckets)
+	}
+
+	// Align the start to the rollup buckets, so each rollup falls entirely within one bucket of the histogram
+	start := startTime.UnixNano() - startTime.UnixNano()%rm.interval.Nanoseconds()
+	end := start + buckets*interval.Nanoseconds()
+	histogram := make([]*fftypes.ChartHistogramTyped, buckets)
+	for i := range histogram {
+		histogram[i] = &fftypes.ChartHistogramTyped{
+			Timestamp: fftypes.UnixTime(start + int64(i)*interval.Nanoseconds()),
+			Types:     []*fftypes.ChartHistogramTypeCount{},
+		}
+	}
+
+	fb := database.MetricRollupQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("metric", metricType),
+		fb.Gte("bucket", fftypes.UnixTime(start)),
+		fb.Lt("bucket", fftypes.UnixTime(end)),
+	)
+	if len(types) > 0 {
+		typeValues := make([]driver.Value, len(types))
+		for i, t := range types {
+			typeValues[i] = t
+		}
+		filter = filter.Condition(fb.In("type", typeValues))
+	}
+	rollups, _, err := rm.database.GetMetricRollups(ctx, filter.Sort("type", "bucket"))
+	if err != nil {
+		return nil, err
+	}
+	// The rollups are sorted by type, so the counts of each type within a bucket of the histogram are adjacent
+	for _, r := range rollups {
+		h := histogram[(r.Bucket.UnixNano()-start)/interval.Nanoseconds()]
+		h.Count += r.Count
+		if len(h.Types) > 0 && h.Types[len(h.Types)-1].Type == r.Type {
+			h.Types[len(h.Types)-1].Count += r.Count
+		} else {
+			h.Types = append(h.Types, &fftypes.ChartHistogramTypeCount{Type: r.Type, Count: r.Count})
+		}
+	}
+	return histogram, nil
+}
@@ -0,0 +1,223 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestRollupManager(t *testing.T) (*rollupManager, *databasemocks.Plugin, func()) {
+	config.Reset()
+	config.Set(config.MetricsRollupInterval, "1m")
+	mdi := &databasemocks.Plugin{}
+	ctx, cancel := context.WithCancel(context.Background())
+	rm, err := NewRollupManager(ctx, mdi)
+	assert.NoError(t, err)
+	return rm.(*rollupManager), mdi, cancel
+}
+
+func TestNewRollupManagerMissingDeps(t *testing.T) {
+	_, err := NewRollupManager(context.Background(), nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestStartDisabled(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+	rm.interval = 0
+
+	err := rm.Start()
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestStartRollupLoop(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+
+	done := make(chan struct{})
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, mock.Anything).Return(nil, fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		if args[2] == fftypes.MetricRollupTypeEvents.String() {
+			cancel()
+			close(done)
+		}
+	})
+
+	err := rm.Start()
+	assert.NoError(t, err)
+	<-done
+	mdi.AssertExpectations(t)
+}
+
+func TestRollupLoopTick(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	rm.interval = time.Millisecond
+
+	calls := 0
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, fftypes.MetricRollupTypeEvents.String()).Return(nil, fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		if calls++; calls == 2 {
+			cancel()
+		}
+	})
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	rm.rollupLoop()
+	assert.Equal(t, 2, calls)
+}
+
+func TestRollupFromStart(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	counts := []*fftypes.MetricRollup{
+		{Namespace: "ns1", Metric: fftypes.MetricRollupTypeMessages, Type: "broadcast", Bucket: fftypes.Now(), Count: 10},
+		{Namespace: "ns1", Metric: fftypes.MetricRollupTypeMessages, Type: "private", Bucket: fftypes.Now(), Count: 5},
+	}
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, "messages").Return(nil, nil)
+	mdi.On("GetMetricCounts", rm.ctx, fftypes.MetricRollupTypeMessages, time.Minute, fftypes.UnixTime(0), mock.Anything).Return(counts, nil)
+	mdi.On("UpsertMetricRollup", rm.ctx, counts[0]).Return(nil)
+	mdi.On("UpsertMetricRollup", rm.ctx, counts[1]).Return(nil)
+	mdi.On("UpsertOffset", rm.ctx, mock.MatchedBy(func(o *fftypes.Offset) bool {
+		return o.Type == fftypes.OffsetTypeRollup && o.Name == "messages" &&
+			o.Current%time.Minute.Nanoseconds() == 0 &&
+			o.Current <= time.Now().Add(-time.Minute).UnixNano()
+	}), true).Return(nil)
+
+	err := rm.rollup(rm.ctx, fftypes.MetricRollupTypeMessages)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestRollupOffsetCurrent(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	current := time.Now().UnixNano()
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, "events").Return(&fftypes.Offset{Current: current}, nil)
+	mdi.On("GetMetricCounts", rm.ctx, fftypes.MetricRollupTypeEvents, time.Minute, fftypes.UnixTime(current), mock.Anything).Return([]*fftypes.MetricRollup{}, nil)
+
+	err := rm.rollup(rm.ctx, fftypes.MetricRollupTypeEvents)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestRollupCountFail(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, "transfers").Return(nil, nil)
+	mdi.On("GetMetricCounts", rm.ctx, fftypes.MetricRollupTypeTransfers, time.Minute, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := rm.rollup(rm.ctx, fftypes.MetricRollupTypeTransfers)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestRollupUpsertFail(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	mdi.On("GetOffset", rm.ctx, fftypes.OffsetTypeRollup, "transfers").Return(nil, nil)
+	mdi.On("GetMetricCounts", rm.ctx, fftypes.MetricRollupTypeTransfers, time.Minute, mock.Anything, mock.Anything).Return([]*fftypes.MetricRollup{{}}, nil)
+	mdi.On("UpsertMetricRollup", rm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := rm.rollup(rm.ctx, fftypes.MetricRollupTypeTransfers)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestGetChartRollup(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	start := time.Unix(1599999960, 0) // aligned to the minute
+	rollups := []*fftypes.MetricRollup{
+		{Type: "broadcast", Bucket: fftypes.UnixTime(start.UnixNano()), Count: 1},
+		{Type: "broadcast", Bucket: fftypes.UnixTime(start.Add(time.Minute).UnixNano()), Count: 2},
+		{Type: "broadcast", Bucket: fftypes.UnixTime(start.Add(2 * time.Minute).UnixNano()), Count: 4},
+		{Type: "private", Bucket: fftypes.UnixTime(start.Add(time.Minute).UnixNano()), Count: 8},
+	}
+	mdi.On("GetMetricRollups", rm.ctx, mock.MatchedBy(func(f database.Filter) bool {
+		fi, _ := f.Finalize()
+		return fi.String() == fmt.Sprintf("( namespace == 'ns1' ) && ( metric == 'messages' ) && ( bucket >= %d ) && ( bucket < %d ) && ( type IN ['broadcast','private'] ) sort=type,bucket",
+			start.UnixNano(), start.Add(4*time.Minute).UnixNano())
+	})).Return(rollups, nil, nil)
+
+	histogram, err := rm.GetChartRollup(rm.ctx, "ns1", "Messages", fftypes.UnixTime(start.Add(time.Second).UnixNano()), 2*time.Minute, 2, []string{"broadcast", "private"})
+	assert.NoError(t, err)
+	assert.Len(t, histogram, 2)
+	assert.Equal(t, start.UnixNano(), histogram[0].Timestamp.UnixNano())
+	assert.Equal(t, int64(11), histogram[0].Count)
+	assert.Equal(t, []*fftypes.ChartHistogramTypeCount{{Type: "broadcast", Count: 3}, {Type: "private", Count: 8}}, histogram[0].Types)
+	assert.Equal(t, start.Add(2*time.Minute).UnixNano(), histogram[1].Timestamp.UnixNano())
+	assert.Equal(t, int64(4), histogram[1].Count)
+	assert.Equal(t, []*fftypes.ChartHistogramTypeCount{{Type: "broadcast", Count: 4}}, histogram[1].Types)
+	mdi.AssertExpectations(t)
+}
+
+func TestGetChartRollupDefaultIntervalFail(t *testing.T) {
+	rm, mdi, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	mdi.On("GetMetricRollups", rm.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := rm.GetChartRollup(rm.ctx, "ns1", "events", fftypes.Now(), 0, 10, nil)
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
+func TestGetChartRollupDisabled(t *testing.T) {
+	rm, _, cancel := newTestRollupManager(t)
+	defer cancel()
+	rm.interval = 0
+
+	_, err := rm.GetChartRollup(rm.ctx, "ns1", "events", fftypes.Now(), 0, 10, nil)
+	assert.Regexp(t, "FF10439", err)
+}
+
+func TestGetChartRollupUnknownMetric(t *testing.T) {
+	rm, _, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	_, err := rm.GetChartRollup(rm.ctx, "ns1", "wrong", fftypes.Now(), 0, 10, nil)
+	assert.Regexp(t, "FF10132", err)
+}
+
+func TestGetChartRollupBadInterval(t *testing.T) {
+	rm, _, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	_, err := rm.GetChartRollup(rm.ctx, "ns1", "events", fftypes.Now(), 90*time.Second, 10, nil)
+	assert.Regexp(t, "FF10440", err)
+}
+
+func TestGetChartRollupBadBuckets(t *testing.T) {
+	rm, _, cancel := newTestRollupManager(t)
+	defer cancel()
+
+	_, err := rm.GetChartRollup(rm.ctx, "ns1", "events", fftypes.Now(), 0, 0, nil)
+	assert.Regexp(t, "FF10298", err)
+}
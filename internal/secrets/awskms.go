@@ -0,0 +1,106 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+// awsKMSResolver decrypts secrets that were encrypted with an AWS KMS key, with references in the form
+// "awskms://alias/firefly#AQICAHh..." where the fragment is the base64 encoded ciphertext. The key is
+// optional for ciphertext encrypted with a symmetric key, as KMS records the key in the ciphertext.
+type awsKMSResolver struct {
+	client *resty.Client
+}
+
+type awsKMSDecryptRequest struct {
+	CiphertextBlob string `json:"CiphertextBlob"`
+	KeyID          string `json:"KeyId,omitempty"`
+}
+
+type awsKMSDecryptResponse struct {
+	Plaintext string `json:"Plaintext"`
+}
+
+func (k *awsKMSResolver) Name() string {
+	return "awskms"
+}
+
+func (k *awsKMSResolver) Init(ctx context.Context, prefix config.Prefix) (err error) {
+	signer := &restclient.SigV4Signer{
+		Region:          prefix.GetString(AWSKMSConfRegion),
+		Service:         "kms",
+		AccessKeyID:     prefix.GetString(AWSKMSConfAccessKeyID),
+		SecretAccessKey: prefix.GetString(AWSKMSConfSecretAccessKey),
+		Now:             time.Now,
+	}
+	if signer.AccessKeyID == "" || signer.SecretAccessKey == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(AWSKMSConfAccessKeyID), "awskms")
+	}
+	if k.client, err = restclient.New(ctx, prefix); err != nil {
+		return err
+	}
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		k.client.SetBaseURL(fmt.Sprintf("https://kms.%s.amazonaws.com", signer.Region))
+	}
+	k.client.SetPreRequestHook(signer.SignRequest)
+	return nil
+}
+
+func (k *awsKMSResolver) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	if ref.Fragment == "" {
+		return "", i18n.NewError(ctx, i18n.MsgAWSKMSCiphertextMissing, ref.String())
+	}
+	// The payload is marshalled here, as its hash is needed to sign the request
+	payload, _ := json.Marshal(&awsKMSDecryptRequest{
+		CiphertextBlob: ref.Fragment,
+		KeyID:          strings.TrimSuffix(ref.Host+ref.Path, "/"),
+	})
+	hash := sha256.Sum256(payload)
+	// KMS responds with a content type of application/x-amz-json-1.1, which must be parsed as JSON
+	var decrypted awsKMSDecryptResponse
+	res, err := k.client.R().
+		SetContext(ctx).
+		SetHeader(restclient.AmzContentSHA256Header, hex.EncodeToString(hash[:])).
+		SetHeader("Content-Type", "application/x-amz-json-1.1").
+		SetHeader("X-Amz-Target", "TrentService.Decrypt").
+		SetBody(payload).
+		SetResult(&decrypted).
+		ForceContentType("application/json").
+		Post("/")
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(ctx, res, err, i18n.MsgAWSKMSRESTErr)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(decrypted.Plaintext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,60 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/restclient"
+)
+
+const (
+	// SecretsConfEnabled is the list of resolvers that can be used in references within the config file
+	SecretsConfEnabled = "enabled"
+	// VaultConfKVVersion is the version of the Vault KV secrets engine mounted at the referenced path (1 or 2)
+	VaultConfKVVersion = "kvVersion"
+	// VaultConfToken is the Vault token used to authenticate (supports file:// references)
+	VaultConfToken = "token"
+	// VaultConfNamespace is an optional Vault Enterprise namespace
+	VaultConfNamespace = "namespace"
+	// AWSKMSConfRegion is the AWS region of the KMS keys, used to sign requests and to build the endpoint URL if no URL is configured
+	AWSKMSConfRegion = "region"
+	// AWSKMSConfAccessKeyID is the AWS access key ID used to sign requests
+	AWSKMSConfAccessKeyID = "accessKeyID"
+	// AWSKMSConfSecretAccessKey is the AWS secret access key used to sign requests (supports file:// and vault:// references)
+	AWSKMSConfSecretAccessKey = "secretAccessKey"
+)
+
+const (
+	defaultAWSKMSRegion = "us-east-1"
+)
+
+func (f *fileResolver) InitPrefix(prefix config.Prefix) {
+}
+
+func (v *vaultResolver) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(VaultConfKVVersion, 2)
+	prefix.AddKnownKey(VaultConfToken)
+	prefix.AddKnownKey(VaultConfNamespace)
+}
+
+func (k *awsKMSResolver) InitPrefix(prefix config.Prefix) {
+	restclient.InitPrefix(prefix)
+	prefix.AddKnownKey(AWSKMSConfRegion, defaultAWSKMSRegion)
+	prefix.AddKnownKey(AWSKMSConfAccessKeyID)
+	prefix.AddKnownKey(AWSKMSConfSecretAccessKey)
+}
@@ -0,0 +1,46 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+)
+
+// fileResolver reads secrets from the filesystem, such as those mounted by Kubernetes or Docker,
+// with references in the form "file:///run/secrets/db_password"
+type fileResolver struct{}
+
+func (f *fileResolver) Name() string {
+	return "file"
+}
+
+func (f *fileResolver) Init(ctx context.Context, prefix config.Prefix) error {
+	return nil
+}
+
+func (f *fileResolver) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	b, err := ioutil.ReadFile(ref.Host + ref.Path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,126 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Resolver is implemented for each URL scheme that can be used to reference a secret
+// in place of a plaintext value in the configuration, such as "vault://secret/firefly#dbpassword"
+type Resolver interface {
+	fftypes.Named
+
+	// InitPrefix initializes the set of configuration options that are valid, with defaults. Called on all resolvers.
+	InitPrefix(prefix config.Prefix)
+
+	// Init initializes the resolver, with configuration. Only called if the resolver is enabled.
+	Init(ctx context.Context, prefix config.Prefix) error
+
+	// Resolve returns the secret value for a reference using the scheme of this resolver
+	Resolve(ctx context.Context, ref *url.URL) (string, error)
+}
+
+var secretsConfig = config.NewPluginConfig("secrets")
+
+// initialized are the resolvers initialized by ResolveConfig, for values applied to the configuration after startup
+var initialized map[string]Resolver
+
+var resolvers = []Resolver{
+	&fileResolver{},
+	&vaultResolver{},
+	&awsKMSResolver{},
+}
+
+func InitConfig() {
+	secretsConfig.AddKnownKey(SecretsConfEnabled, []string{"file"})
+	for _, r := range resolvers {
+		r.InitPrefix(secretsConfig.SubPrefix(r.Name()))
+	}
+}
+
+// ResolveConfig replaces every reference in the configuration that uses the scheme of an
+// enabled resolver, with the secret value. Must be called after the config is read, and
+// before any plugins are initialized.
+func ResolveConfig(ctx context.Context) error {
+	enabled := make(map[string]Resolver)
+	for _, name := range secretsConfig.GetStringSlice(SecretsConfEnabled) {
+		var resolver Resolver
+		for _, r := range resolvers {
+			if r.Name() == name {
+				resolver = r
+			}
+		}
+		if resolver == nil {
+			return i18n.NewError(ctx, i18n.MsgUnknownSecretsResolver, name)
+		}
+		enabled[name] = resolver
+	}
+
+	// The configuration of each resolver can itself reference secrets resolved by previously
+	// initialized resolvers (such as a Vault token read from a file), so we initialize them in turn
+	initialized = make(map[string]Resolver)
+	replaceFn := func(key, value string) (string, bool, error) {
+		return resolveValue(ctx, initialized, key, value)
+	}
+	for _, name := range secretsConfig.GetStringSlice(SecretsConfEnabled) {
+		if err := config.ReplaceStringValues(replaceFn); err != nil {
+			return err
+		}
+		r := enabled[name]
+		if err := r.Init(ctx, secretsConfig.SubPrefix(name)); err != nil {
+			return err
+		}
+		initialized[name] = r
+	}
+	return config.ReplaceStringValues(replaceFn)
+}
+
+// Resolve returns the secret value for a configuration value applied after startup, if it is a reference
+// using the scheme of a resolver initialized by ResolveConfig. It has the signature of the function
+// passed to config.ReplaceStringValues, once bound to a context.
+func Resolve(ctx context.Context, key, value string) (string, bool, error) {
+	return resolveValue(ctx, initialized, key, value)
+}
+
+func resolveValue(ctx context.Context, initialized map[string]Resolver, key, value string) (string, bool, error) {
+	delim := strings.Index(value, "://")
+	if delim <= 0 {
+		return value, false, nil
+	}
+	resolver, ok := initialized[value[0:delim]]
+	if !ok {
+		return value, false, nil
+	}
+	ref, err := url.Parse(value)
+	if err != nil {
+		return "", false, i18n.WrapError(ctx, err, i18n.MsgInvalidSecretReference, key)
+	}
+	secret, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", false, i18n.WrapError(ctx, err, i18n.MsgSecretResolveFailed, key, resolver.Name())
+	}
+	log.L(ctx).Infof("Resolved secret for config key '%s' using '%s'", key, resolver.Name())
+	return secret, true, nil
+}
@@ -0,0 +1,115 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetConf() {
+	config.Reset()
+	InitConfig()
+}
+
+func writeSecretFile(t *testing.T, name, value string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	err := ioutil.WriteFile(fileName, []byte(value), 0600)
+	assert.NoError(t, err)
+	return fileName
+}
+
+func TestResolveFileReferences(t *testing.T) {
+	resetConf()
+	orgKeyFile := writeSecretFile(t, "orgkey", "0x12345\n")
+	pwFile := writeSecretFile(t, "password", "pa$$word")
+	config.Set(config.OrgKey, "file://"+orgKeyFile)
+	config.Set(config.OrgName, "org1")
+	config.Set(config.TokensList, []interface{}{
+		map[interface{}]interface{}{
+			"name": "erc1155",
+			"auth": map[string]interface{}{
+				"password": "file://" + pwFile,
+			},
+		},
+	})
+
+	err := ResolveConfig(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "0x12345", config.GetString(config.OrgKey))
+	assert.Equal(t, "org1", config.GetString(config.OrgName))
+	tokens, _ := fftypes.ToJSONObjectArray(config.Get(config.TokensList))
+	assert.Equal(t, "pa$$word", tokens[0].GetObject("auth").GetString("password"))
+	assert.Equal(t, "erc1155", tokens[0].GetString("name"))
+
+	// The admin view of the config must only show the references
+	conf := config.GetConfig()
+	assert.Equal(t, "file://"+orgKeyFile, conf.GetObject("org").GetString("key"))
+}
+
+func TestResolveAfterStartup(t *testing.T) {
+	resetConf()
+	err := ResolveConfig(context.Background())
+	assert.NoError(t, err)
+
+	pwFile := writeSecretFile(t, "password", "pa$$word")
+	secret, replaced, err := Resolve(context.Background(), "events.webhooks.password", "file://"+pwFile)
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, "pa$$word", secret)
+
+	value, replaced, err := Resolve(context.Background(), "events.webhooks.password", "vault://secret/firefly#password")
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+	assert.Equal(t, "vault://secret/firefly#password", value)
+}
+
+func TestResolveUnknownResolver(t *testing.T) {
+	resetConf()
+	secretsConfig.Set(SecretsConfEnabled, []string{"gcpkms"})
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10305", err)
+}
+
+func TestResolveFileMissing(t *testing.T) {
+	resetConf()
+	config.Set(config.OrgKey, "file://"+filepath.Join(t.TempDir(), "missing"))
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10307.*org.key", err)
+}
+
+func TestResolveBadReference(t *testing.T) {
+	resetConf()
+	config.Set(config.OrgKey, "file://%zz")
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10306", err)
+}
+
+func TestResolveNotEnabledIgnored(t *testing.T) {
+	resetConf()
+	config.Set(config.OrgKey, "vault://secret/firefly#key")
+	err := ResolveConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "vault://secret/firefly#key", config.GetString(config.OrgKey))
+}
@@ -0,0 +1,89 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// vaultResolver reads secrets from a HashiCorp Vault KV secrets engine, with references
+// in the form "vault://secret/firefly/database#password" where the fragment is the key
+// within the secret
+type vaultResolver struct {
+	client    *resty.Client
+	kvVersion int
+}
+
+type vaultSecretResponse struct {
+	Data fftypes.JSONObject `json:"data"`
+}
+
+func (v *vaultResolver) Name() string {
+	return "vault"
+}
+
+func (v *vaultResolver) Init(ctx context.Context, prefix config.Prefix) error {
+	if prefix.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(restclient.HTTPConfigURL), "vault")
+	}
+	if prefix.GetString(VaultConfToken) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, prefix.Resolve(VaultConfToken), "vault")
+	}
+	v.kvVersion = prefix.GetInt(VaultConfKVVersion)
+	client, err := restclient.New(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	v.client = client
+	v.client.SetHeader("X-Vault-Token", prefix.GetString(VaultConfToken))
+	if namespace := prefix.GetString(VaultConfNamespace); namespace != "" {
+		v.client.SetHeader("X-Vault-Namespace", namespace)
+	}
+	return nil
+}
+
+func (v *vaultResolver) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	if ref.Fragment == "" {
+		return "", i18n.NewError(ctx, i18n.MsgVaultSecretKeyMissing, ref.String())
+	}
+	var secret vaultSecretResponse
+	res, err := v.client.R().
+		SetContext(ctx).
+		SetResult(&secret).
+		Get(fmt.Sprintf("/v1/%s%s", ref.Host, ref.Path))
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(ctx, res, err, i18n.MsgVaultRESTErr)
+	}
+	values := secret.Data
+	if v.kvVersion == 2 {
+		// KV version 2 nests the secret values one level further down, alongside the metadata
+		values = values.GetObject("data")
+	}
+	value, ok := values[ref.Fragment].(string)
+	if !ok {
+		return "", i18n.NewError(ctx, i18n.MsgVaultSecretKeyMissing, ref.String())
+	}
+	return value, nil
+}
@@ -0,0 +1,157 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
+	"github.com/hyperledger/firefly/internal/restclient"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetVaultConf(t *testing.T) (config.Prefix, func()) {
+	resetConf()
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	secretsConfig.Set(SecretsConfEnabled, []string{"file", "vault"})
+	vaultConf := secretsConfig.SubPrefix("vault")
+	vaultConf.Set(restclient.HTTPConfigURL, "http://vault:8200")
+	vaultConf.Set(restclient.HTTPCustomClient, mockedClient)
+	vaultConf.Set(VaultConfToken, "file://"+writeSecretFile(t, "token", "s.vaulttoken\n"))
+	return vaultConf, httpmock.DeactivateAndReset
+}
+
+func TestVaultResolveKV2(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(VaultConfNamespace, "ns1")
+	config.Set(config.OrgKey, "vault://secret/data/firefly#orgkey")
+
+	httpmock.RegisterResponder("GET", "http://vault:8200/v1/secret/data/firefly",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "s.vaulttoken", req.Header.Get("X-Vault-Token"))
+			assert.Equal(t, "ns1", req.Header.Get("X-Vault-Namespace"))
+			return httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+				"data": map[string]interface{}{
+					"data": map[string]interface{}{
+						"orgkey": "0x12345",
+					},
+				},
+			})(req)
+		})
+
+	err := ResolveConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", config.GetString(config.OrgKey))
+}
+
+func TestVaultResolveKV1(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(VaultConfKVVersion, 1)
+	config.Set(config.OrgKey, "vault://kv/firefly#orgkey")
+
+	httpmock.RegisterResponder("GET", "http://vault:8200/v1/kv/firefly",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"data": map[string]interface{}{
+				"orgkey": "0x12345",
+			},
+		}))
+
+	err := ResolveConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", config.GetString(config.OrgKey))
+}
+
+func TestVaultResolveMissingKey(t *testing.T) {
+	_, done := resetVaultConf(t)
+	defer done()
+	config.Set(config.OrgKey, "vault://secret/data/firefly#orgkey")
+
+	httpmock.RegisterResponder("GET", "http://vault:8200/v1/secret/data/firefly",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{},
+			},
+		}))
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10307.*FF10309", err)
+}
+
+func TestVaultResolveNoFragment(t *testing.T) {
+	_, done := resetVaultConf(t)
+	defer done()
+	config.Set(config.OrgKey, "vault://secret/data/firefly")
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10307.*FF10309", err)
+}
+
+func TestVaultResolveError(t *testing.T) {
+	_, done := resetVaultConf(t)
+	defer done()
+	config.Set(config.OrgKey, "vault://secret/data/firefly#orgkey")
+
+	httpmock.RegisterResponder("GET", "http://vault:8200/v1/secret/data/firefly",
+		httpmock.NewStringResponder(403, `{"errors":["permission denied"]}`))
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10307.*FF10308", err)
+}
+
+func TestVaultInitMissingURL(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(restclient.HTTPConfigURL, "")
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestVaultInitMissingToken(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(VaultConfToken, "")
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10138.*token", err)
+}
+
+func TestVaultInitTokenFileMissing(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(VaultConfToken, "file:///missing/token")
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10307.*secrets.vault.token", err)
+}
+
+func TestVaultInitBadTLS(t *testing.T) {
+	vaultConf, done := resetVaultConf(t)
+	defer done()
+	vaultConf.Set(tlsconfig.TLSConfigEnabled, true)
+	vaultConf.Set(tlsconfig.TLSConfigCAFile, "/not/a/file")
+
+	err := ResolveConfig(context.Background())
+	assert.Regexp(t, "FF10105", err)
+}
@@ -21,13 +21,17 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/data"
 	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/metrics"
 	"github.com/hyperledger/firefly/internal/sysmessaging"
+	"github.com/hyperledger/firefly/internal/tracing"
 	"github.com/hyperledger/firefly/internal/txcommon"
 	"github.com/hyperledger/firefly/pkg/database"
 	"github.com/hyperledger/firefly/pkg/fftypes"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Bridge translates synchronous (HTTP API) calls, into asynchronously sending a
@@ -47,10 +51,29 @@ type Bridge interface {
 	WaitForTokenPool(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenPool, error)
 	// WaitForTokenTransfer waits for a token transfer with the supplied ID
 	WaitForTokenTransfer(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenTransfer, error)
+	// WaitForTokenApproval waits for a token approval with the supplied ID
+	WaitForTokenApproval(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenApproval, error)
+	// WaitForIdentity waits for the organization identity with the supplied ID to be confirmed
+	WaitForIdentity(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Identity, error)
+	// WaitForInvokeOperation waits for the contract invocation operation with the supplied ID to succeed or fail
+	WaitForInvokeOperation(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Operation, error)
 }
 
 type RequestSender func(ctx context.Context) error
 
+type ctxTimeoutKey struct{}
+
+// WithTimeout returns a context that bounds how long any "WaitFor*" call made with it blocks for a
+// response, independently of (but never beyond) the deadline of the supplied context.
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, ctxTimeoutKey{}, timeout)
+}
+
+func getTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(ctxTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
 type requestType int
 
 const (
@@ -58,8 +81,21 @@ const (
 	messageReply
 	tokenPoolConfirm
 	tokenTransferConfirm
+	identityConfirm
+	invokeOperation
+	tokenApproveConfirm
 )
 
+var requestTypeNames = map[requestType]string{
+	messageConfirm:       "message_confirm",
+	messageReply:         "message_reply",
+	tokenPoolConfirm:     "token_pool_confirm",
+	tokenTransferConfirm: "token_transfer_confirm",
+	identityConfirm:      "identity_confirm",
+	invokeOperation:      "invoke_operation",
+	tokenApproveConfirm:  "token_approve_confirm",
+}
+
 type inflightRequest struct {
 	id        *fftypes.UUID
 	startTime time.Time
@@ -76,20 +112,26 @@ type inflightResponse struct {
 type inflightRequestMap map[string]map[fftypes.UUID]*inflightRequest
 
 type syncAsyncBridge struct {
-	ctx         context.Context
-	database    database.Plugin
-	data        data.Manager
-	sysevents   sysmessaging.SystemEvents
-	inflightMux sync.Mutex
-	inflight    inflightRequestMap
+	ctx            context.Context
+	database       database.Plugin
+	data           data.Manager
+	sysevents      sysmessaging.SystemEvents
+	inflightMux    sync.Mutex
+	inflight       inflightRequestMap
+	metricsEnabled bool
 }
 
 func NewSyncAsyncBridge(ctx context.Context, di database.Plugin, dm data.Manager) Bridge {
 	sa := &syncAsyncBridge{
-		ctx:      log.WithLogField(ctx, "role", "sync-async-bridge"),
+		ctx:      log.WithComponent(log.WithLogField(ctx, "role", "sync-async-bridge"), "syncasync"),
 		database: di,
 		data:     dm,
 		inflight: make(inflightRequestMap),
+
+		metricsEnabled: config.GetBool(config.MetricsEnabled),
+	}
+	if sa.metricsEnabled {
+		metrics.Registry()
 	}
 	return sa
 }
@@ -145,6 +187,17 @@ func (sa *syncAsyncBridge) removeInFlight(ns string, id *fftypes.UUID) {
 	}
 }
 
+// recordInflight updates the count of requests in flight, and records the duration of each request as it completes
+func (sa *syncAsyncBridge) recordInflight(ns string, inflight *inflightRequest, delta float64) {
+	if sa.metricsEnabled {
+		reqType := requestTypeNames[inflight.reqType]
+		metrics.SyncAsyncInflightGauge.WithLabelValues(ns, reqType).Add(delta)
+		if delta < 0 {
+			metrics.SyncAsyncDurationHistogram.WithLabelValues(ns, reqType).Observe(time.Since(inflight.startTime).Seconds())
+		}
+	}
+}
+
 func (inflight *inflightRequest) msInflight() float64 {
 	dur := time.Since(inflight.startTime)
 	return float64(dur) / float64(time.Millisecond)
@@ -183,6 +236,17 @@ func (sa *syncAsyncBridge) getTransferFromEvent(event *fftypes.EventDelivery) (t
 	return transfer, nil
 }
 
+func (sa *syncAsyncBridge) getApprovalFromEvent(event *fftypes.EventDelivery) (approval *fftypes.TokenApproval, err error) {
+	if approval, err = sa.database.GetTokenApproval(sa.ctx, event.Reference); err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		// This should not happen (but we need to move on)
+		log.L(sa.ctx).Errorf("Unable to resolve token approval '%s' for %s event '%s'", event.Reference, event.Type, event.ID)
+	}
+	return approval, nil
+}
+
 func (sa *syncAsyncBridge) getOperationFromEvent(event *fftypes.EventDelivery) (op *fftypes.Operation, err error) {
 	if op, err = sa.database.GetOperationByID(sa.ctx, event.Reference); err != nil {
 		return nil, err
@@ -194,6 +258,17 @@ func (sa *syncAsyncBridge) getOperationFromEvent(event *fftypes.EventDelivery) (
 	return op, nil
 }
 
+func (sa *syncAsyncBridge) getOrgFromEvent(event *fftypes.EventDelivery) (org *fftypes.Organization, err error) {
+	if org, err = sa.database.GetOrganizationByID(sa.ctx, event.Reference); err != nil {
+		return nil, err
+	}
+	if org == nil {
+		// This should not happen (but we need to move on)
+		log.L(sa.ctx).Errorf("Unable to resolve organization '%s' for %s event '%s'", event.Reference, event.Type, event.ID)
+	}
+	return org, nil
+}
+
 func (sa *syncAsyncBridge) eventCallback(event *fftypes.EventDelivery) error {
 	sa.inflightMux.Lock()
 	defer sa.inflightMux.Unlock()
@@ -281,6 +356,73 @@ func (sa *syncAsyncBridge) eventCallback(event *fftypes.EventDelivery) error {
 		if inflight != nil {
 			go sa.resolveFailedTokenTransfer(inflight, transfer.LocalID)
 		}
+
+	case fftypes.EventTypeApprovalConfirmed:
+		approval, err := sa.getApprovalFromEvent(event)
+		if err != nil || approval == nil {
+			return err
+		}
+		// See if this is a confirmation of an inflight token approval
+		inflight := sa.getInFlight(event.Namespace, tokenApproveConfirm, approval.LocalID)
+		if inflight != nil {
+			go sa.resolveConfirmedTokenApproval(inflight, approval)
+		}
+
+	case fftypes.EventTypeApprovalOpFailed:
+		op, err := sa.getOperationFromEvent(event)
+		if err != nil || op == nil {
+			return err
+		}
+		// Extract the LocalID of the approval
+		var approval fftypes.TokenApproval
+		if err := txcommon.RetrieveTokenApprovalInputs(sa.ctx, op, &approval); err != nil {
+			log.L(sa.ctx).Warnf("Failed to extract token approval inputs for operation '%s': %s", op.ID, err)
+		}
+		// See if this is a failure of an inflight token approval operation
+		inflight := sa.getInFlight(event.Namespace, tokenApproveConfirm, approval.LocalID)
+		if inflight != nil {
+			go sa.resolveFailedTokenApproval(inflight, approval.LocalID)
+		}
+
+	case fftypes.EventTypeIdentityConfirmed:
+		org, err := sa.getOrgFromEvent(event)
+		if err != nil || org == nil {
+			return err
+		}
+		// See if this is a confirmation of an inflight identity
+		inflight := sa.getInFlight(event.Namespace, identityConfirm, org.ID)
+		if inflight != nil {
+			go sa.resolveConfirmedIdentity(inflight, org)
+		}
+
+	case fftypes.EventTypeBlockchainInvokeOpSucceeded:
+		op, err := sa.getOperationFromEvent(event)
+		if err != nil || op == nil {
+			return err
+		}
+		// See if this is the success of an inflight contract invocation
+		inflight := sa.getInFlight(event.Namespace, invokeOperation, op.ID)
+		if inflight != nil {
+			go sa.resolveSucceededOperation(inflight, op)
+		}
+
+	case fftypes.EventTypeBlockchainInvokeOpFailed:
+		op, err := sa.getOperationFromEvent(event)
+		if err != nil || op == nil {
+			return err
+		}
+		// See if this is the failure of an inflight contract invocation
+		inflight := sa.getInFlight(event.Namespace, invokeOperation, op.ID)
+		if inflight != nil {
+			go sa.resolveFailedOperation(inflight, op)
+		}
+
+	case fftypes.EventTypeIdentityRejected:
+		// Rejected identities are not stored, so the reference is all we have
+		inflight := sa.getInFlight(event.Namespace, identityConfirm, event.Reference)
+		if inflight != nil {
+			go sa.resolveRejectedIdentity(inflight, event.Reference)
+		}
 	}
 
 	return nil
@@ -332,15 +474,64 @@ func (sa *syncAsyncBridge) resolveFailedTokenTransfer(inflight *inflightRequest,
 	inflight.response <- inflightResponse{err: err}
 }
 
-func (sa *syncAsyncBridge) sendAndWait(ctx context.Context, ns string, id *fftypes.UUID, reqType requestType, send RequestSender) (interface{}, error) {
+func (sa *syncAsyncBridge) resolveConfirmedTokenApproval(inflight *inflightRequest, approval *fftypes.TokenApproval) {
+	log.L(sa.ctx).Debugf("Resolving token approval confirmation request '%s' with ID '%s'", inflight.id, approval.LocalID)
+	inflight.response <- inflightResponse{id: approval.LocalID, data: approval}
+}
+
+func (sa *syncAsyncBridge) resolveFailedTokenApproval(inflight *inflightRequest, approvalID *fftypes.UUID) {
+	err := i18n.NewError(sa.ctx, i18n.MsgTokenApprovalFailed, approvalID)
+	log.L(sa.ctx).Debugf("Resolving token approval confirmation request '%s' with error '%s'", inflight.id, err)
+	inflight.response <- inflightResponse{err: err}
+}
+
+func (sa *syncAsyncBridge) resolveConfirmedIdentity(inflight *inflightRequest, org *fftypes.Organization) {
+	log.L(sa.ctx).Debugf("Resolving identity confirmation request '%s' with ID '%s'", inflight.id, org.ID)
+	identity := &fftypes.Identity{
+		Author: org.GetDID(),
+		Key:    org.Identity,
+	}
+	inflight.response <- inflightResponse{id: org.ID, data: identity}
+}
+
+func (sa *syncAsyncBridge) resolveRejectedIdentity(inflight *inflightRequest, orgID *fftypes.UUID) {
+	err := i18n.NewError(sa.ctx, i18n.MsgIdentityRejected, orgID)
+	log.L(sa.ctx).Errorf("Resolving identity confirmation request '%s' with error '%s'", inflight.id, err)
+	inflight.response <- inflightResponse{err: err}
+}
+
+func (sa *syncAsyncBridge) resolveSucceededOperation(inflight *inflightRequest, op *fftypes.Operation) {
+	log.L(sa.ctx).Debugf("Resolving operation request '%s' with ID '%s'", inflight.id, op.ID)
+	inflight.response <- inflightResponse{id: op.ID, data: op}
+}
+
+func (sa *syncAsyncBridge) resolveFailedOperation(inflight *inflightRequest, op *fftypes.Operation) {
+	err := i18n.NewError(sa.ctx, i18n.MsgContractInvokeFailed, op.ID, op.Error)
+	log.L(sa.ctx).Errorf("Resolving operation request '%s' with error '%s'", inflight.id, err)
+	inflight.response <- inflightResponse{err: err}
+}
+
+func (sa *syncAsyncBridge) sendAndWait(ctx context.Context, ns string, id *fftypes.UUID, reqType requestType, send RequestSender) (data interface{}, err error) {
+	if timeout := getTimeout(ctx); timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "syncasync."+requestTypeNames[reqType], attribute.String("ff.namespace", ns))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	inflight, err := sa.addInFlight(ns, id, reqType)
 	if err != nil {
 		return nil, err
 	}
 	log.L(sa.ctx).Infof("Inflight request '%s' added", inflight.id)
+	span.SetAttributes(attribute.String("ff.request.id", inflight.id.String()))
+	sa.recordInflight(ns, inflight, 1)
 	var replyID *fftypes.UUID
 	defer func() {
 		sa.removeInFlight(ns, inflight.id)
+		sa.recordInflight(ns, inflight, -1)
 		if replyID != nil {
 			log.L(sa.ctx).Infof("Inflight request '%s' resolved with reply '%s' after %.2fms", inflight.id, replyID, inflight.msInflight())
 		} else {
@@ -393,3 +584,27 @@ func (sa *syncAsyncBridge) WaitForTokenTransfer(ctx context.Context, ns string,
 	}
 	return reply.(*fftypes.TokenTransfer), err
 }
+
+func (sa *syncAsyncBridge) WaitForTokenApproval(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.TokenApproval, error) {
+	reply, err := sa.sendAndWait(ctx, ns, id, tokenApproveConfirm, send)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*fftypes.TokenApproval), err
+}
+
+func (sa *syncAsyncBridge) WaitForIdentity(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Identity, error) {
+	reply, err := sa.sendAndWait(ctx, ns, id, identityConfirm, send)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*fftypes.Identity), err
+}
+
+func (sa *syncAsyncBridge) WaitForInvokeOperation(ctx context.Context, ns string, id *fftypes.UUID, send RequestSender) (*fftypes.Operation, error) {
+	reply, err := sa.sendAndWait(ctx, ns, id, invokeOperation, send)
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*fftypes.Operation), err
+}
@@ -19,6 +19,8 @@ package fftokens
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly/internal/config"
@@ -54,6 +56,9 @@ const (
 	messageTokenMint     msgType = "token-mint"
 	messageTokenBurn     msgType = "token-burn"
 	messageTokenTransfer msgType = "token-transfer"
+	messageTokenApproval msgType = "token-approval"
+
+	messageTokenPoolGovernance msgType = "token-pool-governance"
 )
 
 type tokenData struct {
@@ -106,12 +111,21 @@ type transferTokens struct {
 	Data       string `json:"data,omitempty"`
 }
 
+type tokenApproval struct {
+	PoolID    string `json:"poolId"`
+	Signer    string `json:"signer"`
+	Operator  string `json:"operator"`
+	Approved  bool   `json:"approved"`
+	RequestID string `json:"requestId,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
 func (ft *FFTokens) Name() string {
 	return "fftokens"
 }
 
 func (ft *FFTokens) Init(ctx context.Context, name string, prefix config.Prefix, callbacks tokens.Callbacks) (err error) {
-	ft.ctx = log.WithLogField(ctx, "proto", "fftokens")
+	ft.ctx = log.WithComponent(log.WithLogField(ctx, "proto", "fftokens"), "tokens")
 	ft.callbacks = callbacks
 	ft.configuredName = name
 
@@ -119,11 +133,15 @@ func (ft *FFTokens) Init(ctx context.Context, name string, prefix config.Prefix,
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "tokens.fftokens")
 	}
 
-	ft.client = restclient.New(ft.ctx, prefix)
+	wsConfig, err := wsconfig.GenerateConfigFromPrefix(ft.ctx, prefix)
+	if err == nil {
+		ft.client, err = restclient.New(ft.ctx, prefix)
+	}
+	if err != nil {
+		return err
+	}
 	ft.capabilities = &tokens.Capabilities{}
 
-	wsConfig := wsconfig.GenerateConfigFromPrefix(prefix)
-
 	if wsConfig.WSKeyPath == "" {
 		wsConfig.WSKeyPath = "/api/ws"
 	}
@@ -207,6 +225,18 @@ func (ft *FFTokens) handleTokenPoolCreate(ctx context.Context, data fftypes.JSON
 	return ft.callbacks.TokenPoolCreated(ft, pool, txHash, tx)
 }
 
+// poolSequence builds a sortable position for a transfer, from the blockchain location the connector supplied
+// in the transaction info - or empty if the connector did not supply a block number
+func poolSequence(tx fftypes.JSONObject) string {
+	blockNumber, err := strconv.ParseInt(tx.GetString("blockNumber"), 0, 64)
+	if err != nil {
+		return ""
+	}
+	txIndex, _ := strconv.ParseInt(tx.GetString("transactionIndex"), 0, 64)
+	logIndex, _ := strconv.ParseInt(tx.GetString("logIndex"), 0, 64)
+	return fmt.Sprintf("%.12d/%.6d/%.6d", blockNumber, txIndex, logIndex)
+}
+
 func (ft *FFTokens) handleTokenTransfer(ctx context.Context, t fftypes.TokenTransferType, data fftypes.JSONObject) (err error) {
 	protocolID := data.GetString("id")
 	poolProtocolID := data.GetString("poolId")
@@ -264,6 +294,7 @@ func (ft *FFTokens) handleTokenTransfer(ctx context.Context, t fftypes.TokenTran
 			ID:   transferData.TX,
 			Type: fftypes.TransactionTypeTokenTransfer,
 		},
+		PoolSequence: poolSequence(tx),
 	}
 
 	_, ok := transfer.Amount.Int().SetString(value, 10)
@@ -276,6 +307,82 @@ func (ft *FFTokens) handleTokenTransfer(ctx context.Context, t fftypes.TokenTran
 	return ft.callbacks.TokensTransferred(ft, poolProtocolID, transfer, txHash, tx)
 }
 
+func (ft *FFTokens) handleTokenApproval(ctx context.Context, data fftypes.JSONObject) (err error) {
+	protocolID := data.GetString("id")
+	poolProtocolID := data.GetString("poolId")
+	signerAddress := data.GetString("signer")
+	operatorAddress := data.GetString("operator")
+	approved := data.GetBool("approved")
+	tx := data.GetObject("transaction")
+	txHash := tx.GetString("transactionHash")
+
+	if protocolID == "" ||
+		poolProtocolID == "" ||
+		signerAddress == "" ||
+		operatorAddress == "" ||
+		txHash == "" {
+		log.L(ctx).Errorf("Approval event is not valid - missing data: %+v", data)
+		return nil // move on
+	}
+
+	// The "data" argument is optional, so it's important not to fail if it's missing or malformed.
+	approvalDataString := data.GetString("data")
+	var approvalData tokenData
+	if err = json.Unmarshal([]byte(approvalDataString), &approvalData); err != nil {
+		log.L(ctx).Infof("Approval event data could not be parsed - continuing anyway (%s): %+v", err, data)
+		approvalData = tokenData{}
+	}
+
+	approval := &fftypes.TokenApproval{
+		Connector:  ft.configuredName,
+		Key:        signerAddress,
+		Operator:   operatorAddress,
+		Approved:   approved,
+		ProtocolID: protocolID,
+		TX: fftypes.TransactionRef{
+			ID:   approvalData.TX,
+			Type: fftypes.TransactionTypeTokenApproval,
+		},
+	}
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return ft.callbacks.TokensApproved(ft, poolProtocolID, approval, txHash, tx)
+}
+
+func validGovernanceAction(action fftypes.TokenPoolGovernanceAction) bool {
+	for _, v := range fftypes.FFEnumValues("tokenpoolgovernanceaction") {
+		if v == action.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (ft *FFTokens) handleTokenPoolGovernance(ctx context.Context, data fftypes.JSONObject) (err error) {
+	poolProtocolID := data.GetString("poolId")
+	action := fftypes.FFEnum(data.GetString("action")).Lower()
+	signerAddress := data.GetString("signer")
+	tx := data.GetObject("transaction")
+	txHash := tx.GetString("transactionHash")
+
+	if poolProtocolID == "" ||
+		txHash == "" ||
+		!validGovernanceAction(action) {
+		log.L(ctx).Errorf("Pool governance event is not valid - missing data or unknown action: %+v", data)
+		return nil // move on
+	}
+
+	change := &fftypes.TokenPoolGovernance{
+		Action:    action,
+		Connector: ft.configuredName,
+		Key:       signerAddress,
+		Details:   data.GetObject("details"),
+	}
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return ft.callbacks.TokenPoolGovernance(ft, poolProtocolID, change, txHash, tx)
+}
+
 func (ft *FFTokens) eventLoop() {
 	defer ft.wsconn.Close()
 	l := log.L(ft.ctx).WithField("role", "event-loop")
@@ -309,6 +416,10 @@ func (ft *FFTokens) eventLoop() {
 				err = ft.handleTokenTransfer(ctx, fftypes.TokenTransferTypeBurn, msg.Data)
 			case messageTokenTransfer:
 				err = ft.handleTokenTransfer(ctx, fftypes.TokenTransferTypeTransfer, msg.Data)
+			case messageTokenApproval:
+				err = ft.handleTokenApproval(ctx, msg.Data)
+			case messageTokenPoolGovernance:
+				err = ft.handleTokenPoolGovernance(ctx, msg.Data)
 			default:
 				l.Errorf("Message unexpected: %s", msg.Event)
 			}
@@ -433,3 +544,23 @@ func (ft *FFTokens) TransferTokens(ctx context.Context, operationID *fftypes.UUI
 	}
 	return nil
 }
+
+func (ft *FFTokens) TokensApproval(ctx context.Context, operationID *fftypes.UUID, poolProtocolID string, approval *fftypes.TokenApproval) error {
+	data, _ := json.Marshal(tokenData{
+		TX: approval.TX.ID,
+	})
+	res, err := ft.client.R().SetContext(ctx).
+		SetBody(&tokenApproval{
+			PoolID:    poolProtocolID,
+			Signer:    approval.Key,
+			Operator:  approval.Operator,
+			Approved:  approval.Approved,
+			RequestID: operationID.String(),
+			Data:      string(data),
+		}).
+		Post("/api/v1/approval")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgTokensRESTErr)
+	}
+	return nil
+}
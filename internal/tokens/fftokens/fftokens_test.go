@@ -27,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/config/tlsconfig"
 	"github.com/hyperledger/firefly/internal/restclient"
 	"github.com/hyperledger/firefly/mocks/tokenmocks"
 	"github.com/hyperledger/firefly/mocks/wsmocks"
@@ -83,6 +84,20 @@ func TestInitBadURL(t *testing.T) {
 	assert.Regexp(t, "FF10162", err)
 }
 
+func TestInitBadTLS(t *testing.T) {
+	config.Reset()
+	h := &FFTokens{}
+	h.InitPrefix(utConfPrefix)
+
+	utConfPrefix.AddKnownKey(tokens.TokensConfigName, "test")
+	utConfPrefix.AddKnownKey(tokens.TokensConfigPlugin, "fftokens")
+	utConfPrefix.AddKnownKey(restclient.HTTPConfigURL, "https://localhost:12345")
+	utConfPrefix.AddKnownKey(tlsconfig.TLSConfigEnabled, true)
+	utConfPrefix.AddKnownKey(tlsconfig.TLSConfigCAFile, "/not/a/file")
+	err := h.Init(context.Background(), "testtokens", utConfPrefix.ArrayEntry(0), &tokenmocks.Callbacks{})
+	assert.Regexp(t, "FF10105", err)
+}
+
 func TestInitMissingURL(t *testing.T) {
 	config.Reset()
 	h := &FFTokens{}
@@ -400,6 +415,63 @@ func TestTransferTokensError(t *testing.T) {
 	assert.Regexp(t, "FF10274", err)
 }
 
+func TestTokensApproval(t *testing.T) {
+	h, _, _, httpURL, done := newTestFFTokens(t)
+	defer done()
+
+	approval := &fftypes.TokenApproval{
+		LocalID:  fftypes.NewUUID(),
+		Key:      "0x123",
+		Operator: "0x456",
+		Approved: true,
+		TX: fftypes.TransactionRef{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.TransactionTypeTokenApproval,
+		},
+	}
+	opID := fftypes.NewUUID()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/approval", httpURL),
+		func(req *http.Request) (*http.Response, error) {
+			body := make(fftypes.JSONObject)
+			err := json.NewDecoder(req.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, fftypes.JSONObject{
+				"poolId":    "123",
+				"signer":    "0x123",
+				"operator":  "0x456",
+				"approved":  true,
+				"requestId": opID.String(),
+				"data":      `{"tx":"` + approval.TX.ID.String() + `"}`,
+			}, body)
+
+			res := &http.Response{
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"id":"1"}`))),
+				Header: http.Header{
+					"Content-Type": []string{"application/json"},
+				},
+				StatusCode: 202,
+			}
+			return res, nil
+		})
+
+	err := h.TokensApproval(context.Background(), opID, "123", approval)
+	assert.NoError(t, err)
+}
+
+func TestTokensApprovalError(t *testing.T) {
+	h, _, _, httpURL, done := newTestFFTokens(t)
+	defer done()
+
+	approval := &fftypes.TokenApproval{}
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/api/v1/approval", httpURL),
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	err := h.TokensApproval(context.Background(), fftypes.NewUUID(), "F1", approval)
+	assert.Regexp(t, "FF10274", err)
+}
+
 func TestEvents(t *testing.T) {
 	h, toServer, fromServer, _, done := newTestFFTokens(t)
 	defer done()
@@ -618,8 +690,9 @@ func TestEvents(t *testing.T) {
 	// token-transfer: success
 	messageID := fftypes.NewUUID()
 	mcb.On("TokensTransferred", h, "F1", mock.MatchedBy(func(t *fftypes.TokenTransfer) bool {
-		return t.Amount.Int().Int64() == 2 && t.From == "0x0" && t.To == "0x1" && t.TokenIndex == "" && messageID.Equals(t.Message)
-	}), "abc", fftypes.JSONObject{"transactionHash": "abc"}).Return(nil).Once()
+		return t.Amount.Int().Int64() == 2 && t.From == "0x0" && t.To == "0x1" && t.TokenIndex == "" && messageID.Equals(t.Message) &&
+			t.PoolSequence == "000000000010/000002/000003"
+	}), "abc", fftypes.JSONObject{"transactionHash": "abc", "blockNumber": "10", "transactionIndex": "0x2", "logIndex": float64(3)}).Return(nil).Once()
 	fromServer <- fftypes.JSONObject{
 		"id":    "15",
 		"event": "token-transfer",
@@ -632,7 +705,10 @@ func TestEvents(t *testing.T) {
 			"amount":   "2",
 			"data":     fftypes.JSONObject{"tx": txID.String(), "message": messageID.String()}.String(),
 			"transaction": fftypes.JSONObject{
-				"transactionHash": "abc",
+				"transactionHash":  "abc",
+				"blockNumber":      "10",
+				"transactionIndex": "0x2",
+				"logIndex":         3,
 			},
 		},
 	}.String()
@@ -641,7 +717,7 @@ func TestEvents(t *testing.T) {
 
 	// token-burn: success
 	mcb.On("TokensTransferred", h, "F1", mock.MatchedBy(func(t *fftypes.TokenTransfer) bool {
-		return t.Amount.Int().Int64() == 2 && t.From == "0x0" && t.TokenIndex == "0"
+		return t.Amount.Int().Int64() == 2 && t.From == "0x0" && t.TokenIndex == "0" && t.PoolSequence == ""
 	}), "abc", fftypes.JSONObject{"transactionHash": "abc"}).Return(nil).Once()
 	fromServer <- fftypes.JSONObject{
 		"id":    "16",
@@ -662,6 +738,99 @@ func TestEvents(t *testing.T) {
 	msg = <-toServer
 	assert.Equal(t, `{"data":{"id":"16"},"event":"ack"}`, string(msg))
 
+	// token-approval: missing data
+	fromServer <- fftypes.JSONObject{
+		"id":    "17",
+		"event": "token-approval",
+		"data": fftypes.JSONObject{
+			"id":     "3.0.0",
+			"poolId": "F1",
+		},
+	}.String()
+	msg = <-toServer
+	assert.Equal(t, `{"data":{"id":"17"},"event":"ack"}`, string(msg))
+
+	// token-approval: bad data (success)
+	mcb.On("TokensApproved", h, "F1", mock.MatchedBy(func(a *fftypes.TokenApproval) bool {
+		return a.Key == "0x0" && a.Operator == "0x1" && a.Approved && a.ProtocolID == "3.0.0" && a.TX.ID == nil
+	}), "abc", fftypes.JSONObject{"transactionHash": "abc"}).Return(nil).Once()
+	fromServer <- fftypes.JSONObject{
+		"id":    "18",
+		"event": "token-approval",
+		"data": fftypes.JSONObject{
+			"id":       "3.0.0",
+			"poolId":   "F1",
+			"signer":   "0x0",
+			"operator": "0x1",
+			"approved": true,
+			"data":     "bad",
+			"transaction": fftypes.JSONObject{
+				"transactionHash": "abc",
+			},
+		},
+	}.String()
+	msg = <-toServer
+	assert.Equal(t, `{"data":{"id":"18"},"event":"ack"}`, string(msg))
+
+	// token-approval: success
+	mcb.On("TokensApproved", h, "F1", mock.MatchedBy(func(a *fftypes.TokenApproval) bool {
+		return a.Key == "0x0" && a.Operator == "0x1" && !a.Approved && txID.Equals(a.TX.ID) &&
+			a.TX.Type == fftypes.TransactionTypeTokenApproval
+	}), "abc", fftypes.JSONObject{"transactionHash": "abc"}).Return(nil).Once()
+	fromServer <- fftypes.JSONObject{
+		"id":    "19",
+		"event": "token-approval",
+		"data": fftypes.JSONObject{
+			"id":       "3.0.1",
+			"poolId":   "F1",
+			"signer":   "0x0",
+			"operator": "0x1",
+			"approved": false,
+			"data":     fftypes.JSONObject{"tx": txID.String()}.String(),
+			"transaction": fftypes.JSONObject{
+				"transactionHash": "abc",
+			},
+		},
+	}.String()
+	msg = <-toServer
+	assert.Equal(t, `{"data":{"id":"19"},"event":"ack"}`, string(msg))
+
+	// token-pool-governance: unknown action
+	fromServer <- fftypes.JSONObject{
+		"id":    "20",
+		"event": "token-pool-governance",
+		"data": fftypes.JSONObject{
+			"poolId": "F1",
+			"action": "burned",
+			"transaction": fftypes.JSONObject{
+				"transactionHash": "abc",
+			},
+		},
+	}.String()
+	msg = <-toServer
+	assert.Equal(t, `{"data":{"id":"20"},"event":"ack"}`, string(msg))
+
+	// token-pool-governance: success
+	mcb.On("TokenPoolGovernance", h, "F1", mock.MatchedBy(func(c *fftypes.TokenPoolGovernance) bool {
+		return c.Action == fftypes.TokenPoolGovernanceOwnershipTransferred && c.Key == "0x0" &&
+			c.Details.GetString("newOwner") == "0x1"
+	}), "abc", fftypes.JSONObject{"transactionHash": "abc"}).Return(nil).Once()
+	fromServer <- fftypes.JSONObject{
+		"id":    "21",
+		"event": "token-pool-governance",
+		"data": fftypes.JSONObject{
+			"poolId":  "F1",
+			"action":  "Ownership_Transferred",
+			"signer":  "0x0",
+			"details": fftypes.JSONObject{"newOwner": "0x1"},
+			"transaction": fftypes.JSONObject{
+				"transactionHash": "abc",
+			},
+		},
+	}.String()
+	msg = <-toServer
+	assert.Equal(t, `{"data":{"id":"21"},"event":"ack"}`, string(msg))
+
 	mcb.AssertExpectations(t)
 }
 
@@ -0,0 +1,119 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/hyperledger/firefly"
+
+var provider *sdktrace.TracerProvider
+
+// Init installs the W3C trace context propagator, so trace context is passed through even when
+// tracing is disabled, and when enabled a tracer provider that exports spans to an OTLP/HTTP collector
+func Init(ctx context.Context) error {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if !config.GetBool(config.TracingEnabled) {
+		return nil
+	}
+
+	endpoint := config.GetString(config.TracingEndpoint)
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if config.GetBool(config.TracingInsecure) {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgTracingInitFailed)
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.GetFloat64(config.TracingSampleRatio)))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(config.GetString(config.TracingServiceName)))),
+	)
+	otel.SetTracerProvider(provider)
+	log.L(ctx).Infof("Exporting traces to %s", endpoint)
+	return nil
+}
+
+// Shutdown flushes any spans that have not yet been exported, and stops the exporter
+func Shutdown(ctx context.Context) {
+	if provider == nil {
+		return
+	}
+	if err := provider.Shutdown(ctx); err != nil {
+		log.L(ctx).Warnf("Failed to flush traces: %s", err)
+	}
+	provider = nil
+	otel.SetTracerProvider(trace.NewNoopTracerProvider())
+}
+
+// StartSpan starts a span, as a child of any span already in the context
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error against the span if there is one, then ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InjectHeaders adds the trace context from the context to outbound HTTP headers
+func InjectHeaders(ctx context.Context, headers http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(headers))
+}
+
+// ExtractHeaders returns a context that continues any trace propagated in inbound HTTP headers
+func ExtractHeaders(ctx context.Context, headers http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(headers))
+}
+
+// Carrier returns the trace context from the context, to propagate inside a payload sent to another node.
+// Returns nil if there is no trace to propagate
+func Carrier(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ExtractCarrier returns a context that continues any trace propagated inside a payload received from another node
+func ExtractCarrier(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
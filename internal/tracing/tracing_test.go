@@ -0,0 +1,120 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingDisabled(t *testing.T) {
+	config.Reset()
+	err := Init(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	ctx, span := StartSpan(context.Background(), "ut")
+	assert.False(t, span.IsRecording())
+	EndSpan(span, nil)
+	assert.Nil(t, Carrier(ctx))
+
+	// Trace context from a caller is still passed through
+	headers := http.Header{}
+	headers.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx = ExtractHeaders(context.Background(), headers)
+	ctx, span = StartSpan(ctx, "ut")
+	defer span.End()
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+	carrier := Carrier(ctx)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", carrier["traceparent"])
+
+	Shutdown(context.Background())
+}
+
+func TestTracingEnabled(t *testing.T) {
+	exported := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		exported <- req.URL.Path
+		res.WriteHeader(200)
+	}))
+	defer collector.Close()
+
+	config.Reset()
+	config.Set(config.TracingEnabled, true)
+	config.Set(config.TracingInsecure, true)
+	config.Set(config.TracingEndpoint, strings.TrimPrefix(collector.URL, "http://"))
+	defer config.Reset()
+	err := Init(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, provider)
+
+	ctx, span := StartSpan(context.Background(), "parent", attribute.String("ff.namespace", "ns1"))
+	assert.True(t, span.IsRecording())
+	carrier := Carrier(ctx)
+	assert.Regexp(t, span.SpanContext().TraceID().String(), carrier["traceparent"])
+
+	// The trace continues on the other side of an HTTP request, or a payload
+	headers := http.Header{}
+	InjectHeaders(ctx, headers)
+	_, child1 := StartSpan(ExtractHeaders(context.Background(), headers), "child1")
+	assert.Equal(t, span.SpanContext().TraceID(), child1.SpanContext().TraceID())
+	_, child2 := StartSpan(ExtractCarrier(context.Background(), carrier), "child2")
+	assert.Equal(t, span.SpanContext().TraceID(), child2.SpanContext().TraceID())
+
+	EndSpan(child2, nil)
+	EndSpan(child1, fmt.Errorf("pop"))
+	EndSpan(span, nil)
+
+	Shutdown(context.Background())
+	assert.Equal(t, "/v1/traces", <-exported)
+	assert.Nil(t, provider)
+
+	_, span = StartSpan(context.Background(), "ut")
+	assert.Equal(t, trace.SpanContext{}, span.SpanContext())
+}
+
+func TestTracingInitFail(t *testing.T) {
+	config.Reset()
+	config.Set(config.TracingEnabled, true)
+	defer config.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := Init(ctx)
+	assert.Regexp(t, "FF10444", err)
+}
+
+func TestTracingShutdownFail(t *testing.T) {
+	config.Reset()
+	config.Set(config.TracingEnabled, true)
+	defer config.Reset()
+	err := Init(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Shutdown(ctx)
+	assert.Nil(t, provider)
+}
@@ -0,0 +1,95 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func AddBatchPinInputs(op *fftypes.Operation, batchID *fftypes.UUID, contexts []*fftypes.Bytes32) {
+	contextStrings := make([]string, len(contexts))
+	for i, c := range contexts {
+		contextStrings[i] = c.String()
+	}
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"batch":    batchID.String(),
+		"contexts": contextStrings,
+	})
+}
+
+func RetrieveBatchPinInputs(ctx context.Context, op *fftypes.Operation) (batchID *fftypes.UUID, contexts []*fftypes.Bytes32, err error) {
+	if batchID, err = fftypes.ParseUUID(ctx, op.Input.GetString("batch")); err != nil {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+	}
+	contextStrings, ok := op.Input.GetStringArrayOk("contexts")
+	if !ok {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, "contexts")
+	}
+	contexts = make([]*fftypes.Bytes32, len(contextStrings))
+	for i, c := range contextStrings {
+		if contexts[i], err = fftypes.ParseBytes32(ctx, c); err != nil {
+			return nil, nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+		}
+	}
+	return batchID, contexts, nil
+}
+
+// AddDataExchangeBatchSendInputs records the batch sent to a peer. The payload is not recorded, as it
+// is rebuilt from the stored batch on a retry.
+func AddDataExchangeBatchSendInputs(op *fftypes.Operation, peer string, batchID *fftypes.UUID) {
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"peer":  peer,
+		"batch": batchID.String(),
+	})
+}
+
+func RetrieveDataExchangeBatchSendInputs(ctx context.Context, op *fftypes.Operation) (peer string, batchID *fftypes.UUID, err error) {
+	if peer = op.Input.GetString("peer"); peer == "" {
+		return "", nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, "peer")
+	}
+	if batchID, err = fftypes.ParseUUID(ctx, op.Input.GetString("batch")); err != nil {
+		return "", nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+	}
+	return peer, batchID, nil
+}
+
+func AddDataExchangeBlobSendInputs(op *fftypes.Operation, peer string, hash *fftypes.Bytes32) {
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"peer": peer,
+		"hash": hash.String(),
+	})
+}
+
+func RetrieveDataExchangeBlobSendInputs(ctx context.Context, op *fftypes.Operation) (peer string, hash *fftypes.Bytes32, err error) {
+	if peer = op.Input.GetString("peer"); peer == "" {
+		return "", nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, "peer")
+	}
+	if hash, err = fftypes.ParseBytes32(ctx, op.Input.GetString("hash")); err != nil {
+		return "", nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+	}
+	return peer, hash, nil
+}
+
+func AddDataExchangeMessageSendInputs(op *fftypes.Operation, peer string, msgID *fftypes.UUID) {
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"peer":    peer,
+		"message": msgID.String(),
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchPinInputs(t *testing.T) {
+	op := &fftypes.Operation{}
+	batchID := fftypes.NewUUID()
+	contexts := []*fftypes.Bytes32{fftypes.NewRandB32(), fftypes.NewRandB32()}
+
+	AddBatchPinInputs(op, batchID, contexts)
+	assert.Equal(t, batchID.String(), op.Input.GetString("batch"))
+
+	readID, readContexts, err := RetrieveBatchPinInputs(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, batchID, readID)
+	assert.Equal(t, contexts, readContexts)
+}
+
+func TestRetrieveBatchPinInputsBadBatch(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"batch": "bad"},
+	}
+	_, _, err := RetrieveBatchPinInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestRetrieveBatchPinInputsMissingContexts(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"batch": fftypes.NewUUID().String()},
+	}
+	_, _, err := RetrieveBatchPinInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405.*contexts", err)
+}
+
+func TestRetrieveBatchPinInputsBadContext(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"batch":    fftypes.NewUUID().String(),
+			"contexts": []interface{}{"bad"},
+		},
+	}
+	_, _, err := RetrieveBatchPinInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestDataExchangeBatchSendInputs(t *testing.T) {
+	op := &fftypes.Operation{}
+	batchID := fftypes.NewUUID()
+
+	AddDataExchangeBatchSendInputs(op, "peer1", batchID)
+	peer, readID, err := RetrieveDataExchangeBatchSendInputs(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, "peer1", peer)
+	assert.Equal(t, batchID, readID)
+}
+
+func TestRetrieveDataExchangeBatchSendInputsMissingPeer(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"batch": fftypes.NewUUID().String()},
+	}
+	_, _, err := RetrieveDataExchangeBatchSendInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405.*peer", err)
+}
+
+func TestRetrieveDataExchangeBatchSendInputsBadBatch(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"peer": "peer1", "batch": "bad"},
+	}
+	_, _, err := RetrieveDataExchangeBatchSendInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestDataExchangeBlobSendInputs(t *testing.T) {
+	op := &fftypes.Operation{}
+	hash := fftypes.NewRandB32()
+
+	AddDataExchangeBlobSendInputs(op, "peer1", hash)
+	peer, readHash, err := RetrieveDataExchangeBlobSendInputs(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, "peer1", peer)
+	assert.Equal(t, hash, readHash)
+}
+
+func TestRetrieveDataExchangeBlobSendInputsMissingPeer(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"hash": fftypes.NewRandB32().String()},
+	}
+	_, _, err := RetrieveDataExchangeBlobSendInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405.*peer", err)
+}
+
+func TestRetrieveDataExchangeBlobSendInputsBadHash(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{"peer": "peer1", "hash": "bad"},
+	}
+	_, _, err := RetrieveDataExchangeBlobSendInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestDataExchangeMessageSendInputs(t *testing.T) {
+	op := &fftypes.Operation{Type: fftypes.OpTypeDataExchangeMessageSend}
+	msgID := fftypes.NewUUID()
+
+	AddDataExchangeMessageSendInputs(op, "peer1", msgID)
+	assert.Equal(t, "peer1", op.Input.GetString("peer"))
+	assert.Equal(t, msgID.String(), op.Input.GetString("message"))
+}
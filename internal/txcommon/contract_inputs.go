@@ -0,0 +1,34 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+func AddBlockchainInvokeInputs(op *fftypes.Operation, req *fftypes.ContractCallRequest) {
+	input := fftypes.JSONObject{
+		"location": req.Location,
+		"key":      req.Key,
+		"method":   req.Method,
+		"input":    req.Input,
+	}
+	if req.Interface != nil {
+		input["interface"] = req.Interface.String()
+	}
+	op.Input = RedactOperationInput(op.Type, input)
+}
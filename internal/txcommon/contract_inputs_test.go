@@ -0,0 +1,44 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddBlockchainInvokeInputs(t *testing.T) {
+	op := &fftypes.Operation{Type: fftypes.OpTypeBlockchainInvoke}
+	req := &fftypes.ContractCallRequest{
+		Location: fftypes.JSONObject{"address": "0x12345"},
+		Key:      "0x23456",
+		Method:   &fftypes.FFIMethod{Name: "set"},
+		Input:    fftypes.JSONObject{"x": 42},
+	}
+
+	AddBlockchainInvokeInputs(op, req)
+	assert.Equal(t, "0x23456", op.Input.GetString("key"))
+	assert.Equal(t, req.Method, op.Input["method"])
+	_, ok := op.Input["interface"]
+	assert.False(t, ok)
+
+	req.Interface = fftypes.NewUUID()
+	AddBlockchainInvokeInputs(op, req)
+	assert.Equal(t, req.Interface.String(), op.Input.GetString("interface"))
+}
@@ -0,0 +1,65 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// CheckMessageIdempotencyKey fails with a conflict that refers to the original message, if a message
+// has already been submitted to the namespace with the same idempotency key
+func CheckMessageIdempotencyKey(ctx context.Context, di database.Plugin, ns string, key fftypes.IdempotencyKey) error {
+	if key == "" {
+		return nil
+	}
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	msgs, _, err := di.GetMessages(ctx, fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("idempotencykey", string(key)),
+	).Limit(1))
+	if err != nil {
+		return err
+	}
+	if len(msgs) > 0 {
+		return i18n.NewError(ctx, i18n.MsgIdempotencyKeyDuplicateMsg, key, msgs[0].Header.ID)
+	}
+	return nil
+}
+
+// CheckTransactionIdempotencyKey fails with a conflict that refers to the original transaction, and the
+// resource it was submitted for, if a transaction has already been submitted to the namespace with the same idempotency key
+func CheckTransactionIdempotencyKey(ctx context.Context, di database.Plugin, ns string, key fftypes.IdempotencyKey) error {
+	if key == "" {
+		return nil
+	}
+	fb := database.TransactionQueryFactory.NewFilter(ctx)
+	txs, _, err := di.GetTransactions(ctx, fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("idempotencykey", string(key)),
+	).Limit(1))
+	if err != nil {
+		return err
+	}
+	if len(txs) > 0 {
+		return i18n.NewError(ctx, i18n.MsgIdempotencyKeyDuplicateTX, key, txs[0].ID, txs[0].Subject.Type, txs[0].Subject.Reference)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckMessageIdempotencyKeyEmpty(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	err := CheckMessageIdempotencyKey(context.Background(), mdb, "ns1", "")
+	assert.NoError(t, err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckMessageIdempotencyKeyUnused(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	mdb.On("GetMessages", context.Background(), mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	err := CheckMessageIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.NoError(t, err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckMessageIdempotencyKeyDuplicate(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	msgID := fftypes.NewUUID()
+	mdb.On("GetMessages", context.Background(), mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: msgID}},
+	}, nil, nil)
+	err := CheckMessageIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.Regexp(t, "FF10407.*retry1.*"+msgID.String(), err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckMessageIdempotencyKeyFail(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	mdb.On("GetMessages", context.Background(), mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := CheckMessageIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.EqualError(t, err, "pop")
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckTransactionIdempotencyKeyEmpty(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	err := CheckTransactionIdempotencyKey(context.Background(), mdb, "ns1", "")
+	assert.NoError(t, err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckTransactionIdempotencyKeyUnused(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	mdb.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{}, nil, nil)
+	err := CheckTransactionIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.NoError(t, err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckTransactionIdempotencyKeyDuplicate(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Type:      fftypes.TransactionTypeTokenPool,
+			Reference: fftypes.NewUUID(),
+		},
+	}
+	mdb.On("GetTransactions", context.Background(), mock.Anything).Return([]*fftypes.Transaction{tx}, nil, nil)
+	err := CheckTransactionIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.Regexp(t, "FF10408.*retry1.*"+tx.ID.String()+".*token_pool.*"+tx.Subject.Reference.String(), err)
+	mdb.AssertExpectations(t)
+}
+
+func TestCheckTransactionIdempotencyKeyFail(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	mdb.On("GetTransactions", context.Background(), mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := CheckTransactionIdempotencyKey(context.Background(), mdb, "ns1", "retry1")
+	assert.EqualError(t, err, "pop")
+	mdb.AssertExpectations(t)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/blockchain/bimux"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+	"github.com/hyperledger/firefly/internal/policy"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// PreflightChecker performs checks before a transaction is submitted to the blockchain,
+// so that problems the connector would only report after the fact are reported up front
+type PreflightChecker interface {
+	// CheckBalance fails if the signing key does not hold the configured minimum native balance.
+	// An alert event referring to ref is emitted the first time a key is found to be underfunded.
+	CheckBalance(ctx context.Context, ns, signingKey string, ref *fftypes.UUID) error
+
+	// CheckPolicy fails if the policy plugin rejects the submission, or holds it for manual approval
+	CheckPolicy(ctx context.Context, req *fftypes.PolicyRequest) error
+}
+
+type preflightChecker struct {
+	database   database.Plugin
+	blockchain blockchain.Plugin
+	policy     policy.Manager
+	enabled    bool
+	minBalance *big.Int
+	mux        sync.Mutex
+	alerted    map[string]bool
+}
+
+func NewPreflightChecker(ctx context.Context, di database.Plugin, bi blockchain.Plugin, pm policy.Manager) (PreflightChecker, error) {
+	if di == nil || bi == nil || pm == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	minBalanceStr := config.GetString(config.TransactionPreflightMinBalance)
+	minBalance, ok := new(big.Int).SetString(minBalanceStr, 10)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgInvalidPreflightMinBalance, minBalanceStr)
+	}
+	return &preflightChecker{
+		database:   di,
+		blockchain: bi,
+		policy:     pm,
+		enabled:    config.GetBool(config.TransactionPreflightEnabled),
+		minBalance: minBalance,
+		alerted:    make(map[string]bool),
+	}, nil
+}
+
+func (pc *preflightChecker) CheckBalance(ctx context.Context, ns, signingKey string, ref *fftypes.UUID) error {
+	if !pc.enabled {
+		return nil
+	}
+
+	balance, err := bimux.ForNamespace(pc.blockchain, ns).GetNativeBalance(ctx, ns, signingKey)
+	if err != nil {
+		// We do not block submission if we cannot determine the balance
+		log.L(ctx).Warnf("Unable to query native balance of '%s' before submission: %s", signingKey, err)
+		return nil
+	}
+	if balance == nil {
+		return nil
+	}
+
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+	if balance.Int().Cmp(pc.minBalance) >= 0 {
+		delete(pc.alerted, signingKey)
+		return nil
+	}
+
+	log.L(ctx).Errorf("Signing key '%s' has insufficient gas funds: balance=%s minimum=%s", signingKey, balance.Int(), pc.minBalance)
+	if !pc.alerted[signingKey] {
+		event := fftypes.NewEvent(fftypes.EventTypeInsufficientGasFunds, ns, ref)
+		if err := pc.database.InsertEvent(ctx, event); err != nil {
+			log.L(ctx).Errorf("Failed to emit insufficient gas funds event: %s", err)
+		} else {
+			pc.alerted[signingKey] = true
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgInsufficientGasFunds, signingKey, balance.Int().String(), pc.minBalance.String())
+}
+
+func (pc *preflightChecker) CheckPolicy(ctx context.Context, req *fftypes.PolicyRequest) error {
+	return pc.policy.CheckSubmission(ctx, req)
+}
@@ -0,0 +1,140 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/mocks/databasemocks"
+	"github.com/hyperledger/firefly/mocks/policymanagermocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPreflightChecker(t *testing.T) (*preflightChecker, *databasemocks.Plugin, *blockchainmocks.Plugin) {
+	config.Reset()
+	config.Set(config.TransactionPreflightEnabled, true)
+	config.Set(config.TransactionPreflightMinBalance, "1000")
+	mdi := &databasemocks.Plugin{}
+	mbi := &blockchainmocks.Plugin{}
+	pc, err := NewPreflightChecker(context.Background(), mdi, mbi, &policymanagermocks.Manager{})
+	assert.NoError(t, err)
+	return pc.(*preflightChecker), mdi, mbi
+}
+
+func TestNewPreflightCheckerMissingDeps(t *testing.T) {
+	_, err := NewPreflightChecker(context.Background(), nil, nil, nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestNewPreflightCheckerBadMinBalance(t *testing.T) {
+	config.Reset()
+	config.Set(config.TransactionPreflightMinBalance, "lots")
+	defer config.Reset()
+	_, err := NewPreflightChecker(context.Background(), &databasemocks.Plugin{}, &blockchainmocks.Plugin{}, &policymanagermocks.Manager{})
+	assert.Regexp(t, "FF10341.*lots", err)
+}
+
+func TestCheckBalanceDisabled(t *testing.T) {
+	config.Reset()
+	pc, err := NewPreflightChecker(context.Background(), &databasemocks.Plugin{}, &blockchainmocks.Plugin{}, &policymanagermocks.Manager{})
+	assert.NoError(t, err)
+	err = pc.CheckBalance(context.Background(), "ns1", "0x12345", fftypes.NewUUID())
+	assert.NoError(t, err)
+}
+
+func TestCheckBalanceSufficient(t *testing.T) {
+	pc, _, mbi := newTestPreflightChecker(t)
+	defer config.Reset()
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(fftypes.NewBigInt(1000), nil)
+
+	err := pc.CheckBalance(context.Background(), "ns1", "0x12345", fftypes.NewUUID())
+	assert.NoError(t, err)
+	mbi.AssertExpectations(t)
+}
+
+func TestCheckBalanceNotSupported(t *testing.T) {
+	pc, _, mbi := newTestPreflightChecker(t)
+	defer config.Reset()
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(nil, nil)
+
+	err := pc.CheckBalance(context.Background(), "ns1", "0x12345", fftypes.NewUUID())
+	assert.NoError(t, err)
+	mbi.AssertExpectations(t)
+}
+
+func TestCheckBalanceQueryFailProceeds(t *testing.T) {
+	pc, _, mbi := newTestPreflightChecker(t)
+	defer config.Reset()
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(nil, fmt.Errorf("pop"))
+
+	err := pc.CheckBalance(context.Background(), "ns1", "0x12345", fftypes.NewUUID())
+	assert.NoError(t, err)
+	mbi.AssertExpectations(t)
+}
+
+func TestCheckBalanceInsufficientAlertsOnce(t *testing.T) {
+	pc, mdi, mbi := newTestPreflightChecker(t)
+	defer config.Reset()
+	ref := fftypes.NewUUID()
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(fftypes.NewBigInt(999), nil).Times(2)
+	mdi.On("InsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeInsufficientGasFunds && e.Namespace == "ns1" && e.Reference.Equals(ref)
+	})).Return(nil).Once()
+
+	err := pc.CheckBalance(context.Background(), "ns1", "0x12345", ref)
+	assert.Regexp(t, "FF10340.*0x12345.*999.*1000", err)
+	err = pc.CheckBalance(context.Background(), "ns1", "0x12345", ref)
+	assert.Regexp(t, "FF10340", err)
+
+	// Once funded, a subsequent shortfall alerts again
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(fftypes.NewBigInt(5000), nil).Once()
+	err = pc.CheckBalance(context.Background(), "ns1", "0x12345", ref)
+	assert.NoError(t, err)
+	assert.False(t, pc.alerted["0x12345"])
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckBalanceInsufficientAlertFail(t *testing.T) {
+	pc, mdi, mbi := newTestPreflightChecker(t)
+	defer config.Reset()
+	mbi.On("GetNativeBalance", mock.Anything, "ns1", "0x12345").Return(fftypes.NewBigInt(0), nil)
+	mdi.On("InsertEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := pc.CheckBalance(context.Background(), "ns1", "0x12345", fftypes.NewUUID())
+	assert.Regexp(t, "FF10340", err)
+	assert.False(t, pc.alerted["0x12345"])
+	mdi.AssertExpectations(t)
+}
+
+func TestCheckPolicy(t *testing.T) {
+	pc, _, _ := newTestPreflightChecker(t)
+	defer config.Reset()
+	req := &fftypes.PolicyRequest{Type: fftypes.PolicySubmissionTypeBatchPin}
+	mpm := pc.policy.(*policymanagermocks.Manager)
+	mpm.On("CheckSubmission", mock.Anything, req).Return(fmt.Errorf("pop"))
+	err := pc.CheckPolicy(context.Background(), req)
+	assert.EqualError(t, err, "pop")
+	mpm.AssertExpectations(t)
+}
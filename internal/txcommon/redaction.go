@@ -0,0 +1,62 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// RedactOperation applies the configured redaction rules to both the input and output of an operation.
+// It is applied before operations are returned on the API, so that any sensitive fields persisted before
+// a redaction rule was configured are never exposed.
+func RedactOperation(op *fftypes.Operation) *fftypes.Operation {
+	op.Input = RedactOperationInput(op.Type, op.Input)
+	op.Output = RedactOperationOutput(op.Type, op.Output)
+	return op
+}
+
+// RedactOperationInput applies the configured input redaction rules for the operation type
+func RedactOperationInput(opType fftypes.OpType, input fftypes.JSONObject) fftypes.JSONObject {
+	return input.Redact(redactionPaths(opType, config.OperationRedactInput)...)
+}
+
+// RedactOperationOutput applies the configured output redaction rules for the operation type
+func RedactOperationOutput(opType fftypes.OpType, output fftypes.JSONObject) fftypes.JSONObject {
+	return output.Redact(redactionPaths(opType, config.OperationRedactOutput)...)
+}
+
+// redactionPaths returns the paths that apply to the operation type, where each configured
+// rule is either a path that applies to all operations, or is prefixed with "optype:"
+func redactionPaths(opType fftypes.OpType, key config.RootKey) []string {
+	rules := config.GetStringSlice(key)
+	paths := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if i := strings.Index(rule, ":"); i >= 0 {
+			if !strings.EqualFold(rule[0:i], string(opType)) {
+				continue
+			}
+			rule = rule[i+1:]
+		}
+		if rule != "" {
+			paths = append(paths, rule)
+		}
+	}
+	return paths
+}
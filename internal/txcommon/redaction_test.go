@@ -0,0 +1,78 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txcommon
+
+import (
+	"testing"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactOperation(t *testing.T) {
+	config.Reset()
+	config.Set(config.OperationRedactInput, []string{"secret", "token_transfer:transferSecret", "token_create_pool:poolSecret", ""})
+	config.Set(config.OperationRedactOutput, []string{"Token_Transfer:payload", "token_transfer:"})
+	defer config.Reset()
+
+	op := &fftypes.Operation{
+		Type: fftypes.OpTypeTokenTransfer,
+		Input: fftypes.JSONObject{
+			"secret":         "s1",
+			"transferSecret": "s2",
+			"poolSecret":     "s3",
+		},
+		Output: fftypes.JSONObject{
+			"payload": "full payload",
+			"id":      "12345",
+		},
+	}
+	RedactOperation(op)
+	assert.Equal(t, fftypes.JSONObject{
+		"secret":         fftypes.RedactedValue,
+		"transferSecret": fftypes.RedactedValue,
+		"poolSecret":     "s3",
+	}, op.Input)
+	assert.Equal(t, fftypes.JSONObject{
+		"payload": fftypes.RedactedValue,
+		"id":      "12345",
+	}, op.Output)
+}
+
+func TestRedactOperationDefaults(t *testing.T) {
+	config.Reset()
+
+	op := &fftypes.Operation{
+		Type:   fftypes.OpTypeBlockchainBatchPin,
+		Input:  fftypes.JSONObject{"some": "input"},
+		Output: fftypes.JSONObject{"some": "output"},
+	}
+	RedactOperation(op)
+	assert.Equal(t, "input", op.Input.GetString("some"))
+	assert.Equal(t, "output", op.Output.GetString("some"))
+}
+
+func TestAddTokenTransferInputsRedacted(t *testing.T) {
+	config.Reset()
+	config.Set(config.OperationRedactInput, []string{"token_transfer:id"})
+	defer config.Reset()
+
+	op := &fftypes.Operation{Type: fftypes.OpTypeTokenTransfer}
+	AddTokenTransferInputs(op, &fftypes.TokenTransfer{LocalID: fftypes.NewUUID()})
+	assert.Equal(t, fftypes.RedactedValue, op.Input.GetString("id"))
+}
@@ -18,19 +18,21 @@ package txcommon
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/hyperledger/firefly/internal/i18n"
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
 func AddTokenPoolCreateInputs(op *fftypes.Operation, pool *fftypes.TokenPool) {
-	op.Input = fftypes.JSONObject{
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
 		"id":        pool.ID.String(),
 		"namespace": pool.Namespace,
 		"name":      pool.Name,
 		"symbol":    pool.Symbol,
 		"config":    pool.Config,
-	}
+	})
 }
 
 func RetrieveTokenPoolCreateInputs(ctx context.Context, op *fftypes.Operation, pool *fftypes.TokenPool) (err error) {
@@ -49,10 +51,21 @@ func RetrieveTokenPoolCreateInputs(ctx context.Context, op *fftypes.Operation, p
 	return nil
 }
 
+// AddTokenTransferInputs records the local ID used to correlate the transfer events back to the operation,
+// along with the details of the transfer so that it can be resubmitted to the connector on a retry
 func AddTokenTransferInputs(op *fftypes.Operation, transfer *fftypes.TokenTransfer) {
-	op.Input = fftypes.JSONObject{
-		"id": transfer.LocalID.String(),
-	}
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"id":          transfer.LocalID.String(),
+		"type":        transfer.Type,
+		"pool":        transfer.Pool,
+		"tokenIndex":  transfer.TokenIndex,
+		"key":         transfer.Key,
+		"from":        transfer.From,
+		"to":          transfer.To,
+		"amount":      &transfer.Amount,
+		"message":     transfer.Message,
+		"messageHash": transfer.MessageHash,
+	})
 }
 
 func RetrieveTokenTransferInputs(ctx context.Context, op *fftypes.Operation, transfer *fftypes.TokenTransfer) (err error) {
@@ -61,3 +74,54 @@ func RetrieveTokenTransferInputs(ctx context.Context, op *fftypes.Operation, tra
 	}
 	return nil
 }
+
+// RetrieveTokenTransferRetryInputs rebuilds the transfer submitted by the operation
+func RetrieveTokenTransferRetryInputs(ctx context.Context, op *fftypes.Operation) (*fftypes.TokenTransfer, error) {
+	var transfer fftypes.TokenTransfer
+	if err := json.Unmarshal([]byte(op.Input.String()), &transfer); err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+	}
+	if err := RetrieveTokenTransferInputs(ctx, op, &transfer); err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, err)
+	}
+	switch transfer.Type {
+	case fftypes.TokenTransferTypeMint, fftypes.TokenTransferTypeBurn, fftypes.TokenTransferTypeTransfer:
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, "type")
+	}
+	if transfer.Pool == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationInputsMissing, op.ID, "pool")
+	}
+	transfer.Connector = op.Plugin
+	transfer.Namespace = op.Namespace
+	transfer.TX = fftypes.TransactionRef{
+		ID:   op.Transaction,
+		Type: fftypes.TransactionTypeTokenTransfer,
+	}
+	return &transfer, nil
+}
+
+func AddTokenApprovalInputs(op *fftypes.Operation, approval *fftypes.TokenApproval) {
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"id": approval.LocalID.String(),
+	})
+}
+
+func RetrieveTokenApprovalInputs(ctx context.Context, op *fftypes.Operation, approval *fftypes.TokenApproval) (err error) {
+	if approval.LocalID, err = fftypes.ParseUUID(ctx, op.Input.GetString("id")); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AddTokenBridgeInputs records the bridge against each of its linked operations. The same "id" key is used
+// as for transfers, so the transfer events of each leg correlate back to the bridge.
+func AddTokenBridgeInputs(op *fftypes.Operation, bridge *fftypes.TokenBridge) {
+	op.Input = RedactOperationInput(op.Type, fftypes.JSONObject{
+		"id": bridge.ID.String(),
+	})
+}
+
+func RetrieveTokenBridgeInputs(ctx context.Context, op *fftypes.Operation) (*fftypes.UUID, error) {
+	return fftypes.ParseUUID(ctx, op.Input.GetString("id"))
+}
@@ -105,6 +105,88 @@ func TestAddTokenTransferInputs(t *testing.T) {
 	assert.Equal(t, transfer.LocalID.String(), op.Input.GetString("id"))
 }
 
+func TestTokenTransferRetryInputs(t *testing.T) {
+	transfer := &fftypes.TokenTransfer{
+		Type:        fftypes.TokenTransferTypeTransfer,
+		LocalID:     fftypes.NewUUID(),
+		Pool:        fftypes.NewUUID(),
+		TokenIndex:  "1",
+		Key:         "0x12345",
+		From:        "0x12345",
+		To:          "0x67890",
+		Amount:      *fftypes.NewBigInt(10),
+		Message:     fftypes.NewUUID(),
+		MessageHash: fftypes.NewRandB32(),
+	}
+	op := &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        fftypes.OpTypeTokenTransfer,
+		Plugin:      "erc1155",
+	}
+	AddTokenTransferInputs(op, transfer)
+
+	// Round trip the inputs through JSON, as they would be stored
+	var input fftypes.JSONObject
+	err := input.Scan([]byte(op.Input.String()))
+	assert.NoError(t, err)
+	op.Input = input
+
+	retry, err := RetrieveTokenTransferRetryInputs(context.Background(), op)
+	assert.NoError(t, err)
+	transfer.Connector = "erc1155"
+	transfer.Namespace = "ns1"
+	transfer.TX = fftypes.TransactionRef{
+		ID:   op.Transaction,
+		Type: fftypes.TransactionTypeTokenTransfer,
+	}
+	assert.Equal(t, transfer, retry)
+}
+
+func TestTokenTransferRetryInputsBadJSON(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id":   fftypes.NewUUID().String(),
+			"pool": "bad",
+		},
+	}
+	_, err := RetrieveTokenTransferRetryInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestTokenTransferRetryInputsBadID(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id": "bad",
+		},
+	}
+	_, err := RetrieveTokenTransferRetryInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405", err)
+}
+
+func TestTokenTransferRetryInputsBadType(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id":   fftypes.NewUUID().String(),
+			"type": "bad",
+		},
+	}
+	_, err := RetrieveTokenTransferRetryInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405.*type", err)
+}
+
+func TestTokenTransferRetryInputsMissingPool(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id":   fftypes.NewUUID().String(),
+			"type": "mint",
+		},
+	}
+	_, err := RetrieveTokenTransferRetryInputs(context.Background(), op)
+	assert.Regexp(t, "FF10405.*pool", err)
+}
+
 func TestRetrieveTokenTransferInputs(t *testing.T) {
 	id := fftypes.NewUUID()
 	op := &fftypes.Operation{
@@ -130,3 +212,73 @@ func TestRetrieveTokenTransferInputsBadID(t *testing.T) {
 	err := RetrieveTokenTransferInputs(context.Background(), op, transfer)
 	assert.Regexp(t, "FF10142", err)
 }
+
+func TestAddTokenApprovalInputs(t *testing.T) {
+	op := &fftypes.Operation{}
+	approval := &fftypes.TokenApproval{
+		LocalID: fftypes.NewUUID(),
+	}
+
+	AddTokenApprovalInputs(op, approval)
+	assert.Equal(t, approval.LocalID.String(), op.Input.GetString("id"))
+}
+
+func TestRetrieveTokenApprovalInputs(t *testing.T) {
+	id := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id": id.String(),
+		},
+	}
+	approval := &fftypes.TokenApproval{}
+
+	err := RetrieveTokenApprovalInputs(context.Background(), op, approval)
+	assert.NoError(t, err)
+	assert.Equal(t, *id, *approval.LocalID)
+}
+
+func TestRetrieveTokenApprovalInputsBadID(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id": "bad",
+		},
+	}
+	approval := &fftypes.TokenApproval{}
+
+	err := RetrieveTokenApprovalInputs(context.Background(), op, approval)
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestAddTokenBridgeInputs(t *testing.T) {
+	op := &fftypes.Operation{Type: fftypes.OpTypeTokenBridgeLock}
+	bridge := &fftypes.TokenBridge{
+		ID: fftypes.NewUUID(),
+	}
+
+	AddTokenBridgeInputs(op, bridge)
+	assert.Equal(t, bridge.ID.String(), op.Input.GetString("id"))
+}
+
+func TestRetrieveTokenBridgeInputs(t *testing.T) {
+	id := fftypes.NewUUID()
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id": id.String(),
+		},
+	}
+
+	bridgeID, err := RetrieveTokenBridgeInputs(context.Background(), op)
+	assert.NoError(t, err)
+	assert.Equal(t, *id, *bridgeID)
+}
+
+func TestRetrieveTokenBridgeInputsBadID(t *testing.T) {
+	op := &fftypes.Operation{
+		Input: fftypes.JSONObject{
+			"id": "bad",
+		},
+	}
+
+	_, err := RetrieveTokenBridgeInputs(context.Background(), op)
+	assert.Regexp(t, "FF10142", err)
+}
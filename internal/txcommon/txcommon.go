@@ -70,6 +70,10 @@ func (t *transactionHelper) PersistTransaction(ctx context.Context, tx *fftypes.
 		// This is an update to an existing transaction, but the subject is the same
 		tx.Created = existing.Created
 		tx.Hash = existing.Hash
+		if tx.Fee == nil {
+			// The fee is recorded from the receipt, so is only known by the submitting node
+			tx.Fee = existing.Fee
+		}
 
 	default:
 		log.L(ctx).Errorf("Invalid transaction ID='%s' Reference='%s' - does not match existing subject", tx.ID, tx.Subject.Reference)
@@ -176,6 +176,35 @@ func TestPersistTransactionUpdateOk(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPersistTransactionUpdateKeepsFee(t *testing.T) {
+	mdb := &databasemocks.Plugin{}
+	th := NewTransactionHelper(mdb)
+
+	tx := &fftypes.Transaction{
+		ID: fftypes.NewUUID(),
+		Subject: fftypes.TransactionSubject{
+			Namespace: "ns1",
+			Reference: fftypes.NewUUID(),
+		},
+	}
+	existing := &fftypes.Transaction{
+		ID: tx.ID,
+		Subject: fftypes.TransactionSubject{
+			Namespace: "ns1",
+			Reference: tx.Subject.Reference,
+		},
+		Fee: &fftypes.TransactionFee{GasUsed: fftypes.NewBigInt(21000)},
+	}
+
+	mdb.On("GetTransactionByID", context.Background(), tx.ID).Return(existing, nil)
+	mdb.On("UpsertTransaction", context.Background(), tx, false).Return(nil)
+
+	valid, err := th.PersistTransaction(context.Background(), tx)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Fee, tx.Fee)
+}
+
 func TestPersistTransactionCreateOk(t *testing.T) {
 	mdb := &databasemocks.Plugin{}
 	th := NewTransactionHelper(mdb)
@@ -32,6 +32,29 @@ func (_m *Manager) ActivateTokenPool(ctx context.Context, pool *fftypes.TokenPoo
 	return r0
 }
 
+// BridgeTokens provides a mock function with given fields: ctx, ns, bridge
+func (_m *Manager) BridgeTokens(ctx context.Context, ns string, bridge *fftypes.TokenBridgeInput) (*fftypes.TokenBridge, error) {
+	ret := _m.Called(ctx, ns, bridge)
+
+	var r0 *fftypes.TokenBridge
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.TokenBridgeInput) *fftypes.TokenBridge); ok {
+		r0 = rf(ctx, ns, bridge)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenBridge)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.TokenBridgeInput) error); ok {
+		r1 = rf(ctx, ns, bridge)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BurnTokens provides a mock function with given fields: ctx, ns, transfer, waitConfirm
 func (_m *Manager) BurnTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error) {
 	ret := _m.Called(ctx, ns, transfer, waitConfirm)
@@ -188,6 +211,38 @@ func (_m *Manager) GetTokenAccounts(ctx context.Context, ns string, filter datab
 	return r0, r1, r2
 }
 
+// GetTokenApprovals provides a mock function with given fields: ctx, ns, filter
+func (_m *Manager) GetTokenApprovals(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenApproval, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.TokenApproval); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenApproval)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetTokenBalances provides a mock function with given fields: ctx, ns, filter
 func (_m *Manager) GetTokenBalances(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
@@ -220,6 +275,38 @@ func (_m *Manager) GetTokenBalances(ctx context.Context, ns string, filter datab
 	return r0, r1, r2
 }
 
+// GetTokenBalancesAsOf provides a mock function with given fields: ctx, ns, asOf, filter
+func (_m *Manager) GetTokenBalancesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, asOf, filter)
+
+	var r0 []*fftypes.TokenBalance
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) []*fftypes.TokenBalance); ok {
+		r0 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenBalance)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, asOf, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetTokenBalancesByPool provides a mock function with given fields: ctx, ns, connector, poolName, filter
 func (_m *Manager) GetTokenBalancesByPool(ctx context.Context, ns string, connector string, poolName string, filter database.AndFilter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, connector, poolName, filter)
@@ -252,6 +339,61 @@ func (_m *Manager) GetTokenBalancesByPool(ctx context.Context, ns string, connec
 	return r0, r1, r2
 }
 
+// GetTokenBridgeByID provides a mock function with given fields: ctx, ns, id
+func (_m *Manager) GetTokenBridgeByID(ctx context.Context, ns string, id string) (*fftypes.TokenBridge, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.TokenBridge
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.TokenBridge); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenBridge)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTokenBridges provides a mock function with given fields: ctx, ns, filter
+func (_m *Manager) GetTokenBridges(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenBridge, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.TokenBridge
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.TokenBridge); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenBridge)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetTokenConnectors provides a mock function with given fields: ctx, ns
 func (_m *Manager) GetTokenConnectors(ctx context.Context, ns string) ([]*fftypes.TokenConnector, error) {
 	ret := _m.Called(ctx, ns)
@@ -275,6 +417,38 @@ func (_m *Manager) GetTokenConnectors(ctx context.Context, ns string) ([]*fftype
 	return r0, r1
 }
 
+// GetTokenNFTs provides a mock function with given fields: ctx, ns, filter
+func (_m *Manager) GetTokenNFTs(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.TokenNFT, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.TokenNFT
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.TokenNFT); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenNFT)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetTokenPool provides a mock function with given fields: ctx, ns, connector, poolName
 func (_m *Manager) GetTokenPool(ctx context.Context, ns string, connector string, poolName string) (*fftypes.TokenPool, error) {
 	ret := _m.Called(ctx, ns, connector, poolName)
@@ -534,6 +708,20 @@ func (_m *Manager) NewTransfer(ns string, transfer *fftypes.TokenTransferInput)
 	return r0
 }
 
+// RetryOperation provides a mock function with given fields: ctx, op
+func (_m *Manager) RetryOperation(ctx context.Context, op *fftypes.Operation) error {
+	ret := _m.Called(ctx, op)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
+		r0 = rf(ctx, op)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
@@ -548,6 +736,43 @@ func (_m *Manager) Start() error {
 	return r0
 }
 
+// TokenApproval provides a mock function with given fields: ctx, ns, approval, waitConfirm
+func (_m *Manager) TokenApproval(ctx context.Context, ns string, approval *fftypes.TokenApprovalInput, waitConfirm bool) (*fftypes.TokenApproval, error) {
+	ret := _m.Called(ctx, ns, approval, waitConfirm)
+
+	var r0 *fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.TokenApprovalInput, bool) *fftypes.TokenApproval); ok {
+		r0 = rf(ctx, ns, approval, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.TokenApprovalInput, bool) error); ok {
+		r1 = rf(ctx, ns, approval, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenBridgeOpUpdate provides a mock function with given fields: ctx, op, txState, errorMessage
+func (_m *Manager) TokenBridgeOpUpdate(ctx context.Context, op *fftypes.Operation, txState fftypes.OpStatus, errorMessage string) error {
+	ret := _m.Called(ctx, op, txState, errorMessage)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation, fftypes.OpStatus, string) error); ok {
+		r0 = rf(ctx, op, txState, errorMessage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TransferTokens provides a mock function with given fields: ctx, ns, transfer, waitConfirm
 func (_m *Manager) TransferTokens(ctx context.Context, ns string, transfer *fftypes.TokenTransferInput, waitConfirm bool) (*fftypes.TokenTransfer, error) {
 	ret := _m.Called(ctx, ns, transfer, waitConfirm)
@@ -14,6 +14,34 @@ type Submitter struct {
 	mock.Mock
 }
 
+// PreflightCheck provides a mock function with given fields: ctx, batch
+func (_m *Submitter) PreflightCheck(ctx context.Context, batch *fftypes.Batch) error {
+	ret := _m.Called(ctx, batch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Batch) error); ok {
+		r0 = rf(ctx, batch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryOperation provides a mock function with given fields: ctx, op
+func (_m *Submitter) RetryOperation(ctx context.Context, op *fftypes.Operation) error {
+	ret := _m.Called(ctx, op)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
+		r0 = rf(ctx, op)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SubmitPinnedBatch provides a mock function with given fields: ctx, batch, contexts
 func (_m *Submitter) SubmitPinnedBatch(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
 	ret := _m.Called(ctx, batch, contexts)
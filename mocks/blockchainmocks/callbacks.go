@@ -28,6 +28,20 @@ func (_m *Callbacks) BatchPinComplete(batch *blockchain.BatchPin, signingIdentit
 	return r0
 }
 
+// BlockchainEvent provides a mock function with given fields: event
+func (_m *Callbacks) BlockchainEvent(event *fftypes.BlockchainEvent) error {
+	ret := _m.Called(event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*fftypes.BlockchainEvent) error); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BlockchainOpUpdate provides a mock function with given fields: operationID, txState, errorMessage, opOutput
 func (_m *Callbacks) BlockchainOpUpdate(operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
 	ret := _m.Called(operationID, txState, errorMessage, opOutput)
@@ -18,6 +18,20 @@ type Plugin struct {
 	mock.Mock
 }
 
+// AddContractListener provides a mock function with given fields: ctx, listener
+func (_m *Plugin) AddContractListener(ctx context.Context, listener *fftypes.ContractListener) error {
+	ret := _m.Called(ctx, listener)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.ContractListener) error); ok {
+		r0 = rf(ctx, listener)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Capabilities provides a mock function with given fields:
 func (_m *Plugin) Capabilities() *blockchain.Capabilities {
 	ret := _m.Called()
@@ -34,6 +48,45 @@ func (_m *Plugin) Capabilities() *blockchain.Capabilities {
 	return r0
 }
 
+// GetNativeBalance provides a mock function with given fields: ctx, ns, signingKey
+func (_m *Plugin) GetNativeBalance(ctx context.Context, ns string, signingKey string) (*fftypes.BigInt, error) {
+	ret := _m.Called(ctx, ns, signingKey)
+
+	var r0 *fftypes.BigInt
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.BigInt); ok {
+		r0 = rf(ctx, ns, signingKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BigInt)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, signingKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransactionFee provides a mock function with given fields: ns, receipt
+func (_m *Plugin) GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee {
+	ret := _m.Called(ns, receipt)
+
+	var r0 *fftypes.TransactionFee
+	if rf, ok := ret.Get(0).(func(string, fftypes.JSONObject) *fftypes.TransactionFee); ok {
+		r0 = rf(ns, receipt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TransactionFee)
+		}
+	}
+
+	return r0
+}
+
 // Init provides a mock function with given fields: ctx, prefix, callbacks
 func (_m *Plugin) Init(ctx context.Context, prefix config.Prefix, callbacks blockchain.Callbacks) error {
 	ret := _m.Called(ctx, prefix, callbacks)
@@ -53,6 +106,20 @@ func (_m *Plugin) InitPrefix(prefix config.Prefix) {
 	_m.Called(prefix)
 }
 
+// InvokeContract provides a mock function with given fields: ctx, ns, operationID, signingKey, location, method, input
+func (_m *Plugin) InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error {
+	ret := _m.Called(ctx, ns, operationID, signingKey, location, method, input)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID, string, fftypes.JSONObject, *fftypes.FFIMethod, fftypes.JSONObject) error); ok {
+		r0 = rf(ctx, ns, operationID, signingKey, location, method, input)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Name provides a mock function with given fields:
 func (_m *Plugin) Name() string {
 	ret := _m.Called()
@@ -67,20 +134,80 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
-// ResolveSigningKey provides a mock function with given fields: ctx, signingKey
-func (_m *Plugin) ResolveSigningKey(ctx context.Context, signingKey string) (string, error) {
-	ret := _m.Called(ctx, signingKey)
+// NormalizeContractLocation provides a mock function with given fields: ctx, ns, location
+func (_m *Plugin) NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error) {
+	ret := _m.Called(ctx, ns, location)
+
+	var r0 fftypes.JSONObject
+	if rf, ok := ret.Get(0).(func(context.Context, string, fftypes.JSONObject) fftypes.JSONObject); ok {
+		r0 = rf(ctx, ns, location)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.JSONObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, fftypes.JSONObject) error); ok {
+		r1 = rf(ctx, ns, location)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryContract provides a mock function with given fields: ctx, ns, location, method, input
+func (_m *Plugin) QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error) {
+	ret := _m.Called(ctx, ns, location, method, input)
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, fftypes.JSONObject, *fftypes.FFIMethod, fftypes.JSONObject) interface{}); ok {
+		r0 = rf(ctx, ns, location, method, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, fftypes.JSONObject, *fftypes.FFIMethod, fftypes.JSONObject) error); ok {
+		r1 = rf(ctx, ns, location, method, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetEventStream provides a mock function with given fields: ctx, fromBlock
+func (_m *Plugin) ResetEventStream(ctx context.Context, fromBlock string) error {
+	ret := _m.Called(ctx, fromBlock)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, fromBlock)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResolveSigningKey provides a mock function with given fields: ctx, ns, signingKey
+func (_m *Plugin) ResolveSigningKey(ctx context.Context, ns string, signingKey string) (string, error) {
+	ret := _m.Called(ctx, ns, signingKey)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, signingKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, ns, signingKey)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, signingKey)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, signingKey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -115,3 +242,17 @@ func (_m *Plugin) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID,
 
 	return r0
 }
+
+// SubmitBatchPins provides a mock function with given fields: ctx, operationID, ledgerID, signingKey, batches
+func (_m *Plugin) SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*blockchain.BatchPin) error {
+	ret := _m.Called(ctx, operationID, ledgerID, signingKey, batches)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, *fftypes.UUID, string, []*blockchain.BatchPin) error); ok {
+		r0 = rf(ctx, operationID, ledgerID, signingKey, batches)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
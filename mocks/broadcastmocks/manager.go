@@ -85,6 +85,52 @@ func (_m *Manager) BroadcastDefinitionAsNode(ctx context.Context, ns string, def
 	return r0, r1
 }
 
+// BroadcastDelegation provides a mock function with given fields: ctx, ns, delegation, waitConfirm
+func (_m *Manager) BroadcastDelegation(ctx context.Context, ns string, delegation *fftypes.Delegation, waitConfirm bool) (*fftypes.Delegation, error) {
+	ret := _m.Called(ctx, ns, delegation, waitConfirm)
+
+	var r0 *fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Delegation, bool) *fftypes.Delegation); ok {
+		r0 = rf(ctx, ns, delegation, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Delegation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Delegation, bool) error); ok {
+		r1 = rf(ctx, ns, delegation, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BroadcastIdentityClaim provides a mock function with given fields: ctx, ns, def, signingIdentity, tag, waitConfirm
+func (_m *Manager) BroadcastIdentityClaim(ctx context.Context, ns string, def *fftypes.CustomIdentity, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, def, signingIdentity, tag, waitConfirm)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.CustomIdentity, *fftypes.Identity, fftypes.SystemTag, bool) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, def, signingIdentity, tag, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.CustomIdentity, *fftypes.Identity, fftypes.SystemTag, bool) error); ok {
+		r1 = rf(ctx, ns, def, signingIdentity, tag, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BroadcastMessage provides a mock function with given fields: ctx, ns, in, waitConfirm
 func (_m *Manager) BroadcastMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (*fftypes.Message, error) {
 	ret := _m.Called(ctx, ns, in, waitConfirm)
@@ -131,6 +177,29 @@ func (_m *Manager) BroadcastNamespace(ctx context.Context, ns *fftypes.Namespace
 	return r0, r1
 }
 
+// BroadcastPseudonym provides a mock function with given fields: ctx, ns, pseudonym, waitConfirm
+func (_m *Manager) BroadcastPseudonym(ctx context.Context, ns string, pseudonym *fftypes.Pseudonym, waitConfirm bool) (*fftypes.Pseudonym, error) {
+	ret := _m.Called(ctx, ns, pseudonym, waitConfirm)
+
+	var r0 *fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Pseudonym, bool) *fftypes.Pseudonym); ok {
+		r0 = rf(ctx, ns, pseudonym, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Pseudonym)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Pseudonym, bool) error); ok {
+		r1 = rf(ctx, ns, pseudonym, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BroadcastRootOrgDefinition provides a mock function with given fields: ctx, def, signingIdentity, tag, waitConfirm
 func (_m *Manager) BroadcastRootOrgDefinition(ctx context.Context, def *fftypes.Organization, signingIdentity *fftypes.Identity, tag fftypes.SystemTag, waitConfirm bool) (*fftypes.Message, error) {
 	ret := _m.Called(ctx, def, signingIdentity, tag, waitConfirm)
@@ -193,6 +262,29 @@ func (_m *Manager) NewBroadcast(ns string, in *fftypes.MessageInOut) sysmessagin
 	return r0
 }
 
+// RevokeDelegation provides a mock function with given fields: ctx, ns, id, waitConfirm
+func (_m *Manager) RevokeDelegation(ctx context.Context, ns string, id string, waitConfirm bool) (*fftypes.Delegation, error) {
+	ret := _m.Called(ctx, ns, id, waitConfirm)
+
+	var r0 *fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) *fftypes.Delegation); ok {
+		r0 = rf(ctx, ns, id, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Delegation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool) error); ok {
+		r1 = rf(ctx, ns, id, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
@@ -12,6 +12,8 @@ import (
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Plugin is an autogenerated mock type for the Plugin type
@@ -35,6 +37,22 @@ func (_m *Plugin) Capabilities() *database.Capabilities {
 	return r0
 }
 
+// ConnectionStatus provides a mock function with given fields: ctx
+func (_m *Plugin) ConnectionStatus(ctx context.Context) *fftypes.NodeStatusDatabase {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.NodeStatusDatabase
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.NodeStatusDatabase); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NodeStatusDatabase)
+		}
+	}
+
+	return r0
+}
+
 // DeleteBlob provides a mock function with given fields: ctx, sequence
 func (_m *Plugin) DeleteBlob(ctx context.Context, sequence int64) error {
 	ret := _m.Called(ctx, sequence)
@@ -49,6 +67,20 @@ func (_m *Plugin) DeleteBlob(ctx context.Context, sequence int64) error {
 	return r0
 }
 
+// DeleteBlockedPin provides a mock function with given fields: ctx, id
+func (_m *Plugin) DeleteBlockedPin(ctx context.Context, id *fftypes.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteConfigRecord provides a mock function with given fields: ctx, key
 func (_m *Plugin) DeleteConfigRecord(ctx context.Context, key string) error {
 	ret := _m.Called(ctx, key)
@@ -147,16 +179,16 @@ func (_m *Plugin) DeleteSubscriptionByID(ctx context.Context, id *fftypes.UUID)
 	return r0
 }
 
-// GetBatchByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Batch, error) {
+// GetAuditRecordByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetAuditRecordByID(ctx context.Context, id *fftypes.UUID) (*fftypes.AuditRecord, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Batch
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Batch); ok {
+	var r0 *fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.AuditRecord); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Batch)
+			r0 = ret.Get(0).(*fftypes.AuditRecord)
 		}
 	}
 
@@ -170,71 +202,16 @@ func (_m *Plugin) GetBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.
 	return r0, r1
 }
 
-// GetBatches provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetBatches(ctx context.Context, filter database.Filter) ([]*fftypes.Batch, *database.FilterResult, error) {
-	ret := _m.Called(ctx, filter)
-
-	var r0 []*fftypes.Batch
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Batch); ok {
-		r0 = rf(ctx, filter)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Batch)
-		}
-	}
-
-	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
-		r1 = rf(ctx, filter)
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*database.FilterResult)
-		}
-	}
-
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
-		r2 = rf(ctx, filter)
-	} else {
-		r2 = ret.Error(2)
-	}
-
-	return r0, r1, r2
-}
-
-// GetBlobMatchingHash provides a mock function with given fields: ctx, hash
-func (_m *Plugin) GetBlobMatchingHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Blob, error) {
-	ret := _m.Called(ctx, hash)
-
-	var r0 *fftypes.Blob
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Blob); ok {
-		r0 = rf(ctx, hash)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Blob)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
-		r1 = rf(ctx, hash)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
-// GetBlobs provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetBlobs(ctx context.Context, filter database.Filter) ([]*fftypes.Blob, *database.FilterResult, error) {
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetAuditRecords(ctx context.Context, filter database.Filter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Blob
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Blob); ok {
+	var r0 []*fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.AuditRecord); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Blob)
+			r0 = ret.Get(0).([]*fftypes.AuditRecord)
 		}
 	}
 
@@ -257,22 +234,22 @@ func (_m *Plugin) GetBlobs(ctx context.Context, filter database.Filter) ([]*ffty
 	return r0, r1, r2
 }
 
-// GetChartHistogram provides a mock function with given fields: ctx, ns, intervals, collection
-func (_m *Plugin) GetChartHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection database.CollectionName) ([]*fftypes.ChartHistogram, error) {
-	ret := _m.Called(ctx, ns, intervals, collection)
+// GetBatchByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Batch, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 []*fftypes.ChartHistogram
-	if rf, ok := ret.Get(0).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) []*fftypes.ChartHistogram); ok {
-		r0 = rf(ctx, ns, intervals, collection)
+	var r0 *fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Batch); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.ChartHistogram)
+			r0 = ret.Get(0).(*fftypes.Batch)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) error); ok {
-		r1 = rf(ctx, ns, intervals, collection)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -280,22 +257,22 @@ func (_m *Plugin) GetChartHistogram(ctx context.Context, ns string, intervals []
 	return r0, r1
 }
 
-// GetConfigRecord provides a mock function with given fields: ctx, key
-func (_m *Plugin) GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error) {
-	ret := _m.Called(ctx, key)
+// GetBatchQuarantineByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetBatchQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BatchQuarantine, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.ConfigRecord
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.ConfigRecord); ok {
-		r0 = rf(ctx, key)
+	var r0 *fftypes.BatchQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.BatchQuarantine); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.ConfigRecord)
+			r0 = ret.Get(0).(*fftypes.BatchQuarantine)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, key)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -303,16 +280,16 @@ func (_m *Plugin) GetConfigRecord(ctx context.Context, key string) (*fftypes.Con
 	return r0, r1
 }
 
-// GetConfigRecords provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
+// GetBatchQuarantines provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBatchQuarantines(ctx context.Context, filter database.Filter) ([]*fftypes.BatchQuarantine, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.ConfigRecord
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.ConfigRecord); ok {
+	var r0 []*fftypes.BatchQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.BatchQuarantine); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.ConfigRecord)
+			r0 = ret.Get(0).([]*fftypes.BatchQuarantine)
 		}
 	}
 
@@ -335,16 +312,16 @@ func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter)
 	return r0, r1, r2
 }
 
-// GetData provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftypes.Data, *database.FilterResult, error) {
+// GetBatches provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBatches(ctx context.Context, filter database.Filter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Data
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Data); ok {
+	var r0 []*fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Batch); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Data)
+			r0 = ret.Get(0).([]*fftypes.Batch)
 		}
 	}
 
@@ -367,22 +344,22 @@ func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftyp
 	return r0, r1, r2
 }
 
-// GetDataByID provides a mock function with given fields: ctx, id, withValue
-func (_m *Plugin) GetDataByID(ctx context.Context, id *fftypes.UUID, withValue bool) (*fftypes.Data, error) {
-	ret := _m.Called(ctx, id, withValue)
+// GetBlobMatchingHash provides a mock function with given fields: ctx, hash
+func (_m *Plugin) GetBlobMatchingHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Blob, error) {
+	ret := _m.Called(ctx, hash)
 
-	var r0 *fftypes.Data
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, bool) *fftypes.Data); ok {
-		r0 = rf(ctx, id, withValue)
+	var r0 *fftypes.Blob
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Blob); ok {
+		r0 = rf(ctx, hash)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Data)
+			r0 = ret.Get(0).(*fftypes.Blob)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, bool) error); ok {
-		r1 = rf(ctx, id, withValue)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -390,16 +367,39 @@ func (_m *Plugin) GetDataByID(ctx context.Context, id *fftypes.UUID, withValue b
 	return r0, r1
 }
 
-// GetDataRefs provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetDataRefs(ctx context.Context, filter database.Filter) (fftypes.DataRefs, *database.FilterResult, error) {
+// GetBlobStats provides a mock function with given fields: ctx
+func (_m *Plugin) GetBlobStats(ctx context.Context) (*fftypes.BlobStats, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.BlobStats
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.BlobStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlobStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlobs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBlobs(ctx context.Context, filter database.Filter) ([]*fftypes.Blob, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 fftypes.DataRefs
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) fftypes.DataRefs); ok {
+	var r0 []*fftypes.Blob
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Blob); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(fftypes.DataRefs)
+			r0 = ret.Get(0).([]*fftypes.Blob)
 		}
 	}
 
@@ -422,16 +422,16 @@ func (_m *Plugin) GetDataRefs(ctx context.Context, filter database.Filter) (ffty
 	return r0, r1, r2
 }
 
-// GetDatatypeByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetDatatypeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Datatype, error) {
+// GetBlockchainEventByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetBlockchainEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockchainEvent, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Datatype); ok {
+	var r0 *fftypes.BlockchainEvent
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.BlockchainEvent); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Datatype)
+			r0 = ret.Get(0).(*fftypes.BlockchainEvent)
 		}
 	}
 
@@ -445,39 +445,16 @@ func (_m *Plugin) GetDatatypeByID(ctx context.Context, id *fftypes.UUID) (*fftyp
 	return r0, r1
 }
 
-// GetDatatypeByName provides a mock function with given fields: ctx, ns, name, version
-func (_m *Plugin) GetDatatypeByName(ctx context.Context, ns string, name string, version string) (*fftypes.Datatype, error) {
-	ret := _m.Called(ctx, ns, name, version)
-
-	var r0 *fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.Datatype); ok {
-		r0 = rf(ctx, ns, name, version)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Datatype)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, ns, name, version)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
-// GetDatatypes provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*fftypes.Datatype, *database.FilterResult, error) {
+// GetBlockchainEvents provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBlockchainEvents(ctx context.Context, filter database.Filter) ([]*fftypes.BlockchainEvent, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Datatype); ok {
+	var r0 []*fftypes.BlockchainEvent
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.BlockchainEvent); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Datatype)
+			r0 = ret.Get(0).([]*fftypes.BlockchainEvent)
 		}
 	}
 
@@ -500,16 +477,16 @@ func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*
 	return r0, r1, r2
 }
 
-// GetEventByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Event, error) {
+// GetBlockedPinByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetBlockedPinByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockedPin, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Event
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Event); ok {
+	var r0 *fftypes.BlockedPin
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.BlockedPin); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Event)
+			r0 = ret.Get(0).(*fftypes.BlockedPin)
 		}
 	}
 
@@ -523,16 +500,16 @@ func (_m *Plugin) GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.
 	return r0, r1
 }
 
-// GetEvents provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fftypes.Event, *database.FilterResult, error) {
+// GetBlockedPins provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBlockedPins(ctx context.Context, filter database.Filter) ([]*fftypes.BlockedPin, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Event
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Event); ok {
+	var r0 []*fftypes.BlockedPin
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.BlockedPin); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Event)
+			r0 = ret.Get(0).([]*fftypes.BlockedPin)
 		}
 	}
 
@@ -555,22 +532,22 @@ func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fft
 	return r0, r1, r2
 }
 
-// GetGroupByHash provides a mock function with given fields: ctx, hash
-func (_m *Plugin) GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Group, error) {
-	ret := _m.Called(ctx, hash)
+// GetChartHistogram provides a mock function with given fields: ctx, ns, intervals, collection
+func (_m *Plugin) GetChartHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection database.CollectionName) ([]*fftypes.ChartHistogram, error) {
+	ret := _m.Called(ctx, ns, intervals, collection)
 
-	var r0 *fftypes.Group
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Group); ok {
-		r0 = rf(ctx, hash)
+	var r0 []*fftypes.ChartHistogram
+	if rf, ok := ret.Get(0).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) []*fftypes.ChartHistogram); ok {
+		r0 = rf(ctx, ns, intervals, collection)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Group)
+			r0 = ret.Get(0).([]*fftypes.ChartHistogram)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
-		r1 = rf(ctx, hash)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) error); ok {
+		r1 = rf(ctx, ns, intervals, collection)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -578,48 +555,1277 @@ func (_m *Plugin) GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (*f
 	return r0, r1
 }
 
-// GetGroups provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fftypes.Group, *database.FilterResult, error) {
-	ret := _m.Called(ctx, filter)
+// GetChartTopAuthors provides a mock function with given fields: ctx, ns, startTime, endTime, limit
+func (_m *Plugin) GetChartTopAuthors(ctx context.Context, ns string, startTime *fftypes.FFTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, limit)
 
-	var r0 []*fftypes.Group
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Group); ok {
-		r0 = rf(ctx, filter)
+	var r0 []*fftypes.ChartRanking
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) []*fftypes.ChartRanking); ok {
+		r0 = rf(ctx, ns, startTime, endTime, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Group)
+			r0 = ret.Get(0).([]*fftypes.ChartRanking)
 		}
 	}
 
-	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
-		r1 = rf(ctx, filter)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, limit)
 	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*database.FilterResult)
-		}
+		r1 = ret.Error(1)
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
-		r2 = rf(ctx, filter)
-	} else {
-		r2 = ret.Error(2)
+	return r0, r1
+}
+
+// GetChartTopTopics provides a mock function with given fields: ctx, ns, startTime, endTime, limit
+func (_m *Plugin) GetChartTopTopics(ctx context.Context, ns string, startTime *fftypes.FFTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, limit)
+
+	var r0 []*fftypes.ChartRanking
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) []*fftypes.ChartRanking); ok {
+		r0 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ChartRanking)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChartTypeHistogram provides a mock function with given fields: ctx, ns, intervals, collection
+func (_m *Plugin) GetChartTypeHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection database.CollectionName) ([]*fftypes.ChartHistogramTyped, error) {
+	ret := _m.Called(ctx, ns, intervals, collection)
+
+	var r0 []*fftypes.ChartHistogramTyped
+	if rf, ok := ret.Get(0).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) []*fftypes.ChartHistogramTyped); ok {
+		r0 = rf(ctx, ns, intervals, collection)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ChartHistogramTyped)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []fftypes.ChartHistogramInterval, database.CollectionName) error); ok {
+		r1 = rf(ctx, ns, intervals, collection)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfigRecord provides a mock function with given fields: ctx, key
+func (_m *Plugin) GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *fftypes.ConfigRecord
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.ConfigRecord); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConfigRecord)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfigRecords provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.ConfigRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.ConfigRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ConfigRecord)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetContractListenerByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetContractListenerByID(ctx context.Context, id *fftypes.UUID) (*fftypes.ContractListener, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.ContractListener
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.ContractListener); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ContractListener)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetContractListenerByProtocolID provides a mock function with given fields: ctx, protocolID
+func (_m *Plugin) GetContractListenerByProtocolID(ctx context.Context, protocolID string) (*fftypes.ContractListener, error) {
+	ret := _m.Called(ctx, protocolID)
+
+	var r0 *fftypes.ContractListener
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.ContractListener); ok {
+		r0 = rf(ctx, protocolID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ContractListener)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, protocolID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetContractListeners provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetContractListeners(ctx context.Context, filter database.Filter) ([]*fftypes.ContractListener, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.ContractListener
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.ContractListener); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ContractListener)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetCustomIdentities provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetCustomIdentities(ctx context.Context, filter database.Filter) ([]*fftypes.CustomIdentity, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetCustomIdentityByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetCustomIdentityByID(ctx context.Context, id *fftypes.UUID) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomIdentityByKey provides a mock function with given fields: ctx, key
+func (_m *Plugin) GetCustomIdentityByKey(ctx context.Context, key string) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomIdentityByName provides a mock function with given fields: ctx, ns, name
+func (_m *Plugin) GetCustomIdentityByName(ctx context.Context, ns string, name string) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, ns, name)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, ns, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetData provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftypes.Data, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Data
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Data); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Data)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDataByID provides a mock function with given fields: ctx, id, withValue
+func (_m *Plugin) GetDataByID(ctx context.Context, id *fftypes.UUID, withValue bool) (*fftypes.Data, error) {
+	ret := _m.Called(ctx, id, withValue)
+
+	var r0 *fftypes.Data
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, bool) *fftypes.Data); ok {
+		r0 = rf(ctx, id, withValue)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Data)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, bool) error); ok {
+		r1 = rf(ctx, id, withValue)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDataRefs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetDataRefs(ctx context.Context, filter database.Filter) (fftypes.DataRefs, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 fftypes.DataRefs
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) fftypes.DataRefs); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.DataRefs)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDatatypeByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetDatatypeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Datatype, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Datatype); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Datatype)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDatatypeByName provides a mock function with given fields: ctx, ns, name, version
+func (_m *Plugin) GetDatatypeByName(ctx context.Context, ns string, name string, version string) (*fftypes.Datatype, error) {
+	ret := _m.Called(ctx, ns, name, version)
+
+	var r0 *fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.Datatype); ok {
+		r0 = rf(ctx, ns, name, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Datatype)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, ns, name, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDatatypes provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*fftypes.Datatype, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Datatype); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Datatype)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDelegationByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetDelegationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Delegation, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Delegation); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Delegation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDelegations provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetDelegations(ctx context.Context, filter database.Filter) ([]*fftypes.Delegation, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Delegation); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Delegation)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetEventByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Event, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Event
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Event); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEvents provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fftypes.Event, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Event
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Event); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Event)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetFFI provides a mock function with given fields: ctx, ns, name, version
+func (_m *Plugin) GetFFI(ctx context.Context, ns string, name string, version string) (*fftypes.FFI, error) {
+	ret := _m.Called(ctx, ns, name, version)
+
+	var r0 *fftypes.FFI
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.FFI); ok {
+		r0 = rf(ctx, ns, name, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.FFI)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, ns, name, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFFIByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetFFIByID(ctx context.Context, id *fftypes.UUID) (*fftypes.FFI, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.FFI
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.FFI); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.FFI)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFFIs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetFFIs(ctx context.Context, filter database.Filter) ([]*fftypes.FFI, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.FFI
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.FFI); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.FFI)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetGroupByHash provides a mock function with given fields: ctx, hash
+func (_m *Plugin) GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Group, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Group); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroupKey provides a mock function with given fields: ctx, group, epoch
+func (_m *Plugin) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, group, epoch)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, int64) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, group, epoch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32, int64) error); ok {
+		r1 = rf(ctx, group, epoch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroupKeys provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetGroupKeys(ctx context.Context, filter database.Filter) ([]*fftypes.GroupKey, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.GroupKey); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.GroupKey)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetGroups provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fftypes.Group, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Group); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Group)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetIdentityUsage provides a mock function with given fields: ctx, ns, author, keys, startTime, endTime
+func (_m *Plugin) GetIdentityUsage(ctx context.Context, ns string, author string, keys []string, startTime *fftypes.FFTime, endTime *fftypes.FFTime) (*fftypes.IdentityUsage, error) {
+	ret := _m.Called(ctx, ns, author, keys, startTime, endTime)
+
+	var r0 *fftypes.IdentityUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, *fftypes.FFTime, *fftypes.FFTime) *fftypes.IdentityUsage); ok {
+		r0 = rf(ctx, ns, author, keys, startTime, endTime)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.IdentityUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, *fftypes.FFTime, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, ns, author, keys, startTime, endTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLegalHoldByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetLegalHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.LegalHold, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.LegalHold); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LegalHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLegalHolds provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetLegalHolds(ctx context.Context, filter database.Filter) ([]*fftypes.LegalHold, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.LegalHold); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.LegalHold)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessageByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetMessageByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Message); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageHoldByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetMessageHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.MessageHold, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.MessageHold
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.MessageHold); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageHolds provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetMessageHolds(ctx context.Context, filter database.Filter) ([]*fftypes.MessageHold, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.MessageHold
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MessageHold); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageHold)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessageRefs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetMessageRefs(ctx context.Context, filter database.Filter) ([]*fftypes.MessageRef, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.MessageRef
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MessageRef); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageRef)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessageTransitions provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetMessageTransitions(ctx context.Context, filter database.Filter) ([]*fftypes.MessageTransition, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.MessageTransition
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MessageTransition); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageTransition)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessages provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Message); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Message)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessagesForData provides a mock function with given fields: ctx, dataID, filter
+func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
+	ret := _m.Called(ctx, dataID, filter)
+
+	var r0 []*fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Filter) []*fftypes.Message); ok {
+		r0 = rf(ctx, dataID, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Message)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, dataID, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *fftypes.UUID, database.Filter) error); ok {
+		r2 = rf(ctx, dataID, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMetricCounts provides a mock function with given fields: ctx, metric, interval, startTime, endTime
+func (_m *Plugin) GetMetricCounts(ctx context.Context, metric fftypes.FFEnum, interval time.Duration, startTime *fftypes.FFTime, endTime *fftypes.FFTime) ([]*fftypes.MetricRollup, error) {
+	ret := _m.Called(ctx, metric, interval, startTime, endTime)
+
+	var r0 []*fftypes.MetricRollup
+	if rf, ok := ret.Get(0).(func(context.Context, fftypes.FFEnum, time.Duration, *fftypes.FFTime, *fftypes.FFTime) []*fftypes.MetricRollup); ok {
+		r0 = rf(ctx, metric, interval, startTime, endTime)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MetricRollup)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, fftypes.FFEnum, time.Duration, *fftypes.FFTime, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, metric, interval, startTime, endTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMetricRollups provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetMetricRollups(ctx context.Context, filter database.Filter) ([]*fftypes.MetricRollup, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.MetricRollup
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MetricRollup); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MetricRollup)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetNamespace provides a mock function with given fields: ctx, name
+func (_m *Plugin) GetNamespace(ctx context.Context, name string) (*fftypes.Namespace, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *fftypes.Namespace
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Namespace); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Namespace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNamespaces provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]*fftypes.Namespace, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Namespace
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Namespace); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Namespace)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetNextPinByContextAndIdentity provides a mock function with given fields: ctx, _a1, identity
+func (_m *Plugin) GetNextPinByContextAndIdentity(ctx context.Context, _a1 *fftypes.Bytes32, identity string) (*fftypes.NextPin, error) {
+	ret := _m.Called(ctx, _a1, identity)
+
+	var r0 *fftypes.NextPin
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, string) *fftypes.NextPin); ok {
+		r0 = rf(ctx, _a1, identity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NextPin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32, string) error); ok {
+		r1 = rf(ctx, _a1, identity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextPinByHash provides a mock function with given fields: ctx, hash
+func (_m *Plugin) GetNextPinByHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.NextPin, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *fftypes.NextPin
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.NextPin); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NextPin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextPins provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetNextPins(ctx context.Context, filter database.Filter) ([]*fftypes.NextPin, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.NextPin
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.NextPin); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.NextPin)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetNode provides a mock function with given fields: ctx, owner, name
+func (_m *Plugin) GetNode(ctx context.Context, owner string, name string) (*fftypes.Node, error) {
+	ret := _m.Called(ctx, owner, name)
+
+	var r0 *fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Node); ok {
+		r0 = rf(ctx, owner, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Node)
+		}
 	}
 
-	return r0, r1, r2
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, owner, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetMessageByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetMessageByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Message, error) {
+// GetNodeByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetNodeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Node, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Message
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Message); ok {
+	var r0 *fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Node); ok {
 		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Message)
+			r0 = ret.Get(0).(*fftypes.Node)
 		}
 	}
 
@@ -633,16 +1839,16 @@ func (_m *Plugin) GetMessageByID(ctx context.Context, id *fftypes.UUID) (*fftype
 	return r0, r1
 }
 
-// GetMessageRefs provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetMessageRefs(ctx context.Context, filter database.Filter) ([]*fftypes.MessageRef, *database.FilterResult, error) {
+// GetNodes provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*fftypes.Node, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.MessageRef
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MessageRef); ok {
+	var r0 []*fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Node); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.MessageRef)
+			r0 = ret.Get(0).([]*fftypes.Node)
 		}
 	}
 
@@ -665,16 +1871,39 @@ func (_m *Plugin) GetMessageRefs(ctx context.Context, filter database.Filter) ([
 	return r0, r1, r2
 }
 
-// GetMessages provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
+// GetNonce provides a mock function with given fields: ctx, hash
+func (_m *Plugin) GetNonce(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Nonce, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *fftypes.Nonce
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Nonce); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Nonce)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNonces provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetNonces(ctx context.Context, filter database.Filter) ([]*fftypes.Nonce, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Message
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Message); ok {
+	var r0 []*fftypes.Nonce
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Nonce); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Message)
+			r0 = ret.Get(0).([]*fftypes.Nonce)
 		}
 	}
 
@@ -697,22 +1926,45 @@ func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*f
 	return r0, r1, r2
 }
 
-// GetMessagesForData provides a mock function with given fields: ctx, dataID, filter
-func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
-	ret := _m.Called(ctx, dataID, filter)
+// GetOffset provides a mock function with given fields: ctx, t, name
+func (_m *Plugin) GetOffset(ctx context.Context, t fftypes.FFEnum, name string) (*fftypes.Offset, error) {
+	ret := _m.Called(ctx, t, name)
 
-	var r0 []*fftypes.Message
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Filter) []*fftypes.Message); ok {
-		r0 = rf(ctx, dataID, filter)
+	var r0 *fftypes.Offset
+	if rf, ok := ret.Get(0).(func(context.Context, fftypes.FFEnum, string) *fftypes.Offset); ok {
+		r0 = rf(ctx, t, name)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Message)
+			r0 = ret.Get(0).(*fftypes.Offset)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, fftypes.FFEnum, string) error); ok {
+		r1 = rf(ctx, t, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOffsets provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetOffsets(ctx context.Context, filter database.Filter) ([]*fftypes.Offset, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Offset
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Offset); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Offset)
 		}
 	}
 
 	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, database.Filter) *database.FilterResult); ok {
-		r1 = rf(ctx, dataID, filter)
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*database.FilterResult)
@@ -720,8 +1972,8 @@ func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID,
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, *fftypes.UUID, database.Filter) error); ok {
-		r2 = rf(ctx, dataID, filter)
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -729,22 +1981,22 @@ func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID,
 	return r0, r1, r2
 }
 
-// GetNamespace provides a mock function with given fields: ctx, name
-func (_m *Plugin) GetNamespace(ctx context.Context, name string) (*fftypes.Namespace, error) {
-	ret := _m.Called(ctx, name)
+// GetOperationByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetOperationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Namespace
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Namespace); ok {
-		r0 = rf(ctx, name)
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Operation); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Namespace)
+			r0 = ret.Get(0).(*fftypes.Operation)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, name)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -752,16 +2004,16 @@ func (_m *Plugin) GetNamespace(ctx context.Context, name string) (*fftypes.Names
 	return r0, r1
 }
 
-// GetNamespaces provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]*fftypes.Namespace, *database.FilterResult, error) {
+// GetOperations provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]*fftypes.Operation, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Namespace
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Namespace); ok {
+	var r0 []*fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Operation); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Namespace)
+			r0 = ret.Get(0).([]*fftypes.Operation)
 		}
 	}
 
@@ -784,22 +2036,22 @@ func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]
 	return r0, r1, r2
 }
 
-// GetNextPinByContextAndIdentity provides a mock function with given fields: ctx, _a1, identity
-func (_m *Plugin) GetNextPinByContextAndIdentity(ctx context.Context, _a1 *fftypes.Bytes32, identity string) (*fftypes.NextPin, error) {
-	ret := _m.Called(ctx, _a1, identity)
+// GetOrganizationByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetOrganizationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Organization, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.NextPin
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, string) *fftypes.NextPin); ok {
-		r0 = rf(ctx, _a1, identity)
+	var r0 *fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Organization); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.NextPin)
+			r0 = ret.Get(0).(*fftypes.Organization)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32, string) error); ok {
-		r1 = rf(ctx, _a1, identity)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -807,22 +2059,22 @@ func (_m *Plugin) GetNextPinByContextAndIdentity(ctx context.Context, _a1 *fftyp
 	return r0, r1
 }
 
-// GetNextPinByHash provides a mock function with given fields: ctx, hash
-func (_m *Plugin) GetNextPinByHash(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.NextPin, error) {
-	ret := _m.Called(ctx, hash)
+// GetOrganizationByIdentity provides a mock function with given fields: ctx, identity
+func (_m *Plugin) GetOrganizationByIdentity(ctx context.Context, identity string) (*fftypes.Organization, error) {
+	ret := _m.Called(ctx, identity)
 
-	var r0 *fftypes.NextPin
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.NextPin); ok {
-		r0 = rf(ctx, hash)
+	var r0 *fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Organization); ok {
+		r0 = rf(ctx, identity)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.NextPin)
+			r0 = ret.Get(0).(*fftypes.Organization)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
-		r1 = rf(ctx, hash)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, identity)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -830,16 +2082,39 @@ func (_m *Plugin) GetNextPinByHash(ctx context.Context, hash *fftypes.Bytes32) (
 	return r0, r1
 }
 
-// GetNextPins provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNextPins(ctx context.Context, filter database.Filter) ([]*fftypes.NextPin, *database.FilterResult, error) {
+// GetOrganizationByName provides a mock function with given fields: ctx, name
+func (_m *Plugin) GetOrganizationByName(ctx context.Context, name string) (*fftypes.Organization, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Organization); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Organization)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrganizations provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.NextPin
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.NextPin); ok {
+	var r0 []*fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Organization); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.NextPin)
+			r0 = ret.Get(0).([]*fftypes.Organization)
 		}
 	}
 
@@ -862,22 +2137,22 @@ func (_m *Plugin) GetNextPins(ctx context.Context, filter database.Filter) ([]*f
 	return r0, r1, r2
 }
 
-// GetNode provides a mock function with given fields: ctx, owner, name
-func (_m *Plugin) GetNode(ctx context.Context, owner string, name string) (*fftypes.Node, error) {
-	ret := _m.Called(ctx, owner, name)
+// GetPinQuarantineByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetPinQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PinQuarantine, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Node
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Node); ok {
-		r0 = rf(ctx, owner, name)
+	var r0 *fftypes.PinQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.PinQuarantine); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Node)
+			r0 = ret.Get(0).(*fftypes.PinQuarantine)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, owner, name)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -885,39 +2160,48 @@ func (_m *Plugin) GetNode(ctx context.Context, owner string, name string) (*ffty
 	return r0, r1
 }
 
-// GetNodeByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetNodeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Node, error) {
-	ret := _m.Called(ctx, id)
+// GetPinQuarantines provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetPinQuarantines(ctx context.Context, filter database.Filter) ([]*fftypes.PinQuarantine, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
 
-	var r0 *fftypes.Node
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Node); ok {
-		r0 = rf(ctx, id)
+	var r0 []*fftypes.PinQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.PinQuarantine); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Node)
+			r0 = ret.Get(0).([]*fftypes.PinQuarantine)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
-		r1 = rf(ctx, id)
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
-}
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
 
-// GetNodes provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*fftypes.Node, *database.FilterResult, error) {
+	return r0, r1, r2
+}
+
+// GetPins provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetPins(ctx context.Context, filter database.Filter) ([]*fftypes.Pin, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Node
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Node); ok {
+	var r0 []*fftypes.Pin
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Pin); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Node)
+			r0 = ret.Get(0).([]*fftypes.Pin)
 		}
 	}
 
@@ -940,22 +2224,22 @@ func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*ffty
 	return r0, r1, r2
 }
 
-// GetNonce provides a mock function with given fields: ctx, hash
-func (_m *Plugin) GetNonce(ctx context.Context, hash *fftypes.Bytes32) (*fftypes.Nonce, error) {
-	ret := _m.Called(ctx, hash)
+// GetPolicyApprovalByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetPolicyApprovalByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PolicyApproval, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Nonce
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.Nonce); ok {
-		r0 = rf(ctx, hash)
+	var r0 *fftypes.PolicyApproval
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.PolicyApproval); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Nonce)
+			r0 = ret.Get(0).(*fftypes.PolicyApproval)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
-		r1 = rf(ctx, hash)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -963,16 +2247,16 @@ func (_m *Plugin) GetNonce(ctx context.Context, hash *fftypes.Bytes32) (*fftypes
 	return r0, r1
 }
 
-// GetNonces provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNonces(ctx context.Context, filter database.Filter) ([]*fftypes.Nonce, *database.FilterResult, error) {
+// GetPolicyApprovals provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetPolicyApprovals(ctx context.Context, filter database.Filter) ([]*fftypes.PolicyApproval, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Nonce
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Nonce); ok {
+	var r0 []*fftypes.PolicyApproval
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.PolicyApproval); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Nonce)
+			r0 = ret.Get(0).([]*fftypes.PolicyApproval)
 		}
 	}
 
@@ -995,22 +2279,22 @@ func (_m *Plugin) GetNonces(ctx context.Context, filter database.Filter) ([]*fft
 	return r0, r1, r2
 }
 
-// GetOffset provides a mock function with given fields: ctx, t, name
-func (_m *Plugin) GetOffset(ctx context.Context, t fftypes.FFEnum, name string) (*fftypes.Offset, error) {
-	ret := _m.Called(ctx, t, name)
+// GetPseudonymByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetPseudonymByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Pseudonym, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Offset
-	if rf, ok := ret.Get(0).(func(context.Context, fftypes.FFEnum, string) *fftypes.Offset); ok {
-		r0 = rf(ctx, t, name)
+	var r0 *fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Pseudonym); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Offset)
+			r0 = ret.Get(0).(*fftypes.Pseudonym)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, fftypes.FFEnum, string) error); ok {
-		r1 = rf(ctx, t, name)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1018,16 +2302,39 @@ func (_m *Plugin) GetOffset(ctx context.Context, t fftypes.FFEnum, name string)
 	return r0, r1
 }
 
-// GetOffsets provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetOffsets(ctx context.Context, filter database.Filter) ([]*fftypes.Offset, *database.FilterResult, error) {
+// GetPseudonymByKey provides a mock function with given fields: ctx, key
+func (_m *Plugin) GetPseudonymByKey(ctx context.Context, key string) (*fftypes.Pseudonym, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Pseudonym); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Pseudonym)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPseudonyms provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetPseudonyms(ctx context.Context, filter database.Filter) ([]*fftypes.Pseudonym, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Offset
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Offset); ok {
+	var r0 []*fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Pseudonym); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Offset)
+			r0 = ret.Get(0).([]*fftypes.Pseudonym)
 		}
 	}
 
@@ -1050,39 +2357,48 @@ func (_m *Plugin) GetOffsets(ctx context.Context, filter database.Filter) ([]*ff
 	return r0, r1, r2
 }
 
-// GetOperationByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetOperationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Operation, error) {
-	ret := _m.Called(ctx, id)
+// GetReceipts provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetReceipts(ctx context.Context, filter database.Filter) ([]*fftypes.MessageReceipt, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
 
-	var r0 *fftypes.Operation
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Operation); ok {
-		r0 = rf(ctx, id)
+	var r0 []*fftypes.MessageReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.MessageReceipt); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Operation)
+			r0 = ret.Get(0).([]*fftypes.MessageReceipt)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
-		r1 = rf(ctx, id)
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// GetOperations provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]*fftypes.Operation, *database.FilterResult, error) {
+// GetSigningActivity provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetSigningActivity(ctx context.Context, filter database.Filter) ([]*fftypes.SigningActivity, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Operation
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Operation); ok {
+	var r0 []*fftypes.SigningActivity
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.SigningActivity); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Operation)
+			r0 = ret.Get(0).([]*fftypes.SigningActivity)
 		}
 	}
 
@@ -1105,22 +2421,22 @@ func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]
 	return r0, r1, r2
 }
 
-// GetOrganizationByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetOrganizationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Organization, error) {
-	ret := _m.Called(ctx, id)
+// GetSnapshot provides a mock function with given fields: ctx, t, name
+func (_m *Plugin) GetSnapshot(ctx context.Context, t fftypes.FFEnum, name string) (*fftypes.Snapshot, error) {
+	ret := _m.Called(ctx, t, name)
 
-	var r0 *fftypes.Organization
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Organization); ok {
-		r0 = rf(ctx, id)
+	var r0 *fftypes.Snapshot
+	if rf, ok := ret.Get(0).(func(context.Context, fftypes.FFEnum, string) *fftypes.Snapshot); ok {
+		r0 = rf(ctx, t, name)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Organization)
+			r0 = ret.Get(0).(*fftypes.Snapshot)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, fftypes.FFEnum, string) error); ok {
+		r1 = rf(ctx, t, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1128,22 +2444,22 @@ func (_m *Plugin) GetOrganizationByID(ctx context.Context, id *fftypes.UUID) (*f
 	return r0, r1
 }
 
-// GetOrganizationByIdentity provides a mock function with given fields: ctx, identity
-func (_m *Plugin) GetOrganizationByIdentity(ctx context.Context, identity string) (*fftypes.Organization, error) {
-	ret := _m.Called(ctx, identity)
+// GetSubscriptionByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetSubscriptionByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Subscription, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.Organization
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Organization); ok {
-		r0 = rf(ctx, identity)
+	var r0 *fftypes.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Subscription); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Organization)
+			r0 = ret.Get(0).(*fftypes.Subscription)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, identity)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1151,22 +2467,22 @@ func (_m *Plugin) GetOrganizationByIdentity(ctx context.Context, identity string
 	return r0, r1
 }
 
-// GetOrganizationByName provides a mock function with given fields: ctx, name
-func (_m *Plugin) GetOrganizationByName(ctx context.Context, name string) (*fftypes.Organization, error) {
-	ret := _m.Called(ctx, name)
+// GetSubscriptionByName provides a mock function with given fields: ctx, ns, name
+func (_m *Plugin) GetSubscriptionByName(ctx context.Context, ns string, name string) (*fftypes.Subscription, error) {
+	ret := _m.Called(ctx, ns, name)
 
-	var r0 *fftypes.Organization
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Organization); ok {
-		r0 = rf(ctx, name)
+	var r0 *fftypes.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Subscription); ok {
+		r0 = rf(ctx, ns, name)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Organization)
+			r0 = ret.Get(0).(*fftypes.Subscription)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, name)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1174,16 +2490,16 @@ func (_m *Plugin) GetOrganizationByName(ctx context.Context, name string) (*ffty
 	return r0, r1
 }
 
-// GetOrganizations provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter) ([]*fftypes.Organization, *database.FilterResult, error) {
+// GetSubscriptions provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Organization
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Organization); ok {
+	var r0 []*fftypes.Subscription
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Subscription); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Organization)
+			r0 = ret.Get(0).([]*fftypes.Subscription)
 		}
 	}
 
@@ -1206,16 +2522,48 @@ func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter)
 	return r0, r1, r2
 }
 
-// GetPins provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetPins(ctx context.Context, filter database.Filter) ([]*fftypes.Pin, *database.FilterResult, error) {
+// GetTokenAccountPools provides a mock function with given fields: ctx, key, filter
+func (_m *Plugin) GetTokenAccountPools(ctx context.Context, key string, filter database.Filter) ([]*fftypes.TokenAccountPool, *database.FilterResult, error) {
+	ret := _m.Called(ctx, key, filter)
+
+	var r0 []*fftypes.TokenAccountPool
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.Filter) []*fftypes.TokenAccountPool); ok {
+		r0 = rf(ctx, key, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenAccountPool)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, key, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.Filter) error); ok {
+		r2 = rf(ctx, key, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTokenAccounts provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetTokenAccounts(ctx context.Context, filter database.Filter) ([]*fftypes.TokenAccount, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Pin
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Pin); ok {
+	var r0 []*fftypes.TokenAccount
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenAccount); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Pin)
+			r0 = ret.Get(0).([]*fftypes.TokenAccount)
 		}
 	}
 
@@ -1238,22 +2586,22 @@ func (_m *Plugin) GetPins(ctx context.Context, filter database.Filter) ([]*fftyp
 	return r0, r1, r2
 }
 
-// GetSubscriptionByID provides a mock function with given fields: ctx, id
-func (_m *Plugin) GetSubscriptionByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Subscription, error) {
-	ret := _m.Called(ctx, id)
+// GetTokenApproval provides a mock function with given fields: ctx, localID
+func (_m *Plugin) GetTokenApproval(ctx context.Context, localID *fftypes.UUID) (*fftypes.TokenApproval, error) {
+	ret := _m.Called(ctx, localID)
 
-	var r0 *fftypes.Subscription
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.Subscription); ok {
-		r0 = rf(ctx, id)
+	var r0 *fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.TokenApproval); ok {
+		r0 = rf(ctx, localID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Subscription)
+			r0 = ret.Get(0).(*fftypes.TokenApproval)
 		}
 	}
 
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
-		r1 = rf(ctx, id)
+		r1 = rf(ctx, localID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1261,22 +2609,77 @@ func (_m *Plugin) GetSubscriptionByID(ctx context.Context, id *fftypes.UUID) (*f
 	return r0, r1
 }
 
-// GetSubscriptionByName provides a mock function with given fields: ctx, ns, name
-func (_m *Plugin) GetSubscriptionByName(ctx context.Context, ns string, name string) (*fftypes.Subscription, error) {
-	ret := _m.Called(ctx, ns, name)
+// GetTokenApprovalByProtocolID provides a mock function with given fields: ctx, connector, protocolID
+func (_m *Plugin) GetTokenApprovalByProtocolID(ctx context.Context, connector string, protocolID string) (*fftypes.TokenApproval, error) {
+	ret := _m.Called(ctx, connector, protocolID)
+
+	var r0 *fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.TokenApproval); ok {
+		r0 = rf(ctx, connector, protocolID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TokenApproval)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, connector, protocolID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTokenApprovals provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetTokenApprovals(ctx context.Context, filter database.Filter) ([]*fftypes.TokenApproval, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.TokenApproval
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenApproval); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.TokenApproval)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTokenBalance provides a mock function with given fields: ctx, poolID, tokenIndex, identity
+func (_m *Plugin) GetTokenBalance(ctx context.Context, poolID *fftypes.UUID, tokenIndex string, identity string) (*fftypes.TokenBalance, error) {
+	ret := _m.Called(ctx, poolID, tokenIndex, identity)
 
-	var r0 *fftypes.Subscription
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Subscription); ok {
-		r0 = rf(ctx, ns, name)
+	var r0 *fftypes.TokenBalance
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, string) *fftypes.TokenBalance); ok {
+		r0 = rf(ctx, poolID, tokenIndex, identity)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Subscription)
+			r0 = ret.Get(0).(*fftypes.TokenBalance)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, ns, name)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, string, string) error); ok {
+		r1 = rf(ctx, poolID, tokenIndex, identity)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1284,16 +2687,16 @@ func (_m *Plugin) GetSubscriptionByName(ctx context.Context, ns string, name str
 	return r0, r1
 }
 
-// GetSubscriptions provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter) ([]*fftypes.Subscription, *database.FilterResult, error) {
+// GetTokenBalances provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetTokenBalances(ctx context.Context, filter database.Filter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.Subscription
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Subscription); ok {
+	var r0 []*fftypes.TokenBalance
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenBalance); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Subscription)
+			r0 = ret.Get(0).([]*fftypes.TokenBalance)
 		}
 	}
 
@@ -1316,48 +2719,39 @@ func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter)
 	return r0, r1, r2
 }
 
-// GetTokenAccountPools provides a mock function with given fields: ctx, key, filter
-func (_m *Plugin) GetTokenAccountPools(ctx context.Context, key string, filter database.Filter) ([]*fftypes.TokenAccountPool, *database.FilterResult, error) {
-	ret := _m.Called(ctx, key, filter)
+// GetTokenBridgeByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetTokenBridgeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.TokenBridge, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 []*fftypes.TokenAccountPool
-	if rf, ok := ret.Get(0).(func(context.Context, string, database.Filter) []*fftypes.TokenAccountPool); ok {
-		r0 = rf(ctx, key, filter)
+	var r0 *fftypes.TokenBridge
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.TokenBridge); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.TokenAccountPool)
-		}
-	}
-
-	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.Filter) *database.FilterResult); ok {
-		r1 = rf(ctx, key, filter)
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*database.FilterResult)
+			r0 = ret.Get(0).(*fftypes.TokenBridge)
 		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, database.Filter) error); ok {
-		r2 = rf(ctx, key, filter)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// GetTokenAccounts provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetTokenAccounts(ctx context.Context, filter database.Filter) ([]*fftypes.TokenAccount, *database.FilterResult, error) {
+// GetTokenBridges provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetTokenBridges(ctx context.Context, filter database.Filter) ([]*fftypes.TokenBridge, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.TokenAccount
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenAccount); ok {
+	var r0 []*fftypes.TokenBridge
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenBridge); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.TokenAccount)
+			r0 = ret.Get(0).([]*fftypes.TokenBridge)
 		}
 	}
 
@@ -1380,22 +2774,22 @@ func (_m *Plugin) GetTokenAccounts(ctx context.Context, filter database.Filter)
 	return r0, r1, r2
 }
 
-// GetTokenBalance provides a mock function with given fields: ctx, poolID, tokenIndex, identity
-func (_m *Plugin) GetTokenBalance(ctx context.Context, poolID *fftypes.UUID, tokenIndex string, identity string) (*fftypes.TokenBalance, error) {
-	ret := _m.Called(ctx, poolID, tokenIndex, identity)
+// GetTokenNFT provides a mock function with given fields: ctx, poolID, tokenIndex
+func (_m *Plugin) GetTokenNFT(ctx context.Context, poolID *fftypes.UUID, tokenIndex string) (*fftypes.TokenNFT, error) {
+	ret := _m.Called(ctx, poolID, tokenIndex)
 
-	var r0 *fftypes.TokenBalance
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, string) *fftypes.TokenBalance); ok {
-		r0 = rf(ctx, poolID, tokenIndex, identity)
+	var r0 *fftypes.TokenNFT
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string) *fftypes.TokenNFT); ok {
+		r0 = rf(ctx, poolID, tokenIndex)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.TokenBalance)
+			r0 = ret.Get(0).(*fftypes.TokenNFT)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, string, string) error); ok {
-		r1 = rf(ctx, poolID, tokenIndex, identity)
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, string) error); ok {
+		r1 = rf(ctx, poolID, tokenIndex)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1403,16 +2797,16 @@ func (_m *Plugin) GetTokenBalance(ctx context.Context, poolID *fftypes.UUID, tok
 	return r0, r1
 }
 
-// GetTokenBalances provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetTokenBalances(ctx context.Context, filter database.Filter) ([]*fftypes.TokenBalance, *database.FilterResult, error) {
+// GetTokenNFTs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetTokenNFTs(ctx context.Context, filter database.Filter) ([]*fftypes.TokenNFT, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.TokenBalance
-	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenBalance); ok {
+	var r0 []*fftypes.TokenNFT
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.TokenNFT); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.TokenBalance)
+			r0 = ret.Get(0).([]*fftypes.TokenNFT)
 		}
 	}
 
@@ -1663,19 +3057,261 @@ func (_m *Plugin) GetTransactions(ctx context.Context, filter database.Filter) (
 	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
 		r2 = rf(ctx, filter)
 	} else {
-		r2 = ret.Error(2)
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetUnmatchedReceiptByID provides a mock function with given fields: ctx, id
+func (_m *Plugin) GetUnmatchedReceiptByID(ctx context.Context, id *fftypes.UUID) (*fftypes.UnmatchedReceipt, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.UnmatchedReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *fftypes.UnmatchedReceipt); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.UnmatchedReceipt)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUnmatchedReceipts provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetUnmatchedReceipts(ctx context.Context, filter database.Filter) ([]*fftypes.UnmatchedReceipt, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.UnmatchedReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.UnmatchedReceipt); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.UnmatchedReceipt)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Init provides a mock function with given fields: ctx, prefix, callbacks
+func (_m *Plugin) Init(ctx context.Context, prefix config.Prefix, callbacks database.Callbacks) error {
+	ret := _m.Called(ctx, prefix, callbacks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, config.Prefix, database.Callbacks) error); ok {
+		r0 = rf(ctx, prefix, callbacks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InitPrefix provides a mock function with given fields: prefix
+func (_m *Plugin) InitPrefix(prefix config.Prefix) {
+	_m.Called(prefix)
+}
+
+// InsertAuditRecord provides a mock function with given fields: ctx, record
+func (_m *Plugin) InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AuditRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertBatchQuarantine provides a mock function with given fields: ctx, quarantine
+func (_m *Plugin) InsertBatchQuarantine(ctx context.Context, quarantine *fftypes.BatchQuarantine) error {
+	ret := _m.Called(ctx, quarantine)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.BatchQuarantine) error); ok {
+		r0 = rf(ctx, quarantine)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertBlob provides a mock function with given fields: ctx, blob
+func (_m *Plugin) InsertBlob(ctx context.Context, blob *fftypes.Blob) error {
+	ret := _m.Called(ctx, blob)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Blob) error); ok {
+		r0 = rf(ctx, blob)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertBlockchainEvent provides a mock function with given fields: ctx, event
+func (_m *Plugin) InsertBlockchainEvent(ctx context.Context, event *fftypes.BlockchainEvent) error {
+	ret := _m.Called(ctx, event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.BlockchainEvent) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertBlockedPin provides a mock function with given fields: ctx, blocked
+func (_m *Plugin) InsertBlockedPin(ctx context.Context, blocked *fftypes.BlockedPin) error {
+	ret := _m.Called(ctx, blocked)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.BlockedPin) error); ok {
+		r0 = rf(ctx, blocked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertContractListener provides a mock function with given fields: ctx, listener
+func (_m *Plugin) InsertContractListener(ctx context.Context, listener *fftypes.ContractListener) error {
+	ret := _m.Called(ctx, listener)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.ContractListener) error); ok {
+		r0 = rf(ctx, listener)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertEvent provides a mock function with given fields: ctx, data
+func (_m *Plugin) InsertEvent(ctx context.Context, data *fftypes.Event) error {
+	ret := _m.Called(ctx, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Event) error); ok {
+		r0 = rf(ctx, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertGroupKey provides a mock function with given fields: ctx, groupKey
+func (_m *Plugin) InsertGroupKey(ctx context.Context, groupKey *fftypes.GroupKey) error {
+	ret := _m.Called(ctx, groupKey)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.GroupKey) error); ok {
+		r0 = rf(ctx, groupKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertLegalHold provides a mock function with given fields: ctx, hold
+func (_m *Plugin) InsertLegalHold(ctx context.Context, hold *fftypes.LegalHold) error {
+	ret := _m.Called(ctx, hold)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.LegalHold) error); ok {
+		r0 = rf(ctx, hold)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertMessageHold provides a mock function with given fields: ctx, hold
+func (_m *Plugin) InsertMessageHold(ctx context.Context, hold *fftypes.MessageHold) error {
+	ret := _m.Called(ctx, hold)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.MessageHold) error); ok {
+		r0 = rf(ctx, hold)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertMessageTransition provides a mock function with given fields: ctx, transition
+func (_m *Plugin) InsertMessageTransition(ctx context.Context, transition *fftypes.MessageTransition) error {
+	ret := _m.Called(ctx, transition)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.MessageTransition) error); ok {
+		r0 = rf(ctx, transition)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertNextPin provides a mock function with given fields: ctx, nextpin
+func (_m *Plugin) InsertNextPin(ctx context.Context, nextpin *fftypes.NextPin) error {
+	ret := _m.Called(ctx, nextpin)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.NextPin) error); ok {
+		r0 = rf(ctx, nextpin)
+	} else {
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1, r2
+	return r0
 }
 
-// Init provides a mock function with given fields: ctx, prefix, callbacks
-func (_m *Plugin) Init(ctx context.Context, prefix config.Prefix, callbacks database.Callbacks) error {
-	ret := _m.Called(ctx, prefix, callbacks)
+// InsertOperation provides a mock function with given fields: ctx, operation
+func (_m *Plugin) InsertOperation(ctx context.Context, operation *fftypes.Operation) error {
+	ret := _m.Called(ctx, operation)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, config.Prefix, database.Callbacks) error); ok {
-		r0 = rf(ctx, prefix, callbacks)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
+		r0 = rf(ctx, operation)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1683,18 +3319,27 @@ func (_m *Plugin) Init(ctx context.Context, prefix config.Prefix, callbacks data
 	return r0
 }
 
-// InitPrefix provides a mock function with given fields: prefix
-func (_m *Plugin) InitPrefix(prefix config.Prefix) {
-	_m.Called(prefix)
+// InsertPinQuarantine provides a mock function with given fields: ctx, quarantine
+func (_m *Plugin) InsertPinQuarantine(ctx context.Context, quarantine *fftypes.PinQuarantine) error {
+	ret := _m.Called(ctx, quarantine)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.PinQuarantine) error); ok {
+		r0 = rf(ctx, quarantine)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// InsertBlob provides a mock function with given fields: ctx, blob
-func (_m *Plugin) InsertBlob(ctx context.Context, blob *fftypes.Blob) error {
-	ret := _m.Called(ctx, blob)
+// InsertPolicyApproval provides a mock function with given fields: ctx, approval
+func (_m *Plugin) InsertPolicyApproval(ctx context.Context, approval *fftypes.PolicyApproval) error {
+	ret := _m.Called(ctx, approval)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Blob) error); ok {
-		r0 = rf(ctx, blob)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.PolicyApproval) error); ok {
+		r0 = rf(ctx, approval)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1702,13 +3347,13 @@ func (_m *Plugin) InsertBlob(ctx context.Context, blob *fftypes.Blob) error {
 	return r0
 }
 
-// InsertEvent provides a mock function with given fields: ctx, data
-func (_m *Plugin) InsertEvent(ctx context.Context, data *fftypes.Event) error {
-	ret := _m.Called(ctx, data)
+// InsertSigningActivity provides a mock function with given fields: ctx, activity
+func (_m *Plugin) InsertSigningActivity(ctx context.Context, activity *fftypes.SigningActivity) error {
+	ret := _m.Called(ctx, activity)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Event) error); ok {
-		r0 = rf(ctx, data)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.SigningActivity) error); ok {
+		r0 = rf(ctx, activity)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1716,13 +3361,13 @@ func (_m *Plugin) InsertEvent(ctx context.Context, data *fftypes.Event) error {
 	return r0
 }
 
-// InsertNextPin provides a mock function with given fields: ctx, nextpin
-func (_m *Plugin) InsertNextPin(ctx context.Context, nextpin *fftypes.NextPin) error {
-	ret := _m.Called(ctx, nextpin)
+// InsertTokenBridge provides a mock function with given fields: ctx, bridge
+func (_m *Plugin) InsertTokenBridge(ctx context.Context, bridge *fftypes.TokenBridge) error {
+	ret := _m.Called(ctx, bridge)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.NextPin) error); ok {
-		r0 = rf(ctx, nextpin)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.TokenBridge) error); ok {
+		r0 = rf(ctx, bridge)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1730,13 +3375,13 @@ func (_m *Plugin) InsertNextPin(ctx context.Context, nextpin *fftypes.NextPin) e
 	return r0
 }
 
-// InsertOperation provides a mock function with given fields: ctx, operation
-func (_m *Plugin) InsertOperation(ctx context.Context, operation *fftypes.Operation) error {
-	ret := _m.Called(ctx, operation)
+// InsertUnmatchedReceipt provides a mock function with given fields: ctx, receipt
+func (_m *Plugin) InsertUnmatchedReceipt(ctx context.Context, receipt *fftypes.UnmatchedReceipt) error {
+	ret := _m.Called(ctx, receipt)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
-		r0 = rf(ctx, operation)
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UnmatchedReceipt) error); ok {
+		r0 = rf(ctx, receipt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -1772,7 +3417,34 @@ func (_m *Plugin) RunAsGroup(ctx context.Context, fn func(context.Context) error
 	return r0
 }
 
-// SetPinDispatched provides a mock function with given fields: ctx, sequence
+// RunAsRetryableGroup provides a mock function with given fields: ctx, fn
+func (_m *Plugin) RunAsRetryableGroup(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetOperationRetry provides a mock function with given fields: ctx, id, retryID
+func (_m *Plugin) SetOperationRetry(ctx context.Context, id *fftypes.UUID, retryID *fftypes.UUID) error {
+	ret := _m.Called(ctx, id, retryID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, *fftypes.UUID) error); ok {
+		r0 = rf(ctx, id, retryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 func (_m *Plugin) SetPinDispatched(ctx context.Context, sequence int64) error {
 	ret := _m.Called(ctx, sequence)
 
@@ -1800,6 +3472,48 @@ func (_m *Plugin) UpdateBatch(ctx context.Context, id *fftypes.UUID, update data
 	return r0
 }
 
+// UpdateBatchQuarantine provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateBatchQuarantine(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBlobRefs provides a mock function with given fields: ctx, sequence, delta
+func (_m *Plugin) UpdateBlobRefs(ctx context.Context, sequence int64, delta int64) error {
+	ret := _m.Called(ctx, sequence, delta)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, sequence, delta)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBlockedPin provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateBlockedPin(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateData provides a mock function with given fields: ctx, id, update
 func (_m *Plugin) UpdateData(ctx context.Context, id *fftypes.UUID, update database.Update) error {
 	ret := _m.Called(ctx, id, update)
@@ -1856,6 +3570,20 @@ func (_m *Plugin) UpdateGroup(ctx context.Context, hash *fftypes.Bytes32, update
 	return r0
 }
 
+// UpdateLegalHold provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateLegalHold(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateMessage provides a mock function with given fields: ctx, id, update
 func (_m *Plugin) UpdateMessage(ctx context.Context, id *fftypes.UUID, update database.Update) error {
 	ret := _m.Called(ctx, id, update)
@@ -1870,6 +3598,20 @@ func (_m *Plugin) UpdateMessage(ctx context.Context, id *fftypes.UUID, update da
 	return r0
 }
 
+// UpdateMessageHold provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateMessageHold(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateMessages provides a mock function with given fields: ctx, filter, update
 func (_m *Plugin) UpdateMessages(ctx context.Context, filter database.Filter, update database.Update) error {
 	ret := _m.Called(ctx, filter, update)
@@ -1954,6 +3696,34 @@ func (_m *Plugin) UpdateOrganization(ctx context.Context, id *fftypes.UUID, upda
 	return r0
 }
 
+// UpdatePinQuarantine provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdatePinQuarantine(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePolicyApproval provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdatePolicyApproval(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateSubscription provides a mock function with given fields: ctx, ns, name, update
 func (_m *Plugin) UpdateSubscription(ctx context.Context, ns string, name string, update database.Update) error {
 	ret := _m.Called(ctx, ns, name, update)
@@ -1982,6 +3752,20 @@ func (_m *Plugin) UpdateTokenBalances(ctx context.Context, transfer *fftypes.Tok
 	return r0
 }
 
+// UpdateTokenBridge provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateTokenBridge(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTransaction provides a mock function with given fields: ctx, id, update
 func (_m *Plugin) UpdateTransaction(ctx context.Context, id *fftypes.UUID, update database.Update) error {
 	ret := _m.Called(ctx, id, update)
@@ -1996,6 +3780,20 @@ func (_m *Plugin) UpdateTransaction(ctx context.Context, id *fftypes.UUID, updat
 	return r0
 }
 
+// UpdateUnmatchedReceipt provides a mock function with given fields: ctx, id, update
+func (_m *Plugin) UpdateUnmatchedReceipt(ctx context.Context, id *fftypes.UUID, update database.Update) error {
+	ret := _m.Called(ctx, id, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, database.Update) error); ok {
+		r0 = rf(ctx, id, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertBatch provides a mock function with given fields: ctx, data, allowHashUpdate
 func (_m *Plugin) UpsertBatch(ctx context.Context, data *fftypes.Batch, allowHashUpdate bool) error {
 	ret := _m.Called(ctx, data, allowHashUpdate)
@@ -2024,6 +3822,20 @@ func (_m *Plugin) UpsertConfigRecord(ctx context.Context, data *fftypes.ConfigRe
 	return r0
 }
 
+// UpsertCustomIdentity provides a mock function with given fields: ctx, identity
+func (_m *Plugin) UpsertCustomIdentity(ctx context.Context, identity *fftypes.CustomIdentity) error {
+	ret := _m.Called(ctx, identity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.CustomIdentity) error); ok {
+		r0 = rf(ctx, identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertData provides a mock function with given fields: ctx, data, optimization
 func (_m *Plugin) UpsertData(ctx context.Context, data *fftypes.Data, optimization database.UpsertOptimization) error {
 	ret := _m.Called(ctx, data, optimization)
@@ -2052,6 +3864,34 @@ func (_m *Plugin) UpsertDatatype(ctx context.Context, datadef *fftypes.Datatype,
 	return r0
 }
 
+// UpsertDelegation provides a mock function with given fields: ctx, delegation
+func (_m *Plugin) UpsertDelegation(ctx context.Context, delegation *fftypes.Delegation) error {
+	ret := _m.Called(ctx, delegation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Delegation) error); ok {
+		r0 = rf(ctx, delegation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertFFI provides a mock function with given fields: ctx, ffi
+func (_m *Plugin) UpsertFFI(ctx context.Context, ffi *fftypes.FFI) error {
+	ret := _m.Called(ctx, ffi)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.FFI) error); ok {
+		r0 = rf(ctx, ffi)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertGroup provides a mock function with given fields: ctx, data, allowExisting
 func (_m *Plugin) UpsertGroup(ctx context.Context, data *fftypes.Group, allowExisting bool) error {
 	ret := _m.Called(ctx, data, allowExisting)
@@ -2080,6 +3920,20 @@ func (_m *Plugin) UpsertMessage(ctx context.Context, message *fftypes.Message, o
 	return r0
 }
 
+// UpsertMetricRollup provides a mock function with given fields: ctx, rollup
+func (_m *Plugin) UpsertMetricRollup(ctx context.Context, rollup *fftypes.MetricRollup) error {
+	ret := _m.Called(ctx, rollup)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.MetricRollup) error); ok {
+		r0 = rf(ctx, rollup)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertNamespace provides a mock function with given fields: ctx, data, allowExisting
 func (_m *Plugin) UpsertNamespace(ctx context.Context, data *fftypes.Namespace, allowExisting bool) error {
 	ret := _m.Called(ctx, data, allowExisting)
@@ -2164,6 +4018,48 @@ func (_m *Plugin) UpsertPin(ctx context.Context, parked *fftypes.Pin) error {
 	return r0
 }
 
+// UpsertPseudonym provides a mock function with given fields: ctx, pseudonym
+func (_m *Plugin) UpsertPseudonym(ctx context.Context, pseudonym *fftypes.Pseudonym) error {
+	ret := _m.Called(ctx, pseudonym)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Pseudonym) error); ok {
+		r0 = rf(ctx, pseudonym)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertReceipt provides a mock function with given fields: ctx, receipt
+func (_m *Plugin) UpsertReceipt(ctx context.Context, receipt *fftypes.MessageReceipt) error {
+	ret := _m.Called(ctx, receipt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.MessageReceipt) error); ok {
+		r0 = rf(ctx, receipt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertSnapshot provides a mock function with given fields: ctx, snapshot
+func (_m *Plugin) UpsertSnapshot(ctx context.Context, snapshot *fftypes.Snapshot) error {
+	ret := _m.Called(ctx, snapshot)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Snapshot) error); ok {
+		r0 = rf(ctx, snapshot)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertSubscription provides a mock function with given fields: ctx, data, allowExisting
 func (_m *Plugin) UpsertSubscription(ctx context.Context, data *fftypes.Subscription, allowExisting bool) error {
 	ret := _m.Called(ctx, data, allowExisting)
@@ -2178,6 +4074,34 @@ func (_m *Plugin) UpsertSubscription(ctx context.Context, data *fftypes.Subscrip
 	return r0
 }
 
+// UpsertTokenApproval provides a mock function with given fields: ctx, approval
+func (_m *Plugin) UpsertTokenApproval(ctx context.Context, approval *fftypes.TokenApproval) error {
+	ret := _m.Called(ctx, approval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.TokenApproval) error); ok {
+		r0 = rf(ctx, approval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertTokenNFT provides a mock function with given fields: ctx, nft
+func (_m *Plugin) UpsertTokenNFT(ctx context.Context, nft *fftypes.TokenNFT) error {
+	ret := _m.Called(ctx, nft)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.TokenNFT) error); ok {
+		r0 = rf(ctx, nft)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpsertTokenPool provides a mock function with given fields: ctx, pool
 func (_m *Plugin) UpsertTokenPool(ctx context.Context, pool *fftypes.TokenPool) error {
 	ret := _m.Called(ctx, pool)
@@ -5,6 +5,8 @@ package datamocks
 import (
 	context "context"
 
+	data "github.com/hyperledger/firefly/internal/data"
+
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
 	io "io"
@@ -77,6 +79,52 @@ func (_m *Manager) DownloadBLOB(ctx context.Context, ns string, dataID string) (
 	return r0, r1
 }
 
+// DownloadBLOBRange provides a mock function with given fields: ctx, ns, dataID, rangeHeader
+func (_m *Manager) DownloadBLOBRange(ctx context.Context, ns string, dataID string, rangeHeader string) (*fftypes.BlobDownload, error) {
+	ret := _m.Called(ctx, ns, dataID, rangeHeader)
+
+	var r0 *fftypes.BlobDownload
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.BlobDownload); ok {
+		r0 = rf(ctx, ns, dataID, rangeHeader)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlobDownload)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, ns, dataID, rangeHeader)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlobStats provides a mock function with given fields: ctx
+func (_m *Manager) GetBlobStats(ctx context.Context) (*fftypes.BlobStats, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.BlobStats
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.BlobStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlobStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMessageData provides a mock function with given fields: ctx, msg, withValue
 func (_m *Manager) GetMessageData(ctx context.Context, msg *fftypes.Message, withValue bool) ([]*fftypes.Data, bool, error) {
 	ret := _m.Called(ctx, msg, withValue)
@@ -107,6 +155,34 @@ func (_m *Manager) GetMessageData(ctx context.Context, msg *fftypes.Message, wit
 	return r0, r1, r2
 }
 
+// ImportData provides a mock function with given fields: ctx, ns, req
+func (_m *Manager) ImportData(ctx context.Context, ns string, req *fftypes.DataImport) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, req)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.DataImport) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.DataImport) error); ok {
+		r1 = rf(ctx, ns, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegisterTransformer provides a mock function with given fields: t
+func (_m *Manager) RegisterTransformer(t data.Transformer) {
+	_m.Called(t)
+}
+
 // ResolveInlineDataBroadcast provides a mock function with given fields: ctx, ns, inData
 func (_m *Manager) ResolveInlineDataBroadcast(ctx context.Context, ns string, inData fftypes.InlineData) (fftypes.DataRefs, []*fftypes.DataAndBlob, error) {
 	ret := _m.Called(ctx, ns, inData)
@@ -162,6 +238,29 @@ func (_m *Manager) ResolveInlineDataPrivate(ctx context.Context, ns string, inDa
 	return r0, r1
 }
 
+// StoreBlob provides a mock function with given fields: ctx, blob
+func (_m *Manager) StoreBlob(ctx context.Context, blob *fftypes.Blob) (*fftypes.Blob, error) {
+	ret := _m.Called(ctx, blob)
+
+	var r0 *fftypes.Blob
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Blob) *fftypes.Blob); ok {
+		r0 = rf(ctx, blob)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Blob)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Blob) error); ok {
+		r1 = rf(ctx, blob)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UploadBLOB provides a mock function with given fields: ctx, ns, inData, blob, autoMeta
 func (_m *Manager) UploadBLOB(ctx context.Context, ns string, inData *fftypes.DataRefOrValue, blob *fftypes.Multipart, autoMeta bool) (*fftypes.Data, error) {
 	ret := _m.Called(ctx, ns, inData, blob, autoMeta)
@@ -62,6 +62,29 @@ func (_m *DefinitionHandlers) GetGroupByID(ctx context.Context, id string) (*fft
 	return r0, r1
 }
 
+// GetGroupKey provides a mock function with given fields: ctx, group, epoch
+func (_m *DefinitionHandlers) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, group, epoch)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, int64) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, group, epoch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32, int64) error); ok {
+		r1 = rf(ctx, group, epoch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetGroups provides a mock function with given fields: ctx, filter
 func (_m *DefinitionHandlers) GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -94,6 +117,29 @@ func (_m *DefinitionHandlers) GetGroups(ctx context.Context, filter database.And
 	return r0, r1, r2
 }
 
+// GetLatestGroupKey provides a mock function with given fields: ctx, group
+func (_m *DefinitionHandlers) GetLatestGroupKey(ctx context.Context, group *fftypes.Bytes32) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, group)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, group)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, group)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HandleSystemBroadcast provides a mock function with given fields: ctx, msg, data
 func (_m *DefinitionHandlers) HandleSystemBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (definitions.SystemBroadcastAction, error) {
 	ret := _m.Called(ctx, msg, data)
@@ -5,10 +5,14 @@ package eventmocks
 import (
 	context "context"
 
+	database "github.com/hyperledger/firefly/pkg/database"
+
 	blockchain "github.com/hyperledger/firefly/pkg/blockchain"
 
 	dataexchange "github.com/hyperledger/firefly/pkg/dataexchange"
 
+	events "github.com/hyperledger/firefly/pkg/events"
+
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
 	mock "github.com/stretchr/testify/mock"
@@ -37,6 +41,20 @@ func (_m *EventManager) AddSystemEventListener(ns string, el system.EventListene
 	return r0
 }
 
+// ApplyApproved provides a mock function with given fields: ctx, approval
+func (_m *EventManager) ApplyApproved(ctx context.Context, approval *fftypes.PolicyApproval) error {
+	ret := _m.Called(ctx, approval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.PolicyApproval) error); ok {
+		r0 = rf(ctx, approval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BLOBReceived provides a mock function with given fields: dx, peerID, hash, payloadRef
 func (_m *EventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash fftypes.Bytes32, payloadRef string) error {
 	ret := _m.Called(dx, peerID, hash, payloadRef)
@@ -65,6 +83,36 @@ func (_m *EventManager) BatchPinComplete(bi blockchain.Plugin, batch *blockchain
 	return r0
 }
 
+// BlockchainClockSkew provides a mock function with given fields:
+func (_m *EventManager) BlockchainClockSkew() *fftypes.FFDuration {
+	ret := _m.Called()
+
+	var r0 *fftypes.FFDuration
+	if rf, ok := ret.Get(0).(func() *fftypes.FFDuration); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.FFDuration)
+		}
+	}
+
+	return r0
+}
+
+// BlockchainEvent provides a mock function with given fields: bi, event
+func (_m *EventManager) BlockchainEvent(bi blockchain.Plugin, event *fftypes.BlockchainEvent) error {
+	ret := _m.Called(bi, event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(blockchain.Plugin, *fftypes.BlockchainEvent) error); ok {
+		r0 = rf(bi, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ChangeEvents provides a mock function with given fields:
 func (_m *EventManager) ChangeEvents() chan<- *fftypes.ChangeEvent {
 	ret := _m.Called()
@@ -95,6 +143,52 @@ func (_m *EventManager) CreateUpdateDurableSubscription(ctx context.Context, sub
 	return r0
 }
 
+// DecideBatchQuarantine provides a mock function with given fields: ctx, id, decision
+func (_m *EventManager) DecideBatchQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.BatchQuarantine, error) {
+	ret := _m.Called(ctx, id, decision)
+
+	var r0 *fftypes.BatchQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.PolicyApprovalDecision) *fftypes.BatchQuarantine); ok {
+		r0 = rf(ctx, id, decision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BatchQuarantine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.PolicyApprovalDecision) error); ok {
+		r1 = rf(ctx, id, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecidePinQuarantine provides a mock function with given fields: ctx, id, decision
+func (_m *EventManager) DecidePinQuarantine(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.PinQuarantine, error) {
+	ret := _m.Called(ctx, id, decision)
+
+	var r0 *fftypes.PinQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.PolicyApprovalDecision) *fftypes.PinQuarantine); ok {
+		r0 = rf(ctx, id, decision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.PinQuarantine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.PolicyApprovalDecision) error); ok {
+		r1 = rf(ctx, id, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteDurableSubscription provides a mock function with given fields: ctx, subDef
 func (_m *EventManager) DeleteDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) error {
 	ret := _m.Called(ctx, subDef)
@@ -125,6 +219,242 @@ func (_m *EventManager) DeletedSubscriptions() chan<- *fftypes.UUID {
 	return r0
 }
 
+// GetBatchQuarantineByID provides a mock function with given fields: ctx, id
+func (_m *EventManager) GetBatchQuarantineByID(ctx context.Context, id string) (*fftypes.BatchQuarantine, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.BatchQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.BatchQuarantine); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BatchQuarantine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBatchQuarantines provides a mock function with given fields: ctx, filter
+func (_m *EventManager) GetBatchQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.BatchQuarantine, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.BatchQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.BatchQuarantine); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.BatchQuarantine)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetBlockedPinByID provides a mock function with given fields: ctx, id
+func (_m *EventManager) GetBlockedPinByID(ctx context.Context, id string) (*fftypes.BlockedPin, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.BlockedPin
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.BlockedPin); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlockedPin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlockedPins provides a mock function with given fields: ctx, filter
+func (_m *EventManager) GetBlockedPins(ctx context.Context, filter database.AndFilter) ([]*fftypes.BlockedPin, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.BlockedPin
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.BlockedPin); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.BlockedPin)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetPinQuarantineByID provides a mock function with given fields: ctx, id
+func (_m *EventManager) GetPinQuarantineByID(ctx context.Context, id string) (*fftypes.PinQuarantine, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.PinQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.PinQuarantine); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.PinQuarantine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPinQuarantines provides a mock function with given fields: ctx, filter
+func (_m *EventManager) GetPinQuarantines(ctx context.Context, filter database.AndFilter) ([]*fftypes.PinQuarantine, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.PinQuarantine
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.PinQuarantine); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.PinQuarantine)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetTransport provides a mock function with given fields: transport
+func (_m *EventManager) GetTransport(transport string) events.Plugin {
+	ret := _m.Called(transport)
+
+	var r0 events.Plugin
+	if rf, ok := ret.Get(0).(func(string) events.Plugin); ok {
+		r0 = rf(transport)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(events.Plugin)
+		}
+	}
+
+	return r0
+}
+
+// GetUnmatchedReceiptByID provides a mock function with given fields: ctx, id
+func (_m *EventManager) GetUnmatchedReceiptByID(ctx context.Context, id string) (*fftypes.UnmatchedReceipt, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.UnmatchedReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.UnmatchedReceipt); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.UnmatchedReceipt)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUnmatchedReceipts provides a mock function with given fields: ctx, filter
+func (_m *EventManager) GetUnmatchedReceipts(ctx context.Context, filter database.AndFilter) ([]*fftypes.UnmatchedReceipt, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.UnmatchedReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.UnmatchedReceipt); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.UnmatchedReceipt)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // MessageReceived provides a mock function with given fields: dx, peerID, data
 func (_m *EventManager) MessageReceived(dx dataexchange.Plugin, peerID string, data []byte) error {
 	ret := _m.Called(dx, peerID, data)
@@ -201,6 +531,80 @@ func (_m *EventManager) OperationUpdate(plugin fftypes.Named, operationID *fftyp
 	return r0
 }
 
+// ReconcileUnmatchedReceipt provides a mock function with given fields: ctx, id, input
+func (_m *EventManager) ReconcileUnmatchedReceipt(ctx context.Context, id string, input *fftypes.UnmatchedReceiptReconcile) (*fftypes.UnmatchedReceipt, error) {
+	ret := _m.Called(ctx, id, input)
+
+	var r0 *fftypes.UnmatchedReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UnmatchedReceiptReconcile) *fftypes.UnmatchedReceipt); ok {
+		r0 = rf(ctx, id, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.UnmatchedReceipt)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UnmatchedReceiptReconcile) error); ok {
+		r1 = rf(ctx, id, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReloadTransportConfig provides a mock function with given fields: ctx, transport
+func (_m *EventManager) ReloadTransportConfig(ctx context.Context, transport string) error {
+	ret := _m.Called(ctx, transport)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, transport)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetEventDedup provides a mock function with given fields: ctx
+func (_m *EventManager) ResetEventDedup(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetryBlockedPin provides a mock function with given fields: ctx, id
+func (_m *EventManager) RetryBlockedPin(ctx context.Context, id string) (*fftypes.BlockedPin, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.BlockedPin
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.BlockedPin); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlockedPin)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *EventManager) Start() error {
 	ret := _m.Called()
@@ -215,6 +619,20 @@ func (_m *EventManager) Start() error {
 	return r0
 }
 
+// StartDispatch provides a mock function with given fields:
+func (_m *EventManager) StartDispatch() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SubscriptionUpdates provides a mock function with given fields:
 func (_m *EventManager) SubscriptionUpdates() chan<- *fftypes.UUID {
 	ret := _m.Called()
@@ -245,6 +663,34 @@ func (_m *EventManager) TokenPoolCreated(ti tokens.Plugin, pool *tokens.TokenPoo
 	return r0
 }
 
+// TokenPoolGovernance provides a mock function with given fields: ti, poolProtocolID, change, protocolTxID, additionalInfo
+func (_m *EventManager) TokenPoolGovernance(ti tokens.Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	ret := _m.Called(ti, poolProtocolID, change, protocolTxID, additionalInfo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(tokens.Plugin, string, *fftypes.TokenPoolGovernance, string, fftypes.JSONObject) error); ok {
+		r0 = rf(ti, poolProtocolID, change, protocolTxID, additionalInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokensApproved provides a mock function with given fields: ti, poolProtocolID, approval, protocolTxID, additionalInfo
+func (_m *EventManager) TokensApproved(ti tokens.Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	ret := _m.Called(ti, poolProtocolID, approval, protocolTxID, additionalInfo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(tokens.Plugin, string, *fftypes.TokenApproval, string, fftypes.JSONObject) error); ok {
+		r0 = rf(ti, poolProtocolID, approval, protocolTxID, additionalInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TokensTransferred provides a mock function with given fields: ti, poolProtocolID, transfer, protocolTxID, additionalInfo
 func (_m *EventManager) TokensTransferred(ti tokens.Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	ret := _m.Called(ti, poolProtocolID, transfer, protocolTxID, additionalInfo)
@@ -3,9 +3,13 @@
 package eventsmocks
 
 import (
+	context "context"
+
 	events "github.com/hyperledger/firefly/pkg/events"
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -24,6 +28,29 @@ func (_m *Callbacks) DeliveryResponse(connID string, inflight *fftypes.EventDeli
 	_m.Called(connID, inflight)
 }
 
+// DownloadBLOB provides a mock function with given fields: ctx, ns, dataID
+func (_m *Callbacks) DownloadBLOB(ctx context.Context, ns string, dataID *fftypes.UUID) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, ns, dataID)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID) io.ReadCloser); ok {
+		r0 = rf(ctx, ns, dataID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, ns, dataID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EphemeralSubscription provides a mock function with given fields: connID, namespace, filter, options
 func (_m *Callbacks) EphemeralSubscription(connID string, namespace string, filter *fftypes.SubscriptionFilter, options *fftypes.SubscriptionOptions) error {
 	ret := _m.Called(connID, namespace, filter, options)
@@ -51,3 +78,17 @@ func (_m *Callbacks) RegisterConnection(connID string, matcher events.Subscripti
 
 	return r0
 }
+
+// RegisterSubscriptionConnection provides a mock function with given fields: connID, namespace, id, identity, lastSequence
+func (_m *Callbacks) RegisterSubscriptionConnection(connID string, namespace string, id *fftypes.UUID, identity string, lastSequence *int64) error {
+	ret := _m.Called(connID, namespace, id, identity, lastSequence)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, *fftypes.UUID, string, *int64) error); ok {
+		r0 = rf(connID, namespace, id, identity, lastSequence)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
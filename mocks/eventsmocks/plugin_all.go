@@ -101,6 +101,20 @@ func (_m *PluginAll) Name() string {
 	return r0
 }
 
+// ReloadConfig provides a mock function with given fields: ctx, prefix
+func (_m *PluginAll) ReloadConfig(ctx context.Context, prefix config.Prefix) error {
+	ret := _m.Called(ctx, prefix)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, config.Prefix) error); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ValidateOptions provides a mock function with given fields: options
 func (_m *PluginAll) ValidateOptions(options *fftypes.SubscriptionOptions) error {
 	ret := _m.Called(options)
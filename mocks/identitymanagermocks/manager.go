@@ -15,6 +15,29 @@ type Manager struct {
 	mock.Mock
 }
 
+// GetCustomIdentityByDID provides a mock function with given fields: ctx, did
+func (_m *Manager) GetCustomIdentityByDID(ctx context.Context, did string) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, did)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, did)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, did)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLocalOrganization provides a mock function with given fields: ctx
 func (_m *Manager) GetLocalOrganization(ctx context.Context) (*fftypes.Organization, error) {
 	ret := _m.Called(ctx)
@@ -66,6 +89,20 @@ func (_m *Manager) OrgDID(org *fftypes.Organization) string {
 	return r0
 }
 
+// ResolveDelegatedIdentity provides a mock function with given fields: ctx, ns, identity, delegationID
+func (_m *Manager) ResolveDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) error {
+	ret := _m.Called(ctx, ns, identity, delegationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Identity, *fftypes.UUID) error); ok {
+		r0 = rf(ctx, ns, identity, delegationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResolveInputIdentity provides a mock function with given fields: ctx, _a1
 func (_m *Manager) ResolveInputIdentity(ctx context.Context, _a1 *fftypes.Identity) error {
 	ret := _m.Called(ctx, _a1)
@@ -101,20 +138,34 @@ func (_m *Manager) ResolveLocalOrgDID(ctx context.Context) (string, error) {
 	return r0, r1
 }
 
-// ResolveSigningKey provides a mock function with given fields: ctx, inputKey
-func (_m *Manager) ResolveSigningKey(ctx context.Context, inputKey string) (string, error) {
-	ret := _m.Called(ctx, inputKey)
+// ResolvePseudonymIdentity provides a mock function with given fields: ctx, ns, identity
+func (_m *Manager) ResolvePseudonymIdentity(ctx context.Context, ns string, identity *fftypes.Identity) error {
+	ret := _m.Called(ctx, ns, identity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Identity) error); ok {
+		r0 = rf(ctx, ns, identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResolveSigningKey provides a mock function with given fields: ctx, ns, inputKey
+func (_m *Manager) ResolveSigningKey(ctx context.Context, ns string, inputKey string) (string, error) {
+	ret := _m.Called(ctx, ns, inputKey)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, inputKey)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, ns, inputKey)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, inputKey)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, inputKey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -142,3 +193,52 @@ func (_m *Manager) ResolveSigningKeyIdentity(ctx context.Context, signingKey str
 
 	return r0, r1
 }
+
+// VerifyDelegatedIdentity provides a mock function with given fields: ctx, ns, identity, delegationID
+func (_m *Manager) VerifyDelegatedIdentity(ctx context.Context, ns string, identity *fftypes.Identity, delegationID *fftypes.UUID) (bool, bool, error) {
+	ret := _m.Called(ctx, ns, identity, delegationID)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Identity, *fftypes.UUID) bool); ok {
+		r0 = rf(ctx, ns, identity, delegationID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Identity, *fftypes.UUID) bool); ok {
+		r1 = rf(ctx, ns, identity, delegationID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, *fftypes.Identity, *fftypes.UUID) error); ok {
+		r2 = rf(ctx, ns, identity, delegationID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// VerifySigningKeyAuthor provides a mock function with given fields: ctx, signingKey, author
+func (_m *Manager) VerifySigningKeyAuthor(ctx context.Context, signingKey string, author string) (bool, error) {
+	ret := _m.Called(ctx, signingKey, author)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, signingKey, author)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, signingKey, author)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
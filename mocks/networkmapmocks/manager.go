@@ -16,6 +16,84 @@ type Manager struct {
 	mock.Mock
 }
 
+// GetIdentities provides a mock function with given fields: ctx, ns, filter
+func (_m *Manager) GetIdentities(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.CustomIdentity, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetIdentityByID provides a mock function with given fields: ctx, ns, id
+func (_m *Manager) GetIdentityByID(ctx context.Context, ns string, id string) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIdentityDIDDocument provides a mock function with given fields: ctx, ns, id
+func (_m *Manager) GetIdentityDIDDocument(ctx context.Context, ns string, id string) (*fftypes.DIDDocument, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.DIDDocument
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.DIDDocument); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.DIDDocument)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNodeByID provides a mock function with given fields: ctx, id
 func (_m *Manager) GetNodeByID(ctx context.Context, id string) (*fftypes.Node, error) {
 	ret := _m.Called(ctx, id)
@@ -71,6 +149,38 @@ func (_m *Manager) GetNodes(ctx context.Context, filter database.AndFilter) ([]*
 	return r0, r1, r2
 }
 
+// GetNodesAsOf provides a mock function with given fields: ctx, asOf, filter
+func (_m *Manager) GetNodesAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error) {
+	ret := _m.Called(ctx, asOf, filter)
+
+	var r0 []*fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.FFTime, database.AndFilter) []*fftypes.Node); ok {
+		r0 = rf(ctx, asOf, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Node)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.FFTime, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, asOf, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *fftypes.FFTime, database.AndFilter) error); ok {
+		r2 = rf(ctx, asOf, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetOrganizationByID provides a mock function with given fields: ctx, id
 func (_m *Manager) GetOrganizationByID(ctx context.Context, id string) (*fftypes.Organization, error) {
 	ret := _m.Called(ctx, id)
@@ -94,6 +204,29 @@ func (_m *Manager) GetOrganizationByID(ctx context.Context, id string) (*fftypes
 	return r0, r1
 }
 
+// GetOrganizationByName provides a mock function with given fields: ctx, name
+func (_m *Manager) GetOrganizationByName(ctx context.Context, name string) (*fftypes.Organization, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Organization); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Organization)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrganizations provides a mock function with given fields: ctx, filter
 func (_m *Manager) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -126,6 +259,61 @@ func (_m *Manager) GetOrganizations(ctx context.Context, filter database.AndFilt
 	return r0, r1, r2
 }
 
+// GetOrganizationsAsOf provides a mock function with given fields: ctx, asOf, filter
+func (_m *Manager) GetOrganizationsAsOf(ctx context.Context, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
+	ret := _m.Called(ctx, asOf, filter)
+
+	var r0 []*fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.FFTime, database.AndFilter) []*fftypes.Organization); ok {
+		r0 = rf(ctx, asOf, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Organization)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.FFTime, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, asOf, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *fftypes.FFTime, database.AndFilter) error); ok {
+		r2 = rf(ctx, asOf, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RegisterIdentity provides a mock function with given fields: ctx, ns, identity, waitConfirm
+func (_m *Manager) RegisterIdentity(ctx context.Context, ns string, identity *fftypes.CustomIdentity, waitConfirm bool) (*fftypes.CustomIdentity, error) {
+	ret := _m.Called(ctx, ns, identity, waitConfirm)
+
+	var r0 *fftypes.CustomIdentity
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.CustomIdentity, bool) *fftypes.CustomIdentity); ok {
+		r0 = rf(ctx, ns, identity, waitConfirm)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.CustomIdentity)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.CustomIdentity, bool) error); ok {
+		r1 = rf(ctx, ns, identity, waitConfirm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RegisterNode provides a mock function with given fields: ctx, waitConfirm
 func (_m *Manager) RegisterNode(ctx context.Context, waitConfirm bool) (*fftypes.Node, *fftypes.Message, error) {
 	ret := _m.Called(ctx, waitConfirm)
@@ -212,3 +400,17 @@ func (_m *Manager) RegisterOrganization(ctx context.Context, org *fftypes.Organi
 
 	return r0, r1
 }
+
+// Start provides a mock function with given fields:
+func (_m *Manager) Start() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
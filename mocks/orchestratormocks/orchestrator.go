@@ -8,6 +8,10 @@ import (
 
 	context "context"
 
+	io "io"
+
+	contracts "github.com/hyperledger/firefly/internal/contracts"
+
 	data "github.com/hyperledger/firefly/internal/data"
 
 	database "github.com/hyperledger/firefly/pkg/database"
@@ -16,11 +20,21 @@ import (
 
 	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
 
+	log "github.com/hyperledger/firefly/internal/log"
+
 	mock "github.com/stretchr/testify/mock"
 
 	networkmap "github.com/hyperledger/firefly/internal/networkmap"
 
+	operations "github.com/hyperledger/firefly/internal/operations"
+
+	orchestrator "github.com/hyperledger/firefly/internal/orchestrator"
+
+	policy "github.com/hyperledger/firefly/internal/policy"
+
 	privatemessaging "github.com/hyperledger/firefly/internal/privatemessaging"
+
+	rollup "github.com/hyperledger/firefly/internal/rollup"
 )
 
 // Orchestrator is an autogenerated mock type for the Orchestrator type
@@ -60,6 +74,73 @@ func (_m *Orchestrator) Broadcast() broadcast.Manager {
 	return r0
 }
 
+// CheckConsistency provides a mock function with given fields: ctx, input
+func (_m *Orchestrator) CheckConsistency(ctx context.Context, input *fftypes.ConsistencyCheckInput) (*fftypes.ConsistencyReport, error) {
+	ret := _m.Called(ctx, input)
+
+	var r0 *fftypes.ConsistencyReport
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.ConsistencyCheckInput) *fftypes.ConsistencyReport); ok {
+		r0 = rf(ctx, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConsistencyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.ConsistencyCheckInput) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckFeature provides a mock function with given fields: ctx, ns, feature
+func (_m *Orchestrator) CheckFeature(ctx context.Context, ns string, feature fftypes.FFEnum) error {
+	ret := _m.Called(ctx, ns, feature)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, fftypes.FFEnum) error); ok {
+		r0 = rf(ctx, ns, feature)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloseWebSocketConnection provides a mock function with given fields: ctx, id
+func (_m *Orchestrator) CloseWebSocketConnection(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Contracts provides a mock function with given fields:
+func (_m *Orchestrator) Contracts() contracts.Manager {
+	ret := _m.Called()
+
+	var r0 contracts.Manager
+	if rf, ok := ret.Get(0).(func() contracts.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(contracts.Manager)
+		}
+	}
+
+	return r0
+}
+
 // CreateSubscription provides a mock function with given fields: ctx, ns, subDef
 func (_m *Orchestrator) CreateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error) {
 	ret := _m.Called(ctx, ns, subDef)
@@ -166,77 +247,22 @@ func (_m *Orchestrator) Events() events.EventManager {
 	return r0
 }
 
-// GetBatchByID provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetBatchByID(ctx context.Context, ns string, id string) (*fftypes.Batch, error) {
-	ret := _m.Called(ctx, ns, id)
-
-	var r0 *fftypes.Batch
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Batch); ok {
-		r0 = rf(ctx, ns, id)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Batch)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, ns, id)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
-// GetBatches provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error) {
-	ret := _m.Called(ctx, ns, filter)
-
-	var r0 []*fftypes.Batch
-	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Batch); ok {
-		r0 = rf(ctx, ns, filter)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Batch)
-		}
-	}
-
-	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
-		r1 = rf(ctx, ns, filter)
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*database.FilterResult)
-		}
-	}
-
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
-		r2 = rf(ctx, ns, filter)
-	} else {
-		r2 = ret.Error(2)
-	}
-
-	return r0, r1, r2
-}
-
-// GetChartHistogram provides a mock function with given fields: ctx, ns, startTime, endTime, buckets, tableName
-func (_m *Orchestrator) GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogram, error) {
-	ret := _m.Called(ctx, ns, startTime, endTime, buckets, tableName)
+// ExportDefinitions provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) ExportDefinitions(ctx context.Context, ns string) (*fftypes.DefinitionsExport, error) {
+	ret := _m.Called(ctx, ns)
 
-	var r0 []*fftypes.ChartHistogram
-	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64, int64, database.CollectionName) []*fftypes.ChartHistogram); ok {
-		r0 = rf(ctx, ns, startTime, endTime, buckets, tableName)
+	var r0 *fftypes.DefinitionsExport
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.DefinitionsExport); ok {
+		r0 = rf(ctx, ns)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.ChartHistogram)
+			r0 = ret.Get(0).(*fftypes.DefinitionsExport)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64, int64, database.CollectionName) error); ok {
-		r1 = rf(ctx, ns, startTime, endTime, buckets, tableName)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ns)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -244,38 +270,38 @@ func (_m *Orchestrator) GetChartHistogram(ctx context.Context, ns string, startT
 	return r0, r1
 }
 
-// GetConfig provides a mock function with given fields: ctx
-func (_m *Orchestrator) GetConfig(ctx context.Context) fftypes.JSONObject {
-	ret := _m.Called(ctx)
+// ForNamespace provides a mock function with given fields: ns
+func (_m *Orchestrator) ForNamespace(ns string) orchestrator.Orchestrator {
+	ret := _m.Called(ns)
 
-	var r0 fftypes.JSONObject
-	if rf, ok := ret.Get(0).(func(context.Context) fftypes.JSONObject); ok {
-		r0 = rf(ctx)
+	var r0 orchestrator.Orchestrator
+	if rf, ok := ret.Get(0).(func(string) orchestrator.Orchestrator); ok {
+		r0 = rf(ns)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(fftypes.JSONObject)
+			r0 = ret.Get(0).(orchestrator.Orchestrator)
 		}
 	}
 
 	return r0
 }
 
-// GetConfigRecord provides a mock function with given fields: ctx, key
-func (_m *Orchestrator) GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error) {
-	ret := _m.Called(ctx, key)
+// GetAuditRecordByID provides a mock function with given fields: ctx, id
+func (_m *Orchestrator) GetAuditRecordByID(ctx context.Context, id string) (*fftypes.AuditRecord, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 *fftypes.ConfigRecord
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.ConfigRecord); ok {
-		r0 = rf(ctx, key)
+	var r0 *fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.AuditRecord); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.ConfigRecord)
+			r0 = ret.Get(0).(*fftypes.AuditRecord)
 		}
 	}
 
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, key)
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -283,16 +309,16 @@ func (_m *Orchestrator) GetConfigRecord(ctx context.Context, key string) (*fftyp
 	return r0, r1
 }
 
-// GetConfigRecords provides a mock function with given fields: ctx, filter
-func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
+// GetAuditRecords provides a mock function with given fields: ctx, filter
+func (_m *Orchestrator) GetAuditRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.AuditRecord, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []*fftypes.ConfigRecord
-	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.ConfigRecord); ok {
+	var r0 []*fftypes.AuditRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.AuditRecord); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.ConfigRecord)
+			r0 = ret.Get(0).([]*fftypes.AuditRecord)
 		}
 	}
 
@@ -315,48 +341,16 @@ func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.An
 	return r0, r1, r2
 }
 
-// GetData provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error) {
-	ret := _m.Called(ctx, ns, filter)
-
-	var r0 []*fftypes.Data
-	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Data); ok {
-		r0 = rf(ctx, ns, filter)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Data)
-		}
-	}
-
-	var r1 *database.FilterResult
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
-		r1 = rf(ctx, ns, filter)
-	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*database.FilterResult)
-		}
-	}
-
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
-		r2 = rf(ctx, ns, filter)
-	} else {
-		r2 = ret.Error(2)
-	}
-
-	return r0, r1, r2
-}
-
-// GetDataByID provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetDataByID(ctx context.Context, ns string, id string) (*fftypes.Data, error) {
+// GetBatchByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetBatchByID(ctx context.Context, ns string, id string) (*fftypes.Batch, error) {
 	ret := _m.Called(ctx, ns, id)
 
-	var r0 *fftypes.Data
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Data); ok {
+	var r0 *fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Batch); ok {
 		r0 = rf(ctx, ns, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Data)
+			r0 = ret.Get(0).(*fftypes.Batch)
 		}
 	}
 
@@ -370,16 +364,16 @@ func (_m *Orchestrator) GetDataByID(ctx context.Context, ns string, id string) (
 	return r0, r1
 }
 
-// GetDatatypeByID provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetDatatypeByID(ctx context.Context, ns string, id string) (*fftypes.Datatype, error) {
+// GetBatchManifest provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetBatchManifest(ctx context.Context, ns string, id string) (*fftypes.BatchManifest, error) {
 	ret := _m.Called(ctx, ns, id)
 
-	var r0 *fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Datatype); ok {
+	var r0 *fftypes.BatchManifest
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.BatchManifest); ok {
 		r0 = rf(ctx, ns, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Datatype)
+			r0 = ret.Get(0).(*fftypes.BatchManifest)
 		}
 	}
 
@@ -393,39 +387,16 @@ func (_m *Orchestrator) GetDatatypeByID(ctx context.Context, ns string, id strin
 	return r0, r1
 }
 
-// GetDatatypeByName provides a mock function with given fields: ctx, ns, name, version
-func (_m *Orchestrator) GetDatatypeByName(ctx context.Context, ns string, name string, version string) (*fftypes.Datatype, error) {
-	ret := _m.Called(ctx, ns, name, version)
-
-	var r0 *fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.Datatype); ok {
-		r0 = rf(ctx, ns, name, version)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Datatype)
-		}
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, ns, name, version)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
-// GetDatatypes provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, *database.FilterResult, error) {
+// GetBatches provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
-	var r0 []*fftypes.Datatype
-	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Datatype); ok {
+	var r0 []*fftypes.Batch
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Batch); ok {
 		r0 = rf(ctx, ns, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Datatype)
+			r0 = ret.Get(0).([]*fftypes.Batch)
 		}
 	}
 
@@ -448,16 +419,16 @@ func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter data
 	return r0, r1, r2
 }
 
-// GetEventByID provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetEventByID(ctx context.Context, ns string, id string) (*fftypes.Event, error) {
+// GetBlockchainEventByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetBlockchainEventByID(ctx context.Context, ns string, id string) (*fftypes.BlockchainEvent, error) {
 	ret := _m.Called(ctx, ns, id)
 
-	var r0 *fftypes.Event
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Event); ok {
+	var r0 *fftypes.BlockchainEvent
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.BlockchainEvent); ok {
 		r0 = rf(ctx, ns, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Event)
+			r0 = ret.Get(0).(*fftypes.BlockchainEvent)
 		}
 	}
 
@@ -471,16 +442,16 @@ func (_m *Orchestrator) GetEventByID(ctx context.Context, ns string, id string)
 	return r0, r1
 }
 
-// GetEvents provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
+// GetBlockchainEvents provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetBlockchainEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.BlockchainEvent, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
-	var r0 []*fftypes.Event
-	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Event); ok {
+	var r0 []*fftypes.BlockchainEvent
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.BlockchainEvent); ok {
 		r0 = rf(ctx, ns, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*fftypes.Event)
+			r0 = ret.Get(0).([]*fftypes.BlockchainEvent)
 		}
 	}
 
@@ -503,22 +474,22 @@ func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter databas
 	return r0, r1, r2
 }
 
-// GetMessageByID provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetMessageByID(ctx context.Context, ns string, id string) (*fftypes.Message, error) {
-	ret := _m.Called(ctx, ns, id)
+// GetChartActivity provides a mock function with given fields: ctx, ns, limit
+func (_m *Orchestrator) GetChartActivity(ctx context.Context, ns string, limit int) ([]*fftypes.Event, error) {
+	ret := _m.Called(ctx, ns, limit)
 
-	var r0 *fftypes.Message
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Message); ok {
-		r0 = rf(ctx, ns, id)
+	var r0 []*fftypes.Event
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*fftypes.Event); ok {
+		r0 = rf(ctx, ns, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Message)
+			r0 = ret.Get(0).([]*fftypes.Event)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, ns, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, ns, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -526,22 +497,568 @@ func (_m *Orchestrator) GetMessageByID(ctx context.Context, ns string, id string
 	return r0, r1
 }
 
-// GetMessageByIDWithData provides a mock function with given fields: ctx, ns, id
-func (_m *Orchestrator) GetMessageByIDWithData(ctx context.Context, ns string, id string) (*fftypes.MessageInOut, error) {
-	ret := _m.Called(ctx, ns, id)
+// GetChartHistogram provides a mock function with given fields: ctx, ns, startTime, endTime, buckets, tableName
+func (_m *Orchestrator) GetChartHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogram, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, buckets, tableName)
 
-	var r0 *fftypes.MessageInOut
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.MessageInOut); ok {
-		r0 = rf(ctx, ns, id)
+	var r0 []*fftypes.ChartHistogram
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64, int64, database.CollectionName) []*fftypes.ChartHistogram); ok {
+		r0 = rf(ctx, ns, startTime, endTime, buckets, tableName)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.MessageInOut)
+			r0 = ret.Get(0).([]*fftypes.ChartHistogram)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, ns, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64, int64, database.CollectionName) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, buckets, tableName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChartTopAuthors provides a mock function with given fields: ctx, ns, startTime, endTime, limit
+func (_m *Orchestrator) GetChartTopAuthors(ctx context.Context, ns string, startTime *fftypes.FFTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, limit)
+
+	var r0 []*fftypes.ChartRanking
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) []*fftypes.ChartRanking); ok {
+		r0 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ChartRanking)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChartTopTopics provides a mock function with given fields: ctx, ns, startTime, endTime, limit
+func (_m *Orchestrator) GetChartTopTopics(ctx context.Context, ns string, startTime *fftypes.FFTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, limit)
+
+	var r0 []*fftypes.ChartRanking
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) []*fftypes.ChartRanking); ok {
+		r0 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ChartRanking)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, *fftypes.FFTime, int) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChartTypeHistogram provides a mock function with given fields: ctx, ns, startTime, endTime, buckets, tableName
+func (_m *Orchestrator) GetChartTypeHistogram(ctx context.Context, ns string, startTime int64, endTime int64, buckets int64, tableName database.CollectionName) ([]*fftypes.ChartHistogramTyped, error) {
+	ret := _m.Called(ctx, ns, startTime, endTime, buckets, tableName)
+
+	var r0 []*fftypes.ChartHistogramTyped
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int64, int64, database.CollectionName) []*fftypes.ChartHistogramTyped); ok {
+		r0 = rf(ctx, ns, startTime, endTime, buckets, tableName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ChartHistogramTyped)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, int64, int64, database.CollectionName) error); ok {
+		r1 = rf(ctx, ns, startTime, endTime, buckets, tableName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfig provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetConfig(ctx context.Context) fftypes.JSONObject {
+	ret := _m.Called(ctx)
+
+	var r0 fftypes.JSONObject
+	if rf, ok := ret.Get(0).(func(context.Context) fftypes.JSONObject); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.JSONObject)
+		}
+	}
+
+	return r0
+}
+
+// GetConfigRecord provides a mock function with given fields: ctx, key
+func (_m *Orchestrator) GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *fftypes.ConfigRecord
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.ConfigRecord); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConfigRecord)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfigRecords provides a mock function with given fields: ctx, filter
+func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.ConfigRecord
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.ConfigRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.ConfigRecord)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetConsistencyReport provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetConsistencyReport(ctx context.Context) (*fftypes.ConsistencyReport, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.ConsistencyReport
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.ConsistencyReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.ConsistencyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetData provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Data
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Data); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Data)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDataByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetDataByID(ctx context.Context, ns string, id string) (*fftypes.Data, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Data
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Data); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Data)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDatatypeByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetDatatypeByID(ctx context.Context, ns string, id string) (*fftypes.Datatype, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Datatype); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Datatype)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDatatypeByName provides a mock function with given fields: ctx, ns, name, version
+func (_m *Orchestrator) GetDatatypeByName(ctx context.Context, ns string, name string, version string) (*fftypes.Datatype, error) {
+	ret := _m.Called(ctx, ns, name, version)
+
+	var r0 *fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *fftypes.Datatype); ok {
+		r0 = rf(ctx, ns, name, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Datatype)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, ns, name, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDatatypes provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Datatype
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Datatype); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Datatype)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetDelegationByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetDelegationByID(ctx context.Context, ns string, id string) (*fftypes.Delegation, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Delegation); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Delegation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDelegations provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetDelegations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Delegation, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Delegation
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Delegation); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Delegation)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetEventByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetEventByID(ctx context.Context, ns string, id string) (*fftypes.Event, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Event
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Event); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEvents provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Event
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Event); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Event)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetIdentityUsage provides a mock function with given fields: ctx, ns, identity, startTime, endTime
+func (_m *Orchestrator) GetIdentityUsage(ctx context.Context, ns string, identity string, startTime *fftypes.FFTime, endTime *fftypes.FFTime) (*fftypes.IdentityUsage, error) {
+	ret := _m.Called(ctx, ns, identity, startTime, endTime)
+
+	var r0 *fftypes.IdentityUsage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) *fftypes.IdentityUsage); ok {
+		r0 = rf(ctx, ns, identity, startTime, endTime)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.IdentityUsage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, ns, identity, startTime, endTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLegalHoldByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetLegalHoldByID(ctx context.Context, ns string, id string) (*fftypes.LegalHold, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.LegalHold); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LegalHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLegalHolds provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetLegalHolds(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.LegalHold, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.LegalHold); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.LegalHold)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLogLevels provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetLogLevels(ctx context.Context) *log.Levels {
+	ret := _m.Called(ctx)
+
+	var r0 *log.Levels
+	if rf, ok := ret.Get(0).(func(context.Context) *log.Levels); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*log.Levels)
+		}
+	}
+
+	return r0
+}
+
+// GetMessageByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetMessageByID(ctx context.Context, ns string, id string) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageByIDWithData provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetMessageByIDWithData(ctx context.Context, ns string, id string) (*fftypes.MessageInOut, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.MessageInOut
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.MessageInOut); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageInOut)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -604,6 +1121,38 @@ func (_m *Orchestrator) GetMessageEvents(ctx context.Context, ns string, id stri
 	return r0, r1, r2
 }
 
+// GetMessageHistory provides a mock function with given fields: ctx, ns, id, filter
+func (_m *Orchestrator) GetMessageHistory(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.MessageTransition, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, id, filter)
+
+	var r0 []*fftypes.MessageTransition
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.MessageTransition); ok {
+		r0 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageTransition)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, id, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetMessageOperations provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetMessageOperations(ctx context.Context, ns string, id string) ([]*fftypes.Operation, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -636,6 +1185,84 @@ func (_m *Orchestrator) GetMessageOperations(ctx context.Context, ns string, id
 	return r0, r1, r2
 }
 
+// GetMessageProof provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetMessageProof(ctx context.Context, ns string, id string) (*fftypes.MessageProof, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.MessageProof
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.MessageProof); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageProof)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageReceipts provides a mock function with given fields: ctx, ns, id, filter
+func (_m *Orchestrator) GetMessageReceipts(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.MessageReceipt, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, id, filter)
+
+	var r0 []*fftypes.MessageReceipt
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.MessageReceipt); ok {
+		r0 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageReceipt)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, id, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessageTopics provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetMessageTopics(ctx context.Context, ns string, id string) ([]*fftypes.MessageTopicStatus, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 []*fftypes.MessageTopicStatus
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*fftypes.MessageTopicStatus); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageTopicStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMessageTransaction provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetMessageTransaction(ctx context.Context, ns string, id string) (*fftypes.Transaction, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -691,6 +1318,38 @@ func (_m *Orchestrator) GetMessages(ctx context.Context, ns string, filter datab
 	return r0, r1, r2
 }
 
+// GetMessagesAsOf provides a mock function with given fields: ctx, ns, asOf, filter
+func (_m *Orchestrator) GetMessagesAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, asOf, filter)
+
+	var r0 []*fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) []*fftypes.Message); ok {
+		r0 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Message)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, asOf, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetMessagesForData provides a mock function with given fields: ctx, ns, dataID, filter
 func (_m *Orchestrator) GetMessagesForData(ctx context.Context, ns string, dataID string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, dataID, filter)
@@ -755,6 +1414,38 @@ func (_m *Orchestrator) GetMessagesWithData(ctx context.Context, ns string, filt
 	return r0, r1, r2
 }
 
+// GetMessagesWithDataAsOf provides a mock function with given fields: ctx, ns, asOf, filter
+func (_m *Orchestrator) GetMessagesWithDataAsOf(ctx context.Context, ns string, asOf *fftypes.FFTime, filter database.AndFilter) ([]*fftypes.MessageInOut, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, asOf, filter)
+
+	var r0 []*fftypes.MessageInOut
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) []*fftypes.MessageInOut); ok {
+		r0 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageInOut)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, asOf, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, *fftypes.FFTime, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, asOf, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetNamespace provides a mock function with given fields: ctx, ns
 func (_m *Orchestrator) GetNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error) {
 	ret := _m.Called(ctx, ns)
@@ -778,6 +1469,29 @@ func (_m *Orchestrator) GetNamespace(ctx context.Context, ns string) (*fftypes.N
 	return r0, r1
 }
 
+// GetNamespaceFeatures provides a mock function with given fields: ctx, ns
+func (_m *Orchestrator) GetNamespaceFeatures(ctx context.Context, ns string) (*fftypes.NamespaceFeatures, error) {
+	ret := _m.Called(ctx, ns)
+
+	var r0 *fftypes.NamespaceFeatures
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.NamespaceFeatures); ok {
+		r0 = rf(ctx, ns)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NamespaceFeatures)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ns)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNamespaces provides a mock function with given fields: ctx, filter
 func (_m *Orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -855,14 +1569,124 @@ func (_m *Orchestrator) GetOperations(ctx context.Context, ns string, filter dat
 		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
-		r2 = rf(ctx, ns, filter)
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetPseudonymByID provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) GetPseudonymByID(ctx context.Context, ns string, id string) (*fftypes.Pseudonym, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Pseudonym); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Pseudonym)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPseudonyms provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetPseudonyms(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Pseudonym, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Pseudonym
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Pseudonym); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Pseudonym)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSigningActivity provides a mock function with given fields: ctx, ns, filter
+func (_m *Orchestrator) GetSigningActivity(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.SigningActivity, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.SigningActivity
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.SigningActivity); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.SigningActivity)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSigningKeyReport provides a mock function with given fields: ctx, ns, key, startTime, endTime
+func (_m *Orchestrator) GetSigningKeyReport(ctx context.Context, ns string, key string, startTime *fftypes.FFTime, endTime *fftypes.FFTime) (*fftypes.SigningKeyReport, error) {
+	ret := _m.Called(ctx, ns, key, startTime, endTime)
+
+	var r0 *fftypes.SigningKeyReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) *fftypes.SigningKeyReport); ok {
+		r0 = rf(ctx, ns, key, startTime, endTime)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.SigningKeyReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, ns, key, startTime, endTime)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
 // GetStatus provides a mock function with given fields: ctx
@@ -966,6 +1790,29 @@ func (_m *Orchestrator) GetTransactionByID(ctx context.Context, ns string, id st
 	return r0, r1
 }
 
+// GetTransactionCostReport provides a mock function with given fields: ctx, ns, groupBy, startTime, endTime
+func (_m *Orchestrator) GetTransactionCostReport(ctx context.Context, ns string, groupBy string, startTime *fftypes.FFTime, endTime *fftypes.FFTime) (*fftypes.TransactionCostReport, error) {
+	ret := _m.Called(ctx, ns, groupBy, startTime, endTime)
+
+	var r0 *fftypes.TransactionCostReport
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) *fftypes.TransactionCostReport); ok {
+		r0 = rf(ctx, ns, groupBy, startTime, endTime)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.TransactionCostReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.FFTime, *fftypes.FFTime) error); ok {
+		r1 = rf(ctx, ns, groupBy, startTime, endTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionOperations provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetTransactionOperations(ctx context.Context, ns string, id string) ([]*fftypes.Operation, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -1030,6 +1877,45 @@ func (_m *Orchestrator) GetTransactions(ctx context.Context, ns string, filter d
 	return r0, r1, r2
 }
 
+// GetWebSocketStatus provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetWebSocketStatus(ctx context.Context) *fftypes.WebSocketStatus {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.WebSocketStatus
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.WebSocketStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.WebSocketStatus)
+		}
+	}
+
+	return r0
+}
+
+// ImportDefinitions provides a mock function with given fields: ctx, ns, export
+func (_m *Orchestrator) ImportDefinitions(ctx context.Context, ns string, export *fftypes.DefinitionsExport) ([]*fftypes.DefinitionImportResult, error) {
+	ret := _m.Called(ctx, ns, export)
+
+	var r0 []*fftypes.DefinitionImportResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.DefinitionsExport) []*fftypes.DefinitionImportResult); ok {
+		r0 = rf(ctx, ns, export)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.DefinitionImportResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.DefinitionsExport) error); ok {
+		r1 = rf(ctx, ns, export)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Init provides a mock function with given fields: ctx, cancelCtx
 func (_m *Orchestrator) Init(ctx context.Context, cancelCtx context.CancelFunc) error {
 	ret := _m.Called(ctx, cancelCtx)
@@ -1058,6 +1944,20 @@ func (_m *Orchestrator) IsPreInit() bool {
 	return r0
 }
 
+// IsStandby provides a mock function with given fields:
+func (_m *Orchestrator) IsStandby() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // NetworkMap provides a mock function with given fields:
 func (_m *Orchestrator) NetworkMap() networkmap.Manager {
 	ret := _m.Called()
@@ -1074,6 +1974,61 @@ func (_m *Orchestrator) NetworkMap() networkmap.Manager {
 	return r0
 }
 
+// Operations provides a mock function with given fields:
+func (_m *Orchestrator) Operations() operations.Manager {
+	ret := _m.Called()
+
+	var r0 operations.Manager
+	if rf, ok := ret.Get(0).(func() operations.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(operations.Manager)
+		}
+	}
+
+	return r0
+}
+
+// PlaceLegalHold provides a mock function with given fields: ctx, ns, input
+func (_m *Orchestrator) PlaceLegalHold(ctx context.Context, ns string, input *fftypes.LegalHoldInput) (*fftypes.LegalHold, error) {
+	ret := _m.Called(ctx, ns, input)
+
+	var r0 *fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.LegalHoldInput) *fftypes.LegalHold); ok {
+		r0 = rf(ctx, ns, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LegalHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.LegalHoldInput) error); ok {
+		r1 = rf(ctx, ns, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Policy provides a mock function with given fields:
+func (_m *Orchestrator) Policy() policy.Manager {
+	ret := _m.Called()
+
+	var r0 policy.Manager
+	if rf, ok := ret.Get(0).(func() policy.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(policy.Manager)
+		}
+	}
+
+	return r0
+}
+
 // PrivateMessaging provides a mock function with given fields:
 func (_m *Orchestrator) PrivateMessaging() privatemessaging.Manager {
 	ret := _m.Called()
@@ -1090,6 +2045,29 @@ func (_m *Orchestrator) PrivateMessaging() privatemessaging.Manager {
 	return r0
 }
 
+// Promote provides a mock function with given fields: ctx
+func (_m *Orchestrator) Promote(ctx context.Context) (*fftypes.NodeStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *fftypes.NodeStatus
+	if rf, ok := ret.Get(0).(func(context.Context) *fftypes.NodeStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NodeStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PutConfigRecord provides a mock function with given fields: ctx, key, configRecord
 func (_m *Orchestrator) PutConfigRecord(ctx context.Context, key string, configRecord fftypes.Byteable) (fftypes.Byteable, error) {
 	ret := _m.Called(ctx, key, configRecord)
@@ -1113,6 +2091,89 @@ func (_m *Orchestrator) PutConfigRecord(ctx context.Context, key string, configR
 	return r0, r1
 }
 
+// RecordAudit provides a mock function with given fields: ctx, record
+func (_m *Orchestrator) RecordAudit(ctx context.Context, record *fftypes.AuditRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.AuditRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReloadConfig provides a mock function with given fields: ctx, update
+func (_m *Orchestrator) ReloadConfig(ctx context.Context, update fftypes.JSONObject) (fftypes.JSONObject, error) {
+	ret := _m.Called(ctx, update)
+
+	var r0 fftypes.JSONObject
+	if rf, ok := ret.Get(0).(func(context.Context, fftypes.JSONObject) fftypes.JSONObject); ok {
+		r0 = rf(ctx, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.JSONObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, fftypes.JSONObject) error); ok {
+		r1 = rf(ctx, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveLegalHold provides a mock function with given fields: ctx, ns, id, removal
+func (_m *Orchestrator) RemoveLegalHold(ctx context.Context, ns string, id string, removal *fftypes.LegalHoldRemoval) (*fftypes.LegalHold, error) {
+	ret := _m.Called(ctx, ns, id, removal)
+
+	var r0 *fftypes.LegalHold
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.LegalHoldRemoval) *fftypes.LegalHold); ok {
+		r0 = rf(ctx, ns, id, removal)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.LegalHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.LegalHoldRemoval) error); ok {
+		r1 = rf(ctx, ns, id, removal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplayBlockchain provides a mock function with given fields: ctx, replay
+func (_m *Orchestrator) ReplayBlockchain(ctx context.Context, replay *fftypes.BlockchainReplay) (*fftypes.BlockchainReplay, error) {
+	ret := _m.Called(ctx, replay)
+
+	var r0 *fftypes.BlockchainReplay
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.BlockchainReplay) *fftypes.BlockchainReplay); ok {
+		r0 = rf(ctx, replay)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.BlockchainReplay)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.BlockchainReplay) error); ok {
+		r1 = rf(ctx, replay)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RequestReply provides a mock function with given fields: ctx, ns, msg
 func (_m *Orchestrator) RequestReply(ctx context.Context, ns string, msg *fftypes.MessageInOut) (*fftypes.MessageInOut, error) {
 	ret := _m.Called(ctx, ns, msg)
@@ -1141,6 +2202,91 @@ func (_m *Orchestrator) ResetConfig(ctx context.Context) {
 	_m.Called(ctx)
 }
 
+// ResolveAsOf provides a mock function with given fields: ctx, ns, asOf
+func (_m *Orchestrator) ResolveAsOf(ctx context.Context, ns string, asOf string) (*fftypes.FFTime, error) {
+	ret := _m.Called(ctx, ns, asOf)
+
+	var r0 *fftypes.FFTime
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.FFTime); ok {
+		r0 = rf(ctx, ns, asOf)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.FFTime)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, asOf)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolvePseudonymAuthor provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) ResolvePseudonymAuthor(ctx context.Context, ns string, id string) (*fftypes.PseudonymAuthor, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.PseudonymAuthor
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.PseudonymAuthor); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.PseudonymAuthor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rollup provides a mock function with given fields:
+func (_m *Orchestrator) Rollup() rollup.Manager {
+	ret := _m.Called()
+
+	var r0 rollup.Manager
+	if rf, ok := ret.Get(0).(func() rollup.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(rollup.Manager)
+		}
+	}
+
+	return r0
+}
+
+// SetComponentLogLevel provides a mock function with given fields: ctx, component, level
+func (_m *Orchestrator) SetComponentLogLevel(ctx context.Context, component string, level *log.ComponentLevel) (*log.Levels, error) {
+	ret := _m.Called(ctx, component, level)
+
+	var r0 *log.Levels
+	if rf, ok := ret.Get(0).(func(context.Context, string, *log.ComponentLevel) *log.Levels); ok {
+		r0 = rf(ctx, component, level)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*log.Levels)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *log.ComponentLevel) error); ok {
+		r1 = rf(ctx, component, level)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Orchestrator) Start() error {
 	ret := _m.Called()
@@ -1155,6 +2301,52 @@ func (_m *Orchestrator) Start() error {
 	return r0
 }
 
+// TailLogs provides a mock function with given fields: ctx, correlationID
+func (_m *Orchestrator) TailLogs(ctx context.Context, correlationID string) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, correlationID)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string) io.ReadCloser); ok {
+		r0 = rf(ctx, correlationID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, correlationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyMessageProof provides a mock function with given fields: ctx, ns, proof
+func (_m *Orchestrator) VerifyMessageProof(ctx context.Context, ns string, proof *fftypes.MessageProof) (*fftypes.MessageProofVerification, error) {
+	ret := _m.Called(ctx, ns, proof)
+
+	var r0 *fftypes.MessageProofVerification
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.MessageProof) *fftypes.MessageProofVerification); ok {
+		r0 = rf(ctx, ns, proof)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageProofVerification)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.MessageProof) error); ok {
+		r1 = rf(ctx, ns, proof)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitStop provides a mock function with given fields:
 func (_m *Orchestrator) WaitStop() {
 	_m.Called()
@@ -18,6 +18,29 @@ type Manager struct {
 	mock.Mock
 }
 
+// DecideMessageHold provides a mock function with given fields: ctx, id, decision
+func (_m *Manager) DecideMessageHold(ctx context.Context, id string, decision *fftypes.PolicyApprovalDecision) (*fftypes.MessageHold, error) {
+	ret := _m.Called(ctx, id, decision)
+
+	var r0 *fftypes.MessageHold
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.PolicyApprovalDecision) *fftypes.MessageHold); ok {
+		r0 = rf(ctx, id, decision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.PolicyApprovalDecision) error); ok {
+		r1 = rf(ctx, id, decision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EnsureLocalGroup provides a mock function with given fields: ctx, group
 func (_m *Manager) EnsureLocalGroup(ctx context.Context, group *fftypes.Group) (bool, error) {
 	ret := _m.Called(ctx, group)
@@ -62,6 +85,29 @@ func (_m *Manager) GetGroupByID(ctx context.Context, id string) (*fftypes.Group,
 	return r0, r1
 }
 
+// GetGroupKey provides a mock function with given fields: ctx, group, epoch
+func (_m *Manager) GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, group, epoch)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32, int64) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, group, epoch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32, int64) error); ok {
+		r1 = rf(ctx, group, epoch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetGroups provides a mock function with given fields: ctx, filter
 func (_m *Manager) GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -94,6 +140,84 @@ func (_m *Manager) GetGroups(ctx context.Context, filter database.AndFilter) ([]
 	return r0, r1, r2
 }
 
+// GetLatestGroupKey provides a mock function with given fields: ctx, group
+func (_m *Manager) GetLatestGroupKey(ctx context.Context, group *fftypes.Bytes32) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, group)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Bytes32) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, group)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, group)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageHoldByID provides a mock function with given fields: ctx, id
+func (_m *Manager) GetMessageHoldByID(ctx context.Context, id string) (*fftypes.MessageHold, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.MessageHold
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.MessageHold); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageHold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessageHolds provides a mock function with given fields: ctx, filter
+func (_m *Manager) GetMessageHolds(ctx context.Context, filter database.AndFilter) ([]*fftypes.MessageHold, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.MessageHold
+	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.MessageHold); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.MessageHold)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // NewMessage provides a mock function with given fields: ns, msg
 func (_m *Manager) NewMessage(ns string, msg *fftypes.MessageInOut) sysmessaging.MessageSender {
 	ret := _m.Called(ns, msg)
@@ -156,6 +280,43 @@ func (_m *Manager) ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (
 	return r0, r1
 }
 
+// RetryOperation provides a mock function with given fields: ctx, op
+func (_m *Manager) RetryOperation(ctx context.Context, op *fftypes.Operation) error {
+	ret := _m.Called(ctx, op)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
+		r0 = rf(ctx, op)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateGroupKey provides a mock function with given fields: ctx, ns, hash
+func (_m *Manager) RotateGroupKey(ctx context.Context, ns string, hash string) (*fftypes.GroupKey, error) {
+	ret := _m.Called(ctx, ns, hash)
+
+	var r0 *fftypes.GroupKey
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.GroupKey); ok {
+		r0 = rf(ctx, ns, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.GroupKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SendMessage provides a mock function with given fields: ctx, ns, in, waitConfirm
 func (_m *Manager) SendMessage(ctx context.Context, ns string, in *fftypes.MessageInOut, waitConfirm bool) (*fftypes.Message, error) {
 	ret := _m.Called(ctx, ns, in, waitConfirm)
@@ -179,6 +340,20 @@ func (_m *Manager) SendMessage(ctx context.Context, ns string, in *fftypes.Messa
 	return r0, r1
 }
 
+// SendReceipt provides a mock function with given fields: ctx, msg
+func (_m *Manager) SendReceipt(ctx context.Context, msg *fftypes.Message) error {
+	ret := _m.Called(ctx, msg)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Message) error); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Start provides a mock function with given fields:
 func (_m *Manager) Start() error {
 	ret := _m.Called()
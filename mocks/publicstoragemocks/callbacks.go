@@ -2,9 +2,28 @@
 
 package publicstoragemocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
+	mock "github.com/stretchr/testify/mock"
+
+	publicstorage "github.com/hyperledger/firefly/pkg/publicstorage"
+)
 
 // Callbacks is an autogenerated mock type for the Callbacks type
 type Callbacks struct {
 	mock.Mock
 }
+
+// PublicStorageOpUpdate provides a mock function with given fields: plugin, operationID, txState, errorMessage, opOutput
+func (_m *Callbacks) PublicStorageOpUpdate(plugin publicstorage.Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error {
+	ret := _m.Called(plugin, operationID, txState, errorMessage, opOutput)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(publicstorage.Plugin, *fftypes.UUID, fftypes.OpStatus, string, fftypes.JSONObject) error); ok {
+		r0 = rf(plugin, operationID, txState, errorMessage, opOutput)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -7,6 +7,8 @@ import (
 
 	config "github.com/hyperledger/firefly/internal/config"
 
+	fftypes "github.com/hyperledger/firefly/pkg/fftypes"
+
 	io "io"
 
 	mock "github.com/stretchr/testify/mock"
@@ -68,6 +70,11 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// PinData provides a mock function with given fields: ctx, operationID, payloadRef
+func (_m *Plugin) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) {
+	_m.Called(ctx, operationID, payloadRef)
+}
+
 // PublishData provides a mock function with given fields: ctx, data
 func (_m *Plugin) PublishData(ctx context.Context, data io.Reader) (string, error) {
 	ret := _m.Called(ctx, data)
@@ -42,6 +42,34 @@ func (_m *Callbacks) TokenPoolCreated(plugin tokens.Plugin, pool *tokens.TokenPo
 	return r0
 }
 
+// TokenPoolGovernance provides a mock function with given fields: plugin, poolProtocolID, change, protocolTxID, additionalInfo
+func (_m *Callbacks) TokenPoolGovernance(plugin tokens.Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	ret := _m.Called(plugin, poolProtocolID, change, protocolTxID, additionalInfo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(tokens.Plugin, string, *fftypes.TokenPoolGovernance, string, fftypes.JSONObject) error); ok {
+		r0 = rf(plugin, poolProtocolID, change, protocolTxID, additionalInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TokensApproved provides a mock function with given fields: plugin, poolProtocolID, approval, protocolTxID, additionalInfo
+func (_m *Callbacks) TokensApproved(plugin tokens.Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	ret := _m.Called(plugin, poolProtocolID, approval, protocolTxID, additionalInfo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(tokens.Plugin, string, *fftypes.TokenApproval, string, fftypes.JSONObject) error); ok {
+		r0 = rf(plugin, poolProtocolID, approval, protocolTxID, additionalInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TokensTransferred provides a mock function with given fields: plugin, poolProtocolID, transfer, protocolTxID, additionalInfo
 func (_m *Callbacks) TokensTransferred(plugin tokens.Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	ret := _m.Called(plugin, poolProtocolID, transfer, protocolTxID, additionalInfo)
@@ -138,6 +138,20 @@ func (_m *Plugin) Start() error {
 	return r0
 }
 
+// TokensApproval provides a mock function with given fields: ctx, operationID, poolProtocolID, approval
+func (_m *Plugin) TokensApproval(ctx context.Context, operationID *fftypes.UUID, poolProtocolID string, approval *fftypes.TokenApproval) error {
+	ret := _m.Called(ctx, operationID, poolProtocolID, approval)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, string, *fftypes.TokenApproval) error); ok {
+		r0 = rf(ctx, operationID, poolProtocolID, approval)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TransferTokens provides a mock function with given fields: ctx, operationID, poolProtocolID, transfer
 func (_m *Plugin) TransferTokens(ctx context.Context, operationID *fftypes.UUID, poolProtocolID string, transfer *fftypes.TokenTransfer) error {
 	ret := _m.Called(ctx, operationID, poolProtocolID, transfer)
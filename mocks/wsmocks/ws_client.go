@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	wsclient "github.com/hyperledger/firefly/pkg/wsclient"
 )
 
 // WSClient is an autogenerated mock type for the WSClient type
@@ -13,6 +15,16 @@ type WSClient struct {
 	mock.Mock
 }
 
+// AddPostConnectHandler provides a mock function with given fields: handler
+func (_m *WSClient) AddPostConnectHandler(handler wsclient.WSPostConnectHandler) {
+	_m.Called(handler)
+}
+
+// AddReconnectListener provides a mock function with given fields: listener
+func (_m *WSClient) AddReconnectListener(listener wsclient.WSReconnectListener) {
+	_m.Called(listener)
+}
+
 // Close provides a mock function with given fields:
 func (_m *WSClient) Close() {
 	_m.Called()
@@ -67,6 +79,20 @@ func (_m *WSClient) SetURL(url string) {
 	_m.Called(url)
 }
 
+// Subscribe provides a mock function with given fields: ctx, command
+func (_m *WSClient) Subscribe(ctx context.Context, command []byte) error {
+	ret := _m.Called(ctx, command)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) error); ok {
+		r0 = rf(ctx, command)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // URL provides a mock function with given fields:
 func (_m *WSClient) URL() string {
 	ret := _m.Called()
@@ -23,7 +23,9 @@ import (
 	"github.com/hyperledger/firefly/pkg/fftypes"
 )
 
-// Plugin is the interface implemented by each blockchain plugin
+// Plugin is the interface implemented by each blockchain plugin.
+// Methods that take a namespace are passed the namespace of the request, so that a plugin that multiplexes
+// several connectors can route the call to the connector the namespace is bound to.
 type Plugin interface {
 	fftypes.Named
 
@@ -42,10 +44,42 @@ type Plugin interface {
 
 	// ResolveSigningKey verifies that the supplied identity string is valid syntax according to the protocol.
 	// Can apply transformations to the supplied signing identity (only), such as lower case
-	ResolveSigningKey(ctx context.Context, signingKey string) (string, error)
+	ResolveSigningKey(ctx context.Context, ns, signingKey string) (string, error)
 
 	// SubmitBatchPin sequences a batch of message globally to all viewers of a given ledger
 	SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *BatchPin) error
+
+	// SubmitBatchPins sequences multiple batches, which might be in different namespaces, in a single transaction.
+	// Each batch is delivered to BatchPinComplete separately. Only supported if the BatchPinAggregation capability is set.
+	SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*BatchPin) error
+
+	// GetNativeBalance returns the balance of the native (gas) token held by the signing key.
+	// Returns nil if the protocol has no native token, or the plugin is not configured to query it.
+	GetNativeBalance(ctx context.Context, ns, signingKey string) (*fftypes.BigInt, error)
+
+	// InvokeContract submits a transaction to invoke a method on a custom contract, at the supplied location.
+	// The result is delivered asynchronously via BlockchainOpUpdate for the operation.
+	InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error
+
+	// QueryContract synchronously calls a read-only method on a custom contract, at the supplied location, returning the result
+	QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error)
+
+	// NormalizeContractLocation validates the protocol specific location of a contract (such as an address),
+	// and returns it in a normalized form
+	NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error)
+
+	// AddContractListener subscribes to an event emitted by a contract, at the normalized location of the listener.
+	// The plugin sets the ProtocolID of the listener, which is then set as the Listener of each blockchain event
+	// delivered for that subscription
+	AddContractListener(ctx context.Context, listener *fftypes.ContractListener) error
+
+	// GetTransactionFee extracts the gas used and fee paid from the output of an operation update,
+	// as delivered via BlockchainOpUpdate. Returns nil if the output is not a receipt that reports the gas used
+	GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee
+
+	// ResetEventStream rewinds the checkpoint of the subscriptions to BatchPin events to the supplied block number,
+	// so that every BatchPin event from that block onwards is redelivered to BatchPinComplete
+	ResetEventStream(ctx context.Context, fromBlock string) error
 }
 
 // Callbacks is the interface provided to the blockchain plugin, to allow it to pass events back to firefly.
@@ -71,6 +105,14 @@ type Callbacks interface {
 	//
 	// Error should will only be returned in shutdown scenarios
 	BatchPinComplete(batch *BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error
+
+	// BlockchainEvent notifies on the arrival of any event from the blockchain, so that it is recorded in the
+	// index of blockchain events. This includes events that firefly does not otherwise process, such as events
+	// from custom contract listeners. For events that firefly does process, such as BatchPinComplete, this is
+	// called first for the same event.
+	//
+	// Error should will only be returned in shutdown scenarios
+	BlockchainEvent(event *fftypes.BlockchainEvent) error
 }
 
 // Capabilities the supported featureset of the blockchain
@@ -79,6 +121,9 @@ type Capabilities struct {
 	// GlobalSequencer means submitting an ordered piece of data visible to all
 	// participants of the network (requires an all-participant chain)
 	GlobalSequencer bool
+
+	// BatchPinAggregation means the pins of multiple batches can be submitted in a single transaction with SubmitBatchPins
+	BatchPinAggregation bool
 }
 
 // TransactionStatus is the only architecturally significant thing that Firefly tracks on blockchain transactions.
@@ -121,4 +166,7 @@ type BatchPin struct {
 	//   - The hashes contain a sender specific nonce that is a monotomically increasing number
 	//     for batches sent by that sender, within the context (maintined by the sender FireFly node)
 	Contexts []*fftypes.Bytes32
+
+	// Timestamp is the time of the block containing the batch pin, if provided by the blockchain connector
+	Timestamp *fftypes.FFTime
 }
@@ -236,6 +236,25 @@ func (f *FilterInfo) String() string {
 	return val.String()
 }
 
+// FieldIn returns the first field in the set (keyed by lower case name) that the filter conditions
+// or sort reference, or an empty string if none are referenced
+func (f *FilterInfo) FieldIn(fields map[string]bool) string {
+	if fields[strings.ToLower(f.Field)] {
+		return f.Field
+	}
+	for _, sf := range f.Sort {
+		if fields[strings.ToLower(sf.Field)] {
+			return sf.Field
+		}
+	}
+	for _, child := range f.Children {
+		if field := child.FieldIn(fields); field != "" {
+			return field
+		}
+	}
+	return ""
+}
+
 func (fb *filterBuilder) Fields() []string {
 	keys := make([]string, len(fb.queryFields))
 	i := 0
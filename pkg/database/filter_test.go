@@ -309,3 +309,22 @@ func TestStringsForTypes(t *testing.T) {
 	assert.Equal(t, "t1,t2", (&ffNameArrayField{na: fftypes.FFNameArray{"t1", "t2"}}).String())
 	assert.Equal(t, "true", (&boolField{b: true}).String())
 }
+
+func TestFilterFieldIn(t *testing.T) {
+	fb := MessageQueryFactory.NewFilter(context.Background())
+	fields := map[string]bool{"state": true, "confirmed": true}
+
+	f, err := fb.And(fb.Eq("namespace", "ns1"), fb.Or(fb.Eq("tag", "a"), fb.Eq("STATE", "ready"))).Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "STATE", f.FieldIn(fields))
+
+	fb = MessageQueryFactory.NewFilter(context.Background())
+	f, err = fb.And(fb.Eq("namespace", "ns1")).Sort("confirmed").Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "confirmed", f.FieldIn(fields))
+
+	fb = MessageQueryFactory.NewFilter(context.Background())
+	f, err = fb.And(fb.Eq("namespace", "ns1")).Sort("created").Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "", f.FieldIn(fields))
+}
@@ -0,0 +1,50 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sync"
+)
+
+type historicalReadsKey struct{}
+
+type historicalReads struct {
+	once   sync.Once
+	clause string
+}
+
+// WithHistoricalReads marks the context of an API query that can tolerate slightly stale results, so that a
+// database that supports it can serve the reads from a point in time in the past, without contending with
+// writes. Event processing must never use it, as it depends on reading the latest rows.
+func WithHistoricalReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, historicalReadsKey{}, &historicalReads{})
+}
+
+// HistoricalReadClause returns the clause for reads made with a context marked by WithHistoricalReads, calling
+// resolve the first time it is needed, so that every read for the query (such as a page of results and the
+// count of the total) is served from the same point in time. Empty is returned for any other context.
+func HistoricalReadClause(ctx context.Context, resolve func() string) string {
+	hr, ok := ctx.Value(historicalReadsKey{}).(*historicalReads)
+	if !ok {
+		return ""
+	}
+	hr.once.Do(func() {
+		hr.clause = resolve()
+	})
+	return hr.clause
+}
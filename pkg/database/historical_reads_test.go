@@ -0,0 +1,40 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoricalReadClause(t *testing.T) {
+	resolved := 0
+	resolve := func() string {
+		resolved++
+		return "AS OF SYSTEM TIME '2021-01-01T00:00:00Z'"
+	}
+
+	assert.Empty(t, HistoricalReadClause(context.Background(), resolve))
+	assert.Equal(t, 0, resolved)
+
+	ctx := WithHistoricalReads(context.Background())
+	assert.Equal(t, "AS OF SYSTEM TIME '2021-01-01T00:00:00Z'", HistoricalReadClause(ctx, resolve))
+	assert.Equal(t, "AS OF SYSTEM TIME '2021-01-01T00:00:00Z'", HistoricalReadClause(ctx, resolve))
+	assert.Equal(t, 1, resolved)
+}
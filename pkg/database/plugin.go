@@ -18,6 +18,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -54,6 +55,9 @@ type Plugin interface {
 
 	// Capabilities returns capabilities - not called until after Init
 	Capabilities() *Capabilities
+
+	// ConnectionStatus returns the health and utilization of the connection pool - not called until after Init
+	ConnectionStatus(ctx context.Context) *fftypes.NodeStatusDatabase
 }
 
 type iNamespaceCollection interface {
@@ -162,6 +166,104 @@ type iDatatypeCollection interface {
 	GetDatatypes(ctx context.Context, filter Filter) (datadef []*fftypes.Datatype, res *FilterResult, err error)
 }
 
+type iFFICollection interface {
+	// UpsertFFI - Upsert a contract interface
+	UpsertFFI(ctx context.Context, ffi *fftypes.FFI) error
+
+	// GetFFIByID - Get a contract interface by ID
+	GetFFIByID(ctx context.Context, id *fftypes.UUID) (*fftypes.FFI, error)
+
+	// GetFFI - Get a contract interface by name and version
+	GetFFI(ctx context.Context, ns, name, version string) (*fftypes.FFI, error)
+
+	// GetFFIs - Get contract interfaces
+	GetFFIs(ctx context.Context, filter Filter) ([]*fftypes.FFI, *FilterResult, error)
+}
+
+type iContractListenerCollection interface {
+	// InsertContractListener - Insert a listener for events from a contract
+	InsertContractListener(ctx context.Context, listener *fftypes.ContractListener) error
+
+	// GetContractListenerByID - Get a contract listener by ID
+	GetContractListenerByID(ctx context.Context, id *fftypes.UUID) (*fftypes.ContractListener, error)
+
+	// GetContractListenerByProtocolID - Get a contract listener by the ID allocated to it by the blockchain plugin
+	GetContractListenerByProtocolID(ctx context.Context, protocolID string) (*fftypes.ContractListener, error)
+
+	// GetContractListeners - Get contract listeners
+	GetContractListeners(ctx context.Context, filter Filter) ([]*fftypes.ContractListener, *FilterResult, error)
+}
+
+type iBatchQuarantineCollection interface {
+	// InsertBatchQuarantine - Insert an inbound batch quarantined for exceeding the receive limits
+	InsertBatchQuarantine(ctx context.Context, quarantine *fftypes.BatchQuarantine) error
+
+	// UpdateBatchQuarantine - Update a batch quarantine
+	UpdateBatchQuarantine(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetBatchQuarantineByID - Get a batch quarantine by the ID of the batch
+	GetBatchQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BatchQuarantine, error)
+
+	// GetBatchQuarantines - Get batch quarantines
+	GetBatchQuarantines(ctx context.Context, filter Filter) ([]*fftypes.BatchQuarantine, *FilterResult, error)
+}
+
+type iBlockedPinCollection interface {
+	// InsertBlockedPin - Insert a broadcast batch pin blocked on the retrieval of its payload
+	InsertBlockedPin(ctx context.Context, blocked *fftypes.BlockedPin) error
+
+	// UpdateBlockedPin - Update a blocked pin
+	UpdateBlockedPin(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetBlockedPinByID - Get a blocked pin by the ID of the batch
+	GetBlockedPinByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockedPin, error)
+
+	// GetBlockedPins - Get blocked pins
+	GetBlockedPins(ctx context.Context, filter Filter) ([]*fftypes.BlockedPin, *FilterResult, error)
+
+	// DeleteBlockedPin - Delete a blocked pin, once the payload of the batch has been retrieved
+	DeleteBlockedPin(ctx context.Context, id *fftypes.UUID) error
+}
+
+type iPinQuarantineCollection interface {
+	// InsertPinQuarantine - Insert a message quarantined for a pin signed by a key not permitted by the pin policy
+	InsertPinQuarantine(ctx context.Context, quarantine *fftypes.PinQuarantine) error
+
+	// UpdatePinQuarantine - Update a pin quarantine
+	UpdatePinQuarantine(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetPinQuarantineByID - Get a pin quarantine by the ID of the message
+	GetPinQuarantineByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PinQuarantine, error)
+
+	// GetPinQuarantines - Get pin quarantines
+	GetPinQuarantines(ctx context.Context, filter Filter) ([]*fftypes.PinQuarantine, *FilterResult, error)
+}
+
+type iMetricRollupCollection interface {
+	// GetMetricCounts - Count the records for a metric created in a time range, by namespace and type, in buckets of the given interval
+	GetMetricCounts(ctx context.Context, metric fftypes.MetricRollupType, interval time.Duration, startTime, endTime *fftypes.FFTime) ([]*fftypes.MetricRollup, error)
+
+	// UpsertMetricRollup - Upsert the count for a metric rollup bucket, replacing any previous count
+	UpsertMetricRollup(ctx context.Context, rollup *fftypes.MetricRollup) error
+
+	// GetMetricRollups - Get metric rollups
+	GetMetricRollups(ctx context.Context, filter Filter) ([]*fftypes.MetricRollup, *FilterResult, error)
+}
+
+type iUnmatchedReceiptCollection interface {
+	// InsertUnmatchedReceipt - Insert a connector receipt that could not be matched to an operation
+	InsertUnmatchedReceipt(ctx context.Context, receipt *fftypes.UnmatchedReceipt) error
+
+	// UpdateUnmatchedReceipt - Update an unmatched receipt
+	UpdateUnmatchedReceipt(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetUnmatchedReceiptByID - Get an unmatched receipt by ID
+	GetUnmatchedReceiptByID(ctx context.Context, id *fftypes.UUID) (*fftypes.UnmatchedReceipt, error)
+
+	// GetUnmatchedReceipts - Get unmatched receipts
+	GetUnmatchedReceipts(ctx context.Context, filter Filter) ([]*fftypes.UnmatchedReceipt, *FilterResult, error)
+}
+
 type iOffsetCollection interface {
 	// UpsertOffset - Upsert an offset
 	UpsertOffset(ctx context.Context, data *fftypes.Offset, allowExisting bool) (err error)
@@ -200,6 +302,9 @@ type iOperationCollection interface {
 	// UpdateOperation - Update operation by ID
 	UpdateOperation(ctx context.Context, id *fftypes.UUID, update Update) (err error)
 
+	// SetOperationRetry - Link an operation to its retry, failing if the operation already has a retry
+	SetOperationRetry(ctx context.Context, id, retryID *fftypes.UUID) (err error)
+
 	// GetOperationByID - Get an operation by ID
 	GetOperationByID(ctx context.Context, id *fftypes.UUID) (operation *fftypes.Operation, err error)
 
@@ -339,6 +444,12 @@ type iBlobCollection interface {
 
 	// DeleteBlob - delete a blob, using its local database ID
 	DeleteBlob(ctx context.Context, sequence int64) (err error)
+
+	// UpdateBlobRefs - adjust the count of data records referring to a blob, using its local database ID
+	UpdateBlobRefs(ctx context.Context, sequence int64, delta int64) (err error)
+
+	// GetBlobStats - get the count of unique blobs, and of the references to them
+	GetBlobStats(ctx context.Context) (stats *fftypes.BlobStats, err error)
 }
 
 type iConfigRecordCollection interface {
@@ -404,9 +515,209 @@ type iTokenTransferCollection interface {
 	GetTokenTransfers(ctx context.Context, filter Filter) ([]*fftypes.TokenTransfer, *FilterResult, error)
 }
 
+type iTokenApprovalCollection interface {
+	// UpsertTokenApproval - Upsert a token approval
+	UpsertTokenApproval(ctx context.Context, approval *fftypes.TokenApproval) error
+
+	// GetTokenApproval - Get a token approval by ID
+	GetTokenApproval(ctx context.Context, localID *fftypes.UUID) (*fftypes.TokenApproval, error)
+
+	// GetTokenApprovalByProtocolID - Get a token approval by protocol ID
+	GetTokenApprovalByProtocolID(ctx context.Context, connector, protocolID string) (*fftypes.TokenApproval, error)
+
+	// GetTokenApprovals - Get token approvals
+	GetTokenApprovals(ctx context.Context, filter Filter) ([]*fftypes.TokenApproval, *FilterResult, error)
+}
+
+type iTokenNFTCollection interface {
+	// UpsertTokenNFT - Upsert the state of a non-fungible token, keyed by its pool and token index
+	UpsertTokenNFT(ctx context.Context, nft *fftypes.TokenNFT) error
+
+	// GetTokenNFT - Get a non-fungible token by pool and token index
+	GetTokenNFT(ctx context.Context, poolID *fftypes.UUID, tokenIndex string) (*fftypes.TokenNFT, error)
+
+	// GetTokenNFTs - Get non-fungible tokens
+	GetTokenNFTs(ctx context.Context, filter Filter) ([]*fftypes.TokenNFT, *FilterResult, error)
+}
+
+type iPseudonymCollection interface {
+	// UpsertPseudonym - Upsert a pseudonym. The owner is only written on insert, and is never overwritten by an update
+	UpsertPseudonym(ctx context.Context, pseudonym *fftypes.Pseudonym) error
+
+	// GetPseudonymByID - Get a pseudonym by ID
+	GetPseudonymByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Pseudonym, error)
+
+	// GetPseudonymByKey - Get a pseudonym by its signing key
+	GetPseudonymByKey(ctx context.Context, key string) (*fftypes.Pseudonym, error)
+
+	// GetPseudonyms - Get pseudonyms
+	GetPseudonyms(ctx context.Context, filter Filter) ([]*fftypes.Pseudonym, *FilterResult, error)
+}
+
+type iDelegationCollection interface {
+	// UpsertDelegation - Upsert a delegation. Only the message and revocation details change on update
+	UpsertDelegation(ctx context.Context, delegation *fftypes.Delegation) error
+
+	// GetDelegationByID - Get a delegation by ID
+	GetDelegationByID(ctx context.Context, id *fftypes.UUID) (*fftypes.Delegation, error)
+
+	// GetDelegations - Get delegations
+	GetDelegations(ctx context.Context, filter Filter) ([]*fftypes.Delegation, *FilterResult, error)
+}
+
+type iCustomIdentityCollection interface {
+	// UpsertCustomIdentity - Upsert a custom identity. Only the claim and verification details change on update
+	UpsertCustomIdentity(ctx context.Context, identity *fftypes.CustomIdentity) error
+
+	// GetCustomIdentityByID - Get a custom identity by ID
+	GetCustomIdentityByID(ctx context.Context, id *fftypes.UUID) (*fftypes.CustomIdentity, error)
+
+	// GetCustomIdentityByKey - Get the verified custom identity with a signing key
+	GetCustomIdentityByKey(ctx context.Context, key string) (*fftypes.CustomIdentity, error)
+
+	// GetCustomIdentityByName - Get the verified custom identity with a name, within a namespace
+	GetCustomIdentityByName(ctx context.Context, ns, name string) (*fftypes.CustomIdentity, error)
+
+	// GetCustomIdentities - Get custom identities
+	GetCustomIdentities(ctx context.Context, filter Filter) ([]*fftypes.CustomIdentity, *FilterResult, error)
+}
+
+type iGroupKeyCollection interface {
+	// InsertGroupKey - Insert a new epoch of a group key. Keys are never updated, so earlier epochs are retained
+	InsertGroupKey(ctx context.Context, groupKey *fftypes.GroupKey) error
+
+	// GetGroupKey - Get a group key by group hash and epoch
+	GetGroupKey(ctx context.Context, group *fftypes.Bytes32, epoch int64) (*fftypes.GroupKey, error)
+
+	// GetGroupKeys - Get group keys
+	GetGroupKeys(ctx context.Context, filter Filter) ([]*fftypes.GroupKey, *FilterResult, error)
+}
+
+type iBlockchainEventCollection interface {
+	// InsertBlockchainEvent - Insert a blockchain event. Duplicate deliveries of an event with the same source and protocol ID are ignored
+	InsertBlockchainEvent(ctx context.Context, event *fftypes.BlockchainEvent) error
+
+	// GetBlockchainEventByID - Get a blockchain event by ID
+	GetBlockchainEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.BlockchainEvent, error)
+
+	// GetBlockchainEvents - Get blockchain events
+	GetBlockchainEvents(ctx context.Context, filter Filter) ([]*fftypes.BlockchainEvent, *FilterResult, error)
+}
+
+type iPolicyApprovalCollection interface {
+	// InsertPolicyApproval - Insert a submission held for manual approval by the policy plugin
+	InsertPolicyApproval(ctx context.Context, approval *fftypes.PolicyApproval) error
+
+	// UpdatePolicyApproval - Update a policy approval
+	UpdatePolicyApproval(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetPolicyApprovalByID - Get a policy approval by ID
+	GetPolicyApprovalByID(ctx context.Context, id *fftypes.UUID) (*fftypes.PolicyApproval, error)
+
+	// GetPolicyApprovals - Get policy approvals
+	GetPolicyApprovals(ctx context.Context, filter Filter) ([]*fftypes.PolicyApproval, *FilterResult, error)
+}
+
+type iMessageHoldCollection interface {
+	// InsertMessageHold - Insert an outbound private message held until an operator approves its release
+	InsertMessageHold(ctx context.Context, hold *fftypes.MessageHold) error
+
+	// UpdateMessageHold - Update a message hold
+	UpdateMessageHold(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetMessageHoldByID - Get a message hold by the ID of the message
+	GetMessageHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.MessageHold, error)
+
+	// GetMessageHolds - Get message holds
+	GetMessageHolds(ctx context.Context, filter Filter) ([]*fftypes.MessageHold, *FilterResult, error)
+}
+
+type iLegalHoldCollection interface {
+	// InsertLegalHold - Insert a legal hold, exempting a message, data item or topic from retention pruning
+	InsertLegalHold(ctx context.Context, hold *fftypes.LegalHold) error
+
+	// UpdateLegalHold - Update a legal hold
+	UpdateLegalHold(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetLegalHoldByID - Get a legal hold by ID
+	GetLegalHoldByID(ctx context.Context, id *fftypes.UUID) (*fftypes.LegalHold, error)
+
+	// GetLegalHolds - Get legal holds
+	GetLegalHolds(ctx context.Context, filter Filter) ([]*fftypes.LegalHold, *FilterResult, error)
+}
+
+type iTokenBridgeCollection interface {
+	// InsertTokenBridge - Insert a token bridge
+	InsertTokenBridge(ctx context.Context, bridge *fftypes.TokenBridge) error
+
+	// UpdateTokenBridge - Update a token bridge
+	UpdateTokenBridge(ctx context.Context, id *fftypes.UUID, update Update) error
+
+	// GetTokenBridgeByID - Get a token bridge by ID
+	GetTokenBridgeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.TokenBridge, error)
+
+	// GetTokenBridges - Get token bridges
+	GetTokenBridges(ctx context.Context, filter Filter) ([]*fftypes.TokenBridge, *FilterResult, error)
+}
+
+type iSnapshotCollection interface {
+	// UpsertSnapshot - Replace the snapshot for an event poller, inserting the first one
+	UpsertSnapshot(ctx context.Context, snapshot *fftypes.Snapshot) error
+
+	// GetSnapshot - Get the latest snapshot for an event poller
+	GetSnapshot(ctx context.Context, t fftypes.SnapshotType, name string) (*fftypes.Snapshot, error)
+}
+
+type iReceiptCollection interface {
+	// UpsertReceipt - Record a receipt for a message from a member org, replacing any earlier receipt from the same org
+	UpsertReceipt(ctx context.Context, receipt *fftypes.MessageReceipt) error
+
+	// GetReceipts - Get message receipts
+	GetReceipts(ctx context.Context, filter Filter) ([]*fftypes.MessageReceipt, *FilterResult, error)
+}
+
+type iSigningActivityCollection interface {
+	// InsertSigningActivity - Record the use of a signing key to submit an operation
+	InsertSigningActivity(ctx context.Context, activity *fftypes.SigningActivity) error
+
+	// GetSigningActivity - Get signing activity
+	GetSigningActivity(ctx context.Context, filter Filter) ([]*fftypes.SigningActivity, *FilterResult, error)
+}
+
+type iMessageTransitionCollection interface {
+	// InsertMessageTransition - Record a change in the state of a message
+	InsertMessageTransition(ctx context.Context, transition *fftypes.MessageTransition) error
+
+	// GetMessageTransitions - Get message state transitions
+	GetMessageTransitions(ctx context.Context, filter Filter) ([]*fftypes.MessageTransition, *FilterResult, error)
+}
+
+type iAuditLogCollection interface {
+	// InsertAuditRecord - Record an API call in the audit log
+	InsertAuditRecord(ctx context.Context, record *fftypes.AuditRecord) error
+
+	// GetAuditRecordByID - Get an audit record by ID
+	GetAuditRecordByID(ctx context.Context, id *fftypes.UUID) (*fftypes.AuditRecord, error)
+
+	// GetAuditRecords - Get audit records
+	GetAuditRecords(ctx context.Context, filter Filter) ([]*fftypes.AuditRecord, *FilterResult, error)
+}
+
 type iChartCollection interface {
 	// GetChartHistogram - Get charting data for a histogram
 	GetChartHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection CollectionName) ([]*fftypes.ChartHistogram, error)
+
+	// GetChartTypeHistogram - Get charting data for a histogram, with each bucket broken down by type
+	GetChartTypeHistogram(ctx context.Context, ns string, intervals []fftypes.ChartHistogramInterval, collection CollectionName) ([]*fftypes.ChartHistogramTyped, error)
+
+	// GetChartTopTopics - Get the topics with the most messages in a time range, busiest first
+	GetChartTopTopics(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error)
+
+	// GetChartTopAuthors - Get the identities that authored the most messages in a time range, busiest first
+	GetChartTopAuthors(ctx context.Context, ns string, startTime, endTime *fftypes.FFTime, limit int) ([]*fftypes.ChartRanking, error)
+
+	// GetIdentityUsage - Get the usage totals of an author, and its signing keys, in a time range
+	GetIdentityUsage(ctx context.Context, ns, author string, keys []string, startTime, endTime *fftypes.FFTime) (*fftypes.IdentityUsage, error)
 }
 
 // PeristenceInterface are the operations that must be implemented by a database interfavce plugin.
@@ -444,6 +755,12 @@ type PeristenceInterface interface {
 	// - The caller is responsible for passing the supplied context to all database operations within the callback function
 	RunAsGroup(ctx context.Context, fn func(ctx context.Context) error) error
 
+	// RunAsRetryableGroup is RunAsGroup for a function that is idempotent - it has no effects other than the database
+	// operations within the group, so it can be run again from the start if the database aborts the transaction due to
+	// a conflict with another one (as CockroachDB can). Other groups fail with the error reported by the database.
+	// When nested within another group, the function is not retried individually.
+	RunAsRetryableGroup(ctx context.Context, fn func(ctx context.Context) error) error
+
 	iNamespaceCollection
 	iMessageCollection
 	iDataCollection
@@ -458,6 +775,7 @@ type PeristenceInterface interface {
 	iOrganizationsCollection
 	iNodeCollection
 	iGroupCollection
+	iGroupKeyCollection
 	iNonceCollection
 	iNextPinCollection
 	iBlobCollection
@@ -465,7 +783,29 @@ type PeristenceInterface interface {
 	iTokenPoolCollection
 	iTokenBalanceCollection
 	iTokenTransferCollection
+	iTokenApprovalCollection
+	iTokenNFTCollection
+	iPseudonymCollection
+	iDelegationCollection
+	iCustomIdentityCollection
+	iBlockchainEventCollection
 	iChartCollection
+	iPolicyApprovalCollection
+	iMessageHoldCollection
+	iLegalHoldCollection
+	iTokenBridgeCollection
+	iSnapshotCollection
+	iReceiptCollection
+	iSigningActivityCollection
+	iMessageTransitionCollection
+	iFFICollection
+	iContractListenerCollection
+	iBatchQuarantineCollection
+	iUnmatchedReceiptCollection
+	iBlockedPinCollection
+	iPinQuarantineCollection
+	iMetricRollupCollection
+	iAuditLogCollection
 }
 
 // CollectionName represents all collections
@@ -478,8 +818,9 @@ type CollectionName string
 type OrderedUUIDCollectionNS CollectionName
 
 const (
-	CollectionMessages OrderedUUIDCollectionNS = "messages"
-	CollectionEvents   OrderedUUIDCollectionNS = "events"
+	CollectionMessages         OrderedUUIDCollectionNS = "messages"
+	CollectionEvents           OrderedUUIDCollectionNS = "events"
+	CollectionBlockchainEvents OrderedUUIDCollectionNS = "blockchainevents"
 )
 
 // OrderedCollection is a collection that is ordered, and that sequence is the only key
@@ -495,13 +836,20 @@ const (
 type UUIDCollectionNS CollectionName
 
 const (
-	CollectionBatches       UUIDCollectionNS = "batches"
-	CollectionData          UUIDCollectionNS = "data"
-	CollectionDataTypes     UUIDCollectionNS = "datatypes"
-	CollectionOperations    UUIDCollectionNS = "operations"
-	CollectionSubscriptions UUIDCollectionNS = "subscriptions"
-	CollectionTransactions  UUIDCollectionNS = "transactions"
-	CollectionTokenPools    UUIDCollectionNS = "tokenpools"
+	CollectionBatches           UUIDCollectionNS = "batches"
+	CollectionData              UUIDCollectionNS = "data"
+	CollectionDataTypes         UUIDCollectionNS = "datatypes"
+	CollectionOperations        UUIDCollectionNS = "operations"
+	CollectionSubscriptions     UUIDCollectionNS = "subscriptions"
+	CollectionTransactions      UUIDCollectionNS = "transactions"
+	CollectionTokenPools        UUIDCollectionNS = "tokenpools"
+	CollectionFFIs              UUIDCollectionNS = "ffi"
+	CollectionContractListeners UUIDCollectionNS = "contractlisteners"
+	CollectionTokenNFTs         UUIDCollectionNS = "tokennfts"
+	CollectionPseudonyms        UUIDCollectionNS = "pseudonyms"
+	CollectionCustomIdentities  UUIDCollectionNS = "identities"
+	CollectionGroupKeys         UUIDCollectionNS = "groupkeys"
+	CollectionDelegations       UUIDCollectionNS = "delegations"
 )
 
 // HashCollectionNS is a collection where the primary key is a hash, such that it can
@@ -521,6 +869,7 @@ const (
 	CollectionNodes          UUIDCollection = "nodes"
 	CollectionOrganizations  UUIDCollection = "organizations"
 	CollectionTokenTransfers UUIDCollection = "tokentransfers"
+	CollectionTokenApprovals UUIDCollection = "tokenapprovals"
 )
 
 // OtherCollection are odd balls, that don't fit any of the categories above.
@@ -530,12 +879,22 @@ const (
 type OtherCollection CollectionName
 
 const (
-	CollectionConfigrecords OtherCollection = "configrecords"
-	CollectionBlobs         OtherCollection = "blobs"
-	CollectionNextpins      OtherCollection = "nextpins"
-	CollectionNonces        OtherCollection = "nonces"
-	CollectionOffsets       OtherCollection = "offsets"
-	CollectionTokenBalances OtherCollection = "tokenbalances"
+	CollectionConfigrecords   OtherCollection = "configrecords"
+	CollectionBlobs           OtherCollection = "blobs"
+	CollectionNextpins        OtherCollection = "nextpins"
+	CollectionNonces          OtherCollection = "nonces"
+	CollectionOffsets         OtherCollection = "offsets"
+	CollectionTokenBalances   OtherCollection = "tokenbalances"
+	CollectionPolicyApprovals OtherCollection = "policyapprovals"
+	CollectionMessageHolds    OtherCollection = "messageholds"
+	CollectionTokenBridges    OtherCollection = "tokenbridges"
+	CollectionSnapshots       OtherCollection = "snapshots"
+	CollectionReceipts        OtherCollection = "receipts"
+	CollectionSigningActivity OtherCollection = "signingactivity"
+	CollectionMsgTransitions  OtherCollection = "messagetransitions"
+	CollectionBatchQuarantine OtherCollection = "batchquarantine"
+	CollectionPinQuarantine   OtherCollection = "pinquarantine"
+	CollectionLegalHolds      OtherCollection = "legalholds"
 )
 
 // Callbacks are the methods for passing data from plugin to core
@@ -583,54 +942,59 @@ var NamespaceQueryFactory = &queryFields{
 
 // MessageQueryFactory filter fields for messages
 var MessageQueryFactory = &queryFields{
-	"id":        &UUIDField{},
-	"cid":       &UUIDField{},
-	"namespace": &StringField{},
-	"type":      &StringField{},
-	"author":    &StringField{},
-	"key":       &StringField{},
-	"topics":    &FFNameArrayField{},
-	"tag":       &StringField{},
-	"group":     &Bytes32Field{},
-	"created":   &TimeField{},
-	"hash":      &Bytes32Field{},
-	"pins":      &FFNameArrayField{},
-	"state":     &StringField{},
-	"confirmed": &TimeField{},
-	"sequence":  &Int64Field{},
-	"txtype":    &StringField{},
-	"batch":     &UUIDField{},
+	"id":             &UUIDField{},
+	"cid":            &UUIDField{},
+	"namespace":      &StringField{},
+	"type":           &StringField{},
+	"author":         &StringField{},
+	"key":            &StringField{},
+	"topics":         &FFNameArrayField{},
+	"tag":            &StringField{},
+	"group":          &Bytes32Field{},
+	"created":        &TimeField{},
+	"hash":           &Bytes32Field{},
+	"pins":           &FFNameArrayField{},
+	"state":          &StringField{},
+	"confirmed":      &TimeField{},
+	"sequence":       &Int64Field{},
+	"txtype":         &StringField{},
+	"batch":          &UUIDField{},
+	"custom":         &StringField{},
+	"idempotencykey": &StringField{},
+	"delegation":     &UUIDField{},
 }
 
 // BatchQueryFactory filter fields for batches
 var BatchQueryFactory = &queryFields{
-	"id":         &UUIDField{},
-	"namespace":  &StringField{},
-	"type":       &StringField{},
-	"author":     &StringField{},
-	"key":        &StringField{},
-	"group":      &Bytes32Field{},
-	"hash":       &Bytes32Field{},
-	"payloadref": &StringField{},
-	"created":    &TimeField{},
-	"confirmed":  &TimeField{},
-	"tx.type":    &StringField{},
-	"tx.id":      &UUIDField{},
-	"node":       &UUIDField{},
+	"id":            &UUIDField{},
+	"namespace":     &StringField{},
+	"type":          &StringField{},
+	"author":        &StringField{},
+	"key":           &StringField{},
+	"group":         &Bytes32Field{},
+	"hash":          &Bytes32Field{},
+	"payloadref":    &StringField{},
+	"created":       &TimeField{},
+	"confirmed":     &TimeField{},
+	"tx.type":       &StringField{},
+	"tx.id":         &UUIDField{},
+	"node":          &UUIDField{},
+	"hashalgorithm": &StringField{},
 }
 
 // TransactionQueryFactory filter fields for transactions
 var TransactionQueryFactory = &queryFields{
-	"id":         &UUIDField{},
-	"type":       &StringField{},
-	"signer":     &StringField{},
-	"status":     &StringField{},
-	"reference":  &UUIDField{},
-	"protocolid": &StringField{},
-	"created":    &TimeField{},
-	"sequence":   &Int64Field{},
-	"info":       &JSONField{},
-	"namespace":  &StringField{},
+	"id":             &UUIDField{},
+	"type":           &StringField{},
+	"signer":         &StringField{},
+	"status":         &StringField{},
+	"reference":      &UUIDField{},
+	"protocolid":     &StringField{},
+	"created":        &TimeField{},
+	"sequence":       &Int64Field{},
+	"info":           &JSONField{},
+	"namespace":      &StringField{},
+	"idempotencykey": &StringField{},
 }
 
 // DataQueryFactory filter fields for data
@@ -643,7 +1007,10 @@ var DataQueryFactory = &queryFields{
 	"hash":             &Bytes32Field{},
 	"blob.hash":        &Bytes32Field{},
 	"blob.public":      &StringField{},
+	"blob.size":        &Int64Field{},
 	"created":          &TimeField{},
+	"hashalgorithm":    &StringField{},
+	"transform":        &StringField{},
 }
 
 // DatatypeQueryFactory filter fields for data definitions
@@ -676,8 +1043,10 @@ var OperationQueryFactory = &queryFields{
 	"input":     &JSONField{},
 	"output":    &JSONField{},
 	"backendid": &StringField{},
+	"schema":    &StringField{},
 	"created":   &TimeField{},
 	"updated":   &TimeField{},
+	"retry":     &UUIDField{},
 }
 
 // SubscriptionQueryFactory filter fields for data subscriptions
@@ -691,6 +1060,7 @@ var SubscriptionQueryFactory = &queryFields{
 	"filter.tag":    &StringField{},
 	"filter.group":  &StringField{},
 	"options":       &StringField{},
+	"owner":         &StringField{},
 	"created":       &TimeField{},
 }
 
@@ -700,6 +1070,7 @@ var EventQueryFactory = &queryFields{
 	"type":      &StringField{},
 	"namespace": &StringField{},
 	"reference": &UUIDField{},
+	"topic":     &StringField{},
 	"group":     &Bytes32Field{},
 	"sequence":  &Int64Field{},
 	"created":   &TimeField{},
@@ -775,6 +1146,7 @@ var ConfigRecordQueryFactory = &queryFields{
 var BlobQueryFactory = &queryFields{
 	"hash":       &Bytes32Field{},
 	"payloadref": &StringField{},
+	"refs":       &Int64Field{},
 	"created":    &TimeField{},
 }
 
@@ -808,18 +1180,310 @@ var TokenBalanceQueryFactory = &queryFields{
 
 // TokenTransferQueryFactory filter fields for token transfers
 var TokenTransferQueryFactory = &queryFields{
-	"localid":     &StringField{},
-	"pool":        &UUIDField{},
-	"tokenindex":  &StringField{},
-	"uri":         &StringField{},
-	"connector":   &StringField{},
+	"localid":      &StringField{},
+	"pool":         &UUIDField{},
+	"tokenindex":   &StringField{},
+	"uri":          &StringField{},
+	"connector":    &StringField{},
+	"namespace":    &StringField{},
+	"key":          &StringField{},
+	"from":         &StringField{},
+	"to":           &StringField{},
+	"amount":       &Int64Field{},
+	"protocolid":   &StringField{},
+	"poolsequence": &StringField{},
+	"message":      &UUIDField{},
+	"messagehash":  &Bytes32Field{},
+	"created":      &TimeField{},
+}
+
+// TokenApprovalQueryFactory filter fields for token approvals
+var TokenApprovalQueryFactory = &queryFields{
+	"localid":    &StringField{},
+	"pool":       &UUIDField{},
+	"connector":  &StringField{},
+	"namespace":  &StringField{},
+	"key":        &StringField{},
+	"operator":   &StringField{},
+	"approved":   &BoolField{},
+	"protocolid": &StringField{},
+	"created":    &TimeField{},
+}
+
+// TokenNFTQueryFactory filter fields for non-fungible tokens
+var TokenNFTQueryFactory = &queryFields{
+	"id":         &UUIDField{},
+	"pool":       &UUIDField{},
+	"tokenindex": &StringField{},
+	"uri":        &StringField{},
+	"connector":  &StringField{},
+	"namespace":  &StringField{},
+	"owner":      &StringField{},
+	"created":    &TimeField{},
+	"updated":    &TimeField{},
+}
+
+// PseudonymQueryFactory filter fields for pseudonyms
+var PseudonymQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"message":   &UUIDField{},
+	"namespace": &StringField{},
+	"key":       &StringField{},
+	"created":   &TimeField{},
+}
+
+// DelegationQueryFactory filter fields for delegations
+var DelegationQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"message":   &UUIDField{},
+	"namespace": &StringField{},
+	"delegator": &StringField{},
+	"delegate":  &StringField{},
+	"created":   &TimeField{},
+	"revoked":   &TimeField{},
+}
+
+// CustomIdentityQueryFactory filter fields for custom identities
+var CustomIdentityQueryFactory = &queryFields{
+	"id":           &UUIDField{},
+	"namespace":    &StringField{},
+	"name":         &StringField{},
+	"parent":       &StringField{},
+	"key":          &StringField{},
+	"description":  &StringField{},
+	"profile":      &JSONField{},
+	"claim":        &UUIDField{},
+	"verification": &UUIDField{},
+	"created":      &TimeField{},
+	"verified":     &TimeField{},
+}
+
+// GroupKeyQueryFactory filter fields for group keys
+var GroupKeyQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"message":   &UUIDField{},
+	"namespace": &StringField{},
+	"group":     &Bytes32Field{},
+	"epoch":     &Int64Field{},
+	"created":   &TimeField{},
+}
+
+// BlockchainEventQueryFactory filter fields for blockchain events
+var BlockchainEventQueryFactory = &queryFields{
+	"id":           &UUIDField{},
+	"sequence":     &Int64Field{},
+	"source":       &StringField{},
+	"namespace":    &StringField{},
+	"name":         &StringField{},
+	"protocolid":   &StringField{},
+	"address":      &StringField{},
+	"blocknumber":  &Int64Field{},
+	"protocoltxid": &StringField{},
+	"listener":     &StringField{},
+	"created":      &TimeField{},
+}
+
+// PolicyApprovalQueryFactory filter fields for policy approvals
+var PolicyApprovalQueryFactory = &queryFields{
+	"id":         &UUIDField{},
+	"namespace":  &StringField{},
+	"type":       &StringField{},
+	"signingkey": &StringField{},
+	"reference":  &UUIDField{},
+	"hash":       &Bytes32Field{},
+	"reason":     &StringField{},
+	"status":     &StringField{},
+	"created":    &TimeField{},
+	"decided":    &TimeField{},
+	"decidedby":  &StringField{},
+	"comment":    &StringField{},
+}
+
+// MessageHoldQueryFactory filter fields for message holds
+var MessageHoldQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"namespace": &StringField{},
+	"group":     &Bytes32Field{},
+	"tag":       &StringField{},
+	"reason":    &StringField{},
+	"status":    &StringField{},
+	"created":   &TimeField{},
+	"decided":   &TimeField{},
+	"decidedby": &StringField{},
+	"comment":   &StringField{},
+}
+
+// LegalHoldQueryFactory filter fields for legal holds
+var LegalHoldQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"namespace": &StringField{},
+	"type":      &StringField{},
+	"reference": &UUIDField{},
+	"topic":     &StringField{},
+	"reason":    &StringField{},
+	"active":    &BoolField{},
+	"placed":    &TimeField{},
+	"placedby":  &StringField{},
+	"removed":   &TimeField{},
+	"removedby": &StringField{},
+	"comment":   &StringField{},
+}
+
+// TokenBridgeQueryFactory filter fields for token bridges
+var TokenBridgeQueryFactory = &queryFields{
+	"id":                &UUIDField{},
+	"namespace":         &StringField{},
+	"key":               &StringField{},
+	"from":              &StringField{},
+	"to":                &StringField{},
+	"escrow":            &StringField{},
+	"amount":            &Int64Field{},
+	"source.connector":  &StringField{},
+	"source.pool":       &UUIDField{},
+	"source.tokenindex": &StringField{},
+	"target.connector":  &StringField{},
+	"target.pool":       &UUIDField{},
+	"target.tokenindex": &StringField{},
+	"status":            &StringField{},
+	"error":             &StringField{},
+	"tx.type":           &StringField{},
+	"tx.id":             &UUIDField{},
+	"created":           &TimeField{},
+	"updated":           &TimeField{},
+}
+
+// ReceiptQueryFactory filter fields for message receipts
+var ReceiptQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"namespace": &StringField{},
+	"message":   &UUIDField{},
+	"author":    &StringField{},
+	"key":       &StringField{},
+	"created":   &TimeField{},
+}
+
+// SigningActivityQueryFactory filter fields for signing activity
+var SigningActivityQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"namespace": &StringField{},
+	"key":       &StringField{},
+	"type":      &StringField{},
+	"plugin":    &StringField{},
+	"operation": &UUIDField{},
+	"tx":        &UUIDField{},
+	"created":   &TimeField{},
+}
+
+// MessageTransitionQueryFactory filter fields for message state transitions
+var MessageTransitionQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"sequence":  &Int64Field{},
+	"namespace": &StringField{},
+	"message":   &UUIDField{},
+	"state":     &StringField{},
+	"cause":     &StringField{},
+	"created":   &TimeField{},
+}
+
+// FFIQueryFactory filter fields for contract interfaces
+var FFIQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"message":   &UUIDField{},
+	"namespace": &StringField{},
+	"name":      &StringField{},
+	"version":   &StringField{},
+}
+
+// ContractListenerQueryFactory filter fields for contract listeners
+var ContractListenerQueryFactory = &queryFields{
+	"id":         &UUIDField{},
+	"interface":  &UUIDField{},
+	"namespace":  &StringField{},
+	"name":       &StringField{},
+	"protocolid": &StringField{},
+	"created":    &TimeField{},
+}
+
+// BatchQuarantineQueryFactory filter fields for batch quarantines
+var BatchQuarantineQueryFactory = &queryFields{
+	"id":               &UUIDField{},
+	"namespace":        &StringField{},
+	"author":           &StringField{},
+	"key":              &StringField{},
+	"peer":             &StringField{},
+	"payloadref":       &StringField{},
+	"hash":             &Bytes32Field{},
+	"reason":           &StringField{},
+	"awaitingidentity": &StringField{},
+	"size":             &Int64Field{},
+	"messages":         &Int64Field{},
+	"status":           &StringField{},
+	"created":          &TimeField{},
+	"decided":          &TimeField{},
+	"decidedby":        &StringField{},
+	"comment":          &StringField{},
+}
+
+// PinQuarantineQueryFactory filter fields for pin quarantines
+var PinQuarantineQueryFactory = &queryFields{
+	"id":        &UUIDField{},
+	"namespace": &StringField{},
+	"batch":     &UUIDField{},
+	"signer":    &StringField{},
+	"status":    &StringField{},
+	"created":   &TimeField{},
+	"decided":   &TimeField{},
+	"decidedby": &StringField{},
+	"comment":   &StringField{},
+}
+
+// BlockedPinQueryFactory filter fields for blocked pins
+var BlockedPinQueryFactory = &queryFields{
+	"id":          &UUIDField{},
 	"namespace":   &StringField{},
+	"payloadref":  &StringField{},
+	"hash":        &Bytes32Field{},
 	"key":         &StringField{},
-	"from":        &StringField{},
-	"to":          &StringField{},
-	"amount":      &Int64Field{},
-	"protocolid":  &StringField{},
-	"message":     &UUIDField{},
-	"messagehash": &Bytes32Field{},
+	"timestamp":   &TimeField{},
+	"attempts":    &Int64Field{},
+	"lasterror":   &StringField{},
+	"nextattempt": &TimeField{},
 	"created":     &TimeField{},
 }
+
+// MetricRollupQueryFactory filter fields for metric rollups
+var MetricRollupQueryFactory = &queryFields{
+	"namespace": &StringField{},
+	"metric":    &StringField{},
+	"type":      &StringField{},
+	"bucket":    &TimeField{},
+	"count":     &Int64Field{},
+}
+
+// AuditRecordQueryFactory filter fields for the audit log
+var AuditRecordQueryFactory = &queryFields{
+	"id":          &UUIDField{},
+	"sequence":    &Int64Field{},
+	"namespace":   &StringField{},
+	"identity":    &StringField{},
+	"remote":      &StringField{},
+	"method":      &StringField{},
+	"path":        &StringField{},
+	"route":       &StringField{},
+	"status":      &Int64Field{},
+	"payloadhash": &Bytes32Field{},
+	"created":     &TimeField{},
+}
+
+// UnmatchedReceiptQueryFactory filter fields for unmatched receipts
+var UnmatchedReceiptQueryFactory = &queryFields{
+	"id":         &UUIDField{},
+	"plugin":     &StringField{},
+	"requestid":  &StringField{},
+	"status":     &StringField{},
+	"error":      &StringField{},
+	"output":     &JSONField{},
+	"created":    &TimeField{},
+	"operation":  &UUIDField{},
+	"reconciled": &TimeField{},
+}
@@ -0,0 +1,168 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+const (
+	// QueryParamSort is a comma separated list of fields to sort by, each optionally prefixed with '-' for descending
+	QueryParamSort = "sort"
+	// QueryParamAscending forces all sort fields to be ascending
+	QueryParamAscending = "ascending"
+	// QueryParamDescending forces all sort fields to be descending
+	QueryParamDescending = "descending"
+	// QueryParamSkip is the number of records to skip, for pagination
+	QueryParamSkip = "skip"
+	// QueryParamLimit is the maximum number of records to return, for pagination
+	QueryParamLimit = "limit"
+	// QueryParamCount requests the total count of matching records is returned
+	QueryParamCount = "count"
+)
+
+// QueryParamLimits are the pagination limits applied when compiling query parameters into a filter (zero is unlimited)
+type QueryParamLimits struct {
+	DefaultLimit uint64
+	MaxLimit     uint64
+	MaxSkip      uint64
+}
+
+// NewQueryFactory declares the queryable fields of a collection, so that plugins and new APIs
+// can build filters, and compile them from REST query parameters, without redefining the rules
+func NewQueryFactory(fields map[string]Field) QueryFactory {
+	qf := make(queryFields, len(fields))
+	for name, field := range fields {
+		qf[strings.ToLower(name)] = field
+	}
+	return &qf
+}
+
+func queryValues(values url.Values, key string) (results []string) {
+	for queryName, queryValues := range values {
+		// We choose to be case insensitive for our filters, so protocolID and protocolid can be used interchangeably
+		if strings.EqualFold(queryName, key) {
+			results = append(results, queryValues...)
+		}
+	}
+	return results
+}
+
+func queryBool(values url.Values, key string) bool {
+	vals := queryValues(values, key)
+	return len(vals) > 0 && (vals[0] == "" || strings.EqualFold(vals[0], "true"))
+}
+
+func queryCondition(fb FilterBuilder, field, value string) Filter {
+	switch {
+	case strings.HasPrefix(value, ">="):
+		return fb.Gte(field, value[2:])
+	case strings.HasPrefix(value, "<="):
+		return fb.Lte(field, value[2:])
+	case strings.HasPrefix(value, ">"):
+		return fb.Gt(field, value[1:])
+	case strings.HasPrefix(value, "<"):
+		return fb.Lt(field, value[1:])
+	case strings.HasPrefix(value, "@"):
+		return fb.Contains(field, value[1:])
+	case strings.HasPrefix(value, "^"):
+		return fb.IContains(field, value[1:])
+	case strings.HasPrefix(value, "!@"):
+		return fb.NotContains(field, value[2:])
+	case strings.HasPrefix(value, "!^"):
+		return fb.NotIContains(field, value[2:])
+	case strings.HasPrefix(value, "!"):
+		return fb.Neq(field, value[1:])
+	default:
+		return fb.Eq(field, value)
+	}
+}
+
+// CompileQueryParams compiles query parameters into a validated filter, against the fields of the query factory.
+// Each field can be repeated (combined with OR) and prefixed with an operator: >= <= > < @ ^ !@ !^ !
+// The sort, ascending, descending, skip, limit and count parameters control ordering and pagination.
+func CompileQueryParams(ctx context.Context, qf QueryFactory, values url.Values, limits *QueryParamLimits) (AndFilter, error) {
+	fb := qf.NewFilterLimit(ctx, limits.DefaultLimit)
+	possibleFields := fb.Fields()
+	sort.Strings(possibleFields)
+	filter := fb.And()
+	for _, field := range possibleFields {
+		values := queryValues(values, field)
+		if len(values) == 1 {
+			filter.Condition(queryCondition(fb, field, values[0]))
+		} else if len(values) > 0 {
+			sort.Strings(values)
+			fs := make([]Filter, len(values))
+			for i, value := range values {
+				fs[i] = queryCondition(fb, field, value)
+			}
+			filter.Condition(fb.Or(fs...))
+		}
+	}
+	skipVals := queryValues(values, QueryParamSkip)
+	if len(skipVals) > 0 {
+		s, _ := strconv.ParseUint(skipVals[0], 10, 64)
+		if limits.MaxSkip != 0 && s > limits.MaxSkip {
+			return nil, i18n.NewError(ctx, i18n.MsgMaxFilterSkip, limits.MaxSkip)
+		}
+		filter.Skip(s)
+	}
+	limitVals := queryValues(values, QueryParamLimit)
+	if len(limitVals) > 0 {
+		l, _ := strconv.ParseUint(limitVals[0], 10, 64)
+		if limits.MaxLimit != 0 && l > limits.MaxLimit {
+			return nil, i18n.NewError(ctx, i18n.MsgMaxFilterLimit, limits.MaxLimit)
+		}
+		filter.Limit(l)
+	}
+	for _, sv := range queryValues(values, QueryParamSort) {
+		for _, ssv := range strings.Split(sv, ",") {
+			ssv = strings.TrimSpace(ssv)
+			if ssv == "" {
+				continue
+			}
+			ssv = strings.ToLower(ssv)
+			if !fieldKnown(possibleFields, strings.TrimPrefix(ssv, "-")) {
+				return nil, i18n.NewError(ctx, i18n.MsgInvalidFilterField, ssv)
+			}
+			filter.Sort(ssv)
+		}
+	}
+	if queryBool(values, QueryParamDescending) {
+		filter.Descending()
+	} else if queryBool(values, QueryParamAscending) {
+		filter.Ascending()
+	}
+	filter.Count(queryBool(values, QueryParamCount))
+
+	// Validate the values of all conditions now, rather than when the query is executed
+	if _, err := filter.Finalize(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func fieldKnown(sortedFields []string, field string) bool {
+	i := sort.SearchStrings(sortedFields, field)
+	return i < len(sortedFields) && sortedFields[i] == field
+}
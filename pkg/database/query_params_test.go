@@ -0,0 +1,92 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileQueryParamsDescending(t *testing.T) {
+	values, _ := url.ParseQuery("created=0&confirmed=!0&Tag=>abc&TAG=<abc&tag=<=abc&tag=>=abc&tag=@abc&tag=^abc&tag=!@abc&tag=!^abc&skip=10&limit=50&sort=tag,,Sequence&descending")
+	filter, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{
+		MaxLimit: 250,
+	})
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "( confirmed != 0 ) && ( created == 0 ) && ( ( tag %! 'abc' ) || ( tag ^! 'abc' ) || ( tag <= 'abc' ) || ( tag < 'abc' ) || ( tag >= 'abc' ) || ( tag > 'abc' ) || ( tag %= 'abc' ) || ( tag ^= 'abc' ) ) sort=-tag,-sequence skip=10 limit=50", fi.String())
+}
+
+func TestCompileQueryParamsAscendingCount(t *testing.T) {
+	values, _ := url.ParseQuery("created=0&sort=-tag&ascending&count=true")
+	filter, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{
+		DefaultLimit: 25,
+	})
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "( created == 0 ) sort=tag limit=25 count=true", fi.String())
+}
+
+func TestCompileQueryParamsMaxSkip(t *testing.T) {
+	values, _ := url.ParseQuery("skip=251")
+	_, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{
+		MaxSkip: 250,
+	})
+	assert.Regexp(t, "FF10183.*250", err)
+}
+
+func TestCompileQueryParamsMaxLimit(t *testing.T) {
+	values, _ := url.ParseQuery("limit=501")
+	_, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{
+		MaxLimit: 500,
+	})
+	assert.Regexp(t, "FF10184.*500", err)
+}
+
+func TestCompileQueryParamsBadSort(t *testing.T) {
+	values, _ := url.ParseQuery("sort=-unknown")
+	_, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{})
+	assert.Regexp(t, "FF10148.*-unknown", err)
+}
+
+func TestCompileQueryParamsBadValue(t *testing.T) {
+	values, _ := url.ParseQuery("id=!not-a-uuid")
+	_, err := CompileQueryParams(context.Background(), MessageQueryFactory, values, &QueryParamLimits{})
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestNewQueryFactory(t *testing.T) {
+	qf := NewQueryFactory(map[string]Field{
+		"ID":      &UUIDField{},
+		"name":    &StringField{},
+		"created": &TimeField{},
+	})
+	values, _ := url.ParseQuery("name=widget&sort=created")
+	filter, err := CompileQueryParams(context.Background(), qf, values, &QueryParamLimits{})
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( name == 'widget' ) sort=created", fi.String())
+	assert.ElementsMatch(t, []string{"id", "name", "created"}, qf.NewFilter(context.Background()).Fields())
+}
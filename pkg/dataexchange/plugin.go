@@ -81,15 +81,33 @@ type Plugin interface {
 	// DownloadBLOB streams a received blob out of storage
 	DownloadBLOB(ctx context.Context, payloadRef string) (content io.ReadCloser, err error)
 
+	// IsLocalBLOB returns true if the payloadRef is of a blob stored by this node in the namespace, rather than a blob
+	// received from another node, or stored in another namespace
+	IsLocalBLOB(ns, payloadRef string) bool
+
 	// CheckBLOBReceived confirms that a blob with the specified hash has been received from the specified peer
 	CheckBLOBReceived(ctx context.Context, peerID, ns string, id fftypes.UUID) (hash *fftypes.Bytes32, err error)
 
 	// SendMessage sends an in-line package of data to another network node.
 	// Should return as quickly as possible for parallelsim, then report completion asynchronously via the operation ID
-	SendMessage(ctx context.Context, peerID string, data []byte) (trackingID string, err error)
+	SendMessage(ctx context.Context, opID *fftypes.UUID, peerID string, data []byte) (err error)
+
+	// TransferBLOB initiates a transfer of a previoiusly stored blob to another node.
+	// Completion is reported asynchronously via the operation ID, in the same way as SendMessage
+	TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID string, payloadRef string) (err error)
+}
+
+// ConfigReloader is an optional interface for plugins that can apply a change to their configuration without a restart
+type ConfigReloader interface {
+	// ReloadConfig re-initializes the plugin with the current configuration. The plugin must continue with its
+	// existing configuration if an error is returned.
+	ReloadConfig(ctx context.Context, prefix config.Prefix) error
+}
 
-	// TransferBLOB initiates a transfer of a previoiusly stored blob to another node
-	TransferBLOB(ctx context.Context, peerID string, payloadRef string) (trackingID string, err error)
+// PluginAll is a combined interface for easy mocking, with all optional features
+type PluginAll interface {
+	Plugin
+	ConfigReloader
 }
 
 // Callbacks is the interface provided to the data exchange plugin, to allow it to pass events back to firefly.
@@ -101,7 +119,8 @@ type Callbacks interface {
 	// BLOBReceived notifies of the ID of a BLOB that has been stored by DX after being received from another node in the network
 	BLOBReceived(peerID string, hash fftypes.Bytes32, payloadRef string) error
 
-	// TransferResult notifies of a status update of a transfer
+	// TransferResult notifies of a status update of a transfer. The tracking ID is the ID of the operation passed to
+	// SendMessage or TransferBLOB, as returned in the receipt from the data exchange
 	TransferResult(trackingID string, status fftypes.OpStatus, info string, opOutput fftypes.JSONObject) error
 }
 
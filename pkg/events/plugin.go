@@ -18,6 +18,7 @@ package events
 
 import (
 	"context"
+	"io"
 
 	"github.com/hyperledger/firefly/internal/config"
 	"github.com/hyperledger/firefly/pkg/fftypes"
@@ -57,13 +58,21 @@ type ChangeEventListener interface {
 	ChangeEvent(connID string, ce *fftypes.ChangeEvent)
 }
 
+// ConfigReloader is an optional interface for transports that can apply a change to their configuration without a restart
+type ConfigReloader interface {
+	// ReloadConfig re-initializes the transport with the current configuration, for new connections and deliveries.
+	// The transport must continue with its existing configuration if an error is returned.
+	ReloadConfig(ctx context.Context, prefix config.Prefix) error
+}
+
 // PluginAll is a combined interface for easy mocking, with all optional features
 type PluginAll interface {
 	Plugin
 	ChangeEventListener
+	ConfigReloader
 }
 
-type SubscriptionMatcher func(fftypes.SubscriptionRef) bool
+type SubscriptionMatcher func(*fftypes.Subscription) bool
 
 type Callbacks interface {
 
@@ -74,6 +83,14 @@ type Callbacks interface {
 	// For a "connect-in" style plugin (inbound WebSocket connections), you fire it every time the client application connects attaches to a subscription
 	RegisterConnection(connID string, matcher SubscriptionMatcher) error
 
+	// RegisterSubscriptionConnection registers a connection for a single durable subscription, for a "connect-in" plugin where the
+	// client addresses the subscription directly (such as Server-Sent Events). The identity of the client must be allowed to access
+	// the subscription. If lastSequence is set, and the client is the owner of the subscription, the offset of the subscription is
+	// moved to it first, so delivery resumes with the event after the last one the client received. The offset is left alone while
+	// another connection is consuming the subscription.
+	// An error is returned if the subscription does not exist in the namespace for this transport.
+	RegisterSubscriptionConnection(connID, namespace string, id *fftypes.UUID, identity string, lastSequence *int64) error
+
 	// EphemeralSubscription creates an ephemeral (non-durable) subscription, and associates it with a connection
 	EphemeralSubscription(connID, namespace string, filter *fftypes.SubscriptionFilter, options *fftypes.SubscriptionOptions) error
 
@@ -88,6 +105,10 @@ type Callbacks interface {
 	// - Reject it: This resets the associated subscription back to the last committed offset
 	//   * Note all message since the last committed offet will be redelivered, so additional messages to be redelivered if streaming ahead
 	DeliveryResponse(connID string, inflight *fftypes.EventDeliveryResponse)
+
+	// DownloadBLOB streams the content of the blob attached to a piece of data, for plugins that deliver blobs
+	// along with the data of a message. The caller must close the returned reader.
+	DownloadBLOB(ctx context.Context, ns string, dataID *fftypes.UUID) (io.ReadCloser, error)
 }
 
 type Capabilities struct {
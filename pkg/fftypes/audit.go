@@ -0,0 +1,32 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// AuditRecord is an entry in the audit log, recording who made an API call that can change state, what they called,
+// when, and the outcome. The request payload is not stored, only its hash - so it can be matched to the caller's copy.
+type AuditRecord struct {
+	ID          *UUID    `json:"id"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Identity    string   `json:"identity,omitempty"`
+	Remote      string   `json:"remote,omitempty"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Route       string   `json:"route"`
+	Status      int      `json:"status"`
+	PayloadHash *Bytes32 `json:"payloadHash,omitempty"`
+	Created     *FFTime  `json:"created"`
+}
@@ -17,27 +17,116 @@
 package fftypes
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
-	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 
 	"github.com/hyperledger/firefly/internal/i18n"
 )
 
+// BatchCompression is the algorithm used to compress the payload of a batch, when it is uploaded to shared storage
+type BatchCompression = FFEnum
+
+var (
+	// BatchCompressionNone means the payload is uploaded as plain JSON
+	BatchCompressionNone BatchCompression = ffEnum("batchcompression", "none")
+	// BatchCompressionGzip means the JSON payload is gzip compressed
+	BatchCompressionGzip BatchCompression = ffEnum("batchcompression", "gzip")
+)
+
 type Batch struct {
 	ID        *UUID       `json:"id"`
 	Namespace string      `json:"namespace"`
 	Type      MessageType `json:"type"`
 	Node      *UUID       `json:"node,omitempty"`
 	Identity
-	Group      *Bytes32     `jdon:"group,omitempty"`
-	Hash       *Bytes32     `json:"hash"`
-	Created    *FFTime      `json:"created"`
-	Confirmed  *FFTime      `json:"confirmed"`
-	Payload    BatchPayload `json:"payload"`
-	PayloadRef string       `json:"payloadRef,omitempty"`
-	Blobs      []*Bytes32   `json:"blobs,omitempty"` // only used in-flight
+	Group         *Bytes32       `jdon:"group,omitempty"`
+	Hash          *Bytes32       `json:"hash"`
+	HashAlgorithm HashAlgorithm  `json:"hashAlgorithm,omitempty" ffenum:"hashalgorithm"`
+	Created       *FFTime        `json:"created"`
+	Confirmed     *FFTime        `json:"confirmed"`
+	Payload       BatchPayload   `json:"payload"`
+	PayloadRef    string         `json:"payloadRef,omitempty"`
+	Manifest      *BatchManifest `json:"manifest,omitempty"`
+	Blobs         []*Bytes32     `json:"blobs,omitempty"` // only used in-flight
+
+	Compression       BatchCompression  `json:"compression,omitempty" ffenum:"batchcompression"` // only used in-flight
+	CompressedPayload []byte            `json:"compressedPayload,omitempty"`                     // only used in-flight
+	Trace             map[string]string `json:"trace,omitempty"`                                 // only used in-flight
+}
+
+// CompressPayload replaces the payload of the batch with a compressed copy, recording the
+// compression in the batch so that the receiver can reverse it with DecompressPayload
+func (batch *Batch) CompressPayload(ctx context.Context, compression BatchCompression) error {
+	switch compression {
+	case "", BatchCompressionNone:
+		return nil
+	case BatchCompressionGzip:
+		b, err := json.Marshal(&batch.Payload)
+		if err != nil {
+			return i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+		}
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write(b) // cannot fail writing to a buffer
+		_ = w.Close()
+		batch.Compression = compression
+		batch.CompressedPayload = buf.Bytes()
+		batch.Payload = BatchPayload{}
+		return nil
+	default:
+		return i18n.NewError(ctx, i18n.MsgUnknownBatchCompression, compression)
+	}
+}
+
+// CalcHash calculates the hash of the batch payload, using the algorithm recorded on the batch
+func (batch *Batch) CalcHash(ctx context.Context) (*Bytes32, error) {
+	hash, err := NewHash(ctx, batch.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := json.Marshal(&batch.Payload)
+	hash.Write(b)
+	return HashResult(hash), nil
+}
+
+// DecompressPayload restores the payload of a batch received with a compressed payload, returning the size of the
+// decompressed payload (zero if it was not compressed). A payload that decompresses to more than maxSize bytes is
+// left compressed, and a size over maxSize is returned so the caller can reject it. A zero maxSize is unlimited.
+func (batch *Batch) DecompressPayload(ctx context.Context, maxSize int64) (int64, error) {
+	switch batch.Compression {
+	case "", BatchCompressionNone:
+		return 0, nil
+	case BatchCompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(batch.CompressedPayload))
+		if err != nil {
+			return 0, i18n.WrapError(ctx, err, i18n.MsgBatchDecompressFailed, batch.Compression)
+		}
+		// Limit the read, so that a small compressed payload cannot expand without bound
+		var lr io.Reader = r
+		if maxSize > 0 {
+			lr = io.LimitReader(r, maxSize+1)
+		}
+		b, err := ioutil.ReadAll(lr)
+		if err == nil && maxSize > 0 && int64(len(b)) > maxSize {
+			return int64(len(b)), nil
+		}
+		if err == nil {
+			err = json.Unmarshal(b, &batch.Payload)
+		}
+		if err != nil {
+			return 0, i18n.WrapError(ctx, err, i18n.MsgBatchDecompressFailed, batch.Compression)
+		}
+		batch.Compression = ""
+		batch.CompressedPayload = nil
+		return int64(len(b)), nil
+	default:
+		return 0, i18n.NewError(ctx, i18n.MsgUnknownBatchCompression, batch.Compression)
+	}
 }
 
 type BatchPayload struct {
@@ -53,8 +142,7 @@ func (ma BatchPayload) Value() (driver.Value, error) {
 
 func (ma *BatchPayload) Hash() *Bytes32 {
 	b, _ := json.Marshal(&ma)
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32
+	return hashBytes(b)
 }
 
 // Scan implements sql.Scanner
@@ -74,3 +162,67 @@ func (ma *BatchPayload) Scan(src interface{}) error {
 	}
 
 }
+
+// BatchManifest lists the ID and hash of every message and data element in a batch payload, so that
+// individual entries can be verified against a batch without retrieving the whole payload
+type BatchManifest struct {
+	Messages []*MessageRef `json:"messages"`
+	Data     []*DataRef    `json:"data"`
+}
+
+// Manifest builds the manifest of the messages and data in the payload, keeping the position of
+// each entry (any nil entries in the payload are nil in the manifest)
+func (ma *BatchPayload) Manifest() *BatchManifest {
+	manifest := &BatchManifest{
+		Messages: make([]*MessageRef, len(ma.Messages)),
+		Data:     make([]*DataRef, len(ma.Data)),
+	}
+	for i, msg := range ma.Messages {
+		if msg != nil {
+			manifest.Messages[i] = &MessageRef{
+				ID:   msg.Header.ID,
+				Hash: msg.Hash,
+			}
+		}
+	}
+	for i, data := range ma.Data {
+		if data != nil {
+			manifest.Data[i] = &DataRef{
+				ID:   data.ID,
+				Hash: data.Hash,
+			}
+		}
+	}
+	return manifest
+}
+
+// Value implements sql.Valuer
+func (bm *BatchManifest) Value() (driver.Value, error) {
+	if bm == nil {
+		return nil, nil
+	}
+	return json.Marshal(bm)
+}
+
+// Scan implements sql.Scanner
+func (bm *BatchManifest) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+
+	case []byte:
+		if len(src) == 0 {
+			return nil
+		}
+		return json.Unmarshal(src, bm)
+
+	case string:
+		if src == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(src), bm)
+
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, bm)
+	}
+}
@@ -17,7 +17,11 @@
 package fftypes
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -62,3 +66,202 @@ func TestSQLSerializedMessageArray(t *testing.T) {
 	assert.NotNil(t, hash)
 
 }
+
+func TestBatchManifest(t *testing.T) {
+
+	msgID := NewUUID()
+	msgHash := NewRandB32()
+	dataID := NewUUID()
+	dataHash := NewRandB32()
+	batchPayload := BatchPayload{
+		Messages: []*Message{
+			{Header: MessageHeader{ID: msgID}, Hash: msgHash},
+		},
+		Data: []*Data{
+			{ID: dataID, Hash: dataHash},
+		},
+	}
+
+	manifest := batchPayload.Manifest()
+	assert.Len(t, manifest.Messages, 1)
+	assert.Equal(t, msgID, manifest.Messages[0].ID)
+	assert.Equal(t, msgHash, manifest.Messages[0].Hash)
+	assert.Len(t, manifest.Data, 1)
+	assert.Equal(t, dataID, manifest.Data[0].ID)
+	assert.Equal(t, dataHash, manifest.Data[0].Hash)
+
+	b, err := manifest.Value()
+	assert.NoError(t, err)
+	assert.IsType(t, []byte{}, b)
+
+	var manifestRead BatchManifest
+	err = manifestRead.Scan(b)
+	assert.NoError(t, err)
+	assert.Equal(t, *msgID, *manifestRead.Messages[0].ID)
+	assert.Equal(t, *dataHash, *manifestRead.Data[0].Hash)
+
+	var manifestReadString BatchManifest
+	err = manifestReadString.Scan(string(b.([]byte)))
+	assert.NoError(t, err)
+	assert.Equal(t, *msgHash, *manifestReadString.Messages[0].Hash)
+
+	err = manifestRead.Scan("")
+	assert.NoError(t, err)
+
+	err = manifestRead.Scan([]byte{})
+	assert.NoError(t, err)
+
+	err = manifestRead.Scan(nil)
+	assert.NoError(t, err)
+
+	var wrongType int
+	err = manifestRead.Scan(&wrongType)
+	assert.Error(t, err)
+
+	manifest = (&BatchPayload{Messages: []*Message{nil}, Data: []*Data{nil}}).Manifest()
+	assert.Equal(t, []*MessageRef{nil}, manifest.Messages)
+	assert.Equal(t, []*DataRef{nil}, manifest.Data)
+
+	var nilManifest *BatchManifest
+	v, err := nilManifest.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+}
+
+func TestBatchCompressGzip(t *testing.T) {
+	batch := &Batch{
+		ID: NewUUID(),
+		Payload: BatchPayload{
+			TX: TransactionRef{ID: NewUUID()},
+			Messages: []*Message{
+				{Header: MessageHeader{ID: NewUUID()}},
+			},
+			Data: []*Data{
+				{ID: NewUUID(), Value: Byteable(`"hello"`)},
+			},
+		},
+	}
+	hash := batch.Payload.Hash()
+
+	err := batch.CompressPayload(context.Background(), BatchCompressionGzip)
+	assert.NoError(t, err)
+	assert.Equal(t, BatchCompressionGzip, batch.Compression)
+	assert.NotEmpty(t, batch.CompressedPayload)
+	assert.Empty(t, batch.Payload.Messages)
+
+	b, err := json.Marshal(batch)
+	assert.NoError(t, err)
+	var received *Batch
+	err = json.Unmarshal(b, &received)
+	assert.NoError(t, err)
+
+	size, err := received.DecompressPayload(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Greater(t, size, int64(len(received.Payload.Data[0].Value)))
+	assert.Equal(t, *hash, *received.Payload.Hash())
+	assert.Equal(t, FFEnum(""), received.Compression)
+	assert.Nil(t, received.CompressedPayload)
+}
+
+func TestBatchCompressNone(t *testing.T) {
+	batch := &Batch{
+		Payload: BatchPayload{
+			TX: TransactionRef{ID: NewUUID()},
+		},
+	}
+	err := batch.CompressPayload(context.Background(), BatchCompressionNone)
+	assert.NoError(t, err)
+	assert.NotNil(t, batch.Payload.TX.ID)
+	assert.Nil(t, batch.CompressedPayload)
+	size, err := batch.DecompressPayload(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Zero(t, size)
+	assert.NotNil(t, batch.Payload.TX.ID)
+}
+
+func TestBatchCompressUnknown(t *testing.T) {
+	batch := &Batch{}
+	err := batch.CompressPayload(context.Background(), "lzw")
+	assert.Regexp(t, "FF10378", err)
+	batch.Compression = "lzw"
+	_, err = batch.DecompressPayload(context.Background(), 0)
+	assert.Regexp(t, "FF10378", err)
+}
+
+func TestBatchCompressBadPayload(t *testing.T) {
+	batch := &Batch{
+		Payload: BatchPayload{
+			Data: []*Data{
+				{Value: Byteable(`!json`)},
+			},
+		},
+	}
+	err := batch.CompressPayload(context.Background(), BatchCompressionGzip)
+	assert.Regexp(t, "FF10137", err)
+}
+
+func TestBatchDecompressOverLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"data":[{"value":"` + strings.Repeat("a", 10000) + `"}]}`))
+	w.Close()
+	batch := &Batch{
+		Compression:       BatchCompressionGzip,
+		CompressedPayload: buf.Bytes(),
+	}
+	size, err := batch.DecompressPayload(context.Background(), 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1001), size)
+	assert.Equal(t, BatchCompressionGzip, batch.Compression)
+	assert.Empty(t, batch.Payload.Data)
+
+	size, err = batch.DecompressPayload(context.Background(), 100000)
+	assert.NoError(t, err)
+	assert.Greater(t, size, int64(10000))
+	assert.Len(t, batch.Payload.Data, 1)
+}
+
+func TestBatchDecompressBadGzip(t *testing.T) {
+	batch := &Batch{
+		Compression:       BatchCompressionGzip,
+		CompressedPayload: []byte("not gzip"),
+	}
+	_, err := batch.DecompressPayload(context.Background(), 0)
+	assert.Regexp(t, "FF10379", err)
+}
+
+func TestBatchDecompressBadJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("!json"))
+	w.Close()
+	batch := &Batch{
+		Compression:       BatchCompressionGzip,
+		CompressedPayload: buf.Bytes(),
+	}
+	_, err := batch.DecompressPayload(context.Background(), 0)
+	assert.Regexp(t, "FF10379", err)
+}
+
+func TestBatchCalcHash(t *testing.T) {
+	batch := &Batch{
+		Payload: BatchPayload{
+			Messages: []*Message{{Header: MessageHeader{ID: NewUUID()}}},
+		},
+	}
+	hash, err := batch.CalcHash(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, batch.Payload.Hash(), hash)
+
+	batch.HashAlgorithm = HashAlgorithmSHA256
+	hash, err = batch.CalcHash(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, batch.Payload.Hash(), hash)
+}
+
+func TestBatchCalcHashUnknownAlgorithm(t *testing.T) {
+	batch := &Batch{HashAlgorithm: "md5"}
+	_, err := batch.CalcHash(context.Background())
+	assert.Regexp(t, "FF10406", err)
+}
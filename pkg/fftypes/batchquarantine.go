@@ -0,0 +1,41 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// BatchQuarantine records an inbound batch that exceeded the configured receive limits, or that was sent by an identity
+// that is not yet in the network map. The batch is not processed, and the pins that refer to it stay parked, until an
+// operator accepts or discards it (or for an unknown author, until the identity definition arrives).
+// The ID is that of the batch, which is only stored with the other batches once it is accepted.
+type BatchQuarantine struct {
+	ID               *UUID                `json:"id"`
+	Namespace        string               `json:"namespace,omitempty"`
+	Author           string               `json:"author,omitempty"`
+	Key              string               `json:"key,omitempty"`
+	Peer             string               `json:"peer,omitempty"`
+	PayloadRef       string               `json:"payloadRef,omitempty"`
+	Hash             *Bytes32             `json:"hash,omitempty"`
+	Reason           string               `json:"reason,omitempty"`
+	AwaitingIdentity string               `json:"awaitingIdentity,omitempty"`
+	Size             int64                `json:"size"`
+	Messages         int64                `json:"messages"`
+	Batch            *Batch               `json:"batch"`
+	Status           PolicyApprovalStatus `json:"status" ffenum:"policyapprovalstatus"`
+	Created          *FFTime              `json:"created,omitempty"`
+	Decided          *FFTime              `json:"decided,omitempty"`
+	DecidedBy        string               `json:"decidedBy,omitempty"`
+	Comment          string               `json:"comment,omitempty"`
+}
@@ -16,10 +16,31 @@
 
 package fftypes
 
+import "io"
+
+// Blob is stored once for each unique hash, with a count of the times the same content has been stored
 type Blob struct {
 	Hash       *Bytes32 `json:"hash"`
 	PayloadRef string   `json:"payloadRef,omitempty"`
 	Peer       string   `json:"peer,omitempty"`
+	Refs       int64    `json:"refs,omitempty"`
 	Created    *FFTime  `json:"created,omitempty"`
 	Sequence   int64    `json:"-"`
 }
+
+// BlobStats summarizes the deduplication of blobs across data records - Deduplicated is the number of
+// copies of the same content that did not need to be stored separately
+type BlobStats struct {
+	Blobs        int64 `json:"blobs"`
+	References   int64 `json:"references"`
+	Deduplicated int64 `json:"deduplicated"`
+}
+
+// BlobDownload streams the content of a blob, or of the range of the blob that was requested
+type BlobDownload struct {
+	Reader  io.ReadCloser
+	Size    int64 // the total size of the blob
+	Offset  int64
+	Length  int64
+	Partial bool // true if only a range of the blob is being returned
+}
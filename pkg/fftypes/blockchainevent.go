@@ -0,0 +1,45 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// BlockchainEvent is an event received from a blockchain plugin, which FireFly indexes so that applications
+// can query activity on the chain through FireFly. This includes events FireFly does not process itself,
+// such as events delivered to custom contract listeners.
+type BlockchainEvent struct {
+	ID           *UUID      `json:"id,omitempty"`
+	Sequence     int64      `json:"sequence"`
+	Source       string     `json:"source,omitempty"`
+	Namespace    string     `json:"namespace,omitempty"`
+	Name         string     `json:"name,omitempty"`
+	ProtocolID   string     `json:"protocolId,omitempty"`
+	Address      string     `json:"address,omitempty"`
+	BlockNumber  int64      `json:"blockNumber"`
+	ProtocolTxID string     `json:"protocolTxId,omitempty"`
+	Listener     string     `json:"listener,omitempty"`
+	Output       JSONObject `json:"output,omitempty"`
+	Info         JSONObject `json:"info,omitempty"`
+	Created      *FFTime    `json:"created,omitempty"`
+}
+
+// BlockchainReplay is a request to replay the BatchPin events on the blockchain from a block number onwards,
+// such as to recover after restoring the database from a backup
+type BlockchainReplay struct {
+	FromBlock string  `json:"fromBlock"`
+	Connector string  `json:"connector,omitempty"`
+	Plugin    string  `json:"plugin,omitempty"`
+	Started   *FFTime `json:"started,omitempty"`
+}
@@ -0,0 +1,33 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// BlockedPin records a broadcast batch pin, where the payload of the batch could not be retrieved from shared storage.
+// The pins of the batch are parked by the aggregator, which retries the retrieval with an exponential backoff
+// until it succeeds. The ID is that of the batch.
+type BlockedPin struct {
+	ID          *UUID    `json:"id"`
+	Namespace   string   `json:"namespace,omitempty"`
+	PayloadRef  string   `json:"payloadRef,omitempty"`
+	Hash        *Bytes32 `json:"hash,omitempty"`
+	Key         string   `json:"key,omitempty"`
+	Timestamp   *FFTime  `json:"timestamp,omitempty"`
+	Attempts    int64    `json:"attempts"`
+	LastError   string   `json:"lastError,omitempty"`
+	NextAttempt *FFTime  `json:"nextAttempt,omitempty"`
+	Created     *FFTime  `json:"created,omitempty"`
+}
@@ -18,7 +18,6 @@ package fftypes
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -52,8 +51,7 @@ func (h Byteable) MarshalJSON() ([]byte, error) {
 }
 
 func (h Byteable) Hash() *Bytes32 {
-	var b32 Bytes32 = sha256.Sum256([]byte(h))
-	return &b32
+	return hashBytes(h)
 }
 
 func (h Byteable) String() string {
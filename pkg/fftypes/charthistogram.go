@@ -38,3 +38,19 @@ type ChartHistogramInterval struct {
 	// EndTime end time of histogram interval
 	EndTime *FFTime `json:"endTime"`
 }
+
+// ChartHistogramTyped is a bucket in a histogram, with the count broken down by type
+type ChartHistogramTyped struct {
+	// Timestamp of bucket in histogram
+	Timestamp *FFTime `json:"timestamp"`
+	// Count is the total for all types in the bucket
+	Count int64 `json:"count"`
+	// Types is the count of each type in the bucket
+	Types []*ChartHistogramTypeCount `json:"types"`
+}
+
+// ChartHistogramTypeCount is the count for a single type within a histogram bucket
+type ChartHistogramTypeCount struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
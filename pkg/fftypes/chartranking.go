@@ -0,0 +1,30 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+const (
+	// ChartRankingDefaultLimit is the number of ranked values returned when no limit is requested
+	ChartRankingDefaultLimit = 10
+	// ChartRankingMaxLimit max number of ranked values that can be requested
+	ChartRankingMaxLimit = 100
+)
+
+// ChartRanking is a value, such as a topic or an identity, and the number of times it occurs
+type ChartRanking struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,69 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// ConsistencyIssueType is the kind of referential integrity problem found by a consistency check
+type ConsistencyIssueType = FFEnum
+
+var (
+	// ConsistencyIssueMessageMissing a batch contains a message, or an event refers to a message, that does not exist
+	ConsistencyIssueMessageMissing ConsistencyIssueType = ffEnum("consistencyissuetype", "message_missing")
+	// ConsistencyIssueMessageHashMismatch the hash of a message does not match its header, or a stored message differs from the one in its batch
+	ConsistencyIssueMessageHashMismatch ConsistencyIssueType = ffEnum("consistencyissuetype", "message_hash_mismatch")
+	// ConsistencyIssueDataMissing a message or batch refers to data that does not exist
+	ConsistencyIssueDataMissing ConsistencyIssueType = ffEnum("consistencyissuetype", "data_missing")
+	// ConsistencyIssueDataHashMismatch the hash of the data differs from the hash a message or batch holds for it
+	ConsistencyIssueDataHashMismatch ConsistencyIssueType = ffEnum("consistencyissuetype", "data_hash_mismatch")
+	// ConsistencyIssueBatchMissing a message or pin refers to a batch that does not exist
+	ConsistencyIssueBatchMissing ConsistencyIssueType = ffEnum("consistencyissuetype", "batch_missing")
+	// ConsistencyIssueBatchHashMismatch the hash of a batch does not match its payload
+	ConsistencyIssueBatchHashMismatch ConsistencyIssueType = ffEnum("consistencyissuetype", "batch_hash_mismatch")
+)
+
+// ConsistencyIssue is a single inconsistency, on the record with the ID in the collection, referring to another record.
+// Pins do not have an ID, so are identified by their sequence.
+type ConsistencyIssue struct {
+	Type       ConsistencyIssueType `json:"type" ffenum:"consistencyissuetype"`
+	Collection string               `json:"collection"`
+	Namespace  string               `json:"namespace,omitempty"`
+	ID         string               `json:"id"`
+	Reference  *UUID                `json:"reference,omitempty"`
+	Repaired   bool                 `json:"repaired"`
+}
+
+// ConsistencyCheckCounts is the number of records checked in each collection
+type ConsistencyCheckCounts struct {
+	Batches  int64 `json:"batches"`
+	Messages int64 `json:"messages"`
+	Pins     int64 `json:"pins"`
+	Events   int64 `json:"events"`
+}
+
+// ConsistencyCheckInput is the request to run a consistency check
+type ConsistencyCheckInput struct {
+	Repair bool `json:"repair"`
+}
+
+// ConsistencyReport is the result of a consistency check across the messages, data, batches, pins and events in the database
+type ConsistencyReport struct {
+	ID        *UUID                  `json:"id"`
+	Repair    bool                   `json:"repair"`
+	Started   *FFTime                `json:"started"`
+	Completed *FFTime                `json:"completed,omitempty"`
+	Checked   ConsistencyCheckCounts `json:"checked"`
+	Issues    []*ConsistencyIssue    `json:"issues"`
+}
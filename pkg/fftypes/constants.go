@@ -43,4 +43,22 @@ const (
 
 	// SystemTagDefinePool is the topic for messages that broadcast data definitions
 	SystemTagDefinePool SystemTag = "ff_define_pool"
+
+	// SystemTagDefineFFI is the topic for messages that broadcast contract interface (FFI) definitions
+	SystemTagDefineFFI SystemTag = "ff_define_ffi"
+
+	// SystemTagDefinePseudonym is the topic for messages that broadcast the registration of a pseudonymous signing key
+	SystemTagDefinePseudonym SystemTag = "ff_define_pseudonym"
+
+	// SystemTagDefineGroupKey is the topic for group update messages that distribute a new epoch of the group key, to all parties in that group
+	SystemTagDefineGroupKey SystemTag = "ff_define_group_key"
+
+	// SystemTagDefineIdentityClaim is the topic for messages that broadcast the claim of a custom identity, signed by the key of that identity
+	SystemTagDefineIdentityClaim SystemTag = "ff_define_identity_claim"
+
+	// SystemTagDefineIdentityVerification is the topic for messages that broadcast the verification of a custom identity claim, signed by the key of its parent
+	SystemTagDefineIdentityVerification SystemTag = "ff_define_identity_verification"
+
+	// SystemTagDefineDelegation is the topic for messages that broadcast the delegation (or its revocation) by an organization, of authority for another organization to send on its behalf
+	SystemTagDefineDelegation SystemTag = "ff_define_delegation"
 )
@@ -0,0 +1,38 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// ContractListener is a subscription to an event emitted by a contract. Each event received by the
+// blockchain plugin for the listener is recorded in the index of blockchain events, and delivered
+// to applications as a blockchain_event in the namespace of the listener
+type ContractListener struct {
+	ID         *UUID      `json:"id,omitempty"`
+	Interface  *UUID      `json:"interface,omitempty"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	ProtocolID string     `json:"protocolId,omitempty"`
+	Location   JSONObject `json:"location,omitempty"`
+	Event      *FFIEvent  `json:"event,omitempty"`
+	Created    *FFTime    `json:"created,omitempty"`
+}
+
+// ContractListenerInput is the request to add a contract listener, where the event can be supplied
+// inline, or looked up by name from a contract interface that has been broadcast
+type ContractListenerInput struct {
+	ContractListener
+	EventPath string `json:"eventPath,omitempty"`
+}
@@ -0,0 +1,95 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+const (
+	// FireflyIdentityDIDPrefix is the author prefix for messages sent by a custom identity
+	FireflyIdentityDIDPrefix = "did:firefly:identity/"
+)
+
+// CustomIdentity is a child identity registered in a namespace beneath a parent organization, or beneath another
+// custom identity. It is registered with two broadcasts - a claim signed by the key of the new identity, and then
+// a verification of that claim signed by the key of the parent. It can only be used once it has been verified.
+type CustomIdentity struct {
+	ID           *UUID      `json:"id"`
+	Namespace    string     `json:"namespace"`
+	Name         string     `json:"name"`
+	Parent       string     `json:"parent"`
+	Key          string     `json:"key"`
+	Description  string     `json:"description,omitempty"`
+	Profile      JSONObject `json:"profile,omitempty"`
+	Claim        *UUID      `json:"claim,omitempty"`
+	Verification *UUID      `json:"verification,omitempty"`
+	Created      *FFTime    `json:"created,omitempty"`
+	Verified     *FFTime    `json:"verified,omitempty"`
+}
+
+// IdentityVerification is broadcast by the parent of a custom identity, to verify the claim of that identity
+type IdentityVerification struct {
+	Identity  *UUID  `json:"identity"`
+	Namespace string `json:"namespace"`
+	Parent    string `json:"parent"`
+	Key       string `json:"key"`
+	Claim     *UUID  `json:"claim"`
+	Message   *UUID  `json:"message,omitempty"`
+}
+
+func (ci *CustomIdentity) Validate(ctx context.Context) (err error) {
+	if err = ValidateFFNameField(ctx, ci.Name, "name"); err != nil {
+		return err
+	}
+	if err = ValidateLength(ctx, ci.Description, "description", 4096); err != nil {
+		return err
+	}
+	if ci.Parent == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "parent")
+	}
+	if ci.Key == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "key")
+	}
+	return nil
+}
+
+func (ci *CustomIdentity) GetDID() string {
+	if ci == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s%s", FireflyIdentityDIDPrefix, ci.ID)
+}
+
+func (ci *CustomIdentity) Topic() string {
+	return namespaceTopic(ci.Namespace)
+}
+
+func (ci *CustomIdentity) SetBroadcastMessage(msgID *UUID) {
+	ci.Claim = msgID
+}
+
+func (iv *IdentityVerification) Topic() string {
+	return namespaceTopic(iv.Namespace)
+}
+
+func (iv *IdentityVerification) SetBroadcastMessage(msgID *UUID) {
+	iv.Message = msgID
+}
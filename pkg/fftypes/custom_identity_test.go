@@ -0,0 +1,71 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomIdentityValidation(t *testing.T) {
+	ci := &CustomIdentity{
+		Name: "!name",
+	}
+	assert.Regexp(t, "FF10131.*name", ci.Validate(context.Background()))
+
+	ci = &CustomIdentity{
+		Name:        "identity1",
+		Description: string(make([]byte, 4097)),
+	}
+	assert.Regexp(t, "FF10188.*description", ci.Validate(context.Background()))
+
+	ci = &CustomIdentity{
+		Name: "identity1",
+	}
+	assert.Regexp(t, "FF10140.*parent", ci.Validate(context.Background()))
+
+	ci.Parent = "did:firefly:org/" + NewUUID().String()
+	assert.Regexp(t, "FF10140.*key", ci.Validate(context.Background()))
+
+	ci.Key = "0x12345"
+	assert.NoError(t, ci.Validate(context.Background()))
+}
+
+func TestCustomIdentityDefinitions(t *testing.T) {
+	var ci *CustomIdentity
+	assert.Equal(t, "", ci.GetDID())
+
+	ci = &CustomIdentity{
+		ID:        NewUUID(),
+		Namespace: "ns1",
+	}
+	assert.Equal(t, "did:firefly:identity/"+ci.ID.String(), ci.GetDID())
+	assert.Equal(t, "ff_ns_ns1", ci.Topic())
+	msgID := NewUUID()
+	ci.SetBroadcastMessage(msgID)
+	assert.Equal(t, msgID, ci.Claim)
+
+	iv := &IdentityVerification{
+		Identity:  ci.ID,
+		Namespace: "ns1",
+	}
+	assert.Equal(t, "ff_ns_ns1", iv.Topic())
+	iv.SetBroadcastMessage(msgID)
+	assert.Equal(t, msgID, iv.Message)
+}
@@ -0,0 +1,115 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+const (
+	customHeadersMaxEntries   = 16
+	customHeadersMaxValueLen  = 256
+	customHeadersMaxTotalSize = 1024
+)
+
+// CustomHeaders is a small set of application defined key/value pairs attached to a message.
+// Keys must conform to the requirements of a FireFly name, and values must not contain control
+// characters. The serialized form is a sorted list of "key=value" lines, which allows the
+// contains filter operators to be used to query on individual headers.
+type CustomHeaders map[string]string
+
+func (ch CustomHeaders) sortedKeys() []string {
+	keys := make([]string, 0, len(ch))
+	for k := range ch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (ch CustomHeaders) String() string {
+	entries := make([]string, 0, len(ch))
+	for _, k := range ch.sortedKeys() {
+		entries = append(entries, fmt.Sprintf("%s=%s", k, ch[k]))
+	}
+	return strings.Join(entries, "\n")
+}
+
+func (ch CustomHeaders) Value() (driver.Value, error) {
+	return ch.String(), nil
+}
+
+func (ch *CustomHeaders) Scan(src interface{}) error {
+	switch st := src.(type) {
+	case string:
+		*ch = parseCustomHeaders(st)
+		return nil
+	case []byte:
+		*ch = parseCustomHeaders(string(st))
+		return nil
+	case CustomHeaders:
+		*ch = st
+		return nil
+	case nil:
+		return nil
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, ch)
+	}
+}
+
+func parseCustomHeaders(s string) CustomHeaders {
+	if s == "" {
+		return nil
+	}
+	ch := CustomHeaders{}
+	for _, entry := range strings.Split(s, "\n") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) == 2 {
+			ch[kv[0]] = kv[1]
+		} else {
+			ch[kv[0]] = ""
+		}
+	}
+	return ch
+}
+
+func (ch CustomHeaders) Validate(ctx context.Context, fieldName string) error {
+	if len(ch) > customHeadersMaxEntries {
+		return i18n.NewError(ctx, i18n.MsgTooManyItems, fieldName, customHeadersMaxEntries, len(ch))
+	}
+	for _, k := range ch.sortedKeys() {
+		if err := ValidateFFNameField(ctx, k, fmt.Sprintf("%s.%s", fieldName, k)); err != nil {
+			return err
+		}
+		v := ch[k]
+		if len(v) > customHeadersMaxValueLen || strings.IndexFunc(v, unicode.IsControl) >= 0 {
+			entryName := fmt.Sprintf("%s.%s", fieldName, k)
+			return i18n.NewFieldError(ctx, entryName, i18n.MsgInvalidCustomHeaderValue, entryName, customHeadersMaxValueLen)
+		}
+	}
+	if size := len(ch.String()); size > customHeadersMaxTotalSize {
+		return i18n.NewError(ctx, i18n.MsgCustomHeadersTooLarge, fieldName, customHeadersMaxTotalSize, size)
+	}
+	return nil
+}
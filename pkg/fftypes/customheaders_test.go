@@ -0,0 +1,113 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomHeadersValidateOK(t *testing.T) {
+	ch := CustomHeaders{
+		"region":   "eu-west",
+		"priority": "high",
+	}
+	err := ch.Validate(context.Background(), "field1")
+	assert.NoError(t, err)
+
+	var chNil CustomHeaders
+	err = chNil.Validate(context.Background(), "field1")
+	assert.NoError(t, err)
+}
+
+func TestCustomHeadersValidateTooMany(t *testing.T) {
+	ch := CustomHeaders{}
+	for i := 0; i < 17; i++ {
+		ch[fmt.Sprintf("key_%d", i)] = "value"
+	}
+	err := ch.Validate(context.Background(), "field1")
+	assert.Regexp(t, "FF10227.*field1", err)
+}
+
+func TestCustomHeadersValidateBadKey(t *testing.T) {
+	ch := CustomHeaders{"!valid": "value"}
+	err := ch.Validate(context.Background(), "field1")
+	assert.Regexp(t, "FF10131.*field1.!valid", err)
+}
+
+func TestCustomHeadersValidateBadValue(t *testing.T) {
+	ch := CustomHeaders{"key1": "line1\nline2"}
+	err := ch.Validate(context.Background(), "field1")
+	assert.Regexp(t, "FF10338.*field1.key1", err)
+
+	ch = CustomHeaders{"key1": strings.Repeat("a", 257)}
+	err = ch.Validate(context.Background(), "field1")
+	assert.Regexp(t, "FF10338.*field1.key1", err)
+}
+
+func TestCustomHeadersValidateTooLarge(t *testing.T) {
+	ch := CustomHeaders{}
+	for i := 0; i < 5; i++ {
+		ch[fmt.Sprintf("key_%d", i)] = strings.Repeat("a", 250)
+	}
+	err := ch.Validate(context.Background(), "field1")
+	assert.Regexp(t, "FF10339.*field1", err)
+}
+
+func TestCustomHeadersScanValue(t *testing.T) {
+
+	ch1 := CustomHeaders{"region": "eu=west", "priority": "high"}
+	v, err := ch1.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "priority=high\nregion=eu=west", v)
+
+	var ch2 CustomHeaders
+	assert.Equal(t, "", ch2.String())
+	v, err = ch2.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+	err = ch2.Scan("priority=high\nregion=eu=west")
+	assert.NoError(t, err)
+	assert.Equal(t, ch1, ch2)
+
+	var ch3 CustomHeaders
+	err = ch3.Scan([]byte("flag"))
+	assert.NoError(t, err)
+	assert.Equal(t, CustomHeaders{"flag": ""}, ch3)
+
+	var ch4 CustomHeaders
+	err = ch4.Scan("")
+	assert.NoError(t, err)
+	assert.Nil(t, ch4)
+	err = ch4.Scan(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ch4)
+
+	var ch5 CustomHeaders
+	err = ch5.Scan(ch1)
+	assert.NoError(t, err)
+	assert.Equal(t, ch1, ch5)
+
+	var ch6 CustomHeaders
+	err = ch6.Scan(12345)
+	assert.Regexp(t, "FF10125", err)
+
+}
@@ -18,7 +18,6 @@ package fftypes
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 
@@ -33,6 +32,7 @@ type DataRef struct {
 type BlobRef struct {
 	Hash   *Bytes32 `json:"hash"`
 	Public string   `json:"public,omitempty"`
+	Size   int64    `json:"size,omitempty"`
 }
 
 type Data struct {
@@ -44,6 +44,21 @@ type Data struct {
 	Datatype  *DatatypeRef  `json:"datatype,omitempty"`
 	Value     Byteable      `json:"value"`
 	Blob      *BlobRef      `json:"blob,omitempty"`
+
+	HashAlgorithm HashAlgorithm `json:"hashAlgorithm,omitempty" ffenum:"hashalgorithm"`
+	Transform     string        `json:"transform,omitempty"`
+}
+
+// DataImport is a request to create a data item with a blob that FireFly downloads and hashes itself,
+// from an external URL or a payload already uploaded to the local data exchange
+type DataImport struct {
+	Validator  ValidatorType `json:"validator,omitempty"`
+	Datatype   *DatatypeRef  `json:"datatype,omitempty"`
+	Value      Byteable      `json:"value,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	PayloadRef string        `json:"payloadRef,omitempty"`
+	Hash       *Bytes32      `json:"hash,omitempty"`
+	AutoMeta   bool          `json:"autometa,omitempty"`
 }
 
 type DataAndBlob struct {
@@ -67,8 +82,7 @@ type DataRefs []*DataRef
 
 func (d DataRefs) Hash() *Bytes32 {
 	b, _ := json.Marshal(&d)
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32
+	return hashBytes(b)
 }
 
 func CheckValidatorType(ctx context.Context, validator ValidatorType) error {
@@ -91,17 +105,22 @@ func (d *Data) CalcHash(ctx context.Context) (*Bytes32, error) {
 	// The hash is either the blob hash, the value hash, or if both are supplied
 	// (e.g. a blob with associated metadata) it a hash of the two HEX hashes
 	// concattenated together (no spaces or separation).
-	switch {
-	case !valueIsNull && (d.Blob == nil || d.Blob.Hash == nil):
-		return d.Value.Hash(), nil
-	case valueIsNull && d.Blob != nil && d.Blob.Hash != nil:
+	// The value hashes use the algorithm recorded on the data.
+	if valueIsNull {
 		return d.Blob.Hash, nil
-	default:
-		hash := sha256.New()
-		hash.Write([]byte(d.Value.Hash().String()))
-		hash.Write([]byte(d.Blob.Hash.String()))
-		return HashResult(hash), nil
 	}
+	valueHash, err := NewHash(ctx, d.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	valueHash.Write(d.Value)
+	if d.Blob == nil || d.Blob.Hash == nil {
+		return HashResult(valueHash), nil
+	}
+	hash, _ := NewHash(ctx, d.HashAlgorithm)
+	hash.Write([]byte(HashResult(valueHash).String()))
+	hash.Write([]byte(d.Blob.Hash.String()))
+	return HashResult(hash), nil
 }
 
 func (d *Data) Seal(ctx context.Context) (err error) {
@@ -114,6 +133,9 @@ func (d *Data) Seal(ctx context.Context) (err error) {
 	if d.Created == nil {
 		d.Created = Now()
 	}
+	if d.HashAlgorithm == "" {
+		d.HashAlgorithm = DefaultHashAlgorithm
+	}
 	d.Hash, err = d.CalcHash(ctx)
 	if err == nil {
 		err = CheckValidatorType(ctx, d.Validator)
@@ -84,6 +84,24 @@ func TestSealBlobAndHashOnly(t *testing.T) {
 	assert.Equal(t, d.Hash[:], h[:])
 }
 
+func TestSealRecordsHashAlgorithm(t *testing.T) {
+	d := &Data{
+		Value: []byte("{}"),
+	}
+	err := d.Seal(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, HashAlgorithmSHA256, d.HashAlgorithm)
+}
+
+func TestSealUnknownHashAlgorithm(t *testing.T) {
+	d := &Data{
+		Value:         []byte("{}"),
+		HashAlgorithm: "md5",
+	}
+	err := d.Seal(context.Background())
+	assert.Regexp(t, "FF10406", err)
+}
+
 func TestHashDataNull(t *testing.T) {
 
 	jd := []byte(`{
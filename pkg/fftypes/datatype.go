@@ -60,7 +60,7 @@ func (dt *Datatype) Validate(ctx context.Context, existing bool) (err error) {
 		return err
 	}
 	if len(dt.Value) == 0 {
-		return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "value")
+		return i18n.NewFieldError(ctx, "value", i18n.MsgMissingRequiredField, "value")
 	}
 	if existing {
 		if dt.ID == nil {
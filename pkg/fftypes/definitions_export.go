@@ -0,0 +1,61 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// DefinitionsExport contains all the confirmed definitions of a namespace, so they can be imported
+// into the same namespace on another network - such as when migrating a consortium to a new chain
+type DefinitionsExport struct {
+	Namespace  *Namespace        `json:"namespace"`
+	Datatypes  []*Datatype       `json:"datatypes"`
+	FFIs       []*FFI            `json:"ffis"`
+	TokenPools []*TokenPool      `json:"tokenPools"`
+	Identities []*CustomIdentity `json:"identities"`
+	Exported   *FFTime           `json:"exported,omitempty"`
+}
+
+type DefinitionImportType = FFEnum
+
+var (
+	DefinitionImportTypeDatatype  DefinitionImportType = ffEnum("definitionimporttype", "datatype")
+	DefinitionImportTypeFFI       DefinitionImportType = ffEnum("definitionimporttype", "ffi")
+	DefinitionImportTypeTokenPool DefinitionImportType = ffEnum("definitionimporttype", "tokenpool")
+	DefinitionImportTypeIdentity  DefinitionImportType = ffEnum("definitionimporttype", "identity")
+)
+
+type DefinitionImportStatus = FFEnum
+
+var (
+	// DefinitionImportStatusImported the definition was broadcast to this network
+	DefinitionImportStatusImported DefinitionImportStatus = ffEnum("definitionimportstatus", "imported")
+	// DefinitionImportStatusExists a definition with the same name (and version) already exists on this network
+	DefinitionImportStatusExists DefinitionImportStatus = ffEnum("definitionimportstatus", "exists")
+	// DefinitionImportStatusFailed the definition could not be imported - the error contains the reason
+	DefinitionImportStatusFailed DefinitionImportStatus = ffEnum("definitionimportstatus", "failed")
+)
+
+// DefinitionImportResult is the outcome of importing one exported definition. The ID on this network is the same
+// as the exported ID for datatypes and contract interfaces, unless that ID is already in use. Token pools and
+// identities must be created again on the new chain, so are always assigned a new ID.
+type DefinitionImportResult struct {
+	Type       DefinitionImportType   `json:"type" ffenum:"definitionimporttype"`
+	Name       string                 `json:"name"`
+	Version    string                 `json:"version,omitempty"`
+	ExportedID *UUID                  `json:"exportedId,omitempty"`
+	ID         *UUID                  `json:"id,omitempty"`
+	Status     DefinitionImportStatus `json:"status" ffenum:"definitionimportstatus"`
+	Error      string                 `json:"error,omitempty"`
+}
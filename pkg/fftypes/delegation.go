@@ -0,0 +1,38 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// Delegation authorizes the node of one organization (the delegate) to send messages in a namespace on behalf of
+// another organization (the delegator), signed with the key of the delegate. The delegation is broadcast signed by
+// the delegator, and each message sent under it refers to it in its header so every member can verify the send.
+type Delegation struct {
+	ID        *UUID   `json:"id"`
+	Message   *UUID   `json:"message,omitempty"`
+	Namespace string  `json:"namespace"`
+	Delegator string  `json:"delegator"`
+	Delegate  string  `json:"delegate"`
+	Created   *FFTime `json:"created"`
+	Revoked   *FFTime `json:"revoked,omitempty"`
+}
+
+func (d *Delegation) Topic() string {
+	return namespaceTopic(d.Namespace)
+}
+
+func (d *Delegation) SetBroadcastMessage(msgID *UUID) {
+	d.Message = msgID
+}
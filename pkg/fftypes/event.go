@@ -25,6 +25,9 @@ var (
 	EventTypeMessageConfirmed EventType = ffEnum("eventtype", "message_confirmed")
 	// EventTypeMessageRejected occurs if a message is received and confirmed from a sequencing perspective, but is rejected as invalid (mismatch to schema, or duplicate system broadcast)
 	EventTypeMessageRejected EventType = ffEnum("eventtype", "message_rejected")
+	// EventTypeMessageTopicConfirmed occurs for each topic of a multi-topic message, once the message is next in the ordering context of that topic (the topic is set on the event).
+	// The message itself is only confirmed once it is ready on all of its topics, so this might occur while the message is still pending on other topics
+	EventTypeMessageTopicConfirmed EventType = ffEnum("eventtype", "message_topic_confirmed")
 	// EventTypeNamespaceConfirmed occurs when a new namespace is ready for use (on the namespace itself)
 	EventTypeNamespaceConfirmed EventType = ffEnum("eventtype", "namespace_confirmed")
 	// EventTypeDatatypeConfirmed occurs when a new datatype is ready for use (on the namespace of the datatype)
@@ -35,10 +38,62 @@ var (
 	EventTypePoolConfirmed EventType = ffEnum("eventtype", "token_pool_confirmed")
 	// EventTypePoolRejected occurs when a new token pool is rejected (due to validation errors, duplicates, etc)
 	EventTypePoolRejected EventType = ffEnum("eventtype", "token_pool_rejected")
+	// EventTypePoolDeactivated occurs when the deactivation of the contract of a token pool is applied, so the pool can no longer be used
+	EventTypePoolDeactivated EventType = ffEnum("eventtype", "token_pool_deactivated")
+	// EventTypePoolConfigChanged occurs when any other governance action on the contract of a token pool is applied, such as a pause or an ownership transfer
+	EventTypePoolConfigChanged EventType = ffEnum("eventtype", "token_pool_config_changed")
 	// EventTypeTransferConfirmed occurs when a token transfer has been confirmed
 	EventTypeTransferConfirmed EventType = ffEnum("eventtype", "token_transfer_confirmed")
 	// EventTypeTransferOpFailed occurs when a token transfer submitted by this node has failed (based on feedback from connector)
 	EventTypeTransferOpFailed EventType = ffEnum("eventtype", "token_transfer_op_failed")
+	// EventTypeApprovalConfirmed occurs when a token approval has been confirmed
+	EventTypeApprovalConfirmed EventType = ffEnum("eventtype", "token_approval_confirmed")
+	// EventTypeApprovalOpFailed occurs when a token approval submitted by this node has failed (based on feedback from connector)
+	EventTypeApprovalOpFailed EventType = ffEnum("eventtype", "token_approval_op_failed")
+	// EventTypeTokenMetadataChanged occurs when the URI of a token index within a non-fungible pool changes, referring to the token
+	EventTypeTokenMetadataChanged EventType = ffEnum("eventtype", "token_metadata_changed")
+	// EventTypePinPolicyViolation occurs when the key that signed a batch pin is not permitted to send the referenced message, under the configured pin policy
+	EventTypePinPolicyViolation EventType = ffEnum("eventtype", "pin_policy_violation")
+	// EventTypeQuotaWarning occurs when the usage of a configured namespace or topic quota passes the warning threshold, referring to the message that passed it
+	EventTypeQuotaWarning EventType = ffEnum("eventtype", "quota_warning")
+	// EventTypeInsufficientGasFunds occurs when a transaction is rejected before submission, because the signing key does not hold enough native balance to pay for gas
+	EventTypeInsufficientGasFunds EventType = ffEnum("eventtype", "insufficient_gas_funds")
+	// EventTypePolicyApprovalPending occurs when the policy plugin holds a blockchain submission for manual approval, referring to the pending approval
+	EventTypePolicyApprovalPending EventType = ffEnum("eventtype", "policy_approval_pending")
+	// EventTypeMessageHeld occurs when an outbound private message is held until an operator approves its release, referring to the message
+	EventTypeMessageHeld EventType = ffEnum("eventtype", "message_held")
+	// EventTypeTokenBridgeCompleted occurs when tokens have been locked on the source pool and minted on the target pool of a token bridge
+	EventTypeTokenBridgeCompleted EventType = ffEnum("eventtype", "token_bridge_completed")
+	// EventTypeTokenBridgeFailed occurs when a token bridge fails, after any compensation has been attempted (see the status of the bridge)
+	EventTypeTokenBridgeFailed EventType = ffEnum("eventtype", "token_bridge_failed")
+	// EventTypeIdentityConfirmed occurs when an organization identity broadcast has been confirmed, referring to the organization,
+	// or when the claim of a custom identity has been verified by its parent, referring to the custom identity
+	EventTypeIdentityConfirmed EventType = ffEnum("eventtype", "identity_confirmed")
+	// EventTypeIdentityRejected occurs when an organization identity broadcast, or the claim or verification of a custom identity, is rejected (due to validation errors, signature mismatch, etc)
+	EventTypeIdentityRejected EventType = ffEnum("eventtype", "identity_rejected")
+	// EventTypeContractInterfaceConfirmed occurs when a new contract interface (FFI) is ready for use
+	EventTypeContractInterfaceConfirmed EventType = ffEnum("eventtype", "contract_interface_confirmed")
+	// EventTypePseudonymConfirmed occurs when the registration of a pseudonymous signing key has been confirmed
+	EventTypePseudonymConfirmed EventType = ffEnum("eventtype", "pseudonym_confirmed")
+	// EventTypeDelegationConfirmed occurs when a delegation authorizing an organization to send on behalf of another has been confirmed
+	EventTypeDelegationConfirmed EventType = ffEnum("eventtype", "delegation_confirmed")
+	// EventTypeDelegationRevoked occurs when the revocation of a delegation by its delegator has been confirmed
+	EventTypeDelegationRevoked EventType = ffEnum("eventtype", "delegation_revoked")
+	// EventTypeBlockchainInvokeOpSucceeded occurs when a contract invocation submitted by this node has succeeded, referring to the operation
+	EventTypeBlockchainInvokeOpSucceeded EventType = ffEnum("eventtype", "blockchain_invoke_op_succeeded")
+	// EventTypeBlockchainInvokeOpFailed occurs when a contract invocation submitted by this node has failed, referring to the operation
+	EventTypeBlockchainInvokeOpFailed EventType = ffEnum("eventtype", "blockchain_invoke_op_failed")
+	// EventTypeBlockchainEvent occurs when an event is received for a contract listener, referring to the blockchain event
+	EventTypeBlockchainEvent EventType = ffEnum("eventtype", "blockchain_event")
+	// EventTypeBatchQuarantined occurs when an inbound batch exceeds the configured receive limits, and is quarantined until an operator decides it, referring to the batch
+	EventTypeBatchQuarantined EventType = ffEnum("eventtype", "batch_quarantined")
+	// EventTypeDeliveryFailed occurs when a subscription gives up delivering an event, after exhausting its retries, referring to the event that was not delivered
+	EventTypeDeliveryFailed EventType = ffEnum("eventtype", "delivery_failed")
+	// EventTypeOperationUpdated occurs when the status of an operation submitted by this node changes, based on feedback from the
+	// data exchange, blockchain or tokens plugin that processed it, referring to the operation
+	EventTypeOperationUpdated EventType = ffEnum("eventtype", "operation_updated")
+	// EventTypeTimestampSkew occurs when an inbound batch declares timestamps that deviate from the reference time by more than the configured max skew, referring to the batch
+	EventTypeTimestampSkew EventType = ffEnum("eventtype", "timestamp_skew")
 )
 
 // Event is an activity in the system, delivered reliably to applications, that indicates something has happened in the network
@@ -48,6 +103,7 @@ type Event struct {
 	Type      EventType `json:"type" ffenum:"eventtype"`
 	Namespace string    `json:"namespace"`
 	Reference *UUID     `json:"reference"`
+	Topic     string    `json:"topic,omitempty"`
 	Created   *FFTime   `json:"created"`
 }
 
@@ -55,8 +111,11 @@ type Event struct {
 // be dispatched to an applciation.
 type EventDelivery struct {
 	Event
-	Subscription SubscriptionRef `json:"subscription"`
-	Message      *Message        `json:"message,omitempty"`
+	Subscription    SubscriptionRef  `json:"subscription"`
+	Message         *Message         `json:"message,omitempty"`
+	BlockchainEvent *BlockchainEvent `json:"blockchainEvent,omitempty"`
+	TokenTransfer   *TokenTransfer   `json:"tokenTransfer,omitempty"`
+	Operation       *Operation       `json:"operation,omitempty"`
 }
 
 // EventDeliveryResponse is the payload an application sends back, to confirm it has accepted (or rejected) the event and as such
@@ -67,6 +126,7 @@ type EventDeliveryResponse struct {
 	Info         string          `json:"info,omitempty"`
 	Subscription SubscriptionRef `json:"subscription"`
 	Reply        *MessageInOut   `json:"reply,omitempty"`
+	DeadLetter   bool            `json:"-"` // set by transports that gave up on the delivery, to record a delivery_failed event
 }
 
 func NewEvent(t EventType, ns string, ref *UUID) *Event {
@@ -0,0 +1,37 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// Feature is a capability of the node that can be enabled or disabled for each namespace
+type Feature = FFEnum
+
+var (
+	// FeatureTokens is token pools, and the transfers and approvals on them
+	FeatureTokens Feature = ffEnum("feature", "tokens")
+	// FeatureContracts is custom smart contract interfaces, invocations and listeners
+	FeatureContracts Feature = ffEnum("feature", "contracts")
+	// FeaturePrivateMessaging is sending messages privately to groups of members
+	FeaturePrivateMessaging Feature = ffEnum("feature", "privatemessaging")
+	// FeatureSyncRequests is the request/reply exchange of messages, waiting for the reply
+	FeatureSyncRequests Feature = ffEnum("feature", "syncrequests")
+)
+
+// NamespaceFeatures reports which features are enabled in a namespace
+type NamespaceFeatures struct {
+	Namespace string           `json:"namespace"`
+	Features  map[Feature]bool `json:"features"`
+}
@@ -0,0 +1,244 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// FFIParamType is the protocol independent type of a parameter of a contract method or event
+type FFIParamType = string
+
+const (
+	FFIParamTypeString  FFIParamType = "string"
+	FFIParamTypeInteger FFIParamType = "integer"
+	FFIParamTypeBoolean FFIParamType = "boolean"
+	FFIParamTypeObject  FFIParamType = "object"
+	FFIParamTypeArray   FFIParamType = "array"
+)
+
+var ffiParamTypes = []string{
+	FFIParamTypeString,
+	FFIParamTypeInteger,
+	FFIParamTypeBoolean,
+	FFIParamTypeObject,
+	FFIParamTypeArray,
+}
+
+// FFI is a FireFly Interface - a protocol independent description of the methods and events of a smart contract,
+// which can be broadcast to the network, and then used to invoke and query any instance of that contract
+type FFI struct {
+	ID          *UUID      `json:"id,omitempty"`
+	Message     *UUID      `json:"message,omitempty"`
+	Namespace   string     `json:"namespace,omitempty"`
+	Name        string     `json:"name"`
+	Version     string     `json:"version"`
+	Description string     `json:"description,omitempty"`
+	Methods     FFIMethods `json:"methods,omitempty"`
+	Events      FFIEvents  `json:"events,omitempty"`
+}
+
+// FFIMethod is a method of a contract, with its input parameters and return values
+type FFIMethod struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Params      FFIParams `json:"params"`
+	Returns     FFIParams `json:"returns"`
+}
+
+// FFIEvent is an event emitted by a contract
+type FFIEvent struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Params      FFIParams `json:"params"`
+}
+
+// FFIParam is a named and typed parameter. Details can contain protocol specific information,
+// such as the exact ABI type of the parameter on Ethereum (for example {"type":"uint8"})
+type FFIParam struct {
+	Name    string       `json:"name"`
+	Type    FFIParamType `json:"type"`
+	Details JSONObject   `json:"details,omitempty"`
+}
+
+type FFIParams []*FFIParam
+
+type FFIMethods []*FFIMethod
+
+type FFIEvents []*FFIEvent
+
+func (p FFIParams) Validate(ctx context.Context) error {
+	for _, param := range p {
+		if param == nil || param.Name == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "name")
+		}
+		valid := false
+		for _, t := range ffiParamTypes {
+			if param.Type == t {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return i18n.NewError(ctx, i18n.MsgFFIParamTypeInvalid, param.Type, param.Name, strings.Join(ffiParamTypes, ","))
+		}
+	}
+	return nil
+}
+
+func (m *FFIMethod) Validate(ctx context.Context) error {
+	if m.Name == "" {
+		return i18n.NewFieldError(ctx, "name", i18n.MsgMissingRequiredField, "name")
+	}
+	if err := m.Params.Validate(ctx); err != nil {
+		return err
+	}
+	return m.Returns.Validate(ctx)
+}
+
+func (ffi *FFI) Validate(ctx context.Context, existing bool) (err error) {
+	if err = ValidateFFNameField(ctx, ffi.Namespace, "namespace"); err != nil {
+		return err
+	}
+	if err = ValidateFFNameField(ctx, ffi.Name, "name"); err != nil {
+		return err
+	}
+	if err = ValidateFFNameField(ctx, ffi.Version, "version"); err != nil {
+		return err
+	}
+	for _, method := range ffi.Methods {
+		if method == nil {
+			return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "methods")
+		}
+		if err = method.Validate(ctx); err != nil {
+			return err
+		}
+	}
+	for _, event := range ffi.Events {
+		if event == nil || event.Name == "" {
+			return i18n.NewError(ctx, i18n.MsgMissingRequiredField, "events")
+		}
+		if err = event.Params.Validate(ctx); err != nil {
+			return err
+		}
+	}
+	if existing && ffi.ID == nil {
+		return i18n.NewError(ctx, i18n.MsgNilID)
+	}
+	return nil
+}
+
+// GetEvent returns the event with the supplied name, or nil if there is no such event
+func (ffi *FFI) GetEvent(name string) *FFIEvent {
+	for _, event := range ffi.Events {
+		if event.Name == name {
+			return event
+		}
+	}
+	return nil
+}
+
+// GetMethod returns the method with the supplied name, or nil if there is no such method
+func (ffi *FFI) GetMethod(name string) *FFIMethod {
+	for _, method := range ffi.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+func (ffi *FFI) Topic() string {
+	return namespaceTopic(ffi.Namespace)
+}
+
+func (ffi *FFI) SetBroadcastMessage(msgID *UUID) {
+	ffi.Message = msgID
+}
+
+func scanJSON(src interface{}, target interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		if src == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(src), target)
+	case []byte:
+		if len(src) == 0 {
+			return nil
+		}
+		return json.Unmarshal(src, target)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, target)
+	}
+}
+
+// Scan implements sql.Scanner
+func (m *FFIMethods) Scan(src interface{}) error {
+	return scanJSON(src, m)
+}
+
+func (m FFIMethods) Value() (driver.Value, error) {
+	return json.Marshal(&m)
+}
+
+// Scan implements sql.Scanner
+func (e *FFIEvents) Scan(src interface{}) error {
+	return scanJSON(src, e)
+}
+
+func (e FFIEvents) Value() (driver.Value, error) {
+	return json.Marshal(&e)
+}
+
+// Scan implements sql.Scanner
+func (e *FFIEvent) Scan(src interface{}) error {
+	return scanJSON(src, e)
+}
+
+func (e FFIEvent) Value() (driver.Value, error) {
+	return json.Marshal(&e)
+}
+
+// ContractCallType is the kind of call made to a contract
+type ContractCallType = FFEnum
+
+var (
+	// ContractCallTypeInvoke submits a transaction to the blockchain, which is tracked as an operation
+	ContractCallTypeInvoke ContractCallType = ffEnum("contractcalltype", "invoke")
+	// ContractCallTypeQuery synchronously reads the state of the contract, without a transaction
+	ContractCallTypeQuery ContractCallType = ffEnum("contractcalltype", "query")
+)
+
+// ContractCallRequest is a request to invoke or query a method on a contract. The method can either be supplied
+// inline, or be resolved by name (methodPath) from a previously broadcast contract interface
+type ContractCallRequest struct {
+	Type       ContractCallType `json:"type,omitempty" ffenum:"contractcalltype"`
+	Interface  *UUID            `json:"interface,omitempty"`
+	Location   JSONObject       `json:"location"`
+	Key        string           `json:"key,omitempty"`
+	Method     *FFIMethod       `json:"method,omitempty"`
+	MethodPath string           `json:"methodPath,omitempty"`
+	Input      JSONObject       `json:"input"`
+}
@@ -0,0 +1,126 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFFIValidation(t *testing.T) {
+
+	ffi := &FFI{
+		Namespace: "!wrong",
+	}
+	assert.Regexp(t, "FF10131.*namespace", ffi.Validate(context.Background(), false))
+
+	ffi = &FFI{
+		Namespace: "ok",
+		Name:      "!wrong",
+	}
+	assert.Regexp(t, "FF10131.*name", ffi.Validate(context.Background(), false))
+
+	ffi = &FFI{
+		Namespace: "ok",
+		Name:      "ok",
+		Version:   "!wrong",
+	}
+	assert.Regexp(t, "FF10131.*version", ffi.Validate(context.Background(), false))
+
+	ffi = &FFI{
+		Namespace: "ok",
+		Name:      "ok",
+		Version:   "v1",
+		Methods:   FFIMethods{nil},
+	}
+	assert.Regexp(t, "FF10140.*methods", ffi.Validate(context.Background(), false))
+
+	ffi.Methods = FFIMethods{{}}
+	assert.Regexp(t, "FF10140.*name", ffi.Validate(context.Background(), false))
+
+	ffi.Methods = FFIMethods{{Name: "set", Params: FFIParams{{}}}}
+	assert.Regexp(t, "FF10140.*name", ffi.Validate(context.Background(), false))
+
+	ffi.Methods = FFIMethods{{Name: "set", Params: FFIParams{{Name: "x", Type: "wrong"}}}}
+	assert.Regexp(t, "FF10368.*wrong.*x", ffi.Validate(context.Background(), false))
+
+	ffi.Methods = FFIMethods{{Name: "set", Params: FFIParams{{Name: "x", Type: "integer"}}}}
+	ffi.Events = FFIEvents{{}}
+	assert.Regexp(t, "FF10140.*events", ffi.Validate(context.Background(), false))
+
+	ffi.Events = FFIEvents{{Name: "Changed", Params: FFIParams{{Name: "x", Type: "wrong"}}}}
+	assert.Regexp(t, "FF10368", ffi.Validate(context.Background(), false))
+
+	ffi.Events = FFIEvents{{Name: "Changed", Params: FFIParams{{Name: "x", Type: "integer"}}}}
+	assert.NoError(t, ffi.Validate(context.Background(), false))
+	assert.Regexp(t, "FF10203", ffi.Validate(context.Background(), true))
+
+	var def Definition = ffi
+	assert.Equal(t, "ff_ns_ok", def.Topic())
+	def.SetBroadcastMessage(NewUUID())
+	assert.NotNil(t, ffi.Message)
+
+	assert.Equal(t, "set", ffi.GetMethod("set").Name)
+	assert.Nil(t, ffi.GetMethod("get"))
+	assert.Equal(t, "Changed", ffi.GetEvent("Changed").Name)
+	assert.Nil(t, ffi.GetEvent("Removed"))
+}
+
+func TestFFIMethodsDatabaseSerialization(t *testing.T) {
+
+	methods := FFIMethods{{Name: "set", Params: FFIParams{{Name: "x", Type: "integer"}}}}
+	b, err := methods.Value()
+	assert.NoError(t, err)
+
+	var restored FFIMethods
+	assert.NoError(t, restored.Scan(b))
+	assert.Equal(t, "x", restored[0].Params[0].Name)
+
+	restored = nil
+	assert.NoError(t, restored.Scan(string(b.([]byte))))
+	assert.Equal(t, "set", restored[0].Name)
+
+	assert.NoError(t, restored.Scan(nil))
+	assert.NoError(t, restored.Scan(""))
+	assert.NoError(t, restored.Scan([]byte{}))
+	assert.Regexp(t, "FF10125", restored.Scan(12345))
+}
+
+func TestFFIEventsDatabaseSerialization(t *testing.T) {
+
+	events := FFIEvents{{Name: "Changed"}}
+	b, err := events.Value()
+	assert.NoError(t, err)
+
+	var restored FFIEvents
+	assert.NoError(t, restored.Scan(b))
+	assert.Equal(t, "Changed", restored[0].Name)
+}
+
+func TestFFIEventDatabaseSerialization(t *testing.T) {
+
+	event := &FFIEvent{Name: "Changed", Params: FFIParams{{Name: "x", Type: "integer"}}}
+	b, err := event.Value()
+	assert.NoError(t, err)
+
+	var restored FFIEvent
+	assert.NoError(t, restored.Scan(b))
+	assert.Equal(t, "Changed", restored.Name)
+	assert.Equal(t, "x", restored.Params[0].Name)
+}
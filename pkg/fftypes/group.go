@@ -18,7 +18,6 @@ package fftypes
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -58,8 +57,7 @@ type MemberInput struct {
 
 func (man *GroupIdentity) Hash() *Bytes32 {
 	b, _ := json.Marshal(&man)
-	hash := Bytes32(sha256.Sum256(b))
-	return &hash
+	return hashBytes(b)
 }
 
 func (group *Group) Validate(ctx context.Context, existing bool) (err error) {
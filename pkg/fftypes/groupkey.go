@@ -0,0 +1,38 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// GroupKey is one epoch of the symmetric key used to encrypt private payloads sent to a group.
+// Keys are rotated by sending a new epoch to the members of the group in a group update message.
+// Earlier epochs are retained to decrypt history, and new messages use the latest epoch.
+type GroupKey struct {
+	ID        *UUID    `json:"id"`
+	Message   *UUID    `json:"message,omitempty"`
+	Namespace string   `json:"namespace"`
+	Group     *Bytes32 `json:"group"`
+	Epoch     int64    `json:"epoch"`
+	Key       *Bytes32 `json:"key,omitempty"`
+	Created   *FFTime  `json:"created"`
+}
+
+func (gk *GroupKey) Topic() string {
+	return gk.Group.String()
+}
+
+func (gk *GroupKey) SetBroadcastMessage(msgID *UUID) {
+	gk.Message = msgID
+}
@@ -0,0 +1,37 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupKeyDefinition(t *testing.T) {
+	gk := &GroupKey{
+		ID:    NewUUID(),
+		Group: NewRandB32(),
+		Epoch: 1,
+		Key:   NewRandB32(),
+	}
+	assert.Equal(t, gk.Group.String(), gk.Topic())
+
+	msgID := NewUUID()
+	gk.SetBroadcastMessage(msgID)
+	assert.Equal(t, msgID, gk.Message)
+}
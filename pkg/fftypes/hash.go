@@ -0,0 +1,74 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// HashAlgorithm identifies the algorithm used to calculate the hashes of batches, data and pins
+type HashAlgorithm = FFEnum
+
+var (
+	// HashAlgorithmSHA256 is SHA-256, which is assumed for any object that does not record an algorithm
+	HashAlgorithmSHA256 HashAlgorithm = ffEnum("hashalgorithm", "sha256")
+)
+
+// DefaultHashAlgorithm is the algorithm used for all newly calculated hashes
+var DefaultHashAlgorithm = HashAlgorithmSHA256
+
+var hashAlgorithms = map[HashAlgorithm]func() hash.Hash{
+	HashAlgorithmSHA256: sha256.New,
+}
+
+// RegisterHashAlgorithm makes an additional hash algorithm available. It must be called during initialization,
+// and the algorithm must produce a 32 byte digest so the result can be held in a Bytes32
+func RegisterHashAlgorithm(algo HashAlgorithm, newHash func() hash.Hash) {
+	if size := newHash().Size(); size != 32 {
+		panic(fmt.Sprintf("hash algorithm '%s' has a %d byte digest - must be 32 bytes", algo, size))
+	}
+	hashAlgorithms[algo.Lower()] = newHash
+}
+
+// NewHash returns a new hash calculation for the specified algorithm, where an empty algorithm
+// means SHA-256 so that objects persisted before the algorithm was recorded can still be verified
+func NewHash(ctx context.Context, algo HashAlgorithm) (hash.Hash, error) {
+	if algo == "" {
+		algo = HashAlgorithmSHA256
+	}
+	newHash, ok := hashAlgorithms[algo.Lower()]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownHashAlgorithm, algo)
+	}
+	return newHash(), nil
+}
+
+// NewDefaultHash returns a new hash calculation using the DefaultHashAlgorithm
+func NewDefaultHash() hash.Hash {
+	return hashAlgorithms[DefaultHashAlgorithm]()
+}
+
+func hashBytes(b []byte) *Bytes32 {
+	h := NewDefaultHash()
+	h.Write(b)
+	return HashResult(h)
+}
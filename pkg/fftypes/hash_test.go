@@ -0,0 +1,60 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHashDefault(t *testing.T) {
+	h, err := NewHash(context.Background(), "")
+	assert.NoError(t, err)
+	h.Write([]byte("hello"))
+	expected := sha256.Sum256([]byte("hello"))
+	assert.Equal(t, expected[:], HashResult(h)[:])
+
+	h = NewDefaultHash()
+	h.Write([]byte("hello"))
+	assert.Equal(t, expected[:], HashResult(h)[:])
+}
+
+func TestNewHashUnknown(t *testing.T) {
+	_, err := NewHash(context.Background(), "md5")
+	assert.Regexp(t, "FF10406.*md5", err)
+}
+
+func TestRegisterHashAlgorithm(t *testing.T) {
+	RegisterHashAlgorithm("SHA512/256", sha512.New512_256)
+	defer delete(hashAlgorithms, "sha512/256")
+
+	h, err := NewHash(context.Background(), "sha512/256")
+	assert.NoError(t, err)
+	h.Write([]byte("hello"))
+	expected := sha512.Sum512_256([]byte("hello"))
+	assert.Equal(t, expected[:], HashResult(h)[:])
+}
+
+func TestRegisterHashAlgorithmBadSize(t *testing.T) {
+	assert.PanicsWithValue(t, "hash algorithm 'sha512' has a 64 byte digest - must be 32 bytes", func() {
+		RegisterHashAlgorithm("sha512", sha512.New)
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+)
+
+// IdempotencyKey is supplied by a client on submission, so that a retried submission is rejected
+// with a reference to the resource created by the original, rather than creating a duplicate.
+// It is local to the submitting node, and is stored as NULL when empty so it is excluded from the unique index.
+type IdempotencyKey string
+
+// Value implements sql.Valuer
+func (ik IdempotencyKey) Value() (driver.Value, error) {
+	if ik == "" {
+		return nil, nil
+	}
+	return string(ik), nil
+}
+
+// Scan implements sql.Scanner
+func (ik *IdempotencyKey) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*ik = ""
+		return nil
+	case string:
+		*ik = IdempotencyKey(src)
+		return nil
+	case []byte:
+		*ik = IdempotencyKey(src)
+		return nil
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, ik)
+	}
+}
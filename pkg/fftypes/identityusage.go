@@ -0,0 +1,32 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// IdentityUsage summarizes the activity of an org, or custom identity, in a namespace over a time range.
+// Messages and data are attributed by author, while token transfers and contract invokes are attributed
+// by the signing key that submitted them.
+type IdentityUsage struct {
+	Identity        string   `json:"identity"`
+	Author          string   `json:"author"`
+	Keys            []string `json:"keys"`
+	StartTime       *FFTime  `json:"startTime,omitempty"`
+	EndTime         *FFTime  `json:"endTime,omitempty"`
+	Messages        int64    `json:"messages"`
+	DataBytes       int64    `json:"dataBytes"`
+	TokenTransfers  int64    `json:"tokenTransfers"`
+	ContractInvokes int64    `json:"contractInvokes"`
+}
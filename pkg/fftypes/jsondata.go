@@ -18,7 +18,6 @@ package fftypes
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/json"
 	"strconv"
@@ -180,8 +179,54 @@ func (jd JSONObject) Hash(jsonDesc string) (*Bytes32, error) {
 	if err != nil {
 		return nil, i18n.NewError(context.Background(), i18n.MsgJSONObjectParseFailed, jsonDesc)
 	}
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32, nil
+	return hashBytes(b), nil
+}
+
+// RedactedValue is the placeholder stored in place of any redacted JSON value
+const RedactedValue = "[redacted]"
+
+// Redact returns a copy of the object with the value at each of the supplied dot-separated
+// paths replaced with RedactedValue. Paths that are not present in the object are ignored,
+// and the original object is never modified.
+func (jd JSONObject) Redact(paths ...string) JSONObject {
+	if jd == nil || len(paths) == 0 {
+		return jd
+	}
+	redacted := jd.shallowCopy()
+	for _, path := range paths {
+		redacted.redactPath(strings.Split(path, "."))
+	}
+	return redacted
+}
+
+func (jd JSONObject) shallowCopy() JSONObject {
+	c := make(JSONObject, len(jd))
+	for k, v := range jd {
+		c[k] = v
+	}
+	return c
+}
+
+func (jd JSONObject) redactPath(path []string) {
+	v, ok := jd[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		jd[path[0]] = RedactedValue
+		return
+	}
+	var child JSONObject
+	switch vt := v.(type) {
+	case JSONObject:
+		child = vt.shallowCopy()
+	case map[string]interface{}:
+		child = JSONObject(vt).shallowCopy()
+	default:
+		return
+	}
+	child.redactPath(path[1:])
+	jd[path[0]] = child
 }
 
 // JSONObjectArray is an array of JSONObject
@@ -219,6 +264,5 @@ func (jd JSONObjectArray) Hash(jsonDesc string) (*Bytes32, error) {
 	if err != nil {
 		return nil, i18n.NewError(context.Background(), i18n.MsgJSONObjectParseFailed, jsonDesc)
 	}
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32, nil
+	return hashBytes(b), nil
 }
@@ -218,3 +218,30 @@ func TestJSONNestedSafeGet(t *testing.T) {
 	)
 
 }
+
+func TestJSONObjectRedact(t *testing.T) {
+
+	var jd JSONObject
+	err := json.Unmarshal([]byte(`{
+		"headers": {
+			"Authorization": "Bearer secret",
+			"Content-Type": "application/json"
+		},
+		"payload": [1,2,3],
+		"name": "test"
+	}`), &jd)
+	assert.NoError(t, err)
+
+	redacted := jd.Redact("headers.Authorization", "payload", "missing", "name.sub", "headers.missing.deeper")
+	assert.Equal(t, `{"headers":{"Authorization":"[redacted]","Content-Type":"application/json"},"name":"test","payload":"[redacted]"}`, redacted.String())
+
+	// Original untouched
+	assert.Equal(t, "Bearer secret", jd.GetObject("headers").GetString("Authorization"))
+
+	nested := JSONObject{"a": JSONObject{"b": "c"}}
+	assert.Equal(t, `{"a":{"b":"[redacted]"}}`, nested.Redact("a.b").String())
+	assert.Equal(t, "c", nested.GetObject("a").GetString("b"))
+
+	assert.Equal(t, jd, jd.Redact())
+	assert.Nil(t, JSONObject(nil).Redact("a"))
+}
@@ -0,0 +1,63 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// LegalHoldType is the kind of object a legal hold is placed on
+type LegalHoldType = FFEnum
+
+var (
+	// LegalHoldTypeMessage holds a single message, referred to by ID
+	LegalHoldTypeMessage LegalHoldType = ffEnum("legalholdtype", "message")
+	// LegalHoldTypeData holds a single data item, referred to by ID
+	LegalHoldTypeData LegalHoldType = ffEnum("legalholdtype", "data")
+	// LegalHoldTypeTopic holds every message on a topic
+	LegalHoldTypeTopic LegalHoldType = ffEnum("legalholdtype", "topic")
+)
+
+// LegalHold exempts a message, a data item, or the messages on a topic, from retention pruning while it is active.
+// Holds are never deleted - removing a hold records who removed it and when, so the history is available for audit.
+type LegalHold struct {
+	ID        *UUID         `json:"id"`
+	Namespace string        `json:"namespace,omitempty"`
+	Type      LegalHoldType `json:"type" ffenum:"legalholdtype"`
+	Reference *UUID         `json:"reference,omitempty"`
+	Topic     string        `json:"topic,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+	Active    bool          `json:"active"`
+	Placed    *FFTime       `json:"placed,omitempty"`
+	PlacedBy  string        `json:"placedBy,omitempty"`
+	Removed   *FFTime       `json:"removed,omitempty"`
+	RemovedBy string        `json:"removedBy,omitempty"`
+	Comment   string        `json:"comment,omitempty"`
+}
+
+// LegalHoldInput is the request to place a legal hold. The identity of an authenticated API caller is recorded
+// in preference to the supplied placedBy
+type LegalHoldInput struct {
+	Type      LegalHoldType `json:"type" ffenum:"legalholdtype"`
+	Reference *UUID         `json:"reference,omitempty"`
+	Topic     string        `json:"topic,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+	PlacedBy  string        `json:"placedBy,omitempty"`
+}
+
+// LegalHoldRemoval is the request to remove a legal hold. The identity of an authenticated API caller is recorded
+// in preference to the supplied removedBy
+type LegalHoldRemoval struct {
+	RemovedBy string `json:"removedBy,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
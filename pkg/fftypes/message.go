@@ -18,7 +18,6 @@ package fftypes
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -41,6 +40,8 @@ var (
 	MessageTypePrivate MessageType = ffEnum("messagetype", "private")
 	// MessageTypeGroupInit is a special private message that contains the definition of the group
 	MessageTypeGroupInit MessageType = ffEnum("messagetype", "groupinit")
+	// MessageTypeGroupUpdate is a special private message that updates the state of an existing group, such as rotating the group key
+	MessageTypeGroupUpdate MessageType = ffEnum("messagetype", "groupupdate")
 	// MessageTypeTransferBroadcast is a broadcast message to accompany/annotate a token transfer
 	MessageTypeTransferBroadcast MessageType = ffEnum("messagetype", "transfer_broadcast")
 	// MessageTypeTransferPrivate is a private message to accompany/annotate a token transfer
@@ -55,14 +56,38 @@ var (
 	MessageStateStaged MessageState = ffEnum("messagestate", "staged")
 	// MessageStateReady is a message created locally which is ready to send
 	MessageStateReady MessageState = ffEnum("messagestate", "ready")
+	// MessageStateHeld is a message created locally which is held until an operator approves its release
+	MessageStateHeld MessageState = ffEnum("messagestate", "held")
+	// MessageStateSent is recorded in the history of a local message once the batch containing it has been dispatched
+	MessageStateSent MessageState = ffEnum("messagestate", "sent")
 	// MessageStatePending is a message that has been received but is awaiting aggregation/confirmation
 	MessageStatePending MessageState = ffEnum("messagestate", "pending")
+	// MessageStateAwaitingQuorum is a broadcast that has been aggregated, but is waiting for receipts from a quorum of member orgs before it is confirmed
+	MessageStateAwaitingQuorum MessageState = ffEnum("messagestate", "awaiting_quorum")
 	// MessageStateConfirmed is a message that has completed all required confirmations (blockchain if pinned, token transfer if transfer coupled, etc)
 	MessageStateConfirmed MessageState = ffEnum("messagestate", "confirmed")
 	// MessageStateRejected is a message that has completed confirmation, but has been rejected by FireFly
 	MessageStateRejected MessageState = ffEnum("messagestate", "rejected")
 )
 
+// MessageTopicState is the confirmation state of a message within the ordering context of one of its topics
+type MessageTopicState = FFEnum
+
+var (
+	// MessageTopicStatePending is a topic on which the message is still waiting for earlier messages on the same context
+	MessageTopicStatePending MessageTopicState = ffEnum("messagetopicstate", "pending")
+	// MessageTopicStateConfirmed is a topic on which the message is next in sequence (or has been confirmed)
+	MessageTopicStateConfirmed MessageTopicState = ffEnum("messagetopicstate", "confirmed")
+)
+
+// MessageTopicStatus is the confirmation state of a message on one of its topics. A multi-topic message
+// can be confirmed on one topic, while still pending on another, until it is ready on all of them
+type MessageTopicStatus struct {
+	Topic string            `json:"topic"`
+	State MessageTopicState `json:"state" ffenum:"messagetopicstate"`
+	Event *UUID             `json:"event,omitempty"`
+}
+
 // MessageHeader contains all fields that contribute to the hash
 // The order of the serialization mut not change, once released
 type MessageHeader struct {
@@ -71,12 +96,14 @@ type MessageHeader struct {
 	Type   MessageType     `json:"type" ffenum:"messagetype"`
 	TxType TransactionType `json:"txtype,omitempty"`
 	Identity
-	Created   *FFTime     `json:"created,omitempty"`
-	Namespace string      `json:"namespace,omitempty"`
-	Group     *Bytes32    `json:"group,omitempty"`
-	Topics    FFNameArray `json:"topics,omitempty"`
-	Tag       string      `json:"tag,omitempty"`
-	DataHash  *Bytes32    `json:"datahash,omitempty"`
+	Created    *FFTime       `json:"created,omitempty"`
+	Namespace  string        `json:"namespace,omitempty"`
+	Group      *Bytes32      `json:"group,omitempty"`
+	Topics     FFNameArray   `json:"topics,omitempty"`
+	Tag        string        `json:"tag,omitempty"`
+	DataHash   *Bytes32      `json:"datahash,omitempty"`
+	Custom     CustomHeaders `json:"custom,omitempty"`
+	Delegation *UUID         `json:"delegation,omitempty"` // set when the key sends on behalf of the author, under a delegation from the author
 }
 
 // Message is the envelope by which coordinated data exchange can happen between parties in the network
@@ -90,7 +117,10 @@ type Message struct {
 	Confirmed *FFTime       `json:"confirmed,omitempty"`
 	Data      DataRefs      `json:"data"`
 	Pins      FFNameArray   `json:"pins,omitempty"`
-	Sequence  int64         `json:"-"` // Local database sequence used internally for batch assembly
+	Immediate bool          `json:"immediate,omitempty"` // Requests the fastpath, if the namespace is configured for low latency
+	Sequence  int64         `json:"-"`                   // Local database sequence used internally for batch assembly
+
+	IdempotencyKey IdempotencyKey `json:"idempotencyKey,omitempty"` // Local to the submitting node, and not included in the batch
 }
 
 // MessageInOut allows API users to submit values in-line in the payload submitted, which
@@ -131,8 +161,7 @@ type MessageRef struct {
 
 func (h *MessageHeader) Hash() *Bytes32 {
 	b, _ := json.Marshal(&h)
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32
+	return hashBytes(b)
 }
 
 func (m *MessageInOut) SetInlineData(data []*Data) {
@@ -164,6 +193,9 @@ func (m *Message) Seal(ctx context.Context) (err error) {
 	if m.Data == nil {
 		m.Data = DataRefs{}
 	}
+	if err = m.Header.Custom.Validate(ctx, "header.custom"); err != nil {
+		return err
+	}
 	err = m.DupDataCheck(ctx)
 	if err == nil {
 		m.Header.DataHash = m.Data.Hash()
@@ -196,6 +228,9 @@ func (m *Message) Verify(ctx context.Context) error {
 			return err
 		}
 	}
+	if err := m.Header.Custom.Validate(ctx, "header.custom"); err != nil {
+		return err
+	}
 	err := m.DupDataCheck(ctx)
 	if err != nil {
 		return err
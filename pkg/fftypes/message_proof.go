@@ -0,0 +1,63 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// MessageProof is the set of information a third party needs to independently verify
+// that a message was included in a batch, and that the batch was pinned to the blockchain.
+//
+// The message hash can be recomputed from the header, and must appear at the given index in the
+// batch manifest. The batch hash, and the pins covering the topics of the message, are those
+// written to the blockchain in the transaction - which can be looked up on the ledger using the
+// protocol ID and block number. For broadcast batches, the full batch payload can be retrieved
+// using the payload reference, and hashed to check against the batch hash.
+type MessageProof struct {
+	Message     *Message                 `json:"message"`
+	Batch       *MessageProofBatch       `json:"batch"`
+	Transaction *MessageProofTransaction `json:"transaction"`
+}
+
+// MessageProofBatch is the batch that contained the message
+type MessageProofBatch struct {
+	ID         *UUID         `json:"id"`
+	Hash       *Bytes32      `json:"hash"`
+	PayloadRef string        `json:"payloadRef,omitempty"`
+	Manifest   []*MessageRef `json:"manifest"`
+	Index      int           `json:"index"`
+	Pins       []*Bytes32    `json:"pins"`
+}
+
+// MessageProofTransaction is the blockchain transaction that pinned the batch
+type MessageProofTransaction struct {
+	ID          *UUID      `json:"id"`
+	Signer      string     `json:"signer"`
+	ProtocolID  string     `json:"protocolId"`
+	BlockNumber string     `json:"blockNumber,omitempty"`
+	Info        JSONObject `json:"info,omitempty"`
+}
+
+// MessageProofVerification is the result of checking a message proof against the records of the node
+type MessageProofVerification struct {
+	Valid  bool                 `json:"valid"`
+	Checks []*MessageProofCheck `json:"checks"`
+}
+
+// MessageProofCheck is the result of an individual check performed while verifying a message proof
+type MessageProofCheck struct {
+	Name  string `json:"name"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
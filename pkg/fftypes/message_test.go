@@ -54,6 +54,26 @@ func TestVerifyBadTagString(t *testing.T) {
 	assert.Regexp(t, `FF10131.*header.tag`, err)
 }
 
+func TestVerifyBadCustomHeaders(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			Custom: CustomHeaders{"!wrong": "value"},
+		},
+	}
+	err := msg.Verify(context.Background())
+	assert.Regexp(t, `FF10131.*header.custom`, err)
+}
+
+func TestSealBadCustomHeaders(t *testing.T) {
+	msg := Message{
+		Header: MessageHeader{
+			Custom: CustomHeaders{"key1": "bad\x00value"},
+		},
+	}
+	err := msg.Seal(context.Background())
+	assert.Regexp(t, `FF10338.*header.custom.key1`, err)
+}
+
 func TestSealNilDataID(t *testing.T) {
 	msg := Message{
 		Data: DataRefs{
@@ -0,0 +1,40 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// MessageTransition records a single change in the state of a message, and what caused it,
+// building up the full history of the message as it flows through the system
+type MessageTransition struct {
+	ID        *UUID        `json:"id"`
+	Namespace string       `json:"namespace"`
+	Message   *UUID        `json:"message"`
+	State     MessageState `json:"state" ffenum:"messagestate"`
+	Cause     string       `json:"cause,omitempty"`
+	Created   *FFTime      `json:"created"`
+}
+
+// NewMessageTransition records the current state of a message, as set by the supplied cause
+func NewMessageTransition(msg *Message, cause string) *MessageTransition {
+	return &MessageTransition{
+		ID:        NewUUID(),
+		Namespace: msg.Header.Namespace,
+		Message:   msg.Header.ID,
+		State:     msg.State,
+		Cause:     cause,
+		Created:   Now(),
+	}
+}
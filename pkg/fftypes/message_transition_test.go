@@ -0,0 +1,43 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMessageTransition(t *testing.T) {
+
+	msg := &Message{
+		Header: MessageHeader{
+			ID:        NewUUID(),
+			Namespace: "ns1",
+		},
+		State: MessageStatePending,
+	}
+	transition := NewMessageTransition(msg, "received in batch")
+	assert.Equal(t, MessageTransition{
+		ID:        transition.ID,
+		Namespace: "ns1",
+		Message:   msg.Header.ID,
+		State:     MessageStatePending,
+		Cause:     "received in batch",
+		Created:   transition.Created,
+	}, *transition)
+}
@@ -0,0 +1,33 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// MessageHold records an outbound private message that is held, until an operator approves the release of its data.
+// The ID is that of the message, which is only stored with the other messages once it is released.
+type MessageHold struct {
+	ID        *UUID                `json:"id"`
+	Namespace string               `json:"namespace,omitempty"`
+	Group     *Bytes32             `json:"group,omitempty"`
+	Tag       string               `json:"tag,omitempty"`
+	Message   *Message             `json:"message"`
+	Reason    string               `json:"reason,omitempty"`
+	Status    PolicyApprovalStatus `json:"status" ffenum:"policyapprovalstatus"`
+	Created   *FFTime              `json:"created,omitempty"`
+	Decided   *FFTime              `json:"decided,omitempty"`
+	DecidedBy string               `json:"decidedBy,omitempty"`
+	Comment   string               `json:"comment,omitempty"`
+}
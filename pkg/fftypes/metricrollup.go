@@ -0,0 +1,39 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// MetricRollupType is the type of record counted in a metric rollup
+type MetricRollupType = FFEnum
+
+var (
+	// MetricRollupTypeMessages counts messages by message type, as they are confirmed
+	MetricRollupTypeMessages MetricRollupType = ffEnum("metricrolluptype", "messages")
+	// MetricRollupTypeTransfers counts token transfers by transfer type
+	MetricRollupTypeTransfers MetricRollupType = ffEnum("metricrolluptype", "transfers")
+	// MetricRollupTypeEvents counts events by event type
+	MetricRollupTypeEvents MetricRollupType = ffEnum("metricrolluptype", "events")
+)
+
+// MetricRollup is the number of records of a single type in a namespace, within a fixed time bucket.
+// Buckets are aligned to multiples of the configured rollup interval since the epoch.
+type MetricRollup struct {
+	Namespace string           `json:"namespace"`
+	Metric    MetricRollupType `json:"metric" ffenum:"metricrolluptype"`
+	Type      string           `json:"type"`
+	Bucket    *FFTime          `json:"bucket"`
+	Count     int64            `json:"count"`
+}
@@ -18,9 +18,11 @@ package fftypes
 
 // NodeStatus is a set of information that represents the health, and identity of a node
 type NodeStatus struct {
-	Node     NodeStatusNode     `json:"node"`
-	Org      NodeStatusOrg      `json:"org"`
-	Defaults NodeStatusDefaults `json:"defaults"`
+	Node     NodeStatusNode      `json:"node"`
+	Org      NodeStatusOrg       `json:"org"`
+	Defaults NodeStatusDefaults  `json:"defaults"`
+	Database *NodeStatusDatabase `json:"database,omitempty"`
+	Clock    *NodeStatusClock    `json:"clock,omitempty"`
 }
 
 // NodeStatusNode is the information about the local node, returned in the node status
@@ -28,6 +30,7 @@ type NodeStatusNode struct {
 	Name       string `json:"name"`
 	Registered bool   `json:"registered"`
 	ID         *UUID  `json:"id,omitempty"`
+	Standby    bool   `json:"standby,omitempty"`
 }
 
 // NodeStatusOrg is the information about the node owning org, returned in the node status
@@ -42,3 +45,30 @@ type NodeStatusOrg struct {
 type NodeStatusDefaults struct {
 	Namespace string `json:"namespace"`
 }
+
+// NodeStatusDatabase is the state of the database connection pool, returned in the node status
+type NodeStatusDatabase struct {
+	Provider           string      `json:"provider"`
+	Healthy            bool        `json:"healthy"`
+	LastError          string      `json:"lastError,omitempty"`
+	LastCheck          *FFTime     `json:"lastCheck,omitempty"`
+	Reconnects         int64       `json:"reconnects"`
+	MaxOpenConnections int         `json:"maxOpenConnections"`
+	OpenConnections    int         `json:"openConnections"`
+	InUse              int         `json:"inUse"`
+	Idle               int         `json:"idle"`
+	WaitCount          int64       `json:"waitCount"`
+	WaitDuration       FFDuration  `json:"waitDuration"`
+	ClockSkew          *FFDuration `json:"clockSkew,omitempty"`
+}
+
+// NodeStatusClock is the skew detected between the clock of the node, and the clocks of the database and blockchain.
+// A positive skew means the node clock is ahead. The blockchain skew includes the confirmation latency of the last block
+// timestamp received, so is only significant when it is large or negative.
+type NodeStatusClock struct {
+	Time           *FFTime     `json:"time"`
+	MaxSkew        FFDuration  `json:"maxSkew"`
+	DatabaseSkew   *FFDuration `json:"databaseSkew,omitempty"`
+	BlockchainSkew *FFDuration `json:"blockchainSkew,omitempty"`
+	Skewed         bool        `json:"skewed"`
+}
@@ -25,6 +25,8 @@ var (
 	OffsetTypeAggregator OffsetType = ffEnum("offsettype", "aggregator")
 	// OffsetTypeSubscription is an offeset stored by a dispatcher on the events table
 	OffsetTypeSubscription OffsetType = ffEnum("offsettype", "subscription")
+	// OffsetTypeRollup is an offset stored by the metrics rollup, as the start time of the next bucket to count
+	OffsetTypeRollup OffsetType = ffEnum("offsettype", "rollup")
 )
 
 // Offset is a simple stored data structure that records a sequence position within another collection
@@ -16,6 +16,8 @@
 
 package fftypes
 
+import "fmt"
+
 // OpType describes mechanical steps in the process that have to be performed,
 // might be asynchronous, and have results in the back-end systems that might need
 // to be correlated with messages by operators.
@@ -26,16 +28,32 @@ var (
 	OpTypeBlockchainBatchPin OpType = ffEnum("optype", "blockchain_batch_pin")
 	// OpTypePublicStorageBatchBroadcast is a public storage operation to store broadcast data
 	OpTypePublicStorageBatchBroadcast OpType = ffEnum("optype", "publicstorage_batch_broadcast")
+	// OpTypePublicStoragePin is a public storage operation to pin broadcast data at remote pinning services
+	OpTypePublicStoragePin OpType = ffEnum("optype", "publicstorage_pin")
 	// OpTypeDataExchangeBatchSend is a private send
 	OpTypeDataExchangeBatchSend OpType = ffEnum("optype", "dataexchange_batch_send")
 	// OpTypeDataExchangeBlobSend is a private send
 	OpTypeDataExchangeBlobSend OpType = ffEnum("optype", "dataexchange_blob_send")
+	// OpTypeDataExchangeMessageSend is a private send of an unpinned message, or a receipt, outside of any transaction
+	OpTypeDataExchangeMessageSend OpType = ffEnum("optype", "dataexchange_message_send")
 	// OpTypeTokenCreatePool is a token pool creation
 	OpTypeTokenCreatePool OpType = ffEnum("optype", "token_create_pool")
 	// OpTypeTokenAnnouncePool is a broadcast of token pool info
 	OpTypeTokenAnnouncePool OpType = ffEnum("optype", "token_announce_pool")
 	// OpTypeTokenTransfer is a token transfer
 	OpTypeTokenTransfer OpType = ffEnum("optype", "token_transfer")
+	// OpTypeTokenApproval is a token approval
+	OpTypeTokenApproval OpType = ffEnum("optype", "token_approval")
+	// OpTypeTokenBridgeLock is a transfer of tokens into escrow on the source pool of a token bridge
+	OpTypeTokenBridgeLock OpType = ffEnum("optype", "token_bridge_lock")
+	// OpTypeTokenBridgeMint is a mint of tokens on the target pool of a token bridge
+	OpTypeTokenBridgeMint OpType = ffEnum("optype", "token_bridge_mint")
+	// OpTypeTokenBridgeUnlock is a return of escrowed tokens on the source pool, to compensate a failed token bridge
+	OpTypeTokenBridgeUnlock OpType = ffEnum("optype", "token_bridge_unlock")
+	// OpTypeBlockchainInvoke is a blockchain transaction to invoke a method on a custom smart contract
+	OpTypeBlockchainInvoke OpType = ffEnum("optype", "blockchain_invoke")
+	// OpTypeDataImport is a download and hash of a blob by FireFly, to create a data item
+	OpTypeDataImport OpType = ffEnum("optype", "data_import")
 )
 
 // OpStatus is the current status of an operation
@@ -64,10 +82,18 @@ func NewTXOperation(plugin Named, namespace string, tx *UUID, backendID string,
 		Transaction: tx,
 		Type:        opType,
 		Status:      opStatus,
+		Schema:      OpSchema(opType),
 		Created:     Now(),
 	}
 }
 
+// OpSchema returns the tag recorded against operations of the given type, describing the structure
+// of the input/output written by this version of FireFly, so stored operations can be interpreted
+// reliably as those structures evolve
+func OpSchema(opType OpType) string {
+	return fmt.Sprintf("%s/v1", opType)
+}
+
 // Operation is a description of an action performed as part of a transaction submitted by this node
 type Operation struct {
 	ID          *UUID      `json:"id"`
@@ -78,8 +104,10 @@ type Operation struct {
 	Error       string     `json:"error,omitempty"`
 	Plugin      string     `json:"plugin"`
 	BackendID   string     `json:"backendId"`
+	Schema      string     `json:"schema,omitempty"`
 	Input       JSONObject `json:"input,omitempty"`
 	Output      JSONObject `json:"output,omitempty"`
 	Created     *FFTime    `json:"created,omitempty"`
 	Updated     *FFTime    `json:"updated,omitempty"`
+	Retry       *UUID      `json:"retry,omitempty"`
 }
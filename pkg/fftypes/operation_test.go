@@ -38,6 +38,7 @@ func TestNewPendingMessageOp(t *testing.T) {
 		BackendID:   "testBackend",
 		Type:        OpTypePublicStorageBatchBroadcast,
 		Status:      OpStatusPending,
+		Schema:      "publicstorage_batch_broadcast/v1",
 		Created:     op.Created,
 	}, *op)
 }
@@ -0,0 +1,32 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// PinQuarantine records a message in a batch that was pinned on-chain by a key the pin policy does not permit to send it,
+// when the policy action is to quarantine. The message stays pending, and blocks its context, until an operator approves
+// it (to be dispatched as if the key was permitted) or rejects it (so the context moves on). The ID is that of the message.
+type PinQuarantine struct {
+	ID        *UUID                `json:"id"`
+	Namespace string               `json:"namespace,omitempty"`
+	Batch     *UUID                `json:"batch,omitempty"`
+	Signer    string               `json:"signer,omitempty"`
+	Status    PolicyApprovalStatus `json:"status" ffenum:"policyapprovalstatus"`
+	Created   *FFTime              `json:"created,omitempty"`
+	Decided   *FFTime              `json:"decided,omitempty"`
+	DecidedBy string               `json:"decidedBy,omitempty"`
+	Comment   string               `json:"comment,omitempty"`
+}
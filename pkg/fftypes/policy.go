@@ -0,0 +1,107 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"encoding/json"
+)
+
+// PolicySubmissionType is the kind of blockchain submission a policy decision is requested for
+type PolicySubmissionType = FFEnum
+
+var (
+	// PolicySubmissionTypeBatchPin is the pinning of a batch of messages
+	PolicySubmissionTypeBatchPin PolicySubmissionType = ffEnum("policysubmissiontype", "batch_pin")
+	// PolicySubmissionTypeTokenPool is the creation of a token pool
+	PolicySubmissionTypeTokenPool PolicySubmissionType = ffEnum("policysubmissiontype", "token_pool")
+	// PolicySubmissionTypeTokenTransfer is a mint, burn or transfer of tokens
+	PolicySubmissionTypeTokenTransfer PolicySubmissionType = ffEnum("policysubmissiontype", "token_transfer")
+	// PolicySubmissionTypeTokenApproval is the approval of an operator to transfer tokens on behalf of a key
+	PolicySubmissionTypeTokenApproval PolicySubmissionType = ffEnum("policysubmissiontype", "token_approval")
+	// PolicySubmissionTypeContractInvoke is the invocation of a custom smart contract
+	PolicySubmissionTypeContractInvoke PolicySubmissionType = ffEnum("policysubmissiontype", "contract_invoke")
+	// PolicySubmissionTypeTokenPoolGovernance is a governance action on the contract of a token pool, detected by the connector rather than submitted by this node
+	PolicySubmissionTypeTokenPoolGovernance PolicySubmissionType = ffEnum("policysubmissiontype", "token_pool_governance")
+)
+
+// PolicyDecision is the outcome of evaluating a submission against the policy plugin
+type PolicyDecision = FFEnum
+
+var (
+	// PolicyDecisionApprove allows the submission to proceed
+	PolicyDecisionApprove PolicyDecision = ffEnum("policydecision", "approve")
+	// PolicyDecisionReject fails the submission
+	PolicyDecisionReject PolicyDecision = ffEnum("policydecision", "reject")
+	// PolicyDecisionHold blocks the submission until an administrator approves it
+	PolicyDecisionHold PolicyDecision = ffEnum("policydecision", "hold")
+)
+
+// PolicyApprovalStatus is the state of a submission held for manual approval
+type PolicyApprovalStatus = FFEnum
+
+var (
+	// PolicyApprovalStatusPending is awaiting a decision from an administrator
+	PolicyApprovalStatusPending PolicyApprovalStatus = ffEnum("policyapprovalstatus", "pending")
+	// PolicyApprovalStatusApproved has been approved, and the next identical submission will proceed
+	PolicyApprovalStatusApproved PolicyApprovalStatus = ffEnum("policyapprovalstatus", "approved")
+	// PolicyApprovalStatusRejected has been rejected, and identical submissions will fail
+	PolicyApprovalStatusRejected PolicyApprovalStatus = ffEnum("policyapprovalstatus", "rejected")
+	// PolicyApprovalStatusConsumed was approved, and the approval has been used by a submission
+	PolicyApprovalStatusConsumed PolicyApprovalStatus = ffEnum("policyapprovalstatus", "consumed")
+)
+
+// PolicyRequest is the full context of a blockchain submission, passed to the policy plugin before it is submitted.
+// Input contains the details of the submission that do not change when an identical request is resubmitted.
+type PolicyRequest struct {
+	Type       PolicySubmissionType `json:"type" ffenum:"policysubmissiontype"`
+	Namespace  string               `json:"namespace,omitempty"`
+	SigningKey string               `json:"signingKey,omitempty"`
+	Reference  *UUID                `json:"reference,omitempty"`
+	Input      JSONObject           `json:"input,omitempty"`
+}
+
+// Hash identifies identical submissions, so that an approval granted for a held request applies when it is resubmitted.
+// The reference is excluded, as it is newly generated for some types of submission.
+func (pr *PolicyRequest) Hash() *Bytes32 {
+	b, _ := json.Marshal(&PolicyRequest{
+		Type:       pr.Type,
+		Namespace:  pr.Namespace,
+		SigningKey: pr.SigningKey,
+		Input:      pr.Input,
+	})
+	return hashBytes(b)
+}
+
+// PolicyApproval records a submission held by the policy plugin for a decision by an administrator
+type PolicyApproval struct {
+	ID *UUID `json:"id"`
+	PolicyRequest
+	Hash      *Bytes32             `json:"hash"`
+	Reason    string               `json:"reason,omitempty"`
+	Status    PolicyApprovalStatus `json:"status" ffenum:"policyapprovalstatus"`
+	Created   *FFTime              `json:"created,omitempty"`
+	Decided   *FFTime              `json:"decided,omitempty"`
+	DecidedBy string               `json:"decidedBy,omitempty"`
+	Comment   string               `json:"comment,omitempty"`
+}
+
+// PolicyApprovalDecision is the input from an administrator deciding a pending approval
+type PolicyApprovalDecision struct {
+	Status    PolicyApprovalStatus `json:"status" ffenum:"policyapprovalstatus"`
+	DecidedBy string               `json:"decidedBy"`
+	Comment   string               `json:"comment,omitempty"`
+}
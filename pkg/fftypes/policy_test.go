@@ -0,0 +1,39 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyRequestHashIgnoresReference(t *testing.T) {
+	req1 := &PolicyRequest{
+		Type:       PolicySubmissionTypeTokenTransfer,
+		Namespace:  "ns1",
+		SigningKey: "0x12345",
+		Reference:  NewUUID(),
+		Input:      JSONObject{"amount": "10", "to": "0x23456"},
+	}
+	req2 := *req1
+	req2.Reference = NewUUID()
+	assert.Equal(t, req1.Hash(), req2.Hash())
+
+	req2.Input = JSONObject{"amount": "11", "to": "0x23456"}
+	assert.NotEqual(t, req1.Hash(), req2.Hash())
+}
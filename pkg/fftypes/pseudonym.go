@@ -0,0 +1,58 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import "fmt"
+
+const (
+	// FireflyPseudonymDIDPrefix is the author prefix for messages broadcast under a pseudonym
+	FireflyPseudonymDIDPrefix = "did:firefly:pseudonym/"
+)
+
+// Pseudonym is a signing key registered in a namespace to broadcast messages without revealing the organization
+// that sent them. The registration is broadcast signed by the pseudonymous key itself, and only the node that
+// registered it records the owning organization.
+type Pseudonym struct {
+	ID        *UUID   `json:"id"`
+	Message   *UUID   `json:"message,omitempty"`
+	Namespace string  `json:"namespace"`
+	Key       string  `json:"key"`
+	Owner     string  `json:"owner,omitempty"` // only stored locally on the registering node, never broadcast
+	Created   *FFTime `json:"created"`
+}
+
+// PseudonymAuthor is the true author behind a pseudonym
+type PseudonymAuthor struct {
+	Pseudonym *UUID  `json:"pseudonym"`
+	DID       string `json:"did"`
+	Author    string `json:"author"`
+}
+
+func (p *Pseudonym) GetDID() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s%s", FireflyPseudonymDIDPrefix, p.ID)
+}
+
+func (p *Pseudonym) Topic() string {
+	return namespaceTopic(p.Namespace)
+}
+
+func (p *Pseudonym) SetBroadcastMessage(msgID *UUID) {
+	p.Message = msgID
+}
@@ -0,0 +1,40 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPseudonymDefinition(t *testing.T) {
+	var p *Pseudonym
+	assert.Equal(t, "", p.GetDID())
+
+	p = &Pseudonym{
+		ID:        NewUUID(),
+		Namespace: "ns1",
+		Key:       "0x12345",
+	}
+	assert.Equal(t, "did:firefly:pseudonym/"+p.ID.String(), p.GetDID())
+	assert.Equal(t, "ff_ns_ns1", p.Topic())
+
+	msgID := NewUUID()
+	p.SetBroadcastMessage(msgID)
+	assert.Equal(t, msgID, p.Message)
+}
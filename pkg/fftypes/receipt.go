@@ -0,0 +1,27 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// MessageReceipt is a lightweight acknowledgment, sent over data exchange by a member org to the
+// author of a broadcast message, once that member has confirmed the message
+type MessageReceipt struct {
+	ID        *UUID  `json:"id"`
+	Namespace string `json:"namespace"`
+	Message   *UUID  `json:"message"`
+	Identity
+	Created *FFTime `json:"created"`
+}
@@ -16,6 +16,20 @@
 
 package fftypes
 
+// ProblemJSONContentType is the RFC 7807 content type used for error responses on the API
+const ProblemJSONContentType = "application/problem+json"
+
+// RESTError is an RFC 7807 problem details object, with FireFly extension fields.
+// The "error" field contains the full message including the code, for compatibility
+// with clients built before the problem details fields were added.
 type RESTError struct {
-	Error string `json:"error"`
+	Type          string `json:"type,omitempty"`
+	Title         string `json:"title,omitempty"`
+	Status        int    `json:"status,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	Error         string `json:"error"`
+	Code          string `json:"code,omitempty"`
+	Field         string `json:"field,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
 }
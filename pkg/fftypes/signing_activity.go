@@ -0,0 +1,53 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// SigningActivity records a single use of a signing key by this node, to submit an operation to a
+// blockchain or token connector, for key governance and auditing
+type SigningActivity struct {
+	ID          *UUID   `json:"id"`
+	Namespace   string  `json:"namespace"`
+	Key         string  `json:"key"`
+	Type        OpType  `json:"type" ffenum:"optype"`
+	Plugin      string  `json:"plugin"`
+	Operation   *UUID   `json:"operation"`
+	Transaction *UUID   `json:"tx,omitempty"`
+	Created     *FFTime `json:"created"`
+}
+
+// SigningKeyReport summarizes the signing activity of a key over a time range
+type SigningKeyReport struct {
+	Key   string           `json:"key"`
+	Total int64            `json:"total"`
+	First *FFTime          `json:"first,omitempty"`
+	Last  *FFTime          `json:"last,omitempty"`
+	Types map[OpType]int64 `json:"types"`
+}
+
+// NewSigningActivity records the use of a key to sign the submission of an operation
+func NewSigningActivity(op *Operation, key string) *SigningActivity {
+	return &SigningActivity{
+		ID:          NewUUID(),
+		Namespace:   op.Namespace,
+		Key:         key,
+		Type:        op.Type,
+		Plugin:      op.Plugin,
+		Operation:   op.ID,
+		Transaction: op.Transaction,
+		Created:     Now(),
+	}
+}
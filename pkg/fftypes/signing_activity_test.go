@@ -0,0 +1,40 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSigningActivity(t *testing.T) {
+
+	txID := NewUUID()
+	op := NewTXOperation(&fakePlugin{}, "ns1", txID, "", OpTypeBlockchainBatchPin, OpStatusPending)
+	activity := NewSigningActivity(op, "0x12345")
+	assert.Equal(t, SigningActivity{
+		ID:          activity.ID,
+		Namespace:   "ns1",
+		Key:         "0x12345",
+		Type:        OpTypeBlockchainBatchPin,
+		Plugin:      "fake",
+		Operation:   op.ID,
+		Transaction: txID,
+		Created:     activity.Created,
+	}, *activity)
+}
@@ -0,0 +1,39 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// SnapshotType is the type of component the state was captured from
+type SnapshotType = FFEnum
+
+var (
+	// SnapshotTypeAggregator is a snapshot of the aggregator, that sequences pins into messages
+	SnapshotTypeAggregator SnapshotType = ffEnum("snapshottype", "aggregator")
+	// SnapshotTypeEventDedup is a snapshot of the hashes of recently processed blockchain events
+	SnapshotTypeEventDedup SnapshotType = ffEnum("snapshottype", "eventdedup")
+)
+
+// Snapshot is a periodic record of the in-memory state of an event poller, alongside its committed offset.
+// Only the latest snapshot for each poller is kept, and it is restored on startup so that state - such as
+// contexts that are blocked waiting for earlier messages - does not need to be rebuilt by re-scanning.
+type Snapshot struct {
+	ID      *UUID        `json:"id"`
+	Type    SnapshotType `json:"type" ffenum:"snapshottype"`
+	Name    string       `json:"name"`
+	Offset  int64        `json:"offset"`
+	State   JSONObject   `json:"state,omitempty"`
+	Created *FFTime      `json:"created"`
+}
@@ -26,11 +26,67 @@ import (
 
 // SubscriptionFilter contains regular expressions to match against events. All must match for an event to be dispatched to a subscription
 type SubscriptionFilter struct {
-	Events string `json:"events,omitempty"`
-	Topics string `json:"topics,omitempty"`
-	Tag    string `json:"tag,omitempty"`
-	Group  string `json:"group,omitempty"`
-	Author string `json:"author,omitempty"`
+	Events string        `json:"events,omitempty"`
+	Topics string        `json:"topics,omitempty"`
+	Tag    string        `json:"tag,omitempty"`
+	Group  string        `json:"group,omitempty"`
+	Author string        `json:"author,omitempty"`
+	Custom CustomHeaders `json:"custom,omitempty"`
+
+	BlockchainEvent *BlockchainEventFilter `json:"blockchainEvent,omitempty"`
+	Operation       *OperationFilter       `json:"operation,omitempty"`
+}
+
+// BlockchainEventFilter contains regular expressions to match against the blockchain event referred to by a
+// blockchain_event event. Params are matched against the named fields of the event output
+type BlockchainEventFilter struct {
+	Address string            `json:"address,omitempty"`
+	Name    string            `json:"name,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// OperationFilter contains regular expressions to match against the operation referred to by an operation_updated
+// event. Status is matched against the status the operation transitioned to
+type OperationFilter struct {
+	Type   string `json:"type,omitempty"`
+	Plugin string `json:"plugin,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// Scan implements sql.Scanner
+func (bf *BlockchainEventFilter) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, bf)
+	case string:
+		return json.Unmarshal([]byte(src), bf)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, bf)
+	}
+}
+
+// Value implements sql.Valuer
+func (bf BlockchainEventFilter) Value() (driver.Value, error) {
+	b, err := json.Marshal(&bf)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (of *OperationFilter) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, of)
+	case string:
+		return json.Unmarshal([]byte(src), of)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, of)
+	}
+}
+
+// Value implements sql.Valuer
+func (of OperationFilter) Value() (driver.Value, error) {
+	b, err := json.Marshal(&of)
+	return string(b), err
 }
 
 // SubOptsFirstEvent picks the first event that should be dispatched on the subscription, and can be a string containing an exact sequence as well as one of the enum values
@@ -76,10 +132,30 @@ type Subscription struct {
 	Filter    SubscriptionFilter  `json:"filter"`
 	Options   SubscriptionOptions `json:"options"`
 	Ephemeral bool                `json:"ephemeral,omitempty"`
+	Owner     string              `json:"owner,omitempty"`
 	Created   *FFTime             `json:"created"`
 	Updated   *FFTime             `json:"updated"`
 }
 
+// subscriptionWriteOnlyOptions are transport options that are never returned once set, such as the secret
+// used to sign webhook requests
+var subscriptionWriteOnlyOptions = []string{"secret"}
+
+// Redacted returns a copy of the subscription to return from the API, without any write-only transport options
+func (s *Subscription) Redacted() *Subscription {
+	redacted := *s
+	if s.Options.additionalOptions != nil {
+		redacted.Options.additionalOptions = JSONObject{}
+		for k, v := range s.Options.additionalOptions {
+			redacted.Options.additionalOptions[k] = v
+		}
+		for _, k := range subscriptionWriteOnlyOptions {
+			delete(redacted.Options.additionalOptions, k)
+		}
+	}
+	return &redacted
+}
+
 func (so *SubscriptionOptions) UnmarshalJSON(b []byte) error {
 	so.additionalOptions = JSONObject{}
 	err := json.Unmarshal(b, &so.additionalOptions)
@@ -89,3 +89,73 @@ func TestSubscriptionUnMarshalFail(t *testing.T) {
 	assert.Regexp(t, "readAhead", err)
 
 }
+
+func TestSubscriptionRedacted(t *testing.T) {
+
+	sub := &Subscription{}
+	err := json.Unmarshal([]byte(`{"name":"sub1","options":{"url":"http://example.com","secret":"s3cr3t"}}`), sub)
+	assert.NoError(t, err)
+
+	redacted := sub.Redacted()
+	assert.Equal(t, "sub1", redacted.Name)
+	assert.Equal(t, "http://example.com", redacted.Options.TransportOptions().GetString("url"))
+	assert.NotContains(t, redacted.Options.TransportOptions(), "secret")
+	assert.Equal(t, "s3cr3t", sub.Options.TransportOptions().GetString("secret"))
+
+	assert.Equal(t, &Subscription{}, (&Subscription{}).Redacted())
+
+}
+
+func TestBlockchainEventFilterDatabaseSerialization(t *testing.T) {
+
+	bf := &BlockchainEventFilter{
+		Address: "^0x1234",
+		Name:    "Changed",
+		Params:  map[string]string{"from": "^0xabcd"},
+	}
+
+	v, err := bf.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"address":"^0x1234","name":"Changed","params":{"from":"^0xabcd"}}`, v)
+
+	var bf1 BlockchainEventFilter
+	err = bf1.Scan(v)
+	assert.NoError(t, err)
+	assert.Equal(t, *bf, bf1)
+
+	var bf2 BlockchainEventFilter
+	err = bf2.Scan([]byte(v.(string)))
+	assert.NoError(t, err)
+	assert.Equal(t, *bf, bf2)
+
+	err = bf2.Scan(12345)
+	assert.Regexp(t, "FF10125", err)
+
+}
+
+func TestOperationFilterDatabaseSerialization(t *testing.T) {
+
+	of := &OperationFilter{
+		Type:   "^dataexchange_",
+		Plugin: "https",
+		Status: "Failed",
+	}
+
+	v, err := of.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"^dataexchange_","plugin":"https","status":"Failed"}`, v)
+
+	var of1 OperationFilter
+	err = of1.Scan(v)
+	assert.NoError(t, err)
+	assert.Equal(t, *of, of1)
+
+	var of2 OperationFilter
+	err = of2.Scan([]byte(v.(string)))
+	assert.NoError(t, err)
+	assert.Equal(t, *of, of2)
+
+	err = of2.Scan(12345)
+	assert.Regexp(t, "FF10125", err)
+
+}
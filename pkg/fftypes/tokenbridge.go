@@ -0,0 +1,69 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// TokenBridgeStatus is the progress of a token bridge through its linked operations
+type TokenBridgeStatus = FFEnum
+
+var (
+	// TokenBridgeStatusPending the lock of tokens on the source pool has been submitted
+	TokenBridgeStatusPending TokenBridgeStatus = ffEnum("tokenbridgestatus", "pending")
+	// TokenBridgeStatusLocked the tokens are locked on the source pool, and the mint on the target pool has been submitted
+	TokenBridgeStatusLocked TokenBridgeStatus = ffEnum("tokenbridgestatus", "locked")
+	// TokenBridgeStatusCompleted the tokens are locked on the source pool, and minted on the target pool
+	TokenBridgeStatusCompleted TokenBridgeStatus = ffEnum("tokenbridgestatus", "completed")
+	// TokenBridgeStatusCompensating the mint failed, and the return of the locked tokens has been submitted
+	TokenBridgeStatusCompensating TokenBridgeStatus = ffEnum("tokenbridgestatus", "compensating")
+	// TokenBridgeStatusCompensated the mint failed, and the locked tokens have been returned
+	TokenBridgeStatusCompensated TokenBridgeStatus = ffEnum("tokenbridgestatus", "compensated")
+	// TokenBridgeStatusFailed the bridge failed, and could not be compensated automatically
+	TokenBridgeStatusFailed TokenBridgeStatus = ffEnum("tokenbridgestatus", "failed")
+)
+
+// TokenBridgeLeg is one side of a token bridge
+type TokenBridgeLeg struct {
+	Connector  string `json:"connector,omitempty"`
+	Pool       *UUID  `json:"pool,omitempty"`
+	TokenIndex string `json:"tokenIndex,omitempty"`
+}
+
+// TokenBridge locks tokens on a source pool, and mints the same amount on a target pool, which is
+// typically served by a different token connector (and chain). The linked operations all belong to
+// a single transaction, and a failed mint is compensated by returning the locked tokens.
+type TokenBridge struct {
+	ID        *UUID             `json:"id,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Key       string            `json:"key,omitempty"`
+	From      string            `json:"from,omitempty"`
+	To        string            `json:"to,omitempty"`
+	Escrow    string            `json:"escrow,omitempty"`
+	Amount    BigInt            `json:"amount"`
+	Source    TokenBridgeLeg    `json:"source"`
+	Target    TokenBridgeLeg    `json:"target"`
+	Status    TokenBridgeStatus `json:"status" ffenum:"tokenbridgestatus"`
+	Error     string            `json:"error,omitempty"`
+	TX        TransactionRef    `json:"tx,omitempty"`
+	Created   *FFTime           `json:"created,omitempty"`
+	Updated   *FFTime           `json:"updated,omitempty"`
+}
+
+// TokenBridgeInput is the request to bridge tokens, where the pools can be referred to by name or ID
+type TokenBridgeInput struct {
+	TokenBridge
+	SourcePool string `json:"sourcePool,omitempty"`
+	TargetPool string `json:"targetPool,omitempty"`
+}
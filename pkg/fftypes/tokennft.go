@@ -0,0 +1,30 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// TokenNFT is the current state of a single token index within a non-fungible pool
+type TokenNFT struct {
+	ID         *UUID   `json:"id,omitempty"`
+	Pool       *UUID   `json:"pool,omitempty"`
+	TokenIndex string  `json:"tokenIndex,omitempty"`
+	URI        string  `json:"uri,omitempty"`
+	Connector  string  `json:"connector,omitempty"`
+	Namespace  string  `json:"namespace,omitempty"`
+	Owner      string  `json:"owner,omitempty"`
+	Created    *FFTime `json:"created,omitempty"`
+	Updated    *FFTime `json:"updated,omitempty"`
+}
@@ -38,6 +38,26 @@ var (
 	TokenPoolStatePending TokenPoolState = ffEnum("tokenpoolstate", "pending")
 	// TokenPoolStateConfirmed is a token pool that has been confirmed on chain
 	TokenPoolStateConfirmed TokenPoolState = ffEnum("tokenpoolstate", "confirmed")
+	// TokenPoolStatePaused is a confirmed token pool whose contract has been paused on chain
+	TokenPoolStatePaused TokenPoolState = ffEnum("tokenpoolstate", "paused")
+	// TokenPoolStateDeactivated is a token pool whose contract has been permanently deactivated on chain
+	TokenPoolStateDeactivated TokenPoolState = ffEnum("tokenpoolstate", "deactivated")
+)
+
+// TokenPoolGovernanceAction is a governance action on the contract underlying a token pool, detected by the connector
+type TokenPoolGovernanceAction = FFEnum
+
+var (
+	// TokenPoolGovernanceDeactivated is the permanent deactivation of the contract
+	TokenPoolGovernanceDeactivated TokenPoolGovernanceAction = ffEnum("tokenpoolgovernanceaction", "deactivated")
+	// TokenPoolGovernancePaused is the pausing of all transfers in the contract
+	TokenPoolGovernancePaused TokenPoolGovernanceAction = ffEnum("tokenpoolgovernanceaction", "paused")
+	// TokenPoolGovernanceUnpaused is the resumption of transfers in a paused contract
+	TokenPoolGovernanceUnpaused TokenPoolGovernanceAction = ffEnum("tokenpoolgovernanceaction", "unpaused")
+	// TokenPoolGovernanceOwnershipTransferred is the transfer of ownership of the contract to a new key
+	TokenPoolGovernanceOwnershipTransferred TokenPoolGovernanceAction = ffEnum("tokenpoolgovernanceaction", "ownership_transferred")
+	// TokenPoolGovernanceConfigChanged is any other change to the configuration of the contract
+	TokenPoolGovernanceConfigChanged TokenPoolGovernanceAction = ffEnum("tokenpoolgovernanceaction", "config_changed")
 )
 
 type TokenPool struct {
@@ -55,6 +75,19 @@ type TokenPool struct {
 	Created    *FFTime        `json:"created,omitempty"`
 	Config     JSONObject     `json:"config,omitempty"` // for REST calls only (not stored)
 	TX         TransactionRef `json:"tx,omitempty"`
+
+	IdempotencyKey IdempotencyKey `json:"idempotencyKey,omitempty"` // for REST calls only (not stored)
+}
+
+// TokenPoolGovernance is a governance action on the contract underlying a token pool, made on chain by
+// the owner of the contract rather than submitted through FireFly
+type TokenPoolGovernance struct {
+	Action       TokenPoolGovernanceAction `json:"action" ffenum:"tokenpoolgovernanceaction"`
+	Pool         *UUID                     `json:"pool,omitempty"`
+	Connector    string                    `json:"connector,omitempty"`
+	Key          string                    `json:"key,omitempty"`
+	ProtocolTxID string                    `json:"protocolTxId,omitempty"`
+	Details      JSONObject                `json:"details,omitempty"`
 }
 
 type TokenPoolAnnouncement struct {
@@ -72,6 +105,29 @@ func (t *TokenPool) Validate(ctx context.Context) (err error) {
 	return nil
 }
 
+// Confirmed is true if the pool has been confirmed on chain, including if it has since been paused or deactivated
+func (t *TokenPool) Confirmed() bool {
+	return t.State == TokenPoolStateConfirmed || t.State == TokenPoolStatePaused || t.State == TokenPoolStateDeactivated
+}
+
+// ApplyGovernance returns the state of the pool after the governance action, which is unchanged
+// for actions that do not affect whether the pool can be used
+func (t *TokenPool) ApplyGovernance(action TokenPoolGovernanceAction) TokenPoolState {
+	switch action {
+	case TokenPoolGovernanceDeactivated:
+		return TokenPoolStateDeactivated
+	case TokenPoolGovernancePaused:
+		if t.State == TokenPoolStateConfirmed {
+			return TokenPoolStatePaused
+		}
+	case TokenPoolGovernanceUnpaused:
+		if t.State == TokenPoolStatePaused {
+			return TokenPoolStateConfirmed
+		}
+	}
+	return t.State
+}
+
 func (t *TokenPoolAnnouncement) Topic() string {
 	return namespaceTopic(t.Pool.Namespace)
 }
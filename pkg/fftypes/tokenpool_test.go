@@ -58,3 +58,26 @@ func TestTokenPoolDefinition(t *testing.T) {
 	def.SetBroadcastMessage(id)
 	assert.Equal(t, id, pool.Message)
 }
+
+func TestTokenPoolConfirmed(t *testing.T) {
+	assert.False(t, (&TokenPool{State: TokenPoolStatePending}).Confirmed())
+	assert.True(t, (&TokenPool{State: TokenPoolStateConfirmed}).Confirmed())
+	assert.True(t, (&TokenPool{State: TokenPoolStatePaused}).Confirmed())
+	assert.True(t, (&TokenPool{State: TokenPoolStateDeactivated}).Confirmed())
+}
+
+func TestTokenPoolApplyGovernance(t *testing.T) {
+	pool := &TokenPool{State: TokenPoolStateConfirmed}
+	assert.Equal(t, TokenPoolStateConfirmed, pool.ApplyGovernance(TokenPoolGovernanceUnpaused))
+	assert.Equal(t, TokenPoolStateConfirmed, pool.ApplyGovernance(TokenPoolGovernanceOwnershipTransferred))
+	assert.Equal(t, TokenPoolStatePaused, pool.ApplyGovernance(TokenPoolGovernancePaused))
+	assert.Equal(t, TokenPoolStateDeactivated, pool.ApplyGovernance(TokenPoolGovernanceDeactivated))
+
+	pool = &TokenPool{State: TokenPoolStatePaused}
+	assert.Equal(t, TokenPoolStatePaused, pool.ApplyGovernance(TokenPoolGovernancePaused))
+	assert.Equal(t, TokenPoolStateConfirmed, pool.ApplyGovernance(TokenPoolGovernanceUnpaused))
+	assert.Equal(t, TokenPoolStateDeactivated, pool.ApplyGovernance(TokenPoolGovernanceDeactivated))
+
+	pool = &TokenPool{State: TokenPoolStateDeactivated}
+	assert.Equal(t, TokenPoolStateDeactivated, pool.ApplyGovernance(TokenPoolGovernanceUnpaused))
+}
@@ -41,10 +41,15 @@ type TokenTransfer struct {
 	MessageHash *Bytes32          `json:"messageHash,omitempty"`
 	Created     *FFTime           `json:"created,omitempty"`
 	TX          TransactionRef    `json:"tx,omitempty"`
+
+	// PoolSequence is derived from the block number, transaction index and log index of the transfer, and sorts the
+	// confirmed transfers of a pool in the order they occurred on the blockchain (regardless of delivery order)
+	PoolSequence string `json:"poolSequence,omitempty"`
 }
 
 type TokenTransferInput struct {
 	TokenTransfer
-	Message *MessageInOut `json:"message,omitempty"`
-	Pool    string        `json:"pool,omitempty"`
+	Message        *MessageInOut  `json:"message,omitempty"`
+	Pool           string         `json:"pool,omitempty"`
+	IdempotencyKey IdempotencyKey `json:"idempotencyKey,omitempty"`
 }
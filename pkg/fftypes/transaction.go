@@ -17,7 +17,6 @@
 package fftypes
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 )
 
@@ -32,6 +31,14 @@ var (
 	TransactionTypeTokenPool TransactionType = ffEnum("txtype", "token_pool")
 	// TransactionTypeTokenTransfer represents a token transfer
 	TransactionTypeTokenTransfer TransactionType = ffEnum("txtype", "token_transfer")
+	// TransactionTypeTokenApproval represents a token approval
+	TransactionTypeTokenApproval TransactionType = ffEnum("txtype", "token_approval")
+	// TransactionTypeTokenBridge represents the linked operations that bridge tokens between two pools
+	TransactionTypeTokenBridge TransactionType = ffEnum("txtype", "token_bridge")
+	// TransactionTypeContractInvoke represents the invocation of a method on a custom smart contract
+	TransactionTypeContractInvoke TransactionType = ffEnum("txtype", "contract_invoke")
+	// TransactionTypeDataImport represents the server-side download and hashing of a blob to create a data item
+	TransactionTypeDataImport TransactionType = ffEnum("txtype", "data_import")
 )
 
 // TransactionRef refers to a transaction, in other types
@@ -50,8 +57,7 @@ type TransactionSubject struct {
 
 func (t *TransactionSubject) Hash() *Bytes32 {
 	b, _ := json.Marshal(&t)
-	var b32 Bytes32 = sha256.Sum256(b)
-	return &b32
+	return hashBytes(b)
 }
 
 // Transaction represents (blockchain) transactions that were submitted by this
@@ -65,4 +71,33 @@ type Transaction struct {
 	Status     OpStatus           `json:"status"`
 	ProtocolID string             `json:"protocolId,omitempty"`
 	Info       JSONObject         `json:"info,omitempty"`
+	Fee        *TransactionFee    `json:"fee,omitempty"`
+
+	IdempotencyKey IdempotencyKey `json:"idempotencyKey,omitempty"`
+}
+
+// TransactionFee is the cost of a blockchain transaction, as reported in its receipt. The amount is in the
+// smallest denomination of the native currency of the chain (such as wei), and is only set if the receipt
+// includes the gas price
+type TransactionFee struct {
+	GasUsed *BigInt `json:"gasUsed"`
+	Amount  *BigInt `json:"amount,omitempty"`
+}
+
+// TransactionCostReport summarizes the fees paid for the transactions of a namespace over a time range,
+// attributed to groups such as the signing identity, the transaction type, or the tag of the pinned messages
+type TransactionCostReport struct {
+	GroupBy      string                  `json:"groupBy"`
+	Transactions int64                   `json:"transactions"`
+	GasUsed      *BigInt                 `json:"gasUsed"`
+	Amount       *BigInt                 `json:"amount"`
+	Groups       []*TransactionCostGroup `json:"groups"`
+}
+
+// TransactionCostGroup is the share of the fees in a cost report attributed to one value of the grouping
+type TransactionCostGroup struct {
+	Value        string  `json:"value"`
+	Transactions int64   `json:"transactions"`
+	GasUsed      *BigInt `json:"gasUsed"`
+	Amount       *BigInt `json:"amount"`
 }
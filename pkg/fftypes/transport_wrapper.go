@@ -21,6 +21,7 @@ type TransportPayloadType = FFEnum
 var (
 	TransportPayloadTypeMessage TransportPayloadType = ffEnum("transportpayload", "message")
 	TransportPayloadTypeBatch   TransportPayloadType = ffEnum("transportpayload", "batch")
+	TransportPayloadTypeReceipt TransportPayloadType = ffEnum("transportpayload", "receipt")
 )
 
 // TransportWrapper wraps paylaods over data exchange transfers, for easy deserialization at target
@@ -30,4 +31,5 @@ type TransportWrapper struct {
 	Data    []*Data              `json:"data,omitempty"`
 	Batch   *Batch               `json:"batch,omitempty"`
 	Group   *Group               `json:"group,omitempty"`
+	Receipt *MessageReceipt      `json:"receipt,omitempty"`
 }
@@ -0,0 +1,37 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// UnmatchedReceipt records a receipt from a connector that could not be correlated to an operation submitted by this
+// node, because the request ID it carried was not the ID of a known operation. Receipts are quarantined here rather than
+// dropped, so an operator can reconcile them against the correct operation.
+type UnmatchedReceipt struct {
+	ID         *UUID      `json:"id"`
+	Plugin     string     `json:"plugin"`
+	RequestID  string     `json:"requestId"`
+	Status     OpStatus   `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Output     JSONObject `json:"output,omitempty"`
+	Created    *FFTime    `json:"created,omitempty"`
+	Operation  *UUID      `json:"operation,omitempty"`
+	Reconciled *FFTime    `json:"reconciled,omitempty"`
+}
+
+// UnmatchedReceiptReconcile is the input to reconcile an unmatched receipt, by applying it to the given operation
+type UnmatchedReceiptReconcile struct {
+	Operation *UUID `json:"operation"`
+}
@@ -18,7 +18,11 @@ package fftypes
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql/driver"
+	"encoding/binary"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/firefly/internal/i18n"
@@ -27,6 +31,27 @@ import (
 // UUID is a wrapper on a UUID implementation, ensuring Value handles nil
 type UUID uuid.UUID
 
+// UUIDVersion is the version of UUID generated for new objects
+type UUIDVersion = FFEnum
+
+var (
+	// UUIDVersion4 is a random UUID
+	UUIDVersion4 UUIDVersion = ffEnum("uuidversion", "v4")
+	// UUIDVersion7 is a time-ordered UUID, so that new rows are appended to the end of database indexes
+	UUIDVersion7 UUIDVersion = ffEnum("uuidversion", "v7")
+)
+
+// DefaultUUIDVersion is the version used by NewUUID. UUIDs of any version are accepted on input,
+// so objects created with v4 IDs remain valid when this is switched to v7 (and vice versa)
+var DefaultUUIDVersion = UUIDVersion4
+
+var uuidV7State struct {
+	mux    sync.Mutex
+	lastMS int64
+	seq    uint16
+}
+
+// ParseUUID parses a UUID of any version
 func ParseUUID(ctx context.Context, uuidStr string) (*UUID, error) {
 	u, err := uuid.Parse(uuidStr)
 	if err != nil {
@@ -42,10 +67,64 @@ func MustParseUUID(uuidStr string) *UUID {
 }
 
 func NewUUID() *UUID {
-	u := UUID(uuid.New())
+	var u UUID
+	if DefaultUUIDVersion == UUIDVersion7 {
+		u = newUUIDv7()
+	} else {
+		u = UUID(uuid.New())
+	}
 	return &u
 }
 
+// newUUIDv7 generates a UUID as defined in RFC 9562, with a 48 bit millisecond timestamp. The 12 bit rand_a field
+// holds a counter that starts at a random value each millisecond, so the UUIDs generated by this process are
+// strictly ordered - moving on to the next millisecond if the counter is exhausted.
+func newUUIDv7() UUID {
+	var u UUID
+	_, _ = rand.Read(u[:])
+
+	uuidV7State.mux.Lock()
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+	if ms > uuidV7State.lastMS {
+		uuidV7State.lastMS = ms
+		// Start in the lower half of the range, to leave room for the counter to increment
+		uuidV7State.seq = binary.BigEndian.Uint16(u[6:8]) & 0x07ff
+	} else {
+		uuidV7State.seq++
+		if uuidV7State.seq > 0x0fff {
+			uuidV7State.lastMS++
+			uuidV7State.seq = 0
+		}
+	}
+	ms, seq := uuidV7State.lastMS, uuidV7State.seq
+	uuidV7State.mux.Unlock()
+
+	var msBytes [8]byte
+	binary.BigEndian.PutUint64(msBytes[:], uint64(ms))
+	copy(u[0:6], msBytes[2:8])
+	u[6] = 0x70 | byte(seq>>8)
+	u[7] = byte(seq)
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return u
+}
+
+// Version returns the version number of the UUID
+func (u *UUID) Version() int {
+	return int((*uuid.UUID)(u).Version())
+}
+
+// Time returns the creation time embedded in a v7 UUID, or nil for any other version
+func (u *UUID) Time() *FFTime {
+	if u.Version() != 7 {
+		return nil
+	}
+	var msBytes [8]byte
+	copy(msBytes[2:8], u[0:6])
+	ms := int64(binary.BigEndian.Uint64(msBytes[:]))
+	t := FFTime(time.Unix(0, ms*int64(time.Millisecond)))
+	return &t
+}
+
 func (u *UUID) String() string {
 	if u == nil {
 		return ""
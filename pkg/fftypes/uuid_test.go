@@ -17,7 +17,9 @@ package fftypes
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -73,3 +75,51 @@ func TestSafeEquals(t *testing.T) {
 	assert.False(t, u1.Equals(u2))
 
 }
+
+func TestNewUUIDv7Ordered(t *testing.T) {
+	DefaultUUIDVersion = UUIDVersion7
+	defer func() { DefaultUUIDVersion = UUIDVersion4 }()
+
+	before := time.Now().Truncate(time.Millisecond)
+	var last *UUID
+	for i := 0; i < 10000; i++ {
+		u := NewUUID()
+		assert.Equal(t, 7, u.Version())
+		assert.Equal(t, uuid.RFC4122, uuid.UUID(*u).Variant())
+		if last != nil {
+			assert.Less(t, last.String(), u.String())
+		}
+		last = u
+	}
+	created := time.Time(*last.Time())
+	assert.False(t, created.Before(before))
+
+	// v7 IDs parse and serialize just the same as v4 IDs
+	u, err := ParseUUID(context.Background(), last.String())
+	assert.NoError(t, err)
+	assert.Equal(t, *last, *u)
+}
+
+func TestNewUUIDv7CounterExhausted(t *testing.T) {
+	future := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	uuidV7State.mux.Lock()
+	uuidV7State.lastMS = future
+	uuidV7State.seq = 0x0fff
+	uuidV7State.mux.Unlock()
+	defer func() {
+		uuidV7State.mux.Lock()
+		uuidV7State.lastMS = 0
+		uuidV7State.mux.Unlock()
+	}()
+
+	u := newUUIDv7()
+	assert.Equal(t, future+1, time.Time(*u.Time()).UnixNano()/int64(time.Millisecond))
+	assert.Equal(t, byte(0x70), u[6])
+	assert.Equal(t, byte(0x00), u[7])
+}
+
+func TestUUIDTimeNotV7(t *testing.T) {
+	u := MustParseUUID("03D31DFB-9DBB-43F2-9E0B-84DD3D293499")
+	assert.Equal(t, 4, u.Version())
+	assert.Nil(t, u.Time())
+}
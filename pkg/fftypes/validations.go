@@ -29,18 +29,18 @@ var (
 
 func ValidateFFNameField(ctx context.Context, str string, fieldName string) error {
 	if !ffNameValidator.MatchString(str) {
-		return i18n.NewError(ctx, i18n.MsgInvalidName, fieldName)
+		return i18n.NewFieldError(ctx, fieldName, i18n.MsgInvalidName, fieldName)
 	}
 	if _, err := ParseUUID(ctx, str); err == nil {
 		// Name must not be a UUID
-		return i18n.NewError(ctx, i18n.MsgNoUUID, fieldName)
+		return i18n.NewFieldError(ctx, fieldName, i18n.MsgNoUUID, fieldName)
 	}
 	return nil
 }
 
 func ValidateLength(ctx context.Context, str string, fieldName string, max int) error {
 	if len([]byte(str)) > max {
-		return i18n.NewError(ctx, i18n.MsgFieldTooLong, fieldName, max)
+		return i18n.NewFieldError(ctx, fieldName, i18n.MsgFieldTooLong, fieldName, max)
 	}
 	return nil
 }
@@ -70,3 +70,19 @@ type WSChangeNotification struct {
 
 	ChangeEvent *ChangeEvent `json:"change"`
 }
+
+// WSEventDeliveryWithData is sent in place of an event for a subscription with data enabled. The content of each blob
+// listed is streamed in binary frames straight after the event, one blob after another in the order listed. Each frame
+// starts with the 16 byte ID of the data the blob is attached to, then a flags byte (1 on the final frame of the blob)
+type WSEventDeliveryWithData struct {
+	*EventDelivery
+	Data  []*Data         `json:"data,omitempty"`
+	Blobs []*WSBlobStream `json:"blobs,omitempty"`
+}
+
+// WSBlobStream announces a blob that will be streamed in binary frames after an event
+type WSBlobStream struct {
+	Data *UUID    `json:"data"`
+	Hash *Bytes32 `json:"hash"`
+	Size int64    `json:"size,omitempty"`
+}
@@ -0,0 +1,48 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// WebSocketStatus is the status of all websocket connections currently connected to the node
+type WebSocketStatus struct {
+	Connections []*WSConnectionStatus `json:"connections"`
+}
+
+// WSConnectionStatus is the information about a single websocket connection
+type WSConnectionStatus struct {
+	ID            string                  `json:"id"`
+	RemoteAddress string                  `json:"remoteAddress"`
+	UserAgent     string                  `json:"userAgent,omitempty"`
+	Identity      string                  `json:"identity,omitempty"`
+	Connected     *FFTime                 `json:"connected"`
+	AutoAck       bool                    `json:"autoack"`
+	Encoding      string                  `json:"encoding"`
+	Subscriptions []*WSSubscriptionStatus `json:"subscriptions"`
+	Inflight      []*WSInflightEvent      `json:"inflight"`
+}
+
+// WSSubscriptionStatus is a subscription that has been started on a websocket connection
+type WSSubscriptionStatus struct {
+	Ephemeral bool   `json:"ephemeral"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+}
+
+// WSInflightEvent is an event that has been delivered on a websocket connection, but not yet acknowledged
+type WSInflightEvent struct {
+	ID           *UUID           `json:"id"`
+	Subscription SubscriptionRef `json:"subscription"`
+}
@@ -43,10 +43,23 @@ type Plugin interface {
 
 	// RetrieveData reads data back from IPFS using the payload reference format returned from PublishData
 	RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error)
+
+	// PinData asynchronously requests the data is pinned at any remote pinning services configured for the plugin.
+	// Only called when the Pinning capability is set. The outcome is reported via PublicStorageOpUpdate
+	PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string)
 }
 
+// Callbacks is the interface provided to the Public Storage plugin, to allow it to pass events back to firefly.
 type Callbacks interface {
+	// PublicStorageOpUpdate notifies firefly of an update to an asynchronous operation, such as pinning.
+	// Only success/failure and errorMessage (for errors) are modeled.
+	// opOutput can be used to add opaque protocol specific JSON from the plugin (output from the pinning services etc.)
+	//
+	// Error will only be returned in shutdown scenarios
+	PublicStorageOpUpdate(plugin Plugin, operationID *fftypes.UUID, txState fftypes.OpStatus, errorMessage string, opOutput fftypes.JSONObject) error
 }
 
 type Capabilities struct {
+	// Pinning is set when the plugin is configured to pin published data at remote pinning services
+	Pinning bool
 }
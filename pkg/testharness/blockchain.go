@@ -0,0 +1,190 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Blockchain is an in-memory blockchain plugin. Batch pins submitted by the local node are recorded,
+// and confirmed in submission order unless the harness was created with ManualConfirm.
+type Blockchain struct {
+	events        *eventQueue
+	recorder      *recorder
+	publicstorage *PublicStorage
+	autoConfirm   bool
+	callbacks     blockchain.Callbacks
+	mux           sync.Mutex
+	txCount       int
+}
+
+// BatchPinEvent is a batch pin confirmed on the blockchain. For broadcast batches the payload
+// is included, so the batch can be retrieved from public storage by the receiving node.
+type BatchPinEvent struct {
+	BatchPin       *blockchain.BatchPin `json:"batchPin"`
+	SigningKey     string               `json:"signingKey"`
+	ProtocolTxID   string               `json:"protocolTxId"`
+	AdditionalInfo fftypes.JSONObject   `json:"additionalInfo,omitempty"`
+	Payload        []byte               `json:"payload,omitempty"`
+}
+
+func newBlockchain(events *eventQueue, recorder *recorder, ps *PublicStorage, autoConfirm bool) *Blockchain {
+	return &Blockchain{
+		events:        events,
+		recorder:      recorder,
+		publicstorage: ps,
+		autoConfirm:   autoConfirm,
+	}
+}
+
+func (bc *Blockchain) Name() string {
+	return "harness"
+}
+
+func (bc *Blockchain) InitPrefix(prefix config.Prefix) {}
+
+func (bc *Blockchain) Init(ctx context.Context, prefix config.Prefix, callbacks blockchain.Callbacks) error {
+	bc.callbacks = callbacks
+	return nil
+}
+
+func (bc *Blockchain) Start() error {
+	return nil
+}
+
+func (bc *Blockchain) Capabilities() *blockchain.Capabilities {
+	return &blockchain.Capabilities{
+		GlobalSequencer:     true,
+		BatchPinAggregation: true,
+	}
+}
+
+func (bc *Blockchain) ResolveSigningKey(ctx context.Context, ns, signingKey string) (string, error) {
+	return signingKey, nil
+}
+
+func (bc *Blockchain) GetNativeBalance(ctx context.Context, ns, signingKey string) (*fftypes.BigInt, error) {
+	// The in-memory chain has no gas
+	return nil, nil
+}
+
+// ResetEventStream does nothing, as the in-memory chain keeps no history of blocks to replay.
+// Tests can redeliver recorded events with BatchPinComplete instead
+func (bc *Blockchain) ResetEventStream(ctx context.Context, fromBlock string) error {
+	return nil
+}
+
+func (bc *Blockchain) GetTransactionFee(ns string, receipt fftypes.JSONObject) *fftypes.TransactionFee {
+	return nil
+}
+
+func (bc *Blockchain) SubmitBatchPin(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batch *blockchain.BatchPin) error {
+	return bc.SubmitBatchPins(ctx, operationID, ledgerID, signingKey, []*blockchain.BatchPin{batch})
+}
+
+// SubmitBatchPins records all the batch pins in a single transaction, each confirmed as a separate event
+func (bc *Blockchain) SubmitBatchPins(ctx context.Context, operationID *fftypes.UUID, ledgerID *fftypes.UUID, signingKey string, batches []*blockchain.BatchPin) error {
+	bc.mux.Lock()
+	bc.txCount++
+	protocolTxID := fmt.Sprintf("0x%064x", bc.txCount)
+	bc.mux.Unlock()
+
+	events := make([]*BatchPinEvent, len(batches))
+	for i, batch := range batches {
+		event := &BatchPinEvent{
+			BatchPin:     batch,
+			SigningKey:   signingKey,
+			ProtocolTxID: protocolTxID,
+		}
+		if batch.BatchPaylodRef != "" {
+			event.Payload = bc.publicstorage.Get(batch.BatchPaylodRef)
+		}
+		bc.recorder.record(&Step{Type: StepBatchPin, BatchPin: event})
+		events[i] = event
+	}
+
+	if bc.autoConfirm {
+		// Delivered once the submitting database transaction has completed
+		bc.events.post(func() error {
+			if err := bc.callbacks.BlockchainOpUpdate(operationID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{
+				"transactionHash": protocolTxID,
+			}); err != nil {
+				return err
+			}
+			for _, event := range events {
+				if err := bc.batchPinComplete(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil)
+	}
+	return nil
+}
+
+// InvokeContract records no state, as the in-memory chain has no contracts, but the operation
+// is confirmed as successful in the same way as a batch pin
+func (bc *Blockchain) InvokeContract(ctx context.Context, ns string, operationID *fftypes.UUID, signingKey string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) error {
+	bc.mux.Lock()
+	bc.txCount++
+	protocolTxID := fmt.Sprintf("0x%064x", bc.txCount)
+	bc.mux.Unlock()
+
+	if bc.autoConfirm {
+		bc.events.post(func() error {
+			return bc.callbacks.BlockchainOpUpdate(operationID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{
+				"transactionHash": protocolTxID,
+			})
+		}, nil)
+	}
+	return nil
+}
+
+func (bc *Blockchain) QueryContract(ctx context.Context, ns string, location fftypes.JSONObject, method *fftypes.FFIMethod, input fftypes.JSONObject) (interface{}, error) {
+	// The in-memory chain has no contract state
+	return fftypes.JSONObject{}, nil
+}
+
+func (bc *Blockchain) NormalizeContractLocation(ctx context.Context, ns string, location fftypes.JSONObject) (fftypes.JSONObject, error) {
+	return location, nil
+}
+
+func (bc *Blockchain) AddContractListener(ctx context.Context, listener *fftypes.ContractListener) error {
+	listener.ProtocolID = listener.ID.String()
+	return nil
+}
+
+// BatchPinComplete delivers a batch pin event as if it had been confirmed on the blockchain,
+// and waits for the orchestrator to process it
+func (bc *Blockchain) BatchPinComplete(ctx context.Context, event *BatchPinEvent) error {
+	return bc.events.dispatch(ctx, func() error {
+		return bc.batchPinComplete(event)
+	})
+}
+
+func (bc *Blockchain) batchPinComplete(event *BatchPinEvent) error {
+	if event.Payload != nil {
+		bc.publicstorage.store(event.BatchPin.BatchPaylodRef, event.Payload)
+	}
+	return bc.callbacks.BatchPinComplete(event.BatchPin, event.SigningKey, event.ProtocolTxID, event.AdditionalInfo)
+}
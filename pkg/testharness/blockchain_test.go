@@ -0,0 +1,111 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestBlockchain(t *testing.T, autoConfirm bool) (*Blockchain, *blockchainmocks.Callbacks) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	q := newEventQueue()
+	go q.run(ctx)
+	bc := newBlockchain(q, &recorder{}, newPublicStorage(), autoConfirm)
+	mcb := &blockchainmocks.Callbacks{}
+	bc.InitPrefix(nil)
+	assert.NoError(t, bc.Init(ctx, nil, mcb))
+	assert.NoError(t, bc.Start())
+	assert.True(t, bc.Capabilities().GlobalSequencer)
+	assert.Equal(t, "harness", bc.Name())
+	return bc, mcb
+}
+
+func TestSubmitBatchPinManualConfirm(t *testing.T) {
+	bc, mcb := newTestBlockchain(t, false)
+	ctx := context.Background()
+
+	key, err := bc.ResolveSigningKey(ctx, "ns1", "0x12345")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x12345", key)
+
+	balance, err := bc.GetNativeBalance(ctx, "ns1", "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, balance)
+	assert.Nil(t, bc.GetTransactionFee("ns1", fftypes.JSONObject{}))
+	assert.NoError(t, bc.ResetEventStream(ctx, "0"))
+
+	bc.publicstorage.store("ref1", []byte("batch"))
+	pin := &blockchain.BatchPin{BatchID: fftypes.NewUUID(), BatchPaylodRef: "ref1"}
+	err = bc.SubmitBatchPin(ctx, fftypes.NewUUID(), nil, "0x12345", pin)
+	assert.NoError(t, err)
+
+	steps := bc.recorder.recorded()
+	assert.Len(t, steps, 1)
+	event := steps[0].BatchPin
+	assert.Equal(t, "batch", string(event.Payload))
+	assert.Equal(t, fmt.Sprintf("0x%064x", 1), event.ProtocolTxID)
+
+	// Confirm the pin into a node that does not have the payload yet
+	bc.publicstorage = newPublicStorage()
+	mcb.On("BatchPinComplete", pin, "0x12345", event.ProtocolTxID, fftypes.JSONObject(nil)).Return(nil)
+	err = bc.BatchPinComplete(ctx, event)
+	assert.NoError(t, err)
+	assert.Equal(t, "batch", string(bc.publicstorage.Get("ref1")))
+	mcb.AssertExpectations(t)
+}
+
+func TestSubmitBatchPinAutoConfirmOpUpdateFail(t *testing.T) {
+	bc, mcb := newTestBlockchain(t, true)
+	opID := fftypes.NewUUID()
+	done := make(chan struct{})
+	mcb.On("BlockchainOpUpdate", opID, fftypes.OpStatusSucceeded, "", mock.Anything).
+		Run(func(args mock.Arguments) { close(done) }).
+		Return(fmt.Errorf("pop"))
+	err := bc.SubmitBatchPin(context.Background(), opID, nil, "0x12345", &blockchain.BatchPin{})
+	assert.NoError(t, err)
+	<-done
+	mcb.AssertExpectations(t)
+}
+
+func TestSubmitBatchPinsAutoConfirm(t *testing.T) {
+	bc, mcb := newTestBlockchain(t, true)
+	assert.True(t, bc.Capabilities().BatchPinAggregation)
+	opID := fftypes.NewUUID()
+	pin1 := &blockchain.BatchPin{Namespace: "ns1", BatchID: fftypes.NewUUID()}
+	pin2 := &blockchain.BatchPin{Namespace: "ns2", BatchID: fftypes.NewUUID()}
+	done := make(chan struct{})
+	protocolTxID := fmt.Sprintf("0x%064x", 1)
+	mcb.On("BlockchainOpUpdate", opID, fftypes.OpStatusSucceeded, "", fftypes.JSONObject{"transactionHash": protocolTxID}).Return(nil)
+	mcb.On("BatchPinComplete", pin1, "0x12345", protocolTxID, fftypes.JSONObject(nil)).Return(nil)
+	mcb.On("BatchPinComplete", pin2, "0x12345", protocolTxID, fftypes.JSONObject(nil)).
+		Run(func(args mock.Arguments) { close(done) }).
+		Return(fmt.Errorf("pop"))
+	err := bc.SubmitBatchPins(context.Background(), opID, nil, "0x12345", []*blockchain.BatchPin{pin1, pin2})
+	assert.NoError(t, err)
+	<-done
+	assert.Len(t, bc.recorder.recorded(), 2)
+	mcb.AssertExpectations(t)
+}
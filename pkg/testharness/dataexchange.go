@@ -0,0 +1,216 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/dataexchange"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// DataExchange is an in-memory data exchange plugin. Messages and BLOBs sent by the local node are
+// recorded, and their transfers reported as successful.
+type DataExchange struct {
+	events    *eventQueue
+	recorder  *recorder
+	peerID    string
+	callbacks dataexchange.Callbacks
+	mux       sync.Mutex
+	blobs     map[string][]byte
+	peers     map[string]fftypes.JSONObject
+}
+
+// DXMessage is a message delivered between peers by data exchange
+type DXMessage struct {
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Data      []byte `json:"data"`
+}
+
+// DXBLOB is a BLOB transferred between peers by data exchange
+type DXBLOB struct {
+	Sender    string       `json:"sender"`
+	Recipient string       `json:"recipient"`
+	Namespace string       `json:"namespace"`
+	ID        fftypes.UUID `json:"id"`
+	Content   []byte       `json:"content"`
+}
+
+func newDataExchange(events *eventQueue, recorder *recorder, peerID string) *DataExchange {
+	return &DataExchange{
+		events:   events,
+		recorder: recorder,
+		peerID:   peerID,
+		blobs:    make(map[string][]byte),
+		peers:    make(map[string]fftypes.JSONObject),
+	}
+}
+
+func (dx *DataExchange) Name() string {
+	return "harness"
+}
+
+func (dx *DataExchange) InitPrefix(prefix config.Prefix) {}
+
+func (dx *DataExchange) Init(ctx context.Context, prefix config.Prefix, callbacks dataexchange.Callbacks) error {
+	dx.callbacks = callbacks
+	return nil
+}
+
+func (dx *DataExchange) Start() error {
+	return nil
+}
+
+func (dx *DataExchange) Capabilities() *dataexchange.Capabilities {
+	return &dataexchange.Capabilities{}
+}
+
+func (dx *DataExchange) GetEndpointInfo(ctx context.Context) (peerID string, endpoint fftypes.JSONObject, err error) {
+	return dx.peerID, fftypes.JSONObject{
+		"id":       dx.peerID,
+		"endpoint": "harness://" + dx.peerID,
+	}, nil
+}
+
+func (dx *DataExchange) AddPeer(ctx context.Context, peerID string, endpoint fftypes.JSONObject) (err error) {
+	dx.mux.Lock()
+	defer dx.mux.Unlock()
+	dx.peers[peerID] = endpoint
+	return nil
+}
+
+// Peers returns the endpoints of the peers added by the orchestrator
+func (dx *DataExchange) Peers() map[string]fftypes.JSONObject {
+	dx.mux.Lock()
+	defer dx.mux.Unlock()
+	peers := make(map[string]fftypes.JSONObject, len(dx.peers))
+	for k, v := range dx.peers {
+		peers[k] = v
+	}
+	return peers
+}
+
+func (dx *DataExchange) UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, content io.Reader) (payloadRef string, hash *fftypes.Bytes32, err error) {
+	b, err := ioutil.ReadAll(content)
+	if err != nil {
+		return "", nil, err
+	}
+	payloadRef = fmt.Sprintf("%s/%s", ns, &id)
+	return payloadRef, dx.storeBLOB(payloadRef, b), nil
+}
+
+func (dx *DataExchange) IsLocalBLOB(ns, payloadRef string) bool {
+	return strings.HasPrefix(payloadRef, ns+"/") && strings.Count(payloadRef, "/") == 1
+}
+
+func (dx *DataExchange) DownloadBLOB(ctx context.Context, payloadRef string) (content io.ReadCloser, err error) {
+	dx.mux.Lock()
+	b, ok := dx.blobs[payloadRef]
+	dx.mux.Unlock()
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgHarnessPayloadNotFound, payloadRef)
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (dx *DataExchange) CheckBLOBReceived(ctx context.Context, peerID, ns string, id fftypes.UUID) (hash *fftypes.Bytes32, err error) {
+	dx.mux.Lock()
+	defer dx.mux.Unlock()
+	b, ok := dx.blobs[fmt.Sprintf("%s/%s/%s", peerID, ns, &id)]
+	if !ok {
+		return nil, nil
+	}
+	h := fftypes.Bytes32(sha256.Sum256(b))
+	return &h, nil
+}
+
+func (dx *DataExchange) SendMessage(ctx context.Context, opID *fftypes.UUID, peerID string, data []byte) error {
+	dx.recorder.record(&Step{Type: StepDXMessage, DXMessage: &DXMessage{
+		Sender:    dx.peerID,
+		Recipient: peerID,
+		Data:      data,
+	}})
+	dx.transferSucceeded(opID.String())
+	return nil
+}
+
+func (dx *DataExchange) TransferBLOB(ctx context.Context, opID *fftypes.UUID, peerID string, payloadRef string) error {
+	parts := strings.Split(payloadRef, "/")
+	var id *fftypes.UUID
+	if len(parts) == 2 {
+		id, _ = fftypes.ParseUUID(ctx, parts[1])
+	}
+	if id == nil {
+		return i18n.NewError(ctx, i18n.MsgHarnessInvalidPayloadRef, payloadRef)
+	}
+	dx.mux.Lock()
+	b, ok := dx.blobs[payloadRef]
+	dx.mux.Unlock()
+	if !ok {
+		return i18n.NewError(ctx, i18n.MsgHarnessPayloadNotFound, payloadRef)
+	}
+	dx.recorder.record(&Step{Type: StepDXBLOB, DXBLOB: &DXBLOB{
+		Sender:    dx.peerID,
+		Recipient: peerID,
+		Namespace: parts[0],
+		ID:        *id,
+		Content:   b,
+	}})
+	dx.transferSucceeded(opID.String())
+	return nil
+}
+
+// MessageReceived delivers a message from another peer, and waits for the orchestrator to process it
+func (dx *DataExchange) MessageReceived(ctx context.Context, msg *DXMessage) error {
+	return dx.events.dispatch(ctx, func() error {
+		return dx.callbacks.MessageReceived(msg.Sender, msg.Data)
+	})
+}
+
+// BLOBReceived delivers a BLOB from another peer, and waits for the orchestrator to process it
+func (dx *DataExchange) BLOBReceived(ctx context.Context, blob *DXBLOB) error {
+	payloadRef := fmt.Sprintf("%s/%s/%s", blob.Sender, blob.Namespace, &blob.ID)
+	hash := dx.storeBLOB(payloadRef, blob.Content)
+	return dx.events.dispatch(ctx, func() error {
+		return dx.callbacks.BLOBReceived(blob.Sender, *hash, payloadRef)
+	})
+}
+
+func (dx *DataExchange) storeBLOB(payloadRef string, b []byte) *fftypes.Bytes32 {
+	dx.mux.Lock()
+	defer dx.mux.Unlock()
+	dx.blobs[payloadRef] = b
+	hash := fftypes.Bytes32(sha256.Sum256(b))
+	return &hash
+}
+
+func (dx *DataExchange) transferSucceeded(trackingID string) {
+	// Delivered once the sending database transaction has completed
+	dx.events.post(func() error {
+		return dx.callbacks.TransferResult(trackingID, fftypes.OpStatusSucceeded, "", nil)
+	}, nil)
+}
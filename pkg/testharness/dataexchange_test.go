@@ -0,0 +1,140 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hyperledger/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDataExchange(t *testing.T) (*DataExchange, *dataexchangemocks.Callbacks) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	q := newEventQueue()
+	go q.run(ctx)
+	dx := newDataExchange(q, &recorder{}, "node0")
+	mcb := &dataexchangemocks.Callbacks{}
+	dx.InitPrefix(nil)
+	assert.NoError(t, dx.Init(ctx, nil, mcb))
+	assert.NoError(t, dx.Start())
+	assert.NotNil(t, dx.Capabilities())
+	assert.Equal(t, "harness", dx.Name())
+	return dx, mcb
+}
+
+func TestDXBLOBs(t *testing.T) {
+	dx, mcb := newTestDataExchange(t)
+	ctx := context.Background()
+	id := fftypes.NewUUID()
+
+	payloadRef, hash, err := dx.UploadBLOB(ctx, "ns1", *id, bytes.NewReader([]byte("blob")))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("ns1/%s", id), payloadRef)
+	assert.Equal(t, fftypes.Bytes32(sha256.Sum256([]byte("blob"))), *hash)
+	assert.True(t, dx.IsLocalBLOB("ns1", payloadRef))
+	assert.False(t, dx.IsLocalBLOB("ns2", payloadRef))
+	assert.False(t, dx.IsLocalBLOB("node1", fmt.Sprintf("node1/ns1/%s", id)))
+
+	r, err := dx.DownloadBLOB(ctx, payloadRef)
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "blob", string(b))
+
+	transferred := make(chan struct{})
+	opID := fftypes.NewUUID()
+	mcb.On("TransferResult", opID.String(), fftypes.OpStatusSucceeded, "", fftypes.JSONObject(nil)).
+		Run(func(args mock.Arguments) { close(transferred) }).
+		Return(nil)
+	err = dx.TransferBLOB(ctx, opID, "node1", payloadRef)
+	assert.NoError(t, err)
+	<-transferred
+	steps := dx.recorder.recorded()
+	assert.Equal(t, StepDXBLOB, steps[0].Type)
+	assert.Equal(t, "node1", steps[0].DXBLOB.Recipient)
+	assert.Equal(t, "ns1", steps[0].DXBLOB.Namespace)
+	assert.Equal(t, *id, steps[0].DXBLOB.ID)
+
+	// Deliver the BLOB back to ourselves, as if from another node
+	received := &DXBLOB{Sender: "node1", Namespace: "ns1", ID: *id, Content: []byte("blob")}
+	hash, err = dx.CheckBLOBReceived(ctx, "node1", "ns1", *id)
+	assert.NoError(t, err)
+	assert.Nil(t, hash)
+	mcb.On("BLOBReceived", "node1", fftypes.Bytes32(sha256.Sum256([]byte("blob"))), fmt.Sprintf("node1/ns1/%s", id)).Return(nil)
+	err = dx.BLOBReceived(ctx, received)
+	assert.NoError(t, err)
+	hash, err = dx.CheckBLOBReceived(ctx, "node1", "ns1", *id)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.Bytes32(sha256.Sum256([]byte("blob"))), *hash)
+
+	mcb.AssertExpectations(t)
+}
+
+func TestDXBLOBErrors(t *testing.T) {
+	dx, _ := newTestDataExchange(t)
+	ctx := context.Background()
+
+	_, _, err := dx.UploadBLOB(ctx, "ns1", *fftypes.NewUUID(), badReader{})
+	assert.Regexp(t, "pop", err)
+
+	_, err = dx.DownloadBLOB(ctx, "ns1/unknown")
+	assert.Regexp(t, "FF10332", err)
+
+	err = dx.TransferBLOB(ctx, fftypes.NewUUID(), "node1", "bad")
+	assert.Regexp(t, "FF10333", err)
+
+	err = dx.TransferBLOB(ctx, fftypes.NewUUID(), "node1", "ns1/not-a-uuid")
+	assert.Regexp(t, "FF10333", err)
+
+	err = dx.TransferBLOB(ctx, fftypes.NewUUID(), "node1", fmt.Sprintf("ns1/%s", fftypes.NewUUID()))
+	assert.Regexp(t, "FF10332", err)
+}
+
+func TestDXMessages(t *testing.T) {
+	dx, mcb := newTestDataExchange(t)
+	ctx := context.Background()
+
+	peerID, endpoint, err := dx.GetEndpointInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "node0", peerID)
+	assert.Equal(t, "harness://node0", endpoint.GetString("endpoint"))
+
+	err = dx.AddPeer(ctx, "node1", fftypes.JSONObject{"id": "node1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "node1", dx.Peers()["node1"].GetString("id"))
+
+	opID := fftypes.NewUUID()
+	mcb.On("TransferResult", opID.String(), fftypes.OpStatusSucceeded, "", fftypes.JSONObject(nil)).Return(nil)
+	mcb.On("MessageReceived", "node1", []byte("reply")).Return(nil)
+	err = dx.SendMessage(ctx, opID, "node1", []byte("hello"))
+	assert.NoError(t, err)
+	err = dx.MessageReceived(ctx, &DXMessage{Sender: "node1", Recipient: "node0", Data: []byte("reply")})
+	assert.NoError(t, err)
+
+	steps := dx.recorder.recorded()
+	assert.Equal(t, StepDXMessage, steps[0].Type)
+	assert.Equal(t, "hello", string(steps[0].DXMessage.Data))
+	mcb.AssertExpectations(t)
+}
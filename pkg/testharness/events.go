@@ -0,0 +1,97 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/log"
+)
+
+// eventQueue delivers plugin callbacks to the orchestrator one at a time, in the order they were queued,
+// as the plugin interfaces require
+type eventQueue struct {
+	mux    sync.Mutex
+	queue  []*queuedEvent
+	signal chan struct{}
+}
+
+type queuedEvent struct {
+	fn   func() error
+	done chan error
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		signal: make(chan struct{}, 1),
+	}
+}
+
+func (q *eventQueue) post(fn func() error, done chan error) {
+	q.mux.Lock()
+	q.queue = append(q.queue, &queuedEvent{fn: fn, done: done})
+	q.mux.Unlock()
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch queues an event, and waits for it to be processed
+func (q *eventQueue) dispatch(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	q.post(fn, done)
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return i18n.NewError(ctx, i18n.MsgContextCanceled)
+	}
+}
+
+func (q *eventQueue) next() *queuedEvent {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if len(q.queue) == 0 {
+		return nil
+	}
+	e := q.queue[0]
+	q.queue = q.queue[1:]
+	return e
+}
+
+func (q *eventQueue) run(ctx context.Context) {
+	for {
+		e := q.next()
+		if e == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.signal:
+				continue
+			}
+		}
+		err := e.fn()
+		if e.done != nil {
+			e.done <- err
+		} else if err != nil {
+			log.L(ctx).Errorf("Harness event processing failed: %s", err)
+		}
+	}
+}
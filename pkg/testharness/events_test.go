@@ -0,0 +1,49 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventQueueOrdering(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newEventQueue()
+	var order []int
+	for i := 0; i < 3; i++ {
+		i := i
+		q.post(func() error { order = append(order, i); return fmt.Errorf("logged") }, nil)
+	}
+	go q.run(ctx)
+	err := q.dispatch(ctx, func() error { return fmt.Errorf("pop") })
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, []int{0, 1, 2}, order)
+}
+
+func TestEventQueueDispatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q := newEventQueue()
+	q.run(ctx)
+	err := q.dispatch(ctx, func() error { return nil })
+	assert.Regexp(t, "FF10158", err)
+}
@@ -0,0 +1,243 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testharness runs an in-memory FireFly orchestrator, for writing deterministic integration
+// tests against FireFly behavior without a docker-compose stack.
+//
+// The harness uses the SQLite database with an in-memory datasource, and in-memory implementations of the
+// blockchain, data exchange and public storage plugins. Recorded blockchain events, data exchange
+// deliveries and API calls can be fed into the harness in order, and everything the local node sends
+// to the network is recorded so it can be replayed into another harness.
+//
+// FireFly configuration is process-wide, so only one harness can be running at a time. To test
+// interactions between nodes, record the steps sent by one node, close it, then replay them into
+// a harness for the next node.
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/hyperledger/firefly/internal/apiserver"
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/database/difactory"
+	"github.com/hyperledger/firefly/internal/database/sqlcommon"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/internal/orchestrator"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// Options for the harness - all fields are optional
+type Options struct {
+	// OrgName is the name of the local organization (default "org0")
+	OrgName string
+	// OrgKey is the signing key of the local organization on the in-memory blockchain (default "0x" + OrgName)
+	OrgKey string
+	// NodeName is the name of the local node (default "node0")
+	NodeName string
+	// PeerID is the data exchange peer ID of the local node (default NodeName)
+	PeerID string
+	// MigrationsDir is the directory of SQLite migrations (default the migrations in the FireFly module source)
+	MigrationsDir string
+	// ManualConfirm disables the automatic confirmation of batch pins submitted by the local node
+	ManualConfirm bool
+	// Config sets additional configuration keys, such as "broadcast.batch.timeout"
+	Config map[string]interface{}
+	// PollInterval is the interval used when waiting for state to appear (default 10ms)
+	PollInterval time.Duration
+	// ReplayTimeout is how long Replay waits for each batch pin to be processed (default 5s)
+	ReplayTimeout time.Duration
+}
+
+// Harness is an in-memory FireFly orchestrator, with in-memory plugins that can be driven by a test
+type Harness struct {
+	ctx           context.Context
+	cancelCtx     context.CancelFunc
+	orchestrator  orchestrator.Orchestrator
+	database      database.Plugin
+	handler       http.Handler
+	pollInterval  time.Duration
+	replayTimeout time.Duration
+	events        *eventQueue
+	recorder      *recorder
+	Blockchain    *Blockchain
+	DataExchange  *DataExchange
+	PublicStorage *PublicStorage
+}
+
+var (
+	databaseConfig = config.NewPluginConfig("database").SubPrefix("sqlite3")
+)
+
+func defaultMigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "db", "migrations", "sqlite")
+}
+
+// New initializes and starts an in-memory orchestrator
+func New(ctx context.Context, options *Options) (*Harness, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	h := &Harness{
+		pollInterval:  options.PollInterval,
+		replayTimeout: options.ReplayTimeout,
+		events:        newEventQueue(),
+		recorder:      &recorder{},
+	}
+	if h.pollInterval <= 0 {
+		h.pollInterval = 10 * time.Millisecond
+	}
+	if h.replayTimeout <= 0 {
+		h.replayTimeout = 5 * time.Second
+	}
+	h.PublicStorage = newPublicStorage()
+	h.Blockchain = newBlockchain(h.events, h.recorder, h.PublicStorage, !options.ManualConfirm)
+	h.DataExchange = newDataExchange(h.events, h.recorder, peerID(options))
+
+	config.Reset()
+	apiserver.InitConfig()
+	h.database, _ = difactory.GetPlugin(ctx, "sqlite3")
+	if h.database == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgUnknownDatabasePlugin, "sqlite3")
+	}
+	h.orchestrator = orchestrator.NewOrchestratorWithPlugins(&orchestrator.Plugins{
+		Database:      h.database,
+		Blockchain:    h.Blockchain,
+		DataExchange:  h.DataExchange,
+		PublicStorage: h.PublicStorage,
+	})
+	setConfig(options)
+
+	h.ctx, h.cancelCtx = context.WithCancel(ctx)
+	err := h.orchestrator.Init(h.ctx, h.cancelCtx)
+	if err == nil {
+		go h.events.run(h.ctx)
+		err = h.orchestrator.Start()
+	}
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.handler = apiserver.NewAPIHandler(h.ctx, h.orchestrator)
+	return h, nil
+}
+
+func peerID(options *Options) string {
+	if options.PeerID != "" {
+		return options.PeerID
+	}
+	if options.NodeName != "" {
+		return options.NodeName
+	}
+	return "node0"
+}
+
+func setConfig(options *Options) {
+	orgName := options.OrgName
+	if orgName == "" {
+		orgName = "org0"
+	}
+	orgKey := options.OrgKey
+	if orgKey == "" {
+		orgKey = "0x" + orgName
+	}
+	nodeName := options.NodeName
+	if nodeName == "" {
+		nodeName = "node0"
+	}
+	migrationsDir := options.MigrationsDir
+	if migrationsDir == "" {
+		migrationsDir = defaultMigrationsDir()
+	}
+
+	config.Set(config.OrgName, orgName)
+	config.Set(config.OrgKey, orgKey)
+	config.Set(config.NodeName, nodeName)
+	config.Set(config.MetricsEnabled, false)
+	config.Set(config.AdminPreinit, false)
+	config.Set(config.BroadcastBatchTimeout, "10ms")
+	config.Set(config.PrivateMessagingBatchTimeout, "10ms")
+	databaseConfig.Set(sqlcommon.SQLConfDatasourceURL, fmt.Sprintf("file:%s?mode=memory&cache=shared", fftypes.NewUUID()))
+	databaseConfig.Set(sqlcommon.SQLConfMigrationsAuto, true)
+	databaseConfig.Set(sqlcommon.SQLConfMigrationsDirectory, migrationsDir)
+	for k, v := range options.Config {
+		config.Set(config.RootKey(k), v)
+	}
+}
+
+// Orchestrator returns the orchestrator, for direct calls that bypass the API
+func (h *Harness) Orchestrator() orchestrator.Orchestrator {
+	return h.orchestrator
+}
+
+// Context returns the context of the harness, which is cancelled on Close
+func (h *Harness) Context() context.Context {
+	return h.ctx
+}
+
+// Close stops the orchestrator
+func (h *Harness) Close() {
+	h.cancelCtx()
+	h.orchestrator.WaitStop()
+}
+
+// WaitFor polls the check function until it returns true, or the timeout expires
+func (h *Harness) WaitFor(timeout time.Duration, check func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return i18n.NewError(h.ctx, i18n.MsgHarnessWaitTimeout, timeout)
+		}
+		select {
+		case <-h.ctx.Done():
+			return i18n.NewError(h.ctx, i18n.MsgContextCanceled)
+		case <-time.After(h.pollInterval):
+		}
+	}
+}
+
+// WaitForEvents waits until at least count events of the given type exist in the namespace, and returns them
+// in the order they were emitted
+func (h *Harness) WaitForEvents(ns string, eventType fftypes.EventType, count int, timeout time.Duration) (events []*fftypes.Event, err error) {
+	err = h.WaitFor(timeout, func() (bool, error) {
+		fb := database.EventQueryFactory.NewFilter(h.ctx)
+		filter := fb.And(fb.Eq("type", eventType))
+		filter.Sort("sequence")
+		events, _, err = h.orchestrator.GetEvents(h.ctx, ns, filter)
+		return len(events) >= count, err
+	})
+	return events, err
+}
+
+// RegisterLocalNode registers the local organization and node in the network map, waiting for
+// each registration to be confirmed
+func (h *Harness) RegisterLocalNode() (org *fftypes.Organization, node *fftypes.Node, err error) {
+	org, _, err = h.orchestrator.NetworkMap().RegisterNodeOrganization(h.ctx, true)
+	if err == nil {
+		node, _, err = h.orchestrator.NetworkMap().RegisterNode(h.ctx, true)
+	}
+	return org, node, err
+}
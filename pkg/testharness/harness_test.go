@@ -0,0 +1,160 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHarness(t *testing.T, options *Options) *Harness {
+	h, err := New(context.Background(), options)
+	assert.NoError(t, err)
+	t.Cleanup(h.Close)
+	return h
+}
+
+func TestBroadcastConfirmed(t *testing.T) {
+	h := newTestHarness(t, nil)
+	org, node, err := h.RegisterLocalNode()
+	assert.NoError(t, err)
+	assert.Equal(t, "org0", org.Name)
+	assert.Equal(t, "node0", node.Name)
+
+	res, err := h.Call(&APICall{
+		Method:       "POST",
+		Path:         "namespaces/default/messages/broadcast",
+		Body:         json.RawMessage(`{"data":[{"value":"hello"}]}`),
+		ExpectStatus: 202,
+	})
+	assert.NoError(t, err)
+	var msg fftypes.Message
+	assert.NoError(t, res.JSON(&msg))
+
+	events, err := h.WaitForEvents("default", fftypes.EventTypeMessageConfirmed, 1, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, *msg.Header.ID, *events[0].Reference)
+}
+
+func TestReplayBroadcast(t *testing.T) {
+	h1, err := New(context.Background(), nil)
+	assert.NoError(t, err)
+	_, _, err = h1.RegisterLocalNode()
+	assert.NoError(t, err)
+	res, err := h1.Call(&APICall{
+		Method:       "POST",
+		Path:         "namespaces/default/messages/broadcast?confirm",
+		Body:         json.RawMessage(`{"data":[{"value":"hello"}]}`),
+		ExpectStatus: 200,
+	})
+	assert.NoError(t, err)
+	var msg fftypes.Message
+	assert.NoError(t, res.JSON(&msg))
+	recorded := h1.Recorded()
+	h1.Close()
+	assert.Len(t, recorded, 3)
+
+	h2 := newTestHarness(t, &Options{OrgName: "org1", NodeName: "node1"})
+	err = h2.Replay(recorded)
+	assert.NoError(t, err)
+
+	events, err := h2.WaitForEvents("default", fftypes.EventTypeMessageConfirmed, 1, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, *msg.Header.ID, *events[0].Reference)
+	orgs, _, err := h2.Orchestrator().NetworkMap().GetOrganizations(h2.Context(), newOrgFilter(h2, "0xorg0"))
+	assert.NoError(t, err)
+	assert.Len(t, orgs, 1)
+}
+
+func TestReplayPrivateMessage(t *testing.T) {
+	// The receiving node registers itself first
+	h1, err := New(context.Background(), &Options{OrgName: "org1", NodeName: "node1"})
+	assert.NoError(t, err)
+	_, _, err = h1.RegisterLocalNode()
+	assert.NoError(t, err)
+	registration := h1.Recorded()
+	h1.Close()
+
+	// The sending node learns about the receiver, and sends it a private message
+	h0, err := New(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, h0.Replay(registration))
+	_, _, err = h0.RegisterLocalNode()
+	assert.NoError(t, err)
+	_, err = h0.Call(&APICall{
+		Method: "POST",
+		Path:   "namespaces/default/messages/private?confirm",
+		Body: json.RawMessage(`{
+			"data": [{"value": "hello"}],
+			"group": {"members": [{"identity": "org0"}, {"identity": "org1"}]}
+		}`),
+		ExpectStatus: 200,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, h0.DataExchange.Peers(), "node1")
+	sent := h0.RecordedFor("node1")
+	h0.Close()
+
+	// The receiving node replays its own registration, followed by everything sent to it
+	h1 = newTestHarness(t, &Options{OrgName: "org1", NodeName: "node1"})
+	assert.NoError(t, h1.Replay(append(registration, sent...)))
+	// The group is initialized with a message before the message we sent
+	events, err := h1.WaitForEvents("default", fftypes.EventTypeMessageConfirmed, 2, 5*time.Second)
+	assert.NoError(t, err)
+	msg, err := h1.Orchestrator().GetMessageByIDWithData(h1.Context(), "default", events[1].Reference.String())
+	assert.NoError(t, err)
+	assert.Equal(t, `"hello"`, msg.InlineData[0].Value.String())
+}
+
+func newOrgFilter(h *Harness, identity string) database.AndFilter {
+	fb := database.OrganizationQueryFactory.NewFilter(h.Context())
+	return fb.And(fb.Eq("identity", identity))
+}
+
+func TestNewBadMigrations(t *testing.T) {
+	_, err := New(context.Background(), &Options{MigrationsDir: "!!!"})
+	assert.Regexp(t, "FF10163", err)
+}
+
+func TestNewPeerID(t *testing.T) {
+	h := newTestHarness(t, &Options{NodeName: "node1", PeerID: "peer1"})
+	peerID, _, err := h.DataExchange.GetEndpointInfo(h.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, "peer1", peerID)
+}
+
+func TestRegisterLocalNodeFail(t *testing.T) {
+	h := newTestHarness(t, &Options{Config: map[string]interface{}{"org.name": ""}})
+	_, _, err := h.RegisterLocalNode()
+	assert.Regexp(t, "FF10216", err)
+}
+
+func TestWaitForTimeoutAndCancel(t *testing.T) {
+	h := newTestHarness(t, &Options{PollInterval: time.Millisecond})
+	err := h.WaitFor(time.Millisecond, func() (bool, error) { return false, nil })
+	assert.Regexp(t, "FF10331", err)
+
+	h.Close()
+	err = h.WaitFor(time.Minute, func() (bool, error) { return false, nil })
+	assert.Regexp(t, "FF10158", err)
+}
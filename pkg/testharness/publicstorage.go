@@ -0,0 +1,93 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/config"
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/hyperledger/firefly/pkg/publicstorage"
+)
+
+// PublicStorage is an in-memory content-addressed public storage plugin
+type PublicStorage struct {
+	mux      sync.Mutex
+	payloads map[string][]byte
+}
+
+func newPublicStorage() *PublicStorage {
+	return &PublicStorage{
+		payloads: make(map[string][]byte),
+	}
+}
+
+func (ps *PublicStorage) Name() string {
+	return "harness"
+}
+
+func (ps *PublicStorage) InitPrefix(prefix config.Prefix) {}
+
+func (ps *PublicStorage) Init(ctx context.Context, prefix config.Prefix, callbacks publicstorage.Callbacks) error {
+	return nil
+}
+
+func (ps *PublicStorage) Capabilities() *publicstorage.Capabilities {
+	return &publicstorage.Capabilities{}
+}
+
+func (ps *PublicStorage) PublishData(ctx context.Context, data io.Reader) (payloadRef string, err error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	payloadRef = hex.EncodeToString(hash[:])
+	ps.store(payloadRef, b)
+	return payloadRef, nil
+}
+
+func (ps *PublicStorage) RetrieveData(ctx context.Context, payloadRef string) (data io.ReadCloser, err error) {
+	b := ps.Get(payloadRef)
+	if b == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgHarnessPayloadNotFound, payloadRef)
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// PinData is a no-op, as the harness does not advertise the Pinning capability
+func (ps *PublicStorage) PinData(ctx context.Context, operationID *fftypes.UUID, payloadRef string) {}
+
+// Get returns the payload stored under a reference, or nil if there is none
+func (ps *PublicStorage) Get(payloadRef string) []byte {
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+	return ps.payloads[payloadRef]
+}
+
+func (ps *PublicStorage) store(payloadRef string, b []byte) {
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+	ps.payloads[payloadRef] = b
+}
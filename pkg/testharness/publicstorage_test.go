@@ -0,0 +1,65 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+type badReader struct{}
+
+func (badReader) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("pop")
+}
+
+func TestPublicStorage(t *testing.T) {
+	ps := newPublicStorage()
+	ctx := context.Background()
+	ps.InitPrefix(nil)
+	assert.NoError(t, ps.Init(ctx, nil, nil))
+	assert.NotNil(t, ps.Capabilities())
+	assert.Equal(t, "harness", ps.Name())
+
+	payloadRef, err := ps.PublishData(ctx, bytes.NewReader([]byte("hello")))
+	assert.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", payloadRef)
+
+	r, err := ps.RetrieveData(ctx, payloadRef)
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(r)
+	assert.Equal(t, "hello", string(b))
+
+	ps.PinData(ctx, fftypes.NewUUID(), payloadRef)
+}
+
+func TestPublicStorageErrors(t *testing.T) {
+	ps := newPublicStorage()
+	ctx := context.Background()
+
+	_, err := ps.PublishData(ctx, badReader{})
+	assert.Regexp(t, "pop", err)
+
+	_, err = ps.RetrieveData(ctx, "unknown")
+	assert.Regexp(t, "FF10332", err)
+}
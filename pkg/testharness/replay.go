@@ -0,0 +1,193 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/firefly/internal/i18n"
+	"github.com/hyperledger/firefly/pkg/database"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+)
+
+// StepType is the type of a step in a recording
+type StepType string
+
+const (
+	// StepBatchPin is a batch pin confirmed on the blockchain
+	StepBatchPin StepType = "batch_pin"
+	// StepDXMessage is a message delivered by data exchange
+	StepDXMessage StepType = "dx_message"
+	// StepDXBLOB is a BLOB transferred by data exchange
+	StepDXBLOB StepType = "dx_blob"
+	// StepAPI is a call to the REST API
+	StepAPI StepType = "api"
+)
+
+// Step is a single input to the harness, which can be serialized to a recording
+type Step struct {
+	Type      StepType       `json:"type"`
+	BatchPin  *BatchPinEvent `json:"batchPin,omitempty"`
+	DXMessage *DXMessage     `json:"dxMessage,omitempty"`
+	DXBLOB    *DXBLOB        `json:"dxBlob,omitempty"`
+	API       *APICall       `json:"api,omitempty"`
+}
+
+// APICall is a request to the REST API. The path is relative to /api/v1
+type APICall struct {
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Body         json.RawMessage `json:"body,omitempty"`
+	ExpectStatus int             `json:"expectStatus,omitempty"`
+}
+
+// APIResponse is the response to an APICall
+type APIResponse struct {
+	Status int
+	Body   []byte
+}
+
+type recorder struct {
+	mux   sync.Mutex
+	steps []*Step
+}
+
+func (r *recorder) record(step *Step) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) recorded() []*Step {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]*Step{}, r.steps...)
+}
+
+// Recorded returns everything the local node has sent to the network so far - submitted batch pins,
+// and data exchange messages and BLOBs - in the order they were sent. These can be replayed into
+// a new harness, acting as a receiving node.
+func (h *Harness) Recorded() []*Step {
+	return h.recorder.recorded()
+}
+
+// LoadRecording reads a JSON array of steps from a file
+func LoadRecording(filename string) ([]*Step, error) {
+	var steps []*Step
+	b, err := ioutil.ReadFile(filename)
+	if err == nil {
+		err = json.Unmarshal(b, &steps)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(context.Background(), err, i18n.MsgHarnessRecordingReadFailed, filename)
+	}
+	return steps, nil
+}
+
+// SaveRecording writes steps to a file as a JSON array, for loading with LoadRecording
+func SaveRecording(filename string, steps []*Step) error {
+	b, _ := json.MarshalIndent(steps, "", "  ")
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// Replay feeds the steps into the harness in order. Each blockchain and data exchange event
+// is processed by the orchestrator before the next step starts, and for batches held by this node
+// Replay also waits for the aggregator to dispatch the pins of the batch.
+func (h *Harness) Replay(steps []*Step) error {
+	for i, step := range steps {
+		var err error
+		switch {
+		case step.Type == StepBatchPin && step.BatchPin != nil:
+			if err = h.Blockchain.BatchPinComplete(h.ctx, step.BatchPin); err == nil {
+				err = h.waitForBatchDispatched(step.BatchPin.BatchPin.BatchID)
+			}
+		case step.Type == StepDXMessage && step.DXMessage != nil:
+			err = h.DataExchange.MessageReceived(h.ctx, step.DXMessage)
+		case step.Type == StepDXBLOB && step.DXBLOB != nil:
+			err = h.DataExchange.BLOBReceived(h.ctx, step.DXBLOB)
+		case step.Type == StepAPI && step.API != nil:
+			_, err = h.Call(step.API)
+		default:
+			err = i18n.NewError(h.ctx, i18n.MsgHarnessInvalidStep, i, step.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Harness) waitForBatchDispatched(batchID *fftypes.UUID) error {
+	return h.WaitFor(h.replayTimeout, func() (bool, error) {
+		batch, err := h.database.GetBatchByID(h.ctx, batchID)
+		if err != nil || batch == nil {
+			// Private batches are only held by members of the group
+			return true, err
+		}
+		fb := database.PinQueryFactory.NewFilter(h.ctx)
+		pins, _, err := h.database.GetPins(h.ctx, fb.And(
+			fb.Eq("batch", batchID),
+			fb.Eq("dispatched", false),
+		))
+		return len(pins) == 0, err
+	})
+}
+
+// RecordedFor returns the steps recorded by this harness that are received by the given data exchange peer -
+// all batch pins, and the messages and BLOBs sent to that peer
+func (h *Harness) RecordedFor(peerID string) []*Step {
+	var steps []*Step
+	for _, step := range h.Recorded() {
+		switch {
+		case step.DXMessage != nil && step.DXMessage.Recipient != peerID,
+			step.DXBLOB != nil && step.DXBLOB.Recipient != peerID:
+			continue
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// Call makes a request to the REST API, returning an error if ExpectStatus is set and does not match
+func (h *Harness) Call(call *APICall) (*APIResponse, error) {
+	req := httptest.NewRequest(call.Method, "/api/v1/"+strings.TrimPrefix(call.Path, "/"), bytes.NewReader(call.Body))
+	req = req.WithContext(h.ctx)
+	if len(call.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res := httptest.NewRecorder()
+	h.handler.ServeHTTP(res, req)
+	apiRes := &APIResponse{
+		Status: res.Code,
+		Body:   res.Body.Bytes(),
+	}
+	if call.ExpectStatus != 0 && call.ExpectStatus != apiRes.Status {
+		return apiRes, i18n.NewError(h.ctx, i18n.MsgHarnessUnexpectedStatus, call.Method, call.Path, apiRes.Status, call.ExpectStatus, apiRes.Body)
+	}
+	return apiRes, nil
+}
+
+// JSON parses the body of the response
+func (r *APIResponse) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
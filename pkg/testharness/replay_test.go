@@ -0,0 +1,100 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testharness
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/firefly/pkg/blockchain"
+	"github.com/hyperledger/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoadRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "harness")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "recording.json")
+
+	steps := []*Step{
+		{Type: StepAPI, API: &APICall{Method: "GET", Path: "status"}},
+		{Type: StepDXMessage, DXMessage: &DXMessage{Sender: "node1", Recipient: "node0", Data: []byte("hello")}},
+	}
+	err = SaveRecording(filename, steps)
+	assert.NoError(t, err)
+	loaded, err := LoadRecording(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, steps, loaded)
+
+	_, err = LoadRecording(filepath.Join(dir, "missing.json"))
+	assert.Regexp(t, "FF10336", err)
+}
+
+func TestRecordedFor(t *testing.T) {
+	h := &Harness{recorder: &recorder{}}
+	h.recorder.record(&Step{Type: StepBatchPin, BatchPin: &BatchPinEvent{}})
+	h.recorder.record(&Step{Type: StepDXMessage, DXMessage: &DXMessage{Recipient: "node1"}})
+	h.recorder.record(&Step{Type: StepDXMessage, DXMessage: &DXMessage{Recipient: "node2"}})
+	h.recorder.record(&Step{Type: StepDXBLOB, DXBLOB: &DXBLOB{Recipient: "node2"}})
+	h.recorder.record(&Step{Type: StepDXBLOB, DXBLOB: &DXBLOB{Recipient: "node1"}})
+	steps := h.RecordedFor("node1")
+	assert.Len(t, steps, 3)
+	assert.Equal(t, StepBatchPin, steps[0].Type)
+	assert.Equal(t, StepDXMessage, steps[1].Type)
+	assert.Equal(t, StepDXBLOB, steps[2].Type)
+}
+
+func TestReplayAPIAndErrors(t *testing.T) {
+	h := newTestHarness(t, nil)
+
+	err := h.Replay([]*Step{
+		{Type: StepAPI, API: &APICall{Method: "GET", Path: "/status", ExpectStatus: 200}},
+		{Type: StepDXMessage, DXMessage: &DXMessage{Sender: "node1", Recipient: "node0", Data: []byte("!json")}},
+		{Type: StepDXBLOB, DXBLOB: &DXBLOB{Sender: "node1", Recipient: "node0", Namespace: "default", ID: *fftypes.NewUUID(), Content: []byte("blob")}},
+	})
+	assert.NoError(t, err)
+
+	err = h.Replay([]*Step{{Type: StepBatchPin}})
+	assert.Regexp(t, "FF10334.*0.*batch_pin", err)
+
+	err = h.Replay([]*Step{
+		{Type: StepAPI, API: &APICall{Method: "POST", Path: "namespaces/default/messages/broadcast", Body: json.RawMessage("{}"), ExpectStatus: 202}},
+	})
+	assert.Regexp(t, "FF10335.*400", err)
+}
+
+func TestReplayPinForUnknownBatch(t *testing.T) {
+	h := newTestHarness(t, nil)
+	err := h.Replay([]*Step{
+		{Type: StepBatchPin, BatchPin: &BatchPinEvent{
+			BatchPin: &blockchain.BatchPin{
+				Namespace:     "default",
+				TransactionID: fftypes.NewUUID(),
+				BatchID:       fftypes.NewUUID(),
+				BatchHash:     fftypes.NewRandB32(),
+				Contexts:      []*fftypes.Bytes32{fftypes.NewRandB32()},
+			},
+			SigningKey:   "0x12345",
+			ProtocolTxID: "0x1",
+		}},
+	})
+	assert.NoError(t, err)
+}
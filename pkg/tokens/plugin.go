@@ -54,6 +54,9 @@ type Plugin interface {
 
 	// TransferTokens transfers tokens within a pool from one account to another
 	TransferTokens(ctx context.Context, operationID *fftypes.UUID, poolProtocolID string, transfer *fftypes.TokenTransfer) error
+
+	// TokensApproval approves (or revokes the approval of) an operator to transfer tokens within a pool on behalf of the key
+	TokensApproval(ctx context.Context, operationID *fftypes.UUID, poolProtocolID string, approval *fftypes.TokenApproval) error
 }
 
 // Callbacks is the interface provided to the tokens plugin, to allow it to pass events back to firefly.
@@ -81,6 +84,17 @@ type Callbacks interface {
 	//
 	// Error should will only be returned in shutdown scenarios
 	TokensTransferred(plugin Plugin, poolProtocolID string, transfer *fftypes.TokenTransfer, protocolTxID string, additionalInfo fftypes.JSONObject) error
+
+	// TokensApproved notifies on an operator being approved (or having its approval revoked) to transfer tokens on behalf of a key.
+	//
+	// Error should will only be returned in shutdown scenarios
+	TokensApproved(plugin Plugin, poolProtocolID string, approval *fftypes.TokenApproval, protocolTxID string, additionalInfo fftypes.JSONObject) error
+
+	// TokenPoolGovernance notifies on a governance action on the contract underlying a token pool, made directly
+	// on chain (such as the contract being paused, or its ownership being transferred).
+	//
+	// Error should will only be returned in shutdown scenarios
+	TokenPoolGovernance(plugin Plugin, poolProtocolID string, change *fftypes.TokenPoolGovernance, protocolTxID string, additionalInfo fftypes.JSONObject) error
 }
 
 // Capabilities the supported featureset of the tokens
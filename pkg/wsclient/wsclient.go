@@ -18,11 +18,13 @@ package wsclient
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -39,10 +41,13 @@ type WSConfig struct {
 	WriteBufferSize        int                `json:"writeBufferSize,omitempty"`
 	InitialDelay           time.Duration      `json:"initialDelay,omitempty"`
 	MaximumDelay           time.Duration      `json:"maximumDelay,omitempty"`
+	ReconnectJitter        float64            `json:"reconnectJitter,omitempty"`
 	InitialConnectAttempts int                `json:"initialConnectAttempts,omitempty"`
 	AuthUsername           string             `json:"authUsername,omitempty"`
 	AuthPassword           string             `json:"authPassword,omitempty"`
 	HTTPHeaders            fftypes.JSONObject `json:"headers,omitempty"`
+	TLSClientConfig        *tls.Config        `json:"-"`
+	AuthProvider           WSAuthProvider     `json:"-"`
 }
 
 type WSClient interface {
@@ -51,12 +56,16 @@ type WSClient interface {
 	URL() string
 	SetURL(url string)
 	Send(ctx context.Context, message []byte) error
+	Subscribe(ctx context.Context, command []byte) error
+	AddPostConnectHandler(handler WSPostConnectHandler)
+	AddReconnectListener(listener WSReconnectListener)
 	Close()
 }
 
 type wsClient struct {
 	ctx                  context.Context
 	headers              http.Header
+	authProvider         WSAuthProvider
 	url                  string
 	initialRetryAttempts int
 	wsdialer             *websocket.Dialer
@@ -67,12 +76,30 @@ type wsClient struct {
 	send                 chan []byte
 	sendDone             chan []byte
 	closing              chan struct{}
-	afterConnect         WSPostConnectHandler
+	mux                  sync.Mutex
+	connected            bool
+	reconnects           int
+	subscriptions        [][]byte
+	postConnect          []WSPostConnectHandler
+	reconnectListeners   []WSReconnectListener
 }
 
 // WSPostConnectHandler will be called after every connect/reconnect. Can send data over ws, but must not block listening for data on the ws.
 type WSPostConnectHandler func(ctx context.Context, w WSClient) error
 
+// WSReconnectEvent describes a connection that has been re-established, after all subscriptions have been replayed
+type WSReconnectEvent struct {
+	URL        string
+	Reconnects int
+	Replayed   int
+}
+
+// WSAuthProvider returns the Authorization header to send with every connect/reconnect, such as a bearer token that might have been refreshed
+type WSAuthProvider func(ctx context.Context) (string, error)
+
+// WSReconnectListener will be called after every reconnect (but not the initial connect). Must not block.
+type WSReconnectListener func(ctx context.Context, event *WSReconnectEvent)
+
 func New(ctx context.Context, config *WSConfig, afterConnect WSPostConnectHandler) (WSClient, error) {
 
 	wsURL, err := buildWSUrl(ctx, config)
@@ -86,17 +113,22 @@ func New(ctx context.Context, config *WSConfig, afterConnect WSPostConnectHandle
 		wsdialer: &websocket.Dialer{
 			ReadBufferSize:  config.ReadBufferSize,
 			WriteBufferSize: config.WriteBufferSize,
+			TLSClientConfig: config.TLSClientConfig,
 		},
 		retry: retry.Retry{
 			InitialDelay: config.InitialDelay,
 			MaximumDelay: config.MaximumDelay,
+			Jitter:       config.ReconnectJitter,
 		},
 		initialRetryAttempts: config.InitialConnectAttempts,
 		headers:              make(http.Header),
+		authProvider:         config.AuthProvider,
 		receive:              make(chan []byte),
 		send:                 make(chan []byte),
 		closing:              make(chan struct{}),
-		afterConnect:         afterConnect,
+	}
+	if afterConnect != nil {
+		w.postConnect = append(w.postConnect, afterConnect)
 	}
 	for k, v := range config.HTTPHeaders {
 		if vs, ok := v.(string); ok {
@@ -159,6 +191,71 @@ func (w *wsClient) Send(ctx context.Context, message []byte) error {
 	}
 }
 
+// Subscribe sends a command now if the client is connected, and registers it to be
+// replayed (before any post-connect handlers) after every subsequent reconnect
+func (w *wsClient) Subscribe(ctx context.Context, command []byte) error {
+	w.mux.Lock()
+	w.subscriptions = append(w.subscriptions, command)
+	connected := w.connected
+	w.mux.Unlock()
+	if connected {
+		return w.Send(ctx, command)
+	}
+	return nil
+}
+
+func (w *wsClient) AddPostConnectHandler(handler WSPostConnectHandler) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.postConnect = append(w.postConnect, handler)
+}
+
+func (w *wsClient) AddReconnectListener(listener WSReconnectListener) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.reconnectListeners = append(w.reconnectListeners, listener)
+}
+
+func (w *wsClient) setConnected(connected bool) ([][]byte, []WSPostConnectHandler, []WSReconnectListener) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.connected = connected
+	subscriptions := make([][]byte, len(w.subscriptions))
+	copy(subscriptions, w.subscriptions)
+	handlers := make([]WSPostConnectHandler, len(w.postConnect))
+	copy(handlers, w.postConnect)
+	listeners := make([]WSReconnectListener, len(w.reconnectListeners))
+	copy(listeners, w.reconnectListeners)
+	return subscriptions, handlers, listeners
+}
+
+func (w *wsClient) afterConnect(reconnect bool) error {
+	subscriptions, handlers, listeners := w.setConnected(true)
+	for _, command := range subscriptions {
+		if err := w.Send(w.ctx, command); err != nil {
+			return err
+		}
+	}
+	for _, handler := range handlers {
+		if err := handler(w.ctx, w); err != nil {
+			return err
+		}
+	}
+	if reconnect {
+		w.reconnects++
+		event := &WSReconnectEvent{
+			URL:        w.url,
+			Reconnects: w.reconnects,
+			Replayed:   len(subscriptions),
+		}
+		log.L(w.ctx).Infof("WS %s reconnected (reconnects=%d replayed=%d)", w.url, event.Reconnects, event.Replayed)
+		for _, listener := range listeners {
+			listener(w.ctx, event)
+		}
+	}
+	return nil
+}
+
 func buildWSUrl(ctx context.Context, config *WSConfig) (string, error) {
 	u, err := url.Parse(config.HTTPURL)
 	if err != nil {
@@ -182,8 +279,18 @@ func (w *wsClient) connect(initial bool) error {
 		if w.closed {
 			return false, i18n.NewError(w.ctx, i18n.MsgWSClosing)
 		}
+		headers := w.headers
+		if w.authProvider != nil {
+			authHeader, err := w.authProvider(w.ctx)
+			if err != nil {
+				l.Warnf("WS %s connect attempt %d failed to obtain auth header: %s", w.url, attempt, err)
+				return !initial || attempt > w.initialRetryAttempts, err
+			}
+			headers = w.headers.Clone()
+			headers.Set("Authorization", authHeader)
+		}
 		var res *http.Response
-		w.wsconn, res, err = w.wsdialer.Dial(w.url, w.headers)
+		w.wsconn, res, err = w.wsdialer.Dial(w.url, headers)
 		if err != nil {
 			var b []byte
 			var status = -1
@@ -248,17 +355,15 @@ func (w *wsClient) sendLoop(receiverDone chan struct{}) {
 func (w *wsClient) receiveReconnectLoop() {
 	l := log.L(w.ctx)
 	defer close(w.receive)
+	reconnect := false
 	for !w.closed {
 		// Start the sender, letting it close without blocking sending a notifiation on the sendDone
 		w.sendDone = make(chan []byte, 1)
 		receiverDone := make(chan struct{})
 		go w.sendLoop(receiverDone)
 
-		// Call the reconnect processor
-		var err error
-		if w.afterConnect != nil {
-			err = w.afterConnect(w.ctx, w)
-		}
+		// Replay subscriptions, and call the reconnect processors
+		err := w.afterConnect(reconnect)
 
 		if err == nil {
 			// Synchronously invoke the reader, as it's important we react immediately to any error there.
@@ -274,8 +379,10 @@ func (w *wsClient) receiveReconnectLoop() {
 			w.sendDone = nil
 			w.wsconn = nil
 		}
+		w.setConnected(false)
 
 		// Go into reconnect
+		reconnect = true
 		if !w.closed {
 			err = w.connect(false)
 			if err != nil {
@@ -131,6 +131,47 @@ func TestWSFailStartupHttp500(t *testing.T) {
 	assert.Regexp(t, "FF10161", err)
 }
 
+func TestWSAuthProvider(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(
+		func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "custom value", r.Header.Get("Custom-Header"))
+			assert.Equal(t, "Bearer token1", r.Header.Get("Authorization"))
+			rw.WriteHeader(500)
+		},
+	))
+	defer svr.Close()
+
+	wsConfig := generateConfig()
+	wsConfig.HTTPURL = fmt.Sprintf("ws://%s", svr.Listener.Addr())
+	wsConfig.HTTPHeaders = map[string]interface{}{
+		"custom-header": "custom value",
+	}
+	wsConfig.AuthProvider = func(ctx context.Context) (string, error) {
+		return "Bearer token1", nil
+	}
+	wsConfig.InitialDelay = 1
+	wsConfig.InitialConnectAttempts = 1
+
+	w, _ := New(context.Background(), wsConfig, nil)
+	err := w.Connect()
+	assert.Regexp(t, "FF10161", err)
+	assert.Empty(t, w.(*wsClient).headers.Get("Authorization"))
+}
+
+func TestWSAuthProviderFail(t *testing.T) {
+	wsConfig := generateConfig()
+	wsConfig.HTTPURL = "http://test:12345"
+	wsConfig.AuthProvider = func(ctx context.Context) (string, error) {
+		return "", fmt.Errorf("pop")
+	}
+	wsConfig.InitialDelay = 1
+	wsConfig.InitialConnectAttempts = 1
+
+	w, _ := New(context.Background(), wsConfig, nil)
+	err := w.Connect()
+	assert.EqualError(t, err, "pop")
+}
+
 func TestWSFailStartupConnect(t *testing.T) {
 
 	svr := httptest.NewServer(http.HandlerFunc(
@@ -276,3 +317,98 @@ func TestWSSendInstructClose(t *testing.T) {
 	w.sendLoop(receiverClosed)
 	<-w.sendDone
 }
+
+func TestWSClientReconnectReplay(t *testing.T) {
+
+	upgrader := &websocket.Upgrader{WriteBufferSize: 1024, ReadBufferSize: 1024}
+	toServer := make(chan string, 10)
+	connections := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		connections++
+		conn := connections
+		ws, _ := upgrader.Upgrade(res, req, http.Header{})
+		go func() {
+			defer ws.Close()
+			for i := 0; ; i++ {
+				if conn == 1 && i == 2 {
+					// Drop the first connection, once the replay is complete
+					return
+				}
+				_, data, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				toServer <- fmt.Sprintf("%d:%s", conn, data)
+			}
+		}()
+	}))
+	defer svr.Close()
+
+	wsConfig := generateConfig()
+	wsConfig.HTTPURL = fmt.Sprintf("ws://%s", svr.Listener.Addr())
+	wsConfig.ReconnectJitter = 0.5
+
+	wsClient, err := New(context.Background(), wsConfig, nil)
+	assert.NoError(t, err)
+
+	err = wsClient.Subscribe(context.Background(), []byte(`sub1`))
+	assert.NoError(t, err)
+	wsClient.AddPostConnectHandler(func(ctx context.Context, w WSClient) error {
+		return w.Send(ctx, []byte(`hello`))
+	})
+	reconnected := make(chan *WSReconnectEvent, 1)
+	wsClient.AddReconnectListener(func(ctx context.Context, event *WSReconnectEvent) {
+		reconnected <- event
+	})
+
+	err = wsClient.Connect()
+	assert.NoError(t, err)
+
+	assert.Equal(t, `1:sub1`, <-toServer)
+	assert.Equal(t, `1:hello`, <-toServer)
+	assert.Equal(t, `2:sub1`, <-toServer)
+	assert.Equal(t, `2:hello`, <-toServer)
+
+	event := <-reconnected
+	assert.Equal(t, 1, event.Reconnects)
+	assert.Equal(t, 1, event.Replayed)
+
+	// A subscription registered while connected is sent immediately
+	err = wsClient.Subscribe(context.Background(), []byte(`sub2`))
+	assert.NoError(t, err)
+	assert.Equal(t, `2:sub2`, <-toServer)
+
+	wsClient.Close()
+
+}
+
+func TestWSAfterConnectReplayFail(t *testing.T) {
+
+	w := &wsClient{
+		ctx:           context.Background(),
+		send:          make(chan []byte),
+		closing:       make(chan struct{}),
+		subscriptions: [][]byte{[]byte(`sub1`)},
+	}
+	close(w.closing)
+
+	err := w.afterConnect(true)
+	assert.Regexp(t, "FF10160", err)
+	assert.Equal(t, 0, w.reconnects)
+
+}
+
+func TestWSAfterConnectHandlerFail(t *testing.T) {
+
+	w := &wsClient{
+		ctx: context.Background(),
+	}
+	w.AddPostConnectHandler(func(ctx context.Context, w WSClient) error {
+		return fmt.Errorf("pop")
+	})
+
+	err := w.afterConnect(true)
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, 0, w.reconnects)
+
+}
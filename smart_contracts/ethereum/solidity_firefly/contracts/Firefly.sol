@@ -1,6 +1,7 @@
 // SPDX-License-Identifier: Apache-2.0
 
 pragma solidity >=0.6.0 <0.9.0;
+pragma experimental ABIEncoderV2;
 
 contract Firefly {
 
@@ -18,4 +19,12 @@ contract Firefly {
         emit BatchPin(msg.sender, block.timestamp, namespace, uuids, batchHash, payloadRef, contexts);
     }
 
+    function pinBatches(string[] memory namespaces, bytes32[] memory uuids, bytes32[] memory batchHashes, string[] memory payloadRefs, bytes32[][] memory contexts) public {
+        require(uuids.length == namespaces.length && batchHashes.length == namespaces.length &&
+            payloadRefs.length == namespaces.length && contexts.length == namespaces.length, "mismatched batch pin arrays");
+        for (uint i = 0; i < namespaces.length; i++) {
+            emit BatchPin(msg.sender, block.timestamp, namespaces[i], uuids[i], batchHashes[i], payloadRefs[i], contexts[i]);
+        }
+    }
+
 }
@@ -64,6 +64,39 @@ contract('Firefly.sol', accounts => {
 
     });
 
+    describe('pinBatches', () => {
+
+      it('emits a pin for each batch', async () => {
+        const namespaces = ["ns1", "ns2"];
+        const uuids = [randB32Hex(), randB32Hex()];
+        const batchHashes = [randB32Hex(), randB32Hex()];
+        const payloadRefs = ["Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", ""];
+        const contexts = [[randB32Hex()], [randB32Hex(), randB32Hex()]];
+        const result = await fireflyContract.pinBatches(namespaces, uuids, batchHashes, payloadRefs, contexts);
+        assert.equal(result.logs.length, 2);
+        for (let i = 0; i < 2; i++) {
+          const logArgs = result.logs[i].args;
+          assert.equal(logArgs.author, accounts[0]);
+          assert.equal(logArgs.namespace, namespaces[i]);
+          assert.equal(logArgs.uuids, uuids[i]);
+          assert.equal(logArgs.batchHash, batchHashes[i]);
+          assert.equal(logArgs.payloadRef, payloadRefs[i]);
+          assert.equal(logArgs.contexts.length, contexts[i].length);
+          assert.equal(logArgs.contexts[0], contexts[i][0]);
+        }
+      });
+
+      it('rejects mismatched array lengths', async () => {
+        let failed = false;
+        try {
+          await fireflyContract.pinBatches(["ns1", "ns2"], [randB32Hex()], [randB32Hex()], [""], [[randB32Hex()]]);
+        } catch (err) {
+          failed = true;
+        }
+        assert.isTrue(failed);
+      });
+
+    });
 
   });
 